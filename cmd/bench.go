@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/spf13/cobra"
+
+	"github.com/kagent-dev/tools/internal/bench"
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/kagent-dev/tools/internal/guard"
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/pkg/utils"
+)
+
+// defaultBenchCall is the workload of runs given no calls: a cacheable read
+const defaultBenchCall = `k8s_get_resources={"resource_type":"pods","namespace":"default","output":"json"}`
+
+var benchFlags struct {
+	calls       []string
+	concurrency int
+	requests    int
+	duration    time.Duration
+	warmup      int
+	timeout     time.Duration
+	url         string
+	tools       []string
+	kubeconfig  string
+	mock        bool
+	mockLatency time.Duration
+	mockOutput  string
+	output      string
+}
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Load test the tool server",
+	Long: `Drive concurrent tool calls and report p50/p95/p99 latencies, error rates and
+memory growth. By default the server runs in process, with the middleware of
+the real server and kubectl, helm and the other CLIs mocked, so that runs
+measure the server itself; --mock=false calls the cluster of --kubeconfig,
+such as a kind cluster. --url drives a running server over streamable HTTP,
+sampling its memory from its /metrics endpoint.`,
+	Example: `  tool-server bench --concurrency 50 --requests 5000
+  tool-server bench --call 'k8s_get_resources={"resource_type":"pods"}' --call datetime_get_current_time --mock-latency 20ms
+  tool-server bench --url http://localhost:8084/mcp --duration 1m`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runBench,
+}
+
+func init() {
+	flags := benchCmd.Flags()
+	flags.StringArrayVar(&benchFlags.calls, "call", nil, `Tool call to make, as tool or tool={"json":"arguments"}; repeat to mix calls, which are made in turn (default: `+defaultBenchCall+`)`)
+	flags.IntVarP(&benchFlags.concurrency, "concurrency", "c", 10, "Number of concurrent callers")
+	flags.IntVarP(&benchFlags.requests, "requests", "n", 0, "Number of calls to make (default: 1000 without --duration)")
+	flags.DurationVarP(&benchFlags.duration, "duration", "d", 0, "How long to run, ending at --requests if that comes first")
+	flags.IntVar(&benchFlags.warmup, "warmup", 0, "Calls to make before measuring, e.g. to fill caches")
+	flags.DurationVar(&benchFlags.timeout, "timeout", 30*time.Second, "Timeout of each call")
+	flags.StringVar(&benchFlags.url, "url", "", "Streamable HTTP endpoint of a running server to drive, e.g. http://localhost:8084/mcp (default: run the server in process)")
+	flags.StringSliceVar(&benchFlags.tools, "tools", nil, "Tool providers to register in process (default: all)")
+	flags.StringVar(&benchFlags.kubeconfig, "kubeconfig", "", "kubeconfig of the in-process server")
+	flags.BoolVar(&benchFlags.mock, "mock", true, "Mock the CLIs the in-process server runs")
+	flags.DurationVar(&benchFlags.mockLatency, "mock-latency", 0, "Latency of each mocked CLI command")
+	flags.StringVar(&benchFlags.mockOutput, "mock-output", bench.DefaultMockOutput, "Output of each mocked CLI command")
+	flags.StringVarP(&benchFlags.output, "output", "o", "text", "Report format (text, json)")
+	rootCmd.AddCommand(benchCmd)
+}
+
+func runBench(command *cobra.Command, args []string) error {
+	if benchFlags.output != "text" && benchFlags.output != "json" {
+		return fmt.Errorf("invalid output %q, expected text or json", benchFlags.output)
+	}
+	specs := benchFlags.calls
+	if len(specs) == 0 {
+		specs = []string{defaultBenchCall}
+	}
+	cfg := bench.Config{
+		Concurrency: benchFlags.concurrency,
+		Requests:    benchFlags.requests,
+		Duration:    benchFlags.duration,
+		Warmup:      benchFlags.warmup,
+		Timeout:     benchFlags.timeout,
+	}
+	for _, spec := range specs {
+		call, err := bench.ParseCall(spec)
+		if err != nil {
+			return err
+		}
+		cfg.Calls = append(cfg.Calls, call)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	// Logs go to stderr so that the report can be piped
+	logger.Init(true)
+	defer logger.Sync()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var mcpClient *client.Client
+	var sample bench.MemorySampler
+	if benchFlags.url != "" {
+		metricsURL, err := url.Parse(benchFlags.url)
+		if err != nil {
+			return fmt.Errorf("invalid url %q: %w", benchFlags.url, err)
+		}
+		metricsURL.Path, metricsURL.RawQuery = "/metrics", ""
+		sample = bench.MetricsMemory(metricsURL.String())
+
+		httpTransport, err := transport.NewStreamableHTTP(benchFlags.url, transport.WithHTTPTimeout(benchFlags.timeout))
+		if err != nil {
+			return fmt.Errorf("failed to create HTTP transport: %w", err)
+		}
+		mcpClient = client.NewClient(httpTransport)
+	} else {
+		policies, err := guard.LoadGuardFromEnv()
+		if err != nil {
+			return err
+		}
+		mcpServer := server.NewMCPServer(Name, Version, serverOptions(policies)...)
+		utils.SetProviderTools(registerMCP(mcpServer, benchFlags.tools, benchFlags.kubeconfig, false, nil), benchFlags.kubeconfig)
+		if mcpClient, err = client.NewInProcessClient(mcpServer); err != nil {
+			return fmt.Errorf("failed to create in-process client: %w", err)
+		}
+		sample = bench.LocalMemory
+		if benchFlags.mock {
+			ctx = cmd.WithShellExecutor(ctx, bench.MockExecutor{Output: benchFlags.mockOutput, Latency: benchFlags.mockLatency})
+		}
+	}
+	defer func() { _ = mcpClient.Close() }()
+
+	if err := mcpClient.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start MCP client: %w", err)
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: Name + "-bench", Version: Version}
+	if _, err := mcpClient.Initialize(ctx, initRequest); err != nil {
+		return fmt.Errorf("failed to initialize MCP client: %w", err)
+	}
+
+	report, err := bench.Run(ctx, cfg, func(ctx context.Context, call bench.Call) (*mcp.CallToolResult, error) {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = call.Tool
+		request.Params.Arguments = call.Arguments
+		return mcpClient.CallTool(ctx, request)
+	}, sample)
+	if err != nil {
+		return err
+	}
+
+	out := command.OutOrStdout()
+	if benchFlags.output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	return report.WriteText(out)
+}
@@ -14,8 +14,18 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/kagent-dev/tools/internal/annotations"
+	"github.com/kagent-dev/tools/internal/budget"
+	"github.com/kagent-dev/tools/internal/cache"
+	"github.com/kagent-dev/tools/internal/changes"
+	"github.com/kagent-dev/tools/internal/datasources"
+	"github.com/kagent-dev/tools/internal/guard"
+	"github.com/kagent-dev/tools/internal/leader"
+	"github.com/kagent-dev/tools/internal/llm"
 	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/reload"
 	"github.com/kagent-dev/tools/internal/telemetry"
+	"github.com/kagent-dev/tools/internal/tenancy"
 	"github.com/kagent-dev/tools/internal/version"
 	"github.com/kagent-dev/tools/pkg/alerts"
 	"github.com/kagent-dev/tools/pkg/argo"
@@ -97,6 +107,15 @@ func run(cmd *cobra.Command, args []string) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Load secrets and integration settings from KAGENT_CONFIG_FILE, reloading
+	// them on SIGHUP or when the file changes
+	if err := reload.Start(ctx); err != nil {
+		logger.Get().Error("Failed to load configuration file", "error", err)
+		os.Exit(1)
+	}
+	// Datasources re-read their settings and credentials on the next tool call
+	reload.OnReload(datasources.Reset)
+
 	// Initialize OpenTelemetry tracing
 	cfg := telemetry.LoadOtelCfg()
 
@@ -106,6 +125,12 @@ func run(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	// Export logs over OTLP when OTEL_LOGS_EXPORTER=otlp
+	if err := telemetry.SetupLogExport(ctx); err != nil {
+		logger.Get().Error("Failed to setup OTLP log export", "error", err)
+		os.Exit(1)
+	}
+
 	// Start root span for server lifecycle
 	tracer := otel.Tracer("kagent-tools/server")
 	ctx, rootSpan := tracer.Start(ctx, "server.lifecycle")
@@ -123,13 +148,54 @@ func run(cmd *cobra.Command, args []string) {
 
 	logger.Get().Info("Starting "+Name, "version", Version, "git_commit", GitCommit, "build_date", BuildDate)
 
+	// Load tenant profiles; tenancy is disabled when none are configured
+	registry, err := tenancy.LoadRegistryFromEnv()
+	if err != nil {
+		logger.Get().Error("Failed to load tenancy config", "error", err)
+		os.Exit(1)
+	}
+	if registry != nil && stdio {
+		logger.Get().Error("Tenancy requires HTTP mode", "config", os.Getenv(tenancy.TenancyConfig))
+		os.Exit(1)
+	}
+
+	// Load the guard policies evaluated before every mutating tool call
+	policies, err := guard.LoadGuardFromEnv()
+	if err != nil {
+		logger.Get().Error("Failed to load guard policies", "error", err)
+		os.Exit(1)
+	}
+	// Edited policies apply to the next call; invalid policies keep the previous ones
+	reload.OnReload(policies.Reload)
+
+	serverOpts := serverOptions(policies)
+	enforcer := tenancy.NewEnforcer()
+	if registry != nil {
+		serverOpts = append(serverOpts,
+			server.WithToolFilter(enforcer.ToolFilter),
+			server.WithToolHandlerMiddleware(enforcer.ToolMiddleware),
+		)
+	}
+
 	mcp := server.NewMCPServer(
 		Name,
 		Version,
+		serverOpts...,
 	)
 
-	// Register tools
-	registerMCP(mcp, tools, *kubeconfig)
+	// Run background tasks only on the elected replica; every replica serves MCP traffic
+	electionCfg := leader.LoadConfig(*kubeconfig)
+	if err := electionCfg.Validate(); err != nil {
+		logger.Get().Error("Invalid leader election config", "error", err)
+		os.Exit(1)
+	}
+	elector := leader.NewElector(electionCfg)
+
+	// Register tools, whose background tasks must be registered before the election starts
+	toolInfo := registerMCP(mcp, tools, *kubeconfig, stdio, elector)
+	enforcer.SetTools(toolInfo)
+	utils.SetProviderTools(toolInfo, *kubeconfig)
+	go elector.Run(ctx)
 
 	// Create wait group for server goroutines
 	var wg sync.WaitGroup
@@ -170,16 +236,19 @@ func run(cmd *cobra.Command, args []string) {
 			w.WriteHeader(http.StatusOK)
 
 			// Generate real runtime metrics instead of hardcoded values
-			metrics := generateRuntimeMetrics()
+			metrics := generateRuntimeMetrics(elector)
 			if err := writeResponse(w, []byte(metrics)); err != nil {
 				logger.Get().Error("Failed to write metrics response", "error", err)
 			}
 		})
 
-		// Handle all other routes with the MCP server wrapped in telemetry middleware
-		mux.Handle("/", telemetry.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			sseServer.ServeHTTP(w, r)
-		})))
+		// Handle all other routes with the MCP server wrapped in telemetry and request logging middleware,
+		// authenticating tenants first when tenancy is enabled
+		var mcpHandler http.Handler = sseServer
+		if registry != nil {
+			mcpHandler = registry.HTTPMiddleware(mcpHandler)
+		}
+		mux.Handle("/", telemetry.HTTPMiddleware(telemetry.RequestLoggingMiddleware(telemetry.LoadRequestLogConfig(), mcpHandler)))
 
 		httpServer = &http.Server{
 			Addr:    fmt.Sprintf(":%d", port),
@@ -237,7 +306,7 @@ func writeResponse(w http.ResponseWriter, data []byte) error {
 }
 
 // generateRuntimeMetrics generates real runtime metrics for the /metrics endpoint
-func generateRuntimeMetrics() string {
+func generateRuntimeMetrics(elector *leader.Elector) string {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
@@ -274,6 +343,41 @@ func generateRuntimeMetrics() string {
 	metrics.WriteString("# TYPE go_goroutines gauge\n")
 	metrics.WriteString(fmt.Sprintf("go_goroutines %d\n", runtime.NumGoroutine()))
 
+	// Leader election state
+	isLeader := 0
+	if elector.IsLeader() {
+		isLeader = 1
+	}
+	metrics.WriteString("# HELP kagent_tools_leader Whether this replica runs background tasks.\n")
+	metrics.WriteString("# TYPE kagent_tools_leader gauge\n")
+	metrics.WriteString(fmt.Sprintf("kagent_tools_leader %d\n", isLeader))
+
+	// Cache statistics
+	cacheStats := make([]cache.CacheStats, 0)
+	for _, c := range cache.AllCaches() {
+		cacheStats = append(cacheStats, c.Stats())
+	}
+	metrics.WriteString("# HELP kagent_tools_cache_hits_total Total number of cache hits.\n")
+	metrics.WriteString("# TYPE kagent_tools_cache_hits_total counter\n")
+	for _, stats := range cacheStats {
+		metrics.WriteString(fmt.Sprintf("kagent_tools_cache_hits_total{cache=\"%s\"} %d\n", stats.Name, stats.Hits))
+	}
+	metrics.WriteString("# HELP kagent_tools_cache_misses_total Total number of cache misses.\n")
+	metrics.WriteString("# TYPE kagent_tools_cache_misses_total counter\n")
+	for _, stats := range cacheStats {
+		metrics.WriteString(fmt.Sprintf("kagent_tools_cache_misses_total{cache=\"%s\"} %d\n", stats.Name, stats.Misses))
+	}
+	metrics.WriteString("# HELP kagent_tools_cache_evictions_total Total number of expired or evicted cache entries.\n")
+	metrics.WriteString("# TYPE kagent_tools_cache_evictions_total counter\n")
+	for _, stats := range cacheStats {
+		metrics.WriteString(fmt.Sprintf("kagent_tools_cache_evictions_total{cache=\"%s\"} %d\n", stats.Name, stats.Evictions))
+	}
+	metrics.WriteString("# HELP kagent_tools_cache_entries Number of entries currently in the cache.\n")
+	metrics.WriteString("# TYPE kagent_tools_cache_entries gauge\n")
+	for _, stats := range cacheStats {
+		metrics.WriteString(fmt.Sprintf("kagent_tools_cache_entries{cache=\"%s\"} %d\n", stats.Name, stats.Size))
+	}
+
 	return metrics.String()
 }
 
@@ -285,15 +389,61 @@ func runStdioServer(ctx context.Context, mcp *server.MCPServer) {
 	}
 }
 
-func registerMCP(mcp *server.MCPServer, enabledToolProviders []string, kubeconfig string) {
+// serverOptions returns the tool filters, middleware and hooks of the MCP
+// server, shared with the bench command so that it measures what is served
+func serverOptions(policies *guard.Guard) []server.ServerOption {
+	return []server.ServerOption{
+		// Annotate tools with their side effects so clients can confirm dangerous calls
+		server.WithToolFilter(annotations.ToolFilter),
+		// Record call outcomes for providers_status
+		server.WithToolHandlerMiddleware(telemetry.ToolCallMiddleware),
+		// Fill in omitted namespaces before tenancy checks them
+		server.WithToolHandlerMiddleware(utils.DefaultNamespaceMiddleware),
+		// Restrict alert queries to the session's cluster
+		server.WithToolHandlerMiddleware(utils.DefaultClusterMiddleware),
+		// Generate text in the language asked for by the call or session
+		server.WithToolHandlerMiddleware(utils.LanguageMiddleware),
+		// Deny mutating calls the deployment's guard policies forbid, once
+		// namespaces are filled in
+		server.WithToolHandlerMiddleware(policies.ToolMiddleware),
+		server.WithHooks(sessionHooks()),
+	}
+}
+
+// sessionHooks tracks the tenant each session is sent alert activity for, and
+// forgets it, the defaults set with set_context and the budget usage of a
+// session when it ends
+func sessionHooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(alerts.TrackSession)
+	hooks.AddOnUnregisterSession(alerts.ForgetSession)
+	hooks.AddOnUnregisterSession(utils.ForgetSession)
+	hooks.AddOnUnregisterSession(budget.ForgetSession)
+	return hooks
+}
+
+// registerMCP registers the enabled tool providers and returns the scope of
+// each registered tool. Stdio servers run offline, persisting alerts and the
+// change history locally. Background jobs run while elector elects this
+// replica, or always when it is nil.
+func registerMCP(mcp *server.MCPServer, enabledToolProviders []string, kubeconfig string, stdio bool, elector alerts.Elector) map[string]tenancy.ToolInfo {
+	// Generation and analysis tools use the LLM configured by LLM_BASE_URL and
+	// LLM_API_KEY or OPENAI_API_KEY, and are unavailable without one
+	llmModel := llm.FromEnv()
+
+	// Mutating Kubernetes and Helm tools record the prior state of what they change
+	changes.SetDefault(changes.NewStoreFromEnv(stdio))
+
 	// A map to hold tool providers and their registration functions
 	toolProviderMap := map[string]func(*server.MCPServer){
-		"alerts":     func(s *server.MCPServer) { alerts.RegisterTools(s, nil, kubeconfig) },
+		"alerts": func(s *server.MCPServer) {
+			alerts.RegisterToolsWithStore(s, llmModel, kubeconfig, alerts.NewAlertStoreFromEnv(stdio), elector)
+		},
 		"argo":       argo.RegisterTools,
 		"cilium":     cilium.RegisterTools,
 		"helm":       helm.RegisterTools,
 		"istio":      istio.RegisterTools,
-		"k8s":        func(s *server.MCPServer) { k8s.RegisterTools(s, nil, kubeconfig) },
+		"k8s":        func(s *server.MCPServer) { k8s.RegisterTools(s, llmModel, kubeconfig) },
 		"prometheus": prometheus.RegisterTools,
 		"utils":      utils.RegisterTools,
 	}
@@ -304,11 +454,31 @@ func registerMCP(mcp *server.MCPServer, enabledToolProviders []string, kubeconfi
 			enabledToolProviders = append(enabledToolProviders, name)
 		}
 	}
+	toolInfo := make(map[string]tenancy.ToolInfo)
 	for _, toolProviderName := range enabledToolProviders {
 		if registerFunc, ok := toolProviderMap[toolProviderName]; ok {
 			registerFunc(mcp)
 		} else {
 			logger.Get().Error("Unknown tool specified", "provider", toolProviderName)
+			continue
+		}
+
+		// Attribute the tools added by this provider to it
+		registered, err := tenancy.ListTools(context.Background(), mcp)
+		if err != nil {
+			logger.Get().Error("Failed to list registered tools", "provider", toolProviderName, "error", err)
+			continue
+		}
+		for _, tool := range registered {
+			if _, ok := toolInfo[tool.Name]; !ok {
+				toolInfo[tool.Name] = tenancy.ToolInfo{
+					Provider:        toolProviderName,
+					Namespaced:      tenancy.IsNamespaced(tool),
+					NamespaceParams: tenancy.NamespaceParams(tool),
+					ClusterFiltered: tenancy.IsClusterFiltered(tool),
+				}
+			}
 		}
 	}
+	return toolInfo
 }
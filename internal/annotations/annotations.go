@@ -0,0 +1,272 @@
+// Package annotations is the central registry of MCP tool annotations. It
+// records the side effects of every tool so MCP clients can ask for
+// confirmation before running dangerous ones.
+package annotations
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Hints describe the side effects of a tool, following the MCP tool annotations
+type Hints struct {
+	// ReadOnly tools do not modify their environment
+	ReadOnly bool
+	// Destructive tools may delete or overwrite existing state; other tools
+	// that are not read-only only make additive updates
+	Destructive bool
+	// Idempotent tools have no additional effect when called again with the same arguments
+	Idempotent bool
+}
+
+// Side effect classes of the registered tools
+var (
+	readOnly              = Hints{ReadOnly: true, Idempotent: true}
+	additive              = Hints{}
+	additiveIdempotent    = Hints{Idempotent: true}
+	destructive           = Hints{Destructive: true}
+	destructiveIdempotent = Hints{Destructive: true, Idempotent: true}
+)
+
+// registry holds the hints of every tool, by name. Tools that change
+// behaviour with an action parameter are classified by their most
+// dangerous action.
+var registry = map[string]Hints{
+	// alerts
+	"alerts_analyze_pending_pod":         readOnly,
+	"alerts_compare":                     readOnly,
+	"alerts_create_silence":              additive,
+	"alerts_delete_silence":              destructiveIdempotent,
+	"alerts_evaluate_rules":              additive,
+	"alerts_explain_error":               readOnly,
+	"alerts_generate_incident_report":    additive,
+	"alerts_generate_ops_summary":        additive,
+	"alerts_generate_remediation_script": readOnly,
+	"alerts_get_cluster_alerts":          readOnly,
+	"alerts_get_pod_alert_details":       readOnly,
+	"alerts_get_pod_alerts":              readOnly,
+	"alerts_get_schemas":                 readOnly,
+	"alerts_jobs":                        destructive,
+	"alerts_list_script_templates":       readOnly,
+	"alerts_list_silences":               readOnly,
+	"alerts_mark_remediated":             additive,
+	"alerts_reload_runbooks":             additiveIdempotent,
+	"alerts_remediation_history":         readOnly,
+	"alerts_run_remediation":             destructive,
+	"alerts_search_runbooks":             readOnly,
+	"cluster_metadata_delete":            destructiveIdempotent,
+	"cluster_metadata_get":               readOnly,
+	"cluster_metadata_set":               destructiveIdempotent,
+	"monitor_pod":                        additive,
+	"monitor_pod_cancel":                 destructiveIdempotent,
+	"monitor_pod_status":                 readOnly,
+	"slo_burn_rate":                      readOnly,
+	"slo_define":                         destructiveIdempotent,
+	"slo_delete":                         destructiveIdempotent,
+	"slo_status":                         readOnly,
+
+	// argo
+	"argo_check_plugin_logs":                       readOnly,
+	"argo_explain_analysis_runs":                   readOnly,
+	"argo_pause_rollout":                           additiveIdempotent,
+	"argo_promote_rollout":                         destructive,
+	"argo_rollouts_list":                           readOnly,
+	"argo_set_rollout_image":                       destructiveIdempotent,
+	"argo_verify_argo_rollouts_controller_install": readOnly,
+	"argo_verify_gateway_plugin":                   additiveIdempotent,
+	"argo_verify_kubectl_plugin_install":           readOnly,
+
+	// cilium
+	"cilium_connect_to_remote_cluster":        additiveIdempotent,
+	"cilium_delete_key_from_kv_store":         destructiveIdempotent,
+	"cilium_delete_pcap_recorder":             destructiveIdempotent,
+	"cilium_delete_policy_rules":              destructiveIdempotent,
+	"cilium_delete_service":                   destructiveIdempotent,
+	"cilium_delete_xdp_cidr_filters":          destructiveIdempotent,
+	"cilium_disconnect_endpoint":              destructiveIdempotent,
+	"cilium_disconnect_remote_cluster":        destructiveIdempotent,
+	"cilium_display_encryption_state":         readOnly,
+	"cilium_display_policy_node_information":  readOnly,
+	"cilium_display_selectors":                readOnly,
+	"cilium_flush_ipsec_state":                destructiveIdempotent,
+	"cilium_fqdn_cache":                       destructiveIdempotent,
+	"cilium_get_bpf_map":                      readOnly,
+	"cilium_get_daemon_status":                readOnly,
+	"cilium_get_endpoint_details":             readOnly,
+	"cilium_get_endpoint_health":              readOnly,
+	"cilium_get_endpoint_logs":                readOnly,
+	"cilium_get_endpoints_list":               readOnly,
+	"cilium_get_identity_details":             readOnly,
+	"cilium_get_kv_store_key":                 readOnly,
+	"cilium_get_pcap_recorder":                readOnly,
+	"cilium_get_service_information":          readOnly,
+	"cilium_hubble_flows":                     readOnly,
+	"cilium_install_cilium":                   destructive,
+	"cilium_list_bgp_peers":                   readOnly,
+	"cilium_list_bgp_routes":                  readOnly,
+	"cilium_list_bpf_map_events":              readOnly,
+	"cilium_list_bpf_maps":                    readOnly,
+	"cilium_list_cluster_nodes":               readOnly,
+	"cilium_list_envoy_config":                readOnly,
+	"cilium_list_identities":                  readOnly,
+	"cilium_list_ip_addresses":                readOnly,
+	"cilium_list_local_redirect_policies":     readOnly,
+	"cilium_list_metrics":                     readOnly,
+	"cilium_list_node_ids":                    readOnly,
+	"cilium_list_pcap_recorders":              readOnly,
+	"cilium_list_services":                    readOnly,
+	"cilium_list_xdp_cidr_filters":            readOnly,
+	"cilium_manage_endpoint_config":           destructiveIdempotent,
+	"cilium_manage_endpoint_labels":           destructiveIdempotent,
+	"cilium_request_debugging_information":    readOnly,
+	"cilium_set_kv_store_key":                 destructiveIdempotent,
+	"cilium_show_cluster_mesh_status":         readOnly,
+	"cilium_show_configuration_options":       readOnly,
+	"cilium_show_dns_names":                   readOnly,
+	"cilium_show_features_status":             readOnly,
+	"cilium_show_ip_cache_information":        readOnly,
+	"cilium_show_load_information":            readOnly,
+	"cilium_status_and_version":               readOnly,
+	"cilium_toggle_cluster_mesh":              destructiveIdempotent,
+	"cilium_toggle_configuration_option":      destructiveIdempotent,
+	"cilium_toggle_hubble":                    destructiveIdempotent,
+	"cilium_uninstall_cilium":                 destructiveIdempotent,
+	"cilium_update_pcap_recorder":             destructiveIdempotent,
+	"cilium_update_service":                   destructiveIdempotent,
+	"cilium_update_xdp_cidr_filters":          destructiveIdempotent,
+	"cilium_upgrade_cilium":                   destructive,
+	"cilium_validate_cilium_network_policies": readOnly,
+
+	// helm
+	"helm_get_manifest":  readOnly,
+	"helm_get_release":   readOnly,
+	"helm_get_values":    readOnly,
+	"helm_list_releases": readOnly,
+	"helm_repo_add":      additiveIdempotent,
+	"helm_repo_update":   additiveIdempotent,
+	"helm_test":          additive,
+	"helm_uninstall":     destructiveIdempotent,
+	"helm_upgrade":       destructive,
+	"helm_upgrade_safe":  destructive,
+
+	// istio
+	"istio_access_log_sample":             additive,
+	"istio_analyze_cluster_configuration": readOnly,
+	"istio_apply_waypoint":                destructiveIdempotent,
+	"istio_delete_waypoint":               destructiveIdempotent,
+	"istio_generate_manifest":             readOnly,
+	"istio_generate_waypoint":             readOnly,
+	"istio_deploy_canary":                 destructive,
+	"istio_install_istio":                 destructive,
+	"istio_list_waypoints":                readOnly,
+	"istio_proxy_config":                  readOnly,
+	"istio_proxy_status":                  readOnly,
+	"istio_remote_clusters":               readOnly,
+	"istio_route_debug":                   readOnly,
+	"istio_version":                       readOnly,
+	"istio_waypoint_status":               readOnly,
+	"istio_ztunnel_config":                readOnly,
+
+	// k8s
+	"change_history":                  readOnly,
+	"k8s_annotate_resource":           additiveIdempotent,
+	"k8s_apply_manifest":              destructiveIdempotent,
+	"k8s_apply_manifest_chunked":      destructiveIdempotent,
+	"k8s_autoscaling_status":          readOnly,
+	"k8s_can_i":                       readOnly,
+	"k8s_check_references":            readOnly,
+	"k8s_check_service_connectivity":  additive,
+	"k8s_control_plane_health":        readOnly,
+	"k8s_cp_from_pod":                 additive,
+	"k8s_cr_summary":                  readOnly,
+	"k8s_crd_health":                  readOnly,
+	"k8s_create_resource":             additive,
+	"k8s_create_resource_from_url":    additive,
+	"k8s_delete_resource":             destructiveIdempotent,
+	"k8s_deprecation_scan":            readOnly,
+	"k8s_describe_resource":           readOnly,
+	"k8s_diagnose_image_pull":         readOnly,
+	"k8s_endpoints_check":             readOnly,
+	"k8s_evict_pod":                   destructive,
+	"k8s_execute_command":             destructive,
+	"k8s_generate_resource":           readOnly,
+	"k8s_generate_support_bundle":     additive,
+	"k8s_get_available_api_resources": readOnly,
+	"k8s_get_cluster_configuration":   readOnly,
+	"k8s_get_events":                  readOnly,
+	"k8s_get_pod_logs":                readOnly,
+	"k8s_get_resource_yaml":           readOnly,
+	"k8s_get_resources":               readOnly,
+	"k8s_hardening_check":             readOnly,
+	"k8s_image_inspect":               readOnly,
+	"k8s_label_resource":              additiveIdempotent,
+	"k8s_networkpolicy_check":         readOnly,
+	"k8s_patch_resource":              destructiveIdempotent,
+	"k8s_quota_check":                 readOnly,
+	"k8s_release_timeline":            readOnly,
+	"k8s_remove_annotation":           destructiveIdempotent,
+	"k8s_remove_label":                destructiveIdempotent,
+	"k8s_restart_report":              readOnly,
+	"k8s_restart_workload":            destructive,
+	"k8s_rollout":                     destructive,
+	"k8s_rollout_analyze":             readOnly,
+	"k8s_scale":                       destructiveIdempotent,
+	"k8s_search":                      readOnly,
+	"k8s_storage_diagnose":            readOnly,
+	"k8s_topology_report":             readOnly,
+	"k8s_upgrade_readiness":           readOnly,
+	"k8s_version_skew":                readOnly,
+	"undo_last_change":                destructive,
+
+	// prometheus
+	"alertmanager_create_silence": additive,
+	"alertmanager_expire_silence": destructive,
+	"alertmanager_list_alerts":    readOnly,
+	"alertmanager_list_silences":  readOnly,
+	"prometheus_cardinality":      readOnly,
+	"prometheus_label_names_tool": readOnly,
+	"prometheus_promql_tool":      readOnly,
+	"prometheus_query_range_tool": readOnly,
+	"prometheus_query_tool":       readOnly,
+	"prometheus_slo_query":        readOnly,
+	"prometheus_targets_tool":     readOnly,
+
+	// utils
+	"cache_flush":               additiveIdempotent,
+	"cache_inspect":             readOnly,
+	"datasources_list":          readOnly,
+	"datetime_get_current_time": readOnly,
+	"fetch_blob":                readOnly,
+	"providers_status":          readOnly,
+	"set_context":               additiveIdempotent,
+	"shell":                     destructive,
+}
+
+// Lookup returns the hints of a tool, reporting whether it is registered
+func Lookup(name string) (Hints, bool) {
+	hints, ok := registry[name]
+	return hints, ok
+}
+
+// Annotate sets a tool's hints from the registry, keeping its title and open
+// world hint. Unregistered tools keep mcp-go's conservative defaults, which
+// treat them as destructive.
+func Annotate(tool mcp.Tool) mcp.Tool {
+	hints, ok := registry[tool.Name]
+	if !ok {
+		return tool
+	}
+	tool.Annotations.ReadOnlyHint = mcp.ToBoolPtr(hints.ReadOnly)
+	tool.Annotations.DestructiveHint = mcp.ToBoolPtr(hints.Destructive)
+	tool.Annotations.IdempotentHint = mcp.ToBoolPtr(hints.Idempotent)
+	return tool
+}
+
+// ToolFilter annotates the tools returned by tools/list
+func ToolFilter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	for i := range tools {
+		tools[i] = Annotate(tools[i])
+	}
+	return tools
+}
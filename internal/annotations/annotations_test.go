@@ -0,0 +1,69 @@
+package annotations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/tenancy"
+	"github.com/kagent-dev/tools/pkg/alerts"
+	"github.com/kagent-dev/tools/pkg/argo"
+	"github.com/kagent-dev/tools/pkg/cilium"
+	"github.com/kagent-dev/tools/pkg/helm"
+	"github.com/kagent-dev/tools/pkg/istio"
+	"github.com/kagent-dev/tools/pkg/k8s"
+	"github.com/kagent-dev/tools/pkg/prometheus"
+	"github.com/kagent-dev/tools/pkg/utils"
+)
+
+func TestEveryToolIsAnnotated(t *testing.T) {
+	s := server.NewMCPServer("test", "0.0.0", server.WithToolFilter(ToolFilter))
+	alerts.RegisterToolsWithStore(s, nil, "", alerts.NewMemoryAlertStore(), nil)
+	argo.RegisterTools(s)
+	cilium.RegisterTools(s)
+	helm.RegisterTools(s)
+	istio.RegisterTools(s)
+	k8s.RegisterTools(s, nil, "")
+	prometheus.RegisterTools(s)
+	utils.RegisterTools(s)
+
+	tools, err := tenancy.ListTools(context.Background(), s)
+	require.NoError(t, err)
+	require.NotEmpty(t, tools)
+
+	listed := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		listed[tool.Name] = true
+		hints, ok := Lookup(tool.Name)
+		if !assert.True(t, ok, "tool %s has no annotations", tool.Name) {
+			continue
+		}
+		assert.Equal(t, hints.ReadOnly, *tool.Annotations.ReadOnlyHint, tool.Name)
+		assert.Equal(t, hints.Destructive, *tool.Annotations.DestructiveHint, tool.Name)
+		assert.Equal(t, hints.Idempotent, *tool.Annotations.IdempotentHint, tool.Name)
+	}
+	for name := range registry {
+		assert.True(t, listed[name], "annotated tool %s is not registered", name)
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	tool := Annotate(mcp.NewTool("k8s_get_resources", mcp.WithTitleAnnotation("Get resources")))
+	assert.True(t, *tool.Annotations.ReadOnlyHint)
+	assert.False(t, *tool.Annotations.DestructiveHint)
+	assert.Equal(t, "Get resources", tool.Annotations.Title)
+	assert.True(t, *tool.Annotations.OpenWorldHint)
+
+	tool = Annotate(mcp.NewTool("k8s_delete_resource"))
+	assert.False(t, *tool.Annotations.ReadOnlyHint)
+	assert.True(t, *tool.Annotations.DestructiveHint)
+
+	// Unregistered tools keep the conservative defaults
+	tool = Annotate(mcp.NewTool("unknown"))
+	assert.False(t, *tool.Annotations.ReadOnlyHint)
+	assert.True(t, *tool.Annotations.DestructiveHint)
+}
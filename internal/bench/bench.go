@@ -0,0 +1,441 @@
+// Package bench drives concurrent tool calls against an MCP server and reports
+// their latencies, error rates and the server's memory growth, to measure the
+// performance impact of middleware and caching changes.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultRequests is the number of calls of a run given neither a number of
+// requests nor a duration
+const defaultRequests = 1000
+
+// Call is a tool call of the workload
+type Call struct {
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+}
+
+// ParseCall parses a call given as tool or tool={"json":"arguments"}
+func ParseCall(spec string) (Call, error) {
+	tool, arguments, hasArguments := strings.Cut(strings.TrimSpace(spec), "=")
+	call := Call{Tool: strings.TrimSpace(tool)}
+	if call.Tool == "" {
+		return Call{}, fmt.Errorf("invalid call %q: missing tool name", spec)
+	}
+	if hasArguments {
+		if err := json.Unmarshal([]byte(arguments), &call.Arguments); err != nil {
+			return Call{}, fmt.Errorf("invalid arguments of call %q: %w", call.Tool, err)
+		}
+	}
+	return call, nil
+}
+
+// Config configures a run
+type Config struct {
+	// Calls are made in turn by the workers
+	Calls []Call
+	// Concurrency is the number of workers calling tools at once
+	Concurrency int
+	// Requests is the number of calls to make; with a Duration, the run ends
+	// at whichever comes first
+	Requests int
+	// Duration bounds the run
+	Duration time.Duration
+	// Warmup calls are made before measuring, filling caches and connection pools
+	Warmup int
+	// Timeout bounds each call
+	Timeout time.Duration
+	// SampleInterval is how often the memory of the server is sampled during the run
+	SampleInterval time.Duration
+}
+
+// Validate checks the configuration, filling in the defaults
+func (c *Config) Validate() error {
+	if len(c.Calls) == 0 {
+		return errors.New("no calls to make")
+	}
+	if c.Concurrency <= 0 {
+		return fmt.Errorf("concurrency must be positive, got %d", c.Concurrency)
+	}
+	if c.Requests < 0 || c.Warmup < 0 || c.Duration < 0 || c.Timeout < 0 {
+		return errors.New("requests, warmup, duration and timeout must not be negative")
+	}
+	if c.Requests == 0 && c.Duration == 0 {
+		c.Requests = defaultRequests
+	}
+	if c.SampleInterval <= 0 {
+		c.SampleInterval = time.Second
+	}
+	return nil
+}
+
+// Caller makes a tool call
+type Caller func(ctx context.Context, call Call) (*mcp.CallToolResult, error)
+
+// MemorySample is the memory use of the server at a point in time
+type MemorySample struct {
+	HeapBytes  uint64 `json:"heap_bytes"`
+	Goroutines int    `json:"goroutines"`
+}
+
+// MemorySampler samples the memory use of the server
+type MemorySampler func(ctx context.Context) (MemorySample, error)
+
+// Latency summarizes the latencies of a set of calls, in nanoseconds in JSON
+type Latency struct {
+	Min  time.Duration `json:"min_ns"`
+	Mean time.Duration `json:"mean_ns"`
+	P50  time.Duration `json:"p50_ns"`
+	P95  time.Duration `json:"p95_ns"`
+	P99  time.Duration `json:"p99_ns"`
+	Max  time.Duration `json:"max_ns"`
+}
+
+// ToolReport is the outcome of the calls of one tool
+type ToolReport struct {
+	Tool  string `json:"tool"`
+	Calls int    `json:"calls"`
+	// Errors are calls that failed at the protocol level or timed out
+	Errors int `json:"errors"`
+	// ToolErrors are calls whose result is a tool error
+	ToolErrors int     `json:"tool_errors"`
+	ErrorRate  float64 `json:"error_rate"`
+	Latency    Latency `json:"latency"`
+}
+
+// MemoryReport is the memory growth of the server over the run
+type MemoryReport struct {
+	Start       MemorySample `json:"start"`
+	End         MemorySample `json:"end"`
+	PeakHeap    uint64       `json:"peak_heap_bytes"`
+	HeapGrowth  int64        `json:"heap_growth_bytes"`
+	SampleError string       `json:"sample_error,omitempty"`
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Concurrency int           `json:"concurrency"`
+	Duration    time.Duration `json:"duration_ns"`
+	// Throughput is the number of calls completed per second
+	Throughput float64      `json:"throughput"`
+	Total      ToolReport   `json:"total"`
+	Tools      []ToolReport `json:"tools"`
+	Memory     MemoryReport `json:"memory"`
+	// FirstErrors holds the first distinct errors, to tell why calls failed
+	FirstErrors []string `json:"first_errors,omitempty"`
+}
+
+// maxReportedErrors is the number of distinct errors kept in a report
+const maxReportedErrors = 5
+
+// outcome is the result of one call
+type outcome struct {
+	tool      string
+	latency   time.Duration
+	err       error
+	toolError bool
+}
+
+// Run makes the calls of the configuration against the server and reports
+// their outcome. The memory of the server is sampled after the warmup, during
+// the run and at its end when sample is set.
+func Run(ctx context.Context, cfg Config, call Caller, sample MemorySampler) (Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return Report{}, err
+	}
+
+	if cfg.Warmup > 0 {
+		runWorkers(ctx, Config{Calls: cfg.Calls, Concurrency: cfg.Concurrency, Requests: cfg.Warmup, Timeout: cfg.Timeout}, call)
+	}
+
+	report := Report{Concurrency: cfg.Concurrency}
+	var sampler *memorySampler
+	if sample != nil {
+		sampler = startSampler(ctx, sample, cfg.SampleInterval)
+	}
+
+	start := time.Now()
+	outcomes := runWorkers(ctx, cfg, call)
+	report.Duration = time.Since(start)
+	if sampler != nil {
+		report.Memory = sampler.stop(ctx)
+	}
+	if ctx.Err() != nil {
+		return Report{}, ctx.Err()
+	}
+
+	summarize(&report, outcomes)
+	return report, nil
+}
+
+// runWorkers makes the calls of the configuration from concurrent workers
+func runWorkers(ctx context.Context, cfg Config, call Caller) []outcome {
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	var next atomic.Int64
+	results := make([][]outcome, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for worker := 0; worker < cfg.Concurrency; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				n := int(next.Add(1) - 1)
+				if cfg.Requests > 0 && n >= cfg.Requests {
+					return
+				}
+				c := cfg.Calls[n%len(cfg.Calls)]
+				callCtx, cancel := ctx, context.CancelFunc(func() {})
+				if cfg.Timeout > 0 {
+					callCtx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+				}
+				started := time.Now()
+				result, err := call(callCtx, c)
+				latency := time.Since(started)
+				cancel()
+				// Calls cut short by the end of a timed run are not counted
+				if ctx.Err() != nil {
+					return
+				}
+				results[worker] = append(results[worker], outcome{
+					tool:      c.Tool,
+					latency:   latency,
+					err:       err,
+					toolError: err == nil && result != nil && result.IsError,
+				})
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	var outcomes []outcome
+	for _, worker := range results {
+		outcomes = append(outcomes, worker...)
+	}
+	return outcomes
+}
+
+// summarize fills in the totals, the tool reports and the errors of a report
+func summarize(report *Report, outcomes []outcome) {
+	byTool := map[string][]outcome{}
+	seenErrors := map[string]bool{}
+	for _, o := range outcomes {
+		byTool[o.tool] = append(byTool[o.tool], o)
+		if o.err != nil && !seenErrors[o.err.Error()] && len(report.FirstErrors) < maxReportedErrors {
+			seenErrors[o.err.Error()] = true
+			report.FirstErrors = append(report.FirstErrors, fmt.Sprintf("%s: %v", o.tool, o.err))
+		}
+	}
+
+	report.Total = toolReport("total", outcomes)
+	report.Tools = []ToolReport{}
+	for tool, toolOutcomes := range byTool {
+		report.Tools = append(report.Tools, toolReport(tool, toolOutcomes))
+	}
+	sort.Slice(report.Tools, func(i, j int) bool { return report.Tools[i].Tool < report.Tools[j].Tool })
+	if seconds := report.Duration.Seconds(); seconds > 0 {
+		report.Throughput = float64(len(outcomes)) / seconds
+	}
+}
+
+// toolReport counts the errors of a set of calls and summarizes their latencies
+func toolReport(tool string, outcomes []outcome) ToolReport {
+	report := ToolReport{Tool: tool, Calls: len(outcomes)}
+	latencies := make([]time.Duration, 0, len(outcomes))
+	for _, o := range outcomes {
+		switch {
+		case o.err != nil:
+			report.Errors++
+		case o.toolError:
+			report.ToolErrors++
+		}
+		latencies = append(latencies, o.latency)
+	}
+	if report.Calls > 0 {
+		report.ErrorRate = float64(report.Errors+report.ToolErrors) / float64(report.Calls)
+	}
+	report.Latency = summarizeLatencies(latencies)
+	return report
+}
+
+// summarizeLatencies returns the distribution of a set of latencies
+func summarizeLatencies(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var sum time.Duration
+	for _, latency := range latencies {
+		sum += latency
+	}
+	return Latency{
+		Min:  latencies[0],
+		Mean: sum / time.Duration(len(latencies)),
+		P50:  percentile(latencies, 50),
+		P95:  percentile(latencies, 95),
+		P99:  percentile(latencies, 99),
+		Max:  latencies[len(latencies)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// memorySampler samples the memory of the server during a run, keeping the
+// first sample and the peak heap
+type memorySampler struct {
+	sample MemorySampler
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	report MemoryReport
+}
+
+// startSampler takes the starting sample, then samples at each interval until stopped
+func startSampler(ctx context.Context, sample MemorySampler, interval time.Duration) *memorySampler {
+	s := &memorySampler{sample: sample, done: make(chan struct{})}
+	first, err := sample(ctx)
+	s.record(first, err)
+	s.report.Start = first
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.record(sample(ctx))
+			}
+		}
+	}()
+	return s
+}
+
+// record keeps the peak heap and the first sampling error
+func (s *memorySampler) record(sample MemorySample, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		if s.report.SampleError == "" {
+			s.report.SampleError = err.Error()
+		}
+		return
+	}
+	if sample.HeapBytes > s.report.PeakHeap {
+		s.report.PeakHeap = sample.HeapBytes
+	}
+}
+
+// stop takes the final sample and returns the memory growth over the run
+func (s *memorySampler) stop(ctx context.Context) MemoryReport {
+	close(s.done)
+	s.wg.Wait()
+	last, err := s.sample(ctx)
+	s.record(last, err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report := s.report
+	if err == nil {
+		report.End = last
+		report.HeapGrowth = int64(last.HeapBytes) - int64(report.Start.HeapBytes)
+	}
+	return report
+}
+
+// WriteText writes a report as a table of the latencies and error rates of
+// each tool, followed by the memory growth of the server
+func (r Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "%d calls in %s from %d workers (%.1f calls/s)\n\n", r.Total.Calls, r.Duration.Round(time.Millisecond), r.Concurrency, r.Throughput)
+	_, _ = fmt.Fprintln(tw, "TOOL\tCALLS\tERRORS\tTOOL ERRORS\tERROR RATE\tP50\tP95\tP99\tMAX")
+	for _, tool := range append(r.Tools, r.Total) {
+		l := tool.Latency
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.2f%%\t%s\t%s\t%s\t%s\n", tool.Tool, tool.Calls, tool.Errors, tool.ToolErrors, tool.ErrorRate*100,
+			roundLatency(l.P50), roundLatency(l.P95), roundLatency(l.P99), roundLatency(l.Max))
+	}
+
+	m := r.Memory
+	switch {
+	case m.SampleError != "" && m.End == (MemorySample{}):
+		_, _ = fmt.Fprintf(tw, "\nMemory: not sampled (%s)\n", m.SampleError)
+	case m.Start != (MemorySample{}) || m.End != (MemorySample{}):
+		_, _ = fmt.Fprintf(tw, "\nHeap: %s at start, %s at end (%s), %s peak\n", formatBytes(int64(m.Start.HeapBytes)), formatBytes(int64(m.End.HeapBytes)),
+			signedBytes(m.HeapGrowth), formatBytes(int64(m.PeakHeap)))
+		_, _ = fmt.Fprintf(tw, "Goroutines: %d at start, %d at end\n", m.Start.Goroutines, m.End.Goroutines)
+	}
+	for i, err := range r.FirstErrors {
+		if i == 0 {
+			_, _ = fmt.Fprintln(tw, "\nErrors:")
+		}
+		_, _ = fmt.Fprintf(tw, "  %s\n", err)
+	}
+	return tw.Flush()
+}
+
+// roundLatency rounds a latency to a readable precision
+func roundLatency(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
+
+// formatBytes formats a number of bytes in binary units
+func formatBytes(n int64) string {
+	value, unit := float64(n), "B"
+	for _, next := range []string{"KiB", "MiB", "GiB"} {
+		if math.Abs(value) < 1024 {
+			break
+		}
+		value, unit = value/1024, next
+	}
+	if unit == "B" {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", value, unit)
+}
+
+// signedBytes formats a change in bytes with its sign
+func signedBytes(n int64) string {
+	if n >= 0 {
+		return "+" + formatBytes(n)
+	}
+	return "-" + formatBytes(-n)
+}
@@ -0,0 +1,165 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCall(t *testing.T) {
+	call, err := ParseCall(`k8s_get_resources={"resource_type":"pods","namespace":"a=b"}`)
+	require.NoError(t, err)
+	assert.Equal(t, Call{Tool: "k8s_get_resources", Arguments: map[string]any{"resource_type": "pods", "namespace": "a=b"}}, call)
+
+	call, err = ParseCall(" datetime_get_current_time ")
+	require.NoError(t, err)
+	assert.Equal(t, Call{Tool: "datetime_get_current_time"}, call)
+
+	for _, spec := range []string{"", `={"a":1}`, `k8s_get_resources={"resource_type"}`} {
+		_, err := ParseCall(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestSummarizeLatencies(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, Latency{
+		Min:  time.Millisecond,
+		Mean: 50500 * time.Microsecond,
+		P50:  50 * time.Millisecond,
+		P95:  95 * time.Millisecond,
+		P99:  99 * time.Millisecond,
+		Max:  100 * time.Millisecond,
+	}, summarizeLatencies(latencies))
+	assert.Equal(t, Latency{P50: time.Second, P95: time.Second, P99: time.Second, Min: time.Second, Mean: time.Second, Max: time.Second}, summarizeLatencies([]time.Duration{time.Second}))
+	assert.Equal(t, Latency{}, summarizeLatencies(nil))
+}
+
+func TestRun(t *testing.T) {
+	var calls atomic.Int64
+	caller := func(ctx context.Context, call Call) (*mcp.CallToolResult, error) {
+		calls.Add(1)
+		switch call.Tool {
+		case "broken":
+			return nil, errors.New("connection reset")
+		case "invalid":
+			return mcp.NewToolResultError("invalid arguments"), nil
+		}
+		return mcp.NewToolResultText("ok"), nil
+	}
+	var samples atomic.Int64
+	sample := func(ctx context.Context) (MemorySample, error) {
+		n := samples.Add(1)
+		return MemorySample{HeapBytes: uint64(n * 1000), Goroutines: 10}, nil
+	}
+
+	cfg := Config{
+		Calls:       []Call{{Tool: "ok"}, {Tool: "ok"}, {Tool: "broken"}, {Tool: "invalid"}},
+		Concurrency: 4,
+		Requests:    100,
+		Warmup:      8,
+	}
+	report, err := Run(context.Background(), cfg, caller, sample)
+	require.NoError(t, err)
+	assert.Equal(t, int64(108), calls.Load(), "warmup calls are made but not reported")
+
+	assert.Equal(t, 100, report.Total.Calls)
+	assert.Equal(t, 25, report.Total.Errors)
+	assert.Equal(t, 25, report.Total.ToolErrors)
+	assert.Equal(t, 0.5, report.Total.ErrorRate)
+	require.Len(t, report.Tools, 3)
+	assert.Equal(t, ToolReport{Tool: "broken", Calls: 25, Errors: 25, ErrorRate: 1}, withoutLatency(report.Tools[0]))
+	assert.Equal(t, "ok", report.Tools[2].Tool)
+	assert.Equal(t, 50, report.Tools[2].Calls)
+	assert.Equal(t, []string{"broken: connection reset"}, report.FirstErrors)
+	assert.Positive(t, report.Throughput)
+
+	assert.Equal(t, MemorySample{HeapBytes: 1000, Goroutines: 10}, report.Memory.Start)
+	assert.Equal(t, report.Memory.End.HeapBytes, report.Memory.PeakHeap)
+	assert.Equal(t, int64(report.Memory.End.HeapBytes)-1000, report.Memory.HeapGrowth)
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	assert.Contains(t, text.String(), "100 calls in")
+	assert.Regexp(t, `broken\s+25\s+25\s+0\s+100\.00%`, text.String())
+	assert.Contains(t, text.String(), "Heap: 1000 B at start")
+	assert.Contains(t, text.String(), "broken: connection reset")
+}
+
+func withoutLatency(report ToolReport) ToolReport {
+	report.Latency = Latency{}
+	return report
+}
+
+func TestRunForDuration(t *testing.T) {
+	caller := func(ctx context.Context, call Call) (*mcp.CallToolResult, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Millisecond):
+			return mcp.NewToolResultText("ok"), nil
+		}
+	}
+	report, err := Run(context.Background(), Config{Calls: []Call{{Tool: "slow"}}, Concurrency: 2, Duration: 100 * time.Millisecond}, caller, nil)
+	require.NoError(t, err)
+	assert.Positive(t, report.Total.Calls)
+	assert.Zero(t, report.Total.Errors, "calls cut short by the end of the run are not errors")
+	assert.Equal(t, MemoryReport{}, report.Memory)
+}
+
+func TestRunValidation(t *testing.T) {
+	caller := func(ctx context.Context, call Call) (*mcp.CallToolResult, error) { return nil, nil }
+	for _, cfg := range []Config{
+		{Concurrency: 1},
+		{Calls: []Call{{Tool: "a"}}},
+		{Calls: []Call{{Tool: "a"}}, Concurrency: 1, Requests: -1},
+	} {
+		_, err := Run(context.Background(), cfg, caller, nil)
+		assert.Error(t, err)
+	}
+
+	cfg := Config{Calls: []Call{{Tool: "a"}}, Concurrency: 1}
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, defaultRequests, cfg.Requests)
+}
+
+func TestMetricsMemory(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "# HELP go_memstats_alloc_bytes Number of bytes allocated and still in use.\n"+
+			"go_memstats_alloc_bytes 2048\ngo_memstats_sys_bytes 9999\ngo_goroutines 42\n")
+	}))
+	defer srv.Close()
+
+	sample, err := MetricsMemory(srv.URL + "/metrics")(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, MemorySample{HeapBytes: 2048, Goroutines: 42}, sample)
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer empty.Close()
+	_, err = MetricsMemory(empty.URL)(context.Background())
+	assert.ErrorContains(t, err, "go_memstats_alloc_bytes")
+}
+
+func TestMockExecutor(t *testing.T) {
+	output, err := MockExecutor{Output: DefaultMockOutput}.Exec(context.Background(), "kubectl", "get", "pods")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMockOutput, string(output))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = MockExecutor{Latency: time.Hour}.Exec(ctx, "kubectl")
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,91 @@
+package bench
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMockOutput is what mocked commands print: an empty Kubernetes list,
+// which the read tools accept
+const DefaultMockOutput = `{"apiVersion":"v1","kind":"List","items":[]}`
+
+// MockExecutor is a shell executor that answers every command with the same
+// output after a fixed latency, so that runs measure the server rather than
+// the cluster. Unlike the test mock, it keeps no call log, which would grow
+// with the run and distort the memory report.
+type MockExecutor struct {
+	Output  string
+	Latency time.Duration
+}
+
+// Exec waits for the latency, then returns the output
+func (e MockExecutor) Exec(ctx context.Context, command string, args ...string) ([]byte, error) {
+	if e.Latency > 0 {
+		timer := time.NewTimer(e.Latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return []byte(e.Output), nil
+}
+
+// LocalMemory samples the memory of the current process, for servers run in
+// process. A garbage collection runs first so that the heap is the live heap.
+func LocalMemory(ctx context.Context) (MemorySample, error) {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return MemorySample{HeapBytes: m.HeapAlloc, Goroutines: runtime.NumGoroutine()}, nil
+}
+
+// MetricsMemory samples the memory of a running server from its /metrics endpoint
+func MetricsMemory(metricsURL string) MemorySampler {
+	client := &http.Client{Timeout: 5 * time.Second}
+	return func(ctx context.Context) (MemorySample, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, metricsURL, nil)
+		if err != nil {
+			return MemorySample{}, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return MemorySample{}, fmt.Errorf("failed to fetch metrics: %w", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return MemorySample{}, fmt.Errorf("failed to fetch metrics: %s", resp.Status)
+		}
+
+		var sample MemorySample
+		var found bool
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			name, value, ok := strings.Cut(scanner.Text(), " ")
+			if !ok {
+				continue
+			}
+			switch name {
+			case "go_memstats_alloc_bytes":
+				sample.HeapBytes, err = strconv.ParseUint(value, 10, 64)
+				found = err == nil
+			case "go_goroutines":
+				sample.Goroutines, _ = strconv.Atoi(value)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return MemorySample{}, fmt.Errorf("failed to read metrics: %w", err)
+		}
+		if !found {
+			return MemorySample{}, fmt.Errorf("%s reports no go_memstats_alloc_bytes", metricsURL)
+		}
+		return sample, nil
+	}
+}
@@ -0,0 +1,158 @@
+// Package blobs stores large artifacts, such as support bundles, files copied
+// from pods and full pod logs, outside of the documents that reference them.
+// Each blob is its content and a JSON metadata document, stored in a bucket
+// and referenced by a URI of the form <bucket>://<id>.
+package blobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// idPattern matches the IDs generated by NewID
+var idPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Bucket is a kind of blob with its own storage location and URI scheme
+type Bucket struct {
+	// Name is the URI scheme of the bucket's blobs
+	Name string
+	// DirEnv configures the directory of the bucket's blobs on disk
+	DirEnv string
+	// DefaultDir is the directory under the XDG data directory used when DirEnv is unset
+	DefaultDir string
+}
+
+// URI returns the URI of a blob of the bucket
+func (b Bucket) URI(id string) string {
+	return b.Name + "://" + id
+}
+
+// Info identifies a stored blob and its content
+type Info struct {
+	ID     string
+	URI    string
+	Size   int64
+	SHA256 string
+}
+
+// Store stores blobs
+type Store interface {
+	// Write stores the content written by write as a new blob, failing when it
+	// is larger than maxBytes. The metadata document is built from the blob's
+	// ID, size and SHA-256 once its content is written.
+	Write(ctx context.Context, bucket Bucket, maxBytes int64, write func(io.Writer) error, metadata func(Info) interface{}) (Info, error)
+	// Read returns the content of a blob, decoding its metadata into metadata
+	Read(ctx context.Context, bucket Bucket, id string, metadata interface{}) ([]byte, error)
+	// ReadRange returns up to length bytes of a blob's content from offset,
+	// and the size of the whole content
+	ReadRange(ctx context.Context, bucket Bucket, id string, offset, length int64) ([]byte, int64, error)
+}
+
+var (
+	mu           sync.RWMutex
+	buckets            = map[string]Bucket{}
+	defaultStore Store = DiskStore{}
+)
+
+// Register registers a bucket, so that its blobs can be fetched by URI
+func Register(bucket Bucket) Bucket {
+	mu.Lock()
+	defer mu.Unlock()
+	buckets[bucket.Name] = bucket
+	return bucket
+}
+
+// Buckets returns the names of the registered buckets in order
+func Buckets() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(buckets))
+	for name := range buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseURI returns the bucket and ID of a blob URI
+func ParseURI(uri string) (Bucket, string, error) {
+	name, id, ok := strings.Cut(uri, "://")
+	if !ok {
+		return Bucket{}, "", fmt.Errorf("invalid blob URI %q", uri)
+	}
+	mu.RLock()
+	bucket, ok := buckets[name]
+	mu.RUnlock()
+	if !ok {
+		return Bucket{}, "", fmt.Errorf("unknown blob URI scheme %q, expected one of: %s", name, strings.Join(Buckets(), ", "))
+	}
+	if err := ValidateID(id); err != nil {
+		return Bucket{}, "", err
+	}
+	return bucket, id, nil
+}
+
+// Default returns the store blobs are written to
+func Default() Store {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultStore
+}
+
+// SetDefault replaces the store blobs are written to
+func SetDefault(store Store) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultStore = store
+}
+
+// NewID returns a random blob ID
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate blob ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ValidateID rejects IDs that were not generated by NewID, such as paths
+func ValidateID(id string) error {
+	if !idPattern.MatchString(id) {
+		return fmt.Errorf("invalid blob ID %q", id)
+	}
+	return nil
+}
+
+// limitedWriter fails once more than limit bytes are written
+type limitedWriter struct {
+	w       io.Writer
+	limit   int64
+	written int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.written+int64(len(p)) > l.limit {
+		return 0, fmt.Errorf("content exceeds max_bytes (%d)", l.limit)
+	}
+	n, err := l.w.Write(p)
+	l.written += int64(n)
+	return n, err
+}
+
+// checkRange rejects ranges that do not start within a blob of size bytes
+func checkRange(offset, length, size int64) error {
+	if offset < 0 || length < 0 {
+		return fmt.Errorf("invalid range of %d bytes from offset %d", length, offset)
+	}
+	if offset > size {
+		return fmt.Errorf("offset %d is beyond the end of the blob (%d bytes)", offset, size)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package blobs
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testBucket = Register(Bucket{Name: "test-blobs", DirEnv: "TEST_BLOB_DIR", DefaultDir: "kagent-tools/test-blobs"})
+
+type testMetadata struct {
+	ID     string `json:"id"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+func writeString(content string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, content)
+		return err
+	}
+}
+
+func metadataOf(info Info) interface{} {
+	return testMetadata{ID: info.ID, Size: info.Size, SHA256: info.SHA256}
+}
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"disk": func(t *testing.T) Store {
+			t.Setenv(testBucket.DirEnv, t.TempDir())
+			return DiskStore{}
+		},
+		"memory": func(t *testing.T) Store {
+			return NewMemoryStore()
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			info, err := store.Write(ctx, testBucket, 64, writeString("hello blob"), metadataOf)
+			require.NoError(t, err)
+			assert.Equal(t, "test-blobs://"+info.ID, info.URI)
+			assert.EqualValues(t, 10, info.Size)
+			assert.Len(t, info.SHA256, 64)
+
+			var metadata testMetadata
+			content, err := store.Read(ctx, testBucket, info.ID, &metadata)
+			require.NoError(t, err)
+			assert.Equal(t, "hello blob", string(content))
+			assert.Equal(t, testMetadata{ID: info.ID, Size: 10, SHA256: info.SHA256}, metadata)
+
+			content, size, err := store.ReadRange(ctx, testBucket, info.ID, 6, 100)
+			require.NoError(t, err)
+			assert.Equal(t, "blob", string(content))
+			assert.EqualValues(t, 10, size)
+
+			content, _, err = store.ReadRange(ctx, testBucket, info.ID, 10, 5)
+			require.NoError(t, err)
+			assert.Empty(t, content)
+
+			_, _, err = store.ReadRange(ctx, testBucket, info.ID, 11, 5)
+			assert.ErrorContains(t, err, "beyond the end")
+
+			_, err = store.Write(ctx, testBucket, 4, writeString("too large"), metadataOf)
+			assert.ErrorContains(t, err, "exceeds max_bytes")
+
+			missing, err := NewID()
+			require.NoError(t, err)
+			_, err = store.Read(ctx, testBucket, missing, &metadata)
+			assert.ErrorContains(t, err, "no blob found")
+			_, err = store.Read(ctx, testBucket, "../secrets", &metadata)
+			assert.ErrorContains(t, err, "invalid blob ID")
+		})
+	}
+}
+
+func TestDiskStoreRemovesFailedWrites(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(testBucket.DirEnv, dir)
+
+	_, err := DiskStore{}.Write(context.Background(), testBucket, 4, writeString("too large"), metadataOf)
+	require.Error(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDiskStoreDir(t *testing.T) {
+	t.Setenv(testBucket.DirEnv, "")
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	dir, err := DiskStore{}.Dir(context.Background(), testBucket)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dataHome, "kagent-tools", "test-blobs"), dir)
+}
+
+func TestParseURI(t *testing.T) {
+	id, err := NewID()
+	require.NoError(t, err)
+
+	bucket, parsed, err := ParseURI(testBucket.URI(id))
+	require.NoError(t, err)
+	assert.Equal(t, testBucket, bucket)
+	assert.Equal(t, id, parsed)
+
+	tests := map[string]string{
+		"no scheme":      id,
+		"unknown scheme": "unknown://" + id,
+		"invalid ID":     "test-blobs://../../etc/passwd",
+	}
+	for name, uri := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := ParseURI(uri)
+			assert.Error(t, err)
+		})
+	}
+}
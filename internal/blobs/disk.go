@@ -0,0 +1,149 @@
+package blobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/tenancy"
+)
+
+// DiskStore stores each blob as an <id>.blob content file and an <id>.json
+// metadata file in its bucket's directory, with a subdirectory per tenant
+type DiskStore struct{}
+
+// Dir returns the directory of a bucket's blobs for the request's tenant,
+// configured by the bucket's DirEnv or under the XDG data directory
+func (DiskStore) Dir(ctx context.Context, bucket Bucket) (string, error) {
+	dir := os.Getenv(bucket.DirEnv)
+	if dir == "" {
+		if dataHome := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dataHome) {
+			dir = filepath.Join(dataHome, bucket.DefaultDir)
+		} else {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("failed to locate data directory: %w", err)
+			}
+			dir = filepath.Join(home, ".local", "share", bucket.DefaultDir)
+		}
+	}
+	if prefix := tenancy.StoragePrefix(ctx); prefix != "" {
+		dir = filepath.Join(dir, filepath.Base(prefix))
+	}
+	return dir, nil
+}
+
+// Write stores a blob, removing its content again when it cannot be completed
+func (s DiskStore) Write(ctx context.Context, bucket Bucket, maxBytes int64, write func(io.Writer) error, metadata func(Info) interface{}) (Info, error) {
+	dir, err := s.Dir(ctx, bucket)
+	if err != nil {
+		return Info{}, err
+	}
+	id, err := NewID()
+	if err != nil {
+		return Info{}, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return Info{}, fmt.Errorf("failed to create blob store: %w", err)
+	}
+
+	blobPath := filepath.Join(dir, id+".blob")
+	blob, err := os.OpenFile(blobPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to create blob: %w", err)
+	}
+	hash := sha256.New()
+	w := &limitedWriter{w: io.MultiWriter(blob, hash), limit: maxBytes}
+
+	err = write(w)
+	if closeErr := blob.Close(); err == nil {
+		err = closeErr
+	}
+	info := Info{ID: id, URI: bucket.URI(id), Size: w.written, SHA256: hex.EncodeToString(hash.Sum(nil))}
+	if err == nil {
+		var metadataJSON []byte
+		metadataJSON, err = json.MarshalIndent(metadata(info), "", "  ")
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, id+".json"), metadataJSON, 0o600)
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to store blob metadata: %w", err)
+		}
+	}
+	if err != nil {
+		if removeErr := os.Remove(blobPath); removeErr != nil {
+			logger.Get().Error("Failed to remove blob", "path", blobPath, "error", removeErr)
+		}
+		return Info{}, err
+	}
+	return info, nil
+}
+
+// paths returns the content and metadata paths of a stored blob
+func (s DiskStore) paths(ctx context.Context, bucket Bucket, id string) (string, string, error) {
+	if err := ValidateID(id); err != nil {
+		return "", "", err
+	}
+	dir, err := s.Dir(ctx, bucket)
+	if err != nil {
+		return "", "", err
+	}
+	metadataPath := filepath.Join(dir, id+".json")
+	// Blobs being written have no metadata yet
+	if _, err := os.Stat(metadataPath); err != nil {
+		if os.IsNotExist(err) {
+			return "", "", fmt.Errorf("no blob found with ID %s", id)
+		}
+		return "", "", err
+	}
+	return filepath.Join(dir, id+".blob"), metadataPath, nil
+}
+
+// Read returns the content and metadata of a stored blob
+func (s DiskStore) Read(ctx context.Context, bucket Bucket, id string, metadata interface{}) ([]byte, error) {
+	blobPath, metadataPath, err := s.paths(ctx, bucket, id)
+	if err != nil {
+		return nil, err
+	}
+	metadataJSON, err := os.ReadFile(metadataPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(metadataJSON, metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse blob metadata: %w", err)
+	}
+	return os.ReadFile(blobPath)
+}
+
+// ReadRange reads part of a stored blob without loading the rest of it
+func (s DiskStore) ReadRange(ctx context.Context, bucket Bucket, id string, offset, length int64) ([]byte, int64, error) {
+	blobPath, _, err := s.paths(ctx, bucket, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	f, err := os.Open(blobPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	size := info.Size()
+	if err := checkRange(offset, length, size); err != nil {
+		return nil, size, err
+	}
+
+	content := make([]byte, min(length, size-offset))
+	if _, err := f.ReadAt(content, offset); err != nil && err != io.EOF {
+		return nil, size, err
+	}
+	return content, size, nil
+}
@@ -0,0 +1,99 @@
+package blobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/kagent-dev/tools/internal/tenancy"
+)
+
+// memoryBlob is a blob held by a MemoryStore
+type memoryBlob struct {
+	content  []byte
+	metadata []byte
+}
+
+// MemoryStore keeps blobs in memory, for servers without persistent storage and for tests
+type MemoryStore struct {
+	mu    sync.RWMutex
+	blobs map[string]memoryBlob
+}
+
+// NewMemoryStore returns an empty in-memory blob store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{blobs: map[string]memoryBlob{}}
+}
+
+// key returns the key of a blob, scoped to the request's tenant
+func (s *MemoryStore) key(ctx context.Context, bucket Bucket, id string) string {
+	return tenancy.StoragePrefix(ctx) + "/" + bucket.Name + "/" + id
+}
+
+// Write stores a blob
+func (s *MemoryStore) Write(ctx context.Context, bucket Bucket, maxBytes int64, write func(io.Writer) error, metadata func(Info) interface{}) (Info, error) {
+	id, err := NewID()
+	if err != nil {
+		return Info{}, err
+	}
+	var content bytes.Buffer
+	w := &limitedWriter{w: &content, limit: maxBytes}
+	if err := write(w); err != nil {
+		return Info{}, err
+	}
+	sum := sha256.Sum256(content.Bytes())
+	info := Info{ID: id, URI: bucket.URI(id), Size: w.written, SHA256: hex.EncodeToString(sum[:])}
+	metadataJSON, err := json.Marshal(metadata(info))
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to store blob metadata: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[s.key(ctx, bucket, id)] = memoryBlob{content: content.Bytes(), metadata: metadataJSON}
+	return info, nil
+}
+
+// get returns a stored blob
+func (s *MemoryStore) get(ctx context.Context, bucket Bucket, id string) (memoryBlob, error) {
+	if err := ValidateID(id); err != nil {
+		return memoryBlob{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	blob, ok := s.blobs[s.key(ctx, bucket, id)]
+	if !ok {
+		return memoryBlob{}, fmt.Errorf("no blob found with ID %s", id)
+	}
+	return blob, nil
+}
+
+// Read returns the content and metadata of a stored blob
+func (s *MemoryStore) Read(ctx context.Context, bucket Bucket, id string, metadata interface{}) ([]byte, error) {
+	blob, err := s.get(ctx, bucket, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(blob.metadata, metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse blob metadata: %w", err)
+	}
+	return bytes.Clone(blob.content), nil
+}
+
+// ReadRange returns part of a stored blob
+func (s *MemoryStore) ReadRange(ctx context.Context, bucket Bucket, id string, offset, length int64) ([]byte, int64, error) {
+	blob, err := s.get(ctx, bucket, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	size := int64(len(blob.content))
+	if err := checkRange(offset, length, size); err != nil {
+		return nil, size, err
+	}
+	return bytes.Clone(blob.content[offset:min(offset+length, size)]), size, nil
+}
@@ -0,0 +1,121 @@
+package blobs
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// Spill is a writer that keeps content in memory up to a limit and, once it
+// grows larger, streams the whole content into a blob instead. Writes block
+// while the store is busy, so a fast producer such as a command's stdout is
+// slowed down to the pace of the store rather than buffered.
+type Spill struct {
+	ctx      context.Context
+	store    Store
+	bucket   Bucket
+	limit    int
+	maxBytes int64
+	metadata func(Info) interface{}
+
+	head bytes.Buffer
+	size int64
+
+	pipe *io.PipeWriter
+	done chan spillResult
+	err  error
+}
+
+// spillResult is the outcome of storing a spilled blob
+type spillResult struct {
+	info Info
+	err  error
+}
+
+// NewSpill returns a Spill keeping up to limit bytes in memory and storing
+// larger content, of at most maxBytes, in bucket of store
+func NewSpill(ctx context.Context, store Store, bucket Bucket, limit int, maxBytes int64, metadata func(Info) interface{}) *Spill {
+	return &Spill{ctx: ctx, store: store, bucket: bucket, limit: limit, maxBytes: maxBytes, metadata: metadata}
+}
+
+// Write buffers p, or streams it into the blob once the content exceeds the limit
+func (s *Spill) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	if s.pipe == nil && s.head.Len()+len(p) > s.limit {
+		s.start()
+		if _, err := s.pipe.Write(s.head.Bytes()); err != nil {
+			s.err = err
+			return 0, err
+		}
+	}
+	if s.pipe != nil {
+		if _, err := s.pipe.Write(p); err != nil {
+			s.err = err
+			return 0, err
+		}
+	}
+	if room := s.limit - s.head.Len(); room > 0 {
+		s.head.Write(p[:min(len(p), room)])
+	}
+	s.size += int64(len(p))
+	return len(p), nil
+}
+
+// start begins storing the blob, fed through a pipe by Write
+func (s *Spill) start() {
+	reader, writer := io.Pipe()
+	s.pipe = writer
+	s.done = make(chan spillResult, 1)
+	go func() {
+		info, err := s.store.Write(s.ctx, s.bucket, s.maxBytes, func(w io.Writer) error {
+			_, err := io.Copy(w, reader)
+			return err
+		}, s.metadata)
+		// Unblock Write with the error when the store gave up early
+		reader.CloseWithError(err)
+		s.done <- spillResult{info: info, err: err}
+	}()
+}
+
+// Err returns the error of a failed Write, such as content exceeding max bytes
+func (s *Spill) Err() error {
+	return s.err
+}
+
+// Spilled reports whether the content exceeded the limit and went to a blob
+func (s *Spill) Spilled() bool {
+	return s.pipe != nil
+}
+
+// Head returns the first limit bytes of the content, or all of it when it
+// did not spill
+func (s *Spill) Head() []byte {
+	return s.head.Bytes()
+}
+
+// Size returns the number of bytes written
+func (s *Spill) Size() int64 {
+	return s.size
+}
+
+// Close finishes storing the blob of spilled content, returning its Info. It
+// returns a zero Info when the content did not spill.
+func (s *Spill) Close() (Info, error) {
+	if s.pipe == nil {
+		return Info{}, nil
+	}
+	_ = s.pipe.Close()
+	result := <-s.done
+	return result.info, result.err
+}
+
+// Abort discards the blob of spilled content, when the content is incomplete
+func (s *Spill) Abort(err error) {
+	if s.pipe == nil {
+		return
+	}
+	_ = s.pipe.CloseWithError(err)
+	<-s.done
+}
@@ -0,0 +1,71 @@
+package blobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpill(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	t.Run("small content stays in memory", func(t *testing.T) {
+		spill := NewSpill(ctx, store, testBucket, 16, 1024, metadataOf)
+		_, err := io.WriteString(spill, "hello")
+		require.NoError(t, err)
+		info, err := spill.Close()
+		require.NoError(t, err)
+		assert.False(t, spill.Spilled())
+		assert.Equal(t, Info{}, info)
+		assert.Equal(t, "hello", string(spill.Head()))
+		assert.Equal(t, int64(5), spill.Size())
+	})
+
+	t.Run("large content spills to a blob", func(t *testing.T) {
+		spill := NewSpill(ctx, store, testBucket, 16, 1024, metadataOf)
+		content := strings.Repeat("0123456789", 10)
+		for i := 0; i < len(content); i += 7 {
+			_, err := io.WriteString(spill, content[i:min(i+7, len(content))])
+			require.NoError(t, err)
+		}
+		info, err := spill.Close()
+		require.NoError(t, err)
+		assert.True(t, spill.Spilled())
+		assert.Equal(t, content[:16], string(spill.Head()))
+		assert.Equal(t, int64(len(content)), spill.Size())
+		assert.Equal(t, int64(len(content)), info.Size)
+
+		var metadata testMetadata
+		stored, err := store.Read(ctx, testBucket, info.ID, &metadata)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(stored))
+		assert.Equal(t, info.ID, metadata.ID)
+	})
+
+	t.Run("content larger than max bytes fails", func(t *testing.T) {
+		spill := NewSpill(ctx, store, testBucket, 4, 32, metadataOf)
+		var err error
+		for i := 0; i < 10 && err == nil; i++ {
+			_, err = io.WriteString(spill, "0123456789")
+		}
+		assert.ErrorContains(t, err, "exceeds max_bytes")
+		assert.Equal(t, err, spill.Err())
+		_, err = spill.Close()
+		assert.Error(t, err)
+	})
+
+	t.Run("aborted content is not stored", func(t *testing.T) {
+		aborted := NewMemoryStore()
+		spill := NewSpill(ctx, aborted, testBucket, 4, 1024, metadataOf)
+		_, err := io.WriteString(spill, "0123456789")
+		require.NoError(t, err)
+		spill.Abort(errors.New("command failed"))
+		assert.Empty(t, aborted.blobs)
+	})
+}
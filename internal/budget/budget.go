@@ -0,0 +1,271 @@
+// Package budget limits the LLM calls, LLM tokens and kubectl invocations of
+// each client session, protecting shared deployments from runaway agent
+// conversations. Work done outside a client session, such as background jobs,
+// is not metered.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Environment variables setting the budget of each session. Unset or 0 means
+// unlimited. Limits are read on every call so that reloaded values take effect.
+const (
+	// SessionMaxLLMCalls is the number of LLM calls a session may make
+	SessionMaxLLMCalls = "SESSION_MAX_LLM_CALLS"
+	// SessionMaxLLMTokens is the number of LLM tokens, prompt and completion, a
+	// session may use. The call that crosses the limit completes; the next is refused.
+	SessionMaxLLMTokens = "SESSION_MAX_LLM_TOKENS"
+	// SessionMaxKubectlCalls is the number of kubectl commands a session may
+	// run. Cached results do not count.
+	SessionMaxKubectlCalls = "SESSION_MAX_KUBECTL_CALLS"
+)
+
+// Resources a budget limits
+const (
+	ResourceLLMCalls     = "llm_calls"
+	ResourceLLMTokens    = "llm_tokens"
+	ResourceKubectlCalls = "kubectl_calls"
+)
+
+// ErrExceeded is matched by the errors of calls refused because the session
+// used up its budget
+var ErrExceeded = errors.New("session budget exceeded")
+
+// ExceededError reports the resource whose budget a session used up
+type ExceededError struct {
+	Resource string
+	Limit    int
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s: the session used its %d %s", ErrExceeded, e.Limit, e.Resource)
+}
+
+// Is makes ExceededError match ErrExceeded
+func (e *ExceededError) Is(target error) bool {
+	return target == ErrExceeded
+}
+
+// Limits is the budget of each session; 0 means unlimited
+type Limits struct {
+	LLMCalls     int `json:"llm_calls,omitempty"`
+	LLMTokens    int `json:"llm_tokens,omitempty"`
+	KubectlCalls int `json:"kubectl_calls,omitempty"`
+}
+
+// LimitsFromEnv returns the limits set by SESSION_MAX_LLM_CALLS,
+// SESSION_MAX_LLM_TOKENS and SESSION_MAX_KUBECTL_CALLS
+func LimitsFromEnv() Limits {
+	return Limits{
+		LLMCalls:     envLimit(SessionMaxLLMCalls),
+		LLMTokens:    envLimit(SessionMaxLLMTokens),
+		KubectlCalls: envLimit(SessionMaxKubectlCalls),
+	}
+}
+
+// envLimit returns the limit set by an environment variable, ignoring invalid values
+func envLimit(name string) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return 0
+}
+
+// Unlimited reports whether no limit is set
+func (l Limits) Unlimited() bool {
+	return l == Limits{}
+}
+
+// Usage is what a session has used of its budget
+type Usage struct {
+	Limits       Limits `json:"limits"`
+	LLMCalls     int    `json:"llm_calls"`
+	LLMTokens    int    `json:"llm_tokens"`
+	KubectlCalls int    `json:"kubectl_calls"`
+	// Exceeded lists the resources the session used up. Tools that can fall
+	// back to deterministic output do so once LLM budgets are used up.
+	Exceeded []string `json:"exceeded,omitempty"`
+}
+
+// usage holds the usage of each session by session ID
+var usage = struct {
+	sync.Mutex
+	sessions map[string]*Usage
+}{sessions: map[string]*Usage{}}
+
+type detachedKey struct{}
+
+// Detach returns a context whose work is not metered against the session of
+// ctx, for background work that outlives the call that started it
+func Detach(ctx context.Context) context.Context {
+	return context.WithValue(ctx, detachedKey{}, true)
+}
+
+// sessionID returns the ID of the metered session of ctx, if any
+func sessionID(ctx context.Context) (string, bool) {
+	if detached, _ := ctx.Value(detachedKey{}).(bool); detached {
+		return "", false
+	}
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return "", false
+	}
+	return session.SessionID(), true
+}
+
+// sessionUsage returns the usage of a session, creating it; usage must be locked
+func sessionUsage(id string) *Usage {
+	u, ok := usage.sessions[id]
+	if !ok {
+		u = &Usage{}
+		usage.sessions[id] = u
+	}
+	return u
+}
+
+// exceeded returns the error of the first resource whose limit is reached
+// among those checked
+func exceeded(u *Usage, limits Limits, resources ...string) error {
+	for _, resource := range resources {
+		used, limit := 0, 0
+		switch resource {
+		case ResourceLLMCalls:
+			used, limit = u.LLMCalls, limits.LLMCalls
+		case ResourceLLMTokens:
+			used, limit = u.LLMTokens, limits.LLMTokens
+		case ResourceKubectlCalls:
+			used, limit = u.KubectlCalls, limits.KubectlCalls
+		}
+		if limit > 0 && used >= limit {
+			return &ExceededError{Resource: resource, Limit: limit}
+		}
+	}
+	return nil
+}
+
+// AllowLLM returns an ExceededError when the session of ctx used up its LLM
+// calls or tokens
+func AllowLLM(ctx context.Context) error {
+	id, ok := sessionID(ctx)
+	if !ok {
+		return nil
+	}
+	limits := LimitsFromEnv()
+	usage.Lock()
+	defer usage.Unlock()
+	return exceeded(sessionUsage(id), limits, ResourceLLMCalls, ResourceLLMTokens)
+}
+
+// LLMAvailable reports whether the session of ctx may still call the LLM.
+// Tools with a deterministic fallback use it to degrade instead of failing.
+func LLMAvailable(ctx context.Context) bool {
+	return AllowLLM(ctx) == nil
+}
+
+// RecordLLM counts an LLM call and the tokens it used against the session of ctx
+func RecordLLM(ctx context.Context, tokens int) {
+	id, ok := sessionID(ctx)
+	if !ok {
+		return
+	}
+	usage.Lock()
+	defer usage.Unlock()
+	u := sessionUsage(id)
+	u.LLMCalls++
+	u.LLMTokens += tokens
+}
+
+// UseKubectl counts a kubectl invocation against the session of ctx, or
+// returns an ExceededError without counting it when the session used up its
+// kubectl calls
+func UseKubectl(ctx context.Context) error {
+	id, ok := sessionID(ctx)
+	if !ok {
+		return nil
+	}
+	limits := LimitsFromEnv()
+	usage.Lock()
+	defer usage.Unlock()
+	u := sessionUsage(id)
+	if err := exceeded(u, limits, ResourceKubectlCalls); err != nil {
+		return err
+	}
+	u.KubectlCalls++
+	return nil
+}
+
+// SessionUsage returns the usage of the session of ctx and its limits
+func SessionUsage(ctx context.Context) Usage {
+	limits := LimitsFromEnv()
+	var current Usage
+	if id, ok := sessionID(ctx); ok {
+		usage.Lock()
+		if u, ok := usage.sessions[id]; ok {
+			current = *u
+		}
+		usage.Unlock()
+	}
+	current.Limits = limits
+	for _, resource := range []string{ResourceLLMCalls, ResourceLLMTokens, ResourceKubectlCalls} {
+		if exceeded(&current, limits, resource) != nil {
+			current.Exceeded = append(current.Exceeded, resource)
+		}
+	}
+	return current
+}
+
+// ForgetSession drops the usage of a session that ended
+func ForgetSession(ctx context.Context, session server.ClientSession) {
+	usage.Lock()
+	defer usage.Unlock()
+	delete(usage.sessions, session.SessionID())
+}
+
+// meteredModel counts the calls and tokens of a model against the session of
+// each call, refusing calls once the session used up its LLM budget
+type meteredModel struct {
+	model llms.Model
+}
+
+// Model meters the calls of a model against the session budget, returning nil
+// for a nil model
+func Model(model llms.Model) llms.Model {
+	if model == nil {
+		return nil
+	}
+	return meteredModel{model: model}
+}
+
+// GenerateContent implements llms.Model
+func (m meteredModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	if err := AllowLLM(ctx); err != nil {
+		return nil, err
+	}
+	resp, err := m.model.GenerateContent(ctx, messages, options...)
+	RecordLLM(ctx, responseTokens(resp))
+	return resp, err
+}
+
+// Call implements llms.Model
+func (m meteredModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// responseTokens returns the tokens a response reports using. OpenAI-compatible
+// endpoints report the usage of the whole request on each choice.
+func responseTokens(resp *llms.ContentResponse) int {
+	if resp == nil || len(resp.Choices) == 0 || resp.Choices[0] == nil {
+		return 0
+	}
+	tokens, _ := resp.Choices[0].GenerationInfo["TotalTokens"].(int)
+	return tokens
+}
@@ -0,0 +1,103 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// testSession is a client session identified by its ID
+type testSession string
+
+func (s testSession) Initialize()       {}
+func (s testSession) Initialized() bool { return true }
+func (s testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return make(chan mcp.JSONRPCNotification, 1)
+}
+func (s testSession) SessionID() string { return string(s) }
+
+// tokenModel replies with a response reporting the given token usage
+type tokenModel struct {
+	tokens int
+	calls  int
+}
+
+func (m *tokenModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.calls++
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "ok", GenerationInfo: map[string]any{"TotalTokens": m.tokens}}}}, nil
+}
+
+func (m *tokenModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func sessionContext(t *testing.T, id string) context.Context {
+	session := testSession(id)
+	ctx := server.NewMCPServer("test", "1.0").WithContext(context.Background(), session)
+	t.Cleanup(func() { ForgetSession(ctx, session) })
+	return ctx
+}
+
+func TestLimitsFromEnv(t *testing.T) {
+	t.Setenv(SessionMaxLLMCalls, "10")
+	t.Setenv(SessionMaxLLMTokens, "-5")
+	t.Setenv(SessionMaxKubectlCalls, "many")
+	assert.Equal(t, Limits{LLMCalls: 10}, LimitsFromEnv())
+	assert.False(t, LimitsFromEnv().Unlimited())
+
+	t.Setenv(SessionMaxLLMCalls, "")
+	assert.True(t, LimitsFromEnv().Unlimited())
+}
+
+func TestModelTokenBudget(t *testing.T) {
+	t.Setenv(SessionMaxLLMTokens, "1000")
+	alice, bob := sessionContext(t, "alice"), sessionContext(t, "bob")
+	inner := &tokenModel{tokens: 600}
+	model := Model(inner)
+
+	// The call crossing the limit completes; the next one is refused
+	for i := 0; i < 2; i++ {
+		_, err := model.GenerateContent(alice, nil)
+		require.NoError(t, err)
+	}
+	_, err := model.GenerateContent(alice, nil)
+	assert.ErrorIs(t, err, ErrExceeded)
+	var exceededErr *ExceededError
+	require.True(t, errors.As(err, &exceededErr))
+	assert.Equal(t, ResourceLLMTokens, exceededErr.Resource)
+	assert.Equal(t, 2, inner.calls)
+	assert.False(t, LLMAvailable(alice))
+
+	usage := SessionUsage(alice)
+	assert.Equal(t, Usage{Limits: Limits{LLMTokens: 1000}, LLMCalls: 2, LLMTokens: 1200, Exceeded: []string{ResourceLLMTokens}}, usage)
+
+	// Other sessions and work outside sessions keep their budget
+	assert.True(t, LLMAvailable(bob))
+	assert.True(t, LLMAvailable(context.Background()))
+	_, err = model.GenerateContent(Detach(alice), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, SessionUsage(alice).LLMCalls)
+
+	ForgetSession(alice, testSession("alice"))
+	assert.True(t, LLMAvailable(alice))
+	assert.Nil(t, Model(nil))
+}
+
+func TestUseKubectl(t *testing.T) {
+	ctx := sessionContext(t, "kubectl")
+	for i := 0; i < 3; i++ {
+		require.NoError(t, UseKubectl(ctx), "unlimited without SESSION_MAX_KUBECTL_CALLS")
+	}
+
+	t.Setenv(SessionMaxKubectlCalls, "4")
+	require.NoError(t, UseKubectl(ctx))
+	assert.ErrorIs(t, UseKubectl(ctx), ErrExceeded)
+	assert.Equal(t, 4, SessionUsage(ctx).KubectlCalls, "refused calls are not counted")
+	assert.Equal(t, []string{ResourceKubectlCalls}, SessionUsage(ctx).Exceeded)
+}
@@ -3,7 +3,10 @@ package cache
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -78,6 +81,11 @@ type Cache[T any] struct {
 	misses    metric.Int64Counter
 	evictions metric.Int64Counter
 	size      metric.Int64UpDownCounter
+
+	// Counters backing Stats and the /metrics endpoint
+	hitCount      atomic.Int64
+	missCount     atomic.Int64
+	evictionCount atomic.Int64
 }
 
 // NewCache creates a new cache with specified configuration and name
@@ -139,7 +147,7 @@ func (c *Cache[T]) Get(key string) (T, bool) {
 	entry, exists := c.data[key]
 	if !exists {
 		var zero T
-		c.recordMiss(key)
+		c.recordMiss()
 		telemetry.AddEvent(span, "cache.miss",
 			attribute.String("cache.result", "miss"),
 		)
@@ -149,7 +157,7 @@ func (c *Cache[T]) Get(key string) (T, bool) {
 
 	if entry.IsExpired() {
 		var zero T
-		c.recordMiss(key)
+		c.recordMiss()
 		telemetry.AddEvent(span, "cache.miss",
 			attribute.String("cache.result", "miss"),
 			attribute.String("cache.miss_reason", "expired"),
@@ -165,7 +173,7 @@ func (c *Cache[T]) Get(key string) (T, bool) {
 	entry.AccessedAt = time.Now()
 	entry.AccessCount++
 
-	c.recordHit(key)
+	c.recordHit()
 	telemetry.AddEvent(span, "cache.hit",
 		attribute.String("cache.result", "hit"),
 		attribute.Int64("cache.access_count", entry.AccessCount),
@@ -256,11 +264,19 @@ func (c *Cache[T]) Stats() CacheStats {
 	defer c.mu.RUnlock()
 
 	stats := CacheStats{
-		Size:    len(c.data),
-		MaxSize: c.maxSize,
-		Expired: 0,
-		Oldest:  time.Now(),
-		Newest:  time.Time{},
+		Name:       c.name,
+		Size:       len(c.data),
+		MaxSize:    c.maxSize,
+		DefaultTTL: c.defaultTTL.String(),
+		Expired:    0,
+		Oldest:     time.Now(),
+		Newest:     time.Time{},
+		Hits:       c.hitCount.Load(),
+		Misses:     c.missCount.Load(),
+		Evictions:  c.evictionCount.Load(),
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups)
 	}
 
 	for _, entry := range c.data {
@@ -282,11 +298,76 @@ func (c *Cache[T]) Stats() CacheStats {
 
 // CacheStats represents cache statistics
 type CacheStats struct {
-	Size    int       `json:"size"`
-	MaxSize int       `json:"max_size"`
-	Expired int       `json:"expired"`
-	Oldest  time.Time `json:"oldest"`
-	Newest  time.Time `json:"newest"`
+	Name       string    `json:"name"`
+	Size       int       `json:"size"`
+	MaxSize    int       `json:"max_size"`
+	DefaultTTL string    `json:"default_ttl"`
+	Expired    int       `json:"expired"`
+	Oldest     time.Time `json:"oldest"`
+	Newest     time.Time `json:"newest"`
+	Hits       int64     `json:"hits"`
+	Misses     int64     `json:"misses"`
+	Evictions  int64     `json:"evictions"`
+	HitRatio   float64   `json:"hit_ratio"`
+}
+
+// EntryInfo describes a cache entry without its value
+type EntryInfo struct {
+	Key          string    `json:"key"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	TTLRemaining string    `json:"ttl_remaining"`
+	Expired      bool      `json:"expired"`
+	AccessCount  int64     `json:"access_count"`
+}
+
+// Entries lists the entries whose key starts with prefix, soonest to expire first
+func (c *Cache[T]) Entries(prefix string) []EntryInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	entries := make([]EntryInfo, 0, len(c.data))
+	for key, entry := range c.data {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		remaining := entry.ExpiresAt.Sub(now)
+		if remaining < 0 {
+			remaining = 0
+		}
+		entries = append(entries, EntryInfo{
+			Key:          key,
+			CreatedAt:    entry.CreatedAt,
+			ExpiresAt:    entry.ExpiresAt,
+			TTLRemaining: remaining.Round(time.Second).String(),
+			Expired:      entry.IsExpired(),
+			AccessCount:  entry.AccessCount,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ExpiresAt.Before(entries[j].ExpiresAt)
+	})
+	return entries
+}
+
+// DeletePrefix removes the entries whose key starts with prefix and returns how many were removed
+func (c *Cache[T]) DeletePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key := range c.data {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.data, key)
+			removed++
+		}
+	}
+	if removed > 0 {
+		c.size.Add(context.Background(), -int64(removed))
+		logger.Get().Info("Cache entries deleted", "cache", c.name, "prefix", prefix, "items_removed", removed)
+	}
+	return removed
 }
 
 // cleanupExpired removes expired entries from the cache
@@ -320,7 +401,7 @@ func (c *Cache[T]) performCleanup() {
 	if len(keysToDelete) > 0 {
 		for _, key := range keysToDelete {
 			delete(c.data, key)
-			c.evictions.Add(context.Background(), 1)
+			c.recordEviction("expired")
 		}
 
 		c.size.Add(context.Background(), -int64(len(keysToDelete)))
@@ -342,30 +423,40 @@ func (c *Cache[T]) evictLRU() {
 
 	if oldestKey != "" {
 		delete(c.data, oldestKey)
-		c.evictions.Add(context.Background(), 1)
+		c.recordEviction("lru")
 		c.size.Add(context.Background(), -1)
 		logger.Get().Debug("Cache LRU eviction", "key", oldestKey)
 	}
 }
 
-// recordHit records a cache hit
-func (c *Cache[T]) recordHit(key string) {
+// recordHit records a cache hit.
+// Keys are not used as metric attributes, as every command line would become a new series.
+func (c *Cache[T]) recordHit() {
+	c.hitCount.Add(1)
 	c.hits.Add(context.Background(), 1, metric.WithAttributes(
-		attribute.String("cache.key", key),
 		attribute.String("cache.result", "hit"),
 		attribute.String("cache.name", c.name),
 	))
 }
 
 // recordMiss records a cache miss
-func (c *Cache[T]) recordMiss(key string) {
+func (c *Cache[T]) recordMiss() {
+	c.missCount.Add(1)
 	c.misses.Add(context.Background(), 1, metric.WithAttributes(
-		attribute.String("cache.key", key),
 		attribute.String("cache.result", "miss"),
 		attribute.String("cache.name", c.name),
 	))
 }
 
+// recordEviction records the removal of an entry because it expired or to make room
+func (c *Cache[T]) recordEviction(reason string) {
+	c.evictionCount.Add(1)
+	c.evictions.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("cache.eviction_reason", reason),
+		attribute.String("cache.name", c.name),
+	))
+}
+
 // Close stops the cache cleanup goroutine
 func (c *Cache[T]) Close() {
 	close(c.stopCleanup)
@@ -468,6 +559,30 @@ func GetCacheByType(cacheType CacheType) *Cache[string] {
 	return cacheRegistry[CacheTypeCommand]
 }
 
+// GetCacheByName returns the cache with the given name, such as kubernetes or helm
+func GetCacheByName(name string) (*Cache[string], bool) {
+	InitCaches()
+	for _, cache := range cacheRegistry {
+		if cache.name == name {
+			return cache, true
+		}
+	}
+	return nil, false
+}
+
+// AllCaches returns the global caches ordered by name
+func AllCaches() []*Cache[string] {
+	InitCaches()
+	caches := make([]*Cache[string], 0, len(cacheRegistry))
+	for _, cache := range cacheRegistry {
+		caches = append(caches, cache)
+	}
+	sort.Slice(caches, func(i, j int) bool {
+		return caches[i].name < caches[j].name
+	})
+	return caches
+}
+
 // GetCacheByCommand returns a cache instance based on the command name
 func GetCacheByCommand(command string) *Cache[string] {
 	InitCaches()
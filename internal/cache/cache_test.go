@@ -181,6 +181,67 @@ func TestCacheStats(t *testing.T) {
 	}
 }
 
+func TestCacheStatsCounters(t *testing.T) {
+	cache := NewCache[string]("test-cache", 1*time.Minute, 2, 10*time.Second)
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	cache.Get("key1")
+	cache.Get("missing")
+	cache.Set("key2", "value2")
+	cache.Set("key3", "value3") // evicts the least recently used entry
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Evictions != 1 {
+		t.Errorf("Expected 1 hit, 1 miss and 1 eviction, got %d, %d and %d", stats.Hits, stats.Misses, stats.Evictions)
+	}
+	if stats.HitRatio != 0.5 {
+		t.Errorf("Expected hit ratio 0.5, got %f", stats.HitRatio)
+	}
+	if stats.Name != "test-cache" || stats.DefaultTTL != "1m0s" {
+		t.Errorf("Unexpected name %q or default TTL %q", stats.Name, stats.DefaultTTL)
+	}
+}
+
+func TestCacheEntriesAndDeletePrefix(t *testing.T) {
+	cache := NewCache[string]("test-cache", 1*time.Minute, 100, 10*time.Second)
+	defer cache.Close()
+
+	cache.SetWithTTL("kubectl:get:pods", "secret", 30*time.Second)
+	cache.SetWithTTL("kubectl:get:nodes", "secret", 10*time.Second)
+	cache.Set("helm:list", "secret")
+
+	entries := cache.Entries("kubectl:")
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Key != "kubectl:get:nodes" {
+		t.Errorf("Expected entries ordered by expiry, got %q first", entries[0].Key)
+	}
+	if entries[0].TTLRemaining != "10s" {
+		t.Errorf("Expected 10s remaining, got %q", entries[0].TTLRemaining)
+	}
+
+	if removed := cache.DeletePrefix("kubectl:"); removed != 2 {
+		t.Errorf("Expected 2 entries removed, got %d", removed)
+	}
+	if cache.Size() != 1 {
+		t.Errorf("Expected 1 entry left, got %d", cache.Size())
+	}
+}
+
+func TestGetCacheByName(t *testing.T) {
+	if _, found := GetCacheByName("helm"); !found {
+		t.Error("Expected helm cache to be registered")
+	}
+	if _, found := GetCacheByName("missing"); found {
+		t.Error("Expected no cache named missing")
+	}
+	if caches := AllCaches(); len(caches) != 4 || caches[0].Name() != "command" {
+		t.Errorf("Expected 4 caches ordered by name, got %d", len(caches))
+	}
+}
+
 func TestCacheKey(t *testing.T) {
 	tests := []struct {
 		name       string
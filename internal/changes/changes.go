@@ -0,0 +1,401 @@
+// Package changes records the state resources had before the tools changed
+// them, so that changes made by an agent can be reviewed and undone.
+package changes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/tenancy"
+)
+
+// ChangeHistoryFile configures the file the change history is persisted to.
+// When unset, the history is kept in memory unless the server runs in offline mode.
+const ChangeHistoryFile = "CHANGE_HISTORY_FILE"
+
+// defaultChangeHistoryFile is the file name used under the XDG data directory
+const defaultChangeHistoryFile = "kagent-tools/changes.json"
+
+// maxChangesPerTenant bounds the history of each tenant, dropping the oldest changes first
+const maxChangesPerTenant = 500
+
+// KindHelmRelease is the kind of changes to Helm releases
+const KindHelmRelease = "helm_release"
+
+// Kinds of prior state, which decide how a change is undone
+const (
+	// PriorReplicas restores the replica count in Replicas
+	PriorReplicas = "replicas"
+	// PriorManifest restores the object in Manifest, recreating it if it was deleted
+	PriorManifest = "manifest"
+	// PriorAbsent deletes the object or release, which did not exist before
+	PriorAbsent = "absent"
+	// PriorHelmRevision rolls the release back to Revision
+	PriorHelmRevision = "helm_revision"
+	// PriorNone records the change without a way to undo it, explained in Note
+	PriorNone = "none"
+)
+
+// Change is a change made to a resource by a tool, with the state to restore to undo it
+type Change struct {
+	ID        int    `json:"id"`
+	Tool      string `json:"tool"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Summary describes the change, e.g. "scaled to 3 replicas"
+	Summary string `json:"summary"`
+	Prior   string `json:"prior"`
+	// Replicas is the replica count before a scale
+	Replicas *int `json:"replicas,omitempty"`
+	// Manifest is the object before the change, in JSON without its server-set fields
+	Manifest string `json:"manifest,omitempty"`
+	// Revision is the Helm release revision before the change
+	Revision int `json:"revision,omitempty"`
+	// Note explains why a change cannot be undone
+	Note      string     `json:"note,omitempty"`
+	ChangedAt time.Time  `json:"changed_at"`
+	UndoneAt  *time.Time `json:"undone_at,omitempty"`
+}
+
+// Undoable reports whether the change can be undone
+func (c Change) Undoable() bool {
+	return c.Prior != PriorNone && c.UndoneAt == nil
+}
+
+// Filter selects changes; empty fields match any change
+type Filter struct {
+	// Kind matches the kind of a change in any common spelling, e.g.
+	// deploy, deployments or deployment.apps for Deployment
+	Kind      string
+	Name      string
+	Namespace string
+	// IncludeUndone also returns the changes that were undone
+	IncludeUndone bool
+	// Limit is the maximum number of changes returned, 0 for all
+	Limit int
+}
+
+// kindAliases are the kubectl short names of common kinds
+var kindAliases = map[string]string{
+	"cj":     "cronjob",
+	"cm":     "configmap",
+	"deploy": "deployment",
+	"ds":     "daemonset",
+	"hpa":    "horizontalpodautoscaler",
+	"ing":    "ingress",
+	"netpol": "networkpolicy",
+	"no":     "node",
+	"ns":     "namespace",
+	"pdb":    "poddisruptionbudget",
+	"po":     "pod",
+	"pv":     "persistentvolume",
+	"pvc":    "persistentvolumeclaim",
+	"rs":     "replicaset",
+	"sa":     "serviceaccount",
+	"sts":    "statefulset",
+	"svc":    "service",
+}
+
+// NormalizeKind returns the singular lower-case kind of a resource type, so
+// that deploy, deployments, deployment.apps and Deployment compare equal
+func NormalizeKind(kind string) string {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	kind, _, _ = strings.Cut(kind, ".")
+	if alias, ok := kindAliases[kind]; ok {
+		return alias
+	}
+	switch {
+	case strings.HasSuffix(kind, "ies"):
+		return strings.TrimSuffix(kind, "ies") + "y"
+	case strings.HasSuffix(kind, "sses"):
+		return strings.TrimSuffix(kind, "es")
+	case strings.HasSuffix(kind, "s") && !strings.HasSuffix(kind, "ss"):
+		return strings.TrimSuffix(kind, "s")
+	}
+	return kind
+}
+
+// matches reports whether a change is selected by the filter
+func (f Filter) matches(change *Change) bool {
+	switch {
+	case !f.IncludeUndone && change.UndoneAt != nil:
+		return false
+	case f.Kind != "" && NormalizeKind(f.Kind) != NormalizeKind(change.Kind):
+		return false
+	case f.Name != "" && f.Name != change.Name:
+		return false
+	case f.Namespace != "" && f.Namespace != change.Namespace:
+		return false
+	}
+	return true
+}
+
+// Store keeps the change history of each tenant
+type Store interface {
+	// Record adds a change, assigning its ID and time
+	Record(ctx context.Context, change Change) (Change, error)
+	// List returns the changes selected by the filter, the most recent first
+	List(ctx context.Context, filter Filter) ([]Change, error)
+	// MarkUndone records that the change with the given ID was undone
+	MarkUndone(ctx context.Context, id int) (Change, error)
+}
+
+// MemoryStore is a Store kept in memory
+type MemoryStore struct {
+	mu      sync.RWMutex
+	changes map[string][]*Change
+	nextID  int
+}
+
+// NewMemoryStore creates an empty in-memory change history
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{changes: make(map[string][]*Change)}
+}
+
+// Record adds a change to the history of the context's tenant
+func (s *MemoryStore) Record(ctx context.Context, change Change) (Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	change.ID, change.ChangedAt, change.UndoneAt = s.nextID, time.Now().UTC(), nil
+	prefix := tenancy.StoragePrefix(ctx)
+	history := append(s.changes[prefix], &change)
+	if len(history) > maxChangesPerTenant {
+		history = history[len(history)-maxChangesPerTenant:]
+	}
+	s.changes[prefix] = history
+	return change, nil
+}
+
+// List returns copies of the tenant's changes selected by the filter, the most recent first
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]Change, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	changes := []Change{}
+	history := s.changes[tenancy.StoragePrefix(ctx)]
+	for i := len(history) - 1; i >= 0; i-- {
+		if !filter.matches(history[i]) {
+			continue
+		}
+		changes = append(changes, *history[i])
+		if filter.Limit > 0 && len(changes) == filter.Limit {
+			break
+		}
+	}
+	return changes, nil
+}
+
+// MarkUndone records that a change of the tenant was undone
+func (s *MemoryStore) MarkUndone(ctx context.Context, id int) (Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, change := range s.changes[tenancy.StoragePrefix(ctx)] {
+		if change.ID != id {
+			continue
+		}
+		if change.UndoneAt != nil {
+			return Change{}, fmt.Errorf("change %d was already undone", id)
+		}
+		now := time.Now().UTC()
+		change.UndoneAt = &now
+		return *change, nil
+	}
+	return Change{}, fmt.Errorf("change %d not found", id)
+}
+
+// sortChanges orders changes by ID, restoring the order of a loaded history
+func sortChanges(changes map[string][]*Change) {
+	for _, history := range changes {
+		sort.Slice(history, func(i, j int) bool { return history[i].ID < history[j].ID })
+	}
+}
+
+// snapshot is the on-disk representation of a FileStore
+type snapshot struct {
+	Changes map[string][]*Change `json:"changes"`
+	NextID  int                  `json:"next_id"`
+}
+
+// FileStore is a Store that keeps the history in memory and writes it to a
+// local JSON file after every change, so undo survives restarts. Manifests
+// are stored as they were read from the cluster, so the file is only readable
+// by its owner.
+type FileStore struct {
+	*MemoryStore
+	path string
+	// mu serializes changes with the writes that persist them
+	mu sync.Mutex
+}
+
+// NewFileStore creates a store persisted to path, loading any history already stored there
+func NewFileStore(path string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create change history directory: %w", err)
+	}
+
+	store := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read change history: %w", err)
+	}
+
+	var loaded snapshot
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to parse change history %s: %w", path, err)
+	}
+	if loaded.Changes != nil {
+		sortChanges(loaded.Changes)
+		store.changes = loaded.Changes
+	}
+	store.nextID = loaded.NextID
+	return store, nil
+}
+
+// save writes the history to the store file, replacing it atomically
+func (s *FileStore) save() error {
+	s.MemoryStore.mu.RLock()
+	data, err := json.Marshal(snapshot{Changes: s.changes, NextID: s.nextID})
+	s.MemoryStore.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode change history: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write change history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write change history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write change history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write change history: %w", err)
+	}
+	return nil
+}
+
+// Record adds a change and persists the history
+func (s *FileStore) Record(ctx context.Context, change Change) (Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change, err := s.MemoryStore.Record(ctx, change)
+	if err != nil {
+		return Change{}, err
+	}
+	return change, s.save()
+}
+
+// MarkUndone records that a change was undone and persists the history
+func (s *FileStore) MarkUndone(ctx context.Context, id int) (Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change, err := s.MemoryStore.MarkUndone(ctx, id)
+	if err != nil {
+		return Change{}, err
+	}
+	return change, s.save()
+}
+
+// DefaultPath returns the change history file under $XDG_DATA_HOME, falling
+// back to ~/.local/share
+func DefaultPath() (string, error) {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dataHome) {
+		return filepath.Join(dataHome, defaultChangeHistoryFile), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate data directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", defaultChangeHistoryFile), nil
+}
+
+// NewStoreFromEnv returns the change history configured by CHANGE_HISTORY_FILE.
+// In offline mode, used for stdio servers, the history is persisted under the
+// XDG data directory by default. The store falls back to memory when the file
+// cannot be used.
+func NewStoreFromEnv(offline bool) Store {
+	path := os.Getenv(ChangeHistoryFile)
+	if path == "" && offline {
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			logger.Get().Error("Change history persistence disabled", "error", err)
+			return NewMemoryStore()
+		}
+	}
+	if path == "" {
+		return NewMemoryStore()
+	}
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		logger.Get().Error("Change history persistence disabled", "path", path, "error", err)
+		return NewMemoryStore()
+	}
+	logger.Get().Info("Persisting change history to file", "path", path)
+	return store
+}
+
+var (
+	defaultMu    sync.RWMutex
+	defaultStore Store = NewMemoryStore()
+)
+
+// Default returns the change history the tools record to
+func Default() Store {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultStore
+}
+
+// SetDefault replaces the change history the tools record to
+func SetDefault(store Store) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStore = store
+}
+
+// Record adds a change to the default history. Failing to record does not
+// fail the change, which was already made, so errors are only logged.
+func Record(ctx context.Context, change Change) {
+	if _, err := Default().Record(ctx, change); err != nil {
+		logger.Get().Error("Failed to record change", "tool", change.Tool, "kind", change.Kind, "name", change.Name, "error", err)
+	}
+}
+
+// CleanManifest strips the fields the API server sets from an object read
+// from the cluster, so that it can be replaced or created again
+func CleanManifest(object map[string]interface{}) {
+	delete(object, "status")
+	metadata, _ := object["metadata"].(map[string]interface{})
+	for _, field := range []string{"resourceVersion", "uid", "creationTimestamp", "generation", "managedFields", "selfLink", "deletionTimestamp", "deletionGracePeriodSeconds"} {
+		delete(metadata, field)
+	}
+	if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+		delete(annotations, "deployment.kubernetes.io/revision")
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+}
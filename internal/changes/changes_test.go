@@ -0,0 +1,113 @@
+package changes
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeKind(t *testing.T) {
+	for _, kind := range []string{"deploy", "deployments", "deployment.apps", "Deployment"} {
+		assert.Equal(t, "deployment", NormalizeKind(kind), kind)
+	}
+	assert.Equal(t, "networkpolicy", NormalizeKind("networkpolicies"))
+	assert.Equal(t, "ingressclass", NormalizeKind("ingressclasses"))
+	assert.Equal(t, "ingress", NormalizeKind("ing"))
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	replicas := 3
+
+	scaled, err := store.Record(ctx, Change{Tool: "k8s_scale", Kind: "deployment", Name: "web", Namespace: "prod", Prior: PriorReplicas, Replicas: &replicas})
+	require.NoError(t, err)
+	_, err = store.Record(ctx, Change{Tool: "k8s_delete_resource", Kind: "ConfigMap", Name: "web", Namespace: "prod", Prior: PriorManifest, Manifest: "{}"})
+	require.NoError(t, err)
+
+	history, err := store.List(ctx, Filter{Name: "web"})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "ConfigMap", history[0].Kind, "most recent first")
+
+	history, err = store.List(ctx, Filter{Kind: "deploy", Name: "web"})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, scaled.ID, history[0].ID)
+	assert.True(t, history[0].Undoable())
+
+	undone, err := store.MarkUndone(ctx, scaled.ID)
+	require.NoError(t, err)
+	assert.False(t, undone.Undoable())
+	_, err = store.MarkUndone(ctx, scaled.ID)
+	assert.Error(t, err)
+
+	history, err = store.List(ctx, Filter{Kind: "deployment"})
+	require.NoError(t, err)
+	assert.Empty(t, history)
+	history, err = store.List(ctx, Filter{Kind: "deployment", IncludeUndone: true})
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestMemoryStoreBoundsHistory(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	for i := 0; i < maxChangesPerTenant+10; i++ {
+		_, err := store.Record(ctx, Change{Kind: "pod", Name: "p", Prior: PriorAbsent})
+		require.NoError(t, err)
+	}
+
+	history, err := store.List(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, history, maxChangesPerTenant)
+	assert.Equal(t, maxChangesPerTenant+10, history[0].ID)
+}
+
+func TestFileStorePersistsHistory(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "changes.json")
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+	first, err := store.Record(ctx, Change{Kind: KindHelmRelease, Name: "web", Prior: PriorHelmRevision, Revision: 4})
+	require.NoError(t, err)
+	_, err = store.Record(ctx, Change{Kind: "pod", Name: "p", Prior: PriorAbsent})
+	require.NoError(t, err)
+	_, err = store.MarkUndone(ctx, first.ID)
+	require.NoError(t, err)
+
+	reloaded, err := NewFileStore(path)
+	require.NoError(t, err)
+	history, err := reloaded.List(ctx, Filter{IncludeUndone: true})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, 4, history[1].Revision)
+	assert.NotNil(t, history[1].UndoneAt)
+
+	next, err := reloaded.Record(ctx, Change{Kind: "pod", Name: "q", Prior: PriorAbsent})
+	require.NoError(t, err)
+	assert.Equal(t, 3, next.ID)
+}
+
+func TestCleanManifest(t *testing.T) {
+	object := map[string]interface{}{
+		"kind": "Deployment",
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"resourceVersion": "42",
+			"uid":             "abc",
+			"annotations":     map[string]interface{}{"deployment.kubernetes.io/revision": "3"},
+		},
+		"status": map[string]interface{}{"replicas": 2},
+	}
+
+	CleanManifest(object)
+	assert.Equal(t, map[string]interface{}{
+		"kind":     "Deployment",
+		"metadata": map[string]interface{}{"name": "web"},
+	}, object)
+}
@@ -0,0 +1,306 @@
+// Package cliout normalizes the output of kubectl, helm, istioctl and cilium
+// across client versions. Columns are added, renamed and reordered between
+// releases, and some versions print warnings around their JSON, so tools parse
+// CLI output through this package rather than by field position.
+package cliout
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	// versionPattern extracts a version such as v1.29.2-gke.1, 1.22.0 or 3.15
+	versionPattern = regexp.MustCompile(`v?\d+\.\d+(?:\.\d+)?(?:[-+][0-9A-Za-z.+-]*)?`)
+	// ciliumRunningPattern matches the running agent line of cilium version, e.g. "cilium image (running): 1.15.1"
+	ciliumRunningPattern = regexp.MustCompile(`cilium image \(running\):\s*(\S+)`)
+	// ciliumCLIPattern matches the client line of cilium version, e.g. "cilium-cli: v0.16.4 compiled with go1.22.1"
+	ciliumCLIPattern = regexp.MustCompile(`cilium-cli:\s*(\S+)`)
+)
+
+// ExtractJSON returns the JSON object in output, dropping warnings and errors
+// printed before or after it, such as the deprecation notices of older
+// kubectl releases or an unreachable API server
+func ExtractJSON(output string) (string, bool) {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return "", false
+	}
+	candidate := output[start : end+1]
+	if !json.Valid([]byte(candidate)) {
+		return "", false
+	}
+	return candidate, true
+}
+
+// FindVersion returns the first version in s, or an empty string
+func FindVersion(s string) string {
+	return versionPattern.FindString(s)
+}
+
+// APIResource is a resource type served by the API server, as listed by kubectl api-resources
+type APIResource struct {
+	Name       string   `json:"name"`
+	ShortNames []string `json:"short_names,omitempty"`
+	APIVersion string   `json:"api_version"`
+	Namespaced bool     `json:"namespaced"`
+	Kind       string   `json:"kind"`
+}
+
+// apiResourceColumns are the headers of the api-resources columns that are parsed
+var apiResourceColumns = []string{"NAME", "SHORTNAMES", "APIVERSION", "NAMESPACED", "KIND"}
+
+// ParseAPIResources parses the table printed by kubectl api-resources, with or
+// without -o wide. Columns are located by their header, as their widths depend
+// on the resources and later releases add VERBS and CATEGORIES columns.
+func ParseAPIResources(output string) ([]APIResource, error) {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	headerIndex := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "NAME ") {
+			headerIndex = i
+			break
+		}
+	}
+	if headerIndex < 0 {
+		return nil, fmt.Errorf("no api-resources header found")
+	}
+
+	columns := tableColumns(lines[headerIndex])
+	for _, name := range apiResourceColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("api-resources output has no %s column", name)
+		}
+	}
+
+	resources := []APIResource{}
+	for _, line := range lines[headerIndex+1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cell := func(name string) string {
+			return columns[name].value(line)
+		}
+		resource := APIResource{
+			Name:       cell("NAME"),
+			APIVersion: cell("APIVERSION"),
+			Namespaced: cell("NAMESPACED") == "true",
+			Kind:       cell("KIND"),
+		}
+		if shortNames := cell("SHORTNAMES"); shortNames != "" {
+			resource.ShortNames = strings.Split(shortNames, ",")
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// tableColumn is the span of a column in a table aligned by tabwriter; end is
+// -1 for the last column
+type tableColumn struct {
+	name       string
+	start, end int
+}
+
+// value returns the trimmed cell of the column in a row
+func (c tableColumn) value(line string) string {
+	if c.start >= len(line) {
+		return ""
+	}
+	if c.end < 0 || c.end > len(line) {
+		return strings.TrimSpace(line[c.start:])
+	}
+	return strings.TrimSpace(line[c.start:c.end])
+}
+
+// headerPattern matches the column names of a table header. Names may hold
+// single spaces, such as VIRTUAL SERVICE, as tabwriter pads columns with more.
+var headerPattern = regexp.MustCompile(`\S+(?: \S+)*`)
+
+// headerColumns returns the span of each column of a table from its header,
+// in order. Cells start at their header's offset, as tabwriter pads every
+// column, so empty cells do not shift the cells after them.
+func headerColumns(header string) []tableColumn {
+	var columns []tableColumn
+	for _, span := range headerPattern.FindAllStringIndex(header, -1) {
+		if len(columns) > 0 {
+			columns[len(columns)-1].end = span[0]
+		}
+		columns = append(columns, tableColumn{name: header[span[0]:span[1]], start: span[0], end: -1})
+	}
+	return columns
+}
+
+// tableColumns returns the span of each column of a table by name
+func tableColumns(header string) map[string]tableColumn {
+	columns := map[string]tableColumn{}
+	for _, column := range headerColumns(header) {
+		columns[column.name] = column
+	}
+	return columns
+}
+
+// ParseTables parses output holding tables aligned by tabwriter, each with
+// its own header and separated from the next by a blank line, such as that
+// of istioctl proxy-config all. Each row maps the headers of its non-empty
+// cells to their values.
+func ParseTables(output string) [][]map[string]string {
+	var tables [][]map[string]string
+	var columns []tableColumn
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			columns = nil
+			continue
+		}
+		if columns == nil {
+			columns = headerColumns(line)
+			tables = append(tables, []map[string]string{})
+			continue
+		}
+
+		row := make(map[string]string, len(columns))
+		for _, column := range columns {
+			if value := column.value(line); value != "" {
+				row[column.name] = value
+			}
+		}
+		tables[len(tables)-1] = append(tables[len(tables)-1], row)
+	}
+	return tables
+}
+
+// FormatAPIResources renders resources as the api-resources table of current
+// kubectl releases, whatever release listed them
+func FormatAPIResources(resources []APIResource) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, strings.Join(apiResourceColumns, "\t"))
+	for _, r := range resources {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", r.Name, strings.Join(r.ShortNames, ","), r.APIVersion, r.Namespaced, r.Kind)
+	}
+	_ = w.Flush()
+	return b.String()
+}
+
+// KubectlVersion is the client and API server version reported by kubectl version -o json
+type KubectlVersion struct {
+	Client string `json:"client,omitempty"`
+	// Server is empty when the API server was unreachable
+	Server string `json:"server,omitempty"`
+}
+
+// kubectlVersionInfo is a version block of kubectl version -o json
+type kubectlVersionInfo struct {
+	Major      string `json:"major"`
+	Minor      string `json:"minor"`
+	GitVersion string `json:"gitVersion"`
+}
+
+// version returns the git version, or major.minor for builds that leave it
+// empty. Managed clusters report minors such as "29+".
+func (v *kubectlVersionInfo) version() string {
+	if v == nil {
+		return ""
+	}
+	if v.GitVersion != "" {
+		return v.GitVersion
+	}
+	if v.Major == "" || v.Minor == "" {
+		return ""
+	}
+	return "v" + v.Major + "." + strings.TrimSuffix(v.Minor, "+")
+}
+
+// ParseKubectlVersion parses kubectl version -o json
+func ParseKubectlVersion(output string) (KubectlVersion, error) {
+	document, ok := ExtractJSON(output)
+	if !ok {
+		return KubectlVersion{}, fmt.Errorf("no JSON in kubectl version output")
+	}
+	var raw struct {
+		ClientVersion *kubectlVersionInfo `json:"clientVersion"`
+		ServerVersion *kubectlVersionInfo `json:"serverVersion"`
+	}
+	if err := json.Unmarshal([]byte(document), &raw); err != nil {
+		return KubectlVersion{}, fmt.Errorf("failed to parse kubectl version: %w", err)
+	}
+	return KubectlVersion{Client: raw.ClientVersion.version(), Server: raw.ServerVersion.version()}, nil
+}
+
+// IstioVersion is the client and control plane version reported by istioctl version -o json
+type IstioVersion struct {
+	Client string `json:"client,omitempty"`
+	// ControlPlane is empty when no control plane was found
+	ControlPlane string `json:"control_plane,omitempty"`
+}
+
+// ParseIstioVersion parses istioctl version -o json. Older releases report
+// the control plane as pilot and newer ones as istiod.
+func ParseIstioVersion(output string) (IstioVersion, error) {
+	document, ok := ExtractJSON(output)
+	if !ok {
+		return IstioVersion{}, fmt.Errorf("no JSON in istioctl version output")
+	}
+	var raw struct {
+		ClientVersion struct {
+			Version string `json:"version"`
+		} `json:"clientVersion"`
+		MeshVersion []struct {
+			Component string `json:"Component"`
+			Info      struct {
+				Version string `json:"version"`
+			} `json:"Info"`
+		} `json:"meshVersion"`
+	}
+	if err := json.Unmarshal([]byte(document), &raw); err != nil {
+		return IstioVersion{}, fmt.Errorf("failed to parse istioctl version: %w", err)
+	}
+	version := IstioVersion{Client: raw.ClientVersion.Version}
+	for _, component := range raw.MeshVersion {
+		if component.Component == "pilot" || component.Component == "istiod" {
+			version.ControlPlane = component.Info.Version
+			break
+		}
+	}
+	return version, nil
+}
+
+// ParseHelmVersion parses helm version --short, as printed by Helm 3
+// ("v3.15.2+g1a500d5") or Helm 2 ("Client: v2.17.0+ga690bad")
+func ParseHelmVersion(output string) string {
+	return FindVersion(output)
+}
+
+// CiliumVersion is the CLI and running agent version reported by cilium version
+type CiliumVersion struct {
+	CLI string `json:"cli,omitempty"`
+	// Running is empty when no agent is running
+	Running string `json:"running,omitempty"`
+}
+
+// ParseCiliumVersion parses cilium version. Older cilium-cli releases print
+// the running version with a v prefix and newer ones without, so versions are
+// normalized to the v prefix.
+func ParseCiliumVersion(output string) CiliumVersion {
+	var version CiliumVersion
+	if match := ciliumCLIPattern.FindStringSubmatch(output); match != nil {
+		version.CLI = withVPrefix(FindVersion(match[1]))
+	}
+	if match := ciliumRunningPattern.FindStringSubmatch(output); match != nil {
+		version.Running = withVPrefix(FindVersion(match[1]))
+	}
+	return version
+}
+
+// withVPrefix adds a v prefix to a non-empty version
+func withVPrefix(version string) string {
+	if version == "" || strings.HasPrefix(version, "v") {
+		return version
+	}
+	return "v" + version
+}
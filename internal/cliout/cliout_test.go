@@ -0,0 +1,105 @@
+package cliout
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// update rewrites the golden files from the current parsers: go test ./internal/cliout -update
+var update = flag.Bool("update", false, "update golden files")
+
+// normalizers parse the CLI outputs of each testdata directory
+var normalizers = map[string]func(output string) (interface{}, error){
+	"api-resources": func(output string) (interface{}, error) {
+		return ParseAPIResources(output)
+	},
+	"kubectl-version": func(output string) (interface{}, error) {
+		return ParseKubectlVersion(output)
+	},
+	"istioctl-version": func(output string) (interface{}, error) {
+		return ParseIstioVersion(output)
+	},
+	"helm-version": func(output string) (interface{}, error) {
+		return ParseHelmVersion(output), nil
+	},
+	"cilium-version": func(output string) (interface{}, error) {
+		return ParseCiliumVersion(output), nil
+	},
+	"tables": func(output string) (interface{}, error) {
+		return ParseTables(output), nil
+	},
+}
+
+// TestGolden normalizes the output of each supported CLI version in testdata
+// and compares it with its .golden file
+func TestGolden(t *testing.T) {
+	for dir, normalize := range normalizers {
+		inputs, err := filepath.Glob(filepath.Join("testdata", dir, "*.txt"))
+		require.NoError(t, err)
+		require.NotEmpty(t, inputs, dir)
+
+		for _, input := range inputs {
+			t.Run(filepath.Join(dir, filepath.Base(input)), func(t *testing.T) {
+				output, err := os.ReadFile(input)
+				require.NoError(t, err)
+				normalized, err := normalize(string(output))
+				require.NoError(t, err)
+				actual, err := json.MarshalIndent(normalized, "", "  ")
+				require.NoError(t, err)
+				actual = append(actual, '\n')
+
+				golden := strings.TrimSuffix(input, ".txt") + ".golden"
+				if *update {
+					require.NoError(t, os.WriteFile(golden, actual, 0o644))
+				}
+				expected, err := os.ReadFile(golden)
+				require.NoError(t, err, "run go test -update to create the golden file")
+				assert.Equal(t, string(expected), string(actual))
+			})
+		}
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	document, ok := ExtractJSON("WARNING: deprecated\n{\"a\":{\"b\":1}}\nerror: refused\n")
+	require.True(t, ok)
+	assert.Equal(t, `{"a":{"b":1}}`, document)
+
+	_, ok = ExtractJSON("error: unknown flag -o")
+	assert.False(t, ok)
+	_, ok = ExtractJSON("{not json}")
+	assert.False(t, ok)
+}
+
+func TestParseAPIResourcesErrors(t *testing.T) {
+	_, err := ParseAPIResources("pods   po   v1   true   Pod\n")
+	assert.Error(t, err)
+	_, err = ParseAPIResources("NAME   SHORTNAMES   APIGROUP   NAMESPACED   KIND\npods   po      true   Pod\n")
+	assert.ErrorContains(t, err, "APIVERSION")
+}
+
+func TestFormatAPIResourcesRoundTrip(t *testing.T) {
+	output, err := os.ReadFile(filepath.Join("testdata", "api-resources", "kubectl-1.31-wide.txt"))
+	require.NoError(t, err)
+	resources, err := ParseAPIResources(string(output))
+	require.NoError(t, err)
+
+	formatted := FormatAPIResources(resources)
+	assert.True(t, strings.HasPrefix(formatted, "NAME   "))
+	assert.NotContains(t, formatted, "VERBS")
+	reparsed, err := ParseAPIResources(formatted)
+	require.NoError(t, err)
+	assert.Equal(t, resources, reparsed)
+}
+
+func TestParseKubectlVersionWithoutJSON(t *testing.T) {
+	_, err := ParseKubectlVersion("error: unknown shorthand flag: 'o' in -o")
+	assert.Error(t, err)
+}
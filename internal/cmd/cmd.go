@@ -1,18 +1,55 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/kagent-dev/tools/internal/logger"
 )
 
+// waitDelay bounds how long Exec waits for output pipes after the context ends
+const waitDelay = 2 * time.Second
+
 // ShellExecutor defines the interface for executing shell commands
 type ShellExecutor interface {
 	Exec(ctx context.Context, command string, args ...string) (output []byte, err error)
 }
 
+// maxStreamStderrBytes caps the stderr kept by ExecStream for error messages
+const maxStreamStderrBytes = 64 * 1024
+
+// StreamExecutor is implemented by executors that can stream a command's
+// stdout instead of buffering it
+type StreamExecutor interface {
+	// ExecStream runs a command, copying its stdout to stdout as it is
+	// produced. The command blocks while stdout does not accept more output.
+	ExecStream(ctx context.Context, stdout io.Writer, command string, args ...string) error
+}
+
+// ExecStream runs a command with the executor of ctx, copying its stdout to
+// stdout. Executors that cannot stream, such as the mock, have their output
+// written to stdout once the command finished.
+func ExecStream(ctx context.Context, stdout io.Writer, command string, args ...string) error {
+	executor := GetShellExecutor(ctx)
+	if streamer, ok := executor.(StreamExecutor); ok {
+		return streamer.ExecStream(ctx, stdout, command, args...)
+	}
+	output, err := executor.Exec(ctx, command, args...)
+	if err != nil {
+		if len(output) > 0 {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+		}
+		return err
+	}
+	_, err = stdout.Write(output)
+	return err
+}
+
 // DefaultShellExecutor implements ShellExecutor using os/exec
 type DefaultShellExecutor struct{}
 
@@ -27,6 +64,9 @@ func (e *DefaultShellExecutor) Exec(ctx context.Context, command string, args ..
 	)
 
 	cmd := exec.CommandContext(ctx, command, args...)
+	killProcessGroupOnCancel(cmd)
+	// Stop waiting for output held open by orphaned children once the process is killed
+	cmd.WaitDelay = waitDelay
 	output, err := cmd.CombinedOutput()
 
 	duration := time.Since(startTime)
@@ -50,6 +90,62 @@ func (e *DefaultShellExecutor) Exec(ctx context.Context, command string, args ..
 	return output, err
 }
 
+// ExecStream executes a command using os/exec.CommandContext, copying its
+// stdout to stdout. Stderr is kept, up to a cap, for the error of a failed command.
+func (e *DefaultShellExecutor) ExecStream(ctx context.Context, stdout io.Writer, command string, args ...string) error {
+	log := logger.WithContext(ctx)
+	startTime := time.Now()
+
+	log.Info("streaming command",
+		"command", command,
+		"args", args,
+	)
+
+	stderr := &cappedBuffer{limit: maxStreamStderrBytes}
+	cmd := exec.CommandContext(ctx, command, args...)
+	killProcessGroupOnCancel(cmd)
+	cmd.WaitDelay = waitDelay
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+
+	duration := time.Since(startTime)
+
+	if err != nil {
+		log.Error("command streaming failed",
+			"command", command,
+			"args", args,
+			"error", err,
+			"stderr", stderr.String(),
+			"duration", duration.Seconds(),
+		)
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("%w: %s", err, message)
+		}
+		return err
+	}
+
+	log.Info("command streaming successful",
+		"command", command,
+		"args", args,
+		"duration", duration.Seconds(),
+	)
+	return nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it, discarding the rest
+type cappedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
 // Context key for shell executor injection
 type contextKey string
 
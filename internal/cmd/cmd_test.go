@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -56,3 +59,59 @@ func TestContextShellExecutor(t *testing.T) {
 		assert.Equal(t, mock, executor, "should return the mock executor from context")
 	})
 }
+
+func TestDefaultShellExecutorCancel(t *testing.T) {
+	executor := &DefaultShellExecutor{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// The background child holds the output pipe open, so only killing the process group returns promptly
+	start := time.Now()
+	_, err := executor.Exec(ctx, "sh", "-c", "sleep 5 & wait")
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), waitDelay)
+}
+
+func TestExecStream(t *testing.T) {
+	t.Run("default executor streams stdout", func(t *testing.T) {
+		var stdout strings.Builder
+		err := ExecStream(context.Background(), &stdout, "sh", "-c", "echo out; echo warning >&2")
+		assert.NoError(t, err)
+		assert.Equal(t, "out\n", stdout.String())
+	})
+
+	t.Run("default executor reports stderr of failed commands", func(t *testing.T) {
+		var stdout strings.Builder
+		err := ExecStream(context.Background(), &stdout, "sh", "-c", "echo partial; echo denied >&2; exit 3")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "exit status 3: denied")
+		assert.Equal(t, "partial\n", stdout.String())
+	})
+
+	t.Run("default executor stops when stdout fails", func(t *testing.T) {
+		err := ExecStream(context.Background(), failingWriter{}, "sh", "-c", "yes")
+		assert.Error(t, err)
+	})
+
+	t.Run("mock executor falls back to exec", func(t *testing.T) {
+		mock := NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pods"}, "pod-1", nil)
+		mock.AddCommandString("kubectl", []string{"get", "nodes"}, "forbidden", errors.New("exit status 1"))
+		ctx := WithShellExecutor(context.Background(), mock)
+
+		var stdout strings.Builder
+		assert.NoError(t, ExecStream(ctx, &stdout, "kubectl", "get", "pods"))
+		assert.Equal(t, "pod-1", stdout.String())
+
+		err := ExecStream(ctx, &stdout, "kubectl", "get", "nodes")
+		assert.EqualError(t, err, "exit status 1: forbidden")
+	})
+}
+
+// failingWriter rejects every write, like a full blob store
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("store full")
+}
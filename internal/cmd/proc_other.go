@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cmd
+
+import "os/exec"
+
+// killProcessGroupOnCancel keeps the default behaviour of killing only the command itself
+func killProcessGroupOnCancel(cmd *exec.Cmd) {}
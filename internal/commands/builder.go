@@ -3,9 +3,11 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
+	"github.com/kagent-dev/tools/internal/budget"
 	"github.com/kagent-dev/tools/internal/cache"
 	"github.com/kagent-dev/tools/internal/cmd"
 	"github.com/kagent-dev/tools/internal/errors"
@@ -20,6 +22,8 @@ const (
 	DefaultTimeout = 2 * time.Minute
 	// DefaultCacheTTL is the default cache TTL
 	DefaultCacheTTL = 1 * time.Minute
+	// timeoutGracePeriod lets a CLI report its own --timeout before the process is killed
+	timeoutGracePeriod = 10 * time.Second
 )
 
 // CommandBuilder provides a fluent interface for building CLI commands
@@ -111,11 +115,12 @@ func (cb *CommandBuilder) WithContext(context string) *CommandBuilder {
 // WithKubeconfig sets the kubeconfig file
 func (cb *CommandBuilder) WithKubeconfig(kubeconfig string) *CommandBuilder {
 	if kubeconfig != "" {
-		if err := security.ValidateFilePath(kubeconfig); err != nil {
+		path, err := resolveKubeconfig(kubeconfig)
+		if err != nil {
 			logger.Get().Error("Invalid kubeconfig path", "kubeconfig", kubeconfig, "error", err)
 			return cb
 		}
-		cb.kubeconfig = kubeconfig
+		cb.kubeconfig = path
 	}
 	return cb
 }
@@ -176,6 +181,15 @@ func (cb *CommandBuilder) WithTimeout(timeout time.Duration) *CommandBuilder {
 	return cb
 }
 
+// executionContext bounds ctx, whose deadline is the tool call's requested
+// timeout, by the CLI timeout plus a grace period when one is set
+func (cb *CommandBuilder) executionContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if cb.useTimeout && cb.timeout > 0 {
+		return context.WithTimeout(ctx, cb.timeout+timeoutGracePeriod)
+	}
+	return ctx, func() {}
+}
+
 // WithDryRun enables dry run mode
 func (cb *CommandBuilder) WithDryRun(dryRun bool) *CommandBuilder {
 	cb.dryRun = dryRun
@@ -311,11 +325,19 @@ func (cb *CommandBuilder) Execute(ctx context.Context) (string, error) {
 	span.SetAttributes(
 		attribute.String("built_command", command),
 		attribute.StringSlice("built_args", args),
+		attribute.String("command_line", CommandLine(command, args...)),
 	)
 
+	ctx, cancel := cb.executionContext(ctx)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); ok {
+		span.SetAttributes(attribute.String("deadline", deadline.Format(time.RFC3339Nano)))
+	}
+
 	log.Debug("executing command",
 		"command", command,
 		"args", args,
+		"command_line", CommandLine(command, args...),
 		"cached", cb.cached,
 	)
 
@@ -408,29 +430,117 @@ func (cb *CommandBuilder) executeWithCache(ctx context.Context, command string,
 
 // executeCommand executes the actual command
 func (cb *CommandBuilder) executeCommand(ctx context.Context, command string, args []string) (string, error) {
+	if err := useBudget(ctx, command); err != nil {
+		return "", err
+	}
 	executor := cmd.GetShellExecutor(ctx)
 	output, err := executor.Exec(ctx, command, args...)
 	if err != nil {
-		// Create appropriate error based on command type
-		var toolError *errors.ToolError
-		switch command {
-		case "kubectl":
-			toolError = errors.NewKubernetesError(strings.Join(args, " "), err)
-		case "helm":
-			toolError = errors.NewHelmError(strings.Join(args, " "), err)
-		case "istioctl":
-			toolError = errors.NewIstioError(strings.Join(args, " "), err)
-		case "cilium":
-			toolError = errors.NewCiliumError(strings.Join(args, " "), err)
-		default:
-			toolError = errors.NewCommandError(command, err)
-		}
-		return string(output), toolError
+		return string(output), commandError(ctx, command, args, err)
 	}
 
 	return string(output), nil
 }
 
+// Stream runs the command, copying its output to w as it is produced instead
+// of buffering it, for commands whose output may be too large to hold in
+// memory. Results are never cached.
+func (cb *CommandBuilder) Stream(ctx context.Context, w io.Writer) error {
+	log := logger.WithContext(ctx)
+	_, span := telemetry.StartSpan(ctx, "commands.stream",
+		attribute.String("command", cb.command),
+		attribute.StringSlice("args", cb.args),
+	)
+	defer span.End()
+
+	command, args, err := cb.Build()
+	if err != nil {
+		telemetry.RecordError(span, err, "Command build failed")
+		log.Error("failed to build command",
+			"command", cb.command,
+			"error", err,
+		)
+		return err
+	}
+
+	span.SetAttributes(
+		attribute.String("built_command", command),
+		attribute.StringSlice("built_args", args),
+		attribute.String("command_line", CommandLine(command, args...)),
+	)
+
+	ctx, cancel := cb.executionContext(ctx)
+	defer cancel()
+
+	if err := useBudget(ctx, command); err != nil {
+		telemetry.RecordError(span, err, "Session budget exceeded")
+		return err
+	}
+
+	counter := &countingWriter{w: w}
+	if err := cmd.ExecStream(ctx, counter, command, args...); err != nil {
+		err = commandError(ctx, command, args, err)
+		telemetry.RecordError(span, err, "Command streaming failed")
+		return err
+	}
+
+	telemetry.RecordSuccess(span, "Command streamed successfully")
+	span.SetAttributes(attribute.Int64("result_length", counter.n))
+	return nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// useBudget counts a kubectl invocation against the budget of the calling
+// session, refusing it once the session used up its kubectl calls
+func useBudget(ctx context.Context, command string) error {
+	if commandName(command) != "kubectl" {
+		return nil
+	}
+	if err := budget.UseKubectl(ctx); err != nil {
+		return errors.NewBudgetError("run kubectl", err)
+	}
+	return nil
+}
+
+// commandError wraps the error of a failed command in a ToolError for its CLI
+func commandError(ctx context.Context, command string, args []string, err error) error {
+	// The process was killed because the request was cancelled or ran out of time
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		code := "COMMAND_CANCELLED"
+		if ctxErr == context.DeadlineExceeded {
+			code = "COMMAND_DEADLINE_EXCEEDED"
+		}
+		return errors.NewCommandError(command, fmt.Errorf("%w: %v", ctxErr, err)).
+			WithErrorCode(code).
+			WithRetryable(ctxErr == context.DeadlineExceeded)
+	}
+
+	// Create appropriate error based on command type
+	switch commandName(command) {
+	case "kubectl":
+		return errors.NewKubernetesError(strings.Join(args, " "), err)
+	case "helm":
+		return errors.NewHelmError(strings.Join(args, " "), err)
+	case "istioctl":
+		return errors.NewIstioError(strings.Join(args, " "), err)
+	case "cilium":
+		return errors.NewCiliumError(strings.Join(args, " "), err)
+	default:
+		return errors.NewCommandError(command, err)
+	}
+}
+
 // Common command patterns as helper functions
 
 // GetPods creates a command to get pods
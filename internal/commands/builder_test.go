@@ -1,11 +1,20 @@
 package commands
 
 import (
+	"context"
+	stderrors "errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/budget"
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/kagent-dev/tools/internal/errors"
 )
 
 func TestNewCommandBuilder(t *testing.T) {
@@ -579,3 +588,130 @@ func TestCommandBuilderExecuteWithCache(t *testing.T) {
 	assert.Contains(t, args, "world")
 	assert.True(t, cb.cached)
 }
+
+// deadlineExecutor records the deadline of the context commands run with
+type deadlineExecutor struct {
+	deadline    time.Time
+	hasDeadline bool
+	err         error
+}
+
+func (e *deadlineExecutor) Exec(ctx context.Context, command string, args ...string) ([]byte, error) {
+	e.deadline, e.hasDeadline = ctx.Deadline()
+	return nil, e.err
+}
+
+func TestCommandBuilderRequestDeadline(t *testing.T) {
+	deadline := time.Now().Add(30 * time.Second)
+	executor := &deadlineExecutor{}
+	ctx := cmd.WithShellExecutor(context.Background(), executor)
+
+	requestCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	_, err := NewCommandBuilder("kubectl").WithArgs("get", "pods").Execute(requestCtx)
+	require.NoError(t, err)
+	assert.True(t, executor.hasDeadline)
+	assert.WithinDuration(t, deadline, executor.deadline, time.Millisecond)
+
+	// An earlier request deadline wins over the CLI timeout
+	_, err = NewCommandBuilder("kubectl").WithArgs("get", "pods").WithTimeout(time.Minute).Execute(requestCtx)
+	require.NoError(t, err)
+	assert.WithinDuration(t, deadline, executor.deadline, time.Millisecond)
+}
+
+func TestCommandBuilderTimeoutBoundsExecution(t *testing.T) {
+	executor := &deadlineExecutor{}
+	ctx := cmd.WithShellExecutor(context.Background(), executor)
+
+	_, err := NewCommandBuilder("kubectl").WithArgs("get", "pods").WithTimeout(5 * time.Second).Execute(ctx)
+	require.NoError(t, err)
+	assert.True(t, executor.hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(5*time.Second+timeoutGracePeriod), executor.deadline, time.Second)
+
+	_, err = NewCommandBuilder("kubectl").WithArgs("get", "pods").Execute(ctx)
+	require.NoError(t, err)
+	assert.False(t, executor.hasDeadline)
+}
+
+func TestCommandBuilderCancelledExecution(t *testing.T) {
+	executor := &deadlineExecutor{err: stderrors.New("signal: killed")}
+	ctx := cmd.WithShellExecutor(context.Background(), executor)
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := NewCommandBuilder("kubectl").WithArgs("get", "pods").Execute(cancelledCtx)
+	var toolErr *errors.ToolError
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, "COMMAND_CANCELLED", toolErr.ErrorCode)
+	assert.False(t, toolErr.IsRetryable)
+	assert.ErrorIs(t, toolErr.Cause, context.Canceled)
+
+	expiredCtx, cancel := context.WithDeadline(ctx, time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = NewCommandBuilder("kubectl").WithArgs("get", "pods").Execute(expiredCtx)
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, "COMMAND_DEADLINE_EXCEEDED", toolErr.ErrorCode)
+	assert.True(t, toolErr.IsRetryable)
+}
+
+func TestCommandBuilderStream(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pods", "-A", "-o", "json"}, `{"items":[]}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "secrets", "-A"}, "forbidden", stderrors.New("exit status 1"))
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	var output strings.Builder
+	err := NewCommandBuilder("kubectl").WithArgs("get", "pods", "-A", "-o", "json").Stream(ctx, &output)
+	require.NoError(t, err)
+	assert.Equal(t, `{"items":[]}`, output.String())
+
+	err = NewCommandBuilder("kubectl").WithArgs("get", "secrets", "-A").Stream(ctx, &output)
+	var toolErr *errors.ToolError
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, "Kubernetes", toolErr.Component)
+	assert.Contains(t, toolErr.Cause.Error(), "forbidden")
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = NewCommandBuilder("kubectl").WithArgs("get", "secrets", "-A").Stream(cancelledCtx, &output)
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, "COMMAND_CANCELLED", toolErr.ErrorCode)
+}
+
+// testSession is a client session metered by the session budget
+type testSession string
+
+func (s testSession) Initialize()       {}
+func (s testSession) Initialized() bool { return true }
+func (s testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return make(chan mcp.JSONRPCNotification, 1)
+}
+func (s testSession) SessionID() string { return string(s) }
+
+func TestCommandBuilderKubectlBudget(t *testing.T) {
+	t.Setenv(budget.SessionMaxKubectlCalls, "1")
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pods"}, "web-1", nil)
+	mock.AddCommandString("helm", []string{"list"}, "web", nil)
+	session := testSession("kubectl-budget")
+	ctx := server.NewMCPServer("test", "1.0").WithContext(cmd.WithShellExecutor(context.Background(), mock), session)
+	defer budget.ForgetSession(ctx, session)
+
+	_, err := NewCommandBuilder("kubectl").WithArgs("get", "pods").Execute(ctx)
+	require.NoError(t, err)
+
+	_, err = NewCommandBuilder("kubectl").WithArgs("get", "pods").Execute(ctx)
+	var toolErr *errors.ToolError
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, "BUDGET_EXCEEDED", toolErr.ErrorCode)
+	assert.ErrorIs(t, toolErr.Cause, budget.ErrExceeded)
+	err = NewCommandBuilder("kubectl").WithArgs("get", "pods").Stream(ctx, &strings.Builder{})
+	require.ErrorAs(t, err, &toolErr)
+	assert.Len(t, mock.GetCallLog(), 1)
+
+	// Other CLIs and calls outside the session are not limited
+	_, err = NewCommandBuilder("helm").WithArgs("list").Execute(ctx)
+	require.NoError(t, err)
+	_, err = NewCommandBuilder("kubectl").WithArgs("get", "pods").Execute(budget.Detach(ctx))
+	require.NoError(t, err)
+}
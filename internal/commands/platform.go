@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// goos is the platform commands run on; tests override it to exercise Windows handling
+var goos = runtime.GOOS
+
+var (
+	// windowsEnvPattern matches %VAR% references such as %USERPROFILE%
+	windowsEnvPattern = regexp.MustCompile(`%([a-zA-Z_][a-zA-Z0-9_()]*)%`)
+
+	// posixSafeArgPattern matches arguments a POSIX shell reads literally
+	posixSafeArgPattern = regexp.MustCompile(`^[a-zA-Z0-9._/:=,@%+-]+$`)
+)
+
+// ExpandPath expands a leading ~ to the home directory and %VAR% references
+// to environment variables. Unset variables are left as they are.
+func ExpandPath(path string) string {
+	path = windowsEnvPattern.ReplaceAllStringFunc(path, func(ref string) string {
+		if value, ok := os.LookupEnv(ref[1 : len(ref)-1]); ok {
+			return value
+		}
+		return ref
+	})
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = home + path[1:]
+		}
+	}
+	return path
+}
+
+// resolveKubeconfig expands and validates a kubeconfig path. Windows paths may
+// use a drive letter and backslashes, and are passed on with forward slashes,
+// which kubectl and helm accept on every platform.
+func resolveKubeconfig(kubeconfig string) (string, error) {
+	path := ExpandPath(kubeconfig)
+	if goos != "windows" {
+		return path, security.ValidateFilePath(path)
+	}
+	if err := security.ValidateWindowsFilePath(path); err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(path, `\`, "/"), nil
+}
+
+// commandName returns a command without its Windows .exe suffix, so kubectl.exe
+// is handled as kubectl
+func commandName(command string) string {
+	if len(command) > 4 && strings.EqualFold(command[len(command)-4:], ".exe") {
+		return command[:len(command)-4]
+	}
+	return command
+}
+
+// CommandLine renders a command and its arguments quoted the way the
+// platform's shell parses them, so a logged command can be run again as is
+func CommandLine(command string, args ...string) string {
+	quote := quotePOSIXArg
+	if goos == "windows" {
+		quote = quoteWindowsArg
+	}
+	parts := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{command}, args...) {
+		parts = append(parts, quote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quotePOSIXArg single-quotes an argument unless a POSIX shell reads it literally
+func quotePOSIXArg(arg string) string {
+	if posixSafeArgPattern.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// quoteWindowsArg quotes an argument following the rules of CommandLineToArgvW,
+// as os/exec does when starting a process on Windows: backslashes are literal
+// unless they precede a double quote
+func quoteWindowsArg(arg string) string {
+	if arg == "" {
+		return `""`
+	}
+	if !strings.ContainsAny(arg, " \t\"") {
+		return arg
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for i := 0; i < len(arg); i++ {
+		switch c := arg[i]; c {
+		case '\\':
+			slashes++
+		case '"':
+			// Double the backslashes before a quote and escape the quote itself
+			b.WriteString(strings.Repeat(`\`, slashes+1))
+			slashes = 0
+		default:
+			slashes = 0
+		}
+		b.WriteByte(arg[i])
+	}
+	// Double trailing backslashes so they do not escape the closing quote
+	b.WriteString(strings.Repeat(`\`, slashes))
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"context"
+	stderrors "errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/kagent-dev/tools/internal/errors"
+)
+
+// withGOOS runs commands as if on another platform for the rest of the test
+func withGOOS(t *testing.T, platform string) {
+	previous := goos
+	goos = platform
+	t.Cleanup(func() { goos = previous })
+}
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", `C:\Users\Jane Doe`)
+
+	assert.Equal(t, `C:\Users\Jane Doe\.kube\config`, ExpandPath(`%USERPROFILE%\.kube\config`))
+	assert.Equal(t, filepath.Join(home, ".kube/config"), ExpandPath("~/.kube/config"))
+	assert.Equal(t, `%KAGENT_UNSET_VAR%\config`, ExpandPath(`%KAGENT_UNSET_VAR%\config`))
+	assert.Equal(t, "/etc/kubeconfig", ExpandPath("/etc/kubeconfig"))
+}
+
+func TestWithKubeconfigOnWindows(t *testing.T) {
+	withGOOS(t, "windows")
+	t.Setenv("USERPROFILE", `C:\Users\Jane Doe`)
+
+	cb := NewCommandBuilder("kubectl").WithKubeconfig(`%USERPROFILE%\.kube\config`)
+	assert.Equal(t, "C:/Users/Jane Doe/.kube/config", cb.kubeconfig)
+
+	cb = NewCommandBuilder("kubectl").WithKubeconfig(`C:\Program Files (x86)\kube\config`)
+	assert.Equal(t, "C:/Program Files (x86)/kube/config", cb.kubeconfig)
+
+	for _, path := range []string{`C:\Users\..\Admin\config`, `C:\config;del`, `\\server\share$\config`} {
+		assert.Empty(t, NewCommandBuilder("kubectl").WithKubeconfig(path).kubeconfig, path)
+	}
+}
+
+func TestWithKubeconfigOnUnix(t *testing.T) {
+	withGOOS(t, "linux")
+	t.Setenv("HOME", "/home/jane")
+
+	assert.Equal(t, "/home/jane/.kube/config", NewCommandBuilder("kubectl").WithKubeconfig("~/.kube/config").kubeconfig)
+	// Windows paths are not valid elsewhere
+	assert.Empty(t, NewCommandBuilder("kubectl").WithKubeconfig(`C:\Users\jane\config`).kubeconfig)
+}
+
+func TestCommandName(t *testing.T) {
+	assert.Equal(t, "kubectl", commandName("kubectl.exe"))
+	assert.Equal(t, "helm", commandName("helm.EXE"))
+	assert.Equal(t, "kubectl", commandName("kubectl"))
+	assert.Equal(t, ".exe", commandName(".exe"))
+}
+
+func TestExecuteClassifiesExeErrors(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl.exe", []string{"get", "pods"}, "", stderrors.New("connection refused"))
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	_, err := NewCommandBuilder("kubectl.exe").WithArgs("get", "pods").Execute(ctx)
+	var toolError *errors.ToolError
+	require.ErrorAs(t, err, &toolError)
+	assert.Equal(t, "K8S_CONNECTION_ERROR", toolError.ErrorCode)
+}
+
+func TestCommandLine(t *testing.T) {
+	withGOOS(t, "linux")
+	assert.Equal(t, `kubectl get pods -l 'app in (web,api)' --kubeconfig /home/jane/.kube/config`,
+		CommandLine("kubectl", "get", "pods", "-l", "app in (web,api)", "--kubeconfig", "/home/jane/.kube/config"))
+	assert.Equal(t, `echo 'it'\''s'`, CommandLine("echo", "it's"))
+
+	withGOOS(t, "windows")
+	assert.Equal(t, `kubectl.exe --kubeconfig "C:\Users\Jane Doe\.kube\config" get pods`,
+		CommandLine("kubectl.exe", "--kubeconfig", `C:\Users\Jane Doe\.kube\config`, "get", "pods"))
+	assert.Equal(t, `kubectl -o jsonpath={.metadata.name} ""`, CommandLine("kubectl", "-o", "jsonpath={.metadata.name}", ""))
+	// Backslashes double only before a quote, including the closing one
+	assert.Equal(t, `x "say \"hi\"" C:\dir\ "C:\my dir\\"`, CommandLine("x", `say "hi"`, `C:\dir\`, `C:\my dir\`))
+}
@@ -0,0 +1,394 @@
+// Package datasources is a registry of named monitoring backends, such as a
+// Prometheus, Loki, Grafana and Alertmanager per environment, so one tool server can serve
+// clusters with separate monitoring stacks. Tools select a datasource by name
+// and the registry provides its URL and an authenticated, pooled round tripper.
+package datasources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/common/config"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// DatasourcesConfig is the environment variable holding the path of the datasources configuration file
+const DatasourcesConfig = "DATASOURCES_CONFIG"
+
+// Datasource types
+const (
+	TypePrometheus   = "prometheus"
+	TypeLoki         = "loki"
+	TypeGrafana      = "grafana"
+	TypeAlertmanager = "alertmanager"
+)
+
+// Types lists the supported datasource types
+var Types = []string{TypePrometheus, TypeLoki, TypeGrafana, TypeAlertmanager}
+
+// DefaultName names the datasource of a type used when a tool is called without one
+const DefaultName = "default"
+
+// defaultURLs are the URLs of the default datasources when no URL is configured
+var defaultURLs = map[string]string{TypePrometheus: "http://localhost:9090"}
+
+// Settings of datasources configured through the environment, read from
+// <TYPE>_<SETTING> for the default datasource of a type and from
+// <TYPE>_<NAME>_<SETTING> for the named datasources listed in <TYPE>_INSTANCES
+const (
+	settingInstances          = "INSTANCES"
+	settingURL                = "URL"
+	settingBearerToken        = "BEARER_TOKEN"
+	settingBearerTokenFile    = "BEARER_TOKEN_FILE"
+	settingAPIKey             = "API_KEY"
+	settingUsername           = "USERNAME"
+	settingPassword           = "PASSWORD"
+	settingCAFile             = "CA_FILE"
+	settingCertFile           = "CERT_FILE"
+	settingKeyFile            = "KEY_FILE"
+	settingServerName         = "SERVER_NAME"
+	settingInsecureSkipVerify = "INSECURE_SKIP_VERIFY"
+)
+
+// TLS configures the TLS connections to a datasource
+type TLS struct {
+	CAFile             string `json:"ca_file,omitempty"`
+	CertFile           string `json:"cert_file,omitempty"`
+	KeyFile            string `json:"key_file,omitempty"`
+	ServerName         string `json:"server_name,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// Datasource is a named monitoring backend
+type Datasource struct {
+	// Name identifies the datasource within its type, e.g. the environment it monitors
+	Name string `json:"name"`
+	// Type is one of prometheus, loki, grafana or alertmanager
+	Type string `json:"type"`
+	URL  string `json:"url"`
+	// Default makes the datasource the one of its type used when tools are called without one
+	Default bool `json:"default,omitempty"`
+	// BearerToken or BearerTokenFile, which is re-read on every request, authenticate
+	// requests; Grafana API keys and service account tokens are bearer tokens
+	BearerToken     string `json:"bearer_token,omitempty"`
+	BearerTokenFile string `json:"bearer_token_file,omitempty"`
+	// Username and Password or PasswordFile authenticate requests with basic auth
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	PasswordFile string `json:"password_file,omitempty"`
+	// Headers are added to every request, e.g. X-Scope-OrgID to select a Loki tenant
+	Headers map[string]string `json:"headers,omitempty"`
+	TLS     TLS               `json:"tls,omitempty"`
+}
+
+// Config is the datasources configuration file
+type Config struct {
+	Datasources []Datasource `json:"datasources"`
+}
+
+// LoadConfig reads a datasources configuration file
+func LoadConfig(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read datasources config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse datasources config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// envPrefix returns the prefix of the environment variables configuring a datasource
+func envPrefix(datasourceType, name string) string {
+	prefix := strings.ToUpper(datasourceType) + "_"
+	if name == DefaultName {
+		return prefix
+	}
+	return prefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+}
+
+// FromEnv reads the datasources configured through the environment: the default
+// datasource of each type with a URL, or a default URL, and the named datasources
+// of <TYPE>_INSTANCES
+func FromEnv() []Datasource {
+	var datasources []Datasource
+	for _, datasourceType := range Types {
+		names := []string{DefaultName}
+		for _, name := range strings.Split(os.Getenv(envPrefix(datasourceType, DefaultName)+settingInstances), ",") {
+			if name = strings.TrimSpace(name); name != "" && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+
+		for _, name := range names {
+			prefix := envPrefix(datasourceType, name)
+			ds := Datasource{
+				Name:            name,
+				Type:            datasourceType,
+				URL:             os.Getenv(prefix + settingURL),
+				BearerToken:     os.Getenv(prefix + settingBearerToken),
+				BearerTokenFile: os.Getenv(prefix + settingBearerTokenFile),
+				Username:        os.Getenv(prefix + settingUsername),
+				Password:        os.Getenv(prefix + settingPassword),
+				TLS: TLS{
+					CAFile:             os.Getenv(prefix + settingCAFile),
+					CertFile:           os.Getenv(prefix + settingCertFile),
+					KeyFile:            os.Getenv(prefix + settingKeyFile),
+					ServerName:         os.Getenv(prefix + settingServerName),
+					InsecureSkipVerify: os.Getenv(prefix+settingInsecureSkipVerify) == "true",
+				},
+			}
+			if ds.BearerToken == "" {
+				ds.BearerToken = os.Getenv(prefix + settingAPIKey)
+			}
+			if name == DefaultName {
+				if ds.URL == "" {
+					ds.URL = defaultURLs[datasourceType]
+				}
+				// Types without a default URL have no default datasource unless configured
+				if ds.URL == "" {
+					continue
+				}
+			}
+			datasources = append(datasources, ds)
+		}
+	}
+	return datasources
+}
+
+// HTTPClientConfig returns the authentication, header and TLS configuration of the datasource
+func (d Datasource) HTTPClientConfig() config.HTTPClientConfig {
+	cfg := config.DefaultHTTPClientConfig
+	if d.BearerToken != "" || d.BearerTokenFile != "" {
+		cfg.Authorization = &config.Authorization{Type: "Bearer", Credentials: config.Secret(d.BearerToken), CredentialsFile: d.BearerTokenFile}
+	}
+	if d.Username != "" {
+		cfg.BasicAuth = &config.BasicAuth{Username: d.Username, Password: config.Secret(d.Password), PasswordFile: d.PasswordFile}
+	}
+	if len(d.Headers) > 0 {
+		cfg.HTTPHeaders = &config.Headers{Headers: map[string]config.Header{}}
+		for name, value := range d.Headers {
+			cfg.HTTPHeaders.Headers[name] = config.Header{Values: []string{value}}
+		}
+	}
+	cfg.TLSConfig = config.TLSConfig{
+		CAFile:             d.TLS.CAFile,
+		CertFile:           d.TLS.CertFile,
+		KeyFile:            d.TLS.KeyFile,
+		ServerName:         d.TLS.ServerName,
+		InsecureSkipVerify: d.TLS.InsecureSkipVerify,
+	}
+	return cfg
+}
+
+// Validate checks the name, type, URL and authentication of the datasource
+func (d Datasource) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !slices.Contains(Types, d.Type) {
+		return fmt.Errorf("type must be one of: %s", strings.Join(Types, ", "))
+	}
+	if d.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if err := security.ValidateURL(d.URL); err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	httpConfig := d.HTTPClientConfig()
+	return httpConfig.Validate()
+}
+
+// Summary describes a datasource without its credentials
+type Summary struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	URL     string `json:"url"`
+	Default bool   `json:"default,omitempty"`
+	Auth    string `json:"auth,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// entry is a registered datasource with its lazily created round tripper
+type entry struct {
+	datasource   Datasource
+	err          error
+	roundTripper http.RoundTripper
+}
+
+// Registry holds the configured datasources by type and name
+type Registry struct {
+	mu       sync.Mutex
+	entries  map[string]*entry
+	defaults map[string]string
+}
+
+func registryKey(datasourceType, name string) string {
+	return datasourceType + "/" + name
+}
+
+// NewRegistry creates a registry of datasources. Later datasources replace
+// earlier ones of the same type and name, so a configuration file overrides
+// the environment. Invalid datasources are kept with their error, which is
+// returned when they are used, so one misconfigured datasource does not
+// disable the others.
+func NewRegistry(datasources []Datasource) *Registry {
+	r := &Registry{entries: map[string]*entry{}, defaults: map[string]string{}}
+	for _, ds := range datasources {
+		e := &entry{datasource: ds}
+		if err := ds.Validate(); err != nil {
+			logger.Get().Error("Datasource disabled", "type", ds.Type, "name", ds.Name, "error", err)
+			e.err = err
+		}
+		r.entries[registryKey(ds.Type, ds.Name)] = e
+		if ds.Name == DefaultName && r.defaults[ds.Type] == "" {
+			r.defaults[ds.Type] = ds.Name
+		}
+	}
+	// A datasource marked as default replaces the one named default
+	for _, ds := range datasources {
+		if ds.Default {
+			r.defaults[ds.Type] = ds.Name
+		}
+	}
+	return r
+}
+
+// Get returns the named datasource of a type, or the default datasource of
+// the type when name is empty
+func (r *Registry) Get(datasourceType, name string) (Datasource, error) {
+	e, err := r.lookup(datasourceType, name)
+	if err != nil {
+		return Datasource{}, err
+	}
+	return e.datasource, nil
+}
+
+func (r *Registry) lookup(datasourceType, name string) (*entry, error) {
+	if name == "" {
+		name = r.defaults[datasourceType]
+		if name == "" {
+			return nil, fmt.Errorf("no default %s datasource is configured", datasourceType)
+		}
+	}
+	e, ok := r.entries[registryKey(datasourceType, name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown %s datasource %q, configured datasources: %s", datasourceType, name, strings.Join(r.names(datasourceType), ", "))
+	}
+	if e.err != nil {
+		return nil, fmt.Errorf("%s datasource %q is misconfigured: %v", datasourceType, name, e.err)
+	}
+	return e, nil
+}
+
+// names returns the sorted names of the valid datasources of a type
+func (r *Registry) names(datasourceType string) []string {
+	var names []string
+	for _, e := range r.entries {
+		if e.datasource.Type == datasourceType && e.err == nil {
+			names = append(names, e.datasource.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RoundTripper returns the round tripper of a datasource, which authenticates
+// requests and keeps a pool of connections reused across tool calls
+func (r *Registry) RoundTripper(datasourceType, name string) (Datasource, http.RoundTripper, error) {
+	e, err := r.lookup(datasourceType, name)
+	if err != nil {
+		return Datasource{}, nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e.roundTripper == nil {
+		roundTripper, err := config.NewRoundTripperFromConfig(e.datasource.HTTPClientConfig(), e.datasource.Type+"-"+e.datasource.Name)
+		if err != nil {
+			return Datasource{}, nil, fmt.Errorf("%s datasource %q: %w", datasourceType, e.datasource.Name, err)
+		}
+		e.roundTripper = roundTripper
+	}
+	return e.datasource, e.roundTripper, nil
+}
+
+// List describes the datasources of a type, or of all types when empty, without their credentials
+func (r *Registry) List(datasourceType string) []Summary {
+	summaries := []Summary{}
+	for _, e := range r.entries {
+		ds := e.datasource
+		if datasourceType != "" && ds.Type != datasourceType {
+			continue
+		}
+		summary := Summary{Name: ds.Name, Type: ds.Type, URL: ds.URL, Default: r.defaults[ds.Type] == ds.Name}
+		switch {
+		case ds.BearerToken != "" || ds.BearerTokenFile != "":
+			summary.Auth = "bearer"
+		case ds.Username != "":
+			summary.Auth = "basic"
+		}
+		if e.err != nil {
+			summary.Error = e.err.Error()
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Type != summaries[j].Type {
+			return summaries[i].Type < summaries[j].Type
+		}
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries
+}
+
+// LoadFromEnv creates a registry from the environment and the configuration
+// file named by DATASOURCES_CONFIG, whose datasources override those of the
+// environment
+func LoadFromEnv() (*Registry, error) {
+	datasources := FromEnv()
+	if filename := strings.TrimSpace(os.Getenv(DatasourcesConfig)); filename != "" {
+		cfg, err := LoadConfig(filename)
+		if err != nil {
+			return NewRegistry(datasources), err
+		}
+		datasources = append(datasources, cfg.Datasources...)
+	}
+	return NewRegistry(datasources), nil
+}
+
+var (
+	globalMu sync.Mutex
+	global   *Registry
+)
+
+// Global returns the registry configured through the environment, loading it on first use
+func Global() *Registry {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	if global == nil {
+		registry, err := LoadFromEnv()
+		if err != nil {
+			logger.Get().Error("Failed to load datasources config, using datasources of the environment", "error", err)
+		}
+		global = registry
+	}
+	return global
+}
+
+// Reset makes the next use of the global registry reload it from the
+// environment, e.g. after rotated credentials were reloaded
+func Reset() {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	global = nil
+}
@@ -0,0 +1,124 @@
+package datasources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("PROMETHEUS_URL", "")
+	t.Setenv("PROMETHEUS_INSTANCES", "prod, prod-eu,,prod")
+	t.Setenv("PROMETHEUS_PROD_URL", "https://prometheus.prod:9090")
+	t.Setenv("PROMETHEUS_PROD_USERNAME", "agent")
+	t.Setenv("PROMETHEUS_PROD_PASSWORD", "secret")
+	t.Setenv("PROMETHEUS_PROD_EU_URL", "https://prometheus.prod-eu:9090")
+	t.Setenv("PROMETHEUS_PROD_EU_BEARER_TOKEN_FILE", "/var/run/secrets/prometheus/token")
+	t.Setenv("PROMETHEUS_PROD_EU_INSECURE_SKIP_VERIFY", "true")
+	t.Setenv("LOKI_URL", "")
+	t.Setenv("LOKI_INSTANCES", "")
+	t.Setenv("GRAFANA_URL", "https://grafana.example.com")
+	t.Setenv("GRAFANA_API_KEY", "glsa_key")
+	t.Setenv("GRAFANA_INSTANCES", "")
+
+	assert.Equal(t, []Datasource{
+		{Name: DefaultName, Type: TypePrometheus, URL: "http://localhost:9090"},
+		{Name: "prod", Type: TypePrometheus, URL: "https://prometheus.prod:9090", Username: "agent", Password: "secret"},
+		{Name: "prod-eu", Type: TypePrometheus, URL: "https://prometheus.prod-eu:9090", BearerTokenFile: "/var/run/secrets/prometheus/token", TLS: TLS{InsecureSkipVerify: true}},
+		{Name: DefaultName, Type: TypeGrafana, URL: "https://grafana.example.com", BearerToken: "glsa_key"},
+	}, FromEnv())
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry([]Datasource{
+		{Name: DefaultName, Type: TypePrometheus, URL: "http://localhost:9090"},
+		{Name: "prod", Type: TypePrometheus, URL: "http://prometheus.prod:9090"},
+		{Name: "prod", Type: TypePrometheus, URL: "https://prometheus.prod:9090", BearerToken: "token", Default: true},
+		{Name: "prod", Type: TypeLoki, URL: "https://loki.prod:3100", Headers: map[string]string{"X-Scope-OrgID": "prod"}},
+		{Name: "broken", Type: TypeLoki, URL: "https://loki.broken:3100", BearerToken: "token", Username: "agent"},
+		{Name: "unknown", Type: "elasticsearch", URL: "https://elasticsearch:9200"},
+	})
+
+	// Later datasources replace earlier ones, and the one marked default replaces the one named default
+	ds, err := registry.Get(TypePrometheus, "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://prometheus.prod:9090", ds.URL)
+	ds, err = registry.Get(TypePrometheus, DefaultName)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:9090", ds.URL)
+
+	// Names are scoped to their type
+	ds, err = registry.Get(TypeLoki, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "https://loki.prod:3100", ds.URL)
+
+	_, err = registry.Get(TypeLoki, "staging")
+	assert.EqualError(t, err, `unknown loki datasource "staging", configured datasources: prod`)
+	_, err = registry.Get(TypeLoki, "")
+	assert.EqualError(t, err, "no default loki datasource is configured")
+	_, err = registry.Get(TypeLoki, "broken")
+	assert.ErrorContains(t, err, `loki datasource "broken" is misconfigured`)
+
+	summaries := registry.List("")
+	require.Len(t, summaries, 5)
+	assert.Equal(t, Summary{Name: "unknown", Type: "elasticsearch", URL: "https://elasticsearch:9200", Error: "type must be one of: prometheus, loki, grafana, alertmanager"}, summaries[0])
+	assert.Equal(t, Summary{Name: "prod", Type: TypePrometheus, URL: "https://prometheus.prod:9090", Default: true, Auth: "bearer"}, summaries[4])
+	assert.Len(t, registry.List(TypeLoki), 2)
+}
+
+func TestRoundTripper(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer server.Close()
+
+	registry := NewRegistry([]Datasource{
+		{Name: "prod", Type: TypeLoki, URL: server.URL, Username: "agent", Password: "secret", Headers: map[string]string{"X-Scope-OrgID": "prod"}},
+	})
+	ds, roundTripper, err := registry.RoundTripper(TypeLoki, "prod")
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: roundTripper}).Get(ds.URL + "/ready")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "Basic YWdlbnQ6c2VjcmV0", header.Get("Authorization"))
+	assert.Equal(t, "prod", header.Get("X-Scope-OrgID"))
+
+	// The round tripper, and its connection pool, is reused
+	_, again, err := registry.RoundTripper(TypeLoki, "prod")
+	require.NoError(t, err)
+	assert.Same(t, roundTripper, again)
+}
+
+func TestLoadFromEnv(t *testing.T) {
+	t.Setenv("PROMETHEUS_URL", "http://prometheus:9090")
+	t.Setenv("PROMETHEUS_INSTANCES", "")
+	filename := filepath.Join(t.TempDir(), "datasources.json")
+	require.NoError(t, os.WriteFile(filename, []byte(`{"datasources": [
+		{"name": "default", "type": "prometheus", "url": "https://prometheus.monitoring:9090", "bearer_token_file": "/var/run/secrets/token"},
+		{"name": "staging", "type": "grafana", "url": "https://grafana.staging", "bearer_token": "key", "default": true}
+	]}`), 0o600))
+	t.Setenv(DatasourcesConfig, filename)
+
+	registry, err := LoadFromEnv()
+	require.NoError(t, err)
+	ds, err := registry.Get(TypePrometheus, "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://prometheus.monitoring:9090", ds.URL)
+	ds, err = registry.Get(TypeGrafana, "")
+	require.NoError(t, err)
+	assert.Equal(t, "staging", ds.Name)
+
+	// A broken file keeps the datasources of the environment
+	require.NoError(t, os.WriteFile(filename, []byte(`{`), 0o600))
+	registry, err = LoadFromEnv()
+	assert.ErrorContains(t, err, "failed to parse datasources config")
+	ds, err = registry.Get(TypePrometheus, "")
+	require.NoError(t, err)
+	assert.Equal(t, "http://prometheus:9090", ds.URL)
+}
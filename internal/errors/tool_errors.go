@@ -338,6 +338,20 @@ func NewTimeoutError(operation string, timeout time.Duration) *ToolError {
 	return err
 }
 
+// NewBudgetError creates an error for an operation refused because the
+// session used up its budget
+func NewBudgetError(operation string, cause error) *ToolError {
+	err := NewToolError("Budget", operation, cause)
+
+	err = err.WithSuggestions(
+		"Work with the results already returned in this session",
+		"Start a new session to reset the budget",
+		"Ask the operator to raise the SESSION_MAX_* limits",
+	).WithRetryable(false).WithErrorCode("BUDGET_EXCEEDED")
+
+	return err
+}
+
 // NewCommandError creates a command execution error
 func NewCommandError(command string, cause error) *ToolError {
 	err := NewToolError("Command", fmt.Sprintf("execute %s", command), cause)
@@ -0,0 +1,648 @@
+// Package guard evaluates declarative policies before every mutating tool
+// call, so that a deployment can forbid calls such as scaling a production
+// workload below two replicas or deleting resources during business hours.
+//
+// A policy denies the calls matching all of its fields. The glob, parameter
+// and schedule fields cover common cases; any other rule is written as a CEL
+// expression in the when field, evaluated with these variables:
+//
+//	tool    string               the tool name
+//	args    map(string, dyn)     the call arguments as sent, numbers as doubles
+//	target  map(string, string)  namespace, resource_type and resource_name
+//	now     timestamp            the time of the call
+//
+// Expressions must return a bool. One that fails to evaluate, for instance by
+// reading an argument the call does not have, denies the call, so optional
+// arguments are tested with has(args.name) first.
+package guard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kagent-dev/tools/internal/annotations"
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// PolicyFile is the environment variable holding the path of the YAML policy
+// file; unset disables guard policies
+const PolicyFile = "GUARD_POLICY_FILE"
+
+// Parameters naming the resource a tool changes, by precedence
+var (
+	typeParams = []string{"resource_type", "kind"}
+	nameParams = []string{"resource_name", "name", "workload", "rollout_name", "pod_name", "service"}
+)
+
+// manifestParams are the parameters holding a manifest, whose documents may
+// name their own namespaces
+var manifestParams = []string{"manifest", "yaml_content"}
+
+// Tools whose arguments do not tell which namespaces they change: commands
+// can reach any namespace, and remote manifests are only read when applied
+var (
+	commandTools        = []string{"shell", "k8s_execute_command"}
+	remoteManifestTools = []string{"k8s_create_resource_from_url"}
+)
+
+// Target is the resource a tool call changes, as named by its arguments.
+// Fields the tool has no parameter for are empty.
+type Target struct {
+	Namespace string `json:"namespace,omitempty"`
+	// Namespaces are the namespaces of the documents of an applied
+	// manifest, each documented namespace defaulting to Namespace
+	Namespaces []string `json:"namespaces,omitempty"`
+	// AnyNamespace is set when the call may change resources in namespaces
+	// its arguments do not name, such as calls across all namespaces,
+	// commands and manifests without namespaces
+	AnyNamespace bool   `json:"any_namespace,omitempty"`
+	ResourceType string `json:"resource_type,omitempty"`
+	ResourceName string `json:"resource_name,omitempty"`
+}
+
+// TargetOf returns the resource named by the arguments of a tool call
+func TargetOf(tool string, args map[string]any) Target {
+	target := Target{
+		Namespace:    argString(args, "namespace"),
+		ResourceType: firstArg(args, typeParams),
+		ResourceName: firstArg(args, nameParams),
+	}
+
+	// Invalid flags are assumed to be set, as handlers reject them anyway
+	p := params.FromArgs(args)
+	if p.Bool("all_namespaces", false) || p.Err() != nil || contains(commandTools, tool) {
+		target.AnyNamespace = true
+	}
+	if contains(remoteManifestTools, tool) && target.Namespace == "" {
+		target.AnyNamespace = true
+	}
+	if manifest := firstArg(args, manifestParams); manifest != "" {
+		namespaces, ok := manifestNamespaces(manifest, target.Namespace)
+		target.Namespaces = namespaces
+		target.AnyNamespace = target.AnyNamespace || !ok
+	}
+	return target
+}
+
+// namespaces returns every namespace the call names
+func (t Target) namespaces() []string {
+	if len(t.Namespaces) > 0 {
+		return t.Namespaces
+	}
+	return []string{t.Namespace}
+}
+
+// manifestNamespaces returns the namespaces of the documents of a manifest,
+// documents without one defaulting to namespace. It returns false when a
+// namespace is unknown: the manifest cannot be parsed, or a document has no
+// namespace and there is no default, so it goes to the kubeconfig's.
+func manifestNamespaces(manifest, namespace string) ([]string, bool) {
+	var namespaces []string
+	known := true
+	var add func(doc map[string]any)
+	add = func(doc map[string]any) {
+		if items, ok := doc["items"].([]any); ok {
+			for _, item := range items {
+				if itemDoc, ok := item.(map[string]any); ok {
+					add(itemDoc)
+				}
+			}
+			return
+		}
+		metadata, _ := doc["metadata"].(map[string]any)
+		docNamespace, _ := metadata["namespace"].(string)
+		if docNamespace == "" {
+			docNamespace = namespace
+		}
+		if docNamespace == "" {
+			known = false
+			return
+		}
+		if !contains(namespaces, docNamespace) {
+			namespaces = append(namespaces, docNamespace)
+		}
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(manifest))
+	for {
+		var doc map[string]any
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return namespaces, false
+		}
+		if doc != nil {
+			add(doc)
+		}
+	}
+	return namespaces, known
+}
+
+// Condition tests the value of a tool argument. Every set field must hold;
+// numeric comparisons do not hold for missing or non-numeric arguments.
+type Condition struct {
+	Equals  *string  `yaml:"equals,omitempty" json:"equals,omitempty"`
+	In      []string `yaml:"in,omitempty" json:"in,omitempty"`
+	NotIn   []string `yaml:"not_in,omitempty" json:"not_in,omitempty"`
+	Matches string   `yaml:"matches,omitempty" json:"matches,omitempty"`
+	LT      *float64 `yaml:"lt,omitempty" json:"lt,omitempty"`
+	LTE     *float64 `yaml:"lte,omitempty" json:"lte,omitempty"`
+	GT      *float64 `yaml:"gt,omitempty" json:"gt,omitempty"`
+	GTE     *float64 `yaml:"gte,omitempty" json:"gte,omitempty"`
+
+	matches *regexp.Regexp
+}
+
+// holds reports whether the condition holds for an argument
+func (c *Condition) holds(value any, present bool) bool {
+	text := ""
+	if present {
+		text = valueString(value)
+	}
+	if c.Equals != nil && text != *c.Equals {
+		return false
+	}
+	if len(c.In) > 0 && !contains(c.In, text) {
+		return false
+	}
+	if len(c.NotIn) > 0 && contains(c.NotIn, text) {
+		return false
+	}
+	if c.matches != nil && !c.matches.MatchString(text) {
+		return false
+	}
+	if c.LT == nil && c.LTE == nil && c.GT == nil && c.GTE == nil {
+		return true
+	}
+
+	number, err := strconv.ParseFloat(text, 64)
+	if !present || err != nil {
+		return false
+	}
+	return (c.LT == nil || number < *c.LT) &&
+		(c.LTE == nil || number <= *c.LTE) &&
+		(c.GT == nil || number > *c.GT) &&
+		(c.GTE == nil || number >= *c.GTE)
+}
+
+// parseWeekday parses the full or three-letter name of a day, such as mon or Monday
+func parseWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// Schedule restricts a policy to a weekly time window
+type Schedule struct {
+	// Days are the days of the window, such as mon or fri; empty means every day
+	Days []string `yaml:"days,omitempty" json:"days,omitempty"`
+	// Hours is the window within each day, as HH:MM-HH:MM. A window ending
+	// before it starts spans midnight and belongs to the day it starts on.
+	Hours string `yaml:"hours,omitempty" json:"hours,omitempty"`
+	// Timezone is the IANA time zone of the window (default UTC)
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+
+	days       map[time.Weekday]bool
+	start, end int
+	location   *time.Location
+}
+
+// compile validates the schedule
+func (s *Schedule) compile() error {
+	s.location = time.UTC
+	if s.Timezone != "" {
+		location, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", s.Timezone, err)
+		}
+		s.location = location
+	}
+
+	s.days = map[time.Weekday]bool{}
+	for _, day := range s.Days {
+		weekday, ok := parseWeekday(day)
+		if !ok {
+			return fmt.Errorf("invalid day %q", day)
+		}
+		s.days[weekday] = true
+	}
+
+	s.start, s.end = 0, 24*60
+	if s.Hours != "" {
+		start, end, ok := strings.Cut(s.Hours, "-")
+		if !ok {
+			return fmt.Errorf("invalid hours %q, expected HH:MM-HH:MM", s.Hours)
+		}
+		var err error
+		if s.start, err = parseClock(start); err != nil || s.start == 24*60 {
+			return fmt.Errorf("invalid hours %q, expected HH:MM-HH:MM", s.Hours)
+		}
+		if s.end, err = parseClock(end); err != nil {
+			return fmt.Errorf("invalid hours %q, expected HH:MM-HH:MM", s.Hours)
+		}
+		if s.start == s.end {
+			return fmt.Errorf("hours %q is an empty window", s.Hours)
+		}
+	}
+	return nil
+}
+
+// parseClock returns the minutes since midnight of a HH:MM time, accepting 24:00
+func parseClock(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// includes reports whether a time falls in the window
+func (s *Schedule) includes(now time.Time) bool {
+	now = now.In(s.location)
+	minute := now.Hour()*60 + now.Minute()
+	day := now.Weekday()
+	switch {
+	case s.start < s.end:
+		if minute < s.start || minute >= s.end {
+			return false
+		}
+	case minute < s.end:
+		// The early hours of a window spanning midnight belong to the day before
+		day = (day + 6) % 7
+	case minute < s.start:
+		return false
+	}
+	return len(s.days) == 0 || s.days[day]
+}
+
+// Policy denies the mutating tool calls it matches. Every set field must
+// match; unset fields match any call. Tools, namespaces, resource types and
+// resource names are glob patterns such as k8s_* or prod-*.
+type Policy struct {
+	Name          string   `yaml:"name" json:"name"`
+	Tools         []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	Namespaces    []string `yaml:"namespaces,omitempty" json:"namespaces,omitempty"`
+	ResourceTypes []string `yaml:"resource_types,omitempty" json:"resource_types,omitempty"`
+	ResourceNames []string `yaml:"resource_names,omitempty" json:"resource_names,omitempty"`
+	// Params are conditions on the arguments of the call, by parameter name
+	Params map[string]*Condition `yaml:"params,omitempty" json:"params,omitempty"`
+	// Schedule restricts the policy to a time window
+	Schedule *Schedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+	// When is a CEL expression the call must satisfy, described in the package doc
+	When string `yaml:"when,omitempty" json:"when,omitempty"`
+	// Message tells the caller why the call is denied
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+
+	when cel.Program
+}
+
+// maxExpressionCost bounds the evaluation cost of a when expression, so that
+// a policy cannot stall tool calls
+const maxExpressionCost = 100000
+
+// newEnv returns the CEL environment of when expressions
+var newEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("tool", cel.StringType),
+		cel.Variable("args", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("target", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("now", cel.TimestampType),
+	)
+})
+
+// compileWhen compiles a when expression, which must return a bool
+func compileWhen(expression string) (cel.Program, error) {
+	env, err := newEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("must return a bool, not %s", ast.OutputType())
+	}
+	return env.Program(ast, cel.CostLimit(maxExpressionCost))
+}
+
+// matches reports whether the policy denies a call. An error means the when
+// expression could not be evaluated.
+func (p *Policy) matches(call Call, now time.Time) (bool, error) {
+	if !matchesAny(p.Tools, call.Tool) ||
+		!p.matchesNamespace(call.Target) ||
+		!matchesAny(p.ResourceTypes, call.Target.ResourceType) ||
+		!matchesAny(p.ResourceNames, call.Target.ResourceName) {
+		return false, nil
+	}
+	for param, condition := range p.Params {
+		value, present := call.Arguments[param]
+		if !condition.holds(value, present) {
+			return false, nil
+		}
+	}
+	if p.Schedule != nil && !p.Schedule.includes(now) {
+		return false, nil
+	}
+	if p.when == nil {
+		return true, nil
+	}
+
+	args := call.Arguments
+	if args == nil {
+		args = map[string]any{}
+	}
+	out, _, err := p.when.Eval(map[string]any{
+		"tool": call.Tool,
+		"args": args,
+		"target": map[string]string{
+			"namespace":     call.Target.Namespace,
+			"resource_type": call.Target.ResourceType,
+			"resource_name": call.Target.ResourceName,
+		},
+		"now": now,
+	})
+	if err != nil {
+		return true, err
+	}
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return true, fmt.Errorf("when returned %s, not a bool", out.Type().TypeName())
+	}
+	return matched, nil
+}
+
+// matchesNamespace reports whether the policy applies to the namespaces of a
+// call. Calls that may change namespaces their arguments do not name match
+// any namespace, so that namespace-scoped policies cannot be bypassed.
+func (p *Policy) matchesNamespace(target Target) bool {
+	if len(p.Namespaces) == 0 || target.AnyNamespace {
+		return true
+	}
+	for _, namespace := range target.namespaces() {
+		if matchesAny(p.Namespaces, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// Policies is the policy file, listing the policies of a deployment
+type Policies struct {
+	Policies []Policy `yaml:"policies" json:"policies"`
+}
+
+// LoadPolicies reads and compiles the policies of GUARD_POLICY_FILE,
+// returning nil when no file is configured
+func LoadPolicies() (*Policies, error) {
+	filename := strings.TrimSpace(os.Getenv(PolicyFile))
+	if filename == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read guard policies: %w", err)
+	}
+	return ParsePolicies(data)
+}
+
+// ParsePolicies parses and compiles YAML guard policies
+func ParsePolicies(data []byte) (*Policies, error) {
+	var policies Policies
+	if err := yaml.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("failed to parse guard policies: %w", err)
+	}
+
+	names := map[string]bool{}
+	for i := range policies.Policies {
+		policy := &policies.Policies[i]
+		if policy.Name == "" {
+			return nil, fmt.Errorf("guard policy %d has no name", i+1)
+		}
+		if names[policy.Name] {
+			return nil, fmt.Errorf("duplicate guard policy %q", policy.Name)
+		}
+		names[policy.Name] = true
+
+		for field, patterns := range map[string][]string{
+			"tools":          policy.Tools,
+			"namespaces":     policy.Namespaces,
+			"resource_types": policy.ResourceTypes,
+			"resource_names": policy.ResourceNames,
+		} {
+			for _, pattern := range patterns {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return nil, fmt.Errorf("guard policy %q: invalid %s pattern %q: %v", policy.Name, field, pattern, err)
+				}
+			}
+		}
+		for param, condition := range policy.Params {
+			if condition == nil {
+				return nil, fmt.Errorf("guard policy %q: parameter %s has no condition", policy.Name, param)
+			}
+			if condition.Matches != "" {
+				re, err := regexp.Compile(condition.Matches)
+				if err != nil {
+					return nil, fmt.Errorf("guard policy %q: invalid matches of parameter %s: %v", policy.Name, param, err)
+				}
+				condition.matches = re
+			}
+		}
+		if policy.Schedule != nil {
+			if err := policy.Schedule.compile(); err != nil {
+				return nil, fmt.Errorf("guard policy %q: %w", policy.Name, err)
+			}
+		}
+		if policy.When != "" {
+			program, err := compileWhen(policy.When)
+			if err != nil {
+				return nil, fmt.Errorf("guard policy %q: invalid when: %v", policy.Name, err)
+			}
+			policy.when = program
+		}
+	}
+	return &policies, nil
+}
+
+// Call is what policies are evaluated against
+type Call struct {
+	Tool      string
+	Arguments map[string]any
+	Target    Target
+}
+
+// Evaluate returns the first policy denying a call, or nil when it is allowed.
+// A policy whose when expression cannot be evaluated denies the call, and is
+// returned with the evaluation error.
+func (p *Policies) Evaluate(call Call, now time.Time) (*Policy, error) {
+	if p == nil {
+		return nil, nil
+	}
+	for i := range p.Policies {
+		matched, err := p.Policies[i].matches(call, now)
+		if matched {
+			return &p.Policies[i], err
+		}
+	}
+	return nil, nil
+}
+
+// Mutating reports whether a tool may change its environment. Tools missing
+// from the annotations registry are assumed to.
+func Mutating(tool string) bool {
+	hints, ok := annotations.Lookup(tool)
+	return !ok || !hints.ReadOnly
+}
+
+// Guard applies the current policies to tool calls
+type Guard struct {
+	mu       sync.RWMutex
+	policies *Policies
+	now      func() time.Time
+}
+
+// NewGuard creates a guard applying the given policies, which may be nil
+func NewGuard(policies *Policies) *Guard {
+	return &Guard{policies: policies, now: time.Now}
+}
+
+// LoadGuardFromEnv creates a guard applying the policies of GUARD_POLICY_FILE
+func LoadGuardFromEnv() (*Guard, error) {
+	policies, err := LoadPolicies()
+	if err != nil {
+		return nil, err
+	}
+	return NewGuard(policies), nil
+}
+
+// SetPolicies replaces the policies, applying them to the next call
+func (g *Guard) SetPolicies(policies *Policies) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.policies = policies
+}
+
+// Reload re-reads GUARD_POLICY_FILE; invalid policies keep the previous ones
+func (g *Guard) Reload() {
+	policies, err := LoadPolicies()
+	if err != nil {
+		logger.Get().Error("Keeping previous guard policies, the reloaded policies are invalid", "error", err)
+		return
+	}
+	g.SetPolicies(policies)
+}
+
+// Check returns an error when a policy denies a tool call
+func (g *Guard) Check(tool string, args map[string]any) error {
+	if !Mutating(tool) {
+		return nil
+	}
+	g.mu.RLock()
+	policies := g.policies
+	g.mu.RUnlock()
+
+	policy, err := policies.Evaluate(Call{Tool: tool, Arguments: args, Target: TargetOf(tool, args)}, g.now())
+	if policy == nil {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s is denied, guard policy %s could not be evaluated: %v", tool, policy.Name, err)
+	}
+	if policy.Message == "" {
+		return fmt.Errorf("%s is denied by guard policy %s", tool, policy.Name)
+	}
+	return fmt.Errorf("%s is denied by guard policy %s: %s", tool, policy.Name, policy.Message)
+}
+
+// ToolMiddleware rejects tool calls a policy denies before they run
+func (g *Guard) ToolMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := g.Check(request.Params.Name, request.GetArguments()); err != nil {
+			logger.Get().Info("Tool call denied", "tool", request.Params.Name, "reason", err)
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// matchesAny reports whether a value matches one of the patterns, or whether
+// there are no patterns
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, value); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// firstArg returns the first of the named arguments that is set
+func firstArg(args map[string]any, names []string) string {
+	for _, name := range names {
+		if value := argString(args, name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// argString returns a string argument, or an empty string
+func argString(args map[string]any, name string) string {
+	value, ok := args[name]
+	if !ok {
+		return ""
+	}
+	return valueString(value)
+}
+
+// valueString formats an argument as given, numbers without trailing zeros
+func valueString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,269 @@
+package guard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicies = `
+policies:
+  - name: prod-min-replicas
+    tools: [k8s_scale]
+    namespaces: [prod, prod-*]
+    params:
+      replicas: {lt: 2}
+    message: never scale below 2 replicas in prod namespaces
+  - name: business-hours-deletes
+    tools: [k8s_delete_resource, helm_uninstall]
+    schedule:
+      days: [mon, tue, wed, thu, friday]
+      hours: 09:00-17:00
+      timezone: Europe/Paris
+    message: no deletes during business hours
+  - name: no-shell-deletes
+    tools: [shell]
+    params:
+      command: {matches: 'kubectl\s+delete'}
+`
+
+func TestGuardCheck(t *testing.T) {
+	policies, err := ParsePolicies([]byte(testPolicies))
+	require.NoError(t, err)
+	g := NewGuard(policies)
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	// A Wednesday, in business hours in Paris
+	g.now = func() time.Time { return time.Date(2026, 10, 14, 10, 30, 0, 0, paris) }
+
+	err = g.Check("k8s_scale", map[string]any{"name": "web", "namespace": "prod-eu", "replicas": float64(1)})
+	assert.EqualError(t, err, "k8s_scale is denied by guard policy prod-min-replicas: never scale below 2 replicas in prod namespaces")
+	assert.NoError(t, g.Check("k8s_scale", map[string]any{"name": "web", "namespace": "prod", "replicas": "3"}))
+	assert.NoError(t, g.Check("k8s_scale", map[string]any{"name": "web", "namespace": "staging", "replicas": float64(0)}))
+
+	assert.ErrorContains(t, g.Check("k8s_delete_resource", map[string]any{"resource_type": "pod", "resource_name": "web-0"}), "no deletes during business hours")
+	g.now = func() time.Time { return time.Date(2026, 10, 14, 17, 0, 0, 0, paris) }
+	assert.NoError(t, g.Check("k8s_delete_resource", map[string]any{"resource_type": "pod", "resource_name": "web-0"}))
+	g.now = func() time.Time { return time.Date(2026, 10, 17, 10, 30, 0, 0, paris) }
+	assert.NoError(t, g.Check("helm_uninstall", map[string]any{"name": "web"}), "saturdays are outside the window")
+
+	assert.EqualError(t, g.Check("shell", map[string]any{"command": "kubectl  delete pod web-0"}), "shell is denied by guard policy no-shell-deletes")
+	assert.NoError(t, g.Check("shell", map[string]any{"command": "kubectl get pods"}))
+
+	g.SetPolicies(mustParse(t, "policies:\n  - name: read-only\n"))
+	assert.NoError(t, g.Check("k8s_get_resources", map[string]any{"resource_type": "pods"}), "read-only tools are not guarded")
+	assert.Error(t, g.Check("k8s_apply_manifest", nil))
+	assert.Error(t, g.Check("unregistered_tool", nil), "tools without annotations are assumed to mutate")
+	assert.NoError(t, NewGuard(nil).Check("k8s_apply_manifest", nil))
+}
+
+func TestGuardCheckWhen(t *testing.T) {
+	g := NewGuard(mustParse(t, `
+policies:
+  - name: prod-min-replicas
+    tools: [k8s_scale]
+    when: target.namespace.startsWith("prod") && double(args.replicas) < 2.0
+    message: never scale below 2 replicas in prod namespaces
+  - name: weekend-helm
+    tools: [helm_*]
+    when: now.getDayOfWeek("Europe/Paris") in [0, 6] && !(has(args.dry_run) && args.dry_run == "true")
+  - name: protected-labels
+    tools: [k8s_label_resource]
+    when: has(args.labels) && args.labels.matches("(^|\\s)team=")
+`))
+	// A Saturday in Paris
+	g.now = func() time.Time { return time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC) }
+
+	assert.ErrorContains(t, g.Check("k8s_scale", map[string]any{"name": "web", "namespace": "prod-eu", "replicas": float64(1)}), "never scale below 2")
+	assert.ErrorContains(t, g.Check("k8s_scale", map[string]any{"name": "web", "namespace": "prod", "replicas": "0"}), "never scale below 2")
+	assert.NoError(t, g.Check("k8s_scale", map[string]any{"name": "web", "namespace": "prod", "replicas": float64(3)}))
+	assert.NoError(t, g.Check("k8s_scale", map[string]any{"name": "web", "namespace": "staging", "replicas": float64(0)}))
+
+	assert.EqualError(t, g.Check("helm_upgrade", map[string]any{"name": "web"}), "helm_upgrade is denied by guard policy weekend-helm")
+	assert.NoError(t, g.Check("helm_upgrade", map[string]any{"name": "web", "dry_run": "true"}))
+	g.now = func() time.Time { return time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC) }
+	assert.NoError(t, g.Check("helm_upgrade", map[string]any{"name": "web"}), "mondays are allowed")
+
+	assert.Error(t, g.Check("k8s_label_resource", map[string]any{"labels": "tier=web team=payments"}))
+	assert.NoError(t, g.Check("k8s_label_resource", map[string]any{"labels": "tier=web"}))
+
+	// An expression that cannot be evaluated denies the call
+	err := g.Check("k8s_scale", map[string]any{"name": "web", "namespace": "prod"})
+	assert.ErrorContains(t, err, "guard policy prod-min-replicas could not be evaluated")
+}
+
+func TestTargetOf(t *testing.T) {
+	assert.Equal(t, Target{Namespace: "prod", ResourceType: "deployment", ResourceName: "web"},
+		TargetOf("k8s_delete_resource", map[string]any{"namespace": "prod", "resource_type": "deployment", "resource_name": "web", "name": "other"}))
+	assert.Equal(t, Target{ResourceName: "deployment/web"}, TargetOf("k8s_rollout_restart", map[string]any{"workload": "deployment/web"}))
+	assert.Equal(t, Target{}, TargetOf("k8s_scale", nil))
+
+	assert.Equal(t, Target{Namespace: "staging", Namespaces: []string{"prod", "staging"}},
+		TargetOf("k8s_apply_manifest_chunked", map[string]any{"namespace": "staging", "manifest": `
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: a, namespace: prod}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: b}
+`}))
+	assert.Equal(t, Target{Namespaces: []string{"prod"}},
+		TargetOf("k8s_create_resource", map[string]any{"yaml_content": "apiVersion: v1\nkind: List\nitems:\n- kind: ConfigMap\n  metadata: {name: a, namespace: prod}\n"}))
+	assert.True(t, TargetOf("k8s_apply_manifest", map[string]any{"manifest": "kind: ConfigMap\nmetadata: {name: a}\n"}).AnyNamespace)
+	assert.True(t, TargetOf("k8s_apply_manifest", map[string]any{"manifest": "kind: [ConfigMap"}).AnyNamespace)
+	assert.True(t, TargetOf("k8s_create_resource_from_url", map[string]any{"url": "https://example.com/app.yaml"}).AnyNamespace)
+	assert.False(t, TargetOf("k8s_create_resource_from_url", map[string]any{"url": "https://example.com/app.yaml", "namespace": "dev"}).AnyNamespace)
+	assert.True(t, TargetOf("k8s_label_resource", map[string]any{"resource_type": "pod", "all_namespaces": true}).AnyNamespace)
+	assert.True(t, TargetOf("k8s_label_resource", map[string]any{"resource_type": "pod", "all_namespaces": "yes please"}).AnyNamespace)
+	assert.True(t, TargetOf("k8s_execute_command", map[string]any{"pod_name": "web-0", "namespace": "dev", "command": "kubectl -n prod delete pod web-0"}).AnyNamespace)
+}
+
+func TestGuardCheckNamespaces(t *testing.T) {
+	g := NewGuard(mustParse(t, `
+policies:
+  - name: frozen-prod
+    namespaces: [prod, prod-*]
+    message: prod is frozen
+`))
+
+	assert.ErrorContains(t, g.Check("k8s_apply_manifest", map[string]any{"manifest": `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: dev}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: prod-eu}
+`}), "prod is frozen")
+	assert.NoError(t, g.Check("k8s_apply_manifest", map[string]any{"manifest": "kind: ConfigMap\nmetadata: {name: a, namespace: dev}\n"}))
+	assert.ErrorContains(t, g.Check("k8s_apply_manifest", map[string]any{"manifest": "kind: ConfigMap\nmetadata: {name: a}\n"}), "prod is frozen",
+		"documents without a namespace go to the kubeconfig's")
+	assert.ErrorContains(t, g.Check("k8s_create_resource", map[string]any{"yaml_content": "kind: ConfigMap\nmetadata: {name: a, namespace: prod}\n"}), "prod is frozen")
+	assert.NoError(t, g.Check("k8s_create_resource", map[string]any{"yaml_content": "kind: ConfigMap\nmetadata: {name: a, namespace: dev}\n"}))
+	assert.ErrorContains(t, g.Check("k8s_create_resource_from_url", map[string]any{"url": "https://example.com/app.yaml"}), "prod is frozen")
+
+	assert.ErrorContains(t, g.Check("k8s_label_resource", map[string]any{"resource_type": "pod", "labels": "a=b", "all_namespaces": true}), "prod is frozen")
+	assert.ErrorContains(t, g.Check("k8s_annotate_resource", map[string]any{"resource_type": "pod", "annotations": "a=b", "all_namespaces": "1"}), "prod is frozen")
+	assert.NoError(t, g.Check("k8s_label_resource", map[string]any{"resource_type": "pod", "resource_name": "web-0", "labels": "a=b", "namespace": "dev"}))
+
+	assert.ErrorContains(t, g.Check("shell", map[string]any{"command": "kubectl -n prod delete pod web-0"}), "prod is frozen")
+	assert.ErrorContains(t, g.Check("k8s_execute_command", map[string]any{"pod_name": "tools", "namespace": "dev", "command": "kubectl -n prod delete pod web-0"}), "prod is frozen")
+}
+
+func TestConditionHolds(t *testing.T) {
+	two, five := 2.0, 5.0
+	equals := "true"
+	for _, tc := range []struct {
+		name      string
+		condition Condition
+		value     any
+		present   bool
+		holds     bool
+	}{
+		{"lt number", Condition{LT: &two}, float64(1), true, true},
+		{"lt string", Condition{LT: &two}, "2", true, false},
+		{"range", Condition{GTE: &two, LTE: &five}, 5, true, true},
+		{"missing numeric", Condition{LT: &two}, nil, false, false},
+		{"non-numeric", Condition{LT: &two}, "many", true, false},
+		{"equals", Condition{Equals: &equals}, "true", true, true},
+		{"equals missing", Condition{Equals: &equals}, nil, false, false},
+		{"in", Condition{In: []string{"a", "b"}}, "b", true, true},
+		{"not in", Condition{NotIn: []string{"a", "b"}}, "c", true, true},
+		{"not in missing", Condition{NotIn: []string{""}}, nil, false, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.holds, tc.condition.holds(tc.value, tc.present))
+		})
+	}
+}
+
+func TestScheduleIncludes(t *testing.T) {
+	schedule := Schedule{Days: []string{"Fri"}, Hours: "22:00-06:00"}
+	require.NoError(t, schedule.compile())
+	// 2026-10-16 is a Friday
+	assert.True(t, schedule.includes(time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, schedule.includes(time.Date(2026, 10, 17, 5, 59, 0, 0, time.UTC)), "the early hours belong to friday")
+	assert.False(t, schedule.includes(time.Date(2026, 10, 17, 6, 0, 0, 0, time.UTC)))
+	assert.False(t, schedule.includes(time.Date(2026, 10, 16, 5, 0, 0, 0, time.UTC)), "the early hours of friday belong to thursday")
+	assert.False(t, schedule.includes(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)))
+
+	allDay := Schedule{Hours: "00:00-24:00"}
+	require.NoError(t, allDay.compile())
+	assert.True(t, allDay.includes(time.Date(2026, 10, 16, 23, 59, 0, 0, time.UTC)))
+}
+
+func TestParsePoliciesErrors(t *testing.T) {
+	for _, data := range []string{
+		"policies: [{tools: [k8s_scale]}]",
+		"policies: [{name: a}, {name: a}]",
+		"policies: [{name: a, namespaces: ['[']}]",
+		"policies: [{name: a, params: {replicas: }}]",
+		"policies: [{name: a, params: {command: {matches: '('}}}]",
+		"policies: [{name: a, schedule: {days: [someday]}}]",
+		"policies: [{name: a, schedule: {hours: '9-17'}}]",
+		"policies: [{name: a, schedule: {hours: '09:00-09:00'}}]",
+		"policies: [{name: a, schedule: {timezone: Mars/Olympus}}]",
+		"policies: {}",
+		"policies: [{name: a, when: 'args.replicas <'}]",
+		"policies: [{name: a, when: 'args.replicas'}]",
+		"policies: [{name: a, when: 'unknown == 1'}]",
+	} {
+		_, err := ParsePolicies([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestGuardReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("policies:\n  - name: freeze\n"), 0o600))
+	t.Setenv(PolicyFile, path)
+
+	g, err := LoadGuardFromEnv()
+	require.NoError(t, err)
+	assert.Error(t, g.Check("k8s_scale", nil))
+
+	require.NoError(t, os.WriteFile(path, []byte("policies: ["), 0o600))
+	g.Reload()
+	assert.Error(t, g.Check("k8s_scale", nil), "invalid policies keep the previous ones")
+
+	require.NoError(t, os.WriteFile(path, []byte("policies: []\n"), 0o600))
+	g.Reload()
+	assert.NoError(t, g.Check("k8s_scale", nil))
+}
+
+func TestToolMiddleware(t *testing.T) {
+	g := NewGuard(mustParse(t, "policies:\n  - name: freeze\n    message: change freeze\n"))
+	called := false
+	handler := g.ToolMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "k8s_scale"
+	request.Params.Arguments = map[string]any{"name": "web", "replicas": float64(0)}
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.False(t, called)
+
+	request.Params.Name = "k8s_get_resources"
+	result, err = handler(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.True(t, called)
+}
+
+func mustParse(t *testing.T, data string) *Policies {
+	t.Helper()
+	policies, err := ParsePolicies([]byte(data))
+	require.NoError(t, err)
+	return policies
+}
@@ -0,0 +1,394 @@
+// Package leader implements Kubernetes Lease based leader election so that
+// only one replica of the tool server runs background work, while every
+// replica keeps serving MCP traffic.
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/logger"
+)
+
+// Environment variables configuring leader election
+const (
+	LeaderElectionEnabled       = "LEADER_ELECTION_ENABLED"
+	LeaderElectionNamespace     = "LEADER_ELECTION_NAMESPACE"
+	LeaderElectionLeaseName     = "LEADER_ELECTION_LEASE_NAME"
+	LeaderElectionIdentity      = "LEADER_ELECTION_IDENTITY"
+	LeaderElectionLeaseDuration = "LEADER_ELECTION_LEASE_DURATION"
+	LeaderElectionRenewDeadline = "LEADER_ELECTION_RENEW_DEADLINE"
+	LeaderElectionRetryPeriod   = "LEADER_ELECTION_RETRY_PERIOD"
+)
+
+// Defaults match those of the Kubernetes controller leader election
+const (
+	defaultLeaseName     = "kagent-tools-leader"
+	defaultLeaseDuration = 15 * time.Second
+	defaultRenewDeadline = 10 * time.Second
+	defaultRetryPeriod   = 2 * time.Second
+)
+
+// microTimeFormat is the serialization of Kubernetes MicroTime fields
+const microTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// Config holds the leader election configuration
+type Config struct {
+	// Enabled turns on leader election; when disabled this replica always leads
+	Enabled bool
+	// Namespace and LeaseName identify the Lease object used as the lock
+	Namespace string
+	LeaseName string
+	// Identity is this replica's holder identity, usually the pod name
+	Identity string
+	// LeaseDuration is how long non-leaders wait before taking over an unrenewed lease
+	LeaseDuration time.Duration
+	// RenewDeadline is how long the leader retries renewing before stepping down
+	RenewDeadline time.Duration
+	// RetryPeriod is the interval between acquire and renew attempts
+	RetryPeriod time.Duration
+	// Kubeconfig is the kubeconfig used to access the Lease
+	Kubeconfig string
+}
+
+// LoadConfig reads the leader election configuration from the environment
+func LoadConfig(kubeconfig string) Config {
+	cfg := Config{
+		Enabled:       os.Getenv(LeaderElectionEnabled) == "true",
+		Namespace:     os.Getenv(LeaderElectionNamespace),
+		LeaseName:     os.Getenv(LeaderElectionLeaseName),
+		Identity:      os.Getenv(LeaderElectionIdentity),
+		LeaseDuration: durationFromEnv(LeaderElectionLeaseDuration, defaultLeaseDuration),
+		RenewDeadline: durationFromEnv(LeaderElectionRenewDeadline, defaultRenewDeadline),
+		RetryPeriod:   durationFromEnv(LeaderElectionRetryPeriod, defaultRetryPeriod),
+		Kubeconfig:    kubeconfig,
+	}
+
+	if cfg.Namespace == "" {
+		cfg.Namespace = os.Getenv("KAGENT_NAMESPACE")
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = "default"
+	}
+	if cfg.LeaseName == "" {
+		cfg.LeaseName = defaultLeaseName
+	}
+	if cfg.Identity == "" {
+		cfg.Identity = os.Getenv("POD_NAME")
+	}
+	if cfg.Identity == "" {
+		cfg.Identity, _ = os.Hostname()
+	}
+	return cfg
+}
+
+// durationFromEnv parses a positive duration from the environment
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// Validate checks that the durations allow a leader to renew before its lease expires
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Identity == "" {
+		return fmt.Errorf("leader election requires an identity")
+	}
+	if c.RenewDeadline >= c.LeaseDuration {
+		return fmt.Errorf("renew deadline %s must be shorter than lease duration %s", c.RenewDeadline, c.LeaseDuration)
+	}
+	if c.RetryPeriod >= c.RenewDeadline {
+		return fmt.Errorf("retry period %s must be shorter than renew deadline %s", c.RetryPeriod, c.RenewDeadline)
+	}
+	return nil
+}
+
+// lease is the subset of a coordination.k8s.io/v1 Lease used for election
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int    `json:"leaseTransitions"`
+}
+
+// Elector runs background tasks only while this replica holds the Lease
+type Elector struct {
+	cfg Config
+	now func() time.Time
+
+	mu     sync.RWMutex
+	leader bool
+	tasks  []func(ctx context.Context)
+
+	// observedSpec and observedTime record when the lease last changed, measured
+	// with the local clock so that clock skew between replicas does not matter
+	observedSpec leaseSpec
+	observedTime time.Time
+	lastRenew    time.Time
+}
+
+// NewElector creates an elector for the given configuration
+func NewElector(cfg Config) *Elector {
+	return &Elector{cfg: cfg, now: time.Now}
+}
+
+// Go registers a background task. Tasks are started with a context that is
+// cancelled when this replica loses leadership, and started again if it is
+// regained. Tasks must be registered before Run is called.
+func (e *Elector) Go(task func(ctx context.Context)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tasks = append(e.tasks, task)
+}
+
+// IsLeader reports whether this replica currently runs the background tasks
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leader
+}
+
+// setLeader records the leadership state
+func (e *Elector) setLeader(leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.leader = leader
+}
+
+// Run takes part in the election until ctx is cancelled, running the
+// registered tasks whenever this replica is the leader. When election is
+// disabled the tasks run for the lifetime of ctx.
+func (e *Elector) Run(ctx context.Context) {
+	if !e.cfg.Enabled {
+		e.setLeader(true)
+		e.runTasks(ctx)
+		<-ctx.Done()
+		e.setLeader(false)
+		return
+	}
+
+	log := logger.Get().With("lease", e.cfg.Namespace+"/"+e.cfg.LeaseName, "identity", e.cfg.Identity)
+	ticker := time.NewTicker(e.cfg.RetryPeriod)
+	defer ticker.Stop()
+
+	var stopTasks func()
+	for {
+		acquired := e.tryAcquireOrRenew(ctx)
+		switch {
+		case acquired && stopTasks == nil:
+			log.Info("Acquired leadership, starting background tasks")
+			e.setLeader(true)
+			stopTasks = e.startTasks(ctx)
+		case !acquired && stopTasks != nil && e.now().Sub(e.lastRenew) > e.cfg.RenewDeadline:
+			log.Info("Lost leadership, stopping background tasks")
+			e.setLeader(false)
+			stopTasks()
+			stopTasks = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if stopTasks != nil {
+				e.setLeader(false)
+				stopTasks()
+				e.release()
+				log.Info("Released leadership")
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runTasks starts the registered tasks and returns a wait group tracking them
+func (e *Elector) runTasks(ctx context.Context) *sync.WaitGroup {
+	e.mu.RLock()
+	tasks := append([]func(context.Context){}, e.tasks...)
+	e.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(task func(context.Context)) {
+			defer wg.Done()
+			task(ctx)
+		}(task)
+	}
+	return &wg
+}
+
+// startTasks starts the registered tasks and returns a function that stops
+// them and waits for them to return, so that tasks never overlap with a new leader's
+func (e *Elector) startTasks(ctx context.Context) func() {
+	taskCtx, cancel := context.WithCancel(ctx)
+	wg := e.runTasks(taskCtx)
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// tryAcquireOrRenew creates, takes over or renews the lease, reporting whether this replica holds it
+func (e *Elector) tryAcquireOrRenew(ctx context.Context) bool {
+	log := logger.Get().With("lease", e.cfg.Namespace+"/"+e.cfg.LeaseName, "identity", e.cfg.Identity)
+	now := e.now()
+
+	current, err := e.getLease(ctx)
+	if err != nil {
+		log.Error("Failed to get leader lease", "error", err)
+		return false
+	}
+
+	if current == nil {
+		spec := leaseSpec{
+			HolderIdentity:       e.cfg.Identity,
+			LeaseDurationSeconds: int(e.cfg.LeaseDuration.Seconds()),
+			AcquireTime:          now.UTC().Format(microTimeFormat),
+			RenewTime:            now.UTC().Format(microTimeFormat),
+		}
+		if err := e.writeLease(ctx, "create", "", spec); err != nil {
+			log.Debug("Failed to create leader lease", "error", err)
+			return false
+		}
+		e.observe(spec, now)
+		e.lastRenew = now
+		return true
+	}
+
+	if current.Spec != e.observedSpec {
+		e.observe(current.Spec, now)
+	}
+
+	holder := current.Spec.HolderIdentity
+	leaseDuration := time.Duration(current.Spec.LeaseDurationSeconds) * time.Second
+	if holder != "" && holder != e.cfg.Identity && e.observedTime.Add(leaseDuration).After(now) {
+		return false
+	}
+
+	spec := current.Spec
+	if holder != e.cfg.Identity {
+		spec.AcquireTime = now.UTC().Format(microTimeFormat)
+		spec.LeaseTransitions++
+	}
+	spec.HolderIdentity = e.cfg.Identity
+	spec.LeaseDurationSeconds = int(e.cfg.LeaseDuration.Seconds())
+	spec.RenewTime = now.UTC().Format(microTimeFormat)
+
+	// The resource version makes the replace fail if another replica updated the lease first
+	if err := e.writeLease(ctx, "replace", current.Metadata.ResourceVersion, spec); err != nil {
+		log.Debug("Failed to update leader lease", "error", err)
+		return false
+	}
+	e.observe(spec, now)
+	e.lastRenew = now
+	return true
+}
+
+// observe records the last seen lease spec and when it was seen
+func (e *Elector) observe(spec leaseSpec, now time.Time) {
+	e.observedSpec = spec
+	e.observedTime = now
+}
+
+// release gives up the lease so another replica can take over without waiting for it to expire
+func (e *Elector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.cfg.RetryPeriod)
+	defer cancel()
+
+	current, err := e.getLease(ctx)
+	if err != nil || current == nil || current.Spec.HolderIdentity != e.cfg.Identity {
+		return
+	}
+
+	spec := current.Spec
+	spec.HolderIdentity = ""
+	spec.LeaseDurationSeconds = 1
+	spec.RenewTime = e.now().UTC().Format(microTimeFormat)
+	if err := e.writeLease(ctx, "replace", current.Metadata.ResourceVersion, spec); err != nil {
+		logger.Get().Error("Failed to release leader lease", "error", err)
+	}
+}
+
+// getLease returns the lease, or nil if it does not exist
+func (e *Elector) getLease(ctx context.Context) (*lease, error) {
+	output, err := commands.NewCommandBuilder("kubectl").
+		WithArgs("get", "lease", e.cfg.LeaseName, "-n", e.cfg.Namespace, "-o", "json", "--ignore-not-found").
+		WithKubeconfig(e.cfg.Kubeconfig).
+		Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(output) == "" {
+		return nil, nil
+	}
+
+	var current lease
+	if err := json.Unmarshal([]byte(output), &current); err != nil {
+		return nil, fmt.Errorf("failed to parse lease: %w", err)
+	}
+	return &current, nil
+}
+
+// writeLease creates or replaces the lease through a temporary manifest file
+func (e *Elector) writeLease(ctx context.Context, verb, resourceVersion string, spec leaseSpec) error {
+	manifest, err := json.Marshal(lease{
+		APIVersion: "coordination.k8s.io/v1",
+		Kind:       "Lease",
+		Metadata: leaseMetadata{
+			Name:            e.cfg.LeaseName,
+			Namespace:       e.cfg.Namespace,
+			ResourceVersion: resourceVersion,
+		},
+		Spec: spec,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal lease: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp("", "leader-lease-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		if removeErr := os.Remove(tmpFile.Name()); removeErr != nil {
+			logger.Get().Error("Failed to remove temporary file", "error", removeErr, "file", tmpFile.Name())
+		}
+	}()
+	if _, err := tmpFile.Write(manifest); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	_, err = commands.NewCommandBuilder("kubectl").
+		WithArgs(verb, "-f", tmpFile.Name()).
+		WithKubeconfig(e.cfg.Kubeconfig).
+		Execute(ctx)
+	return err
+}
@@ -0,0 +1,158 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var getLeaseArgs = []string{"get", "lease", "tools-leader", "-n", "kagent", "-o", "json", "--ignore-not-found"}
+
+func newTestElector() *Elector {
+	return NewElector(Config{
+		Enabled:       true,
+		Namespace:     "kagent",
+		LeaseName:     "tools-leader",
+		Identity:      "pod-a",
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+	})
+}
+
+func leaseJSON(holder string, renewSeconds int) string {
+	return fmt.Sprintf(`{"apiVersion":"coordination.k8s.io/v1","kind":"Lease","metadata":{"name":"tools-leader","namespace":"kagent","resourceVersion":"42"},"spec":{"holderIdentity":%q,"leaseDurationSeconds":15,"renewTime":"2024-01-01T00:00:%02d.000000Z","leaseTransitions":1}}`, holder, renewSeconds)
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv(LeaderElectionEnabled, "true")
+	t.Setenv(LeaderElectionNamespace, "")
+	t.Setenv(LeaderElectionIdentity, "")
+	t.Setenv(LeaderElectionRetryPeriod, "invalid")
+	t.Setenv("KAGENT_NAMESPACE", "kagent")
+	t.Setenv("POD_NAME", "pod-a")
+
+	cfg := LoadConfig("/tmp/kubeconfig")
+	assert.True(t, cfg.Enabled)
+	assert.Equal(t, "kagent", cfg.Namespace)
+	assert.Equal(t, defaultLeaseName, cfg.LeaseName)
+	assert.Equal(t, "pod-a", cfg.Identity)
+	assert.Equal(t, defaultRetryPeriod, cfg.RetryPeriod)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.RenewDeadline = cfg.LeaseDuration
+	assert.Error(t, cfg.Validate())
+}
+
+func TestTryAcquireOrRenew(t *testing.T) {
+	t.Run("creates missing lease", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", getLeaseArgs, "", nil)
+		mock.AddPartialMatcherString("kubectl", []string{"create", "-f", "leader-lease-"}, "lease created", nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		assert.True(t, newTestElector().tryAcquireOrRenew(ctx))
+		assert.Len(t, mock.GetCallLog(), 2)
+	})
+
+	t.Run("does not take over a lease held by another replica", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", getLeaseArgs, leaseJSON("pod-b", 0), nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		assert.False(t, newTestElector().tryAcquireOrRenew(ctx))
+		assert.Len(t, mock.GetCallLog(), 1)
+	})
+
+	t.Run("takes over an expired lease", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", getLeaseArgs, leaseJSON("pod-b", 0), nil)
+		mock.AddPartialMatcherString("kubectl", []string{"replace", "-f", "leader-lease-"}, "lease replaced", nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		elector := newTestElector()
+		now := time.Now()
+		elector.now = func() time.Time { return now }
+		assert.False(t, elector.tryAcquireOrRenew(ctx))
+
+		// The holder has not renewed for longer than the lease duration
+		now = now.Add(16 * time.Second)
+		assert.True(t, elector.tryAcquireOrRenew(ctx))
+		assert.Equal(t, "pod-a", elector.observedSpec.HolderIdentity)
+		assert.Equal(t, 2, elector.observedSpec.LeaseTransitions)
+	})
+
+	t.Run("renews its own lease", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", getLeaseArgs, leaseJSON("pod-a", 0), nil)
+		mock.AddPartialMatcherString("kubectl", []string{"replace", "-f", "leader-lease-"}, "lease replaced", nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		elector := newTestElector()
+		assert.True(t, elector.tryAcquireOrRenew(ctx))
+		assert.Equal(t, 1, elector.observedSpec.LeaseTransitions)
+	})
+
+	t.Run("conflicting update loses the election", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", getLeaseArgs, leaseJSON("", 0), nil)
+		mock.AddPartialMatcherString("kubectl", []string{"replace", "-f", "leader-lease-"}, "", errors.New("the object has been modified"))
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		assert.False(t, newTestElector().tryAcquireOrRenew(ctx))
+	})
+}
+
+func TestRunStartsAndStopsTasks(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", getLeaseArgs, "", nil)
+	mock.AddPartialMatcherString("kubectl", []string{"create", "-f", "leader-lease-"}, "lease created", nil)
+	ctx, cancel := context.WithCancel(cmd.WithShellExecutor(context.Background(), mock))
+
+	elector := newTestElector()
+	var running atomic.Int32
+	elector.Go(func(ctx context.Context) {
+		running.Add(1)
+		<-ctx.Done()
+		running.Add(-1)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		elector.Run(ctx)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool { return running.Load() == 1 }, time.Second, 10*time.Millisecond)
+	assert.True(t, elector.IsLeader())
+
+	cancel()
+	<-done
+	assert.Equal(t, int32(0), running.Load())
+	assert.False(t, elector.IsLeader())
+}
+
+func TestRunWithElectionDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	elector := NewElector(Config{})
+	started := make(chan struct{})
+	elector.Go(func(ctx context.Context) { close(started) })
+
+	done := make(chan struct{})
+	go func() {
+		elector.Run(ctx)
+		close(done)
+	}()
+
+	<-started
+	assert.Eventually(t, elector.IsLeader, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+}
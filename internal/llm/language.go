@@ -0,0 +1,137 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LLMLanguage is the language generated text is written in when neither the
+// call nor the session asks for one, e.g. fr or Japanese
+const LLMLanguage = "LLM_LANGUAGE"
+
+// English is the language of generated text by default, and of the fixed text
+// tools return, such as report headings and knowledge base explanations
+const English = "English"
+
+// languages maps ISO 639-1 codes to the languages generated text can be written in
+var languages = map[string]string{
+	"ar": "Arabic",
+	"cs": "Czech",
+	"da": "Danish",
+	"de": "German",
+	"el": "Greek",
+	"en": English,
+	"es": "Spanish",
+	"fi": "Finnish",
+	"fr": "French",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"hu": "Hungarian",
+	"id": "Indonesian",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"no": "Norwegian",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ro": "Romanian",
+	"ru": "Russian",
+	"sv": "Swedish",
+	"th": "Thai",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"vi": "Vietnamese",
+	"zh": "Chinese",
+}
+
+// regions names the regional variants whose differences matter in writing
+var regions = map[string]string{
+	"pt-br":   "Brazilian Portuguese",
+	"pt-pt":   "European Portuguese",
+	"zh-cn":   "Simplified Chinese",
+	"zh-hans": "Simplified Chinese",
+	"zh-hant": "Traditional Chinese",
+	"zh-tw":   "Traditional Chinese",
+}
+
+// NormalizeLanguage returns the name of a language given as an ISO 639-1 code,
+// a locale such as pt-BR or fr_CA, or its English name. Only known languages
+// are accepted, as the name is inserted into prompts.
+func NormalizeLanguage(language string) (string, error) {
+	tag := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(language), "_", "-"))
+	if tag == "" {
+		return "", nil
+	}
+	if name, ok := regions[tag]; ok {
+		return name, nil
+	}
+	code, _, _ := strings.Cut(tag, "-")
+	if name, ok := languages[code]; ok {
+		return name, nil
+	}
+	for _, name := range languages {
+		if strings.EqualFold(name, tag) {
+			return name, nil
+		}
+	}
+	for _, name := range regions {
+		if strings.EqualFold(name, tag) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported language %q, use one of %s", language, strings.Join(languageCodes(), ", "))
+}
+
+// ValidateLanguage checks that a language parameter names a supported language
+func ValidateLanguage(language string) error {
+	_, err := NormalizeLanguage(language)
+	return err
+}
+
+// languageCodes returns the supported language codes in order
+func languageCodes() []string {
+	codes := make([]string, 0, len(languages))
+	for code := range languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+type languageKey struct{}
+
+// WithLanguage returns a context in which text is generated in the given
+// language, which must have been normalized
+func WithLanguage(ctx context.Context, language string) context.Context {
+	return context.WithValue(ctx, languageKey{}, language)
+}
+
+// Language returns the language text is generated in: the one of the context,
+// else LLM_LANGUAGE, else English
+func Language(ctx context.Context) string {
+	if language, _ := ctx.Value(languageKey{}).(string); language != "" {
+		return language
+	}
+	if language, err := NormalizeLanguage(os.Getenv(LLMLanguage)); err == nil && language != "" {
+		return language
+	}
+	return English
+}
+
+// LanguageInstruction returns the prompt instruction to respond in the
+// language of the context, or an empty string for English. Identifiers stay
+// in English so that responses still match their schemas and commands run.
+func LanguageInstruction(ctx context.Context) string {
+	language := Language(ctx)
+	if language == English {
+		return ""
+	}
+	return fmt.Sprintf(`
+
+Write all prose of your response, including the text of JSON string values, in %s.
+Keep JSON keys, enumerated values, Kubernetes resource names, commands and code in English.`, language)
+}
@@ -0,0 +1,154 @@
+// Package llm configures the LLM used by generation and analysis tools. Any
+// OpenAI-compatible endpoint can serve it, such as vLLM, LM Studio or the
+// llama.cpp server.
+package llm
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+
+	"github.com/kagent-dev/tools/internal/budget"
+)
+
+// Environment variables configuring the LLM
+const (
+	// LLMBaseURL is the base URL of an OpenAI-compatible API, e.g. http://localhost:8000/v1
+	LLMBaseURL = "LLM_BASE_URL"
+	// LLMModel is the model requested from the API
+	LLMModel = "LLM_MODEL"
+	// LLMAPIKey is the API key, falling back to OPENAI_API_KEY
+	LLMAPIKey = "LLM_API_KEY"
+	// LLMEmbeddingModel is the embedding model requested from the API
+	LLMEmbeddingModel = "LLM_EMBEDDING_MODEL"
+)
+
+// DefaultModel is the model requested when LLM_MODEL is unset
+const DefaultModel = "gpt-4o-mini"
+
+// localAPIKey is sent to OpenAI-compatible endpoints configured without a key,
+// as local servers usually accept any key but the client requires one
+const localAPIKey = "unused"
+
+// ErrEmptyResponse is returned when the model returns no choices
+var ErrEmptyResponse = errors.New("empty response from model")
+
+// ModelName returns the configured model
+func ModelName() string {
+	if model := os.Getenv(LLMModel); model != "" {
+		return model
+	}
+	return DefaultModel
+}
+
+// Configured reports whether an API key or an OpenAI-compatible endpoint is set
+func Configured() bool {
+	return os.Getenv(LLMBaseURL) != "" || os.Getenv(LLMAPIKey) != "" || os.Getenv("OPENAI_API_KEY") != ""
+}
+
+// New creates a client of the configured endpoint whose calls are metered
+// against the budget of the calling session. Settings are read from the
+// environment on every call so that reloaded keys take effect.
+func New() (llms.Model, error) {
+	model, err := openai.New(append(clientOptions(), openai.WithModel(ModelName()))...)
+	if err != nil {
+		return nil, err
+	}
+	return budget.Model(model), nil
+}
+
+// NewEmbedder creates an embedder of the configured endpoint, or returns nil
+// when no endpoint or API key is configured
+func NewEmbedder() (embeddings.Embedder, error) {
+	if !Configured() {
+		return nil, nil
+	}
+	opts := clientOptions()
+	if model := os.Getenv(LLMEmbeddingModel); model != "" {
+		opts = append(opts, openai.WithEmbeddingModel(model))
+	}
+	client, err := openai.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	embedder, err := embeddings.NewEmbedder(client)
+	if err != nil {
+		return nil, err
+	}
+	return embedder, nil
+}
+
+// clientOptions returns the endpoint and key options of the configured API
+func clientOptions() []openai.Option {
+	var opts []openai.Option
+	baseURL := os.Getenv(LLMBaseURL)
+	if baseURL != "" {
+		opts = append(opts, openai.WithBaseURL(baseURL))
+	}
+	apiKey := os.Getenv(LLMAPIKey)
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if apiKey == "" && baseURL != "" {
+		apiKey = localAPIKey
+	}
+	if apiKey != "" {
+		opts = append(opts, openai.WithToken(apiKey))
+	}
+	return opts
+}
+
+// envModel is a model that creates a client of the configured endpoint per
+// call, so keys and endpoints reloaded from KAGENT_CONFIG_FILE take effect
+type envModel struct{}
+
+// FromEnv returns a model of the configured endpoint, or nil when no endpoint
+// or API key is configured
+func FromEnv() llms.Model {
+	if !Configured() {
+		return nil
+	}
+	return envModel{}
+}
+
+// GenerateContent implements llms.Model
+func (envModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	model, err := New()
+	if err != nil {
+		return nil, err
+	}
+	return model.GenerateContent(ctx, messages, options...)
+}
+
+// Call implements llms.Model
+func (m envModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// Generate returns the model's reply to a conversation, using the model routed
+// for the task. When stream is not nil the reply is streamed and each chunk is
+// passed to it as it arrives.
+func Generate(ctx context.Context, model llms.Model, task string, contents []llms.MessageContent, stream func(chunk string)) (string, error) {
+	opts := []llms.CallOption{llms.WithModel(RouteModel(ctx, task))}
+	if stream != nil {
+		opts = append(opts, llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+			if len(chunk) > 0 {
+				stream(string(chunk))
+			}
+			return nil
+		}))
+	}
+
+	resp, err := model.GenerateContent(ctx, contents, opts...)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) < 1 {
+		return "", ErrEmptyResponse
+	}
+	return resp.Choices[0].Content, nil
+}
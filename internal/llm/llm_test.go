@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// newCompatibleServer serves an OpenAI-compatible chat completions API that
+// streams the reply in chunks
+func newCompatibleServer(t *testing.T, chunks []string) (*httptest.Server, *http.Request) {
+	var received http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = *r
+		var body struct {
+			Model  string `json:"model"`
+			Stream bool   `json:"stream"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "qwen2.5-coder", body.Model)
+		assert.True(t, body.Stream)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			_, _ = fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", chunk)
+		}
+		_, _ = fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+func TestModelName(t *testing.T) {
+	t.Setenv(LLMModel, "")
+	assert.Equal(t, DefaultModel, ModelName())
+	t.Setenv(LLMModel, "llama3")
+	assert.Equal(t, "llama3", ModelName())
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(LLMBaseURL, "")
+	t.Setenv(LLMAPIKey, "")
+	t.Setenv("OPENAI_API_KEY", "")
+	assert.Nil(t, FromEnv())
+
+	t.Setenv(LLMBaseURL, "http://localhost:8000/v1")
+	assert.NotNil(t, FromEnv())
+}
+
+func TestNewEmbedder(t *testing.T) {
+	t.Setenv(LLMBaseURL, "")
+	t.Setenv(LLMAPIKey, "")
+	t.Setenv("OPENAI_API_KEY", "")
+	embedder, err := NewEmbedder()
+	require.NoError(t, err)
+	assert.Nil(t, embedder)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "/embeddings", r.URL.Path)
+		assert.Equal(t, "nomic-embed-text", body.Model)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.5,0.25]}]}`)
+	}))
+	defer server.Close()
+	t.Setenv(LLMBaseURL, server.URL)
+	t.Setenv(LLMEmbeddingModel, "nomic-embed-text")
+
+	embedder, err = NewEmbedder()
+	require.NoError(t, err)
+	require.NotNil(t, embedder)
+	embedding, err := embedder.EmbedQuery(context.Background(), "pod is OOMKilled")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.5, 0.25}, embedding)
+}
+
+func TestGenerateStreamsFromCompatibleEndpoint(t *testing.T) {
+	server, received := newCompatibleServer(t, []string{"rate(", "http_requests_total", "[5m])"})
+	t.Setenv(LLMBaseURL, server.URL)
+	t.Setenv(LLMModel, "qwen2.5-coder")
+	t.Setenv(LLMAPIKey, "")
+	t.Setenv("OPENAI_API_KEY", "")
+
+	var streamed []string
+	contents := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "request rate")}
+	reply, err := Generate(context.Background(), FromEnv(), TaskQuery, contents, func(chunk string) {
+		streamed = append(streamed, chunk)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "rate(http_requests_total[5m])", reply)
+	assert.Equal(t, []string{"rate(", "http_requests_total", "[5m])"}, streamed)
+	assert.Equal(t, "/chat/completions", received.URL.Path)
+	assert.Equal(t, "Bearer "+localAPIKey, received.Header.Get("Authorization"))
+}
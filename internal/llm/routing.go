@@ -0,0 +1,156 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kagent-dev/tools/internal/logger"
+)
+
+// Environment variables configuring model routing
+const (
+	// LLMModelFast is the cheap model of routine tasks, defaulting to LLM_MODEL
+	LLMModelFast = "LLM_MODEL_FAST"
+	// LLMModelStrong is the model of critical tasks, defaulting to LLM_MODEL
+	LLMModelStrong = "LLM_MODEL_STRONG"
+	// LLMRouting overrides routes of the routing table, as comma-separated
+	// key=target pairs where the key is a task or severity and the target a
+	// tier or model name, e.g. "summary=fast,medium=strong,query=llama3"
+	LLMRouting = "LLM_ROUTING"
+)
+
+// Tasks the LLM is used for
+const (
+	// TaskAnalysis is the analysis of alerts, routed by their severity
+	TaskAnalysis = "analysis"
+	// TaskRemediation is the generation of remediation scripts
+	TaskRemediation = "remediation"
+	// TaskExplanation is the explanation of errors
+	TaskExplanation = "explanation"
+	// TaskSummary is the narrative of operations summaries, which only formats
+	// data already computed
+	TaskSummary = "summary"
+	// TaskQuery is the generation of PromQL queries
+	TaskQuery = "query"
+	// TaskManifest is the generation of Kubernetes manifests
+	TaskManifest = "manifest"
+)
+
+// Tiers of models a route selects
+const (
+	TierFast    = "fast"
+	TierDefault = "default"
+	TierStrong  = "strong"
+)
+
+// defaultRoutes sends remediation and critical analyses to the strong model
+// and formatting and low-severity work to the fast one. Task routes take
+// precedence over severity routes.
+var defaultRoutes = map[string]string{
+	TaskRemediation: TierStrong,
+	TaskSummary:     TierFast,
+	"critical":      TierStrong,
+	"high":          TierStrong,
+	"low":           TierFast,
+}
+
+// Route is the model chosen for an LLM call and why
+type Route struct {
+	Task     string `json:"task"`
+	Severity string `json:"severity,omitempty"`
+	// Rule is the routing table key that matched, empty when none did
+	Rule  string `json:"rule,omitempty"`
+	Tier  string `json:"tier"`
+	Model string `json:"model"`
+}
+
+// Routes returns the routing table: the default routes overridden by LLM_ROUTING
+func Routes() map[string]string {
+	routes := make(map[string]string, len(defaultRoutes))
+	for key, target := range defaultRoutes {
+		routes[key] = target
+	}
+	for _, entry := range strings.Split(os.Getenv(LLMRouting), ",") {
+		key, target, ok := strings.Cut(entry, "=")
+		key, target = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(target)
+		if !ok || key == "" || target == "" {
+			if strings.TrimSpace(entry) != "" {
+				logger.Get().Warn("Ignoring invalid LLM route", "route", entry)
+			}
+			continue
+		}
+		routes[key] = target
+	}
+	return routes
+}
+
+// tierModel returns the model of a tier, falling back to LLM_MODEL
+func tierModel(tier string) string {
+	var model string
+	switch tier {
+	case TierFast:
+		model = os.Getenv(LLMModelFast)
+	case TierStrong:
+		model = os.Getenv(LLMModelStrong)
+	}
+	if model == "" {
+		return ModelName()
+	}
+	return model
+}
+
+// ResolveRoute returns the model of a task at a severity, which may be empty
+func ResolveRoute(task, severity string) Route {
+	route := Route{Task: task, Severity: strings.ToLower(severity), Tier: TierDefault}
+	routes := Routes()
+	target := ""
+	for _, key := range []string{task, route.Severity} {
+		if key == "" {
+			continue
+		}
+		if t, ok := routes[key]; ok {
+			route.Rule, target = key, t
+			break
+		}
+	}
+
+	switch target {
+	case "", TierDefault:
+		route.Model = ModelName()
+	case TierFast, TierStrong:
+		route.Tier, route.Model = target, tierModel(target)
+	default:
+		// Targets other than tiers name the model directly
+		route.Tier, route.Model = "", target
+	}
+	return route
+}
+
+type severityKey struct{}
+
+// WithSeverity returns a context in which LLM calls are routed by the given
+// severity, such as the severity of the alert being analyzed
+func WithSeverity(ctx context.Context, severity string) context.Context {
+	return context.WithValue(ctx, severityKey{}, severity)
+}
+
+// RouteModel returns the model of a task at the severity of the context,
+// recording the decision on the current span and in the logs
+func RouteModel(ctx context.Context, task string) string {
+	severity, _ := ctx.Value(severityKey{}).(string)
+	route := ResolveRoute(task, severity)
+
+	trace.SpanFromContext(ctx).AddEvent("llm.route", trace.WithAttributes(
+		attribute.String("llm.task", route.Task),
+		attribute.String("llm.severity", route.Severity),
+		attribute.String("llm.route.rule", route.Rule),
+		attribute.String("llm.route.tier", route.Tier),
+		attribute.String("llm.model", route.Model),
+	))
+	logger.Get().Debug("Routed LLM call", "task", route.Task, "severity", route.Severity, "rule", route.Rule, "tier", route.Tier, "model", route.Model)
+	return route.Model
+}
@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestResolveRoute(t *testing.T) {
+	t.Setenv(LLMModel, "gpt-4o-mini")
+	t.Setenv(LLMModelFast, "gpt-4.1-nano")
+	t.Setenv(LLMModelStrong, "gpt-4o")
+	t.Setenv(LLMRouting, "")
+
+	assert.Equal(t, Route{Task: TaskAnalysis, Severity: "critical", Rule: "critical", Tier: TierStrong, Model: "gpt-4o"}, ResolveRoute(TaskAnalysis, "Critical"))
+	assert.Equal(t, Route{Task: TaskAnalysis, Severity: "low", Rule: "low", Tier: TierFast, Model: "gpt-4.1-nano"}, ResolveRoute(TaskAnalysis, "Low"))
+	assert.Equal(t, Route{Task: TaskAnalysis, Severity: "medium", Tier: TierDefault, Model: "gpt-4o-mini"}, ResolveRoute(TaskAnalysis, "Medium"))
+	// Task routes take precedence over severity routes
+	assert.Equal(t, TierStrong, ResolveRoute(TaskRemediation, "Low").Tier)
+	assert.Equal(t, TierFast, ResolveRoute(TaskSummary, "").Tier)
+
+	t.Setenv(LLMRouting, "medium=strong, summary=default,query=llama3,invalid")
+	assert.Equal(t, "gpt-4o", ResolveRoute(TaskAnalysis, "Medium").Model)
+	assert.Equal(t, "gpt-4o-mini", ResolveRoute(TaskSummary, "").Model)
+	assert.Equal(t, Route{Task: TaskQuery, Rule: TaskQuery, Model: "llama3"}, ResolveRoute(TaskQuery, ""))
+
+	// Tiers without a model of their own use LLM_MODEL
+	t.Setenv(LLMModelStrong, "")
+	assert.Equal(t, "gpt-4o-mini", ResolveRoute(TaskRemediation, "").Model)
+}
+
+func TestRouteModelRecordsDecision(t *testing.T) {
+	t.Setenv(LLMModel, "gpt-4o-mini")
+	t.Setenv(LLMModelStrong, "gpt-4o")
+	t.Setenv(LLMRouting, "")
+
+	recorder := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(context.Background(), "tool")
+	model := RouteModel(WithSeverity(ctx, "High"), TaskAnalysis)
+	span.End()
+
+	assert.Equal(t, "gpt-4o", model)
+	require.Len(t, recorder.Ended(), 1)
+	events := recorder.Ended()[0].Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "llm.route", events[0].Name)
+	attributes := map[string]string{}
+	for _, attribute := range events[0].Attributes {
+		attributes[string(attribute.Key)] = attribute.Value.AsString()
+	}
+	assert.Equal(t, "high", attributes["llm.severity"])
+	assert.Equal(t, TierStrong, attributes["llm.route.tier"])
+	assert.Equal(t, "gpt-4o", attributes["llm.model"])
+}
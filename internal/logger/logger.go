@@ -2,6 +2,7 @@ package logger
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 
@@ -40,6 +41,51 @@ func InitWithEnv() {
 	Init(useStderr)
 }
 
+// AddHandler sends log records to handler in addition to the configured
+// output, e.g. to export them to an OpenTelemetry collector
+func AddHandler(handler slog.Handler) {
+	globalLogger = slog.New(fanoutHandler{Get().Handler(), handler})
+	slog.SetDefault(globalLogger)
+}
+
+// fanoutHandler passes log records to several handlers
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
+
 func Get() *slog.Logger {
 	if globalLogger == nil {
 		InitWithEnv()
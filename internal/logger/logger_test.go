@@ -71,3 +71,23 @@ func TestInit(t *testing.T) {
 func TestSync(t *testing.T) {
 	assert.NotPanics(t, Sync)
 }
+
+func TestAddHandler(t *testing.T) {
+	previous := globalLogger
+	defer func() {
+		globalLogger = previous
+		slog.SetDefault(Get())
+	}()
+
+	var console, exported bytes.Buffer
+	globalLogger = slog.New(slog.NewJSONHandler(&console, nil))
+	AddHandler(slog.NewJSONHandler(&exported, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	Get().With("tool", "k8s_get_resources").Info("info")
+	Get().Warn("warning")
+
+	assert.Contains(t, console.String(), `"tool":"k8s_get_resources"`)
+	assert.Contains(t, console.String(), "warning")
+	assert.NotContains(t, exported.String(), "info")
+	assert.Contains(t, exported.String(), "warning")
+}
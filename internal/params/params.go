@@ -0,0 +1,293 @@
+// Package params parses MCP tool arguments against declarative specs,
+// reporting every invalid field in one consistent validation error.
+package params
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// FieldError describes why a single parameter is invalid
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s parameter %s", e.Field, e.Message)
+}
+
+// ValidationError lists every invalid parameter of a request
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// spec holds the constraints declared for a parameter
+type spec struct {
+	required  bool
+	allowZero bool
+	enum      []string
+	min       *int
+	max       *int
+	checks    []func(string) error
+}
+
+// Option declares a constraint on a parameter
+type Option func(*spec)
+
+// Required rejects a missing or empty parameter
+func Required() Option {
+	return func(s *spec) { s.required = true }
+}
+
+// OneOf restricts a string parameter to the given values
+func OneOf(values ...string) Option {
+	return func(s *spec) { s.enum = values }
+}
+
+// Min sets the smallest accepted value of an integer parameter
+func Min(n int) Option {
+	return func(s *spec) { s.min = &n }
+}
+
+// Max sets the largest accepted value of an integer parameter
+func Max(n int) Option {
+	return func(s *spec) { s.max = &n }
+}
+
+// Range restricts an integer parameter to [min, max]
+func Range(min, max int) Option {
+	return func(s *spec) {
+		s.min = &min
+		s.max = &max
+	}
+}
+
+// AllowZero accepts a zero duration parameter
+func AllowZero() Option {
+	return func(s *spec) { s.allowZero = true }
+}
+
+// Check runs a validator, such as those in the security package, on a non-empty string parameter
+func Check(fn func(string) error) Option {
+	return func(s *spec) { s.checks = append(s.checks, fn) }
+}
+
+// Parser reads the arguments of a tool request, accumulating field errors
+type Parser struct {
+	args   map[string]interface{}
+	errors []FieldError
+}
+
+// New creates a parser for the arguments of a tool request
+func New(request mcp.CallToolRequest) *Parser {
+	return &Parser{args: request.GetArguments()}
+}
+
+// FromArgs creates a parser for raw tool arguments, for middleware that
+// inspects a call before its handler runs
+func FromArgs(args map[string]interface{}) *Parser {
+	return &Parser{args: args}
+}
+
+// Err returns a *ValidationError listing every invalid parameter, or nil
+func (p *Parser) Err() error {
+	if len(p.errors) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: p.errors}
+}
+
+// fail records a field error
+func (p *Parser) fail(field, format string, args ...interface{}) {
+	p.errors = append(p.errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// raw returns the argument value, treating nil and empty strings as absent
+func (p *Parser) raw(name string) (interface{}, bool) {
+	value, ok := p.args[name]
+	if !ok || value == nil {
+		return nil, false
+	}
+	if s, isString := value.(string); isString && s == "" {
+		return nil, false
+	}
+	return value, true
+}
+
+// newSpec applies the options of a parameter
+func newSpec(opts []Option) spec {
+	var s spec
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// defaultChecks validate well-known parameters whichever tool reads them, so
+// a malformed name or namespace never reaches a command line
+var defaultChecks = map[string]func(string) error{
+	"annotation_key": security.ValidateK8sKey,
+	"container":      security.ValidateK8sResourceName,
+	"label_key":      security.ValidateK8sKey,
+	"namespace":      security.ValidateNamespaceName,
+	"pod_name":       security.ValidateK8sObjectName,
+	"resource_name":  security.ValidateK8sObjectName,
+	"resource_type":  security.ValidateK8sResourceType,
+}
+
+// String returns a string parameter, or def when it is absent. Parameters
+// named in defaultChecks are validated before the checks of opts.
+func (p *Parser) String(name, def string, opts ...Option) string {
+	s := newSpec(opts)
+	value, ok := p.raw(name)
+	if !ok {
+		if s.required {
+			p.fail(name, "is required")
+		}
+		return def
+	}
+
+	str, isString := value.(string)
+	if !isString {
+		str = fmt.Sprint(value)
+	}
+
+	if len(s.enum) > 0 && !slices.Contains(s.enum, str) {
+		p.fail(name, "must be one of: %s", strings.Join(s.enum, ", "))
+		return def
+	}
+	checks := s.checks
+	if check, ok := defaultChecks[name]; ok {
+		checks = append([]func(string) error{check}, checks...)
+	}
+	for _, check := range checks {
+		if err := check(str); err != nil {
+			p.fail(name, "is invalid: %s", checkMessage(err))
+			return def
+		}
+	}
+	return str
+}
+
+// checkMessage strips the field prefix of security validation errors
+func checkMessage(err error) string {
+	var validationErr security.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Message
+	}
+	return err.Error()
+}
+
+// Int returns an integer parameter, or def when it is absent. Numbers may be
+// given as JSON numbers or numeric strings.
+func (p *Parser) Int(name string, def int, opts ...Option) int {
+	s := newSpec(opts)
+	value, ok := p.raw(name)
+	if !ok {
+		if s.required {
+			p.fail(name, "is required")
+		}
+		return def
+	}
+
+	var n int
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			p.fail(name, "must be an integer")
+			return def
+		}
+		n = int(v)
+	case int:
+		n = v
+	case int64:
+		n = int(v)
+	case string:
+		parsed, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			p.fail(name, "must be an integer")
+			return def
+		}
+		n = parsed
+	default:
+		p.fail(name, "must be an integer")
+		return def
+	}
+
+	switch {
+	case s.min != nil && s.max != nil && (n < *s.min || n > *s.max):
+		p.fail(name, "must be between %d and %d", *s.min, *s.max)
+		return def
+	case s.min != nil && n < *s.min:
+		p.fail(name, "must be at least %d", *s.min)
+		return def
+	case s.max != nil && n > *s.max:
+		p.fail(name, "must be at most %d", *s.max)
+		return def
+	}
+	return n
+}
+
+// Bool returns a boolean parameter, or def when it is absent. Booleans may be
+// given as JSON booleans or strings such as "true" and "false".
+func (p *Parser) Bool(name string, def bool) bool {
+	value, ok := p.raw(name)
+	if !ok {
+		return def
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err == nil {
+			return parsed
+		}
+	}
+	p.fail(name, "must be true or false")
+	return def
+}
+
+// Duration returns a positive duration parameter such as "30s" or "5m", or
+// def when it is absent. Zero is only accepted with AllowZero.
+func (p *Parser) Duration(name string, def time.Duration, opts ...Option) time.Duration {
+	s := newSpec(opts)
+	value, ok := p.raw(name)
+	if !ok {
+		if s.required {
+			p.fail(name, "is required")
+		}
+		return def
+	}
+
+	str, isString := value.(string)
+	if !isString {
+		p.fail(name, "must be a duration such as 30s or 5m")
+		return def
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(str))
+	if err != nil || d < 0 || (d == 0 && !s.allowZero) {
+		p.fail(name, "must be a positive duration such as 30s or 5m")
+		return def
+	}
+	return d
+}
@@ -0,0 +1,115 @@
+package params
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+func newParser(args map[string]interface{}) *Parser {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	return New(request)
+}
+
+func TestParserValidRequest(t *testing.T) {
+	p := newParser(map[string]interface{}{
+		"name":      "web",
+		"namespace": "",
+		"format":    "html",
+		"limit":     float64(10),
+		"tail":      "25",
+		"confirm":   "true",
+		"force":     false,
+		"timeout":   "2m",
+	})
+
+	assert.Equal(t, "web", p.String("name", "", Required(), Check(security.ValidateK8sResourceName)))
+	assert.Equal(t, "default", p.String("namespace", "default"))
+	assert.Equal(t, "html", p.String("format", "markdown", OneOf("markdown", "html")))
+	assert.Equal(t, 10, p.Int("limit", 20, Range(1, 100)))
+	assert.Equal(t, 25, p.Int("tail", 50, Min(0)))
+	assert.Equal(t, 5, p.Int("missing", 5))
+	assert.True(t, p.Bool("confirm", false))
+	assert.False(t, p.Bool("force", true))
+	assert.Equal(t, 2*time.Minute, p.Duration("timeout", time.Minute))
+	assert.Equal(t, time.Minute, p.Duration("missing", time.Minute))
+	assert.NoError(t, p.Err())
+}
+
+func TestParserFieldErrors(t *testing.T) {
+	p := newParser(map[string]interface{}{
+		"name":      "Not_Valid",
+		"format":    "pdf",
+		"limit":     float64(500),
+		"tail":      "many",
+		"replicas":  float64(1.5),
+		"confirm":   "yes",
+		"timeout":   "soon",
+		"min_count": float64(-1),
+	})
+
+	assert.Equal(t, "", p.String("name", "", Check(security.ValidateK8sResourceName)))
+	p.String("pod_name", "", Required())
+	assert.Equal(t, "markdown", p.String("format", "markdown", OneOf("markdown", "html")))
+	assert.Equal(t, 20, p.Int("limit", 20, Range(1, 100)))
+	p.Int("tail", 50)
+	p.Int("replicas", 1)
+	p.Int("min_count", 0, Min(0))
+	p.Bool("confirm", false)
+	p.Duration("timeout", time.Minute)
+
+	err := p.Err()
+	require.Error(t, err)
+	validationErr, ok := err.(*ValidationError)
+	require.True(t, ok)
+	assert.Equal(t, []FieldError{
+		{Field: "name", Message: "is invalid: must follow RFC 1123 naming convention"},
+		{Field: "pod_name", Message: "is required"},
+		{Field: "format", Message: "must be one of: markdown, html"},
+		{Field: "limit", Message: "must be between 1 and 100"},
+		{Field: "tail", Message: "must be an integer"},
+		{Field: "replicas", Message: "must be an integer"},
+		{Field: "min_count", Message: "must be at least 0"},
+		{Field: "confirm", Message: "must be true or false"},
+		{Field: "timeout", Message: "must be a positive duration such as 30s or 5m"},
+	}, validationErr.Fields)
+	assert.Contains(t, err.Error(), "pod_name parameter is required; format parameter must be one of: markdown, html")
+}
+
+func TestParserDefaultChecks(t *testing.T) {
+	p := newParser(map[string]interface{}{
+		"namespace":      "kube-system",
+		"resource_type":  "deployments.apps",
+		"resource_name":  "certificates.cert-manager.io",
+		"label_key":      "app.kubernetes.io/name",
+		"annotation_key": "--all",
+		"pod_name":       "web -A",
+		"container":      "app",
+	})
+	// Static pod names carry the node name
+	assert.Equal(t, "kube-apiserver-cp-1.example.com", newParser(map[string]interface{}{
+		"pod_name": "kube-apiserver-cp-1.example.com",
+	}).String("pod_name", ""))
+
+	assert.Equal(t, "kube-system", p.String("namespace", ""))
+	assert.Equal(t, "deployments.apps", p.String("resource_type", ""))
+	assert.Equal(t, "certificates.cert-manager.io", p.String("resource_name", ""))
+	assert.Equal(t, "app.kubernetes.io/name", p.String("label_key", ""))
+	assert.Equal(t, "app", p.String("container", ""))
+	assert.Equal(t, "", p.String("annotation_key", ""))
+	assert.Equal(t, "", p.String("pod_name", "", Required()))
+	// Checks of opts run after the default check
+	assert.Equal(t, "default", p.String("namespace", "default", Check(security.ValidateNamespace)))
+
+	err := p.Err()
+	require.Error(t, err)
+	assert.Equal(t, "annotation_key parameter is invalid: invalid key format; "+
+		"pod_name parameter is invalid: must start with a letter or digit and contain only letters, digits, '.', '-', '_', ':' or '@'; "+
+		"namespace parameter is invalid: 'kube-system' is a reserved namespace", err.Error())
+}
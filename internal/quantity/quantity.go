@@ -0,0 +1,73 @@
+// Package quantity parses and formats Kubernetes resource quantities without
+// depending on the Kubernetes API machinery.
+package quantity
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Quantity is a resource quantity in thousandths of its unit, so cpu is held
+// in millicores and memory in millibytes
+type Quantity int64
+
+var pattern = regexp.MustCompile(`^([+-]?(?:[0-9]+\.?[0-9]*|\.[0-9]+)(?:[eE][+-]?[0-9]+)?)([a-zA-Z]*)$`)
+
+var suffixes = map[string]float64{
+	"n": 1e-9, "u": 1e-6, "m": 1e-3, "": 1, "k": 1e3, "M": 1e6, "G": 1e9, "T": 1e12, "P": 1e15, "E": 1e18,
+	"Ki": 1 << 10, "Mi": 1 << 20, "Gi": 1 << 30, "Ti": 1 << 40, "Pi": 1 << 50, "Ei": 1 << 60,
+}
+
+// binaryUnits are the suffixes byte quantities are formatted with, largest first
+var binaryUnits = []struct {
+	suffix string
+	size   int64
+}{{"Pi", 1 << 50}, {"Ti", 1 << 40}, {"Gi", 1 << 30}, {"Mi", 1 << 20}, {"Ki", 1 << 10}}
+
+// Parse parses a quantity such as 500m, 1.5, 256Mi or 1e3
+func Parse(s string) (Quantity, error) {
+	match := pattern.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	factor, ok := suffixes[match[2]]
+	if !ok {
+		return 0, fmt.Errorf("invalid quantity %q: unknown suffix %q", s, match[2])
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q: %w", s, err)
+	}
+	milli := math.Round(value * factor * 1000)
+	if math.Abs(milli) > math.MaxInt64/2 {
+		return 0, fmt.Errorf("quantity %q is too large", s)
+	}
+	return Quantity(milli), nil
+}
+
+// Format formats a quantity of the named resource the way kubectl shows it:
+// byte resources such as memory and storage use binary suffixes when exact
+func Format(resource string, q Quantity) string {
+	if q < 0 {
+		return "-" + Format(resource, -q)
+	}
+	if q%1000 != 0 {
+		return fmt.Sprintf("%dm", int64(q))
+	}
+	value := int64(q) / 1000
+	if isBytes(resource) {
+		for _, unit := range binaryUnits {
+			if value >= unit.size && value%unit.size == 0 {
+				return fmt.Sprintf("%d%s", value/unit.size, unit.suffix)
+			}
+		}
+	}
+	return strconv.FormatInt(value, 10)
+}
+
+func isBytes(resource string) bool {
+	return strings.Contains(resource, "memory") || strings.Contains(resource, "storage") || strings.Contains(resource, "hugepages")
+}
@@ -0,0 +1,32 @@
+package quantity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for input, expected := range map[string]Quantity{
+		"500m": 500, "1": 1000, "0.1": 100, "1.5": 1500, "2k": 2000000,
+		"256Mi": 256 << 20 * 1000, "1Gi": 1 << 30 * 1000, "1e3": 1000000,
+	} {
+		q, err := Parse(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, q, input)
+	}
+	for _, input := range []string{"", "abc", "1Xi", "1.2.3"} {
+		_, err := Parse(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "1500m", Format("requests.cpu", 1500))
+	assert.Equal(t, "2", Format("limits.cpu", 2000))
+	assert.Equal(t, "1536Mi", Format("requests.memory", 1536<<20*1000))
+	assert.Equal(t, "-1Gi", Format("memory", -(1<<30*1000)))
+	assert.Equal(t, "1000", Format("limits.memory", 1000*1000))
+	assert.Equal(t, "3", Format("pods", 3000))
+}
@@ -0,0 +1,174 @@
+// Package reload reloads secrets and integration settings, such as LLM API
+// keys and alert webhooks, from a configuration file without restarting the
+// server, so rotating credentials does not drop MCP sessions.
+package reload
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/kagent-dev/tools/internal/logger"
+)
+
+// Environment variables configuring reloads
+const (
+	// ConfigFileEnv names a file of KEY=value lines, e.g. a mounted Secret, whose
+	// values override the environment and are reloaded on SIGHUP or when it changes
+	ConfigFileEnv = "KAGENT_CONFIG_FILE"
+	// ConfigReloadIntervalEnv is how often the file is checked for changes
+	ConfigReloadIntervalEnv = "KAGENT_CONFIG_RELOAD_INTERVAL"
+)
+
+// defaultReloadInterval is how often the file is checked when no interval is configured
+const defaultReloadInterval = 10 * time.Second
+
+// Reloader applies a configuration file to the environment and runs the
+// registered hooks whenever its values change
+type Reloader struct {
+	path string
+
+	mu     sync.Mutex
+	values map[string]string
+	stat   os.FileInfo
+	hooks  []func()
+}
+
+// NewReloader creates a reloader of the file at path
+func NewReloader(path string) *Reloader {
+	return &Reloader{path: path, values: map[string]string{}}
+}
+
+var global = NewReloader("")
+
+// OnReload registers a hook that is run after the configuration changed. Hooks
+// re-read the settings they depend on from the environment.
+func OnReload(hook func()) {
+	global.OnReload(hook)
+}
+
+// OnReload registers a hook that is run after the configuration changed
+func (r *Reloader) OnReload(hook func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook)
+}
+
+// Reload applies the file to the environment, unsetting variables that were
+// removed from it, and runs the hooks if any value changed. It returns the
+// names of the changed variables.
+func (r *Reloader) Reload() ([]string, error) {
+	stat, err := os.Stat(r.path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := godotenv.Read(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	var changed []string
+	for key, value := range values {
+		if previous, ok := r.values[key]; !ok || previous != value {
+			changed = append(changed, key)
+		}
+		if err := os.Setenv(key, value); err != nil {
+			r.mu.Unlock()
+			return nil, err
+		}
+	}
+	for key := range r.values {
+		if _, ok := values[key]; !ok {
+			changed = append(changed, key)
+			_ = os.Unsetenv(key)
+		}
+	}
+	r.values = values
+	r.stat = stat
+	hooks := slices.Clone(r.hooks)
+	r.mu.Unlock()
+
+	slices.Sort(changed)
+	if len(changed) > 0 {
+		for _, hook := range hooks {
+			hook()
+		}
+	}
+	return changed, nil
+}
+
+// modified reports whether the file changed since it was last loaded
+func (r *Reloader) modified() bool {
+	stat, err := os.Stat(r.path)
+	if err != nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stat == nil || !stat.ModTime().Equal(r.stat.ModTime()) || stat.Size() != r.stat.Size()
+}
+
+// reload reloads the file, logging the names of the changed variables but never their values
+func (r *Reloader) reload(reason string) {
+	changed, err := r.Reload()
+	if err != nil {
+		logger.Get().Error("Failed to reload configuration", "file", r.path, "reason", reason, "error", err)
+		return
+	}
+	if len(changed) > 0 {
+		logger.Get().Info("Reloaded configuration", "file", r.path, "reason", reason, "changed", changed)
+	}
+}
+
+// watch reloads the file on SIGHUP and when it changes, until ctx is done
+func (r *Reloader) watch(ctx context.Context, interval time.Duration) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			r.reload("SIGHUP")
+		case <-ticker.C:
+			if r.modified() {
+				r.reload("file changed")
+			}
+		}
+	}
+}
+
+// Start loads the file named by KAGENT_CONFIG_FILE and reloads it on SIGHUP
+// and when it changes, until ctx is done. It does nothing if no file is configured.
+func Start(ctx context.Context) error {
+	path := os.Getenv(ConfigFileEnv)
+	if path == "" {
+		return nil
+	}
+	interval := defaultReloadInterval
+	if value, err := time.ParseDuration(os.Getenv(ConfigReloadIntervalEnv)); err == nil && value > 0 {
+		interval = value
+	}
+
+	global.path = path
+	// Every variable of the file is new on the first load
+	variables, err := global.Reload()
+	if err != nil {
+		return err
+	}
+	logger.Get().Info("Loaded configuration", "file", path, "variables", variables, "reload_interval", interval.String())
+
+	go global.watch(ctx, interval)
+	return nil
+}
@@ -0,0 +1,58 @@
+package reload
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	// Restore the variables the file sets
+	t.Setenv("RELOAD_TEST_API_KEY", "")
+	t.Setenv("RELOAD_TEST_WEBHOOK", "")
+	t.Setenv("RELOAD_TEST_OLD", "")
+
+	path := filepath.Join(t.TempDir(), "config.env")
+	require.NoError(t, os.WriteFile(path, []byte("RELOAD_TEST_API_KEY=first\nRELOAD_TEST_OLD=gone\n"), 0o600))
+
+	reloader := NewReloader(path)
+	hooks := 0
+	reloader.OnReload(func() { hooks++ })
+
+	changed, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"RELOAD_TEST_API_KEY", "RELOAD_TEST_OLD"}, changed)
+	assert.Equal(t, "first", os.Getenv("RELOAD_TEST_API_KEY"))
+	assert.Equal(t, 1, hooks)
+	assert.False(t, reloader.modified())
+
+	// Unchanged values do not run the hooks
+	changed, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+	assert.Equal(t, 1, hooks)
+
+	require.NoError(t, os.WriteFile(path, []byte("RELOAD_TEST_API_KEY=rotated\nRELOAD_TEST_WEBHOOK=https://hooks.example.com\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	assert.True(t, reloader.modified())
+
+	changed, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"RELOAD_TEST_API_KEY", "RELOAD_TEST_OLD", "RELOAD_TEST_WEBHOOK"}, changed)
+	assert.Equal(t, "rotated", os.Getenv("RELOAD_TEST_API_KEY"))
+	assert.Equal(t, "https://hooks.example.com", os.Getenv("RELOAD_TEST_WEBHOOK"))
+	_, ok := os.LookupEnv("RELOAD_TEST_OLD")
+	assert.False(t, ok)
+	assert.Equal(t, 2, hooks)
+}
+
+func TestReloadMissingFile(t *testing.T) {
+	reloader := NewReloader(filepath.Join(t.TempDir(), "missing.env"))
+	_, err := reloader.Reload()
+	assert.Error(t, err)
+	assert.False(t, reloader.modified())
+}
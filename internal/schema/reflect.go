@@ -0,0 +1,107 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// FromType generates the schema of the JSON encoding of a Go type. Struct
+// fields are named by their json tags and are required unless tagged
+// omitempty; slices, maps and pointers that are not omitted are nullable.
+func FromType(t reflect.Type) *Schema {
+	return fromType(t, map[reflect.Type]bool{})
+}
+
+// fromType generates the schema of t; seen holds the struct types being
+// generated, so that recursive types terminate
+func fromType(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		// Custom encodings cannot be described
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is encoded as a base64 string
+			return &Schema{Type: "string"}
+		}
+		return &Schema{Type: "array", Items: fromType(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		return structSchema(t, seen)
+	default:
+		return &Schema{}
+	}
+}
+
+// structSchema generates the schema of the exported, JSON-encoded fields of a struct
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	if seen[t] {
+		return &Schema{Type: "object"}
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			// Fields of untagged embedded structs are promoted
+			embedded := structSchema(field.Type, seen)
+			for embeddedName, property := range embedded.Properties {
+				s.Properties[embeddedName] = property
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := fromType(field.Type, seen)
+		omitEmpty := strings.Contains(","+options+",", ",omitempty,")
+		switch field.Type.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Pointer:
+			property.Nullable = !omitEmpty && field.Type != timeType
+		}
+		s.Properties[name] = property
+		if !omitEmpty {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
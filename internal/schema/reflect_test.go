@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBase struct {
+	ID string `json:"id"`
+}
+
+type testItem struct {
+	Name string `json:"name"`
+}
+
+type testDocument struct {
+	testBase
+	Title     string                 `json:"title"`
+	Count     int32                  `json:"count"`
+	Score     *float64               `json:"score,omitempty"`
+	Tags      []string               `json:"tags"`
+	Items     []testItem             `json:"items,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+	Parent    *testDocument          `json:"parent,omitempty"`
+	Ignored   string                 `json:"-"`
+	internal  string
+}
+
+func TestFromType(t *testing.T) {
+	s := FromType(reflect.TypeOf(testDocument{}))
+
+	assert.Equal(t, "object", s.Type)
+	assert.ElementsMatch(t, []string{"id", "title", "count", "tags", "created_at"}, s.Required)
+	assert.Len(t, s.Properties, 9)
+	assert.Equal(t, "integer", s.Properties["count"].Type)
+	assert.Equal(t, "number", s.Properties["score"].Type)
+	assert.False(t, s.Properties["score"].Nullable)
+	assert.True(t, s.Properties["tags"].Nullable)
+	assert.Equal(t, "string", s.Properties["tags"].Items.Type)
+	assert.Equal(t, []string{"name"}, s.Properties["items"].Items.Required)
+	assert.Equal(t, "date-time", s.Properties["created_at"].Format)
+	assert.Equal(t, &Schema{Type: "object"}, s.Properties["parent"])
+	assert.NotContains(t, s.Properties, "Ignored")
+	assert.NotContains(t, s.Properties, "internal")
+}
+
+func TestFromTypeValidatesEncodedValues(t *testing.T) {
+	s := FromType(reflect.TypeOf(testDocument{}))
+	score := 0.5
+
+	for _, doc := range []testDocument{
+		{testBase: testBase{ID: "1"}, Title: "empty"},
+		{testBase: testBase{ID: "2"}, Title: "full", Score: &score, Tags: []string{"a"}, Items: []testItem{{Name: "b"}}, Data: map[string]interface{}{"k": 1}, CreatedAt: time.Now()},
+	} {
+		data, err := json.Marshal(doc)
+		require.NoError(t, err)
+		_, errs := s.ValidateJSON(string(data))
+		assert.Empty(t, errs, doc.Title)
+	}
+
+	_, errs := s.ValidateJSON(`{"id": "1", "title": "x", "count": 1.5, "tags": null, "created_at": "2024-01-01T00:00:00Z"}`)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "$.count", errs[0].Path)
+}
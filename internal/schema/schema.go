@@ -0,0 +1,230 @@
+// Package schema validates JSON documents against a subset of JSON Schema.
+//
+// Supported keywords are type, properties, required, additionalProperties
+// (boolean form), items, enum, minLength, minItems, minimum and maximum,
+// which covers the schemas used to validate structured LLM responses, and
+// the OpenAPI nullable keyword. Schemas for Go types can be generated with
+// FromType.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema is a JSON Schema document
+type Schema struct {
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+// Parse parses a JSON Schema document
+func Parse(data string) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &s, nil
+}
+
+// MustParse parses a JSON Schema document and panics if it is invalid
+func MustParse(data string) *Schema {
+	s, err := Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// String returns the schema as indented JSON, suitable for prompts
+func (s *Schema) String() string {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// ValidationError describes a value that does not match the schema
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate checks a decoded JSON value against the schema and returns every violation found
+func (s *Schema) Validate(value interface{}) []ValidationError {
+	var errs []ValidationError
+	s.validate("$", value, &errs)
+	return errs
+}
+
+// ValidateJSON decodes a JSON document and validates it against the schema
+func (s *Schema) ValidateJSON(data string) (interface{}, []ValidationError) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return nil, []ValidationError{{Path: "$", Message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+	return value, s.Validate(value)
+}
+
+func (s *Schema) validate(path string, value interface{}, errs *[]ValidationError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value == nil && s.Nullable {
+		return
+	}
+
+	if s.Type != "" && !matchesType(s.Type, value) {
+		fail("expected %s, got %s", s.Type, typeOf(value))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		fail("must be one of %s", formatEnum(s.Enum))
+	}
+
+	switch v := value.(type) {
+	case string:
+		if s.MinLength != nil && len(v) < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			fail("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			fail("must be <= %v", *s.Maximum)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(path+"."+name, v[name], errs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				fail("unexpected property %q", name)
+			}
+		}
+	}
+}
+
+// matchesType reports whether a decoded JSON value has the given schema type
+func matchesType(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return typeOf(value) == schemaType
+	}
+}
+
+// typeOf returns the JSON type name of a decoded value
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, value := range enum {
+		data, _ := json.Marshal(value)
+		values[i] = string(data)
+	}
+	return strings.Join(values, ", ")
+}
+
+// ExtractJSON returns the JSON document embedded in an LLM response, removing
+// Markdown code fences and any prose before or after the document
+func ExtractJSON(text string) string {
+	text = strings.TrimSpace(text)
+
+	if start := strings.Index(text, "```"); start >= 0 {
+		body := text[start+3:]
+		if newline := strings.Index(body, "\n"); newline >= 0 {
+			body = body[newline+1:]
+		}
+		if end := strings.Index(body, "```"); end >= 0 {
+			body = body[:end]
+		}
+		text = strings.TrimSpace(body)
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return text
+	}
+	closing := "}"
+	if text[start] == '[' {
+		closing = "]"
+	}
+	if end := strings.LastIndex(text, closing); end > start {
+		return text[start : end+1]
+	}
+	return text[start:]
+}
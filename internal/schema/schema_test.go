@@ -0,0 +1,71 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+  "type": "object",
+  "required": ["summary", "severity", "steps"],
+  "additionalProperties": false,
+  "properties": {
+    "summary": {"type": "string", "minLength": 1},
+    "severity": {"type": "string", "enum": ["low", "high"]},
+    "confidence": {"type": "number", "minimum": 0, "maximum": 1},
+    "count": {"type": "integer"},
+    "steps": {"type": "array", "minItems": 1, "items": {"type": "string"}}
+  }
+}`
+
+func TestValidateJSON(t *testing.T) {
+	s := MustParse(testSchema)
+
+	value, errs := s.ValidateJSON(`{"summary": "ok", "severity": "high", "confidence": 0.5, "count": 2, "steps": ["a"]}`)
+	assert.Empty(t, errs)
+	assert.NotNil(t, value)
+
+	_, errs = s.ValidateJSON(`{"summary": "", "severity": "medium", "confidence": 2, "count": 1.5, "steps": [], "extra": true}`)
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	assert.ElementsMatch(t, []string{
+		`$.summary: must be at least 1 characters`,
+		`$.severity: must be one of "low", "high"`,
+		`$.confidence: must be <= 1`,
+		`$.count: expected integer, got number`,
+		`$.steps: must have at least 1 items`,
+		`$: unexpected property "extra"`,
+	}, messages)
+
+	_, errs = s.ValidateJSON(`{"severity": "low", "steps": [1]}`)
+	require.Len(t, errs, 2)
+	assert.Equal(t, `$: missing required property "summary"`, errs[0].Error())
+	assert.Equal(t, `$.steps[0]: expected string, got number`, errs[1].Error())
+
+	_, errs = s.ValidateJSON(`not json`)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Message, "invalid JSON")
+}
+
+func TestParseInvalidSchema(t *testing.T) {
+	_, err := Parse(`{`)
+	assert.Error(t, err)
+	assert.Panics(t, func() { MustParse(`{`) })
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := map[string]string{
+		`{"a": 1}`:                               `{"a": 1}`,
+		"```json\n{\"a\": 1}\n```":               `{"a": 1}`,
+		"Here you go:\n```\n[1, 2]\n```\nThanks": `[1, 2]`,
+		`Sure! {"a": {"b": 2}} Let me know.`:     `{"a": {"b": 2}}`,
+		"no json here":                           "no json here",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, ExtractJSON(input), input)
+	}
+}
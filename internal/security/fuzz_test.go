@@ -0,0 +1,117 @@
+package security
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// assertArgumentSafe fails when a validator accepted a value that kubectl
+// could read as a flag or a shell could split
+func assertArgumentSafe(t *testing.T, value string) {
+	t.Helper()
+	if strings.HasPrefix(value, "-") {
+		t.Errorf("accepted %q, which starts with a dash", value)
+	}
+	if strings.ContainsAny(value, " \t\r\n;&|$`\\\"'<>()*?") {
+		t.Errorf("accepted %q, which holds whitespace or shell metacharacters", value)
+	}
+}
+
+func FuzzValidateK8sNames(f *testing.F) {
+	for _, seed := range []string{"web", "kube-system", "--all", "web -A", "certificates.cert-manager.io", "a/b", "pods,services", "x;rm -rf /", "\x00", "é"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		validators := map[string]func(string) error{
+			"ValidateK8sResourceName": ValidateK8sResourceName,
+			"ValidateNamespace":       ValidateNamespace,
+			"ValidateNamespaceName":   ValidateNamespaceName,
+			"ValidateK8sObjectName":   ValidateK8sObjectName,
+			"ValidateK8sResourceType": ValidateK8sResourceType,
+			"ValidateK8sKey":          ValidateK8sKey,
+			"ValidateHelmReleaseName": ValidateHelmReleaseName,
+		}
+		for name, validate := range validators {
+			if err := validate(value); err == nil {
+				if len(value) > 253 {
+					t.Errorf("%s accepted %d bytes", name, len(value))
+				}
+				assertArgumentSafe(t, value)
+			}
+		}
+	})
+}
+
+func FuzzValidateAssignments(f *testing.F) {
+	for _, seed := range []string{"env=prod", "a=1 b=2", "--overwrite=true", "env=prod --all", "example.com/owner=team@example.com", "=x", "a==b"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		for _, validate := range []func(string) error{ValidateLabelAssignments, ValidateAnnotationAssignments} {
+			if err := validate(value); err != nil {
+				continue
+			}
+			for _, token := range strings.Fields(value) {
+				key, _, ok := strings.Cut(token, "=")
+				if !ok {
+					t.Errorf("accepted %q, which is not a key=value assignment", token)
+				}
+				assertArgumentSafe(t, key)
+			}
+		}
+	})
+}
+
+func FuzzValidateFetchURL(f *testing.F) {
+	for _, seed := range []string{
+		"https://example.com/manifest.yaml",
+		"http://127.0.0.1/",
+		"http://[::ffff:169.254.169.254]/",
+		"http://user@example.com/",
+		"file:///etc/passwd",
+		"http://0x7f000001/",
+		"http://example.com@127.0.0.1/",
+		"http://localhost./",
+	} {
+		f.Add(seed)
+	}
+	// Every name resolves to loopback, except example.com, so only example.com
+	// and public address literals may pass
+	previous := SetResolver(func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if host == "example.com" {
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+		}
+		return []net.IPAddr{{IP: net.IPv4(127, 0, 0, 1)}}, nil
+	})
+	f.Cleanup(func() { SetResolver(previous) })
+	f.Fuzz(func(t *testing.T, value string) {
+		if err := ValidateFetchURL(value); err != nil {
+			return
+		}
+		u, err := url.Parse(value)
+		if err != nil {
+			t.Fatalf("accepted %q, which does not parse: %v", value, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			t.Errorf("accepted %q with scheme %q", value, u.Scheme)
+		}
+		if u.User != nil {
+			t.Errorf("accepted %q with credentials", value)
+		}
+		host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+		if host == "example.com" {
+			return
+		}
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			t.Fatalf("accepted %q, whose host %q resolves to loopback", value, host)
+		}
+		if validateFetchAddr(addr) != nil {
+			t.Errorf("accepted %q, whose address %s is not public", value, addr)
+		}
+	})
+}
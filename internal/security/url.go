@@ -0,0 +1,183 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Resolver looks up the addresses of a host name
+type Resolver func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+// resolver resolves the hosts of ValidateFetchURL
+var resolver Resolver = net.DefaultResolver.LookupIPAddr
+
+// SetResolver replaces the resolver of ValidateFetchURL, returning the
+// previous one. Tests use it to avoid real DNS lookups.
+func SetResolver(r Resolver) Resolver {
+	previous := resolver
+	resolver = r
+	return previous
+}
+
+// fetchURLResolveTimeout bounds the DNS lookup of ValidateFetchURL
+const fetchURLResolveTimeout = 5 * time.Second
+
+// blockedFetchHosts are metadata and local host names that must never be fetched
+var blockedFetchHosts = []string{
+	"localhost",
+	"metadata",
+	"metadata.google.internal",
+	"metadata.azure.com",
+	"instance-data",
+	"instance-data.ec2.internal",
+}
+
+// blockedFetchPrefixes are address ranges not covered by the netip predicates:
+// "this network" and carrier-grade NAT, which cloud providers use internally
+var blockedFetchPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// ValidateFetchURL validates a URL the server will fetch on behalf of a tool
+// caller. On top of ValidateURL it rejects credentials in the URL, local and
+// metadata hosts, and hosts resolving to loopback, private, link-local,
+// multicast or unspecified addresses, to prevent server-side request forgery.
+func ValidateFetchURL(rawURL string) error {
+	if err := ValidateURL(rawURL); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ValidationError{Field: "url", Message: "invalid URL"}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ValidationError{Field: "url", Message: "scheme must be http or https"}
+	}
+	if u.User != nil {
+		return ValidationError{Field: "url", Message: "credentials are not allowed in the URL"}
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return ValidationError{Field: "url", Message: "host cannot be empty"}
+	}
+	for _, blocked := range blockedFetchHosts {
+		if host == blocked || strings.HasSuffix(host, "."+blocked) {
+			return ValidationError{Field: "url", Message: "host is not allowed"}
+		}
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return validateFetchAddr(addr)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchURLResolveTimeout)
+	defer cancel()
+	addrs, err := resolver(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return ValidationError{Field: "url", Message: "host cannot be resolved"}
+	}
+	for _, ipAddr := range addrs {
+		addr, ok := netip.AddrFromSlice(ipAddr.IP)
+		if !ok {
+			return ValidationError{Field: "url", Message: "host cannot be resolved"}
+		}
+		if err := validateFetchAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateFetchAddr rejects addresses that are not publicly routable
+func validateFetchAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified() {
+		return ValidationError{Field: "url", Message: "host resolves to a non-public address"}
+	}
+	for _, prefix := range blockedFetchPrefixes {
+		if prefix.Contains(addr) {
+			return ValidationError{Field: "url", Message: "host resolves to a non-public address"}
+		}
+	}
+	return nil
+}
+
+// MaxFetchSize is the largest response FetchURL reads by default
+const MaxFetchSize = 4 << 20
+
+// fetchTimeout bounds a whole FetchURL request, redirects included
+const fetchTimeout = 30 * time.Second
+
+// maxFetchRedirects bounds the redirects FetchURL follows
+const maxFetchRedirects = 5
+
+// checkDialAddr validates every address FetchURL connects to. Tests replace
+// it to reach servers on the loopback interface.
+var checkDialAddr = func(addr netip.AddrPort) error {
+	return validateFetchAddr(addr.Addr())
+}
+
+// FetchURL fetches a URL validated with ValidateFetchURL, reading at most
+// maxBytes of the response. The URL must be fetched here rather than handed
+// to another program: the address of every connection is checked as it is
+// dialed and every redirect target is validated again, so neither redirects
+// nor a host name re-resolving to a different address can reach private or
+// metadata addresses.
+func FetchURL(ctx context.Context, rawURL string, maxBytes int64) ([]byte, error) {
+	dialer := &net.Dialer{
+		Timeout: fetchURLResolveTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return ValidationError{Field: "url", Message: "host cannot be resolved"}
+			}
+			return checkDialAddr(addr)
+		},
+	}
+	client := &http.Client{
+		Timeout: fetchTimeout,
+		// No proxy: the dialed address must be the host's own
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			return ValidateFetchURL(req.URL.String())
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, fmt.Errorf("response is larger than %d bytes", maxBytes)
+	}
+	return body, nil
+}
@@ -30,6 +30,10 @@ var (
 	// Path pattern (no directory traversal)
 	pathPattern = regexp.MustCompile(`^[a-zA-Z0-9._/-]+$`)
 
+	// Windows path pattern: an optional drive letter, either separator, and the
+	// spaces and parentheses of folders such as "Program Files (x86)"
+	windowsPathPattern = regexp.MustCompile(`^([a-zA-Z]:)?[a-zA-Z0-9._/\\ ()-]+$`)
+
 	// Command injection patterns to reject
 	commandInjectionPatterns = []*regexp.Regexp{
 		regexp.MustCompile(`[;&|` + "`" + `$(){}[\]\\<>*?~!#\n\r\t]`),
@@ -117,6 +121,24 @@ func ValidateFilePath(path string) error {
 	return nil
 }
 
+// ValidateWindowsFilePath validates a Windows file path for security, such as
+// C:\Users\me\.kube\config
+func ValidateWindowsFilePath(path string) error {
+	if len(path) > 4096 {
+		return ValidationError{Field: "path", Message: "path too long"}
+	}
+
+	if strings.Contains(path, "..") {
+		return ValidationError{Field: "path", Message: "path traversal not allowed"}
+	}
+
+	if !windowsPathPattern.MatchString(path) {
+		return ValidationError{Field: "path", Message: "contains invalid characters"}
+	}
+
+	return nil
+}
+
 // ValidateCommandInput validates command inputs for injection attacks
 func ValidateCommandInput(input string) error {
 	if input == "" {
@@ -285,3 +307,141 @@ func ValidateURL(url string) error {
 
 	return nil
 }
+
+var (
+	// Object names allow the colons and uppercase letters of RBAC names such as
+	// system:controller:job-controller, but never a leading dash, which kubectl
+	// would read as a flag
+	objectNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:@-]*$`)
+
+	// Resource types such as pod, deployments.apps or a comma-separated list of them
+	resourceTypePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._-]*(,[A-Za-z][A-Za-z0-9._-]*)*$`)
+
+	// Qualified names of label and annotation keys, with an optional DNS subdomain prefix
+	keyNamePattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+	keyPrefixPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// ValidateNamespaceName validates the format of a Kubernetes namespace,
+// accepting the reserved namespaces ValidateNamespace rejects, for reads
+func ValidateNamespaceName(namespace string) error {
+	if namespace == "" {
+		return nil
+	}
+
+	if len(namespace) > 63 {
+		return ValidationError{Field: "namespace", Message: "cannot exceed 63 characters"}
+	}
+
+	if !namespacePattern.MatchString(namespace) {
+		return ValidationError{Field: "namespace", Message: "must follow RFC 1123 naming convention"}
+	}
+
+	return nil
+}
+
+// ValidateK8sObjectName validates the name of any Kubernetes object, including
+// DNS subdomain names such as CRD and node names
+func ValidateK8sObjectName(name string) error {
+	if name == "" {
+		return ValidationError{Field: "name", Message: "cannot be empty"}
+	}
+
+	if len(name) > 253 {
+		return ValidationError{Field: "name", Message: "cannot exceed 253 characters"}
+	}
+
+	if !objectNamePattern.MatchString(name) {
+		return ValidationError{Field: "name", Message: "must start with a letter or digit and contain only letters, digits, '.', '-', '_', ':' or '@'"}
+	}
+
+	return nil
+}
+
+// ValidateK8sResourceType validates a kubectl resource type, such as pods,
+// deployments.apps or pods,services
+func ValidateK8sResourceType(resourceType string) error {
+	if resourceType == "" {
+		return ValidationError{Field: "resource_type", Message: "cannot be empty"}
+	}
+
+	if len(resourceType) > 253 {
+		return ValidationError{Field: "resource_type", Message: "cannot exceed 253 characters"}
+	}
+
+	if !resourceTypePattern.MatchString(resourceType) {
+		return ValidationError{Field: "resource_type", Message: "invalid resource type format"}
+	}
+
+	return nil
+}
+
+// ValidateK8sKey validates a label or annotation key, such as
+// app.kubernetes.io/name
+func ValidateK8sKey(key string) error {
+	if key == "" {
+		return ValidationError{Field: "key", Message: "cannot be empty"}
+	}
+
+	name := key
+	if prefix, rest, ok := strings.Cut(key, "/"); ok {
+		if len(prefix) > 253 || !keyPrefixPattern.MatchString(prefix) {
+			return ValidationError{Field: "key", Message: "prefix must be a DNS subdomain"}
+		}
+		name = rest
+	}
+
+	if len(name) > 63 {
+		return ValidationError{Field: "key", Message: "name cannot exceed 63 characters"}
+	}
+
+	if !keyNamePattern.MatchString(name) {
+		return ValidationError{Field: "key", Message: "invalid key format"}
+	}
+
+	return nil
+}
+
+// ValidateLabelAssignments validates space-separated key=value label
+// assignments, as passed to kubectl label
+func ValidateLabelAssignments(assignments string) error {
+	return validateAssignments("labels", assignments, func(value string) error {
+		return ValidateK8sLabel("value", value)
+	})
+}
+
+// ValidateAnnotationAssignments validates space-separated key=value annotation
+// assignments, as passed to kubectl annotate. Values are free-form, but cannot
+// hold whitespace as the assignments are split on it.
+func ValidateAnnotationAssignments(assignments string) error {
+	return validateAssignments("annotations", assignments, func(value string) error {
+		if len(value) > 256*1024 {
+			return ValidationError{Field: "annotations", Message: "value too long"}
+		}
+		return nil
+	})
+}
+
+// validateAssignments validates each key=value of a space-separated list,
+// rejecting tokens such as --overwrite=false that kubectl would read as flags
+func validateAssignments(field, assignments string, validateValue func(string) error) error {
+	tokens := strings.Fields(assignments)
+	if len(tokens) == 0 {
+		return ValidationError{Field: field, Message: "cannot be empty"}
+	}
+
+	for _, token := range tokens {
+		key, value, ok := strings.Cut(token, "=")
+		if !ok {
+			return ValidationError{Field: field, Message: fmt.Sprintf("%q is not a key=value assignment", token)}
+		}
+		if err := ValidateK8sKey(key); err != nil {
+			return ValidationError{Field: field, Message: fmt.Sprintf("invalid key %q", key)}
+		}
+		if err := validateValue(value); err != nil {
+			return ValidationError{Field: field, Message: fmt.Sprintf("invalid value for %s", key)}
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package security
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -114,6 +115,35 @@ func TestValidateFilePath(t *testing.T) {
 	}
 }
 
+func TestValidateWindowsFilePath(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectError bool
+	}{
+		{"drive path", `C:\Users\jane\.kube\config`, false},
+		{"spaces and parentheses", `C:\Program Files (x86)\kube\config`, false},
+		{"forward slashes", "D:/kube/config", false},
+		{"relative path", `kube\config`, false},
+		{"empty path", "", true},
+		{"path traversal", `C:\Users\..\Admin\config`, true},
+		{"command separator", `C:\config&del`, true},
+		{"drive letter mid-path", `kube\C:\config`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWindowsFilePath(tt.input)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error for input %q, but got none", tt.input)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error for input %q: %v", tt.input, err)
+			}
+		})
+	}
+}
+
 func TestValidateCommandInput(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -320,3 +350,151 @@ func TestValidationError(t *testing.T) {
 		t.Errorf("Expected error message %q, got %q", expected, err.Error())
 	}
 }
+
+func TestValidateNamespaceName(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectError bool
+	}{
+		{"valid namespace", "my-namespace", false},
+		{"empty namespace", "", false},
+		{"reserved namespace", "kube-system", false},
+		{"flag", "-A", true},
+		{"space", "default -A", true},
+		{"too long", strings.Repeat("a", 64), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateNamespaceName(tt.input)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error for input %q, but got none", tt.input)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error for input %q: %v", tt.input, err)
+			}
+		})
+	}
+}
+
+func TestValidateK8sObjectName(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectError bool
+	}{
+		{"valid name", "my-service", false},
+		{"dns subdomain", "certificates.cert-manager.io", false},
+		{"rbac name", "system:controller:job-controller", false},
+		{"uppercase", "Web", false},
+		{"empty name", "", true},
+		{"flag", "--all", true},
+		{"slash", "deployment/web", true},
+		{"space", "web -A", true},
+		{"shell metacharacter", "web;rm", true},
+		{"too long", strings.Repeat("a", 254), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateK8sObjectName(tt.input)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error for input %q, but got none", tt.input)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error for input %q: %v", tt.input, err)
+			}
+		})
+	}
+}
+
+func TestValidateK8sResourceType(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectError bool
+	}{
+		{"short name", "po", false},
+		{"group qualified", "deployments.apps", false},
+		{"list", "pods,services", false},
+		{"generator type", "istio_auth_policy", false},
+		{"empty", "", true},
+		{"flag", "--all", true},
+		{"trailing comma", "pods,", true},
+		{"space", "pods -A", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateK8sResourceType(tt.input)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error for input %q, but got none", tt.input)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error for input %q: %v", tt.input, err)
+			}
+		})
+	}
+}
+
+func TestValidateK8sKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectError bool
+	}{
+		{"simple key", "env", false},
+		{"prefixed key", "app.kubernetes.io/name", false},
+		{"empty", "", true},
+		{"flag", "--all", true},
+		{"empty prefix", "/name", true},
+		{"uppercase prefix", "Example.com/name", true},
+		{"empty name", "example.com/", true},
+		{"two slashes", "a/b/c", true},
+		{"name too long", strings.Repeat("a", 64), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateK8sKey(tt.input)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error for input %q, but got none", tt.input)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error for input %q: %v", tt.input, err)
+			}
+		})
+	}
+}
+
+func TestValidateAssignments(t *testing.T) {
+	tests := []struct {
+		name        string
+		validate    func(string) error
+		input       string
+		expectError bool
+	}{
+		{"labels", ValidateLabelAssignments, "env=prod app.kubernetes.io/version=1.0", false},
+		{"empty label value", ValidateLabelAssignments, "env=", false},
+		{"invalid label value", ValidateLabelAssignments, "env=prod;rm", true},
+		{"missing value", ValidateLabelAssignments, "env", true},
+		{"flag", ValidateLabelAssignments, "env=prod --overwrite", true},
+		{"flag with value", ValidateLabelAssignments, "--overwrite=true", true},
+		{"empty labels", ValidateLabelAssignments, " ", true},
+		{"annotations", ValidateAnnotationAssignments, "note=free-form:text,ok example.com/owner=team@example.com", false},
+		{"annotation flag", ValidateAnnotationAssignments, "note=x --all=true", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validate(tt.input)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error for input %q, but got none", tt.input)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error for input %q: %v", tt.input, err)
+			}
+		})
+	}
+}
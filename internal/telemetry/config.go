@@ -17,6 +17,10 @@ type Telemetry struct {
 	SamplingRatio  float64
 	Insecure       bool
 	Disabled       bool
+	// LogsExporter is otlp to export logs, none otherwise
+	LogsExporter string
+	// LogsEndpoint overrides Endpoint for logs
+	LogsEndpoint string
 }
 
 // Config holds all application configuration.
@@ -42,6 +46,8 @@ func LoadOtelCfg() *Config {
 				SamplingRatio:  getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1.0),
 				Insecure:       getEnvBool("OTEL_EXPORTER_OTLP_TRACES_INSECURE", false),
 				Disabled:       getEnvBool("OTEL_SDK_DISABLED", false),
+				LogsExporter:   getEnv("OTEL_LOGS_EXPORTER", "none"),
+				LogsEndpoint:   getEnv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", ""),
 			},
 		}
 	})
@@ -0,0 +1,311 @@
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kagent-dev/tools/internal/logger"
+)
+
+// Environment variables configuring HTTP request logging
+const (
+	RequestLogEnabledEnv      = "HTTP_REQUEST_LOG_ENABLED"
+	RequestLogSampleRateEnv   = "HTTP_REQUEST_LOG_SAMPLE_RATE"
+	RequestLogMaxBodyBytesEnv = "HTTP_REQUEST_LOG_MAX_BODY_BYTES"
+	RequestLogBodiesEnv       = "HTTP_REQUEST_LOG_BODIES"
+)
+
+// defaultRequestLogMaxBodyBytes bounds the request and response bodies kept per request
+const defaultRequestLogMaxBodyBytes = 4096
+
+// RequestLogConfig configures the request logging middleware
+type RequestLogConfig struct {
+	Enabled bool
+	// SampleRate is the fraction of successful requests logged; failures are always logged
+	SampleRate float64
+	// MaxBodyBytes truncates logged bodies; the whole response is still scanned for errors
+	MaxBodyBytes int
+	// LogBodies includes the (truncated) request and response bodies in the log entry
+	LogBodies bool
+
+	logger *slog.Logger
+	random func() float64
+}
+
+// LoadRequestLogConfig reads the request logging configuration from the environment
+func LoadRequestLogConfig() RequestLogConfig {
+	cfg := RequestLogConfig{
+		Enabled:      getEnvBool(RequestLogEnabledEnv, false),
+		SampleRate:   getEnvFloat(RequestLogSampleRateEnv, 1.0),
+		MaxBodyBytes: defaultRequestLogMaxBodyBytes,
+		LogBodies:    getEnvBool(RequestLogBodiesEnv, false),
+	}
+	if value, err := strconv.Atoi(getEnv(RequestLogMaxBodyBytesEnv, "")); err == nil && value >= 0 {
+		cfg.MaxBodyBytes = value
+	}
+	cfg.SampleRate = min(max(cfg.SampleRate, 0), 1)
+	return cfg
+}
+
+// minRequestPrefixBytes is the smallest request prefix read to find the MCP
+// method and tool name, however small the logged bodies are
+const minRequestPrefixBytes = 4096
+
+// rpcRequest is the subset of a JSON-RPC request logged for MCP calls
+type rpcRequest struct {
+	Method string `json:"method"`
+	Params struct {
+		Name string `json:"name"`
+	} `json:"params"`
+}
+
+// jsonFrame is an object or array being walked by parseRPCPrefix
+type jsonFrame struct {
+	object    bool
+	expectKey bool
+	key       string
+}
+
+// parseRPCPrefix reads the method and tool name of a JSON-RPC request from a
+// prefix of its body, which may be cut anywhere
+func parseRPCPrefix(prefix []byte) rpcRequest {
+	var rpc rpcRequest
+	if json.Unmarshal(prefix, &rpc) == nil {
+		return rpc
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(prefix))
+	var stack []jsonFrame
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return rpc
+		}
+
+		if delim, ok := token.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				stack = append(stack, jsonFrame{object: delim == '{', expectKey: delim == '{'})
+				continue
+			default:
+				stack = stack[:len(stack)-1]
+			}
+		} else if n := len(stack); n > 0 && stack[n-1].expectKey {
+			stack[n-1].key, _ = token.(string)
+			stack[n-1].expectKey = false
+			continue
+		} else if value, ok := token.(string); ok {
+			switch {
+			case n == 1 && stack[0].key == "method":
+				rpc.Method = value
+			case n == 2 && stack[0].key == "params" && stack[1].key == "name":
+				rpc.Params.Name = value
+			}
+		}
+
+		// A value is complete, so the enclosing object expects a key next
+		if n := len(stack); n > 0 && stack[n-1].object {
+			stack[n-1].expectKey = true
+		}
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += n
+	return n, err
+}
+
+// readCloser combines a reader with the closer of the body it reads
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// loggingResponseWriter records the status, size and a bounded prefix of the
+// response, and scans all of it for failures
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+	limit  int
+	body   bytes.Buffer
+	failed failureScanner
+}
+
+func (w *loggingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *loggingResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if remaining := w.limit - w.body.Len(); remaining > 0 {
+		w.body.Write(p[:min(len(p), remaining)])
+	}
+	w.failed.scan(p)
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += n
+	return n, err
+}
+
+// Flush keeps streamed SSE responses working through the wrapper
+func (w *loggingResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack supports connection upgrades through the wrapper
+func (w *loggingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("response writer does not support hijacking")
+}
+
+// failureMarkers are the response fragments of a JSON-RPC error or a failed tool result
+var failureMarkers = [][]byte{[]byte(`"error":{`), []byte(`"isError":true`)}
+
+// failureScanner looks for failureMarkers in a response written in chunks,
+// keeping only enough of the previous chunk to find a marker split across writes
+type failureScanner struct {
+	found bool
+	tail  []byte
+}
+
+func (s *failureScanner) scan(p []byte) {
+	if s.found || len(p) == 0 {
+		return
+	}
+	const keep = len(`"isError":true`) - 1
+	boundary := append(s.tail, p[:min(len(p), keep)]...)
+	s.found = rpcFailed(boundary) || rpcFailed(p)
+	if len(p) >= keep {
+		s.tail = append(s.tail[:0], p[len(p)-keep:]...)
+	} else {
+		s.tail = append(s.tail[:0], boundary[max(len(boundary)-keep, 0):]...)
+	}
+}
+
+// rpcFailed reports whether body carries a JSON-RPC error or a failed tool result
+func rpcFailed(body []byte) bool {
+	for _, marker := range failureMarkers {
+		if bytes.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncatedMarker is appended to logged bodies cut at the size limit
+const truncatedMarker = "...(truncated)"
+
+// truncate returns at most limit bytes of body as a string
+func truncate(body []byte, limit int) string {
+	if len(body) <= limit {
+		return string(body)
+	}
+	return string(body[:limit]) + truncatedMarker
+}
+
+// RequestLoggingMiddleware logs the MCP method, tool name, duration and status of HTTP requests.
+// It must run inside HTTPMiddleware so that trace and request IDs are available.
+func RequestLoggingMiddleware(cfg RequestLogConfig, next http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return next
+	}
+	random := cfg.random
+	if random == nil {
+		random = rand.Float64
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		// Only a bounded prefix of the request is kept for logging; the
+		// handler reads the prefix and then the rest of the body unbuffered
+		var requestBody []byte
+		var rpc rpcRequest
+		counter := &countingReader{}
+		if r.Body != nil && r.Method == http.MethodPost {
+			limit := int64(max(cfg.MaxBodyBytes, minRequestPrefixBytes))
+			requestBody, _ = io.ReadAll(io.LimitReader(r.Body, limit))
+			counter.Reader = io.MultiReader(bytes.NewReader(requestBody), r.Body)
+			r.Body = readCloser{Reader: counter, Closer: r.Body}
+			rpc = parseRPCPrefix(requestBody)
+		}
+
+		recorder := &loggingResponseWriter{ResponseWriter: w, limit: cfg.MaxBodyBytes}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		failed := recorder.status >= http.StatusBadRequest || recorder.failed.found
+		if !failed && random() >= cfg.SampleRate {
+			return
+		}
+
+		ctx := r.Context()
+		attrs := []any{
+			"http_method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"request_bytes", counter.n,
+			"response_bytes", recorder.bytes,
+		}
+		if rpc.Method != "" {
+			attrs = append(attrs, "rpc_method", rpc.Method)
+		}
+		if rpc.Params.Name != "" {
+			attrs = append(attrs, "tool", rpc.Params.Name)
+		}
+		if requestID := r.Header.Get("X-Request-ID"); requestID != "" {
+			attrs = append(attrs, "request_id", requestID)
+		}
+		if traceID, _ := ExtractTraceInfo(ctx); traceID != "" {
+			attrs = append(attrs, "trace_id", traceID)
+		}
+		if cfg.LogBodies {
+			responseBody := recorder.body.String()
+			if recorder.bytes > recorder.body.Len() {
+				responseBody += truncatedMarker
+			}
+			attrs = append(attrs,
+				"request_body", truncate(requestBody, cfg.MaxBodyBytes),
+				"response_body", responseBody,
+			)
+		}
+
+		log := cfg.logger
+		if log == nil {
+			log = logger.Get()
+		}
+		if failed {
+			log.Warn("MCP HTTP request failed", attrs...)
+		} else {
+			log.Info("MCP HTTP request", attrs...)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logEntries parses the JSON log lines written to buf
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLoadRequestLogConfig(t *testing.T) {
+	t.Setenv(RequestLogEnabledEnv, "true")
+	t.Setenv(RequestLogSampleRateEnv, "2")
+	t.Setenv(RequestLogMaxBodyBytesEnv, "128")
+
+	cfg := LoadRequestLogConfig()
+	assert.True(t, cfg.Enabled)
+	assert.Equal(t, 1.0, cfg.SampleRate)
+	assert.Equal(t, 128, cfg.MaxBodyBytes)
+	assert.False(t, cfg.LogBodies)
+}
+
+func TestRequestLoggingMiddleware(t *testing.T) {
+	toolCall := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"k8s_get_resources","arguments":{}}}`
+
+	newHandler := func(cfg RequestLogConfig, status int, response string) (http.Handler, *bytes.Buffer) {
+		buf := &bytes.Buffer{}
+		cfg.Enabled = true
+		cfg.logger = slog.New(slog.NewJSONHandler(buf, nil))
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The body must still be readable by the MCP server
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, toolCall, string(body))
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(response))
+		})
+		return HTTPMiddleware(RequestLoggingMiddleware(cfg, next)), buf
+	}
+
+	t.Run("logs sampled request", func(t *testing.T) {
+		handler, buf := newHandler(RequestLogConfig{SampleRate: 1, MaxBodyBytes: 10, LogBodies: true}, http.StatusOK, `{"result":{"content":[]}}`)
+
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(toolCall))
+		req.Header.Set("X-Request-ID", "req-1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		entries := logEntries(t, buf)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "INFO", entries[0]["level"])
+		assert.Equal(t, "tools/call", entries[0]["rpc_method"])
+		assert.Equal(t, "k8s_get_resources", entries[0]["tool"])
+		assert.Equal(t, "req-1", entries[0]["request_id"])
+		assert.Equal(t, float64(200), entries[0]["status"])
+		assert.Equal(t, `{"jsonrpc"...(truncated)`, entries[0]["request_body"])
+		assert.Equal(t, `{"result":...(truncated)`, entries[0]["response_body"])
+	})
+
+	t.Run("skips unsampled success", func(t *testing.T) {
+		cfg := RequestLogConfig{SampleRate: 0.5, MaxBodyBytes: 1024, random: func() float64 { return 0.9 }}
+		handler, buf := newHandler(cfg, http.StatusOK, `{"result":{"content":[]}}`)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(toolCall)))
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("always logs failures", func(t *testing.T) {
+		for _, tc := range []struct {
+			status   int
+			response string
+		}{
+			{http.StatusUnauthorized, "unauthorized"},
+			{http.StatusOK, `{"result":{"content":[],"isError":true}}`},
+			{http.StatusOK, `{"error":{"code":-32602,"message":"invalid params"}}`},
+		} {
+			handler, buf := newHandler(RequestLogConfig{SampleRate: 0, MaxBodyBytes: 1024}, tc.status, tc.response)
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(toolCall)))
+			entries := logEntries(t, buf)
+			require.Len(t, entries, 1, tc.response)
+			assert.Equal(t, "WARN", entries[0]["level"])
+			assert.NotContains(t, entries[0], "response_body")
+		}
+	})
+
+	t.Run("detects failures beyond the logged body", func(t *testing.T) {
+		for _, maxBodyBytes := range []int{0, 16} {
+			buf := &bytes.Buffer{}
+			cfg := RequestLogConfig{Enabled: true, SampleRate: 0, MaxBodyBytes: maxBodyBytes, logger: slog.New(slog.NewJSONHandler(buf, nil))}
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// The marker is split across writes, far past the logged prefix
+				_, _ = w.Write([]byte(`{"result":{"content":[{"type":"text","text":"` + strings.Repeat("a", 4096) + `"}],"isEr`))
+				_, _ = w.Write([]byte(`ror":true}}`))
+			})
+			handler := RequestLoggingMiddleware(cfg, next)
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(toolCall)))
+			entries := logEntries(t, buf)
+			require.Len(t, entries, 1, maxBodyBytes)
+			assert.Equal(t, "WARN", entries[0]["level"])
+		}
+	})
+
+	t.Run("bounds buffered request body", func(t *testing.T) {
+		largeCall := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"k8s_create_resource","arguments":{"yaml_content":"` +
+			strings.Repeat("a", 1<<20) + `"}}}`
+		buf := &bytes.Buffer{}
+		cfg := RequestLogConfig{Enabled: true, SampleRate: 1, MaxBodyBytes: 16, LogBodies: true, logger: slog.New(slog.NewJSONHandler(buf, nil))}
+		var received string
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			received = string(body)
+		})
+		handler := RequestLoggingMiddleware(cfg, next)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(largeCall)))
+		assert.Equal(t, largeCall, received)
+
+		entries := logEntries(t, buf)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "tools/call", entries[0]["rpc_method"])
+		assert.Equal(t, "k8s_create_resource", entries[0]["tool"])
+		assert.Equal(t, float64(len(largeCall)), entries[0]["request_bytes"])
+		assert.Equal(t, `{"jsonrpc":"2.0"...(truncated)`, entries[0]["request_body"])
+	})
+
+	t.Run("disabled passes through", func(t *testing.T) {
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+		handler := RequestLoggingMiddleware(RequestLogConfig{}, next)
+		assert.IsType(t, next, handler)
+	})
+}
+
+func TestFailureScanner(t *testing.T) {
+	response := `data: {"jsonrpc":"2.0","id":1,"error":{"code":-32602}}`
+	for size := 1; size <= len(response); size++ {
+		var scanner failureScanner
+		for i := 0; i < len(response); i += size {
+			scanner.scan([]byte(response[i:min(i+size, len(response))]))
+		}
+		assert.True(t, scanner.found, "chunks of %d bytes", size)
+	}
+
+	var scanner failureScanner
+	scanner.scan([]byte(`{"result":{"content":[],"isError":false}}`))
+	assert.False(t, scanner.found)
+}
+
+func TestParseRPCPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		method string
+		tool   string
+	}{
+		{"complete", `{"method":"tools/call","params":{"name":"helm_list"}}`, "tools/call", "helm_list"},
+		{"cut in arguments", `{"id":[1,{"name":"x"}],"method":"tools/call","params":{"arguments":{"name":"inner"},"name":"helm_list","extra":"aaa`, "tools/call", "helm_list"},
+		{"cut before name", `{"method":"tools/call","params":{"argu`, "tools/call", ""},
+		{"not json", `method=tools/call`, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpc := parseRPCPrefix([]byte(tt.prefix))
+			assert.Equal(t, tt.method, rpc.Method)
+			assert.Equal(t, tt.tool, rpc.Params.Name)
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/kagent-dev/tools/internal/logger"
+)
+
+// LogsExporterOTLP enables exporting logs with OTEL_LOGS_EXPORTER
+const LogsExporterOTLP = "otlp"
+
+// Log batching limits
+const (
+	logBatchSize     = 512
+	logQueueSize     = 4096
+	logFlushInterval = 5 * time.Second
+	logExportTimeout = 10 * time.Second
+)
+
+// logScopeName is the instrumentation scope of exported log records
+const logScopeName = "github.com/kagent-dev/tools/internal/logger"
+
+// logExportFunc sends a batch of log records to the collector
+type logExportFunc func(ctx context.Context, request *collogspb.ExportLogsServiceRequest) error
+
+// logBatcher queues log records and exports them in batches
+type logBatcher struct {
+	export   logExportFunc
+	resource *resourcepb.Resource
+	// errorLog reports export failures without feeding them back into the export
+	errorLog *slog.Logger
+
+	mu      sync.Mutex
+	records []*logspb.LogRecord
+	dropped int
+	flushCh chan struct{}
+}
+
+func newLogBatcher(export logExportFunc, resource *resourcepb.Resource, errorLog *slog.Logger) *logBatcher {
+	return &logBatcher{export: export, resource: resource, errorLog: errorLog, flushCh: make(chan struct{}, 1)}
+}
+
+// add queues a record, dropping it when the queue is full
+func (b *logBatcher) add(record *logspb.LogRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.records) >= logQueueSize {
+		b.dropped++
+		return
+	}
+	b.records = append(b.records, record)
+	if len(b.records) >= logBatchSize {
+		select {
+		case b.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flush exports the queued records
+func (b *logBatcher) flush(ctx context.Context) error {
+	b.mu.Lock()
+	records, dropped := b.records, b.dropped
+	b.records, b.dropped = nil, 0
+	b.mu.Unlock()
+
+	if dropped > 0 {
+		b.errorLog.Warn("Dropped log records, the export queue was full", "dropped", dropped)
+	}
+	for start := 0; start < len(records); start += logBatchSize {
+		batch := records[start:min(start+logBatchSize, len(records))]
+		request := &collogspb.ExportLogsServiceRequest{
+			ResourceLogs: []*logspb.ResourceLogs{{
+				Resource: b.resource,
+				ScopeLogs: []*logspb.ScopeLogs{{
+					Scope:      &commonpb.InstrumentationScope{Name: logScopeName},
+					LogRecords: batch,
+				}},
+			}},
+		}
+		exportCtx, cancel := context.WithTimeout(ctx, logExportTimeout)
+		err := b.export(exportCtx, request)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to export %d log records: %w", len(batch), err)
+		}
+	}
+	return nil
+}
+
+// run exports queued records periodically and when a batch is full, until ctx is done
+func (b *logBatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(logFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// Export what is left after the server stopped
+			if err := b.flush(context.Background()); err != nil {
+				b.errorLog.Error("Failed to export logs", "error", err)
+			}
+			return
+		case <-ticker.C:
+		case <-b.flushCh:
+		}
+		if err := b.flush(ctx); err != nil {
+			b.errorLog.Error("Failed to export logs", "error", err)
+		}
+	}
+}
+
+// otlpLogHandler is a slog.Handler converting records to OTLP log records.
+// Records logged with a span in their context, or through logger.WithContext,
+// carry its trace and span IDs so backends can correlate them with traces.
+type otlpLogHandler struct {
+	batcher *logBatcher
+	level   slog.Leveler
+	attrs   []*commonpb.KeyValue
+	// prefix qualifies attribute keys with the open groups, e.g. "request."
+	prefix  string
+	traceID []byte
+	spanID  []byte
+}
+
+func (h *otlpLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *otlpLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := make([]*commonpb.KeyValue, len(h.attrs), len(h.attrs)+record.NumAttrs())
+	copy(attrs, h.attrs)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = appendOTLPAttr(attrs, h.prefix, attr)
+		return true
+	})
+
+	logRecord := &logspb.LogRecord{
+		TimeUnixNano:         uint64(record.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()),
+		SeverityNumber:       otlpSeverity(record.Level),
+		SeverityText:         record.Level.String(),
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: record.Message}},
+		Attributes:           attrs,
+		TraceId:              h.traceID,
+		SpanId:               h.spanID,
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		traceID, spanID := spanContext.TraceID(), spanContext.SpanID()
+		logRecord.TraceId, logRecord.SpanId = traceID[:], spanID[:]
+		logRecord.Flags = uint32(spanContext.TraceFlags())
+	}
+	h.batcher.add(logRecord)
+	return nil
+}
+
+func (h *otlpLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.attrs = make([]*commonpb.KeyValue, len(h.attrs), len(h.attrs)+len(attrs))
+	copy(handler.attrs, h.attrs)
+	for _, attr := range attrs {
+		// logger.WithContext records the span as attributes
+		switch attr.Key {
+		case "trace_id":
+			if id, err := hex.DecodeString(attr.Value.String()); err == nil && len(id) == 16 {
+				handler.traceID = id
+				continue
+			}
+		case "span_id":
+			if id, err := hex.DecodeString(attr.Value.String()); err == nil && len(id) == 8 {
+				handler.spanID = id
+				continue
+			}
+		}
+		handler.attrs = appendOTLPAttr(handler.attrs, h.prefix, attr)
+	}
+	return &handler
+}
+
+func (h *otlpLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	handler := *h
+	handler.prefix = h.prefix + name + "."
+	return &handler
+}
+
+// otlpSeverity maps a slog level to an OTLP severity number
+func otlpSeverity(level slog.Level) logspb.SeverityNumber {
+	switch {
+	case level >= slog.LevelError:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	case level >= slog.LevelWarn:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	case level >= slog.LevelInfo:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	default:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	}
+}
+
+// appendOTLPAttr appends an attribute, flattening groups into prefixed keys
+func appendOTLPAttr(attrs []*commonpb.KeyValue, prefix string, attr slog.Attr) []*commonpb.KeyValue {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix += attr.Key + "."
+		}
+		for _, member := range value.Group() {
+			attrs = appendOTLPAttr(attrs, groupPrefix, member)
+		}
+		return attrs
+	}
+	if attr.Key == "" {
+		return attrs
+	}
+	return append(attrs, &commonpb.KeyValue{Key: prefix + attr.Key, Value: otlpValue(value)})
+}
+
+// otlpValue converts a resolved slog value that is not a group
+func otlpValue(value slog.Value) *commonpb.AnyValue {
+	switch value.Kind() {
+	case slog.KindBool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: value.Bool()}}
+	case slog.KindInt64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: value.Int64()}}
+	case slog.KindUint64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(value.Uint64())}}
+	case slog.KindFloat64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: value.Float64()}}
+	case slog.KindTime:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value.Time().Format(time.RFC3339Nano)}}
+	case slog.KindAny:
+		if items, ok := value.Any().([]string); ok {
+			values := make([]*commonpb.AnyValue, len(items))
+			for i, item := range items {
+				values[i] = &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: item}}
+			}
+			return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+		}
+	}
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value.String()}}
+}
+
+// logResource describes the service emitting the logs
+func logResource(cfg *Telemetry) *resourcepb.Resource {
+	attribute := func(key, value string) *commonpb.KeyValue {
+		return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+	}
+	return &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+		attribute("service.name", cfg.ServiceName),
+		attribute("service.version", cfg.ServiceVersion),
+		attribute("deployment.environment", cfg.Environment),
+	}}
+}
+
+// newHTTPLogExporter exports logs as protobuf over OTLP/HTTP
+func newHTTPLogExporter(endpoint string, headers map[string]string) logExportFunc {
+	client := &http.Client{}
+	return func(ctx context.Context, request *collogspb.ExportLogsServiceRequest) error {
+		body, err := proto.Marshal(request)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("collector returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+		}
+		return nil
+	}
+}
+
+// newGRPCLogExporter exports logs over OTLP/gRPC
+func newGRPCLogExporter(endpoint string, insecureConn bool, headers map[string]string) (logExportFunc, func() error, error) {
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if insecureConn {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, nil, err
+	}
+	client := collogspb.NewLogsServiceClient(conn)
+	export := func(ctx context.Context, request *collogspb.ExportLogsServiceRequest) error {
+		if len(headers) > 0 {
+			ctx = metadata.NewOutgoingContext(ctx, metadata.New(headers))
+		}
+		_, err := client.Export(ctx, request)
+		return err
+	}
+	return export, conn.Close, nil
+}
+
+// SetupLogExport exports the server logs over OTLP in addition to writing
+// them to the console, when OTEL_LOGS_EXPORTER is otlp. Logs are exported to
+// OTEL_EXPORTER_OTLP_LOGS_ENDPOINT, or OTEL_EXPORTER_OTLP_ENDPOINT, until ctx is done.
+func SetupLogExport(ctx context.Context) error {
+	cfg := LoadOtelCfg().Telemetry
+	if cfg.Disabled || cfg.LogsExporter != LogsExporterOTLP {
+		return nil
+	}
+
+	endpoint := cfg.LogsEndpoint
+	if endpoint == "" {
+		endpoint = cfg.Endpoint
+	}
+	if endpoint == "" {
+		return fmt.Errorf("%s=%s requires %s or %s", OtelLogsExporter, LogsExporterOTLP, OtelExporterOtlpLogsEndpoint, OtelExporterOtlpEndpoint)
+	}
+	headers := parseHeaders(os.Getenv(OtelExporterOtlpHeaders))
+
+	protocol := cfg.Protocol
+	if protocol == ProtocolAuto || protocol == "" {
+		protocol = detectProtocol(endpoint)
+	}
+	var export logExportFunc
+	closeExporter := func() error { return nil }
+	switch strings.ToLower(protocol) {
+	case ProtocolGRPC:
+		var err error
+		export, closeExporter, err = newGRPCLogExporter(normalizeGRPCEndpoint(endpoint), cfg.Insecure, headers)
+		if err != nil {
+			return fmt.Errorf("failed to create log exporter: %w", err)
+		}
+	case ProtocolHTTP:
+		export = newHTTPLogExporter(normalizeHTTPSignalEndpoint(endpoint, cfg.Insecure, DefaultHttpLogsPath), headers)
+	default:
+		return fmt.Errorf("unsupported protocol: %s (supported: %s, %s)", protocol, ProtocolGRPC, ProtocolHTTP)
+	}
+
+	batcher := newLogBatcher(export, logResource(&cfg), logger.Get())
+	logger.AddHandler(&otlpLogHandler{batcher: batcher, level: slog.LevelInfo})
+	go func() {
+		batcher.run(ctx)
+		if err := closeExporter(); err != nil {
+			batcher.errorLog.Error("Failed to close log exporter", "error", err)
+		}
+	}()
+
+	logger.Get().Info("OTLP log export enabled", "endpoint", endpoint, "protocol", protocol)
+	return nil
+}
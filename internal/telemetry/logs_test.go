@@ -0,0 +1,125 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// recordedAttrs maps the attributes of a log record to their string values
+func recordedAttrs(record *logspb.LogRecord) map[string]string {
+	attrs := map[string]string{}
+	for _, attr := range record.Attributes {
+		switch value := attr.Value.Value.(type) {
+		case *commonpb.AnyValue_StringValue:
+			attrs[attr.Key] = value.StringValue
+		default:
+			attrs[attr.Key] = attr.Value.String()
+		}
+	}
+	return attrs
+}
+
+func TestOTLPLogHandler(t *testing.T) {
+	var requests []*collogspb.ExportLogsServiceRequest
+	batcher := newLogBatcher(func(ctx context.Context, request *collogspb.ExportLogsServiceRequest) error {
+		requests = append(requests, request)
+		return nil
+	}, logResource(&Telemetry{ServiceName: "kagent-tools"}), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	log := slog.New(&otlpLogHandler{batcher: batcher, level: slog.LevelInfo})
+
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+	ctx, span := tracer.Start(context.Background(), "tool")
+	defer span.End()
+
+	log.InfoContext(ctx, "executing command", "command", "kubectl", "args", []string{"get", "pods"})
+	log.With("tool", "k8s_get_resources").WithGroup("request").Warn("slow", "duration_seconds", 2.5, slog.Group("client", "id", "abc"))
+	log.Debug("not exported")
+	// logger.WithContext records the span as attributes
+	log.With("trace_id", span.SpanContext().TraceID().String(), "span_id", span.SpanContext().SpanID().String()).Error("failed")
+
+	require.NoError(t, batcher.flush(context.Background()))
+	require.Len(t, requests, 1)
+	resourceLogs := requests[0].ResourceLogs[0]
+	assert.Equal(t, "kagent-tools", resourceLogs.Resource.Attributes[0].Value.GetStringValue())
+	records := resourceLogs.ScopeLogs[0].LogRecords
+	require.Len(t, records, 3)
+
+	traceID, spanID := span.SpanContext().TraceID(), span.SpanContext().SpanID()
+	assert.Equal(t, "executing command", records[0].Body.GetStringValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_INFO, records[0].SeverityNumber)
+	assert.Equal(t, traceID[:], records[0].TraceId)
+	assert.Equal(t, spanID[:], records[0].SpanId)
+	assert.Equal(t, "kubectl", recordedAttrs(records[0])["command"])
+	assert.Len(t, records[0].Attributes[1].Value.GetArrayValue().Values, 2)
+
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_WARN, records[1].SeverityNumber)
+	assert.Empty(t, records[1].TraceId)
+	attrs := recordedAttrs(records[1])
+	assert.Equal(t, "k8s_get_resources", attrs["tool"])
+	assert.Equal(t, "abc", attrs["request.client.id"])
+	assert.Contains(t, attrs, "request.duration_seconds")
+
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, records[2].SeverityNumber)
+	assert.Equal(t, traceID[:], records[2].TraceId)
+	assert.Equal(t, spanID[:], records[2].SpanId)
+	assert.NotContains(t, recordedAttrs(records[2]), "trace_id")
+
+	// Nothing is exported when the queue is empty
+	require.NoError(t, batcher.flush(context.Background()))
+	assert.Len(t, requests, 1)
+}
+
+func TestHTTPLogExporter(t *testing.T) {
+	var received collogspb.ExportLogsServiceRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, DefaultHttpLogsPath, r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, proto.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	export := newHTTPLogExporter(normalizeHTTPSignalEndpoint(server.URL, true, DefaultHttpLogsPath), map[string]string{"Authorization": "secret"})
+	request := &collogspb.ExportLogsServiceRequest{ResourceLogs: []*logspb.ResourceLogs{{
+		ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{{SeverityText: "INFO"}}}},
+	}}}
+	require.NoError(t, export(context.Background(), request))
+	assert.Equal(t, "INFO", received.ResourceLogs[0].ScopeLogs[0].LogRecords[0].SeverityText)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer failing.Close()
+	err := newHTTPLogExporter(failing.URL+DefaultHttpLogsPath, nil)(context.Background(), request)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "quota exceeded")
+}
+
+func TestSetupLogExportDisabled(t *testing.T) {
+	cfg := LoadOtelCfg()
+	previous := cfg.Telemetry.LogsExporter
+	defer func() { cfg.Telemetry.LogsExporter = previous }()
+
+	cfg.Telemetry.LogsExporter = "none"
+	require.NoError(t, SetupLogExport(context.Background()))
+
+	cfg.Telemetry.LogsExporter = LogsExporterOTLP
+	previousEndpoint, previousLogsEndpoint := cfg.Telemetry.Endpoint, cfg.Telemetry.LogsEndpoint
+	defer func() { cfg.Telemetry.Endpoint, cfg.Telemetry.LogsEndpoint = previousEndpoint, previousLogsEndpoint }()
+	cfg.Telemetry.Endpoint, cfg.Telemetry.LogsEndpoint = "", ""
+	assert.Error(t, SetupLogExport(context.Background()))
+}
@@ -83,6 +83,31 @@ func ExtractTraceInfo(ctx context.Context) (traceID, spanID string) {
 	return traceID, spanID
 }
 
+// RequestTimeoutMetaKey is the _meta field of a tool call carrying an optional timeout,
+// either as a duration string such as "30s" or as a number of seconds
+const RequestTimeoutMetaKey = "timeout"
+
+// RequestTimeout returns the positive timeout requested in a tool call's _meta
+func RequestTimeout(request mcp.CallToolRequest) (time.Duration, bool) {
+	if request.Params.Meta == nil {
+		return 0, false
+	}
+	var timeout time.Duration
+	switch value := request.Params.Meta.AdditionalFields[RequestTimeoutMetaKey].(type) {
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, false
+		}
+		timeout = parsed
+	case float64:
+		timeout = time.Duration(value * float64(time.Second))
+	default:
+		return 0, false
+	}
+	return timeout, timeout > 0
+}
+
 func WithTracing(toolName string, handler ToolHandler) ToolHandler {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		tracer := otel.Tracer("kagent-tools/mcp")
@@ -117,6 +142,14 @@ func WithTracing(toolName string, handler ToolHandler) ToolHandler {
 			}
 		}
 
+		// Bound the call, and every CLI command it runs, by the client's requested timeout
+		if timeout, ok := RequestTimeout(request); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+			span.SetAttributes(attribute.String("mcp.request.timeout", timeout.String()))
+		}
+
 		span.AddEvent("tool.execution.start")
 		startTime := time.Now()
 
@@ -799,3 +799,48 @@ func TestWithTracingPerformance(t *testing.T) {
 	// Verify performance is reasonable (should complete in less than 1 second)
 	assert.Less(t, duration, time.Second)
 }
+
+func TestRequestTimeout(t *testing.T) {
+	newRequest := func(timeout any) mcp.CallToolRequest {
+		request := mcp.CallToolRequest{}
+		request.Params.Meta = &mcp.Meta{AdditionalFields: map[string]any{RequestTimeoutMetaKey: timeout}}
+		return request
+	}
+
+	timeout, ok := RequestTimeout(newRequest("30s"))
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, timeout)
+
+	timeout, ok = RequestTimeout(newRequest(float64(1.5)))
+	assert.True(t, ok)
+	assert.Equal(t, 1500*time.Millisecond, timeout)
+
+	for _, invalid := range []any{"soon", "-1s", float64(0), true} {
+		_, ok = RequestTimeout(newRequest(invalid))
+		assert.False(t, ok, invalid)
+	}
+	_, ok = RequestTimeout(mcp.CallToolRequest{})
+	assert.False(t, ok)
+}
+
+func TestWithTracingRequestTimeout(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	handler := WithTracing("test-tool", func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		deadline, hasDeadline = ctx.Deadline()
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "test-tool"
+	request.Params.Meta = &mcp.Meta{AdditionalFields: map[string]any{RequestTimeoutMetaKey: "10s"}}
+	_, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), deadline, time.Second)
+
+	request.Params.Meta = nil
+	_, err = handler(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, hasDeadline)
+}
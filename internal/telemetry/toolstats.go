@@ -0,0 +1,135 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxToolCallOutcomes bounds the call outcomes kept per tool for error rates
+const maxToolCallOutcomes = 1000
+
+// maxToolErrorMessage bounds the length of the last error message kept per tool
+const maxToolErrorMessage = 200
+
+// ToolCallStats summarizes the calls of a tool
+type ToolCallStats struct {
+	// Calls and Errors count the calls made since the window start
+	Calls            int        `json:"calls"`
+	Errors           int        `json:"errors"`
+	LastSuccess      *time.Time `json:"last_success,omitempty"`
+	LastError        *time.Time `json:"last_error,omitempty"`
+	LastErrorMessage string     `json:"last_error_message,omitempty"`
+}
+
+// callOutcome is the time and result of one tool call
+type callOutcome struct {
+	at     time.Time
+	failed bool
+}
+
+// toolCalls holds the recent outcomes of a tool, oldest first
+type toolCalls struct {
+	outcomes         []callOutcome
+	lastSuccess      time.Time
+	lastError        time.Time
+	lastErrorMessage string
+}
+
+var (
+	toolCallsMu sync.Mutex
+	toolCallLog = map[string]*toolCalls{}
+)
+
+// RecordToolCall records the outcome of a tool call. An empty errorMessage
+// marks a successful call.
+func RecordToolCall(toolName string, at time.Time, errorMessage string) {
+	toolCallsMu.Lock()
+	defer toolCallsMu.Unlock()
+
+	calls, ok := toolCallLog[toolName]
+	if !ok {
+		calls = &toolCalls{}
+		toolCallLog[toolName] = calls
+	}
+	if len(calls.outcomes) == maxToolCallOutcomes {
+		calls.outcomes = append(calls.outcomes[:0], calls.outcomes[1:]...)
+	}
+	calls.outcomes = append(calls.outcomes, callOutcome{at: at, failed: errorMessage != ""})
+	if errorMessage == "" {
+		calls.lastSuccess = at
+		return
+	}
+	if len(errorMessage) > maxToolErrorMessage {
+		errorMessage = errorMessage[:maxToolErrorMessage] + "..."
+	}
+	calls.lastError = at
+	calls.lastErrorMessage = errorMessage
+}
+
+// ToolCallStatsSince returns the call statistics of every called tool, counting
+// the calls made since a time. Last success and error times cover all calls.
+func ToolCallStatsSince(since time.Time) map[string]ToolCallStats {
+	toolCallsMu.Lock()
+	defer toolCallsMu.Unlock()
+
+	stats := make(map[string]ToolCallStats, len(toolCallLog))
+	for name, calls := range toolCallLog {
+		var s ToolCallStats
+		for _, outcome := range calls.outcomes {
+			if outcome.at.Before(since) {
+				continue
+			}
+			s.Calls++
+			if outcome.failed {
+				s.Errors++
+			}
+		}
+		if !calls.lastSuccess.IsZero() {
+			lastSuccess := calls.lastSuccess
+			s.LastSuccess = &lastSuccess
+		}
+		if !calls.lastError.IsZero() {
+			lastError := calls.lastError
+			s.LastError = &lastError
+			s.LastErrorMessage = calls.lastErrorMessage
+		}
+		stats[name] = s
+	}
+	return stats
+}
+
+// ResetToolCallStats forgets every recorded tool call
+func ResetToolCallStats() {
+	toolCallsMu.Lock()
+	defer toolCallsMu.Unlock()
+	toolCallLog = map[string]*toolCalls{}
+}
+
+// ToolCallMiddleware records the outcome of every tool call for ToolCallStatsSince
+func ToolCallMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		RecordToolCall(request.Params.Name, time.Now(), toolCallError(result, err))
+		return result, err
+	}
+}
+
+// toolCallError returns the error message of a failed call, or an empty string
+func toolCallError(result *mcp.CallToolResult, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	if result == nil || !result.IsError {
+		return ""
+	}
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok && text.Text != "" {
+			return text.Text
+		}
+	}
+	return "tool returned an error"
+}
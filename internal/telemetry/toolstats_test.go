@@ -0,0 +1,68 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolCallStatsSince(t *testing.T) {
+	ResetToolCallStats()
+	defer ResetToolCallStats()
+
+	now := time.Now()
+	RecordToolCall("k8s_get_resources", now.Add(-2*time.Hour), "")
+	RecordToolCall("k8s_get_resources", now.Add(-time.Minute), "connection refused")
+	RecordToolCall("k8s_get_resources", now, "")
+	RecordToolCall("helm_list_releases", now, strings.Repeat("x", 500))
+
+	stats := ToolCallStatsSince(now.Add(-time.Hour))
+	k8s := stats["k8s_get_resources"]
+	assert.Equal(t, 2, k8s.Calls)
+	assert.Equal(t, 1, k8s.Errors)
+	require.NotNil(t, k8s.LastSuccess)
+	assert.True(t, k8s.LastSuccess.Equal(now))
+	assert.Equal(t, "connection refused", k8s.LastErrorMessage)
+
+	helm := stats["helm_list_releases"]
+	assert.Nil(t, helm.LastSuccess)
+	assert.Len(t, helm.LastErrorMessage, maxToolErrorMessage+len("..."))
+
+	for i := 0; i < maxToolCallOutcomes+10; i++ {
+		RecordToolCall("shell", now, "")
+	}
+	assert.Equal(t, maxToolCallOutcomes, ToolCallStatsSince(now.Add(-time.Hour))["shell"].Calls)
+}
+
+func TestToolCallMiddleware(t *testing.T) {
+	ResetToolCallStats()
+	defer ResetToolCallStats()
+
+	results := []struct {
+		result *mcp.CallToolResult
+		err    error
+	}{
+		{mcp.NewToolResultText("ok"), nil},
+		{mcp.NewToolResultError("kubectl not found"), nil},
+		{nil, errors.New("handler failed")},
+	}
+	for _, r := range results {
+		handler := ToolCallMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return r.result, r.err
+		})
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "k8s_get_resources"
+		_, _ = handler(context.Background(), request)
+	}
+
+	stats := ToolCallStatsSince(time.Now().Add(-time.Minute))["k8s_get_resources"]
+	assert.Equal(t, 3, stats.Calls)
+	assert.Equal(t, 2, stats.Errors)
+	assert.Equal(t, "handler failed", stats.LastErrorMessage)
+}
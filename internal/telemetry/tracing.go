@@ -38,6 +38,10 @@ const (
 	// Trace-specific OTLP configuration
 	OtelExporterOtlpTracesInsecure = "OTEL_EXPORTER_OTLP_TRACES_INSECURE"
 
+	// Log export configuration; logs are exported when OTEL_LOGS_EXPORTER is otlp
+	OtelLogsExporter             = "OTEL_LOGS_EXPORTER"
+	OtelExporterOtlpLogsEndpoint = "OTEL_EXPORTER_OTLP_LOGS_ENDPOINT"
+
 	// Sampling configuration
 	OtelTracesSamplerArg = "OTEL_TRACES_SAMPLER_ARG"
 
@@ -62,6 +66,7 @@ const (
 // Default endpoint paths
 const (
 	DefaultHttpTracesPath = "/v1/traces"
+	DefaultHttpLogsPath   = "/v1/logs"
 )
 
 // SetupOTelSDK initializes the OpenTelemetry SDK
@@ -252,6 +257,12 @@ func normalizeGRPCEndpoint(endpoint string) string {
 
 // normalizeHTTPEndpoint normalizes the endpoint for HTTP usage
 func normalizeHTTPEndpoint(endpoint string, insecure bool) string {
+	return normalizeHTTPSignalEndpoint(endpoint, insecure, DefaultHttpTracesPath)
+}
+
+// normalizeHTTPSignalEndpoint normalizes the endpoint for exporting a signal,
+// such as traces or logs, to the given path over HTTP
+func normalizeHTTPSignalEndpoint(endpoint string, insecure bool, path string) string {
 	// Ensure we have a proper HTTP URL
 	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
 		// Use HTTP if insecure is true or if endpoint contains localhost/127.0.0.1/docker.internal
@@ -262,9 +273,9 @@ func normalizeHTTPEndpoint(endpoint string, insecure bool) string {
 		}
 	}
 
-	// Add /v1/traces suffix if not present
-	if !strings.HasSuffix(endpoint, DefaultHttpTracesPath) {
-		endpoint = strings.TrimSuffix(endpoint, "/") + DefaultHttpTracesPath
+	// Add the signal path, e.g. /v1/traces, if not present
+	if !strings.HasSuffix(endpoint, path) {
+		endpoint = strings.TrimSuffix(endpoint, "/") + path
 	}
 
 	return endpoint
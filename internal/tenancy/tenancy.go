@@ -0,0 +1,400 @@
+// Package tenancy maps authenticated HTTP clients to tenant profiles that
+// restrict which tool providers and namespaces they may use and which
+// storage prefix their data is kept under.
+package tenancy
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// TenancyConfig is the environment variable holding the path of the tenancy configuration file
+const TenancyConfig = "TENANCY_CONFIG"
+
+// Profile describes what a tenant is allowed to do
+type Profile struct {
+	// Name identifies the tenant in logs and errors
+	Name string `json:"name"`
+	// Token is the bearer token the tenant authenticates with
+	Token string `json:"token"`
+	// Providers lists the tool providers the tenant may use; empty allows all
+	Providers []string `json:"providers,omitempty"`
+	// Namespaces lists the namespaces the tenant may target, supporting glob
+	// patterns such as team-a-*; empty allows all
+	Namespaces []string `json:"namespaces,omitempty"`
+	// StoragePrefix isolates the tenant's stored data; defaults to Name
+	StoragePrefix string `json:"storage_prefix,omitempty"`
+	// AllowClusterScoped permits tools without a namespace parameter when
+	// Namespaces is restricted
+	AllowClusterScoped bool `json:"allow_cluster_scoped,omitempty"`
+}
+
+// Config is the tenancy configuration file
+type Config struct {
+	Tenants []Profile `json:"tenants"`
+}
+
+// LoadConfig reads a tenancy configuration file
+func LoadConfig(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenancy config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse tenancy config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// Enabled reports whether a tenancy configuration file is named in the environment
+func Enabled() bool {
+	return strings.TrimSpace(os.Getenv(TenancyConfig)) != ""
+}
+
+// LoadRegistryFromEnv creates a registry from the configuration file named in
+// the environment. It returns nil when tenancy is not configured.
+func LoadRegistryFromEnv() (*Registry, error) {
+	if !Enabled() {
+		return nil, nil
+	}
+
+	cfg, err := LoadConfig(strings.TrimSpace(os.Getenv(TenancyConfig)))
+	if err != nil {
+		return nil, err
+	}
+	return NewRegistry(cfg)
+}
+
+// AllowsProvider reports whether the tenant may use a tool provider
+func (p *Profile) AllowsProvider(provider string) bool {
+	if len(p.Providers) == 0 {
+		return true
+	}
+	for _, allowed := range p.Providers {
+		if allowed == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// RestrictsNamespaces reports whether the tenant is limited to a set of namespaces
+func (p *Profile) RestrictsNamespaces() bool {
+	return len(p.Namespaces) > 0
+}
+
+// AllowsNamespace reports whether the tenant may target a namespace
+func (p *Profile) AllowsNamespace(namespace string) bool {
+	if !p.RestrictsNamespaces() {
+		return true
+	}
+	for _, pattern := range p.Namespaces {
+		if matched, err := path.Match(pattern, namespace); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry authenticates clients against the configured tenants
+type Registry struct {
+	tenants []Profile
+}
+
+// NewRegistry validates the configuration and creates a registry
+func NewRegistry(cfg *Config) (*Registry, error) {
+	if len(cfg.Tenants) == 0 {
+		return nil, fmt.Errorf("tenancy config defines no tenants")
+	}
+
+	names := make(map[string]bool)
+	tokens := make(map[string]bool)
+	tenants := make([]Profile, len(cfg.Tenants))
+	for i, tenant := range cfg.Tenants {
+		if tenant.Name == "" {
+			return nil, fmt.Errorf("tenant %d has no name", i)
+		}
+		if tenant.Token == "" {
+			return nil, fmt.Errorf("tenant %s has no token", tenant.Name)
+		}
+		if names[tenant.Name] {
+			return nil, fmt.Errorf("duplicate tenant name %s", tenant.Name)
+		}
+		if tokens[tenant.Token] {
+			return nil, fmt.Errorf("tenant %s reuses another tenant's token", tenant.Name)
+		}
+		for _, pattern := range tenant.Namespaces {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("tenant %s has invalid namespace pattern %q: %w", tenant.Name, pattern, err)
+			}
+		}
+		if tenant.StoragePrefix == "" {
+			tenant.StoragePrefix = tenant.Name
+		}
+
+		names[tenant.Name] = true
+		tokens[tenant.Token] = true
+		tenants[i] = tenant
+	}
+
+	return &Registry{tenants: tenants}, nil
+}
+
+// Authenticate returns the tenant owning the bearer token
+func (r *Registry) Authenticate(token string) (*Profile, bool) {
+	if token == "" {
+		return nil, false
+	}
+	for i := range r.tenants {
+		if subtle.ConstantTimeCompare([]byte(r.tenants[i].Token), []byte(token)) == 1 {
+			return &r.tenants[i], true
+		}
+	}
+	return nil, false
+}
+
+// profileKey is the context key of the authenticated tenant profile
+type profileKey struct{}
+
+// WithProfile returns a context carrying the tenant profile
+func WithProfile(ctx context.Context, profile *Profile) context.Context {
+	return context.WithValue(ctx, profileKey{}, profile)
+}
+
+// FromContext returns the tenant profile of the request, or nil when tenancy is disabled
+func FromContext(ctx context.Context) *Profile {
+	profile, _ := ctx.Value(profileKey{}).(*Profile)
+	return profile
+}
+
+// StoragePrefix returns the storage prefix of the request's tenant, or an empty string
+func StoragePrefix(ctx context.Context) string {
+	if profile := FromContext(ctx); profile != nil {
+		return profile.StoragePrefix
+	}
+	return ""
+}
+
+// HTTPMiddleware rejects requests without a valid bearer token and adds the
+// authenticated tenant profile to the request context
+func (r *Registry) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		profile, ok := r.Authenticate(strings.TrimSpace(token))
+		if !found || !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kagent-tools"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, req.WithContext(WithProfile(req.Context(), profile)))
+	})
+}
+
+// ToolInfo describes how a tool is scoped
+type ToolInfo struct {
+	// Provider is the tool provider that registered the tool
+	Provider string
+	// Namespaced is true when the tool accepts a namespace parameter
+	Namespaced bool
+	// NamespaceParams lists the other parameters naming the namespaces a
+	// tool reads, each holding one namespace or a comma-separated list
+	NamespaceParams []string
+	// ClusterFiltered is true when the tool accepts a cluster parameter
+	// restricting it to the alerts of one cluster
+	ClusterFiltered bool
+}
+
+// namespaceParams returns every parameter of the tool naming a namespace
+func (info ToolInfo) namespaceParams() []string {
+	if !info.Namespaced {
+		return info.NamespaceParams
+	}
+	return append([]string{"namespace"}, info.NamespaceParams...)
+}
+
+// Enforcer applies tenant profiles to tool listing and dispatch. Tools are
+// denied to tenants until their scope is known through SetTools.
+type Enforcer struct {
+	mu    sync.RWMutex
+	tools map[string]ToolInfo
+}
+
+// NewEnforcer creates an enforcer with no known tools
+func NewEnforcer() *Enforcer {
+	return &Enforcer{tools: make(map[string]ToolInfo)}
+}
+
+// SetTools sets the scope of the registered tools, keyed by tool name
+func (e *Enforcer) SetTools(tools map[string]ToolInfo) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tools = tools
+}
+
+// toolInfo returns the scope of a tool
+func (e *Enforcer) toolInfo(name string) (ToolInfo, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	info, ok := e.tools[name]
+	return info, ok
+}
+
+// Authorize checks whether the tenant may call a tool with the given arguments
+func (e *Enforcer) Authorize(profile *Profile, toolName string, args map[string]any) error {
+	info, ok := e.toolInfo(toolName)
+	if !ok {
+		return fmt.Errorf("tool %s is not available to tenant %s", toolName, profile.Name)
+	}
+	if !profile.AllowsProvider(info.Provider) {
+		return fmt.Errorf("tenant %s is not allowed to use %s tools", profile.Name, info.Provider)
+	}
+	if !profile.RestrictsNamespaces() {
+		return nil
+	}
+
+	names := info.namespaceParams()
+	if len(names) == 0 {
+		if profile.AllowClusterScoped {
+			return nil
+		}
+		return fmt.Errorf("tenant %s is not allowed to use cluster-scoped tool %s", profile.Name, toolName)
+	}
+
+	// Parse the flag the way handlers do, so that JSON booleans and values
+	// such as "1" cannot slip past the check. Invalid values are rejected
+	// rather than guessed at.
+	p := params.FromArgs(args)
+	if p.Bool("all_namespaces", false) || p.Err() != nil {
+		return fmt.Errorf("tenant %s is not allowed to query all namespaces", profile.Name)
+	}
+
+	// An omitted namespace falls back to a tool-specific default, which may
+	// be every namespace, so restricted tenants must always name one
+	for _, name := range names {
+		value, _ := args[name].(string)
+		namespaces := splitNamespaces(value)
+		if len(namespaces) == 0 {
+			return fmt.Errorf("tenant %s must specify %s", profile.Name, name)
+		}
+		for _, namespace := range namespaces {
+			if !profile.AllowsNamespace(namespace) {
+				return fmt.Errorf("tenant %s is not allowed to access namespace %s", profile.Name, namespace)
+			}
+		}
+	}
+	return nil
+}
+
+// splitNamespaces splits a comma-separated list of namespaces
+func splitNamespaces(value string) []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(value, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// ToolFilter hides tools the tenant may not use from tool listings
+func (e *Enforcer) ToolFilter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	profile := FromContext(ctx)
+	if profile == nil {
+		return tools
+	}
+
+	allowed := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		info, ok := e.toolInfo(tool.Name)
+		if !ok || !profile.AllowsProvider(info.Provider) {
+			continue
+		}
+		if profile.RestrictsNamespaces() && len(info.namespaceParams()) == 0 && !profile.AllowClusterScoped {
+			continue
+		}
+		allowed = append(allowed, tool)
+	}
+	return allowed
+}
+
+// ToolMiddleware rejects tool calls the tenant is not authorized to make
+func (e *Enforcer) ToolMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		profile := FromContext(ctx)
+		if profile == nil {
+			return next(ctx, request)
+		}
+
+		if err := e.Authorize(profile, request.Params.Name, request.GetArguments()); err != nil {
+			logger.Get().Info("Tool call denied", "tenant", profile.Name, "tool", request.Params.Name, "reason", err)
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// ListTools returns the tools registered on a server, as listed to a client
+// without a tenant profile
+func ListTools(ctx context.Context, s *server.MCPServer) ([]mcp.Tool, error) {
+	response := s.HandleMessage(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	switch msg := response.(type) {
+	case mcp.JSONRPCResponse:
+		result, ok := msg.Result.(mcp.ListToolsResult)
+		if !ok {
+			return nil, fmt.Errorf("unexpected tools/list result %T", msg.Result)
+		}
+		return result.Tools, nil
+	case mcp.JSONRPCError:
+		// The tools capability is only registered with the first tool
+		if msg.Error.Code == mcp.METHOD_NOT_FOUND {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list tools: %s", msg.Error.Message)
+	default:
+		return nil, fmt.Errorf("unexpected tools/list response %T", response)
+	}
+}
+
+// IsNamespaced reports whether a tool accepts a namespace parameter
+func IsNamespaced(tool mcp.Tool) bool {
+	_, ok := tool.InputSchema.Properties["namespace"]
+	return ok
+}
+
+// namespaceListParams are the parameters other than namespace that name the
+// namespaces a tool reads
+var namespaceListParams = []string{"namespaces", "source_namespace", "destination_namespace"}
+
+// NamespaceParams returns the parameters of a tool other than namespace that
+// name the namespaces it reads
+func NamespaceParams(tool mcp.Tool) []string {
+	var names []string
+	for _, name := range namespaceListParams {
+		if _, ok := tool.InputSchema.Properties[name]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// IsClusterFiltered reports whether a tool accepts a cluster parameter
+func IsClusterFiltered(tool mcp.Tool) bool {
+	_, ok := tool.InputSchema.Properties["cluster"]
+	return ok
+}
@@ -0,0 +1,212 @@
+package tenancy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry(t *testing.T) *Registry {
+	registry, err := NewRegistry(&Config{Tenants: []Profile{
+		{Name: "team-a", Token: "token-a", Providers: []string{"k8s"}, Namespaces: []string{"team-a-*"}},
+		{Name: "platform", Token: "token-p"},
+	}})
+	require.NoError(t, err)
+	return registry
+}
+
+func newTestEnforcer() *Enforcer {
+	enforcer := NewEnforcer()
+	enforcer.SetTools(map[string]ToolInfo{
+		"k8s_get_resources": {Provider: "k8s", Namespaced: true},
+		"k8s_get_nodes":     {Provider: "k8s"},
+		"helm_list":         {Provider: "helm", Namespaced: true},
+		"k8s_search":        {Provider: "k8s", NamespaceParams: []string{"namespaces"}},
+		"k8s_networkpolicy_check": {
+			Provider:        "k8s",
+			NamespaceParams: []string{"source_namespace", "destination_namespace"},
+		},
+	})
+	return enforcer
+}
+
+func TestNewRegistry(t *testing.T) {
+	tests := []struct {
+		name    string
+		tenants []Profile
+	}{
+		{"no tenants", nil},
+		{"missing name", []Profile{{Token: "t"}}},
+		{"missing token", []Profile{{Name: "a"}}},
+		{"duplicate name", []Profile{{Name: "a", Token: "t1"}, {Name: "a", Token: "t2"}}},
+		{"duplicate token", []Profile{{Name: "a", Token: "t"}, {Name: "b", Token: "t"}}},
+		{"invalid pattern", []Profile{{Name: "a", Token: "t", Namespaces: []string{"["}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegistry(&Config{Tenants: tt.tenants})
+			assert.Error(t, err)
+		})
+	}
+
+	registry := newTestRegistry(t)
+	profile, ok := registry.Authenticate("token-a")
+	require.True(t, ok)
+	assert.Equal(t, "team-a", profile.StoragePrefix)
+
+	_, ok = registry.Authenticate("wrong")
+	assert.False(t, ok)
+	_, ok = registry.Authenticate("")
+	assert.False(t, ok)
+}
+
+func TestLoadRegistryFromEnv(t *testing.T) {
+	t.Setenv(TenancyConfig, "")
+	registry, err := LoadRegistryFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, registry)
+
+	filename := filepath.Join(t.TempDir(), "tenancy.json")
+	require.NoError(t, os.WriteFile(filename, []byte(`{"tenants":[{"name":"a","token":"t","storage_prefix":"p"}]}`), 0o600))
+	t.Setenv(TenancyConfig, filename)
+
+	registry, err = LoadRegistryFromEnv()
+	require.NoError(t, err)
+	profile, ok := registry.Authenticate("t")
+	require.True(t, ok)
+	assert.Equal(t, "p", profile.StoragePrefix)
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	var seen *Profile
+	handler := newTestRegistry(t).HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Nil(t, seen)
+
+	req.Header.Set("Authorization", "Bearer token-a")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, seen)
+	assert.Equal(t, "team-a", seen.Name)
+}
+
+func TestAuthorize(t *testing.T) {
+	registry := newTestRegistry(t)
+	teamA, _ := registry.Authenticate("token-a")
+	platform, _ := registry.Authenticate("token-p")
+	enforcer := newTestEnforcer()
+
+	tests := []struct {
+		name        string
+		profile     *Profile
+		tool        string
+		args        map[string]any
+		expectError bool
+	}{
+		{"allowed namespace", teamA, "k8s_get_resources", map[string]any{"namespace": "team-a-prod"}, false},
+		{"other namespace", teamA, "k8s_get_resources", map[string]any{"namespace": "team-b"}, true},
+		{"missing namespace", teamA, "k8s_get_resources", map[string]any{}, true},
+		{"all namespaces", teamA, "k8s_get_resources", map[string]any{"namespace": "team-a-prod", "all_namespaces": "true"}, true},
+		{"all namespaces as JSON boolean", teamA, "k8s_get_resources", map[string]any{"namespace": "team-a-prod", "all_namespaces": true}, true},
+		{"all namespaces as 1", teamA, "k8s_get_resources", map[string]any{"namespace": "team-a-prod", "all_namespaces": "1"}, true},
+		{"all namespaces as True", teamA, "k8s_get_resources", map[string]any{"namespace": "team-a-prod", "all_namespaces": "True"}, true},
+		{"invalid all namespaces", teamA, "k8s_get_resources", map[string]any{"namespace": "team-a-prod", "all_namespaces": "yes"}, true},
+		{"all namespaces false", teamA, "k8s_get_resources", map[string]any{"namespace": "team-a-prod", "all_namespaces": false}, false},
+		{"allowed namespace list", teamA, "k8s_search", map[string]any{"namespaces": "team-a-prod, team-a-dev"}, false},
+		{"namespace list with other namespace", teamA, "k8s_search", map[string]any{"namespaces": "team-a-prod,team-b"}, true},
+		{"missing namespace list", teamA, "k8s_search", map[string]any{"query": "web"}, true},
+		{"blank namespace list", teamA, "k8s_search", map[string]any{"namespaces": " , "}, true},
+		{"allowed source and destination", teamA, "k8s_networkpolicy_check", map[string]any{"source_namespace": "team-a-web", "destination_namespace": "team-a-db"}, false},
+		{"other source namespace", teamA, "k8s_networkpolicy_check", map[string]any{"source_namespace": "team-b", "destination_namespace": "team-a-db"}, true},
+		{"other destination namespace", teamA, "k8s_networkpolicy_check", map[string]any{"source_namespace": "team-a-web", "destination_namespace": "kube-system"}, true},
+		{"missing source namespace", teamA, "k8s_networkpolicy_check", map[string]any{"destination_namespace": "team-a-db"}, true},
+		{"missing destination namespace", teamA, "k8s_networkpolicy_check", map[string]any{"source_namespace": "team-a-web"}, true},
+		{"cluster scoped", teamA, "k8s_get_nodes", nil, true},
+		{"disallowed provider", teamA, "helm_list", map[string]any{"namespace": "team-a-prod"}, true},
+		{"unknown tool", platform, "unknown", nil, true},
+		{"unrestricted tenant", platform, "helm_list", map[string]any{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := enforcer.Authorize(tt.profile, tt.tool, tt.args)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestToolFilterAndMiddleware(t *testing.T) {
+	teamA, _ := newTestRegistry(t).Authenticate("token-a")
+	enforcer := newTestEnforcer()
+	tools := []mcp.Tool{
+		mcp.NewTool("k8s_get_resources"),
+		mcp.NewTool("k8s_get_nodes"),
+		mcp.NewTool("helm_list"),
+	}
+
+	assert.Len(t, enforcer.ToolFilter(context.Background(), tools), 3)
+	filtered := enforcer.ToolFilter(WithProfile(context.Background(), teamA), tools)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "k8s_get_resources", filtered[0].Name)
+
+	called := false
+	handler := enforcer.ToolMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "helm_list"
+	result, err := handler(WithProfile(context.Background(), teamA), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.False(t, called)
+
+	result, err = handler(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.True(t, called)
+}
+
+func TestListTools(t *testing.T) {
+	s := server.NewMCPServer("test", "0.0.0")
+	tools, err := ListTools(context.Background(), s)
+	require.NoError(t, err)
+	assert.Empty(t, tools)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+	s.AddTool(mcp.NewTool("namespaced", mcp.WithString("namespace"), mcp.WithString("namespaces"), mcp.WithString("destination_namespace"), mcp.WithString("cluster")), handler)
+	s.AddTool(mcp.NewTool("cluster"), handler)
+
+	tools, err = ListTools(context.Background(), s)
+	require.NoError(t, err)
+	require.Len(t, tools, 2)
+	assert.False(t, IsNamespaced(tools[0]))
+	assert.False(t, IsClusterFiltered(tools[0]))
+	assert.True(t, IsNamespaced(tools[1]))
+	assert.Empty(t, NamespaceParams(tools[0]))
+	assert.Equal(t, []string{"namespaces", "destination_namespace"}, NamespaceParams(tools[1]))
+	assert.True(t, IsClusterFiltered(tools[1]))
+}
@@ -3,21 +3,59 @@ package alerts
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/tmc/langchaingo/llms"
 
+	"github.com/kagent-dev/tools/internal/budget"
 	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/llm"
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/reload"
 	"github.com/kagent-dev/tools/internal/telemetry"
+	"github.com/kagent-dev/tools/pkg/runbooks"
 )
 
+// languageDescription documents the language parameter of tools generating analyses
+const languageDescription = "Language of the generated analysis, as a code or name (e.g. fr, pt-BR, Japanese; default: the session's language, LLM_LANGUAGE or English). Fixed text such as knowledge base explanations stays in English"
+
+// clusterFilterDescription documents the cluster parameter of tools querying stored alerts
+const clusterFilterDescription = "Only include the alerts of this cluster, as named by CLUSTER_NAME or the kubeconfig context of the server that stored them (default: the session's cluster set with set_context, else every cluster)"
+
 // AlertTool struct to hold the LLM model and kubeconfig
 type AlertTool struct {
-	kubeconfig string
-	llmModel   llms.Model
+	kubeconfig   string
+	llmModel     llms.Model
+	notifier     atomic.Pointer[WebhookNotifier]
+	clients      *ClientNotifier
+	store        AlertStore
+	runbookIndex *runbooks.Index
+	summaries    SummaryConfig
+	rules        atomic.Pointer[RuleSet]
+	// rulesInterval is the interval of scheduled rule evaluations, zero when unscheduled
+	rulesInterval time.Duration
+	// sloInterval is the interval of scheduled SLO evaluations, zero when unscheduled
+	sloInterval time.Duration
+	// jobs tracks the goroutines running background jobs
+	jobs       sync.WaitGroup
+	jobsMu     sync.Mutex
+	jobCancels map[string]context.CancelFunc
+	// dispatched holds the IDs of the jobs with a running goroutine
+	dispatched map[string]bool
+	// elected is set when jobs only run while this replica is the leader;
+	// jobsCtx is then the context of the current term, nil between terms
+	elected bool
+	jobsCtx context.Context
+	// monitorsMu serializes the checks of pod monitors with their cancellation
+	monitorsMu sync.Mutex
 }
 
 // PodAlert represents a pod alert with details
@@ -33,6 +71,28 @@ type PodAlert struct {
 	Logs         []string   `json:"logs"`
 	Analysis     string     `json:"analysis"`
 	Remediation  string     `json:"remediation"`
+	State        AlertState `json:"state,omitempty"`
+	// Labels are the pod's labels, used to match service silences
+	Labels map[string]string `json:"labels,omitempty"`
+	// SilencedBy is the ID of the silence suppressing the alert
+	SilencedBy string `json:"silenced_by,omitempty"`
+	// LogsURI references the full logs, in the blob store, when Logs were truncated
+	LogsURI string `json:"logs_uri,omitempty"`
+	// Exceptions are the exceptions, panics and errors the log parsers found in the collected logs
+	Exceptions []LogException `json:"exceptions,omitempty"`
+	// Kind is the kind of the alerted object when an alert rule matched the
+	// events of an object other than a pod
+	Kind string `json:"kind,omitempty"`
+	// Rule is the name of the alert rule that matched the alert's events,
+	// with the severity and tags it assigns
+	Rule     string   `json:"rule,omitempty"`
+	Severity string   `json:"severity,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	// Cluster is the cluster the alert comes from, with its registered metadata
+	Cluster *ClusterInfo `json:"cluster,omitempty"`
+
+	AnalysisResult *AnalysisResult   `json:"analysis_result,omitempty"`
+	Collection     *CollectionStatus `json:"collection,omitempty"`
 }
 
 // PodEvent represents a Kubernetes event
@@ -45,12 +105,97 @@ type PodEvent struct {
 	LastTime  string `json:"last_time"`
 }
 
+// errNoLLM is why generation falls back to deterministic output when no LLM is configured
+var errNoLLM = errors.New("no LLM is configured")
+
+// llmUnavailable returns why the calling session cannot use the LLM, or nil
+// when it can. Callers fall back to deterministic output, such as the
+// scheduling analyzer or script templates, when either no LLM is configured or
+// the session used up its LLM budget.
+func (a *AlertTool) llmUnavailable(ctx context.Context) error {
+	if a.llmModel == nil {
+		return errNoLLM
+	}
+	return budget.AllowLLM(ctx)
+}
+
 func NewAlertTool(llmModel llms.Model) *AlertTool {
-	return &AlertTool{llmModel: llmModel}
+	return &AlertTool{llmModel: llmModel, store: NewMemoryAlertStore()}
 }
 
 func NewAlertToolWithConfig(kubeconfig string, llmModel llms.Model) *AlertTool {
-	return &AlertTool{kubeconfig: kubeconfig, llmModel: llmModel}
+	return &AlertTool{kubeconfig: kubeconfig, llmModel: llmModel, store: NewMemoryAlertStore()}
+}
+
+// WithStore sets the store used to persist alert documents
+func (a *AlertTool) WithStore(store AlertStore) *AlertTool {
+	a.store = store
+	return a
+}
+
+// WithNotifier sets the webhook notifier used to publish alert state transitions
+func (a *AlertTool) WithNotifier(notifier *WebhookNotifier) *AlertTool {
+	a.notifier.Store(notifier)
+	return a
+}
+
+// WithClientNotifier sets the notifier used to push alert activity to connected MCP clients
+func (a *AlertTool) WithClientNotifier(clients *ClientNotifier) *AlertTool {
+	a.clients = clients
+	return a
+}
+
+// WithRunbooks sets the runbook index used to ground analysis prompts
+func (a *AlertTool) WithRunbooks(index *runbooks.Index) *AlertTool {
+	a.runbookIndex = index
+	return a
+}
+
+// transition moves an alert to a new lifecycle state and notifies subscribers
+func (a *AlertTool) transition(ctx context.Context, alert *PodAlert, to AlertState) {
+	a.transitionAll(ctx, []*PodAlert{alert}, to)
+}
+
+// transitionAll moves alerts to a new lifecycle state, storing them with a
+// single batched write, and notifies subscribers
+func (a *AlertTool) transitionAll(ctx context.Context, alerts []*PodAlert, to AlertState) {
+	if len(alerts) == 0 {
+		return
+	}
+
+	from := make([]AlertState, len(alerts))
+	changed := make([]bool, len(alerts))
+	updated := make([]PodAlert, len(alerts))
+	cluster := a.currentCluster(ctx)
+	for i, alert := range alerts {
+		from[i] = alert.State
+		alert.State = to
+		alert.Cluster = cluster
+		updated[i] = *alert
+
+		// Clients are only told about alerts whose state actually changed, so that
+		// repeated queries for the same alert do not flood them
+		previous, err := a.store.Get(ctx, alert.Namespace, alert.PodName)
+		if err != nil {
+			logger.Get().Error("Failed to load alert", "pod", alert.PodName, "namespace", alert.Namespace, "error", err)
+		}
+		changed[i] = previous == nil || previous.Alert.State != to
+	}
+
+	if err := a.store.UpsertMany(ctx, updated); err != nil {
+		logger.Get().Error("Failed to store alerts", "alerts", len(updated), "error", err)
+	}
+
+	for i, alert := range updated {
+		// Silenced alerts are stored, tagged with their silence, without notifying anyone
+		if alert.SilencedBy != "" {
+			continue
+		}
+		a.notifier.Load().Notify(ctx, alert, from[i], to)
+		if changed[i] {
+			a.clients.AlertChanged(ctx, alert, from[i])
+		}
+	}
 }
 
 // runKubectlCommand runs a kubectl command and returns the result
@@ -83,9 +228,19 @@ func (a *AlertTool) runKubectlCommandString(ctx context.Context, args ...string)
 
 // handleGetPodAlerts gets all pod alerts and analyzes them
 func (a *AlertTool) handleGetPodAlerts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	namespace := mcp.ParseString(request, "namespace", "")
-	allNamespaces := mcp.ParseString(request, "all_namespaces", "") == "true"
-	includeAnalysis := mcp.ParseString(request, "include_analysis", "") == "true"
+	p := params.New(request)
+	namespace := p.String("namespace", "")
+	allNamespaces := p.Bool("all_namespaces", false)
+	includeAnalysis := p.Bool("include_analysis", false)
+	parallelism := p.Int("parallelism", collectionParallelism(), params.Range(1, maxCollectionParallelism))
+	podTimeout := p.Duration("pod_timeout", collectionPodTimeout())
+	limits := defaultCollectionLimits()
+	limits.LogLines = p.Int("max_log_lines", limits.LogLines, params.Range(1, maxCollectionLogLines()))
+	limits.LogBytes = p.Int("max_log_bytes", limits.LogBytes, params.Range(1, maxCollectionLogBytes()))
+	batchSize := p.Int("analysis_batch_size", analysisBatchSize(), params.Range(1, maxAnalysisBatchSize))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	// Get all pods with their status
 	args := []string{"get", "pods", "-o", "json"}
@@ -104,8 +259,9 @@ func (a *AlertTool) handleGetPodAlerts(ctx context.Context, request mcp.CallTool
 	var podList struct {
 		Items []struct {
 			Metadata struct {
-				Name      string `json:"name"`
-				Namespace string `json:"namespace"`
+				Name      string            `json:"name"`
+				Namespace string            `json:"namespace"`
+				Labels    map[string]string `json:"labels"`
 			} `json:"metadata"`
 			Status struct {
 				Phase      string `json:"phase"`
@@ -146,6 +302,7 @@ func (a *AlertTool) handleGetPodAlerts(ctx context.Context, request mcp.CallTool
 			PodName:   pod.Metadata.Name,
 			Namespace: pod.Metadata.Namespace,
 			Status:    pod.Status.Phase,
+			Labels:    pod.Metadata.Labels,
 		}
 
 		// Check if pod is in a problematic state
@@ -181,52 +338,63 @@ func (a *AlertTool) handleGetPodAlerts(ctx context.Context, request mcp.CallTool
 		}
 
 		if isAlert {
-			// Get pod events
-			eventsResult, err := a.runKubectlCommandString(ctx, "get", "events", "-n", pod.Metadata.Namespace,
-				"--field-selector", fmt.Sprintf("involvedObject.name=%s", pod.Metadata.Name), "-o", "json")
-			if err == nil {
-				var eventsList struct {
-					Items []struct {
-						Type      string `json:"type"`
-						Reason    string `json:"reason"`
-						Message   string `json:"message"`
-						Count     int32  `json:"count"`
-						FirstTime string `json:"firstTimestamp"`
-						LastTime  string `json:"lastTimestamp"`
-					} `json:"items"`
-				}
-				if err := json.Unmarshal([]byte(eventsResult), &eventsList); err == nil {
-					for _, event := range eventsList.Items {
-						alert.Events = append(alert.Events, PodEvent{
-							Type:      event.Type,
-							Reason:    event.Reason,
-							Message:   event.Message,
-							Count:     event.Count,
-							FirstTime: event.FirstTime,
-							LastTime:  event.LastTime,
-						})
-					}
-				}
-			}
-
-			// Get pod logs if available
-			logsResult, err := a.runKubectlCommandString(ctx, "logs", pod.Metadata.Name, "-n", pod.Metadata.Namespace, "--tail=50")
-			if err == nil {
-				alert.Logs = strings.Split(strings.TrimSpace(logsResult), "\n")
-			}
-
 			alerts = append(alerts, alert)
 		}
 	}
 
-	// Generate analysis using LLM if requested
-	if includeAnalysis && a.llmModel != nil && len(alerts) > 0 {
-		for i := range alerts {
-			analysis, err := a.generateAnalysis(ctx, alerts[i])
-			if err == nil {
-				alerts[i].Analysis = analysis
+	// Alert rules add the objects whose events they match, and classify the pods found above
+	ruleNamespace := namespace
+	if allNamespaces {
+		ruleNamespace = ""
+	}
+	ruleAlerts, err := a.ruleAlerts(ctx, ruleNamespace)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to evaluate alert rules: %v", err)), nil
+	}
+	alerts = mergeRuleAlerts(alerts, ruleAlerts)
+
+	// Collect events and logs for the alerting pods in parallel
+	a.collectPodData(ctx, alerts, parallelism, podTimeout, limits)
+	a.tagSilenced(ctx, alerts)
+	collected := make([]*PodAlert, len(alerts))
+	for i := range alerts {
+		collected[i] = &alerts[i]
+	}
+	a.transitionAll(ctx, collected, AlertStateCollected)
+
+	// Generate analysis if requested, skipping silenced alerts
+	var unsilenced []int
+	var pending []PodAlert
+	for i, alert := range alerts {
+		if alert.SilencedBy == "" {
+			unsilenced = append(unsilenced, i)
+			pending = append(pending, alert)
+		}
+	}
+	if includeAnalysis && len(pending) > 0 {
+		analyses := make([]*AnalysisResult, len(pending))
+		if a.llmUnavailable(ctx) == nil {
+			analyses = a.analyzePodAlerts(ctx, pending, batchSize)
+		}
+		// Pending pods without an LLM analysis fall back to the scheduling analyzer
+		for j, analysis := range analyses {
+			if analysis == nil {
+				analyses[j] = a.schedulingFallback(ctx, pending[j])
 			}
 		}
+
+		// Each pod is only updated with its own analysis
+		var analyzed []*PodAlert
+		for j, analysis := range analyses {
+			i := unsilenced[j]
+			if analysis != nil {
+				alerts[i].Analysis = analysis.Text()
+				alerts[i].Remediation = strings.Join(analysis.RemediationSteps(), "\n")
+				alerts[i].AnalysisResult = analysis
+				analyzed = append(analyzed, &alerts[i])
+			}
+		}
+		a.transitionAll(ctx, analyzed, AlertStateAnalyzed)
 	}
 
 	// Convert to JSON for response
@@ -239,51 +407,18 @@ func (a *AlertTool) handleGetPodAlerts(ctx context.Context, request mcp.CallTool
 }
 
 // generateAnalysis uses the LLM to analyze a pod alert
-func (a *AlertTool) generateAnalysis(ctx context.Context, alert PodAlert) (string, error) {
+func (a *AlertTool) generateAnalysis(ctx context.Context, alert PodAlert) (*AnalysisResult, error) {
 	prompt := fmt.Sprintf(`Analyze this Kubernetes pod alert and provide insights:
 
-Pod: %s
-Namespace: %s
-Status: %s
-Reason: %s
-Message: %s
-Restart Count: %d
-
-Events:
-%s
-
-Logs:
 %s
-
 Please provide:
 1. Root cause analysis
 2. Potential solutions
 3. Prevention recommendations
 
-Provide a concise but comprehensive analysis.`,
-		alert.PodName, alert.Namespace, alert.Status, alert.Reason, alert.Message, alert.RestartCount,
-		formatEvents(alert.Events), strings.Join(alert.Logs, "\n"))
-
-	contents := []llms.MessageContent{
-		{
-			Role: llms.ChatMessageTypeHuman,
-			Parts: []llms.ContentPart{
-				llms.TextContent{Text: prompt},
-			},
-		},
-	}
-
-	resp, err := a.llmModel.GenerateContent(ctx, contents, llms.WithModel("gpt-4o-mini"))
-	if err != nil {
-		return "", err
-	}
+Provide a concise but comprehensive analysis.`, a.formatPodAlert(ctx, alert))
 
-	choices := resp.Choices
-	if len(choices) < 1 {
-		return "", fmt.Errorf("empty response from model")
-	}
-	c1 := choices[0]
-	return c1.Content, nil
+	return a.generateStructuredAnalysis(llm.WithSeverity(ctx, triageSeverity(alert)), AnalysisTypePod, prompt)
 }
 
 // formatEvents formats pod events for the prompt
@@ -302,12 +437,12 @@ func formatEvents(events []PodEvent) string {
 
 // handleGetPodAlertDetails gets detailed information about a specific pod alert
 func (a *AlertTool) handleGetPodAlertDetails(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	podName := mcp.ParseString(request, "pod_name", "")
-	namespace := mcp.ParseString(request, "namespace", "default")
-	includeAnalysis := mcp.ParseString(request, "include_analysis", "") == "true"
-
-	if podName == "" {
-		return mcp.NewToolResultError("pod_name parameter is required"), nil
+	p := params.New(request)
+	podName := p.String("pod_name", "", params.Required())
+	namespace := p.String("namespace", "default")
+	includeAnalysis := p.Bool("include_analysis", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	// Get pod details
@@ -332,12 +467,25 @@ func (a *AlertTool) handleGetPodAlertDetails(ctx context.Context, request mcp.Ca
 	// Combine all information
 	details := fmt.Sprintf("Pod Details:\n%s\n\nLogs:\n%s\n\nEvents:\n%s",
 		describeResult, logsResult, eventsResult)
+	if exceptions := parseLogExceptions(strings.Split(logsResult, "\n")); len(exceptions) > 0 {
+		details += "\n\nExceptions:\n" + formatExceptions(exceptions)
+	}
 
 	// Generate analysis if requested and LLM is available
-	if includeAnalysis && a.llmModel != nil {
+	if includeAnalysis && a.llmUnavailable(ctx) == nil {
 		analysis, err := a.generateDetailedAnalysis(ctx, podName, namespace, details)
 		if err == nil {
-			details += fmt.Sprintf("\n\nAI Analysis:\n%s", analysis)
+			if analysisJSON, err := json.MarshalIndent(analysis, "", "  "); err == nil {
+				details += fmt.Sprintf("\n\nAI Analysis:\n%s", analysisJSON)
+			}
+			a.transition(ctx, &PodAlert{
+				PodName:        podName,
+				Namespace:      namespace,
+				Analysis:       analysis.Text(),
+				Remediation:    strings.Join(analysis.RemediationSteps(), "\n"),
+				AnalysisResult: analysis,
+				State:          AlertStateCollected,
+			}, AlertStateAnalyzed)
 		}
 	}
 
@@ -345,7 +493,7 @@ func (a *AlertTool) handleGetPodAlertDetails(ctx context.Context, request mcp.Ca
 }
 
 // generateDetailedAnalysis uses the LLM to analyze detailed pod information
-func (a *AlertTool) generateDetailedAnalysis(ctx context.Context, podName, namespace, details string) (string, error) {
+func (a *AlertTool) generateDetailedAnalysis(ctx context.Context, podName, namespace, details string) (*AnalysisResult, error) {
 	prompt := fmt.Sprintf(`Analyze this Kubernetes pod in detail:
 
 Pod: %s
@@ -353,40 +501,25 @@ Namespace: %s
 
 Details:
 %s
-
+%s
 Please provide:
 1. Root cause analysis
 2. Specific remediation steps
 3. Prevention strategies
 4. Monitoring recommendations
 
-Provide a detailed technical analysis with actionable steps.`, podName, namespace, details)
-
-	contents := []llms.MessageContent{
-		{
-			Role: llms.ChatMessageTypeHuman,
-			Parts: []llms.ContentPart{
-				llms.TextContent{Text: prompt},
-			},
-		},
-	}
-
-	resp, err := a.llmModel.GenerateContent(ctx, contents, llms.WithModel("gpt-4o-mini"))
-	if err != nil {
-		return "", err
-	}
+Provide a detailed technical analysis with actionable steps.`, podName, namespace, details, a.runbookContext(ctx, details))
 
-	choices := resp.Choices
-	if len(choices) < 1 {
-		return "", fmt.Errorf("empty response from model")
-	}
-	c1 := choices[0]
-	return c1.Content, nil
+	return a.generateStructuredAnalysis(ctx, AnalysisTypePod, prompt)
 }
 
 // handleGetClusterAlerts gets alerts across the entire cluster
 func (a *AlertTool) handleGetClusterAlerts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	includeAnalysis := mcp.ParseString(request, "include_analysis", "") == "true"
+	p := params.New(request)
+	includeAnalysis := p.Bool("include_analysis", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	// Get all pods across all namespaces
 	result, err := a.runKubectlCommandString(ctx, "get", "pods", "--all-namespaces", "-o", "wide")
@@ -437,7 +570,7 @@ func (a *AlertTool) handleGetClusterAlerts(ctx context.Context, request mcp.Call
 	}
 
 	// Generate cluster-wide analysis if requested
-	if includeAnalysis && a.llmModel != nil && len(alerts) > 0 {
+	if includeAnalysis && a.llmUnavailable(ctx) == nil && len(alerts) > 0 {
 		clusterAnalysis, err := a.generateClusterAnalysis(ctx, alerts)
 		if err == nil {
 			// Add cluster analysis to the response
@@ -461,7 +594,7 @@ func (a *AlertTool) handleGetClusterAlerts(ctx context.Context, request mcp.Call
 }
 
 // generateClusterAnalysis uses the LLM to analyze cluster-wide alerts
-func (a *AlertTool) generateClusterAnalysis(ctx context.Context, alerts []PodAlert) (string, error) {
+func (a *AlertTool) generateClusterAnalysis(ctx context.Context, alerts []PodAlert) (*AnalysisResult, error) {
 	alertSummary := fmt.Sprintf("Cluster Alert Summary:\nTotal Alerts: %d\n", len(alerts))
 
 	for _, alert := range alerts {
@@ -481,37 +614,168 @@ Please provide:
 
 Provide a strategic analysis for cluster health improvement.`, alertSummary)
 
-	contents := []llms.MessageContent{
-		{
-			Role: llms.ChatMessageTypeHuman,
-			Parts: []llms.ContentPart{
-				llms.TextContent{Text: prompt},
-			},
-		},
+	return a.generateStructuredAnalysis(llm.WithSeverity(ctx, maxTriageSeverity(alerts)), AnalysisTypeCluster, prompt)
+}
+
+// handleMarkRemediated records that an alert has been remediated and schedules
+// a follow-up check of the pod to measure whether the remediation was effective
+func (a *AlertTool) handleMarkRemediated(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	podName := p.String("pod_name", "", params.Required())
+	namespace := p.String("namespace", "default")
+	remediation := p.String("remediation", "")
+	delay := p.Duration("verify_after", verifyDelay(), params.AllowZero())
+	idempotencyKey := p.String("idempotency_key", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// A retried call returns the remediation recorded by the first one
+	if idempotencyKey != "" {
+		result, err := a.existingRemediation(ctx, JobKindRemediationVerification, idempotencyKey)
+		if result != nil || err != nil {
+			return result, err
+		}
 	}
 
-	resp, err := a.llmModel.GenerateContent(ctx, contents, llms.WithModel("gpt-4o-mini"))
+	alert := PodAlert{
+		PodName:   podName,
+		Namespace: namespace,
+		State:     AlertStateAnalyzed,
+	}
+	doc, err := a.store.Get(ctx, namespace, podName)
 	if err != nil {
-		return "", err
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to load alert: %v", err)), nil
+	}
+	if doc != nil {
+		alert = doc.Alert
+	}
+	alert.Remediation = remediation
+	a.transition(ctx, &alert, AlertStateRemediated)
+
+	// The restart count at remediation time is the baseline for detecting recurrence
+	record := RemediationRecord{
+		Remediation:  remediation,
+		AppliedAt:    time.Now(),
+		VerifyAfter:  delay.String(),
+		Verification: VerificationPending,
+	}
+	if health, err := a.getPodHealth(ctx, namespace, podName); err == nil {
+		record.BaselineRestarts = health.RestartCount
+	}
+
+	record.ID, err = a.store.AddRemediation(ctx, namespace, podName, record)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to store remediation: %v", err)), nil
+	}
+	job, err := a.scheduleVerification(ctx, namespace, podName, record, delay, idempotencyKey)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to schedule remediation verification: %v", err)), nil
 	}
 
-	choices := resp.Choices
-	if len(choices) < 1 {
-		return "", fmt.Errorf("empty response from model")
+	alertJSON, err := json.MarshalIndent(map[string]interface{}{
+		"alert":       alert,
+		"remediation": record,
+		"job_id":      job.ID,
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal alert: %v", err)), nil
 	}
-	c1 := choices[0]
-	return c1.Content, nil
+
+	return mcp.NewToolResultText(string(alertJSON)), nil
 }
 
-// RegisterTools registers all alert tools with the MCP server
+// RegisterTools registers all alert tools with the MCP server, using the
+// alert store configured by ALERT_STORE_FILE
 func RegisterTools(s *server.MCPServer, llm llms.Model, kubeconfig string) {
-	alertTool := NewAlertToolWithConfig(kubeconfig, llm)
+	RegisterToolsWithStore(s, llm, kubeconfig, NewAlertStoreFromEnv(false), nil)
+}
+
+// RegisterToolsWithStore registers all alert tools with the MCP server,
+// persisting alerts to store. Background jobs run on the replica elected by
+// elector, or always when elector is nil.
+func RegisterToolsWithStore(s *server.MCPServer, llm llms.Model, kubeconfig string, store AlertStore, elector Elector) {
+	alertTool := NewAlertToolWithConfig(kubeconfig, llm).WithStore(store)
+
+	notifier, err := NewWebhookNotifier(LoadWebhookConfig())
+	if err != nil {
+		logger.Get().Error("Alert webhooks disabled", "error", err)
+	}
+	alertTool.WithNotifier(notifier)
+	// Rotated webhook URLs and tokens apply to the next notification; deliveries
+	// in flight finish with the previous configuration
+	reload.OnReload(func() {
+		notifier, err := NewWebhookNotifier(LoadWebhookConfig())
+		if err != nil {
+			logger.Get().Error("Keeping previous alert webhooks, the reloaded configuration is invalid", "error", err)
+			return
+		}
+		alertTool.WithNotifier(notifier)
+	})
+
+	runbookIndex := runbooks.NewIndexFromEnv()
+	if len(runbookIndex.Sources()) > 0 {
+		if err := runbookIndex.Reload(context.Background()); err != nil {
+			logger.Get().Error("Failed to load runbooks", "error", err)
+		}
+	}
+	alertTool.WithRunbooks(runbookIndex)
+	alertTool.WithClientNotifier(NewClientNotifier(s))
+	if elector != nil {
+		alertTool.WithElector(elector)
+	} else if err := alertTool.ResumeJobs(context.Background()); err != nil {
+		logger.Get().Error("Failed to resume jobs", "error", err)
+	}
+	if rules, err := LoadRuleSet(); err != nil {
+		logger.Get().Error("Alert rules disabled", "error", err)
+	} else {
+		alertTool.WithRules(rules)
+	}
+	// Edited rules apply to the next evaluation; invalid rules keep the previous ones
+	reload.OnReload(func() {
+		rules, err := LoadRuleSet()
+		if err != nil {
+			logger.Get().Error("Keeping previous alert rules, the reloaded rules are invalid", "error", err)
+			return
+		}
+		alertTool.WithRules(rules)
+	})
+	if interval, err := rulesInterval(); err != nil {
+		logger.Get().Error("Scheduled alert rule evaluation disabled", "error", err)
+	} else if err := alertTool.WithRulesSchedule(interval).ScheduleRules(context.Background()); err != nil {
+		logger.Get().Error("Failed to schedule alert rule evaluation", "error", err)
+	}
+	if summaries, err := LoadSummaryConfig(); err != nil {
+		logger.Get().Error("Scheduled ops summaries disabled", "error", err)
+	} else if err := alertTool.WithSummarySchedule(summaries).ScheduleSummaries(context.Background()); err != nil {
+		logger.Get().Error("Failed to schedule ops summaries", "error", err)
+	}
+	if interval, err := sloIntervalFromEnv(); err != nil {
+		logger.Get().Error("Scheduled SLO evaluation disabled", "error", err)
+	} else if err := alertTool.WithSLOSchedule(interval).ScheduleSLOs(context.Background()); err != nil {
+		logger.Get().Error("Failed to schedule SLO evaluation", "error", err)
+	}
+
+	s.AddResourceTemplate(mcp.NewResourceTemplate(alertResourceScheme+"{namespace}/{pod_name}", "Pod alert",
+		mcp.WithTemplateDescription("Stored pod alert with its lifecycle state, analysis and remediation history. Clients are sent notifications/resources/updated when it changes."),
+		mcp.WithTemplateMIMEType("application/json"),
+	), alertTool.handleReadAlertResource)
+
+	s.AddResourceTemplate(mcp.NewResourceTemplate(reportResourceScheme+"{id}", "Incident report",
+		mcp.WithTemplateDescription("Incident report rendered by alerts_generate_incident_report, or operations summary rendered by alerts_generate_ops_summary"),
+	), alertTool.handleReadReportResource)
 
 	s.AddTool(mcp.NewTool("alerts_get_pod_alerts",
 		mcp.WithDescription("Get all pod alerts in a namespace or cluster"),
 		mcp.WithString("namespace", mcp.Description("Namespace to check (optional, defaults to all)")),
 		mcp.WithString("all_namespaces", mcp.Description("Check all namespaces (true/false)")),
 		mcp.WithString("include_analysis", mcp.Description("Include AI analysis of alerts (true/false)")),
+		mcp.WithNumber("parallelism", mcp.Description("Number of pods to collect events and logs for concurrently (default: ALERT_COLLECTION_PARALLELISM or 8)")),
+		mcp.WithString("pod_timeout", mcp.Description("Timeout for collecting a single pod's events and logs (e.g. 30s, default: ALERT_COLLECTION_POD_TIMEOUT or 30s)")),
+		mcp.WithNumber("max_log_lines", mcp.Description("Maximum number of trailing log lines collected per pod (default: 50, max: ALERT_COLLECTION_MAX_LOG_LINES or 1000)")),
+		mcp.WithNumber("max_log_bytes", mcp.Description("Maximum number of bytes of trailing logs collected per pod (default: 65536, max: ALERT_COLLECTION_MAX_LOG_BYTES or 1048576)")),
+		mcp.WithNumber("analysis_batch_size", mcp.Description("Number of pods analyzed per LLM prompt, each receiving its own analysis (default: ALERT_ANALYSIS_BATCH_SIZE or 5)")),
+		mcp.WithString("language", mcp.Description(languageDescription)),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_get_pod_alerts", alertTool.handleGetPodAlerts)))
 
 	s.AddTool(mcp.NewTool("alerts_get_pod_alert_details",
@@ -519,10 +783,207 @@ func RegisterTools(s *server.MCPServer, llm llms.Model, kubeconfig string) {
 		mcp.WithString("pod_name", mcp.Description("Name of the pod"), mcp.Required()),
 		mcp.WithString("namespace", mcp.Description("Namespace of the pod (default: default)")),
 		mcp.WithString("include_analysis", mcp.Description("Include AI analysis (true/false)")),
+		mcp.WithString("language", mcp.Description(languageDescription)),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_get_pod_alert_details", alertTool.handleGetPodAlertDetails)))
 
+	s.AddTool(mcp.NewTool("alerts_analyze_pending_pod",
+		mcp.WithDescription("Explain why a Pending pod is not scheduled: checks each node against the pod's node selector, affinity, tolerations and resource requests, merges the scheduler's FailedScheduling events and ranks the reasons by how many nodes they rule out, and assesses whether its priority class lets it preempt lower-priority pods. Used as the analysis of Pending pod alerts when no LLM analysis is available"),
+		mcp.WithString("pod_name", mcp.Description("Name of the pod"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the pod (default: default)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_analyze_pending_pod", alertTool.handleAnalyzePendingPod)))
+
 	s.AddTool(mcp.NewTool("alerts_get_cluster_alerts",
 		mcp.WithDescription("Get all alerts across the entire cluster"),
 		mcp.WithString("include_analysis", mcp.Description("Include AI analysis of cluster alerts (true/false)")),
+		mcp.WithString("language", mcp.Description(languageDescription)),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_get_cluster_alerts", alertTool.handleGetClusterAlerts)))
+
+	s.AddTool(mcp.NewTool("alerts_mark_remediated",
+		mcp.WithDescription("Mark a pod alert as remediated, notifying any configured alert webhooks"),
+		mcp.WithString("pod_name", mcp.Description("Name of the pod"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the pod (default: default)")),
+		mcp.WithString("remediation", mcp.Description("Description of the remediation that was applied")),
+		mcp.WithString("verify_after", mcp.Description("Delay before re-checking the pod to score the remediation (e.g. 5m, default: ALERT_REMEDIATION_VERIFY_DELAY or 5m)")),
+		mcp.WithString("idempotency_key", mcp.Description("Key identifying this remediation; repeating a call with the same key returns the remediation already recorded")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_mark_remediated", alertTool.handleMarkRemediated)))
+
+	s.AddTool(mcp.NewTool("alerts_remediation_history",
+		mcp.WithDescription("Get the history of remediations with their verification outcome and effectiveness score"),
+		mcp.WithString("pod_name", mcp.Description("Only include remediations for this pod")),
+		mcp.WithString("namespace", mcp.Description("Only include remediations in this namespace")),
+		mcp.WithString("cluster", mcp.Description(clusterFilterDescription)),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_remediation_history", alertTool.handleRemediationHistory)))
+
+	s.AddTool(mcp.NewTool("alerts_generate_remediation_script",
+		mcp.WithDescription("Generate a remediation script for a stored pod alert from the best matching curated template, falling back to LLM generation when no template applies"),
+		mcp.WithString("pod_name", mcp.Description("Name of the pod"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the pod (default: default)")),
+		mcp.WithString("template", mcp.Description("Use this template instead of matching one ("+strings.Join(scriptTemplateNames(), ", ")+")")),
+		mcp.WithString("parameters", mcp.Description(`Template parameters as a JSON object of strings, overriding those derived from the alert (e.g. {"memory": "1Gi"})`)),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_generate_remediation_script", alertTool.handleGenerateRemediationScript)))
+
+	s.AddTool(mcp.NewTool("alerts_run_remediation",
+		mcp.WithDescription("Run a curated remediation script for a stored pod alert in a short-lived Kubernetes Job as the dedicated remediation ServiceAccount (ALERT_REMEDIATION_SERVICE_ACCOUNT, default: kagent-remediation) rather than with the tool server's credentials. The Job's outcome and logs are recorded in the remediation history, and a successful run marks the alert remediated and schedules its verification. LLM generated scripts are never run"),
+		mcp.WithString("pod_name", mcp.Description("Name of the pod"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the pod, in which the Job runs (default: default)")),
+		mcp.WithString("template", mcp.Description("Run this template instead of matching one ("+strings.Join(scriptTemplateNames(), ", ")+")")),
+		mcp.WithString("parameters", mcp.Description(`Template parameters as a JSON object of strings, overriding those derived from the alert (e.g. {"memory": "1Gi"})`)),
+		mcp.WithString("timeout", mcp.Description("Deadline of the Job (e.g. 5m, default: 10m, max: 1h)")),
+		mcp.WithString("verify_after", mcp.Description("Delay after the Job succeeded before re-checking the pod to score the remediation (e.g. 5m, default: ALERT_REMEDIATION_VERIFY_DELAY or 5m)")),
+		mcp.WithString("idempotency_key", mcp.Description("Key identifying this remediation; repeating a call with the same key returns the remediation already started")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_run_remediation", alertTool.handleRunRemediation)))
+
+	s.AddTool(mcp.NewTool("alerts_list_script_templates",
+		mcp.WithDescription("List the curated remediation script templates and their parameters"),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_list_script_templates", alertTool.handleListScriptTemplates)))
+
+	s.AddTool(mcp.NewTool("alerts_compare",
+		mcp.WithDescription("Compare two stored alerts, such as the same service yesterday and today, diffing their issue types, affected resources, severity and log signatures to tell recurring issues from new regressions"),
+		mcp.WithString("before", mcp.Description("ID of the earlier alert (namespace/pod_name or alerts:// URI)"), mcp.Required()),
+		mcp.WithString("after", mcp.Description("ID of the later alert (namespace/pod_name or alerts:// URI)"), mcp.Required()),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_compare", alertTool.handleCompareAlerts)))
+
+	s.AddTool(mcp.NewTool("alerts_generate_incident_report",
+		mcp.WithDescription("Render a stored alert's analysis, event timeline, log excerpt and remediations into a Markdown or HTML incident report, returned as a downloadable resource"),
+		mcp.WithString("pod_name", mcp.Description("Name of the pod"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the pod (default: default)")),
+		mcp.WithString("format", mcp.Description("Report format (markdown, html, text, slack for Slack Block Kit JSON; default: markdown)")),
+		mcp.WithString("title", mcp.Description("Report title (default: Incident report: <namespace>/<pod_name>)")),
+		mcp.WithNumber("max_log_lines", mcp.Description("Maximum number of trailing log lines to include, 0 for all (default: 20)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_generate_incident_report", alertTool.handleGenerateIncidentReport)))
+
+	s.AddTool(mcp.NewTool("alerts_generate_ops_summary",
+		mcp.WithDescription("Summarize the alert store, across every cluster or for one, over the last day or week: new alerts, resolved incidents, top crashing workloads and capacity trends, with an optional LLM narrative. The summary is stored as a downloadable resource; set ALERT_SUMMARY_SCHEDULE to generate it on a schedule"),
+		mcp.WithString("period", mcp.Description("Period to summarize (daily, weekly; default: daily)")),
+		mcp.WithString("format", mcp.Description("Summary format (markdown, text; default: markdown)")),
+		mcp.WithString("cluster", mcp.Description(clusterFilterDescription+". Summaries of several clusters break the counts down by cluster")),
+		mcp.WithString("include_narrative", mcp.Description("Add an LLM narrative of the period (true/false, default: true when an LLM is configured)")),
+		mcp.WithString("language", mcp.Description(languageDescription)),
+		mcp.WithString("deliver", mcp.Description("Deliver the summary to the alert webhooks and connected clients (true/false, default: false)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_generate_ops_summary", alertTool.handleGenerateOpsSummary)))
+
+	s.AddTool(mcp.NewTool("alerts_explain_error",
+		mcp.WithDescription("Explain a raw error message, such as kubectl output, an Envoy response or a CrashLoopBackOff reason. The error is matched against a curated knowledge base first, falling back to the LLM grounded in similar resolved incidents and runbooks; returns the cause, a confidence level and next steps"),
+		mcp.WithString("error", mcp.Description("Error message to explain"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Only search the resolved incidents of this namespace (default: all)")),
+		mcp.WithString("cluster", mcp.Description(clusterFilterDescription)),
+		mcp.WithString("language", mcp.Description(languageDescription)),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_explain_error", alertTool.handleExplainError)))
+
+	s.AddTool(mcp.NewTool("alerts_evaluate_rules",
+		mcp.WithDescription("Evaluate the alert rules of ALERT_RULES_FILE against the current Kubernetes events, storing an alert, with the rule's severity and tags, for each object whose events match a rule. Set ALERT_RULES_INTERVAL to evaluate them on a schedule"),
+		mcp.WithString("namespace", mcp.Description("Only evaluate the events of this namespace (default: all)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_evaluate_rules", alertTool.handleEvaluateRules)))
+
+	s.AddTool(mcp.NewTool("alerts_search_runbooks",
+		mcp.WithDescription("Search the indexed runbooks for sections relevant to a symptom or error"),
+		mcp.WithString("query", mcp.Description("Symptom, error message or alert reason to search for"), mcp.Required()),
+		mcp.WithNumber("top_k", mcp.Description("Maximum number of sections to return (default: 3)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_search_runbooks", alertTool.handleSearchRunbooks)))
+
+	s.AddTool(mcp.NewTool("alerts_reload_runbooks",
+		mcp.WithDescription("Reload and re-index runbooks from the configured RUNBOOK_SOURCES"),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_reload_runbooks", alertTool.handleReloadRunbooks)))
+
+	s.AddTool(mcp.NewTool("alerts_jobs",
+		mcp.WithDescription("List, cancel or retry the background jobs, such as remediation verifications, persisted in the alert store"),
+		mcp.WithString("action", mcp.Description("Action to perform (list, cancel, retry; default: list)")),
+		mcp.WithString("job_id", mcp.Description("ID of the job to cancel or retry")),
+		mcp.WithString("status", mcp.Description("Only list jobs with this status (queued, running, succeeded, failed, cancelled)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_jobs", alertTool.handleJobs)))
+
+	s.AddTool(mcp.NewTool("alerts_create_silence",
+		mcp.WithDescription("Silence alerts during a time window, such as planned maintenance. Matching alerts are still stored, tagged with the silence, but are not analyzed and do not trigger webhooks or client notifications"),
+		mcp.WithString("namespace", mcp.Description("Namespace whose alerts are silenced"), mcp.Required()),
+		mcp.WithString("service", mcp.Description("Only silence the pods selected by this service (optional)")),
+		mcp.WithString("issue_type", mcp.Description("Only silence alerts with this issue type, such as CrashLoopBackOff or a warning event reason (optional)")),
+		mcp.WithString("starts_at", mcp.Description("Start of the silence as an RFC 3339 time (default: now)")),
+		mcp.WithString("ends_at", mcp.Description("End of the silence as an RFC 3339 time")),
+		mcp.WithString("duration", mcp.Description("Length of the silence (e.g. 2h), instead of ends_at")),
+		mcp.WithString("comment", mcp.Description("Reason for the silence, such as the maintenance ticket")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_create_silence", alertTool.handleCreateSilence)))
+
+	s.AddTool(mcp.NewTool("alerts_list_silences",
+		mcp.WithDescription("List the pending and active alert silences"),
+		mcp.WithString("include_expired", mcp.Description("Include expired silences (true/false, default: false)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_list_silences", alertTool.handleListSilences)))
+
+	s.AddTool(mcp.NewTool("alerts_delete_silence",
+		mcp.WithDescription("Delete an alert silence, ending it early"),
+		mcp.WithString("silence_id", mcp.Description("ID of the silence"), mcp.Required()),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_delete_silence", alertTool.handleDeleteSilence)))
+
+	s.AddTool(mcp.NewTool("alerts_get_schemas",
+		mcp.WithDescription("Get the JSON Schemas of the stored alert documents, for validating or generating code against the storage format"),
+		mcp.WithString("name", mcp.Description("Only return this schema ("+storageSchemaNamesDescription()+"; default: all)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alerts_get_schemas", alertTool.handleGetSchemas)))
+
+	s.AddTool(mcp.NewTool("slo_define",
+		mcp.WithDescription("Define or replace a service level objective: the fraction of good events an SLI query must reach over a rolling window. The SLO is evaluated right away and then in the background"),
+		mcp.WithString("name", mcp.Description("Name of the SLO"), mcp.Required()),
+		mcp.WithString("target", mcp.Description("Objective as a fraction or a percentage, such as 0.999 or 99.9"), mcp.Required()),
+		mcp.WithString("sli_query", mcp.Description("PromQL returning the fraction of good events over $window, such as sum(rate(http_requests_total{code!~\"5..\"}[$window])) / sum(rate(http_requests_total[$window]))"), mcp.Required()),
+		mcp.WithString("window", mcp.Description("Rolling compliance window, at least 1h (default: 30d)")),
+		mcp.WithString("service", mcp.Description("Service the SLO belongs to")),
+		mcp.WithString("description", mcp.Description("What the SLO promises, in a sentence")),
+		mcp.WithString("datasource", mcp.Description("Named Prometheus datasource to query (default: the default Prometheus datasource)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("slo_define", alertTool.handleDefineSLO)))
+
+	s.AddTool(mcp.NewTool("slo_delete",
+		mcp.WithDescription("Delete a service level objective"),
+		mcp.WithString("name", mcp.Description("Name of the SLO"), mcp.Required()),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("slo_delete", alertTool.handleDeleteSLO)))
+
+	s.AddTool(mcp.NewTool("slo_status",
+		mcp.WithDescription("List the service level objectives with their error budget and health, the most urgent first, to prioritize incidents by user impact"),
+		mcp.WithString("name", mcp.Description("Only return this SLO")),
+		mcp.WithString("service", mcp.Description("Only return the SLOs of this service")),
+		mcp.WithString("refresh", mcp.Description("Evaluate the SLOs now instead of returning the latest background evaluation (true/false, default: false)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("slo_status", alertTool.handleSLOStatus)))
+
+	s.AddTool(mcp.NewTool("slo_burn_rate",
+		mcp.WithDescription("Evaluate how fast a service level objective burns its error budget now, with the multiwindow burn rate alerts that would page or open a ticket"),
+		mcp.WithString("name", mcp.Description("Name of the SLO"), mcp.Required()),
+		mcp.WithString("windows", mcp.Description("Comma-separated extra windows to compute the burn rate over, such as 15m,12h")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("slo_burn_rate", alertTool.handleSLOBurnRate)))
+
+	s.AddTool(mcp.NewTool("cluster_metadata_set",
+		mcp.WithDescription("Register or update the metadata of a cluster: its environment, criticality, owning team and notification channels. The metadata is attached to the cluster's alerts, reports and notifications. Only the given fields change"),
+		mcp.WithString("name", mcp.Description("Name of the cluster (default: "+ClusterName+", else the current kubeconfig context)")),
+		mcp.WithString("environment", mcp.Description("Environment the cluster serves, such as prod or staging")),
+		mcp.WithString("criticality", mcp.Description("Criticality of the cluster ("+strings.Join(clusterCriticalities, ", ")+")")),
+		mcp.WithString("team", mcp.Description("Team owning the cluster")),
+		mcp.WithString("channels", mcp.Description("Comma-separated notification channels of the cluster's incidents, such as slack:#payments-oncall, replacing the registered ones")),
+		mcp.WithString("labels", mcp.Description(`Labels of the cluster as a JSON object of strings, such as {"region": "eu-west-1"}; an empty value removes a label`)),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("cluster_metadata_set", alertTool.handleSetClusterMetadata)))
+
+	s.AddTool(mcp.NewTool("cluster_metadata_get",
+		mcp.WithDescription("Get the registered metadata of the clusters, and which of them the server manages"),
+		mcp.WithString("name", mcp.Description("Only return this cluster")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("cluster_metadata_get", alertTool.handleGetClusterMetadata)))
+
+	s.AddTool(mcp.NewTool("cluster_metadata_delete",
+		mcp.WithDescription("Delete the registered metadata of a cluster"),
+		mcp.WithString("name", mcp.Description("Name of the cluster"), mcp.Required()),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("cluster_metadata_delete", alertTool.handleDeleteClusterMetadata)))
+
+	s.AddTool(mcp.NewTool("monitor_pod",
+		mcp.WithDescription("Monitor a pod for a limited time, such as the next hour after a fix. Status changes, restarts and warning events are recorded in the background, and an outcome summary is stored and sent to the alert webhooks and connected clients when the monitor ends"),
+		mcp.WithString("namespace", mcp.Description("Namespace of the pod"), mcp.Required()),
+		mcp.WithString("pod_name", mcp.Description("Name of the pod"), mcp.Required()),
+		mcp.WithString("duration", mcp.Description(fmt.Sprintf("How long to monitor the pod, at most %s (default: %s)", maxMonitorDuration, defaultMonitorDuration))),
+		mcp.WithString("interval", mcp.Description(fmt.Sprintf("Time between checks, at least %s (default: %s)", minMonitorInterval, defaultMonitorInterval))),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("monitor_pod", alertTool.handleMonitorPod)))
+
+	s.AddTool(mcp.NewTool("monitor_pod_status",
+		mcp.WithDescription("Get a pod monitor with its observations and outcome, or list the pod monitors"),
+		mcp.WithString("monitor_id", mcp.Description("ID of the monitor (default: list all monitors)")),
+		mcp.WithString("namespace", mcp.Description("Only list the monitors of pods in this namespace")),
+		mcp.WithString("status", mcp.Description("Only list the monitors with this status ("+monitorStatusesDescription+")")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("monitor_pod_status", alertTool.handleMonitorPodStatus)))
+
+	s.AddTool(mcp.NewTool("monitor_pod_cancel",
+		mcp.WithDescription("Stop an active pod monitor early, recording and notifying its outcome so far"),
+		mcp.WithString("monitor_id", mcp.Description("ID of the monitor"), mcp.Required()),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("monitor_pod_cancel", alertTool.handleCancelMonitor)))
 }
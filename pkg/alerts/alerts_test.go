@@ -2,10 +2,18 @@ package alerts
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
 )
 
 func TestNewAlertTool(t *testing.T) {
@@ -111,57 +119,321 @@ func TestPodEventStruct(t *testing.T) {
 	}
 }
 
-// Mock test for handleGetPodAlerts (without actual kubectl calls)
-func TestHandleGetPodAlertsBasic(t *testing.T) {
-	tool := NewAlertTool(nil)
+// podsJSON returns a kubectl pod list of the given pod objects
+func podsJSON(pods ...string) string {
+	return `{"items":[` + strings.Join(pods, ",") + `]}`
+}
 
-	request := mcp.CallToolRequest{}
-	request.Params.Arguments = map[string]interface{}{
-		"namespace":        "default",
-		"all_namespaces":   "false",
-		"include_analysis": "false",
+func TestHandleGetPodAlerts(t *testing.T) {
+	t.Setenv(ClusterName, "prod-eu")
+	pending := `{"metadata":{"name":"web-1","namespace":"prod"},"status":{"phase":"Pending"}}`
+	ready := `{"metadata":{"name":"web-2","namespace":"prod"},"status":{"phase":"Running","containerStatuses":[{"ready":true}]}}`
+	crashing := `{"metadata":{"name":"api-1","namespace":"prod","labels":{"app":"api"}},"status":{"phase":"Running","containerStatuses":[{"ready":false,"restartCount":7,"state":{"waiting":{"reason":"CrashLoopBackOff","message":"back-off 5m0s"}}}]}}`
+	oomKilled := `{"metadata":{"name":"api-2","namespace":"prod"},"status":{"phase":"Running","containerStatuses":[{"ready":false,"state":{"terminated":{"reason":"OOMKilled","exitCode":137}}}]}}`
+	notReady := `{"metadata":{"name":"db-0","namespace":"data"},"status":{"phase":"Running","conditions":[{"type":"Ready","status":"False","reason":"ReadinessGatesNotReady","message":"gate pending"}]}}`
+
+	tests := []podAlertsCase{
+		{
+			name:       "pending pod",
+			args:       map[string]interface{}{"namespace": "prod"},
+			podsArgs:   []string{"get", "pods", "-o", "json", "-n", "prod"},
+			pods:       podsJSON(pending, ready),
+			expectPods: []string{"web-1"},
+		},
+		{
+			name:       "waiting container",
+			args:       map[string]interface{}{"namespace": "prod"},
+			podsArgs:   []string{"get", "pods", "-o", "json", "-n", "prod"},
+			pods:       podsJSON(crashing),
+			expectPods: []string{"api-1"},
+			check: func(t *testing.T, alerts []PodAlert) {
+				assert.Equal(t, "CrashLoopBackOff", alerts[0].Reason)
+				assert.Equal(t, "back-off 5m0s", alerts[0].Message)
+				assert.Equal(t, int32(7), alerts[0].RestartCount)
+				assert.Equal(t, map[string]string{"app": "api"}, alerts[0].Labels)
+			},
+		},
+		{
+			name:       "terminated container",
+			args:       map[string]interface{}{"namespace": "prod"},
+			podsArgs:   []string{"get", "pods", "-o", "json", "-n", "prod"},
+			pods:       podsJSON(oomKilled),
+			expectPods: []string{"api-2"},
+			check: func(t *testing.T, alerts []PodAlert) {
+				assert.Equal(t, "OOMKilled", alerts[0].Reason)
+			},
+		},
+		{
+			name:       "all namespaces with failing readiness condition",
+			args:       map[string]interface{}{"all_namespaces": true},
+			podsArgs:   []string{"get", "pods", "-o", "json", "--all-namespaces"},
+			pods:       podsJSON(pending, notReady),
+			expectPods: []string{"web-1", "db-0"},
+			check: func(t *testing.T, alerts []PodAlert) {
+				assert.Equal(t, "ReadinessGatesNotReady", alerts[1].Reason)
+				assert.Equal(t, "gate pending", alerts[1].Message)
+			},
+		},
+		{
+			name:       "healthy pods",
+			args:       map[string]interface{}{"namespace": "prod"},
+			podsArgs:   []string{"get", "pods", "-o", "json", "-n", "prod"},
+			pods:       podsJSON(ready),
+			expectPods: []string{},
+		},
+		{
+			name:        "kubectl failure",
+			args:        map[string]interface{}{"namespace": "prod"},
+			podsArgs:    []string{"get", "pods", "-o", "json", "-n", "prod"},
+			podsErr:     errors.New("forbidden"),
+			expectError: "Failed to get pods",
+		},
+		{
+			name:        "invalid pod list",
+			args:        map[string]interface{}{"namespace": "prod"},
+			podsArgs:    []string{"get", "pods", "-o", "json", "-n", "prod"},
+			pods:        "not json",
+			expectError: "Failed to parse pod list",
+		},
+		{
+			name:        "invalid all_namespaces",
+			args:        map[string]interface{}{"all_namespaces": "sometimes"},
+			expectError: "all_namespaces parameter must be true or false",
+		},
+	}
+
+	// Collected alerts behave the same whichever store keeps them
+	stores := map[string]func(t *testing.T) AlertStore{
+		"memory": func(t *testing.T) AlertStore { return NewMemoryAlertStore() },
+		"file": func(t *testing.T) AlertStore {
+			store, err := NewFileAlertStore(filepath.Join(t.TempDir(), "alerts.json"))
+			require.NoError(t, err)
+			return store
+		},
 	}
 
-	// This will fail due to no kubectl, but we can test the parameter parsing
-	_, err := tool.handleGetPodAlerts(context.Background(), request)
-	// We expect an error since kubectl is not available in test environment
-	if err == nil {
-		t.Log("handleGetPodAlerts completed (this is expected to fail in test environment)")
+	for storeName, newStore := range stores {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				tt.run(t, newStore(t))
+			})
+		}
 	}
 }
 
-// Mock test for handleGetPodAlertDetails (without actual kubectl calls)
-func TestHandleGetPodAlertDetailsBasic(t *testing.T) {
-	tool := NewAlertTool(nil)
+// podAlertsCase is a case of TestHandleGetPodAlerts
+type podAlertsCase struct {
+	name        string
+	args        map[string]interface{}
+	podsArgs    []string
+	pods        string
+	podsErr     error
+	expectError string
+	expectPods  []string
+	check       func(t *testing.T, alerts []PodAlert)
+}
+
+// run collects the alerts of the case into store and checks what is
+// returned and stored
+func (tt podAlertsCase) run(t *testing.T, store AlertStore) {
+	mock := cmd.NewMockShellExecutor()
+	if tt.podsArgs != nil {
+		mock.AddCommandString("kubectl", tt.podsArgs, tt.pods, tt.podsErr)
+	}
+	mock.AddPartialMatcherString("kubectl", []string{"events"}, `{"items":[]}`, nil)
+	mock.AddPartialMatcherString("kubectl", []string{"logs"}, "", nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	tool := NewAlertTool(nil).WithStore(store)
 
 	request := mcp.CallToolRequest{}
-	request.Params.Arguments = map[string]interface{}{
-		"pod_name":         "test-pod",
-		"namespace":        "default",
-		"include_analysis": "false",
+	request.Params.Arguments = tt.args
+	result, err := tool.handleGetPodAlerts(ctx, request)
+	require.NoError(t, err)
+
+	if tt.expectError != "" {
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, tt.expectError)
+		docs, err := store.List(ctx, "")
+		require.NoError(t, err)
+		assert.Empty(t, docs)
+		return
+	}
+	require.False(t, result.IsError)
+
+	var alerts []PodAlert
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &alerts))
+	pods := []string{}
+	for _, alert := range alerts {
+		pods = append(pods, alert.PodName)
+		assert.Equal(t, AlertStateCollected, alert.State)
+	}
+	assert.Equal(t, tt.expectPods, pods)
+	if tt.check != nil {
+		tt.check(t, alerts)
 	}
 
-	// This will fail due to no kubectl, but we can test the parameter parsing
-	_, err := tool.handleGetPodAlertDetails(context.Background(), request)
-	// We expect an error since kubectl is not available in test environment
-	if err == nil {
-		t.Log("handleGetPodAlertDetails completed (this is expected to fail in test environment)")
+	// Every collected alert is stored with the current cluster
+	docs, err := store.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, docs, len(tt.expectPods))
+	for _, doc := range docs {
+		assert.Contains(t, tt.expectPods, doc.Alert.PodName)
+		assert.Equal(t, AlertStateCollected, doc.Alert.State)
+		require.NotNil(t, doc.Alert.Cluster)
+		assert.Equal(t, "prod-eu", doc.Alert.Cluster.Name)
 	}
 }
 
-// Mock test for handleGetClusterAlerts (without actual kubectl calls)
-func TestHandleGetClusterAlertsBasic(t *testing.T) {
-	tool := NewAlertTool(nil)
+func TestHandleGetPodAlertDetails(t *testing.T) {
+	describeArgs := []string{"describe", "pod", "web-1", "-n", "prod"}
+	logsArgs := []string{"logs", "web-1", "-n", "prod", "--tail=100"}
+	eventsArgs := []string{"get", "events", "-n", "prod", "--field-selector", "involvedObject.name=web-1", "-o", "wide"}
 
-	request := mcp.CallToolRequest{}
-	request.Params.Arguments = map[string]interface{}{
-		"include_analysis": "false",
+	tests := []struct {
+		name        string
+		args        map[string]interface{}
+		describeErr error
+		logs        string
+		logsErr     error
+		eventsErr   error
+		expectError string
+		contains    []string
+	}{
+		{
+			name:     "details",
+			args:     map[string]interface{}{"pod_name": "web-1", "namespace": "prod"},
+			logs:     "starting\nready",
+			contains: []string{"Pod Details:\nName: web-1", "Logs:\nstarting\nready", "Events:\nBackOff"},
+		},
+		{
+			name:     "exceptions in logs",
+			args:     map[string]interface{}{"pod_name": "web-1", "namespace": "prod"},
+			logs:     "panic: runtime error: index out of range [3] with length 2\n\ngoroutine 1 [running]:\nmain.main()\n\t/app/main.go:12 +0x1d",
+			contains: []string{"Exceptions:", "index out of range"},
+		},
+		{
+			name:      "missing logs and events",
+			args:      map[string]interface{}{"pod_name": "web-1", "namespace": "prod"},
+			logsErr:   errors.New("container is waiting to start"),
+			eventsErr: errors.New("forbidden"),
+			contains:  []string{"Unable to retrieve logs", "Unable to retrieve events"},
+		},
+		{
+			name:        "describe failure",
+			args:        map[string]interface{}{"pod_name": "web-1", "namespace": "prod"},
+			describeErr: errors.New("pods \"web-1\" not found"),
+			expectError: "Failed to describe pod",
+		},
+		{
+			name:        "missing pod name",
+			args:        map[string]interface{}{"namespace": "prod"},
+			expectError: "pod_name parameter is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := cmd.NewMockShellExecutor()
+			mock.AddCommandString("kubectl", describeArgs, "Name: web-1", tt.describeErr)
+			mock.AddCommandString("kubectl", logsArgs, tt.logs, tt.logsErr)
+			mock.AddCommandString("kubectl", eventsArgs, "BackOff", tt.eventsErr)
+			ctx := cmd.WithShellExecutor(context.Background(), mock)
+			store := NewMemoryAlertStore()
+			tool := NewAlertTool(nil).WithStore(store)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.args
+			result, err := tool.handleGetPodAlertDetails(ctx, request)
+			require.NoError(t, err)
+
+			text := result.Content[0].(mcp.TextContent).Text
+			if tt.expectError != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, text, tt.expectError)
+				return
+			}
+			require.False(t, result.IsError)
+			for _, expected := range tt.contains {
+				assert.Contains(t, text, expected)
+			}
+
+			// Without an LLM nothing is analyzed, so nothing is stored
+			docs, err := store.List(ctx, "")
+			require.NoError(t, err)
+			assert.Empty(t, docs)
+		})
+	}
+}
+
+func TestHandleGetClusterAlerts(t *testing.T) {
+	podsArgs := []string{"get", "pods", "--all-namespaces", "-o", "wide"}
+	header := "NAMESPACE   NAME    READY   STATUS             RESTARTS   AGE   IP          NODE\n"
+
+	tests := []struct {
+		name        string
+		pods        string
+		podsErr     error
+		expectError string
+		expectPods  []string
+		expectState []string
+	}{
+		{
+			name: "problematic pods",
+			pods: header +
+				"prod        web-1   0/1     CrashLoopBackOff   7          1h    10.0.0.1    node-1\n" +
+				"prod        web-2   1/1     Running            0          1h    10.0.0.2    node-1\n" +
+				"data        db-0    0/1     Pending            0          5m    <none>      <none>\n" +
+				"batch       job-1   1/1     Error              0          2h    10.0.0.3    node-2\n",
+			expectPods:  []string{"prod/web-1", "data/db-0", "batch/job-1"},
+			expectState: []string{"CrashLoopBackOff", "Pending", "Error"},
+		},
+		{
+			name:       "healthy cluster",
+			pods:       header + "prod        web-2   1/1     Running            0          1h    10.0.0.2    node-1\n",
+			expectPods: []string{},
+		},
+		{
+			name:       "short lines are skipped",
+			pods:       header + "prod web-1 0/1\n\n",
+			expectPods: []string{},
+		},
+		{
+			name:        "kubectl failure",
+			podsErr:     errors.New("connection refused"),
+			expectError: "Failed to get cluster pods",
+		},
 	}
 
-	// This will fail due to no kubectl, but we can test the parameter parsing
-	_, err := tool.handleGetClusterAlerts(context.Background(), request)
-	// We expect an error since kubectl is not available in test environment
-	if err == nil {
-		t.Log("handleGetClusterAlerts completed (this is expected to fail in test environment)")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := cmd.NewMockShellExecutor()
+			mock.AddCommandString("kubectl", podsArgs, tt.pods, tt.podsErr)
+			mock.AddPartialMatcherString("kubectl", []string{"describe", "pod"}, "Events: BackOff", nil)
+			ctx := cmd.WithShellExecutor(context.Background(), mock)
+			tool := NewAlertTool(nil)
+
+			result, err := tool.handleGetClusterAlerts(ctx, mcp.CallToolRequest{})
+			require.NoError(t, err)
+
+			text := result.Content[0].(mcp.TextContent).Text
+			if tt.expectError != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, text, tt.expectError)
+				return
+			}
+			require.False(t, result.IsError)
+
+			var alerts []PodAlert
+			require.NoError(t, json.Unmarshal([]byte(text), &alerts))
+			pods := []string{}
+			var states []string
+			for _, alert := range alerts {
+				pods = append(pods, alert.Namespace+"/"+alert.PodName)
+				states = append(states, alert.Status)
+				assert.Equal(t, "Events: BackOff", alert.Message)
+			}
+			assert.Equal(t, tt.expectPods, pods)
+			assert.Equal(t, tt.expectState, states)
+		})
 	}
 }
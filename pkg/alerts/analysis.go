@@ -0,0 +1,273 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/kagent-dev/tools/internal/llm"
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/schema"
+)
+
+// AlertAnalysisMaxRetries configures how many times the LLM is re-prompted
+// when its analysis does not match the expected schema
+const AlertAnalysisMaxRetries = "ALERT_ANALYSIS_MAX_RETRIES"
+
+// defaultAnalysisMaxRetries is used when no retry count is configured
+const defaultAnalysisMaxRetries = 2
+
+// Analysis types, each validated against its own schema
+const (
+	AnalysisTypePod      = "pod"
+	AnalysisTypePodBatch = "pod_batch"
+	AnalysisTypeCluster  = "cluster"
+	// AnalysisTypeOpsSummary is the narrative of an operations summary
+	AnalysisTypeOpsSummary = "ops_summary"
+	// AnalysisTypeErrorExplanation explains an error the knowledge base does not cover
+	AnalysisTypeErrorExplanation = "error_explanation"
+)
+
+// analysisSchemas holds the JSON Schema for each analysis type
+var analysisSchemas = map[string]*schema.Schema{
+	AnalysisTypePod: schema.MustParse(`{
+  "type": "object",
+  "required": ["summary", "root_cause", "severity", "remediation_steps"],
+  "properties": {
+    "summary": {"type": "string", "minLength": 1, "description": "One sentence summary of the problem"},
+    "root_cause": {"type": "string", "minLength": 1, "description": "Most likely root cause"},
+    "severity": {"type": "string", "enum": ["Critical", "High", "Medium", "Low"]},
+    "remediation_steps": {"type": "array", "minItems": 1, "items": {"type": "string"}, "description": "Ordered, actionable remediation steps"},
+    "prevention": {"type": "array", "items": {"type": "string"}, "description": "Recommendations to prevent recurrence"}
+  }
+}`),
+	AnalysisTypePodBatch: schema.MustParse(`{
+  "type": "object",
+  "required": ["analyses"],
+  "properties": {
+    "analyses": {
+      "type": "array",
+      "minItems": 1,
+      "description": "One analysis per pod",
+      "items": {
+        "type": "object",
+        "required": ["pod", "summary", "root_cause", "severity", "remediation_steps"],
+        "properties": {
+          "pod": {"type": "string", "minLength": 1, "description": "namespace/name of the pod, as in its section heading"},
+          "summary": {"type": "string", "minLength": 1, "description": "One sentence summary of the problem"},
+          "root_cause": {"type": "string", "minLength": 1, "description": "Most likely root cause"},
+          "severity": {"type": "string", "enum": ["Critical", "High", "Medium", "Low"]},
+          "remediation_steps": {"type": "array", "minItems": 1, "items": {"type": "string"}, "description": "Ordered, actionable remediation steps"},
+          "prevention": {"type": "array", "items": {"type": "string"}, "description": "Recommendations to prevent recurrence"}
+        }
+      }
+    }
+  }
+}`),
+	AnalysisTypeCluster: schema.MustParse(`{
+  "type": "object",
+  "required": ["summary", "patterns", "remediation_strategies"],
+  "properties": {
+    "summary": {"type": "string", "minLength": 1, "description": "Overall assessment of cluster health"},
+    "patterns": {"type": "array", "items": {"type": "string"}, "description": "Common patterns or root causes across alerts"},
+    "remediation_strategies": {"type": "array", "minItems": 1, "items": {"type": "string"}},
+    "infrastructure_improvements": {"type": "array", "items": {"type": "string"}},
+    "monitoring_recommendations": {"type": "array", "items": {"type": "string"}}
+  }
+}`),
+	AnalysisTypeOpsSummary: schema.MustParse(`{
+  "type": "object",
+  "required": ["summary", "highlights"],
+  "properties": {
+    "summary": {"type": "string", "minLength": 1, "description": "Two or three sentence overview of the period"},
+    "highlights": {"type": "array", "items": {"type": "string"}, "description": "Notable changes, such as new regressions or resolved incidents"},
+    "risks": {"type": "array", "items": {"type": "string"}, "description": "Workloads or capacity trends that need attention"},
+    "recommendations": {"type": "array", "items": {"type": "string"}}
+  }
+}`),
+	AnalysisTypeErrorExplanation: schema.MustParse(`{
+  "type": "object",
+  "required": ["cause", "confidence", "next_steps"],
+  "properties": {
+    "cause": {"type": "string", "minLength": 1, "description": "Most likely cause of the error"},
+    "confidence": {"type": "string", "enum": ["high", "medium", "low"], "description": "Confidence in the cause given the information available"},
+    "category": {"type": "string", "description": "Component reporting the error, such as kubectl, envoy, container or network"},
+    "next_steps": {"type": "array", "minItems": 1, "items": {"type": "string"}, "description": "Ordered steps to confirm and fix the cause"}
+  }
+}`),
+}
+
+// AnalysisValidation records whether the LLM output matched its schema
+type AnalysisValidation struct {
+	Valid    bool     `json:"valid"`
+	Attempts int      `json:"attempts"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// AnalysisResult is a schema-validated LLM analysis. Data holds the validated
+// document; Raw holds the last response when no valid document was produced.
+type AnalysisResult struct {
+	Type       string                 `json:"type"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	Raw        string                 `json:"raw,omitempty"`
+	Validation AnalysisValidation     `json:"validation"`
+	// Source is set when the analysis was not produced by the LLM, such as
+	// AnalysisSourceDeterministic for the scheduling analysis of Pending pods
+	Source string `json:"source,omitempty"`
+	// Language is the language the LLM was asked to write in, unless English
+	Language string `json:"language,omitempty"`
+	// Model is the model the analysis was routed to
+	Model string `json:"model,omitempty"`
+}
+
+// analysisTasks are the LLM routing tasks of the analysis types
+var analysisTasks = map[string]string{
+	AnalysisTypePod:              llm.TaskAnalysis,
+	AnalysisTypePodBatch:         llm.TaskAnalysis,
+	AnalysisTypeCluster:          llm.TaskAnalysis,
+	AnalysisTypeOpsSummary:       llm.TaskSummary,
+	AnalysisTypeErrorExplanation: llm.TaskExplanation,
+}
+
+// triageReasons are the severities of alert reasons before an analysis assigns one
+var triageReasons = map[string]string{
+	"OOMKilled":                  "High",
+	"CrashLoopBackOff":           "High",
+	"Error":                      "Medium",
+	"ImagePullBackOff":           "Medium",
+	"ErrImagePull":               "Medium",
+	"CreateContainerConfigError": "Medium",
+}
+
+// triageSeverity estimates the severity of an alert to route its analysis:
+// the severity of the matching alert rule, else of a previous analysis, else
+// of its reason
+func triageSeverity(alert PodAlert) string {
+	if alert.Severity != "" {
+		return alert.Severity
+	}
+	if severity := alertSeverity(alert); severity != "" {
+		return severity
+	}
+	return triageReasons[alert.Reason]
+}
+
+// maxTriageSeverity returns the highest triage severity of some alerts
+func maxTriageSeverity(alerts []PodAlert) string {
+	highest := ""
+	for _, alert := range alerts {
+		if severity := triageSeverity(alert); severityRanks[severity] > severityRanks[highest] {
+			highest = severity
+		}
+	}
+	return highest
+}
+
+// Text returns a readable summary of the analysis
+func (r *AnalysisResult) Text() string {
+	if !r.Validation.Valid {
+		return r.Raw
+	}
+
+	summary, _ := r.Data["summary"].(string)
+	if rootCause, ok := r.Data["root_cause"].(string); ok && rootCause != "" {
+		return fmt.Sprintf("%s\n\nRoot cause: %s", summary, rootCause)
+	}
+	return summary
+}
+
+// RemediationSteps returns the remediation steps of a valid pod analysis
+func (r *AnalysisResult) RemediationSteps() []string {
+	if !r.Validation.Valid {
+		return nil
+	}
+
+	items, _ := r.Data["remediation_steps"].([]interface{})
+	steps := make([]string, 0, len(items))
+	for _, item := range items {
+		if step, ok := item.(string); ok {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// analysisMaxRetries returns the configured number of re-prompts
+func analysisMaxRetries() int {
+	if value, err := strconv.Atoi(os.Getenv(AlertAnalysisMaxRetries)); err == nil && value >= 0 {
+		return value
+	}
+	return defaultAnalysisMaxRetries
+}
+
+// generateStructuredAnalysis asks the LLM for a JSON analysis of the given type
+// and validates it against the type's schema. Invalid responses are sent back
+// with the validation errors until the retry budget is exhausted, in which case
+// the last response is returned unvalidated. The prose is written in the
+// language of the context.
+func (a *AlertTool) generateStructuredAnalysis(ctx context.Context, analysisType, prompt string) (*AnalysisResult, error) {
+	analysisSchema, ok := analysisSchemas[analysisType]
+	if !ok {
+		return nil, fmt.Errorf("unknown analysis type: %s", analysisType)
+	}
+
+	prompt = fmt.Sprintf(`%s
+
+Respond only with a JSON object matching this JSON Schema, without Markdown or commentary:
+%s%s`, prompt, analysisSchema, llm.LanguageInstruction(ctx))
+
+	contents := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, prompt),
+	}
+
+	result := &AnalysisResult{Type: analysisType, Model: llm.RouteModel(ctx, analysisTasks[analysisType])}
+	if language := llm.Language(ctx); language != llm.English {
+		result.Language = language
+	}
+	maxAttempts := analysisMaxRetries() + 1
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		resp, err := a.llmModel.GenerateContent(ctx, contents, llms.WithModel(result.Model))
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Choices) < 1 {
+			return nil, fmt.Errorf("empty response from model")
+		}
+		content := resp.Choices[0].Content
+
+		result.Raw = content
+		result.Validation.Attempts = attempt
+
+		value, validationErrs := analysisSchema.ValidateJSON(schema.ExtractJSON(content))
+		if len(validationErrs) == 0 {
+			if data, ok := value.(map[string]interface{}); ok {
+				result.Data = data
+				result.Raw = ""
+				result.Validation.Valid = true
+				result.Validation.Errors = nil
+				return result, nil
+			}
+		}
+
+		result.Validation.Errors = make([]string, len(validationErrs))
+		for i, validationErr := range validationErrs {
+			result.Validation.Errors[i] = validationErr.Error()
+		}
+
+		logger.Get().Info("LLM analysis failed schema validation", "type", analysisType, "attempt", attempt, "errors", result.Validation.Errors)
+
+		contents = append(contents,
+			llms.TextParts(llms.ChatMessageTypeAI, content),
+			llms.TextParts(llms.ChatMessageTypeHuman, fmt.Sprintf(`Your response did not match the schema:
+- %s
+
+Respond again with only the corrected JSON object.`, strings.Join(result.Validation.Errors, "\n- "))),
+		)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,144 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// scriptedModel returns its responses in order and records the messages it received
+type scriptedModel struct {
+	responses []string
+	calls     [][]llms.MessageContent
+}
+
+func (m *scriptedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.calls = append(m.calls, messages)
+	if len(m.calls) > len(m.responses) {
+		return nil, fmt.Errorf("unexpected call %d", len(m.calls))
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: m.responses[len(m.calls)-1]}}}, nil
+}
+
+func (m *scriptedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+const validPodAnalysis = "```json\n" + `{
+  "summary": "Container is OOMKilled",
+  "root_cause": "Memory limit too low",
+  "severity": "High",
+  "remediation_steps": ["Raise the memory limit", "Restart the deployment"]
+}` + "\n```"
+
+func TestGenerateStructuredAnalysisValid(t *testing.T) {
+	model := &scriptedModel{responses: []string{validPodAnalysis}}
+	tool := NewAlertTool(model)
+
+	result, err := tool.generateStructuredAnalysis(context.Background(), AnalysisTypePod, "Analyze")
+	require.NoError(t, err)
+	assert.True(t, result.Validation.Valid)
+	assert.Equal(t, 1, result.Validation.Attempts)
+	assert.Empty(t, result.Raw)
+	assert.Equal(t, "Container is OOMKilled\n\nRoot cause: Memory limit too low", result.Text())
+	assert.Equal(t, []string{"Raise the memory limit", "Restart the deployment"}, result.RemediationSteps())
+}
+
+func TestGenerateStructuredAnalysisLanguage(t *testing.T) {
+	t.Setenv(llm.LLMLanguage, "")
+	model := &scriptedModel{responses: []string{validPodAnalysis, validPodAnalysis}}
+	tool := NewAlertTool(model)
+
+	result, err := tool.generateStructuredAnalysis(context.Background(), AnalysisTypePod, "Analyze")
+	require.NoError(t, err)
+	assert.Empty(t, result.Language)
+	assert.NotContains(t, model.calls[0][0].Parts[0].(llms.TextContent).Text, "Write all prose")
+
+	result, err = tool.generateStructuredAnalysis(llm.WithLanguage(context.Background(), "French"), AnalysisTypePod, "Analyze")
+	require.NoError(t, err)
+	assert.Equal(t, "French", result.Language)
+	assert.Contains(t, model.calls[1][0].Parts[0].(llms.TextContent).Text, "in French")
+}
+
+func TestTriageSeverity(t *testing.T) {
+	assert.Equal(t, "Low", triageSeverity(PodAlert{Reason: "OOMKilled", Severity: "Low"}), "rule severity first")
+	assert.Equal(t, "High", triageSeverity(PodAlert{Reason: "OOMKilled"}))
+	assert.Equal(t, "Critical", triageSeverity(PodAlert{Reason: "OOMKilled", AnalysisResult: &AnalysisResult{
+		Validation: AnalysisValidation{Valid: true},
+		Data:       map[string]interface{}{"severity": "Critical"},
+	}}))
+	assert.Empty(t, triageSeverity(PodAlert{Reason: "Unknown"}))
+	assert.Equal(t, "High", maxTriageSeverity([]PodAlert{{Reason: "Error"}, {Reason: "CrashLoopBackOff"}, {}}))
+}
+
+func TestGenerateStructuredAnalysisRoutesModel(t *testing.T) {
+	t.Setenv(llm.LLMModelStrong, "strong-model")
+	t.Setenv(llm.LLMRouting, "")
+	tool := NewAlertTool(&scriptedModel{responses: []string{validPodAnalysis}})
+
+	result, err := tool.generateAnalysis(context.Background(), PodAlert{PodName: "web", Namespace: "prod", Reason: "CrashLoopBackOff"})
+	require.NoError(t, err)
+	assert.Equal(t, "strong-model", result.Model)
+}
+
+func TestGenerateStructuredAnalysisReprompts(t *testing.T) {
+	model := &scriptedModel{responses: []string{
+		`{"summary": "Container is OOMKilled", "severity": "Urgent"}`,
+		validPodAnalysis,
+	}}
+	tool := NewAlertTool(model)
+
+	result, err := tool.generateStructuredAnalysis(context.Background(), AnalysisTypePod, "Analyze")
+	require.NoError(t, err)
+	assert.True(t, result.Validation.Valid)
+	assert.Equal(t, 2, result.Validation.Attempts)
+	assert.Empty(t, result.Validation.Errors)
+
+	require.Len(t, model.calls, 2)
+	retry := model.calls[1]
+	require.Len(t, retry, 3)
+	assert.Equal(t, llms.ChatMessageTypeAI, retry[1].Role)
+	feedback := retry[2].Parts[0].(llms.TextContent).Text
+	assert.Contains(t, feedback, `missing required property "root_cause"`)
+	assert.Contains(t, feedback, "$.severity: must be one of")
+}
+
+func TestGenerateStructuredAnalysisGivesUp(t *testing.T) {
+	t.Setenv(AlertAnalysisMaxRetries, "1")
+	model := &scriptedModel{responses: []string{"not json", "still not json"}}
+	tool := NewAlertTool(model)
+
+	result, err := tool.generateStructuredAnalysis(context.Background(), AnalysisTypeCluster, "Analyze")
+	require.NoError(t, err)
+	assert.False(t, result.Validation.Valid)
+	assert.Equal(t, 2, result.Validation.Attempts)
+	assert.NotEmpty(t, result.Validation.Errors)
+	assert.Equal(t, "still not json", result.Raw)
+	assert.Equal(t, "still not json", result.Text())
+	assert.Nil(t, result.RemediationSteps())
+	assert.Len(t, model.calls, 2)
+}
+
+func TestGenerateStructuredAnalysisUnknownType(t *testing.T) {
+	_, err := NewAlertTool(&scriptedModel{}).generateStructuredAnalysis(context.Background(), "unknown", "Analyze")
+	assert.Error(t, err)
+}
+
+func TestGenerateAnalysisPopulatesAlert(t *testing.T) {
+	model := &scriptedModel{responses: []string{validPodAnalysis}}
+	tool := NewAlertTool(model)
+
+	result, err := tool.generateAnalysis(context.Background(), PodAlert{PodName: "web-1", Namespace: "prod", Reason: "OOMKilled"})
+	require.NoError(t, err)
+	assert.True(t, result.Validation.Valid)
+	assert.Equal(t, AnalysisTypePod, result.Type)
+
+	prompt := model.calls[0][0].Parts[0].(llms.TextContent).Text
+	assert.Contains(t, prompt, "Pod: web-1")
+	assert.Contains(t, prompt, `"remediation_steps"`)
+}
@@ -0,0 +1,142 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kagent-dev/tools/internal/llm"
+	"github.com/kagent-dev/tools/internal/logger"
+)
+
+// AlertAnalysisBatchSize configures how many pod alerts are analyzed in one LLM prompt
+const AlertAnalysisBatchSize = "ALERT_ANALYSIS_BATCH_SIZE"
+
+// Analysis batch defaults and limits
+const (
+	defaultAnalysisBatchSize = 5
+	maxAnalysisBatchSize     = 20
+)
+
+// analysisBatchSize returns the configured number of pods per analysis prompt
+func analysisBatchSize() int {
+	if value, err := strconv.Atoi(os.Getenv(AlertAnalysisBatchSize)); err == nil && value > 0 {
+		return min(value, maxAnalysisBatchSize)
+	}
+	return defaultAnalysisBatchSize
+}
+
+// formatPodAlert formats the details of a pod alert for an analysis prompt
+func (a *AlertTool) formatPodAlert(ctx context.Context, alert PodAlert) string {
+	return fmt.Sprintf(`Pod: %s
+Namespace: %s
+Status: %s
+Reason: %s
+Message: %s
+Restart Count: %d
+
+Events:
+%s
+
+Exceptions:
+%s
+
+Logs:
+%s
+%s`,
+		alert.PodName, alert.Namespace, alert.Status, alert.Reason, alert.Message, alert.RestartCount,
+		formatEvents(alert.Events), formatExceptions(alert.Exceptions), strings.Join(alert.Logs, "\n"),
+		a.runbookContext(ctx, strings.Join([]string{alert.Status, alert.Reason, alert.Message}, " ")))
+}
+
+// analyzePodAlerts analyzes each alert on its own, sending up to batchSize
+// alerts per prompt. The returned slice is aligned with alerts; a nil entry
+// means no analysis could be produced for that pod.
+func (a *AlertTool) analyzePodAlerts(ctx context.Context, alerts []PodAlert, batchSize int) []*AnalysisResult {
+	results := make([]*AnalysisResult, len(alerts))
+	batchSize = max(batchSize, 1)
+
+	for start := 0; start < len(alerts); start += batchSize {
+		end := min(start+batchSize, len(alerts))
+		batch := alerts[start:end]
+
+		if len(batch) > 1 {
+			analyses, err := a.generateBatchAnalysis(ctx, batch)
+			if err != nil {
+				logger.Get().Error("Batch alert analysis failed", "pods", len(batch), "error", err)
+			}
+			copy(results[start:end], analyses)
+		}
+
+		// Pods the batch response did not cover are analyzed individually
+		for i := start; i < end; i++ {
+			if results[i] != nil {
+				continue
+			}
+			analysis, err := a.generateAnalysis(ctx, alerts[i])
+			if err != nil {
+				logger.Get().Error("Alert analysis failed", "pod", alerts[i].PodName, "namespace", alerts[i].Namespace, "error", err)
+				continue
+			}
+			results[i] = analysis
+		}
+	}
+	return results
+}
+
+// generateBatchAnalysis asks the LLM for a separate analysis of each alert in
+// one prompt with a section per pod. The returned slice is aligned with
+// alerts and holds nil for pods missing from a valid response.
+func (a *AlertTool) generateBatchAnalysis(ctx context.Context, alerts []PodAlert) ([]*AnalysisResult, error) {
+	sections := make([]string, len(alerts))
+	for i, alert := range alerts {
+		sections[i] = fmt.Sprintf("## Pod %s\n\n%s", alertKey(alert.Namespace, alert.PodName), a.formatPodAlert(ctx, alert))
+	}
+
+	prompt := fmt.Sprintf(`Analyze each of these %d Kubernetes pod alerts separately and provide insights:
+
+%s
+
+For each pod, identified by the namespace/name of its section heading, please provide:
+1. Root cause analysis
+2. Potential solutions
+3. Prevention recommendations
+
+Analyze every pod on its own evidence; do not merge pods into one analysis.`, len(alerts), strings.Join(sections, "\n\n"))
+
+	batch, err := a.generateStructuredAnalysis(llm.WithSeverity(ctx, maxTriageSeverity(alerts)), AnalysisTypePodBatch, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*AnalysisResult, len(alerts))
+	if !batch.Validation.Valid {
+		return results, nil
+	}
+
+	index := make(map[string]int, len(alerts))
+	for i, alert := range alerts {
+		index[alertKey(alert.Namespace, alert.PodName)] = i
+	}
+	items, _ := batch.Data["analyses"].([]interface{})
+	for _, item := range items {
+		data, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pod, _ := data["pod"].(string)
+		i, ok := index[pod]
+		if !ok || results[i] != nil {
+			continue
+		}
+		delete(data, "pod")
+		results[i] = &AnalysisResult{
+			Type:       AnalysisTypePod,
+			Data:       data,
+			Validation: AnalysisValidation{Valid: true, Attempts: batch.Validation.Attempts},
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,77 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+const batchPodAnalysis = `{"analyses": [
+  {"pod": "prod/db-0", "summary": "Volume is full", "root_cause": "Disk usage", "severity": "Critical", "remediation_steps": ["Expand the PVC"]},
+  {"pod": "prod/web-1", "summary": "Container is OOMKilled", "root_cause": "Memory limit too low", "severity": "High", "remediation_steps": ["Raise the memory limit"]}
+]}`
+
+func TestAnalyzePodAlertsBatches(t *testing.T) {
+	model := &scriptedModel{responses: []string{batchPodAnalysis, validPodAnalysis}}
+	tool := NewAlertTool(model)
+	alerts := []PodAlert{
+		{PodName: "web-1", Namespace: "prod", Reason: "OOMKilled"},
+		{PodName: "db-0", Namespace: "prod", Reason: "CrashLoopBackOff"},
+		{PodName: "api-2", Namespace: "prod", Reason: "OOMKilled"},
+	}
+
+	results := tool.analyzePodAlerts(context.Background(), alerts, 2)
+	require.Len(t, results, 3)
+	require.Len(t, model.calls, 2)
+
+	// Each pod keeps its own analysis regardless of the order of the response
+	assert.Equal(t, "Container is OOMKilled\n\nRoot cause: Memory limit too low", results[0].Text())
+	assert.Equal(t, []string{"Expand the PVC"}, results[1].RemediationSteps())
+	assert.Equal(t, AnalysisTypePod, results[1].Type)
+	assert.NotContains(t, results[1].Data, "pod")
+	assert.Equal(t, AnalysisTypePod, results[2].Type)
+
+	prompt := model.calls[0][0].Parts[0].(llms.TextContent).Text
+	assert.Contains(t, prompt, "## Pod prod/web-1")
+	assert.Contains(t, prompt, "## Pod prod/db-0")
+	assert.NotContains(t, prompt, "api-2")
+}
+
+func TestAnalyzePodAlertsFallsBackForMissingPods(t *testing.T) {
+	model := &scriptedModel{responses: []string{
+		`{"analyses": [{"pod": "prod/db-0", "summary": "Volume is full", "root_cause": "Disk usage", "severity": "Critical", "remediation_steps": ["Expand the PVC"]}]}`,
+		validPodAnalysis,
+	}}
+	tool := NewAlertTool(model)
+	alerts := []PodAlert{
+		{PodName: "web-1", Namespace: "prod", Reason: "OOMKilled"},
+		{PodName: "db-0", Namespace: "prod", Reason: "CrashLoopBackOff"},
+	}
+
+	results := tool.analyzePodAlerts(context.Background(), alerts, 5)
+	require.Len(t, model.calls, 2)
+	assert.Equal(t, "Container is OOMKilled\n\nRoot cause: Memory limit too low", results[0].Text())
+	assert.Equal(t, "Volume is full\n\nRoot cause: Disk usage", results[1].Text())
+
+	retry := model.calls[1][0].Parts[0].(llms.TextContent).Text
+	assert.Contains(t, retry, "Pod: web-1")
+	assert.NotContains(t, retry, "db-0")
+}
+
+func TestAnalyzePodAlertsModelError(t *testing.T) {
+	tool := NewAlertTool(&scriptedModel{})
+
+	results := tool.analyzePodAlerts(context.Background(), []PodAlert{{PodName: "web-1", Namespace: "prod"}, {PodName: "db-0", Namespace: "prod"}}, 2)
+	assert.Equal(t, []*AnalysisResult{nil, nil}, results)
+}
+
+func TestAnalysisBatchSize(t *testing.T) {
+	assert.Equal(t, defaultAnalysisBatchSize, analysisBatchSize())
+	t.Setenv(AlertAnalysisBatchSize, "100")
+	assert.Equal(t, maxAnalysisBatchSize, analysisBatchSize())
+	t.Setenv(AlertAnalysisBatchSize, "0")
+	assert.Equal(t, defaultAnalysisBatchSize, analysisBatchSize())
+}
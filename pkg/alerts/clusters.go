@@ -0,0 +1,295 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// ClusterName names the cluster the server manages. When unset, the current
+// context of the kubeconfig is used.
+const ClusterName = "CLUSTER_NAME"
+
+// Criticality of a cluster, telling consumers how urgently its incidents need attention
+const (
+	ClusterCriticalityCritical = "critical"
+	ClusterCriticalityHigh     = "high"
+	ClusterCriticalityMedium   = "medium"
+	ClusterCriticalityLow      = "low"
+)
+
+var clusterCriticalities = []string{ClusterCriticalityCritical, ClusterCriticalityHigh, ClusterCriticalityMedium, ClusterCriticalityLow}
+
+// ClusterInfo identifies the cluster an alert, report or notification comes
+// from, so that consumers know its environment and who owns it
+type ClusterInfo struct {
+	Name string `json:"name"`
+	// Environment is the environment the cluster serves, such as prod or staging
+	Environment string `json:"environment,omitempty"`
+	Criticality string `json:"criticality,omitempty"`
+	// Team is the team owning the cluster
+	Team string `json:"team,omitempty"`
+	// Channels are where the cluster's incidents are announced, such as
+	// slack:#payments-oncall or pagerduty:PXXXXXX
+	Channels []string          `json:"channels,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// Label describes the cluster in one line, such as "prod-eu (prod, critical)"
+func (c ClusterInfo) Label() string {
+	var qualifiers []string
+	for _, qualifier := range []string{c.Environment, c.Criticality} {
+		if qualifier != "" {
+			qualifiers = append(qualifiers, qualifier)
+		}
+	}
+	if len(qualifiers) == 0 {
+		return c.Name
+	}
+	return fmt.Sprintf("%s (%s)", c.Name, strings.Join(qualifiers, ", "))
+}
+
+// ClusterMetadata is the stored metadata of a cluster
+type ClusterMetadata struct {
+	ClusterInfo
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// kubeconfigContext is the part of a kubeconfig naming its current context
+type kubeconfigContext struct {
+	CurrentContext string `yaml:"current-context"`
+}
+
+// kubeconfigPaths returns the kubeconfig files kubectl would read, in order
+func kubeconfigPaths(kubeconfig string) []string {
+	if kubeconfig != "" {
+		return []string{commands.ExpandPath(kubeconfig)}
+	}
+	if paths := filepath.SplitList(os.Getenv("KUBECONFIG")); len(paths) > 0 {
+		return paths
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return []string{filepath.Join(home, ".kube", "config")}
+	}
+	return nil
+}
+
+// currentClusterName returns CLUSTER_NAME, else the current context of the
+// kubeconfig, or an empty string when neither is set. The kubeconfig is read
+// directly so that every stored alert does not cost a kubectl call.
+func currentClusterName(kubeconfig string) string {
+	if name := strings.TrimSpace(os.Getenv(ClusterName)); name != "" {
+		return name
+	}
+	for _, path := range kubeconfigPaths(kubeconfig) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var config kubeconfigContext
+		if err := yaml.Unmarshal(data, &config); err == nil && config.CurrentContext != "" {
+			return config.CurrentContext
+		}
+	}
+	return ""
+}
+
+// currentCluster returns the cluster the server manages, with its stored
+// metadata. When its name is unknown, the only registered cluster is used.
+func (a *AlertTool) currentCluster(ctx context.Context) *ClusterInfo {
+	name := currentClusterName(a.kubeconfig)
+	if name == "" {
+		clusters, err := a.store.ListClusters(ctx)
+		if err != nil || len(clusters) != 1 {
+			return nil
+		}
+		return &clusters[0].ClusterInfo
+	}
+
+	cluster, err := a.store.GetCluster(ctx, name)
+	if err != nil {
+		logger.Get().Error("Failed to load cluster metadata", "cluster", name, "error", err)
+	}
+	if cluster == nil {
+		return &ClusterInfo{Name: name}
+	}
+	return &cluster.ClusterInfo
+}
+
+// clusterInfo returns the named cluster with its stored metadata
+func (a *AlertTool) clusterInfo(ctx context.Context, name string) *ClusterInfo {
+	cluster, err := a.store.GetCluster(ctx, name)
+	if err != nil || cluster == nil {
+		return &ClusterInfo{Name: name}
+	}
+	return &cluster.ClusterInfo
+}
+
+// alertCluster returns the name of the cluster an alert comes from, or an
+// empty string for alerts stored without one
+func alertCluster(alert PodAlert) string {
+	if alert.Cluster == nil {
+		return ""
+	}
+	return alert.Cluster.Name
+}
+
+// filterCluster returns the documents of the alerts from a cluster, or every
+// document when cluster is empty
+func filterCluster(docs []AlertDocument, cluster string) []AlertDocument {
+	if cluster == "" {
+		return docs
+	}
+	filtered := make([]AlertDocument, 0, len(docs))
+	for _, doc := range docs {
+		if alertCluster(doc.Alert) == cluster {
+			filtered = append(filtered, doc)
+		}
+	}
+	return filtered
+}
+
+// handleSetClusterMetadata registers or updates the metadata of a cluster
+func (a *AlertTool) handleSetClusterMetadata(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("name", currentClusterName(a.kubeconfig))
+	environment := p.String("environment", "", params.Check(func(value string) error {
+		return security.ValidateK8sLabel("environment", value)
+	}))
+	criticality := p.String("criticality", "", params.OneOf(clusterCriticalities...))
+	team := p.String("team", "")
+	channelsParam := p.String("channels", "")
+	labelsJSON := p.String("labels", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if name == "" {
+		return mcp.NewToolResultError(fmt.Sprintf("name is required when neither %s nor a kubeconfig current context is set", ClusterName)), nil
+	}
+
+	cluster, err := a.store.GetCluster(ctx, name)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to load cluster metadata: %v", err)), nil
+	}
+	info := ClusterInfo{Name: name}
+	if cluster != nil {
+		info = cluster.ClusterInfo
+	}
+
+	// Only the given fields change, so metadata can be filled in piece by piece
+	if environment != "" {
+		info.Environment = environment
+	}
+	if criticality != "" {
+		info.Criticality = criticality
+	}
+	if team != "" {
+		info.Team = team
+	}
+	if channelsParam != "" {
+		info.Channels = nil
+		for _, channel := range strings.Split(channelsParam, ",") {
+			if channel = strings.TrimSpace(channel); channel != "" {
+				info.Channels = append(info.Channels, channel)
+			}
+		}
+	}
+	if labelsJSON != "" {
+		var labels map[string]string
+		if err := json.Unmarshal([]byte(labelsJSON), &labels); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("labels must be a JSON object of strings: %v", err)), nil
+		}
+		if info.Labels == nil {
+			info.Labels = map[string]string{}
+		}
+		for key, value := range labels {
+			// Empty values remove a label
+			if value == "" {
+				delete(info.Labels, key)
+			} else {
+				info.Labels[key] = value
+			}
+		}
+	}
+
+	stored, err := a.store.SaveCluster(ctx, info)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to store cluster metadata: %v", err)), nil
+	}
+	return marshalClusters(stored)
+}
+
+// ClusterList is the response of cluster_metadata_get
+type ClusterList struct {
+	// Current is the name of the cluster the server manages, if known
+	Current  string            `json:"current,omitempty"`
+	Clusters []ClusterMetadata `json:"clusters"`
+}
+
+// handleGetClusterMetadata returns the metadata of one or every registered cluster
+func (a *AlertTool) handleGetClusterMetadata(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("name", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	list := ClusterList{Current: currentClusterName(a.kubeconfig), Clusters: []ClusterMetadata{}}
+	if name == "" {
+		clusters, err := a.store.ListClusters(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list clusters: %v", err)), nil
+		}
+		list.Clusters = clusters
+		return marshalClusters(list)
+	}
+
+	cluster, err := a.store.GetCluster(ctx, name)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to load cluster metadata: %v", err)), nil
+	}
+	if cluster == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no metadata is registered for cluster %s", name)), nil
+	}
+	list.Clusters = append(list.Clusters, *cluster)
+	return marshalClusters(list)
+}
+
+// handleDeleteClusterMetadata removes the metadata of a cluster
+func (a *AlertTool) handleDeleteClusterMetadata(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("name", "", params.Required())
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	deleted, err := a.store.DeleteCluster(ctx, name)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to delete cluster metadata: %v", err)), nil
+	}
+	if !deleted {
+		return mcp.NewToolResultError(fmt.Sprintf("no metadata is registered for cluster %s", name)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Deleted the metadata of cluster %s", name)), nil
+}
+
+func marshalClusters(value interface{}) (*mcp.CallToolResult, error) {
+	clustersJSON, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal cluster metadata: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(clustersJSON)), nil
+}
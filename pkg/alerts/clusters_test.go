@@ -0,0 +1,127 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useKubeconfig points KUBECONFIG at a kubeconfig whose current context is
+// the given one, or at a missing file when it is empty
+func useKubeconfig(t *testing.T, currentContext string) {
+	path := filepath.Join(t.TempDir(), "config")
+	if currentContext != "" {
+		require.NoError(t, os.WriteFile(path, []byte("apiVersion: v1\nkind: Config\ncurrent-context: "+currentContext+"\n"), 0o600))
+	}
+	t.Setenv(ClusterName, "")
+	t.Setenv("KUBECONFIG", path)
+}
+
+func callClusterTool(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) (string, bool) {
+	result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+	require.NoError(t, err)
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestCurrentClusterName(t *testing.T) {
+	useKubeconfig(t, "kind-dev")
+	assert.Equal(t, "kind-dev", currentClusterName(""))
+
+	t.Setenv(ClusterName, "prod-eu")
+	assert.Equal(t, "prod-eu", currentClusterName(""))
+
+	useKubeconfig(t, "")
+	assert.Empty(t, currentClusterName(""))
+}
+
+func TestClusterMetadataTools(t *testing.T) {
+	useKubeconfig(t, "prod-eu")
+	tool := NewAlertTool(nil)
+
+	text, isError := callClusterTool(t, tool.handleSetClusterMetadata, map[string]interface{}{
+		"environment": "prod",
+		"criticality": "critical",
+		"channels":    "slack:#payments-oncall, pagerduty:P123",
+		"labels":      `{"region": "eu-west-1"}`,
+	})
+	require.False(t, isError, text)
+
+	// Only the given fields change
+	text, isError = callClusterTool(t, tool.handleSetClusterMetadata, map[string]interface{}{"team": "payments"})
+	require.False(t, isError, text)
+	var stored ClusterMetadata
+	require.NoError(t, json.Unmarshal([]byte(text), &stored))
+	assert.Equal(t, ClusterInfo{
+		Name:        "prod-eu",
+		Environment: "prod",
+		Criticality: ClusterCriticalityCritical,
+		Team:        "payments",
+		Channels:    []string{"slack:#payments-oncall", "pagerduty:P123"},
+		Labels:      map[string]string{"region": "eu-west-1"},
+	}, stored.ClusterInfo)
+
+	_, isError = callClusterTool(t, tool.handleSetClusterMetadata, map[string]interface{}{"criticality": "urgent"})
+	assert.True(t, isError)
+	_, isError = callClusterTool(t, tool.handleSetClusterMetadata, map[string]interface{}{"labels": `["region"]`})
+	assert.True(t, isError)
+
+	text, isError = callClusterTool(t, tool.handleGetClusterMetadata, map[string]interface{}{})
+	require.False(t, isError, text)
+	var list ClusterList
+	require.NoError(t, json.Unmarshal([]byte(text), &list))
+	assert.Equal(t, "prod-eu", list.Current)
+	require.Len(t, list.Clusters, 1)
+
+	_, isError = callClusterTool(t, tool.handleGetClusterMetadata, map[string]interface{}{"name": "staging"})
+	assert.True(t, isError)
+
+	_, isError = callClusterTool(t, tool.handleDeleteClusterMetadata, map[string]interface{}{"name": "prod-eu"})
+	assert.False(t, isError)
+	_, isError = callClusterTool(t, tool.handleDeleteClusterMetadata, map[string]interface{}{"name": "prod-eu"})
+	assert.True(t, isError)
+}
+
+func TestClusterAttachedToAlerts(t *testing.T) {
+	useKubeconfig(t, "")
+	ctx := context.Background()
+	tool := NewAlertTool(nil)
+
+	// Without a known cluster name, nothing is attached
+	alert := &PodAlert{PodName: "web-1", Namespace: "prod", Status: "CrashLoopBackOff"}
+	tool.transition(ctx, alert, AlertStateCollected)
+	assert.Nil(t, alert.Cluster)
+
+	// The only registered cluster is the one the server manages
+	_, err := tool.store.SaveCluster(ctx, ClusterInfo{Name: "prod-eu", Environment: "prod", Criticality: ClusterCriticalityCritical, Team: "payments"})
+	require.NoError(t, err)
+	tool.transition(ctx, alert, AlertStateAnalyzed)
+	require.NotNil(t, alert.Cluster)
+	assert.Equal(t, "prod", alert.Cluster.Environment)
+
+	doc, err := tool.store.Get(ctx, "prod", "web-1")
+	require.NoError(t, err)
+	require.NotNil(t, doc.Alert.Cluster)
+	assert.Equal(t, "payments", doc.Alert.Cluster.Team)
+
+	data := buildReportData(doc, "", 0, doc.UpdatedAt)
+	markdown, err := renderIncidentReport(data, ReportFormatMarkdown)
+	require.NoError(t, err)
+	assert.Contains(t, markdown, "| Cluster | prod-eu (prod, critical) |")
+	assert.Contains(t, markdown, "| Team | payments |")
+
+	text, err := renderIncidentReport(data, ReportFormatText)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Cluster:      prod-eu (prod, critical)")
+
+	notifier, err := NewWebhookNotifier(WebhookConfig{URLs: []string{"http://example.com"}})
+	require.NoError(t, err)
+	payload, err := notifier.render(WebhookEvent{Alert: doc.Alert, From: AlertStateCollected, To: AlertStateAnalyzed})
+	require.NoError(t, err)
+	assert.Contains(t, string(payload), `"environment":"prod"`)
+}
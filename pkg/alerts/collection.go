@@ -0,0 +1,341 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kagent-dev/tools/internal/blobs"
+	"github.com/kagent-dev/tools/internal/logger"
+)
+
+// Environment variables configuring per-pod data collection
+const (
+	AlertCollectionParallelism = "ALERT_COLLECTION_PARALLELISM"
+	AlertCollectionPodTimeout  = "ALERT_COLLECTION_POD_TIMEOUT"
+	// AlertCollectionMaxLogLines is the largest max_log_lines a caller may request
+	AlertCollectionMaxLogLines = "ALERT_COLLECTION_MAX_LOG_LINES"
+	// AlertCollectionMaxLogBytes is the largest max_log_bytes a caller may request
+	AlertCollectionMaxLogBytes = "ALERT_COLLECTION_MAX_LOG_BYTES"
+	// AlertCollectionMaxAlertBytes caps the encoded size of a collected alert
+	AlertCollectionMaxAlertBytes = "ALERT_COLLECTION_MAX_ALERT_BYTES"
+	// AlertLogDir configures the directory the full logs of truncated alerts are stored in
+	AlertLogDir = "ALERT_LOG_DIR"
+)
+
+// alertLogBucket stores the full logs of alerts whose logs were truncated
+var alertLogBucket = blobs.Register(blobs.Bucket{Name: "alert-logs", DirEnv: AlertLogDir, DefaultDir: "kagent-tools/alert-logs"})
+
+// maxAlertLogBlobBytes caps the stored full logs of an alert, keeping their end
+const maxAlertLogBlobBytes = 64 << 20
+
+// Collection defaults and limits
+const (
+	defaultCollectionParallelism = 8
+	maxCollectionParallelism     = 64
+	defaultCollectionPodTimeout  = 30 * time.Second
+	defaultCollectionLogLines    = 50
+	defaultCollectionLogBytes    = 64 << 10
+	defaultMaxCollectionLogLines = 1000
+	defaultMaxCollectionLogBytes = 1 << 20
+	defaultMaxAlertBytes         = 2 << 20
+)
+
+// Per-pod collection outcomes
+const (
+	CollectionStatusCollected = "collected"
+	CollectionStatusPartial   = "partial"
+	CollectionStatusFailed    = "failed"
+)
+
+// CollectionStatus reports how events and logs were collected for one pod
+type CollectionStatus struct {
+	Status     string                `json:"status"`
+	Errors     []string              `json:"errors,omitempty"`
+	DurationMs int64                 `json:"duration_ms"`
+	Truncated  *CollectionTruncation `json:"truncated,omitempty"`
+}
+
+// CollectionTruncation records the data left out of an alert to respect the collection limits
+type CollectionTruncation struct {
+	// LogLines is set when the pod has older log lines than the line limit
+	LogLines bool `json:"log_lines,omitempty"`
+	// LogBytes is the number of bytes of older logs dropped to respect the byte limit
+	LogBytes int `json:"log_bytes,omitempty"`
+	// DroppedEvents and DroppedLogLines were removed, oldest first, to respect the alert size limit
+	DroppedEvents   int `json:"dropped_events,omitempty"`
+	DroppedLogLines int `json:"dropped_log_lines,omitempty"`
+	// MessageBytes is the number of bytes cut from the end of the message
+	MessageBytes int `json:"message_bytes,omitempty"`
+}
+
+// collectionLimits bound the data collected for one pod
+type collectionLimits struct {
+	LogLines   int
+	LogBytes   int
+	AlertBytes int
+}
+
+// collectionParallelism returns the configured number of collection workers
+func collectionParallelism() int {
+	if value, err := strconv.Atoi(os.Getenv(AlertCollectionParallelism)); err == nil && value > 0 {
+		return min(value, maxCollectionParallelism)
+	}
+	return defaultCollectionParallelism
+}
+
+// collectionPodTimeout returns the configured per-pod collection timeout
+func collectionPodTimeout() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(AlertCollectionPodTimeout)); err == nil && value > 0 {
+		return value
+	}
+	return defaultCollectionPodTimeout
+}
+
+// envLimit returns the positive integer of an environment variable, or def
+func envLimit(name string, def int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return def
+}
+
+// maxCollectionLogLines returns the server maximum of collected log lines per pod
+func maxCollectionLogLines() int {
+	return envLimit(AlertCollectionMaxLogLines, defaultMaxCollectionLogLines)
+}
+
+// maxCollectionLogBytes returns the server maximum of collected log bytes per pod
+func maxCollectionLogBytes() int {
+	return envLimit(AlertCollectionMaxLogBytes, defaultMaxCollectionLogBytes)
+}
+
+// defaultCollectionLimits returns the limits used when callers set none
+func defaultCollectionLimits() collectionLimits {
+	return collectionLimits{
+		LogLines:   min(defaultCollectionLogLines, maxCollectionLogLines()),
+		LogBytes:   min(defaultCollectionLogBytes, maxCollectionLogBytes()),
+		AlertBytes: envLimit(AlertCollectionMaxAlertBytes, defaultMaxAlertBytes),
+	}
+}
+
+// collectPodData fetches events and logs for every pod alert using a bounded
+// pool of workers. Each pod gets its own timeout; failures are recorded in the
+// alert's collection status and never discard the data collected for other pods.
+// Alerts of other objects, created by alert rules, keep the events that matched.
+func (a *AlertTool) collectPodData(ctx context.Context, alerts []PodAlert, parallelism int, podTimeout time.Duration, limits collectionLimits) {
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(parallelism, len(alerts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				a.collectSinglePod(ctx, &alerts[i], podTimeout, limits)
+			}
+		}()
+	}
+
+	for i := range alerts {
+		if alerts[i].Kind == "" || alerts[i].Kind == "Pod" {
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// collectSinglePod fetches the events and logs of one alerting pod within the limits
+func (a *AlertTool) collectSinglePod(ctx context.Context, alert *PodAlert, timeout time.Duration, limits collectionLimits) {
+	start := time.Now()
+	podCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var errs []string
+
+	events, err := a.getPodEvents(podCtx, alert.PodName, alert.Namespace)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("events: %v", err))
+	} else {
+		alert.Events = events
+	}
+
+	// One line more than the limit tells whether older lines were left out
+	var truncation CollectionTruncation
+	logsResult, err := a.runKubectlCommandString(podCtx, "logs", alert.PodName, "-n", alert.Namespace, fmt.Sprintf("--tail=%d", limits.LogLines+1))
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("logs: %v", err))
+	} else {
+		alert.Exceptions = parseLogExceptions(strings.Split(logsResult, "\n"))
+		alert.Logs, truncation.LogLines, truncation.LogBytes = truncateLogs(logsResult, limits.LogLines, limits.LogBytes)
+	}
+	truncation.DroppedEvents, truncation.DroppedLogLines, truncation.MessageBytes = limitAlertSize(alert, limits.AlertBytes)
+	// Logs cut from the alert are kept whole in the blob store
+	if truncation.LogBytes > 0 || truncation.DroppedLogLines > 0 {
+		if err := a.storeAlertLogs(podCtx, alert, logsResult); err != nil {
+			logger.Get().Error("Failed to store alert logs", "pod", alert.PodName, "namespace", alert.Namespace, "error", err)
+		}
+	}
+
+	status := CollectionStatusCollected
+	switch {
+	case len(errs) == 2:
+		status = CollectionStatusFailed
+	case len(errs) == 1:
+		status = CollectionStatusPartial
+	}
+	if podCtx.Err() == context.DeadlineExceeded {
+		errs = append(errs, fmt.Sprintf("timed out after %s", timeout))
+	}
+
+	alert.Collection = &CollectionStatus{
+		Status:     status,
+		Errors:     errs,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if truncation != (CollectionTruncation{}) {
+		alert.Collection.Truncated = &truncation
+	}
+}
+
+// AlertLogs describes the full logs of an alert stored in the blob store
+type AlertLogs struct {
+	ID        string    `json:"id"`
+	URI       string    `json:"uri"`
+	Namespace string    `json:"namespace"`
+	PodName   string    `json:"pod_name"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// storeAlertLogs stores the collected logs of an alert as a blob referenced by the alert
+func (a *AlertTool) storeAlertLogs(ctx context.Context, alert *PodAlert, logs string) error {
+	if len(logs) > maxAlertLogBlobBytes {
+		logs = logs[len(logs)-maxAlertLogBlobBytes:]
+	}
+	metadata := AlertLogs{Namespace: alert.Namespace, PodName: alert.PodName, CreatedAt: time.Now().UTC()}
+	info, err := blobs.Default().Write(ctx, alertLogBucket, maxAlertLogBlobBytes, func(w io.Writer) error {
+		_, err := io.WriteString(w, logs)
+		return err
+	}, func(info blobs.Info) interface{} {
+		metadata.ID, metadata.URI, metadata.Size, metadata.SHA256 = info.ID, info.URI, info.Size, info.SHA256
+		return metadata
+	})
+	if err != nil {
+		return err
+	}
+	alert.LogsURI = info.URI
+	return nil
+}
+
+// truncateLogs splits log output into lines, keeping the newest maxLines lines
+// and at most maxBytes bytes of them. It reports whether older lines were left
+// out by the line limit and how many bytes the byte limit dropped.
+func truncateLogs(output string, maxLines, maxBytes int) ([]string, bool, int) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	moreLines := len(lines) > maxLines
+	if moreLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+
+	droppedBytes, size := 0, 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		size += len(lines[i]) + 1
+		if size <= maxBytes+1 {
+			continue
+		}
+		// The newest line is kept even when it alone exceeds the limit, cut to its end
+		if i == len(lines)-1 {
+			cut := len(lines[i]) - maxBytes
+			lines[i] = strings.ToValidUTF8(lines[i][cut:], "")
+			droppedBytes += cut
+			i--
+		}
+		for _, line := range lines[:i+1] {
+			droppedBytes += len(line) + 1
+		}
+		lines = lines[i+1:]
+		break
+	}
+	return lines, moreLines, droppedBytes
+}
+
+// limitAlertSize drops the oldest events, then the oldest log lines, and
+// finally cuts the message of an alert until its JSON encoding fits in
+// maxBytes, returning what was removed
+func limitAlertSize(alert *PodAlert, maxBytes int) (events, logLines, messageBytes int) {
+	data, err := json.Marshal(alert)
+	if err != nil || len(data) <= maxBytes {
+		return 0, 0, 0
+	}
+	excess := len(data) - maxBytes
+
+	for excess > 0 && len(alert.Events) > 0 {
+		event, _ := json.Marshal(alert.Events[0])
+		excess -= len(event) + 1
+		alert.Events = alert.Events[1:]
+		events++
+	}
+	for excess > 0 && len(alert.Logs) > 0 {
+		line, _ := json.Marshal(alert.Logs[0])
+		excess -= len(line) + 1
+		alert.Logs = alert.Logs[1:]
+		logLines++
+	}
+	// Escaping makes the encoded message longer than the one cut, so the size is checked again
+	for {
+		if data, err = json.Marshal(alert); err != nil || len(data) <= maxBytes || alert.Message == "" {
+			return events, logLines, messageBytes
+		}
+		cut := min(len(data)-maxBytes, len(alert.Message))
+		alert.Message = strings.ToValidUTF8(alert.Message[:len(alert.Message)-cut], "")
+		messageBytes += cut
+	}
+}
+
+// getPodEvents returns the events recorded for a pod
+func (a *AlertTool) getPodEvents(ctx context.Context, podName, namespace string) ([]PodEvent, error) {
+	eventsResult, err := a.runKubectlCommandString(ctx, "get", "events", "-n", namespace,
+		"--field-selector", fmt.Sprintf("involvedObject.name=%s", podName), "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	var eventsList struct {
+		Items []struct {
+			Type      string `json:"type"`
+			Reason    string `json:"reason"`
+			Message   string `json:"message"`
+			Count     int32  `json:"count"`
+			FirstTime string `json:"firstTimestamp"`
+			LastTime  string `json:"lastTimestamp"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(eventsResult), &eventsList); err != nil {
+		return nil, fmt.Errorf("failed to parse events: %w", err)
+	}
+
+	events := make([]PodEvent, 0, len(eventsList.Items))
+	for _, event := range eventsList.Items {
+		events = append(events, PodEvent{
+			Type:      event.Type,
+			Reason:    event.Reason,
+			Message:   event.Message,
+			Count:     event.Count,
+			FirstTime: event.FirstTime,
+			LastTime:  event.LastTime,
+		})
+	}
+	return events, nil
+}
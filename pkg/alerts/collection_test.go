@@ -0,0 +1,220 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/blobs"
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+func eventsArgs(pod string) []string {
+	return []string{"get", "events", "-n", "prod", "--field-selector", "involvedObject.name=" + pod, "-o", "json"}
+}
+
+func logsArgs(pod string) []string {
+	return []string{"logs", pod, "-n", "prod", "--tail=51"}
+}
+
+const testEventsJSON = `{"items":[{"type":"Warning","reason":"BackOff","message":"Back-off restarting failed container","count":3}]}`
+
+func TestCollectPodData(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", eventsArgs("web-1"), testEventsJSON, nil)
+	mock.AddCommandString("kubectl", logsArgs("web-1"), "line 1\nline 2\n", nil)
+	mock.AddCommandString("kubectl", eventsArgs("web-2"), "", errors.New("forbidden"))
+	mock.AddCommandString("kubectl", logsArgs("web-2"), "line 1\n", nil)
+	mock.AddCommandString("kubectl", eventsArgs("web-3"), "not json", nil)
+	mock.AddCommandString("kubectl", logsArgs("web-3"), "", errors.New("container not found"))
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	alerts := []PodAlert{
+		{PodName: "web-1", Namespace: "prod"},
+		{PodName: "web-2", Namespace: "prod"},
+		{PodName: "web-3", Namespace: "prod"},
+	}
+	NewAlertTool(nil).collectPodData(ctx, alerts, 2, time.Second, defaultCollectionLimits())
+
+	require.NotNil(t, alerts[0].Collection)
+	assert.Equal(t, CollectionStatusCollected, alerts[0].Collection.Status)
+	assert.Len(t, alerts[0].Events, 1)
+	assert.Equal(t, []string{"line 1", "line 2"}, alerts[0].Logs)
+
+	// Logs collected despite the events failure are kept
+	assert.Equal(t, CollectionStatusPartial, alerts[1].Collection.Status)
+	assert.Equal(t, []string{"line 1"}, alerts[1].Logs)
+	require.Len(t, alerts[1].Collection.Errors, 1)
+	assert.Contains(t, alerts[1].Collection.Errors[0], "events")
+
+	assert.Equal(t, CollectionStatusFailed, alerts[2].Collection.Status)
+	assert.Len(t, alerts[2].Collection.Errors, 2)
+
+	assert.Len(t, mock.GetCallLog(), 6)
+}
+
+func TestCollectPodDataStoresTruncatedLogs(t *testing.T) {
+	t.Setenv(AlertLogDir, t.TempDir())
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", eventsArgs("web-1"), testEventsJSON, nil)
+	mock.AddCommandString("kubectl", logsArgs("web-1"), "line 1\nline 2\nline 3\n", nil)
+	mock.AddCommandString("kubectl", eventsArgs("web-2"), testEventsJSON, nil)
+	mock.AddCommandString("kubectl", logsArgs("web-2"), "line 1\n", nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	alerts := []PodAlert{
+		{PodName: "web-1", Namespace: "prod"},
+		{PodName: "web-2", Namespace: "prod"},
+	}
+	limits := defaultCollectionLimits()
+	limits.LogBytes = 10
+	NewAlertTool(nil).collectPodData(ctx, alerts, 2, time.Second, limits)
+
+	assert.Equal(t, []string{"line 3"}, alerts[0].Logs)
+	require.NotEmpty(t, alerts[0].LogsURI)
+	bucket, id, err := blobs.ParseURI(alerts[0].LogsURI)
+	require.NoError(t, err)
+	var metadata AlertLogs
+	content, err := blobs.Default().Read(ctx, bucket, id, &metadata)
+	require.NoError(t, err)
+	assert.Equal(t, "line 1\nline 2\nline 3\n", string(content))
+	assert.Equal(t, "web-1", metadata.PodName)
+	assert.EqualValues(t, len(content), metadata.Size)
+
+	// Logs that fit are not stored
+	assert.Empty(t, alerts[1].LogsURI)
+}
+
+func TestCollectionConfig(t *testing.T) {
+	t.Setenv(AlertCollectionParallelism, "1000")
+	t.Setenv(AlertCollectionPodTimeout, "5s")
+	assert.Equal(t, maxCollectionParallelism, collectionParallelism())
+	assert.Equal(t, 5*time.Second, collectionPodTimeout())
+
+	t.Setenv(AlertCollectionParallelism, "-1")
+	t.Setenv(AlertCollectionPodTimeout, "soon")
+	assert.Equal(t, defaultCollectionParallelism, collectionParallelism())
+	assert.Equal(t, defaultCollectionPodTimeout, collectionPodTimeout())
+}
+
+func TestCollectionLimits(t *testing.T) {
+	t.Setenv(AlertCollectionMaxLogLines, "20")
+	t.Setenv(AlertCollectionMaxLogBytes, "")
+	t.Setenv(AlertCollectionMaxAlertBytes, "4096")
+	assert.Equal(t, collectionLimits{LogLines: 20, LogBytes: defaultCollectionLogBytes, AlertBytes: 4096}, defaultCollectionLimits())
+	assert.Equal(t, defaultMaxCollectionLogBytes, maxCollectionLogBytes())
+}
+
+func TestTruncateLogs(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		maxLines  int
+		maxBytes  int
+		want      []string
+		moreLines bool
+		dropped   int
+	}{
+		{name: "within limits", output: "a\nb\n", maxLines: 2, maxBytes: 10, want: []string{"a", "b"}},
+		{name: "older lines", output: "a\nb\nc\n", maxLines: 2, maxBytes: 10, want: []string{"b", "c"}, moreLines: true},
+		{name: "byte limit", output: "aaaa\nbbbb\ncccc", maxLines: 3, maxBytes: 9, want: []string{"bbbb", "cccc"}, dropped: 5},
+		{name: "long newest line", output: "aaaa\nbbbbbbbb", maxLines: 2, maxBytes: 3, want: []string{"bbb"}, dropped: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, moreLines, dropped := truncateLogs(tt.output, tt.maxLines, tt.maxBytes)
+			assert.Equal(t, tt.want, lines)
+			assert.Equal(t, tt.moreLines, moreLines)
+			assert.Equal(t, tt.dropped, dropped)
+		})
+	}
+}
+
+func TestLimitAlertSize(t *testing.T) {
+	alert := PodAlert{PodName: "web-1", Namespace: "prod", Message: strings.Repeat("m", 100)}
+	for i := range 10 {
+		alert.Events = append(alert.Events, PodEvent{Reason: "BackOff", Message: strings.Repeat("e", 100), Count: int32(i)})
+		alert.Logs = append(alert.Logs, strings.Repeat("l", 100))
+	}
+
+	small := alert
+	events, logLines, messageBytes := limitAlertSize(&small, 1<<20)
+	assert.Zero(t, events+logLines+messageBytes)
+
+	limited := alert
+	events, logLines, messageBytes = limitAlertSize(&limited, 1000)
+	data, err := json.Marshal(limited)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(data), 1000)
+	assert.Equal(t, 10, events)
+	assert.Positive(t, logLines)
+	assert.Zero(t, messageBytes)
+	// The newest logs are kept
+	assert.Equal(t, alert.Logs[len(alert.Logs)-1], limited.Logs[len(limited.Logs)-1])
+
+	tiny := alert
+	tiny.Message = strings.Repeat("\"", 200)
+	_, _, messageBytes = limitAlertSize(&tiny, 300)
+	data, err = json.Marshal(tiny)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(data), 300)
+	assert.Positive(t, messageBytes)
+}
+
+func TestHandleGetPodAlertsCollection(t *testing.T) {
+	podsJSON := `{"items":[
+		{"metadata":{"name":"web-1","namespace":"prod"},"status":{"phase":"Pending"}},
+		{"metadata":{"name":"web-2","namespace":"prod"},"status":{"phase":"Running","containerStatuses":[{"ready":true}]}}
+	]}`
+
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pods", "-o", "json", "-n", "prod"}, podsJSON, nil)
+	mock.AddCommandString("kubectl", eventsArgs("web-1"), testEventsJSON, nil)
+	mock.AddCommandString("kubectl", logsArgs("web-1"), "", errors.New("container is waiting to start"))
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	tool := NewAlertTool(nil)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"namespace":   "prod",
+		"parallelism": float64(4),
+		"pod_timeout": "10s",
+	}
+	result, err := tool.handleGetPodAlerts(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	var alerts []PodAlert
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &alerts))
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "web-1", alerts[0].PodName)
+	assert.Equal(t, AlertStateCollected, alerts[0].State)
+	require.NotNil(t, alerts[0].Collection)
+	assert.Equal(t, CollectionStatusPartial, alerts[0].Collection.Status)
+	assert.Len(t, alerts[0].Events, 1)
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		tests := []map[string]interface{}{
+			{"namespace": "prod", "parallelism": float64(0)},
+			{"namespace": "prod", "parallelism": float64(maxCollectionParallelism + 1)},
+			{"namespace": "prod", "pod_timeout": "soon"},
+			{"namespace": "prod", "max_log_lines": float64(0)},
+			{"namespace": "prod", "max_log_lines": float64(defaultMaxCollectionLogLines + 1)},
+			{"namespace": "prod", "max_log_bytes": float64(defaultMaxCollectionLogBytes + 1)},
+		}
+		for _, args := range tests {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = args
+			result, err := tool.handleGetPodAlerts(ctx, request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+		}
+	})
+}
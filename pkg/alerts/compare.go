@@ -0,0 +1,259 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// Verdicts of an alert comparison
+const (
+	DiffVerdictRecurring        = "recurring"
+	DiffVerdictChanged          = "recurring_with_changes"
+	DiffVerdictRegression       = "new_regression"
+	DiffVerdictInsufficientData = "insufficient_data"
+)
+
+// Severity changes between two alerts
+const (
+	SeverityUnchanged = "unchanged"
+	SeverityEscalated = "escalated"
+	SeverityReduced   = "reduced"
+	SeverityUnknown   = "unknown"
+)
+
+// severityRanks orders the severities assigned by the analysis schema
+var severityRanks = map[string]int{"Low": 1, "Medium": 2, "High": 3, "Critical": 4}
+
+// logVariables match the parts of a log line that vary between occurrences of
+// the same message, in the order they are replaced
+var logVariables = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`), "<time>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`(?i)\b(0x[0-9a-f]+|[0-9a-f]{8,})\b`), "<hex>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<n>"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// AlertRef identifies one side of an alert comparison
+type AlertRef struct {
+	ID        string     `json:"id"`
+	Status    string     `json:"status"`
+	Reason    string     `json:"reason,omitempty"`
+	State     AlertState `json:"state,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// SetDiff compares a property holding a set of values
+type SetDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Common  []string `json:"common"`
+}
+
+// SeverityDiff compares the severities assigned by the analyses
+type SeverityDiff struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	Change string `json:"change"`
+}
+
+// AlertDiff is the structured response of alerts_compare
+type AlertDiff struct {
+	Before            AlertRef     `json:"before"`
+	After             AlertRef     `json:"after"`
+	Verdict           string       `json:"verdict"`
+	Summary           string       `json:"summary"`
+	IssueTypes        SetDiff      `json:"issue_types"`
+	AffectedResources SetDiff      `json:"affected_resources"`
+	Severity          SeverityDiff `json:"severity"`
+	LogSignatures     SetDiff      `json:"log_signatures"`
+}
+
+// parseAlertID splits an alert ID, namespace/pod_name or an alerts:// URI
+func parseAlertID(id string) (string, string, error) {
+	namespace, podName, found := strings.Cut(strings.TrimPrefix(id, alertResourceScheme), "/")
+	if !found || namespace == "" || podName == "" || strings.Contains(podName, "/") {
+		return "", "", fmt.Errorf("invalid alert ID %q, expected namespace/pod_name", id)
+	}
+	return namespace, podName, nil
+}
+
+// alertIssueTypes returns the failure reasons of an alert and its warning events
+func alertIssueTypes(alert PodAlert) []string {
+	types := []string{}
+	for _, value := range []string{alert.Status, alert.Reason} {
+		if value != "" && value != "Running" {
+			types = append(types, value)
+		}
+	}
+	for _, event := range alert.Events {
+		if event.Type == "Warning" && event.Reason != "" {
+			types = append(types, event.Reason)
+		}
+	}
+	return types
+}
+
+// alertResources returns the resources affected by an alert, including the
+// Deployment that owns ReplicaSet pods
+func alertResources(alert PodAlert) []string {
+	resources := []string{"namespace/" + alert.Namespace, "pod/" + alert.PodName}
+	if match := replicaSetPodName.FindStringSubmatch(alert.PodName); match != nil {
+		resources = append(resources, "deployment/"+match[1])
+	}
+	return resources
+}
+
+// logSignature reduces a log line to its message, replacing timestamps, IDs
+// and numbers so that repeated occurrences of a message match
+func logSignature(line string) string {
+	for _, variable := range logVariables {
+		line = variable.pattern.ReplaceAllString(line, variable.replacement)
+	}
+	return strings.TrimSpace(line)
+}
+
+// alertLogSignatures returns the signatures of the alert's log lines
+func alertLogSignatures(alert PodAlert) []string {
+	signatures := []string{}
+	for _, line := range alert.Logs {
+		if signature := logSignature(line); signature != "" {
+			signatures = append(signatures, signature)
+		}
+	}
+	return signatures
+}
+
+// diffSets compares two lists of values, ignoring duplicates and order
+func diffSets(before, after []string) SetDiff {
+	diff := SetDiff{Added: []string{}, Removed: []string{}, Common: []string{}}
+	for _, value := range uniqueSorted(after) {
+		if slices.Contains(before, value) {
+			diff.Common = append(diff.Common, value)
+		} else {
+			diff.Added = append(diff.Added, value)
+		}
+	}
+	for _, value := range uniqueSorted(before) {
+		if !slices.Contains(after, value) {
+			diff.Removed = append(diff.Removed, value)
+		}
+	}
+	return diff
+}
+
+// uniqueSorted returns the distinct values in order
+func uniqueSorted(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return slices.Compact(sorted)
+}
+
+// diffSeverity compares the severities of two alerts
+func diffSeverity(before, after PodAlert) SeverityDiff {
+	diff := SeverityDiff{Before: alertSeverity(before), After: alertSeverity(after)}
+	beforeRank, afterRank := severityRanks[diff.Before], severityRanks[diff.After]
+	switch {
+	case beforeRank == 0 || afterRank == 0:
+		diff.Change = SeverityUnknown
+	case afterRank > beforeRank:
+		diff.Change = SeverityEscalated
+	case afterRank < beforeRank:
+		diff.Change = SeverityReduced
+	default:
+		diff.Change = SeverityUnchanged
+	}
+	return diff
+}
+
+// alertRef summarizes a stored alert for a comparison
+func alertRef(doc AlertDocument) AlertRef {
+	return AlertRef{
+		ID:        alertKey(doc.Alert.Namespace, doc.Alert.PodName),
+		Status:    doc.Alert.Status,
+		Reason:    doc.Alert.Reason,
+		State:     doc.Alert.State,
+		UpdatedAt: doc.UpdatedAt,
+	}
+}
+
+// buildAlertDiff compares an earlier alert with a later one. An alert whose
+// issue types and log signatures were all seen before is recurring, one that
+// shares none of them is a new regression.
+func buildAlertDiff(before, after AlertDocument) AlertDiff {
+	diff := AlertDiff{
+		Before:            alertRef(before),
+		After:             alertRef(after),
+		IssueTypes:        diffSets(alertIssueTypes(before.Alert), alertIssueTypes(after.Alert)),
+		AffectedResources: diffSets(alertResources(before.Alert), alertResources(after.Alert)),
+		Severity:          diffSeverity(before.Alert, after.Alert),
+		LogSignatures:     diffSets(alertLogSignatures(before.Alert), alertLogSignatures(after.Alert)),
+	}
+
+	shared := len(diff.IssueTypes.Common) + len(diff.LogSignatures.Common)
+	added := len(diff.IssueTypes.Added) + len(diff.LogSignatures.Added)
+	switch {
+	case shared == 0 && added == 0:
+		diff.Verdict = DiffVerdictInsufficientData
+		diff.Summary = fmt.Sprintf("%s has no issue types or logs to compare", diff.After.ID)
+	case shared == 0:
+		diff.Verdict = DiffVerdictRegression
+		diff.Summary = fmt.Sprintf("%s shows %d issue type(s) and %d log signature(s) not seen in %s", diff.After.ID, len(diff.IssueTypes.Added), len(diff.LogSignatures.Added), diff.Before.ID)
+	case added == 0:
+		diff.Verdict = DiffVerdictRecurring
+		diff.Summary = fmt.Sprintf("%s repeats %s: %d shared issue type(s) and %d shared log signature(s)", diff.After.ID, diff.Before.ID, len(diff.IssueTypes.Common), len(diff.LogSignatures.Common))
+	default:
+		diff.Verdict = DiffVerdictChanged
+		diff.Summary = fmt.Sprintf("%s shares %d issue type(s) and %d log signature(s) with %s, but adds %d issue type(s) and %d log signature(s)", diff.After.ID, len(diff.IssueTypes.Common), len(diff.LogSignatures.Common), diff.Before.ID, len(diff.IssueTypes.Added), len(diff.LogSignatures.Added))
+	}
+	if diff.Severity.Change == SeverityEscalated || diff.Severity.Change == SeverityReduced {
+		diff.Summary += fmt.Sprintf("; severity %s from %s to %s", diff.Severity.Change, diff.Severity.Before, diff.Severity.After)
+	}
+	return diff
+}
+
+// handleCompareAlerts compares two stored alerts
+func (a *AlertTool) handleCompareAlerts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	beforeID := p.String("before", "", params.Required())
+	afterID := p.String("after", "", params.Required())
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var docs []AlertDocument
+	for _, id := range []string{beforeID, afterID} {
+		namespace, podName, err := parseAlertID(id)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		doc, err := a.store.Get(ctx, namespace, podName)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to load alert: %v", err)), nil
+		}
+		if doc == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("No alert found for %s", alertKey(namespace, podName))), nil
+		}
+		docs = append(docs, *doc)
+	}
+
+	diffJSON, err := json.MarshalIndent(buildAlertDiff(docs[0], docs[1]), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal alert diff: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(diffJSON)), nil
+}
@@ -0,0 +1,107 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogSignature(t *testing.T) {
+	assert.Equal(t, "<time> ERROR request <uuid> from <ip> failed after <n>ms",
+		logSignature("2026-10-15T09:00:01.123Z ERROR request 3f2b6c1e-8a4d-4e7f-9b0a-1c2d3e4f5a6b from 10.0.3.17:8080 failed after 312ms"))
+	assert.Equal(t, "panic at <hex>: nil map", logSignature("panic at 0xc000123abc:   nil map"))
+	assert.Equal(t, logSignature("connection to db-0 refused (attempt 3)"), logSignature("connection to db-0 refused (attempt 7)"))
+}
+
+// analyzedAlert returns a stored alert with the given severity
+func analyzedAlert(podName, reason, severity string, events []PodEvent, logs []string) AlertDocument {
+	return AlertDocument{Alert: PodAlert{
+		PodName:        podName,
+		Namespace:      "prod",
+		Status:         reason,
+		Reason:         reason,
+		Events:         events,
+		Logs:           logs,
+		AnalysisResult: &AnalysisResult{Data: map[string]interface{}{"severity": severity}, Validation: AnalysisValidation{Valid: true}},
+	}}
+}
+
+func TestBuildAlertDiff(t *testing.T) {
+	before := analyzedAlert("web-7c9d8f6b5-x2k4q", "CrashLoopBackOff", "Medium",
+		[]PodEvent{{Type: "Warning", Reason: "BackOff"}, {Type: "Normal", Reason: "Pulled"}},
+		[]string{"2026-10-14T09:00:00Z connection to db-0 refused (attempt 1)"})
+
+	t.Run("recurring", func(t *testing.T) {
+		after := analyzedAlert("web-7c9d8f6b5-p9zt2", "CrashLoopBackOff", "High",
+			[]PodEvent{{Type: "Warning", Reason: "BackOff"}},
+			[]string{"2026-10-15T09:00:00Z connection to db-0 refused (attempt 4)"})
+
+		diff := buildAlertDiff(before, after)
+		assert.Equal(t, DiffVerdictRecurring, diff.Verdict)
+		assert.Equal(t, []string{"BackOff", "CrashLoopBackOff"}, diff.IssueTypes.Common)
+		assert.Equal(t, []string{"pod/web-7c9d8f6b5-p9zt2"}, diff.AffectedResources.Added)
+		assert.Equal(t, []string{"deployment/web", "namespace/prod"}, diff.AffectedResources.Common)
+		assert.Equal(t, []string{"<time> connection to db-<n> refused (attempt <n>)"}, diff.LogSignatures.Common)
+		assert.Equal(t, SeverityDiff{Before: "Medium", After: "High", Change: SeverityEscalated}, diff.Severity)
+		assert.Contains(t, diff.Summary, "severity escalated from Medium to High")
+	})
+
+	t.Run("new regression", func(t *testing.T) {
+		after := analyzedAlert("web-7c9d8f6b5-p9zt2", "OOMKilled", "Medium", nil, []string{"fatal error: out of memory"})
+
+		diff := buildAlertDiff(before, after)
+		assert.Equal(t, DiffVerdictRegression, diff.Verdict)
+		assert.Equal(t, []string{"OOMKilled"}, diff.IssueTypes.Added)
+		assert.Equal(t, []string{"BackOff", "CrashLoopBackOff"}, diff.IssueTypes.Removed)
+		assert.Equal(t, SeverityUnchanged, diff.Severity.Change)
+	})
+
+	t.Run("recurring with changes", func(t *testing.T) {
+		after := analyzedAlert("web-7c9d8f6b5-p9zt2", "CrashLoopBackOff", "", []PodEvent{{Type: "Warning", Reason: "Unhealthy"}}, nil)
+
+		diff := buildAlertDiff(before, after)
+		assert.Equal(t, DiffVerdictChanged, diff.Verdict)
+		assert.Equal(t, []string{"Unhealthy"}, diff.IssueTypes.Added)
+		assert.Equal(t, SeverityUnknown, diff.Severity.Change)
+	})
+
+	t.Run("nothing to compare", func(t *testing.T) {
+		diff := buildAlertDiff(before, AlertDocument{Alert: PodAlert{PodName: "web-1", Namespace: "prod", Status: "Running"}})
+		assert.Equal(t, DiffVerdictInsufficientData, diff.Verdict)
+		assert.Empty(t, diff.IssueTypes.Added)
+	})
+}
+
+func TestHandleCompareAlerts(t *testing.T) {
+	ctx := context.Background()
+	tool := NewAlertTool(nil)
+	require.NoError(t, tool.store.Upsert(ctx, PodAlert{PodName: "web-1", Namespace: "prod", Status: "CrashLoopBackOff"}))
+	require.NoError(t, tool.store.Upsert(ctx, PodAlert{PodName: "web-2", Namespace: "prod", Status: "CrashLoopBackOff"}))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"before": "prod/web-1", "after": "alerts://prod/web-2"}
+	result, err := tool.handleCompareAlerts(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	var diff AlertDiff
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &diff))
+	assert.Equal(t, "prod/web-1", diff.Before.ID)
+	assert.Equal(t, "prod/web-2", diff.After.ID)
+	assert.Equal(t, DiffVerdictRecurring, diff.Verdict)
+
+	for _, arguments := range []map[string]interface{}{
+		{"before": "prod/web-1"},
+		{"before": "web-1", "after": "prod/web-2"},
+		{"before": "prod/web-1", "after": "prod/web-3"},
+	} {
+		request.Params.Arguments = arguments
+		result, err := tool.handleCompareAlerts(ctx, request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError, arguments)
+	}
+}
@@ -0,0 +1,536 @@
+package alerts
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/blobs"
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+const (
+	// AlertRemediationServiceAccount configures the ServiceAccount remediation
+	// Jobs run as, in the namespace of the alert
+	AlertRemediationServiceAccount = "ALERT_REMEDIATION_SERVICE_ACCOUNT"
+	// AlertRemediationImage configures the image of remediation Jobs, which
+	// must provide bash, kubectl and helm
+	AlertRemediationImage = "ALERT_REMEDIATION_IMAGE"
+	// AlertRemediationLogDir configures the directory the logs of remediation
+	// Jobs are stored in. When unset, they are stored under the XDG data directory.
+	AlertRemediationLogDir = "ALERT_REMEDIATION_LOG_DIR"
+)
+
+const (
+	defaultRemediationServiceAccount = "kagent-remediation"
+	defaultRemediationImage          = "alpine/k8s:1.31.4"
+	// defaultRemediationTimeout bounds a remediation Job when no timeout is given
+	defaultRemediationTimeout = 10 * time.Minute
+	// maxRemediationTimeout is the largest accepted remediation Job timeout
+	maxRemediationTimeout = time.Hour
+	// remediationJobTTL is how long finished remediation Jobs are kept before
+	// Kubernetes deletes them, in seconds
+	remediationJobTTL = 3600
+	// remediationJobGrace is how long past its deadline a Job is waited for,
+	// for Kubernetes to mark it failed
+	remediationJobGrace = time.Minute
+	// maxRemediationLogBytes caps the stored logs of a remediation Job
+	maxRemediationLogBytes = 16 << 20
+	// remediationLogTailLines is the number of log lines kept in the remediation record
+	remediationLogTailLines = 20
+)
+
+// JobKindDelegatedRemediation waits for a remediation Job, captures its logs
+// and records its outcome
+const JobKindDelegatedRemediation = "delegated_remediation"
+
+// ExecutionModeDelegatedJob runs a remediation script in a Kubernetes Job
+const ExecutionModeDelegatedJob = "delegated_job"
+
+// Statuses of a remediation execution
+const (
+	ExecutionStatusRunning   = "running"
+	ExecutionStatusSucceeded = "succeeded"
+	ExecutionStatusFailed    = "failed"
+)
+
+// remediationLogBucket stores the logs of remediation Jobs
+var remediationLogBucket = blobs.Register(blobs.Bucket{Name: "remediation-logs", DirEnv: AlertRemediationLogDir, DefaultDir: "kagent-tools/remediation-logs"})
+
+// jobPollInterval is how often a remediation Job's status is checked
+var jobPollInterval = 5 * time.Second
+
+// RemediationExecution records how a remediation was executed, for the audit trail
+type RemediationExecution struct {
+	Mode           string            `json:"mode"`
+	JobName        string            `json:"job_name"`
+	Namespace      string            `json:"namespace"`
+	ServiceAccount string            `json:"service_account"`
+	Image          string            `json:"image"`
+	Template       string            `json:"template"`
+	Parameters     map[string]string `json:"parameters,omitempty"`
+	Script         string            `json:"script"`
+	Timeout        string            `json:"timeout"`
+	Status         string            `json:"status"`
+	Reason         string            `json:"reason,omitempty"`
+	FinishedAt     *time.Time        `json:"finished_at,omitempty"`
+	// LogsURI references the full logs of the Job, to be read with fetch_blob
+	LogsURI string `json:"logs_uri,omitempty"`
+	// LogTail holds the last lines of the Job's logs
+	LogTail []string `json:"log_tail,omitempty"`
+}
+
+// RemediationLogs describes the logs of a remediation Job stored in the blob store
+type RemediationLogs struct {
+	ID            string    `json:"id"`
+	URI           string    `json:"uri"`
+	Namespace     string    `json:"namespace"`
+	PodName       string    `json:"pod_name"`
+	RemediationID string    `json:"remediation_id"`
+	JobName       string    `json:"job_name"`
+	Size          int64     `json:"size"`
+	SHA256        string    `json:"sha256"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// delegatedPayload is the payload of a delegated remediation job. It holds the
+// fields of a verification payload, so idempotent retries can find its record.
+type delegatedPayload struct {
+	verificationPayload
+	VerifyAfter string `json:"verify_after"`
+}
+
+// remediationServiceAccount returns the configured remediation ServiceAccount
+func remediationServiceAccount() string {
+	if name := os.Getenv(AlertRemediationServiceAccount); name != "" {
+		return name
+	}
+	return defaultRemediationServiceAccount
+}
+
+// remediationImage returns the configured remediation image
+func remediationImage() string {
+	if image := os.Getenv(AlertRemediationImage); image != "" {
+		return image
+	}
+	return defaultRemediationImage
+}
+
+// remediationJobName returns a unique name for a remediation Job of a template
+func remediationJobName(templateName string) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate job name: %w", err)
+	}
+	prefix := "kagent-remediation-" + templateName
+	if len(prefix) > 54 {
+		prefix = strings.TrimRight(prefix[:54], "-")
+	}
+	return prefix + "-" + hex.EncodeToString(suffix), nil
+}
+
+// maxLabelValueLength is the longest value Kubernetes accepts for a label
+const maxLabelValueLength = 63
+
+// remediationForLabel returns the label value naming the pod a remediation Job
+// is for. Pod names can be longer than label values, so long names are cut
+// and suffixed with a hash of the full name, which is kept in an annotation.
+func remediationForLabel(podName string) string {
+	if len(podName) <= maxLabelValueLength {
+		return podName
+	}
+	sum := sha256.Sum256([]byte(podName))
+	hash := hex.EncodeToString(sum[:])[:10]
+	prefix := strings.TrimRight(podName[:maxLabelValueLength-len(hash)-1], "-.")
+	return prefix + "-" + hash
+}
+
+// remediationJobManifest returns the Job running a remediation script. The
+// Job runs once, as the dedicated ServiceAccount, without privileges, and is
+// deleted by Kubernetes an hour after it finished.
+func remediationJobManifest(execution RemediationExecution, podName string, timeout time.Duration) map[string]interface{} {
+	labels := map[string]string{
+		"app.kubernetes.io/managed-by": "kagent-tools",
+		"app.kubernetes.io/component":  "remediation",
+		"kagent.dev/remediation-for":   remediationForLabel(podName),
+	}
+	return map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]interface{}{
+			"name":      execution.JobName,
+			"namespace": execution.Namespace,
+			"labels":    labels,
+			"annotations": map[string]string{
+				"kagent.dev/remediation-template": execution.Template,
+				"kagent.dev/remediation-for":      podName,
+			},
+		},
+		"spec": map[string]interface{}{
+			"backoffLimit":            0,
+			"activeDeadlineSeconds":   int(timeout.Seconds()),
+			"ttlSecondsAfterFinished": remediationJobTTL,
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": labels},
+				"spec": map[string]interface{}{
+					"serviceAccountName": execution.ServiceAccount,
+					"restartPolicy":      "Never",
+					"securityContext": map[string]interface{}{
+						"runAsNonRoot":   true,
+						"runAsUser":      65532,
+						"seccompProfile": map[string]string{"type": "RuntimeDefault"},
+					},
+					"containers": []map[string]interface{}{{
+						"name":    "remediation",
+						"image":   execution.Image,
+						"command": []string{"bash", "-c", execution.Script},
+						"env":     []map[string]string{{"name": "HOME", "value": "/tmp"}},
+						"securityContext": map[string]interface{}{
+							"allowPrivilegeEscalation": false,
+							"readOnlyRootFilesystem":   true,
+							"capabilities":             map[string][]string{"drop": {"ALL"}},
+						},
+						"resources": map[string]interface{}{
+							"requests": map[string]string{"cpu": "50m", "memory": "64Mi"},
+							"limits":   map[string]string{"memory": "256Mi"},
+						},
+						"volumeMounts": []map[string]string{{"name": "tmp", "mountPath": "/tmp"}},
+					}},
+					"volumes": []map[string]interface{}{{"name": "tmp", "emptyDir": map[string]interface{}{}}},
+				},
+			},
+		},
+	}
+}
+
+// createRemediationJob creates the Job of a remediation execution
+func (a *AlertTool) createRemediationJob(ctx context.Context, execution RemediationExecution, podName string, timeout time.Duration) error {
+	body, err := json.Marshal(remediationJobManifest(execution, podName, timeout))
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp("", "alerts-remediation-job-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		if removeErr := os.Remove(tmpFile.Name()); removeErr != nil {
+			logger.Get().Error("Failed to remove temporary file", "error", removeErr, "file", tmpFile.Name())
+		}
+	}()
+	if _, err := tmpFile.Write(body); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	_, err = a.runKubectlCommandString(ctx, "create", "-f", tmpFile.Name())
+	return err
+}
+
+// handleRunRemediation runs a curated remediation script for a stored alert in
+// a short-lived Job with the dedicated remediation ServiceAccount, rather than
+// with the credentials of the tool server. The Job is followed by a background
+// job that captures its logs into the remediation record.
+func (a *AlertTool) handleRunRemediation(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	podName := p.String("pod_name", "", params.Required())
+	namespace := p.String("namespace", "default")
+	templateName := p.String("template", "", params.OneOf(scriptTemplateNames()...))
+	parametersJSON := p.String("parameters", "")
+	timeout := p.Duration("timeout", defaultRemediationTimeout)
+	delay := p.Duration("verify_after", verifyDelay(), params.AllowZero())
+	idempotencyKey := p.String("idempotency_key", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if timeout > maxRemediationTimeout {
+		return mcp.NewToolResultError(fmt.Sprintf("timeout parameter must be at most %s", maxRemediationTimeout)), nil
+	}
+
+	// A retried call returns the remediation started by the first one
+	if idempotencyKey != "" {
+		result, err := a.existingRemediation(ctx, JobKindDelegatedRemediation, idempotencyKey)
+		if result != nil || err != nil {
+			return result, err
+		}
+	}
+
+	overrides := map[string]string{}
+	if parametersJSON != "" {
+		if err := json.Unmarshal([]byte(parametersJSON), &overrides); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("parameters must be a JSON object of strings: %v", err)), nil
+		}
+	}
+
+	doc, err := a.store.Get(ctx, namespace, podName)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to load alert: %v", err)), nil
+	}
+	if doc == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no alert found for %s; collect it with alerts_get_pod_alerts first", alertKey(namespace, podName))), nil
+	}
+
+	// The Job runs in the alert's namespace with its ServiceAccount, so its
+	// script must not be pointed at another namespace
+	if value, ok := overrides["namespace"]; ok && value != doc.Alert.Namespace {
+		return mcp.NewToolResultError(fmt.Sprintf("parameter namespace must be the namespace of the alert, %s", doc.Alert.Namespace)), nil
+	}
+
+	// Only curated templates are run; LLM generated scripts are never executed
+	var script *RemediationScript
+	if templateName != "" {
+		values := alertParameters(doc.Alert)
+		for name, value := range overrides {
+			values[name] = value
+		}
+		tmpl := findScriptTemplate(templateName)
+		rendered, resolved, err := tmpl.render(values)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		script = &RemediationScript{Source: ScriptSourceTemplate, Template: tmpl.Name, Parameters: resolved, Script: rendered}
+	} else if script = matchScriptTemplate(doc.Alert, overrides); script == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no script template applies to %s; choose one with template (%s)",
+			alertKey(namespace, podName), strings.Join(scriptTemplateNames(), ", "))), nil
+	}
+
+	// The ServiceAccount is created by the cluster administrator with only the
+	// permissions remediations need, so it must exist before anything runs
+	serviceAccount := remediationServiceAccount()
+	if _, err := a.runKubectlCommandString(ctx, "get", "serviceaccount", serviceAccount, "-n", namespace, "-o", "name"); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf(
+			"remediation ServiceAccount %s/%s is not available: %v. Create it with a Role granting only the permissions remediations need, or set %s",
+			namespace, serviceAccount, err, AlertRemediationServiceAccount)), nil
+	}
+
+	jobName, err := remediationJobName(script.Template)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	execution := RemediationExecution{
+		Mode:           ExecutionModeDelegatedJob,
+		JobName:        jobName,
+		Namespace:      namespace,
+		ServiceAccount: serviceAccount,
+		Image:          remediationImage(),
+		Template:       script.Template,
+		Parameters:     script.Parameters,
+		Script:         script.Script,
+		Timeout:        timeout.String(),
+		Status:         ExecutionStatusRunning,
+	}
+	if err := a.createRemediationJob(ctx, execution, podName, timeout); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to create remediation job: %v", err)), nil
+	}
+
+	record := RemediationRecord{
+		Remediation:  fmt.Sprintf("%s template run by job %s/%s", script.Template, namespace, jobName),
+		AppliedAt:    time.Now(),
+		VerifyAfter:  delay.String(),
+		Verification: VerificationPending,
+		Execution:    &execution,
+	}
+	if health, err := a.getPodHealth(ctx, namespace, podName); err == nil {
+		record.BaselineRestarts = health.RestartCount
+	}
+	record.ID, err = a.store.AddRemediation(ctx, namespace, podName, record)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to store remediation: %v", err)), nil
+	}
+
+	payload, err := json.Marshal(delegatedPayload{
+		verificationPayload: verificationPayload{Namespace: namespace, PodName: podName, Record: record},
+		VerifyAfter:         delay.String(),
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to encode remediation job: %v", err)), nil
+	}
+	if idempotencyKey == "" {
+		idempotencyKey = JobKindDelegatedRemediation + "/" + record.ID
+	}
+	job, err := a.enqueueJob(ctx, Job{
+		Kind:           JobKindDelegatedRemediation,
+		IdempotencyKey: idempotencyKey,
+		Payload:        payload,
+		RunAfter:       time.Now(),
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to follow remediation job: %v", err)), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(map[string]interface{}{
+		"alert":       doc.Alert,
+		"remediation": record,
+		"job_id":      job.ID,
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal remediation: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// remediationJobStatus is the subset of a Job's status used to follow it
+type remediationJobStatus struct {
+	Status struct {
+		Conditions []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// outcome returns the execution status of a finished Job and the reason it
+// failed, or an empty status while the Job runs
+func (s remediationJobStatus) outcome() (string, string) {
+	for _, condition := range s.Status.Conditions {
+		if condition.Status != "True" {
+			continue
+		}
+		switch condition.Type {
+		case "Complete":
+			return ExecutionStatusSucceeded, ""
+		case "Failed":
+			return ExecutionStatusFailed, strings.TrimSpace(condition.Reason + ": " + condition.Message)
+		}
+	}
+	return "", ""
+}
+
+// waitRemediationJob polls a remediation Job until it finished or the deadline passed
+func (a *AlertTool) waitRemediationJob(ctx context.Context, namespace, jobName string, deadline time.Time) (string, string, error) {
+	for {
+		output, err := a.runKubectlCommandString(ctx, "get", "job", jobName, "-n", namespace, "-o", "json")
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get remediation job: %w", err)
+		}
+		var status remediationJobStatus
+		if err := json.Unmarshal([]byte(output), &status); err != nil {
+			return "", "", fmt.Errorf("failed to parse remediation job: %w", err)
+		}
+		if outcome, reason := status.outcome(); outcome != "" {
+			return outcome, reason, nil
+		}
+		if time.Now().After(deadline) {
+			return ExecutionStatusFailed, "job did not finish before its deadline", nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
+
+// storeRemediationLogs stores the logs of a remediation Job and links them,
+// with their last lines, into the execution record
+func (a *AlertTool) storeRemediationLogs(ctx context.Context, namespace, podName string, record RemediationRecord) error {
+	execution := record.Execution
+	logs, err := a.runKubectlCommandString(ctx, "logs", "job/"+execution.JobName, "-n", namespace, "--all-containers")
+	if err != nil {
+		return err
+	}
+	if len(logs) > maxRemediationLogBytes {
+		logs = logs[len(logs)-maxRemediationLogBytes:]
+	}
+
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	if len(lines) > remediationLogTailLines {
+		lines = lines[len(lines)-remediationLogTailLines:]
+	}
+	if logs != "" {
+		execution.LogTail = lines
+	}
+
+	metadata := RemediationLogs{Namespace: namespace, PodName: podName, RemediationID: record.ID, JobName: execution.JobName, CreatedAt: time.Now().UTC()}
+	info, err := blobs.Default().Write(ctx, remediationLogBucket, maxRemediationLogBytes, func(w io.Writer) error {
+		_, err := io.WriteString(w, logs)
+		return err
+	}, func(info blobs.Info) interface{} {
+		metadata.ID, metadata.URI, metadata.Size, metadata.SHA256 = info.ID, info.URI, info.Size, info.SHA256
+		return metadata
+	})
+	if err != nil {
+		return err
+	}
+	execution.LogsURI = info.URI
+	return nil
+}
+
+// runDelegatedRemediationJob waits for a remediation Job, records its outcome
+// and logs, and schedules the verification of a successful remediation. It
+// only reads the Job, so it is safe to repeat.
+func (a *AlertTool) runDelegatedRemediationJob(ctx context.Context, job Job) error {
+	var payload delegatedPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid delegated remediation payload: %w", err)
+	}
+	record := payload.Record
+	if record.Execution == nil {
+		return fmt.Errorf("remediation %s has no execution", record.ID)
+	}
+	execution := *record.Execution
+	record.Execution = &execution
+
+	timeout, err := time.ParseDuration(execution.Timeout)
+	if err != nil {
+		return fmt.Errorf("invalid remediation timeout %q: %w", execution.Timeout, err)
+	}
+	status, reason, err := a.waitRemediationJob(ctx, payload.Namespace, execution.JobName, record.AppliedAt.Add(timeout+remediationJobGrace))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	execution.Status, execution.Reason, execution.FinishedAt = status, reason, &now
+
+	if err := a.storeRemediationLogs(ctx, payload.Namespace, payload.PodName, record); err != nil {
+		logger.Get().Error("Failed to store remediation job logs", "job", execution.JobName, "namespace", payload.Namespace, "error", err)
+	}
+
+	if status != ExecutionStatusSucceeded {
+		record.Verification = VerificationUnknown
+		record.Details = "remediation job failed: " + reason
+		if err := a.store.UpdateRemediation(ctx, payload.Namespace, payload.PodName, record); err != nil {
+			return fmt.Errorf("failed to store remediation job outcome: %w", err)
+		}
+		return nil
+	}
+
+	if err := a.store.UpdateRemediation(ctx, payload.Namespace, payload.PodName, record); err != nil {
+		return fmt.Errorf("failed to store remediation job outcome: %w", err)
+	}
+	doc, err := a.store.Get(ctx, payload.Namespace, payload.PodName)
+	if err != nil {
+		return fmt.Errorf("failed to load alert: %w", err)
+	}
+	if doc != nil && doc.Alert.State != AlertStateRemediated {
+		alert := doc.Alert
+		alert.Remediation = record.Remediation
+		a.transition(ctx, &alert, AlertStateRemediated)
+	}
+
+	delay, err := time.ParseDuration(payload.VerifyAfter)
+	if err != nil {
+		delay = verifyDelay()
+	}
+	if _, err := a.scheduleVerification(ctx, payload.Namespace, payload.PodName, record, delay, ""); err != nil {
+		return fmt.Errorf("failed to schedule remediation verification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kagent-dev/tools/internal/blobs"
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func jobStatusJSON(conditionType, reason string) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []map[string]string{{"type": conditionType, "status": "True", "reason": reason}},
+		},
+	})
+	return string(data)
+}
+
+func TestRemediationJobManifest(t *testing.T) {
+	execution := RemediationExecution{
+		JobName:        "kagent-remediation-restart-deployment-0a1b2c3d",
+		Namespace:      "prod",
+		ServiceAccount: "kagent-remediation",
+		Image:          defaultRemediationImage,
+		Template:       "restart-deployment",
+		Script:         "kubectl rollout restart deployment/web -n prod",
+	}
+	data, err := json.Marshal(remediationJobManifest(execution, "web-1", 5*time.Minute))
+	require.NoError(t, err)
+
+	var job struct {
+		Metadata struct {
+			Name        string            `json:"name"`
+			Namespace   string            `json:"namespace"`
+			Labels      map[string]string `json:"labels"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Spec struct {
+			BackoffLimit          int `json:"backoffLimit"`
+			ActiveDeadlineSeconds int `json:"activeDeadlineSeconds"`
+			Template              struct {
+				Spec struct {
+					ServiceAccountName string `json:"serviceAccountName"`
+					RestartPolicy      string `json:"restartPolicy"`
+					Containers         []struct {
+						Image           string   `json:"image"`
+						Command         []string `json:"command"`
+						SecurityContext struct {
+							AllowPrivilegeEscalation bool `json:"allowPrivilegeEscalation"`
+							Capabilities             struct {
+								Drop []string `json:"drop"`
+							} `json:"capabilities"`
+						} `json:"securityContext"`
+					} `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	require.NoError(t, json.Unmarshal(data, &job))
+	assert.Equal(t, execution.JobName, job.Metadata.Name)
+	assert.Equal(t, "prod", job.Metadata.Namespace)
+	assert.Equal(t, "web-1", job.Metadata.Labels["kagent.dev/remediation-for"])
+	assert.Equal(t, "web-1", job.Metadata.Annotations["kagent.dev/remediation-for"])
+	assert.Equal(t, 0, job.Spec.BackoffLimit)
+	assert.Equal(t, 300, job.Spec.ActiveDeadlineSeconds)
+
+	pod := job.Spec.Template.Spec
+	assert.Equal(t, "kagent-remediation", pod.ServiceAccountName)
+	assert.Equal(t, "Never", pod.RestartPolicy)
+	require.Len(t, pod.Containers, 1)
+	assert.Equal(t, []string{"bash", "-c", execution.Script}, pod.Containers[0].Command)
+	assert.False(t, pod.Containers[0].SecurityContext.AllowPrivilegeEscalation)
+	assert.Equal(t, []string{"ALL"}, pod.Containers[0].SecurityContext.Capabilities.Drop)
+}
+
+func TestRemediationForLabel(t *testing.T) {
+	assert.Equal(t, "web-7d4b9c8f6d-x2k9p", remediationForLabel("web-7d4b9c8f6d-x2k9p"))
+	exact := strings.Repeat("a", 63)
+	assert.Equal(t, exact, remediationForLabel(exact))
+
+	// Names longer than a label value are cut and made unique by a hash
+	long := strings.Repeat("statefulset-with-a-very-long-name.", 7) + "0"
+	label := remediationForLabel(long)
+	assert.LessOrEqual(t, len(label), 63)
+	assert.Regexp(t, `^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`, label)
+	assert.True(t, strings.HasPrefix(label, "statefulset-with-a-very-long-name"))
+	assert.NotEqual(t, label, remediationForLabel(long[:len(long)-1]+"1"))
+
+	execution := RemediationExecution{JobName: "kagent-remediation-restart-pod-0a1b2c3d", Namespace: "prod", Template: "restart-pod"}
+	manifest := remediationJobManifest(execution, long, time.Minute)
+	metadata := manifest["metadata"].(map[string]interface{})
+	assert.Equal(t, label, metadata["labels"].(map[string]string)["kagent.dev/remediation-for"])
+	assert.Equal(t, long, metadata["annotations"].(map[string]string)["kagent.dev/remediation-for"])
+}
+
+func TestRemediationJobName(t *testing.T) {
+	name, err := remediationJobName("restart-deployment")
+	require.NoError(t, err)
+	assert.Regexp(t, `^kagent-remediation-restart-deployment-[0-9a-f]{8}$`, name)
+
+	name, err = remediationJobName("a-template-name-long-enough-to-exceed-the-limit-of-job-names")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(name), 63)
+}
+
+func TestHandleRunRemediation(t *testing.T) {
+	previous := blobs.Default()
+	blobs.SetDefault(blobs.NewMemoryStore())
+	defer blobs.SetDefault(previous)
+	previousInterval := jobPollInterval
+	jobPollInterval = time.Millisecond
+	defer func() { jobPollInterval = previousInterval }()
+
+	alert := PodAlert{PodName: "web-7d4b9c8f6d-x2k9p", Namespace: "prod", Reason: "CrashLoopBackOff", State: AlertStateAnalyzed}
+	run := func(t *testing.T, mock *cmd.MockShellExecutor, args map[string]interface{}) (*AlertTool, *mcp.CallToolResult) {
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+		tool := NewAlertTool(nil)
+		require.NoError(t, tool.store.Upsert(ctx, alert))
+		result, err := tool.handleRunRemediation(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+		require.NoError(t, err)
+		tool.waitJobs()
+		return tool, result
+	}
+	newMock := func(jobStatus string) *cmd.MockShellExecutor {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "serviceaccount", "kagent-remediation", "-n", "prod", "-o", "name"}, "serviceaccount/kagent-remediation", nil)
+		mock.AddCommandString("kubectl", []string{"get", "pod", alert.PodName, "-n", "prod", "-o", "json"}, podJSON("Running", true, 4), nil)
+		mock.AddPartialMatcherString("kubectl", []string{"create", "-f"}, "job.batch/created", nil)
+		mock.AddPartialMatcherString("kubectl", []string{"get", "job"}, jobStatus, nil)
+		mock.AddPartialMatcherString("kubectl", []string{"logs", "--all-containers"}, "restarting deployment/web\ndeployment.apps/web restarted\n", nil)
+		return mock
+	}
+	args := map[string]interface{}{"pod_name": alert.PodName, "namespace": "prod", "verify_after": "0s"}
+
+	t.Run("succeeded", func(t *testing.T) {
+		mock := newMock(jobStatusJSON("Complete", ""))
+		tool, result := run(t, mock, args)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+		doc, err := tool.store.Get(context.Background(), "prod", alert.PodName)
+		require.NoError(t, err)
+		assert.Equal(t, AlertStateRemediated, doc.Alert.State)
+		require.Len(t, doc.Remediations, 1)
+		record := doc.Remediations[0]
+		assert.Equal(t, VerificationResolved, record.Verification)
+		assert.Equal(t, int32(4), record.BaselineRestarts)
+
+		execution := record.Execution
+		require.NotNil(t, execution)
+		assert.Equal(t, ExecutionModeDelegatedJob, execution.Mode)
+		assert.Equal(t, ExecutionStatusSucceeded, execution.Status)
+		assert.Equal(t, "restart-deployment", execution.Template)
+		assert.Equal(t, "kagent-remediation", execution.ServiceAccount)
+		assert.Contains(t, execution.Script, "kubectl rollout restart deployment/web -n prod")
+		assert.Equal(t, []string{"restarting deployment/web", "deployment.apps/web restarted"}, execution.LogTail)
+		require.NotEmpty(t, execution.LogsURI)
+
+		bucket, id, err := blobs.ParseURI(execution.LogsURI)
+		require.NoError(t, err)
+		var metadata RemediationLogs
+		logs, err := blobs.Default().Read(context.Background(), bucket, id, &metadata)
+		require.NoError(t, err)
+		assert.Contains(t, string(logs), "deployment.apps/web restarted")
+		assert.Equal(t, execution.JobName, metadata.JobName)
+		assert.Equal(t, record.ID, metadata.RemediationID)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock := newMock(jobStatusJSON("Failed", "BackoffLimitExceeded"))
+		tool, result := run(t, mock, args)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+		doc, err := tool.store.Get(context.Background(), "prod", alert.PodName)
+		require.NoError(t, err)
+		assert.Equal(t, AlertStateAnalyzed, doc.Alert.State)
+		require.Len(t, doc.Remediations, 1)
+		record := doc.Remediations[0]
+		assert.Equal(t, VerificationUnknown, record.Verification)
+		assert.Contains(t, record.Details, "BackoffLimitExceeded")
+		assert.Equal(t, ExecutionStatusFailed, record.Execution.Status)
+		assert.NotEmpty(t, record.Execution.LogsURI)
+	})
+
+	t.Run("missing service account", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "serviceaccount", "kagent-remediation", "-n", "prod", "-o", "name"}, "", errors.New("NotFound"))
+		tool, result := run(t, mock, args)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, AlertRemediationServiceAccount)
+
+		doc, err := tool.store.Get(context.Background(), "prod", alert.PodName)
+		require.NoError(t, err)
+		assert.Empty(t, doc.Remediations)
+		for _, call := range mock.GetCallLog() {
+			assert.NotEqual(t, "create", call.Args[0])
+		}
+	})
+
+	t.Run("no applicable template", func(t *testing.T) {
+		_, result := run(t, cmd.NewMockShellExecutor(), map[string]interface{}{"pod_name": alert.PodName, "namespace": "prod", "template": "rollback-helm-release"})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "requires parameter release")
+	})
+
+	t.Run("namespace override", func(t *testing.T) {
+		for _, template := range []string{"", "restart-deployment"} {
+			mock := cmd.NewMockShellExecutor()
+			_, result := run(t, mock, map[string]interface{}{
+				"pod_name":   alert.PodName,
+				"namespace":  "prod",
+				"template":   template,
+				"parameters": `{"namespace": "kube-system", "deployment": "coredns"}`,
+			})
+			require.True(t, result.IsError, template)
+			assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "namespace of the alert")
+			assert.Empty(t, mock.GetCallLog())
+		}
+
+		// Naming the alert's own namespace is allowed
+		mock := newMock(jobStatusJSON("Complete", ""))
+		_, result := run(t, mock, map[string]interface{}{"pod_name": alert.PodName, "namespace": "prod", "verify_after": "0s", "parameters": `{"namespace": "prod"}`})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("timeout too long", func(t *testing.T) {
+		_, result := run(t, cmd.NewMockShellExecutor(), map[string]interface{}{"pod_name": alert.PodName, "namespace": "prod", "timeout": "2h"})
+		require.True(t, result.IsError)
+	})
+}
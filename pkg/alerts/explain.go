@@ -0,0 +1,633 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// Sources of an error explanation
+const (
+	ExplanationSourceKnowledgeBase = "knowledge_base"
+	ExplanationSourceLLM           = "llm"
+)
+
+// Confidence levels of an error explanation
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// Categories of the knowledge base patterns
+const (
+	ErrorCategoryKubectl   = "kubectl"
+	ErrorCategoryEnvoy     = "envoy"
+	ErrorCategoryContainer = "container"
+	ErrorCategoryNetwork   = "network"
+)
+
+// maxExplainedErrorLength is the length of the error text matched and sent to the LLM
+const maxExplainedErrorLength = 8000
+
+// Retrieval of prior resolved incidents
+const (
+	relatedIncidentLimit         = 3
+	relatedIncidentMinSimilarity = 0.3
+)
+
+// ErrorPattern is a curated knowledge base entry. Cause and next steps may
+// reference the pattern's named groups as $name.
+type ErrorPattern struct {
+	Name       string   `json:"name"`
+	Category   string   `json:"category"`
+	Cause      string   `json:"cause"`
+	Confidence string   `json:"confidence"`
+	NextSteps  []string `json:"next_steps"`
+
+	pattern *regexp.Regexp
+}
+
+// RelatedIncident is a prior alert resolved by a verified remediation, similar to the explained error
+type RelatedIncident struct {
+	ID            string   `json:"id"`
+	Cluster       string   `json:"cluster,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
+	RootCause     string   `json:"root_cause,omitempty"`
+	Remediation   string   `json:"remediation"`
+	Effectiveness *float64 `json:"effectiveness,omitempty"`
+	Similarity    float64  `json:"similarity"`
+}
+
+// ErrorExplanation is the structured response of alerts_explain_error
+type ErrorExplanation struct {
+	Source           string            `json:"source"`
+	Pattern          string            `json:"pattern,omitempty"`
+	Category         string            `json:"category,omitempty"`
+	Cause            string            `json:"cause"`
+	Confidence       string            `json:"confidence"`
+	NextSteps        []string          `json:"next_steps"`
+	AlsoMatched      []string          `json:"also_matched,omitempty"`
+	RelatedIncidents []RelatedIncident `json:"related_incidents,omitempty"`
+	// Analysis is the validated LLM response the explanation was taken from
+	Analysis *AnalysisResult `json:"analysis,omitempty"`
+}
+
+// errorPatterns is the curated knowledge base, in matching priority order:
+// patterns naming a specific cause come before the generic symptoms they also match
+var errorPatterns = []ErrorPattern{
+	{
+		Name:       "api-server-connection-refused",
+		Category:   ErrorCategoryKubectl,
+		Cause:      "The Kubernetes API server at $server refused the connection: it is down or restarting, or the kubeconfig points at the wrong address",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Check the current context and its server address with kubectl config view --minify",
+			"Check the control plane with k8s_control_plane_health, or the API server pods or service on the control plane nodes",
+			"Check that a VPN or tunnel required to reach the cluster is up",
+		},
+		pattern: regexp.MustCompile(`(?i)the connection to the server (?P<server>\S+?) was refused`),
+	},
+	{
+		Name:       "unauthorized",
+		Category:   ErrorCategoryKubectl,
+		Cause:      "The API server rejected the credentials: the token or client certificate in the kubeconfig expired or is invalid",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Refresh the credentials, e.g. re-run the cloud provider's get-credentials command or log in again",
+			"Check the expiry of the client certificate or token of the current kubeconfig user",
+		},
+		pattern: regexp.MustCompile(`(?i)must be logged in to the server|\(Unauthorized\)`),
+	},
+	{
+		Name:       "rbac-forbidden",
+		Category:   ErrorCategoryKubectl,
+		Cause:      `User "$user" lacks the RBAC permission to $verb $resource`,
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Confirm the missing permission with k8s_can_i",
+			"Grant it with a Role and RoleBinding, or a ClusterRole and ClusterRoleBinding for cluster-scoped resources",
+		},
+		pattern: regexp.MustCompile(`(?i)is forbidden: User "(?P<user>[^"]+)" cannot (?P<verb>\S+) resource "(?P<resource>[^"]+)"`),
+	},
+	{
+		Name:       "admission-webhook-denied",
+		Category:   ErrorCategoryKubectl,
+		Cause:      "The admission webhook $webhook rejected the object: $reason",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Read the policy enforced by the webhook (e.g. Gatekeeper constraints or Kyverno policies) and change the manifest to comply",
+			"Dry-run the change with kubectl apply --dry-run=server to check it passes admission",
+		},
+		pattern: regexp.MustCompile(`(?i)admission webhook "(?P<webhook>[^"]+)" denied the request:?\s*(?P<reason>[^\n]*)`),
+	},
+	{
+		Name:       "admission-webhook-unreachable",
+		Category:   ErrorCategoryKubectl,
+		Cause:      "The API server could not call the admission webhook $webhook; with failurePolicy Fail, requests are rejected while it is unavailable",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Check the pods and endpoints of the webhook's service",
+			"Check the webhook's caBundle matches its serving certificate",
+			"As a last resort during an outage, set the webhook configuration's failurePolicy to Ignore",
+		},
+		pattern: regexp.MustCompile(`(?i)failed calling webhook "(?P<webhook>[^"]+)"`),
+	},
+	{
+		Name:       "quota-exceeded",
+		Category:   ErrorCategoryKubectl,
+		Cause:      "The change exceeds the ResourceQuota $quota (requested $requested)",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Check the quota's usage and headroom with k8s_quota_check",
+			"Lower the requests or replicas of the change, free capacity in the namespace, or raise the quota",
+		},
+		pattern: regexp.MustCompile(`(?i)exceeded quota: (?P<quota>[^,]+), requested: (?P<requested>[^,]+)`),
+	},
+	{
+		Name:       "immutable-field",
+		Category:   ErrorCategoryKubectl,
+		Cause:      "The update changes a field that cannot be changed after creation, such as a Deployment's selector, a Job's template or a Service's clusterIP",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Diff the manifest against the live object with kubectl diff to find the changed field",
+			"Revert the field, or delete and recreate the object (kubectl replace --force) if the change is intended",
+		},
+		pattern: regexp.MustCompile(`(?i)field is immutable`),
+	},
+	{
+		Name:       "kind-not-registered",
+		Category:   ErrorCategoryKubectl,
+		Cause:      `The API server does not serve kind $kind in $version: its CRD is not installed or the apiVersion is wrong`,
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"List the served versions with kubectl api-resources",
+			"Install the CRD, or install the operator before the resources that use it",
+		},
+		pattern: regexp.MustCompile(`(?i)no matches for kind "(?P<kind>[^"]+)" in version "(?P<version>[^"]+)"`),
+	},
+	{
+		Name:       "resource-type-unknown",
+		Category:   ErrorCategoryKubectl,
+		Cause:      `The API server does not serve the resource type $resource: its CRD is not installed or the name is misspelled`,
+		Confidence: ConfidenceHigh,
+		NextSteps:  []string{"List the served resource types with kubectl api-resources"},
+		pattern:    regexp.MustCompile(`(?i)the server doesn't have a resource type "(?P<resource>[^"]+)"`),
+	},
+	{
+		Name:       "certificate-error",
+		Category:   ErrorCategoryNetwork,
+		Cause:      "TLS verification failed because the certificate $problem",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Inspect the certificate with openssl s_client -connect <host>:<port> -showcerts",
+			"Renew expired certificates, or add the issuing CA to the client's trusted CAs",
+		},
+		pattern: regexp.MustCompile(`(?i)x509: certificate (?P<problem>signed by unknown authority|has expired or is not yet valid|is valid for [^\n]+?, not \S+)`),
+	},
+	{
+		Name:       "object-not-found",
+		Category:   ErrorCategoryKubectl,
+		Cause:      "$resource $name does not exist in the queried namespace and context",
+		Confidence: ConfidenceMedium,
+		NextSteps: []string{
+			"Check the namespace (-n) and the current context",
+			"List the objects of that type with kubectl get <type> -A to find where it lives",
+		},
+		pattern: regexp.MustCompile(`\(NotFound\): (?P<resource>\S+) "(?P<name>[^"]+)" not found`),
+	},
+	{
+		Name:       "update-conflict",
+		Category:   ErrorCategoryKubectl,
+		Cause:      "The object was modified between reading and writing it, so the update was rejected by optimistic concurrency control",
+		Confidence: ConfidenceHigh,
+		NextSteps:  []string{"Re-read the object and retry the update, or use server-side apply"},
+		pattern:    regexp.MustCompile(`(?i)the object has been modified; please apply your changes to the latest version`),
+	},
+	{
+		Name:       "envoy-upstream-tls",
+		Category:   ErrorCategoryEnvoy,
+		Cause:      "Envoy failed the TLS handshake with the upstream, usually an mTLS mismatch: one side requires mutual TLS and the other sends plaintext or a certificate it does not trust",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Check the PeerAuthentication and DestinationRule TLS modes of the destination with istio_analyze_cluster_configuration",
+			"Check that both workloads have sidecars injected, and the destination's certificate with istio_proxy_config (config_type secret)",
+		},
+		pattern: regexp.MustCompile(`(?i)transport failure reason:\s*TLS.error`),
+	},
+	{
+		Name:       "envoy-circuit-breaker",
+		Category:   ErrorCategoryEnvoy,
+		Cause:      "Envoy's circuit breaker for the upstream tripped: its connection or pending request limits were reached",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Check the connectionPool settings of the destination's DestinationRule",
+			"Check whether the upstream is slow, causing requests to queue, before raising the limits",
+		},
+		pattern: regexp.MustCompile(`(?i)reset reason:\s*overflow|upstream_rq_pending_overflow`),
+	},
+	{
+		Name:       "envoy-upstream-connection-failure",
+		Category:   ErrorCategoryEnvoy,
+		Cause:      "Envoy could not connect to the upstream: nothing listens on the target port, the pod is not ready, or a network policy blocks the traffic",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Check the service's endpoints and that its targetPort matches the container port",
+			"Check the destination pods are ready and listening, and that no NetworkPolicy blocks the traffic (k8s_networkpolicy_check)",
+		},
+		pattern: regexp.MustCompile(`(?i)reset reason:\s*(remote )?connection failure`),
+	},
+	{
+		Name:       "envoy-upstream-connection-termination",
+		Category:   ErrorCategoryEnvoy,
+		Cause:      "The upstream closed the connection before responding: the application crashed or restarted, or closed an idle keep-alive connection Envoy reused",
+		Confidence: ConfidenceMedium,
+		NextSteps: []string{
+			"Check the destination pods for restarts and errors at the time of the failures",
+			"Make the application's keep-alive timeout longer than Envoy's idle timeout, or configure retries on reset",
+		},
+		pattern: regexp.MustCompile(`(?i)reset reason:\s*connection termination`),
+	},
+	{
+		Name:       "envoy-no-healthy-upstream",
+		Category:   ErrorCategoryEnvoy,
+		Cause:      "Envoy has no healthy endpoint for the upstream cluster: the service has no ready pods, or outlier detection ejected all of them",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Check the service has ready endpoints with kubectl get endpoints",
+			"Check the endpoints Envoy sees and their health with istio_proxy_config (config_type endpoint)",
+			"Check the destination pods' readiness probes and the outlierDetection settings of its DestinationRule",
+		},
+		pattern: regexp.MustCompile(`(?i)no healthy upstream`),
+	},
+	{
+		Name:       "envoy-upstream-timeout",
+		Category:   ErrorCategoryEnvoy,
+		Cause:      "The upstream did not respond within the route timeout (15s by default in Envoy)",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Check the latency of the destination service, e.g. with prometheus_slo_query",
+			"Raise the timeout of the VirtualService route if the slow requests are expected",
+		},
+		pattern: regexp.MustCompile(`(?i)upstream request timeout`),
+	},
+	{
+		Name:       "envoy-rbac-denied",
+		Category:   ErrorCategoryEnvoy,
+		Cause:      "An Istio AuthorizationPolicy denied the request",
+		Confidence: ConfidenceHigh,
+		NextSteps:  []string{"Check the AuthorizationPolicies of the destination workload and namespace, and the source's service account identity"},
+		pattern:    regexp.MustCompile(`RBAC: access denied`),
+	},
+	{
+		Name:       "envoy-route-not-found",
+		Category:   ErrorCategoryEnvoy,
+		Cause:      "No Envoy route matched the request's host and path",
+		Confidence: ConfidenceMedium,
+		NextSteps:  []string{"Check the hosts and match rules of the VirtualService or HTTPRoute, and the routes Envoy received with istio_proxy_config (config_type route)"},
+		pattern:    regexp.MustCompile(`(?i)route_not_found|\bNR\b.*\b404\b|\b404\b.*\bNR\b`),
+	},
+	{
+		Name:       "oom-killed",
+		Category:   ErrorCategoryContainer,
+		Cause:      "The container exceeded its memory limit and was killed by the kernel",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Compare the container's memory usage with its limit, e.g. with prometheus_slo_query saturation",
+			"Raise the memory limit (alerts_generate_remediation_script with bump-memory-limit) or fix the memory growth",
+		},
+		pattern: regexp.MustCompile(`(?i)OOMKilled|exit code:?\s*137\b|out of memory`),
+	},
+	{
+		Name:       "exec-format-error",
+		Category:   ErrorCategoryContainer,
+		Cause:      "The image was built for a different CPU architecture than the node",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Check the platforms of the image with k8s_image_inspect",
+			"Build a multi-platform image, or schedule the pod on nodes of the image's architecture with a kubernetes.io/arch node selector",
+		},
+		pattern: regexp.MustCompile(`(?i)exec format error`),
+	},
+	{
+		Name:       "executable-not-found",
+		Category:   ErrorCategoryContainer,
+		Cause:      "The container's command $command does not exist in the image",
+		Confidence: ConfidenceHigh,
+		NextSteps:  []string{"Check the command and args of the container against the image's entrypoint (k8s_image_inspect)"},
+		pattern:    regexp.MustCompile(`(?i)exec: "(?P<command>[^"]+)": executable file not found`),
+	},
+	{
+		Name:       "missing-config-reference",
+		Category:   ErrorCategoryContainer,
+		Cause:      "The pod references the $kind $name, which does not exist, so its containers cannot be created",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Find every missing reference of the workload with k8s_check_references",
+			"Create the missing object in the pod's namespace, or fix the reference",
+		},
+		pattern: regexp.MustCompile(`(?i)(?P<kind>configmap|secret) "(?P<name>[^"]+)" not found`),
+	},
+	{
+		Name:       "image-pull-failure",
+		Category:   ErrorCategoryContainer,
+		Cause:      "The node could not pull the container image: the reference is wrong, the tag does not exist, or the registry requires credentials",
+		Confidence: ConfidenceMedium,
+		NextSteps:  []string{"Find the precise cause with k8s_diagnose_image_pull"},
+		pattern:    regexp.MustCompile(`(?i)ErrImagePull|ImagePullBackOff|manifest unknown|pull access denied`),
+	},
+	{
+		Name:       "liveness-probe-failure",
+		Category:   ErrorCategoryContainer,
+		Cause:      "The liveness probe failed, so the kubelet restarts the container: the application hangs, starts slower than the probe allows, or the probe is misconfigured",
+		Confidence: ConfidenceMedium,
+		NextSteps: []string{
+			"Check the probe's path, port and timeouts against the application",
+			"Add a startupProbe or raise initialDelaySeconds if the application starts slowly",
+		},
+		pattern: regexp.MustCompile(`(?i)liveness probe failed`),
+	},
+	{
+		Name:       "readiness-probe-failure",
+		Category:   ErrorCategoryContainer,
+		Cause:      "The readiness probe failed, so the pod is removed from its services' endpoints until it passes again",
+		Confidence: ConfidenceMedium,
+		NextSteps:  []string{"Check the application's health and the dependencies its readiness endpoint checks"},
+		pattern:    regexp.MustCompile(`(?i)readiness probe failed`),
+	},
+	{
+		Name:       "no-space-left",
+		Category:   ErrorCategoryContainer,
+		Cause:      "A filesystem the container writes to is full: a volume, the container's writable layer or the node's disk",
+		Confidence: ConfidenceHigh,
+		NextSteps: []string{
+			"Check the pod's volumes with k8s_storage_diagnose and the node's DiskPressure condition",
+			"Expand the volume, clean up old data, or set an ephemeral-storage limit",
+		},
+		pattern: regexp.MustCompile(`(?i)no space left on device`),
+	},
+	{
+		Name:       "too-many-open-files",
+		Category:   ErrorCategoryContainer,
+		Cause:      "The process reached its open file descriptor limit, often because connections or files are leaked",
+		Confidence: ConfidenceMedium,
+		NextSteps:  []string{"Check the process for leaked connections or files, then raise the node's or runtime's nofile limit if the usage is legitimate"},
+		pattern:    regexp.MustCompile(`(?i)too many open files`),
+	},
+	{
+		Name:       "dns-resolution-failure",
+		Category:   ErrorCategoryNetwork,
+		Cause:      "A hostname could not be resolved: it is misspelled, in another namespace without its namespace suffix, or cluster DNS is failing",
+		Confidence: ConfidenceMedium,
+		NextSteps: []string{
+			"Check the hostname, using <service>.<namespace>.svc.cluster.local for services in other namespaces",
+			"Check the CoreDNS pods and logs in kube-system",
+		},
+		pattern: regexp.MustCompile(`(?i)no such host|server misbehaving|temporary failure in name resolution`),
+	},
+	{
+		Name:       "permission-denied",
+		Category:   ErrorCategoryContainer,
+		Cause:      "The process lacks file system permissions, typically because it runs as a non-root user, with a read-only root filesystem, or on a volume owned by another group",
+		Confidence: ConfidenceMedium,
+		NextSteps: []string{
+			"Check the pod's securityContext (runAsUser, fsGroup, readOnlyRootFilesystem) with k8s_hardening_check",
+			"Mount a writable emptyDir at the path, or set fsGroup so the volume is writable",
+		},
+		pattern: regexp.MustCompile(`(?i)permission denied`),
+	},
+	{
+		Name:       "connection-refused",
+		Category:   ErrorCategoryNetwork,
+		Cause:      "Nothing listens on the target address and port, e.g. the destination is not ready yet or the port is wrong",
+		Confidence: ConfidenceMedium,
+		NextSteps:  []string{"Check the destination service's endpoints and ports, and that the application listens on all interfaces rather than localhost"},
+		pattern:    regexp.MustCompile(`(?i)connection refused`),
+	},
+	{
+		Name:       "network-timeout",
+		Category:   ErrorCategoryNetwork,
+		Cause:      "A network call timed out: the destination is overloaded or unreachable, or traffic is dropped by a NetworkPolicy or firewall",
+		Confidence: ConfidenceLow,
+		NextSteps:  []string{"Check connectivity to the destination and any NetworkPolicies on the path (k8s_networkpolicy_check)"},
+		pattern:    regexp.MustCompile(`(?i)i/o timeout|context deadline exceeded|TLS handshake timeout`),
+	},
+	{
+		Name:       "crash-loop",
+		Category:   ErrorCategoryContainer,
+		Cause:      "The container keeps exiting after it starts and the kubelet backs off restarting it; the cause is in its previous logs or exit code",
+		Confidence: ConfidenceLow,
+		NextSteps: []string{
+			"Read the previous container's logs with kubectl logs --previous",
+			"Check the exit code and reason of the last termination with alerts_get_pod_alert_details",
+		},
+		pattern: regexp.MustCompile(`(?i)CrashLoopBackOff|back-off restarting failed container`),
+	},
+}
+
+// matchErrorPatterns returns the explanation of the first matching pattern and
+// the names of the other patterns the error matches, or nil if none matches
+func matchErrorPatterns(text string) *ErrorExplanation {
+	var explanation *ErrorExplanation
+	for _, entry := range errorPatterns {
+		match := entry.pattern.FindStringSubmatchIndex(text)
+		if match == nil {
+			continue
+		}
+		if explanation != nil {
+			explanation.AlsoMatched = append(explanation.AlsoMatched, entry.Name)
+			continue
+		}
+		expand := func(template string) string {
+			return strings.TrimSpace(string(entry.pattern.ExpandString(nil, template, text, match)))
+		}
+		explanation = &ErrorExplanation{
+			Source:     ExplanationSourceKnowledgeBase,
+			Pattern:    entry.Name,
+			Category:   entry.Category,
+			Cause:      expand(entry.Cause),
+			Confidence: entry.Confidence,
+			NextSteps:  make([]string, len(entry.NextSteps)),
+		}
+		for i, step := range entry.NextSteps {
+			explanation.NextSteps[i] = expand(step)
+		}
+	}
+	return explanation
+}
+
+// errorTerms returns the distinct terms of a text, ignoring the timestamps, IDs
+// and numbers that vary between occurrences of an error
+func errorTerms(text string) map[string]bool {
+	terms := map[string]bool{}
+	for _, term := range strings.FieldsFunc(strings.ToLower(logSignature(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len(term) > 2 {
+			terms[term] = true
+		}
+	}
+	return terms
+}
+
+// resolvedRemediation returns the most effective remediation of a document
+// verified as resolved, or nil if none resolved the alert
+func resolvedRemediation(doc AlertDocument) *RemediationRecord {
+	var best *RemediationRecord
+	for i := range doc.Remediations {
+		record := &doc.Remediations[i]
+		if record.Verification != VerificationResolved {
+			continue
+		}
+		if best == nil || (record.Effectiveness != nil && (best.Effectiveness == nil || *record.Effectiveness > *best.Effectiveness)) {
+			best = record
+		}
+	}
+	return best
+}
+
+// relatedIncidents returns the resolved alerts whose text shares the most terms with the error
+func relatedIncidents(docs []AlertDocument, text string) []RelatedIncident {
+	query := errorTerms(text)
+	if len(query) == 0 {
+		return nil
+	}
+
+	var incidents []RelatedIncident
+	for _, doc := range docs {
+		record := resolvedRemediation(doc)
+		if record == nil {
+			continue
+		}
+		incidentText := alertText(doc.Alert) + "\n" + exceptionText(doc.Alert.Exceptions) + "\n" + strings.Join(doc.Alert.Logs, "\n")
+		terms := errorTerms(incidentText)
+		shared := 0
+		for term := range query {
+			if terms[term] {
+				shared++
+			}
+		}
+		similarity := float64(shared) / float64(len(query))
+		if similarity < relatedIncidentMinSimilarity {
+			continue
+		}
+
+		incident := RelatedIncident{
+			ID:            alertKey(doc.Alert.Namespace, doc.Alert.PodName),
+			Cluster:       alertCluster(doc.Alert),
+			Reason:        doc.Alert.Reason,
+			Remediation:   record.Remediation,
+			Effectiveness: record.Effectiveness,
+			Similarity:    math.Round(similarity*100) / 100,
+		}
+		if doc.Alert.AnalysisResult != nil && doc.Alert.AnalysisResult.Validation.Valid {
+			incident.RootCause, _ = doc.Alert.AnalysisResult.Data["root_cause"].(string)
+		}
+		incidents = append(incidents, incident)
+	}
+
+	sort.SliceStable(incidents, func(i, j int) bool { return incidents[i].Similarity > incidents[j].Similarity })
+	if len(incidents) > relatedIncidentLimit {
+		incidents = incidents[:relatedIncidentLimit]
+	}
+	return incidents
+}
+
+// formatRelatedIncidents renders related incidents for an analysis prompt
+func formatRelatedIncidents(incidents []RelatedIncident) string {
+	if len(incidents) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\nSimilar incidents resolved before (prefer their verified remediations when they apply):\n")
+	for _, incident := range incidents {
+		b.WriteString(fmt.Sprintf("- %s (%s): ", incident.ID, incident.Reason))
+		if incident.RootCause != "" {
+			b.WriteString(fmt.Sprintf("root cause %q, ", incident.RootCause))
+		}
+		b.WriteString(fmt.Sprintf("resolved by %q\n", incident.Remediation))
+	}
+	return b.String()
+}
+
+// explainWithLLM asks the LLM to explain an error the knowledge base does not cover
+func (a *AlertTool) explainWithLLM(ctx context.Context, text string, incidents []RelatedIncident) (*ErrorExplanation, error) {
+	if err := a.llmUnavailable(ctx); err != nil {
+		return nil, fmt.Errorf("no knowledge base pattern matches the error and %w", err)
+	}
+
+	prompt := fmt.Sprintf(`Explain this error from a Kubernetes environment (kubectl, Envoy or a container):
+
+%s
+%s%s
+Give its most likely cause, how confident you are given the information available, and the next steps to confirm and fix it.`,
+		text, formatRelatedIncidents(incidents), a.runbookContext(ctx, text))
+
+	analysis, err := a.generateStructuredAnalysis(ctx, AnalysisTypeErrorExplanation, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := &ErrorExplanation{Source: ExplanationSourceLLM, Analysis: analysis, NextSteps: []string{}}
+	if !analysis.Validation.Valid {
+		explanation.Cause = analysis.Raw
+		explanation.Confidence = ConfidenceLow
+		return explanation, nil
+	}
+	explanation.Cause, _ = analysis.Data["cause"].(string)
+	explanation.Confidence, _ = analysis.Data["confidence"].(string)
+	explanation.Category, _ = analysis.Data["category"].(string)
+	steps, _ := analysis.Data["next_steps"].([]interface{})
+	for _, step := range steps {
+		if step, ok := step.(string); ok {
+			explanation.NextSteps = append(explanation.NextSteps, step)
+		}
+	}
+	return explanation, nil
+}
+
+// handleExplainError explains an error string from the curated knowledge base,
+// falling back to the LLM grounded in similar resolved incidents
+func (a *AlertTool) handleExplainError(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	text := strings.TrimSpace(p.String("error", "", params.Required()))
+	namespace := p.String("namespace", "")
+	cluster := p.String("cluster", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(text) > maxExplainedErrorLength {
+		text = text[:maxExplainedErrorLength]
+	}
+
+	docs, err := a.store.List(ctx, namespace)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list alerts: %v", err)), nil
+	}
+	incidents := relatedIncidents(filterCluster(docs, cluster), text)
+
+	explanation := matchErrorPatterns(text)
+	if explanation == nil {
+		explanation, err = a.explainWithLLM(ctx, text, incidents)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to explain error: %v", err)), nil
+		}
+	}
+	explanation.RelatedIncidents = incidents
+
+	explanationJSON, err := json.MarshalIndent(explanation, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal explanation: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(explanationJSON)), nil
+}
@@ -0,0 +1,229 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/kagent-dev/tools/internal/budget"
+)
+
+func TestMatchErrorPatterns(t *testing.T) {
+	tests := []struct {
+		error      string
+		pattern    string
+		cause      string
+		confidence string
+	}{
+		{
+			error:      "The connection to the server 10.0.0.1:6443 was refused - did you specify the right host or port?",
+			pattern:    "api-server-connection-refused",
+			cause:      "The Kubernetes API server at 10.0.0.1:6443 refused the connection: it is down or restarting, or the kubeconfig points at the wrong address",
+			confidence: ConfidenceHigh,
+		},
+		{
+			error:      `Error from server (Forbidden): pods is forbidden: User "jane" cannot list resource "pods" in API group "" in the namespace "prod"`,
+			pattern:    "rbac-forbidden",
+			cause:      `User "jane" lacks the RBAC permission to list pods`,
+			confidence: ConfidenceHigh,
+		},
+		{
+			error:   `Error from server (Forbidden): error when creating "web.yaml": admission webhook "validation.gatekeeper.sh" denied the request: [require-labels] missing label team`,
+			pattern: "admission-webhook-denied",
+			cause:   "The admission webhook validation.gatekeeper.sh rejected the object: [require-labels] missing label team",
+		},
+		{
+			error:   "upstream connect error or disconnect/reset before headers. retried and the latest reset reason: connection failure, transport failure reason: TLS_error: CERTIFICATE_VERIFY_FAILED",
+			pattern: "envoy-upstream-tls",
+		},
+		{
+			error:   "upstream connect error or disconnect/reset before headers. reset reason: connection termination",
+			pattern: "envoy-upstream-connection-termination",
+		},
+		{
+			error:   `Error: failed to create containerd task: exec: "/app/server": executable file not found in $PATH`,
+			pattern: "executable-not-found",
+			cause:   "The container's command /app/server does not exist in the image",
+		},
+		{
+			error:   `Error: secret "db-credentials" not found`,
+			pattern: "missing-config-reference",
+			cause:   "The pod references the secret db-credentials, which does not exist, so its containers cannot be created",
+		},
+		{
+			error:      "Back-off restarting failed container app in pod web-7d4b9c8f6d-x2x9z",
+			pattern:    "crash-loop",
+			confidence: ConfidenceLow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			explanation := matchErrorPatterns(tt.error)
+			require.NotNil(t, explanation)
+			assert.Equal(t, ExplanationSourceKnowledgeBase, explanation.Source)
+			assert.Equal(t, tt.pattern, explanation.Pattern)
+			assert.NotEmpty(t, explanation.NextSteps)
+			if tt.cause != "" {
+				assert.Equal(t, tt.cause, explanation.Cause)
+			}
+			if tt.confidence != "" {
+				assert.Equal(t, tt.confidence, explanation.Confidence)
+			}
+		})
+	}
+
+	// Specific causes take priority over the symptoms they also match
+	explanation := matchErrorPatterns("Last State: Terminated, Reason: OOMKilled, Exit Code: 137; pod is in CrashLoopBackOff")
+	require.NotNil(t, explanation)
+	assert.Equal(t, "oom-killed", explanation.Pattern)
+	assert.Equal(t, []string{"crash-loop"}, explanation.AlsoMatched)
+
+	assert.Nil(t, matchErrorPatterns("panic: runtime error: invalid memory address or nil pointer dereference"))
+}
+
+// groupReference matches the $name references expanded in causes and next steps
+var groupReference = regexp.MustCompile(`\$(\w+)`)
+
+func TestErrorPatternsExpandTheirGroups(t *testing.T) {
+	for _, entry := range errorPatterns {
+		names := map[string]bool{}
+		for _, name := range entry.pattern.SubexpNames() {
+			names[name] = true
+		}
+		for _, text := range append([]string{entry.Cause}, entry.NextSteps...) {
+			for _, match := range groupReference.FindAllStringSubmatch(text, -1) {
+				assert.True(t, names[match[1]], "pattern %s references unknown group %s", entry.Name, match[1])
+			}
+		}
+	}
+}
+
+func resolvedAlert(namespace, podName, reason, message, remediation string, effectiveness float64) AlertDocument {
+	return AlertDocument{
+		Alert: PodAlert{Namespace: namespace, PodName: podName, Reason: reason, Message: message},
+		Remediations: []RemediationRecord{
+			{Remediation: "restarted the pod", Verification: VerificationRecurred},
+			{Remediation: remediation, Verification: VerificationResolved, Effectiveness: &effectiveness},
+		},
+	}
+}
+
+func TestRelatedIncidents(t *testing.T) {
+	docs := []AlertDocument{
+		resolvedAlert("prod", "api-1", "Error", "panic: runtime error: invalid memory address or nil pointer dereference in handler", "rolled back to v1.4.2", 1),
+		resolvedAlert("prod", "db-0", "OOMKilled", "container exceeded its memory limit", "raised the memory limit", 1),
+		{Alert: PodAlert{Namespace: "prod", PodName: "api-2", Message: "panic: runtime error: invalid memory address or nil pointer dereference"}},
+	}
+
+	incidents := relatedIncidents(docs, "panic: runtime error: invalid memory address or nil pointer dereference [signal SIGSEGV: segmentation violation code=0x1 addr=0x0]")
+	require.Len(t, incidents, 1)
+	assert.Equal(t, "prod/api-1", incidents[0].ID)
+	assert.Equal(t, "rolled back to v1.4.2", incidents[0].Remediation)
+	assert.Equal(t, 0.56, incidents[0].Similarity)
+}
+
+func TestHandleExplainError(t *testing.T) {
+	call := func(t *testing.T, tool *AlertTool, args map[string]interface{}) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := tool.handleExplainError(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+	decode := func(t *testing.T, result *mcp.CallToolResult) ErrorExplanation {
+		text := result.Content[0].(mcp.TextContent).Text
+		require.False(t, result.IsError, text)
+		var explanation ErrorExplanation
+		require.NoError(t, json.Unmarshal([]byte(text), &explanation))
+		return explanation
+	}
+	panicError := "panic: runtime error: invalid memory address or nil pointer dereference"
+
+	t.Run("knowledge base match needs no LLM", func(t *testing.T) {
+		explanation := decode(t, call(t, NewAlertTool(nil), map[string]interface{}{"error": "503 no healthy upstream"}))
+		assert.Equal(t, ExplanationSourceKnowledgeBase, explanation.Source)
+		assert.Equal(t, "envoy-no-healthy-upstream", explanation.Pattern)
+		assert.Equal(t, ErrorCategoryEnvoy, explanation.Category)
+	})
+
+	t.Run("falls back to the LLM with resolved incidents", func(t *testing.T) {
+		model := &scriptedModel{responses: []string{`{"cause": "A nil pointer is dereferenced in the request handler", "confidence": "medium", "category": "container", "next_steps": ["Roll back to the last good version", "Fix the nil check"]}`}}
+		tool := NewAlertTool(model)
+		doc := resolvedAlert("prod", "api-1", "Error", panicError+" in handler", "rolled back to v1.4.2", 1)
+		require.NoError(t, tool.store.Upsert(context.Background(), doc.Alert))
+		_, err := tool.store.AddRemediation(context.Background(), "prod", "api-1", doc.Remediations[1])
+		require.NoError(t, err)
+
+		explanation := decode(t, call(t, tool, map[string]interface{}{"error": panicError}))
+		assert.Equal(t, ExplanationSourceLLM, explanation.Source)
+		assert.Equal(t, "A nil pointer is dereferenced in the request handler", explanation.Cause)
+		assert.Equal(t, ConfidenceMedium, explanation.Confidence)
+		assert.Equal(t, []string{"Roll back to the last good version", "Fix the nil check"}, explanation.NextSteps)
+		require.Len(t, explanation.RelatedIncidents, 1)
+		require.NotNil(t, explanation.Analysis)
+		assert.True(t, explanation.Analysis.Validation.Valid)
+
+		require.Len(t, model.calls, 1)
+		prompt := model.calls[0][0].Parts[0].(llms.TextContent).Text
+		assert.Contains(t, prompt, `prod/api-1 (Error): resolved by "rolled back to v1.4.2"`)
+
+		// Incidents of other namespaces are not retrieved
+		model.responses = append(model.responses, model.responses[0])
+		explanation = decode(t, call(t, tool, map[string]interface{}{"error": panicError, "namespace": "staging"}))
+		assert.Empty(t, explanation.RelatedIncidents)
+	})
+
+	t.Run("unmatched error without an LLM", func(t *testing.T) {
+		result := call(t, NewAlertTool(nil), map[string]interface{}{"error": panicError})
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "no LLM is configured")
+
+		result = call(t, NewAlertTool(nil), map[string]interface{}{})
+		assert.True(t, result.IsError)
+	})
+}
+
+// budgetSession is a client session metered by the session budget
+type budgetSession string
+
+func (s budgetSession) Initialize()       {}
+func (s budgetSession) Initialized() bool { return true }
+func (s budgetSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return make(chan mcp.JSONRPCNotification, 1)
+}
+func (s budgetSession) SessionID() string { return string(s) }
+
+func TestExplainErrorDegradesOnceBudgetIsUsed(t *testing.T) {
+	t.Setenv(budget.SessionMaxLLMCalls, "1")
+	session := budgetSession("explain-budget")
+	ctx := server.NewMCPServer("test", "1.0").WithContext(context.Background(), session)
+	defer budget.ForgetSession(ctx, session)
+
+	model := &scriptedModel{responses: []string{`{"cause": "A nil pointer is dereferenced", "confidence": "medium", "category": "container", "next_steps": ["Fix the nil check"]}`}}
+	tool := NewAlertTool(budget.Model(model))
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"error": "panic: runtime error: invalid memory address or nil pointer dereference"}
+
+	result, err := tool.handleExplainError(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+	// The LLM is no longer called, while knowledge base explanations still work
+	result, err = tool.handleExplainError(ctx, request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "session budget exceeded")
+	assert.Len(t, model.calls, 1)
+
+	request.Params.Arguments = map[string]interface{}{"error": "503 no healthy upstream"}
+	result, err = tool.handleExplainError(ctx, request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+}
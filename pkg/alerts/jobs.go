@@ -0,0 +1,482 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/budget"
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/tenancy"
+)
+
+// Statuses of a background job
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// JobKindRemediationVerification re-checks a remediated pod and scores the remediation
+const JobKindRemediationVerification = "remediation_verification"
+
+// defaultJobMaxAttempts is the number of times a failing job is run before it fails
+const defaultJobMaxAttempts = 3
+
+// jobRetryBackoff is multiplied by the attempt number to delay the retry of a failed attempt
+var jobRetryBackoff = 30 * time.Second
+
+// queuePollInterval is how often the leader looks for jobs queued by other
+// replicas sharing its store
+var queuePollInterval = 30 * time.Second
+
+// Elector runs background tasks on the one replica elected to run them, with
+// a context cancelled when the replica loses the election, as leader.Elector does
+type Elector interface {
+	Go(task func(ctx context.Context))
+}
+
+// Job is a background task persisted in the alert store. Jobs that are queued
+// or running when the server stops are run again when it starts, so a job may
+// run more than once and its handler must be safe to repeat.
+type Job struct {
+	ID             string          `json:"id"`
+	Kind           string          `json:"kind"`
+	IdempotencyKey string          `json:"idempotency_key,omitempty"`
+	Status         string          `json:"status"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	Attempts       int             `json:"attempts"`
+	MaxAttempts    int             `json:"max_attempts"`
+	RunAfter       time.Time       `json:"run_after"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	FinishedAt     *time.Time      `json:"finished_at,omitempty"`
+	// Tenant is the storage prefix of the tenant that queued the job
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// jobHandler runs a single attempt of a job
+type jobHandler func(ctx context.Context, job Job) error
+
+// verificationPayload is the payload of a remediation verification job
+type verificationPayload struct {
+	Namespace string            `json:"namespace"`
+	PodName   string            `json:"pod_name"`
+	Record    RemediationRecord `json:"record"`
+}
+
+// jobHandlers returns the handlers of the job kinds run by the tool
+func (a *AlertTool) jobHandlers() map[string]jobHandler {
+	return map[string]jobHandler{
+		JobKindRemediationVerification: a.runVerificationJob,
+		JobKindOpsSummary:              a.runSummaryJob,
+		JobKindAlertRules:              a.runRulesJob,
+		JobKindDelegatedRemediation:    a.runDelegatedRemediationJob,
+		JobKindSLOEvaluation:           a.runSLOJob,
+		JobKindPodMonitor:              a.runMonitorJob,
+	}
+}
+
+// enqueueJob stores a job and starts it in the background. A job whose
+// idempotency key was already used is not queued again; the existing job is
+// returned instead.
+func (a *AlertTool) enqueueJob(ctx context.Context, job Job) (Job, error) {
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = defaultJobMaxAttempts
+	}
+	job.Tenant = tenancy.StoragePrefix(ctx)
+	stored, created, err := a.store.EnqueueJob(ctx, job)
+	if err != nil {
+		return Job{}, err
+	}
+	if created {
+		a.dispatchJob(ctx, stored.ID)
+	}
+	return stored, nil
+}
+
+// WithElector runs jobs only while this replica is the one elected, every
+// term of leadership resuming the jobs left queued or running
+func (a *AlertTool) WithElector(elector Elector) *AlertTool {
+	a.jobsMu.Lock()
+	a.elected = true
+	a.jobsMu.Unlock()
+	elector.Go(a.RunJobs)
+	return a
+}
+
+// RunJobs runs jobs for a term of leadership, until ctx is cancelled. It
+// resumes the jobs left queued or running and picks up the jobs other
+// replicas queue in a shared store. Jobs are interrupted when it returns, and
+// resumed by the next leader.
+func (a *AlertTool) RunJobs(ctx context.Context) {
+	a.jobsMu.Lock()
+	a.jobsCtx = ctx
+	a.jobsMu.Unlock()
+	defer func() {
+		a.jobsMu.Lock()
+		a.jobsCtx = nil
+		a.jobsMu.Unlock()
+		a.waitJobs()
+	}()
+
+	if err := a.ResumeJobs(ctx); err != nil {
+		logger.Get().Error("Failed to resume jobs", "error", err)
+	}
+	ticker := time.NewTicker(queuePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.dispatchQueuedJobs(ctx)
+		}
+	}
+}
+
+// dispatchQueuedJobs dispatches the queued jobs that are not running yet
+func (a *AlertTool) dispatchQueuedJobs(ctx context.Context) {
+	jobs, err := a.store.PendingJobs(ctx)
+	if err != nil {
+		logger.Get().Error("Failed to list pending jobs", "error", err)
+		return
+	}
+	for _, job := range jobs {
+		if job.Status == JobStatusQueued {
+			a.dispatchJob(jobContext(ctx, job), job.ID)
+		}
+	}
+}
+
+// jobContext returns a context for a job, carrying its tenant
+func jobContext(ctx context.Context, job Job) context.Context {
+	if job.Tenant == "" {
+		return ctx
+	}
+	return tenancy.WithProfile(ctx, &tenancy.Profile{Name: job.Tenant, StoragePrefix: job.Tenant})
+}
+
+// dispatchJob runs a queued job in the background once it is due, retrying
+// failed attempts until the job runs out of attempts. Jobs outlive the call
+// that queued them and are not metered against its session's budget. While
+// another replica is the leader the job is left queued for it; a job that is
+// already running is not run twice.
+func (a *AlertTool) dispatchJob(ctx context.Context, id string) {
+	a.jobsMu.Lock()
+	termCtx := context.Background()
+	if a.elected {
+		termCtx = a.jobsCtx
+	}
+	if termCtx == nil || a.dispatched[id] {
+		a.jobsMu.Unlock()
+		return
+	}
+	if a.dispatched == nil {
+		a.dispatched = make(map[string]bool)
+	}
+	a.dispatched[id] = true
+	a.jobs.Add(1)
+	a.jobsMu.Unlock()
+
+	// The job stops with the term of leadership, not with the call
+	ctx, cancel := context.WithCancel(budget.Detach(context.WithoutCancel(ctx)))
+	stop := context.AfterFunc(termCtx, cancel)
+	go func() {
+		defer a.jobs.Done()
+		defer func() {
+			stop()
+			cancel()
+			a.jobsMu.Lock()
+			delete(a.dispatched, id)
+			a.jobsMu.Unlock()
+		}()
+		for a.runJobAttempt(ctx, id) {
+		}
+	}()
+}
+
+// runJobAttempt waits until the job is due and runs one attempt, returning
+// whether the job was queued again for another attempt
+func (a *AlertTool) runJobAttempt(ctx context.Context, id string) bool {
+	// Cancelling the job wakes it up while it waits and interrupts the attempt
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	a.jobsMu.Lock()
+	job, err := a.store.GetJob(ctx, id)
+	if err != nil || job == nil || job.Status != JobStatusQueued {
+		a.jobsMu.Unlock()
+		if job == nil {
+			logger.Get().Error("Failed to load job", "job", id, "error", err)
+		}
+		return false
+	}
+	if a.jobCancels == nil {
+		a.jobCancels = make(map[string]context.CancelFunc)
+	}
+	a.jobCancels[id] = cancel
+	a.jobsMu.Unlock()
+
+	timer := time.NewTimer(time.Until(job.RunAfter))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-attemptCtx.Done():
+	}
+	if ctx.Err() != nil {
+		// The term of leadership ended; the job stays queued for the next leader
+		a.forgetJobCancel(id)
+		return false
+	}
+
+	job, err = a.claimJob(ctx, id)
+	if err != nil {
+		logger.Get().Error("Failed to start job", "job", id, "error", err)
+		return false
+	}
+	if job == nil {
+		// The job was cancelled while it was waiting
+		return false
+	}
+
+	handler, ok := a.jobHandlers()[job.Kind]
+	if !ok {
+		err = fmt.Errorf("unknown job kind %q", job.Kind)
+	} else {
+		err = handler(attemptCtx, *job)
+	}
+	if ctx.Err() != nil {
+		// Interrupted by the end of the term, the job is left running for the
+		// next leader to resume
+		a.forgetJobCancel(id)
+		return false
+	}
+	return a.finishJob(ctx, id, err)
+}
+
+// forgetJobCancel drops the cancel function of a job that stopped running
+func (a *AlertTool) forgetJobCancel(id string) {
+	a.jobsMu.Lock()
+	defer a.jobsMu.Unlock()
+	delete(a.jobCancels, id)
+}
+
+// claimJob marks a queued job as running, or returns nil when it is no
+// longer queued, such as when it was cancelled or another dispatcher claimed it
+func (a *AlertTool) claimJob(ctx context.Context, id string) (*Job, error) {
+	a.jobsMu.Lock()
+	defer a.jobsMu.Unlock()
+
+	job, err := a.store.ClaimJob(ctx, id)
+	if err != nil || job == nil {
+		delete(a.jobCancels, id)
+		return nil, err
+	}
+	return job, nil
+}
+
+// finishJob records the outcome of an attempt, queueing the job again when
+// the attempt failed and attempts remain. A job cancelled while it was running
+// stays cancelled.
+func (a *AlertTool) finishJob(ctx context.Context, id string, runErr error) bool {
+	a.jobsMu.Lock()
+	defer a.jobsMu.Unlock()
+
+	delete(a.jobCancels, id)
+	job, err := a.store.GetJob(ctx, id)
+	if err != nil || job == nil {
+		logger.Get().Error("Failed to load job", "job", id, "error", err)
+		return false
+	}
+	if job.Status != JobStatusRunning {
+		return false
+	}
+
+	now := time.Now()
+	retry := false
+	switch {
+	case runErr == nil:
+		job.Status = JobStatusSucceeded
+		job.LastError = ""
+		job.FinishedAt = &now
+	case job.Attempts < job.MaxAttempts:
+		job.Status = JobStatusQueued
+		job.LastError = runErr.Error()
+		job.RunAfter = now.Add(time.Duration(job.Attempts) * jobRetryBackoff)
+		retry = true
+	default:
+		job.Status = JobStatusFailed
+		job.LastError = runErr.Error()
+		job.FinishedAt = &now
+	}
+	if runErr != nil {
+		logger.Get().Error("Job attempt failed", "job", id, "kind", job.Kind, "attempt", job.Attempts, "error", runErr)
+	}
+	if err := a.store.UpdateJob(ctx, *job); err != nil {
+		logger.Get().Error("Failed to store job", "job", id, "error", err)
+		return false
+	}
+	return retry
+}
+
+// cancelJob cancels a queued or running job, interrupting a running attempt
+func (a *AlertTool) cancelJob(ctx context.Context, id string) (*Job, error) {
+	a.jobsMu.Lock()
+	defer a.jobsMu.Unlock()
+
+	job, err := a.store.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, fmt.Errorf("job %s not found", id)
+	}
+	if job.Status != JobStatusQueued && job.Status != JobStatusRunning {
+		return nil, fmt.Errorf("job %s is %s and cannot be cancelled", id, job.Status)
+	}
+
+	now := time.Now()
+	job.Status = JobStatusCancelled
+	job.FinishedAt = &now
+	if err := a.store.UpdateJob(ctx, *job); err != nil {
+		return nil, err
+	}
+	if cancel, ok := a.jobCancels[id]; ok {
+		cancel()
+	}
+	return job, nil
+}
+
+// retryJob queues a failed or cancelled job again with a fresh set of attempts
+func (a *AlertTool) retryJob(ctx context.Context, id string) (*Job, error) {
+	a.jobsMu.Lock()
+	job, err := a.store.GetJob(ctx, id)
+	if err == nil && job == nil {
+		err = fmt.Errorf("job %s not found", id)
+	}
+	if err == nil && job.Status != JobStatusFailed && job.Status != JobStatusCancelled {
+		err = fmt.Errorf("job %s is %s, only failed or cancelled jobs can be retried", id, job.Status)
+	}
+	if err == nil {
+		job.Status = JobStatusQueued
+		job.Attempts = 0
+		job.RunAfter = time.Now()
+		job.FinishedAt = nil
+		err = a.store.UpdateJob(ctx, *job)
+	}
+	a.jobsMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	a.dispatchJob(ctx, job.ID)
+	return job, nil
+}
+
+// ResumeJobs restarts the jobs that were queued or running when the server
+// last stopped or lost leadership. Jobs interrupted while running are run again.
+func (a *AlertTool) ResumeJobs(ctx context.Context) error {
+	jobs, err := a.store.PendingJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list pending jobs: %w", err)
+	}
+
+	for _, job := range jobs {
+		jobCtx := jobContext(ctx, job)
+		if job.Status == JobStatusRunning {
+			job.Status = JobStatusQueued
+			job.LastError = "interrupted by a server restart or a change of leader"
+			if err := a.store.UpdateJob(jobCtx, job); err != nil {
+				return fmt.Errorf("failed to requeue job %s: %w", job.ID, err)
+			}
+		}
+		logger.Get().Info("Resuming job", "job", job.ID, "kind", job.Kind)
+		a.dispatchJob(jobCtx, job.ID)
+	}
+	return nil
+}
+
+// waitJobs blocks until all dispatched jobs have finished
+func (a *AlertTool) waitJobs() {
+	a.jobs.Wait()
+}
+
+// runVerificationJob re-checks a remediated pod and stores the scored remediation
+func (a *AlertTool) runVerificationJob(ctx context.Context, job Job) error {
+	var payload verificationPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid verification payload: %w", err)
+	}
+
+	health, err := a.getPodHealth(ctx, payload.Namespace, payload.PodName)
+	if err != nil {
+		logger.Get().Info("Remediated pod could not be inspected", "pod", payload.PodName, "namespace", payload.Namespace, "error", err)
+		health = nil
+	}
+
+	record := scoreRemediation(payload.Record, health)
+	record.SilencedBy = a.alertSilence(ctx, payload.Namespace, payload.PodName)
+	if err := a.store.UpdateRemediation(ctx, payload.Namespace, payload.PodName, record); err != nil {
+		return fmt.Errorf("failed to store remediation verification: %w", err)
+	}
+	// Verifications during a silence are recorded without notifying clients
+	if record.SilencedBy == "" {
+		a.clients.RemediationVerified(ctx, payload.Namespace, payload.PodName, record)
+	}
+	return nil
+}
+
+// JobList is the structured response of the list action of alerts_jobs
+type JobList struct {
+	Total int   `json:"total"`
+	Jobs  []Job `json:"jobs"`
+}
+
+// handleJobs lists, cancels and retries background jobs
+func (a *AlertTool) handleJobs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	action := p.String("action", "list", params.OneOf("list", "cancel", "retry"))
+	jobID := p.String("job_id", "")
+	status := p.String("status", "", params.OneOf(JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if action != "list" && jobID == "" {
+		return mcp.NewToolResultError(fmt.Sprintf("job_id is required to %s a job", action)), nil
+	}
+
+	var response interface{}
+	switch action {
+	case "list":
+		jobs, err := a.store.ListJobs(ctx, status)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list jobs: %v", err)), nil
+		}
+		response = JobList{Total: len(jobs), Jobs: jobs}
+	case "cancel":
+		job, err := a.cancelJob(ctx, jobID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to cancel job: %v", err)), nil
+		}
+		response = job
+	case "retry":
+		job, err := a.retryJob(ctx, jobID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to retry job: %v", err)), nil
+		}
+		response = job
+	}
+
+	responseJSON, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal jobs: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(responseJSON)), nil
+}
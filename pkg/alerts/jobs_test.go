@@ -0,0 +1,289 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/kagent-dev/tools/internal/tenancy"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callJobs calls alerts_jobs and decodes its response into v
+func callJobs(t *testing.T, ctx context.Context, tool *AlertTool, arguments map[string]interface{}, v interface{}) *mcp.CallToolResult {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = arguments
+	result, err := tool.handleJobs(ctx, request)
+	require.NoError(t, err)
+	if !result.IsError && v != nil {
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), v))
+	}
+	return result
+}
+
+func TestMemoryAlertStoreJobs(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryAlertStore()
+
+	job, created, err := store.EnqueueJob(ctx, Job{Kind: "test", IdempotencyKey: "key-1"})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, JobStatusQueued, job.Status)
+	assert.False(t, job.RunAfter.IsZero())
+
+	duplicate, created, err := store.EnqueueJob(ctx, Job{Kind: "test", IdempotencyKey: "key-1"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, job.ID, duplicate.ID)
+
+	_, _, err = store.EnqueueJob(ctx, Job{Kind: "test"})
+	require.NoError(t, err)
+	job.Status = JobStatusSucceeded
+	require.NoError(t, store.UpdateJob(ctx, job))
+
+	jobs, err := store.ListJobs(ctx, JobStatusQueued)
+	require.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	pending, err := store.PendingJobs(ctx)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	// Jobs and idempotency keys are scoped to the tenant
+	teamA := tenancy.WithProfile(ctx, &tenancy.Profile{Name: "team-a", StoragePrefix: "team-a"})
+	missing, err := store.GetJob(teamA, job.ID)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+	assert.Error(t, store.UpdateJob(teamA, job))
+	_, created, err = store.EnqueueJob(teamA, Job{Kind: "test", IdempotencyKey: "key-1"})
+	require.NoError(t, err)
+	assert.True(t, created)
+	pending, err = store.PendingJobs(ctx)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+
+	// Only one claim of a queued job succeeds
+	claimed, err := store.ClaimJob(ctx, jobs[0].ID)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, JobStatusRunning, claimed.Status)
+	assert.Equal(t, 1, claimed.Attempts)
+	again, err := store.ClaimJob(ctx, jobs[0].ID)
+	require.NoError(t, err)
+	assert.Nil(t, again)
+	missing, err = store.ClaimJob(ctx, "job-99")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestSharedStoreRunsJobOnce(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryAlertStore()
+	replicas := []*AlertTool{NewAlertTool(nil).WithStore(store), NewAlertTool(nil).WithStore(store)}
+
+	// An unknown kind fails on its only attempt; a second run would count another
+	job, _, err := store.EnqueueJob(ctx, Job{Kind: "test", MaxAttempts: 1})
+	require.NoError(t, err)
+	for _, replica := range replicas {
+		replica.dispatchJob(ctx, job.ID)
+	}
+	for _, replica := range replicas {
+		replica.waitJobs()
+	}
+
+	stored, err := store.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusFailed, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+}
+
+func TestJobRetries(t *testing.T) {
+	jobRetryBackoff = 0
+	t.Cleanup(func() { jobRetryBackoff = 30 * time.Second })
+
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pod", "web-1", "-n", "prod", "-o", "json"}, podJSON("Running", true, 0), nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	tool := NewAlertTool(nil)
+
+	// The remediation does not exist, so every attempt fails to store its outcome
+	job, err := tool.scheduleVerification(ctx, "prod", "web-1", RemediationRecord{ID: "rem-1"}, 0, "")
+	require.NoError(t, err)
+	tool.waitJobs()
+
+	var list JobList
+	callJobs(t, ctx, tool, map[string]interface{}{"status": JobStatusFailed}, &list)
+	require.Equal(t, 1, list.Total)
+	failed := list.Jobs[0]
+	assert.Equal(t, job.ID, failed.ID)
+	assert.Equal(t, defaultJobMaxAttempts, failed.Attempts)
+	assert.Contains(t, failed.LastError, "no alert found for prod/web-1")
+	assert.NotNil(t, failed.FinishedAt)
+
+	// Once the remediation exists, retrying the job succeeds
+	_, err = tool.store.AddRemediation(ctx, "prod", "web-1", RemediationRecord{Verification: VerificationPending})
+	require.NoError(t, err)
+	var retried Job
+	callJobs(t, ctx, tool, map[string]interface{}{"action": "retry", "job_id": job.ID}, &retried)
+	assert.Equal(t, JobStatusQueued, retried.Status)
+	tool.waitJobs()
+
+	stored, err := tool.store.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusSucceeded, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	doc, err := tool.store.Get(ctx, "prod", "web-1")
+	require.NoError(t, err)
+	assert.Equal(t, VerificationResolved, doc.Remediations[0].Verification)
+
+	result := callJobs(t, ctx, tool, map[string]interface{}{"action": "retry", "job_id": job.ID}, nil)
+	assert.True(t, result.IsError)
+}
+
+func TestCancelJob(t *testing.T) {
+	ctx := context.Background()
+	tool := NewAlertTool(nil)
+
+	job, err := tool.enqueueJob(ctx, Job{Kind: JobKindRemediationVerification, RunAfter: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	var cancelled Job
+	callJobs(t, ctx, tool, map[string]interface{}{"action": "cancel", "job_id": job.ID}, &cancelled)
+	assert.Equal(t, JobStatusCancelled, cancelled.Status)
+	// Cancelling wakes the waiting job, which then exits without running
+	tool.waitJobs()
+	stored, err := tool.store.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusCancelled, stored.Status)
+	assert.Zero(t, stored.Attempts)
+
+	assert.True(t, callJobs(t, ctx, tool, map[string]interface{}{"action": "cancel", "job_id": job.ID}, nil).IsError)
+	assert.True(t, callJobs(t, ctx, tool, map[string]interface{}{"action": "cancel", "job_id": "job-99"}, nil).IsError)
+	assert.True(t, callJobs(t, ctx, tool, map[string]interface{}{"action": "cancel"}, nil).IsError)
+	assert.True(t, callJobs(t, ctx, tool, map[string]interface{}{"action": "pause", "job_id": job.ID}, nil).IsError)
+}
+
+func TestResumeJobsAfterRestart(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pod", "web-1", "-n", "prod", "-o", "json"}, podJSON("Running", true, 4), nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	path := filepath.Join(t.TempDir(), "alerts.json")
+
+	// A verification that was running when the server stopped
+	store, err := NewFileAlertStore(path)
+	require.NoError(t, err)
+	id, err := store.AddRemediation(ctx, "prod", "web-1", RemediationRecord{BaselineRestarts: 2, Verification: VerificationPending})
+	require.NoError(t, err)
+	payload, err := json.Marshal(verificationPayload{Namespace: "prod", PodName: "web-1", Record: RemediationRecord{ID: id, BaselineRestarts: 2}})
+	require.NoError(t, err)
+	job, _, err := store.EnqueueJob(ctx, Job{Kind: JobKindRemediationVerification, Payload: payload, MaxAttempts: 3})
+	require.NoError(t, err)
+	job.Status = JobStatusRunning
+	job.Attempts = 1
+	require.NoError(t, store.UpdateJob(ctx, job))
+
+	reopened, err := NewFileAlertStore(path)
+	require.NoError(t, err)
+	tool := NewAlertTool(nil).WithStore(reopened)
+	require.NoError(t, tool.ResumeJobs(ctx))
+	tool.waitJobs()
+
+	stored, err := reopened.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusSucceeded, stored.Status)
+	assert.Equal(t, 2, stored.Attempts)
+	doc, err := reopened.Get(ctx, "prod", "web-1")
+	require.NoError(t, err)
+	assert.Equal(t, VerificationDegraded, doc.Remediations[0].Verification)
+}
+
+// testElector records the tasks registered with it, leaving the caller to run them
+type testElector struct {
+	tasks []func(ctx context.Context)
+}
+
+func (e *testElector) Go(task func(ctx context.Context)) {
+	e.tasks = append(e.tasks, task)
+}
+
+func TestJobsRunOnlyOnLeader(t *testing.T) {
+	previousInterval := queuePollInterval
+	queuePollInterval = time.Millisecond
+	t.Cleanup(func() { queuePollInterval = previousInterval })
+
+	ctx := context.Background()
+	elector := &testElector{}
+	tool := NewAlertTool(nil).WithElector(elector)
+	require.Len(t, elector.tasks, 1)
+
+	// An unknown kind fails on its only attempt, which shows that it ran
+	jobStatus := func(id string) string {
+		job, err := tool.store.GetJob(ctx, id)
+		require.NoError(t, err)
+		return job.Status
+	}
+	queued, err := tool.enqueueJob(ctx, Job{Kind: "test", MaxAttempts: 1})
+	require.NoError(t, err)
+	tool.waitJobs()
+	assert.Equal(t, JobStatusQueued, jobStatus(queued.ID), "followers leave jobs queued")
+
+	term, endTerm := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		elector.tasks[0](term)
+	}()
+	assert.Eventually(t, func() bool { return jobStatus(queued.ID) == JobStatusFailed }, time.Second, time.Millisecond,
+		"the leader resumes jobs queued before its term")
+
+	// Jobs queued by another replica sharing the store are picked up
+	shared, _, err := tool.store.EnqueueJob(ctx, Job{Kind: "test", MaxAttempts: 1})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return jobStatus(shared.ID) == JobStatusFailed }, time.Second, time.Millisecond)
+
+	endTerm()
+	<-done
+	later, err := tool.enqueueJob(ctx, Job{Kind: "test", MaxAttempts: 1})
+	require.NoError(t, err)
+	tool.waitJobs()
+	assert.Equal(t, JobStatusQueued, jobStatus(later.ID), "jobs do not run after the term ends")
+}
+
+func TestHandleMarkRemediatedIdempotencyKey(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pod", "web-1", "-n", "prod", "-o", "json"}, "", errors.New("not found"))
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	tool := NewAlertTool(nil)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"pod_name":        "web-1",
+		"namespace":       "prod",
+		"verify_after":    "1h",
+		"idempotency_key": "incident-42",
+	}
+	var responses []map[string]interface{}
+	for range 2 {
+		result, err := tool.handleMarkRemediated(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &response))
+		responses = append(responses, response)
+	}
+	assert.Equal(t, responses[0]["job_id"], responses[1]["job_id"])
+	assert.Equal(t, responses[0]["remediation"].(map[string]interface{})["id"], responses[1]["remediation"].(map[string]interface{})["id"])
+
+	doc, err := tool.store.Get(ctx, "prod", "web-1")
+	require.NoError(t, err)
+	assert.Len(t, doc.Remediations, 1)
+
+	_, err = tool.cancelJob(ctx, responses[0]["job_id"].(string))
+	require.NoError(t, err)
+	tool.waitJobs()
+}
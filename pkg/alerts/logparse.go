@@ -0,0 +1,355 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Limits of the exceptions extracted from an alert's logs
+const (
+	maxLogExceptions       = 10
+	maxExceptionFrames     = 20
+	maxExceptionMessageLen = 512
+)
+
+// Runtimes of the built-in log parsers
+const (
+	LogRuntimeJSON   = "json"
+	LogRuntimeJava   = "java"
+	LogRuntimeGo     = "go"
+	LogRuntimePython = "python"
+)
+
+// StackFrame is a frame of an exception's stack trace
+type StackFrame struct {
+	Function string `json:"function,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// LogException is an exception, panic or error extracted from a pod's logs
+type LogException struct {
+	// Runtime is the name of the parser that found the exception
+	Runtime string `json:"runtime"`
+	Type    string `json:"type"`
+	Message string `json:"message,omitempty"`
+	// Frames are the exception's stack frames in the order the runtime prints them
+	Frames []StackFrame `json:"frames,omitempty"`
+}
+
+// LogParser extracts exceptions from log lines, oldest line first
+type LogParser interface {
+	Name() string
+	Parse(lines []string) []LogException
+}
+
+var (
+	logParsersMu sync.RWMutex
+	logParsers   = []LogParser{jsonLogParser{}, javaLogParser{}, goPanicParser{}, pythonTracebackParser{}}
+)
+
+// RegisterLogParser adds a parser run on collected logs, replacing any parser of the same name
+func RegisterLogParser(parser LogParser) {
+	logParsersMu.Lock()
+	defer logParsersMu.Unlock()
+	for i, registered := range logParsers {
+		if registered.Name() == parser.Name() {
+			logParsers[i] = parser
+			return
+		}
+	}
+	logParsers = append(logParsers, parser)
+}
+
+// parseLogExceptions runs the registered parsers over log lines, returning
+// the distinct exceptions they found, up to maxLogExceptions
+func parseLogExceptions(lines []string) []LogException {
+	logParsersMu.RLock()
+	parsers := append([]LogParser(nil), logParsers...)
+	logParsersMu.RUnlock()
+
+	var exceptions []LogException
+	seen := map[string]bool{}
+	for _, parser := range parsers {
+		for _, exception := range parser.Parse(lines) {
+			key := exception.Runtime + "\x00" + exception.Type + "\x00" + exception.Message
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if len(exception.Message) > maxExceptionMessageLen {
+				exception.Message = strings.ToValidUTF8(exception.Message[:maxExceptionMessageLen], "")
+			}
+			if len(exception.Frames) > maxExceptionFrames {
+				exception.Frames = exception.Frames[:maxExceptionFrames]
+			}
+			exceptions = append(exceptions, exception)
+			if len(exceptions) == maxLogExceptions {
+				return exceptions
+			}
+		}
+	}
+	return exceptions
+}
+
+// formatExceptions renders extracted exceptions for an analysis prompt
+func formatExceptions(exceptions []LogException) string {
+	if len(exceptions) == 0 {
+		return "No exceptions found in logs"
+	}
+
+	var b strings.Builder
+	for _, exception := range exceptions {
+		b.WriteString(fmt.Sprintf("- [%s] %s", exception.Runtime, exception.Type))
+		if exception.Message != "" {
+			b.WriteString(": " + exception.Message)
+		}
+		b.WriteString("\n")
+		for _, frame := range exception.Frames[:min(len(exception.Frames), 5)] {
+			b.WriteString(fmt.Sprintf("    at %s (%s:%d)\n", frame.Function, frame.File, frame.Line))
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// exceptionText returns the types and messages of exceptions for term matching
+func exceptionText(exceptions []LogException) string {
+	parts := make([]string, 0, len(exceptions))
+	for _, exception := range exceptions {
+		parts = append(parts, exception.Type+" "+exception.Message)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// jsonLogParser extracts errors from structured JSON log lines, such as those
+// written by zap, logrus, slog, Logback's JSON encoder or python-json-logger
+type jsonLogParser struct{}
+
+// Fields holding the error type, message and stack of a JSON log line, by preference
+var (
+	jsonErrorLevels   = []string{"error", "fatal", "panic", "critical", "severe", "dpanic"}
+	jsonTypeFields    = []string{"error.type", "error.kind", "exception.type", "exception.class", "exc_type", "error_type", "exception"}
+	jsonMessageFields = []string{"error.message", "exception.message", "error", "err", "exc_message", "msg", "message"}
+	jsonStackFields   = []string{"error.stack", "exception.stacktrace", "stacktrace", "stack_trace", "stack", "exc_info"}
+)
+
+func (jsonLogParser) Name() string { return LogRuntimeJSON }
+
+func (jsonLogParser) Parse(lines []string) []LogException {
+	var exceptions []LogException
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var entry map[string]interface{}
+		if json.Unmarshal([]byte(line), &entry) != nil {
+			continue
+		}
+
+		exceptionType := jsonField(entry, jsonTypeFields)
+		stack := jsonField(entry, jsonStackFields)
+		_, hasError := entry["error"]
+		isError := hasError || exceptionType != "" || stack != ""
+		for _, key := range []string{"level", "severity", "lvl"} {
+			if level, ok := entry[key].(string); ok && containsFold(jsonErrorLevels, level) {
+				isError = true
+			}
+		}
+		if !isError {
+			continue
+		}
+
+		exception := LogException{
+			Runtime: LogRuntimeJSON,
+			Type:    exceptionType,
+			Message: jsonField(entry, jsonMessageFields),
+		}
+		// Stacks embedded in the line are parsed by the runtime parsers for their frames
+		if stack != "" {
+			if embedded := parseEmbeddedStack(strings.Split(stack, "\n")); len(embedded) > 0 {
+				exception.Frames = embedded[0].Frames
+				if exception.Type == "" {
+					exception.Type = embedded[0].Type
+				}
+			}
+		}
+		if exception.Type == "" {
+			exception.Type = "error"
+		}
+		exceptions = append(exceptions, exception)
+	}
+	return exceptions
+}
+
+// jsonField returns the first of fields set to a scalar in entry, where
+// dotted fields are looked up both flat and in nested objects
+func jsonField(entry map[string]interface{}, fields []string) string {
+	for _, field := range fields {
+		value, ok := entry[field]
+		if !ok {
+			if parent, child, nested := strings.Cut(field, "."); nested {
+				if object, isObject := entry[parent].(map[string]interface{}); isObject {
+					value = object[child]
+				}
+			}
+		}
+		switch v := value.(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEmbeddedStack runs the runtime parsers over a stack embedded in a structured log line
+func parseEmbeddedStack(lines []string) []LogException {
+	for _, parser := range []LogParser{javaLogParser{}, goPanicParser{}, pythonTracebackParser{}} {
+		if exceptions := parser.Parse(lines); len(exceptions) > 0 {
+			return exceptions
+		}
+	}
+	return nil
+}
+
+// javaLogParser extracts exceptions and their "at" frames from JVM stack traces,
+// reporting each "Caused by" exception on its own
+type javaLogParser struct{}
+
+var (
+	javaExceptionPattern = regexp.MustCompile(`^(?:Exception in thread "[^"]*" |Caused by: )?((?:[a-zA-Z_$][\w$]*\.)+[A-Z][\w$]*(?:Exception|Error|Throwable))(?::\s*(.*))?$`)
+	javaFramePattern     = regexp.MustCompile(`^\s+at ([\w$.<>/]+)\(([^:)]*)(?::(\d+))?\)`)
+)
+
+func (javaLogParser) Name() string { return LogRuntimeJava }
+
+func (javaLogParser) Parse(lines []string) []LogException {
+	var exceptions []LogException
+	var current *LogException
+	for _, line := range lines {
+		if match := javaExceptionPattern.FindStringSubmatch(strings.TrimSpace(line)); match != nil && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			exceptions = append(exceptions, LogException{Runtime: LogRuntimeJava, Type: match[1], Message: match[2]})
+			current = &exceptions[len(exceptions)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if match := javaFramePattern.FindStringSubmatch(line); match != nil {
+			line, _ := strconv.Atoi(match[3])
+			current.Frames = append(current.Frames, StackFrame{Function: match[1], File: match[2], Line: line})
+			continue
+		}
+		// "... 12 more" lines continue the trace, anything else ends it
+		if !strings.HasPrefix(strings.TrimSpace(line), "...") {
+			current = nil
+		}
+	}
+	return exceptions
+}
+
+// goPanicParser extracts panics and fatal errors with the frames of the
+// panicking goroutine from Go runtime crash output
+type goPanicParser struct{}
+
+var (
+	goPanicPattern     = regexp.MustCompile(`^(panic|fatal error): (.*?)(?: \[recovered\])?$`)
+	goGoroutinePattern = regexp.MustCompile(`^goroutine \d+ \[`)
+	goFunctionPattern  = regexp.MustCompile(`^([^\s(][^\s]*)\(.*\)$`)
+	goLocationPattern  = regexp.MustCompile(`^\s+(\S+\.go):(\d+)`)
+)
+
+func (goPanicParser) Name() string { return LogRuntimeGo }
+
+func (goPanicParser) Parse(lines []string) []LogException {
+	var exceptions []LogException
+	for i := 0; i < len(lines); i++ {
+		match := goPanicPattern.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if match == nil {
+			continue
+		}
+		exception := LogException{Runtime: LogRuntimeGo, Type: match[1], Message: match[2]}
+		if message, ok := strings.CutPrefix(match[2], "runtime error: "); ok {
+			exception.Type, exception.Message = "runtime error", message
+		}
+
+		// Frames follow the first goroutine header as function and location line pairs
+		j := i + 1
+		for j < len(lines) && !goGoroutinePattern.MatchString(lines[j]) && !goPanicPattern.MatchString(strings.TrimSpace(lines[j])) {
+			j++
+		}
+		if j < len(lines) && goGoroutinePattern.MatchString(lines[j]) {
+			for j++; j+1 < len(lines); j += 2 {
+				function := goFunctionPattern.FindStringSubmatch(lines[j])
+				location := goLocationPattern.FindStringSubmatch(lines[j+1])
+				if function == nil || location == nil {
+					break
+				}
+				line, _ := strconv.Atoi(location[2])
+				exception.Frames = append(exception.Frames, StackFrame{Function: function[1], File: location[1], Line: line})
+			}
+			i = j - 1
+		}
+		exceptions = append(exceptions, exception)
+	}
+	return exceptions
+}
+
+// pythonTracebackParser extracts exceptions and their frames from Python tracebacks
+type pythonTracebackParser struct{}
+
+var (
+	pythonTracebackPattern = regexp.MustCompile(`^Traceback \(most recent call last\):$`)
+	pythonFramePattern     = regexp.MustCompile(`^\s+File "([^"]+)", line (\d+)(?:, in (.+))?$`)
+	pythonExceptionPattern = regexp.MustCompile(`^([A-Za-z_][\w.]*)(?::\s*(.*))?$`)
+)
+
+func (pythonTracebackParser) Name() string { return LogRuntimePython }
+
+func (pythonTracebackParser) Parse(lines []string) []LogException {
+	var exceptions []LogException
+	for i := 0; i < len(lines); i++ {
+		if !pythonTracebackPattern.MatchString(strings.TrimSpace(lines[i])) {
+			continue
+		}
+		var frames []StackFrame
+		// Frame lines are followed by indented source lines; the first
+		// unindented line names the exception
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if match := pythonFramePattern.FindStringSubmatch(line); match != nil {
+				number, _ := strconv.Atoi(match[2])
+				frames = append(frames, StackFrame{Function: match[3], File: match[1], Line: number})
+				continue
+			}
+			if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+				continue
+			}
+			if match := pythonExceptionPattern.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+				exceptions = append(exceptions, LogException{Runtime: LogRuntimePython, Type: match[1], Message: match[2], Frames: frames})
+			} else {
+				i--
+			}
+			break
+		}
+	}
+	return exceptions
+}
@@ -0,0 +1,159 @@
+package alerts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogExceptions(t *testing.T) {
+	tests := []struct {
+		name string
+		logs string
+		want []LogException
+	}{
+		{
+			name: "java with cause",
+			logs: `2024-05-01 12:00:00 ERROR [main] c.e.App - request failed
+java.lang.IllegalStateException: pool exhausted
+	at com.example.db.Pool.acquire(Pool.java:42)
+	at com.example.App.main(App.java:10)
+Caused by: java.net.ConnectException: Connection refused
+	at java.base/sun.nio.ch.Net.connect0(Native Method)
+	... 2 more
+INFO retrying`,
+			want: []LogException{
+				{Runtime: LogRuntimeJava, Type: "java.lang.IllegalStateException", Message: "pool exhausted", Frames: []StackFrame{
+					{Function: "com.example.db.Pool.acquire", File: "Pool.java", Line: 42},
+					{Function: "com.example.App.main", File: "App.java", Line: 10},
+				}},
+				{Runtime: LogRuntimeJava, Type: "java.net.ConnectException", Message: "Connection refused", Frames: []StackFrame{
+					{Function: "java.base/sun.nio.ch.Net.connect0", File: "Native Method"},
+				}},
+			},
+		},
+		{
+			name: "go panic",
+			logs: `starting server
+panic: runtime error: invalid memory address or nil pointer dereference
+[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x4a2b1c]
+
+goroutine 1 [running]:
+main.(*Server).handle(0x0, {0xc000012345, 0x5})
+	/app/server.go:88 +0x1c
+main.main()
+	/app/main.go:12 +0x25
+exit status 2`,
+			want: []LogException{
+				{Runtime: LogRuntimeGo, Type: "runtime error", Message: "invalid memory address or nil pointer dereference", Frames: []StackFrame{
+					{Function: "main.(*Server).handle", File: "/app/server.go", Line: 88},
+					{Function: "main.main", File: "/app/main.go", Line: 12},
+				}},
+			},
+		},
+		{
+			name: "python traceback",
+			logs: `Traceback (most recent call last):
+  File "/app/main.py", line 20, in <module>
+    run()
+  File "/app/worker.py", line 7, in run
+    raise KeyError("user_id")
+KeyError: 'user_id'`,
+			want: []LogException{
+				{Runtime: LogRuntimePython, Type: "KeyError", Message: "'user_id'", Frames: []StackFrame{
+					{Function: "<module>", File: "/app/main.py", Line: 20},
+					{Function: "run", File: "/app/worker.py", Line: 7},
+				}},
+			},
+		},
+		{
+			name: "json logs",
+			logs: `{"level":"info","msg":"listening"}
+{"level":"error","msg":"query failed","error":"dial tcp 10.0.0.5:5432: connect: connection refused"}
+{"severity":"ERROR","message":"unhandled","exception":{"type":"ValueError","message":"bad input"}}
+not json {"level":"error"}`,
+			want: []LogException{
+				{Runtime: LogRuntimeJSON, Type: "error", Message: "dial tcp 10.0.0.5:5432: connect: connection refused"},
+				{Runtime: LogRuntimeJSON, Type: "ValueError", Message: "bad input"},
+			},
+		},
+		{
+			name: "json with embedded java stack",
+			logs: `{"level":"ERROR","message":"failed","stack_trace":"java.io.IOException: disk full\n\tat com.example.Store.write(Store.java:5)"}`,
+			want: []LogException{
+				{Runtime: LogRuntimeJSON, Type: "java.io.IOException", Message: "failed", Frames: []StackFrame{
+					{Function: "com.example.Store.write", File: "Store.java", Line: 5},
+				}},
+			},
+		},
+		{
+			name: "no exceptions",
+			logs: "server started\nlistening on :8080",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseLogExceptions(strings.Split(tt.logs, "\n")))
+		})
+	}
+}
+
+func TestParseLogExceptionsLimits(t *testing.T) {
+	var lines []string
+	for i := 0; i < maxLogExceptions+5; i++ {
+		lines = append(lines, "panic: boom "+strings.Repeat("x", i))
+	}
+	lines = append(lines, "panic: boom")
+	assert.Len(t, parseLogExceptions(lines), maxLogExceptions)
+
+	lines = []string{"java.lang.RuntimeException: " + strings.Repeat("a", 2*maxExceptionMessageLen)}
+	for i := 0; i < 2*maxExceptionFrames; i++ {
+		lines = append(lines, "\tat com.example.Loop.run(Loop.java:1)")
+	}
+	exceptions := parseLogExceptions(lines)
+	require.Len(t, exceptions, 1)
+	assert.Len(t, exceptions[0].Message, maxExceptionMessageLen)
+	assert.Len(t, exceptions[0].Frames, maxExceptionFrames)
+}
+
+type testLogParser struct{}
+
+func (testLogParser) Name() string { return "test" }
+
+func (testLogParser) Parse(lines []string) []LogException {
+	var exceptions []LogException
+	for _, line := range lines {
+		if message, ok := strings.CutPrefix(line, "OOPS "); ok {
+			exceptions = append(exceptions, LogException{Runtime: "test", Type: "oops", Message: message})
+		}
+	}
+	return exceptions
+}
+
+func TestRegisterLogParser(t *testing.T) {
+	logParsersMu.RLock()
+	previous := append([]LogParser(nil), logParsers...)
+	logParsersMu.RUnlock()
+	defer func() {
+		logParsersMu.Lock()
+		logParsers = previous
+		logParsersMu.Unlock()
+	}()
+
+	RegisterLogParser(testLogParser{})
+	RegisterLogParser(testLogParser{})
+	assert.Len(t, logParsers, len(previous)+1)
+	assert.Equal(t, []LogException{{Runtime: "test", Type: "oops", Message: "disk on fire"}},
+		parseLogExceptions([]string{"OOPS disk on fire"}))
+}
+
+func TestFormatExceptions(t *testing.T) {
+	assert.Equal(t, "No exceptions found in logs", formatExceptions(nil))
+	formatted := formatExceptions([]LogException{{
+		Runtime: LogRuntimeGo, Type: "panic", Message: "boom",
+		Frames: []StackFrame{{Function: "main.main", File: "/app/main.go", Line: 3}},
+	}})
+	assert.Equal(t, "- [go] panic: boom\n    at main.main (/app/main.go:3)", formatted)
+}
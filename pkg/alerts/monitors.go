@@ -0,0 +1,447 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// JobKindPodMonitor checks a monitored pod and queues the next check until the monitor ends
+const JobKindPodMonitor = "pod_monitor"
+
+const (
+	defaultMonitorDuration = time.Hour
+	maxMonitorDuration     = 24 * time.Hour
+	defaultMonitorInterval = time.Minute
+	// minMonitorInterval keeps monitors from polling the API server too often
+	minMonitorInterval = 10 * time.Second
+	// maxMonitorObservations bounds the stored observations; the oldest are dropped first
+	maxMonitorObservations = 200
+)
+
+// Statuses of a pod monitor
+const (
+	MonitorStatusActive    = "active"
+	MonitorStatusCompleted = "completed"
+	MonitorStatusCancelled = "cancelled"
+)
+
+// Types of the observations of a pod monitor
+const (
+	ObservationStatusChange = "status_change"
+	ObservationRestart      = "restart"
+	ObservationEvent        = "event"
+	ObservationUnavailable  = "unavailable"
+)
+
+// Verdicts of a finished pod monitor
+const (
+	MonitorVerdictStable      = "stable"
+	MonitorVerdictUnstable    = "unstable"
+	MonitorVerdictUnavailable = "unavailable"
+)
+
+// MonitorSnapshot is the state of a monitored pod at a check
+type MonitorSnapshot struct {
+	Phase        string `json:"phase"`
+	Ready        bool   `json:"ready"`
+	RestartCount int32  `json:"restart_count"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// String describes the snapshot, such as "Running, not ready (CrashLoopBackOff)"
+func (s MonitorSnapshot) String() string {
+	description := s.Phase + ", ready"
+	if !s.Ready {
+		description = s.Phase + ", not ready"
+	}
+	if s.Reason != "" {
+		description += " (" + s.Reason + ")"
+	}
+	return description
+}
+
+// snapshotFromHealth returns the monitor snapshot of a pod health check
+func snapshotFromHealth(health *podHealth) MonitorSnapshot {
+	return MonitorSnapshot{Phase: health.Phase, Ready: health.Ready, RestartCount: health.RestartCount, Reason: health.Reason}
+}
+
+// MonitorObservation is something a pod monitor noticed between two checks
+type MonitorObservation struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Message string    `json:"message"`
+}
+
+// MonitorOutcome summarizes what happened to a pod while it was monitored
+type MonitorOutcome struct {
+	Verdict       string          `json:"verdict"`
+	Restarts      int32           `json:"restarts"`
+	StatusChanges int             `json:"status_changes"`
+	WarningEvents int             `json:"warning_events"`
+	Final         MonitorSnapshot `json:"final"`
+	Summary       string          `json:"summary"`
+}
+
+// PodMonitor is a time-boxed watch of a pod. Checks run as background jobs
+// every interval until the monitor ends, recording status changes, restarts
+// and warning events, and the outcome is recorded and notified at the end.
+type PodMonitor struct {
+	ID        string `json:"id"`
+	Namespace string `json:"namespace"`
+	PodName   string `json:"pod_name"`
+	Status    string `json:"status"`
+	// Duration and Interval are Go durations, such as 1h0m0s
+	Duration  string    `json:"duration"`
+	Interval  string    `json:"interval"`
+	StartedAt time.Time `json:"started_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	// Baseline is the state of the pod when the monitor started, Last at the latest check
+	Baseline      MonitorSnapshot `json:"baseline"`
+	Last          MonitorSnapshot `json:"last"`
+	LastCheckedAt time.Time       `json:"last_checked_at"`
+	Checks        int             `json:"checks"`
+	// Unavailable is set while the pod cannot be fetched, such as after it was deleted
+	Unavailable   bool `json:"unavailable,omitempty"`
+	StatusChanges int  `json:"status_changes"`
+	WarningEvents int  `json:"warning_events"`
+	// Observations are the latest observations; DroppedObservations counts the older ones
+	Observations        []MonitorObservation `json:"observations"`
+	DroppedObservations int                  `json:"dropped_observations,omitempty"`
+	Outcome             *MonitorOutcome      `json:"outcome,omitempty"`
+	// JobID is the job running the next check
+	JobID      string       `json:"job_id,omitempty"`
+	Cluster    *ClusterInfo `json:"cluster,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+}
+
+// monitorPayload is the payload of a pod monitor check job
+type monitorPayload struct {
+	MonitorID string `json:"monitor_id"`
+}
+
+// observe adds an observation, dropping the oldest beyond maxMonitorObservations
+func (m *PodMonitor) observe(at time.Time, kind, format string, args ...interface{}) {
+	m.Observations = append(m.Observations, MonitorObservation{Time: at, Type: kind, Message: fmt.Sprintf(format, args...)})
+	if excess := len(m.Observations) - maxMonitorObservations; excess > 0 {
+		m.Observations = append([]MonitorObservation{}, m.Observations[excess:]...)
+		m.DroppedObservations += excess
+	}
+}
+
+// record updates the monitor with a check of the pod made at a time. A nil
+// health means the pod could not be fetched. Only warning events last seen
+// since the previous check are observed.
+func (m *PodMonitor) record(at time.Time, health *podHealth, healthErr error, events []PodEvent) {
+	m.Checks++
+	since := m.LastCheckedAt
+	m.LastCheckedAt = at
+
+	if health == nil {
+		if !m.Unavailable {
+			m.observe(at, ObservationUnavailable, "pod could not be fetched: %v", healthErr)
+		}
+		m.Unavailable = true
+		return
+	}
+	current := snapshotFromHealth(health)
+	if m.Unavailable {
+		m.observe(at, ObservationStatusChange, "pod is available again: %s", current)
+		m.Unavailable = false
+	}
+	if current.RestartCount > m.Last.RestartCount {
+		m.observe(at, ObservationRestart, "containers restarted %d times (%d since the monitor started)",
+			current.RestartCount-m.Last.RestartCount, current.RestartCount-m.Baseline.RestartCount)
+	}
+	if current.Phase != m.Last.Phase || current.Ready != m.Last.Ready || current.Reason != m.Last.Reason {
+		m.StatusChanges++
+		m.observe(at, ObservationStatusChange, "status changed from %s to %s", m.Last, current)
+	}
+	m.Last = current
+
+	for _, event := range events {
+		if event.Type != "Warning" {
+			continue
+		}
+		lastTime, err := time.Parse(time.RFC3339, event.LastTime)
+		if err != nil || !lastTime.After(since) {
+			continue
+		}
+		m.WarningEvents++
+		m.observe(lastTime, ObservationEvent, "%s: %s (count %d)", event.Reason, event.Message, event.Count)
+	}
+}
+
+// outcome summarizes the monitor as of its latest check
+func (m *PodMonitor) outcome() MonitorOutcome {
+	outcome := MonitorOutcome{
+		Verdict:       MonitorVerdictStable,
+		Restarts:      max(m.Last.RestartCount-m.Baseline.RestartCount, 0),
+		StatusChanges: m.StatusChanges,
+		WarningEvents: m.WarningEvents,
+		Final:         m.Last,
+	}
+	switch {
+	case m.Unavailable:
+		outcome.Verdict = MonitorVerdictUnavailable
+	case outcome.Restarts > 0 || outcome.WarningEvents > 0 || !m.Last.Ready:
+		outcome.Verdict = MonitorVerdictUnstable
+	}
+
+	watched := m.LastCheckedAt.Sub(m.StartedAt).Round(time.Second)
+	switch outcome.Verdict {
+	case MonitorVerdictUnavailable:
+		outcome.Summary = fmt.Sprintf("Pod %s/%s could no longer be fetched at the end of %s of monitoring; it may have been deleted or replaced", m.Namespace, m.PodName, watched)
+	case MonitorVerdictStable:
+		outcome.Summary = fmt.Sprintf("Pod %s/%s was stable over %s of monitoring: no restarts or warning events, ending %s", m.Namespace, m.PodName, watched, m.Last)
+	default:
+		outcome.Summary = fmt.Sprintf("Pod %s/%s was unstable over %s of monitoring: %d restarts, %d status changes and %d warning events, ending %s",
+			m.Namespace, m.PodName, watched, outcome.Restarts, outcome.StatusChanges, outcome.WarningEvents, m.Last)
+	}
+	return outcome
+}
+
+// scheduleMonitorCheck queues the next check of a monitor. Each check has its
+// own idempotency key, so a check resumed after a restart is not queued twice.
+func (a *AlertTool) scheduleMonitorCheck(ctx context.Context, monitor PodMonitor, at time.Time) (Job, error) {
+	payload, err := json.Marshal(monitorPayload{MonitorID: monitor.ID})
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to encode monitor check: %w", err)
+	}
+	return a.enqueueJob(ctx, Job{
+		Kind:           JobKindPodMonitor,
+		IdempotencyKey: fmt.Sprintf("%s/%s/%d", JobKindPodMonitor, monitor.ID, monitor.Checks+1),
+		Payload:        payload,
+		RunAfter:       at,
+	})
+}
+
+// runMonitorJob checks a monitored pod, then queues the next check or ends
+// the monitor once its time is up
+func (a *AlertTool) runMonitorJob(ctx context.Context, job Job) error {
+	var payload monitorPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid monitor payload: %w", err)
+	}
+
+	monitor, err := a.store.GetMonitor(ctx, payload.MonitorID)
+	if err != nil {
+		return fmt.Errorf("failed to load monitor %s: %w", payload.MonitorID, err)
+	}
+	// A cancelled or deleted monitor has nothing left to check
+	if monitor == nil || monitor.Status != MonitorStatusActive {
+		return nil
+	}
+
+	health, healthErr := a.getPodHealth(ctx, monitor.Namespace, monitor.PodName)
+	var events []PodEvent
+	if healthErr == nil {
+		if events, err = a.getPodEvents(ctx, monitor.PodName, monitor.Namespace); err != nil {
+			logger.Get().Info("Monitored pod events could not be fetched", "monitor", monitor.ID, "error", err)
+		}
+	}
+
+	a.monitorsMu.Lock()
+	defer a.monitorsMu.Unlock()
+	// The monitor may have been cancelled during the check
+	if current, err := a.store.GetMonitor(ctx, monitor.ID); err != nil || current == nil || current.Status != MonitorStatusActive {
+		return err
+	}
+
+	now := time.Now()
+	monitor.record(now, health, healthErr, events)
+	if !now.Before(monitor.EndsAt) {
+		return a.finishMonitor(ctx, monitor, MonitorStatusCompleted)
+	}
+
+	interval, err := time.ParseDuration(monitor.Interval)
+	if err != nil {
+		return fmt.Errorf("invalid monitor interval: %w", err)
+	}
+	next := now.Add(interval)
+	if next.After(monitor.EndsAt) {
+		next = monitor.EndsAt
+	}
+	nextJob, err := a.scheduleMonitorCheck(ctx, *monitor, next)
+	if err != nil {
+		return fmt.Errorf("failed to schedule the next monitor check: %w", err)
+	}
+	monitor.JobID = nextJob.ID
+	return a.store.UpdateMonitor(ctx, *monitor)
+}
+
+// finishMonitor ends a monitor with the given status, records its outcome and
+// notifies the webhooks and connected clients. The caller holds monitorsMu.
+func (a *AlertTool) finishMonitor(ctx context.Context, monitor *PodMonitor, status string) error {
+	now := time.Now()
+	outcome := monitor.outcome()
+	monitor.Status = status
+	monitor.Outcome = &outcome
+	monitor.FinishedAt = &now
+	monitor.JobID = ""
+	if err := a.store.UpdateMonitor(ctx, *monitor); err != nil {
+		return fmt.Errorf("failed to store monitor outcome: %w", err)
+	}
+
+	logger.Get().Info("Pod monitor finished", "monitor", monitor.ID, "pod", monitor.PodName, "namespace", monitor.Namespace,
+		"status", status, "verdict", outcome.Verdict)
+	a.notifier.Load().NotifyMonitor(ctx, *monitor)
+	a.clients.MonitorFinished(ctx, *monitor)
+	return nil
+}
+
+// handleMonitorPod starts a time-boxed watch of a pod
+func (a *AlertTool) handleMonitorPod(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	namespace := p.String("namespace", "", params.Required(), params.Check(security.ValidateNamespace))
+	podName := p.String("pod_name", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	duration := p.Duration("duration", defaultMonitorDuration)
+	interval := p.Duration("interval", defaultMonitorInterval)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if duration > maxMonitorDuration {
+		return mcp.NewToolResultError(fmt.Sprintf("duration must be at most %s", maxMonitorDuration)), nil
+	}
+	if interval < minMonitorInterval || interval > duration {
+		return mcp.NewToolResultError(fmt.Sprintf("interval must be between %s and the duration", minMonitorInterval)), nil
+	}
+
+	health, err := a.getPodHealth(ctx, namespace, podName)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get pod %s/%s: %v", namespace, podName, err)), nil
+	}
+
+	now := time.Now()
+	baseline := snapshotFromHealth(health)
+	monitor, err := a.store.CreateMonitor(ctx, PodMonitor{
+		Namespace:     namespace,
+		PodName:       podName,
+		Status:        MonitorStatusActive,
+		Duration:      duration.String(),
+		Interval:      interval.String(),
+		StartedAt:     now,
+		EndsAt:        now.Add(duration),
+		Baseline:      baseline,
+		Last:          baseline,
+		LastCheckedAt: now,
+		Observations:  []MonitorObservation{},
+		Cluster:       a.currentCluster(ctx),
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to store monitor: %v", err)), nil
+	}
+
+	a.monitorsMu.Lock()
+	job, err := a.scheduleMonitorCheck(ctx, monitor, now.Add(interval))
+	if err == nil {
+		monitor.JobID = job.ID
+		err = a.store.UpdateMonitor(ctx, monitor)
+	}
+	a.monitorsMu.Unlock()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to schedule monitor: %v", err)), nil
+	}
+	return marshalMonitor(monitor)
+}
+
+// MonitorList is the response of monitor_pod_status without a monitor ID
+type MonitorList struct {
+	Total    int          `json:"total"`
+	Monitors []PodMonitor `json:"monitors"`
+}
+
+// handleMonitorPodStatus returns a pod monitor with its observations, or lists the monitors
+func (a *AlertTool) handleMonitorPodStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	monitorID := p.String("monitor_id", "")
+	namespace := p.String("namespace", "", params.Check(security.ValidateNamespace))
+	status := p.String("status", "", params.OneOf(MonitorStatusActive, MonitorStatusCompleted, MonitorStatusCancelled))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if monitorID != "" {
+		monitor, err := a.store.GetMonitor(ctx, monitorID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to get monitor: %v", err)), nil
+		}
+		if monitor == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("monitor %s not found", monitorID)), nil
+		}
+		return marshalMonitor(*monitor)
+	}
+
+	monitors, err := a.store.ListMonitors(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list monitors: %v", err)), nil
+	}
+	list := MonitorList{Monitors: []PodMonitor{}}
+	for _, monitor := range monitors {
+		if (namespace != "" && monitor.Namespace != namespace) || (status != "" && monitor.Status != status) {
+			continue
+		}
+		list.Monitors = append(list.Monitors, monitor)
+	}
+	list.Total = len(list.Monitors)
+
+	listJSON, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal monitors: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(listJSON)), nil
+}
+
+// handleCancelMonitor ends an active pod monitor early, recording its outcome so far
+func (a *AlertTool) handleCancelMonitor(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	monitorID := p.String("monitor_id", "", params.Required())
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	a.monitorsMu.Lock()
+	defer a.monitorsMu.Unlock()
+	monitor, err := a.store.GetMonitor(ctx, monitorID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get monitor: %v", err)), nil
+	}
+	if monitor == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("monitor %s not found", monitorID)), nil
+	}
+	if monitor.Status != MonitorStatusActive {
+		return mcp.NewToolResultError(fmt.Sprintf("monitor %s is already %s", monitorID, monitor.Status)), nil
+	}
+
+	if monitor.JobID != "" {
+		// The check may be running; it stops once it sees the monitor cancelled
+		if _, err := a.cancelJob(ctx, monitor.JobID); err != nil {
+			logger.Get().Info("Monitor check was not cancelled", "monitor", monitorID, "job", monitor.JobID, "error", err)
+		}
+	}
+	if err := a.finishMonitor(ctx, monitor, MonitorStatusCancelled); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return marshalMonitor(*monitor)
+}
+
+// marshalMonitor returns a pod monitor as the JSON result of a tool
+func marshalMonitor(monitor PodMonitor) (*mcp.CallToolResult, error) {
+	monitorJSON, err := json.MarshalIndent(monitor, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal monitor: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(monitorJSON)), nil
+}
+
+// monitorStatusesDescription lists the monitor statuses in tool descriptions
+var monitorStatusesDescription = strings.Join([]string{MonitorStatusActive, MonitorStatusCompleted, MonitorStatusCancelled}, ", ")
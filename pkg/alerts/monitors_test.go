@@ -0,0 +1,168 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+func TestPodMonitorRecord(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	baseline := MonitorSnapshot{Phase: "Running", Ready: true, RestartCount: 1}
+	monitor := PodMonitor{Namespace: "prod", PodName: "web-1", StartedAt: start, Baseline: baseline, Last: baseline, LastCheckedAt: start}
+
+	monitor.record(start.Add(time.Minute), &podHealth{Phase: "Running", Ready: true, RestartCount: 1}, nil, nil)
+	assert.Empty(t, monitor.Observations)
+	assert.Equal(t, MonitorVerdictStable, monitor.outcome().Verdict)
+
+	monitor.record(start.Add(2*time.Minute), &podHealth{Phase: "Running", RestartCount: 3, Reason: "CrashLoopBackOff"}, nil, []PodEvent{
+		{Type: "Warning", Reason: "BackOff", Message: "Back-off restarting failed container", Count: 2, LastTime: start.Add(90 * time.Second).Format(time.RFC3339)},
+		{Type: "Warning", Reason: "Unhealthy", Message: "Readiness probe failed", LastTime: start.Add(-time.Hour).Format(time.RFC3339)},
+		{Type: "Normal", Reason: "Pulled", Message: "Container image pulled", LastTime: start.Add(90 * time.Second).Format(time.RFC3339)},
+	})
+	require.Len(t, monitor.Observations, 3)
+	assert.Equal(t, ObservationRestart, monitor.Observations[0].Type)
+	assert.Equal(t, "containers restarted 2 times (2 since the monitor started)", monitor.Observations[0].Message)
+	assert.Equal(t, "status changed from Running, ready to Running, not ready (CrashLoopBackOff)", monitor.Observations[1].Message)
+	assert.Equal(t, ObservationEvent, monitor.Observations[2].Type)
+
+	outcome := monitor.outcome()
+	assert.Equal(t, MonitorVerdictUnstable, outcome.Verdict)
+	assert.Equal(t, int32(2), outcome.Restarts)
+	assert.Equal(t, 1, outcome.StatusChanges)
+	assert.Equal(t, 1, outcome.WarningEvents)
+	assert.Contains(t, outcome.Summary, "unstable over 2m0s of monitoring: 2 restarts")
+
+	// A deleted pod is observed once, however many checks fail
+	monitor.record(start.Add(3*time.Minute), nil, errors.New("not found"), nil)
+	monitor.record(start.Add(4*time.Minute), nil, errors.New("not found"), nil)
+	require.Len(t, monitor.Observations, 4)
+	assert.Equal(t, ObservationUnavailable, monitor.Observations[3].Type)
+	assert.Equal(t, MonitorVerdictUnavailable, monitor.outcome().Verdict)
+	assert.Equal(t, 4, monitor.Checks)
+}
+
+func TestPodMonitorDropsOldestObservations(t *testing.T) {
+	var monitor PodMonitor
+	for i := 0; i < maxMonitorObservations+5; i++ {
+		monitor.observe(time.Now(), ObservationEvent, "event %d", i)
+	}
+	assert.Len(t, monitor.Observations, maxMonitorObservations)
+	assert.Equal(t, 5, monitor.DroppedObservations)
+	assert.Equal(t, "event 5", monitor.Observations[0].Message)
+}
+
+func callMonitorTool(t *testing.T, ctx context.Context, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}, v interface{}) *mcp.CallToolResult {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	result, err := handler(ctx, request)
+	require.NoError(t, err)
+	if !result.IsError && v != nil {
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), v))
+	}
+	return result
+}
+
+func TestMonitorPodLifecycle(t *testing.T) {
+	useKubeconfig(t, "")
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pod", "web-1", "-n", "prod", "-o", "json"}, podJSON("Running", true, 0), nil)
+	mock.AddCommandString("kubectl", []string{"get", "events", "-n", "prod", "--field-selector", "involvedObject.name=web-1", "-o", "json"}, `{"items": []}`, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	sender := &recordingSender{}
+	tool := NewAlertTool(nil).WithClientNotifier(&ClientNotifier{sender: sender})
+
+	var monitor PodMonitor
+	result := callMonitorTool(t, ctx, tool.handleMonitorPod, map[string]interface{}{"namespace": "prod", "pod_name": "web-1", "duration": "2h", "interval": "5m"}, &monitor)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	assert.Equal(t, MonitorStatusActive, monitor.Status)
+	assert.Equal(t, "2h0m0s", monitor.Duration)
+	assert.NotEmpty(t, monitor.JobID)
+
+	// The first check is due after the interval; run it now instead
+	job, err := tool.cancelJob(ctx, monitor.JobID)
+	require.NoError(t, err)
+	require.NoError(t, tool.runMonitorJob(ctx, *job))
+	checked, err := tool.store.GetMonitor(ctx, monitor.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, checked.Checks)
+	assert.NotEqual(t, monitor.JobID, checked.JobID, "the next check is queued")
+
+	var cancelled PodMonitor
+	callMonitorTool(t, ctx, tool.handleCancelMonitor, map[string]interface{}{"monitor_id": monitor.ID}, &cancelled)
+	tool.waitJobs()
+	assert.Equal(t, MonitorStatusCancelled, cancelled.Status)
+	require.NotNil(t, cancelled.Outcome)
+	assert.Equal(t, MonitorVerdictStable, cancelled.Outcome.Verdict)
+	next, err := tool.store.GetJob(ctx, checked.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusCancelled, next.Status)
+
+	events := sender.events()
+	require.Len(t, events, 1)
+	assert.Equal(t, AlertEventMonitorFinished, events[0]["event"])
+	assert.Equal(t, MonitorVerdictStable, events[0]["verdict"])
+
+	assert.True(t, callMonitorTool(t, ctx, tool.handleCancelMonitor, map[string]interface{}{"monitor_id": monitor.ID}, nil).IsError)
+
+	var list MonitorList
+	callMonitorTool(t, ctx, tool.handleMonitorPodStatus, map[string]interface{}{"status": MonitorStatusCancelled}, &list)
+	assert.Equal(t, 1, list.Total)
+	callMonitorTool(t, ctx, tool.handleMonitorPodStatus, map[string]interface{}{"namespace": "staging"}, &list)
+	assert.Zero(t, list.Total)
+}
+
+func TestMonitorPodCompletes(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pod", "web-1", "-n", "prod", "-o", "json"}, podJSON("Running", false, 4), nil)
+	mock.AddCommandString("kubectl", []string{"get", "events", "-n", "prod", "--field-selector", "involvedObject.name=web-1", "-o", "json"}, `{"items": []}`, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	tool := NewAlertTool(nil)
+
+	// A monitor whose time is up ends at its next check
+	start := time.Now().Add(-time.Hour)
+	baseline := MonitorSnapshot{Phase: "Running", Ready: true, RestartCount: 1}
+	monitor, err := tool.store.CreateMonitor(ctx, PodMonitor{Namespace: "prod", PodName: "web-1", Status: MonitorStatusActive,
+		Interval: "1m0s", StartedAt: start, EndsAt: start.Add(time.Hour), Baseline: baseline, Last: baseline, LastCheckedAt: start})
+	require.NoError(t, err)
+	payload, err := json.Marshal(monitorPayload{MonitorID: monitor.ID})
+	require.NoError(t, err)
+	require.NoError(t, tool.runMonitorJob(ctx, Job{Kind: JobKindPodMonitor, Payload: payload}))
+
+	finished, err := tool.store.GetMonitor(ctx, monitor.ID)
+	require.NoError(t, err)
+	assert.Equal(t, MonitorStatusCompleted, finished.Status)
+	require.NotNil(t, finished.Outcome)
+	assert.Equal(t, MonitorVerdictUnstable, finished.Outcome.Verdict)
+	assert.Equal(t, int32(3), finished.Outcome.Restarts)
+	assert.Empty(t, finished.JobID)
+	assert.NotNil(t, finished.FinishedAt)
+
+	// Checks of a finished monitor do nothing
+	require.NoError(t, tool.runMonitorJob(ctx, Job{Kind: JobKindPodMonitor, Payload: payload}))
+}
+
+func TestMonitorPodValidation(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pod", "gone", "-n", "prod", "-o", "json"}, "", errors.New("not found"))
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	tool := NewAlertTool(nil)
+
+	for _, args := range []map[string]interface{}{
+		{"namespace": "prod"},
+		{"namespace": "prod", "pod_name": "web-1", "duration": "48h"},
+		{"namespace": "prod", "pod_name": "web-1", "interval": "1s"},
+		{"namespace": "prod", "pod_name": "web-1", "duration": "5m", "interval": "10m"},
+		{"namespace": "prod", "pod_name": "gone"},
+	} {
+		assert.True(t, callMonitorTool(t, ctx, tool.handleMonitorPod, args, nil).IsError, args)
+	}
+}
@@ -0,0 +1,245 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/kagent-dev/tools/internal/tenancy"
+)
+
+// MethodNotificationAlertEvent is the custom MCP notification sent to connected
+// clients when background activity produces new alert information
+const MethodNotificationAlertEvent = "notifications/kagent/alert"
+
+// alertResourceScheme is the URI scheme of alert resources
+const alertResourceScheme = "alerts://"
+
+// Alert events published to MCP clients
+const (
+	AlertEventCollected           = "alert.collected"
+	AlertEventAnalyzed            = "alert.analyzed"
+	AlertEventRemediated          = "alert.remediated"
+	AlertEventRemediationVerified = "remediation.verified"
+	AlertEventOpsSummary          = "ops_summary.generated"
+	AlertEventMonitorFinished     = "pod_monitor.finished"
+)
+
+// alertEvents maps lifecycle states to the event published when an alert enters them
+var alertEvents = map[AlertState]string{
+	AlertStateCollected:  AlertEventCollected,
+	AlertStateAnalyzed:   AlertEventAnalyzed,
+	AlertStateRemediated: AlertEventRemediated,
+}
+
+// notificationSender sends notifications to connected MCP clients
+type notificationSender interface {
+	SendNotificationToAllClients(method string, params map[string]any)
+	SendNotificationToSpecificClient(sessionID string, method string, params map[string]any) error
+}
+
+// ClientNotifier pushes alert activity to connected MCP clients so they can
+// surface new alerts without polling. With tenancy enabled, activity is only
+// pushed to the sessions of the tenant whose storage prefix it belongs to.
+type ClientNotifier struct {
+	sender notificationSender
+	scoped bool
+}
+
+// NewClientNotifier creates a notifier that sends through the given MCP server
+func NewClientNotifier(s *server.MCPServer) *ClientNotifier {
+	return &ClientNotifier{sender: s, scoped: tenancy.Enabled()}
+}
+
+// clientSessions holds the storage prefix of the tenant of each connected session
+var clientSessions = struct {
+	sync.RWMutex
+	prefixes map[string]string
+}{prefixes: map[string]string{}}
+
+// TrackSession records the tenant of a session that connected, so that it is
+// only sent the alert activity of that tenant
+func TrackSession(ctx context.Context, session server.ClientSession) {
+	clientSessions.Lock()
+	defer clientSessions.Unlock()
+	clientSessions.prefixes[session.SessionID()] = tenancy.StoragePrefix(ctx)
+}
+
+// ForgetSession drops the tenant of a session that ended
+func ForgetSession(ctx context.Context, session server.ClientSession) {
+	clientSessions.Lock()
+	defer clientSessions.Unlock()
+	delete(clientSessions.prefixes, session.SessionID())
+}
+
+// send sends a notification to every client allowed to see activity stored
+// under the storage prefix of ctx
+func (n *ClientNotifier) send(ctx context.Context, method string, params map[string]any) {
+	if !n.scoped {
+		n.sender.SendNotificationToAllClients(method, params)
+		return
+	}
+
+	prefix := tenancy.StoragePrefix(ctx)
+	clientSessions.RLock()
+	var sessions []string
+	for id, sessionPrefix := range clientSessions.prefixes {
+		if sessionPrefix == prefix {
+			sessions = append(sessions, id)
+		}
+	}
+	clientSessions.RUnlock()
+
+	for _, id := range sessions {
+		// Sessions that have not finished initializing miss the notification
+		_ = n.sender.SendNotificationToSpecificClient(id, method, params)
+	}
+}
+
+// alertResourceURI returns the resource URI of a pod alert
+func alertResourceURI(namespace, podName string) string {
+	return alertResourceScheme + alertKey(namespace, podName)
+}
+
+// AlertChanged notifies clients that an alert entered a new lifecycle state
+func (n *ClientNotifier) AlertChanged(ctx context.Context, alert PodAlert, from AlertState) {
+	if n == nil {
+		return
+	}
+
+	uri := alertResourceURI(alert.Namespace, alert.PodName)
+	n.send(ctx, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+
+	params := map[string]any{
+		"event":     alertEvents[alert.State],
+		"uri":       uri,
+		"pod_name":  alert.PodName,
+		"namespace": alert.Namespace,
+		"state":     string(alert.State),
+		"from":      string(from),
+		"status":    alert.Status,
+		"reason":    alert.Reason,
+	}
+	if severity := alertSeverity(alert); severity != "" {
+		params["severity"] = severity
+	}
+	addClusterParams(params, alert.Cluster)
+	n.send(ctx, MethodNotificationAlertEvent, params)
+}
+
+// RemediationVerified notifies clients of the outcome of a remediation follow-up check
+func (n *ClientNotifier) RemediationVerified(ctx context.Context, namespace, podName string, record RemediationRecord) {
+	if n == nil {
+		return
+	}
+
+	uri := alertResourceURI(namespace, podName)
+	n.send(ctx, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+
+	params := map[string]any{
+		"event":          AlertEventRemediationVerified,
+		"uri":            uri,
+		"pod_name":       podName,
+		"namespace":      namespace,
+		"remediation_id": record.ID,
+		"verification":   record.Verification,
+		"recurred":       record.Recurred,
+		"details":        record.Details,
+	}
+	if record.Effectiveness != nil {
+		params["effectiveness"] = *record.Effectiveness
+	}
+	n.send(ctx, MethodNotificationAlertEvent, params)
+}
+
+// SummaryGenerated notifies clients that an operations summary was stored
+func (n *ClientNotifier) SummaryGenerated(ctx context.Context, summary OpsSummary, report IncidentReport) {
+	if n == nil {
+		return
+	}
+
+	params := map[string]any{
+		"event":      AlertEventOpsSummary,
+		"uri":        reportResourceURI(report.ID),
+		"report_id":  report.ID,
+		"title":      report.Title,
+		"period":     summary.Period,
+		"new_alerts": len(summary.NewAlerts),
+		"resolved":   len(summary.ResolvedIncidents),
+	}
+	addClusterParams(params, summary.Cluster)
+	n.send(ctx, MethodNotificationAlertEvent, params)
+}
+
+// MonitorFinished notifies clients of the outcome of a pod monitor
+func (n *ClientNotifier) MonitorFinished(ctx context.Context, monitor PodMonitor) {
+	if n == nil || monitor.Outcome == nil {
+		return
+	}
+
+	params := map[string]any{
+		"event":      AlertEventMonitorFinished,
+		"monitor_id": monitor.ID,
+		"pod_name":   monitor.PodName,
+		"namespace":  monitor.Namespace,
+		"status":     monitor.Status,
+		"verdict":    monitor.Outcome.Verdict,
+		"summary":    monitor.Outcome.Summary,
+	}
+	addClusterParams(params, monitor.Cluster)
+	n.send(ctx, MethodNotificationAlertEvent, params)
+}
+
+// addClusterParams adds the name and environment of a cluster to notification params
+func addClusterParams(params map[string]any, cluster *ClusterInfo) {
+	if cluster == nil {
+		return
+	}
+	params["cluster"] = cluster.Name
+	if cluster.Environment != "" {
+		params["environment"] = cluster.Environment
+	}
+}
+
+// alertSeverity returns the severity assigned by a valid analysis, if any
+func alertSeverity(alert PodAlert) string {
+	if alert.AnalysisResult == nil || !alert.AnalysisResult.Validation.Valid {
+		return ""
+	}
+	severity, _ := alert.AnalysisResult.Data["severity"].(string)
+	return severity
+}
+
+// handleReadAlertResource returns the stored document of the alert identified by the resource URI
+func (a *AlertTool) handleReadAlertResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	namespace, podName, found := strings.Cut(strings.TrimPrefix(request.Params.URI, alertResourceScheme), "/")
+	if !found || namespace == "" || podName == "" {
+		return nil, fmt.Errorf("invalid alert resource URI: %s", request.Params.URI)
+	}
+
+	doc, err := a.store.Get(ctx, namespace, podName)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("no alert found for %s", alertKey(namespace, podName))
+	}
+
+	docJSON, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(docJSON),
+		},
+	}, nil
+}
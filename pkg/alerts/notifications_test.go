@@ -0,0 +1,172 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/tenancy"
+)
+
+type sentNotification struct {
+	session string
+	method  string
+	params  map[string]any
+}
+
+// recordingSender records the notifications sent to clients
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []sentNotification
+}
+
+func (r *recordingSender) SendNotificationToAllClients(method string, params map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, sentNotification{method: method, params: params})
+}
+
+func (r *recordingSender) SendNotificationToSpecificClient(sessionID string, method string, params map[string]any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, sentNotification{session: sessionID, method: method, params: params})
+	return nil
+}
+
+// sessionsSent returns the sessions sent each notification, in order
+func (r *recordingSender) sessionsSent() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sessions []string
+	for _, n := range r.sent {
+		sessions = append(sessions, n.session)
+	}
+	return sessions
+}
+
+func (r *recordingSender) events() []map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []map[string]any
+	for _, n := range r.sent {
+		if n.method == MethodNotificationAlertEvent {
+			events = append(events, n.params)
+		}
+	}
+	return events
+}
+
+func TestTransitionNotifiesClientsOnStateChange(t *testing.T) {
+	sender := &recordingSender{}
+	tool := NewAlertTool(nil).WithClientNotifier(&ClientNotifier{sender: sender})
+	ctx := context.Background()
+
+	alert := PodAlert{PodName: "web-1", Namespace: "prod", Status: "CrashLoopBackOff"}
+	tool.transition(ctx, &alert, AlertStateCollected)
+
+	require.Len(t, sender.sent, 2)
+	assert.Equal(t, mcp.MethodNotificationResourceUpdated, sender.sent[0].method)
+	assert.Equal(t, "alerts://prod/web-1", sender.sent[0].params["uri"])
+
+	event := sender.sent[1].params
+	assert.Equal(t, AlertEventCollected, event["event"])
+	assert.Equal(t, "CrashLoopBackOff", event["status"])
+	assert.NotContains(t, event, "severity")
+
+	// Collecting the same alert again is not news to clients
+	again := PodAlert{PodName: "web-1", Namespace: "prod", Status: "CrashLoopBackOff"}
+	tool.transition(ctx, &again, AlertStateCollected)
+	assert.Len(t, sender.events(), 1)
+
+	alert.AnalysisResult = &AnalysisResult{
+		Data:       map[string]interface{}{"severity": "Critical"},
+		Validation: AnalysisValidation{Valid: true},
+	}
+	tool.transition(ctx, &alert, AlertStateAnalyzed)
+
+	events := sender.events()
+	require.Len(t, events, 2)
+	assert.Equal(t, AlertEventAnalyzed, events[1]["event"])
+	assert.Equal(t, "Collected", events[1]["from"])
+	assert.Equal(t, "Critical", events[1]["severity"])
+}
+
+func TestRemediationVerifiedNotification(t *testing.T) {
+	sender := &recordingSender{}
+	notifier := &ClientNotifier{sender: sender}
+	score := 1.0
+
+	notifier.RemediationVerified(context.Background(), "prod", "web-1", RemediationRecord{ID: "rem-1", Verification: VerificationResolved, Effectiveness: &score})
+
+	events := sender.events()
+	require.Len(t, events, 1)
+	assert.Equal(t, AlertEventRemediationVerified, events[0]["event"])
+	assert.Equal(t, "rem-1", events[0]["remediation_id"])
+	assert.Equal(t, 1.0, events[0]["effectiveness"])
+}
+
+func TestNilClientNotifier(t *testing.T) {
+	var notifier *ClientNotifier
+	notifier.AlertChanged(context.Background(), PodAlert{PodName: "web-1"}, "")
+	notifier.RemediationVerified(context.Background(), "prod", "web-1", RemediationRecord{})
+}
+
+// testSession is a client session with a fixed ID
+type testSession struct {
+	id string
+}
+
+func (s testSession) SessionID() string                                   { return s.id }
+func (s testSession) Initialize()                                         {}
+func (s testSession) Initialized() bool                                   { return true }
+func (s testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+
+func TestClientNotifierScopedToTenant(t *testing.T) {
+	teamA := tenancy.WithProfile(context.Background(), &tenancy.Profile{Name: "team-a", StoragePrefix: "team-a"})
+	teamB := tenancy.WithProfile(context.Background(), &tenancy.Profile{Name: "team-b", StoragePrefix: "team-b"})
+	for ctx, session := range map[context.Context]testSession{teamA: {id: "session-a"}, teamB: {id: "session-b"}} {
+		TrackSession(ctx, session)
+		t.Cleanup(func() { ForgetSession(ctx, session) })
+	}
+
+	sender := &recordingSender{}
+	notifier := &ClientNotifier{sender: sender, scoped: true}
+	notifier.AlertChanged(teamA, PodAlert{PodName: "web-1", Namespace: "team-a-prod", State: AlertStateCollected}, "")
+	notifier.RemediationVerified(teamA, "team-a-prod", "web-1", RemediationRecord{ID: "rem-1"})
+	notifier.SummaryGenerated(teamA, OpsSummary{}, IncidentReport{ID: "report-1"})
+	notifier.MonitorFinished(teamA, PodMonitor{ID: "monitor-1", Outcome: &MonitorOutcome{}})
+	// Activity of the default store belongs to no tenant
+	notifier.AlertChanged(context.Background(), PodAlert{PodName: "web-2", Namespace: "default"}, "")
+
+	assert.Equal(t, []string{"session-a", "session-a", "session-a", "session-a", "session-a", "session-a"}, sender.sessionsSent())
+	assert.NotContains(t, sender.sessionsSent(), "session-b")
+}
+
+func TestHandleReadAlertResource(t *testing.T) {
+	tool := NewAlertTool(nil)
+	ctx := context.Background()
+	require.NoError(t, tool.store.Upsert(ctx, PodAlert{PodName: "web-1", Namespace: "prod", State: AlertStateCollected}))
+
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = "alerts://prod/web-1"
+	contents, err := tool.handleReadAlertResource(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+
+	var doc AlertDocument
+	require.NoError(t, json.Unmarshal([]byte(contents[0].(mcp.TextResourceContents).Text), &doc))
+	assert.Equal(t, AlertStateCollected, doc.Alert.State)
+
+	req.Params.URI = "alerts://prod/missing"
+	_, err = tool.handleReadAlertResource(ctx, req)
+	assert.Error(t, err)
+
+	req.Params.URI = "alerts://prod"
+	_, err = tool.handleReadAlertResource(ctx, req)
+	assert.Error(t, err)
+}
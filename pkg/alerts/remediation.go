@@ -0,0 +1,280 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// AlertRemediationVerifyDelay configures how long to wait before re-checking a remediated pod
+const AlertRemediationVerifyDelay = "ALERT_REMEDIATION_VERIFY_DELAY"
+
+// defaultVerifyDelay is used when no delay is configured
+const defaultVerifyDelay = 5 * time.Minute
+
+// Verification outcomes of a remediation
+const (
+	VerificationPending  = "pending"
+	VerificationResolved = "resolved"
+	VerificationDegraded = "degraded"
+	VerificationRecurred = "recurred"
+	VerificationUnknown  = "unknown"
+)
+
+// RemediationRecord tracks a remediation and the outcome of its follow-up check
+type RemediationRecord struct {
+	ID               string     `json:"id"`
+	Remediation      string     `json:"remediation"`
+	AppliedAt        time.Time  `json:"applied_at"`
+	VerifyAfter      string     `json:"verify_after"`
+	BaselineRestarts int32      `json:"baseline_restarts"`
+	Verification     string     `json:"verification"`
+	VerifiedAt       *time.Time `json:"verified_at,omitempty"`
+	Recurred         bool       `json:"recurred"`
+	Effectiveness    *float64   `json:"effectiveness,omitempty"`
+	Details          string     `json:"details,omitempty"`
+	// SilencedBy is the ID of the silence active when the remediation was verified
+	SilencedBy string `json:"silenced_by,omitempty"`
+	// Execution records the Job that ran the remediation, when it was run by alerts_run_remediation
+	Execution *RemediationExecution `json:"execution,omitempty"`
+}
+
+// podHealth is a point-in-time health snapshot of a pod
+type podHealth struct {
+	Phase        string
+	Ready        bool
+	RestartCount int32
+	Reason       string
+}
+
+// verifyDelay returns the configured verification delay
+func verifyDelay() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(AlertRemediationVerifyDelay)); err == nil && value >= 0 {
+		return value
+	}
+	return defaultVerifyDelay
+}
+
+// getPodHealth fetches the current health of a pod
+func (a *AlertTool) getPodHealth(ctx context.Context, namespace, podName string) (*podHealth, error) {
+	output, err := a.runKubectlCommandString(ctx, "get", "pod", podName, "-n", namespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	var pod struct {
+		Status struct {
+			Phase             string `json:"phase"`
+			ContainerStatuses []struct {
+				Ready        bool  `json:"ready"`
+				RestartCount int32 `json:"restartCount"`
+				State        struct {
+					Waiting struct {
+						Reason string `json:"reason"`
+					} `json:"waiting"`
+				} `json:"state"`
+				LastState struct {
+					Terminated struct {
+						Reason string `json:"reason"`
+					} `json:"terminated"`
+				} `json:"lastState"`
+			} `json:"containerStatuses"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(output), &pod); err != nil {
+		return nil, fmt.Errorf("failed to parse pod: %w", err)
+	}
+
+	health := &podHealth{Phase: pod.Status.Phase, Ready: len(pod.Status.ContainerStatuses) > 0}
+	for _, container := range pod.Status.ContainerStatuses {
+		health.RestartCount += container.RestartCount
+		if !container.Ready {
+			health.Ready = false
+			if container.State.Waiting.Reason != "" {
+				health.Reason = container.State.Waiting.Reason
+			}
+		}
+		if health.Reason == "" && container.LastState.Terminated.Reason != "" {
+			health.Reason = container.LastState.Terminated.Reason
+		}
+	}
+	return health, nil
+}
+
+// scoreRemediation compares the pod health after a remediation with the
+// restart count recorded when it was applied
+func scoreRemediation(record RemediationRecord, health *podHealth) RemediationRecord {
+	now := time.Now()
+	record.VerifiedAt = &now
+
+	if health == nil {
+		record.Verification = VerificationUnknown
+		record.Details = "pod no longer exists, it may have been replaced by its controller"
+		return record
+	}
+
+	var score float64
+	switch {
+	case !health.Ready || health.Phase != "Running":
+		record.Verification = VerificationRecurred
+		record.Recurred = true
+		score = 0
+		record.Details = fmt.Sprintf("pod is %s and not ready", health.Phase)
+		if health.Reason != "" {
+			record.Details += fmt.Sprintf(" (%s)", health.Reason)
+		}
+	case health.RestartCount > record.BaselineRestarts:
+		record.Verification = VerificationDegraded
+		record.Recurred = true
+		score = 0.5
+		record.Details = fmt.Sprintf("pod is ready but restarted %d more time(s)", health.RestartCount-record.BaselineRestarts)
+	default:
+		record.Verification = VerificationResolved
+		score = 1
+		record.Details = "pod is running and ready with no new restarts"
+	}
+
+	record.Effectiveness = &score
+	return record
+}
+
+// scheduleVerification queues a job that re-checks the pod once the delay has
+// elapsed and stores the outcome. The job is persisted with the alert store, so
+// the check still runs when the server restarts before it is due.
+func (a *AlertTool) scheduleVerification(ctx context.Context, namespace, podName string, record RemediationRecord, delay time.Duration, idempotencyKey string) (Job, error) {
+	payload, err := json.Marshal(verificationPayload{Namespace: namespace, PodName: podName, Record: record})
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to encode verification: %w", err)
+	}
+	if idempotencyKey == "" {
+		idempotencyKey = JobKindRemediationVerification + "/" + record.ID
+	}
+	return a.enqueueJob(ctx, Job{
+		Kind:           JobKindRemediationVerification,
+		IdempotencyKey: idempotencyKey,
+		Payload:        payload,
+		RunAfter:       time.Now().Add(delay),
+	})
+}
+
+// existingRemediation returns the response of the remediation recorded by a
+// job of kind with an idempotency key, or nil if the key has not been used
+func (a *AlertTool) existingRemediation(ctx context.Context, kind, idempotencyKey string) (*mcp.CallToolResult, error) {
+	jobs, err := a.store.ListJobs(ctx, "")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list jobs: %v", err)), nil
+	}
+
+	for _, job := range jobs {
+		if job.Kind != kind || job.IdempotencyKey != idempotencyKey {
+			continue
+		}
+		var payload verificationPayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to read job %s: %v", job.ID, err)), nil
+		}
+
+		// The stored record carries the verification outcome once the job has run
+		alert := PodAlert{PodName: payload.PodName, Namespace: payload.Namespace}
+		record := payload.Record
+		doc, err := a.store.Get(ctx, payload.Namespace, payload.PodName)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to load alert: %v", err)), nil
+		}
+		if doc != nil {
+			alert = doc.Alert
+			for _, stored := range doc.Remediations {
+				if stored.ID == record.ID {
+					record = stored
+				}
+			}
+		}
+
+		alertJSON, err := json.MarshalIndent(map[string]interface{}{
+			"alert":       alert,
+			"remediation": record,
+			"job_id":      job.ID,
+		}, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal alert: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(alertJSON)), nil
+	}
+	return nil, nil
+}
+
+// RemediationHistoryEntry is a remediation together with the alert it belongs to
+type RemediationHistoryEntry struct {
+	PodName   string `json:"pod_name"`
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster,omitempty"`
+	RemediationRecord
+}
+
+// RemediationHistory is the structured response of alerts_remediation_history
+type RemediationHistory struct {
+	Total                int                       `json:"total"`
+	Verified             int                       `json:"verified"`
+	Recurred             int                       `json:"recurred"`
+	AverageEffectiveness *float64                  `json:"average_effectiveness,omitempty"`
+	Remediations         []RemediationHistoryEntry `json:"remediations"`
+}
+
+// handleRemediationHistory lists stored remediations and their effectiveness
+func (a *AlertTool) handleRemediationHistory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	podName := p.String("pod_name", "")
+	namespace := p.String("namespace", "")
+	cluster := p.String("cluster", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	docs, err := a.store.List(ctx, namespace)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list alerts: %v", err)), nil
+	}
+	docs = filterCluster(docs, cluster)
+
+	history := RemediationHistory{Remediations: []RemediationHistoryEntry{}}
+	var scoreSum float64
+	for _, doc := range docs {
+		if podName != "" && doc.Alert.PodName != podName {
+			continue
+		}
+		for _, record := range doc.Remediations {
+			history.Remediations = append(history.Remediations, RemediationHistoryEntry{
+				PodName:           doc.Alert.PodName,
+				Namespace:         doc.Alert.Namespace,
+				Cluster:           alertCluster(doc.Alert),
+				RemediationRecord: record,
+			})
+			if record.Effectiveness != nil {
+				history.Verified++
+				scoreSum += *record.Effectiveness
+			}
+			if record.Recurred {
+				history.Recurred++
+			}
+		}
+	}
+
+	history.Total = len(history.Remediations)
+	if history.Verified > 0 {
+		average := scoreSum / float64(history.Verified)
+		history.AverageEffectiveness = &average
+	}
+
+	historyJSON, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal remediation history: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(historyJSON)), nil
+}
@@ -0,0 +1,162 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/kagent-dev/tools/internal/tenancy"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func podJSON(phase string, ready bool, restarts int) string {
+	status := map[string]interface{}{
+		"phase": phase,
+		"containerStatuses": []map[string]interface{}{
+			{"ready": ready, "restartCount": restarts},
+		},
+	}
+	data, _ := json.Marshal(map[string]interface{}{"status": status})
+	return string(data)
+}
+
+func TestScoreRemediation(t *testing.T) {
+	record := RemediationRecord{BaselineRestarts: 3}
+
+	resolved := scoreRemediation(record, &podHealth{Phase: "Running", Ready: true, RestartCount: 3})
+	assert.Equal(t, VerificationResolved, resolved.Verification)
+	assert.Equal(t, 1.0, *resolved.Effectiveness)
+	assert.False(t, resolved.Recurred)
+
+	degraded := scoreRemediation(record, &podHealth{Phase: "Running", Ready: true, RestartCount: 5})
+	assert.Equal(t, VerificationDegraded, degraded.Verification)
+	assert.Equal(t, 0.5, *degraded.Effectiveness)
+	assert.True(t, degraded.Recurred)
+
+	recurred := scoreRemediation(record, &podHealth{Phase: "Running", Ready: false, Reason: "CrashLoopBackOff"})
+	assert.Equal(t, VerificationRecurred, recurred.Verification)
+	assert.Equal(t, 0.0, *recurred.Effectiveness)
+	assert.Contains(t, recurred.Details, "CrashLoopBackOff")
+
+	unknown := scoreRemediation(record, nil)
+	assert.Equal(t, VerificationUnknown, unknown.Verification)
+	assert.Nil(t, unknown.Effectiveness)
+	assert.NotNil(t, unknown.VerifiedAt)
+}
+
+func TestRemediationVerificationLoop(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pod", "web-1", "-n", "prod", "-o", "json"}, podJSON("Running", true, 2), nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	tool := NewAlertTool(nil)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"pod_name":     "web-1",
+		"namespace":    "prod",
+		"remediation":  "raised memory limit",
+		"verify_after": "0s",
+	}
+	result, err := tool.handleMarkRemediated(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	tool.waitJobs()
+
+	result, err = tool.handleRemediationHistory(ctx, mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	var history RemediationHistory
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &history))
+	require.Equal(t, 1, history.Total)
+	assert.Equal(t, 1, history.Verified)
+	assert.Equal(t, 1.0, *history.AverageEffectiveness)
+
+	entry := history.Remediations[0]
+	assert.Equal(t, "web-1", entry.PodName)
+	assert.Equal(t, int32(2), entry.BaselineRestarts)
+	assert.Equal(t, VerificationResolved, entry.Verification)
+
+	request = mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"cluster": "elsewhere"}
+	result, err = tool.handleRemediationHistory(ctx, request)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &history))
+	assert.Zero(t, history.Total, "remediations of other clusters are left out")
+
+	doc, err := tool.store.Get(ctx, "prod", "web-1")
+	require.NoError(t, err)
+	assert.Equal(t, AlertStateRemediated, doc.Alert.State)
+	assert.Equal(t, "raised memory limit", doc.Alert.Remediation)
+}
+
+func TestHandleMarkRemediatedInvalidDelay(t *testing.T) {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"pod_name":     "web-1",
+		"verify_after": "soon",
+	}
+
+	result, err := NewAlertTool(nil).handleMarkRemediated(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestMemoryAlertStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryAlertStore()
+
+	require.NoError(t, store.Upsert(ctx, PodAlert{PodName: "b", Namespace: "ns1", State: AlertStateCollected}))
+	require.NoError(t, store.Upsert(ctx, PodAlert{PodName: "a", Namespace: "ns1", State: AlertStateCollected}))
+	require.NoError(t, store.Upsert(ctx, PodAlert{PodName: "c", Namespace: "ns2", State: AlertStateCollected}))
+
+	id, err := store.AddRemediation(ctx, "ns1", "a", RemediationRecord{Remediation: "restart"})
+	require.NoError(t, err)
+
+	// Upserting keeps the remediation history
+	require.NoError(t, store.Upsert(ctx, PodAlert{PodName: "a", Namespace: "ns1", State: AlertStateAnalyzed}))
+	doc, err := store.Get(ctx, "ns1", "a")
+	require.NoError(t, err)
+	assert.Equal(t, AlertStateAnalyzed, doc.Alert.State)
+	require.Len(t, doc.Remediations, 1)
+	assert.Equal(t, id, doc.Remediations[0].ID)
+
+	require.NoError(t, store.UpdateRemediation(ctx, "ns1", "a", RemediationRecord{ID: id, Verification: VerificationResolved}))
+	assert.Error(t, store.UpdateRemediation(ctx, "ns1", "a", RemediationRecord{ID: "missing"}))
+	assert.Error(t, store.UpdateRemediation(ctx, "ns3", "x", RemediationRecord{ID: id}))
+
+	docs, err := store.List(ctx, "ns1")
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "a", docs[0].Alert.PodName)
+	assert.Equal(t, VerificationResolved, docs[0].Remediations[0].Verification)
+
+	missing, err := store.Get(ctx, "ns1", "missing")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestMemoryAlertStoreTenantIsolation(t *testing.T) {
+	store := NewMemoryAlertStore()
+	teamA := tenancy.WithProfile(context.Background(), &tenancy.Profile{Name: "team-a", StoragePrefix: "team-a"})
+	teamB := tenancy.WithProfile(context.Background(), &tenancy.Profile{Name: "team-b", StoragePrefix: "team-b"})
+
+	require.NoError(t, store.Upsert(teamA, PodAlert{PodName: "web-1", Namespace: "shared", State: AlertStateCollected}))
+
+	doc, err := store.Get(teamB, "shared", "web-1")
+	require.NoError(t, err)
+	assert.Nil(t, doc)
+
+	docs, err := store.List(teamB, "")
+	require.NoError(t, err)
+	assert.Empty(t, docs)
+	assert.Error(t, store.UpdateRemediation(teamB, "shared", "web-1", RemediationRecord{ID: "rem-1"}))
+
+	docs, err = store.List(teamA, "")
+	require.NoError(t, err)
+	assert.Len(t, docs, 1)
+}
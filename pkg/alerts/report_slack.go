@@ -0,0 +1,168 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Slack Block Kit limits on text lengths
+const (
+	slackHeaderLimit  = 150
+	slackSectionLimit = 3000
+)
+
+// slackText is a Block Kit text object
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackBlock is a Block Kit layout block
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+// slackMessage is a Block Kit message; Text is the fallback shown in notifications
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// slackEscape escapes the control characters of Slack mrkdwn
+func slackEscape(value string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(value)
+}
+
+// slackTruncate shortens text to at most limit characters
+func slackTruncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// slackSection is a mrkdwn section block
+func slackSection(text string) slackBlock {
+	return slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: slackTruncate(text, slackSectionLimit)}}
+}
+
+// slackField is a labelled mrkdwn field of a section block
+func slackField(label, value string) slackText {
+	return slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", label, value)}
+}
+
+// slackLogs renders the log excerpt as a code block, dropping the earliest
+// lines that do not fit into a section
+func slackLogs(data reportData) string {
+	logs, omitted := data.Logs, data.OmittedLogs
+	for {
+		text := "*Logs*\n"
+		if omitted > 0 {
+			text += fmt.Sprintf("_%d earlier lines omitted._\n", omitted)
+		}
+		text += "```\n" + slackEscape(strings.Join(logs, "\n")) + "\n```"
+		if len([]rune(text)) <= slackSectionLimit || len(logs) <= 1 {
+			return text
+		}
+		logs = logs[1:]
+		omitted++
+	}
+}
+
+// renderSlackReport renders report data as a Slack Block Kit message
+func renderSlackReport(w io.Writer, data reportData) error {
+	fields := []slackText{
+		slackField("Pod", fmt.Sprintf("`%s/%s`", slackEscape(data.Alert.Namespace), slackEscape(data.Alert.PodName))),
+		slackField("Status", slackEscape(data.Alert.Status)),
+		slackField("Reason", slackEscape(data.Alert.Reason)),
+		slackField("State", string(data.Alert.State)),
+	}
+	if cluster := data.Alert.Cluster; cluster != nil {
+		fields = append(fields, slackField("Cluster", slackEscape(cluster.Label())))
+		if cluster.Team != "" {
+			fields = append(fields, slackField("Team", slackEscape(cluster.Team)))
+		}
+	}
+	if data.Severity != "" {
+		fields = append(fields, slackField("Severity", slackEscape(data.Severity)))
+	}
+	fields = append(fields,
+		slackField("Restarts", fmt.Sprint(data.Alert.RestartCount)),
+		slackField("First seen", data.FirstSeen),
+		slackField("Last updated", data.LastUpdated),
+	)
+
+	blocks := []slackBlock{
+		{Type: "header", Text: &slackText{Type: "plain_text", Text: slackTruncate(data.Title, slackHeaderLimit)}},
+		{Type: "section", Fields: fields},
+	}
+	if data.Alert.Message != "" {
+		blocks = append(blocks, slackSection(">"+strings.ReplaceAll(slackEscape(strings.TrimSpace(data.Alert.Message)), "\n", "\n>")))
+	}
+	blocks = append(blocks, slackBlock{Type: "divider"})
+
+	var analysis strings.Builder
+	analysis.WriteString("*Analysis*\n")
+	if !data.HasAnalysis {
+		analysis.WriteString("No analysis is available for this alert.")
+	}
+	if data.Summary != "" {
+		fmt.Fprintf(&analysis, "*Summary:* %s\n", slackEscape(data.Summary))
+	}
+	if data.RootCause != "" {
+		fmt.Fprintf(&analysis, "*Root cause:* %s\n", slackEscape(data.RootCause))
+	}
+	if data.Analysis != "" && data.Summary == "" {
+		fmt.Fprintf(&analysis, "%s\n", slackEscape(data.Analysis))
+	}
+	if len(data.Steps) > 0 {
+		analysis.WriteString("*Recommended remediation*\n")
+		for i, step := range data.Steps {
+			fmt.Fprintf(&analysis, "%d. %s\n", i+1, slackEscape(step))
+		}
+	}
+	if len(data.Prevention) > 0 {
+		analysis.WriteString("*Prevention*\n")
+		for _, item := range data.Prevention {
+			fmt.Fprintf(&analysis, "• %s\n", slackEscape(item))
+		}
+	}
+	blocks = append(blocks, slackSection(strings.TrimSpace(analysis.String())))
+
+	var timeline strings.Builder
+	timeline.WriteString("*Timeline*")
+	for _, entry := range data.Timeline {
+		fmt.Fprintf(&timeline, "\n`%s` %s: %s", entry.Time, entry.Source, slackEscape(entry.Description))
+	}
+	blocks = append(blocks, slackSection(timeline.String()))
+
+	if len(data.Logs) > 0 {
+		blocks = append(blocks, slackSection(slackLogs(data)))
+	} else {
+		blocks = append(blocks, slackSection("*Logs*\nNo logs were collected."))
+	}
+
+	var remediation strings.Builder
+	remediation.WriteString("*Remediation*")
+	if !data.HasRemediation {
+		remediation.WriteString("\nNo remediation has been recorded.")
+	}
+	for _, record := range data.Remediations {
+		fmt.Fprintf(&remediation, "\n`%s` %s (%s, effectiveness %s)", reportTime(record.AppliedAt), slackEscape(record.Remediation), record.Verification, effectiveness(record.Effectiveness))
+	}
+	blocks = append(blocks,
+		slackSection(remediation.String()),
+		slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: "Generated " + data.GeneratedAt}}},
+	)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(slackMessage{Text: data.Title, Blocks: blocks})
+}
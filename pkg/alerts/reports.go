@@ -0,0 +1,575 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// reportResourceScheme is the URI scheme of incident report resources
+const reportResourceScheme = "incident-reports://"
+
+// defaultReportLogLines is the number of log lines excerpted when none is given
+const defaultReportLogLines = 20
+
+// Incident report formats
+const (
+	ReportFormatMarkdown = "markdown"
+	ReportFormatHTML     = "html"
+	ReportFormatText     = "text"
+	// ReportFormatSlack renders Slack Block Kit JSON that can be posted with chat.postMessage
+	ReportFormatSlack = "slack"
+)
+
+// reportMIMETypes maps report formats to the MIME type of their resource
+var reportMIMETypes = map[string]string{
+	ReportFormatMarkdown: "text/markdown",
+	ReportFormatHTML:     "text/html",
+	ReportFormatText:     "text/plain",
+	ReportFormatSlack:    "application/json",
+}
+
+// reportFormats lists the supported report formats
+var reportFormats = []string{ReportFormatMarkdown, ReportFormatHTML, ReportFormatText, ReportFormatSlack}
+
+// IncidentReport is a rendered incident report
+type IncidentReport struct {
+	ID        string    `json:"id"`
+	Namespace string    `json:"namespace"`
+	PodName   string    `json:"pod_name"`
+	Title     string    `json:"title"`
+	Format    string    `json:"format"`
+	MIMEType  string    `json:"mime_type"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TimelineEntry is a single point in an incident timeline
+type TimelineEntry struct {
+	Time        string
+	Source      string
+	Description string
+}
+
+// reportData is the input of the incident report templates
+type reportData struct {
+	Title          string
+	GeneratedAt    string
+	Alert          PodAlert
+	Severity       string
+	Summary        string
+	RootCause      string
+	Analysis       string
+	Steps          []string
+	Prevention     []string
+	Timeline       []TimelineEntry
+	Logs           []string
+	OmittedLogs    int
+	Remediations   []RemediationRecord
+	FirstSeen      string
+	LastUpdated    string
+	HasAnalysis    bool
+	HasRemediation bool
+}
+
+// reportTime formats a timestamp for reports
+func reportTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// stringList returns the string items of an analysis field
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if text, ok := item.(string); ok {
+			list = append(list, text)
+		}
+	}
+	return list
+}
+
+// buildReportData gathers the analysis, timeline, log excerpt and remediations of a stored alert
+func buildReportData(doc *AlertDocument, title string, maxLogLines int, now time.Time) reportData {
+	alert := doc.Alert
+	if title == "" {
+		title = fmt.Sprintf("Incident report: %s/%s", alert.Namespace, alert.PodName)
+	}
+
+	data := reportData{
+		Title:        title,
+		GeneratedAt:  reportTime(now),
+		Alert:        alert,
+		Severity:     alertSeverity(alert),
+		Analysis:     alert.Analysis,
+		Remediations: doc.Remediations,
+		FirstSeen:    reportTime(doc.CreatedAt),
+		LastUpdated:  reportTime(doc.UpdatedAt),
+	}
+
+	if result := alert.AnalysisResult; result != nil && result.Validation.Valid {
+		data.Summary, _ = result.Data["summary"].(string)
+		data.RootCause, _ = result.Data["root_cause"].(string)
+		data.Steps = result.RemediationSteps()
+		data.Prevention = stringList(result.Data["prevention"])
+	} else if alert.Remediation != "" && alert.State != AlertStateRemediated {
+		data.Steps = strings.Split(alert.Remediation, "\n")
+	}
+	data.HasAnalysis = data.Summary != "" || data.Analysis != "" || len(data.Steps) > 0
+
+	for _, event := range alert.Events {
+		timestamp := event.FirstTime
+		if timestamp == "" {
+			timestamp = event.LastTime
+		}
+		description := fmt.Sprintf("%s %s: %s", event.Type, event.Reason, event.Message)
+		if event.Count > 1 {
+			description += fmt.Sprintf(" (x%d, last %s)", event.Count, event.LastTime)
+		}
+		data.Timeline = append(data.Timeline, TimelineEntry{Time: timestamp, Source: "event", Description: description})
+	}
+	data.Timeline = append(data.Timeline, TimelineEntry{Time: data.FirstSeen, Source: "alert", Description: "Alert first recorded"})
+	for _, record := range doc.Remediations {
+		data.Timeline = append(data.Timeline, TimelineEntry{
+			Time:        reportTime(record.AppliedAt),
+			Source:      "remediation",
+			Description: "Remediation applied: " + record.Remediation,
+		})
+		if record.VerifiedAt != nil {
+			description := "Remediation verified: " + record.Verification
+			if record.Details != "" {
+				description += " (" + record.Details + ")"
+			}
+			data.Timeline = append(data.Timeline, TimelineEntry{Time: reportTime(*record.VerifiedAt), Source: "verification", Description: description})
+		}
+	}
+	sort.SliceStable(data.Timeline, func(i, j int) bool {
+		return data.Timeline[i].Time < data.Timeline[j].Time
+	})
+
+	logs := alert.Logs
+	if maxLogLines > 0 && len(logs) > maxLogLines {
+		data.OmittedLogs = len(logs) - maxLogLines
+		logs = logs[len(logs)-maxLogLines:]
+	}
+	data.Logs = logs
+	data.HasRemediation = len(doc.Remediations) > 0
+
+	return data
+}
+
+// effectiveness formats a remediation effectiveness score
+func effectiveness(score *float64) string {
+	if score == nil {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.0f%%", *score*100)
+}
+
+// markdownCell escapes a value for use in a Markdown table cell
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// markdownQuote renders a possibly multi-line value as a Markdown blockquote
+func markdownQuote(value string) string {
+	lines := strings.Split(strings.TrimSpace(value), "\n")
+	return "> " + strings.Join(lines, "\n> ")
+}
+
+var reportFuncs = map[string]interface{}{
+	"cell":          markdownCell,
+	"effectiveness": effectiveness,
+	"inc":           func(i int) int { return i + 1 },
+	"quote":         markdownQuote,
+	"time":          reportTime,
+}
+
+var markdownReportTemplate = texttemplate.Must(texttemplate.New("markdown").Funcs(reportFuncs).Parse(`# {{ .Title }}
+
+| | |
+|---|---|
+| Pod | ` + "`{{ .Alert.Namespace }}/{{ .Alert.PodName }}`" + ` |
+{{- with .Alert.Cluster }}
+| Cluster | {{ cell .Label }} |
+{{- if .Team }}
+| Team | {{ cell .Team }} |
+{{- end }}
+{{- end }}
+| Status | {{ cell .Alert.Status }} |
+| Reason | {{ cell .Alert.Reason }} |
+| State | {{ .Alert.State }} |
+{{- if .Severity }}
+| Severity | {{ .Severity }} |
+{{- end }}
+| Restarts | {{ .Alert.RestartCount }} |
+| First seen | {{ .FirstSeen }} |
+| Last updated | {{ .LastUpdated }} |
+{{ if .Alert.Message }}
+{{ quote .Alert.Message }}
+{{ end }}
+## Analysis
+{{ if not .HasAnalysis }}
+No analysis is available for this alert.
+{{ else }}
+{{- if .Summary }}
+**Summary:** {{ .Summary }}
+{{ end }}
+{{- if .RootCause }}
+**Root cause:** {{ .RootCause }}
+{{ end }}
+{{- if and .Analysis (not .Summary) }}
+{{ .Analysis }}
+{{ end }}
+{{- if .Steps }}
+### Recommended remediation
+{{ range .Steps }}
+1. {{ . }}
+{{- end }}
+{{ end }}
+{{- if .Prevention }}
+### Prevention
+{{ range .Prevention }}
+- {{ . }}
+{{- end }}
+{{ end }}
+{{- end }}
+## Timeline
+
+| Time | Source | Description |
+|---|---|---|
+{{- range .Timeline }}
+| {{ .Time }} | {{ .Source }} | {{ cell .Description }} |
+{{- end }}
+
+## Logs
+{{ if .Logs }}
+{{- if .OmittedLogs }}
+_{{ .OmittedLogs }} earlier lines omitted._
+{{ end }}
+` + "```" + `
+{{- range .Logs }}
+{{ . }}
+{{- end }}
+` + "```" + `
+{{ else }}
+No logs were collected.
+{{ end }}
+## Remediation
+{{ if not .HasRemediation }}
+No remediation has been recorded.
+{{ else }}
+| Applied | Remediation | Verification | Effectiveness |
+|---|---|---|---|
+{{- range .Remediations }}
+| {{ time .AppliedAt }} | {{ cell .Remediation }} | {{ .Verification }} | {{ effectiveness .Effectiveness }} |
+{{- end }}
+{{ end }}
+---
+_Generated {{ .GeneratedAt }}_
+`))
+
+var htmlReportTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(reportFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 960px; margin: 2em auto; color: #1f2328; line-height: 1.5; }
+h1 { border-bottom: 1px solid #d0d7de; padding-bottom: .3em; }
+table { border-collapse: collapse; width: 100%; margin: 1em 0; }
+th, td { border: 1px solid #d0d7de; padding: 6px 12px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+blockquote { border-left: 4px solid #d0d7de; margin: 0; padding: 0 1em; color: #59636e; white-space: pre-wrap; }
+.severity { font-weight: bold; }
+footer { color: #59636e; font-size: .85em; margin-top: 2em; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<table>
+<tr><th>Pod</th><td><code>{{ .Alert.Namespace }}/{{ .Alert.PodName }}</code></td></tr>
+{{- with .Alert.Cluster }}
+<tr><th>Cluster</th><td>{{ .Label }}</td></tr>
+{{- if .Team }}
+<tr><th>Team</th><td>{{ .Team }}</td></tr>
+{{- end }}
+{{- end }}
+<tr><th>Status</th><td>{{ .Alert.Status }}</td></tr>
+<tr><th>Reason</th><td>{{ .Alert.Reason }}</td></tr>
+<tr><th>State</th><td>{{ .Alert.State }}</td></tr>
+{{- if .Severity }}
+<tr><th>Severity</th><td class="severity">{{ .Severity }}</td></tr>
+{{- end }}
+<tr><th>Restarts</th><td>{{ .Alert.RestartCount }}</td></tr>
+<tr><th>First seen</th><td>{{ .FirstSeen }}</td></tr>
+<tr><th>Last updated</th><td>{{ .LastUpdated }}</td></tr>
+</table>
+{{- if .Alert.Message }}
+<blockquote>{{ .Alert.Message }}</blockquote>
+{{- end }}
+<h2>Analysis</h2>
+{{- if not .HasAnalysis }}
+<p>No analysis is available for this alert.</p>
+{{- else }}
+{{- if .Summary }}
+<p><strong>Summary:</strong> {{ .Summary }}</p>
+{{- end }}
+{{- if .RootCause }}
+<p><strong>Root cause:</strong> {{ .RootCause }}</p>
+{{- end }}
+{{- if and .Analysis (not .Summary) }}
+<pre>{{ .Analysis }}</pre>
+{{- end }}
+{{- if .Steps }}
+<h3>Recommended remediation</h3>
+<ol>
+{{- range .Steps }}
+<li>{{ . }}</li>
+{{- end }}
+</ol>
+{{- end }}
+{{- if .Prevention }}
+<h3>Prevention</h3>
+<ul>
+{{- range .Prevention }}
+<li>{{ . }}</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- end }}
+<h2>Timeline</h2>
+<table>
+<tr><th>Time</th><th>Source</th><th>Description</th></tr>
+{{- range .Timeline }}
+<tr><td>{{ .Time }}</td><td>{{ .Source }}</td><td>{{ .Description }}</td></tr>
+{{- end }}
+</table>
+<h2>Logs</h2>
+{{- if .Logs }}
+{{- if .OmittedLogs }}
+<p><em>{{ .OmittedLogs }} earlier lines omitted.</em></p>
+{{- end }}
+<pre>
+{{- range .Logs }}
+{{ . }}
+{{- end }}
+</pre>
+{{- else }}
+<p>No logs were collected.</p>
+{{- end }}
+<h2>Remediation</h2>
+{{- if not .HasRemediation }}
+<p>No remediation has been recorded.</p>
+{{- else }}
+<table>
+<tr><th>Applied</th><th>Remediation</th><th>Verification</th><th>Effectiveness</th></tr>
+{{- range .Remediations }}
+<tr><td>{{ time .AppliedAt }}</td><td>{{ .Remediation }}</td><td>{{ .Verification }}</td><td>{{ effectiveness .Effectiveness }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+<footer>Generated {{ .GeneratedAt }}</footer>
+</body>
+</html>
+`))
+
+var textReportTemplate = texttemplate.Must(texttemplate.New("text").Funcs(reportFuncs).Parse(`{{ .Title }}
+
+Pod:          {{ .Alert.Namespace }}/{{ .Alert.PodName }}
+{{- with .Alert.Cluster }}
+Cluster:      {{ .Label }}
+{{- if .Team }}
+Team:         {{ .Team }}
+{{- end }}
+{{- end }}
+Status:       {{ .Alert.Status }}
+Reason:       {{ .Alert.Reason }}
+State:        {{ .Alert.State }}
+{{- if .Severity }}
+Severity:     {{ .Severity }}
+{{- end }}
+Restarts:     {{ .Alert.RestartCount }}
+First seen:   {{ .FirstSeen }}
+Last updated: {{ .LastUpdated }}
+{{ if .Alert.Message }}
+{{ .Alert.Message }}
+{{ end }}
+ANALYSIS
+{{ if not .HasAnalysis }}
+No analysis is available for this alert.
+{{ else }}
+{{- if .Summary }}
+Summary: {{ .Summary }}
+{{- end }}
+{{- if .RootCause }}
+Root cause: {{ .RootCause }}
+{{- end }}
+{{- if and .Analysis (not .Summary) }}
+{{ .Analysis }}
+{{- end }}
+{{- if .Steps }}
+
+Recommended remediation:
+{{- range $i, $step := .Steps }}
+  {{ inc $i }}. {{ $step }}
+{{- end }}
+{{- end }}
+{{- if .Prevention }}
+
+Prevention:
+{{- range .Prevention }}
+  - {{ . }}
+{{- end }}
+{{- end }}
+{{ end }}
+TIMELINE
+{{ range .Timeline }}
+{{ .Time }}  {{ .Source }}: {{ .Description }}
+{{- end }}
+
+LOGS
+{{ if .Logs }}
+{{- if .OmittedLogs }}
+({{ .OmittedLogs }} earlier lines omitted)
+{{- end }}
+{{- range .Logs }}
+{{ . }}
+{{- end }}
+{{ else }}
+No logs were collected.
+{{ end }}
+REMEDIATION
+{{ if not .HasRemediation }}
+No remediation has been recorded.
+{{ else }}
+{{- range .Remediations }}
+{{ time .AppliedAt }}  {{ .Remediation }} ({{ .Verification }}, effectiveness {{ effectiveness .Effectiveness }})
+{{- end }}
+{{ end }}
+Generated {{ .GeneratedAt }}
+`))
+
+// renderIncidentReport renders report data in the given format
+func renderIncidentReport(data reportData, format string) (string, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case ReportFormatMarkdown:
+		err = markdownReportTemplate.Execute(&buf, data)
+	case ReportFormatHTML:
+		err = htmlReportTemplate.Execute(&buf, data)
+	case ReportFormatText:
+		err = textReportTemplate.Execute(&buf, data)
+	case ReportFormatSlack:
+		err = renderSlackReport(&buf, data)
+	default:
+		return "", fmt.Errorf("unsupported report format %q", format)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// reportResourceURI returns the resource URI of an incident report
+func reportResourceURI(id string) string {
+	return reportResourceScheme + id
+}
+
+// handleGenerateIncidentReport renders a stored alert into an incident report,
+// stores it and returns it as an embedded resource
+func (a *AlertTool) handleGenerateIncidentReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	podName := p.String("pod_name", "", params.Required())
+	namespace := p.String("namespace", "default")
+	format := p.String("format", ReportFormatMarkdown, params.OneOf(reportFormats...))
+	title := p.String("title", "")
+	maxLogLines := p.Int("max_log_lines", defaultReportLogLines, params.Min(0))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	mimeType := reportMIMETypes[format]
+
+	doc, err := a.store.Get(ctx, namespace, podName)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to load alert: %v", err)), nil
+	}
+	if doc == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no alert found for %s; collect it with alerts_get_pod_alerts first", alertKey(namespace, podName))), nil
+	}
+
+	now := time.Now()
+	data := buildReportData(doc, title, maxLogLines, now)
+	content, err := renderIncidentReport(data, format)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := IncidentReport{
+		Namespace: namespace,
+		PodName:   podName,
+		Title:     data.Title,
+		Format:    format,
+		MIMEType:  mimeType,
+		Content:   content,
+		CreatedAt: now,
+	}
+	report.ID, err = a.store.SaveReport(ctx, report)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to store report: %v", err)), nil
+	}
+
+	summaryJSON, err := json.MarshalIndent(map[string]interface{}{
+		"id":        report.ID,
+		"uri":       reportResourceURI(report.ID),
+		"title":     report.Title,
+		"format":    report.Format,
+		"mime_type": report.MIMEType,
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultResource(string(summaryJSON), mcp.TextResourceContents{
+		URI:      reportResourceURI(report.ID),
+		MIMEType: report.MIMEType,
+		Text:     report.Content,
+	}), nil
+}
+
+// handleReadReportResource returns the stored incident report identified by the resource URI
+func (a *AlertTool) handleReadReportResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	id := strings.TrimPrefix(request.Params.URI, reportResourceScheme)
+	if id == "" || id == request.Params.URI {
+		return nil, fmt.Errorf("invalid incident report URI: %s", request.Params.URI)
+	}
+
+	report, err := a.store.GetReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, fmt.Errorf("no incident report found with ID %s", id)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: report.MIMEType,
+			Text:     report.Content,
+		},
+	}, nil
+}
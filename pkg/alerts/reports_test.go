@@ -0,0 +1,168 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReportTestDocument() *AlertDocument {
+	applied := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	verified := applied.Add(5 * time.Minute)
+	score := 1.0
+
+	return &AlertDocument{
+		Alert: PodAlert{
+			PodName:      "web-1",
+			Namespace:    "prod",
+			Status:       "Running",
+			Reason:       "OOMKilled",
+			Message:      "container exceeded its memory limit",
+			RestartCount: 3,
+			State:        AlertStateRemediated,
+			Events: []PodEvent{
+				{Type: "Warning", Reason: "BackOff", Message: "Back-off restarting <web>", Count: 4, FirstTime: "2024-01-01T10:00:00Z", LastTime: "2024-01-01T10:30:00Z"},
+			},
+			Logs: []string{"line 1", "line 2", "line 3"},
+			AnalysisResult: &AnalysisResult{
+				Type: AnalysisTypePod,
+				Data: map[string]interface{}{
+					"summary":           "Pod is OOMKilled under load",
+					"root_cause":        "Memory limit too low",
+					"severity":          "High",
+					"remediation_steps": []interface{}{"Raise the memory limit"},
+					"prevention":        []interface{}{"Load test before release"},
+				},
+				Validation: AnalysisValidation{Valid: true, Attempts: 1},
+			},
+		},
+		Remediations: []RemediationRecord{
+			{ID: "rem-1", Remediation: "raised memory | limit", AppliedAt: applied, Verification: VerificationResolved, VerifiedAt: &verified, Effectiveness: &score},
+		},
+		CreatedAt: time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC),
+		UpdatedAt: verified,
+	}
+}
+
+func TestBuildReportData(t *testing.T) {
+	data := buildReportData(newReportTestDocument(), "", 2, time.Now())
+
+	assert.Equal(t, "Incident report: prod/web-1", data.Title)
+	assert.Equal(t, "High", data.Severity)
+	assert.Equal(t, "Memory limit too low", data.RootCause)
+	assert.Equal(t, []string{"Raise the memory limit"}, data.Steps)
+	assert.Equal(t, []string{"line 2", "line 3"}, data.Logs)
+	assert.Equal(t, 1, data.OmittedLogs)
+
+	require.Len(t, data.Timeline, 4)
+	assert.Equal(t, "event", data.Timeline[0].Source)
+	assert.Equal(t, "alert", data.Timeline[1].Source)
+	assert.Equal(t, "remediation", data.Timeline[2].Source)
+	assert.Equal(t, "verification", data.Timeline[3].Source)
+}
+
+func TestRenderIncidentReport(t *testing.T) {
+	data := buildReportData(newReportTestDocument(), "OOM incident", 0, time.Now())
+
+	markdown, err := renderIncidentReport(data, ReportFormatMarkdown)
+	require.NoError(t, err)
+	assert.Contains(t, markdown, "# OOM incident")
+	assert.Contains(t, markdown, "| Severity | High |")
+	assert.Contains(t, markdown, "1. Raise the memory limit")
+	assert.Contains(t, markdown, `raised memory \| limit`)
+	assert.Contains(t, markdown, "| resolved | 100% |")
+	assert.Contains(t, markdown, "line 1")
+
+	html, err := renderIncidentReport(data, ReportFormatHTML)
+	require.NoError(t, err)
+	assert.Contains(t, html, "<title>OOM incident</title>")
+	assert.Contains(t, html, "Back-off restarting &lt;web&gt;")
+	assert.Contains(t, html, "<li>Raise the memory limit</li>")
+
+	text, err := renderIncidentReport(data, ReportFormatText)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Severity:     High")
+	assert.Contains(t, text, "  1. Raise the memory limit")
+	assert.Contains(t, text, "raised memory | limit (resolved, effectiveness 100%)")
+	assert.NotContains(t, text, "**")
+
+	slack, err := renderIncidentReport(data, ReportFormatSlack)
+	require.NoError(t, err)
+	var message slackMessage
+	require.NoError(t, json.Unmarshal([]byte(slack), &message))
+	assert.Equal(t, "OOM incident", message.Text)
+	assert.Equal(t, "header", message.Blocks[0].Type)
+	assert.Contains(t, message.Blocks[1].Fields, slackText{Type: "mrkdwn", Text: "*Severity*\nHigh"})
+	assert.Contains(t, slack, "Back-off restarting &lt;web&gt;")
+	assert.Contains(t, slack, "1. Raise the memory limit")
+
+	_, err = renderIncidentReport(data, "pdf")
+	assert.Error(t, err)
+}
+
+func TestHandleGenerateIncidentReport(t *testing.T) {
+	tool := NewAlertTool(nil)
+	ctx := context.Background()
+	require.NoError(t, tool.store.Upsert(ctx, newReportTestDocument().Alert))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"pod_name":  "web-1",
+		"namespace": "prod",
+		"format":    "html",
+	}
+	result, err := tool.handleGenerateIncidentReport(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Len(t, result.Content, 2)
+
+	var summary map[string]string
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &summary))
+	assert.Equal(t, "incident-reports://report-1", summary["uri"])
+
+	embedded := result.Content[1].(mcp.EmbeddedResource).Resource.(mcp.TextResourceContents)
+	assert.Equal(t, "text/html", embedded.MIMEType)
+
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = summary["uri"]
+	contents, err := tool.handleReadReportResource(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	assert.Equal(t, embedded.Text, contents[0].(mcp.TextResourceContents).Text)
+
+	req.Params.URI = "incident-reports://report-2"
+	_, err = tool.handleReadReportResource(ctx, req)
+	assert.Error(t, err)
+
+	t.Run("invalid requests", func(t *testing.T) {
+		tests := []map[string]interface{}{
+			{},
+			{"pod_name": "web-1", "namespace": "prod", "format": "pdf"},
+			{"pod_name": "missing", "namespace": "prod"},
+		}
+		for _, args := range tests {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = args
+			result, err := tool.handleGenerateIncidentReport(ctx, request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+		}
+	})
+}
+
+func TestSlackLogsFitSection(t *testing.T) {
+	data := reportData{Logs: make([]string, 100)}
+	for i := range data.Logs {
+		data.Logs[i] = strings.Repeat("x", 99)
+	}
+	text := slackLogs(data)
+	assert.LessOrEqual(t, len(text), slackSectionLimit)
+	assert.Contains(t, text, "earlier lines omitted")
+	assert.True(t, strings.HasSuffix(text, strings.Repeat("x", 99)+"\n```"))
+}
@@ -0,0 +1,380 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// Environment variables used to configure event-to-alert rules
+const (
+	// AlertRulesFile is the YAML file of rules; unset disables rules
+	AlertRulesFile = "ALERT_RULES_FILE"
+	// AlertRulesInterval is how often the rules are evaluated across the
+	// cluster; unset only evaluates them when alerts are queried
+	AlertRulesInterval = "ALERT_RULES_INTERVAL"
+)
+
+// JobKindAlertRules evaluates the alert rules and queues the next evaluation
+const JobKindAlertRules = "alert_rules"
+
+// minRulesInterval keeps scheduled evaluations from hammering the API server
+const minRulesInterval = time.Minute
+
+// ruleSeverities are the severities a rule may assign, as used by analyses
+var ruleSeverities = []string{"Critical", "High", "Medium", "Low"}
+
+// RuleMatch selects the events a rule applies to. Each set field is a regular
+// expression that must match the whole value; unset fields match any value.
+type RuleMatch struct {
+	Reason    string `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Kind      string `yaml:"kind,omitempty" json:"kind,omitempty"`
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Type      string `yaml:"type,omitempty" json:"type,omitempty"`
+	Message   string `yaml:"message,omitempty" json:"message,omitempty"`
+	// MinCount is the number of times the event must have occurred
+	MinCount int32 `yaml:"min_count,omitempty" json:"min_count,omitempty"`
+}
+
+// AlertRule creates an alert, with a severity and tags, for the object of each matching event
+type AlertRule struct {
+	Name     string    `yaml:"name" json:"name"`
+	Match    RuleMatch `yaml:"match" json:"match"`
+	Severity string    `yaml:"severity" json:"severity"`
+	Tags     []string  `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	matchers map[string]*regexp.Regexp
+}
+
+// RuleSet is an ordered list of compiled alert rules
+type RuleSet struct {
+	Rules []AlertRule `yaml:"rules" json:"rules"`
+}
+
+// LoadRuleSet reads and compiles the rules of ALERT_RULES_FILE, returning nil
+// when no file is configured
+func LoadRuleSet() (*RuleSet, error) {
+	filename := os.Getenv(AlertRulesFile)
+	if filename == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert rules: %w", err)
+	}
+	return ParseRuleSet(data)
+}
+
+// ParseRuleSet parses and compiles YAML alert rules
+func ParseRuleSet(data []byte) (*RuleSet, error) {
+	var rules RuleSet
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse alert rules: %w", err)
+	}
+
+	names := map[string]bool{}
+	for i := range rules.Rules {
+		rule := &rules.Rules[i]
+		if rule.Name == "" {
+			return nil, fmt.Errorf("alert rule %d has no name", i+1)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate alert rule %q", rule.Name)
+		}
+		names[rule.Name] = true
+
+		severity := ""
+		for _, s := range ruleSeverities {
+			if strings.EqualFold(rule.Severity, s) {
+				severity = s
+			}
+		}
+		if severity == "" {
+			return nil, fmt.Errorf("alert rule %q: severity must be one of: %s", rule.Name, strings.Join(ruleSeverities, ", "))
+		}
+		rule.Severity = severity
+
+		rule.matchers = map[string]*regexp.Regexp{}
+		for field, pattern := range map[string]string{
+			"reason":    rule.Match.Reason,
+			"kind":      rule.Match.Kind,
+			"namespace": rule.Match.Namespace,
+			"type":      rule.Match.Type,
+			"message":   rule.Match.Message,
+		} {
+			if pattern == "" {
+				continue
+			}
+			re, err := regexp.Compile("^(?:" + pattern + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("alert rule %q: invalid %s: %v", rule.Name, field, err)
+			}
+			rule.matchers[field] = re
+		}
+	}
+	return &rules, nil
+}
+
+// ruleEvent is a Kubernetes event with the object it is about
+type ruleEvent struct {
+	PodEvent
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// matches reports whether a rule applies to an event
+func (r *AlertRule) matches(event ruleEvent) bool {
+	if event.Count < r.Match.MinCount {
+		return false
+	}
+	for field, value := range map[string]string{
+		"reason":    event.Reason,
+		"kind":      event.Kind,
+		"namespace": event.Namespace,
+		"type":      event.Type,
+		"message":   event.Message,
+	} {
+		if re, ok := r.matchers[field]; ok && !re.MatchString(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluate returns an alert for each object with events matching a rule. An
+// object is alerted by the first rule, in file order, that any of its events
+// matches, so the same events always produce the same alerts.
+func (s *RuleSet) evaluate(events []ruleEvent) []PodAlert {
+	var alerts []PodAlert
+	byObject := map[string]int{}
+	ruleIndex := map[string]int{}
+	for _, event := range events {
+		for i := range s.Rules {
+			rule := &s.Rules[i]
+			if !rule.matches(event) {
+				continue
+			}
+			key := event.Kind + "/" + event.Namespace + "/" + event.Name
+			j, ok := byObject[key]
+			if !ok {
+				j = len(alerts)
+				byObject[key] = j
+				ruleIndex[key] = len(s.Rules)
+				alerts = append(alerts, PodAlert{PodName: event.Name, Namespace: event.Namespace, Kind: event.Kind})
+			}
+			alert := &alerts[j]
+			alert.Events = append(alert.Events, event.PodEvent)
+			if i < ruleIndex[key] {
+				ruleIndex[key] = i
+				alert.Rule, alert.Severity, alert.Tags = rule.Name, rule.Severity, rule.Tags
+				alert.Reason, alert.Message = event.Reason, event.Message
+			}
+			break
+		}
+	}
+	return alerts
+}
+
+// WithRules sets the rules that create alerts from events; nil disables them
+func (a *AlertTool) WithRules(rules *RuleSet) *AlertTool {
+	a.rules.Store(rules)
+	return a
+}
+
+// getRuleEvents returns the events of a namespace, or of all namespaces when namespace is empty
+func (a *AlertTool) getRuleEvents(ctx context.Context, namespace string) ([]ruleEvent, error) {
+	args := []string{"get", "events", "-o", "json"}
+	if namespace == "" {
+		args = append(args, "--all-namespaces")
+	} else {
+		args = append(args, "-n", namespace)
+	}
+	output, err := a.runKubectlCommandString(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var eventsList struct {
+		Items []struct {
+			Type           string `json:"type"`
+			Reason         string `json:"reason"`
+			Message        string `json:"message"`
+			Count          int32  `json:"count"`
+			FirstTime      string `json:"firstTimestamp"`
+			LastTime       string `json:"lastTimestamp"`
+			InvolvedObject struct {
+				Kind      string `json:"kind"`
+				Namespace string `json:"namespace"`
+				Name      string `json:"name"`
+			} `json:"involvedObject"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &eventsList); err != nil {
+		return nil, fmt.Errorf("failed to parse events: %w", err)
+	}
+
+	events := make([]ruleEvent, 0, len(eventsList.Items))
+	for _, event := range eventsList.Items {
+		events = append(events, ruleEvent{
+			PodEvent: PodEvent{
+				Type:      event.Type,
+				Reason:    event.Reason,
+				Message:   event.Message,
+				Count:     event.Count,
+				FirstTime: event.FirstTime,
+				LastTime:  event.LastTime,
+			},
+			Kind:      event.InvolvedObject.Kind,
+			Namespace: event.InvolvedObject.Namespace,
+			Name:      event.InvolvedObject.Name,
+		})
+	}
+	return events, nil
+}
+
+// ruleAlerts evaluates the configured rules against the events of a
+// namespace, or of all namespaces when namespace is empty
+func (a *AlertTool) ruleAlerts(ctx context.Context, namespace string) ([]PodAlert, error) {
+	rules := a.rules.Load()
+	if rules == nil || len(rules.Rules) == 0 {
+		return nil, nil
+	}
+	events, err := a.getRuleEvents(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+	return rules.evaluate(events), nil
+}
+
+// mergeRuleAlerts applies the severity and tags of rule alerts to the pod
+// alerts found by the pod status checks, and adds the others
+func mergeRuleAlerts(alerts, ruleAlerts []PodAlert) []PodAlert {
+	for _, ruleAlert := range ruleAlerts {
+		merged := false
+		if ruleAlert.Kind == "Pod" {
+			for i := range alerts {
+				if alerts[i].Namespace == ruleAlert.Namespace && alerts[i].PodName == ruleAlert.PodName {
+					alerts[i].Rule, alerts[i].Severity, alerts[i].Tags = ruleAlert.Rule, ruleAlert.Severity, ruleAlert.Tags
+					merged = true
+				}
+			}
+		}
+		if !merged {
+			alerts = append(alerts, ruleAlert)
+		}
+	}
+	return alerts
+}
+
+// evaluateRules creates and stores the alerts of the rules matching the
+// events of a namespace, or of all namespaces when namespace is empty
+func (a *AlertTool) evaluateRules(ctx context.Context, namespace string) ([]PodAlert, error) {
+	alerts, err := a.ruleAlerts(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	a.collectPodData(ctx, alerts, collectionParallelism(), collectionPodTimeout(), defaultCollectionLimits())
+	a.tagSilenced(ctx, alerts)
+	stored := make([]*PodAlert, len(alerts))
+	for i := range alerts {
+		stored[i] = &alerts[i]
+	}
+	a.transitionAll(ctx, stored, AlertStateCollected)
+	return alerts, nil
+}
+
+// rulesInterval returns the configured interval of scheduled rule
+// evaluations, or zero when they are not scheduled
+func rulesInterval() (time.Duration, error) {
+	value := os.Getenv(AlertRulesInterval)
+	if value == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < minRulesInterval {
+		return 0, fmt.Errorf("invalid %s %q, expected a duration of at least %s", AlertRulesInterval, value, minRulesInterval)
+	}
+	return interval, nil
+}
+
+// WithRulesSchedule sets the interval of scheduled rule evaluations. Call
+// ScheduleRules to queue the first one.
+func (a *AlertTool) WithRulesSchedule(interval time.Duration) *AlertTool {
+	a.rulesInterval = interval
+	return a
+}
+
+// ScheduleRules queues the next scheduled rule evaluation. Runs are aligned to
+// the interval, so the idempotency key of a run keeps restarts from queueing
+// it twice.
+func (a *AlertTool) ScheduleRules(ctx context.Context) error {
+	if a.rulesInterval == 0 {
+		return nil
+	}
+	_, err := a.scheduleRules(ctx, time.Now())
+	return err
+}
+
+// scheduleRules queues the first evaluation aligned to the interval after a time
+func (a *AlertTool) scheduleRules(ctx context.Context, after time.Time) (Job, error) {
+	at := after.UTC().Truncate(a.rulesInterval).Add(a.rulesInterval)
+	return a.enqueueJob(ctx, Job{
+		Kind:           JobKindAlertRules,
+		IdempotencyKey: fmt.Sprintf("%s/%s", JobKindAlertRules, at.Format(time.RFC3339)),
+		RunAfter:       at,
+	})
+}
+
+// runRulesJob evaluates the rules across the cluster after queueing the next
+// run, so a failing evaluation does not stop the schedule
+func (a *AlertTool) runRulesJob(ctx context.Context, job Job) error {
+	if a.rulesInterval != 0 {
+		if _, err := a.scheduleRules(ctx, job.RunAfter); err != nil {
+			return fmt.Errorf("failed to schedule the next rule evaluation: %w", err)
+		}
+	}
+
+	alerts, err := a.evaluateRules(ctx, "")
+	if err != nil {
+		return err
+	}
+	logger.Get().Info("Evaluated alert rules", "alerts", len(alerts))
+	return nil
+}
+
+// handleEvaluateRules evaluates the alert rules on demand
+func (a *AlertTool) handleEvaluateRules(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	namespace := p.String("namespace", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if rules := a.rules.Load(); rules == nil || len(rules.Rules) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("no alert rules are configured, set %s", AlertRulesFile)), nil
+	}
+
+	alerts, err := a.evaluateRules(ctx, namespace)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if alerts == nil {
+		alerts = []PodAlert{}
+	}
+
+	alertsJSON, err := json.MarshalIndent(alerts, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal alerts: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(alertsJSON)), nil
+}
@@ -0,0 +1,227 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const testRulesYAML = `
+rules:
+  - name: oom
+    match:
+      reason: OOMKill.*
+    severity: critical
+    tags: [memory]
+  - name: crashloop
+    match:
+      reason: BackOff
+      kind: Pod
+      namespace: prod|staging
+      min_count: 3
+    severity: high
+    tags: [crash]
+  - name: rollout-stuck
+    match:
+      kind: Deployment
+      type: Warning
+    severity: medium
+`
+
+func TestParseRuleSet(t *testing.T) {
+	rules, err := ParseRuleSet([]byte(testRulesYAML))
+	require.NoError(t, err)
+	require.Len(t, rules.Rules, 3)
+	assert.Equal(t, "Critical", rules.Rules[0].Severity)
+	assert.Equal(t, int32(3), rules.Rules[1].Match.MinCount)
+
+	tests := map[string]string{
+		"rules: [{severity: low}]":                                    "has no name",
+		"rules: [{name: a, severity: low}, {name: a, severity: low}]": `duplicate alert rule "a"`,
+		"rules: [{name: a, severity: urgent}]":                        "severity must be one of",
+		"rules: [{name: a, severity: low, match: {reason: '('}}]":     `alert rule "a": invalid reason`,
+		"rules: {name: a}":                                            "failed to parse alert rules",
+	}
+	for data, message := range tests {
+		_, err := ParseRuleSet([]byte(data))
+		assert.ErrorContains(t, err, message, data)
+	}
+}
+
+func TestLoadRuleSet(t *testing.T) {
+	t.Setenv(AlertRulesFile, "")
+	rules, err := LoadRuleSet()
+	require.NoError(t, err)
+	assert.Nil(t, rules)
+
+	filename := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(testRulesYAML), 0o600))
+	t.Setenv(AlertRulesFile, filename)
+	rules, err = LoadRuleSet()
+	require.NoError(t, err)
+	assert.Len(t, rules.Rules, 3)
+
+	t.Setenv(AlertRulesFile, filepath.Join(t.TempDir(), "missing.yaml"))
+	_, err = LoadRuleSet()
+	assert.ErrorContains(t, err, "failed to read alert rules")
+}
+
+func TestRuleSetEvaluate(t *testing.T) {
+	rules, err := ParseRuleSet([]byte(testRulesYAML))
+	require.NoError(t, err)
+
+	event := func(kind, namespace, name, reason string, count int32) ruleEvent {
+		return ruleEvent{PodEvent: PodEvent{Type: "Warning", Reason: reason, Count: count}, Kind: kind, Namespace: namespace, Name: name}
+	}
+	alerts := rules.evaluate([]ruleEvent{
+		event("Pod", "prod", "web-1", "BackOff", 5),
+		event("Pod", "prod", "web-1", "OOMKilling", 1),
+		event("Pod", "prod", "web-2", "BackOff", 1),
+		event("Pod", "dev", "web-3", "BackOff", 5),
+		event("Pod", "prod", "web-4", "BackOffLimitExceeded", 5),
+		event("Deployment", "prod", "web", "ProgressDeadlineExceeded", 1),
+	})
+
+	require.Len(t, alerts, 2)
+	// The first rule in the file wins, whatever the order of the events
+	assert.Equal(t, "web-1", alerts[0].PodName)
+	assert.Equal(t, "oom", alerts[0].Rule)
+	assert.Equal(t, "Critical", alerts[0].Severity)
+	assert.Equal(t, []string{"memory"}, alerts[0].Tags)
+	assert.Equal(t, "OOMKilling", alerts[0].Reason)
+	assert.Len(t, alerts[0].Events, 2)
+
+	assert.Equal(t, PodAlert{
+		PodName: "web", Namespace: "prod", Kind: "Deployment", Rule: "rollout-stuck", Severity: "Medium",
+		Reason: "ProgressDeadlineExceeded", Events: []PodEvent{{Type: "Warning", Reason: "ProgressDeadlineExceeded", Count: 1}},
+	}, alerts[1])
+}
+
+func TestHandleGetPodAlertsRules(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pods", "-o", "json", "-n", "prod"}, `{"items":[
+		{"metadata":{"name":"web-1","namespace":"prod"},"status":{"phase":"Pending"}},
+		{"metadata":{"name":"web-2","namespace":"prod"},"status":{"phase":"Running","containerStatuses":[{"ready":true}]}}
+	]}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "events", "-o", "json", "-n", "prod"}, `{"items":[
+		{"type":"Warning","reason":"BackOff","count":4,"involvedObject":{"kind":"Pod","namespace":"prod","name":"web-1"}},
+		{"type":"Warning","reason":"ProgressDeadlineExceeded","count":1,"involvedObject":{"kind":"Deployment","namespace":"prod","name":"web"}},
+		{"type":"Normal","reason":"Scheduled","count":1,"involvedObject":{"kind":"Pod","namespace":"prod","name":"web-2"}}
+	]}`, nil)
+	mock.AddCommandString("kubectl", eventsArgs("web-1"), testEventsJSON, nil)
+	mock.AddCommandString("kubectl", logsArgs("web-1"), "line 1\n", nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	rules, err := ParseRuleSet([]byte(testRulesYAML))
+	require.NoError(t, err)
+	tool := NewAlertTool(nil).WithRules(rules)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"namespace": "prod"}
+	result, err := tool.handleGetPodAlerts(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+	var alerts []PodAlert
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &alerts))
+	require.Len(t, alerts, 2)
+	// The pending pod keeps its status and is classified by the rule
+	assert.Equal(t, "web-1", alerts[0].PodName)
+	assert.Equal(t, "Pending", alerts[0].Status)
+	assert.Equal(t, "crashloop", alerts[0].Rule)
+	assert.Equal(t, "High", alerts[0].Severity)
+	assert.Equal(t, CollectionStatusCollected, alerts[0].Collection.Status)
+
+	// Objects other than pods keep the events matched by the rule
+	assert.Equal(t, "Deployment", alerts[1].Kind)
+	assert.Equal(t, "rollout-stuck", alerts[1].Rule)
+	assert.Nil(t, alerts[1].Collection)
+	require.Len(t, alerts[1].Events, 1)
+	assert.Equal(t, "ProgressDeadlineExceeded", alerts[1].Events[0].Reason)
+
+	doc, err := tool.store.Get(ctx, "prod", "web")
+	require.NoError(t, err)
+	require.NotNil(t, doc)
+	assert.Equal(t, "Medium", doc.Alert.Severity)
+}
+
+func TestHandleEvaluateRules(t *testing.T) {
+	tool := NewAlertTool(nil)
+	result, err := tool.handleEvaluateRules(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, AlertRulesFile)
+
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "events", "-o", "json", "--all-namespaces"}, `{"items":[
+		{"type":"Warning","reason":"OOMKilling","count":1,"involvedObject":{"kind":"Node","name":"node-1"}}
+	]}`, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	rules, err := ParseRuleSet([]byte(testRulesYAML))
+	require.NoError(t, err)
+	result, err = tool.WithRules(rules).handleEvaluateRules(ctx, mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+	var alerts []PodAlert
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &alerts))
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "Node", alerts[0].Kind)
+	assert.Equal(t, AlertStateCollected, alerts[0].State)
+	assert.Len(t, mock.GetCallLog(), 1)
+}
+
+func TestRulesInterval(t *testing.T) {
+	t.Setenv(AlertRulesInterval, "")
+	interval, err := rulesInterval()
+	require.NoError(t, err)
+	assert.Zero(t, interval)
+
+	t.Setenv(AlertRulesInterval, "5m")
+	interval, err = rulesInterval()
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, interval)
+
+	for _, value := range []string{"10s", "often"} {
+		t.Setenv(AlertRulesInterval, value)
+		_, err = rulesInterval()
+		assert.ErrorContains(t, err, AlertRulesInterval)
+	}
+}
+
+func TestRulesJobQueuesNextRun(t *testing.T) {
+	tool := NewAlertTool(nil).WithRulesSchedule(5 * time.Minute)
+	ctx := context.Background()
+
+	require.NoError(t, tool.ScheduleRules(ctx))
+	// Scheduling again, as after a restart, does not queue a second evaluation
+	require.NoError(t, tool.ScheduleRules(ctx))
+	jobs, err := tool.store.ListJobs(ctx, JobStatusQueued)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, JobKindAlertRules, jobs[0].Kind)
+
+	// Without rules the evaluation does nothing, but the next one is queued
+	at := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	require.NoError(t, tool.runRulesJob(ctx, Job{Kind: JobKindAlertRules, RunAfter: at}))
+	jobs, err = tool.store.ListJobs(ctx, JobStatusQueued)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, at.Add(5*time.Minute), jobs[1].RunAfter)
+
+	for _, job := range jobs {
+		_, err := tool.cancelJob(ctx, job.ID)
+		require.NoError(t, err)
+	}
+	tool.waitJobs()
+}
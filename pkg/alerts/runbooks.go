@@ -0,0 +1,80 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/pkg/runbooks"
+)
+
+// runbookSections is the number of runbook sections added to analysis prompts
+const runbookSections = 3
+
+// runbookContext returns the runbook sections relevant to the query, formatted
+// for inclusion in an analysis prompt, or an empty string if none match
+func (a *AlertTool) runbookContext(ctx context.Context, query string) string {
+	if a.runbookIndex == nil || strings.TrimSpace(query) == "" {
+		return ""
+	}
+
+	chunks, err := a.runbookIndex.Search(ctx, query, runbookSections)
+	if err != nil {
+		logger.Get().Error("Failed to search runbooks", "error", err)
+		return ""
+	}
+	if len(chunks) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(`
+Relevant runbook sections (prefer these documented procedures when recommending remediation):
+%s
+`, runbooks.FormatForPrompt(chunks))
+}
+
+// handleSearchRunbooks returns the runbook sections matching a query
+func (a *AlertTool) handleSearchRunbooks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	query := p.String("query", "", params.Required())
+	topK := p.Int("top_k", runbookSections, params.Min(1))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if a.runbookIndex == nil {
+		return mcp.NewToolResultError("No runbook index is configured"), nil
+	}
+
+	chunks, err := a.runbookIndex.Search(ctx, query, topK)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to search runbooks: %v", err)), nil
+	}
+	if chunks == nil {
+		chunks = []runbooks.Chunk{}
+	}
+
+	chunksJSON, err := json.MarshalIndent(chunks, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal runbook sections: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(chunksJSON)), nil
+}
+
+// handleReloadRunbooks reloads the runbook sources and rebuilds the index
+func (a *AlertTool) handleReloadRunbooks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if a.runbookIndex == nil || len(a.runbookIndex.Sources()) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("No runbook sources configured, set %s", runbooks.RunbookSources)), nil
+	}
+
+	if err := a.runbookIndex.Reload(ctx); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Runbooks reloaded with errors (%d sections indexed): %v", a.runbookIndex.Size(), err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Indexed %d runbook sections from %d source(s)", a.runbookIndex.Size(), len(a.runbookIndex.Sources()))), nil
+}
@@ -0,0 +1,59 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/pkg/runbooks"
+)
+
+func newRunbookTool(t *testing.T) *AlertTool {
+	index := runbooks.NewIndex(nil, nil)
+	require.NoError(t, index.Build(context.Background(), []runbooks.Runbook{{
+		Path:    "pods.md",
+		Content: "# CrashLoopBackOff\nInspect previous logs.\n\n# OOMKilled\nRaise the memory limit.",
+	}}))
+	return NewAlertTool(nil).WithRunbooks(index)
+}
+
+func TestRunbookContext(t *testing.T) {
+	tool := newRunbookTool(t)
+
+	prompt := tool.runbookContext(context.Background(), "Failed OOMKilled container exceeded memory")
+	assert.Contains(t, prompt, "Relevant runbook sections")
+	assert.Contains(t, prompt, "### OOMKilled (pods.md)")
+
+	assert.Empty(t, tool.runbookContext(context.Background(), "nothing matches"))
+	assert.Empty(t, NewAlertTool(nil).runbookContext(context.Background(), "OOMKilled"))
+}
+
+func TestHandleSearchRunbooks(t *testing.T) {
+	tool := newRunbookTool(t)
+
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{"query": "CrashLoopBackOff", "top_k": float64(1)}
+	result, err := tool.handleSearchRunbooks(context.Background(), req)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	var chunks []runbooks.Chunk
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &chunks))
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "CrashLoopBackOff", chunks[0].Heading)
+
+	req.Params.Arguments = map[string]interface{}{}
+	result, err = tool.handleSearchRunbooks(context.Background(), req)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestHandleReloadRunbooksWithoutSources(t *testing.T) {
+	result, err := newRunbookTool(t).handleReloadRunbooks(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
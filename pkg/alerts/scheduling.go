@@ -0,0 +1,840 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/quantity"
+)
+
+// AnalysisSourceDeterministic marks an analysis produced by the built-in analyzers instead of the LLM
+const AnalysisSourceDeterministic = "deterministic"
+
+// Reasons a Pending pod cannot be scheduled
+const (
+	SchedulingInsufficientResources = "insufficient_resources"
+	SchedulingNodeSelector          = "node_selector_mismatch"
+	SchedulingNodeAffinity          = "node_affinity_mismatch"
+	SchedulingUntoleratedTaint      = "untolerated_taint"
+	SchedulingNodeUnschedulable     = "node_unschedulable"
+	SchedulingNodeNotReady          = "node_not_ready"
+	SchedulingPodAffinity           = "pod_affinity"
+	SchedulingTopologySpread        = "topology_spread"
+	SchedulingVolume                = "volume"
+	SchedulingHostPort              = "host_port"
+	SchedulingNoNodes               = "no_nodes"
+	SchedulingOther                 = "other"
+)
+
+// Sources of a scheduling reason
+const (
+	reasonSourceAnalysis  = "analysis"
+	reasonSourceScheduler = "scheduler"
+)
+
+// schedulingRemediations are the remediation steps of each scheduling reason
+var schedulingRemediations = map[string]string{
+	SchedulingInsufficientResources: "Lower the pod's resource requests, free capacity by scaling down other workloads, or add nodes (or let the cluster autoscaler add them)",
+	SchedulingNodeSelector:          "Fix the pod's nodeSelector or label the nodes it should run on",
+	SchedulingNodeAffinity:          "Relax the pod's required node affinity or label the nodes it should run on",
+	SchedulingUntoleratedTaint:      "Add tolerations for the node taints to the pod, or remove the taints from nodes it may run on",
+	SchedulingNodeUnschedulable:     "Uncordon the cordoned nodes with kubectl uncordon once their maintenance is done",
+	SchedulingNodeNotReady:          "Investigate why the nodes are NotReady (kubelet, network or resource pressure)",
+	SchedulingPodAffinity:           "Relax the pod's required pod affinity or anti-affinity rules, or add nodes in the topology domains they need",
+	SchedulingTopologySpread:        "Relax the topology spread constraints (maxSkew or whenUnsatisfiable: ScheduleAnyway) or add nodes in the missing zones",
+	SchedulingVolume:                "Check that the pod's PersistentVolumeClaims are bound and their volumes are reachable from a schedulable node's zone",
+	SchedulingHostPort:              "Remove the pod's hostPort or free the port on the nodes",
+	SchedulingNoNodes:               "Add nodes to the cluster",
+	SchedulingOther:                 "Review the scheduler's FailedScheduling events for the pod",
+}
+
+// schedulingReasonOrder breaks ties between reasons ruling out the same number of nodes
+var schedulingReasonOrder = []string{
+	SchedulingNoNodes, SchedulingNodeNotReady, SchedulingNodeUnschedulable, SchedulingNodeSelector, SchedulingNodeAffinity,
+	SchedulingUntoleratedTaint, SchedulingInsufficientResources, SchedulingVolume, SchedulingPodAffinity,
+	SchedulingTopologySpread, SchedulingHostPort, SchedulingOther,
+}
+
+// schedulerMessagePatterns classify the reasons of a FailedScheduling event, most specific first
+var schedulerMessagePatterns = []struct {
+	reason  string
+	pattern string
+}{
+	{SchedulingPodAffinity, "pod affinity"},
+	{SchedulingPodAffinity, "pod anti-affinity"},
+	{SchedulingNodeAffinity, "node affinity/selector"},
+	{SchedulingNodeSelector, "node selector"},
+	{SchedulingNodeAffinity, "node affinity"},
+	{SchedulingInsufficientResources, "insufficient"},
+	{SchedulingInsufficientResources, "too many pods"},
+	{SchedulingUntoleratedTaint, "taint"},
+	{SchedulingNodeUnschedulable, "unschedulable"},
+	{SchedulingNodeNotReady, "not-ready"},
+	{SchedulingNodeNotReady, "not ready"},
+	{SchedulingTopologySpread, "topology spread"},
+	{SchedulingVolume, "volume"},
+	{SchedulingVolume, "persistentvolumeclaim"},
+	{SchedulingHostPort, "free ports"},
+}
+
+// SchedulingReason is a reason a pod cannot be scheduled, with the number of nodes it rules out
+type SchedulingReason struct {
+	Reason      string `json:"reason"`
+	Nodes       int    `json:"nodes"`
+	Detail      string `json:"detail"`
+	Remediation string `json:"remediation"`
+	// Source is analysis when the reason was derived from the node and pod specs,
+	// or scheduler when it was only reported by a FailedScheduling event
+	Source string `json:"source"`
+}
+
+// NodeFit records whether a pod fits a node
+type NodeFit struct {
+	Node    string            `json:"node"`
+	Fits    bool              `json:"fits"`
+	Reasons []string          `json:"reasons,omitempty"`
+	Details []string          `json:"details,omitempty"`
+	Free    map[string]string `json:"free,omitempty"`
+}
+
+// PreemptionCandidate is a node where preempting lower-priority pods would make room for the pod
+type PreemptionCandidate struct {
+	Node    string   `json:"node"`
+	Victims []string `json:"victims"`
+}
+
+// PreemptionAnalysis assesses whether the pod can preempt lower-priority pods
+type PreemptionAnalysis struct {
+	Priority      int32                 `json:"priority"`
+	PriorityClass string                `json:"priority_class,omitempty"`
+	Policy        string                `json:"policy"`
+	NominatedNode string                `json:"nominated_node,omitempty"`
+	Possible      bool                  `json:"possible"`
+	Candidates    []PreemptionCandidate `json:"candidates,omitempty"`
+	Detail        string                `json:"detail"`
+}
+
+// SchedulingAnalysis explains why a Pending pod is not scheduled
+type SchedulingAnalysis struct {
+	Pod              string              `json:"pod"`
+	Namespace        string              `json:"namespace"`
+	Scheduled        bool                `json:"scheduled"`
+	Node             string              `json:"node,omitempty"`
+	Requests         map[string]string   `json:"requests,omitempty"`
+	SchedulerMessage string              `json:"scheduler_message,omitempty"`
+	Summary          string              `json:"summary"`
+	Reasons          []SchedulingReason  `json:"reasons"`
+	Preemption       *PreemptionAnalysis `json:"preemption,omitempty"`
+	Nodes            []NodeFit           `json:"nodes"`
+}
+
+type toleration struct {
+	Key      string `json:"key"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+	Effect   string `json:"effect"`
+}
+
+type taint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Effect string `json:"effect"`
+}
+
+func (t taint) String() string {
+	if t.Value == "" {
+		return fmt.Sprintf("%s:%s", t.Key, t.Effect)
+	}
+	return fmt.Sprintf("%s=%s:%s", t.Key, t.Value, t.Effect)
+}
+
+type nodeSelectorRequirement struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values"`
+}
+
+type nodeSelectorTerm struct {
+	MatchExpressions []nodeSelectorRequirement `json:"matchExpressions"`
+	MatchFields      []nodeSelectorRequirement `json:"matchFields"`
+}
+
+type schedulingContainer struct {
+	Name      string `json:"name"`
+	Resources struct {
+		Requests map[string]string `json:"requests"`
+	} `json:"resources"`
+}
+
+type schedulingPod struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName     string            `json:"nodeName"`
+		NodeSelector map[string]string `json:"nodeSelector"`
+		Tolerations  []toleration      `json:"tolerations"`
+		Affinity     struct {
+			NodeAffinity struct {
+				Required *struct {
+					NodeSelectorTerms []nodeSelectorTerm `json:"nodeSelectorTerms"`
+				} `json:"requiredDuringSchedulingIgnoredDuringExecution"`
+			} `json:"nodeAffinity"`
+		} `json:"affinity"`
+		Priority          *int32                `json:"priority"`
+		PriorityClassName string                `json:"priorityClassName"`
+		PreemptionPolicy  string                `json:"preemptionPolicy"`
+		Containers        []schedulingContainer `json:"containers"`
+		InitContainers    []schedulingContainer `json:"initContainers"`
+		Overhead          map[string]string     `json:"overhead"`
+	} `json:"spec"`
+	Status struct {
+		Phase             string `json:"phase"`
+		NominatedNodeName string `json:"nominatedNodeName"`
+	} `json:"status"`
+}
+
+type schedulingNode struct {
+	Metadata struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		Unschedulable bool    `json:"unschedulable"`
+		Taints        []taint `json:"taints"`
+	} `json:"spec"`
+	Status struct {
+		Allocatable map[string]string `json:"allocatable"`
+		Conditions  []struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+func (n schedulingNode) ready() bool {
+	for _, condition := range n.Status.Conditions {
+		if condition.Type == "Ready" {
+			return condition.Status == "True"
+		}
+	}
+	return false
+}
+
+func (p schedulingPod) id() string {
+	return alertKey(p.Metadata.Namespace, p.Metadata.Name)
+}
+
+func (p schedulingPod) priority() int32 {
+	if p.Spec.Priority == nil {
+		return 0
+	}
+	return *p.Spec.Priority
+}
+
+// requests returns what the scheduler reserves for the pod: the larger of its
+// containers' total and its largest init container, plus the pod overhead, and
+// one pod slot
+func (p schedulingPod) requests() map[string]quantity.Quantity {
+	total := map[string]quantity.Quantity{}
+	for _, container := range p.Spec.Containers {
+		for resource, value := range parseRequests(container.Resources.Requests) {
+			total[resource] += value
+		}
+	}
+	for _, container := range p.Spec.InitContainers {
+		for resource, value := range parseRequests(container.Resources.Requests) {
+			total[resource] = max(total[resource], value)
+		}
+	}
+	for resource, value := range parseRequests(p.Spec.Overhead) {
+		total[resource] += value
+	}
+	total["pods"] = 1000
+	return total
+}
+
+// parseRequests parses a resource list, skipping values that are not valid quantities
+func parseRequests(list map[string]string) map[string]quantity.Quantity {
+	parsed := make(map[string]quantity.Quantity, len(list))
+	for resource, value := range list {
+		if q, err := quantity.Parse(value); err == nil {
+			parsed[resource] = q
+		}
+	}
+	return parsed
+}
+
+// tolerates reports whether any toleration matches the taint
+func tolerates(tolerations []toleration, t taint) bool {
+	for _, tol := range tolerations {
+		if tol.Effect != "" && tol.Effect != t.Effect {
+			continue
+		}
+		if tol.Key == "" && tol.Operator == "Exists" {
+			return true
+		}
+		if tol.Key != t.Key {
+			continue
+		}
+		if tol.Operator == "Exists" || tol.Value == t.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesRequirement evaluates a node selector requirement against a label or field value
+func matchesRequirement(req nodeSelectorRequirement, values map[string]string) bool {
+	value, ok := values[req.Key]
+	switch req.Operator {
+	case "In":
+		return ok && containsValue(req.Values, value)
+	case "NotIn":
+		return !ok || !containsValue(req.Values, value)
+	case "Exists":
+		return ok
+	case "DoesNotExist":
+		return !ok
+	case "Gt", "Lt":
+		if !ok || len(req.Values) != 1 {
+			return false
+		}
+		actual, err1 := strconv.ParseInt(value, 10, 64)
+		bound, err2 := strconv.ParseInt(req.Values[0], 10, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if req.Operator == "Gt" {
+			return actual > bound
+		}
+		return actual < bound
+	}
+	return false
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNodeAffinity reports whether a node satisfies any of the required node selector terms
+func matchesNodeAffinity(terms []nodeSelectorTerm, node schedulingNode) bool {
+	fields := map[string]string{"metadata.name": node.Metadata.Name}
+	for _, term := range terms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		matches := true
+		for _, req := range term.MatchExpressions {
+			matches = matches && matchesRequirement(req, node.Metadata.Labels)
+		}
+		for _, req := range term.MatchFields {
+			matches = matches && matchesRequirement(req, fields)
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeFit runs the scheduler's node filters the analyzer can evaluate from the
+// pod and node specs; free is what the node has left after the pods running on
+// it. The details of the fit are also returned by reason.
+func nodeFit(pod schedulingPod, node schedulingNode, requests, free map[string]quantity.Quantity) (NodeFit, map[string][]string) {
+	fit := NodeFit{Node: node.Metadata.Name, Free: map[string]string{}}
+	details := map[string][]string{}
+	add := func(reason, detail string) {
+		if !containsValue(fit.Reasons, reason) {
+			fit.Reasons = append(fit.Reasons, reason)
+		}
+		fit.Details = append(fit.Details, detail)
+		details[reason] = append(details[reason], detail)
+	}
+
+	if !node.ready() {
+		add(SchedulingNodeNotReady, "node is NotReady")
+	}
+	if node.Spec.Unschedulable && !tolerates(pod.Spec.Tolerations, taint{Key: "node.kubernetes.io/unschedulable", Effect: "NoSchedule"}) {
+		add(SchedulingNodeUnschedulable, "node is cordoned")
+	}
+	for _, key := range sortedStrings(pod.Spec.NodeSelector) {
+		if value, ok := node.Metadata.Labels[key]; !ok || value != pod.Spec.NodeSelector[key] {
+			add(SchedulingNodeSelector, fmt.Sprintf("node lacks label %s=%s", key, pod.Spec.NodeSelector[key]))
+		}
+	}
+	if required := pod.Spec.Affinity.NodeAffinity.Required; required != nil && !matchesNodeAffinity(required.NodeSelectorTerms, node) {
+		add(SchedulingNodeAffinity, "node does not match the required node affinity")
+	}
+	for _, t := range node.Spec.Taints {
+		if (t.Effect == "NoSchedule" || t.Effect == "NoExecute") && !tolerates(pod.Spec.Tolerations, t) {
+			add(SchedulingUntoleratedTaint, "untolerated taint "+t.String())
+		}
+	}
+	for _, resource := range sortedStrings(requests) {
+		requested := requests[resource]
+		if requested <= 0 {
+			continue
+		}
+		available := free[resource]
+		fit.Free[resource] = quantity.Format(resource, available)
+		if requested > available {
+			add(SchedulingInsufficientResources, fmt.Sprintf("insufficient %s: requested %s, free %s",
+				resource, quantity.Format(resource, requested), quantity.Format(resource, available)))
+		}
+	}
+	fit.Fits = len(fit.Reasons) == 0
+	return fit, details
+}
+
+func sortedStrings[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// schedulerMessageReason is one "<count> <reason>" clause of a FailedScheduling message
+type schedulerMessageReason struct {
+	Nodes int
+	Text  string
+}
+
+// parseSchedulerMessage splits a FailedScheduling message such as "0/3 nodes are
+// available: 1 node(s) had untolerated taint {...}, 2 Insufficient cpu. preemption: ..."
+// into its per-reason node counts
+func parseSchedulerMessage(message string) []schedulerMessageReason {
+	if i := strings.Index(message, " preemption:"); i >= 0 {
+		message = message[:i]
+	}
+	_, reasons, ok := strings.Cut(message, "available: ")
+	if !ok {
+		return nil
+	}
+	reasons = strings.TrimRight(strings.TrimSpace(reasons), ".")
+
+	// Taint and label clauses may contain ", " themselves, so clauses are only
+	// split before a node count
+	var clauses []string
+	for _, part := range strings.Split(reasons, ", ") {
+		if count, _, _ := strings.Cut(part, " "); len(clauses) > 0 && !isCount(count) {
+			clauses[len(clauses)-1] += ", " + part
+			continue
+		}
+		clauses = append(clauses, part)
+	}
+
+	var parsed []schedulerMessageReason
+	for _, clause := range clauses {
+		count, text, _ := strings.Cut(clause, " ")
+		nodes, err := strconv.Atoi(count)
+		if err != nil {
+			continue
+		}
+		parsed = append(parsed, schedulerMessageReason{Nodes: nodes, Text: text})
+	}
+	return parsed
+}
+
+func isCount(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// classifySchedulerReason maps a FailedScheduling clause to a scheduling reason
+func classifySchedulerReason(text string) string {
+	lower := strings.ToLower(text)
+	for _, p := range schedulerMessagePatterns {
+		if strings.Contains(lower, p.pattern) {
+			return p.reason
+		}
+	}
+	return SchedulingOther
+}
+
+// latestSchedulingFailure returns the message of the most recent FailedScheduling event
+func latestSchedulingFailure(events []PodEvent) string {
+	var latest *PodEvent
+	for i, event := range events {
+		if event.Reason == "FailedScheduling" && (latest == nil || event.LastTime >= latest.LastTime) {
+			latest = &events[i]
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.Message
+}
+
+// analyzeScheduling explains why a pod is not scheduled from the nodes, the pods
+// running on them and the pod's events, ranking the reasons by how many nodes
+// they rule out
+func analyzeScheduling(pod schedulingPod, nodes []schedulingNode, running []schedulingPod, events []PodEvent) *SchedulingAnalysis {
+	analysis := &SchedulingAnalysis{
+		Pod:       pod.Metadata.Name,
+		Namespace: pod.Metadata.Namespace,
+		Reasons:   []SchedulingReason{},
+		Nodes:     []NodeFit{},
+		Requests:  map[string]string{},
+	}
+	if pod.Spec.NodeName != "" {
+		analysis.Scheduled = true
+		analysis.Node = pod.Spec.NodeName
+		analysis.Summary = fmt.Sprintf("Pod is scheduled to node %s and is Pending while its volumes are mounted and containers start", pod.Spec.NodeName)
+		return analysis
+	}
+
+	requests := pod.requests()
+	for resource, value := range requests {
+		if resource != "pods" {
+			analysis.Requests[resource] = quantity.Format(resource, value)
+		}
+	}
+	analysis.SchedulerMessage = latestSchedulingFailure(events)
+
+	// What each node has left is its allocatable capacity minus the requests of its pods
+	podsByNode := map[string][]schedulingPod{}
+	for _, p := range running {
+		if p.Spec.NodeName != "" && p.id() != pod.id() {
+			podsByNode[p.Spec.NodeName] = append(podsByNode[p.Spec.NodeName], p)
+		}
+	}
+	freeByNode := map[string]map[string]quantity.Quantity{}
+	type reasonTally struct {
+		nodes   int
+		details []string
+	}
+	tallies := map[string]*reasonTally{}
+	for _, node := range nodes {
+		free := parseRequests(node.Status.Allocatable)
+		for _, p := range podsByNode[node.Metadata.Name] {
+			for resource, value := range p.requests() {
+				free[resource] -= value
+			}
+		}
+		freeByNode[node.Metadata.Name] = free
+
+		fit, details := nodeFit(pod, node, requests, free)
+		analysis.Nodes = append(analysis.Nodes, fit)
+		for _, reason := range fit.Reasons {
+			if tallies[reason] == nil {
+				tallies[reason] = &reasonTally{}
+			}
+			tallies[reason].nodes++
+			for _, detail := range details[reason] {
+				if !containsValue(tallies[reason].details, detail) {
+					tallies[reason].details = append(tallies[reason].details, detail)
+				}
+			}
+		}
+	}
+	if len(nodes) == 0 {
+		tallies[SchedulingNoNodes] = &reasonTally{details: []string{"the cluster has no nodes"}}
+	}
+
+	for reason, tally := range tallies {
+		analysis.Reasons = append(analysis.Reasons, SchedulingReason{
+			Reason:      reason,
+			Nodes:       tally.nodes,
+			Detail:      strings.Join(tally.details, "; "),
+			Remediation: schedulingRemediations[reason],
+			Source:      reasonSourceAnalysis,
+		})
+	}
+
+	// The scheduler also reports constraints the analyzer does not evaluate, such
+	// as pod affinity, topology spread and volume binding
+	for _, clause := range parseSchedulerMessage(analysis.SchedulerMessage) {
+		reason := classifySchedulerReason(clause.Text)
+		if tallies[reason] != nil || (reason == SchedulingNodeAffinity && tallies[SchedulingNodeSelector] != nil) {
+			continue
+		}
+		analysis.Reasons = append(analysis.Reasons, SchedulingReason{
+			Reason:      reason,
+			Nodes:       clause.Nodes,
+			Detail:      clause.Text,
+			Remediation: schedulingRemediations[reason],
+			Source:      reasonSourceScheduler,
+		})
+		tallies[reason] = &reasonTally{nodes: clause.Nodes}
+	}
+	rankSchedulingReasons(analysis.Reasons)
+
+	analysis.Preemption = assessPreemption(pod, requests, analysis.Nodes, podsByNode, freeByNode)
+	analysis.Summary = schedulingSummary(analysis)
+	return analysis
+}
+
+func rankSchedulingReasons(reasons []SchedulingReason) {
+	order := make(map[string]int, len(schedulingReasonOrder))
+	for i, reason := range schedulingReasonOrder {
+		order[reason] = i
+	}
+	sort.SliceStable(reasons, func(i, j int) bool {
+		if reasons[i].Nodes != reasons[j].Nodes {
+			return reasons[i].Nodes > reasons[j].Nodes
+		}
+		return order[reasons[i].Reason] < order[reasons[j].Reason]
+	})
+}
+
+// maxPreemptionCandidates is the number of preemption candidates reported
+const maxPreemptionCandidates = 3
+
+// assessPreemption finds the nodes where evicting lower-priority pods would free
+// enough resources, the way the scheduler's preemption picks victims
+func assessPreemption(pod schedulingPod, requests map[string]quantity.Quantity, fits []NodeFit, podsByNode map[string][]schedulingPod, freeByNode map[string]map[string]quantity.Quantity) *PreemptionAnalysis {
+	preemption := &PreemptionAnalysis{
+		Priority:      pod.priority(),
+		PriorityClass: pod.Spec.PriorityClassName,
+		Policy:        pod.Spec.PreemptionPolicy,
+		NominatedNode: pod.Status.NominatedNodeName,
+	}
+	if preemption.Policy == "" {
+		preemption.Policy = "PreemptLowerPriority"
+	}
+	if preemption.Policy == "Never" {
+		preemption.Detail = "preemptionPolicy is Never, so the pod waits for capacity instead of preempting lower-priority pods"
+		return preemption
+	}
+
+	resourceOnly := 0
+	for _, fit := range fits {
+		if len(fit.Reasons) != 1 || fit.Reasons[0] != SchedulingInsufficientResources {
+			continue
+		}
+		resourceOnly++
+
+		var lower []schedulingPod
+		for _, p := range podsByNode[fit.Node] {
+			if p.priority() < preemption.Priority {
+				lower = append(lower, p)
+			}
+		}
+		sort.SliceStable(lower, func(i, j int) bool { return lower[i].priority() < lower[j].priority() })
+
+		free := make(map[string]quantity.Quantity, len(freeByNode[fit.Node]))
+		for resource, value := range freeByNode[fit.Node] {
+			free[resource] = value
+		}
+		var victims []string
+		for _, victim := range lower {
+			if fitsResources(requests, free) {
+				break
+			}
+			for resource, value := range victim.requests() {
+				free[resource] += value
+			}
+			victims = append(victims, victim.id())
+		}
+		if fitsResources(requests, free) {
+			preemption.Candidates = append(preemption.Candidates, PreemptionCandidate{Node: fit.Node, Victims: victims})
+		}
+	}
+	sort.SliceStable(preemption.Candidates, func(i, j int) bool {
+		return len(preemption.Candidates[i].Victims) < len(preemption.Candidates[j].Victims)
+	})
+	if len(preemption.Candidates) > maxPreemptionCandidates {
+		preemption.Candidates = preemption.Candidates[:maxPreemptionCandidates]
+	}
+	preemption.Possible = len(preemption.Candidates) > 0
+
+	switch {
+	case preemption.NominatedNode != "":
+		preemption.Detail = fmt.Sprintf("the scheduler nominated node %s and is waiting for the preempted pods to terminate", preemption.NominatedNode)
+	case preemption.Possible:
+		best := preemption.Candidates[0]
+		preemption.Detail = fmt.Sprintf("preempting %d lower-priority pod(s) on node %s would make room; the scheduler does so unless PodDisruptionBudgets protect them", len(best.Victims), best.Node)
+	case resourceOnly > 0:
+		preemption.Detail = fmt.Sprintf("no node has enough pods below priority %d to free the requested resources; give the pod a higher priority class or add capacity", preemption.Priority)
+	default:
+		preemption.Detail = "preemption cannot help because every node is ruled out by constraints other than resources"
+	}
+	return preemption
+}
+
+func fitsResources(requests, free map[string]quantity.Quantity) bool {
+	for resource, requested := range requests {
+		if requested > free[resource] {
+			return false
+		}
+	}
+	return true
+}
+
+// schedulingSummary is a one sentence summary of a scheduling analysis
+func schedulingSummary(analysis *SchedulingAnalysis) string {
+	fitting := 0
+	for _, fit := range analysis.Nodes {
+		if fit.Fits {
+			fitting++
+		}
+	}
+	if fitting > 0 {
+		summary := fmt.Sprintf("%d of %d nodes satisfy the pod's selectors, tolerations and resource requests", fitting, len(analysis.Nodes))
+		for _, reason := range analysis.Reasons {
+			if reason.Source == reasonSourceScheduler {
+				return fmt.Sprintf("%s, but the scheduler reports: %s", summary, reason.Detail)
+			}
+		}
+		return summary + "; the scheduler may not have retried the pod yet"
+	}
+	if len(analysis.Reasons) == 0 {
+		return "No reason the pod cannot be scheduled was found"
+	}
+	top := analysis.Reasons[0]
+	return fmt.Sprintf("Pod cannot be scheduled on any of %d nodes; the main reason is %s (%d nodes): %s",
+		len(analysis.Nodes), strings.ReplaceAll(top.Reason, "_", " "), top.Nodes, top.Detail)
+}
+
+// AnalysisResult converts the scheduling analysis to a pod analysis, used when
+// no LLM analysis is available
+func (s *SchedulingAnalysis) AnalysisResult() *AnalysisResult {
+	severity := "High"
+	for _, fit := range s.Nodes {
+		if fit.Fits {
+			severity = "Medium"
+		}
+	}
+
+	rootCause := "The scheduler has not placed the pod"
+	if len(s.Reasons) > 0 {
+		rootCause = fmt.Sprintf("%s: %s", strings.ReplaceAll(s.Reasons[0].Reason, "_", " "), s.Reasons[0].Detail)
+	}
+	var steps []interface{}
+	for _, reason := range s.Reasons {
+		if !containsInterface(steps, reason.Remediation) {
+			steps = append(steps, reason.Remediation)
+		}
+	}
+	if s.Preemption != nil && s.Preemption.Possible {
+		steps = append(steps, "Preemption: "+s.Preemption.Detail)
+	}
+	if len(steps) == 0 {
+		steps = append(steps, schedulingRemediations[SchedulingOther])
+	}
+
+	return &AnalysisResult{
+		Type:   AnalysisTypePod,
+		Source: AnalysisSourceDeterministic,
+		Data: map[string]interface{}{
+			"summary":           s.Summary,
+			"root_cause":        rootCause,
+			"severity":          severity,
+			"remediation_steps": steps,
+		},
+		Validation: AnalysisValidation{Valid: true},
+	}
+}
+
+func containsInterface(values []interface{}, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzePendingPod collects the pod, the nodes and the pods running on them and
+// analyzes why the pod is not scheduled; events are fetched when nil
+func (a *AlertTool) analyzePendingPod(ctx context.Context, podName, namespace string, events []PodEvent) (*SchedulingAnalysis, error) {
+	output, err := a.runKubectlCommandString(ctx, "get", "pod", podName, "-n", namespace, "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod: %w", err)
+	}
+	var pod schedulingPod
+	if err := json.Unmarshal([]byte(output), &pod); err != nil {
+		return nil, fmt.Errorf("failed to parse pod: %w", err)
+	}
+	if pod.Status.Phase != "" && pod.Status.Phase != "Pending" {
+		return nil, fmt.Errorf("pod %s/%s is %s, not Pending", namespace, podName, pod.Status.Phase)
+	}
+	if pod.Spec.NodeName != "" {
+		return analyzeScheduling(pod, nil, nil, nil), nil
+	}
+
+	if events == nil {
+		if events, err = a.getPodEvents(ctx, podName, namespace); err != nil {
+			logger.Get().Info("Failed to get events for scheduling analysis", "pod", podName, "namespace", namespace, "error", err)
+		}
+	}
+
+	output, err = a.runKubectlCommandString(ctx, "get", "nodes", "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes: %w", err)
+	}
+	var nodeList struct {
+		Items []schedulingNode `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &nodeList); err != nil {
+		return nil, fmt.Errorf("failed to parse nodes: %w", err)
+	}
+
+	output, err = a.runKubectlCommandString(ctx, "get", "pods", "--all-namespaces",
+		"--field-selector", "status.phase!=Succeeded,status.phase!=Failed", "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get running pods: %w", err)
+	}
+	var podList struct {
+		Items []schedulingPod `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &podList); err != nil {
+		return nil, fmt.Errorf("failed to parse running pods: %w", err)
+	}
+
+	return analyzeScheduling(pod, nodeList.Items, podList.Items, events), nil
+}
+
+// schedulingFallback analyzes a Pending pod alert without the LLM, returning nil
+// when the pod is not waiting to be scheduled or cannot be analyzed
+func (a *AlertTool) schedulingFallback(ctx context.Context, alert PodAlert) *AnalysisResult {
+	if alert.Status != "Pending" {
+		return nil
+	}
+	analysis, err := a.analyzePendingPod(ctx, alert.PodName, alert.Namespace, alert.Events)
+	if err != nil {
+		logger.Get().Error("Scheduling analysis failed", "pod", alert.PodName, "namespace", alert.Namespace, "error", err)
+		return nil
+	}
+	if analysis.Scheduled {
+		return nil
+	}
+	return analysis.AnalysisResult()
+}
+
+func (a *AlertTool) handleAnalyzePendingPod(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	podName := p.String("pod_name", "", params.Required())
+	namespace := p.String("namespace", "default")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	analysis, err := a.analyzePendingPod(ctx, podName, namespace, nil)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	analysisJSON, err := json.MarshalIndent(analysis, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal scheduling analysis: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(analysisJSON)), nil
+}
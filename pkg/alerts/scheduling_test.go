@@ -0,0 +1,258 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const testPendingPodJSON = `{"metadata":{"name":"api-1","namespace":"prod"},
+	"spec":{"nodeSelector":{"disk":"ssd"},"priority":1000,"priorityClassName":"online",
+		"containers":[{"name":"api","resources":{"requests":{"cpu":"2","memory":"1Gi"}}}]},
+	"status":{"phase":"Pending"}}`
+
+const testNodesJSON = `{"items":[
+	{"metadata":{"name":"cp","labels":{"disk":"ssd"}},
+	 "spec":{"taints":[{"key":"node-role.kubernetes.io/control-plane","effect":"NoSchedule"}]},
+	 "status":{"allocatable":{"cpu":"4","memory":"8Gi","pods":"110"},"conditions":[{"type":"Ready","status":"True"}]}},
+	{"metadata":{"name":"node-1","labels":{"disk":"ssd"}},
+	 "status":{"allocatable":{"cpu":"4","memory":"8Gi","pods":"110"},"conditions":[{"type":"Ready","status":"True"}]}},
+	{"metadata":{"name":"node-2","labels":{"disk":"ssd"}},
+	 "status":{"allocatable":{"cpu":"4","memory":"8Gi","pods":"110"},"conditions":[{"type":"Ready","status":"True"}]}},
+	{"metadata":{"name":"node-3","labels":{"disk":"hdd"}},"spec":{"unschedulable":true},
+	 "status":{"allocatable":{"cpu":"4","memory":"8Gi","pods":"110"},"conditions":[{"type":"Ready","status":"True"}]}}]}`
+
+const testRunningPodsJSON = `{"items":[
+	{"metadata":{"name":"batch-1","namespace":"batch"},"spec":{"nodeName":"node-1","priority":0,
+		"containers":[{"name":"worker","resources":{"requests":{"cpu":"3"}}}]},"status":{"phase":"Running"}},
+	{"metadata":{"name":"db-0","namespace":"prod"},"spec":{"nodeName":"node-2","priority":2000,
+		"containers":[{"name":"db","resources":{"requests":{"cpu":"2500m","memory":"4Gi"}}}]},"status":{"phase":"Running"}},
+	{"metadata":{"name":"api-1","namespace":"prod"},"spec":{},"status":{"phase":"Pending"}}]}`
+
+const testSchedulingMessage = "0/4 nodes are available: 1 node(s) didn't match pod anti-affinity rules, " +
+	"1 node(s) had untolerated taint {node-role.kubernetes.io/control-plane: }, 2 Insufficient cpu. " +
+	"preemption: 0/4 nodes are available: 1 No preemption victims found for incoming pod."
+
+func TestParseSchedulerMessage(t *testing.T) {
+	assert.Equal(t, []schedulerMessageReason{
+		{Nodes: 1, Text: "node(s) didn't match pod anti-affinity rules"},
+		{Nodes: 1, Text: "node(s) had untolerated taint {node-role.kubernetes.io/control-plane: }"},
+		{Nodes: 2, Text: "Insufficient cpu"},
+	}, parseSchedulerMessage(testSchedulingMessage))
+
+	// Clauses containing ", " are kept whole
+	assert.Equal(t, []schedulerMessageReason{{Nodes: 3, Text: "node(s) had untolerated taint {a: b, c: d}"}},
+		parseSchedulerMessage("0/3 nodes are available: 3 node(s) had untolerated taint {a: b, c: d}."))
+	assert.Nil(t, parseSchedulerMessage("Successfully assigned prod/api-1 to node-1"))
+
+	assert.Equal(t, SchedulingPodAffinity, classifySchedulerReason("node(s) didn't match pod anti-affinity rules"))
+	assert.Equal(t, SchedulingNodeAffinity, classifySchedulerReason("node(s) didn't match Pod's node affinity/selector"))
+	assert.Equal(t, SchedulingVolume, classifySchedulerReason("pod has unbound immediate PersistentVolumeClaims"))
+	assert.Equal(t, SchedulingTopologySpread, classifySchedulerReason("node(s) didn't match pod topology spread constraints"))
+	assert.Equal(t, SchedulingOther, classifySchedulerReason("something new"))
+}
+
+func TestNodeConstraints(t *testing.T) {
+	controlPlane := taint{Key: "node-role.kubernetes.io/control-plane", Effect: "NoSchedule"}
+	assert.True(t, tolerates([]toleration{{Operator: "Exists"}}, controlPlane))
+	assert.True(t, tolerates([]toleration{{Key: controlPlane.Key, Operator: "Exists", Effect: "NoSchedule"}}, controlPlane))
+	assert.False(t, tolerates([]toleration{{Key: controlPlane.Key, Operator: "Exists", Effect: "NoExecute"}}, controlPlane))
+	assert.True(t, tolerates([]toleration{{Key: "gpu", Value: "true"}}, taint{Key: "gpu", Value: "true", Effect: "NoSchedule"}))
+	assert.False(t, tolerates([]toleration{{Key: "gpu", Value: "false"}}, taint{Key: "gpu", Value: "true", Effect: "NoSchedule"}))
+
+	var node schedulingNode
+	node.Metadata.Name = "node-1"
+	node.Metadata.Labels = map[string]string{"zone": "a", "cores": "16"}
+	terms := []nodeSelectorTerm{
+		{MatchExpressions: []nodeSelectorRequirement{{Key: "zone", Operator: "In", Values: []string{"b"}}}},
+		{MatchExpressions: []nodeSelectorRequirement{{Key: "cores", Operator: "Gt", Values: []string{"8"}}, {Key: "gpu", Operator: "DoesNotExist"}}},
+	}
+	assert.True(t, matchesNodeAffinity(terms, node))
+	assert.False(t, matchesNodeAffinity(terms[:1], node))
+	assert.False(t, matchesNodeAffinity([]nodeSelectorTerm{{MatchFields: []nodeSelectorRequirement{{Key: "metadata.name", Operator: "NotIn", Values: []string{"node-1"}}}}}, node))
+}
+
+func testSchedulingInputs(t *testing.T) (schedulingPod, []schedulingNode, []schedulingPod) {
+	var pod schedulingPod
+	require.NoError(t, json.Unmarshal([]byte(testPendingPodJSON), &pod))
+	var nodes, running struct {
+		Items json.RawMessage `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(testNodesJSON), &nodes))
+	require.NoError(t, json.Unmarshal([]byte(testRunningPodsJSON), &running))
+	var nodeItems []schedulingNode
+	var podItems []schedulingPod
+	require.NoError(t, json.Unmarshal(nodes.Items, &nodeItems))
+	require.NoError(t, json.Unmarshal(running.Items, &podItems))
+	return pod, nodeItems, podItems
+}
+
+func TestAnalyzeScheduling(t *testing.T) {
+	pod, nodes, running := testSchedulingInputs(t)
+	events := []PodEvent{
+		{Reason: "FailedScheduling", Message: "0/4 nodes are available: 4 Insufficient memory.", LastTime: "2026-01-05T09:00:00Z"},
+		{Reason: "FailedScheduling", Message: testSchedulingMessage, LastTime: "2026-01-05T10:00:00Z"},
+	}
+	analysis := analyzeScheduling(pod, nodes, running, events)
+
+	assert.False(t, analysis.Scheduled)
+	assert.Equal(t, map[string]string{"cpu": "2", "memory": "1Gi"}, analysis.Requests)
+	assert.Equal(t, testSchedulingMessage, analysis.SchedulerMessage)
+
+	reasons := make([]string, len(analysis.Reasons))
+	for i, reason := range analysis.Reasons {
+		reasons[i] = reason.Reason
+	}
+	assert.Equal(t, []string{
+		SchedulingInsufficientResources, SchedulingNodeUnschedulable, SchedulingNodeSelector,
+		SchedulingUntoleratedTaint, SchedulingPodAffinity,
+	}, reasons)
+	assert.Equal(t, SchedulingReason{
+		Reason:      SchedulingInsufficientResources,
+		Nodes:       2,
+		Detail:      "insufficient cpu: requested 2, free 1; insufficient cpu: requested 2, free 1500m",
+		Remediation: schedulingRemediations[SchedulingInsufficientResources],
+		Source:      reasonSourceAnalysis,
+	}, analysis.Reasons[0])
+	assert.Equal(t, "node lacks label disk=ssd", analysis.Reasons[2].Detail)
+	assert.Equal(t, "untolerated taint node-role.kubernetes.io/control-plane:NoSchedule", analysis.Reasons[3].Detail)
+	assert.Equal(t, reasonSourceScheduler, analysis.Reasons[4].Source)
+	assert.Equal(t, 1, analysis.Reasons[4].Nodes)
+
+	require.Len(t, analysis.Nodes, 4)
+	assert.Equal(t, map[string]string{"cpu": "1", "memory": "8Gi", "pods": "109"}, analysis.Nodes[1].Free)
+
+	// Only batch-1 on node-1 has a lower priority than the pod; db-0 on node-2 does not
+	require.NotNil(t, analysis.Preemption)
+	assert.Equal(t, PreemptionAnalysis{
+		Priority:      1000,
+		PriorityClass: "online",
+		Policy:        "PreemptLowerPriority",
+		Possible:      true,
+		Candidates:    []PreemptionCandidate{{Node: "node-1", Victims: []string{"batch/batch-1"}}},
+		Detail:        "preempting 1 lower-priority pod(s) on node node-1 would make room; the scheduler does so unless PodDisruptionBudgets protect them",
+	}, *analysis.Preemption)
+
+	assert.Equal(t, "Pod cannot be scheduled on any of 4 nodes; the main reason is insufficient resources (2 nodes): "+analysis.Reasons[0].Detail, analysis.Summary)
+
+	result := analysis.AnalysisResult()
+	assert.Equal(t, AnalysisSourceDeterministic, result.Source)
+	assert.True(t, result.Validation.Valid)
+	assert.Equal(t, "High", result.Data["severity"])
+	steps := result.RemediationSteps()
+	require.Len(t, steps, 6)
+	assert.Equal(t, schedulingRemediations[SchedulingInsufficientResources], steps[0])
+	assert.Equal(t, "Preemption: "+analysis.Preemption.Detail, steps[5])
+
+	// The analysis result matches the pod analysis schema
+	data, err := json.Marshal(result.Data)
+	require.NoError(t, err)
+	_, errs := analysisSchemas[AnalysisTypePod].ValidateJSON(string(data))
+	assert.Empty(t, errs)
+}
+
+func TestAnalyzeSchedulingPreemption(t *testing.T) {
+	pod, nodes, running := testSchedulingInputs(t)
+
+	pod.Spec.PreemptionPolicy = "Never"
+	preemption := analyzeScheduling(pod, nodes, running, nil).Preemption
+	assert.False(t, preemption.Possible)
+	assert.Contains(t, preemption.Detail, "preemptionPolicy is Never")
+
+	pod.Spec.PreemptionPolicy = ""
+	pod.Spec.Priority = nil
+	preemption = analyzeScheduling(pod, nodes, running, nil).Preemption
+	assert.False(t, preemption.Possible)
+	assert.Equal(t, "no node has enough pods below priority 0 to free the requested resources; give the pod a higher priority class or add capacity", preemption.Detail)
+
+	// A pod that fits a node is only waiting for the scheduler
+	pod.Spec.Containers[0].Resources.Requests = map[string]string{"cpu": "500m"}
+	analysis := analyzeScheduling(pod, nodes, running, nil)
+	assert.Equal(t, "2 of 4 nodes satisfy the pod's selectors, tolerations and resource requests; the scheduler may not have retried the pod yet", analysis.Summary)
+	assert.Equal(t, "Medium", analysis.AnalysisResult().Data["severity"])
+
+	assert.Equal(t, []string{SchedulingNoNodes}, []string{analyzeScheduling(pod, nil, nil, nil).Reasons[0].Reason})
+}
+
+func addSchedulingCommands(mock *cmd.MockShellExecutor, podJSON string) {
+	mock.AddCommandString("kubectl", []string{"get", "pod", "api-1", "-n", "prod", "-o", "json"}, podJSON, nil)
+	mock.AddCommandString("kubectl", []string{"get", "nodes", "-o", "json"}, testNodesJSON, nil)
+	mock.AddCommandString("kubectl", []string{"get", "pods", "--all-namespaces", "--field-selector", "status.phase!=Succeeded,status.phase!=Failed", "-o", "json"}, testRunningPodsJSON, nil)
+}
+
+func TestHandleAnalyzePendingPod(t *testing.T) {
+	call := func(t *testing.T, mock *cmd.MockShellExecutor) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"pod_name": "api-1", "namespace": "prod"}
+		result, err := NewAlertTool(nil).handleAnalyzePendingPod(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("unschedulable pod", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		addSchedulingCommands(mock, testPendingPodJSON)
+		mock.AddCommandString("kubectl", eventsArgs("api-1"), `{"items":[{"type":"Warning","reason":"FailedScheduling","message":"`+testSchedulingMessage+`"}]}`, nil)
+
+		result := call(t, mock)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		var analysis SchedulingAnalysis
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &analysis))
+		assert.Len(t, analysis.Reasons, 5)
+		assert.Equal(t, testSchedulingMessage, analysis.SchedulerMessage)
+	})
+
+	t.Run("scheduled pod", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pod", "api-1", "-n", "prod", "-o", "json"},
+			`{"metadata":{"name":"api-1","namespace":"prod"},"spec":{"nodeName":"node-1"},"status":{"phase":"Pending"}}`, nil)
+
+		result := call(t, mock)
+		require.False(t, result.IsError)
+		var analysis SchedulingAnalysis
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &analysis))
+		assert.True(t, analysis.Scheduled)
+		assert.Equal(t, "node-1", analysis.Node)
+		assert.Len(t, mock.GetCallLog(), 1)
+	})
+
+	t.Run("running pod", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pod", "api-1", "-n", "prod", "-o", "json"},
+			`{"metadata":{"name":"api-1","namespace":"prod"},"status":{"phase":"Running"}}`, nil)
+
+		result := call(t, mock)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "is Running, not Pending")
+	})
+}
+
+func TestHandleGetPodAlertsSchedulingFallback(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pods", "-o", "json", "-n", "prod"},
+		`{"items":[{"metadata":{"name":"api-1","namespace":"prod"},"status":{"phase":"Pending"}}]}`, nil)
+	mock.AddCommandString("kubectl", eventsArgs("api-1"), `{"items":[{"type":"Warning","reason":"FailedScheduling","message":"`+testSchedulingMessage+`"}]}`, nil)
+	mock.AddCommandString("kubectl", logsArgs("api-1"), "", nil)
+	addSchedulingCommands(mock, testPendingPodJSON)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"namespace": "prod", "include_analysis": "true"}
+	result, err := NewAlertTool(nil).handleGetPodAlerts(cmd.WithShellExecutor(context.Background(), mock), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	var alerts []PodAlert
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &alerts))
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertStateAnalyzed, alerts[0].State)
+	require.NotNil(t, alerts[0].AnalysisResult)
+	assert.Equal(t, AnalysisSourceDeterministic, alerts[0].AnalysisResult.Source)
+	assert.Contains(t, alerts[0].Analysis, "Root cause: insufficient resources: insufficient cpu")
+	assert.Contains(t, alerts[0].Remediation, "Preemption: preempting 1 lower-priority pod(s) on node node-1")
+}
@@ -0,0 +1,109 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/schema"
+)
+
+// storedTypes are the documents persisted by an AlertStore, by schema name
+var storedTypes = map[string]struct {
+	Type        reflect.Type
+	Description string
+}{
+	"ClusterMetadata":   {reflect.TypeOf(ClusterMetadata{}), "Environment, criticality, owning team and notification channels of a cluster"},
+	"AlertDocument":     {reflect.TypeOf(AlertDocument{}), "Stored pod alert with its remediation history"},
+	"PodAlert":          {reflect.TypeOf(PodAlert{}), "Pod alert with collected events, logs and analysis"},
+	"RemediationRecord": {reflect.TypeOf(RemediationRecord{}), "Remediation applied to an alert and the outcome of its follow-up check"},
+	"IncidentReport":    {reflect.TypeOf(IncidentReport{}), "Rendered incident report"},
+	"Job":               {reflect.TypeOf(Job{}), "Background job, such as a remediation verification, and its attempts"},
+	"Silence":           {reflect.TypeOf(Silence{}), "Time window during which matching alerts are stored without notifications or analysis"},
+	"SLO":               {reflect.TypeOf(SLO{}), "Service level objective with its latest error budget evaluation"},
+	"PodMonitor":        {reflect.TypeOf(PodMonitor{}), "Time-boxed watch of a pod with its observations and outcome"},
+}
+
+// StorageSchemaNames returns the names of the published storage schemas in order
+func StorageSchemaNames() []string {
+	names := make([]string, 0, len(storedTypes))
+	for name := range storedTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StorageSchema returns the JSON Schema of a stored document type, or nil if
+// the name is unknown
+func StorageSchema(name string) *schema.Schema {
+	stored, ok := storedTypes[name]
+	if !ok {
+		return nil
+	}
+
+	s := schema.FromType(stored.Type)
+	s.Title = name
+	s.Description = stored.Description
+	setStorageEnums(s)
+	return s
+}
+
+// setStorageEnums restricts the properties holding known constants to their values
+func setStorageEnums(s *schema.Schema) {
+	enums := map[string][]interface{}{
+		"state":        {string(AlertStateCollected), string(AlertStateAnalyzed), string(AlertStateRemediated)},
+		"verification": {VerificationPending, VerificationResolved, VerificationDegraded, VerificationRecurred, VerificationUnknown},
+		"format":       {ReportFormatMarkdown, ReportFormatHTML},
+	}
+
+	var walk func(*schema.Schema)
+	walk = func(s *schema.Schema) {
+		if s == nil {
+			return
+		}
+		for name, property := range s.Properties {
+			if values, ok := enums[name]; ok && property.Type == "string" {
+				property.Enum = values
+			}
+			walk(property)
+		}
+		walk(s.Items)
+	}
+	walk(s)
+}
+
+// handleGetSchemas returns the JSON Schemas of the stored alert documents
+func (a *AlertTool) handleGetSchemas(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	names := StorageSchemaNames()
+	p := params.New(request)
+	name := p.String("name", "", params.OneOf(names...))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if name != "" {
+		names = []string{name}
+	}
+
+	schemas := make(map[string]*schema.Schema, len(names))
+	for _, name := range names {
+		schemas[name] = StorageSchema(name)
+	}
+
+	schemasJSON, err := json.MarshalIndent(schemas, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal schemas: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(schemasJSON)), nil
+}
+
+// storageSchemaNamesDescription lists the schema names for tool descriptions
+func storageSchemaNamesDescription() string {
+	return strings.Join(StorageSchemaNames(), ", ")
+}
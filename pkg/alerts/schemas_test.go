@@ -0,0 +1,64 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/schema"
+)
+
+func TestStorageSchemaValidatesStoredDocuments(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryAlertStore()
+	require.NoError(t, store.Upsert(ctx, PodAlert{PodName: "web-1", Namespace: "prod", State: AlertStateAnalyzed, Events: []PodEvent{{Type: "Warning"}}}))
+	_, err := store.AddRemediation(ctx, "prod", "web-1", RemediationRecord{Remediation: "restart", AppliedAt: time.Now(), Verification: VerificationPending})
+	require.NoError(t, err)
+	require.NoError(t, store.Upsert(ctx, PodAlert{PodName: "db-0", Namespace: "prod"}))
+
+	docs, err := store.List(ctx, "")
+	require.NoError(t, err)
+	documentSchema := StorageSchema("AlertDocument")
+	for _, doc := range docs {
+		data, err := json.Marshal(doc)
+		require.NoError(t, err)
+		_, errs := documentSchema.ValidateJSON(string(data))
+		assert.Empty(t, errs, doc.Alert.PodName)
+	}
+
+	_, errs := documentSchema.ValidateJSON(`{"alert": {"state": "Closed"}, "remediations": [], "created_at": "", "updated_at": ""}`)
+	assert.NotEmpty(t, errs)
+	assert.Nil(t, StorageSchema("ChatSession"))
+}
+
+func TestHandleGetSchemas(t *testing.T) {
+	tool := NewAlertTool(nil)
+
+	request := mcp.CallToolRequest{}
+	result, err := tool.handleGetSchemas(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	var schemas map[string]*schema.Schema
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &schemas))
+	assert.Len(t, schemas, len(StorageSchemaNames()))
+	assert.Equal(t, "PodAlert", schemas["PodAlert"].Title)
+	assert.Equal(t, []interface{}{"Collected", "Analyzed", "Remediated"}, schemas["PodAlert"].Properties["state"].Enum)
+
+	request.Params.Arguments = map[string]interface{}{"name": "IncidentReport"}
+	result, err = tool.handleGetSchemas(context.Background(), request)
+	require.NoError(t, err)
+	schemas = nil
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &schemas))
+	assert.Len(t, schemas, 1)
+	assert.Equal(t, "date-time", schemas["IncidentReport"].Properties["created_at"].Format)
+
+	request.Params.Arguments = map[string]interface{}{"name": "ChatSession"}
+	result, err = tool.handleGetSchemas(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
@@ -0,0 +1,393 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/kagent-dev/tools/internal/llm"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Sources of a remediation script
+const (
+	ScriptSourceTemplate = "template"
+	ScriptSourceLLM      = "llm"
+)
+
+var (
+	// quantityPattern matches Kubernetes resource quantities such as 512Mi or 1.5Gi
+	quantityPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(Ki|Mi|Gi|Ti|k|M|G|T)?$`)
+	// kindPattern matches kubectl resource types such as pvc or deployments.apps
+	kindPattern = regexp.MustCompile(`^[a-z][a-z0-9.-]*$`)
+	// replicaSetPodName matches pods created by a Deployment's ReplicaSet, capturing the Deployment name
+	replicaSetPodName = regexp.MustCompile(`^(.+)-[bcdfghjklmnpqrstvwxz2456789]{6,10}-[bcdfghjklmnpqrstvwxz2456789]{5}$`)
+	// scriptFence matches a fenced code block in an LLM response
+	scriptFence = regexp.MustCompile("(?s)```[a-z]*\n(.*?)```")
+)
+
+// ScriptParameter is a substitutable parameter of a script template
+type ScriptParameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+	Default     string `json:"default,omitempty"`
+
+	check func(string) error
+}
+
+// ScriptTemplate is a curated remediation script, selected for alerts with
+// one of its reasons or containing one of its keywords
+type ScriptTemplate struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Parameters  []ScriptParameter `json:"parameters"`
+
+	reasons  []string
+	keywords []string
+	script   *template.Template
+}
+
+// RemediationScript is a generated remediation script
+type RemediationScript struct {
+	Namespace  string            `json:"namespace"`
+	PodName    string            `json:"pod_name"`
+	Source     string            `json:"source"`
+	Template   string            `json:"template,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Script     string            `json:"script"`
+}
+
+// checkQuantity validates a resource quantity parameter
+func checkQuantity(value string) error {
+	if !quantityPattern.MatchString(value) {
+		return fmt.Errorf("invalid resource quantity %q", value)
+	}
+	return nil
+}
+
+// checkKind validates a resource type parameter
+func checkKind(value string) error {
+	if !kindPattern.MatchString(value) || len(value) > 253 {
+		return fmt.Errorf("invalid resource type %q", value)
+	}
+	return nil
+}
+
+// checkRevision validates a Helm revision parameter
+func checkRevision(value string) error {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("invalid revision %q", value)
+		}
+	}
+	return nil
+}
+
+// parseScript parses a template script, failing on parameters it does not declare
+func parseScript(name, text string) *template.Template {
+	return template.Must(template.New(name).Option("missingkey=error").Parse(text))
+}
+
+// Parameters shared by the templates
+var (
+	namespaceParameter  = ScriptParameter{Name: "namespace", Description: "Namespace of the workload", Required: true, check: security.ValidateNamespace}
+	deploymentParameter = ScriptParameter{Name: "deployment", Description: "Name of the Deployment owning the pod", Required: true, check: security.ValidateK8sResourceName}
+)
+
+// scriptTemplates is the curated template library, in matching priority order
+var scriptTemplates = []ScriptTemplate{
+	{
+		Name:        "bump-memory-limit",
+		Description: "Raise the memory request and limit of a Deployment's container and wait for the rollout",
+		Parameters: []ScriptParameter{
+			namespaceParameter,
+			deploymentParameter,
+			{Name: "container", Description: "Container to update (default: all containers)", check: security.ValidateK8sResourceName},
+			{Name: "memory", Description: "New memory request and limit", Default: "512Mi", check: checkQuantity},
+		},
+		reasons:  []string{"OOMKilled"},
+		keywords: []string{"oomkilled", "out of memory", "memory limit"},
+		script: parseScript("bump-memory-limit", `#!/usr/bin/env bash
+set -euo pipefail
+
+kubectl get deployment/{{.deployment}} -n {{.namespace}} -o jsonpath='{range .spec.template.spec.containers[*]}{.name}: {.resources}{"\n"}{end}'
+kubectl set resources deployment/{{.deployment}} -n {{.namespace}}{{if .container}} -c {{.container}}{{end}} --requests=memory={{.memory}} --limits=memory={{.memory}}
+kubectl rollout status deployment/{{.deployment}} -n {{.namespace}} --timeout=5m
+`),
+	},
+	{
+		Name:        "rollback-helm-release",
+		Description: "Roll a Helm release back to its previous or a given revision",
+		Parameters: []ScriptParameter{
+			namespaceParameter,
+			{Name: "release", Description: "Name of the Helm release", Required: true, check: security.ValidateHelmReleaseName},
+			{Name: "revision", Description: "Revision to roll back to (default: the previous revision)", check: checkRevision},
+		},
+		keywords: []string{"helm", "upgrade failed", "release failed"},
+		script: parseScript("rollback-helm-release", `#!/usr/bin/env bash
+set -euo pipefail
+
+helm history {{.release}} -n {{.namespace}} --max 5
+helm rollback {{.release}}{{if .revision}} {{.revision}}{{end}} -n {{.namespace}} --wait --timeout 5m
+helm status {{.release}} -n {{.namespace}}
+`),
+	},
+	{
+		Name:        "clear-stuck-finalizer",
+		Description: "Remove the finalizers of a resource stuck terminating",
+		Parameters: []ScriptParameter{
+			namespaceParameter,
+			{Name: "kind", Description: "Type of the stuck resource", Default: "pod", check: checkKind},
+			{Name: "name", Description: "Name of the stuck resource", Required: true, check: security.ValidateK8sResourceName},
+		},
+		keywords: []string{"finalizer", "stuck terminating"},
+		script: parseScript("clear-stuck-finalizer", `#!/usr/bin/env bash
+set -euo pipefail
+
+# Finalizers guard cleanup by their controllers; check it is safe to skip them
+kubectl get {{.kind}}/{{.name}} -n {{.namespace}} -o jsonpath='{.metadata.deletionTimestamp}{" "}{.metadata.finalizers}{"\n"}'
+kubectl patch {{.kind}}/{{.name}} -n {{.namespace}} --type merge -p '{"metadata":{"finalizers":null}}'
+`),
+	},
+	{
+		Name:        "restart-deployment",
+		Description: "Restart a Deployment's pods with a rolling restart and wait for the rollout",
+		Parameters:  []ScriptParameter{namespaceParameter, deploymentParameter},
+		reasons:     []string{"CrashLoopBackOff", "Error", "Unhealthy"},
+		keywords:    []string{"liveness probe failed", "deadlock"},
+		script: parseScript("restart-deployment", `#!/usr/bin/env bash
+set -euo pipefail
+
+kubectl rollout restart deployment/{{.deployment}} -n {{.namespace}}
+kubectl rollout status deployment/{{.deployment}} -n {{.namespace}} --timeout=5m
+`),
+	},
+}
+
+// scriptTemplateNames lists the template names for tool descriptions
+func scriptTemplateNames() []string {
+	names := make([]string, len(scriptTemplates))
+	for i, tmpl := range scriptTemplates {
+		names[i] = tmpl.Name
+	}
+	return names
+}
+
+// findScriptTemplate returns the template with the given name
+func findScriptTemplate(name string) *ScriptTemplate {
+	for i := range scriptTemplates {
+		if scriptTemplates[i].Name == name {
+			return &scriptTemplates[i]
+		}
+	}
+	return nil
+}
+
+// alertText gathers the text of an alert searched for template keywords
+func alertText(alert PodAlert) string {
+	parts := []string{alert.Status, alert.Reason, alert.Message, alert.Analysis}
+	for _, event := range alert.Events {
+		parts = append(parts, event.Reason, event.Message)
+	}
+	if alert.AnalysisResult != nil {
+		parts = append(parts, alert.AnalysisResult.RemediationSteps()...)
+	}
+	return strings.ToLower(strings.Join(parts, "\n"))
+}
+
+// matchScore scores how well a template applies to an alert; 0 means it does not apply.
+// Keywords point at a specific cause, so they outweigh generic reasons such as CrashLoopBackOff.
+func (t *ScriptTemplate) matchScore(alert PodAlert, text string) int {
+	score := 0
+	for _, reason := range t.reasons {
+		if strings.EqualFold(alert.Reason, reason) {
+			score += 5
+		}
+	}
+	for _, keyword := range t.keywords {
+		if strings.Contains(text, keyword) {
+			score += 10
+		}
+	}
+	return score
+}
+
+// alertParameters returns the template parameters that can be derived from an alert
+func alertParameters(alert PodAlert) map[string]string {
+	values := map[string]string{"namespace": alert.Namespace, "name": alert.PodName}
+	if match := replicaSetPodName.FindStringSubmatch(alert.PodName); match != nil {
+		values["deployment"] = match[1]
+	}
+	return values
+}
+
+// render substitutes the parameters into the template, applying defaults and validating each value
+func (t *ScriptTemplate) render(values map[string]string) (string, map[string]string, error) {
+	resolved := make(map[string]string, len(t.Parameters))
+	for _, parameter := range t.Parameters {
+		value := values[parameter.Name]
+		if value == "" {
+			value = parameter.Default
+		}
+		if value == "" {
+			if parameter.Required {
+				return "", nil, fmt.Errorf("template %s requires parameter %s", t.Name, parameter.Name)
+			}
+			resolved[parameter.Name] = ""
+			continue
+		}
+		if parameter.check != nil {
+			if err := parameter.check(value); err != nil {
+				return "", nil, fmt.Errorf("invalid parameter %s: %w", parameter.Name, err)
+			}
+		}
+		resolved[parameter.Name] = value
+	}
+
+	var script bytes.Buffer
+	if err := t.script.Execute(&script, resolved); err != nil {
+		return "", nil, fmt.Errorf("failed to render template %s: %w", t.Name, err)
+	}
+	for name, value := range resolved {
+		if value == "" {
+			delete(resolved, name)
+		}
+	}
+	return script.String(), resolved, nil
+}
+
+// matchScriptTemplate renders the best matching template whose required
+// parameters are available, or returns nil if none applies
+func matchScriptTemplate(alert PodAlert, overrides map[string]string) *RemediationScript {
+	values := alertParameters(alert)
+	for name, value := range overrides {
+		values[name] = value
+	}
+
+	text := alertText(alert)
+	type candidate struct {
+		tmpl  *ScriptTemplate
+		score int
+	}
+	var candidates []candidate
+	for i := range scriptTemplates {
+		if score := scriptTemplates[i].matchScore(alert, text); score > 0 {
+			candidates = append(candidates, candidate{&scriptTemplates[i], score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	for _, c := range candidates {
+		script, resolved, err := c.tmpl.render(values)
+		if err != nil {
+			continue
+		}
+		return &RemediationScript{Source: ScriptSourceTemplate, Template: c.tmpl.Name, Parameters: resolved, Script: script}
+	}
+	return nil
+}
+
+// generateLLMScript asks the LLM for a remediation script when no template applies
+func (a *AlertTool) generateLLMScript(ctx context.Context, alert PodAlert) (string, error) {
+	if err := a.llmUnavailable(ctx); err != nil {
+		return "", fmt.Errorf("no script template applies and %w", err)
+	}
+
+	prompt := fmt.Sprintf(`Write a bash remediation script for this Kubernetes pod alert:
+
+%s
+The script must start with "#!/usr/bin/env bash" and "set -euo pipefail", use kubectl or helm,
+inspect the current state before changing it, and wait for changes to roll out.
+Respond only with the script in a single fenced code block.`, a.formatPodAlert(ctx, alert))
+
+	resp, err := a.llmModel.GenerateContent(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, prompt),
+	}, llms.WithModel(llm.RouteModel(llm.WithSeverity(ctx, triageSeverity(alert)), llm.TaskRemediation)))
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) < 1 {
+		return "", fmt.Errorf("empty response from model")
+	}
+
+	content := resp.Choices[0].Content
+	if match := scriptFence.FindStringSubmatch(content); match != nil {
+		content = match[1]
+	}
+	return strings.TrimSpace(content) + "\n", nil
+}
+
+// handleGenerateRemediationScript generates a remediation script for a stored
+// alert from the best matching template, falling back to the LLM
+func (a *AlertTool) handleGenerateRemediationScript(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	podName := p.String("pod_name", "", params.Required())
+	namespace := p.String("namespace", "default")
+	templateName := p.String("template", "", params.OneOf(scriptTemplateNames()...))
+	parametersJSON := p.String("parameters", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	overrides := map[string]string{}
+	if parametersJSON != "" {
+		if err := json.Unmarshal([]byte(parametersJSON), &overrides); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("parameters must be a JSON object of strings: %v", err)), nil
+		}
+	}
+
+	doc, err := a.store.Get(ctx, namespace, podName)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to load alert: %v", err)), nil
+	}
+	if doc == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no alert found for %s; collect it with alerts_get_pod_alerts first", alertKey(namespace, podName))), nil
+	}
+
+	var script *RemediationScript
+	if templateName != "" {
+		values := alertParameters(doc.Alert)
+		for name, value := range overrides {
+			values[name] = value
+		}
+		tmpl := findScriptTemplate(templateName)
+		rendered, resolved, err := tmpl.render(values)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		script = &RemediationScript{Source: ScriptSourceTemplate, Template: tmpl.Name, Parameters: resolved, Script: rendered}
+	} else if script = matchScriptTemplate(doc.Alert, overrides); script == nil {
+		generated, err := a.generateLLMScript(ctx, doc.Alert)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to generate script: %v", err)), nil
+		}
+		script = &RemediationScript{Source: ScriptSourceLLM, Script: generated}
+	}
+	script.Namespace = namespace
+	script.PodName = podName
+
+	scriptJSON, err := json.MarshalIndent(script, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal script: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(scriptJSON)), nil
+}
+
+// handleListScriptTemplates returns the remediation script template library
+func (a *AlertTool) handleListScriptTemplates(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	templatesJSON, err := json.MarshalIndent(scriptTemplates, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal templates: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(templatesJSON)), nil
+}
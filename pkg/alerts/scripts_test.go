@@ -0,0 +1,112 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchScriptTemplate(t *testing.T) {
+	t.Run("keyword outweighs generic reason", func(t *testing.T) {
+		script := matchScriptTemplate(PodAlert{
+			PodName: "web-7d4b9c8f6d-x2k9p", Namespace: "prod", Reason: "CrashLoopBackOff",
+			Events: []PodEvent{{Reason: "BackOff", Message: "Back-off restarting failed container; last state OOMKilled"}},
+		}, nil)
+		require.NotNil(t, script)
+		assert.Equal(t, "bump-memory-limit", script.Template)
+		assert.Equal(t, map[string]string{"namespace": "prod", "deployment": "web", "memory": "512Mi"}, script.Parameters)
+		assert.Contains(t, script.Script, "kubectl set resources deployment/web -n prod --requests=memory=512Mi --limits=memory=512Mi\n")
+	})
+
+	t.Run("reason", func(t *testing.T) {
+		script := matchScriptTemplate(PodAlert{PodName: "api-5f6d7c8b9-bxkzq", Namespace: "prod", Reason: "CrashLoopBackOff"}, nil)
+		require.NotNil(t, script)
+		assert.Equal(t, "restart-deployment", script.Template)
+		assert.Contains(t, script.Script, "kubectl rollout restart deployment/api -n prod")
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		script := matchScriptTemplate(PodAlert{PodName: "worker-0", Namespace: "jobs", Reason: "OOMKilled"},
+			map[string]string{"deployment": "worker", "container": "main", "memory": "2Gi"})
+		require.NotNil(t, script)
+		assert.Contains(t, script.Script, "-c main --requests=memory=2Gi")
+	})
+
+	t.Run("skips templates missing required parameters", func(t *testing.T) {
+		// Pods of a StatefulSet have no Deployment to restart
+		assert.Nil(t, matchScriptTemplate(PodAlert{PodName: "db-0", Namespace: "prod", Reason: "CrashLoopBackOff"}, nil))
+		assert.Nil(t, matchScriptTemplate(PodAlert{PodName: "web-1", Namespace: "prod", Reason: "ImagePullBackOff"}, nil))
+	})
+
+	t.Run("rejects unsafe parameters", func(t *testing.T) {
+		assert.Nil(t, matchScriptTemplate(PodAlert{PodName: "web-7d4b9c8f6d-x2k9p", Namespace: "prod", Reason: "OOMKilled"},
+			map[string]string{"memory": "1Gi; rm -rf /"}))
+	})
+}
+
+func TestHandleGenerateRemediationScript(t *testing.T) {
+	newTool := func(t *testing.T, model *scriptedModel, alert PodAlert) *AlertTool {
+		tool := NewAlertTool(model)
+		require.NoError(t, tool.store.Upsert(context.Background(), alert))
+		return tool
+	}
+	generate := func(t *testing.T, tool *AlertTool, args map[string]interface{}) (*mcp.CallToolResult, RemediationScript) {
+		result, err := tool.handleGenerateRemediationScript(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+		require.NoError(t, err)
+		var script RemediationScript
+		if !result.IsError {
+			require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &script))
+		}
+		return result, script
+	}
+
+	t.Run("template", func(t *testing.T) {
+		model := &scriptedModel{}
+		tool := newTool(t, model, PodAlert{PodName: "web-7d4b9c8f6d-x2k9p", Namespace: "prod", Reason: "OOMKilled"})
+		result, script := generate(t, tool, map[string]interface{}{"pod_name": "web-7d4b9c8f6d-x2k9p", "namespace": "prod"})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		assert.Equal(t, ScriptSourceTemplate, script.Source)
+		assert.Equal(t, "bump-memory-limit", script.Template)
+		assert.Equal(t, "prod", script.Namespace)
+		assert.Empty(t, model.calls)
+	})
+
+	t.Run("explicit template", func(t *testing.T) {
+		tool := newTool(t, &scriptedModel{}, PodAlert{PodName: "web-1", Namespace: "prod", Reason: "Pending"})
+		result, script := generate(t, tool, map[string]interface{}{
+			"pod_name": "web-1", "namespace": "prod", "template": "rollback-helm-release", "parameters": `{"release": "web", "revision": "3"}`,
+		})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		assert.Contains(t, script.Script, "helm rollback web 3 -n prod --wait")
+
+		result, _ = generate(t, tool, map[string]interface{}{"pod_name": "web-1", "namespace": "prod", "template": "rollback-helm-release"})
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "requires parameter release")
+	})
+
+	t.Run("falls back to the LLM", func(t *testing.T) {
+		model := &scriptedModel{responses: []string{"Here you go:\n```bash\n#!/usr/bin/env bash\nkubectl get pod web-1 -n prod\n```"}}
+		tool := newTool(t, model, PodAlert{PodName: "web-1", Namespace: "prod", Reason: "ImagePullBackOff"})
+		result, script := generate(t, tool, map[string]interface{}{"pod_name": "web-1", "namespace": "prod"})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		assert.Equal(t, ScriptSourceLLM, script.Source)
+		assert.Equal(t, "#!/usr/bin/env bash\nkubectl get pod web-1 -n prod\n", script.Script)
+		require.Len(t, model.calls, 1)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tool := newTool(t, &scriptedModel{}, PodAlert{PodName: "web-1", Namespace: "prod"})
+		for _, args := range []map[string]interface{}{
+			{"pod_name": "missing", "namespace": "prod"},
+			{"pod_name": "web-1", "namespace": "prod", "template": "unknown"},
+			{"pod_name": "web-1", "namespace": "prod", "parameters": "memory=1Gi"},
+		} {
+			result, _ := generate(t, tool, args)
+			assert.True(t, result.IsError, args)
+		}
+	})
+}
@@ -0,0 +1,284 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Silence states, derived from the silence window
+const (
+	SilenceStatusPending = "pending"
+	SilenceStatusActive  = "active"
+	SilenceStatusExpired = "expired"
+)
+
+// Silence suppresses the alerts of a namespace during a time window, such as
+// planned maintenance. Service and IssueType narrow it to the pods selected
+// by a service and to one issue type.
+type Silence struct {
+	ID        string    `json:"id"`
+	Namespace string    `json:"namespace"`
+	Service   string    `json:"service,omitempty"`
+	IssueType string    `json:"issue_type,omitempty"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StatusAt returns whether the silence is pending, active or expired at a time
+func (s Silence) StatusAt(now time.Time) string {
+	switch {
+	case now.Before(s.StartsAt):
+		return SilenceStatusPending
+	case now.Before(s.EndsAt):
+		return SilenceStatusActive
+	default:
+		return SilenceStatusExpired
+	}
+}
+
+// silenceMatcher matches alerts against the active silences
+type silenceMatcher struct {
+	silences []Silence
+	// selectors holds the pod selector of each service silence
+	selectors map[string]map[string]string
+}
+
+// activeSilences returns a matcher for the silences active at a time,
+// resolving the pod selectors of service silences
+func (a *AlertTool) activeSilences(ctx context.Context, now time.Time) (*silenceMatcher, error) {
+	silences, err := a.store.ListSilences(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &silenceMatcher{selectors: map[string]map[string]string{}}
+	for _, silence := range silences {
+		if silence.StatusAt(now) != SilenceStatusActive {
+			continue
+		}
+		if silence.Service != "" {
+			selector, err := a.serviceSelector(ctx, silence.Namespace, silence.Service)
+			if err != nil {
+				// A service that cannot be resolved silences nothing rather than its whole namespace
+				logger.Get().Error("Failed to resolve silenced service", "silence", silence.ID, "service", silence.Service, "namespace", silence.Namespace, "error", err)
+				continue
+			}
+			m.selectors[silence.ID] = selector
+		}
+		m.silences = append(m.silences, silence)
+	}
+	return m, nil
+}
+
+// serviceSelector returns the pod selector of a service
+func (a *AlertTool) serviceSelector(ctx context.Context, namespace, service string) (map[string]string, error) {
+	output, err := a.runKubectlCommandString(ctx, "get", "service", service, "-n", namespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var svc struct {
+		Spec struct {
+			Selector map[string]string `json:"selector"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal([]byte(output), &svc); err != nil {
+		return nil, fmt.Errorf("failed to parse service: %w", err)
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return nil, fmt.Errorf("service has no pod selector")
+	}
+	return svc.Spec.Selector, nil
+}
+
+// match returns the first silence matching an alert, or nil
+func (m *silenceMatcher) match(alert PodAlert) *Silence {
+	for i, silence := range m.silences {
+		if silence.Namespace != alert.Namespace {
+			continue
+		}
+		if silence.IssueType != "" && !slices.Contains(alertIssueTypes(alert), silence.IssueType) {
+			continue
+		}
+		if selector, ok := m.selectors[silence.ID]; ok && !selectorMatches(selector, alert.Labels) {
+			continue
+		}
+		return &m.silences[i]
+	}
+	return nil
+}
+
+// selectorMatches reports whether labels satisfy an equality-based selector
+func selectorMatches(selector, labels map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// tagSilenced marks the alerts matched by an active silence
+func (a *AlertTool) tagSilenced(ctx context.Context, alerts []PodAlert) {
+	matcher, err := a.activeSilences(ctx, time.Now())
+	if err != nil {
+		logger.Get().Error("Failed to load silences", "error", err)
+		return
+	}
+	for i := range alerts {
+		alerts[i].SilencedBy = ""
+		if silence := matcher.match(alerts[i]); silence != nil {
+			alerts[i].SilencedBy = silence.ID
+		}
+	}
+}
+
+// alertSilence returns the ID of the active silence matching a stored alert,
+// or an empty string
+func (a *AlertTool) alertSilence(ctx context.Context, namespace, podName string) string {
+	doc, err := a.store.Get(ctx, namespace, podName)
+	if err != nil || doc == nil {
+		return ""
+	}
+	matcher, err := a.activeSilences(ctx, time.Now())
+	if err != nil {
+		logger.Get().Error("Failed to load silences", "error", err)
+		return ""
+	}
+	if silence := matcher.match(doc.Alert); silence != nil {
+		return silence.ID
+	}
+	return ""
+}
+
+// silenceView is a silence with its current status
+type silenceView struct {
+	Silence
+	Status string `json:"status"`
+}
+
+// SilenceList is the structured response of alerts_list_silences
+type SilenceList struct {
+	Total    int           `json:"total"`
+	Silences []silenceView `json:"silences"`
+}
+
+// handleCreateSilence stores a silence for a namespace, service or issue type
+func (a *AlertTool) handleCreateSilence(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	namespace := p.String("namespace", "", params.Required(), params.Check(security.ValidateNamespace))
+	service := p.String("service", "", params.Check(security.ValidateK8sResourceName))
+	issueType := p.String("issue_type", "")
+	startsAtParam := p.String("starts_at", "")
+	endsAtParam := p.String("ends_at", "")
+	duration := p.Duration("duration", 0)
+	comment := p.String("comment", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	startsAt := time.Now()
+	if startsAtParam != "" {
+		var err error
+		if startsAt, err = time.Parse(time.RFC3339, startsAtParam); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("starts_at must be an RFC 3339 time: %v", err)), nil
+		}
+	}
+	var endsAt time.Time
+	switch {
+	case endsAtParam != "" && duration > 0:
+		return mcp.NewToolResultError("give either ends_at or duration, not both"), nil
+	case endsAtParam != "":
+		var err error
+		if endsAt, err = time.Parse(time.RFC3339, endsAtParam); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("ends_at must be an RFC 3339 time: %v", err)), nil
+		}
+	case duration > 0:
+		endsAt = startsAt.Add(duration)
+	default:
+		return mcp.NewToolResultError("ends_at or duration is required"), nil
+	}
+	if !endsAt.After(startsAt) {
+		return mcp.NewToolResultError("the silence must end after it starts"), nil
+	}
+	if !endsAt.After(time.Now()) {
+		return mcp.NewToolResultError("the silence has already ended"), nil
+	}
+
+	silence, err := a.store.CreateSilence(ctx, Silence{
+		Namespace: namespace,
+		Service:   service,
+		IssueType: issueType,
+		StartsAt:  startsAt.UTC(),
+		EndsAt:    endsAt.UTC(),
+		Comment:   comment,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to store silence: %v", err)), nil
+	}
+
+	silenceJSON, err := json.MarshalIndent(silenceView{Silence: silence, Status: silence.StatusAt(time.Now())}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal silence: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(silenceJSON)), nil
+}
+
+// handleListSilences lists the pending and active silences, and optionally the expired ones
+func (a *AlertTool) handleListSilences(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	includeExpired := p.Bool("include_expired", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	silences, err := a.store.ListSilences(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list silences: %v", err)), nil
+	}
+
+	now := time.Now()
+	list := SilenceList{Silences: []silenceView{}}
+	for _, silence := range silences {
+		status := silence.StatusAt(now)
+		if status == SilenceStatusExpired && !includeExpired {
+			continue
+		}
+		list.Silences = append(list.Silences, silenceView{Silence: silence, Status: status})
+	}
+	list.Total = len(list.Silences)
+
+	listJSON, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal silences: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(listJSON)), nil
+}
+
+// handleDeleteSilence removes a silence, ending it early
+func (a *AlertTool) handleDeleteSilence(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	id := p.String("silence_id", "", params.Required())
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	deleted, err := a.store.DeleteSilence(ctx, id)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to delete silence: %v", err)), nil
+	}
+	if !deleted {
+		return mcp.NewToolResultError(fmt.Sprintf("no silence found with ID %s", id)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Silence %s deleted", id)), nil
+}
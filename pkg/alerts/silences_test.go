@@ -0,0 +1,176 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+func TestSilenceStatusAt(t *testing.T) {
+	now := time.Now()
+	silence := Silence{StartsAt: now, EndsAt: now.Add(time.Hour)}
+	assert.Equal(t, SilenceStatusPending, silence.StatusAt(now.Add(-time.Minute)))
+	assert.Equal(t, SilenceStatusActive, silence.StatusAt(now))
+	assert.Equal(t, SilenceStatusExpired, silence.StatusAt(now.Add(time.Hour)))
+}
+
+func TestSilenceMatcher(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "service", "web", "-n", "prod", "-o", "json"}, `{"spec":{"selector":{"app":"web"}}}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "service", "gone", "-n", "prod", "-o", "json"}, "", errors.New("not found"))
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	tool := NewAlertTool(nil)
+	now := time.Now()
+	window := func(s Silence) Silence {
+		s.StartsAt, s.EndsAt = now.Add(-time.Minute), now.Add(time.Hour)
+		return s
+	}
+	for _, silence := range []Silence{
+		window(Silence{Namespace: "prod", Service: "web"}),
+		window(Silence{Namespace: "prod", Service: "gone"}),
+		window(Silence{Namespace: "staging", IssueType: "OOMKilled"}),
+		{Namespace: "dev", StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)},
+	} {
+		_, err := tool.store.CreateSilence(ctx, silence)
+		require.NoError(t, err)
+	}
+
+	matcher, err := tool.activeSilences(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, matcher.silences, 2)
+
+	silence := matcher.match(PodAlert{Namespace: "prod", PodName: "web-1", Labels: map[string]string{"app": "web", "pod-template-hash": "abc"}})
+	require.NotNil(t, silence)
+	assert.Equal(t, "silence-1", silence.ID)
+	assert.Nil(t, matcher.match(PodAlert{Namespace: "prod", PodName: "db-0", Labels: map[string]string{"app": "db"}}))
+	assert.Nil(t, matcher.match(PodAlert{Namespace: "prod", PodName: "web-2"}))
+
+	assert.NotNil(t, matcher.match(PodAlert{Namespace: "staging", PodName: "web-1", Reason: "OOMKilled"}))
+	assert.NotNil(t, matcher.match(PodAlert{Namespace: "staging", PodName: "web-1", Events: []PodEvent{{Type: "Warning", Reason: "OOMKilled"}}}))
+	assert.Nil(t, matcher.match(PodAlert{Namespace: "staging", PodName: "web-1", Reason: "CrashLoopBackOff"}))
+	assert.Nil(t, matcher.match(PodAlert{Namespace: "dev", PodName: "web-1"}))
+}
+
+func TestHandleGetPodAlertsTagsSilenced(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pods", "-o", "json", "-n", "prod"}, `{"items":[
+{"metadata":{"name":"web-1","namespace":"prod","labels":{"app":"web"}},"status":{"phase":"Pending"}},
+{"metadata":{"name":"db-0","namespace":"prod","labels":{"app":"db"}},"status":{"phase":"Pending"}}
+]}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "service", "web", "-n", "prod", "-o", "json"}, `{"spec":{"selector":{"app":"web"}}}`, nil)
+	for _, pod := range []string{"web-1", "db-0"} {
+		mock.AddCommandString("kubectl", eventsArgs(pod), `{"items":[]}`, nil)
+		mock.AddCommandString("kubectl", logsArgs(pod), "", nil)
+	}
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	sender := &recordingSender{}
+	tool := NewAlertTool(nil).WithClientNotifier(&ClientNotifier{sender: sender})
+	_, err := tool.store.CreateSilence(ctx, Silence{Namespace: "prod", Service: "web", StartsAt: time.Now().Add(-time.Minute), EndsAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"namespace": "prod"}
+	result, err := tool.handleGetPodAlerts(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+	var alerts []PodAlert
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &alerts))
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "silence-1", alerts[0].SilencedBy)
+	assert.Empty(t, alerts[1].SilencedBy)
+
+	// The silenced alert is stored but only the other one is announced
+	doc, err := tool.store.Get(ctx, "prod", "web-1")
+	require.NoError(t, err)
+	require.NotNil(t, doc)
+	assert.Equal(t, "silence-1", doc.Alert.SilencedBy)
+	events := sender.events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "db-0", events[0]["pod_name"])
+}
+
+func TestHandleSilenceTools(t *testing.T) {
+	ctx := context.Background()
+	tool := NewAlertTool(nil)
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), arguments map[string]interface{}) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = arguments
+		result, err := handler(ctx, request)
+		require.NoError(t, err)
+		return result
+	}
+
+	result := call(tool.handleCreateSilence, map[string]interface{}{"namespace": "prod", "issue_type": "CrashLoopBackOff", "duration": "2h", "comment": "CHG-1234"})
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	var created silenceView
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &created))
+	assert.Equal(t, SilenceStatusActive, created.Status)
+	assert.Equal(t, 2*time.Hour, created.EndsAt.Sub(created.StartsAt))
+
+	startsAt := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	endsAt := time.Now().Add(26 * time.Hour).UTC().Format(time.RFC3339)
+	result = call(tool.handleCreateSilence, map[string]interface{}{"namespace": "prod", "service": "web", "starts_at": startsAt, "ends_at": endsAt})
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+	var list SilenceList
+	result = call(tool.handleListSilences, map[string]interface{}{})
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &list))
+	require.Equal(t, 2, list.Total)
+	assert.Equal(t, SilenceStatusPending, list.Silences[1].Status)
+	assert.Equal(t, "web", list.Silences[1].Service)
+
+	result = call(tool.handleDeleteSilence, map[string]interface{}{"silence_id": created.ID})
+	assert.False(t, result.IsError)
+	result = call(tool.handleDeleteSilence, map[string]interface{}{"silence_id": created.ID})
+	assert.True(t, result.IsError)
+
+	for _, arguments := range []map[string]interface{}{
+		{"duration": "1h"},
+		{"namespace": "prod"},
+		{"namespace": "prod", "duration": "1h", "ends_at": endsAt},
+		{"namespace": "prod", "ends_at": "tomorrow"},
+		{"namespace": "prod", "starts_at": endsAt, "ends_at": startsAt},
+		{"namespace": "prod", "ends_at": "2020-01-01T00:00:00Z", "starts_at": "2019-01-01T00:00:00Z"},
+		{"namespace": "prod", "service": "web;rm", "duration": "1h"},
+	} {
+		assert.True(t, call(tool.handleCreateSilence, arguments).IsError, arguments)
+	}
+}
+
+func TestFileAlertStorePersistsSilences(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "alerts.json")
+
+	store, err := NewFileAlertStore(path)
+	require.NoError(t, err)
+	first, err := store.CreateSilence(ctx, Silence{Namespace: "prod", EndsAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	second, err := store.CreateSilence(ctx, Silence{Namespace: "staging", EndsAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	deleted, err := store.DeleteSilence(ctx, first.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	reopened, err := NewFileAlertStore(path)
+	require.NoError(t, err)
+	silences, err := reopened.ListSilences(ctx)
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	assert.Equal(t, second.ID, silences[0].ID)
+
+	third, err := reopened.CreateSilence(ctx, Silence{Namespace: "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "silence-3", third.ID)
+}
@@ -0,0 +1,566 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/prometheus/common/model"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+	"github.com/kagent-dev/tools/pkg/prometheus"
+)
+
+// AlertSLOInterval is how often the SLOs are evaluated in the background
+// (default: 5m); 0 only evaluates them when requested
+const AlertSLOInterval = "ALERT_SLO_INTERVAL"
+
+// JobKindSLOEvaluation evaluates the SLOs and queues the next evaluation
+const JobKindSLOEvaluation = "slo_evaluation"
+
+const (
+	defaultSLOInterval = 5 * time.Minute
+	// minSLOInterval keeps scheduled evaluations from hammering Prometheus
+	minSLOInterval = time.Minute
+	// defaultSLOWindow is the compliance period of SLOs defined without one
+	defaultSLOWindow = "30d"
+	// atRiskBudget is the remaining error budget below which an SLO is at risk
+	atRiskBudget = 0.25
+)
+
+// sloWindowPlaceholder is replaced in SLI queries by the range they are evaluated over
+const sloWindowPlaceholder = "$window"
+
+// Health of an SLO, ordered from the most to the least urgent
+const (
+	SLOHealthExhausted = "exhausted"
+	SLOHealthAtRisk    = "at_risk"
+	SLOHealthError     = "error"
+	SLOHealthNoData    = "no_data"
+	SLOHealthHealthy   = "healthy"
+)
+
+// sloHealthPriority orders SLOs by urgency when prioritizing incidents
+var sloHealthPriority = map[string]int{
+	SLOHealthExhausted: 0,
+	SLOHealthAtRisk:    1,
+	SLOHealthError:     2,
+	SLOHealthNoData:    3,
+	SLOHealthHealthy:   4,
+}
+
+// SLO is a service level objective: the fraction of good events, measured by
+// an SLI query, a service must reach over a rolling compliance window
+type SLO struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Service     string `json:"service,omitempty"`
+	// Target is the objective as a fraction of good events, such as 0.999
+	Target float64 `json:"target"`
+	// Window is the rolling compliance period in Prometheus duration syntax, such as 30d
+	Window string `json:"window"`
+	// SLIQuery is PromQL returning the fraction of good events over $window
+	SLIQuery string `json:"sli_query"`
+	// Datasource is the Prometheus datasource the SLI is queried from; empty uses the default
+	Datasource string         `json:"datasource,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	Evaluation *SLOEvaluation `json:"evaluation,omitempty"`
+}
+
+// BurnRate is how fast an SLO consumes its error budget over a window: 1
+// consumes exactly the budget over the compliance window
+type BurnRate struct {
+	Window   string   `json:"window"`
+	SLI      *float64 `json:"sli,omitempty"`
+	BurnRate *float64 `json:"burn_rate,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// BurnRateAlert is a multiwindow burn rate condition. It fires when both the
+// long and the short window burn faster than the threshold, so it catches
+// fast burns quickly and stops firing soon after they end.
+type BurnRateAlert struct {
+	Severity    string  `json:"severity"`
+	LongWindow  string  `json:"long_window"`
+	ShortWindow string  `json:"short_window"`
+	Threshold   float64 `json:"threshold"`
+	Firing      bool    `json:"firing"`
+}
+
+// SLOEvaluation is the error budget of an SLO at a point in time
+type SLOEvaluation struct {
+	EvaluatedAt time.Time `json:"evaluated_at"`
+	Health      string    `json:"health"`
+	// SLI is the fraction of good events over the compliance window
+	SLI *float64 `json:"sli,omitempty"`
+	// ErrorBudget is the fraction of events allowed to be bad, 1 - target
+	ErrorBudget float64 `json:"error_budget"`
+	// BudgetConsumed and BudgetRemaining are fractions of the error budget;
+	// BudgetRemaining is negative once the budget is overspent
+	BudgetConsumed  *float64        `json:"budget_consumed,omitempty"`
+	BudgetRemaining *float64        `json:"budget_remaining,omitempty"`
+	BurnRates       []BurnRate      `json:"burn_rates,omitempty"`
+	Alerts          []BurnRateAlert `json:"alerts,omitempty"`
+	// ExhaustedIn estimates when the remaining budget runs out at the burn
+	// rate of the shortest page window
+	ExhaustedIn string `json:"exhausted_in,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// burnRatePolicy is a multiwindow burn rate alert consuming budgetFraction of
+// the error budget within its long window
+type burnRatePolicy struct {
+	severity       string
+	long, short    time.Duration
+	budgetFraction float64
+}
+
+// burnRatePolicies are the multiwindow alerts of the Google SRE workbook, which
+// for a 30d window page at 14.4x over 1h and 6x over 6h, and open tickets at 3x
+// over 1d and 1x over 3d
+var burnRatePolicies = []burnRatePolicy{
+	{severity: "page", long: time.Hour, short: 5 * time.Minute, budgetFraction: 0.02},
+	{severity: "page", long: 6 * time.Hour, short: 30 * time.Minute, budgetFraction: 0.05},
+	{severity: "ticket", long: 24 * time.Hour, short: 2 * time.Hour, budgetFraction: 0.10},
+	{severity: "ticket", long: 72 * time.Hour, short: 6 * time.Hour, budgetFraction: 0.10},
+}
+
+// sloIntervalFromEnv returns the interval of scheduled SLO evaluations, zero
+// when they are disabled
+func sloIntervalFromEnv() (time.Duration, error) {
+	value := os.Getenv(AlertSLOInterval)
+	if value == "" {
+		return defaultSLOInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || (interval != 0 && interval < minSLOInterval) {
+		return 0, fmt.Errorf("invalid %s %q, expected 0 or a duration of at least %s", AlertSLOInterval, value, minSLOInterval)
+	}
+	return interval, nil
+}
+
+// parseSLOWindow parses a window in Prometheus duration syntax, such as 30d
+func parseSLOWindow(value string) (time.Duration, error) {
+	window, err := model.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("window must be a Prometheus duration such as 30d: %v", err)
+	}
+	return time.Duration(window), nil
+}
+
+// formatSLOWindow renders a duration in Prometheus duration syntax
+func formatSLOWindow(d time.Duration) string {
+	return model.Duration(d).String()
+}
+
+// parseSLOTarget parses a target given as a fraction (0.999) or a percentage (99.9)
+func parseSLOTarget(value string) (float64, error) {
+	target, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("target must be a number such as 0.999 or 99.9")
+	}
+	if target > 1 {
+		target /= 100
+	}
+	if target <= 0 || target >= 1 || math.IsNaN(target) {
+		return 0, fmt.Errorf("target must be between 0 and 1, or 0 and 100 percent, exclusive")
+	}
+	return target, nil
+}
+
+// validateSLIQuery checks that an SLI query is valid PromQL over $window
+func validateSLIQuery(query string) error {
+	if !strings.Contains(query, sloWindowPlaceholder) {
+		return fmt.Errorf("sli_query must use %s as the range of its rates, such as rate(http_requests_total[%s])", sloWindowPlaceholder, sloWindowPlaceholder)
+	}
+	return security.ValidatePromQLQuery(strings.ReplaceAll(query, sloWindowPlaceholder, "5m"))
+}
+
+// querySLI returns the SLI of an SLO over a window, clamped to [0, 1], or nil without data
+func (a *AlertTool) querySLI(ctx context.Context, slo SLO, window time.Duration) (*float64, error) {
+	query := strings.ReplaceAll(slo.SLIQuery, sloWindowPlaceholder, formatSLOWindow(window))
+	value, err := prometheus.QueryDatasourceValue(ctx, slo.Datasource, query)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	sli := math.Min(math.Max(*value, 0), 1)
+	return &sli, nil
+}
+
+// burnRate returns the rate at which a SLI consumes the error budget
+func burnRate(sli, errorBudget float64) float64 {
+	return (1 - sli) / errorBudget
+}
+
+// evaluateSLO computes the error budget of an SLO over its window and its burn
+// rates over the windows of the applicable burn rate alerts and extraWindows
+func (a *AlertTool) evaluateSLO(ctx context.Context, slo SLO, extraWindows ...time.Duration) SLOEvaluation {
+	evaluation := SLOEvaluation{EvaluatedAt: time.Now().UTC(), ErrorBudget: 1 - slo.Target}
+	window, err := parseSLOWindow(slo.Window)
+	if err != nil {
+		evaluation.Health, evaluation.Error = SLOHealthError, err.Error()
+		return evaluation
+	}
+
+	sli, err := a.querySLI(ctx, slo, window)
+	if err != nil {
+		evaluation.Health, evaluation.Error = SLOHealthError, err.Error()
+		return evaluation
+	}
+	// Without data over the whole window, as for a new service, the burn rates
+	// over the shorter windows still show whether the budget is burning
+	if sli != nil {
+		consumed := burnRate(*sli, evaluation.ErrorBudget)
+		remaining := 1 - consumed
+		evaluation.SLI, evaluation.BudgetConsumed, evaluation.BudgetRemaining = sli, &consumed, &remaining
+	}
+
+	// Alerts with a long window as long as the SLO window would never fire first
+	var policies []burnRatePolicy
+	windows := map[time.Duration]bool{}
+	for _, policy := range burnRatePolicies {
+		if policy.long < window {
+			policies = append(policies, policy)
+			windows[policy.long], windows[policy.short] = true, true
+		}
+	}
+	for _, extra := range extraWindows {
+		windows[extra] = true
+	}
+	sorted := make([]time.Duration, 0, len(windows))
+	for w := range windows {
+		sorted = append(sorted, w)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rates := map[time.Duration]*float64{}
+	for _, w := range sorted {
+		rate := BurnRate{Window: formatSLOWindow(w)}
+		if sli, err := a.querySLI(ctx, slo, w); err != nil {
+			rate.Error = err.Error()
+		} else if sli != nil {
+			value := burnRate(*sli, evaluation.ErrorBudget)
+			rate.SLI, rate.BurnRate = sli, &value
+			rates[w] = &value
+		}
+		evaluation.BurnRates = append(evaluation.BurnRates, rate)
+	}
+
+	paging := false
+	for _, policy := range policies {
+		alert := BurnRateAlert{
+			Severity:    policy.severity,
+			LongWindow:  formatSLOWindow(policy.long),
+			ShortWindow: formatSLOWindow(policy.short),
+			Threshold:   math.Round(policy.budgetFraction*float64(window)/float64(policy.long)*100) / 100,
+		}
+		long, short := rates[policy.long], rates[policy.short]
+		alert.Firing = long != nil && short != nil && *long > alert.Threshold && *short > alert.Threshold
+		paging = paging || (alert.Firing && policy.severity == "page")
+		evaluation.Alerts = append(evaluation.Alerts, alert)
+	}
+
+	// At the current burn rate the remaining budget lasts remaining * window / rate
+	remaining := evaluation.BudgetRemaining
+	if len(policies) > 0 && remaining != nil && *remaining > 0 {
+		if rate := rates[policies[0].long]; rate != nil && *rate > 0 {
+			evaluation.ExhaustedIn = formatSLOWindow((time.Duration(*remaining * float64(window) / *rate)).Round(time.Minute))
+		}
+	}
+
+	switch {
+	case remaining != nil && *remaining <= 0:
+		evaluation.Health = SLOHealthExhausted
+	case paging || (remaining != nil && *remaining < atRiskBudget):
+		evaluation.Health = SLOHealthAtRisk
+	case remaining == nil:
+		evaluation.Health = SLOHealthNoData
+	default:
+		evaluation.Health = SLOHealthHealthy
+	}
+	return evaluation
+}
+
+// evaluateSLOs evaluates every SLO and stores the evaluations
+func (a *AlertTool) evaluateSLOs(ctx context.Context) ([]SLO, error) {
+	slos, err := a.store.ListSLOs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SLOs: %w", err)
+	}
+	for i := range slos {
+		evaluation := a.evaluateSLO(ctx, slos[i])
+		previous := slos[i].Evaluation
+		if previous == nil || previous.Health != evaluation.Health {
+			logger.Get().Info("SLO health changed", "slo", slos[i].Name, "health", evaluation.Health, "error", evaluation.Error)
+		}
+		// An SLO deleted during the evaluation is skipped
+		if stored, err := a.store.GetSLO(ctx, slos[i].Name); err != nil || stored == nil {
+			continue
+		}
+		if err := a.store.UpdateSLOEvaluation(ctx, slos[i].Name, evaluation); err != nil {
+			return nil, fmt.Errorf("failed to store the evaluation of SLO %s: %w", slos[i].Name, err)
+		}
+		slos[i].Evaluation = &evaluation
+	}
+	return slos, nil
+}
+
+// WithSLOSchedule sets the interval of scheduled SLO evaluations. Call
+// ScheduleSLOs to queue the first one.
+func (a *AlertTool) WithSLOSchedule(interval time.Duration) *AlertTool {
+	a.sloInterval = interval
+	return a
+}
+
+// ScheduleSLOs queues the next scheduled SLO evaluation when SLOs are defined
+func (a *AlertTool) ScheduleSLOs(ctx context.Context) error {
+	if a.sloInterval == 0 {
+		return nil
+	}
+	slos, err := a.store.ListSLOs(ctx)
+	if err != nil || len(slos) == 0 {
+		return err
+	}
+	_, err = a.scheduleSLOs(ctx, time.Now())
+	return err
+}
+
+// scheduleSLOs queues the first evaluation aligned to the interval after a
+// time. Runs are aligned, so the idempotency key keeps each from being queued twice.
+func (a *AlertTool) scheduleSLOs(ctx context.Context, after time.Time) (Job, error) {
+	at := after.UTC().Truncate(a.sloInterval).Add(a.sloInterval)
+	return a.enqueueJob(ctx, Job{
+		Kind:           JobKindSLOEvaluation,
+		IdempotencyKey: fmt.Sprintf("%s/%s", JobKindSLOEvaluation, at.Format(time.RFC3339)),
+		RunAfter:       at,
+	})
+}
+
+// runSLOJob evaluates the SLOs after queueing the next run, so a failing
+// evaluation does not stop the schedule. The schedule ends when no SLO is
+// left and starts again with the next SLO defined.
+func (a *AlertTool) runSLOJob(ctx context.Context, job Job) error {
+	slos, err := a.store.ListSLOs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list SLOs: %w", err)
+	}
+	if len(slos) == 0 {
+		return nil
+	}
+	if a.sloInterval != 0 {
+		// Every evaluation covers the windows up to now, so runs missed while
+		// the server was down are not caught up
+		after := job.RunAfter
+		if now := time.Now(); now.After(after) {
+			after = now
+		}
+		if _, err := a.scheduleSLOs(ctx, after); err != nil {
+			return fmt.Errorf("failed to schedule the next SLO evaluation: %w", err)
+		}
+	}
+
+	evaluated, err := a.evaluateSLOs(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Get().Info("Evaluated SLOs", "slos", len(evaluated))
+	return nil
+}
+
+// sortSLOsByUrgency orders SLOs by health, then by remaining error budget
+func sortSLOsByUrgency(slos []SLO) {
+	health := func(slo SLO) (int, float64) {
+		if slo.Evaluation == nil {
+			return sloHealthPriority[SLOHealthNoData], math.Inf(1)
+		}
+		remaining := math.Inf(1)
+		if slo.Evaluation.BudgetRemaining != nil {
+			remaining = *slo.Evaluation.BudgetRemaining
+		}
+		return sloHealthPriority[slo.Evaluation.Health], remaining
+	}
+	sort.SliceStable(slos, func(i, j int) bool {
+		pi, ri := health(slos[i])
+		pj, rj := health(slos[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return ri < rj
+	})
+}
+
+// handleDefineSLO creates or replaces an SLO and evaluates it
+func (a *AlertTool) handleDefineSLO(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("name", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	targetParam := p.String("target", "", params.Required())
+	window := p.String("window", defaultSLOWindow)
+	sliQuery := p.String("sli_query", "", params.Required(), params.Check(validateSLIQuery))
+	service := p.String("service", "")
+	description := p.String("description", "")
+	datasource := p.String("datasource", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	target, err := parseSLOTarget(targetParam)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	windowDuration, err := parseSLOWindow(window)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if windowDuration < time.Hour {
+		return mcp.NewToolResultError("window must be at least 1h"), nil
+	}
+
+	slo, err := a.store.SaveSLO(ctx, SLO{
+		Name:        name,
+		Description: description,
+		Service:     service,
+		Target:      target,
+		Window:      window,
+		SLIQuery:    sliQuery,
+		Datasource:  datasource,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to store SLO: %v", err)), nil
+	}
+
+	// Evaluating right away surfaces queries Prometheus rejects or has no data for
+	evaluation := a.evaluateSLO(ctx, slo)
+	if err := a.store.UpdateSLOEvaluation(ctx, slo.Name, evaluation); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to store SLO evaluation: %v", err)), nil
+	}
+	slo.Evaluation = &evaluation
+	if a.sloInterval != 0 {
+		if _, err := a.scheduleSLOs(ctx, time.Now()); err != nil {
+			logger.Get().Error("Failed to schedule SLO evaluation", "error", err)
+		}
+	}
+
+	sloJSON, err := json.MarshalIndent(slo, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal SLO: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(sloJSON)), nil
+}
+
+// handleDeleteSLO removes an SLO
+func (a *AlertTool) handleDeleteSLO(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("name", "", params.Required())
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	deleted, err := a.store.DeleteSLO(ctx, name)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to delete SLO: %v", err)), nil
+	}
+	if !deleted {
+		return mcp.NewToolResultError(fmt.Sprintf("SLO %s not found", name)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("SLO %s deleted", name)), nil
+}
+
+// SLOStatusList is the response of slo_status
+type SLOStatusList struct {
+	Total int   `json:"total"`
+	SLOs  []SLO `json:"slos"`
+}
+
+// handleSLOStatus lists the SLOs with their latest error budget, the most
+// urgent first, evaluating them first when asked to
+func (a *AlertTool) handleSLOStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("name", "")
+	service := p.String("service", "")
+	refresh := p.Bool("refresh", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var slos []SLO
+	var err error
+	if refresh {
+		slos, err = a.evaluateSLOs(ctx)
+	} else {
+		slos, err = a.store.ListSLOs(ctx)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	list := SLOStatusList{SLOs: []SLO{}}
+	for _, slo := range slos {
+		if (name != "" && slo.Name != name) || (service != "" && slo.Service != service) {
+			continue
+		}
+		list.SLOs = append(list.SLOs, slo)
+	}
+	if name != "" && len(list.SLOs) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("SLO %s not found; define it with slo_define", name)), nil
+	}
+	sortSLOsByUrgency(list.SLOs)
+	list.Total = len(list.SLOs)
+
+	listJSON, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal SLOs: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(listJSON)), nil
+}
+
+// handleSLOBurnRate evaluates how fast an SLO burns its error budget now, over
+// the windows of the multiwindow burn rate alerts and any requested windows
+func (a *AlertTool) handleSLOBurnRate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("name", "", params.Required())
+	windowsParam := p.String("windows", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var windows []time.Duration
+	for _, value := range strings.Split(windowsParam, ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		window, err := parseSLOWindow(value)
+		if err != nil || window <= 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid window %q, expected a Prometheus duration such as 1h", value)), nil
+		}
+		windows = append(windows, window)
+	}
+
+	slo, err := a.store.GetSLO(ctx, name)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to load SLO: %v", err)), nil
+	}
+	if slo == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("SLO %s not found; define it with slo_define", name)), nil
+	}
+
+	evaluation := a.evaluateSLO(ctx, *slo, windows...)
+	if err := a.store.UpdateSLOEvaluation(ctx, slo.Name, evaluation); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to store SLO evaluation: %v", err)), nil
+	}
+	slo.Evaluation = &evaluation
+
+	sloJSON, err := json.MarshalIndent(slo, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal SLO: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(sloJSON)), nil
+}
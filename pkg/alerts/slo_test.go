@@ -0,0 +1,301 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/datasources"
+)
+
+// fakeSLIPrometheus answers instant queries with the SLI configured for the
+// range of the query, an empty vector for unknown ranges
+func fakeSLIPrometheus(t *testing.T, slis map[string]string) {
+	rangePattern := regexp.MustCompile(`\[([^\]]+)\]`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		match := rangePattern.FindStringSubmatch(r.FormValue("query"))
+		if match == nil || match[1] == "" {
+			http.Error(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`, http.StatusBadRequest)
+			return
+		}
+		sli, ok := slis[match[1]]
+		if !ok {
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"scalar","result":[1760601600,"%s"]}}`, sli)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv(datasources.DatasourcesConfig, "")
+	t.Setenv("PROMETHEUS_URL", server.URL)
+	datasources.Reset()
+	t.Cleanup(datasources.Reset)
+}
+
+const testSLIQuery = `sum(rate(http_requests_total{code!~"5.."}[$window])) / sum(rate(http_requests_total[$window]))`
+
+func callSLOTool(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) (string, bool) {
+	result, err := handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+	require.NoError(t, err)
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestParseSLOTarget(t *testing.T) {
+	for value, expected := range map[string]float64{"0.999": 0.999, "99.9": 0.999, "99.5%": 0.995} {
+		target, err := parseSLOTarget(value)
+		require.NoError(t, err, value)
+		assert.InDelta(t, expected, target, 1e-9, value)
+	}
+	for _, value := range []string{"", "high", "0", "1", "100", "-5"} {
+		_, err := parseSLOTarget(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestSLOInterval(t *testing.T) {
+	t.Setenv(AlertSLOInterval, "")
+	interval, err := sloIntervalFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, defaultSLOInterval, interval)
+
+	t.Setenv(AlertSLOInterval, "0")
+	interval, err = sloIntervalFromEnv()
+	require.NoError(t, err)
+	assert.Zero(t, interval)
+
+	for _, value := range []string{"10s", "often"} {
+		t.Setenv(AlertSLOInterval, value)
+		_, err = sloIntervalFromEnv()
+		assert.ErrorContains(t, err, AlertSLOInterval)
+	}
+}
+
+func TestEvaluateSLO(t *testing.T) {
+	slo := SLO{Name: "checkout-availability", Target: 0.99, Window: "30d", SLIQuery: testSLIQuery}
+
+	t.Run("fast burn", func(t *testing.T) {
+		// Half the budget is spent and the last hour burns it 20 times too fast
+		fakeSLIPrometheus(t, map[string]string{
+			"30d": "0.995", "1h": "0.8", "5m": "0.8",
+			"6h": "0.999", "30m": "0.999", "1d": "0.999", "2h": "0.999", "3d": "0.999",
+		})
+		evaluation := NewAlertTool(nil).evaluateSLO(context.Background(), slo)
+		require.Empty(t, evaluation.Error)
+
+		assert.Equal(t, SLOHealthAtRisk, evaluation.Health)
+		assert.InDelta(t, 0.01, evaluation.ErrorBudget, 1e-9)
+		assert.InDelta(t, 0.5, *evaluation.BudgetRemaining, 1e-9)
+		assert.Equal(t, "18h", evaluation.ExhaustedIn)
+		require.Len(t, evaluation.BurnRates, 7)
+		assert.Equal(t, "5m", evaluation.BurnRates[0].Window)
+		assert.InDelta(t, 20, *evaluation.BurnRates[0].BurnRate, 1e-9)
+
+		require.Len(t, evaluation.Alerts, 4)
+		assert.Equal(t, BurnRateAlert{Severity: "page", LongWindow: "1h", ShortWindow: "5m", Threshold: 14.4, Firing: true}, evaluation.Alerts[0])
+		for _, alert := range evaluation.Alerts[1:] {
+			assert.False(t, alert.Firing, alert.LongWindow)
+		}
+		assert.Equal(t, 6.0, evaluation.Alerts[1].Threshold)
+		assert.Equal(t, 3.0, evaluation.Alerts[2].Threshold)
+		assert.Equal(t, 1.0, evaluation.Alerts[3].Threshold)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		fakeSLIPrometheus(t, map[string]string{"30d": "0.98"})
+		evaluation := NewAlertTool(nil).evaluateSLO(context.Background(), slo)
+		assert.Equal(t, SLOHealthExhausted, evaluation.Health)
+		assert.InDelta(t, -1, *evaluation.BudgetRemaining, 1e-9)
+		assert.Empty(t, evaluation.ExhaustedIn)
+	})
+
+	t.Run("healthy with short window", func(t *testing.T) {
+		// Alerts with a long window as long as the SLO window are skipped
+		fakeSLIPrometheus(t, map[string]string{"1d": "0.999", "1h": "0.999", "5m": "0.999", "6h": "0.999", "30m": "0.999", "2h": "0.999"})
+		evaluation := NewAlertTool(nil).evaluateSLO(context.Background(), SLO{Target: 0.99, Window: "1d", SLIQuery: testSLIQuery})
+		assert.Equal(t, SLOHealthHealthy, evaluation.Health)
+		assert.Len(t, evaluation.Alerts, 2)
+	})
+
+	t.Run("no data", func(t *testing.T) {
+		fakeSLIPrometheus(t, map[string]string{})
+		evaluation := NewAlertTool(nil).evaluateSLO(context.Background(), slo)
+		assert.Equal(t, SLOHealthNoData, evaluation.Health)
+		assert.Nil(t, evaluation.BudgetRemaining)
+		assert.Len(t, evaluation.BurnRates, 7)
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		fakeSLIPrometheus(t, map[string]string{})
+		evaluation := NewAlertTool(nil).evaluateSLO(context.Background(), SLO{Target: 0.99, Window: "30d", SLIQuery: "up"})
+		assert.Equal(t, SLOHealthError, evaluation.Health)
+		assert.NotEmpty(t, evaluation.Error)
+	})
+}
+
+func TestSLOTools(t *testing.T) {
+	fakeSLIPrometheus(t, map[string]string{"30d": "0.9995", "1h": "0.9999", "5m": "0.9999", "7d": "0.998"})
+	tool := NewAlertTool(nil)
+	ctx := context.Background()
+
+	text, isError := callSLOTool(t, tool.handleDefineSLO, map[string]interface{}{
+		"name": "checkout-availability", "target": "99.9", "sli_query": testSLIQuery, "service": "checkout",
+	})
+	require.False(t, isError, text)
+	var slo SLO
+	require.NoError(t, json.Unmarshal([]byte(text), &slo))
+	assert.InDelta(t, 0.999, slo.Target, 1e-9)
+	assert.Equal(t, "30d", slo.Window)
+	require.NotNil(t, slo.Evaluation)
+	assert.Equal(t, SLOHealthHealthy, slo.Evaluation.Health)
+
+	// An SLO without data sorts before the healthy one
+	fakeSLIPrometheus(t, map[string]string{"30d": "0.9985"})
+	text, isError = callSLOTool(t, tool.handleDefineSLO, map[string]interface{}{
+		"name": "search-availability", "target": "0.999", "window": "7d", "sli_query": testSLIQuery, "service": "search",
+	})
+	require.False(t, isError, text)
+
+	t.Run("status", func(t *testing.T) {
+		text, isError := callSLOTool(t, tool.handleSLOStatus, map[string]interface{}{})
+		require.False(t, isError, text)
+		var list SLOStatusList
+		require.NoError(t, json.Unmarshal([]byte(text), &list))
+		require.Equal(t, 2, list.Total)
+		assert.Equal(t, "search-availability", list.SLOs[0].Name)
+		assert.Equal(t, SLOHealthNoData, list.SLOs[0].Evaluation.Health)
+		assert.Equal(t, SLOHealthHealthy, list.SLOs[1].Evaluation.Health)
+
+		// Refreshing evaluates the SLOs again: the whole 30d window is now over budget
+		text, isError = callSLOTool(t, tool.handleSLOStatus, map[string]interface{}{"service": "checkout", "refresh": "true"})
+		require.False(t, isError, text)
+		require.NoError(t, json.Unmarshal([]byte(text), &list))
+		require.Equal(t, 1, list.Total)
+		assert.Equal(t, SLOHealthExhausted, list.SLOs[0].Evaluation.Health)
+
+		_, isError = callSLOTool(t, tool.handleSLOStatus, map[string]interface{}{"name": "missing"})
+		assert.True(t, isError)
+	})
+
+	t.Run("burn rate", func(t *testing.T) {
+		fakeSLIPrometheus(t, map[string]string{"1w": "0.999", "15m": "0.99"})
+		text, isError := callSLOTool(t, tool.handleSLOBurnRate, map[string]interface{}{"name": "search-availability", "windows": "15m"})
+		require.False(t, isError, text)
+		var slo SLO
+		require.NoError(t, json.Unmarshal([]byte(text), &slo))
+		var found bool
+		for _, rate := range slo.Evaluation.BurnRates {
+			if rate.Window == "15m" {
+				found = true
+				assert.InDelta(t, 10, *rate.BurnRate, 1e-6)
+			}
+		}
+		assert.True(t, found, text)
+
+		_, isError = callSLOTool(t, tool.handleSLOBurnRate, map[string]interface{}{"name": "search-availability", "windows": "soon"})
+		assert.True(t, isError)
+	})
+
+	t.Run("invalid definitions", func(t *testing.T) {
+		for message, args := range map[string]map[string]interface{}{
+			"must use $window":             {"name": "a", "target": "0.99", "sli_query": "up"},
+			"target must be between":       {"name": "a", "target": "100", "sli_query": testSLIQuery},
+			"window must be at least 1h":   {"name": "a", "target": "0.99", "window": "30m", "sli_query": testSLIQuery},
+			"Prometheus duration":          {"name": "a", "target": "0.99", "window": "month", "sli_query": testSLIQuery},
+			"sli_query parameter is requi": {"name": "a", "target": "0.99"},
+		} {
+			text, isError := callSLOTool(t, tool.handleDefineSLO, args)
+			assert.True(t, isError, message)
+			assert.Contains(t, text, message)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, isError := callSLOTool(t, tool.handleDeleteSLO, map[string]interface{}{"name": "search-availability"})
+		require.False(t, isError)
+		_, isError = callSLOTool(t, tool.handleDeleteSLO, map[string]interface{}{"name": "search-availability"})
+		assert.True(t, isError)
+		slos, err := tool.store.ListSLOs(ctx)
+		require.NoError(t, err)
+		assert.Len(t, slos, 1)
+	})
+}
+
+func TestSLOJobQueuesNextRun(t *testing.T) {
+	fakeSLIPrometheus(t, map[string]string{"30d": "0.9995"})
+	tool := NewAlertTool(nil).WithSLOSchedule(5 * time.Minute)
+	ctx := context.Background()
+
+	// Nothing is scheduled until an SLO is defined
+	require.NoError(t, tool.ScheduleSLOs(ctx))
+	jobs, err := tool.store.ListJobs(ctx, JobStatusQueued)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	_, err = tool.store.SaveSLO(ctx, SLO{Name: "checkout-availability", Target: 0.999, Window: "30d", SLIQuery: testSLIQuery})
+	require.NoError(t, err)
+	require.NoError(t, tool.ScheduleSLOs(ctx))
+	require.NoError(t, tool.ScheduleSLOs(ctx))
+	jobs, err = tool.store.ListJobs(ctx, JobStatusQueued)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, JobKindSLOEvaluation, jobs[0].Kind)
+
+	at := time.Now().UTC().Truncate(5 * time.Minute).Add(time.Hour)
+	require.NoError(t, tool.runSLOJob(ctx, Job{Kind: JobKindSLOEvaluation, RunAfter: at}))
+	jobs, err = tool.store.ListJobs(ctx, JobStatusQueued)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, at.Add(5*time.Minute), jobs[1].RunAfter)
+
+	slo, err := tool.store.GetSLO(ctx, "checkout-availability")
+	require.NoError(t, err)
+	require.NotNil(t, slo.Evaluation)
+	assert.Equal(t, SLOHealthHealthy, slo.Evaluation.Health)
+
+	for _, job := range jobs {
+		_, err := tool.cancelJob(ctx, job.ID)
+		require.NoError(t, err)
+	}
+	tool.waitJobs()
+}
+
+func TestFileAlertStorePersistsSLOs(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "alerts.json")
+
+	store, err := NewFileAlertStore(path)
+	require.NoError(t, err)
+	saved, err := store.SaveSLO(ctx, SLO{Name: "checkout-availability", Target: 0.999, Window: "30d", SLIQuery: testSLIQuery})
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateSLOEvaluation(ctx, saved.Name, SLOEvaluation{Health: SLOHealthHealthy}))
+
+	// Redefining an SLO keeps its creation time and latest evaluation
+	_, err = store.SaveSLO(ctx, SLO{Name: "checkout-availability", Target: 0.99, Window: "7d", SLIQuery: testSLIQuery})
+	require.NoError(t, err)
+
+	reopened, err := NewFileAlertStore(path)
+	require.NoError(t, err)
+	slo, err := reopened.GetSLO(ctx, "checkout-availability")
+	require.NoError(t, err)
+	require.NotNil(t, slo)
+	assert.Equal(t, "7d", slo.Window)
+	assert.True(t, saved.CreatedAt.Equal(slo.CreatedAt))
+	require.NotNil(t, slo.Evaluation)
+	assert.Equal(t, SLOHealthHealthy, slo.Evaluation.Health)
+
+	deleted, err := reopened.DeleteSLO(ctx, "checkout-availability")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	assert.Error(t, reopened.UpdateSLOEvaluation(ctx, "checkout-availability", SLOEvaluation{}))
+}
@@ -0,0 +1,677 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kagent-dev/tools/internal/tenancy"
+)
+
+// AlertDocument is the stored representation of a pod alert and its history
+type AlertDocument struct {
+	Alert        PodAlert            `json:"alert"`
+	Remediations []RemediationRecord `json:"remediations"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+// AlertStore persists alert documents across tool calls. Implementations
+// keep the documents of each tenant storage prefix in the context separate.
+type AlertStore interface {
+	// Upsert creates or updates the document for an alert, keeping its remediation history
+	Upsert(ctx context.Context, alert PodAlert) error
+	// UpsertMany creates or updates the documents for several alerts in one write
+	UpsertMany(ctx context.Context, alerts []PodAlert) error
+	// Get returns the document for a pod, or nil if none exists
+	Get(ctx context.Context, namespace, podName string) (*AlertDocument, error)
+	// List returns all documents, optionally restricted to a namespace
+	List(ctx context.Context, namespace string) ([]AlertDocument, error)
+	// AddRemediation appends a remediation record and returns its ID
+	AddRemediation(ctx context.Context, namespace, podName string, record RemediationRecord) (string, error)
+	// UpdateRemediation replaces the remediation record with the given ID
+	UpdateRemediation(ctx context.Context, namespace, podName string, record RemediationRecord) error
+	// SaveReport stores a rendered incident report and returns its ID
+	SaveReport(ctx context.Context, report IncidentReport) (string, error)
+	// GetReport returns the incident report with the given ID, or nil if none exists
+	GetReport(ctx context.Context, id string) (*IncidentReport, error)
+	// EnqueueJob stores a queued job under a new ID. When a job with the same
+	// idempotency key exists, that job is returned instead and created is false
+	EnqueueJob(ctx context.Context, job Job) (stored Job, created bool, err error)
+	// GetJob returns the job with the given ID, or nil if none exists
+	GetJob(ctx context.Context, id string) (*Job, error)
+	// UpdateJob replaces the job with the same ID
+	UpdateJob(ctx context.Context, job Job) error
+	// ClaimJob atomically marks a queued job as running and counts the
+	// attempt, returning nil when the job is missing or no longer queued, so
+	// that a job is never run by two dispatchers at once
+	ClaimJob(ctx context.Context, id string) (*Job, error)
+	// ListJobs returns the jobs in creation order, optionally restricted to a status
+	ListJobs(ctx context.Context, status string) ([]Job, error)
+	// PendingJobs returns the queued and running jobs of every tenant
+	PendingJobs(ctx context.Context) ([]Job, error)
+	// CreateSilence stores a silence under a new ID
+	CreateSilence(ctx context.Context, silence Silence) (Silence, error)
+	// ListSilences returns the silences in creation order
+	ListSilences(ctx context.Context) ([]Silence, error)
+	// DeleteSilence removes the silence with the given ID, returning whether it existed
+	DeleteSilence(ctx context.Context, id string) (bool, error)
+	// SaveSLO creates or replaces the definition of the SLO with the same
+	// name, keeping its creation time and last evaluation
+	SaveSLO(ctx context.Context, slo SLO) (SLO, error)
+	// GetSLO returns the SLO with the given name, or nil if none exists
+	GetSLO(ctx context.Context, name string) (*SLO, error)
+	// ListSLOs returns the SLOs ordered by name
+	ListSLOs(ctx context.Context) ([]SLO, error)
+	// UpdateSLOEvaluation stores the latest evaluation of the SLO with the given name
+	UpdateSLOEvaluation(ctx context.Context, name string, evaluation SLOEvaluation) error
+	// DeleteSLO removes the SLO with the given name, returning whether it existed
+	DeleteSLO(ctx context.Context, name string) (bool, error)
+	// SaveCluster creates or replaces the metadata of the cluster with the
+	// same name, keeping its creation time
+	SaveCluster(ctx context.Context, cluster ClusterInfo) (ClusterMetadata, error)
+	// GetCluster returns the metadata of the cluster with the given name, or nil if none exists
+	GetCluster(ctx context.Context, name string) (*ClusterMetadata, error)
+	// ListClusters returns the metadata of the clusters ordered by name
+	ListClusters(ctx context.Context) ([]ClusterMetadata, error)
+	// DeleteCluster removes the metadata of the cluster with the given name, returning whether it existed
+	DeleteCluster(ctx context.Context, name string) (bool, error)
+	// CreateMonitor stores a pod monitor under a new ID
+	CreateMonitor(ctx context.Context, monitor PodMonitor) (PodMonitor, error)
+	// GetMonitor returns the pod monitor with the given ID, or nil if none exists
+	GetMonitor(ctx context.Context, id string) (*PodMonitor, error)
+	// UpdateMonitor replaces the pod monitor with the same ID
+	UpdateMonitor(ctx context.Context, monitor PodMonitor) error
+	// ListMonitors returns the pod monitors in creation order
+	ListMonitors(ctx context.Context) ([]PodMonitor, error)
+}
+
+// alertKey returns the key identifying a pod alert
+func alertKey(namespace, podName string) string {
+	return namespace + "/" + podName
+}
+
+// MemoryAlertStore is an in-memory AlertStore
+type MemoryAlertStore struct {
+	mu sync.RWMutex
+	// docs holds the documents of each tenant storage prefix
+	docs map[string]map[string]*AlertDocument
+	// reports holds the incident reports of each tenant storage prefix
+	reports map[string]map[string]*IncidentReport
+	// jobs holds the background jobs of each tenant storage prefix
+	jobs map[string]map[string]*Job
+	// silences holds the silences of each tenant storage prefix
+	silences map[string]map[string]*Silence
+	// slos holds the SLOs of each tenant storage prefix, by name
+	slos map[string]map[string]*SLO
+	// clusters holds the cluster metadata of each tenant storage prefix, by name
+	clusters map[string]map[string]*ClusterMetadata
+	// monitors holds the pod monitors of each tenant storage prefix
+	monitors      map[string]map[string]*PodMonitor
+	nextID        int
+	nextReportID  int
+	nextJobID     int
+	nextSilenceID int
+	nextMonitorID int
+}
+
+// NewMemoryAlertStore creates an empty in-memory alert store
+func NewMemoryAlertStore() *MemoryAlertStore {
+	return &MemoryAlertStore{
+		docs:     make(map[string]map[string]*AlertDocument),
+		reports:  make(map[string]map[string]*IncidentReport),
+		jobs:     make(map[string]map[string]*Job),
+		silences: make(map[string]map[string]*Silence),
+		slos:     make(map[string]map[string]*SLO),
+		clusters: make(map[string]map[string]*ClusterMetadata),
+		monitors: make(map[string]map[string]*PodMonitor),
+	}
+}
+
+// tenantDocs returns the documents of the context's tenant, creating the
+// tenant's partition when create is set
+func (s *MemoryAlertStore) tenantDocs(ctx context.Context, create bool) map[string]*AlertDocument {
+	prefix := tenancy.StoragePrefix(ctx)
+	docs, ok := s.docs[prefix]
+	if !ok && create {
+		docs = make(map[string]*AlertDocument)
+		s.docs[prefix] = docs
+	}
+	return docs
+}
+
+// Upsert creates or updates the document for an alert
+func (s *MemoryAlertStore) Upsert(ctx context.Context, alert PodAlert) error {
+	return s.UpsertMany(ctx, []PodAlert{alert})
+}
+
+// UpsertMany creates or updates the documents for several alerts
+func (s *MemoryAlertStore) UpsertMany(ctx context.Context, alerts []PodAlert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	docs := s.tenantDocs(ctx, true)
+	for _, alert := range alerts {
+		key := alertKey(alert.Namespace, alert.PodName)
+		if doc, ok := docs[key]; ok {
+			doc.Alert = alert
+			doc.UpdatedAt = now
+			continue
+		}
+
+		docs[key] = &AlertDocument{
+			Alert:        alert,
+			Remediations: []RemediationRecord{},
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+	}
+	return nil
+}
+
+// Get returns a copy of the document for a pod, or nil if none exists
+func (s *MemoryAlertStore) Get(ctx context.Context, namespace, podName string) (*AlertDocument, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	doc, ok := s.tenantDocs(ctx, false)[alertKey(namespace, podName)]
+	if !ok {
+		return nil, nil
+	}
+	copied := copyDocument(doc)
+	return &copied, nil
+}
+
+// List returns copies of all documents ordered by namespace and pod name
+func (s *MemoryAlertStore) List(ctx context.Context, namespace string) ([]AlertDocument, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenantDocs := s.tenantDocs(ctx, false)
+	docs := make([]AlertDocument, 0, len(tenantDocs))
+	for _, doc := range tenantDocs {
+		if namespace != "" && doc.Alert.Namespace != namespace {
+			continue
+		}
+		docs = append(docs, copyDocument(doc))
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		return alertKey(docs[i].Alert.Namespace, docs[i].Alert.PodName) < alertKey(docs[j].Alert.Namespace, docs[j].Alert.PodName)
+	})
+	return docs, nil
+}
+
+// AddRemediation appends a remediation record, creating the document if needed
+func (s *MemoryAlertStore) AddRemediation(ctx context.Context, namespace, podName string, record RemediationRecord) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	docs := s.tenantDocs(ctx, true)
+	key := alertKey(namespace, podName)
+	doc, ok := docs[key]
+	if !ok {
+		doc = &AlertDocument{
+			Alert:     PodAlert{PodName: podName, Namespace: namespace},
+			CreatedAt: now,
+		}
+		docs[key] = doc
+	}
+
+	s.nextID++
+	record.ID = fmt.Sprintf("rem-%d", s.nextID)
+	doc.Remediations = append(doc.Remediations, record)
+	doc.UpdatedAt = now
+	return record.ID, nil
+}
+
+// UpdateRemediation replaces the remediation record with the given ID
+func (s *MemoryAlertStore) UpdateRemediation(ctx context.Context, namespace, podName string, record RemediationRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.tenantDocs(ctx, false)[alertKey(namespace, podName)]
+	if !ok {
+		return fmt.Errorf("no alert found for %s", alertKey(namespace, podName))
+	}
+
+	for i := range doc.Remediations {
+		if doc.Remediations[i].ID == record.ID {
+			doc.Remediations[i] = record
+			doc.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return fmt.Errorf("remediation %s not found for %s", record.ID, alertKey(namespace, podName))
+}
+
+// SaveReport stores an incident report under a new ID
+func (s *MemoryAlertStore) SaveReport(ctx context.Context, report IncidentReport) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := tenancy.StoragePrefix(ctx)
+	reports, ok := s.reports[prefix]
+	if !ok {
+		reports = make(map[string]*IncidentReport)
+		s.reports[prefix] = reports
+	}
+
+	s.nextReportID++
+	report.ID = fmt.Sprintf("report-%d", s.nextReportID)
+	reports[report.ID] = &report
+	return report.ID, nil
+}
+
+// GetReport returns a copy of the incident report with the given ID, or nil if none exists
+func (s *MemoryAlertStore) GetReport(ctx context.Context, id string) (*IncidentReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report, ok := s.reports[tenancy.StoragePrefix(ctx)][id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *report
+	return &copied, nil
+}
+
+// EnqueueJob stores a queued job under a new ID, unless a job with the same
+// idempotency key exists
+func (s *MemoryAlertStore) EnqueueJob(ctx context.Context, job Job) (Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := tenancy.StoragePrefix(ctx)
+	jobs, ok := s.jobs[prefix]
+	if !ok {
+		jobs = make(map[string]*Job)
+		s.jobs[prefix] = jobs
+	}
+	if job.IdempotencyKey != "" {
+		for _, existing := range jobs {
+			if existing.IdempotencyKey == job.IdempotencyKey {
+				return *existing, false, nil
+			}
+		}
+	}
+
+	now := time.Now()
+	s.nextJobID++
+	job.ID = fmt.Sprintf("job-%d", s.nextJobID)
+	job.Status = JobStatusQueued
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	if job.RunAfter.IsZero() {
+		job.RunAfter = now
+	}
+	jobs[job.ID] = &job
+	return job, true, nil
+}
+
+// GetJob returns a copy of the job with the given ID, or nil if none exists
+func (s *MemoryAlertStore) GetJob(ctx context.Context, id string) (*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.jobs[tenancy.StoragePrefix(ctx)][id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// UpdateJob replaces the job with the same ID
+func (s *MemoryAlertStore) UpdateJob(ctx context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := s.jobs[tenancy.StoragePrefix(ctx)]
+	if _, ok := jobs[job.ID]; !ok {
+		return fmt.Errorf("job %s not found", job.ID)
+	}
+	job.UpdatedAt = time.Now()
+	jobs[job.ID] = &job
+	return nil
+}
+
+// ClaimJob marks a queued job as running and returns a copy, or nil when it is not queued
+func (s *MemoryAlertStore) ClaimJob(ctx context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[tenancy.StoragePrefix(ctx)][id]
+	if !ok || job.Status != JobStatusQueued {
+		return nil, nil
+	}
+	job.Status = JobStatusRunning
+	job.Attempts++
+	job.UpdatedAt = time.Now()
+	copied := *job
+	return &copied, nil
+}
+
+// ListJobs returns copies of the jobs in creation order
+func (s *MemoryAlertStore) ListJobs(ctx context.Context, status string) ([]Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := []Job{}
+	for _, job := range s.jobs[tenancy.StoragePrefix(ctx)] {
+		if status == "" || job.Status == status {
+			jobs = append(jobs, *job)
+		}
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+// PendingJobs returns copies of the queued and running jobs of every tenant
+func (s *MemoryAlertStore) PendingJobs(ctx context.Context) ([]Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := []Job{}
+	for _, tenantJobs := range s.jobs {
+		for _, job := range tenantJobs {
+			if job.Status == JobStatusQueued || job.Status == JobStatusRunning {
+				jobs = append(jobs, *job)
+			}
+		}
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+// CreateSilence stores a silence under a new ID
+func (s *MemoryAlertStore) CreateSilence(ctx context.Context, silence Silence) (Silence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := tenancy.StoragePrefix(ctx)
+	silences, ok := s.silences[prefix]
+	if !ok {
+		silences = make(map[string]*Silence)
+		s.silences[prefix] = silences
+	}
+
+	s.nextSilenceID++
+	silence.ID = fmt.Sprintf("silence-%d", s.nextSilenceID)
+	silence.CreatedAt = time.Now()
+	silences[silence.ID] = &silence
+	return silence, nil
+}
+
+// ListSilences returns copies of the silences in creation order
+func (s *MemoryAlertStore) ListSilences(ctx context.Context) ([]Silence, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	silences := []Silence{}
+	for _, silence := range s.silences[tenancy.StoragePrefix(ctx)] {
+		silences = append(silences, *silence)
+	}
+	sort.Slice(silences, func(i, j int) bool {
+		if !silences[i].CreatedAt.Equal(silences[j].CreatedAt) {
+			return silences[i].CreatedAt.Before(silences[j].CreatedAt)
+		}
+		return silences[i].ID < silences[j].ID
+	})
+	return silences, nil
+}
+
+// DeleteSilence removes the silence with the given ID
+func (s *MemoryAlertStore) DeleteSilence(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	silences := s.silences[tenancy.StoragePrefix(ctx)]
+	if _, ok := silences[id]; !ok {
+		return false, nil
+	}
+	delete(silences, id)
+	return true, nil
+}
+
+// sortJobs orders jobs by creation time, then ID
+func sortJobs(jobs []Job) {
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+}
+
+// copyDocument returns a copy that does not share the remediation slice
+func copyDocument(doc *AlertDocument) AlertDocument {
+	copied := *doc
+	copied.Remediations = append([]RemediationRecord{}, doc.Remediations...)
+	return copied
+}
+
+// SaveSLO creates or replaces the definition of the SLO with the same name
+func (s *MemoryAlertStore) SaveSLO(ctx context.Context, slo SLO) (SLO, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := tenancy.StoragePrefix(ctx)
+	slos, ok := s.slos[prefix]
+	if !ok {
+		slos = make(map[string]*SLO)
+		s.slos[prefix] = slos
+	}
+	now := time.Now()
+	slo.CreatedAt, slo.UpdatedAt, slo.Evaluation = now, now, nil
+	if existing, ok := slos[slo.Name]; ok {
+		slo.CreatedAt, slo.Evaluation = existing.CreatedAt, existing.Evaluation
+	}
+	slos[slo.Name] = &slo
+	return slo, nil
+}
+
+// GetSLO returns a copy of the SLO with the given name, or nil if none exists
+func (s *MemoryAlertStore) GetSLO(ctx context.Context, name string) (*SLO, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	slo, ok := s.slos[tenancy.StoragePrefix(ctx)][name]
+	if !ok {
+		return nil, nil
+	}
+	copied := *slo
+	return &copied, nil
+}
+
+// ListSLOs returns copies of the SLOs ordered by name
+func (s *MemoryAlertStore) ListSLOs(ctx context.Context) ([]SLO, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	slos := []SLO{}
+	for _, slo := range s.slos[tenancy.StoragePrefix(ctx)] {
+		slos = append(slos, *slo)
+	}
+	sort.Slice(slos, func(i, j int) bool { return slos[i].Name < slos[j].Name })
+	return slos, nil
+}
+
+// UpdateSLOEvaluation stores the latest evaluation of the SLO with the given name
+func (s *MemoryAlertStore) UpdateSLOEvaluation(ctx context.Context, name string, evaluation SLOEvaluation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slo, ok := s.slos[tenancy.StoragePrefix(ctx)][name]
+	if !ok {
+		return fmt.Errorf("SLO %s not found", name)
+	}
+	slo.Evaluation = &evaluation
+	return nil
+}
+
+// DeleteSLO removes the SLO with the given name
+func (s *MemoryAlertStore) DeleteSLO(ctx context.Context, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slos := s.slos[tenancy.StoragePrefix(ctx)]
+	if _, ok := slos[name]; !ok {
+		return false, nil
+	}
+	delete(slos, name)
+	return true, nil
+}
+
+// copyCluster returns a copy that does not share the channels or labels
+func copyCluster(cluster *ClusterMetadata) ClusterMetadata {
+	copied := *cluster
+	copied.Channels = append([]string(nil), cluster.Channels...)
+	if cluster.Labels != nil {
+		copied.Labels = make(map[string]string, len(cluster.Labels))
+		for key, value := range cluster.Labels {
+			copied.Labels[key] = value
+		}
+	}
+	return copied
+}
+
+// SaveCluster creates or replaces the metadata of the cluster with the same name
+func (s *MemoryAlertStore) SaveCluster(ctx context.Context, cluster ClusterInfo) (ClusterMetadata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := tenancy.StoragePrefix(ctx)
+	clusters, ok := s.clusters[prefix]
+	if !ok {
+		clusters = make(map[string]*ClusterMetadata)
+		s.clusters[prefix] = clusters
+	}
+	now := time.Now()
+	stored := ClusterMetadata{ClusterInfo: cluster, CreatedAt: now, UpdatedAt: now}
+	if existing, ok := clusters[cluster.Name]; ok {
+		stored.CreatedAt = existing.CreatedAt
+	}
+	stored = copyCluster(&stored)
+	clusters[cluster.Name] = &stored
+	return copyCluster(&stored), nil
+}
+
+// GetCluster returns a copy of the metadata of the cluster with the given name, or nil if none exists
+func (s *MemoryAlertStore) GetCluster(ctx context.Context, name string) (*ClusterMetadata, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cluster, ok := s.clusters[tenancy.StoragePrefix(ctx)][name]
+	if !ok {
+		return nil, nil
+	}
+	copied := copyCluster(cluster)
+	return &copied, nil
+}
+
+// ListClusters returns copies of the cluster metadata ordered by name
+func (s *MemoryAlertStore) ListClusters(ctx context.Context) ([]ClusterMetadata, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	clusters := []ClusterMetadata{}
+	for _, cluster := range s.clusters[tenancy.StoragePrefix(ctx)] {
+		clusters = append(clusters, copyCluster(cluster))
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters, nil
+}
+
+// DeleteCluster removes the metadata of the cluster with the given name
+func (s *MemoryAlertStore) DeleteCluster(ctx context.Context, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clusters := s.clusters[tenancy.StoragePrefix(ctx)]
+	if _, ok := clusters[name]; !ok {
+		return false, nil
+	}
+	delete(clusters, name)
+	return true, nil
+}
+
+// copyMonitor returns a copy that does not share the observations or outcome
+func copyMonitor(monitor *PodMonitor) PodMonitor {
+	copied := *monitor
+	copied.Observations = append([]MonitorObservation{}, monitor.Observations...)
+	if monitor.Outcome != nil {
+		outcome := *monitor.Outcome
+		copied.Outcome = &outcome
+	}
+	return copied
+}
+
+// CreateMonitor stores a pod monitor under a new ID
+func (s *MemoryAlertStore) CreateMonitor(ctx context.Context, monitor PodMonitor) (PodMonitor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := tenancy.StoragePrefix(ctx)
+	monitors, ok := s.monitors[prefix]
+	if !ok {
+		monitors = make(map[string]*PodMonitor)
+		s.monitors[prefix] = monitors
+	}
+
+	s.nextMonitorID++
+	monitor.ID = fmt.Sprintf("monitor-%d", s.nextMonitorID)
+	monitor.CreatedAt = time.Now()
+	stored := copyMonitor(&monitor)
+	monitors[monitor.ID] = &stored
+	return monitor, nil
+}
+
+// GetMonitor returns a copy of the pod monitor with the given ID, or nil if none exists
+func (s *MemoryAlertStore) GetMonitor(ctx context.Context, id string) (*PodMonitor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	monitor, ok := s.monitors[tenancy.StoragePrefix(ctx)][id]
+	if !ok {
+		return nil, nil
+	}
+	copied := copyMonitor(monitor)
+	return &copied, nil
+}
+
+// UpdateMonitor replaces the pod monitor with the same ID
+func (s *MemoryAlertStore) UpdateMonitor(ctx context.Context, monitor PodMonitor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	monitors := s.monitors[tenancy.StoragePrefix(ctx)]
+	if _, ok := monitors[monitor.ID]; !ok {
+		return fmt.Errorf("monitor %s not found", monitor.ID)
+	}
+	stored := copyMonitor(&monitor)
+	monitors[monitor.ID] = &stored
+	return nil
+}
+
+// ListMonitors returns copies of the pod monitors in creation order
+func (s *MemoryAlertStore) ListMonitors(ctx context.Context) ([]PodMonitor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	monitors := []PodMonitor{}
+	for _, monitor := range s.monitors[tenancy.StoragePrefix(ctx)] {
+		monitors = append(monitors, copyMonitor(monitor))
+	}
+	sort.Slice(monitors, func(i, j int) bool {
+		if !monitors[i].CreatedAt.Equal(monitors[j].CreatedAt) {
+			return monitors[i].CreatedAt.Before(monitors[j].CreatedAt)
+		}
+		return monitors[i].ID < monitors[j].ID
+	})
+	return monitors, nil
+}
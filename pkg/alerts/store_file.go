@@ -0,0 +1,397 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/kagent-dev/tools/internal/logger"
+)
+
+// AlertStoreFile configures the file alert documents are persisted to. When
+// unset, alerts are kept in memory unless the server runs in offline mode.
+const AlertStoreFile = "ALERT_STORE_FILE"
+
+// AlertStoreSync set to true makes every write of the alert store file wait
+// until it is flushed to disk, so acknowledged changes survive a host crash
+// rather than only a restart of the server.
+const AlertStoreSync = "ALERT_STORE_SYNC"
+
+// defaultAlertStoreFile is the file name used under the XDG data directory
+const defaultAlertStoreFile = "kagent-tools/alerts.json"
+
+// DefaultAlertStorePath returns the alert store file under $XDG_DATA_HOME,
+// falling back to ~/.local/share
+func DefaultAlertStorePath() (string, error) {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dataHome) {
+		return filepath.Join(dataHome, defaultAlertStoreFile), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate data directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", defaultAlertStoreFile), nil
+}
+
+// NewAlertStoreFromEnv returns the alert store configured by ALERT_STORE_FILE.
+// In offline mode, used for stdio servers without shared storage, alerts are
+// persisted under the XDG data directory by default. The store falls back to
+// memory when the file cannot be used.
+func NewAlertStoreFromEnv(offline bool) AlertStore {
+	path := os.Getenv(AlertStoreFile)
+	if path == "" && offline {
+		var err error
+		if path, err = DefaultAlertStorePath(); err != nil {
+			logger.Get().Error("Alert persistence disabled", "error", err)
+			return NewMemoryAlertStore()
+		}
+	}
+	if path == "" {
+		return NewMemoryAlertStore()
+	}
+
+	store, err := NewFileAlertStore(path)
+	if err != nil {
+		logger.Get().Error("Alert persistence disabled", "path", path, "error", err)
+		return NewMemoryAlertStore()
+	}
+	store.WithSync(os.Getenv(AlertStoreSync) == "true")
+	logger.Get().Info("Persisting alerts to file", "path", path, "sync", store.sync)
+	return store
+}
+
+// alertStoreSnapshot is the on-disk representation of a FileAlertStore
+type alertStoreSnapshot struct {
+	Docs          map[string]map[string]*AlertDocument   `json:"alerts"`
+	Reports       map[string]map[string]*IncidentReport  `json:"reports"`
+	Jobs          map[string]map[string]*Job             `json:"jobs,omitempty"`
+	Silences      map[string]map[string]*Silence         `json:"silences,omitempty"`
+	SLOs          map[string]map[string]*SLO             `json:"slos,omitempty"`
+	Clusters      map[string]map[string]*ClusterMetadata `json:"clusters,omitempty"`
+	Monitors      map[string]map[string]*PodMonitor      `json:"monitors,omitempty"`
+	NextID        int                                    `json:"next_remediation_id"`
+	NextReportID  int                                    `json:"next_report_id"`
+	NextJobID     int                                    `json:"next_job_id,omitempty"`
+	NextSilenceID int                                    `json:"next_silence_id,omitempty"`
+	NextMonitorID int                                    `json:"next_monitor_id,omitempty"`
+}
+
+// FileAlertStore is an AlertStore that keeps documents in memory and writes
+// them to a local JSON file after every change, so that alerts, their
+// remediation history and pending jobs survive restarts without an external
+// database
+type FileAlertStore struct {
+	*MemoryAlertStore
+	path string
+	// sync flushes each write to disk before it is acknowledged
+	sync bool
+	// mu serializes changes with the writes that persist them
+	mu sync.Mutex
+}
+
+// NewFileAlertStore creates a store persisted to path, loading any documents
+// already stored there
+func NewFileAlertStore(path string) (*FileAlertStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create alert store directory: %w", err)
+	}
+
+	store := &FileAlertStore{MemoryAlertStore: NewMemoryAlertStore(), path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert store: %w", err)
+	}
+
+	var snapshot alertStoreSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse alert store %s: %w", path, err)
+	}
+	if snapshot.Docs != nil {
+		store.docs = snapshot.Docs
+	}
+	if snapshot.Reports != nil {
+		store.reports = snapshot.Reports
+	}
+	if snapshot.Jobs != nil {
+		store.jobs = snapshot.Jobs
+	}
+	if snapshot.Silences != nil {
+		store.silences = snapshot.Silences
+	}
+	if snapshot.SLOs != nil {
+		store.slos = snapshot.SLOs
+	}
+	if snapshot.Clusters != nil {
+		store.clusters = snapshot.Clusters
+	}
+	if snapshot.Monitors != nil {
+		store.monitors = snapshot.Monitors
+	}
+	store.nextID = snapshot.NextID
+	store.nextReportID = snapshot.NextReportID
+	store.nextJobID = snapshot.NextJobID
+	store.nextSilenceID = snapshot.NextSilenceID
+	store.nextMonitorID = snapshot.NextMonitorID
+	return store, nil
+}
+
+// WithSync sets whether writes are flushed to disk before they are acknowledged
+func (s *FileAlertStore) WithSync(sync bool) *FileAlertStore {
+	s.sync = sync
+	return s
+}
+
+// Path returns the file the store is persisted to
+func (s *FileAlertStore) Path() string {
+	return s.path
+}
+
+// save writes the current documents to the store file, replacing it atomically
+func (s *FileAlertStore) save() error {
+	s.MemoryAlertStore.mu.RLock()
+	data, err := json.Marshal(alertStoreSnapshot{
+		Docs:          s.docs,
+		Reports:       s.reports,
+		Jobs:          s.jobs,
+		Silences:      s.silences,
+		SLOs:          s.slos,
+		Clusters:      s.clusters,
+		Monitors:      s.monitors,
+		NextID:        s.nextID,
+		NextReportID:  s.nextReportID,
+		NextJobID:     s.nextJobID,
+		NextSilenceID: s.nextSilenceID,
+		NextMonitorID: s.nextMonitorID,
+	})
+	s.MemoryAlertStore.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode alert store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write alert store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write alert store: %w", err)
+	}
+	if s.sync {
+		if err := tmp.Sync(); err != nil {
+			_ = tmp.Close()
+			return fmt.Errorf("failed to sync alert store: %w", err)
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write alert store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write alert store: %w", err)
+	}
+	return nil
+}
+
+// Upsert creates or updates the document for an alert and persists it
+func (s *FileAlertStore) Upsert(ctx context.Context, alert PodAlert) error {
+	return s.UpsertMany(ctx, []PodAlert{alert})
+}
+
+// UpsertMany creates or updates the documents for several alerts and
+// persists them with a single write of the store file
+func (s *FileAlertStore) UpsertMany(ctx context.Context, alerts []PodAlert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.MemoryAlertStore.UpsertMany(ctx, alerts); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// AddRemediation appends a remediation record and persists it
+func (s *FileAlertStore) AddRemediation(ctx context.Context, namespace, podName string, record RemediationRecord) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := s.MemoryAlertStore.AddRemediation(ctx, namespace, podName, record)
+	if err != nil {
+		return "", err
+	}
+	return id, s.save()
+}
+
+// UpdateRemediation replaces the remediation record with the given ID and persists it
+func (s *FileAlertStore) UpdateRemediation(ctx context.Context, namespace, podName string, record RemediationRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.MemoryAlertStore.UpdateRemediation(ctx, namespace, podName, record); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// SaveReport stores an incident report under a new ID and persists it
+func (s *FileAlertStore) SaveReport(ctx context.Context, report IncidentReport) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := s.MemoryAlertStore.SaveReport(ctx, report)
+	if err != nil {
+		return "", err
+	}
+	return id, s.save()
+}
+
+// EnqueueJob stores a queued job under a new ID and persists it
+func (s *FileAlertStore) EnqueueJob(ctx context.Context, job Job) (Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, created, err := s.MemoryAlertStore.EnqueueJob(ctx, job)
+	if err != nil || !created {
+		return stored, created, err
+	}
+	return stored, created, s.save()
+}
+
+// UpdateJob replaces the job with the same ID and persists it
+func (s *FileAlertStore) UpdateJob(ctx context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.MemoryAlertStore.UpdateJob(ctx, job); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// ClaimJob marks a queued job as running and persists it
+func (s *FileAlertStore) ClaimJob(ctx context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.MemoryAlertStore.ClaimJob(ctx, id)
+	if err != nil || job == nil {
+		return job, err
+	}
+	return job, s.save()
+}
+
+// CreateSilence stores a silence under a new ID and persists it
+func (s *FileAlertStore) CreateSilence(ctx context.Context, silence Silence) (Silence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.MemoryAlertStore.CreateSilence(ctx, silence)
+	if err != nil {
+		return stored, err
+	}
+	return stored, s.save()
+}
+
+// DeleteSilence removes the silence with the given ID and persists the change
+func (s *FileAlertStore) DeleteSilence(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted, err := s.MemoryAlertStore.DeleteSilence(ctx, id)
+	if err != nil || !deleted {
+		return deleted, err
+	}
+	return deleted, s.save()
+}
+
+// SaveSLO creates or replaces an SLO definition and persists it
+func (s *FileAlertStore) SaveSLO(ctx context.Context, slo SLO) (SLO, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.MemoryAlertStore.SaveSLO(ctx, slo)
+	if err != nil {
+		return stored, err
+	}
+	return stored, s.save()
+}
+
+// UpdateSLOEvaluation stores the latest evaluation of an SLO and persists it
+func (s *FileAlertStore) UpdateSLOEvaluation(ctx context.Context, name string, evaluation SLOEvaluation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.MemoryAlertStore.UpdateSLOEvaluation(ctx, name, evaluation); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// DeleteSLO removes the SLO with the given name and persists the change
+func (s *FileAlertStore) DeleteSLO(ctx context.Context, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted, err := s.MemoryAlertStore.DeleteSLO(ctx, name)
+	if err != nil || !deleted {
+		return deleted, err
+	}
+	return deleted, s.save()
+}
+
+// SaveCluster creates or replaces the metadata of a cluster and persists it
+func (s *FileAlertStore) SaveCluster(ctx context.Context, cluster ClusterInfo) (ClusterMetadata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.MemoryAlertStore.SaveCluster(ctx, cluster)
+	if err != nil {
+		return stored, err
+	}
+	return stored, s.save()
+}
+
+// DeleteCluster removes the metadata of the cluster with the given name and persists the change
+func (s *FileAlertStore) DeleteCluster(ctx context.Context, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted, err := s.MemoryAlertStore.DeleteCluster(ctx, name)
+	if err != nil || !deleted {
+		return deleted, err
+	}
+	return deleted, s.save()
+}
+
+// CreateMonitor stores a pod monitor under a new ID and persists it
+func (s *FileAlertStore) CreateMonitor(ctx context.Context, monitor PodMonitor) (PodMonitor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.MemoryAlertStore.CreateMonitor(ctx, monitor)
+	if err != nil {
+		return stored, err
+	}
+	return stored, s.save()
+}
+
+// UpdateMonitor replaces the pod monitor with the same ID and persists the change
+func (s *FileAlertStore) UpdateMonitor(ctx context.Context, monitor PodMonitor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.MemoryAlertStore.UpdateMonitor(ctx, monitor); err != nil {
+		return err
+	}
+	return s.save()
+}
@@ -0,0 +1,115 @@
+package alerts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileAlertStorePersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "data", "alerts.json")
+
+	store, err := NewFileAlertStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Upsert(ctx, PodAlert{PodName: "web-1", Namespace: "prod", State: AlertStateAnalyzed, Analysis: "OOMKilled"}))
+	id, err := store.AddRemediation(ctx, "prod", "web-1", RemediationRecord{Remediation: "raise memory limit"})
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateRemediation(ctx, "prod", "web-1", RemediationRecord{ID: id, Verification: VerificationResolved}))
+	reportID, err := store.SaveReport(ctx, IncidentReport{Namespace: "prod", PodName: "web-1", Content: "# Incident"})
+	require.NoError(t, err)
+
+	// A new store on the same file sees every change
+	reopened, err := NewFileAlertStore(path)
+	require.NoError(t, err)
+	doc, err := reopened.Get(ctx, "prod", "web-1")
+	require.NoError(t, err)
+	require.NotNil(t, doc)
+	assert.Equal(t, "OOMKilled", doc.Alert.Analysis)
+	require.Len(t, doc.Remediations, 1)
+	assert.Equal(t, VerificationResolved, doc.Remediations[0].Verification)
+
+	report, err := reopened.GetReport(ctx, reportID)
+	require.NoError(t, err)
+	require.NotNil(t, report)
+	assert.Equal(t, "# Incident", report.Content)
+
+	// IDs keep increasing across restarts
+	nextID, err := reopened.AddRemediation(ctx, "prod", "web-1", RemediationRecord{})
+	require.NoError(t, err)
+	assert.NotEqual(t, id, nextID)
+}
+
+func TestFileAlertStoreInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+	_, err := NewFileAlertStore(path)
+	assert.Error(t, err)
+}
+
+func TestNewAlertStoreFromEnv(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv(AlertStoreFile, "")
+
+	assert.IsType(t, &MemoryAlertStore{}, NewAlertStoreFromEnv(false))
+
+	offline, ok := NewAlertStoreFromEnv(true).(*FileAlertStore)
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(dataHome, "kagent-tools", "alerts.json"), offline.Path())
+
+	path := filepath.Join(t.TempDir(), "custom.json")
+	t.Setenv(AlertStoreFile, path)
+	configured, ok := NewAlertStoreFromEnv(false).(*FileAlertStore)
+	require.True(t, ok)
+	assert.Equal(t, path, configured.Path())
+	assert.False(t, configured.sync)
+
+	t.Setenv(AlertStoreSync, "true")
+	synced, ok := NewAlertStoreFromEnv(false).(*FileAlertStore)
+	require.True(t, ok)
+	assert.True(t, synced.sync)
+}
+
+// countingStore counts the batched writes of a store
+type countingStore struct {
+	*FileAlertStore
+	writes int
+}
+
+func (s *countingStore) UpsertMany(ctx context.Context, alerts []PodAlert) error {
+	s.writes++
+	return s.FileAlertStore.UpsertMany(ctx, alerts)
+}
+
+func TestTransitionAllBatchesWrites(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	fileStore, err := NewFileAlertStore(path)
+	require.NoError(t, err)
+	store := &countingStore{FileAlertStore: fileStore.WithSync(true)}
+	tool := NewAlertTool(nil).WithStore(store)
+
+	alerts := []PodAlert{
+		{PodName: "web-1", Namespace: "prod"},
+		{PodName: "web-2", Namespace: "prod"},
+		{PodName: "web-3", Namespace: "prod"},
+	}
+	tool.transitionAll(ctx, []*PodAlert{&alerts[0], &alerts[1], &alerts[2]}, AlertStateCollected)
+	assert.Equal(t, 1, store.writes)
+	assert.Equal(t, AlertStateCollected, alerts[2].State)
+
+	reopened, err := NewFileAlertStore(path)
+	require.NoError(t, err)
+	docs, err := reopened.List(ctx, "prod")
+	require.NoError(t, err)
+	assert.Len(t, docs, 3)
+
+	tool.transitionAll(ctx, nil, AlertStateAnalyzed)
+	assert.Equal(t, 1, store.writes)
+}
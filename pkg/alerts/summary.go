@@ -0,0 +1,719 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// Environment variables used to schedule operations summaries
+const (
+	// AlertSummarySchedule is the summary period, daily or weekly; unset disables the schedule
+	AlertSummarySchedule = "ALERT_SUMMARY_SCHEDULE"
+	// AlertSummaryTime is the UTC time of day, as HH:MM, at which summaries are generated
+	AlertSummaryTime = "ALERT_SUMMARY_TIME"
+	// AlertSummaryNarrative disables the LLM narrative of scheduled summaries when false
+	AlertSummaryNarrative = "ALERT_SUMMARY_NARRATIVE"
+)
+
+// Periods covered by an operations summary
+const (
+	SummaryPeriodDaily  = "daily"
+	SummaryPeriodWeekly = "weekly"
+)
+
+// summaryPeriods maps summary periods to the length of the window they cover
+var summaryPeriods = map[string]time.Duration{
+	SummaryPeriodDaily:  24 * time.Hour,
+	SummaryPeriodWeekly: 7 * 24 * time.Hour,
+}
+
+// JobKindOpsSummary generates a scheduled operations summary and queues the next one
+const JobKindOpsSummary = "ops_summary"
+
+// defaultSummaryTime is the time of day summaries are generated when none is configured
+const defaultSummaryTime = 8 * time.Hour
+
+// summaryTopWorkloads is the number of crashing workloads listed in a summary
+const summaryTopWorkloads = 5
+
+// capacitySignals maps the issue types that indicate capacity pressure to the
+// resource they point at
+var capacitySignals = map[string]string{
+	"OOMKilled":        "memory",
+	"Evicted":          "node pressure",
+	"FailedScheduling": "schedulable capacity",
+}
+
+// SummaryConfig configures scheduled operations summaries
+type SummaryConfig struct {
+	// Period is daily or weekly; an empty period disables the schedule
+	Period string
+	// At is the UTC time of day the summary is generated
+	At time.Duration
+	// Narrative adds an LLM narrative when a model is configured
+	Narrative bool
+}
+
+// LoadSummaryConfig reads the summary schedule from the environment
+func LoadSummaryConfig() (SummaryConfig, error) {
+	cfg := SummaryConfig{
+		Period:    strings.ToLower(strings.TrimSpace(os.Getenv(AlertSummarySchedule))),
+		At:        defaultSummaryTime,
+		Narrative: os.Getenv(AlertSummaryNarrative) != "false",
+	}
+	if cfg.Period == "" {
+		return cfg, nil
+	}
+	if _, ok := summaryPeriods[cfg.Period]; !ok {
+		return SummaryConfig{}, fmt.Errorf("invalid %s %q, expected %s or %s", AlertSummarySchedule, cfg.Period, SummaryPeriodDaily, SummaryPeriodWeekly)
+	}
+
+	if value := os.Getenv(AlertSummaryTime); value != "" {
+		at, err := time.Parse("15:04", value)
+		if err != nil {
+			return SummaryConfig{}, fmt.Errorf("invalid %s %q, expected HH:MM", AlertSummaryTime, value)
+		}
+		cfg.At = time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+	}
+	return cfg, nil
+}
+
+// nextSummaryRun returns the first scheduled run after now. Weekly summaries run on Mondays.
+func nextSummaryRun(cfg SummaryConfig, now time.Time) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(cfg.At)
+	for !next.After(now) || (cfg.Period == SummaryPeriodWeekly && next.Weekday() != time.Monday) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// SummaryAlert is an alert first recorded during the summary window
+type SummaryAlert struct {
+	ID         string     `json:"id"`
+	Cluster    string     `json:"cluster,omitempty"`
+	Status     string     `json:"status"`
+	Reason     string     `json:"reason,omitempty"`
+	Severity   string     `json:"severity,omitempty"`
+	State      AlertState `json:"state,omitempty"`
+	SilencedBy string     `json:"silenced_by,omitempty"`
+	FirstSeen  time.Time  `json:"first_seen"`
+}
+
+// SummaryResolution is a remediation verified as resolved during the summary window
+type SummaryResolution struct {
+	ID            string    `json:"id"`
+	Cluster       string    `json:"cluster,omitempty"`
+	Remediation   string    `json:"remediation"`
+	VerifiedAt    time.Time `json:"verified_at"`
+	Effectiveness *float64  `json:"effectiveness,omitempty"`
+}
+
+// WorkloadCrashes aggregates the restarts of the alerting pods of a workload
+type WorkloadCrashes struct {
+	Workload string   `json:"workload"`
+	Cluster  string   `json:"cluster,omitempty"`
+	Pods     int      `json:"pods"`
+	Restarts int32    `json:"restarts"`
+	Reasons  []string `json:"reasons"`
+}
+
+// CapacityTrend compares the alerts pointing at a capacity problem with the previous window
+type CapacityTrend struct {
+	Signal   string `json:"signal"`
+	Resource string `json:"resource"`
+	Current  int    `json:"current"`
+	Previous int    `json:"previous"`
+	Change   int    `json:"change"`
+}
+
+// ClusterAlertCounts breaks the counts of a summary down for one cluster
+type ClusterAlertCounts struct {
+	// Cluster is empty for alerts stored without a cluster
+	Cluster              string `json:"cluster"`
+	NewAlerts            int    `json:"new_alerts"`
+	OpenAlerts           int    `json:"open_alerts"`
+	ResolvedIncidents    int    `json:"resolved_incidents"`
+	RecurredRemediations int    `json:"recurred_remediations"`
+	Restarts             int32  `json:"restarts"`
+}
+
+// OpsSummary is an operations summary of the alert store over a window
+type OpsSummary struct {
+	Period               string              `json:"period"`
+	Start                time.Time           `json:"start"`
+	End                  time.Time           `json:"end"`
+	NewAlerts            []SummaryAlert      `json:"new_alerts"`
+	OpenAlerts           int                 `json:"open_alerts"`
+	ResolvedIncidents    []SummaryResolution `json:"resolved_incidents"`
+	RecurredRemediations int                 `json:"recurred_remediations"`
+	TopCrashingWorkloads []WorkloadCrashes   `json:"top_crashing_workloads"`
+	CapacityTrends       []CapacityTrend     `json:"capacity_trends"`
+	Narrative            *AnalysisResult     `json:"narrative,omitempty"`
+	NarrativeError       string              `json:"narrative_error,omitempty"`
+	// Cluster is the cluster the summary covers, with its registered metadata.
+	// It is unset when the summary aggregates several clusters.
+	Cluster *ClusterInfo `json:"cluster,omitempty"`
+	// Clusters breaks the summary down by cluster when the alerts come from
+	// more than one, ordered by name
+	Clusters []ClusterAlertCounts `json:"clusters,omitempty"`
+}
+
+// alertWorkload returns the workload owning an alerting pod, the Deployment of
+// ReplicaSet pods or the pod itself
+func alertWorkload(alert PodAlert) string {
+	if match := replicaSetPodName.FindStringSubmatch(alert.PodName); match != nil {
+		return alert.Namespace + "/deployment/" + match[1]
+	}
+	return alert.Namespace + "/pod/" + alert.PodName
+}
+
+// inWindow reports whether t falls in [start, end)
+func inWindow(t, start, end time.Time) bool {
+	return !t.Before(start) && t.Before(end)
+}
+
+// buildOpsSummary summarizes the alerts and remediations stored during the
+// period ending at end, comparing capacity signals with the period before.
+// Alerts from several clusters are aggregated, with a breakdown by cluster.
+func buildOpsSummary(docs []AlertDocument, period string, end time.Time) OpsSummary {
+	length := summaryPeriods[period]
+	start := end.Add(-length)
+	summary := OpsSummary{
+		Period:               period,
+		Start:                start,
+		End:                  end,
+		NewAlerts:            []SummaryAlert{},
+		ResolvedIncidents:    []SummaryResolution{},
+		TopCrashingWorkloads: []WorkloadCrashes{},
+		CapacityTrends:       []CapacityTrend{},
+	}
+
+	workloads := map[string]*WorkloadCrashes{}
+	clusters := map[string]*ClusterAlertCounts{}
+	current := map[string]int{}
+	previous := map[string]int{}
+	for _, doc := range docs {
+		alert := doc.Alert
+		id := alertKey(alert.Namespace, alert.PodName)
+		cluster := alertCluster(alert)
+		counts, ok := clusters[cluster]
+		if !ok {
+			counts = &ClusterAlertCounts{Cluster: cluster}
+			clusters[cluster] = counts
+		}
+		if alert.State != AlertStateRemediated && doc.CreatedAt.Before(end) {
+			summary.OpenAlerts++
+			counts.OpenAlerts++
+		}
+		if inWindow(doc.CreatedAt, start, end) {
+			counts.NewAlerts++
+			summary.NewAlerts = append(summary.NewAlerts, SummaryAlert{
+				ID:         id,
+				Cluster:    cluster,
+				Status:     alert.Status,
+				Reason:     alert.Reason,
+				Severity:   alertSeverity(alert),
+				State:      alert.State,
+				SilencedBy: alert.SilencedBy,
+				FirstSeen:  doc.CreatedAt,
+			})
+		}
+
+		signals := map[string]int(nil)
+		switch {
+		case inWindow(doc.CreatedAt, start, end):
+			signals = current
+		case inWindow(doc.CreatedAt, start.Add(-length), start):
+			signals = previous
+		}
+		if signals != nil {
+			for _, issue := range uniqueSorted(alertIssueTypes(alert)) {
+				if _, ok := capacitySignals[issue]; ok {
+					signals[issue]++
+				}
+			}
+		}
+
+		if alert.RestartCount > 0 && inWindow(doc.UpdatedAt, start, end) {
+			// The same workload in two clusters crashes independently
+			name := alertWorkload(alert)
+			key := cluster + "/" + name
+			workload, ok := workloads[key]
+			if !ok {
+				workload = &WorkloadCrashes{Workload: name, Cluster: cluster}
+				workloads[key] = workload
+			}
+			counts.Restarts += alert.RestartCount
+			workload.Pods++
+			workload.Restarts += alert.RestartCount
+			if alert.Reason != "" {
+				workload.Reasons = uniqueSorted(append(workload.Reasons, alert.Reason))
+			}
+		}
+
+		for _, record := range doc.Remediations {
+			if record.VerifiedAt == nil || !inWindow(*record.VerifiedAt, start, end) {
+				continue
+			}
+			switch record.Verification {
+			case VerificationResolved:
+				counts.ResolvedIncidents++
+				summary.ResolvedIncidents = append(summary.ResolvedIncidents, SummaryResolution{
+					ID:            id,
+					Cluster:       cluster,
+					Remediation:   record.Remediation,
+					VerifiedAt:    *record.VerifiedAt,
+					Effectiveness: record.Effectiveness,
+				})
+			case VerificationRecurred:
+				counts.RecurredRemediations++
+				summary.RecurredRemediations++
+			}
+		}
+	}
+
+	sort.SliceStable(summary.NewAlerts, func(i, j int) bool {
+		return summary.NewAlerts[i].FirstSeen.Before(summary.NewAlerts[j].FirstSeen)
+	})
+	sort.SliceStable(summary.ResolvedIncidents, func(i, j int) bool {
+		return summary.ResolvedIncidents[i].VerifiedAt.Before(summary.ResolvedIncidents[j].VerifiedAt)
+	})
+
+	for _, workload := range workloads {
+		summary.TopCrashingWorkloads = append(summary.TopCrashingWorkloads, *workload)
+	}
+	sort.Slice(summary.TopCrashingWorkloads, func(i, j int) bool {
+		a, b := summary.TopCrashingWorkloads[i], summary.TopCrashingWorkloads[j]
+		if a.Restarts != b.Restarts {
+			return a.Restarts > b.Restarts
+		}
+		if a.Workload != b.Workload {
+			return a.Workload < b.Workload
+		}
+		return a.Cluster < b.Cluster
+	})
+	if len(summary.TopCrashingWorkloads) > summaryTopWorkloads {
+		summary.TopCrashingWorkloads = summary.TopCrashingWorkloads[:summaryTopWorkloads]
+	}
+
+	for signal, resource := range capacitySignals {
+		if current[signal] == 0 && previous[signal] == 0 {
+			continue
+		}
+		summary.CapacityTrends = append(summary.CapacityTrends, CapacityTrend{
+			Signal:   signal,
+			Resource: resource,
+			Current:  current[signal],
+			Previous: previous[signal],
+			Change:   current[signal] - previous[signal],
+		})
+	}
+	sort.Slice(summary.CapacityTrends, func(i, j int) bool {
+		return summary.CapacityTrends[i].Signal < summary.CapacityTrends[j].Signal
+	})
+
+	if len(clusters) > 1 {
+		for _, counts := range clusters {
+			summary.Clusters = append(summary.Clusters, *counts)
+		}
+		sort.Slice(summary.Clusters, func(i, j int) bool {
+			return summary.Clusters[i].Cluster < summary.Clusters[j].Cluster
+		})
+	}
+	return summary
+}
+
+// summaryNarrativePrompt asks the LLM to explain a summary to the on-call team
+func summaryNarrativePrompt(summary OpsSummary) (string, error) {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`You are a Kubernetes SRE writing the %s operations summary for the on-call team.
+Explain what changed between %s and %s, which workloads need attention and whether
+capacity is trending the wrong way, based only on this data:
+
+%s`, summary.Period, reportTime(summary.Start), reportTime(summary.End), data), nil
+}
+
+// addNarrative asks the LLM for a narrative of the summary. Failures are
+// recorded on the summary rather than failing it.
+func (a *AlertTool) addNarrative(ctx context.Context, summary *OpsSummary) {
+	if err := a.llmUnavailable(ctx); err != nil {
+		summary.NarrativeError = err.Error()
+		return
+	}
+	prompt, err := summaryNarrativePrompt(*summary)
+	if err == nil {
+		summary.Narrative, err = a.generateStructuredAnalysis(ctx, AnalysisTypeOpsSummary, prompt)
+	}
+	if err != nil {
+		logger.Get().Error("Failed to generate ops summary narrative", "error", err)
+		summary.NarrativeError = err.Error()
+	}
+}
+
+// summaryData is the input of the summary templates
+type summaryData struct {
+	OpsSummary
+	Title           string
+	GeneratedAt     string
+	Headline        string
+	Highlights      []string
+	Risks           []string
+	Recommendations []string
+}
+
+// summaryClusterName names a cluster in a summary breakdown, including the
+// alerts stored without one
+func summaryClusterName(cluster string) string {
+	if cluster == "" {
+		return "unknown"
+	}
+	return cluster
+}
+
+// summaryFormats lists the formats an operations summary can be rendered in
+var summaryFormats = []string{ReportFormatMarkdown, ReportFormatText}
+
+var summaryFuncs = map[string]interface{}{
+	"cell":          markdownCell,
+	"clusterName":   summaryClusterName,
+	"effectiveness": effectiveness,
+	"signed":        func(i int) string { return fmt.Sprintf("%+d", i) },
+	"join":          strings.Join,
+	"time":          reportTime,
+}
+
+var markdownSummaryTemplate = texttemplate.Must(texttemplate.New("markdown").Funcs(summaryFuncs).Parse(`# {{ .Title }}
+
+{{ time .Start }} to {{ time .End }}
+{{- with .Cluster }} on {{ .Label }}{{ if .Team }}, owned by {{ .Team }}{{ end }}{{ end }}
+
+| New alerts | Open alerts | Resolved incidents | Recurred remediations |
+|---|---|---|---|
+| {{ len .NewAlerts }} | {{ .OpenAlerts }} | {{ len .ResolvedIncidents }} | {{ .RecurredRemediations }} |
+{{- if .Clusters }}
+
+## Clusters
+
+| Cluster | New alerts | Open alerts | Resolved incidents | Recurred remediations | Restarts |
+|---|---|---|---|---|---|
+{{- range .Clusters }}
+| {{ cell (clusterName .Cluster) }} | {{ .NewAlerts }} | {{ .OpenAlerts }} | {{ .ResolvedIncidents }} | {{ .RecurredRemediations }} | {{ .Restarts }} |
+{{- end }}
+{{- end }}
+{{- if .Headline }}
+
+## Narrative
+
+{{ .Headline }}
+{{- range .Highlights }}
+- {{ . }}
+{{- end }}
+{{- if .Risks }}
+
+**Risks**
+{{ range .Risks }}
+- {{ . }}
+{{- end }}
+{{- end }}
+{{- if .Recommendations }}
+
+**Recommendations**
+{{ range .Recommendations }}
+- {{ . }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+## New alerts
+{{ if .NewAlerts }}
+| Alert | Status | Reason | Severity | First seen |
+|---|---|---|---|---|
+{{- range .NewAlerts }}
+| {{ if $.Clusters }}{{ cell (clusterName .Cluster) }}: {{ end }}{{ cell .ID }}{{ if .SilencedBy }} (silenced){{ end }} | {{ cell .Status }} | {{ cell .Reason }} | {{ cell .Severity }} | {{ time .FirstSeen }} |
+{{- end }}
+{{ else }}
+No new alerts.
+{{ end }}
+## Resolved incidents
+{{ if .ResolvedIncidents }}
+| Alert | Remediation | Verified | Effectiveness |
+|---|---|---|---|
+{{- range .ResolvedIncidents }}
+| {{ if $.Clusters }}{{ cell (clusterName .Cluster) }}: {{ end }}{{ cell .ID }} | {{ cell .Remediation }} | {{ time .VerifiedAt }} | {{ effectiveness .Effectiveness }} |
+{{- end }}
+{{ else }}
+No incidents resolved.
+{{ end }}
+## Top crashing workloads
+{{ if .TopCrashingWorkloads }}
+| Workload | Pods | Restarts | Reasons |
+|---|---|---|---|
+{{- range .TopCrashingWorkloads }}
+| {{ if $.Clusters }}{{ cell (clusterName .Cluster) }}: {{ end }}{{ cell .Workload }} | {{ .Pods }} | {{ .Restarts }} | {{ cell (join .Reasons ", ") }} |
+{{- end }}
+{{ else }}
+No crashing workloads.
+{{ end }}
+## Capacity trends
+{{ if .CapacityTrends }}
+| Signal | Resource | This period | Previous period | Change |
+|---|---|---|---|---|
+{{- range .CapacityTrends }}
+| {{ .Signal }} | {{ .Resource }} | {{ .Current }} | {{ .Previous }} | {{ signed .Change }} |
+{{- end }}
+{{ else }}
+No capacity-related alerts.
+{{ end }}
+_Generated {{ .GeneratedAt }}_
+`))
+
+var textSummaryTemplate = texttemplate.Must(texttemplate.New("text").Funcs(summaryFuncs).Parse(`{{ .Title }}
+{{ time .Start }} to {{ time .End }}
+{{- with .Cluster }} on {{ .Label }}{{ if .Team }}, owned by {{ .Team }}{{ end }}{{ end }}
+
+New alerts: {{ len .NewAlerts }}, open alerts: {{ .OpenAlerts }}, resolved incidents: {{ len .ResolvedIncidents }}, recurred remediations: {{ .RecurredRemediations }}
+{{- if .Clusters }}
+
+CLUSTERS
+{{- range .Clusters }}
+{{ clusterName .Cluster }}  {{ .NewAlerts }} new, {{ .OpenAlerts }} open, {{ .ResolvedIncidents }} resolved, {{ .Restarts }} restarts
+{{- end }}
+{{- end }}
+{{- if .Headline }}
+
+{{ .Headline }}
+{{- range .Highlights }}
+- {{ . }}
+{{- end }}
+{{- range .Risks }}
+! {{ . }}
+{{- end }}
+{{- range .Recommendations }}
+> {{ . }}
+{{- end }}
+{{- end }}
+{{- if .NewAlerts }}
+
+NEW ALERTS
+{{- range .NewAlerts }}
+{{ if $.Clusters }}{{ clusterName .Cluster }}: {{ end }}{{ .ID }}  {{ .Status }} {{ .Reason }}{{ if .SilencedBy }} (silenced){{ end }}
+{{- end }}
+{{- end }}
+{{- if .ResolvedIncidents }}
+
+RESOLVED INCIDENTS
+{{- range .ResolvedIncidents }}
+{{ if $.Clusters }}{{ clusterName .Cluster }}: {{ end }}{{ .ID }}  {{ .Remediation }} (effectiveness {{ effectiveness .Effectiveness }})
+{{- end }}
+{{- end }}
+{{- if .TopCrashingWorkloads }}
+
+TOP CRASHING WORKLOADS
+{{- range .TopCrashingWorkloads }}
+{{ if $.Clusters }}{{ clusterName .Cluster }}: {{ end }}{{ .Workload }}  {{ .Restarts }} restarts across {{ .Pods }} pods
+{{- end }}
+{{- end }}
+{{- if .CapacityTrends }}
+
+CAPACITY TRENDS
+{{- range .CapacityTrends }}
+{{ .Signal }} ({{ .Resource }})  {{ .Current }} vs {{ .Previous }} ({{ signed .Change }})
+{{- end }}
+{{- end }}
+
+Generated {{ .GeneratedAt }}
+`))
+
+// renderOpsSummary renders a summary in the given format
+func renderOpsSummary(summary OpsSummary, format string, now time.Time) (string, string, error) {
+	data := summaryData{
+		OpsSummary:  summary,
+		Title:       fmt.Sprintf("%s ops summary: %s", strings.ToUpper(summary.Period[:1])+summary.Period[1:], summary.End.UTC().Format(time.DateOnly)),
+		GeneratedAt: reportTime(now),
+	}
+	if narrative := summary.Narrative; narrative != nil {
+		if narrative.Validation.Valid {
+			data.Headline, _ = narrative.Data["summary"].(string)
+			data.Highlights = stringList(narrative.Data["highlights"])
+			data.Risks = stringList(narrative.Data["risks"])
+			data.Recommendations = stringList(narrative.Data["recommendations"])
+		} else {
+			data.Headline = narrative.Raw
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case ReportFormatMarkdown:
+		err = markdownSummaryTemplate.Execute(&buf, data)
+	case ReportFormatText:
+		err = textSummaryTemplate.Execute(&buf, data)
+	default:
+		return "", "", fmt.Errorf("unsupported summary format %q", format)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render summary: %w", err)
+	}
+	return data.Title, buf.String(), nil
+}
+
+// generateOpsSummary builds, renders and stores the summary of the period
+// ending at end, delivering it to the webhooks and MCP clients when deliver is
+// set. An empty cluster summarizes the alerts of every cluster.
+func (a *AlertTool) generateOpsSummary(ctx context.Context, period, cluster string, end time.Time, format string, narrative, deliver bool) (OpsSummary, IncidentReport, error) {
+	docs, err := a.store.List(ctx, "")
+	if err != nil {
+		return OpsSummary{}, IncidentReport{}, fmt.Errorf("failed to list alerts: %w", err)
+	}
+	summary := buildOpsSummary(filterCluster(docs, cluster), period, end)
+	switch {
+	case cluster != "":
+		summary.Cluster = a.clusterInfo(ctx, cluster)
+	case len(summary.Clusters) == 0:
+		summary.Cluster = a.currentCluster(ctx)
+	}
+	if narrative {
+		a.addNarrative(ctx, &summary)
+	}
+
+	now := time.Now()
+	title, content, err := renderOpsSummary(summary, format, now)
+	if err != nil {
+		return OpsSummary{}, IncidentReport{}, err
+	}
+	report := IncidentReport{
+		Title:     title,
+		Format:    format,
+		MIMEType:  reportMIMETypes[format],
+		Content:   content,
+		CreatedAt: now,
+	}
+	report.ID, err = a.store.SaveReport(ctx, report)
+	if err != nil {
+		return OpsSummary{}, IncidentReport{}, fmt.Errorf("failed to store summary: %w", err)
+	}
+
+	if deliver {
+		a.notifier.Load().NotifySummary(ctx, summary, report)
+		a.clients.SummaryGenerated(ctx, summary, report)
+	}
+	return summary, report, nil
+}
+
+// summaryPayload is the payload of a scheduled operations summary job
+type summaryPayload struct {
+	Period    string    `json:"period"`
+	End       time.Time `json:"end"`
+	Narrative bool      `json:"narrative"`
+}
+
+// WithSummarySchedule sets the schedule of operations summaries. Call
+// ScheduleSummaries to queue the first one.
+func (a *AlertTool) WithSummarySchedule(cfg SummaryConfig) *AlertTool {
+	a.summaries = cfg
+	return a
+}
+
+// ScheduleSummaries queues the next scheduled operations summary. Each summary
+// job queues the one after it, and the idempotency key of a run keeps restarts
+// from queueing it twice.
+func (a *AlertTool) ScheduleSummaries(ctx context.Context) error {
+	if a.summaries.Period == "" {
+		return nil
+	}
+	_, err := a.scheduleSummary(ctx, nextSummaryRun(a.summaries, time.Now()))
+	return err
+}
+
+// scheduleSummary queues the summary job of the run at the given time
+func (a *AlertTool) scheduleSummary(ctx context.Context, at time.Time) (Job, error) {
+	payload, err := json.Marshal(summaryPayload{Period: a.summaries.Period, End: at, Narrative: a.summaries.Narrative})
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to encode summary: %w", err)
+	}
+	return a.enqueueJob(ctx, Job{
+		Kind:           JobKindOpsSummary,
+		IdempotencyKey: fmt.Sprintf("%s/%s/%s", JobKindOpsSummary, a.summaries.Period, at.UTC().Format(time.RFC3339)),
+		Payload:        payload,
+		RunAfter:       at,
+	})
+}
+
+// runSummaryJob generates a scheduled summary after queueing the next run, so
+// a failing summary does not stop the schedule
+func (a *AlertTool) runSummaryJob(ctx context.Context, job Job) error {
+	var payload summaryPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid summary payload: %w", err)
+	}
+	if _, ok := summaryPeriods[payload.Period]; !ok {
+		return fmt.Errorf("invalid summary period %q", payload.Period)
+	}
+
+	if a.summaries.Period != "" {
+		if _, err := a.scheduleSummary(ctx, nextSummaryRun(a.summaries, payload.End)); err != nil {
+			return fmt.Errorf("failed to schedule the next summary: %w", err)
+		}
+	}
+
+	_, report, err := a.generateOpsSummary(ctx, payload.Period, "", payload.End, ReportFormatMarkdown, payload.Narrative, true)
+	if err != nil {
+		return err
+	}
+	logger.Get().Info("Generated scheduled ops summary", "report", report.ID, "period", payload.Period)
+	return nil
+}
+
+// handleGenerateOpsSummary generates an operations summary on demand
+func (a *AlertTool) handleGenerateOpsSummary(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	period := p.String("period", SummaryPeriodDaily, params.OneOf(SummaryPeriodDaily, SummaryPeriodWeekly))
+	format := p.String("format", ReportFormatMarkdown, params.OneOf(summaryFormats...))
+	cluster := p.String("cluster", "")
+	narrative := p.Bool("include_narrative", a.llmUnavailable(ctx) == nil)
+	deliver := p.Bool("deliver", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	summary, report, err := a.generateOpsSummary(ctx, period, cluster, time.Now(), format, narrative, deliver)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to generate ops summary: %v", err)), nil
+	}
+
+	summaryJSON, err := json.MarshalIndent(map[string]interface{}{
+		"id":        report.ID,
+		"uri":       reportResourceURI(report.ID),
+		"title":     report.Title,
+		"format":    report.Format,
+		"mime_type": report.MIMEType,
+		"delivered": deliver,
+		"summary":   summary,
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal ops summary: %v", err)), nil
+	}
+
+	return mcp.NewToolResultResource(string(summaryJSON), mcp.TextResourceContents{
+		URI:      reportResourceURI(report.ID),
+		MIMEType: report.MIMEType,
+		Text:     report.Content,
+	}), nil
+}
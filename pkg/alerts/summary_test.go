@@ -0,0 +1,304 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSummaryConfig(t *testing.T) {
+	t.Setenv(AlertSummarySchedule, "")
+	cfg, err := LoadSummaryConfig()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Period)
+
+	t.Setenv(AlertSummarySchedule, "Weekly")
+	t.Setenv(AlertSummaryTime, "06:30")
+	t.Setenv(AlertSummaryNarrative, "false")
+	cfg, err = LoadSummaryConfig()
+	require.NoError(t, err)
+	assert.Equal(t, SummaryConfig{Period: SummaryPeriodWeekly, At: 6*time.Hour + 30*time.Minute}, cfg)
+
+	t.Setenv(AlertSummaryTime, "6pm")
+	_, err = LoadSummaryConfig()
+	assert.ErrorContains(t, err, AlertSummaryTime)
+
+	t.Setenv(AlertSummarySchedule, "hourly")
+	_, err = LoadSummaryConfig()
+	assert.ErrorContains(t, err, AlertSummarySchedule)
+}
+
+func TestNextSummaryRun(t *testing.T) {
+	// 2026-10-14 is a Wednesday
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	daily := SummaryConfig{Period: SummaryPeriodDaily, At: 8 * time.Hour}
+	assert.Equal(t, time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC), nextSummaryRun(daily, now))
+	assert.Equal(t, time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC), nextSummaryRun(daily, now.Add(-2*time.Hour)))
+
+	weekly := SummaryConfig{Period: SummaryPeriodWeekly, At: 8 * time.Hour}
+	assert.Equal(t, time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC), nextSummaryRun(weekly, now))
+	// A run at the scheduled time is followed by the next week's
+	assert.Equal(t, time.Date(2026, 10, 26, 8, 0, 0, 0, time.UTC), nextSummaryRun(weekly, time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)))
+}
+
+// newSummaryTestDocuments returns alerts stored around the day ending at end
+func newSummaryTestDocuments(end time.Time) []AlertDocument {
+	verified := end.Add(-time.Hour)
+	score := 1.0
+	return []AlertDocument{
+		{
+			Alert:     PodAlert{PodName: "api-7d4b9c6f8d-x2x4z", Namespace: "shop", Status: "CrashLoopBackOff", Reason: "OOMKilled", RestartCount: 7},
+			CreatedAt: end.Add(-3 * time.Hour),
+			UpdatedAt: end.Add(-time.Hour),
+		},
+		{
+			Alert:     PodAlert{PodName: "api-7d4b9c6f8d-q8w7r", Namespace: "shop", Status: "CrashLoopBackOff", Reason: "Error", RestartCount: 3},
+			CreatedAt: end.Add(-2 * time.Hour),
+			UpdatedAt: end.Add(-2 * time.Hour),
+		},
+		{
+			Alert: PodAlert{PodName: "worker-0", Namespace: "jobs", Status: "Running", RestartCount: 12, State: AlertStateRemediated},
+			Remediations: []RemediationRecord{
+				{ID: "rem-1", Remediation: "Raised memory limit", Verification: VerificationResolved, VerifiedAt: &verified, Effectiveness: &score},
+			},
+			CreatedAt: end.Add(-30 * time.Hour),
+			UpdatedAt: end.Add(-time.Hour),
+		},
+		{
+			Alert:     PodAlert{PodName: "batch-1", Namespace: "jobs", Status: "Pending", Events: []PodEvent{{Type: "Warning", Reason: "FailedScheduling"}}},
+			CreatedAt: end.Add(-40 * time.Hour),
+			UpdatedAt: end.Add(-40 * time.Hour),
+		},
+		{
+			Alert:     PodAlert{PodName: "late", Namespace: "shop", Status: "Failed"},
+			CreatedAt: end.Add(time.Hour),
+			UpdatedAt: end.Add(time.Hour),
+		},
+	}
+}
+
+func TestBuildOpsSummary(t *testing.T) {
+	end := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	summary := buildOpsSummary(newSummaryTestDocuments(end), SummaryPeriodDaily, end)
+
+	assert.Equal(t, end.Add(-24*time.Hour), summary.Start)
+	require.Len(t, summary.NewAlerts, 2)
+	assert.Equal(t, "shop/api-7d4b9c6f8d-x2x4z", summary.NewAlerts[0].ID)
+	assert.Equal(t, 3, summary.OpenAlerts)
+	require.Len(t, summary.ResolvedIncidents, 1)
+	assert.Equal(t, "jobs/worker-0", summary.ResolvedIncidents[0].ID)
+
+	assert.Equal(t, []WorkloadCrashes{
+		{Workload: "jobs/pod/worker-0", Pods: 1, Restarts: 12, Reasons: nil},
+		{Workload: "shop/deployment/api", Pods: 2, Restarts: 10, Reasons: []string{"Error", "OOMKilled"}},
+	}, summary.TopCrashingWorkloads)
+	assert.Equal(t, []CapacityTrend{
+		{Signal: "FailedScheduling", Resource: "schedulable capacity", Current: 0, Previous: 1, Change: -1},
+		{Signal: "OOMKilled", Resource: "memory", Current: 1, Previous: 0, Change: 1},
+	}, summary.CapacityTrends)
+}
+
+func TestBuildOpsSummaryAcrossClusters(t *testing.T) {
+	end := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	docs := newSummaryTestDocuments(end)
+	for i := range docs[:2] {
+		docs[i].Alert.Cluster = &ClusterInfo{Name: "prod-eu"}
+	}
+	// The same deployment crashing in another cluster is a separate workload
+	docs = append(docs, AlertDocument{
+		Alert:     PodAlert{PodName: "api-7d4b9c6f8d-k2j4h", Namespace: "shop", Status: "CrashLoopBackOff", RestartCount: 2, Cluster: &ClusterInfo{Name: "prod-us"}},
+		CreatedAt: end.Add(-time.Hour),
+		UpdatedAt: end.Add(-time.Hour),
+	})
+
+	summary := buildOpsSummary(docs, SummaryPeriodDaily, end)
+	assert.Equal(t, []ClusterAlertCounts{
+		{Cluster: "", OpenAlerts: 1, ResolvedIncidents: 1, Restarts: 12},
+		{Cluster: "prod-eu", NewAlerts: 2, OpenAlerts: 2, Restarts: 10},
+		{Cluster: "prod-us", NewAlerts: 1, OpenAlerts: 1, Restarts: 2},
+	}, summary.Clusters)
+	assert.Equal(t, 4, summary.OpenAlerts)
+	require.Len(t, summary.TopCrashingWorkloads, 3)
+	assert.Equal(t, WorkloadCrashes{Workload: "shop/deployment/api", Cluster: "prod-us", Pods: 1, Restarts: 2}, summary.TopCrashingWorkloads[2])
+
+	_, content, err := renderOpsSummary(summary, ReportFormatMarkdown, end)
+	require.NoError(t, err)
+	assert.Contains(t, content, "| unknown | 0 | 1 | 1 | 0 | 12 |")
+	assert.Contains(t, content, "| prod-eu: shop/deployment/api | 2 | 10 |")
+	assert.Contains(t, content, "| unknown: jobs/worker-0 | Raised memory limit |")
+
+	// Alerts of a single cluster are not broken down
+	assert.Empty(t, buildOpsSummary(filterCluster(docs, "prod-eu"), SummaryPeriodDaily, end).Clusters)
+}
+
+func TestRenderOpsSummary(t *testing.T) {
+	end := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	summary := buildOpsSummary(newSummaryTestDocuments(end), SummaryPeriodDaily, end)
+	summary.Narrative = &AnalysisResult{
+		Type:       AnalysisTypeOpsSummary,
+		Data:       map[string]interface{}{"summary": "The api deployment regressed.", "highlights": []interface{}{"worker-0 recovered"}, "risks": []interface{}{"api is OOMKilled"}},
+		Validation: AnalysisValidation{Valid: true, Attempts: 1},
+	}
+
+	title, content, err := renderOpsSummary(summary, ReportFormatMarkdown, end)
+	require.NoError(t, err)
+	assert.Equal(t, "Daily ops summary: 2026-10-15", title)
+	assert.Contains(t, content, "# Daily ops summary: 2026-10-15")
+	assert.Contains(t, content, "The api deployment regressed.\n- worker-0 recovered")
+	assert.Contains(t, content, "- api is OOMKilled")
+	assert.Contains(t, content, "| shop/deployment/api | 2 | 10 | Error, OOMKilled |")
+	assert.Contains(t, content, "| OOMKilled | memory | 1 | 0 | +1 |")
+	assert.Contains(t, content, "| jobs/worker-0 | Raised memory limit | 2026-10-15T07:00:00Z | 100% |")
+
+	_, content, err = renderOpsSummary(OpsSummary{Period: SummaryPeriodWeekly, End: end}, ReportFormatText, end)
+	require.NoError(t, err)
+	assert.Contains(t, content, "Weekly ops summary: 2026-10-15")
+	assert.Contains(t, content, "New alerts: 0, open alerts: 0")
+
+	_, _, err = renderOpsSummary(summary, ReportFormatSlack, end)
+	assert.Error(t, err)
+}
+
+func TestHandleGenerateOpsSummary(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]interface{}
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		received <- payload
+	}))
+	defer srv.Close()
+	notifier, err := NewWebhookNotifier(WebhookConfig{URLs: []string{srv.URL}, Timeout: time.Second})
+	require.NoError(t, err)
+
+	model := &scriptedModel{responses: []string{`{"summary": "One new crash loop.", "highlights": ["web-1 is crash looping"]}`}}
+	sender := &recordingSender{}
+	tool := NewAlertTool(model).WithNotifier(notifier).WithClientNotifier(&ClientNotifier{sender: sender})
+	ctx := context.Background()
+	require.NoError(t, tool.store.Upsert(ctx, PodAlert{PodName: "web-1", Namespace: "prod", Status: "CrashLoopBackOff", RestartCount: 4}))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"deliver": "true"}
+	result, err := tool.handleGenerateOpsSummary(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	notifier.Wait()
+
+	var response struct {
+		ID      string     `json:"id"`
+		URI     string     `json:"uri"`
+		Summary OpsSummary `json:"summary"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &response))
+	assert.Equal(t, reportResourceURI(response.ID), response.URI)
+	assert.Len(t, response.Summary.NewAlerts, 1)
+	require.NotNil(t, response.Summary.Narrative)
+	assert.True(t, response.Summary.Narrative.Validation.Valid)
+
+	resource := result.Content[1].(mcp.EmbeddedResource).Resource.(mcp.TextResourceContents)
+	assert.Contains(t, resource.Text, "One new crash loop.")
+	stored, err := tool.store.GetReport(ctx, response.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, resource.Text, stored.Content)
+
+	payload := <-received
+	assert.Equal(t, AlertEventOpsSummary, payload["event"])
+	assert.Equal(t, response.URI, payload["uri"])
+	events := sender.events()
+	require.Len(t, events, 1)
+	assert.Equal(t, AlertEventOpsSummary, events[0]["event"])
+	assert.Equal(t, 1, events[0]["new_alerts"])
+}
+
+func TestHandleGenerateOpsSummaryWithoutLLM(t *testing.T) {
+	tool := NewAlertTool(nil)
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"period": "weekly", "include_narrative": "true", "format": "text"}
+	result, err := tool.handleGenerateOpsSummary(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	var response struct {
+		Summary OpsSummary `json:"summary"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &response))
+	assert.Equal(t, SummaryPeriodWeekly, response.Summary.Period)
+	assert.Nil(t, response.Summary.Narrative)
+	assert.Equal(t, "no LLM is configured", response.Summary.NarrativeError)
+}
+
+func TestHandleGenerateOpsSummaryForCluster(t *testing.T) {
+	tool := NewAlertTool(nil)
+	ctx := context.Background()
+	_, err := tool.store.SaveCluster(ctx, ClusterInfo{Name: "prod-eu", Environment: "prod"})
+	require.NoError(t, err)
+	require.NoError(t, tool.store.UpsertMany(ctx, []PodAlert{
+		{PodName: "web-1", Namespace: "prod", Status: "CrashLoopBackOff", Cluster: &ClusterInfo{Name: "prod-eu"}},
+		{PodName: "web-2", Namespace: "prod", Status: "CrashLoopBackOff", Cluster: &ClusterInfo{Name: "prod-us"}},
+	}))
+
+	var response struct {
+		Summary OpsSummary `json:"summary"`
+	}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"cluster": "prod-eu"}
+	result, err := tool.handleGenerateOpsSummary(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &response))
+	require.Len(t, response.Summary.NewAlerts, 1)
+	assert.Equal(t, "prod/web-1", response.Summary.NewAlerts[0].ID)
+	assert.Equal(t, &ClusterInfo{Name: "prod-eu", Environment: "prod"}, response.Summary.Cluster)
+	assert.Empty(t, response.Summary.Clusters)
+
+	request.Params.Arguments = map[string]interface{}{}
+	result, err = tool.handleGenerateOpsSummary(ctx, request)
+	require.NoError(t, err)
+	response.Summary = OpsSummary{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &response))
+	assert.Len(t, response.Summary.NewAlerts, 2)
+	assert.Nil(t, response.Summary.Cluster, "a fleet summary covers no single cluster")
+	assert.Len(t, response.Summary.Clusters, 2)
+}
+
+func TestSummaryJobQueuesNextRun(t *testing.T) {
+	tool := NewAlertTool(nil).WithSummarySchedule(SummaryConfig{Period: SummaryPeriodDaily, At: 8 * time.Hour})
+	ctx := context.Background()
+
+	require.NoError(t, tool.ScheduleSummaries(ctx))
+	// Scheduling again, as after a restart, does not queue a second summary
+	require.NoError(t, tool.ScheduleSummaries(ctx))
+	jobs, err := tool.store.ListJobs(ctx, JobStatusQueued)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, JobKindOpsSummary, jobs[0].Kind)
+
+	end := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	payload, err := json.Marshal(summaryPayload{Period: SummaryPeriodDaily, End: end})
+	require.NoError(t, err)
+	require.NoError(t, tool.runSummaryJob(ctx, Job{Kind: JobKindOpsSummary, Payload: payload}))
+
+	jobs, err = tool.store.ListJobs(ctx, JobStatusQueued)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, end.Add(24*time.Hour), jobs[1].RunAfter)
+	report, err := tool.store.GetReport(ctx, "report-1")
+	require.NoError(t, err)
+	require.NotNil(t, report)
+	assert.Equal(t, "Daily ops summary: 2026-01-05", report.Title)
+
+	for _, job := range jobs {
+		_, err := tool.cancelJob(ctx, job.ID)
+		require.NoError(t, err)
+	}
+	tool.waitJobs()
+}
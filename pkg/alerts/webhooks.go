@@ -0,0 +1,300 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// AlertState represents a stage in the alert lifecycle
+type AlertState string
+
+const (
+	AlertStateCollected  AlertState = "Collected"
+	AlertStateAnalyzed   AlertState = "Analyzed"
+	AlertStateRemediated AlertState = "Remediated"
+)
+
+// Environment variables used to configure outbound alert webhooks
+const (
+	AlertWebhookURLs       = "ALERT_WEBHOOK_URLS"
+	AlertWebhookTemplate   = "ALERT_WEBHOOK_TEMPLATE"
+	AlertWebhookMaxRetries = "ALERT_WEBHOOK_MAX_RETRIES"
+	AlertWebhookTimeout    = "ALERT_WEBHOOK_TIMEOUT"
+)
+
+// defaultWebhookTemplate renders the payload sent when no custom template is configured
+const defaultWebhookTemplate = `{
+  "event": "alert.state_changed",
+  "from": {{ json .From }},
+  "to": {{ json .To }},
+  "timestamp": {{ json .Timestamp }},
+  "alert": {
+    "pod_name": {{ json .Alert.PodName }},
+    "namespace": {{ json .Alert.Namespace }},
+    "status": {{ json .Alert.Status }},
+    "reason": {{ json .Alert.Reason }},
+    "message": {{ json .Alert.Message }},
+    "restart_count": {{ .Alert.RestartCount }},
+    "analysis": {{ json .Alert.Analysis }},
+    "remediation": {{ json .Alert.Remediation }}
+  },
+  "cluster": {{ json .Alert.Cluster }}
+}`
+
+// WebhookEvent is the data available to webhook payload templates
+type WebhookEvent struct {
+	From      AlertState
+	To        AlertState
+	Timestamp string
+	Alert     PodAlert
+}
+
+// WebhookConfig holds outbound webhook configuration
+type WebhookConfig struct {
+	URLs       []string
+	Template   string
+	MaxRetries int
+	Timeout    time.Duration
+}
+
+// LoadWebhookConfig reads the webhook configuration from the environment
+func LoadWebhookConfig() WebhookConfig {
+	cfg := WebhookConfig{
+		Template:   os.Getenv(AlertWebhookTemplate),
+		MaxRetries: 3,
+		Timeout:    10 * time.Second,
+	}
+
+	for _, url := range strings.Split(os.Getenv(AlertWebhookURLs), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			cfg.URLs = append(cfg.URLs, url)
+		}
+	}
+
+	if value, err := strconv.Atoi(os.Getenv(AlertWebhookMaxRetries)); err == nil && value >= 0 {
+		cfg.MaxRetries = value
+	}
+
+	if value, err := time.ParseDuration(os.Getenv(AlertWebhookTimeout)); err == nil && value > 0 {
+		cfg.Timeout = value
+	}
+
+	return cfg
+}
+
+// WebhookNotifier posts alert state transitions to the configured webhooks
+type WebhookNotifier struct {
+	urls       []string
+	template   *template.Template
+	maxRetries int
+	backoff    time.Duration
+	client     *http.Client
+	wg         sync.WaitGroup
+}
+
+// NewWebhookNotifier creates a notifier from the given configuration. It
+// returns nil if no webhook URLs are configured.
+func NewWebhookNotifier(cfg WebhookConfig) (*WebhookNotifier, error) {
+	if len(cfg.URLs) == 0 {
+		return nil, nil
+	}
+
+	for _, webhookURL := range cfg.URLs {
+		if err := security.ValidateURL(webhookURL); err != nil {
+			return nil, fmt.Errorf("invalid webhook URL %s: %w", redactWebhookURL(webhookURL), err)
+		}
+	}
+
+	text := cfg.Template
+	if text == "" {
+		text = defaultWebhookTemplate
+	}
+
+	tmpl, err := template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %w", err)
+	}
+
+	return &WebhookNotifier{
+		urls:       cfg.URLs,
+		template:   tmpl,
+		maxRetries: cfg.MaxRetries,
+		backoff:    time.Second,
+		client:     &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// Notify renders the payload for a state transition and delivers it to every
+// webhook in the background. Delivery failures are logged, never returned.
+func (n *WebhookNotifier) Notify(ctx context.Context, alert PodAlert, from, to AlertState) {
+	if n == nil {
+		return
+	}
+
+	payload, err := n.render(WebhookEvent{
+		From:      from,
+		To:        to,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Alert:     alert,
+	})
+	if err != nil {
+		logger.Get().Error("Failed to render alert webhook payload", "error", err, "pod", alert.PodName, "namespace", alert.Namespace)
+		return
+	}
+
+	n.send(ctx, payload, "to", string(to))
+}
+
+// NotifySummary delivers a generated operations summary to every webhook in
+// the background. The payload is fixed JSON; the alert template does not apply.
+func (n *WebhookNotifier) NotifySummary(ctx context.Context, summary OpsSummary, report IncidentReport) {
+	if n == nil {
+		return
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":     AlertEventOpsSummary,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"report_id": report.ID,
+		"uri":       reportResourceURI(report.ID),
+		"title":     report.Title,
+		"content":   report.Content,
+		"summary":   summary,
+	})
+	if err != nil {
+		logger.Get().Error("Failed to render ops summary webhook payload", "error", err, "report", report.ID)
+		return
+	}
+	n.send(ctx, payload, "report", report.ID)
+}
+
+// NotifyMonitor delivers the outcome of a finished pod monitor to every
+// webhook in the background. Like summaries, the payload is fixed JSON.
+func (n *WebhookNotifier) NotifyMonitor(ctx context.Context, monitor PodMonitor) {
+	if n == nil {
+		return
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":     AlertEventMonitorFinished,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"monitor":   monitor,
+	})
+	if err != nil {
+		logger.Get().Error("Failed to render pod monitor webhook payload", "error", err, "monitor", monitor.ID)
+		return
+	}
+	n.send(ctx, payload, "monitor", monitor.ID)
+}
+
+// send delivers a payload to every webhook in the background, logging failures
+// with the given attributes
+func (n *WebhookNotifier) send(ctx context.Context, payload []byte, attrs ...any) {
+	// Deliveries outlive the tool call that triggered them
+	ctx = context.WithoutCancel(ctx)
+	for _, webhookURL := range n.urls {
+		n.wg.Add(1)
+		go func(webhookURL string) {
+			defer n.wg.Done()
+			if err := n.deliver(ctx, webhookURL, payload); err != nil {
+				logger.Get().Error("Failed to deliver alert webhook", append([]any{"webhook", redactWebhookURL(webhookURL), "error", err}, attrs...)...)
+			}
+		}(webhookURL)
+	}
+}
+
+// redactWebhookURL returns the scheme and host of a webhook URL. Webhook
+// paths and query strings often embed credentials, so they are never logged.
+func redactWebhookURL(webhookURL string) string {
+	u, err := url.Parse(webhookURL)
+	if err != nil || u.Host == "" {
+		return "<invalid URL>"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// Wait blocks until all in-flight deliveries have finished
+func (n *WebhookNotifier) Wait() {
+	if n == nil {
+		return
+	}
+	n.wg.Wait()
+}
+
+// render executes the payload template and checks the result is valid JSON
+func (n *WebhookNotifier) render(event WebhookEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := n.template.Execute(&buf, event); err != nil {
+		return nil, err
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("webhook template did not produce valid JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+// deliver posts the payload, retrying with exponential backoff on failure
+func (n *WebhookNotifier) deliver(ctx context.Context, webhookURL string, payload []byte) error {
+	var lastErr error
+	backoff := n.backoff
+
+	for attempt := 0; attempt <= n.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff *= 2
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("invalid webhook URL %s", redactWebhookURL(webhookURL))
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := n.client.Do(req)
+		if err != nil {
+			// Transport errors quote the full URL
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = fmt.Errorf("%s %s: %w", urlErr.Op, redactWebhookURL(webhookURL), urlErr.Err)
+			}
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+
+		// Client errors other than throttling will not succeed on retry
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return lastErr
+		}
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", n.maxRetries+1, lastErr)
+}
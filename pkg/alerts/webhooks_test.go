@@ -0,0 +1,197 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWebhookConfig(t *testing.T) {
+	t.Setenv(AlertWebhookURLs, "http://a.example.com/hook, ,https://b.example.com/hook")
+	t.Setenv(AlertWebhookMaxRetries, "5")
+	t.Setenv(AlertWebhookTimeout, "2s")
+
+	cfg := LoadWebhookConfig()
+	assert.Equal(t, []string{"http://a.example.com/hook", "https://b.example.com/hook"}, cfg.URLs)
+	assert.Equal(t, 5, cfg.MaxRetries)
+	assert.Equal(t, 2*time.Second, cfg.Timeout)
+}
+
+func TestNewWebhookNotifier(t *testing.T) {
+	t.Run("no urls disables notifier", func(t *testing.T) {
+		notifier, err := NewWebhookNotifier(WebhookConfig{})
+		require.NoError(t, err)
+		assert.Nil(t, notifier)
+
+		// A nil notifier is safe to use
+		notifier.Notify(context.Background(), PodAlert{}, "", AlertStateCollected)
+		notifier.Wait()
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		_, err := NewWebhookNotifier(WebhookConfig{URLs: []string{"ftp://example.com"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := NewWebhookNotifier(WebhookConfig{URLs: []string{"http://example.com"}, Template: "{{ .Missing"})
+		assert.Error(t, err)
+	})
+}
+
+func TestWebhookNotifierDelivers(t *testing.T) {
+	var mu sync.Mutex
+	var payloads []map[string]interface{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &payload))
+
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	notifier, err := NewWebhookNotifier(WebhookConfig{URLs: []string{srv.URL}, Timeout: time.Second})
+	require.NoError(t, err)
+
+	notifier.Notify(context.Background(), PodAlert{PodName: "web-1", Namespace: "default", Message: `quote " inside`}, AlertStateCollected, AlertStateAnalyzed)
+	notifier.Wait()
+
+	require.Len(t, payloads, 1)
+	assert.Equal(t, "alert.state_changed", payloads[0]["event"])
+	assert.Equal(t, "Collected", payloads[0]["from"])
+	assert.Equal(t, "Analyzed", payloads[0]["to"])
+	alert := payloads[0]["alert"].(map[string]interface{})
+	assert.Equal(t, "web-1", alert["pod_name"])
+	assert.Equal(t, `quote " inside`, alert["message"])
+}
+
+func TestWebhookNotifierCustomTemplate(t *testing.T) {
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	defer srv.Close()
+
+	notifier, err := NewWebhookNotifier(WebhookConfig{
+		URLs:     []string{srv.URL},
+		Template: `{"text": {{ json (printf "%s/%s is %s" .Alert.Namespace .Alert.PodName .To) }}}`,
+	})
+	require.NoError(t, err)
+
+	notifier.Notify(context.Background(), PodAlert{PodName: "web-1", Namespace: "default"}, AlertStateAnalyzed, AlertStateRemediated)
+	notifier.Wait()
+
+	assert.JSONEq(t, `{"text": "default/web-1 is Remediated"}`, <-received)
+}
+
+func TestWebhookNotifierRetries(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	notifier, err := NewWebhookNotifier(WebhookConfig{URLs: []string{srv.URL}, MaxRetries: 3})
+	require.NoError(t, err)
+	notifier.backoff = time.Millisecond
+
+	require.NoError(t, notifier.deliver(context.Background(), srv.URL, []byte(`{}`)))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		var calls int32
+		badRequest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer badRequest.Close()
+
+		assert.Error(t, notifier.deliver(context.Background(), badRequest.URL, []byte(`{}`)))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestWebhookURLsAreRedacted(t *testing.T) {
+	assert.Equal(t, "https://hooks.slack.com", redactWebhookURL("https://hooks.slack.com/services/T000/B000/secret?token=secret"))
+	assert.Equal(t, "<invalid URL>", redactWebhookURL("://secret"))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close()
+	notifier, err := NewWebhookNotifier(WebhookConfig{URLs: []string{srv.URL}})
+	require.NoError(t, err)
+	notifier.backoff = time.Millisecond
+
+	// Transport errors name the host but not the path
+	err = notifier.deliver(context.Background(), srv.URL+"/services/secret", []byte(`{}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), srv.URL)
+	assert.NotContains(t, err.Error(), "secret")
+
+	_, err = NewWebhookNotifier(WebhookConfig{URLs: []string{"ftp://example.com/secret"}})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func TestHandleMarkRemediated(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer srv.Close()
+
+	notifier, err := NewWebhookNotifier(WebhookConfig{URLs: []string{srv.URL}})
+	require.NoError(t, err)
+	tool := NewAlertTool(nil).WithNotifier(notifier)
+
+	t.Run("missing pod name", func(t *testing.T) {
+		result, err := tool.handleMarkRemediated(context.Background(), mcp.CallToolRequest{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+
+	mock := cmd.NewMockShellExecutor()
+	mock.AddPartialMatcherString("kubectl", []string{"get", "pod", "web-1"}, `{"status":{"phase":"Running","containerStatuses":[{"ready":true,"restartCount":0}]}}`, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"pod_name":     "web-1",
+		"namespace":    "prod",
+		"remediation":  "increased memory limit",
+		"verify_after": "0s",
+	}
+
+	result, err := tool.handleMarkRemediated(ctx, request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	notifier.Wait()
+	tool.waitJobs()
+
+	payload := <-received
+	assert.Equal(t, "Analyzed", payload["from"])
+	assert.Equal(t, "Remediated", payload["to"])
+	assert.Equal(t, "increased memory limit", payload["alert"].(map[string]interface{})["remediation"])
+}
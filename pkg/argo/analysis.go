@@ -0,0 +1,466 @@
+package argo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// defaultAnalysisRunLimit is the number of AnalysisRuns explained when no limit is given
+const defaultAnalysisRunLimit = 3
+
+// maxReportedMeasurements is the number of most recent measurements reported per metric
+const maxReportedMeasurements = 5
+
+// defaultConsecutiveErrorLimit is the consecutiveErrorLimit of a metric that does not set it
+const defaultConsecutiveErrorLimit = 4
+
+// Phases of AnalysisRuns, metrics and measurements
+const (
+	analysisSuccessful   = "Successful"
+	analysisFailed       = "Failed"
+	analysisError        = "Error"
+	analysisInconclusive = "Inconclusive"
+	analysisRunning      = "Running"
+)
+
+// analysisLimit is a metric limit or count, which Argo Rollouts accepts as a number or a string
+type analysisLimit struct {
+	value int
+	set   bool
+}
+
+// UnmarshalJSON accepts 2 and "2"; unparsable strings such as arguments are treated as unset
+func (l *analysisLimit) UnmarshalJSON(data []byte) error {
+	value, err := strconv.Atoi(strings.Trim(string(data), `"`))
+	if err == nil {
+		l.value, l.set = value, true
+	}
+	return nil
+}
+
+// or returns the limit, or fallback when it is not set
+func (l analysisLimit) or(fallback int) int {
+	if l.set {
+		return l.value
+	}
+	return fallback
+}
+
+// analysisMetric is the subset of an AnalysisRun metric spec used for explanations
+type analysisMetric struct {
+	Name                  string                     `json:"name"`
+	Interval              string                     `json:"interval"`
+	Count                 analysisLimit              `json:"count"`
+	FailureLimit          analysisLimit              `json:"failureLimit"`
+	InconclusiveLimit     analysisLimit              `json:"inconclusiveLimit"`
+	ConsecutiveErrorLimit analysisLimit              `json:"consecutiveErrorLimit"`
+	SuccessCondition      string                     `json:"successCondition"`
+	FailureCondition      string                     `json:"failureCondition"`
+	Provider              map[string]json.RawMessage `json:"provider"`
+}
+
+// analysisMeasurement is a single measurement of a metric
+type analysisMeasurement struct {
+	Phase      string `json:"phase"`
+	Value      string `json:"value"`
+	Message    string `json:"message"`
+	FinishedAt string `json:"finishedAt"`
+}
+
+// analysisMetricResult is the status of a metric of an AnalysisRun
+type analysisMetricResult struct {
+	Name             string                `json:"name"`
+	Phase            string                `json:"phase"`
+	Message          string                `json:"message"`
+	Count            int                   `json:"count"`
+	Successful       int                   `json:"successful"`
+	Failed           int                   `json:"failed"`
+	Inconclusive     int                   `json:"inconclusive"`
+	Error            int                   `json:"error"`
+	ConsecutiveError int                   `json:"consecutiveError"`
+	Measurements     []analysisMeasurement `json:"measurements"`
+}
+
+// analysisRunList is the subset of `kubectl get analysisruns -o json` output used for explanations
+type analysisRunList struct {
+	Items []struct {
+		Metadata struct {
+			Name              string            `json:"name"`
+			CreationTimestamp string            `json:"creationTimestamp"`
+			Labels            map[string]string `json:"labels"`
+			OwnerReferences   []struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"ownerReferences"`
+		} `json:"metadata"`
+		Spec struct {
+			Metrics []analysisMetric `json:"metrics"`
+			Args    []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"args"`
+		} `json:"spec"`
+		Status struct {
+			Phase         string                 `json:"phase"`
+			Message       string                 `json:"message"`
+			StartedAt     string                 `json:"startedAt"`
+			MetricResults []analysisMetricResult `json:"metricResults"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// analysisRunRef names the AnalysisRun of a rollout step or background analysis
+type analysisRunRef struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// argoRollout is the subset of `kubectl get rollout -o json` output used for explanations
+type argoRollout struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Status struct {
+		Phase            string `json:"phase"`
+		Message          string `json:"message"`
+		Abort            bool   `json:"abort"`
+		AbortedAt        string `json:"abortedAt"`
+		CurrentStepIndex *int   `json:"currentStepIndex"`
+		Canary           struct {
+			CurrentStepAnalysisRunStatus       *analysisRunRef `json:"currentStepAnalysisRunStatus"`
+			CurrentBackgroundAnalysisRunStatus *analysisRunRef `json:"currentBackgroundAnalysisRunStatus"`
+		} `json:"canary"`
+		BlueGreen struct {
+			PrePromotionAnalysisRunStatus  *analysisRunRef `json:"prePromotionAnalysisRunStatus"`
+			PostPromotionAnalysisRunStatus *analysisRunRef `json:"postPromotionAnalysisRunStatus"`
+		} `json:"blueGreen"`
+	} `json:"status"`
+}
+
+// MeasurementValue is a measurement of a metric
+type MeasurementValue struct {
+	Phase      string `json:"phase"`
+	Value      string `json:"value,omitempty"`
+	Message    string `json:"message,omitempty"`
+	FinishedAt string `json:"finished_at,omitempty"`
+}
+
+// MetricExplanation interprets the measurements of a metric against its conditions and limits
+type MetricExplanation struct {
+	Name                  string             `json:"name"`
+	Phase                 string             `json:"phase"`
+	Provider              string             `json:"provider"`
+	Address               string             `json:"address,omitempty"`
+	Query                 string             `json:"query,omitempty"`
+	SuccessCondition      string             `json:"success_condition,omitempty"`
+	FailureCondition      string             `json:"failure_condition,omitempty"`
+	Measurements          int                `json:"measurements"`
+	Successful            int                `json:"successful"`
+	Failed                int                `json:"failed"`
+	Inconclusive          int                `json:"inconclusive"`
+	Errors                int                `json:"errors"`
+	FailureLimit          int                `json:"failure_limit"`
+	InconclusiveLimit     int                `json:"inconclusive_limit"`
+	ConsecutiveErrorLimit int                `json:"consecutive_error_limit"`
+	RecentMeasurements    []MeasurementValue `json:"recent_measurements,omitempty"`
+	Explanation           string             `json:"explanation"`
+}
+
+// AnalysisRunExplanation describes an AnalysisRun of a Rollout and why it ended as it did
+type AnalysisRunExplanation struct {
+	Name           string              `json:"name"`
+	Phase          string              `json:"phase"`
+	Message        string              `json:"message,omitempty"`
+	Type           string              `json:"type,omitempty"`
+	StepIndex      string              `json:"step_index,omitempty"`
+	StartedAt      string              `json:"started_at,omitempty"`
+	Args           map[string]string   `json:"args,omitempty"`
+	FailingMetrics []string            `json:"failing_metrics,omitempty"`
+	Metrics        []MetricExplanation `json:"metrics"`
+}
+
+// AnalysisReport is the structured response of argo_explain_analysis_runs
+type AnalysisReport struct {
+	Rollout          string                   `json:"rollout"`
+	Namespace        string                   `json:"namespace"`
+	Phase            string                   `json:"phase"`
+	Aborted          bool                     `json:"aborted"`
+	Message          string                   `json:"message,omitempty"`
+	CurrentStepIndex *int                     `json:"current_step_index,omitempty"`
+	CurrentRuns      []string                 `json:"current_runs,omitempty"`
+	Cause            string                   `json:"cause"`
+	AnalysisRuns     []AnalysisRunExplanation `json:"analysis_runs"`
+}
+
+// metricProvider returns the provider of a metric and, for Prometheus, its address and query
+func metricProvider(metric analysisMetric) (string, string, string) {
+	names := make([]string, 0, len(metric.Provider))
+	for name := range metric.Provider {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return "", "", ""
+	}
+	var prometheus struct {
+		Address string `json:"address"`
+		Query   string `json:"query"`
+	}
+	if raw, ok := metric.Provider["prometheus"]; ok {
+		_ = json.Unmarshal(raw, &prometheus)
+		return "prometheus", prometheus.Address, strings.TrimSpace(prometheus.Query)
+	}
+	return names[0], "", ""
+}
+
+// lastMeasurement returns the most recent measurement in the given phase
+func lastMeasurement(measurements []analysisMeasurement, phase string) *analysisMeasurement {
+	for i := len(measurements) - 1; i >= 0; i-- {
+		if measurements[i].Phase == phase {
+			return &measurements[i]
+		}
+	}
+	return nil
+}
+
+// measurementErrorHint explains common metric provider errors
+func measurementErrorHint(message string) string {
+	switch {
+	case strings.Contains(message, "index out of range") || strings.Contains(message, "no results"):
+		return "the query returned no data, check its metric name and label selectors"
+	case strings.Contains(message, "connection refused") || strings.Contains(message, "no such host") ||
+		strings.Contains(message, "i/o timeout") || strings.Contains(message, "context deadline exceeded"):
+		return "the metric provider is unreachable from the Argo Rollouts controller"
+	case strings.Contains(message, "bad_data") || strings.Contains(message, "parse error"):
+		return "the query is not valid PromQL"
+	case strings.Contains(message, "unsupported") || strings.Contains(message, "could not evaluate"):
+		return "the condition could not be evaluated against the query result"
+	default:
+		return ""
+	}
+}
+
+// explainMetric interprets a metric result against its conditions and limits, the
+// same way the Argo Rollouts controller assesses it
+func explainMetric(metric analysisMetric, result analysisMetricResult) MetricExplanation {
+	explanation := MetricExplanation{
+		Name:                  result.Name,
+		Phase:                 result.Phase,
+		SuccessCondition:      metric.SuccessCondition,
+		FailureCondition:      metric.FailureCondition,
+		Measurements:          result.Count,
+		Successful:            result.Successful,
+		Failed:                result.Failed,
+		Inconclusive:          result.Inconclusive,
+		Errors:                result.Error,
+		FailureLimit:          metric.FailureLimit.or(0),
+		InconclusiveLimit:     metric.InconclusiveLimit.or(0),
+		ConsecutiveErrorLimit: metric.ConsecutiveErrorLimit.or(defaultConsecutiveErrorLimit),
+	}
+	explanation.Provider, explanation.Address, explanation.Query = metricProvider(metric)
+	for _, measurement := range result.Measurements[max(len(result.Measurements)-maxReportedMeasurements, 0):] {
+		explanation.RecentMeasurements = append(explanation.RecentMeasurements, MeasurementValue{
+			Phase:      measurement.Phase,
+			Value:      measurement.Value,
+			Message:    measurement.Message,
+			FinishedAt: measurement.FinishedAt,
+		})
+	}
+
+	switch result.Phase {
+	case analysisFailed:
+		explanation.Explanation = fmt.Sprintf("%d of %d measurements failed, exceeding the failure limit of %d",
+			result.Failed, result.Count, explanation.FailureLimit)
+		if failed := lastMeasurement(result.Measurements, analysisFailed); failed != nil {
+			if metric.FailureCondition != "" {
+				explanation.Explanation += fmt.Sprintf("; the latest failing value %s met the failure condition %s", failed.Value, metric.FailureCondition)
+			} else {
+				explanation.Explanation += fmt.Sprintf("; the latest failing value %s did not meet the success condition %s", failed.Value, metric.SuccessCondition)
+			}
+		}
+	case analysisError:
+		explanation.Explanation = fmt.Sprintf("%d consecutive measurement errors exceeded the limit of %d",
+			result.ConsecutiveError, explanation.ConsecutiveErrorLimit)
+		message := result.Message
+		if errored := lastMeasurement(result.Measurements, analysisError); errored != nil && errored.Message != "" {
+			message = errored.Message
+		}
+		if message != "" {
+			explanation.Explanation += ": " + message
+		}
+		if hint := measurementErrorHint(message); hint != "" {
+			explanation.Explanation += " (" + hint + ")"
+		}
+	case analysisInconclusive:
+		explanation.Explanation = fmt.Sprintf("%d inconclusive measurements exceeded the limit of %d: values met neither the success nor the failure condition",
+			result.Inconclusive, explanation.InconclusiveLimit)
+	case analysisSuccessful:
+		explanation.Explanation = fmt.Sprintf("%d of %d measurements succeeded", result.Successful, result.Count)
+	default:
+		explanation.Explanation = fmt.Sprintf("%d measurements taken so far, %d failed", result.Count, result.Failed)
+		if count := metric.Count.or(0); count > 0 {
+			explanation.Explanation = fmt.Sprintf("%d of %d measurements taken so far, %d failed", result.Count, count, result.Failed)
+		}
+	}
+	return explanation
+}
+
+// buildAnalysisReport explains the AnalysisRuns owned by a Rollout, newest first,
+// and why the Rollout's canary was aborted
+func buildAnalysisReport(rolloutOutput, runsOutput, runName string, limit int) (*AnalysisReport, error) {
+	var rollout argoRollout
+	if err := json.Unmarshal([]byte(rolloutOutput), &rollout); err != nil {
+		return nil, fmt.Errorf("failed to parse rollout: %w", err)
+	}
+	var runs analysisRunList
+	if err := json.Unmarshal([]byte(runsOutput), &runs); err != nil {
+		return nil, fmt.Errorf("failed to parse analysis runs: %w", err)
+	}
+
+	report := &AnalysisReport{
+		Rollout:          rollout.Metadata.Name,
+		Namespace:        rollout.Metadata.Namespace,
+		Phase:            rollout.Status.Phase,
+		Aborted:          rollout.Status.Abort,
+		Message:          rollout.Status.Message,
+		CurrentStepIndex: rollout.Status.CurrentStepIndex,
+		AnalysisRuns:     []AnalysisRunExplanation{},
+	}
+	for _, ref := range []*analysisRunRef{
+		rollout.Status.Canary.CurrentStepAnalysisRunStatus,
+		rollout.Status.Canary.CurrentBackgroundAnalysisRunStatus,
+		rollout.Status.BlueGreen.PrePromotionAnalysisRunStatus,
+		rollout.Status.BlueGreen.PostPromotionAnalysisRunStatus,
+	} {
+		if ref != nil && ref.Name != "" {
+			report.CurrentRuns = append(report.CurrentRuns, ref.Name)
+		}
+	}
+
+	sort.SliceStable(runs.Items, func(i, j int) bool {
+		return runs.Items[i].Metadata.CreationTimestamp > runs.Items[j].Metadata.CreationTimestamp
+	})
+	for _, run := range runs.Items {
+		owned := false
+		for _, owner := range run.Metadata.OwnerReferences {
+			owned = owned || (owner.Kind == "Rollout" && owner.Name == rollout.Metadata.Name)
+		}
+		if !owned || (runName != "" && run.Metadata.Name != runName) {
+			continue
+		}
+		if len(report.AnalysisRuns) >= limit {
+			break
+		}
+
+		explanation := AnalysisRunExplanation{
+			Name:      run.Metadata.Name,
+			Phase:     run.Status.Phase,
+			Message:   run.Status.Message,
+			Type:      run.Metadata.Labels["rollout-type"],
+			StepIndex: run.Metadata.Labels["step-index"],
+			StartedAt: run.Status.StartedAt,
+			Metrics:   []MetricExplanation{},
+		}
+		for _, arg := range run.Spec.Args {
+			if explanation.Args == nil {
+				explanation.Args = make(map[string]string)
+			}
+			explanation.Args[arg.Name] = arg.Value
+		}
+		metrics := make(map[string]analysisMetric)
+		for _, metric := range run.Spec.Metrics {
+			metrics[metric.Name] = metric
+		}
+		for _, result := range run.Status.MetricResults {
+			metric := explainMetric(metrics[result.Name], result)
+			if result.Phase == analysisFailed || result.Phase == analysisError || result.Phase == analysisInconclusive {
+				explanation.FailingMetrics = append(explanation.FailingMetrics, result.Name)
+			}
+			explanation.Metrics = append(explanation.Metrics, metric)
+		}
+		report.AnalysisRuns = append(report.AnalysisRuns, explanation)
+	}
+
+	report.Cause = analysisCause(report)
+	return report, nil
+}
+
+// analysisCause summarizes why the rollout was aborted, or the state of its analysis otherwise
+func analysisCause(report *AnalysisReport) string {
+	for _, run := range report.AnalysisRuns {
+		if len(run.FailingMetrics) == 0 {
+			continue
+		}
+		var reasons []string
+		for _, metric := range run.Metrics {
+			if slices.Contains(run.FailingMetrics, metric.Name) {
+				reasons = append(reasons, fmt.Sprintf("metric %s is %s: %s", metric.Name, metric.Phase, metric.Explanation))
+			}
+		}
+		prefix := fmt.Sprintf("AnalysisRun %s is %s", run.Name, run.Phase)
+		if report.Aborted {
+			prefix = fmt.Sprintf("The rollout was aborted because AnalysisRun %s is %s", run.Name, run.Phase)
+		}
+		return prefix + ": " + strings.Join(reasons, "; ")
+	}
+	switch {
+	case report.Aborted:
+		return "The rollout was aborted, but none of its analysis runs failed: " + report.Message
+	case len(report.AnalysisRuns) == 0:
+		return "No analysis runs found for the rollout"
+	case report.AnalysisRuns[0].Phase == analysisRunning || report.AnalysisRuns[0].Phase == "Pending" || report.AnalysisRuns[0].Phase == "":
+		return fmt.Sprintf("AnalysisRun %s is still running", report.AnalysisRuns[0].Name)
+	default:
+		return fmt.Sprintf("AnalysisRun %s is %s", report.AnalysisRuns[0].Name, report.AnalysisRuns[0].Phase)
+	}
+}
+
+func handleExplainAnalysisRuns(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	rolloutName := p.String("rollout_name", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	ns := p.String("namespace", "", params.Check(security.ValidateNamespace))
+	runName := p.String("analysis_run", "", params.Check(security.ValidateK8sResourceName))
+	limit := p.Int("limit", defaultAnalysisRunLimit, params.Min(1))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var scope []string
+	if ns != "" {
+		scope = []string{"-n", ns}
+	}
+	rolloutOutput, err := runArgoRolloutCommand(ctx, append([]string{"get", "rollouts.argoproj.io", rolloutName, "-o", "json"}, scope...))
+	if err != nil {
+		return mcp.NewToolResultError("Error getting rollout: " + err.Error()), nil
+	}
+	runsOutput, err := runArgoRolloutCommand(ctx, append([]string{"get", "analysisruns.argoproj.io", "-o", "json"}, scope...))
+	if err != nil {
+		return mcp.NewToolResultError("Error getting analysis runs: " + err.Error()), nil
+	}
+
+	report, err := buildAnalysisReport(rolloutOutput, runsOutput, runName, limit)
+	if err != nil {
+		return mcp.NewToolResultError("Error building analysis report: " + err.Error()), nil
+	}
+	if runName != "" && len(report.AnalysisRuns) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("no AnalysisRun named %s found for rollout %s", runName, rolloutName)), nil
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling analysis report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
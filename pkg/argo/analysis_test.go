@@ -0,0 +1,122 @@
+package argo
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAbortedRollout = `{"metadata":{"name":"web","namespace":"prod"},
+ "status":{"phase":"Degraded","abort":true,"currentStepIndex":0,
+  "message":"RolloutAborted: Rollout aborted update to revision 4: Metric \"success-rate\" assessed Failed due to failed (3) > failureLimit (2)",
+  "canary":{"currentStepAnalysisRunStatus":{"name":"web-7c9-4-1","status":"Failed"}}}}`
+
+const testAnalysisRuns = `{"items":[
+{"metadata":{"name":"web-6b8-3-1","creationTimestamp":"2026-10-14T09:00:00Z","ownerReferences":[{"kind":"Rollout","name":"web"}]},
+ "spec":{"metrics":[{"name":"success-rate","successCondition":"result[0] >= 0.95","provider":{"prometheus":{"address":"http://prometheus:9090","query":"sum(rate(ok[5m]))"}}}]},
+ "status":{"phase":"Successful","metricResults":[{"name":"success-rate","phase":"Successful","count":5,"successful":5}]}},
+{"metadata":{"name":"web-7c9-4-1","creationTimestamp":"2026-10-15T09:00:00Z","labels":{"rollout-type":"Step","step-index":"1"},
+  "ownerReferences":[{"kind":"Rollout","name":"web"}]},
+ "spec":{"args":[{"name":"service-name","value":"web-canary"}],
+  "metrics":[{"name":"success-rate","interval":"1m","failureLimit":"2","successCondition":"result[0] >= 0.95",
+    "provider":{"prometheus":{"address":"http://prometheus:9090","query":"\n  sum(rate(ok[5m])) / sum(rate(all[5m]))\n"}}},
+   {"name":"latency","failureCondition":"result[0] > 0.5","provider":{"prometheus":{"address":"http://prometheus:9090","query":"p95"}}},
+   {"name":"errors","consecutiveErrorLimit":2,"provider":{"prometheus":{"address":"http://prom:9090","query":"missing_metric"}}}]},
+ "status":{"phase":"Failed","message":"Metric \"success-rate\" assessed Failed due to failed (3) > failureLimit (2)","startedAt":"2026-10-15T09:00:01Z",
+  "metricResults":[
+   {"name":"success-rate","phase":"Failed","count":4,"successful":1,"failed":3,
+    "measurements":[{"phase":"Successful","value":"[0.97]"},{"phase":"Failed","value":"[0.91]"},{"phase":"Failed","value":"[0.9]"},{"phase":"Failed","value":"[0.87]","finishedAt":"2026-10-15T09:04:00Z"}]},
+   {"name":"latency","phase":"Running","count":2,"successful":2},
+   {"name":"errors","phase":"Error","count":3,"error":3,"consecutiveError":3,
+    "measurements":[{"phase":"Error","message":"reflect: slice index out of range"}]}]}},
+{"metadata":{"name":"api-1","creationTimestamp":"2026-10-15T10:00:00Z","ownerReferences":[{"kind":"Rollout","name":"api"}]},
+ "status":{"phase":"Failed"}}
+]}`
+
+func TestBuildAnalysisReport(t *testing.T) {
+	report, err := buildAnalysisReport(testAbortedRollout, testAnalysisRuns, "", defaultAnalysisRunLimit)
+	require.NoError(t, err)
+
+	assert.True(t, report.Aborted)
+	assert.Equal(t, []string{"web-7c9-4-1"}, report.CurrentRuns)
+	// Runs of other rollouts are left out, and the newest comes first
+	require.Len(t, report.AnalysisRuns, 2)
+	run := report.AnalysisRuns[0]
+	assert.Equal(t, "web-7c9-4-1", run.Name)
+	assert.Equal(t, "Step", run.Type)
+	assert.Equal(t, "1", run.StepIndex)
+	assert.Equal(t, map[string]string{"service-name": "web-canary"}, run.Args)
+	assert.Equal(t, []string{"success-rate", "errors"}, run.FailingMetrics)
+
+	successRate := run.Metrics[0]
+	assert.Equal(t, "prometheus", successRate.Provider)
+	assert.Equal(t, "sum(rate(ok[5m])) / sum(rate(all[5m]))", successRate.Query)
+	assert.Equal(t, 2, successRate.FailureLimit)
+	assert.Len(t, successRate.RecentMeasurements, 4)
+	assert.Equal(t, "3 of 4 measurements failed, exceeding the failure limit of 2; the latest failing value [0.87] did not meet the success condition result[0] >= 0.95", successRate.Explanation)
+
+	assert.Equal(t, "2 measurements taken so far, 0 failed", run.Metrics[1].Explanation)
+
+	errors := run.Metrics[2]
+	assert.Equal(t, 2, errors.ConsecutiveErrorLimit)
+	assert.Contains(t, errors.Explanation, "3 consecutive measurement errors exceeded the limit of 2")
+	assert.Contains(t, errors.Explanation, "the query returned no data")
+
+	assert.Contains(t, report.Cause, "The rollout was aborted because AnalysisRun web-7c9-4-1 is Failed")
+	assert.Contains(t, report.Cause, "metric success-rate is Failed")
+	assert.Contains(t, report.Cause, "metric errors is Error")
+}
+
+func TestBuildAnalysisReportFilters(t *testing.T) {
+	report, err := buildAnalysisReport(testAbortedRollout, testAnalysisRuns, "web-6b8-3-1", defaultAnalysisRunLimit)
+	require.NoError(t, err)
+	require.Len(t, report.AnalysisRuns, 1)
+	assert.Equal(t, "5 of 5 measurements succeeded", report.AnalysisRuns[0].Metrics[0].Explanation)
+	assert.Equal(t, "The rollout was aborted, but none of its analysis runs failed: "+report.Message, report.Cause)
+
+	report, err = buildAnalysisReport(testAbortedRollout, testAnalysisRuns, "", 1)
+	require.NoError(t, err)
+	assert.Len(t, report.AnalysisRuns, 1)
+}
+
+func TestExplainMetricInconclusive(t *testing.T) {
+	var metric analysisMetric
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"m","inconclusiveLimit":1,"successCondition":"result > 1","failureCondition":"result < 0"}`), &metric))
+	explanation := explainMetric(metric, analysisMetricResult{Name: "m", Phase: analysisInconclusive, Count: 2, Inconclusive: 2})
+	assert.Equal(t, 1, explanation.InconclusiveLimit)
+	assert.Contains(t, explanation.Explanation, "2 inconclusive measurements exceeded the limit of 1")
+	assert.Empty(t, explanation.Provider)
+}
+
+func TestHandleExplainAnalysisRuns(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "rollouts.argoproj.io", "web", "-o", "json", "-n", "prod"}, testAbortedRollout, nil)
+	mock.AddCommandString("kubectl", []string{"get", "analysisruns.argoproj.io", "-o", "json", "-n", "prod"}, testAnalysisRuns, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"rollout_name": "web", "namespace": "prod"}
+	result, err := handleExplainAnalysisRuns(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var report AnalysisReport
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+	assert.Equal(t, "web", report.Rollout)
+	assert.Len(t, report.AnalysisRuns, 2)
+
+	request.Params.Arguments = map[string]interface{}{"rollout_name": "web", "namespace": "prod", "analysis_run": "other"}
+	result, err = handleExplainAnalysisRuns(ctx, request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	request.Params.Arguments = map[string]interface{}{}
+	result, err = handleExplainAnalysisRuns(ctx, request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
@@ -412,6 +412,14 @@ func RegisterTools(s *server.MCPServer) {
 		mcp.WithString("namespace", mcp.Description("The namespace of the rollout")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("argo_set_rollout_image", handleSetRolloutImage)))
 
+	s.AddTool(mcp.NewTool("argo_explain_analysis_runs",
+		mcp.WithDescription("Explain the AnalysisRuns of a rollout: interpret metric provider results such as Prometheus queries against their success and failure conditions and limits, and why a canary was aborted"),
+		mcp.WithString("rollout_name", mcp.Description("The name of the rollout"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("The namespace of the rollout")),
+		mcp.WithString("analysis_run", mcp.Description("Only explain the AnalysisRun with this name")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of most recent AnalysisRuns to explain (default: 3)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("argo_explain_analysis_runs", handleExplainAnalysisRuns)))
+
 	s.AddTool(mcp.NewTool("argo_verify_gateway_plugin",
 		mcp.WithDescription("Verify the installation status of the Argo Rollouts Gateway API plugin"),
 		mcp.WithString("version", mcp.Description("The version of the plugin to check")),
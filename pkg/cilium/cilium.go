@@ -256,6 +256,17 @@ func RegisterTools(s *server.MCPServer) {
 		mcp.WithString("enable", mcp.Description("Set to 'true' to enable, 'false' to disable")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("cilium_toggle_hubble", handleToggleHubble)))
 
+	s.AddTool(mcp.NewTool("cilium_hubble_flows",
+		mcp.WithDescription("Query recent Hubble flows filtered by namespace, pod and verdict, summarizing verdicts and drop reasons"),
+		mcp.WithString("namespace", mcp.Description("Namespace to filter flows by")),
+		mcp.WithString("pod_name", mcp.Description("Pod to filter flows by (combined with namespace if set)")),
+		mcp.WithString("verdict", mcp.Description("Verdict to filter flows by (DROPPED, FORWARDED, ERROR, AUDIT, REDIRECTED, TRACED, TRANSLATED)")),
+		mcp.WithString("since", mcp.Description("Only show flows since the given duration or time (e.g. 5m)")),
+		mcp.WithNumber("last", mcp.Description("Number of most recent flows to query (default: 100, max: 1000)")),
+		mcp.WithString("server", mcp.Description("Hubble relay address (default: the hubble CLI configuration)")),
+		mcp.WithString("include_flows", mcp.Description("Include the individual flows in the response (true/false)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("cilium_hubble_flows", handleHubbleFlows)))
+
 	s.AddTool(mcp.NewTool("cilium_toggle_cluster_mesh",
 		mcp.WithDescription("Enable or disable cluster mesh"),
 		mcp.WithString("enable", mcp.Description("Set to 'true' to enable, 'false' to disable")),
@@ -0,0 +1,249 @@
+package cilium
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/security"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxHubbleFlows bounds the number of flows requested from Hubble in one call
+const maxHubbleFlows = 1000
+
+// hubbleEndpoint is the subset of a Hubble flow endpoint used for summaries
+type hubbleEndpoint struct {
+	Namespace string `json:"namespace"`
+	PodName   string `json:"pod_name"`
+}
+
+// hubblePort holds the destination port of an L4 flow
+type hubblePort struct {
+	DestinationPort int `json:"destination_port"`
+}
+
+// hubbleFlow is the subset of a Hubble flow used for summaries
+type hubbleFlow struct {
+	Time           string         `json:"time"`
+	Verdict        string         `json:"verdict"`
+	DropReasonDesc string         `json:"drop_reason_desc"`
+	Source         hubbleEndpoint `json:"source"`
+	Destination    hubbleEndpoint `json:"destination"`
+	IP             struct {
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+	} `json:"IP"`
+	L4 struct {
+		TCP *hubblePort `json:"TCP"`
+		UDP *hubblePort `json:"UDP"`
+	} `json:"l4"`
+	TrafficDirection string `json:"traffic_direction"`
+	Summary          string `json:"Summary"`
+}
+
+// HubbleFlowRecord is a flattened flow as returned by cilium_hubble_flows
+type HubbleFlowRecord struct {
+	Time        string `json:"time"`
+	Verdict     string `json:"verdict"`
+	DropReason  string `json:"drop_reason,omitempty"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Direction   string `json:"direction,omitempty"`
+	Summary     string `json:"summary,omitempty"`
+}
+
+// HubbleCount is a label and the number of flows it was seen in
+type HubbleCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// HubbleFlowSummary is the structured response of cilium_hubble_flows
+type HubbleFlowSummary struct {
+	TotalFlows   int                `json:"total_flows"`
+	Verdicts     map[string]int     `json:"verdicts"`
+	DropReasons  []HubbleCount      `json:"drop_reasons"`
+	TopDropPaths []HubbleCount      `json:"top_drop_paths"`
+	Flows        []HubbleFlowRecord `json:"flows"`
+}
+
+func runHubbleCommand(ctx context.Context, args ...string) (string, error) {
+	return commands.NewCommandBuilder("hubble").
+		WithArgs(args...).
+		Execute(ctx)
+}
+
+// describeHubbleEndpoint renders an endpoint as namespace/pod, falling back to the IP
+func describeHubbleEndpoint(endpoint hubbleEndpoint, ip string) string {
+	if endpoint.PodName != "" {
+		return endpoint.Namespace + "/" + endpoint.PodName
+	}
+	if ip != "" {
+		return ip
+	}
+	return "unknown"
+}
+
+// sortedCounts converts a count map into a slice ordered by descending count
+func sortedCounts(counts map[string]int, limit int) []HubbleCount {
+	result := make([]HubbleCount, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, HubbleCount{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count == result[j].Count {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Count > result[j].Count
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// summarizeHubbleFlows parses `hubble observe -o json` output, one JSON object per line
+func summarizeHubbleFlows(output string) (HubbleFlowSummary, error) {
+	summary := HubbleFlowSummary{
+		Verdicts:     make(map[string]int),
+		DropReasons:  []HubbleCount{},
+		TopDropPaths: []HubbleCount{},
+		Flows:        []HubbleFlowRecord{},
+	}
+	dropReasons := make(map[string]int)
+	dropPaths := make(map[string]int)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var entry struct {
+			Flow *hubbleFlow `json:"flow"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return summary, fmt.Errorf("failed to parse flow: %w", err)
+		}
+		if entry.Flow == nil {
+			continue
+		}
+		flow := entry.Flow
+
+		destination := describeHubbleEndpoint(flow.Destination, flow.IP.Destination)
+		if flow.L4.TCP != nil {
+			destination = fmt.Sprintf("%s:%d/TCP", destination, flow.L4.TCP.DestinationPort)
+		} else if flow.L4.UDP != nil {
+			destination = fmt.Sprintf("%s:%d/UDP", destination, flow.L4.UDP.DestinationPort)
+		}
+
+		record := HubbleFlowRecord{
+			Time:        flow.Time,
+			Verdict:     flow.Verdict,
+			Source:      describeHubbleEndpoint(flow.Source, flow.IP.Source),
+			Destination: destination,
+			Direction:   flow.TrafficDirection,
+			Summary:     flow.Summary,
+		}
+
+		summary.Verdicts[flow.Verdict]++
+		if flow.Verdict == "DROPPED" {
+			record.DropReason = flow.DropReasonDesc
+			if record.DropReason == "" {
+				record.DropReason = "UNKNOWN"
+			}
+			dropReasons[record.DropReason]++
+			dropPaths[record.Source+" -> "+record.Destination]++
+		}
+
+		summary.Flows = append(summary.Flows, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return summary, fmt.Errorf("failed to read flows: %w", err)
+	}
+
+	summary.TotalFlows = len(summary.Flows)
+	summary.DropReasons = sortedCounts(dropReasons, 0)
+	summary.TopDropPaths = sortedCounts(dropPaths, 10)
+	return summary, nil
+}
+
+func handleHubbleFlows(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	namespace := mcp.ParseString(request, "namespace", "")
+	podName := mcp.ParseString(request, "pod_name", "")
+	verdict := strings.ToUpper(mcp.ParseString(request, "verdict", ""))
+	since := mcp.ParseString(request, "since", "")
+	hubbleServer := mcp.ParseString(request, "server", "")
+	last := mcp.ParseInt(request, "last", 100)
+	includeFlows := mcp.ParseString(request, "include_flows", "") == "true"
+
+	if err := security.ValidateNamespace(namespace); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid namespace: %v", err)), nil
+	}
+	if podName != "" {
+		if err := security.ValidateK8sResourceName(podName); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid pod name: %v", err)), nil
+		}
+	}
+	switch verdict {
+	case "", "DROPPED", "FORWARDED", "ERROR", "AUDIT", "REDIRECTED", "TRACED", "TRANSLATED":
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("unsupported verdict %q", verdict)), nil
+	}
+	if last <= 0 || last > maxHubbleFlows {
+		return mcp.NewToolResultError(fmt.Sprintf("last must be between 1 and %d", maxHubbleFlows)), nil
+	}
+
+	args := []string{"observe", "--output", "json", "--last", fmt.Sprintf("%d", last)}
+	if hubbleServer != "" {
+		if err := security.ValidateCommandInput(hubbleServer); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid server: %v", err)), nil
+		}
+		args = append(args, "--server", hubbleServer)
+	}
+	if podName != "" {
+		if namespace != "" {
+			args = append(args, "--pod", namespace+"/"+podName)
+		} else {
+			args = append(args, "--pod", podName)
+		}
+	} else if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	if verdict != "" {
+		args = append(args, "--verdict", verdict)
+	}
+	if since != "" {
+		if err := security.ValidateCommandInput(since); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid since: %v", err)), nil
+		}
+		args = append(args, "--since", since)
+	}
+
+	output, err := runHubbleCommand(ctx, args...)
+	if err != nil {
+		return mcp.NewToolResultError("Error observing Hubble flows: " + err.Error()), nil
+	}
+
+	summary, err := summarizeHubbleFlows(output)
+	if err != nil {
+		return mcp.NewToolResultError("Error parsing Hubble flows: " + err.Error()), nil
+	}
+	if !includeFlows {
+		summary.Flows = nil
+	}
+
+	summaryJSON, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling Hubble flow summary: " + err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(string(summaryJSON)), nil
+}
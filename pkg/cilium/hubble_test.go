@@ -0,0 +1,84 @@
+package cilium
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHubbleFlows = `{"flow":{"time":"2024-01-01T10:00:00Z","verdict":"DROPPED","drop_reason_desc":"POLICY_DENIED","source":{"namespace":"default","pod_name":"frontend-1"},"destination":{"namespace":"default","pod_name":"backend-1"},"l4":{"TCP":{"destination_port":8080}},"traffic_direction":"INGRESS"}}
+{"flow":{"time":"2024-01-01T10:00:01Z","verdict":"DROPPED","drop_reason_desc":"POLICY_DENIED","source":{"namespace":"default","pod_name":"frontend-1"},"destination":{"namespace":"default","pod_name":"backend-1"},"l4":{"TCP":{"destination_port":8080}},"traffic_direction":"INGRESS"}}
+{"flow":{"time":"2024-01-01T10:00:02Z","verdict":"FORWARDED","source":{"namespace":"default","pod_name":"frontend-1"},"destination":{},"IP":{"destination":"10.0.0.10"},"l4":{"UDP":{"destination_port":53}}}}
+`
+
+func TestSummarizeHubbleFlows(t *testing.T) {
+	summary, err := summarizeHubbleFlows(testHubbleFlows)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, summary.TotalFlows)
+	assert.Equal(t, 2, summary.Verdicts["DROPPED"])
+	assert.Equal(t, 1, summary.Verdicts["FORWARDED"])
+	assert.Equal(t, []HubbleCount{{Name: "POLICY_DENIED", Count: 2}}, summary.DropReasons)
+	assert.Equal(t, []HubbleCount{{Name: "default/frontend-1 -> default/backend-1:8080/TCP", Count: 2}}, summary.TopDropPaths)
+	assert.Equal(t, "10.0.0.10:53/UDP", summary.Flows[2].Destination)
+
+	_, err = summarizeHubbleFlows("not json")
+	assert.Error(t, err)
+}
+
+func TestHandleHubbleFlows(t *testing.T) {
+	t.Run("filters are passed to hubble observe", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("hubble", []string{"observe", "--output", "json", "--last", "50", "--pod", "default/backend-1", "--verdict", "DROPPED"}, testHubbleFlows, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"namespace": "default",
+			"pod_name":  "backend-1",
+			"verdict":   "dropped",
+			"last":      float64(50),
+		}
+
+		result, err := handleHubbleFlows(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		var summary HubbleFlowSummary
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &summary))
+		assert.Equal(t, 3, summary.TotalFlows)
+		assert.Empty(t, summary.Flows)
+	})
+
+	t.Run("invalid verdict", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"verdict": "BLOCKED",
+		}
+
+		result, err := handleHubbleFlows(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("hubble failure", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("hubble", []string{"observe", "--output", "json", "--last", "100", "--namespace", "default"}, "", errors.New("connection refused"))
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"namespace": "default",
+		}
+
+		result, err := handleHubbleFlows(ctx, request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
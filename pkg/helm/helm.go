@@ -2,12 +2,17 @@ package helm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/kagent-dev/tools/internal/changes"
 	"github.com/kagent-dev/tools/internal/commands"
 	"github.com/kagent-dev/tools/internal/errors"
+	"github.com/kagent-dev/tools/internal/params"
 	"github.com/kagent-dev/tools/internal/security"
 	"github.com/kagent-dev/tools/internal/telemetry"
 	"github.com/kagent-dev/tools/pkg/utils"
@@ -17,16 +22,20 @@ import (
 
 // Helm list releases
 func handleHelmListReleases(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	namespace := mcp.ParseString(request, "namespace", "")
-	allNamespaces := mcp.ParseString(request, "all_namespaces", "") == "true"
-	all := mcp.ParseString(request, "all", "") == "true"
-	uninstalled := mcp.ParseString(request, "uninstalled", "") == "true"
-	uninstalling := mcp.ParseString(request, "uninstalling", "") == "true"
-	failed := mcp.ParseString(request, "failed", "") == "true"
-	deployed := mcp.ParseString(request, "deployed", "") == "true"
-	pending := mcp.ParseString(request, "pending", "") == "true"
-	filter := mcp.ParseString(request, "filter", "")
-	output := mcp.ParseString(request, "output", "")
+	p := params.New(request)
+	namespace := p.String("namespace", "")
+	allNamespaces := p.Bool("all_namespaces", false)
+	all := p.Bool("all", false)
+	uninstalled := p.Bool("uninstalled", false)
+	uninstalling := p.Bool("uninstalling", false)
+	failed := p.Bool("failed", false)
+	deployed := p.Bool("deployed", false)
+	pending := p.Bool("pending", false)
+	filter := p.String("filter", "")
+	output := p.String("output", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	args := []string{"list"}
 
@@ -88,16 +97,24 @@ func handleHelmListReleases(ctx context.Context, request mcp.CallToolRequest) (*
 }
 
 func runHelmCommand(ctx context.Context, args []string) (string, error) {
+	// Only add timeout for upgrade commands
+	var timeout time.Duration
+	if len(args) > 0 && args[0] == "upgrade" {
+		timeout = 30 * time.Second
+	}
+	return runHelmCommandWithTimeout(ctx, args, timeout)
+}
+
+// runHelmCommandWithTimeout runs a helm command, passing timeout as its --timeout flag when positive
+func runHelmCommandWithTimeout(ctx context.Context, args []string, timeout time.Duration) (string, error) {
 	kubeconfigPath := utils.GetKubeconfig()
 
-	// Add timeout for helm upgrade commands
 	cmdBuilder := commands.NewCommandBuilder("helm").
 		WithArgs(args...).
 		WithKubeconfig(kubeconfigPath)
 
-	// Only add timeout for upgrade commands
-	if len(args) > 0 && args[0] == "upgrade" {
-		cmdBuilder = cmdBuilder.WithTimeout(30 * time.Second)
+	if timeout > 0 {
+		cmdBuilder = cmdBuilder.WithTimeout(timeout)
 	}
 
 	result, err := cmdBuilder.Execute(ctx)
@@ -116,18 +133,55 @@ func runHelmCommand(ctx context.Context, args []string) (string, error) {
 	return result, nil
 }
 
-// Helm get release
-func handleHelmGetRelease(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	name := mcp.ParseString(request, "name", "")
-	namespace := mcp.ParseString(request, "namespace", "")
-	resource := mcp.ParseString(request, "resource", "all")
+// priorRelease returns the change to record before a release is changed,
+// holding the revision to roll back to, or PriorAbsent when it is not installed
+func priorRelease(ctx context.Context, name, namespace string) changes.Change {
+	change := changes.Change{Kind: changes.KindHelmRelease, Name: name, Namespace: namespace}
+	args := []string{"list", "-a", "--filter", "^" + regexp.QuoteMeta(name) + "$", "-o", "json"}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	output, err := runHelmCommand(ctx, args)
+	if err != nil {
+		change.Prior, change.Note = changes.PriorNone, fmt.Sprintf("the release could not be read before the change: %v", err)
+		return change
+	}
 
-	if name == "" {
-		return mcp.NewToolResultError("name parameter is required"), nil
+	var releases []struct {
+		Namespace string `json:"namespace"`
+		Revision  string `json:"revision"`
 	}
+	if err := json.Unmarshal([]byte(output), &releases); err != nil {
+		change.Prior, change.Note = changes.PriorNone, fmt.Sprintf("the release could not be parsed before the change: %v", err)
+		return change
+	}
+	if len(releases) == 0 {
+		change.Prior = changes.PriorAbsent
+		return change
+	}
+	revision, err := strconv.Atoi(releases[0].Revision)
+	if err != nil {
+		change.Prior, change.Note = changes.PriorNone, fmt.Sprintf("the release has an invalid revision %q", releases[0].Revision)
+		return change
+	}
+	change.Prior, change.Revision, change.Namespace = changes.PriorHelmRevision, revision, releases[0].Namespace
+	return change
+}
+
+// recordReleaseChange records the change of a release once the command changing it has succeeded
+func recordReleaseChange(ctx context.Context, tool, summary string, prior changes.Change) {
+	prior.Tool, prior.Summary = tool, summary
+	changes.Record(ctx, prior)
+}
 
-	if namespace == "" {
-		return mcp.NewToolResultError("namespace parameter is required"), nil
+// Helm get release
+func handleHelmGetRelease(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("name", "", params.Required())
+	namespace := p.String("namespace", "", params.Required())
+	resource := p.String("resource", "all", params.OneOf("all", "hooks", "manifest", "notes", "values"))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	args := []string{"get", resource, name, "-n", namespace}
@@ -142,37 +196,18 @@ func handleHelmGetRelease(ctx context.Context, request mcp.CallToolRequest) (*mc
 
 // Helm upgrade release
 func handleHelmUpgradeRelease(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	name := mcp.ParseString(request, "name", "")
-	chart := mcp.ParseString(request, "chart", "")
-	namespace := mcp.ParseString(request, "namespace", "")
-	version := mcp.ParseString(request, "version", "")
-	values := mcp.ParseString(request, "values", "")
-	setValues := mcp.ParseString(request, "set", "")
-	install := mcp.ParseString(request, "install", "") == "true"
-	dryRun := mcp.ParseString(request, "dry_run", "") == "true"
-	wait := mcp.ParseString(request, "wait", "") == "true"
-
-	if name == "" || chart == "" {
-		return mcp.NewToolResultError("name and chart parameters are required"), nil
-	}
-
-	// Validate release name
-	if err := security.ValidateHelmReleaseName(name); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid release name: %v", err)), nil
-	}
-
-	// Validate namespace if provided
-	if namespace != "" {
-		if err := security.ValidateNamespace(namespace); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Invalid namespace: %v", err)), nil
-		}
-	}
-
-	// Validate values file path if provided
-	if values != "" {
-		if err := security.ValidateFilePath(values); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Invalid values file path: %v", err)), nil
-		}
+	p := params.New(request)
+	name := p.String("name", "", params.Required(), params.Check(security.ValidateHelmReleaseName))
+	chart := p.String("chart", "", params.Required())
+	namespace := p.String("namespace", "", params.Check(security.ValidateNamespace))
+	version := p.String("version", "")
+	values := p.String("values", "", params.Check(security.ValidateFilePath))
+	setValues := p.String("set", "")
+	install := p.Bool("install", false)
+	dryRun := p.Bool("dry_run", false)
+	wait := p.Bool("wait", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	args := []string{"upgrade", name, chart}
@@ -209,23 +244,37 @@ func handleHelmUpgradeRelease(ctx context.Context, request mcp.CallToolRequest)
 		args = append(args, "--wait")
 	}
 
+	var prior changes.Change
+	if !dryRun {
+		prior = priorRelease(ctx, name, namespace)
+	}
 	result, err := runHelmCommand(ctx, args)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Helm upgrade command failed: %v", err)), nil
 	}
+	if !dryRun {
+		summary := "upgraded to " + chart
+		if prior.Prior == changes.PriorAbsent {
+			summary = "installed " + chart
+		}
+		if version != "" {
+			summary += " " + version
+		}
+		recordReleaseChange(ctx, "helm_upgrade", summary, prior)
+	}
 
 	return mcp.NewToolResultText(result), nil
 }
 
 // Helm uninstall release
 func handleHelmUninstall(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	name := mcp.ParseString(request, "name", "")
-	namespace := mcp.ParseString(request, "namespace", "")
-	dryRun := mcp.ParseString(request, "dry_run", "") == "true"
-	wait := mcp.ParseString(request, "wait", "") == "true"
-
-	if name == "" || namespace == "" {
-		return mcp.NewToolResultError("name and namespace parameters are required"), nil
+	p := params.New(request)
+	name := p.String("name", "", params.Required())
+	namespace := p.String("namespace", "", params.Required())
+	dryRun := p.Bool("dry_run", false)
+	wait := p.Bool("wait", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	args := []string{"uninstall", name, "-n", namespace}
@@ -242,27 +291,27 @@ func handleHelmUninstall(ctx context.Context, request mcp.CallToolRequest) (*mcp
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Helm uninstall command failed: %v", err)), nil
 	}
+	if !dryRun {
+		// Helm deletes the history of uninstalled releases, leaving no revision to roll back to
+		recordReleaseChange(ctx, "helm_uninstall", "uninstalled", changes.Change{
+			Kind:      changes.KindHelmRelease,
+			Name:      name,
+			Namespace: namespace,
+			Prior:     changes.PriorNone,
+			Note:      "Helm deletes the history of uninstalled releases; install the chart again to restore it",
+		})
+	}
 
 	return mcp.NewToolResultText(result), nil
 }
 
 // Helm repo add
 func handleHelmRepoAdd(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	name := mcp.ParseString(request, "name", "")
-	url := mcp.ParseString(request, "url", "")
-
-	if name == "" || url == "" {
-		return mcp.NewToolResultError("name and url parameters are required"), nil
-	}
-
-	// Validate repository name
-	if err := security.ValidateHelmReleaseName(name); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid repository name: %v", err)), nil
-	}
-
-	// Validate repository URL
-	if err := security.ValidateURL(url); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid repository URL: %v", err)), nil
+	p := params.New(request)
+	name := p.String("name", "", params.Required(), params.Check(security.ValidateHelmReleaseName))
+	url := p.String("url", "", params.Required(), params.Check(security.ValidateURL))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	args := []string{"repo", "add", name, url}
@@ -311,6 +360,22 @@ func RegisterTools(s *server.MCPServer) {
 		mcp.WithString("resource", mcp.Description("The resource to get (all, hooks, manifest, notes, values)")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("helm_get_release", handleHelmGetRelease)))
 
+	s.AddTool(mcp.NewTool("helm_get_values",
+		mcp.WithDescription("Get the values of a Helm release as JSON, separating the user-supplied values from the computed values merged with the chart defaults"),
+		mcp.WithString("name", mcp.Description("The name of the release"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("The namespace of the release"), mcp.Required()),
+		mcp.WithNumber("revision", mcp.Description("The revision to get (default: latest)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("helm_get_values", handleHelmGetValues)))
+
+	s.AddTool(mcp.NewTool("helm_get_manifest",
+		mcp.WithDescription("Get the resources a Helm release deployed as parsed documents, with their source templates and a count per kind"),
+		mcp.WithString("name", mcp.Description("The name of the release"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("The namespace of the release"), mcp.Required()),
+		mcp.WithNumber("revision", mcp.Description("The revision to get (default: latest)")),
+		mcp.WithString("kind", mcp.Description("Only return resources of this kind (e.g. Deployment); the summary still counts all resources")),
+		mcp.WithString("include_objects", mcp.Description("Include the full resource documents, or only their kind, name and source (true/false, default: true)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("helm_get_manifest", handleHelmGetManifest)))
+
 	s.AddTool(mcp.NewTool("helm_upgrade",
 		mcp.WithDescription("Upgrade or install a Helm release"),
 		mcp.WithString("name", mcp.Description("The name of the release"), mcp.Required()),
@@ -324,6 +389,26 @@ func RegisterTools(s *server.MCPServer) {
 		mcp.WithString("wait", mcp.Description("Wait for the upgrade to complete")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("helm_upgrade", handleHelmUpgradeRelease)))
 
+	s.AddTool(mcp.NewTool("helm_upgrade_safe",
+		mcp.WithDescription("Upgrade a Helm release after validating values against the chart's values.schema.json and diffing them against the release. Changes to replicas, resources or image tags require confirm=true. Runs with --atomic."),
+		mcp.WithString("name", mcp.Description("The name of the release"), mcp.Required()),
+		mcp.WithString("chart", mcp.Description("The chart to upgrade to"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("The namespace of the release"), mcp.Required()),
+		mcp.WithString("version", mcp.Description("The version of the chart to upgrade to")),
+		mcp.WithString("values", mcp.Description("Values content in YAML or JSON")),
+		mcp.WithString("timeout", mcp.Description("Time to wait for the upgrade before rolling back (default: 5m)")),
+		mcp.WithString("confirm", mcp.Description("Set to 'true' to confirm changes to replicas, resources or image tags")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("helm_upgrade_safe", handleHelmUpgradeSafe)))
+
+	s.AddTool(mcp.NewTool("helm_test",
+		mcp.WithDescription("Run the tests of a Helm release, collect the logs of the test pods and summarize pass/fail per test hook"),
+		mcp.WithString("name", mcp.Description("The name of the release"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("The namespace of the release"), mcp.Required()),
+		mcp.WithString("timeout", mcp.Description("Time to wait for each test to complete (default: 5m)")),
+		mcp.WithString("tests", mcp.Description("Comma-separated names of the tests to run (default: all)")),
+		mcp.WithNumber("log_lines", mcp.Description("Number of log lines to collect per test pod, 0 to skip logs (default: 50)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("helm_test", handleHelmTest)))
+
 	s.AddTool(mcp.NewTool("helm_uninstall",
 		mcp.WithDescription("Uninstall a Helm release"),
 		mcp.WithString("name", mcp.Description("The name of the release to uninstall"), mcp.Required()),
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/kagent-dev/tools/internal/changes"
 	"github.com/kagent-dev/tools/internal/cmd"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -226,8 +227,12 @@ NAMESPACE: default
 STATUS: deployed
 REVISION: 2`
 
+		mock.AddCommandString("helm", []string{"list", "-a", "--filter", "^myapp$", "-o", "json"}, `[{"name": "myapp", "namespace": "default", "revision": "1"}]`, nil)
 		mock.AddCommandString("helm", []string{"upgrade", "myapp", "stable/myapp", "--timeout", "30s"}, expectedOutput, nil)
 		ctx := cmd.WithShellExecutor(context.Background(), mock)
+		history := changes.NewMemoryStore()
+		changes.SetDefault(history)
+		defer changes.SetDefault(changes.NewMemoryStore())
 
 		request := mcp.CallToolRequest{}
 		request.Params.Arguments = map[string]interface{}{
@@ -241,11 +246,19 @@ REVISION: 2`
 		assert.False(t, result.IsError)
 		assert.Contains(t, getResultText(result), "has been upgraded")
 
-		// Verify the correct command was called
+		// Verify the correct command was called after reading the revision to roll back to
 		callLog := mock.GetCallLog()
-		require.Len(t, callLog, 1)
-		assert.Equal(t, "helm", callLog[0].Command)
-		assert.Equal(t, []string{"upgrade", "myapp", "stable/myapp", "--timeout", "30s"}, callLog[0].Args)
+		require.Len(t, callLog, 2)
+		assert.Equal(t, "helm", callLog[1].Command)
+		assert.Equal(t, []string{"upgrade", "myapp", "stable/myapp", "--timeout", "30s"}, callLog[1].Args)
+
+		recorded, err := history.List(context.Background(), changes.Filter{})
+		require.NoError(t, err)
+		require.Len(t, recorded, 1)
+		assert.Equal(t, changes.PriorHelmRevision, recorded[0].Prior)
+		assert.Equal(t, 1, recorded[0].Revision)
+		assert.Equal(t, "default", recorded[0].Namespace)
+		assert.Equal(t, "upgraded to stable/myapp", recorded[0].Summary)
 	})
 
 	t.Run("upgrade with all options", func(t *testing.T) {
@@ -303,7 +316,7 @@ REVISION: 2`
 		result, err := handleHelmUpgradeRelease(ctx, request)
 		assert.NoError(t, err)
 		assert.True(t, result.IsError)
-		assert.Contains(t, getResultText(result), "name and chart parameters are required")
+		assert.Contains(t, getResultText(result), "chart parameter is required")
 
 		// Verify no commands were executed
 		callLog := mock.GetCallLog()
@@ -380,7 +393,7 @@ func TestHandleHelmUninstall(t *testing.T) {
 		result, err := handleHelmUninstall(ctx, request)
 		assert.NoError(t, err)
 		assert.True(t, result.IsError)
-		assert.Contains(t, getResultText(result), "name and namespace parameters are required")
+		assert.Contains(t, getResultText(result), "name parameter is required")
 
 		// Test missing namespace
 		request.Params.Arguments = map[string]interface{}{
@@ -390,7 +403,7 @@ func TestHandleHelmUninstall(t *testing.T) {
 		result, err = handleHelmUninstall(ctx, request)
 		assert.NoError(t, err)
 		assert.True(t, result.IsError)
-		assert.Contains(t, getResultText(result), "name and namespace parameters are required")
+		assert.Contains(t, getResultText(result), "namespace parameter is required")
 
 		// Verify no commands were executed
 		callLog := mock.GetCallLog()
@@ -439,7 +452,7 @@ func TestHandleHelmRepoAdd(t *testing.T) {
 		result, err := handleHelmRepoAdd(ctx, request)
 		assert.NoError(t, err)
 		assert.True(t, result.IsError)
-		assert.Contains(t, getResultText(result), "name and url parameters are required")
+		assert.Contains(t, getResultText(result), "name parameter is required")
 
 		// Verify no commands were executed
 		callLog := mock.GetCallLog()
@@ -0,0 +1,198 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+var (
+	// documentSeparator splits a multi-document manifest
+	documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+	// sourcePattern matches the template comment helm writes above each document, e.g. "# Source: app/templates/svc.yaml"
+	sourcePattern = regexp.MustCompile(`(?m)^# Source: (.+)$`)
+)
+
+// ReleaseValues is the structured response of helm_get_values
+type ReleaseValues struct {
+	Release   string `json:"release"`
+	Namespace string `json:"namespace"`
+	Revision  int    `json:"revision,omitempty"`
+	// UserSupplied are the values given with --values and --set
+	UserSupplied map[string]interface{} `json:"user_supplied"`
+	// Computed are the chart defaults merged with the user-supplied values
+	Computed map[string]interface{} `json:"computed"`
+	// OverriddenKeys are the dotted paths of the user-supplied values, sorted
+	OverriddenKeys []string `json:"overridden_keys"`
+}
+
+// ManifestResource is a resource a release deployed
+type ManifestResource struct {
+	APIVersion string `json:"api_version"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	// Source is the chart template that rendered the resource
+	Source string                 `json:"source,omitempty"`
+	Object map[string]interface{} `json:"object,omitempty"`
+}
+
+// ManifestSummary counts the resources of a release
+type ManifestSummary struct {
+	Total  int            `json:"total"`
+	ByKind map[string]int `json:"by_kind"`
+}
+
+// ReleaseManifest is the structured response of helm_get_manifest
+type ReleaseManifest struct {
+	Release   string             `json:"release"`
+	Namespace string             `json:"namespace"`
+	Revision  int                `json:"revision,omitempty"`
+	Summary   ManifestSummary    `json:"summary"`
+	Resources []ManifestResource `json:"resources"`
+}
+
+// revisionArgs returns the --revision flag for a revision, or none for the latest
+func revisionArgs(revision int) []string {
+	if revision == 0 {
+		return nil
+	}
+	return []string{"--revision", strconv.Itoa(revision)}
+}
+
+// parseManifest parses the documents of a release manifest, skipping empty ones
+func parseManifest(manifest string) ([]ManifestResource, error) {
+	resources := []ManifestResource{}
+	for i, document := range documentSeparator.Split(manifest, -1) {
+		var object map[string]interface{}
+		if err := yaml.Unmarshal([]byte(document), &object); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest document %d: %w", i+1, err)
+		}
+		if len(object) == 0 {
+			continue
+		}
+
+		resource := ManifestResource{Object: object}
+		if match := sourcePattern.FindStringSubmatch(document); match != nil {
+			resource.Source = strings.TrimSpace(match[1])
+		}
+		resource.APIVersion, _ = object["apiVersion"].(string)
+		resource.Kind, _ = object["kind"].(string)
+		if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+			resource.Name, _ = metadata["name"].(string)
+			resource.Namespace, _ = metadata["namespace"].(string)
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// Helm get values
+func handleHelmGetValues(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("name", "", params.Required(), params.Check(security.ValidateHelmReleaseName))
+	namespace := p.String("namespace", "", params.Required(), params.Check(security.ValidateNamespace))
+	revision := p.Int("revision", 0, params.Min(0))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	args := append([]string{"get", "values", name, "-n", namespace, "-o", "json"}, revisionArgs(revision)...)
+	output, err := runHelmCommand(ctx, args)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Helm get values command failed: %v", err)), nil
+	}
+	userSupplied, err := parseValues(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse user-supplied values: %v", err)), nil
+	}
+
+	output, err = runHelmCommand(ctx, append(args, "--all"))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Helm get values command failed: %v", err)), nil
+	}
+	computed, err := parseValues(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse computed values: %v", err)), nil
+	}
+
+	leaves := map[string]interface{}{}
+	flattenValues("", userSupplied, leaves)
+	overridden := make([]string, 0, len(leaves))
+	for key := range leaves {
+		overridden = append(overridden, key)
+	}
+	sort.Strings(overridden)
+
+	values := ReleaseValues{
+		Release:        name,
+		Namespace:      namespace,
+		Revision:       revision,
+		UserSupplied:   userSupplied,
+		Computed:       computed,
+		OverriddenKeys: overridden,
+	}
+	valuesJSON, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling release values: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(valuesJSON)), nil
+}
+
+// Helm get manifest
+func handleHelmGetManifest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("name", "", params.Required(), params.Check(security.ValidateHelmReleaseName))
+	namespace := p.String("namespace", "", params.Required(), params.Check(security.ValidateNamespace))
+	revision := p.Int("revision", 0, params.Min(0))
+	kind := p.String("kind", "")
+	includeObjects := p.Bool("include_objects", true)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	args := append([]string{"get", "manifest", name, "-n", namespace}, revisionArgs(revision)...)
+	output, err := runHelmCommand(ctx, args)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Helm get manifest command failed: %v", err)), nil
+	}
+	resources, err := parseManifest(output)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	manifest := ReleaseManifest{
+		Release:   name,
+		Namespace: namespace,
+		Revision:  revision,
+		Summary:   ManifestSummary{ByKind: map[string]int{}},
+		Resources: []ManifestResource{},
+	}
+	for _, resource := range resources {
+		manifest.Summary.Total++
+		manifest.Summary.ByKind[resource.Kind]++
+		if kind != "" && !strings.EqualFold(resource.Kind, kind) {
+			continue
+		}
+		if !includeObjects {
+			resource.Object = nil
+		}
+		manifest.Resources = append(manifest.Resources, resource)
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling release manifest: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(manifestJSON)), nil
+}
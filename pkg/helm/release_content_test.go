@@ -0,0 +1,144 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const testReleaseManifest = `---
+# Source: web/templates/serviceaccount.yaml
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+spec:
+  ports:
+  - port: 80
+---
+# Source: web/templates/empty.yaml
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+`
+
+func TestParseManifest(t *testing.T) {
+	resources, err := parseManifest(testReleaseManifest)
+	require.NoError(t, err)
+	require.Len(t, resources, 3)
+
+	assert.Equal(t, "ServiceAccount", resources[0].Kind)
+	assert.Equal(t, "web/templates/serviceaccount.yaml", resources[0].Source)
+	assert.Equal(t, "v1", resources[1].APIVersion)
+	assert.Equal(t, "shop", resources[1].Namespace)
+	assert.Equal(t, "apps/v1", resources[2].APIVersion)
+	assert.Equal(t, "web/templates/deployment.yaml", resources[2].Source)
+
+	_, err = parseManifest("kind: [unclosed")
+	assert.Error(t, err)
+}
+
+func TestHandleHelmGetValues(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("helm", []string{"get", "values", "web", "-n", "shop", "-o", "json", "--revision", "2"},
+		`{"image":{"tag":"1.2.0"},"replicaCount":3}`, nil)
+	mock.AddCommandString("helm", []string{"get", "values", "web", "-n", "shop", "-o", "json", "--revision", "2", "--all"},
+		`{"image":{"repository":"example/web","tag":"1.2.0"},"replicaCount":3,"service":{"port":80}}`, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"name": "web", "namespace": "shop", "revision": float64(2)}
+	result, err := handleHelmGetValues(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var values ReleaseValues
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &values))
+	assert.Equal(t, 2, values.Revision)
+	assert.Equal(t, []string{"image.tag", "replicaCount"}, values.OverriddenKeys)
+	assert.Equal(t, float64(3), values.UserSupplied["replicaCount"])
+	assert.Equal(t, map[string]interface{}{"port": float64(80)}, values.Computed["service"])
+
+	t.Run("no user-supplied values", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("helm", []string{"get", "values", "web", "-n", "shop", "-o", "json"}, "null\n", nil)
+		mock.AddCommandString("helm", []string{"get", "values", "web", "-n", "shop", "-o", "json", "--all"}, `{"replicaCount":1}`, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request.Params.Arguments = map[string]interface{}{"name": "web", "namespace": "shop"}
+		result, err := handleHelmGetValues(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var values ReleaseValues
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &values))
+		assert.Empty(t, values.UserSupplied)
+		assert.Empty(t, values.OverriddenKeys)
+		assert.Equal(t, float64(1), values.Computed["replicaCount"])
+	})
+}
+
+func TestHandleHelmGetManifest(t *testing.T) {
+	getManifest := func(t *testing.T, args map[string]interface{}) ReleaseManifest {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("helm", []string{"get", "manifest", "web", "-n", "shop"}, testReleaseManifest, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handleHelmGetManifest(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var manifest ReleaseManifest
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &manifest))
+		return manifest
+	}
+
+	t.Run("all resources", func(t *testing.T) {
+		manifest := getManifest(t, map[string]interface{}{"name": "web", "namespace": "shop"})
+		assert.Equal(t, 3, manifest.Summary.Total)
+		assert.Equal(t, map[string]int{"ServiceAccount": 1, "Service": 1, "Deployment": 1}, manifest.Summary.ByKind)
+		require.Len(t, manifest.Resources, 3)
+		assert.Equal(t, map[string]interface{}{"replicas": float64(3)}, manifest.Resources[2].Object["spec"])
+	})
+
+	t.Run("kind filter without objects", func(t *testing.T) {
+		manifest := getManifest(t, map[string]interface{}{"name": "web", "namespace": "shop", "kind": "deployment", "include_objects": "false"})
+		assert.Equal(t, 3, manifest.Summary.Total)
+		require.Len(t, manifest.Resources, 1)
+		assert.Equal(t, "Deployment", manifest.Resources[0].Kind)
+		assert.Nil(t, manifest.Resources[0].Object)
+	})
+
+	t.Run("missing release", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"namespace": "shop"}
+		result, err := handleHelmGetManifest(ctx, request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "name parameter is required")
+		assert.Empty(t, mock.GetCallLog())
+	})
+}
@@ -0,0 +1,198 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+	"github.com/kagent-dev/tools/pkg/utils"
+)
+
+// defaultReleaseTestTimeout is the helm --timeout used for each test when none is given
+const defaultReleaseTestTimeout = 5 * time.Minute
+
+// defaultTestLogLines is the number of log lines collected per test pod when no limit is given
+const defaultTestLogLines = 50
+
+// Overall statuses reported by helm_test
+const (
+	TestStatusPassed  = "passed"
+	TestStatusFailed  = "failed"
+	TestStatusNoTests = "no_tests"
+)
+
+// releaseStatus is the subset of `helm status -o json` output describing the test hooks
+type releaseStatus struct {
+	Name  string `json:"name"`
+	Hooks []struct {
+		Name    string   `json:"name"`
+		Kind    string   `json:"kind"`
+		Events  []string `json:"events"`
+		LastRun struct {
+			StartedAt   string `json:"started_at"`
+			CompletedAt string `json:"completed_at"`
+			Phase       string `json:"phase"`
+		} `json:"last_run"`
+	} `json:"hooks"`
+}
+
+// HelmTestResult is the outcome of a single test hook
+type HelmTestResult struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Phase       string `json:"phase"`
+	Passed      bool   `json:"passed"`
+	StartedAt   string `json:"started_at,omitempty"`
+	CompletedAt string `json:"completed_at,omitempty"`
+	Duration    string `json:"duration,omitempty"`
+	Logs        string `json:"logs,omitempty"`
+	LogsError   string `json:"logs_error,omitempty"`
+}
+
+// HelmTestReport is the structured response of helm_test
+type HelmTestReport struct {
+	Release   string           `json:"release"`
+	Namespace string           `json:"namespace"`
+	Status    string           `json:"status"`
+	Passed    int              `json:"passed"`
+	Failed    int              `json:"failed"`
+	Tests     []HelmTestResult `json:"tests"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// buildTestResults extracts the last run of the release's test hooks, optionally
+// only those named in tests
+func buildTestResults(statusOutput string, tests []string) ([]HelmTestResult, error) {
+	var status releaseStatus
+	if err := json.Unmarshal([]byte(statusOutput), &status); err != nil {
+		return nil, fmt.Errorf("failed to parse release status: %w", err)
+	}
+
+	results := []HelmTestResult{}
+	for _, hook := range status.Hooks {
+		if !slices.Contains(hook.Events, "test") && !slices.Contains(hook.Events, "test-success") {
+			continue
+		}
+		if len(tests) > 0 && !slices.Contains(tests, hook.Name) {
+			continue
+		}
+		result := HelmTestResult{
+			Name:        hook.Name,
+			Kind:        hook.Kind,
+			Phase:       hook.LastRun.Phase,
+			Passed:      hook.LastRun.Phase == "Succeeded",
+			StartedAt:   hook.LastRun.StartedAt,
+			CompletedAt: hook.LastRun.CompletedAt,
+		}
+		if result.Phase == "" {
+			result.Phase = "Unknown"
+		}
+		started, startErr := time.Parse(time.RFC3339Nano, hook.LastRun.StartedAt)
+		completed, completeErr := time.Parse(time.RFC3339Nano, hook.LastRun.CompletedAt)
+		if startErr == nil && completeErr == nil && !completed.Before(started) {
+			result.Duration = completed.Sub(started).Round(time.Millisecond).String()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// testHookLogs returns the logs of a test hook's pod, or of the pods of a Job hook
+func testHookLogs(ctx context.Context, namespace string, result HelmTestResult, lines int) (string, error) {
+	target := result.Name
+	if result.Kind == "Job" {
+		target = "job/" + result.Name
+	}
+	return commands.NewCommandBuilder("kubectl").
+		WithArgs("logs", target, "-n", namespace, "--all-containers", "--tail", fmt.Sprintf("%d", lines)).
+		WithKubeconfig(utils.GetKubeconfig()).
+		Execute(ctx)
+}
+
+// Helm test
+func handleHelmTest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("name", "", params.Required(), params.Check(security.ValidateHelmReleaseName))
+	namespace := p.String("namespace", "", params.Required(), params.Check(security.ValidateNamespace))
+	timeout := p.Duration("timeout", defaultReleaseTestTimeout)
+	testsParam := p.String("tests", "")
+	logLines := p.Int("log_lines", defaultTestLogLines, params.Min(0))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var tests []string
+	for _, test := range strings.Split(testsParam, ",") {
+		if test = strings.TrimSpace(test); test == "" {
+			continue
+		}
+		if err := security.ValidateK8sResourceName(test); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid test name %q: %v", test, err)), nil
+		}
+		tests = append(tests, test)
+	}
+
+	args := []string{"test", name, "-n", namespace}
+	for _, test := range tests {
+		args = append(args, "--filter", "name="+test)
+	}
+
+	report := HelmTestReport{Release: name, Namespace: namespace}
+	// A failing test fails the command, so its outcome is read from the release hooks
+	if _, err := runHelmCommandWithTimeout(ctx, args, timeout); err != nil {
+		report.Error = err.Error()
+	}
+
+	statusOutput, err := runHelmCommand(ctx, []string{"status", name, "-n", namespace, "-o", "json"})
+	if err != nil {
+		if report.Error != "" {
+			return mcp.NewToolResultError(fmt.Sprintf("Helm test command failed: %s", report.Error)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get release status: %v", err)), nil
+	}
+	report.Tests, err = buildTestResults(statusOutput, tests)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	for i, test := range report.Tests {
+		if test.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		if logLines == 0 {
+			continue
+		}
+		// Pods deleted by a hook-succeeded delete policy have no logs left
+		logs, err := testHookLogs(ctx, namespace, test, logLines)
+		if err != nil {
+			report.Tests[i].LogsError = err.Error()
+			continue
+		}
+		report.Tests[i].Logs = logs
+	}
+
+	switch {
+	case len(report.Tests) == 0 && report.Error == "":
+		report.Status = TestStatusNoTests
+	case report.Failed > 0 || report.Error != "":
+		report.Status = TestStatusFailed
+	default:
+		report.Status = TestStatusPassed
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling test report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
@@ -0,0 +1,99 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testReleaseStatus = `{"name":"web","namespace":"prod","hooks":[
+{"name":"web-test-connection","kind":"Pod","events":["test"],
+ "last_run":{"started_at":"2026-10-15T09:00:00.5Z","completed_at":"2026-10-15T09:00:04Z","phase":"Succeeded"}},
+{"name":"web-test-db","kind":"Job","events":["test"],
+ "last_run":{"started_at":"2026-10-15T09:00:04Z","completed_at":"2026-10-15T09:00:10Z","phase":"Failed"}},
+{"name":"web-migrate","kind":"Job","events":["pre-upgrade"],"last_run":{"phase":"Succeeded"}}
+]}`
+
+func TestBuildTestResults(t *testing.T) {
+	results, err := buildTestResults(testReleaseStatus, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, HelmTestResult{
+		Name:        "web-test-connection",
+		Kind:        "Pod",
+		Phase:       "Succeeded",
+		Passed:      true,
+		StartedAt:   "2026-10-15T09:00:00.5Z",
+		CompletedAt: "2026-10-15T09:00:04Z",
+		Duration:    "3.5s",
+	}, results[0])
+	assert.False(t, results[1].Passed)
+
+	results, err = buildTestResults(testReleaseStatus, []string{"web-test-db"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "web-test-db", results[0].Name)
+
+	_, err = buildTestResults("not json", nil)
+	assert.Error(t, err)
+}
+
+func TestHandleHelmTest(t *testing.T) {
+	t.Run("failed test is summarized with logs", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("helm", []string{"test", "web", "-n", "prod", "--timeout", "2m0s"}, "", errors.New("1 test failed"))
+		mock.AddCommandString("helm", []string{"status", "web", "-n", "prod", "-o", "json"}, testReleaseStatus, nil)
+		mock.AddCommandString("kubectl", []string{"logs", "web-test-connection", "-n", "prod", "--all-containers", "--tail", "50"}, "", errors.New("pods \"web-test-connection\" not found"))
+		mock.AddCommandString("kubectl", []string{"logs", "job/web-test-db", "-n", "prod", "--all-containers", "--tail", "50"}, "connection refused\n", nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"name": "web", "namespace": "prod", "timeout": "2m"}
+		result, err := handleHelmTest(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report HelmTestReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		assert.Equal(t, TestStatusFailed, report.Status)
+		assert.Equal(t, 1, report.Passed)
+		assert.Equal(t, 1, report.Failed)
+		assert.Contains(t, report.Error, "1 test failed")
+		require.Len(t, report.Tests, 2)
+		assert.Contains(t, report.Tests[0].LogsError, "not found")
+		assert.Equal(t, "connection refused\n", report.Tests[1].Logs)
+	})
+
+	t.Run("filtered tests without logs", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("helm", []string{"test", "web", "-n", "prod", "--filter", "name=web-test-connection", "--timeout", "5m0s"}, "Phase: Succeeded", nil)
+		mock.AddCommandString("helm", []string{"status", "web", "-n", "prod", "-o", "json"}, testReleaseStatus, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"name": "web", "namespace": "prod", "tests": "web-test-connection", "log_lines": float64(0)}
+		result, err := handleHelmTest(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report HelmTestReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		assert.Equal(t, TestStatusPassed, report.Status)
+		require.Len(t, report.Tests, 1)
+		assert.Len(t, mock.GetCallLog(), 2)
+	})
+
+	t.Run("invalid test name", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"name": "web", "namespace": "prod", "tests": "a;b"}
+		result, err := handleHelmTest(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
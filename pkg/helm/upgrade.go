@@ -0,0 +1,294 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/schema"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// defaultSafeUpgradeTimeout is the helm --timeout used when none is given
+const defaultSafeUpgradeTimeout = 5 * time.Minute
+
+// Upgrade statuses reported by helm_upgrade_safe
+const (
+	UpgradeStatusUpgraded             = "upgraded"
+	UpgradeStatusConfirmationRequired = "confirmation_required"
+)
+
+// ValueChange is a change to a single values key, identified by its dotted path
+type ValueChange struct {
+	Key    string      `json:"key"`
+	Action string      `json:"action"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// SafeUpgradeResult is the structured response of helm_upgrade_safe
+type SafeUpgradeResult struct {
+	Release         string        `json:"release"`
+	Namespace       string        `json:"namespace"`
+	Chart           string        `json:"chart"`
+	Status          string        `json:"status"`
+	SchemaValidated bool          `json:"schema_validated"`
+	SchemaWarning   string        `json:"schema_warning,omitempty"`
+	Changes         []ValueChange `json:"changes"`
+	FlaggedChanges  []ValueChange `json:"flagged_changes"`
+	Output          string        `json:"output,omitempty"`
+}
+
+// parseValues decodes YAML or JSON values into their JSON representation
+func parseValues(content string) (map[string]interface{}, error) {
+	var decoded interface{}
+	if err := yaml.Unmarshal([]byte(content), &decoded); err != nil {
+		return nil, fmt.Errorf("invalid values: %w", err)
+	}
+	if decoded == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	// Round-trip through JSON so numbers compare equal to values reported by helm
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid values: %w", err)
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("values must be a map: %w", err)
+	}
+	return values, nil
+}
+
+// flattenValues maps every leaf of a values tree to its dotted path
+func flattenValues(prefix string, value interface{}, out map[string]interface{}) {
+	nested, ok := value.(map[string]interface{})
+	if !ok || len(nested) == 0 {
+		if prefix != "" {
+			out[prefix] = value
+		}
+		return
+	}
+	for key, child := range nested {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		flattenValues(path, child, out)
+	}
+}
+
+// diffValues returns the key-level changes between two values trees, ordered by key
+func diffValues(current, proposed map[string]interface{}) []ValueChange {
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	flattenValues("", current, before)
+	flattenValues("", proposed, after)
+
+	changes := []ValueChange{}
+	for key, oldValue := range before {
+		newValue, ok := after[key]
+		switch {
+		case !ok:
+			changes = append(changes, ValueChange{Key: key, Action: "removed", Before: oldValue})
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, ValueChange{Key: key, Action: "changed", Before: oldValue, After: newValue})
+		}
+	}
+	for key, newValue := range after {
+		if _, ok := before[key]; !ok {
+			changes = append(changes, ValueChange{Key: key, Action: "added", After: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// isFlaggedKey reports whether a values key controls replicas, resources or an image tag
+func isFlaggedKey(key string) bool {
+	segments := strings.Split(strings.ToLower(key), ".")
+	for i, segment := range segments {
+		switch {
+		case segment == "replicas" || segment == "replicacount":
+			return true
+		case segment == "resources":
+			return true
+		case segment == "imagetag":
+			return true
+		case segment == "tag" && i > 0 && strings.Contains(segments[i-1], "image"):
+			return true
+		}
+	}
+	return false
+}
+
+// loadChartSchema returns the values.schema.json of a chart, or an empty string
+// if the chart has none. Charts that are not local directories are pulled into
+// a temporary directory.
+func loadChartSchema(ctx context.Context, chart, version string) (string, error) {
+	if info, err := os.Stat(chart); err == nil && info.IsDir() {
+		return readSchemaFile(filepath.Join(chart, "values.schema.json"))
+	}
+
+	dir, err := os.MkdirTemp("", "helm-chart-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(dir); removeErr != nil {
+			logger.Get().Error("Failed to remove temporary directory", "error", removeErr, "dir", dir)
+		}
+	}()
+
+	args := []string{"pull", chart, "--untar", "--untardir", dir}
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+	if _, err := runHelmCommand(ctx, args); err != nil {
+		return "", fmt.Errorf("failed to pull chart: %w", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*", "values.schema.json"))
+	if err != nil || len(matches) == 0 {
+		return "", nil
+	}
+	return readSchemaFile(matches[0])
+}
+
+// readSchemaFile reads a schema file, returning an empty string if it does not exist
+func readSchemaFile(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read values schema: %w", err)
+	}
+	return string(data), nil
+}
+
+// Helm schema-aware upgrade
+func handleHelmUpgradeSafe(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("name", "", params.Required(), params.Check(security.ValidateHelmReleaseName))
+	chart := p.String("chart", "", params.Required(), params.Check(security.ValidateCommandInput))
+	namespace := p.String("namespace", "", params.Required(), params.Check(security.ValidateNamespace))
+	version := p.String("version", "", params.Check(security.ValidateCommandInput))
+	valuesContent := p.String("values", "")
+	timeout := p.Duration("timeout", defaultSafeUpgradeTimeout)
+	confirm := p.Bool("confirm", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	proposed, err := parseValues(valuesContent)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result := SafeUpgradeResult{
+		Release:        name,
+		Namespace:      namespace,
+		Chart:          chart,
+		FlaggedChanges: []ValueChange{},
+	}
+
+	// Validate the values against the chart's schema when it ships one. Schemas
+	// using keywords the validator cannot parse are left to helm's own validation.
+	schemaContent, err := loadChartSchema(ctx, chart, version)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to load chart schema: %v", err)), nil
+	}
+	if schemaContent != "" {
+		valuesSchema, err := schema.Parse(schemaContent)
+		if err != nil {
+			result.SchemaWarning = fmt.Sprintf("values schema could not be parsed, relying on helm validation: %v", err)
+		} else {
+			if violations := valuesSchema.Validate(proposed); len(violations) > 0 {
+				messages := make([]string, 0, len(violations))
+				for _, violation := range violations {
+					messages = append(messages, violation.Error())
+				}
+				return mcp.NewToolResultError("Values do not match the chart schema:\n" + strings.Join(messages, "\n")), nil
+			}
+			result.SchemaValidated = true
+		}
+	}
+
+	currentJSON, err := runHelmCommand(ctx, []string{"get", "values", name, "-n", namespace, "-o", "json"})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get current release values: %v", err)), nil
+	}
+	current, err := parseValues(currentJSON)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse current release values: %v", err)), nil
+	}
+
+	result.Changes = diffValues(current, proposed)
+	for _, change := range result.Changes {
+		if isFlaggedKey(change.Key) {
+			result.FlaggedChanges = append(result.FlaggedChanges, change)
+		}
+	}
+
+	if len(result.FlaggedChanges) > 0 && !confirm {
+		result.Status = UpgradeStatusConfirmationRequired
+		return marshalSafeUpgradeResult(result)
+	}
+
+	tmpFile, err := os.CreateTemp("", "helm-values-*.yaml")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to create temp file: %v", err)), nil
+	}
+	defer func() {
+		if removeErr := os.Remove(tmpFile.Name()); removeErr != nil {
+			logger.Get().Error("Failed to remove temporary file", "error", removeErr, "file", tmpFile.Name())
+		}
+	}()
+	if _, err := tmpFile.WriteString(valuesContent); err != nil {
+		tmpFile.Close()
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to write to temp file: %v", err)), nil
+	}
+	if err := tmpFile.Close(); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to close temp file: %v", err)), nil
+	}
+
+	args := []string{"upgrade", name, chart, "-n", namespace, "-f", tmpFile.Name(), "--atomic"}
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+
+	prior := priorRelease(ctx, name, namespace)
+	output, err := runHelmCommandWithTimeout(ctx, args, timeout)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Helm upgrade command failed: %v", err)), nil
+	}
+	recordReleaseChange(ctx, "helm_upgrade_safe", fmt.Sprintf("upgraded to %s with %d changed values", chart, len(result.Changes)), prior)
+
+	result.Status = UpgradeStatusUpgraded
+	result.Output = output
+	return marshalSafeUpgradeResult(result)
+}
+
+func marshalSafeUpgradeResult(result SafeUpgradeResult) (*mcp.CallToolResult, error) {
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling upgrade result: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
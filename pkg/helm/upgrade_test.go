@@ -0,0 +1,165 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testValuesSchema = `{
+  "type": "object",
+  "properties": {
+    "replicaCount": {"type": "integer", "minimum": 1},
+    "image": {"type": "object", "properties": {"tag": {"type": "string"}}}
+  }
+}`
+
+func newTestChart(t *testing.T, schemaContent string) string {
+	dir := t.TempDir()
+	if schemaContent != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "values.schema.json"), []byte(schemaContent), 0o600))
+	}
+	return dir
+}
+
+func TestDiffValues(t *testing.T) {
+	current, err := parseValues(`{"replicaCount":2,"image":{"tag":"1.0"},"debug":true}`)
+	require.NoError(t, err)
+	proposed, err := parseValues("replicaCount: 3\nimage:\n  tag: \"1.0\"\nlogLevel: info\n")
+	require.NoError(t, err)
+
+	changes := diffValues(current, proposed)
+	assert.Equal(t, []ValueChange{
+		{Key: "debug", Action: "removed", Before: true},
+		{Key: "logLevel", Action: "added", After: "info"},
+		{Key: "replicaCount", Action: "changed", Before: float64(2), After: float64(3)},
+	}, changes)
+
+	_, err = parseValues("- not\n- a map\n")
+	assert.Error(t, err)
+}
+
+func TestIsFlaggedKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		flagged bool
+	}{
+		{"replicaCount", true},
+		{"worker.replicas", true},
+		{"resources.limits.memory", true},
+		{"image.tag", true},
+		{"sidecarImage.tag", true},
+		{"imageTag", true},
+		{"image.repository", false},
+		{"tag", false},
+		{"logLevel", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.flagged, isFlaggedKey(tt.key))
+		})
+	}
+}
+
+func TestHandleHelmUpgradeSafe(t *testing.T) {
+	currentValues := `{"replicaCount":2,"image":{"tag":"1.0"}}`
+
+	t.Run("flagged changes require confirmation", func(t *testing.T) {
+		chart := newTestChart(t, testValuesSchema)
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("helm", []string{"get", "values", "web", "-n", "prod", "-o", "json"}, currentValues, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"name":      "web",
+			"chart":     chart,
+			"namespace": "prod",
+			"values":    "replicaCount: 3\nimage:\n  tag: \"1.1\"\n",
+		}
+
+		result, err := handleHelmUpgradeSafe(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var upgrade SafeUpgradeResult
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &upgrade))
+		assert.Equal(t, UpgradeStatusConfirmationRequired, upgrade.Status)
+		assert.True(t, upgrade.SchemaValidated)
+		assert.Len(t, upgrade.FlaggedChanges, 2)
+		assert.Len(t, mock.GetCallLog(), 1)
+	})
+
+	t.Run("confirmed upgrade runs atomically", func(t *testing.T) {
+		chart := newTestChart(t, "")
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("helm", []string{"get", "values", "web", "-n", "prod", "-o", "json"}, currentValues, nil)
+		mock.AddPartialMatcherString("helm", []string{"upgrade", "web", "--atomic", "--timeout"}, "Release \"web\" has been upgraded.", nil)
+		mock.AddCommandString("helm", []string{"list", "-a", "--filter", "^web$", "-o", "json", "-n", "prod"}, "[]", nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"name":      "web",
+			"chart":     chart,
+			"namespace": "prod",
+			"values":    "replicaCount: 3\n",
+			"timeout":   "10m",
+			"confirm":   "true",
+		}
+
+		result, err := handleHelmUpgradeSafe(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var upgrade SafeUpgradeResult
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &upgrade))
+		assert.Equal(t, UpgradeStatusUpgraded, upgrade.Status)
+		assert.False(t, upgrade.SchemaValidated)
+
+		callLog := mock.GetCallLog()
+		require.Len(t, callLog, 3)
+		args := callLog[2].Args
+		assert.Equal(t, []string{"upgrade", "web", chart, "-n", "prod", "-f"}, args[:6])
+		assert.Equal(t, []string{"--atomic", "--timeout", "10m0s"}, args[7:])
+	})
+
+	t.Run("schema violations are rejected", func(t *testing.T) {
+		chart := newTestChart(t, testValuesSchema)
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"name":      "web",
+			"chart":     chart,
+			"namespace": "prod",
+			"values":    "replicaCount: 0\n",
+		}
+
+		result, err := handleHelmUpgradeSafe(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "replicaCount")
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		tests := []map[string]interface{}{
+			{"name": "web", "chart": "stable/web"},
+			{"name": "web", "chart": "stable/web", "namespace": "prod", "timeout": "soon"},
+			{"name": "web", "chart": "stable/web", "namespace": "prod", "values": "[1, 2"},
+		}
+		for _, args := range tests {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = args
+			result, err := handleHelmUpgradeSafe(context.Background(), request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+		}
+	})
+}
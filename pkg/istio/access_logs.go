@@ -0,0 +1,454 @@
+package istio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/cache"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+	"github.com/kagent-dev/tools/pkg/utils"
+)
+
+// Defaults of istio_access_log_sample
+const (
+	defaultAccessLogCount    = 50
+	maxAccessLogCount        = 1000
+	defaultAccessLogDuration = 30 * time.Second
+	defaultAccessLogInterval = 5 * time.Second
+	// accessLogTopPaths is the number of failing paths reported
+	accessLogTopPaths = 10
+)
+
+// sidecarContainer is the container of the Envoy sidecar
+const sidecarContainer = "istio-proxy"
+
+// envoyTextLogPattern matches Istio's default TEXT access log format, e.g.
+// [2024-05-01T10:00:00.000Z] "GET /api HTTP/1.1" 503 UF upstream_reset_before_response_started{connection_failure} - "-" 0 91 3 - "-" "curl/8.4.0" "3f2c..." "api:8080" "10.0.1.7:8080" outbound|8080||api.shop.svc.cluster.local ...
+var envoyTextLogPattern = regexp.MustCompile(`^\[([^\]]+)\] "(\S+) (\S+) [^"]*" (\d+) (\S+) (\S+) \S+ "[^"]*" \d+ \d+ (\d+|-) (\d+|-) "[^"]*" "[^"]*" "[^"]*" "([^"]*)" "([^"]*)" (\S+)`)
+
+// AccessLogEntry is a request logged by Envoy
+type AccessLogEntry struct {
+	Time          string `json:"time"`
+	Method        string `json:"method"`
+	Path          string `json:"path"`
+	Authority     string `json:"authority,omitempty"`
+	Status        int    `json:"status"`
+	ResponseFlags string `json:"response_flags,omitempty"`
+	Details       string `json:"details,omitempty"`
+	DurationMs    int    `json:"duration_ms"`
+	// UpstreamMs is the time the upstream took to respond, when it responded
+	UpstreamMs      *int   `json:"upstream_ms,omitempty"`
+	UpstreamHost    string `json:"upstream_host,omitempty"`
+	UpstreamCluster string `json:"upstream_cluster,omitempty"`
+}
+
+// failed reports whether the request failed, with a 5xx status or without a response
+func (e AccessLogEntry) failed() bool {
+	return e.Status >= 500 || e.Status == 0
+}
+
+// envoyJSONLogEntry is an access log entry in Istio's JSON encoding
+type envoyJSONLogEntry struct {
+	StartTime           string          `json:"start_time"`
+	Method              string          `json:"method"`
+	Path                string          `json:"path"`
+	Authority           string          `json:"authority"`
+	ResponseCode        int             `json:"response_code"`
+	ResponseFlags       string          `json:"response_flags"`
+	ResponseCodeDetails string          `json:"response_code_details"`
+	Duration            int             `json:"duration"`
+	UpstreamServiceTime json.RawMessage `json:"upstream_service_time"`
+	UpstreamHost        string          `json:"upstream_host"`
+	UpstreamCluster     string          `json:"upstream_cluster"`
+}
+
+// optionalInt parses a timing that Envoy logs as "-" when it is unknown
+func optionalInt(value string) *int {
+	n, err := strconv.Atoi(strings.Trim(value, `"`))
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// logField returns an access log field, or an empty string for Envoy's "-" placeholder
+func logField(value string) string {
+	if value == "-" {
+		return ""
+	}
+	return value
+}
+
+// parseAccessLogLine parses a sidecar log line in the TEXT or JSON access log
+// encoding. Lines that are not access log entries, such as Envoy's own logs,
+// are reported as not ok.
+func parseAccessLogLine(line string) (AccessLogEntry, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		var raw envoyJSONLogEntry
+		if err := json.Unmarshal([]byte(line), &raw); err != nil || raw.StartTime == "" {
+			return AccessLogEntry{}, false
+		}
+		return AccessLogEntry{
+			Time:            raw.StartTime,
+			Method:          logField(raw.Method),
+			Path:            logField(raw.Path),
+			Authority:       logField(raw.Authority),
+			Status:          raw.ResponseCode,
+			ResponseFlags:   logField(raw.ResponseFlags),
+			Details:         logField(raw.ResponseCodeDetails),
+			DurationMs:      raw.Duration,
+			UpstreamMs:      optionalInt(string(raw.UpstreamServiceTime)),
+			UpstreamHost:    logField(raw.UpstreamHost),
+			UpstreamCluster: logField(raw.UpstreamCluster),
+		}, true
+	}
+
+	match := envoyTextLogPattern.FindStringSubmatch(line)
+	if match == nil {
+		return AccessLogEntry{}, false
+	}
+	status, _ := strconv.Atoi(match[4])
+	duration, _ := strconv.Atoi(match[7])
+	return AccessLogEntry{
+		Time:            match[1],
+		Method:          logField(match[2]),
+		Path:            logField(match[3]),
+		Status:          status,
+		ResponseFlags:   logField(match[5]),
+		Details:         logField(match[6]),
+		DurationMs:      duration,
+		UpstreamMs:      optionalInt(match[8]),
+		Authority:       logField(match[9]),
+		UpstreamHost:    logField(match[10]),
+		UpstreamCluster: logField(match[11]),
+	}, true
+}
+
+// parseAccessLogs returns the access log entries of sidecar logs, at most limit,
+// and the number of other lines
+func parseAccessLogs(logs string, limit int) ([]AccessLogEntry, int) {
+	entries := []AccessLogEntry{}
+	skipped := 0
+	for _, line := range strings.Split(logs, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry, ok := parseAccessLogLine(line)
+		if !ok {
+			skipped++
+			continue
+		}
+		if len(entries) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, skipped
+}
+
+// LatencySummary is the distribution of a request timing in milliseconds
+type LatencySummary struct {
+	P50 int `json:"p50"`
+	P95 int `json:"p95"`
+	P99 int `json:"p99"`
+	Max int `json:"max"`
+}
+
+// summarizeLatency returns the percentiles of values, or nil without values
+func summarizeLatency(values []int) *LatencySummary {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	percentile := func(p float64) int {
+		return sorted[int(p*float64(len(sorted)-1))]
+	}
+	return &LatencySummary{P50: percentile(0.5), P95: percentile(0.95), P99: percentile(0.99), Max: sorted[len(sorted)-1]}
+}
+
+// AccessLogCount is a count of requests, such as those failing for a path
+type AccessLogCount struct {
+	Key      string `json:"key"`
+	Requests int    `json:"requests"`
+	Errors   int    `json:"errors"`
+}
+
+// AccessLogSummary summarizes sampled requests
+type AccessLogSummary struct {
+	Requests      int             `json:"requests"`
+	Errors        int             `json:"errors"`
+	ErrorRate     float64         `json:"error_rate"`
+	StatusCodes   map[string]int  `json:"status_codes"`
+	StatusClasses map[string]int  `json:"status_classes"`
+	ResponseFlags map[string]int  `json:"response_flags,omitempty"`
+	Duration      *LatencySummary `json:"duration_ms,omitempty"`
+	Upstream      *LatencySummary `json:"upstream_ms,omitempty"`
+	// FailingPaths are the paths with the most failed requests
+	FailingPaths []AccessLogCount `json:"failing_paths,omitempty"`
+	// UpstreamClusters counts requests and failures per upstream cluster
+	UpstreamClusters []AccessLogCount `json:"upstream_clusters,omitempty"`
+}
+
+// sortedCounts returns counts sorted by errors, then requests, then key
+func sortedCounts(counts map[string]*AccessLogCount) []AccessLogCount {
+	result := make([]AccessLogCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Errors != result[j].Errors {
+			return result[i].Errors > result[j].Errors
+		}
+		if result[i].Requests != result[j].Requests {
+			return result[i].Requests > result[j].Requests
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// summarizeAccessLogs computes the error distribution and timings of entries
+func summarizeAccessLogs(entries []AccessLogEntry) AccessLogSummary {
+	summary := AccessLogSummary{Requests: len(entries), StatusCodes: map[string]int{}, StatusClasses: map[string]int{}}
+	flags := map[string]int{}
+	paths := map[string]*AccessLogCount{}
+	clusters := map[string]*AccessLogCount{}
+	var durations, upstream []int
+
+	for _, entry := range entries {
+		summary.StatusCodes[strconv.Itoa(entry.Status)]++
+		if entry.Status == 0 {
+			summary.StatusClasses["no_response"]++
+		} else {
+			summary.StatusClasses[fmt.Sprintf("%dxx", entry.Status/100)]++
+		}
+		if entry.ResponseFlags != "" {
+			for _, flag := range strings.Split(entry.ResponseFlags, ",") {
+				flags[flag]++
+			}
+		}
+		durations = append(durations, entry.DurationMs)
+		if entry.UpstreamMs != nil {
+			upstream = append(upstream, *entry.UpstreamMs)
+		}
+
+		failed := entry.failed()
+		if failed {
+			summary.Errors++
+			path := entry.Method + " " + entry.Path
+			if paths[path] == nil {
+				paths[path] = &AccessLogCount{Key: path}
+			}
+			paths[path].Requests++
+			paths[path].Errors++
+		}
+		if entry.UpstreamCluster != "" {
+			if clusters[entry.UpstreamCluster] == nil {
+				clusters[entry.UpstreamCluster] = &AccessLogCount{Key: entry.UpstreamCluster}
+			}
+			clusters[entry.UpstreamCluster].Requests++
+			if failed {
+				clusters[entry.UpstreamCluster].Errors++
+			}
+		}
+	}
+
+	if summary.Requests > 0 {
+		summary.ErrorRate = float64(summary.Errors) / float64(summary.Requests)
+	}
+	if len(flags) > 0 {
+		summary.ResponseFlags = flags
+	}
+	summary.Duration = summarizeLatency(durations)
+	summary.Upstream = summarizeLatency(upstream)
+	summary.FailingPaths = sortedCounts(paths)
+	if len(summary.FailingPaths) > accessLogTopPaths {
+		summary.FailingPaths = summary.FailingPaths[:accessLogTopPaths]
+	}
+	summary.UpstreamClusters = sortedCounts(clusters)
+	return summary
+}
+
+// AccessLogReport is the structured response of istio_access_log_sample
+type AccessLogReport struct {
+	Pod       string `json:"pod"`
+	Namespace string `json:"namespace"`
+	// Telemetry is the Telemetry resource that enabled access logging during sampling
+	Telemetry    string           `json:"telemetry,omitempty"`
+	Since        string           `json:"since"`
+	Summary      AccessLogSummary `json:"summary"`
+	Entries      []AccessLogEntry `json:"entries,omitempty"`
+	SkippedLines int              `json:"skipped_lines"`
+	Note         string           `json:"note,omitempty"`
+}
+
+// accessLogTelemetryName returns the name of the Telemetry resource enabling access logs for a pod
+func accessLogTelemetryName(pod string) string {
+	name := "kagent-access-log-" + pod
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-.")
+	}
+	return name
+}
+
+// accessLogTelemetryManifest renders a Telemetry resource enabling Envoy access
+// logs for the pods with the given labels
+func accessLogTelemetryManifest(name, namespace string, labels map[string]string) (string, error) {
+	telemetry := map[string]interface{}{
+		"apiVersion": "telemetry.istio.io/v1",
+		"kind":       "Telemetry",
+		"metadata":   map[string]string{"name": name, "namespace": namespace},
+		"spec": map[string]interface{}{
+			"selector":      map[string]interface{}{"matchLabels": labels},
+			"accessLogging": []interface{}{map[string]interface{}{"providers": []interface{}{map[string]string{"name": "envoy"}}}},
+		},
+	}
+	manifest, err := json.Marshal(telemetry)
+	return string(manifest), err
+}
+
+// sidecarPod is the subset of a pod used to sample its access logs
+type sidecarPod struct {
+	Metadata struct {
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		Containers []struct {
+			Name string `json:"name"`
+		} `json:"containers"`
+		// Native sidecars run as init containers
+		InitContainers []struct {
+			Name string `json:"name"`
+		} `json:"initContainers"`
+	} `json:"spec"`
+}
+
+// hasSidecar reports whether the pod runs an Envoy sidecar
+func (p sidecarPod) hasSidecar() bool {
+	for _, c := range append(p.Spec.Containers, p.Spec.InitContainers...) {
+		if c.Name == sidecarContainer {
+			return true
+		}
+	}
+	return false
+}
+
+// Istio access log sampling
+func handleIstioAccessLogSample(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	pod := p.String("pod_name", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	count := p.Int("count", defaultAccessLogCount, params.Range(1, maxAccessLogCount))
+	duration := p.Duration("duration", defaultAccessLogDuration)
+	interval := p.Duration("interval", defaultAccessLogInterval)
+	enable := p.Bool("enable", true)
+	includeEntries := p.Bool("include_entries", true)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if interval <= 0 {
+		interval = defaultAccessLogInterval
+	}
+
+	output, err := runKubectl(ctx, "get", "pod", pod, "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get pod %s: %v", pod, err)), nil
+	}
+	var target sidecarPod
+	if err := json.Unmarshal([]byte(output), &target); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to parse pod %s: %v", pod, err)), nil
+	}
+	if !target.hasSidecar() {
+		return mcp.NewToolResultError(fmt.Sprintf("pod %s has no %s sidecar; access logs of ambient workloads are in their ztunnel or waypoint", pod, sidecarContainer)), nil
+	}
+
+	report := AccessLogReport{Pod: pod, Namespace: namespace}
+	since := time.Now().UTC()
+	logArgs := []string{"logs", pod, "-n", namespace, "-c", sidecarContainer}
+	disable := func() {}
+	if enable {
+		name := accessLogTelemetryName(pod)
+		manifest, err := accessLogTelemetryManifest(name, namespace, target.Metadata.Labels)
+		if err != nil {
+			return mcp.NewToolResultError("failed to render Telemetry: " + err.Error()), nil
+		}
+		filename, err := writeTempManifest("istio-access-log-*.json", manifest)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		defer removeTempManifest(filename)
+		if _, err := runKubectl(ctx, "apply", "-f", filename); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to enable access logging: %v", err)), nil
+		}
+		cache.InvalidateKubernetesCache()
+		report.Telemetry = namespace + "/" + name
+		logArgs = append(logArgs, "--since-time", since.Format(time.RFC3339))
+
+		// Access logging is disabled again even when the request was cancelled
+		disable = func() {
+			if _, err := runKubectl(context.WithoutCancel(ctx), "delete", "telemetry", name, "-n", namespace, "--ignore-not-found"); err != nil {
+				report.Note = fmt.Sprintf("failed to delete Telemetry %s, delete it to disable access logging: %v", report.Telemetry, err)
+			}
+			cache.InvalidateKubernetesCache()
+		}
+	} else {
+		since = since.Add(-duration)
+		logArgs = append(logArgs, "--since", duration.String())
+	}
+	report.Since = since.Format(time.RFC3339)
+
+	logs, err := collectAccessLogs(ctx, logArgs, count, duration, interval, enable, utils.ProgressReporter(ctx, request))
+	disable()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	entries, skipped := parseAccessLogs(logs, count)
+	report.Summary = summarizeAccessLogs(entries)
+	report.SkippedLines = skipped
+	if includeEntries {
+		report.Entries = entries
+	}
+	if len(entries) == 0 && report.Note == "" {
+		report.Note = "no requests were logged; check that the workload received traffic, and that the envoy access log provider is enabled in the mesh config when sampling with enable=true"
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal access log report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
+
+// collectAccessLogs reads the sidecar logs. When wait is true it polls them
+// until count requests were logged or duration elapsed, reporting the number
+// of requests sampled after each read.
+func collectAccessLogs(ctx context.Context, logArgs []string, count int, duration, interval time.Duration, wait bool, reportProgress func(int, int, string)) (string, error) {
+	deadline := time.Now().Add(duration)
+	for {
+		logs, err := runKubectl(ctx, logArgs...)
+		if err != nil {
+			return "", fmt.Errorf("failed to get %s logs: %w", sidecarContainer, err)
+		}
+		entries, _ := parseAccessLogs(logs, count)
+		if !wait || len(entries) >= count || !time.Now().Add(interval).Before(deadline) {
+			return logs, nil
+		}
+		reportProgress(len(entries), count, fmt.Sprintf("sampled %d of %d requests", len(entries), count))
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("access log sampling cancelled: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
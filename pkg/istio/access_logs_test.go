@@ -0,0 +1,154 @@
+package istio
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSidecarPod = `{"metadata":{"labels":{"app":"web","version":"v1"}},"spec":{"containers":[{"name":"web"},{"name":"istio-proxy"}]}}`
+
+const testSidecarLogs = `2024-05-01T10:00:00.000000Z	info	Envoy proxy is ready
+[2024-05-01T10:00:01.000Z] "GET /api/items HTTP/1.1" 200 - via_upstream - "-" 0 512 12 10 "-" "curl/8.4.0" "a1" "web.shop:8080" "10.0.1.7:8080" inbound|8080|| 127.0.0.6:41234 10.0.1.7:8080 10.0.2.3:50000 - default
+[2024-05-01T10:00:02.000Z] "GET /api/items HTTP/1.1" 503 UF upstream_reset_before_response_started{connection_failure} - "-" 0 91 3 - "-" "curl/8.4.0" "a2" "api:8080" "10.0.1.9:8080" outbound|8080||api.shop.svc.cluster.local - 10.96.0.10:8080 10.0.1.7:51000 - default
+[2024-05-01T10:00:03.000Z] "POST /api/orders HTTP/1.1" 504 UT response_timeout - "-" 120 24 15000 - "-" "curl/8.4.0" "a3" "api:8080" "10.0.1.9:8080" outbound|8080||api.shop.svc.cluster.local - 10.96.0.10:8080 10.0.1.7:51002 - default
+{"start_time":"2024-05-01T10:00:04.000Z","method":"GET","path":"/healthz","authority":"web.shop:8080","response_code":200,"response_flags":"-","duration":2,"upstream_service_time":"1","upstream_host":"10.0.1.7:8080","upstream_cluster":"inbound|8080||"}
+`
+
+func TestParseAccessLogLine(t *testing.T) {
+	entries, skipped := parseAccessLogs(testSidecarLogs, 10)
+	require.Len(t, entries, 4)
+	assert.Equal(t, 1, skipped)
+
+	assert.Equal(t, "GET", entries[0].Method)
+	assert.Equal(t, "/api/items", entries[0].Path)
+	assert.Equal(t, 200, entries[0].Status)
+	assert.Empty(t, entries[0].ResponseFlags)
+	assert.Equal(t, "web.shop:8080", entries[0].Authority)
+	require.NotNil(t, entries[0].UpstreamMs)
+	assert.Equal(t, 10, *entries[0].UpstreamMs)
+
+	assert.Equal(t, 503, entries[1].Status)
+	assert.Equal(t, "UF", entries[1].ResponseFlags)
+	assert.Equal(t, "upstream_reset_before_response_started{connection_failure}", entries[1].Details)
+	assert.Nil(t, entries[1].UpstreamMs)
+	assert.Equal(t, "outbound|8080||api.shop.svc.cluster.local", entries[1].UpstreamCluster)
+
+	assert.Equal(t, "/healthz", entries[3].Path)
+	assert.Empty(t, entries[3].ResponseFlags)
+	require.NotNil(t, entries[3].UpstreamMs)
+	assert.Equal(t, 1, *entries[3].UpstreamMs)
+
+	limited, _ := parseAccessLogs(testSidecarLogs, 2)
+	assert.Len(t, limited, 2)
+}
+
+func TestSummarizeAccessLogs(t *testing.T) {
+	entries, _ := parseAccessLogs(testSidecarLogs, 10)
+	summary := summarizeAccessLogs(entries)
+
+	assert.Equal(t, 4, summary.Requests)
+	assert.Equal(t, 2, summary.Errors)
+	assert.Equal(t, 0.5, summary.ErrorRate)
+	assert.Equal(t, map[string]int{"200": 2, "503": 1, "504": 1}, summary.StatusCodes)
+	assert.Equal(t, map[string]int{"2xx": 2, "5xx": 2}, summary.StatusClasses)
+	assert.Equal(t, map[string]int{"UF": 1, "UT": 1}, summary.ResponseFlags)
+	require.NotNil(t, summary.Duration)
+	assert.Equal(t, 15000, summary.Duration.Max)
+	require.NotNil(t, summary.Upstream)
+	assert.Equal(t, 10, summary.Upstream.Max)
+
+	assert.Equal(t, []AccessLogCount{
+		{Key: "GET /api/items", Requests: 1, Errors: 1},
+		{Key: "POST /api/orders", Requests: 1, Errors: 1},
+	}, summary.FailingPaths)
+	require.Len(t, summary.UpstreamClusters, 2)
+	assert.Equal(t, AccessLogCount{Key: "outbound|8080||api.shop.svc.cluster.local", Requests: 2, Errors: 2}, summary.UpstreamClusters[0])
+}
+
+func TestHandleIstioAccessLogSample(t *testing.T) {
+	call := func(t *testing.T, mock *cmd.MockShellExecutor, args map[string]interface{}) AccessLogReport {
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handleIstioAccessLogSample(ctx, request)
+		require.NoError(t, err)
+		text := result.Content[0].(mcp.TextContent).Text
+		require.False(t, result.IsError, text)
+
+		var report AccessLogReport
+		require.NoError(t, json.Unmarshal([]byte(text), &report))
+		return report
+	}
+
+	t.Run("enables access logging while sampling", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pod", "web-1", "-n", "shop", "-o", "json"}, testSidecarPod, nil)
+		mock.AddPartialMatcherString("kubectl", []string{"apply", "-f", "istio-access-log-"}, "telemetry.telemetry.istio.io/kagent-access-log-web-1 created", nil)
+		mock.AddPartialMatcherString("kubectl", []string{"logs", "web-1", "istio-proxy", "--since-time"}, testSidecarLogs, nil)
+		mock.AddCommandString("kubectl", []string{"delete", "telemetry", "kagent-access-log-web-1", "-n", "shop", "--ignore-not-found"}, "deleted", nil)
+
+		report := call(t, mock, map[string]interface{}{"pod_name": "web-1", "namespace": "shop", "count": float64(3), "duration": "1s", "interval": "10ms"})
+		assert.Equal(t, "shop/kagent-access-log-web-1", report.Telemetry)
+		assert.Equal(t, 3, report.Summary.Requests)
+		assert.Len(t, report.Entries, 3)
+		assert.Empty(t, report.Note)
+
+		var commands []string
+		for _, c := range mock.GetCallLog() {
+			commands = append(commands, c.Args[0])
+		}
+		// Enough requests were logged on the first read, so the logs are read once
+		assert.Equal(t, []string{"get", "apply", "logs", "delete"}, commands)
+	})
+
+	t.Run("reads existing logs", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pod", "web-1", "-n", "shop", "-o", "json"}, testSidecarPod, nil)
+		mock.AddCommandString("kubectl", []string{"logs", "web-1", "-n", "shop", "-c", "istio-proxy", "--since", "10m0s"}, testSidecarLogs, nil)
+
+		report := call(t, mock, map[string]interface{}{"pod_name": "web-1", "namespace": "shop", "enable": "false", "duration": "10m", "include_entries": "false"})
+		assert.Empty(t, report.Telemetry)
+		assert.Equal(t, 4, report.Summary.Requests)
+		assert.Empty(t, report.Entries)
+		assert.Equal(t, 1, report.SkippedLines)
+	})
+
+	t.Run("notes a failed cleanup", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pod", "web-1", "-n", "shop", "-o", "json"}, testSidecarPod, nil)
+		mock.AddPartialMatcherString("kubectl", []string{"apply", "-f"}, "created", nil)
+		mock.AddPartialMatcherString("kubectl", []string{"logs", "web-1"}, "", nil)
+
+		report := call(t, mock, map[string]interface{}{"pod_name": "web-1", "namespace": "shop", "duration": "20ms", "interval": "10ms"})
+		assert.Equal(t, 0, report.Summary.Requests)
+		assert.True(t, strings.HasPrefix(report.Note, "failed to delete Telemetry shop/kagent-access-log-web-1"), report.Note)
+	})
+
+	t.Run("pod without sidecar", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pod", "web-1", "-n", "shop", "-o", "json"}, `{"spec":{"containers":[{"name":"web"}]}}`, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"pod_name": "web-1", "namespace": "shop"}
+		result, err := handleIstioAccessLogSample(ctx, request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "no istio-proxy sidecar")
+		assert.Len(t, mock.GetCallLog(), 1)
+	})
+}
+
+func TestAccessLogTelemetryName(t *testing.T) {
+	assert.Equal(t, "kagent-access-log-web-1", accessLogTelemetryName("web-1"))
+	long := accessLogTelemetryName(strings.Repeat("a", 40) + "-" + strings.Repeat("b", 40))
+	assert.LessOrEqual(t, len(long), 63)
+	assert.False(t, strings.HasSuffix(long, "-"))
+}
@@ -0,0 +1,399 @@
+package istio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/cache"
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+	"github.com/kagent-dev/tools/pkg/prometheus"
+	"github.com/kagent-dev/tools/pkg/utils"
+)
+
+// Defaults of istio_deploy_canary
+const (
+	defaultCanaryWeight       = 10
+	defaultCanaryBakeTime     = 5 * time.Minute
+	defaultCanaryInterval     = 30 * time.Second
+	defaultCanaryReadyTimeout = 5 * time.Minute
+	defaultCanaryMaxErrorRate = "0.01"
+	defaultCanaryMaxLatencyMs = 500
+	defaultCanaryRateWindow   = "1m"
+	defaultVersionLabel       = "version"
+)
+
+// Subsets of the DestinationRule managed by istio_deploy_canary
+const (
+	stableSubset = "stable"
+	canarySubset = "canary"
+)
+
+// Final statuses reported by istio_deploy_canary
+const (
+	CanaryStatusPromoted       = "promoted"
+	CanaryStatusReadyToPromote = "ready_to_promote"
+	CanaryStatusRolledBack     = "rolled_back"
+	CanaryStatusFailed         = "failed"
+)
+
+// Statuses of the steps of a canary deployment
+const (
+	canaryStepSucceeded = "succeeded"
+	canaryStepFailed    = "failed"
+)
+
+// canaryTarget identifies the service whose traffic is split and its two versions
+type canaryTarget struct {
+	Service       string
+	Namespace     string
+	VersionLabel  string
+	StableVersion string
+	CanaryVersion string
+}
+
+// host returns the fully qualified host of the service
+func (c canaryTarget) host() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", c.Service, c.Namespace)
+}
+
+// trafficSplitManifest renders the DestinationRule with the stable and canary
+// subsets and the VirtualService routing canaryWeight percent to the canary
+func trafficSplitManifest(target canaryTarget, canaryWeight int) string {
+	return fmt.Sprintf(`apiVersion: networking.istio.io/v1
+kind: DestinationRule
+metadata:
+  name: %[1]s
+  namespace: %[2]s
+spec:
+  host: %[3]s
+  subsets:
+  - name: %[4]s
+    labels:
+      %[5]s: %[6]q
+  - name: %[7]s
+    labels:
+      %[5]s: %[8]q
+---
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: %[1]s
+  namespace: %[2]s
+spec:
+  hosts:
+  - %[1]s
+  http:
+  - route:
+    - destination:
+        host: %[3]s
+        subset: %[4]s
+      weight: %[9]d
+    - destination:
+        host: %[3]s
+        subset: %[7]s
+      weight: %[10]d
+`, target.Service, target.Namespace, target.host(), stableSubset, target.VersionLabel, target.StableVersion,
+		canarySubset, target.CanaryVersion, 100-canaryWeight, canaryWeight)
+}
+
+// canaryQueries returns the default error rate and p95 latency queries of the
+// canary, based on Istio's standard request metrics
+func canaryQueries(target canaryTarget, window string) (string, string) {
+	selector := fmt.Sprintf(`reporter="destination",destination_service_name="%s",destination_service_namespace="%s",destination_version="%s"`,
+		target.Service, target.Namespace, target.CanaryVersion)
+	errorRate := fmt.Sprintf(`sum(rate(istio_requests_total{%[1]s,response_code=~"5.."}[%[2]s])) / sum(rate(istio_requests_total{%[1]s}[%[2]s]))`,
+		selector, window)
+	latency := fmt.Sprintf(`histogram_quantile(0.95, sum(rate(istio_request_duration_milliseconds_bucket{%s}[%s])) by (le))`,
+		selector, window)
+	return errorRate, latency
+}
+
+// CanaryStep is the outcome of a step of a canary deployment
+type CanaryStep struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// CanaryCheck is a health check of the canary during the bake period
+type CanaryCheck struct {
+	Time      string   `json:"time"`
+	ErrorRate *float64 `json:"error_rate,omitempty"`
+	LatencyMs *float64 `json:"latency_p95_ms,omitempty"`
+	Healthy   bool     `json:"healthy"`
+	Reason    string   `json:"reason,omitempty"`
+}
+
+// CanaryReport is the structured response of istio_deploy_canary
+type CanaryReport struct {
+	Service       string        `json:"service"`
+	Namespace     string        `json:"namespace"`
+	StableVersion string        `json:"stable_version"`
+	CanaryVersion string        `json:"canary_version"`
+	Weight        int           `json:"weight"`
+	Status        string        `json:"status"`
+	Reason        string        `json:"reason"`
+	ErrorQuery    string        `json:"error_rate_query"`
+	LatencyQuery  string        `json:"latency_query"`
+	Steps         []CanaryStep  `json:"steps"`
+	Checks        []CanaryCheck `json:"checks"`
+}
+
+// canaryThresholds are the limits the canary must stay within during the bake period
+type canaryThresholds struct {
+	MaxErrorRate float64
+	MaxLatencyMs float64
+}
+
+// checkCanary queries the canary's error rate and latency and compares them with
+// the thresholds. Queries without data, e.g. before the canary received traffic,
+// do not fail the check.
+func checkCanary(ctx context.Context, prometheusURL, errorQuery, latencyQuery string, thresholds canaryThresholds) CanaryCheck {
+	check := CanaryCheck{Time: time.Now().UTC().Format(time.RFC3339), Healthy: true}
+	errorRate, err := prometheus.QueryValue(ctx, prometheusURL, errorQuery)
+	if err != nil {
+		check.Healthy, check.Reason = false, "error rate query failed: "+err.Error()
+		return check
+	}
+	latency, err := prometheus.QueryValue(ctx, prometheusURL, latencyQuery)
+	if err != nil {
+		check.Healthy, check.Reason = false, "latency query failed: "+err.Error()
+		return check
+	}
+	check.ErrorRate, check.LatencyMs = errorRate, latency
+
+	switch {
+	case errorRate != nil && *errorRate > thresholds.MaxErrorRate:
+		check.Healthy = false
+		check.Reason = fmt.Sprintf("error rate %.4f exceeds %.4f", *errorRate, thresholds.MaxErrorRate)
+	case latency != nil && *latency > thresholds.MaxLatencyMs:
+		check.Healthy = false
+		check.Reason = fmt.Sprintf("p95 latency %.0fms exceeds %.0fms", *latency, thresholds.MaxLatencyMs)
+	case errorRate == nil && latency == nil:
+		check.Reason = "no canary traffic yet"
+	}
+	return check
+}
+
+// runKubectl runs a kubectl command against the configured kubeconfig
+func runKubectl(ctx context.Context, args ...string) (string, error) {
+	return commands.NewCommandBuilder("kubectl").
+		WithArgs(args...).
+		WithKubeconfig(utils.GetKubeconfig()).
+		Execute(ctx)
+}
+
+// writeTempManifest writes content to a temporary file and returns its name
+func writeTempManifest(pattern, content string) (string, error) {
+	tmpFile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if _, err := tmpFile.WriteString(content); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to close temp file: %w", err)
+	}
+	return tmpFile.Name(), nil
+}
+
+// removeTempManifest removes a file written by writeTempManifest
+func removeTempManifest(name string) {
+	if err := os.Remove(name); err != nil {
+		logger.Get().Error("Failed to remove temporary file", "error", err, "file", name)
+	}
+}
+
+// canaryRun records the steps of a canary deployment in its report
+type canaryRun struct {
+	target   canaryTarget
+	manifest string
+	report   *CanaryReport
+}
+
+// step runs a kubectl command and records its outcome
+func (r *canaryRun) step(ctx context.Context, name string, args ...string) bool {
+	output, err := runKubectl(ctx, args...)
+	step := CanaryStep{Name: name, Status: canaryStepSucceeded, Output: output}
+	if err != nil {
+		step.Status, step.Error = canaryStepFailed, err.Error()
+	}
+	r.report.Steps = append(r.report.Steps, step)
+	return err == nil
+}
+
+// route applies the traffic split sending canaryWeight percent to the canary
+func (r *canaryRun) route(ctx context.Context, name string, canaryWeight int) bool {
+	filename, err := writeTempManifest("istio-canary-route-*.yaml", trafficSplitManifest(r.target, canaryWeight))
+	if err != nil {
+		r.report.Steps = append(r.report.Steps, CanaryStep{Name: name, Status: canaryStepFailed, Error: err.Error()})
+		return false
+	}
+	defer removeTempManifest(filename)
+	return r.step(ctx, name, "apply", "-f", filename)
+}
+
+// rollback routes all traffic back to the stable version and deletes the canary.
+// It runs even when the request was cancelled, so traffic is never left split.
+func (r *canaryRun) rollback(ctx context.Context, reason string) {
+	ctx = context.WithoutCancel(ctx)
+	r.report.Status, r.report.Reason = CanaryStatusRolledBack, reason
+	r.route(ctx, "route_to_stable", 0)
+	r.step(ctx, "delete_canary", "delete", "-f", r.manifest, "-n", r.target.Namespace, "--ignore-not-found")
+	cache.InvalidateKubernetesCache()
+}
+
+// deploy applies the canary, waits for it to become ready and splits traffic.
+// It returns a final status and reason when a step failed, or empty strings.
+func (r *canaryRun) deploy(ctx context.Context, readyTimeout time.Duration, weight int, reportProgress func(int, int, string), total int) (string, string) {
+	if !r.step(ctx, "apply_canary", "apply", "-f", r.manifest, "-n", r.target.Namespace) {
+		return CanaryStatusFailed, "failed to apply the canary manifest"
+	}
+	cache.InvalidateKubernetesCache()
+	reportProgress(1, total, "canary applied")
+
+	if !r.step(ctx, "wait_ready", "rollout", "status", "-f", r.manifest, "-n", r.target.Namespace, "--timeout", readyTimeout.String()) {
+		r.rollback(ctx, "canary did not become ready")
+		return r.report.Status, r.report.Reason
+	}
+	reportProgress(2, total, "canary ready")
+
+	if !r.route(ctx, "split_traffic", weight) {
+		r.rollback(ctx, "failed to split traffic")
+		return r.report.Status, r.report.Reason
+	}
+	reportProgress(3, total, fmt.Sprintf("routing %d%% of traffic to the canary", weight))
+	return "", ""
+}
+
+// Istio canary deployment
+func handleDeployCanary(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	target := canaryTarget{
+		Service:       p.String("service", "", params.Required(), params.Check(security.ValidateK8sResourceName)),
+		Namespace:     p.String("namespace", "default", params.Check(security.ValidateNamespace)),
+		VersionLabel:  p.String("version_label", defaultVersionLabel),
+		StableVersion: p.String("stable_version", "", params.Required()),
+		CanaryVersion: p.String("canary_version", "", params.Required()),
+	}
+	manifest := p.String("manifest", "", params.Required(), params.Check(security.ValidateYAMLContent))
+	weight := p.Int("weight", defaultCanaryWeight, params.Range(1, 99))
+	bakeTime := p.Duration("bake_time", defaultCanaryBakeTime)
+	interval := p.Duration("interval", defaultCanaryInterval)
+	readyTimeout := p.Duration("ready_timeout", defaultCanaryReadyTimeout)
+	prometheusURL := p.String("prometheus_url", "", params.Check(security.ValidateURL))
+	maxErrorRate := p.String("max_error_rate", defaultCanaryMaxErrorRate, params.Check(func(value string) error {
+		if rate, err := strconv.ParseFloat(value, 64); err != nil || rate < 0 || rate > 1 {
+			return fmt.Errorf("must be a fraction between 0 and 1")
+		}
+		return nil
+	}))
+	maxLatencyMs := p.Int("max_latency_ms", defaultCanaryMaxLatencyMs, params.Min(1))
+	errorQuery := p.String("error_rate_query", "", params.Check(security.ValidatePromQLQuery))
+	latencyQuery := p.String("latency_query", "", params.Check(security.ValidatePromQLQuery))
+	promote := p.Bool("promote", true)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	for _, label := range []string{target.StableVersion, target.CanaryVersion} {
+		if err := security.ValidateK8sLabel(target.VersionLabel, label); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	if target.StableVersion == target.CanaryVersion {
+		return mcp.NewToolResultError("stable_version and canary_version must differ"), nil
+	}
+	if interval <= 0 {
+		interval = defaultCanaryInterval
+	}
+
+	defaultErrorQuery, defaultLatencyQuery := canaryQueries(target, defaultCanaryRateWindow)
+	if errorQuery == "" {
+		errorQuery = defaultErrorQuery
+	}
+	if latencyQuery == "" {
+		latencyQuery = defaultLatencyQuery
+	}
+	thresholds := canaryThresholds{MaxLatencyMs: float64(maxLatencyMs)}
+	thresholds.MaxErrorRate, _ = strconv.ParseFloat(maxErrorRate, 64)
+
+	report := &CanaryReport{
+		Service:       target.Service,
+		Namespace:     target.Namespace,
+		StableVersion: target.StableVersion,
+		CanaryVersion: target.CanaryVersion,
+		Weight:        weight,
+		ErrorQuery:    errorQuery,
+		LatencyQuery:  latencyQuery,
+		Steps:         []CanaryStep{},
+		Checks:        []CanaryCheck{},
+	}
+	manifestFile, err := writeTempManifest("istio-canary-*.yaml", manifest)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer removeTempManifest(manifestFile)
+	run := &canaryRun{target: target, manifest: manifestFile, report: report}
+
+	// Progress counts the three setup steps, one unit per health check and the promotion
+	checks := int(bakeTime/interval) + 1
+	total := checks + 4
+	reportProgress := utils.ProgressReporter(ctx, request)
+
+	report.Status, report.Reason = run.deploy(ctx, readyTimeout, weight, reportProgress, total)
+	completed := 3
+	deadline := time.Now().Add(bakeTime)
+	for report.Status == "" {
+		check := checkCanary(ctx, prometheusURL, errorQuery, latencyQuery, thresholds)
+		report.Checks = append(report.Checks, check)
+		completed = min(completed+1, total-1)
+		reportProgress(completed, total, fmt.Sprintf("check %d: healthy=%t %s", len(report.Checks), check.Healthy, check.Reason))
+		if !check.Healthy {
+			run.rollback(ctx, "canary unhealthy: "+check.Reason)
+			break
+		}
+		if !time.Now().Add(interval).Before(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			run.rollback(ctx, "canary deployment cancelled during the bake period")
+		case <-time.After(interval):
+		}
+	}
+
+	if report.Status == "" {
+		switch {
+		case !promote:
+			report.Status = CanaryStatusReadyToPromote
+			report.Reason = fmt.Sprintf("canary stayed healthy for %s at %d%% of traffic", bakeTime, weight)
+		case run.route(context.WithoutCancel(ctx), "promote", 100):
+			report.Status = CanaryStatusPromoted
+			report.Reason = fmt.Sprintf("canary stayed healthy for %s at %d%% of traffic and now receives all traffic", bakeTime, weight)
+		default:
+			run.rollback(ctx, "failed to promote the canary")
+		}
+		cache.InvalidateKubernetesCache()
+	}
+	reportProgress(total, total, "canary "+report.Status)
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling canary report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
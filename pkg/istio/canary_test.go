@@ -0,0 +1,189 @@
+package istio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testCanaryManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-v2
+spec:
+  selector:
+    matchLabels: {app: web, version: v2}
+  template:
+    metadata:
+      labels: {app: web, version: v2}
+    spec:
+      containers:
+      - name: web
+        image: web:2.0
+`
+
+var testCanaryTarget = canaryTarget{Service: "web", Namespace: "prod", VersionLabel: "version", StableVersion: "v1", CanaryVersion: "v2"}
+
+func TestTrafficSplitManifest(t *testing.T) {
+	decoder := yaml.NewDecoder(strings.NewReader(trafficSplitManifest(testCanaryTarget, 25)))
+	var destinationRule, virtualService map[string]interface{}
+	require.NoError(t, decoder.Decode(&destinationRule))
+	require.NoError(t, decoder.Decode(&virtualService))
+
+	assert.Equal(t, "DestinationRule", destinationRule["kind"])
+	subsets := destinationRule["spec"].(map[string]interface{})["subsets"].([]interface{})
+	require.Len(t, subsets, 2)
+	assert.Equal(t, map[string]interface{}{"version": "v2"}, subsets[1].(map[string]interface{})["labels"])
+
+	assert.Equal(t, "VirtualService", virtualService["kind"])
+	route := virtualService["spec"].(map[string]interface{})["http"].([]interface{})[0].(map[string]interface{})["route"].([]interface{})
+	require.Len(t, route, 2)
+	assert.Equal(t, 75, route[0].(map[string]interface{})["weight"])
+	assert.Equal(t, 25, route[1].(map[string]interface{})["weight"])
+	assert.Equal(t, "web.prod.svc.cluster.local", route[1].(map[string]interface{})["destination"].(map[string]interface{})["host"])
+}
+
+func TestCanaryQueries(t *testing.T) {
+	errorRate, latency := canaryQueries(testCanaryTarget, "1m")
+	assert.Contains(t, errorRate, `destination_version="v2"`)
+	assert.Contains(t, errorRate, `response_code=~"5.."`)
+	assert.Contains(t, latency, "histogram_quantile(0.95")
+}
+
+// newTestPrometheus serves the error rate and latency of the canary, or no data when negative
+func newTestPrometheus(t *testing.T, errorRate, latency float64) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := errorRate
+		if strings.HasPrefix(r.URL.Query().Get("query"), "histogram_quantile") {
+			value = latency
+		}
+		if value < 0 {
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"%g"]}]}}`, value)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCheckCanary(t *testing.T) {
+	thresholds := canaryThresholds{MaxErrorRate: 0.01, MaxLatencyMs: 500}
+	errorQuery, latencyQuery := canaryQueries(testCanaryTarget, "1m")
+
+	check := checkCanary(context.Background(), newTestPrometheus(t, 0.001, 120).URL, errorQuery, latencyQuery, thresholds)
+	assert.True(t, check.Healthy)
+	assert.Equal(t, 120.0, *check.LatencyMs)
+
+	check = checkCanary(context.Background(), newTestPrometheus(t, 0.05, 120).URL, errorQuery, latencyQuery, thresholds)
+	assert.False(t, check.Healthy)
+	assert.Equal(t, "error rate 0.0500 exceeds 0.0100", check.Reason)
+
+	check = checkCanary(context.Background(), newTestPrometheus(t, 0, 900).URL, errorQuery, latencyQuery, thresholds)
+	assert.False(t, check.Healthy)
+	assert.Equal(t, "p95 latency 900ms exceeds 500ms", check.Reason)
+
+	check = checkCanary(context.Background(), newTestPrometheus(t, -1, -1).URL, errorQuery, latencyQuery, thresholds)
+	assert.True(t, check.Healthy)
+	assert.Equal(t, "no canary traffic yet", check.Reason)
+}
+
+// newCanaryMock mocks the kubectl commands of a canary deployment. Routes are
+// applied from files without a namespace flag, the canary with one.
+func newCanaryMock(rolloutErr error) *cmd.MockShellExecutor {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddPartialMatcherString("kubectl", []string{"apply", "istio-canary-route-"}, "virtualservice.networking.istio.io/web configured", nil)
+	mock.AddPartialMatcherString("kubectl", []string{"apply", "prod"}, "deployment.apps/web-v2 created", nil)
+	mock.AddPartialMatcherString("kubectl", []string{"rollout", "status"}, "successfully rolled out", rolloutErr)
+	mock.AddPartialMatcherString("kubectl", []string{"delete", "--ignore-not-found"}, "deployment.apps \"web-v2\" deleted", nil)
+	return mock
+}
+
+// canarySteps returns the names and statuses of the steps of a report
+func canarySteps(report CanaryReport) []string {
+	var steps []string
+	for _, step := range report.Steps {
+		steps = append(steps, step.Name+":"+step.Status)
+	}
+	return steps
+}
+
+func TestHandleDeployCanary(t *testing.T) {
+	runCanary := func(t *testing.T, mock *cmd.MockShellExecutor, arguments map[string]interface{}) CanaryReport {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"service":        "web",
+			"namespace":      "prod",
+			"manifest":       testCanaryManifest,
+			"stable_version": "v1",
+			"canary_version": "v2",
+			"bake_time":      "30ms",
+			"interval":       "10ms",
+		}
+		for key, value := range arguments {
+			request.Params.Arguments.(map[string]interface{})[key] = value
+		}
+		result, err := handleDeployCanary(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		text := result.Content[0].(mcp.TextContent).Text
+		require.False(t, result.IsError, text)
+
+		var report CanaryReport
+		require.NoError(t, json.Unmarshal([]byte(text), &report))
+		return report
+	}
+
+	t.Run("healthy canary is promoted", func(t *testing.T) {
+		report := runCanary(t, newCanaryMock(nil), map[string]interface{}{"prometheus_url": newTestPrometheus(t, 0.001, 100).URL})
+		assert.Equal(t, CanaryStatusPromoted, report.Status)
+		assert.Equal(t, []string{"apply_canary:succeeded", "wait_ready:succeeded", "split_traffic:succeeded", "promote:succeeded"}, canarySteps(report))
+		assert.GreaterOrEqual(t, len(report.Checks), 3)
+	})
+
+	t.Run("unhealthy canary is rolled back", func(t *testing.T) {
+		report := runCanary(t, newCanaryMock(nil), map[string]interface{}{"prometheus_url": newTestPrometheus(t, 0.2, 100).URL})
+		assert.Equal(t, CanaryStatusRolledBack, report.Status)
+		assert.Contains(t, report.Reason, "error rate 0.2000 exceeds 0.0100")
+		assert.Len(t, report.Checks, 1)
+		assert.Equal(t, []string{"apply_canary:succeeded", "wait_ready:succeeded", "split_traffic:succeeded", "route_to_stable:succeeded", "delete_canary:succeeded"}, canarySteps(report))
+	})
+
+	t.Run("canary that does not become ready is deleted", func(t *testing.T) {
+		report := runCanary(t, newCanaryMock(errors.New("timed out waiting for the condition")), nil)
+		assert.Equal(t, CanaryStatusRolledBack, report.Status)
+		assert.Equal(t, "canary did not become ready", report.Reason)
+		assert.Empty(t, report.Checks)
+	})
+
+	t.Run("healthy canary waits for promotion", func(t *testing.T) {
+		report := runCanary(t, newCanaryMock(nil), map[string]interface{}{"prometheus_url": newTestPrometheus(t, -1, -1).URL, "promote": "false"})
+		assert.Equal(t, CanaryStatusReadyToPromote, report.Status)
+		assert.Len(t, report.Steps, 3)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, arguments := range []map[string]interface{}{
+			{"service": "web", "manifest": testCanaryManifest, "stable_version": "v1", "canary_version": "v1"},
+			{"service": "web", "manifest": testCanaryManifest, "stable_version": "v1", "canary_version": "v2", "weight": float64(100)},
+			{"service": "web", "manifest": testCanaryManifest, "stable_version": "v1", "canary_version": "v2", "max_error_rate": "5"},
+			{"service": "web", "manifest": testCanaryManifest, "stable_version": "v1", "canary_version": "v2;rm"},
+		} {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = arguments
+			result, err := handleDeployCanary(context.Background(), request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError, arguments)
+		}
+	})
+}
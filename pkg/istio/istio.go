@@ -2,6 +2,7 @@ package istio
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -16,6 +17,7 @@ import (
 func handleIstioProxyStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	podName := mcp.ParseString(request, "pod_name", "")
 	namespace := mcp.ParseString(request, "namespace", "")
+	output := mcp.ParseString(request, "output", "")
 
 	args := []string{"proxy-status"}
 
@@ -32,6 +34,16 @@ func handleIstioProxyStatus(ctx context.Context, request mcp.CallToolRequest) (*
 		return mcp.NewToolResultError(fmt.Sprintf("istioctl proxy-status failed: %v", err)), nil
 	}
 
+	// A single pod returns an xDS diff rather than a table, so only the
+	// mesh-wide listing can be summarized
+	if output == "structured" && podName == "" {
+		summaryJSON, err := json.MarshalIndent(parseProxyStatus(result), "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal proxy status: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(summaryJSON)), nil
+	}
+
 	return mcp.NewToolResultText(result), nil
 }
 
@@ -48,6 +60,10 @@ func handleIstioProxyConfig(ctx context.Context, request mcp.CallToolRequest) (*
 	podName := mcp.ParseString(request, "pod_name", "")
 	namespace := mcp.ParseString(request, "namespace", "")
 	configType := mcp.ParseString(request, "config_type", "all")
+	output := mcp.ParseString(request, "output", "")
+	fqdn := mcp.ParseString(request, "fqdn", "")
+	port := mcp.ParseString(request, "port", "")
+	routeName := mcp.ParseString(request, "route_name", "")
 
 	if podName == "" {
 		return mcp.NewToolResultError("pod_name parameter is required"), nil
@@ -61,11 +77,45 @@ func handleIstioProxyConfig(ctx context.Context, request mcp.CallToolRequest) (*
 		args = append(args, podName)
 	}
 
+	if fqdn != "" {
+		args = append(args, "--fqdn", fqdn)
+	}
+
+	if port != "" {
+		args = append(args, "--port", port)
+	}
+
+	if routeName != "" {
+		args = append(args, "--name", routeName)
+	}
+
+	switch output {
+	case "", "structured":
+	case "json", "yaml", "short":
+		args = append(args, "-o", output)
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("unsupported output %q, must be one of short, json, yaml, structured", output)), nil
+	}
+
 	result, err := runIstioCtl(ctx, args)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("istioctl proxy-config failed: %v", err)), nil
 	}
 
+	if output == "structured" {
+		entries := parseIstioTable(result)
+		summaryJSON, err := json.MarshalIndent(ProxyConfigSummary{
+			Pod:        podName,
+			ConfigType: configType,
+			Count:      len(entries),
+			Entries:    entries,
+		}, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal proxy config: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(summaryJSON)), nil
+	}
+
 	return mcp.NewToolResultText(result), nil
 }
 
@@ -305,6 +355,7 @@ func RegisterTools(s *server.MCPServer) {
 		mcp.WithDescription("Get Envoy proxy status for pods, retrieves last sent and acknowledged xDS sync from Istiod to each Envoy in the mesh"),
 		mcp.WithString("pod_name", mcp.Description("Name of the pod to get proxy status for")),
 		mcp.WithString("namespace", mcp.Description("Namespace of the pod")),
+		mcp.WithString("output", mcp.Description("Set to 'structured' to return a JSON summary with out-of-sync proxies flagged (mesh-wide listing only)")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("istio_proxy_status", handleIstioProxyStatus)))
 
 	// Istio proxy config
@@ -312,7 +363,11 @@ func RegisterTools(s *server.MCPServer) {
 		mcp.WithDescription("Get specific proxy configuration for a single pod"),
 		mcp.WithString("pod_name", mcp.Description("Name of the pod to get proxy configuration for"), mcp.Required()),
 		mcp.WithString("namespace", mcp.Description("Namespace of the pod")),
-		mcp.WithString("config_type", mcp.Description("Type of configuration (all, bootstrap, cluster, ecds, listener, log, route, secret)")),
+		mcp.WithString("config_type", mcp.Description("Type of configuration (all, bootstrap, cluster, ecds, endpoint, listener, log, route, secret)")),
+		mcp.WithString("output", mcp.Description("Output format (short, json, yaml, structured). 'structured' returns the table as JSON rows")),
+		mcp.WithString("fqdn", mcp.Description("Filter clusters or endpoints by FQDN")),
+		mcp.WithString("port", mcp.Description("Filter clusters, endpoints or listeners by port")),
+		mcp.WithString("route_name", mcp.Description("Filter routes by route name")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("istio_proxy_config", handleIstioProxyConfig)))
 
 	// Istio install
@@ -367,6 +422,47 @@ func RegisterTools(s *server.MCPServer) {
 		mcp.WithDescription("Get the status of a waypoint resource"),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("istio_waypoint_status", handleWaypointStatus)))
 
+	// Access log sampling
+	s.AddTool(mcp.NewTool("istio_access_log_sample",
+		mcp.WithDescription("Sample the Envoy access logs of a pod's sidecar: temporarily enable access logging with a Telemetry resource (removed afterwards), collect requests with their status codes, response flags and upstream timings, and summarize the error distribution"),
+		mcp.WithString("pod_name", mcp.Description("Name of the pod whose sidecar logs are sampled"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the pod (default: default)")),
+		mcp.WithNumber("count", mcp.Description("Number of requests to collect, 1-1000 (default: 50)")),
+		mcp.WithString("duration", mcp.Description("How long to collect requests, or with enable=false how far back to read the logs (default: 30s)")),
+		mcp.WithString("interval", mcp.Description("Time between reads of the logs while collecting (default: 5s)")),
+		mcp.WithString("enable", mcp.Description("Enable access logging while collecting; false reads logs the mesh already writes (true/false, default: true)")),
+		mcp.WithString("include_entries", mcp.Description("Include the parsed requests besides the summary (true/false, default: true)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("istio_access_log_sample", handleIstioAccessLogSample)))
+
+	// Route debugging
+	s.AddTool(mcp.NewTool("istio_route_debug",
+		mcp.WithDescription("Trace a request for a host and path through the mesh: find the Gateways accepting the host, the VirtualService route that matches the path and its destinations, check that referenced Services and DestinationRule subsets exist, and report conflicting Gateways, VirtualServices and DestinationRules. Covers most 404 and 503 responses from the mesh."),
+		mcp.WithString("host", mcp.Description("Hostname of the request, e.g. shop.example.com or reviews.default.svc.cluster.local"), mcp.Required()),
+		mcp.WithString("path", mcp.Description("Path of the request (default: /)")),
+		mcp.WithString("check_services", mcp.Description("Check that destination Services exist (true/false, default: true)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("istio_route_debug", handleIstioRouteDebug)))
+
+	// Canary deployment
+	s.AddTool(mcp.NewTool("istio_deploy_canary",
+		mcp.WithDescription("Deploy a canary version of a service: apply its manifest, route a percentage of traffic to it with an Istio VirtualService, watch its error rate and p95 latency in Prometheus for a bake period, then promote it or roll back. Reports progress notifications while running."),
+		mcp.WithString("service", mcp.Description("Kubernetes service whose traffic is split; the DestinationRule and VirtualService are named after it"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the service (default: default)")),
+		mcp.WithString("manifest", mcp.Description("YAML manifest of the canary workload, whose pods carry the canary version label"), mcp.Required()),
+		mcp.WithString("stable_version", mcp.Description("Version label value of the stable pods"), mcp.Required()),
+		mcp.WithString("canary_version", mcp.Description("Version label value of the canary pods"), mcp.Required()),
+		mcp.WithString("version_label", mcp.Description("Pod label distinguishing the versions (default: version)")),
+		mcp.WithNumber("weight", mcp.Description("Percentage of traffic routed to the canary during the bake period, 1-99 (default: 10)")),
+		mcp.WithString("bake_time", mcp.Description("How long to watch the canary before promoting it (default: 5m)")),
+		mcp.WithString("interval", mcp.Description("Time between health checks (default: 30s)")),
+		mcp.WithString("ready_timeout", mcp.Description("Time to wait for the canary to become ready (default: 5m)")),
+		mcp.WithString("max_error_rate", mcp.Description("Maximum fraction of 5xx responses of the canary (default: 0.01)")),
+		mcp.WithNumber("max_latency_ms", mcp.Description("Maximum p95 latency of the canary in milliseconds (default: 500)")),
+		mcp.WithString("error_rate_query", mcp.Description("PromQL returning the canary error rate (default: based on istio_requests_total)")),
+		mcp.WithString("latency_query", mcp.Description("PromQL returning the canary p95 latency in milliseconds (default: based on istio_request_duration_milliseconds)")),
+		mcp.WithString("promote", mcp.Description("Route all traffic to a healthy canary after the bake period (true/false, default: true)")),
+		mcp.WithString("prometheus_url", mcp.Description("Prometheus server URL (default: the default Prometheus instance, PROMETHEUS_URL)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("istio_deploy_canary", handleDeployCanary)))
+
 	// Ztunnel config
 	s.AddTool(mcp.NewTool("istio_ztunnel_config",
 		mcp.WithDescription("Get the ztunnel configuration for a namespace"),
@@ -2,6 +2,7 @@ package istio
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/kagent-dev/tools/internal/cmd"
@@ -355,3 +356,119 @@ func TestIstioErrorHandling(t *testing.T) {
 		assert.True(t, result.IsError)
 	})
 }
+
+func TestHandleIstioProxyStatusStructured(t *testing.T) {
+	output := `NAME                                  CLUSTER        CDS              LDS              EDS              RDS              ECDS        ISTIOD                      VERSION
+details-v1-7d4d9d5fcb-abcde.default   Kubernetes     SYNCED (2m1s)    SYNCED (2m1s)    SYNCED (2m1s)    SYNCED (2m1s)    IGNORED     istiod-5d8c6b7f9c-xyz       1.22.0
+reviews-v1-6f8d5c9b7-fghij.default    Kubernetes     STALE (10m)      SYNCED (2m1s)    SYNCED (2m1s)    SYNCED (2m1s)    IGNORED     istiod-5d8c6b7f9c-xyz       1.22.0
+`
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("istioctl", []string{"proxy-status"}, output, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"output": "structured",
+	}
+
+	result, err := handleIstioProxyStatus(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	var summary ProxyStatusSummary
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &summary))
+	assert.Equal(t, 2, summary.Total)
+	assert.Equal(t, []string{"reviews-v1-6f8d5c9b7-fghij.default"}, summary.OutOfSync)
+	assert.Equal(t, "SYNCED (2m1s)", summary.Proxies[0].Sync["CDS"])
+	assert.Equal(t, "1.22.0", summary.Proxies[0].Version)
+}
+
+func TestHandleIstioProxyConfigOptions(t *testing.T) {
+	t.Run("filters and output are passed through", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("istioctl", []string{"proxy-config", "cluster", "test-pod.default", "--fqdn", "reviews.default.svc.cluster.local", "--port", "9080", "-o", "json"}, "[]", nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"pod_name":    "test-pod",
+			"namespace":   "default",
+			"config_type": "cluster",
+			"fqdn":        "reviews.default.svc.cluster.local",
+			"port":        "9080",
+			"output":      "json",
+		}
+
+		result, err := handleIstioProxyConfig(ctx, request)
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+	})
+
+	t.Run("structured output", func(t *testing.T) {
+		output := `NAME                 DOMAINS                               MATCH     VIRTUAL SERVICE
+9080                 reviews, reviews.default + 1 more...  /*        reviews.default
+backend              *                                     /stats*
+`
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("istioctl", []string{"proxy-config", "route", "test-pod"}, output, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"pod_name":    "test-pod",
+			"config_type": "route",
+			"output":      "structured",
+		}
+
+		result, err := handleIstioProxyConfig(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		var summary ProxyConfigSummary
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &summary))
+		assert.Equal(t, 2, summary.Count)
+		assert.Equal(t, "reviews.default", summary.Entries[0]["VIRTUAL SERVICE"])
+		assert.Equal(t, "/stats*", summary.Entries[1]["MATCH"])
+	})
+
+	t.Run("structured output of every config type", func(t *testing.T) {
+		output := `SERVICE FQDN                          PORT      SUBSET     DIRECTION     TYPE
+reviews.default.svc.cluster.local     9080      v1         outbound      EDS
+
+ADDRESSES      PORT      MATCH     DESTINATION
+10.96.0.10     53        ALL       Cluster: outbound|53||kube-dns.kube-system.svc.cluster.local
+`
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("istioctl", []string{"proxy-config", "all", "test-pod"}, output, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"pod_name":    "test-pod",
+			"config_type": "all",
+			"output":      "structured",
+		}
+
+		result, err := handleIstioProxyConfig(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		var summary ProxyConfigSummary
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &summary))
+		assert.Equal(t, 2, summary.Count)
+		assert.Equal(t, "v1", summary.Entries[0]["SUBSET"])
+		assert.Equal(t, "10.96.0.10", summary.Entries[1]["ADDRESSES"])
+	})
+
+	t.Run("invalid output", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"pod_name": "test-pod",
+			"output":   "xml",
+		}
+
+		result, err := handleIstioProxyConfig(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
@@ -0,0 +1,84 @@
+package istio
+
+import (
+	"strings"
+
+	"github.com/kagent-dev/tools/internal/cliout"
+)
+
+// ProxyStatus represents a single row of `istioctl proxy-status`
+type ProxyStatus struct {
+	Name      string            `json:"name"`
+	Cluster   string            `json:"cluster,omitempty"`
+	Istiod    string            `json:"istiod,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	Sync      map[string]string `json:"sync"`
+	OutOfSync bool              `json:"out_of_sync"`
+}
+
+// ProxyStatusSummary is the structured response of istio_proxy_status
+type ProxyStatusSummary struct {
+	Total     int           `json:"total"`
+	OutOfSync []string      `json:"out_of_sync"`
+	Proxies   []ProxyStatus `json:"proxies"`
+}
+
+// ProxyConfigSummary is the structured response of istio_proxy_config
+type ProxyConfigSummary struct {
+	Pod        string              `json:"pod"`
+	ConfigType string              `json:"config_type"`
+	Count      int                 `json:"count"`
+	Entries    []map[string]string `json:"entries"`
+}
+
+// xdsColumns are the proxy-status columns that carry an xDS sync state
+var xdsColumns = map[string]bool{"CDS": true, "LDS": true, "EDS": true, "RDS": true, "ECDS": true}
+
+// parseIstioTable parses the tabular output of istioctl into rows keyed by
+// header name, concatenating the tables of output such as proxy-config all
+func parseIstioTable(output string) []map[string]string {
+	rows := []map[string]string{}
+	for _, table := range cliout.ParseTables(output) {
+		rows = append(rows, table...)
+	}
+	return rows
+}
+
+// parseProxyStatus converts `istioctl proxy-status` output into a summary,
+// flagging any proxy whose xDS state is not SYNCED or IGNORED
+func parseProxyStatus(output string) ProxyStatusSummary {
+	summary := ProxyStatusSummary{
+		OutOfSync: []string{},
+		Proxies:   []ProxyStatus{},
+	}
+
+	for _, row := range parseIstioTable(output) {
+		proxy := ProxyStatus{
+			Name:    row["NAME"],
+			Cluster: row["CLUSTER"],
+			Istiod:  row["ISTIOD"],
+			Version: row["VERSION"],
+			Sync:    make(map[string]string),
+		}
+
+		for column, value := range row {
+			if !xdsColumns[column] {
+				continue
+			}
+			proxy.Sync[column] = value
+
+			state := strings.Fields(value)
+			if len(state) > 0 && state[0] != "SYNCED" && state[0] != "IGNORED" {
+				proxy.OutOfSync = true
+			}
+		}
+
+		if proxy.OutOfSync {
+			summary.OutOfSync = append(summary.OutOfSync, proxy.Name)
+		}
+		summary.Proxies = append(summary.Proxies, proxy)
+	}
+
+	summary.Total = len(summary.Proxies)
+	return summary
+}
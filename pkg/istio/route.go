@@ -0,0 +1,537 @@
+package istio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// meshGateway is the reserved gateway name of sidecar-to-sidecar traffic
+const meshGateway = "mesh"
+
+// Severities of route findings
+const (
+	routeSeverityError   = "error"
+	routeSeverityWarning = "warning"
+)
+
+// istioMetadata is the metadata of an Istio resource
+type istioMetadata struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// ref returns the namespace/name of a resource
+func (m istioMetadata) ref() string {
+	return m.Namespace + "/" + m.Name
+}
+
+// gatewayList is the subset of `kubectl get gateways.networking.istio.io -o json` output used by route debugging
+type gatewayList struct {
+	Items []struct {
+		Metadata istioMetadata `json:"metadata"`
+		Spec     struct {
+			Servers []struct {
+				Port struct {
+					Number   int    `json:"number"`
+					Protocol string `json:"protocol"`
+				} `json:"port"`
+				Hosts []string `json:"hosts"`
+				TLS   *struct {
+					Mode string `json:"mode"`
+				} `json:"tls"`
+			} `json:"servers"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// stringMatch is an Istio StringMatch of a URI
+type stringMatch struct {
+	Exact  string `json:"exact"`
+	Prefix string `json:"prefix"`
+	Regex  string `json:"regex"`
+}
+
+// matches reports whether path satisfies the match; regexes must match the whole path
+func (m *stringMatch) matches(path string) bool {
+	switch {
+	case m == nil:
+		return true
+	case m.Exact != "":
+		return path == m.Exact
+	case m.Prefix != "":
+		return strings.HasPrefix(path, m.Prefix)
+	case m.Regex != "":
+		re, err := regexp.Compile("^(?:" + m.Regex + ")$")
+		return err == nil && re.MatchString(path)
+	}
+	return true
+}
+
+// String renders the match as it appears in a VirtualService
+func (m *stringMatch) String() string {
+	switch {
+	case m == nil:
+		return "any"
+	case m.Exact != "":
+		return "exact " + m.Exact
+	case m.Prefix != "":
+		return "prefix " + m.Prefix
+	case m.Regex != "":
+		return "regex " + m.Regex
+	}
+	return "any"
+}
+
+// httpMatch is a match condition of an HTTP route
+type httpMatch struct {
+	URI         *stringMatch               `json:"uri"`
+	Method      json.RawMessage            `json:"method"`
+	Headers     map[string]json.RawMessage `json:"headers"`
+	QueryParams map[string]json.RawMessage `json:"queryParams"`
+	Port        int                        `json:"port"`
+	Gateways    []string                   `json:"gateways"`
+}
+
+// conditions lists the match conditions other than the URI, which depend on the request
+func (m httpMatch) conditions() []string {
+	var conditions []string
+	if len(m.Method) > 0 {
+		conditions = append(conditions, "method")
+	}
+	for header := range m.Headers {
+		conditions = append(conditions, "header "+header)
+	}
+	for param := range m.QueryParams {
+		conditions = append(conditions, "query parameter "+param)
+	}
+	if m.Port != 0 {
+		conditions = append(conditions, fmt.Sprintf("port %d", m.Port))
+	}
+	sort.Strings(conditions)
+	return conditions
+}
+
+// virtualServiceList is the subset of `kubectl get virtualservices.networking.istio.io -o json` output used by route debugging
+type virtualServiceList struct {
+	Items []struct {
+		Metadata istioMetadata `json:"metadata"`
+		Spec     struct {
+			Hosts    []string `json:"hosts"`
+			Gateways []string `json:"gateways"`
+			HTTP     []struct {
+				Name  string      `json:"name"`
+				Match []httpMatch `json:"match"`
+				Route []struct {
+					Destination struct {
+						Host   string `json:"host"`
+						Subset string `json:"subset"`
+						Port   struct {
+							Number int `json:"number"`
+						} `json:"port"`
+					} `json:"destination"`
+					Weight int `json:"weight"`
+				} `json:"route"`
+				Redirect *struct {
+					URI       string `json:"uri"`
+					Authority string `json:"authority"`
+				} `json:"redirect"`
+				DirectResponse *struct {
+					Status int `json:"status"`
+				} `json:"directResponse"`
+				Rewrite *struct {
+					URI string `json:"uri"`
+				} `json:"rewrite"`
+			} `json:"http"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// destinationRuleList is the subset of `kubectl get destinationrules.networking.istio.io -o json` output used by route debugging
+type destinationRuleList struct {
+	Items []struct {
+		Metadata istioMetadata `json:"metadata"`
+		Spec     struct {
+			Host    string `json:"host"`
+			Subsets []struct {
+				Name string `json:"name"`
+			} `json:"subsets"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// serviceList is the subset of `kubectl get services -o json` output used by route debugging
+type serviceList struct {
+	Items []struct {
+		Metadata istioMetadata `json:"metadata"`
+	} `json:"items"`
+}
+
+// RouteGateway is a Gateway server accepting the host
+type RouteGateway struct {
+	Gateway  string   `json:"gateway"`
+	Port     int      `json:"port"`
+	Protocol string   `json:"protocol"`
+	Hosts    []string `json:"hosts"`
+	TLSMode  string   `json:"tls_mode,omitempty"`
+}
+
+// RouteDestination is a destination of the matched route and the resources it depends on
+type RouteDestination struct {
+	Host            string `json:"host"`
+	Subset          string `json:"subset,omitempty"`
+	Port            int    `json:"port,omitempty"`
+	Weight          int    `json:"weight,omitempty"`
+	ServiceFound    *bool  `json:"service_found,omitempty"`
+	DestinationRule string `json:"destination_rule,omitempty"`
+	SubsetFound     *bool  `json:"subset_found,omitempty"`
+}
+
+// ConditionalRoute is a route matching the path only for requests with further conditions
+type ConditionalRoute struct {
+	VirtualService string   `json:"virtual_service"`
+	Index          int      `json:"index"`
+	Name           string   `json:"name,omitempty"`
+	Conditions     []string `json:"conditions"`
+}
+
+// MatchedRoute is the HTTP route that serves the host and path
+type MatchedRoute struct {
+	VirtualService string             `json:"virtual_service"`
+	Index          int                `json:"index"`
+	Name           string             `json:"name,omitempty"`
+	Match          string             `json:"match"`
+	RewriteURI     string             `json:"rewrite_uri,omitempty"`
+	Redirect       string             `json:"redirect,omitempty"`
+	DirectResponse int                `json:"direct_response,omitempty"`
+	Destinations   []RouteDestination `json:"destinations,omitempty"`
+}
+
+// RouteFinding is a problem that makes the mesh fail or misroute the request
+type RouteFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// RouteDebugReport is the structured response of istio_route_debug
+type RouteDebugReport struct {
+	Host string `json:"host"`
+	Path string `json:"path"`
+	// Context is "gateway" when a Gateway accepts the host, and "mesh" for sidecar traffic otherwise
+	Context           string             `json:"context"`
+	Gateways          []RouteGateway     `json:"gateways,omitempty"`
+	VirtualServices   []string           `json:"virtual_services"`
+	ConditionalRoutes []ConditionalRoute `json:"conditional_routes,omitempty"`
+	Route             *MatchedRoute      `json:"route,omitempty"`
+	Findings          []RouteFinding     `json:"findings"`
+}
+
+// qualifyHost expands a short service name to its cluster-local name in namespace
+func qualifyHost(host, namespace string) string {
+	if host == "*" || strings.Contains(host, ".") || namespace == "" {
+		return host
+	}
+	return host + "." + namespace + ".svc.cluster.local"
+}
+
+// hostMatches reports whether host matches an Istio host, which may be a
+// wildcard such as *.example.com
+func hostMatches(pattern, host string) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	switch {
+	case pattern == "*" || pattern == host:
+		return true
+	case strings.HasPrefix(pattern, "*."):
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return false
+}
+
+// gatewayRef resolves a gateway reference of a VirtualService in namespace to namespace/name
+func gatewayRef(ref, namespace string) string {
+	if ref == meshGateway || strings.Contains(ref, "/") {
+		return ref
+	}
+	// Gateways may also be referenced by their cluster-local name, e.g. ingress.istio-system.svc.cluster.local
+	if name, rest, found := strings.Cut(ref, "."); found {
+		ns, _, _ := strings.Cut(rest, ".")
+		return ns + "/" + name
+	}
+	return namespace + "/" + ref
+}
+
+// buildRouteDebugReport follows a request for host and path through the
+// Gateways, VirtualServices and DestinationRules of the mesh
+func buildRouteDebugReport(host, path string, gateways gatewayList, virtualServices virtualServiceList, destinationRules destinationRuleList, services *serviceList) RouteDebugReport {
+	report := RouteDebugReport{Host: host, Path: path, Context: meshGateway, VirtualServices: []string{}, Findings: []RouteFinding{}}
+	add := func(rule, severity, format string, args ...interface{}) {
+		report.Findings = append(report.Findings, RouteFinding{Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Gateway servers accepting the host, by port
+	accepting := map[string]bool{}
+	gatewaysByPort := map[int][]string{}
+	for _, gateway := range gateways.Items {
+		for _, server := range gateway.Spec.Servers {
+			for _, serverHost := range server.Hosts {
+				// Server hosts may be prefixed with the namespaces whose VirtualServices they accept
+				if _, h, found := strings.Cut(serverHost, "/"); found {
+					serverHost = h
+				}
+				if !hostMatches(serverHost, host) {
+					continue
+				}
+				route := RouteGateway{Gateway: gateway.Metadata.ref(), Port: server.Port.Number, Protocol: server.Port.Protocol, Hosts: server.Hosts}
+				if server.TLS != nil {
+					route.TLSMode = server.TLS.Mode
+				}
+				report.Gateways = append(report.Gateways, route)
+				accepting[gateway.Metadata.ref()] = true
+				gatewaysByPort[server.Port.Number] = append(gatewaysByPort[server.Port.Number], gateway.Metadata.ref())
+				break
+			}
+		}
+	}
+	if len(accepting) > 0 {
+		report.Context = "gateway"
+	}
+	ports := make([]int, 0, len(gatewaysByPort))
+	for port := range gatewaysByPort {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	for _, port := range ports {
+		if refs := gatewaysByPort[port]; len(refs) > 1 {
+			add("conflicting_gateways", routeSeverityWarning, "Gateways %s all accept %s on port %d; if they select the same ingress pods only one of their servers is used", strings.Join(refs, ", "), host, port)
+		}
+	}
+
+	// appliesTo reports whether a match restricted to gateways applies in the report's context
+	appliesTo := func(refs []string, namespace string) bool {
+		if len(refs) == 0 {
+			return true
+		}
+		for _, ref := range refs {
+			ref = gatewayRef(ref, namespace)
+			if (report.Context == meshGateway && ref == meshGateway) || accepting[ref] {
+				return true
+			}
+		}
+		return false
+	}
+
+	// VirtualServices for the host bound to the accepting gateways, or to the mesh
+	type boundVirtualService struct {
+		ref   string
+		index int
+	}
+	var bound []boundVirtualService
+	for i, vs := range virtualServices.Items {
+		namespace := vs.Metadata.Namespace
+		hostMatched := false
+		for _, vsHost := range vs.Spec.Hosts {
+			if hostMatches(qualifyHost(vsHost, namespace), host) || hostMatches(vsHost, host) {
+				hostMatched = true
+				break
+			}
+		}
+		if !hostMatched {
+			continue
+		}
+		refs := vs.Spec.Gateways
+		if len(refs) == 0 {
+			refs = []string{meshGateway}
+		}
+		if appliesTo(refs, namespace) {
+			bound = append(bound, boundVirtualService{ref: vs.Metadata.ref(), index: i})
+		}
+	}
+	sort.Slice(bound, func(i, j int) bool { return bound[i].ref < bound[j].ref })
+	for _, vs := range bound {
+		report.VirtualServices = append(report.VirtualServices, vs.ref)
+	}
+
+	switch {
+	case len(bound) == 0 && report.Context == meshGateway:
+		add("no_virtual_service", routeSeverityWarning, "No VirtualService routes %s for sidecars, so requests use the default route to the service if one exists", host)
+		return report
+	case len(bound) == 0:
+		add("no_virtual_service", routeSeverityError, "Gateways accept %s but no VirtualService bound to them routes it, so the ingress returns 404 (NR)", host)
+		return report
+	case len(bound) > 1 && report.Context == meshGateway:
+		add("conflicting_virtual_services", routeSeverityError, "VirtualServices %s all route %s for sidecars; only one of them is applied", strings.Join(report.VirtualServices, ", "), host)
+	case len(bound) > 1:
+		add("conflicting_virtual_services", routeSeverityWarning, "VirtualServices %s all route %s on the gateway; their routes are merged in an undefined order", strings.Join(report.VirtualServices, ", "), host)
+	}
+
+	// The first route matching the path without further conditions serves the request
+	for _, b := range bound {
+		vs := virtualServices.Items[b.index]
+		for index, route := range vs.Spec.HTTP {
+			matches := route.Match
+			if len(matches) == 0 {
+				matches = []httpMatch{{}}
+			}
+			var matched *httpMatch
+			for i := range matches {
+				if !matches[i].URI.matches(path) || !appliesTo(matches[i].Gateways, vs.Metadata.Namespace) {
+					continue
+				}
+				if conditions := matches[i].conditions(); len(conditions) > 0 {
+					report.ConditionalRoutes = append(report.ConditionalRoutes, ConditionalRoute{VirtualService: b.ref, Index: index, Name: route.Name, Conditions: conditions})
+					continue
+				}
+				matched = &matches[i]
+				break
+			}
+			if matched == nil {
+				continue
+			}
+
+			report.Route = &MatchedRoute{VirtualService: b.ref, Index: index, Name: route.Name, Match: matched.URI.String()}
+			if route.Rewrite != nil {
+				report.Route.RewriteURI = route.Rewrite.URI
+			}
+			if route.Redirect != nil {
+				report.Route.Redirect = route.Redirect.Authority + route.Redirect.URI
+			}
+			if route.DirectResponse != nil {
+				report.Route.DirectResponse = route.DirectResponse.Status
+			}
+			for _, r := range route.Route {
+				report.Route.Destinations = append(report.Route.Destinations, RouteDestination{
+					Host:   qualifyHost(r.Destination.Host, vs.Metadata.Namespace),
+					Subset: r.Destination.Subset,
+					Port:   r.Destination.Port.Number,
+					Weight: r.Weight,
+				})
+			}
+			break
+		}
+		if report.Route != nil {
+			break
+		}
+	}
+	if report.Route == nil {
+		add("no_matching_route", routeSeverityError, "No route of %s matches path %s without further conditions, so requests get 404 (NR)", strings.Join(report.VirtualServices, ", "), path)
+		return report
+	}
+	if report.Route.Redirect == "" && report.Route.DirectResponse == 0 && len(report.Route.Destinations) == 0 {
+		add("no_destination", routeSeverityError, "Route %d of %s has no destination, redirect or direct response", report.Route.Index, report.Route.VirtualService)
+	}
+
+	// Destinations must exist, and their subsets must be defined by a DestinationRule
+	for i := range report.Route.Destinations {
+		destination := &report.Route.Destinations[i]
+		if services != nil && strings.HasSuffix(destination.Host, ".svc.cluster.local") {
+			name, namespace, _ := strings.Cut(strings.TrimSuffix(destination.Host, ".svc.cluster.local"), ".")
+			found := false
+			for _, service := range services.Items {
+				if service.Metadata.Name == name && service.Metadata.Namespace == namespace {
+					found = true
+					break
+				}
+			}
+			destination.ServiceFound = &found
+			if !found {
+				add("missing_service", routeSeverityError, "Destination %s is not a Service in the cluster, so requests get 503 (no healthy upstream)", destination.Host)
+			}
+		}
+
+		var rules []string
+		subsetFound := false
+		for _, rule := range destinationRules.Items {
+			if !hostMatches(qualifyHost(rule.Spec.Host, rule.Metadata.Namespace), destination.Host) {
+				continue
+			}
+			rules = append(rules, rule.Metadata.ref())
+			for _, subset := range rule.Spec.Subsets {
+				if subset.Name == destination.Subset {
+					subsetFound = true
+				}
+			}
+		}
+		sort.Strings(rules)
+		if len(rules) > 0 {
+			destination.DestinationRule = rules[0]
+		}
+		if len(rules) > 1 {
+			add("conflicting_destination_rules", routeSeverityWarning, "DestinationRules %s all apply to %s; only one of them takes effect", strings.Join(rules, ", "), destination.Host)
+		}
+		if destination.Subset == "" {
+			continue
+		}
+		destination.SubsetFound = &subsetFound
+		switch {
+		case len(rules) == 0:
+			add("missing_destination_rule", routeSeverityError, "Destination %s uses subset %s but no DestinationRule applies to the host, so requests get 503 (NR)", destination.Host, destination.Subset)
+		case !subsetFound:
+			add("missing_subset", routeSeverityError, "Subset %s of %s is not defined in DestinationRule %s, so requests get 503 (NR)", destination.Subset, destination.Host, strings.Join(rules, ", "))
+		}
+	}
+	return report
+}
+
+// getIstioResources lists resources of a type in all namespaces and decodes them into out
+func getIstioResources(ctx context.Context, resource string, out interface{}) error {
+	output, err := runKubectl(ctx, "get", resource, "-A", "-o", "json")
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", resource, err)
+	}
+	if err := json.Unmarshal([]byte(output), out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", resource, err)
+	}
+	return nil
+}
+
+// Istio route debugging
+func handleIstioRouteDebug(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	host := p.String("host", "", params.Required())
+	path := p.String("path", "/")
+	checkServices := p.Bool("check_services", true)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return mcp.NewToolResultError("path must start with /"), nil
+	}
+
+	var gateways gatewayList
+	var virtualServices virtualServiceList
+	var destinationRules destinationRuleList
+	for _, list := range []struct {
+		resource string
+		out      interface{}
+	}{
+		{"gateways.networking.istio.io", &gateways},
+		{"virtualservices.networking.istio.io", &virtualServices},
+		{"destinationrules.networking.istio.io", &destinationRules},
+	} {
+		if err := getIstioResources(ctx, list.resource, list.out); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	var services *serviceList
+	if checkServices {
+		services = &serviceList{}
+		if err := getIstioResources(ctx, "services", services); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	report := buildRouteDebugReport(strings.ToLower(host), path, gateways, virtualServices, destinationRules, services)
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal route debug report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
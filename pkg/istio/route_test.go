@@ -0,0 +1,153 @@
+package istio
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRouteGateways = `{"items":[
+ {"metadata":{"name":"ingress","namespace":"istio-system"},"spec":{"servers":[
+  {"port":{"number":443,"protocol":"HTTPS"},"hosts":["*/*.example.com"],"tls":{"mode":"SIMPLE"}}]}},
+ {"metadata":{"name":"internal","namespace":"istio-system"},"spec":{"servers":[
+  {"port":{"number":80,"protocol":"HTTP"},"hosts":["internal.corp"]}]}}]}`
+
+const testRouteVirtualServices = `{"items":[
+ {"metadata":{"name":"shop","namespace":"shop"},"spec":{"hosts":["shop.example.com"],"gateways":["istio-system/ingress"],"http":[
+  {"name":"canary","match":[{"uri":{"prefix":"/api"},"headers":{"x-canary":{"exact":"true"}}}],"route":[{"destination":{"host":"api","subset":"v3"}}]},
+  {"name":"api","match":[{"uri":{"prefix":"/api"}}],"route":[{"destination":{"host":"api","subset":"v2","port":{"number":8080}},"weight":100}]},
+  {"name":"legacy","match":[{"uri":{"regex":"/old/.*"}}],"redirect":{"uri":"/new"}},
+  {"name":"default","route":[{"destination":{"host":"web"}}]}]}},
+ {"metadata":{"name":"reviews","namespace":"shop"},"spec":{"hosts":["reviews"],"http":[
+  {"route":[{"destination":{"host":"reviews","subset":"v1"}}]}]}},
+ {"metadata":{"name":"reviews-canary","namespace":"shop"},"spec":{"hosts":["reviews.shop.svc.cluster.local"],"gateways":["mesh"],"http":[
+  {"match":[{"uri":{"exact":"/health"}}],"route":[{"destination":{"host":"reviews","subset":"v2"}}]}]}},
+ {"metadata":{"name":"internal","namespace":"tools"},"spec":{"hosts":["internal.corp"],"gateways":["internal.istio-system.svc.cluster.local"],"http":[
+  {"match":[{"uri":{"exact":"/admin"}}],"route":[{"destination":{"host":"admin"}}]}]}}]}`
+
+const testRouteDestinationRules = `{"items":[
+ {"metadata":{"name":"api","namespace":"shop"},"spec":{"host":"api","subsets":[{"name":"v1"}]}},
+ {"metadata":{"name":"api-global","namespace":"istio-system"},"spec":{"host":"api.shop.svc.cluster.local"}}]}`
+
+const testRouteServices = `{"items":[
+ {"metadata":{"name":"api","namespace":"shop"}},
+ {"metadata":{"name":"reviews","namespace":"shop"}}]}`
+
+func routeDebug(t *testing.T, host, path string) RouteDebugReport {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "gateways.networking.istio.io", "-A", "-o", "json"}, testRouteGateways, nil)
+	mock.AddCommandString("kubectl", []string{"get", "virtualservices.networking.istio.io", "-A", "-o", "json"}, testRouteVirtualServices, nil)
+	mock.AddCommandString("kubectl", []string{"get", "destinationrules.networking.istio.io", "-A", "-o", "json"}, testRouteDestinationRules, nil)
+	mock.AddCommandString("kubectl", []string{"get", "services", "-A", "-o", "json"}, testRouteServices, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"host": host, "path": path}
+	result, err := handleIstioRouteDebug(ctx, request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	require.False(t, result.IsError, text)
+
+	var report RouteDebugReport
+	require.NoError(t, json.Unmarshal([]byte(text), &report))
+	return report
+}
+
+func findingRules(report RouteDebugReport) []string {
+	var rules []string
+	for _, finding := range report.Findings {
+		rules = append(rules, finding.Rule)
+	}
+	return rules
+}
+
+func TestHandleIstioRouteDebug(t *testing.T) {
+	t.Run("gateway route with missing subset", func(t *testing.T) {
+		report := routeDebug(t, "shop.example.com", "/api/items")
+		assert.Equal(t, "gateway", report.Context)
+		require.Len(t, report.Gateways, 1)
+		assert.Equal(t, "istio-system/ingress", report.Gateways[0].Gateway)
+		assert.Equal(t, "SIMPLE", report.Gateways[0].TLSMode)
+		assert.Equal(t, []string{"shop/shop"}, report.VirtualServices)
+
+		require.Len(t, report.ConditionalRoutes, 1)
+		assert.Equal(t, []string{"header x-canary"}, report.ConditionalRoutes[0].Conditions)
+
+		require.NotNil(t, report.Route)
+		assert.Equal(t, 1, report.Route.Index)
+		assert.Equal(t, "prefix /api", report.Route.Match)
+		require.Len(t, report.Route.Destinations, 1)
+		destination := report.Route.Destinations[0]
+		assert.Equal(t, "api.shop.svc.cluster.local", destination.Host)
+		assert.Equal(t, 8080, destination.Port)
+		assert.Equal(t, "istio-system/api-global", destination.DestinationRule)
+		require.NotNil(t, destination.ServiceFound)
+		assert.True(t, *destination.ServiceFound)
+		require.NotNil(t, destination.SubsetFound)
+		assert.False(t, *destination.SubsetFound)
+		assert.Equal(t, []string{"conflicting_destination_rules", "missing_subset"}, findingRules(report))
+	})
+
+	t.Run("default route to missing service", func(t *testing.T) {
+		report := routeDebug(t, "shop.example.com", "/")
+		require.NotNil(t, report.Route)
+		assert.Equal(t, "default", report.Route.Name)
+		assert.Equal(t, "any", report.Route.Match)
+		assert.Equal(t, []string{"missing_service"}, findingRules(report))
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		report := routeDebug(t, "shop.example.com", "/old/cart")
+		require.NotNil(t, report.Route)
+		assert.Equal(t, "/new", report.Route.Redirect)
+		assert.Empty(t, report.Findings)
+	})
+
+	t.Run("gateway without virtual service", func(t *testing.T) {
+		report := routeDebug(t, "blog.example.com", "/")
+		assert.Equal(t, "gateway", report.Context)
+		assert.Empty(t, report.VirtualServices)
+		assert.Nil(t, report.Route)
+		assert.Equal(t, []string{"no_virtual_service"}, findingRules(report))
+		assert.Equal(t, routeSeverityError, report.Findings[0].Severity)
+	})
+
+	t.Run("gateway referenced by cluster-local name without matching route", func(t *testing.T) {
+		report := routeDebug(t, "internal.corp", "/")
+		assert.Equal(t, []string{"tools/internal"}, report.VirtualServices)
+		assert.Nil(t, report.Route)
+		assert.Equal(t, []string{"no_matching_route"}, findingRules(report))
+	})
+
+	t.Run("mesh route with conflicting virtual services", func(t *testing.T) {
+		report := routeDebug(t, "reviews.shop.svc.cluster.local", "/health")
+		assert.Equal(t, meshGateway, report.Context)
+		assert.Equal(t, []string{"shop/reviews", "shop/reviews-canary"}, report.VirtualServices)
+		require.NotNil(t, report.Route)
+		assert.Equal(t, "shop/reviews", report.Route.VirtualService)
+		assert.Equal(t, []string{"conflicting_virtual_services", "missing_destination_rule"}, findingRules(report))
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"host": "shop.example.com", "path": "api"}
+		result, err := handleIstioRouteDebug(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestHostMatches(t *testing.T) {
+	assert.True(t, hostMatches("*", "anything"))
+	assert.True(t, hostMatches("*.example.com", "shop.example.com"))
+	assert.False(t, hostMatches("*.example.com", "example.com"))
+	assert.True(t, hostMatches("Shop.Example.com", "shop.example.com"))
+	assert.Equal(t, "web.shop.svc.cluster.local", qualifyHost("web", "shop"))
+	assert.Equal(t, "istio-system/ingress", gatewayRef("ingress.istio-system.svc.cluster.local", "shop"))
+	assert.Equal(t, "shop/ingress", gatewayRef("ingress", "shop"))
+}
@@ -0,0 +1,469 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kagent-dev/tools/internal/cache"
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+	"github.com/kagent-dev/tools/pkg/utils"
+)
+
+// manifestChecksumAnnotation records the checksum of the manifest a resource was last applied from
+const manifestChecksumAnnotation = "kagent.dev/manifest-checksum"
+
+// defaultApplyChunkSize is the number of resources applied per kubectl call when no chunk size is given
+const defaultApplyChunkSize = 25
+
+// Actions of a resource in a chunked apply
+const (
+	applyActionCreated    = "created"
+	applyActionConfigured = "configured"
+	applyActionUnchanged  = "unchanged"
+	applyActionSkipped    = "skipped"
+	applyActionFailed     = "failed"
+	applyActionPending    = "pending"
+)
+
+// Statuses of a chunk in a chunked apply
+const (
+	chunkStatusApplied = "applied"
+	chunkStatusSkipped = "skipped"
+	chunkStatusFailed  = "failed"
+	chunkStatusPending = "pending"
+)
+
+// applyFirstKinds are applied before all other kinds, so the namespaces and
+// CRDs of an operator bundle exist before the resources that need them
+var applyFirstKinds = map[string]int{"Namespace": 0, "CustomResourceDefinition": 1}
+
+// manifestResource is a resource of a chunked manifest
+type manifestResource struct {
+	object    map[string]interface{}
+	kind      string
+	name      string
+	namespace string
+	checksum  string
+}
+
+// id identifies the resource in reports, e.g. Deployment/web
+func (r manifestResource) id() string {
+	return r.kind + "/" + r.name
+}
+
+// ResourceApplyResult is the outcome of applying a single resource
+type ResourceApplyResult struct {
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Chunk     int    `json:"chunk"`
+	Action    string `json:"action"`
+}
+
+// ChunkApplyResult is the outcome of applying a chunk of resources
+type ChunkApplyResult struct {
+	Chunk     int    `json:"chunk"`
+	Resources int    `json:"resources"`
+	Skipped   int    `json:"skipped"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ChunkedApplySummary counts the resources of a chunked apply by action
+type ChunkedApplySummary struct {
+	Total      int `json:"total"`
+	Created    int `json:"created"`
+	Configured int `json:"configured"`
+	Unchanged  int `json:"unchanged"`
+	Skipped    int `json:"skipped"`
+	Failed     int `json:"failed"`
+	Pending    int `json:"pending"`
+}
+
+// ChunkedApplyReport is the consolidated report of k8s_apply_manifest_chunked
+type ChunkedApplyReport struct {
+	Complete  bool                  `json:"complete"`
+	Summary   ChunkedApplySummary   `json:"summary"`
+	Chunks    []ChunkApplyResult    `json:"chunks"`
+	Resources []ResourceApplyResult `json:"resources"`
+	// ResumeHint explains how to resume after a failed chunk
+	ResumeHint string `json:"resume_hint,omitempty"`
+}
+
+// manifestChecksum returns the checksum of a resource, ignoring any checksum annotation it carries
+func manifestChecksum(object map[string]interface{}) (string, error) {
+	metadata, _ := object["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if _, ok := annotations[manifestChecksumAnnotation]; ok {
+		// Checksum shallow copies without the annotation, dropping the
+		// annotations altogether when it was the only one
+		metadata = maps.Clone(metadata)
+		annotations = maps.Clone(annotations)
+		delete(annotations, manifestChecksumAnnotation)
+		metadata["annotations"] = annotations
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+		object = maps.Clone(object)
+		object["metadata"] = metadata
+	}
+	// Maps are marshaled with sorted keys, so equal resources have equal checksums
+	data, err := json.Marshal(object)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// parseManifestResources splits a multi-document YAML manifest into its
+// resources, expanding List kinds, and annotates each with its checksum
+func parseManifestResources(manifest string) ([]manifestResource, error) {
+	var objects []map[string]interface{}
+	decoder := yaml.NewDecoder(strings.NewReader(manifest))
+	for document := 1; ; document++ {
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse document %d: %w", document, err)
+		}
+		if len(object) == 0 {
+			continue
+		}
+		if kind, _ := object["kind"].(string); strings.HasSuffix(kind, "List") {
+			items, _ := object["items"].([]interface{})
+			for _, item := range items {
+				if itemObject, ok := item.(map[string]interface{}); ok {
+					objects = append(objects, itemObject)
+				}
+			}
+			continue
+		}
+		objects = append(objects, object)
+	}
+
+	resources := make([]manifestResource, 0, len(objects))
+	for i, object := range objects {
+		kind, _ := object["kind"].(string)
+		metadata, _ := object["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		if kind == "" || name == "" {
+			return nil, fmt.Errorf("resource %d has no kind or metadata.name", i+1)
+		}
+		namespace, _ := metadata["namespace"].(string)
+
+		checksum, err := manifestChecksum(object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to checksum %s/%s: %w", kind, name, err)
+		}
+		annotations, _ := metadata["annotations"].(map[string]interface{})
+		if annotations == nil {
+			annotations = map[string]interface{}{}
+			metadata["annotations"] = annotations
+		}
+		annotations[manifestChecksumAnnotation] = checksum
+
+		resources = append(resources, manifestResource{object: object, kind: kind, name: name, namespace: namespace, checksum: checksum})
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		return applyOrder(resources[i].kind) < applyOrder(resources[j].kind)
+	})
+	return resources, nil
+}
+
+// applyOrder ranks a kind in the apply order
+func applyOrder(kind string) int {
+	if order, ok := applyFirstKinds[kind]; ok {
+		return order
+	}
+	return len(applyFirstKinds)
+}
+
+// chunkResources splits resources into chunks of at most size resources
+func chunkResources(resources []manifestResource, size int) [][]manifestResource {
+	var chunks [][]manifestResource
+	for start := 0; start < len(resources); start += size {
+		chunks = append(chunks, resources[start:min(start+size, len(resources))])
+	}
+	return chunks
+}
+
+// writeResourceList writes resources to a temporary file as a v1 List
+func writeResourceList(resources []manifestResource) (string, error) {
+	items := make([]map[string]interface{}, len(resources))
+	for i, resource := range resources {
+		items[i] = resource.object
+	}
+	data, err := json.Marshal(map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items})
+	if err != nil {
+		return "", err
+	}
+
+	tmpFile, err := os.CreateTemp("", "k8s-manifest-chunk-*.json")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to close temp file: %w", err)
+	}
+	return tmpFile.Name(), nil
+}
+
+// removeTempFile removes a chunk file, logging failures
+func removeTempFile(name string) {
+	if err := os.Remove(name); err != nil {
+		logger.Get().Error("Failed to remove temporary file", "error", err, "file", name)
+	}
+}
+
+// liveChecksums returns the checksum annotations of the resources that already
+// exist, keyed by resource id and namespace
+func (k *K8sTool) liveChecksums(ctx context.Context, filename string, namespaceArgs []string) (map[string]string, error) {
+	args := append([]string{"get", "-f", filename, "--ignore-not-found", "-o", "json"}, namespaceArgs...)
+	output, err := k.runKubectlCommandString(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	checksums := map[string]string{}
+	if strings.TrimSpace(output) == "" {
+		return checksums, nil
+	}
+
+	type liveObject struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name        string            `json:"name"`
+			Namespace   string            `json:"namespace"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}
+	var live struct {
+		liveObject
+		Items []liveObject `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &live); err != nil {
+		return nil, fmt.Errorf("failed to parse live resources: %w", err)
+	}
+	objects := live.Items
+	if !strings.HasSuffix(live.Kind, "List") {
+		objects = []liveObject{live.liveObject}
+	}
+	for _, object := range objects {
+		checksums[liveKey(object.Kind, object.Metadata.Name, object.Metadata.Namespace)] = object.Metadata.Annotations[manifestChecksumAnnotation]
+	}
+	return checksums, nil
+}
+
+// liveKey identifies a live resource
+func liveKey(kind, name, namespace string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// liveChecksum looks up the checksum of a manifest resource, which matches a
+// live resource in any namespace when the manifest leaves its namespace to kubectl
+func liveChecksum(checksums map[string]string, resource manifestResource) (string, bool) {
+	if checksum, ok := checksums[liveKey(resource.kind, resource.name, resource.namespace)]; ok || resource.namespace != "" {
+		return checksum, ok
+	}
+	for key, checksum := range checksums {
+		kind, rest, _ := strings.Cut(key, "/")
+		if _, name, _ := strings.Cut(rest, "/"); kind == resource.kind && name == resource.name {
+			return checksum, true
+		}
+	}
+	return "", false
+}
+
+// parseApplyActions maps the lines printed by kubectl apply, such as
+// "deployment.apps/web configured", to the action taken per resource
+func parseApplyActions(output string, resources []manifestResource) map[string]string {
+	actions := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		ref, action := fields[0], fields[len(fields)-1]
+		resourceType, name, found := strings.Cut(ref, "/")
+		if !found {
+			continue
+		}
+		resourceType, _, _ = strings.Cut(resourceType, ".")
+		for _, resource := range resources {
+			if resource.name == name && strings.EqualFold(resource.kind, resourceType) {
+				actions[resource.id()] = action
+			}
+		}
+	}
+	return actions
+}
+
+// applyChunk applies the resources of a chunk whose live checksum differs,
+// recording the outcome of each resource in report
+func (k *K8sTool) applyChunk(ctx context.Context, index int, chunk []manifestResource, namespaceArgs []string, resume bool, report *ChunkedApplyReport) error {
+	result := ChunkApplyResult{Chunk: index, Resources: len(chunk), Status: chunkStatusApplied}
+	record := func(resource manifestResource, action string) {
+		report.Resources = append(report.Resources, ResourceApplyResult{Resource: resource.id(), Namespace: resource.namespace, Chunk: index, Action: action})
+	}
+
+	pending := chunk
+	if resume {
+		filename, err := writeResourceList(chunk)
+		if err != nil {
+			return err
+		}
+		checksums, err := k.liveChecksums(ctx, filename, namespaceArgs)
+		removeTempFile(filename)
+		if err != nil {
+			// Kinds whose CRD is not installed yet cannot be looked up; apply everything
+			logger.Get().Info("Failed to look up live resources, applying the whole chunk", "chunk", index, "error", err)
+		}
+		pending = nil
+		for _, resource := range chunk {
+			if checksum, ok := liveChecksum(checksums, resource); ok && checksum == resource.checksum {
+				record(resource, applyActionSkipped)
+				result.Skipped++
+				continue
+			}
+			pending = append(pending, resource)
+		}
+	}
+
+	if len(pending) == 0 {
+		result.Status = chunkStatusSkipped
+		report.Chunks = append(report.Chunks, result)
+		return nil
+	}
+
+	filename, err := writeResourceList(pending)
+	if err != nil {
+		return err
+	}
+	defer removeTempFile(filename)
+
+	output, err := k.runKubectlCommandString(ctx, append([]string{"apply", "-f", filename}, namespaceArgs...)...)
+	if err != nil {
+		result.Status = chunkStatusFailed
+		result.Error = err.Error()
+		report.Chunks = append(report.Chunks, result)
+		for _, resource := range pending {
+			record(resource, applyActionFailed)
+		}
+		return nil
+	}
+	cache.InvalidateKubernetesCache()
+
+	actions := parseApplyActions(output, pending)
+	for _, resource := range pending {
+		action := actions[resource.id()]
+		if action == "" {
+			action = applyActionConfigured
+		}
+		record(resource, action)
+	}
+	report.Chunks = append(report.Chunks, result)
+	return nil
+}
+
+// Apply a large manifest in chunks
+func (k *K8sTool) handleApplyManifestChunked(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	manifest := p.String("manifest", "", params.Required(), params.Check(security.ValidateYAMLContent))
+	namespace := p.String("namespace", "", params.Check(security.ValidateNamespace))
+	chunkSize := p.Int("chunk_size", defaultApplyChunkSize, params.Range(1, 500))
+	resume := p.Bool("resume", true)
+	continueOnError := p.Bool("continue_on_error", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	resources, err := parseManifestResources(manifest)
+	if err != nil {
+		return mcp.NewToolResultError("Invalid manifest: " + err.Error()), nil
+	}
+	if len(resources) == 0 {
+		return mcp.NewToolResultError("manifest contains no resources"), nil
+	}
+
+	var namespaceArgs []string
+	if namespace != "" {
+		namespaceArgs = []string{"-n", namespace}
+	}
+
+	chunks := chunkResources(resources, chunkSize)
+	report := &ChunkedApplyReport{Chunks: []ChunkApplyResult{}, Resources: []ResourceApplyResult{}}
+	reportProgress := utils.ProgressReporter(ctx, request)
+	failed := false
+	for i, chunk := range chunks {
+		index := i + 1
+		if failed && !continueOnError {
+			report.Chunks = append(report.Chunks, ChunkApplyResult{Chunk: index, Resources: len(chunk), Status: chunkStatusPending})
+			for _, resource := range chunk {
+				report.Resources = append(report.Resources, ResourceApplyResult{Resource: resource.id(), Namespace: resource.namespace, Chunk: index, Action: applyActionPending})
+			}
+			continue
+		}
+
+		if err := k.applyChunk(ctx, index, chunk, namespaceArgs, resume, report); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to apply chunk %d: %v", index, err)), nil
+		}
+		last := report.Chunks[len(report.Chunks)-1]
+		if last.Status == chunkStatusFailed {
+			failed = true
+		}
+		reportProgress(index, len(chunks), fmt.Sprintf("chunk %d/%d %s (%d resources, %d skipped)", index, len(chunks), last.Status, last.Resources, last.Skipped))
+	}
+
+	report.Summary.Total = len(resources)
+	for _, resource := range report.Resources {
+		switch resource.Action {
+		case applyActionCreated:
+			report.Summary.Created++
+		case applyActionUnchanged:
+			report.Summary.Unchanged++
+		case applyActionSkipped:
+			report.Summary.Skipped++
+		case applyActionFailed:
+			report.Summary.Failed++
+		case applyActionPending:
+			report.Summary.Pending++
+		default:
+			report.Summary.Configured++
+		}
+	}
+	report.Complete = !failed
+	if failed {
+		report.ResumeHint = "Fix the failed chunk and apply the same manifest again with resume enabled; resources already applied from it are skipped by their " + manifestChecksumAnnotation + " annotation"
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return mcp.NewToolResultError("Error marshaling apply report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(buf.String()), nil
+}
@@ -0,0 +1,201 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+// clusterExecutor fakes kubectl get and apply of chunk files against an in-memory cluster
+type clusterExecutor struct {
+	// live maps kind/name to the checksum annotation of the resource
+	live map[string]string
+	// fail makes applying the named resource fail
+	fail    string
+	applies int
+}
+
+func (e *clusterExecutor) Exec(ctx context.Context, command string, args ...string) ([]byte, error) {
+	data, err := os.ReadFile(args[2])
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	switch args[0] {
+	case "get":
+		items := []map[string]interface{}{}
+		for _, item := range list.Items {
+			kind, name := item["kind"].(string), item["metadata"].(map[string]interface{})["name"].(string)
+			if checksum, ok := e.live[kind+"/"+name]; ok {
+				items = append(items, map[string]interface{}{
+					"kind":     kind,
+					"metadata": map[string]interface{}{"name": name, "annotations": map[string]string{manifestChecksumAnnotation: checksum}},
+				})
+			}
+		}
+		return json.Marshal(map[string]interface{}{"kind": "List", "items": items})
+	case "apply":
+		e.applies++
+		var output strings.Builder
+		for _, item := range list.Items {
+			metadata := item["metadata"].(map[string]interface{})
+			kind, name := item["kind"].(string), metadata["name"].(string)
+			if name == e.fail {
+				return nil, fmt.Errorf("error applying %s/%s", kind, name)
+			}
+			action := "created"
+			if _, ok := e.live[kind+"/"+name]; ok {
+				action = "configured"
+			}
+			e.live[kind+"/"+name] = metadata["annotations"].(map[string]interface{})[manifestChecksumAnnotation].(string)
+			fmt.Fprintf(&output, "%s/%s %s\n", strings.ToLower(kind), name, action)
+		}
+		return []byte(output.String()), nil
+	}
+	return nil, fmt.Errorf("unexpected command %v", args)
+}
+
+const testChunkedManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  key: value
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: operator
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: operators
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ServiceAccount
+  metadata:
+    name: operator
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: operator
+`
+
+func TestParseManifestResources(t *testing.T) {
+	resources, err := parseManifestResources(testChunkedManifest)
+	require.NoError(t, err)
+	require.Len(t, resources, 5)
+	// Namespaces are applied first, otherwise the manifest order is kept
+	var ids []string
+	for _, resource := range resources {
+		ids = append(ids, resource.id())
+	}
+	assert.Equal(t, []string{"Namespace/operators", "ConfigMap/settings", "Deployment/operator", "ServiceAccount/operator", "Service/operator"}, ids)
+
+	// The checksum ignores the annotation it is recorded in
+	annotated, err := parseManifestResources(testChunkedManifest)
+	require.NoError(t, err)
+	checksum, err := manifestChecksum(annotated[1].object)
+	require.NoError(t, err)
+	assert.Equal(t, resources[1].checksum, checksum)
+	assert.Equal(t, checksum, annotated[1].object["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})[manifestChecksumAnnotation])
+
+	_, err = parseManifestResources("kind: ConfigMap\nmetadata: {}\n")
+	assert.Error(t, err)
+}
+
+func TestParseApplyActions(t *testing.T) {
+	resources := []manifestResource{{kind: "Deployment", name: "web"}, {kind: "Service", name: "web"}}
+	actions := parseApplyActions("deployment.apps/web configured\nservice/web unchanged\n", resources)
+	assert.Equal(t, map[string]string{"Deployment/web": "configured", "Service/web": "unchanged"}, actions)
+}
+
+func TestHandleApplyManifestChunked(t *testing.T) {
+	apply := func(t *testing.T, executor *clusterExecutor, args map[string]interface{}) ChunkedApplyReport {
+		args["manifest"] = testChunkedManifest
+		ctx := cmd.WithShellExecutor(context.Background(), executor)
+		result, err := newTestK8sTool().handleApplyManifestChunked(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+		var report ChunkedApplyReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		return report
+	}
+
+	t.Run("applies in chunks", func(t *testing.T) {
+		executor := &clusterExecutor{live: map[string]string{}}
+		report := apply(t, executor, map[string]interface{}{"chunk_size": float64(2)})
+		assert.True(t, report.Complete)
+		assert.Len(t, report.Chunks, 3)
+		assert.Equal(t, 3, executor.applies)
+		assert.Equal(t, ChunkedApplySummary{Total: 5, Created: 5}, report.Summary)
+		assert.Empty(t, report.ResumeHint)
+	})
+
+	t.Run("stops at a failed chunk and resumes", func(t *testing.T) {
+		executor := &clusterExecutor{live: map[string]string{}, fail: "operator"}
+		report := apply(t, executor, map[string]interface{}{"chunk_size": float64(2)})
+		assert.False(t, report.Complete)
+		assert.Equal(t, ChunkedApplySummary{Total: 5, Created: 2, Failed: 2, Pending: 1}, report.Summary)
+		assert.Equal(t, []string{chunkStatusApplied, chunkStatusFailed, chunkStatusPending}, []string{report.Chunks[0].Status, report.Chunks[1].Status, report.Chunks[2].Status})
+		assert.NotEmpty(t, report.ResumeHint)
+
+		executor.fail = ""
+		executor.applies = 0
+		report = apply(t, executor, map[string]interface{}{"chunk_size": float64(2)})
+		assert.True(t, report.Complete)
+		assert.Equal(t, ChunkedApplySummary{Total: 5, Created: 3, Skipped: 2}, report.Summary)
+		assert.Equal(t, chunkStatusSkipped, report.Chunks[0].Status)
+		assert.Equal(t, 2, report.Chunks[0].Skipped)
+		assert.Equal(t, 2, executor.applies)
+
+		// Applying an up to date manifest again is a no-op
+		executor.applies = 0
+		report = apply(t, executor, map[string]interface{}{"chunk_size": float64(2)})
+		assert.Equal(t, ChunkedApplySummary{Total: 5, Skipped: 5}, report.Summary)
+		assert.Zero(t, executor.applies)
+	})
+
+	t.Run("continue on error", func(t *testing.T) {
+		executor := &clusterExecutor{live: map[string]string{}, fail: "settings"}
+		report := apply(t, executor, map[string]interface{}{"chunk_size": float64(2), "continue_on_error": "true"})
+		assert.False(t, report.Complete)
+		assert.Equal(t, ChunkedApplySummary{Total: 5, Created: 3, Failed: 2}, report.Summary)
+	})
+
+	t.Run("resume disabled", func(t *testing.T) {
+		executor := &clusterExecutor{live: map[string]string{}}
+		apply(t, executor, map[string]interface{}{})
+		report := apply(t, executor, map[string]interface{}{"resume": "false"})
+		assert.Equal(t, ChunkedApplySummary{Total: 5, Configured: 5}, report.Summary)
+	})
+
+	t.Run("invalid manifest", func(t *testing.T) {
+		result, err := newTestK8sTool().handleApplyManifestChunked(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"manifest": "kind: [",
+		}}})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
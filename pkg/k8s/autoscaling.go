@@ -0,0 +1,463 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// defaultScalingEventLimit is the number of events reported per autoscaler when no limit is given
+const defaultScalingEventLimit = 5
+
+// Values of AutoscalerStatus.Pinned
+const (
+	pinnedAtMin = "min"
+	pinnedAtMax = "max"
+)
+
+// kedaPausedAnnotation pauses a ScaledObject at a fixed replica count
+const kedaPausedAnnotation = "autoscaling.keda.sh/paused-replicas"
+
+// metricValue is a metric target or current value of an autoscaling/v2 HPA
+type metricValue struct {
+	Type               string `json:"type"`
+	AverageUtilization *int   `json:"averageUtilization"`
+	AverageValue       string `json:"averageValue"`
+	Value              string `json:"value"`
+}
+
+// String renders the value, e.g. 80% or avg 100m
+func (v metricValue) String() string {
+	switch {
+	case v.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *v.AverageUtilization)
+	case v.AverageValue != "":
+		return "avg " + v.AverageValue
+	default:
+		return v.Value
+	}
+}
+
+// hpaMetricSource is the union of the resource, pods, object and external metric sources
+type hpaMetricSource struct {
+	Name      string `json:"name"`
+	Container string `json:"container"`
+	Metric    struct {
+		Name string `json:"name"`
+	} `json:"metric"`
+	Target  metricValue `json:"target"`
+	Current metricValue `json:"current"`
+}
+
+// hpaMetric is a metric spec or status entry of an autoscaling/v2 HPA
+type hpaMetric struct {
+	Type              string           `json:"type"`
+	Resource          *hpaMetricSource `json:"resource"`
+	ContainerResource *hpaMetricSource `json:"containerResource"`
+	Pods              *hpaMetricSource `json:"pods"`
+	Object            *hpaMetricSource `json:"object"`
+	External          *hpaMetricSource `json:"external"`
+}
+
+// source returns the populated metric source
+func (m hpaMetric) source() hpaMetricSource {
+	for _, source := range []*hpaMetricSource{m.Resource, m.ContainerResource, m.Pods, m.Object, m.External} {
+		if source != nil {
+			return *source
+		}
+	}
+	return hpaMetricSource{}
+}
+
+// name identifies the metric, e.g. cpu, memory (app) or http_requests
+func (m hpaMetric) name() string {
+	source := m.source()
+	switch {
+	case source.Container != "":
+		return fmt.Sprintf("%s (%s)", source.Name, source.Container)
+	case source.Name != "":
+		return source.Name
+	default:
+		return source.Metric.Name
+	}
+}
+
+// hpaList is the subset of `kubectl get hpa -o json` output used for autoscaling status
+type hpaList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			ScaleTargetRef struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"scaleTargetRef"`
+			MinReplicas *int        `json:"minReplicas"`
+			MaxReplicas int         `json:"maxReplicas"`
+			Metrics     []hpaMetric `json:"metrics"`
+		} `json:"spec"`
+		Status struct {
+			CurrentReplicas int            `json:"currentReplicas"`
+			DesiredReplicas int            `json:"desiredReplicas"`
+			LastScaleTime   string         `json:"lastScaleTime"`
+			CurrentMetrics  []hpaMetric    `json:"currentMetrics"`
+			Conditions      []crdCondition `json:"conditions"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// scaledObjectList is the subset of `kubectl get scaledobjects.keda.sh -o json` output used for autoscaling status
+type scaledObjectList struct {
+	Items []struct {
+		Metadata struct {
+			Name        string            `json:"name"`
+			Namespace   string            `json:"namespace"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Spec struct {
+			ScaleTargetRef struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"scaleTargetRef"`
+			MinReplicaCount *int `json:"minReplicaCount"`
+			MaxReplicaCount *int `json:"maxReplicaCount"`
+			Triggers        []struct {
+				Type string `json:"type"`
+			} `json:"triggers"`
+		} `json:"spec"`
+		Status struct {
+			HPAName    string         `json:"hpaName"`
+			Conditions []crdCondition `json:"conditions"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// ScalingMetric compares an autoscaling metric's target with its current value
+type ScalingMetric struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Target      string `json:"target"`
+	Current     string `json:"current,omitempty"`
+	AboveTarget bool   `json:"above_target,omitempty"`
+}
+
+// AutoscalerCondition is a status condition of an HPA or ScaledObject
+type AutoscalerCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ScalingEvent is a recent event emitted for an autoscaler
+type ScalingEvent struct {
+	Type     string `json:"type"`
+	Reason   string `json:"reason"`
+	Message  string `json:"message"`
+	Count    int    `json:"count"`
+	LastTime string `json:"last_time,omitempty"`
+}
+
+// AutoscalerStatus describes an HPA or KEDA ScaledObject and how close it is to its replica bounds
+type AutoscalerStatus struct {
+	Kind            string                `json:"kind"`
+	Name            string                `json:"name"`
+	Namespace       string                `json:"namespace"`
+	Target          string                `json:"target"`
+	MinReplicas     int                   `json:"min_replicas"`
+	MaxReplicas     int                   `json:"max_replicas"`
+	CurrentReplicas int                   `json:"current_replicas"`
+	DesiredReplicas int                   `json:"desired_replicas"`
+	Pinned          string                `json:"pinned,omitempty"`
+	LastScaleTime   string                `json:"last_scale_time,omitempty"`
+	ManagedBy       string                `json:"managed_by,omitempty"`
+	Triggers        []string              `json:"triggers,omitempty"`
+	Metrics         []ScalingMetric       `json:"metrics,omitempty"`
+	Conditions      []AutoscalerCondition `json:"conditions,omitempty"`
+	Issues          []string              `json:"issues,omitempty"`
+	Events          []ScalingEvent        `json:"events,omitempty"`
+}
+
+// AutoscalingSummary counts autoscalers by state
+type AutoscalingSummary struct {
+	HPAs          int  `json:"hpas"`
+	ScaledObjects int  `json:"scaled_objects"`
+	AtMax         int  `json:"at_max"`
+	AtMin         int  `json:"at_min"`
+	Unhealthy     int  `json:"unhealthy"`
+	KEDAInstalled bool `json:"keda_installed"`
+}
+
+// AutoscalingReport is the structured response of k8s_autoscaling_status
+type AutoscalingReport struct {
+	Summary     AutoscalingSummary `json:"summary"`
+	Autoscalers []AutoscalerStatus `json:"autoscalers"`
+}
+
+// pinnedState reports whether the replica count sits at a bound
+func pinnedState(current, minReplicas, maxReplicas int) string {
+	switch {
+	case maxReplicas > 0 && current >= maxReplicas:
+		return pinnedAtMax
+	case current <= minReplicas && minReplicas < maxReplicas:
+		return pinnedAtMin
+	default:
+		return ""
+	}
+}
+
+// autoscalerConditions converts conditions and returns the issues of those in a failing state
+func autoscalerConditions(conditions []crdCondition, healthy map[string]string) ([]AutoscalerCondition, []string) {
+	var result []AutoscalerCondition
+	var issues []string
+	for _, condition := range conditions {
+		result = append(result, AutoscalerCondition{
+			Type:    condition.Type,
+			Status:  condition.Status,
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		})
+		if expected, ok := healthy[condition.Type]; ok && condition.Status != expected {
+			issues = append(issues, fmt.Sprintf("%s is %s: %s", condition.Type, condition.Status, condition.Message))
+		}
+	}
+	return result, issues
+}
+
+// buildAutoscalingReport correlates HPAs, ScaledObjects and their events
+func buildAutoscalingReport(hpaOutput, scaledObjectOutput, eventsOutput, name string, eventLimit int) (*AutoscalingReport, error) {
+	var hpas hpaList
+	if err := json.Unmarshal([]byte(hpaOutput), &hpas); err != nil {
+		return nil, fmt.Errorf("failed to parse horizontal pod autoscalers: %w", err)
+	}
+	report := &AutoscalingReport{Autoscalers: []AutoscalerStatus{}}
+
+	var scaledObjects scaledObjectList
+	if scaledObjectOutput != "" {
+		if err := json.Unmarshal([]byte(scaledObjectOutput), &scaledObjects); err != nil {
+			return nil, fmt.Errorf("failed to parse scaled objects: %w", err)
+		}
+		report.Summary.KEDAInstalled = true
+	}
+
+	// Events are optional, so parse failures only drop them from the report
+	var events eventList
+	_ = json.Unmarshal([]byte(eventsOutput), &events)
+	eventsByObject := make(map[string][]ScalingEvent)
+	for _, event := range events.Items {
+		if event.InvolvedObject.Kind != "HorizontalPodAutoscaler" && event.InvolvedObject.Kind != "ScaledObject" {
+			continue
+		}
+		lastTime := event.LastTimestamp
+		if lastTime == "" {
+			lastTime = event.EventTime
+		}
+		key := event.InvolvedObject.Kind + "/" + event.InvolvedObject.Namespace + "/" + event.InvolvedObject.Name
+		eventsByObject[key] = append(eventsByObject[key], ScalingEvent{
+			Type:     event.Type,
+			Reason:   event.Reason,
+			Message:  event.Message,
+			Count:    event.Count,
+			LastTime: lastTime,
+		})
+	}
+	recentEvents := func(kind, namespace, name string) []ScalingEvent {
+		objectEvents := eventsByObject[kind+"/"+namespace+"/"+name]
+		sort.SliceStable(objectEvents, func(i, j int) bool {
+			return objectEvents[i].LastTime > objectEvents[j].LastTime
+		})
+		return objectEvents[:min(len(objectEvents), eventLimit)]
+	}
+
+	// KEDA manages an HPA per ScaledObject, which holds the live replica counts and metrics
+	managedBy := make(map[string]string)
+	hpaReplicas := make(map[string][2]int)
+	for _, so := range scaledObjects.Items {
+		hpaName := so.Status.HPAName
+		if hpaName == "" {
+			hpaName = "keda-hpa-" + so.Metadata.Name
+		}
+		managedBy[so.Metadata.Namespace+"/"+hpaName] = "ScaledObject/" + so.Metadata.Name
+	}
+
+	for _, hpa := range hpas.Items {
+		key := hpa.Metadata.Namespace + "/" + hpa.Metadata.Name
+		hpaReplicas[key] = [2]int{hpa.Status.CurrentReplicas, hpa.Status.DesiredReplicas}
+		if name != "" && hpa.Metadata.Name != name {
+			continue
+		}
+
+		status := AutoscalerStatus{
+			Kind:            "HorizontalPodAutoscaler",
+			Name:            hpa.Metadata.Name,
+			Namespace:       hpa.Metadata.Namespace,
+			Target:          hpa.Spec.ScaleTargetRef.Kind + "/" + hpa.Spec.ScaleTargetRef.Name,
+			MinReplicas:     1,
+			MaxReplicas:     hpa.Spec.MaxReplicas,
+			CurrentReplicas: hpa.Status.CurrentReplicas,
+			DesiredReplicas: hpa.Status.DesiredReplicas,
+			LastScaleTime:   hpa.Status.LastScaleTime,
+			ManagedBy:       managedBy[key],
+			Events:          recentEvents("HorizontalPodAutoscaler", hpa.Metadata.Namespace, hpa.Metadata.Name),
+		}
+		if hpa.Spec.MinReplicas != nil {
+			status.MinReplicas = *hpa.Spec.MinReplicas
+		}
+		status.Pinned = pinnedState(status.CurrentReplicas, status.MinReplicas, status.MaxReplicas)
+
+		current := make(map[string]metricValue)
+		for _, metric := range hpa.Status.CurrentMetrics {
+			current[metric.Type+"/"+metric.name()] = metric.source().Current
+		}
+		for _, metric := range hpa.Spec.Metrics {
+			target := metric.source().Target
+			scalingMetric := ScalingMetric{Name: metric.name(), Type: metric.Type, Target: target.String()}
+			if value, ok := current[metric.Type+"/"+metric.name()]; ok {
+				scalingMetric.Current = value.String()
+				// Only utilizations are compared, as quantities need unit-aware parsing
+				scalingMetric.AboveTarget = target.AverageUtilization != nil && value.AverageUtilization != nil &&
+					*value.AverageUtilization > *target.AverageUtilization
+			}
+			if status.Pinned == pinnedAtMax && scalingMetric.AboveTarget {
+				status.Issues = append(status.Issues, fmt.Sprintf("pinned at max replicas while %s is above target (%s > %s)",
+					scalingMetric.Name, scalingMetric.Current, scalingMetric.Target))
+			}
+			status.Metrics = append(status.Metrics, scalingMetric)
+		}
+
+		var issues []string
+		status.Conditions, issues = autoscalerConditions(hpa.Status.Conditions, map[string]string{"AbleToScale": "True", "ScalingActive": "True"})
+		status.Issues = append(status.Issues, issues...)
+		report.Autoscalers = append(report.Autoscalers, status)
+	}
+
+	for _, so := range scaledObjects.Items {
+		if name != "" && so.Metadata.Name != name {
+			continue
+		}
+		kind := so.Spec.ScaleTargetRef.Kind
+		if kind == "" {
+			kind = "Deployment"
+		}
+		// Defaults of the ScaledObject CRD
+		status := AutoscalerStatus{
+			Kind:        "ScaledObject",
+			Name:        so.Metadata.Name,
+			Namespace:   so.Metadata.Namespace,
+			Target:      kind + "/" + so.Spec.ScaleTargetRef.Name,
+			MinReplicas: 0,
+			MaxReplicas: 100,
+			Events:      recentEvents("ScaledObject", so.Metadata.Namespace, so.Metadata.Name),
+		}
+		if so.Spec.MinReplicaCount != nil {
+			status.MinReplicas = *so.Spec.MinReplicaCount
+		}
+		if so.Spec.MaxReplicaCount != nil {
+			status.MaxReplicas = *so.Spec.MaxReplicaCount
+		}
+		for _, trigger := range so.Spec.Triggers {
+			status.Triggers = append(status.Triggers, trigger.Type)
+		}
+
+		hpaName := so.Status.HPAName
+		if hpaName == "" {
+			hpaName = "keda-hpa-" + so.Metadata.Name
+		}
+		if replicas, ok := hpaReplicas[so.Metadata.Namespace+"/"+hpaName]; ok {
+			status.CurrentReplicas, status.DesiredReplicas = replicas[0], replicas[1]
+			status.Pinned = pinnedState(status.CurrentReplicas, status.MinReplicas, status.MaxReplicas)
+		} else {
+			status.Issues = append(status.Issues, fmt.Sprintf("managed HPA %s not found", hpaName))
+		}
+		if paused, ok := so.Metadata.Annotations[kedaPausedAnnotation]; ok {
+			status.Issues = append(status.Issues, fmt.Sprintf("scaling is paused at %s replicas", paused))
+		}
+
+		var issues []string
+		status.Conditions, issues = autoscalerConditions(so.Status.Conditions, map[string]string{"Ready": "True", "Fallback": "False"})
+		status.Issues = append(status.Issues, issues...)
+		report.Autoscalers = append(report.Autoscalers, status)
+	}
+
+	for _, status := range report.Autoscalers {
+		if status.Kind == "ScaledObject" {
+			report.Summary.ScaledObjects++
+		} else {
+			report.Summary.HPAs++
+		}
+		switch status.Pinned {
+		case pinnedAtMax:
+			report.Summary.AtMax++
+		case pinnedAtMin:
+			report.Summary.AtMin++
+		}
+		if len(status.Issues) > 0 {
+			report.Summary.Unhealthy++
+		}
+	}
+
+	// Autoscalers with issues first, then those pinned at max
+	rank := func(status AutoscalerStatus) int {
+		switch {
+		case len(status.Issues) > 0:
+			return 0
+		case status.Pinned == pinnedAtMax:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(report.Autoscalers, func(i, j int) bool {
+		return rank(report.Autoscalers[i]) < rank(report.Autoscalers[j])
+	})
+	return report, nil
+}
+
+// HPA and KEDA autoscaling status
+func (k *K8sTool) handleAutoscalingStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	namespace := p.String("namespace", "", params.Check(security.ValidateNamespace))
+	name := p.String("name", "", params.Check(security.ValidateK8sResourceName))
+	eventLimit := p.Int("event_limit", defaultScalingEventLimit, params.Min(0))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	scope := []string{"--all-namespaces"}
+	if namespace != "" {
+		scope = []string{"-n", namespace}
+	}
+
+	hpaOutput, err := k.runKubectlCommandString(ctx, append([]string{"get", "hpa", "-o", "json"}, scope...)...)
+	if err != nil {
+		return mcp.NewToolResultError("Error getting horizontal pod autoscalers: " + err.Error()), nil
+	}
+	// KEDA is optional; a failure means its CRDs are not installed or not readable
+	scaledObjectOutput, _ := k.runKubectlCommandString(ctx, append([]string{"get", "scaledobjects.keda.sh", "-o", "json"}, scope...)...)
+	eventsOutput := ""
+	if eventLimit > 0 {
+		eventsOutput, _ = k.runKubectlCommandString(ctx, append([]string{"get", "events", "-o", "json"}, scope...)...)
+	}
+
+	report, err := buildAutoscalingReport(hpaOutput, scaledObjectOutput, eventsOutput, name, eventLimit)
+	if err != nil {
+		return mcp.NewToolResultError("Error building autoscaling report: " + err.Error()), nil
+	}
+	if name != "" && len(report.Autoscalers) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("no HPA or ScaledObject named %s found", name)), nil
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling autoscaling report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
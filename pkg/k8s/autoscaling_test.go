@@ -0,0 +1,149 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHPAs = `{"items":[
+{"metadata":{"name":"web","namespace":"prod"},
+ "spec":{"scaleTargetRef":{"kind":"Deployment","name":"web"},"minReplicas":2,"maxReplicas":5,
+  "metrics":[{"type":"Resource","resource":{"name":"cpu","target":{"type":"Utilization","averageUtilization":70}}},
+   {"type":"Pods","pods":{"metric":{"name":"http_requests"},"target":{"type":"AverageValue","averageValue":"100"}}}]},
+ "status":{"currentReplicas":5,"desiredReplicas":5,"lastScaleTime":"2026-10-15T09:00:00Z",
+  "currentMetrics":[{"type":"Resource","resource":{"name":"cpu","current":{"averageUtilization":95}}},
+   {"type":"Pods","pods":{"metric":{"name":"http_requests"},"current":{"averageValue":"150"}}}],
+  "conditions":[{"type":"AbleToScale","status":"True"},{"type":"ScalingActive","status":"True"},{"type":"ScalingLimited","status":"True","reason":"TooManyReplicas"}]}},
+{"metadata":{"name":"api","namespace":"prod"},
+ "spec":{"scaleTargetRef":{"kind":"Deployment","name":"api"},"maxReplicas":10,
+  "metrics":[{"type":"Resource","resource":{"name":"memory","target":{"type":"Utilization","averageUtilization":80}}}]},
+ "status":{"currentReplicas":3,"desiredReplicas":3,
+  "conditions":[{"type":"ScalingActive","status":"False","reason":"FailedGetResourceMetric","message":"unable to get metrics for resource memory"}]}},
+{"metadata":{"name":"keda-hpa-worker","namespace":"prod"},
+ "spec":{"scaleTargetRef":{"kind":"Deployment","name":"worker"},"minReplicas":1,"maxReplicas":20,
+  "metrics":[{"type":"External","external":{"metric":{"name":"s0-kafka-orders"},"target":{"type":"AverageValue","averageValue":"50"}}}]},
+ "status":{"currentReplicas":1,"desiredReplicas":1}}
+]}`
+
+const testScaledObjects = `{"items":[
+{"metadata":{"name":"worker","namespace":"prod"},
+ "spec":{"scaleTargetRef":{"name":"worker"},"minReplicaCount":1,"maxReplicaCount":20,"triggers":[{"type":"kafka"}]},
+ "status":{"hpaName":"keda-hpa-worker","conditions":[{"type":"Ready","status":"True"},{"type":"Active","status":"False"},{"type":"Fallback","status":"False"}]}}
+]}`
+
+const testScalingEvents = `{"items":[
+{"involvedObject":{"kind":"HorizontalPodAutoscaler","name":"web","namespace":"prod"},"type":"Normal",
+ "reason":"SuccessfulRescale","message":"New size: 4","count":1,"lastTimestamp":"2026-10-15T08:00:00Z"},
+{"involvedObject":{"kind":"HorizontalPodAutoscaler","name":"web","namespace":"prod"},"type":"Normal",
+ "reason":"SuccessfulRescale","message":"New size: 5","count":1,"lastTimestamp":"2026-10-15T09:00:00Z"},
+{"involvedObject":{"kind":"Pod","name":"web-1","namespace":"prod"},"type":"Normal",
+ "reason":"Pulled","message":"Container image pulled","count":1,"lastTimestamp":"2026-10-15T09:01:00Z"}
+]}`
+
+func TestPinnedState(t *testing.T) {
+	assert.Equal(t, pinnedAtMax, pinnedState(5, 2, 5))
+	assert.Equal(t, pinnedAtMin, pinnedState(2, 2, 5))
+	assert.Empty(t, pinnedState(3, 2, 5))
+	assert.Equal(t, pinnedAtMax, pinnedState(3, 3, 3))
+}
+
+func TestHandleAutoscalingStatus(t *testing.T) {
+	newMock := func() *cmd.MockShellExecutor {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "hpa", "-o", "json", "-n", "prod"}, testHPAs, nil)
+		mock.AddCommandString("kubectl", []string{"get", "scaledobjects.keda.sh", "-o", "json", "-n", "prod"}, testScaledObjects, nil)
+		mock.AddCommandString("kubectl", []string{"get", "events", "-o", "json", "-n", "prod"}, testScalingEvents, nil)
+		return mock
+	}
+
+	t.Run("reports hpas and scaled objects", func(t *testing.T) {
+		ctx := cmd.WithShellExecutor(context.Background(), newMock())
+
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{"namespace": "prod"}
+		result, err := newTestK8sTool().handleAutoscalingStatus(ctx, req)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report AutoscalingReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		assert.Equal(t, AutoscalingSummary{HPAs: 3, ScaledObjects: 1, AtMax: 1, AtMin: 2, Unhealthy: 2, KEDAInstalled: true}, report.Summary)
+		require.Len(t, report.Autoscalers, 4)
+
+		web := report.Autoscalers[0]
+		assert.Equal(t, "web", web.Name)
+		assert.Equal(t, pinnedAtMax, web.Pinned)
+		require.Len(t, web.Metrics, 2)
+		assert.Equal(t, ScalingMetric{Name: "cpu", Type: "Resource", Target: "70%", Current: "95%", AboveTarget: true}, web.Metrics[0])
+		assert.Equal(t, "avg 150", web.Metrics[1].Current)
+		assert.False(t, web.Metrics[1].AboveTarget)
+		require.Len(t, web.Issues, 1)
+		assert.Contains(t, web.Issues[0], "pinned at max replicas while cpu is above target")
+		require.Len(t, web.Events, 2)
+		assert.Equal(t, "New size: 5", web.Events[0].Message)
+
+		api := report.Autoscalers[1]
+		assert.Equal(t, "api", api.Name)
+		assert.Equal(t, 1, api.MinReplicas)
+		require.Len(t, api.Issues, 1)
+		assert.Contains(t, api.Issues[0], "unable to get metrics")
+
+		worker := report.Autoscalers[2]
+		assert.Equal(t, "ScaledObject/worker", worker.ManagedBy)
+		assert.Equal(t, "s0-kafka-orders", worker.Metrics[0].Name)
+
+		scaledObject := report.Autoscalers[3]
+		assert.Equal(t, "ScaledObject", scaledObject.Kind)
+		assert.Equal(t, "Deployment/worker", scaledObject.Target)
+		assert.Equal(t, []string{"kafka"}, scaledObject.Triggers)
+		assert.Equal(t, 1, scaledObject.CurrentReplicas)
+		assert.Equal(t, pinnedAtMin, scaledObject.Pinned)
+		assert.Empty(t, scaledObject.Issues)
+	})
+
+	t.Run("without keda or events", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "hpa", "-o", "json", "--all-namespaces"}, testHPAs, nil)
+		mock.AddCommandString("kubectl", []string{"get", "scaledobjects.keda.sh", "-o", "json", "--all-namespaces"}, "", assert.AnError)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{"name": "keda-hpa-worker", "event_limit": float64(0)}
+		result, err := newTestK8sTool().handleAutoscalingStatus(ctx, req)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report AutoscalingReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		assert.False(t, report.Summary.KEDAInstalled)
+		require.Len(t, report.Autoscalers, 1)
+		assert.Empty(t, report.Autoscalers[0].ManagedBy)
+		assert.Len(t, mock.GetCallLog(), 2)
+	})
+
+	t.Run("name not found", func(t *testing.T) {
+		ctx := cmd.WithShellExecutor(context.Background(), newMock())
+
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{"namespace": "prod", "name": "missing"}
+		result, err := newTestK8sTool().handleAutoscalingStatus(ctx, req)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("kubectl failure", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "hpa", "-o", "json", "--all-namespaces"}, "", assert.AnError)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleAutoscalingStatus(ctx, mcp.CallToolRequest{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
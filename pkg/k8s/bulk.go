@@ -0,0 +1,327 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/changes"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Limits of selector mode in the label and annotation tools
+const (
+	defaultBulkBatchSize  = 50
+	maxBulkBatchSize      = 500
+	defaultBulkMaxRetries = 2
+	maxBulkObjects        = 1000
+)
+
+// bulkRetryDelay is the delay before the first retry of an object, growing with each attempt
+var bulkRetryDelay = time.Second
+
+// Outcomes of an object in selector mode
+const (
+	BulkUpdated     = "updated"
+	BulkUnchanged   = "unchanged"
+	BulkFailed      = "failed"
+	BulkWouldUpdate = "would_update"
+)
+
+// metadataEdit is a change of the labels or annotations of resources, as
+// made by kubectl label or kubectl annotate
+type metadataEdit struct {
+	tool string
+	// verb is the kubectl command, label or annotate
+	verb string
+	// field is the metadata field the verb changes, labels or annotations
+	field string
+	// assignments are key=value pairs to set and key- removals
+	assignments []string
+	overwrite   bool
+	summary     string
+}
+
+// args returns the arguments of kubectl changing the given objects
+func (e metadataEdit) args(resourceType, namespace string, names []string) []string {
+	args := append([]string{e.verb, resourceType}, names...)
+	args = append(args, e.assignments...)
+	if e.overwrite {
+		args = append(args, "--overwrite")
+	}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	return args
+}
+
+// preview returns the current values of the keys the edit changes, and
+// whether the edit changes anything. It fails when a key would be replaced
+// without overwrite, as kubectl refuses to.
+func (e metadataEdit) preview(current map[string]string) (previous map[string]string, changed bool, err error) {
+	previous = map[string]string{}
+	for _, assignment := range e.assignments {
+		if key, ok := strings.CutSuffix(assignment, "-"); ok && !strings.Contains(assignment, "=") {
+			if value, exists := current[key]; exists {
+				previous[key], changed = value, true
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(assignment, "=")
+		existing, exists := current[key]
+		if exists {
+			previous[key] = existing
+		}
+		switch {
+		case exists && existing == value:
+		case exists && !e.overwrite:
+			return previous, false, fmt.Errorf("%s %s already has the value %q; set overwrite to replace it", strings.TrimSuffix(e.field, "s"), key, existing)
+		default:
+			changed = true
+		}
+	}
+	if len(previous) == 0 {
+		previous = nil
+	}
+	return previous, changed, nil
+}
+
+// bulkSelection selects the resources a label or annotation tool changes in selector mode
+type bulkSelection struct {
+	labelSelector string
+	fieldSelector string
+	allNamespaces bool
+	batchSize     int
+	maxRetries    int
+	dryRun        bool
+}
+
+// parseBulkSelection reads the selector mode parameters of the label and annotation tools
+func parseBulkSelection(p *params.Parser) bulkSelection {
+	return bulkSelection{
+		labelSelector: p.String("label_selector", "", params.Check(security.ValidateCommandInput)),
+		fieldSelector: p.String("field_selector", "", params.Check(security.ValidateCommandInput)),
+		allNamespaces: p.Bool("all_namespaces", false),
+		batchSize:     p.Int("batch_size", defaultBulkBatchSize, params.Range(1, maxBulkBatchSize)),
+		maxRetries:    p.Int("max_retries", defaultBulkMaxRetries, params.Range(0, 10)),
+		dryRun:        p.Bool("dry_run", false),
+	}
+}
+
+// withSelectorMode adds the selector mode parameters to the options of a label or annotation tool
+func withSelectorMode(opts ...mcp.ToolOption) []mcp.ToolOption {
+	return append(opts,
+		mcp.WithString("label_selector", mcp.Description("Change all resources matching this label selector instead of one named resource, e.g. app=web")),
+		mcp.WithString("field_selector", mcp.Description("Change all resources matching this field selector instead of one named resource, e.g. status.phase=Running")),
+		mcp.WithString("all_namespaces", mcp.Description("Match resources in all namespaces in selector mode (true/false, default: false)")),
+		mcp.WithNumber("batch_size", mcp.Description(fmt.Sprintf("Resources changed per kubectl call in selector mode (default: %d)", defaultBulkBatchSize))),
+		mcp.WithNumber("max_retries", mcp.Description(fmt.Sprintf("Retries of each resource of a failed batch (default: %d)", defaultBulkMaxRetries))),
+		mcp.WithString("dry_run", mcp.Description("Only preview which resources would change in selector mode (true/false, default: false)")),
+	)
+}
+
+// active reports whether a selector was given
+func (s bulkSelection) active() bool {
+	return s.labelSelector != "" || s.fieldSelector != ""
+}
+
+// validate checks that a tool targets either one named resource or the
+// resources matching a selector
+func (s bulkSelection) validate(resourceName string) error {
+	switch {
+	case resourceName == "" && !s.active():
+		return fmt.Errorf("either resource_name or label_selector/field_selector is required")
+	case resourceName != "" && s.active():
+		return fmt.Errorf("resource_name cannot be combined with label_selector/field_selector")
+	case resourceName != "" && (s.allNamespaces || s.dryRun):
+		return fmt.Errorf("all_namespaces and dry_run apply to selector mode only")
+	}
+	return nil
+}
+
+// BulkObjectResult is the outcome of changing one resource in selector mode
+type BulkObjectResult struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	// Previous holds the values the changed keys had before
+	Previous map[string]string `json:"previous,omitempty"`
+	Attempts int               `json:"attempts,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// BulkMetadataResult is the response of the label and annotation tools in selector mode
+type BulkMetadataResult struct {
+	Operation     string             `json:"operation"`
+	ResourceType  string             `json:"resource_type"`
+	LabelSelector string             `json:"label_selector,omitempty"`
+	FieldSelector string             `json:"field_selector,omitempty"`
+	Assignments   []string           `json:"assignments"`
+	DryRun        bool               `json:"dry_run,omitempty"`
+	Matched       int                `json:"matched"`
+	Updated       int                `json:"updated"`
+	Unchanged     int                `json:"unchanged"`
+	Failed        int                `json:"failed"`
+	Objects       []BulkObjectResult `json:"objects"`
+}
+
+// bulkObject is a resource matched by a selector, as listed before the change
+type bulkObject struct {
+	result BulkObjectResult
+	prior  changes.Change
+}
+
+// bulkMetadata applies a label or annotation edit to every resource matching
+// a selector, in batches of one kubectl call per namespace. Objects of a
+// failed batch are retried one by one, so that each gets its own outcome.
+func (k *K8sTool) bulkMetadata(ctx context.Context, resourceType, namespace string, selection bulkSelection, edit metadataEdit) (*mcp.CallToolResult, error) {
+	args := []string{"get", resourceType, "-o", "json"}
+	if selection.labelSelector != "" {
+		args = append(args, "-l", selection.labelSelector)
+	}
+	if selection.fieldSelector != "" {
+		args = append(args, "--field-selector", selection.fieldSelector)
+	}
+	if selection.allNamespaces {
+		args = append(args, "--all-namespaces")
+	} else if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	output, err := k.runKubectlCommandString(ctx, args...)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list %s: %v", resourceType, err)), nil
+	}
+	var list struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse %s: %v", resourceType, err)), nil
+	}
+	if len(list.Items) > maxBulkObjects {
+		return mcp.NewToolResultError(fmt.Sprintf("the selector matches %d %s, more than the limit of %d; narrow it down", len(list.Items), resourceType, maxBulkObjects)), nil
+	}
+
+	result := BulkMetadataResult{
+		Operation:     edit.verb,
+		ResourceType:  resourceType,
+		LabelSelector: selection.labelSelector,
+		FieldSelector: selection.fieldSelector,
+		Assignments:   edit.assignments,
+		DryRun:        selection.dryRun,
+		Matched:       len(list.Items),
+		Objects:       []BulkObjectResult{},
+	}
+
+	// Objects to change, by namespace
+	pending := map[string][]*bulkObject{}
+	var objects []*bulkObject
+	for _, item := range list.Items {
+		metadata, _ := item["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		objectNamespace, _ := metadata["namespace"].(string)
+		current := map[string]string{}
+		if values, ok := metadata[edit.field].(map[string]interface{}); ok {
+			for key, value := range values {
+				current[key], _ = value.(string)
+			}
+		}
+
+		object := &bulkObject{result: BulkObjectResult{Namespace: objectNamespace, Name: name}}
+		objects = append(objects, object)
+		previous, changed, err := edit.preview(current)
+		object.result.Previous = previous
+		switch {
+		case err != nil:
+			object.result.Status, object.result.Error = BulkFailed, err.Error()
+		case !changed:
+			object.result.Status = BulkUnchanged
+		case selection.dryRun:
+			object.result.Status = BulkWouldUpdate
+		default:
+			object.prior = priorFromObject(changes.Change{Kind: resourceType, Name: name, Namespace: objectNamespace}, item)
+			pending[objectNamespace] = append(pending[objectNamespace], object)
+		}
+	}
+
+	namespaces := make([]string, 0, len(pending))
+	for objectNamespace := range pending {
+		namespaces = append(namespaces, objectNamespace)
+	}
+	sort.Strings(namespaces)
+	for _, objectNamespace := range namespaces {
+		batch := pending[objectNamespace]
+		for start := 0; start < len(batch); start += selection.batchSize {
+			end := min(start+selection.batchSize, len(batch))
+			k.applyBatch(ctx, resourceType, objectNamespace, batch[start:end], selection.maxRetries, edit)
+		}
+	}
+
+	for _, object := range objects {
+		switch object.result.Status {
+		case BulkUpdated:
+			result.Updated++
+			object.prior.Tool, object.prior.Summary = edit.tool, edit.summary
+			changes.Record(ctx, object.prior)
+		case BulkUnchanged:
+			result.Unchanged++
+		case BulkFailed:
+			result.Failed++
+		}
+		result.Objects = append(result.Objects, object.result)
+	}
+	// Failures first, so that they are not lost in long listings
+	sort.SliceStable(result.Objects, func(i, j int) bool {
+		return result.Objects[i].Status == BulkFailed && result.Objects[j].Status != BulkFailed
+	})
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling bulk result: " + err.Error()), nil
+	}
+	if result.Failed > 0 && result.Updated == 0 && !selection.dryRun {
+		return mcp.NewToolResultError(string(resultJSON)), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// applyBatch changes a batch of objects of one namespace with a single
+// kubectl call, retrying the objects one by one when the batch fails
+func (k *K8sTool) applyBatch(ctx context.Context, resourceType, namespace string, batch []*bulkObject, maxRetries int, edit metadataEdit) {
+	names := make([]string, len(batch))
+	for i, object := range batch {
+		names[i] = object.result.Name
+	}
+	if _, err := k.runKubectlCommandString(ctx, edit.args(resourceType, namespace, names)...); err == nil {
+		for _, object := range batch {
+			object.result.Status, object.result.Attempts = BulkUpdated, 1
+		}
+		return
+	}
+
+	for _, object := range batch {
+		object.result.Attempts = 1
+		for attempt := 0; attempt <= maxRetries; attempt++ {
+			if attempt > 0 {
+				select {
+				case <-ctx.Done():
+					object.result.Status, object.result.Error = BulkFailed, ctx.Err().Error()
+					return
+				case <-time.After(time.Duration(attempt) * bulkRetryDelay):
+				}
+			}
+			object.result.Attempts++
+			_, err := k.runKubectlCommandString(ctx, edit.args(resourceType, namespace, []string{object.result.Name})...)
+			if err == nil {
+				object.result.Status, object.result.Error = BulkUpdated, ""
+				break
+			}
+			object.result.Status, object.result.Error = BulkFailed, err.Error()
+		}
+	}
+}
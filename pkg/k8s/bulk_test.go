@@ -0,0 +1,126 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/changes"
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const testBulkDeployments = `{"items":[
+ {"kind":"Deployment","metadata":{"name":"api","namespace":"prod","labels":{"app":"api","tier":"backend"}}},
+ {"kind":"Deployment","metadata":{"name":"web","namespace":"prod","labels":{"app":"web","backup":"daily"}}},
+ {"kind":"Deployment","metadata":{"name":"worker","namespace":"prod","labels":{"app":"worker","backup":"weekly"}}}]}`
+
+func callBulkLabel(t *testing.T, ctx context.Context, args map[string]interface{}) (BulkMetadataResult, *mcp.CallToolResult) {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	result, err := newTestK8sTool().handleLabelResource(ctx, request)
+	require.NoError(t, err)
+	var bulk BulkMetadataResult
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &bulk), getResultText(result))
+	return bulk, result
+}
+
+func TestBulkLabelDryRun(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "deployment", "-o", "json", "-l", "team=payments", "-n", "prod"}, testBulkDeployments, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	bulk, result := callBulkLabel(t, ctx, map[string]interface{}{
+		"resource_type": "deployment", "labels": "backup=daily", "namespace": "prod",
+		"label_selector": "team=payments", "dry_run": "true",
+	})
+	assert.False(t, result.IsError)
+	assert.Equal(t, 3, bulk.Matched)
+	assert.Equal(t, 1, bulk.Unchanged)
+	assert.Equal(t, 1, bulk.Failed)
+
+	require.Len(t, bulk.Objects, 3)
+	assert.Equal(t, BulkObjectResult{Namespace: "prod", Name: "worker", Status: BulkFailed, Previous: map[string]string{"backup": "weekly"},
+		Error: `label backup already has the value "weekly"; set overwrite to replace it`}, bulk.Objects[0])
+	assert.Equal(t, BulkWouldUpdate, bulk.Objects[1].Status)
+	assert.Equal(t, BulkUnchanged, bulk.Objects[2].Status)
+	assert.Len(t, mock.GetCallLog(), 1, "a dry run changes nothing")
+}
+
+func TestBulkLabelBatches(t *testing.T) {
+	history := useChangeHistory(t)
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "deployment", "-o", "json", "-l", "team=payments", "-n", "prod"}, testBulkDeployments, nil)
+	mock.AddCommandString("kubectl", []string{"label", "deployment", "api", "worker", "backup=daily", "--overwrite", "-n", "prod"}, "labeled", nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	bulk, result := callBulkLabel(t, ctx, map[string]interface{}{
+		"resource_type": "deployment", "labels": "backup=daily", "namespace": "prod",
+		"label_selector": "team=payments", "overwrite": "true",
+	})
+	require.False(t, result.IsError, getResultText(result))
+	assert.Equal(t, 2, bulk.Updated)
+	assert.Equal(t, 1, bulk.Unchanged)
+	assert.Len(t, mock.GetCallLog(), 2, "one list and one batch")
+
+	recorded, err := history.List(context.Background(), changes.Filter{})
+	require.NoError(t, err)
+	require.Len(t, recorded, 2)
+	assert.Equal(t, "k8s_label_resource", recorded[0].Tool)
+}
+
+func TestBulkLabelRetriesFailedBatch(t *testing.T) {
+	useChangeHistory(t)
+	bulkRetryDelay = 0
+	t.Cleanup(func() { bulkRetryDelay = time.Second })
+
+	testDeployments := `{"items":[
+ {"metadata":{"name":"api","namespace":"prod","labels":{"maintenance":"true"}}},
+ {"metadata":{"name":"web","namespace":"prod","labels":{"maintenance":"true"}}},
+ {"metadata":{"name":"worker","namespace":"prod","labels":{"maintenance":"true"}}}]}`
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "deployment", "-o", "json", "-l", "team=payments", "--all-namespaces"}, testDeployments, nil)
+	mock.AddCommandString("kubectl", []string{"label", "deployment", "api", "web", "worker", "maintenance-", "-n", "prod"}, "", errors.New("conflict"))
+	mock.AddCommandString("kubectl", []string{"label", "deployment", "web", "maintenance-", "-n", "prod"}, "labeled", nil)
+	mock.AddCommandString("kubectl", []string{"label", "deployment", "worker", "maintenance-", "-n", "prod"}, "labeled", nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"resource_type": "deployment", "label_key": "maintenance",
+		"label_selector": "team=payments", "all_namespaces": "true", "max_retries": float64(1),
+	}
+	result, err := newTestK8sTool().handleRemoveLabel(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var bulk BulkMetadataResult
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &bulk))
+	assert.Equal(t, 2, bulk.Updated)
+	assert.Equal(t, 1, bulk.Failed)
+	assert.Equal(t, "api", bulk.Objects[0].Name)
+	assert.Equal(t, 3, bulk.Objects[0].Attempts, "the batch and two attempts on its own")
+	assert.Equal(t, map[string]string{"maintenance": "true"}, bulk.Objects[0].Previous)
+}
+
+func TestBulkSelectionValidation(t *testing.T) {
+	for name, args := range map[string]map[string]interface{}{
+		"no target":         {"resource_type": "pod", "labels": "a=b"},
+		"name and selector": {"resource_type": "pod", "labels": "a=b", "resource_name": "web", "label_selector": "app=web"},
+		"dry run by name":   {"resource_type": "pod", "labels": "a=b", "resource_name": "web", "dry_run": "true"},
+		"unsafe selector":   {"resource_type": "pod", "labels": "a=b", "label_selector": "app=web; rm -rf /"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = args
+			result, err := newTestK8sTool().handleLabelResource(context.Background(), request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+		})
+	}
+}
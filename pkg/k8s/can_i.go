@@ -0,0 +1,274 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// PermissionRule is a rule of the server's effective permissions, as listed by kubectl auth can-i --list
+type PermissionRule struct {
+	Resources       []string `json:"resources,omitempty"`
+	NonResourceURLs []string `json:"non_resource_urls,omitempty"`
+	ResourceNames   []string `json:"resource_names,omitempty"`
+	Verbs           []string `json:"verbs"`
+}
+
+// SuggestedRule is the RBAC rule that would grant a denied permission
+type SuggestedRule struct {
+	APIGroups       []string `json:"apiGroups,omitempty"`
+	Resources       []string `json:"resources,omitempty"`
+	ResourceNames   []string `json:"resourceNames,omitempty"`
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty"`
+	Verbs           []string `json:"verbs"`
+}
+
+// PermissionCheck is the structured response of k8s_can_i for a single action
+type PermissionCheck struct {
+	Verb            string         `json:"verb"`
+	Resource        string         `json:"resource,omitempty"`
+	Group           string         `json:"group,omitempty"`
+	Subresource     string         `json:"subresource,omitempty"`
+	Name            string         `json:"name,omitempty"`
+	Namespace       string         `json:"namespace,omitempty"`
+	NonResourceURL  string         `json:"non_resource_url,omitempty"`
+	Allowed         bool           `json:"allowed"`
+	Denied          bool           `json:"denied,omitempty"`
+	Reason          string         `json:"reason,omitempty"`
+	EvaluationError string         `json:"evaluation_error,omitempty"`
+	Explanation     string         `json:"explanation,omitempty"`
+	SuggestedRule   *SuggestedRule `json:"suggested_rule,omitempty"`
+}
+
+// PermissionList is the structured response of k8s_can_i in list mode
+type PermissionList struct {
+	Namespace string           `json:"namespace,omitempty"`
+	Rules     []PermissionRule `json:"rules"`
+}
+
+// splitResource splits a kubectl resource argument such as deployments.apps/scale
+// into its resource, API group and subresource
+func splitResource(resource string) (name, group, subresource string) {
+	name, subresource, _ = strings.Cut(resource, "/")
+	name, group, _ = strings.Cut(name, ".")
+	return name, group, subresource
+}
+
+// accessReview returns the SelfSubjectAccessReview kubectl auth can-i submits for a check
+func accessReview(check PermissionCheck) map[string]interface{} {
+	spec := map[string]interface{}{}
+	if check.NonResourceURL != "" {
+		spec["nonResourceAttributes"] = map[string]string{"path": check.NonResourceURL, "verb": check.Verb}
+	} else {
+		spec["resourceAttributes"] = map[string]string{
+			"verb":        check.Verb,
+			"group":       check.Group,
+			"resource":    check.Resource,
+			"subresource": check.Subresource,
+			"name":        check.Name,
+			"namespace":   check.Namespace,
+		}
+	}
+	return map[string]interface{}{
+		"apiVersion": "authorization.k8s.io/v1",
+		"kind":       "SelfSubjectAccessReview",
+		"spec":       spec,
+	}
+}
+
+// explainDenial describes a denied check and the RBAC rule that would allow it
+func explainDenial(check *PermissionCheck) {
+	if check.NonResourceURL != "" {
+		check.Explanation = fmt.Sprintf("The server's identity is not allowed to %s the non-resource URL %s. A ClusterRole with this rule must be bound to it with a ClusterRoleBinding.",
+			check.Verb, check.NonResourceURL)
+		check.SuggestedRule = &SuggestedRule{NonResourceURLs: []string{check.NonResourceURL}, Verbs: []string{check.Verb}}
+		return
+	}
+
+	resource := check.Resource
+	if check.Subresource != "" {
+		resource += "/" + check.Subresource
+	}
+	target := resource
+	if check.Name != "" {
+		target = fmt.Sprintf("%s %q", resource, check.Name)
+	}
+	scope := "in any namespace or at cluster scope. A ClusterRole with this rule must be bound to it with a ClusterRoleBinding."
+	if check.Namespace != "" {
+		scope = fmt.Sprintf("in namespace %s. A Role in %s (or a ClusterRole) with this rule must be bound to it with a RoleBinding in that namespace.", check.Namespace, check.Namespace)
+	}
+	check.Explanation = fmt.Sprintf("The server's identity is not allowed to %s %s %s", check.Verb, target, scope)
+	if check.Reason != "" {
+		check.Explanation += " Authorizer: " + check.Reason
+	}
+
+	check.SuggestedRule = &SuggestedRule{APIGroups: []string{check.Group}, Resources: []string{resource}, Verbs: []string{check.Verb}}
+	if check.Name != "" {
+		check.SuggestedRule.ResourceNames = []string{check.Name}
+	}
+}
+
+// splitCanIFields splits a row of kubectl auth can-i --list output into its
+// columns, keeping bracketed lists such as [get list watch] together
+func splitCanIFields(line string) []string {
+	var fields []string
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimSpace(line) {
+		end := strings.IndexAny(line, " \t")
+		if line[0] == '[' {
+			end = strings.Index(line, "]") + 1
+		}
+		if end <= 0 {
+			end = len(line)
+		}
+		fields = append(fields, line[:end])
+		line = line[end:]
+	}
+	return fields
+}
+
+// bracketList parses a bracketed list column such as [get list]
+func bracketList(field string) []string {
+	return strings.Fields(strings.Trim(field, "[]"))
+}
+
+// parseCanIList parses the table printed by kubectl auth can-i --list
+func parseCanIList(output string) []PermissionRule {
+	rules := []PermissionRule{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "Resources") {
+			continue
+		}
+		fields := splitCanIFields(line)
+		// Rows for non-resource URLs have an empty Resources column
+		if len(fields) == 3 {
+			fields = append([]string{""}, fields...)
+		}
+		if len(fields) != 4 {
+			continue
+		}
+		rule := PermissionRule{
+			NonResourceURLs: bracketList(fields[1]),
+			ResourceNames:   bracketList(fields[2]),
+			Verbs:           bracketList(fields[3]),
+		}
+		if fields[0] != "" {
+			rule.Resources = []string{fields[0]}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// runAccessReview submits a SelfSubjectAccessReview and records its outcome in check
+func (k *K8sTool) runAccessReview(ctx context.Context, check *PermissionCheck) error {
+	review, err := json.Marshal(accessReview(*check))
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp("", "k8s-access-review-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		if removeErr := os.Remove(tmpFile.Name()); removeErr != nil {
+			logger.Get().Error("Failed to remove temporary file", "error", removeErr, "file", tmpFile.Name())
+		}
+	}()
+	if _, err := tmpFile.Write(review); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	output, err := k.runKubectlCommandString(ctx, "create", "-f", tmpFile.Name(), "-o", "json")
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Status struct {
+			Allowed         bool   `json:"allowed"`
+			Denied          bool   `json:"denied"`
+			Reason          string `json:"reason"`
+			EvaluationError string `json:"evaluationError"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return fmt.Errorf("failed to parse access review: %w", err)
+	}
+	check.Allowed = result.Status.Allowed
+	check.Denied = result.Status.Denied
+	check.Reason = result.Status.Reason
+	check.EvaluationError = result.Status.EvaluationError
+	return nil
+}
+
+// Check whether the server's identity may perform an action, like kubectl auth can-i
+func (k *K8sTool) handleCanI(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	list := p.Bool("list", false)
+	// The action is sent in an access review document rather than as CLI arguments
+	verb := p.String("verb", "")
+	resource := p.String("resource", "")
+	nonResourceURL := p.String("non_resource_url", "")
+	name := p.String("name", "", params.Check(security.ValidateK8sResourceName))
+	namespace := p.String("namespace", "", params.Check(security.ValidateNamespace))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if list {
+		args := []string{"auth", "can-i", "--list"}
+		if namespace != "" {
+			args = append(args, "-n", namespace)
+		}
+		output, err := k.runKubectlCommandString(ctx, args...)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list permissions: %v", err)), nil
+		}
+		listJSON, err := json.MarshalIndent(PermissionList{Namespace: namespace, Rules: parseCanIList(output)}, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError("Error marshaling permissions: " + err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(listJSON)), nil
+	}
+
+	switch {
+	case verb == "":
+		return mcp.NewToolResultError("verb is required unless list is true"), nil
+	case resource == "" && nonResourceURL == "":
+		return mcp.NewToolResultError("resource or non_resource_url is required unless list is true"), nil
+	case resource != "" && nonResourceURL != "":
+		return mcp.NewToolResultError("resource and non_resource_url are mutually exclusive"), nil
+	case nonResourceURL != "" && !strings.HasPrefix(nonResourceURL, "/"):
+		return mcp.NewToolResultError("non_resource_url must start with /"), nil
+	}
+
+	check := PermissionCheck{Verb: verb, Name: name, Namespace: namespace, NonResourceURL: nonResourceURL}
+	if resource != "" {
+		check.Resource, check.Group, check.Subresource = splitResource(resource)
+	}
+	if err := k.runAccessReview(ctx, &check); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to check permission: %v", err)), nil
+	}
+	if !check.Allowed {
+		explainDenial(&check)
+	}
+
+	checkJSON, err := json.MarshalIndent(check, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling permission check: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(checkJSON)), nil
+}
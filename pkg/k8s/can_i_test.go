@@ -0,0 +1,143 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCanIList = `Resources                                       Non-Resource URLs   Resource Names   Verbs
+selfsubjectaccessreviews.authorization.k8s.io   []                  []               [create]
+pods                                            []                  []               [get list watch]
+configmaps                                      []                  [app-config]     [get]
+                                                [/api/*]            []               [get]
+`
+
+// accessReviewExecutor answers SelfSubjectAccessReviews, recording the submitted review
+type accessReviewExecutor struct {
+	allowed bool
+	reason  string
+	review  map[string]interface{}
+}
+
+func (e *accessReviewExecutor) Exec(ctx context.Context, command string, args ...string) ([]byte, error) {
+	data, err := os.ReadFile(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &e.review); err != nil {
+		return nil, err
+	}
+	response, _ := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"allowed": e.allowed, "reason": e.reason},
+	})
+	return response, nil
+}
+
+func TestParseCanIList(t *testing.T) {
+	rules := parseCanIList(testCanIList)
+	require.Len(t, rules, 4)
+	assert.Equal(t, PermissionRule{Resources: []string{"pods"}, NonResourceURLs: []string{}, ResourceNames: []string{}, Verbs: []string{"get", "list", "watch"}}, rules[1])
+	assert.Equal(t, []string{"app-config"}, rules[2].ResourceNames)
+	assert.Nil(t, rules[3].Resources)
+	assert.Equal(t, []string{"/api/*"}, rules[3].NonResourceURLs)
+}
+
+func TestSplitResource(t *testing.T) {
+	name, group, subresource := splitResource("deployments.apps/scale")
+	assert.Equal(t, []string{"deployments", "apps", "scale"}, []string{name, group, subresource})
+
+	name, group, subresource = splitResource("pods")
+	assert.Equal(t, []string{"pods", "", ""}, []string{name, group, subresource})
+}
+
+func TestHandleCanI(t *testing.T) {
+	t.Run("allowed", func(t *testing.T) {
+		executor := &accessReviewExecutor{allowed: true}
+		ctx := cmd.WithShellExecutor(context.Background(), executor)
+
+		result, err := newTestK8sTool().handleCanI(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"verb": "get", "resource": "pods/log", "namespace": "prod",
+		}}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var check PermissionCheck
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &check))
+		assert.True(t, check.Allowed)
+		assert.Empty(t, check.Explanation)
+		assert.Equal(t, "SelfSubjectAccessReview", executor.review["kind"])
+		attributes := executor.review["spec"].(map[string]interface{})["resourceAttributes"].(map[string]interface{})
+		assert.Equal(t, "pods", attributes["resource"])
+		assert.Equal(t, "log", attributes["subresource"])
+		assert.Equal(t, "prod", attributes["namespace"])
+	})
+
+	t.Run("denied is explained", func(t *testing.T) {
+		executor := &accessReviewExecutor{reason: "no RBAC policy matched"}
+		ctx := cmd.WithShellExecutor(context.Background(), executor)
+
+		result, err := newTestK8sTool().handleCanI(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"verb": "delete", "resource": "deployments.apps", "name": "web", "namespace": "prod",
+		}}})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		var check PermissionCheck
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &check))
+		assert.False(t, check.Allowed)
+		assert.Contains(t, check.Explanation, `not allowed to delete deployments "web" in namespace prod`)
+		assert.Contains(t, check.Explanation, "no RBAC policy matched")
+		assert.Equal(t, &SuggestedRule{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, ResourceNames: []string{"web"}, Verbs: []string{"delete"}}, check.SuggestedRule)
+	})
+
+	t.Run("non-resource URL", func(t *testing.T) {
+		executor := &accessReviewExecutor{}
+		ctx := cmd.WithShellExecutor(context.Background(), executor)
+
+		result, err := newTestK8sTool().handleCanI(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"verb": "get", "non_resource_url": "/metrics",
+		}}})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		assert.Equal(t, map[string]interface{}{"path": "/metrics", "verb": "get"}, executor.review["spec"].(map[string]interface{})["nonResourceAttributes"])
+		assert.Contains(t, getResultText(result), "ClusterRoleBinding")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"auth", "can-i", "--list", "-n", "prod"}, testCanIList, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleCanI(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"list": "true", "namespace": "prod",
+		}}})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		var list PermissionList
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &list))
+		assert.Equal(t, "prod", list.Namespace)
+		assert.Len(t, list.Rules, 4)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		for _, args := range []map[string]interface{}{
+			{"resource": "pods"},
+			{"verb": "get"},
+			{"verb": "get", "resource": "pods", "non_resource_url": "/metrics"},
+			{"verb": "get", "non_resource_url": "metrics"},
+			{"verb": "get", "resource": "pods", "namespace": "Invalid_NS"},
+		} {
+			result, err := newTestK8sTool().handleCanI(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+			require.NoError(t, err)
+			assert.True(t, result.IsError, args)
+		}
+	})
+}
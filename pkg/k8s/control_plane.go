@@ -0,0 +1,583 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// Statuses of the control plane checks reported by k8s_control_plane_health
+const (
+	HealthOK          = "ok"
+	HealthDegraded    = "degraded"
+	HealthFailed      = "failed"
+	HealthUnavailable = "unavailable"
+)
+
+// defaultHealthChecks are probed individually when a verbose health endpoint
+// fails and no other endpoint listed its checks
+var defaultHealthChecks = []string{"ping", "log", "etcd", "etcd-readiness", "informer-sync", "shutdown"}
+
+// etcdctlArgs run etcdctl inside a kubeadm etcd static pod with its server certificate
+var etcdctlArgs = []string{
+	"etcdctl",
+	"--endpoints=https://127.0.0.1:2379",
+	"--cacert=/etc/kubernetes/pki/etcd/ca.crt",
+	"--cert=/etc/kubernetes/pki/etcd/server.crt",
+	"--key=/etc/kubernetes/pki/etcd/server.key",
+}
+
+// etcdDefaultQuota is etcd's default backend quota; databases near it raise a NOSPACE alarm
+const etcdDefaultQuota = 2 << 30
+
+// longRunningVerbs are excluded from request latencies, their duration is the length of the stream
+var longRunningVerbs = map[string]bool{"WATCH": true, "CONNECT": true}
+
+// latencyThresholds are the p99 latencies, in seconds, of the Kubernetes API call
+// latency SLO: 1s for single-object calls and 30s for LIST
+var latencyThresholds = map[string]float64{"LIST": 30}
+
+// defaultLatencyThreshold applies to the verbs without a threshold of their own
+const defaultLatencyThreshold = 1.0
+
+// slowResourceLimit is the number of slowest resources reported
+const slowResourceLimit = 5
+
+// metricLabel matches one label of a Prometheus text exposition sample
+var metricLabel = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*)="((?:[^"\\]|\\.)*)"`)
+
+// HealthCheckFailure is a failing check of a health endpoint
+type HealthCheckFailure struct {
+	Name    string `json:"name"`
+	Message string `json:"message,omitempty"`
+}
+
+// HealthEndpointReport is the result of a verbose API server health endpoint
+type HealthEndpointReport struct {
+	Path   string               `json:"path"`
+	Status string               `json:"status"`
+	Passed []string             `json:"passed,omitempty"`
+	Failed []HealthCheckFailure `json:"failed,omitempty"`
+	Error  string               `json:"error,omitempty"`
+}
+
+// ComponentHealth is the status of a control plane component from the componentstatuses API
+type ComponentHealth struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// EtcdMember is the status of an etcd member reported by etcdctl endpoint status
+type EtcdMember struct {
+	Endpoint         string   `json:"endpoint"`
+	ID               string   `json:"id"`
+	Version          string   `json:"version"`
+	Leader           bool     `json:"leader"`
+	DBSizeBytes      int64    `json:"db_size_bytes"`
+	DBSizeInUseBytes int64    `json:"db_size_in_use_bytes"`
+	RaftTerm         uint64   `json:"raft_term"`
+	Errors           []string `json:"errors,omitempty"`
+}
+
+// EtcdHealth is the health of etcd, from the API server's etcd checks and,
+// when its pods are reachable, etcdctl
+type EtcdHealth struct {
+	Status    string       `json:"status"`
+	Readiness string       `json:"readiness"`
+	Members   []EtcdMember `json:"members,omitempty"`
+	Alarms    []string     `json:"alarms,omitempty"`
+	// Error explains why the members could not be inspected
+	Error string `json:"error,omitempty"`
+}
+
+// RequestLatency summarizes a request duration histogram
+type RequestLatency struct {
+	Verb      string  `json:"verb,omitempty"`
+	Resource  string  `json:"resource,omitempty"`
+	Requests  uint64  `json:"requests"`
+	P50       float64 `json:"p50_seconds"`
+	P90       float64 `json:"p90_seconds"`
+	P99       float64 `json:"p99_seconds"`
+	Threshold float64 `json:"threshold_seconds,omitempty"`
+	Slow      bool    `json:"slow,omitempty"`
+}
+
+// ControlPlaneReport is the structured response of k8s_control_plane_health
+type ControlPlaneReport struct {
+	Healthy         bool                 `json:"healthy"`
+	Readyz          HealthEndpointReport `json:"readyz"`
+	Livez           HealthEndpointReport `json:"livez"`
+	Components      []ComponentHealth    `json:"components,omitempty"`
+	ComponentsError string               `json:"components_error,omitempty"`
+	Etcd            EtcdHealth           `json:"etcd"`
+	// Latency holds the API request latencies by verb since the API server
+	// replica answering the request started
+	Latency            []RequestLatency `json:"latency,omitempty"`
+	SlowestResources   []RequestLatency `json:"slowest_resources,omitempty"`
+	EtcdRequestLatency *RequestLatency  `json:"etcd_request_latency,omitempty"`
+	LatencyError       string           `json:"latency_error,omitempty"`
+	Issues             []string         `json:"issues"`
+}
+
+// parseHealthChecks reads the [+]name ok and [-]name failed lines of a verbose health endpoint
+func parseHealthChecks(output string) ([]string, []HealthCheckFailure) {
+	var passed []string
+	var failed []HealthCheckFailure
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "[+]"):
+			name, _, _ := strings.Cut(line[3:], " ")
+			passed = append(passed, name)
+		case strings.HasPrefix(line, "[-]"):
+			name, message, _ := strings.Cut(line[3:], " ")
+			failed = append(failed, HealthCheckFailure{Name: name, Message: strings.TrimPrefix(message, "failed: ")})
+		}
+	}
+	return passed, failed
+}
+
+// checkHealthEndpoint reads a verbose health endpoint. kubectl does not return
+// the body of a failing endpoint, so its checks are then probed one at a time.
+func (k *K8sTool) checkHealthEndpoint(ctx context.Context, path string, knownChecks []string) HealthEndpointReport {
+	report := HealthEndpointReport{Path: path}
+	output, err := k.runKubectlCommandString(ctx, "get", "--raw", path+"?verbose")
+	if err == nil {
+		report.Passed, report.Failed = parseHealthChecks(output)
+		report.Status = HealthOK
+		if len(report.Failed) > 0 {
+			report.Status = HealthFailed
+		}
+		return report
+	}
+
+	report.Status = HealthFailed
+	report.Error = err.Error()
+	if len(knownChecks) == 0 {
+		knownChecks = defaultHealthChecks
+	}
+	probed := false
+	for _, check := range knownChecks {
+		if _, err := k.runKubectlCommandString(ctx, "get", "--raw", path+"/"+check); err != nil {
+			report.Failed = append(report.Failed, HealthCheckFailure{Name: check, Message: err.Error()})
+			continue
+		}
+		probed = true
+		report.Passed = append(report.Passed, check)
+	}
+	if !probed {
+		// Nothing answered, so the API server itself is unreachable
+		report.Status = HealthUnavailable
+		report.Passed, report.Failed = nil, nil
+	}
+	return report
+}
+
+// parseComponentStatuses reads the componentstatuses list
+func parseComponentStatuses(output string) ([]ComponentHealth, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Conditions []struct {
+				Type    string `json:"type"`
+				Status  string `json:"status"`
+				Message string `json:"message"`
+				Error   string `json:"error"`
+			} `json:"conditions"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, err
+	}
+	components := make([]ComponentHealth, 0, len(list.Items))
+	for _, item := range list.Items {
+		component := ComponentHealth{Name: item.Metadata.Name}
+		for _, condition := range item.Conditions {
+			if condition.Type == "Healthy" {
+				component.Healthy = condition.Status == "True"
+				component.Message = condition.Message
+				component.Error = condition.Error
+			}
+		}
+		components = append(components, component)
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i].Name < components[j].Name })
+	return components, nil
+}
+
+// parseEtcdEndpointStatus reads the output of etcdctl endpoint status -w json
+func parseEtcdEndpointStatus(output string) ([]EtcdMember, error) {
+	var statuses []struct {
+		Endpoint string `json:"Endpoint"`
+		Status   struct {
+			Header struct {
+				MemberID uint64 `json:"member_id"`
+			} `json:"header"`
+			Version     string   `json:"version"`
+			DBSize      int64    `json:"dbSize"`
+			DBSizeInUse int64    `json:"dbSizeInUse"`
+			Leader      uint64   `json:"leader"`
+			RaftTerm    uint64   `json:"raftTerm"`
+			Errors      []string `json:"errors"`
+		} `json:"Status"`
+	}
+	if err := json.Unmarshal([]byte(output), &statuses); err != nil {
+		return nil, err
+	}
+	members := make([]EtcdMember, 0, len(statuses))
+	for _, s := range statuses {
+		members = append(members, EtcdMember{
+			Endpoint:         s.Endpoint,
+			ID:               strconv.FormatUint(s.Status.Header.MemberID, 16),
+			Version:          s.Status.Version,
+			Leader:           s.Status.Leader != 0 && s.Status.Leader == s.Status.Header.MemberID,
+			DBSizeBytes:      s.Status.DBSize,
+			DBSizeInUseBytes: s.Status.DBSizeInUse,
+			RaftTerm:         s.Status.RaftTerm,
+			Errors:           s.Status.Errors,
+		})
+	}
+	return members, nil
+}
+
+// etcdReadiness derives the health of etcd from the API server's etcd readiness checks
+func etcdReadiness(readyz HealthEndpointReport) EtcdHealth {
+	etcd := EtcdHealth{Readiness: "unknown"}
+	if containsString(readyz.Passed, "etcd") {
+		etcd.Readiness = HealthOK
+	}
+	for _, failed := range readyz.Failed {
+		if failed.Name == "etcd" || failed.Name == "etcd-readiness" {
+			etcd.Readiness = HealthFailed
+		}
+	}
+	etcd.Status = etcd.Readiness
+	if etcd.Status == "unknown" {
+		etcd.Status = HealthUnavailable
+	}
+	return etcd
+}
+
+// inspectEtcd adds the member status and alarms reported by etcdctl in a
+// kubeadm etcd pod. Managed control planes do not expose these pods, so etcd
+// is then only known through the API server's etcd checks.
+func (k *K8sTool) inspectEtcd(ctx context.Context, etcd EtcdHealth) EtcdHealth {
+	output, err := k.runKubectlCommandString(ctx, "get", "pods", "-n", "kube-system", "-l", "component=etcd", "-o", "json")
+	if err != nil {
+		etcd.Error = fmt.Sprintf("failed to list etcd pods: %v", err)
+		return etcd
+	}
+	var pods struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Status struct {
+				Phase string `json:"phase"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &pods); err != nil {
+		etcd.Error = fmt.Sprintf("failed to parse etcd pods: %v", err)
+		return etcd
+	}
+	pod := ""
+	for _, item := range pods.Items {
+		if item.Status.Phase == "Running" {
+			pod = item.Metadata.Name
+			break
+		}
+	}
+	if pod == "" {
+		etcd.Error = "no running etcd pod in kube-system; the control plane may be managed by the cloud provider"
+		return etcd
+	}
+
+	exec := func(args ...string) (string, error) {
+		command := append([]string{"exec", "-n", "kube-system", pod, "--"}, etcdctlArgs...)
+		return k.runKubectlCommandString(ctx, append(command, args...)...)
+	}
+	output, err = exec("endpoint", "status", "--cluster", "-w", "json")
+	if err != nil {
+		etcd.Error = fmt.Sprintf("etcdctl endpoint status failed in pod %s: %v", pod, err)
+		return etcd
+	}
+	if etcd.Members, err = parseEtcdEndpointStatus(output); err != nil {
+		etcd.Error = fmt.Sprintf("failed to parse etcdctl endpoint status: %v", err)
+		return etcd
+	}
+	if output, err = exec("alarm", "list"); err != nil {
+		etcd.Error = fmt.Sprintf("etcdctl alarm list failed in pod %s: %v", pod, err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			etcd.Alarms = append(etcd.Alarms, line)
+		}
+	}
+
+	if etcd.Status != HealthFailed && len(etcdIssues(etcd)) > 0 {
+		etcd.Status = HealthDegraded
+	} else if etcd.Status == HealthUnavailable {
+		etcd.Status = HealthOK
+	}
+	return etcd
+}
+
+// etcdIssues lists the problems found in the etcd members and alarms
+func etcdIssues(etcd EtcdHealth) []string {
+	var issues []string
+	if len(etcd.Members) > 0 {
+		leaders := 0
+		for _, member := range etcd.Members {
+			if member.Leader {
+				leaders++
+			}
+			for _, err := range member.Errors {
+				issues = append(issues, fmt.Sprintf("etcd member %s reports: %s", member.Endpoint, err))
+			}
+			if member.DBSizeBytes > etcdDefaultQuota*8/10 {
+				issues = append(issues, fmt.Sprintf("etcd member %s database is %d MiB, above 80%% of the default 2 GiB quota; compact and defragment it or raise --quota-backend-bytes",
+					member.Endpoint, member.DBSizeBytes>>20))
+			}
+		}
+		if leaders == 0 {
+			issues = append(issues, "etcd has no leader, so writes to the cluster fail")
+		}
+	}
+	for _, alarm := range etcd.Alarms {
+		issues = append(issues, "etcd alarm raised: "+alarm)
+	}
+	return issues
+}
+
+// histogram maps the upper bounds of a histogram's buckets to their cumulative counts
+type histogram map[float64]float64
+
+// quantile estimates the q-quantile like PromQL's histogram_quantile, by
+// linear interpolation within the bucket holding it
+func (h histogram) quantile(q float64) float64 {
+	total := h[math.Inf(1)]
+	if total == 0 {
+		return 0
+	}
+	bounds := make([]float64, 0, len(h))
+	for bound := range h {
+		bounds = append(bounds, bound)
+	}
+	sort.Float64s(bounds)
+
+	rank := q * total
+	lower, below := 0.0, 0.0
+	for _, upper := range bounds {
+		count := h[upper]
+		if count >= rank {
+			if math.IsInf(upper, 1) {
+				return lower
+			}
+			if count == below {
+				return upper
+			}
+			return lower + (upper-lower)*(rank-below)/(count-below)
+		}
+		lower, below = upper, count
+	}
+	return lower
+}
+
+// parseHistograms sums the buckets of a histogram metric by the values of the
+// given labels, in the Prometheus text exposition format
+func parseHistograms(output, metric string, groupBy func(labels map[string]string) (string, bool)) map[string]histogram {
+	prefix := metric + "_bucket{"
+	histograms := map[string]histogram{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		end := strings.LastIndex(line, "}")
+		fields := strings.Fields(line[end+1:])
+		if end < 0 || len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		labels := map[string]string{}
+		for _, match := range metricLabel.FindAllStringSubmatch(line[len(prefix):end], -1) {
+			labels[match[1]] = match[2]
+		}
+		bound, err := strconv.ParseFloat(labels["le"], 64)
+		if err != nil {
+			continue
+		}
+		key, ok := groupBy(labels)
+		if !ok {
+			continue
+		}
+		if histograms[key] == nil {
+			histograms[key] = histogram{}
+		}
+		histograms[key][bound] += value
+	}
+	return histograms
+}
+
+// requestLatency summarizes a histogram, flagging a p99 above the verb's SLO threshold
+func requestLatency(verb, resource string, h histogram) RequestLatency {
+	round := func(seconds float64) float64 { return math.Round(seconds*1000) / 1000 }
+	latency := RequestLatency{
+		Verb:     verb,
+		Resource: resource,
+		Requests: uint64(h[math.Inf(1)]),
+		P50:      round(h.quantile(0.5)),
+		P90:      round(h.quantile(0.9)),
+		P99:      round(h.quantile(0.99)),
+	}
+	if verb != "" {
+		latency.Threshold = defaultLatencyThreshold
+		if threshold, ok := latencyThresholds[verb]; ok {
+			latency.Threshold = threshold
+		}
+		latency.Slow = latency.P99 > latency.Threshold
+	}
+	return latency
+}
+
+// apiLatencies computes request latencies by verb, the slowest resources and
+// the etcd request latency from the API server's metrics
+func apiLatencies(metrics string) ([]RequestLatency, []RequestLatency, *RequestLatency) {
+	byVerb := parseHistograms(metrics, "apiserver_request_duration_seconds", func(labels map[string]string) (string, bool) {
+		return labels["verb"], !longRunningVerbs[labels["verb"]]
+	})
+	byResource := parseHistograms(metrics, "apiserver_request_duration_seconds", func(labels map[string]string) (string, bool) {
+		resource := labels["resource"]
+		if labels["subresource"] != "" {
+			resource += "/" + labels["subresource"]
+		}
+		return labels["verb"] + " " + resource, !longRunningVerbs[labels["verb"]] && resource != ""
+	})
+	etcdRequests := parseHistograms(metrics, "etcd_request_duration_seconds", func(map[string]string) (string, bool) {
+		return "", true
+	})
+
+	latency := make([]RequestLatency, 0, len(byVerb))
+	for verb, h := range byVerb {
+		latency = append(latency, requestLatency(verb, "", h))
+	}
+	sort.Slice(latency, func(i, j int) bool { return latency[i].Verb < latency[j].Verb })
+
+	slowest := make([]RequestLatency, 0, len(byResource))
+	for key, h := range byResource {
+		verb, resource, _ := strings.Cut(key, " ")
+		slowest = append(slowest, requestLatency(verb, resource, h))
+	}
+	sort.Slice(slowest, func(i, j int) bool {
+		if slowest[i].P99 != slowest[j].P99 {
+			return slowest[i].P99 > slowest[j].P99
+		}
+		return slowest[i].Verb+slowest[i].Resource < slowest[j].Verb+slowest[j].Resource
+	})
+	if len(slowest) > slowResourceLimit {
+		slowest = slowest[:slowResourceLimit]
+	}
+
+	var etcd *RequestLatency
+	if h, ok := etcdRequests[""]; ok {
+		summary := requestLatency("", "", h)
+		etcd = &summary
+	}
+	return latency, slowest, etcd
+}
+
+// controlPlaneIssues lists the problems found across the report
+func controlPlaneIssues(report ControlPlaneReport) []string {
+	issues := []string{}
+	for _, endpoint := range []HealthEndpointReport{report.Readyz, report.Livez} {
+		switch endpoint.Status {
+		case HealthUnavailable:
+			issues = append(issues, fmt.Sprintf("API server %s is unreachable: %s", endpoint.Path, endpoint.Error))
+		case HealthFailed:
+			names := make([]string, 0, len(endpoint.Failed))
+			for _, failed := range endpoint.Failed {
+				names = append(names, failed.Name)
+			}
+			issues = append(issues, fmt.Sprintf("API server %s checks failing: %s", endpoint.Path, strings.Join(names, ", ")))
+		}
+	}
+	for _, component := range report.Components {
+		if !component.Healthy {
+			issues = append(issues, fmt.Sprintf("component %s is unhealthy: %s", component.Name, strings.TrimSpace(component.Message+" "+component.Error)))
+		}
+	}
+	issues = append(issues, etcdIssues(report.Etcd)...)
+	for _, latency := range report.Latency {
+		if latency.Slow {
+			issues = append(issues, fmt.Sprintf("%s requests have a p99 latency of %.3fs, above the %.0fs SLO", latency.Verb, latency.P99, latency.Threshold))
+		}
+	}
+	return issues
+}
+
+// Control plane health check
+func (k *K8sTool) handleControlPlaneHealth(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	checkEtcd := p.Bool("check_etcd", true)
+	includeLatency := p.Bool("include_latency", true)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var report ControlPlaneReport
+	report.Livez = k.checkHealthEndpoint(ctx, "/livez", nil)
+	report.Readyz = k.checkHealthEndpoint(ctx, "/readyz", report.Livez.Passed)
+	if report.Readyz.Status == HealthUnavailable && report.Livez.Status == HealthUnavailable {
+		return mcp.NewToolResultError(fmt.Sprintf("API server is unreachable: %s", report.Readyz.Error)), nil
+	}
+
+	// componentstatuses is deprecated and may be removed, so its absence is not an issue
+	if output, err := k.runKubectlCommandString(ctx, "get", "componentstatuses", "-o", "json"); err != nil {
+		report.ComponentsError = err.Error()
+	} else if report.Components, err = parseComponentStatuses(output); err != nil {
+		report.ComponentsError = fmt.Sprintf("failed to parse componentstatuses: %v", err)
+	}
+
+	report.Etcd = etcdReadiness(report.Readyz)
+	if checkEtcd {
+		report.Etcd = k.inspectEtcd(ctx, report.Etcd)
+	}
+
+	if includeLatency {
+		metrics, err := k.runKubectlCommandString(ctx, "get", "--raw", "/metrics")
+		if err != nil {
+			report.LatencyError = fmt.Sprintf("failed to read API server metrics: %v", err)
+		} else {
+			report.Latency, report.SlowestResources, report.EtcdRequestLatency = apiLatencies(metrics)
+		}
+	}
+
+	report.Issues = controlPlaneIssues(report)
+	report.Healthy = len(report.Issues) == 0
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling control plane report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
@@ -0,0 +1,178 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const testAPIServerMetrics = `# HELP apiserver_request_duration_seconds [STABLE] Response latency distribution in seconds
+# TYPE apiserver_request_duration_seconds histogram
+apiserver_request_duration_seconds_bucket{component="apiserver",group="",resource="pods",scope="namespace",subresource="",verb="GET",version="v1",le="0.1"} 80
+apiserver_request_duration_seconds_bucket{component="apiserver",group="",resource="pods",scope="namespace",subresource="",verb="GET",version="v1",le="1"} 100
+apiserver_request_duration_seconds_bucket{component="apiserver",group="",resource="pods",scope="namespace",subresource="",verb="GET",version="v1",le="+Inf"} 100
+apiserver_request_duration_seconds_bucket{component="apiserver",group="apps",resource="deployments",scope="namespace",subresource="status",verb="PATCH",version="v1",le="0.1"} 10
+apiserver_request_duration_seconds_bucket{component="apiserver",group="apps",resource="deployments",scope="namespace",subresource="status",verb="PATCH",version="v1",le="1"} 50
+apiserver_request_duration_seconds_bucket{component="apiserver",group="apps",resource="deployments",scope="namespace",subresource="status",verb="PATCH",version="v1",le="4"} 100
+apiserver_request_duration_seconds_bucket{component="apiserver",group="apps",resource="deployments",scope="namespace",subresource="status",verb="PATCH",version="v1",le="+Inf"} 100
+apiserver_request_duration_seconds_bucket{component="apiserver",group="",resource="pods",scope="cluster",subresource="",verb="WATCH",version="v1",le="+Inf"} 5
+apiserver_request_duration_seconds_sum{component="apiserver",group="",resource="pods",scope="namespace",subresource="",verb="GET",version="v1"} 4.2
+etcd_request_duration_seconds_bucket{operation="get",type="/registry/pods",le="0.005"} 90
+etcd_request_duration_seconds_bucket{operation="get",type="/registry/pods",le="0.025"} 100
+etcd_request_duration_seconds_bucket{operation="get",type="/registry/pods",le="+Inf"} 100
+`
+
+func TestHistogramQuantile(t *testing.T) {
+	h := histogram{0.1: 80, 1: 100, 2: 100}
+	h[math.Inf(1)] = 100
+	assert.InDelta(t, 0.0625, h.quantile(0.5), 1e-9)
+	assert.InDelta(t, 0.55, h.quantile(0.9), 1e-9)
+	assert.Equal(t, 0.0, histogram{}.quantile(0.99))
+
+	// Quantiles in the +Inf bucket are capped at the highest finite bound
+	overflow := histogram{0.5: 10}
+	overflow[math.Inf(1)] = 100
+	assert.Equal(t, 0.5, overflow.quantile(0.99))
+}
+
+func TestAPILatencies(t *testing.T) {
+	latency, slowest, etcd := apiLatencies(testAPIServerMetrics)
+	require.Len(t, latency, 2)
+	assert.Equal(t, RequestLatency{Verb: "GET", Requests: 100, P50: 0.063, P90: 0.55, P99: 0.955, Threshold: 1}, latency[0])
+	assert.Equal(t, "PATCH", latency[1].Verb)
+	assert.Equal(t, 3.94, latency[1].P99)
+	assert.True(t, latency[1].Slow)
+
+	require.Len(t, slowest, 2)
+	assert.Equal(t, "deployments/status", slowest[0].Resource)
+	require.NotNil(t, etcd)
+	assert.Equal(t, uint64(100), etcd.Requests)
+	assert.Equal(t, 0.023, etcd.P99)
+}
+
+func TestParseHealthChecks(t *testing.T) {
+	passed, failed := parseHealthChecks("[+]ping ok\n[+]log ok\n[-]etcd failed: reason withheld\n[+]poststarthook/start-informers ok\nreadyz check failed\n")
+	assert.Equal(t, []string{"ping", "log", "poststarthook/start-informers"}, passed)
+	assert.Equal(t, []HealthCheckFailure{{Name: "etcd", Message: "reason withheld"}}, failed)
+}
+
+const testEtcdEndpointStatus = `[
+ {"Endpoint":"https://10.0.0.1:2379","Status":{"header":{"cluster_id":1,"member_id":171,"revision":900,"raft_term":4},"version":"3.5.12","dbSize":2000000000,"leader":171,"raftTerm":4,"dbSizeInUse":1000000}},
+ {"Endpoint":"https://10.0.0.2:2379","Status":{"header":{"cluster_id":1,"member_id":172,"revision":900,"raft_term":4},"version":"3.5.12","dbSize":30000000,"leader":171,"raftTerm":4,"dbSizeInUse":1000000}}]`
+
+func TestParseEtcdEndpointStatus(t *testing.T) {
+	members, err := parseEtcdEndpointStatus(testEtcdEndpointStatus)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, "ab", members[0].ID)
+	assert.True(t, members[0].Leader)
+	assert.False(t, members[1].Leader)
+
+	issues := etcdIssues(EtcdHealth{Members: members, Alarms: []string{"memberID:171 alarm:NOSPACE"}})
+	require.Len(t, issues, 2)
+	assert.Contains(t, issues[0], "above 80% of the default 2 GiB quota")
+	assert.Contains(t, issues[1], "NOSPACE")
+
+	members[0].Leader = false
+	assert.Contains(t, etcdIssues(EtcdHealth{Members: members[1:]}), "etcd has no leader, so writes to the cluster fail")
+}
+
+func TestHandleControlPlaneHealth(t *testing.T) {
+	etcdctl := func(args ...string) []string {
+		return append(append([]string{"exec", "-n", "kube-system", "etcd-cp-1", "--"}, etcdctlArgs...), args...)
+	}
+	call := func(t *testing.T, mock *cmd.MockShellExecutor, args map[string]interface{}) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := newTestK8sTool().handleControlPlaneHealth(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		return result
+	}
+	decode := func(t *testing.T, result *mcp.CallToolResult) ControlPlaneReport {
+		require.False(t, result.IsError, getResultText(result))
+		var report ControlPlaneReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		return report
+	}
+
+	t.Run("kubeadm cluster with a failing etcd check", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "--raw", "/livez?verbose"}, "[+]ping ok\n[+]log ok\n[+]etcd ok\nlivez check passed\n", nil)
+		mock.AddCommandString("kubectl", []string{"get", "--raw", "/readyz?verbose"}, "", errors.New("exit status 1"))
+		mock.AddCommandString("kubectl", []string{"get", "--raw", "/readyz/ping"}, "ok", nil)
+		mock.AddCommandString("kubectl", []string{"get", "--raw", "/readyz/log"}, "ok", nil)
+		mock.AddCommandString("kubectl", []string{"get", "--raw", "/readyz/etcd"}, "", errors.New("etcd failed: reason withheld"))
+		mock.AddCommandString("kubectl", []string{"get", "componentstatuses", "-o", "json"}, `{"items":[
+			{"metadata":{"name":"scheduler"},"conditions":[{"type":"Healthy","status":"True","message":"ok"}]},
+			{"metadata":{"name":"etcd-0"},"conditions":[{"type":"Healthy","status":"False","error":"context deadline exceeded"}]}]}`, nil)
+		mock.AddCommandString("kubectl", []string{"get", "pods", "-n", "kube-system", "-l", "component=etcd", "-o", "json"},
+			`{"items":[{"metadata":{"name":"etcd-cp-1"},"status":{"phase":"Running"}}]}`, nil)
+		mock.AddCommandString("kubectl", etcdctl("endpoint", "status", "--cluster", "-w", "json"), testEtcdEndpointStatus, nil)
+		mock.AddCommandString("kubectl", etcdctl("alarm", "list"), "memberID:171 alarm:NOSPACE\n", nil)
+		mock.AddCommandString("kubectl", []string{"get", "--raw", "/metrics"}, testAPIServerMetrics, nil)
+
+		report := decode(t, call(t, mock, map[string]interface{}{}))
+		assert.False(t, report.Healthy)
+		assert.Equal(t, HealthOK, report.Livez.Status)
+		assert.Equal(t, HealthFailed, report.Readyz.Status)
+		assert.Equal(t, []string{"ping", "log"}, report.Readyz.Passed)
+		require.Len(t, report.Readyz.Failed, 1)
+		assert.Equal(t, "etcd", report.Readyz.Failed[0].Name)
+
+		assert.Equal(t, HealthFailed, report.Etcd.Status)
+		assert.Equal(t, HealthFailed, report.Etcd.Readiness)
+		assert.Len(t, report.Etcd.Members, 2)
+		assert.Equal(t, []string{"memberID:171 alarm:NOSPACE"}, report.Etcd.Alarms)
+
+		assert.Equal(t, []string{
+			"API server /readyz checks failing: etcd",
+			"component etcd-0 is unhealthy: context deadline exceeded",
+			"etcd member https://10.0.0.1:2379 database is 1907 MiB, above 80% of the default 2 GiB quota; compact and defragment it or raise --quota-backend-bytes",
+			"etcd alarm raised: memberID:171 alarm:NOSPACE",
+			"PATCH requests have a p99 latency of 3.940s, above the 1s SLO",
+		}, report.Issues)
+	})
+
+	t.Run("managed control plane", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "--raw", "/livez?verbose"}, "[+]ping ok\n[+]etcd ok\n", nil)
+		mock.AddCommandString("kubectl", []string{"get", "--raw", "/readyz?verbose"}, "[+]ping ok\n[+]etcd ok\n[+]etcd-readiness ok\n", nil)
+		mock.AddCommandString("kubectl", []string{"get", "componentstatuses", "-o", "json"}, "", errors.New("the server doesn't have a resource type \"componentstatuses\""))
+		mock.AddCommandString("kubectl", []string{"get", "pods", "-n", "kube-system", "-l", "component=etcd", "-o", "json"}, `{"items":[]}`, nil)
+
+		report := decode(t, call(t, mock, map[string]interface{}{"include_latency": "false"}))
+		assert.True(t, report.Healthy)
+		assert.Empty(t, report.Issues)
+		assert.NotEmpty(t, report.ComponentsError)
+		assert.Equal(t, HealthOK, report.Etcd.Status)
+		assert.Contains(t, report.Etcd.Error, "managed by the cloud provider")
+		assert.Empty(t, report.Latency)
+	})
+
+	t.Run("etcd inspection disabled", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "--raw", "/livez?verbose"}, "[+]ping ok\n", nil)
+		mock.AddCommandString("kubectl", []string{"get", "--raw", "/readyz?verbose"}, "[+]ping ok\n", nil)
+		mock.AddCommandString("kubectl", []string{"get", "componentstatuses", "-o", "json"}, `{"items":[]}`, nil)
+
+		report := decode(t, call(t, mock, map[string]interface{}{"check_etcd": "false", "include_latency": "false"}))
+		assert.Equal(t, HealthUnavailable, report.Etcd.Status)
+		assert.Equal(t, "unknown", report.Etcd.Readiness)
+		assert.Len(t, mock.GetCallLog(), 3)
+	})
+
+	t.Run("API server unreachable", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		result := call(t, mock, map[string]interface{}{})
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "API server is unreachable")
+	})
+}
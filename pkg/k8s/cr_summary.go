@@ -0,0 +1,571 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Summarized readiness of a custom resource, following condition statuses
+const (
+	CRReadyTrue    = "True"
+	CRReadyFalse   = "False"
+	CRReadyUnknown = "Unknown"
+)
+
+// readyPhases and failedPhases map the status.phase or status.state of
+// resources without conditions to their readiness, lower-cased
+var (
+	readyPhases  = []string{"active", "available", "bound", "complete", "completed", "established", "healthy", "ready", "running", "succeeded"}
+	failedPhases = []string{"degraded", "error", "failed", "failure", "unhealthy"}
+)
+
+// syncConditionTypes are condition types reporting whether a controller
+// applied the resource's spec
+var syncConditionTypes = []string{"Synced", "Reconciled"}
+
+// printerColumn is an additional printer column of a CRD version
+type printerColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Format      string `json:"format,omitempty"`
+	Description string `json:"description,omitempty"`
+	Priority    int    `json:"priority,omitempty"`
+	JSONPath    string `json:"jsonPath"`
+}
+
+// crdDefinition is the subset of a CRD used to summarize its resources
+type crdDefinition struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Group string `json:"group"`
+		Names struct {
+			Kind       string   `json:"kind"`
+			Plural     string   `json:"plural"`
+			Singular   string   `json:"singular"`
+			ShortNames []string `json:"shortNames"`
+		} `json:"names"`
+		Scope    string `json:"scope"`
+		Versions []struct {
+			Name                     string          `json:"name"`
+			Served                   bool            `json:"served"`
+			Storage                  bool            `json:"storage"`
+			AdditionalPrinterColumns []printerColumn `json:"additionalPrinterColumns"`
+		} `json:"versions"`
+	} `json:"spec"`
+}
+
+// matches reports whether a resource type names the CRD's resources, as
+// kubectl accepts them: plural, singular, kind or short name, optionally
+// qualified by the group
+func (c crdDefinition) matches(resourceType string) bool {
+	name, group, qualified := strings.Cut(strings.ToLower(resourceType), ".")
+	if qualified && group != strings.ToLower(c.Spec.Group) {
+		return false
+	}
+	names := c.Spec.Names
+	return name == names.Plural || name == names.Singular || name == strings.ToLower(names.Kind) || containsFold(names.ShortNames, name)
+}
+
+// summaryVersion returns the version resources are summarized at, the storage
+// version if served, and its printer columns
+func (c crdDefinition) summaryVersion() (string, []printerColumn) {
+	version, columns := "", []printerColumn(nil)
+	for _, v := range c.Spec.Versions {
+		if !v.Served {
+			continue
+		}
+		if version == "" || v.Storage {
+			version, columns = v.Name, v.AdditionalPrinterColumns
+		}
+	}
+	return version, columns
+}
+
+// CRColumn is a printer column of a CRD, whose values are summarized per resource
+type CRColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	JSONPath    string `json:"json_path"`
+	Description string `json:"description,omitempty"`
+}
+
+// CRSummary is the standard summarized view of a custom resource
+type CRSummary struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Ready is True, False or Unknown, from the resource's readiness and failure
+	// conditions or, without conditions, its phase
+	Ready string `json:"ready"`
+	// Synced reports whether the controller applied the latest spec, from a
+	// Synced or Reconciled condition or the observed generation
+	Synced  string `json:"synced,omitempty"`
+	Phase   string `json:"phase,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Columns holds the values of the CRD's printer columns by column name
+	Columns    map[string]string `json:"columns,omitempty"`
+	Conditions []crdCondition    `json:"conditions,omitempty"`
+	Created    string            `json:"created,omitempty"`
+}
+
+// CRSummaryCounts counts resources by readiness
+type CRSummaryCounts struct {
+	Total    int `json:"total"`
+	Ready    int `json:"ready"`
+	NotReady int `json:"not_ready"`
+	Unknown  int `json:"unknown"`
+}
+
+// CRSummaryReport is the structured response of k8s_cr_summary
+type CRSummaryReport struct {
+	CRD       string          `json:"crd"`
+	Group     string          `json:"group"`
+	Version   string          `json:"version"`
+	Kind      string          `json:"kind"`
+	Scope     string          `json:"scope"`
+	Columns   []CRColumn      `json:"columns"`
+	Summary   CRSummaryCounts `json:"summary"`
+	Resources []CRSummary     `json:"resources"`
+	// ColumnErrors reports printer columns whose JSONPath could not be evaluated
+	ColumnErrors map[string]string `json:"column_errors,omitempty"`
+}
+
+// customResource is the subset of a custom resource summarized, with the
+// whole object kept for printer columns
+type customResource struct {
+	Metadata struct {
+		Name              string `json:"name"`
+		Namespace         string `json:"namespace"`
+		Generation        int64  `json:"generation"`
+		CreationTimestamp string `json:"creationTimestamp"`
+	} `json:"metadata"`
+	Status struct {
+		ObservedGeneration int64 `json:"observedGeneration"`
+		// Phase and State are strings in most CRDs, but not all
+		Phase      json.RawMessage `json:"phase"`
+		State      json.RawMessage `json:"state"`
+		Conditions []crdCondition  `json:"conditions"`
+	} `json:"status"`
+	object map[string]interface{}
+}
+
+// parseCustomResources parses `kubectl get -o json` output of a single
+// resource or a list
+func parseCustomResources(output string) ([]customResource, error) {
+	var raw struct {
+		Kind  string            `json:"kind"`
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse resources: %w", err)
+	}
+	items := raw.Items
+	if !strings.HasSuffix(raw.Kind, "List") {
+		items = []json.RawMessage{json.RawMessage(output)}
+	}
+
+	resources := make([]customResource, 0, len(items))
+	for _, item := range items {
+		var resource customResource
+		if err := json.Unmarshal(item, &resource); err != nil {
+			return nil, fmt.Errorf("failed to parse resource: %w", err)
+		}
+		if err := json.Unmarshal(item, &resource.object); err != nil {
+			return nil, fmt.Errorf("failed to parse resource: %w", err)
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// summarizeCustomResource derives the standard view of a resource from its
+// conditions, phase and observed generation
+func summarizeCustomResource(resource customResource) CRSummary {
+	summary := CRSummary{
+		Name:      resource.Metadata.Name,
+		Namespace: resource.Metadata.Namespace,
+		Ready:     CRReadyUnknown,
+		Created:   resource.Metadata.CreationTimestamp,
+	}
+	for _, raw := range []json.RawMessage{resource.Status.Phase, resource.Status.State} {
+		if summary.Phase == "" && len(raw) > 0 {
+			_ = json.Unmarshal(raw, &summary.Phase)
+		}
+	}
+
+	var ready, failed, synced *crdCondition
+	for i := range resource.Status.Conditions {
+		condition := &resource.Status.Conditions[i]
+		switch {
+		case failed == nil && condition.Status == "True" && containsFold(failureConditionTypes, condition.Type):
+			failed = condition
+		// Sync conditions report whether the spec was applied, not whether the resource works
+		case containsFold(syncConditionTypes, condition.Type):
+			if synced == nil {
+				synced = condition
+			}
+		// A Ready condition is preferred to other readiness conditions
+		case containsFold(readinessConditionTypes, condition.Type) && (ready == nil || condition.Type == "Ready"):
+			ready = condition
+		}
+	}
+
+	switch {
+	case failed != nil:
+		summary.Ready = CRReadyFalse
+		summary.Reason, summary.Message = failed.Reason, failed.Message
+	case ready != nil:
+		summary.Ready = ready.Status
+		summary.Reason, summary.Message = ready.Reason, ready.Message
+	case containsFold(readyPhases, summary.Phase):
+		summary.Ready = CRReadyTrue
+	case containsFold(failedPhases, summary.Phase):
+		summary.Ready = CRReadyFalse
+	}
+	if summary.Ready != CRReadyTrue && summary.Ready != CRReadyFalse {
+		summary.Ready = CRReadyUnknown
+	}
+
+	generation := resource.Metadata.Generation
+	switch {
+	case synced != nil:
+		summary.Synced = synced.Status
+		if synced.Status != "True" && summary.Reason == "" {
+			summary.Reason, summary.Message = synced.Reason, synced.Message
+		}
+	case resource.Status.ObservedGeneration > 0:
+		summary.Synced = "True"
+		if resource.Status.ObservedGeneration < generation {
+			summary.Synced = "False"
+		}
+	}
+	if summary.Synced == "False" && summary.Reason == "" && resource.Status.ObservedGeneration > 0 {
+		summary.Reason = "StatusStale"
+		summary.Message = fmt.Sprintf("status reflects generation %d of %d", resource.Status.ObservedGeneration, generation)
+	}
+	return summary
+}
+
+// evalPrinterPath evaluates the JSONPath of a printer column against an
+// object. It supports the subset printer columns use: fields, indexes, [*]
+// and filters comparing a field with == or !=, such as
+// .status.conditions[?(@.type=="Ready")].status
+func evalPrinterPath(object interface{}, path string) ([]interface{}, error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "{"), "}")
+	if !strings.HasPrefix(path, ".") {
+		return nil, fmt.Errorf("unsupported JSONPath %q", path)
+	}
+
+	values := []interface{}{object}
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			var field strings.Builder
+			for i++; i < len(path) && path[i] != '.' && path[i] != '['; i++ {
+				if path[i] == '\\' && i+1 < len(path) {
+					i++
+				}
+				field.WriteByte(path[i])
+			}
+			if field.Len() == 0 {
+				continue
+			}
+			var next []interface{}
+			for _, value := range values {
+				if m, ok := value.(map[string]interface{}); ok {
+					if v, ok := m[field.String()]; ok {
+						next = append(next, v)
+					}
+				}
+			}
+			values = next
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ in JSONPath %q", path)
+			}
+			selector := path[i+1 : i+end]
+			i += end + 1
+			next, err := selectElements(values, selector)
+			if err != nil {
+				return nil, fmt.Errorf("%w in JSONPath %q", err, path)
+			}
+			values = next
+		default:
+			return nil, fmt.Errorf("unexpected %q in JSONPath %q", path[i], path)
+		}
+	}
+	return values, nil
+}
+
+// selectElements applies a bracketed JSONPath selector to each value
+func selectElements(values []interface{}, selector string) ([]interface{}, error) {
+	var next []interface{}
+	switch {
+	case selector == "*":
+		for _, value := range values {
+			switch v := value.(type) {
+			case []interface{}:
+				next = append(next, v...)
+			case map[string]interface{}:
+				keys := make([]string, 0, len(v))
+				for key := range v {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					next = append(next, v[key])
+				}
+			}
+		}
+	case strings.HasPrefix(selector, "?(") && strings.HasSuffix(selector, ")"):
+		field, op, want, err := parseFilter(selector[2 : len(selector)-1])
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			items, _ := value.([]interface{})
+			for _, item := range items {
+				got, err := evalPrinterPath(item, field)
+				if err != nil {
+					return nil, err
+				}
+				equal := len(got) == 1 && formatColumnValue(got[0]) == want
+				if equal == (op == "==") {
+					next = append(next, item)
+				}
+			}
+		}
+	default:
+		index, err := strconv.Atoi(selector)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported selector [%s]", selector)
+		}
+		for _, value := range values {
+			items, _ := value.([]interface{})
+			if index < 0 {
+				index += len(items)
+			}
+			if index >= 0 && index < len(items) {
+				next = append(next, items[index])
+			}
+		}
+	}
+	return next, nil
+}
+
+// parseFilter parses a filter expression such as @.type=="Ready"
+func parseFilter(expression string) (field, op, value string, err error) {
+	for _, op = range []string{"==", "!="} {
+		if left, right, found := strings.Cut(expression, op); found {
+			left, right = strings.TrimSpace(left), strings.TrimSpace(right)
+			if !strings.HasPrefix(left, "@.") {
+				break
+			}
+			return strings.TrimPrefix(left, "@"), op, strings.Trim(right, `"'`), nil
+		}
+	}
+	return "", "", "", fmt.Errorf("unsupported filter %q", expression)
+}
+
+// formatColumnValue renders a JSON value as kubectl prints it in a column
+func formatColumnValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// findCRD returns the CRD defining a resource type
+func findCRD(output, resourceType string) (*crdDefinition, error) {
+	var crds struct {
+		Items []crdDefinition `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &crds); err != nil {
+		return nil, fmt.Errorf("failed to parse CRDs: %w", err)
+	}
+	var matched []crdDefinition
+	for _, crd := range crds.Items {
+		if strings.EqualFold(crd.Metadata.Name, resourceType) {
+			return &crd, nil
+		}
+		if crd.matches(resourceType) {
+			matched = append(matched, crd)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return nil, fmt.Errorf("no CRD defines resource type %s; built-in resources are described with k8s_describe_resource", resourceType)
+	case 1:
+		return &matched[0], nil
+	}
+	names := make([]string, 0, len(matched))
+	for _, crd := range matched {
+		names = append(names, crd.Metadata.Name)
+	}
+	return nil, fmt.Errorf("resource type %s is ambiguous, qualify it with its group: %s", resourceType, strings.Join(names, ", "))
+}
+
+// buildCRSummaryReport summarizes custom resources with the printer columns of their CRD
+func buildCRSummaryReport(crd crdDefinition, version string, columns []printerColumn, resources []customResource, wide, notReadyOnly bool) CRSummaryReport {
+	report := CRSummaryReport{
+		CRD:       crd.Metadata.Name,
+		Group:     crd.Spec.Group,
+		Version:   version,
+		Kind:      crd.Spec.Names.Kind,
+		Scope:     crd.Spec.Scope,
+		Columns:   []CRColumn{},
+		Resources: []CRSummary{},
+	}
+	var shown []printerColumn
+	for _, column := range columns {
+		// kubectl only prints columns of priority 0 without -o wide
+		if column.Priority > 0 && !wide {
+			continue
+		}
+		shown = append(shown, column)
+		report.Columns = append(report.Columns, CRColumn{Name: column.Name, Type: column.Type, JSONPath: column.JSONPath, Description: column.Description})
+	}
+
+	for _, resource := range resources {
+		summary := summarizeCustomResource(resource)
+		for _, column := range shown {
+			values, err := evalPrinterPath(resource.object, column.JSONPath)
+			if err != nil {
+				if report.ColumnErrors == nil {
+					report.ColumnErrors = map[string]string{}
+				}
+				report.ColumnErrors[column.Name] = err.Error()
+				continue
+			}
+			if len(values) == 0 {
+				continue
+			}
+			formatted := make([]string, 0, len(values))
+			for _, value := range values {
+				formatted = append(formatted, formatColumnValue(value))
+			}
+			if summary.Columns == nil {
+				summary.Columns = map[string]string{}
+			}
+			summary.Columns[column.Name] = strings.Join(formatted, ",")
+		}
+
+		report.Summary.Total++
+		switch summary.Ready {
+		case CRReadyTrue:
+			report.Summary.Ready++
+		case CRReadyFalse:
+			report.Summary.NotReady++
+		default:
+			report.Summary.Unknown++
+		}
+		if notReadyOnly && summary.Ready == CRReadyTrue {
+			continue
+		}
+		// Conditions are only listed in full when a single resource is summarized
+		if len(resources) == 1 {
+			summary.Conditions = resource.Status.Conditions
+		}
+		report.Resources = append(report.Resources, summary)
+	}
+
+	// Not ready resources first, then unknown, then by namespace and name
+	rank := map[string]int{CRReadyFalse: 0, CRReadyUnknown: 1, CRReadyTrue: 2}
+	sort.SliceStable(report.Resources, func(i, j int) bool {
+		a, b := report.Resources[i], report.Resources[j]
+		if rank[a.Ready] != rank[b.Ready] {
+			return rank[a.Ready] < rank[b.Ready]
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// Schema-aware summary of custom resources
+func (k *K8sTool) handleCRSummary(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	resourceType := p.String("resource_type", "", params.Required(), params.Check(security.ValidateCommandInput))
+	resourceName := p.String("resource_name", "", params.Check(security.ValidateK8sResourceName))
+	namespace := p.String("namespace", "", params.Check(security.ValidateNamespace))
+	allNamespaces := p.Bool("all_namespaces", false)
+	labelSelector := p.String("label_selector", "", params.Check(security.ValidateCommandInput))
+	wide := p.Bool("wide", false)
+	notReadyOnly := p.Bool("not_ready_only", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if resourceName != "" && (allNamespaces || labelSelector != "") {
+		return mcp.NewToolResultError("resource_name cannot be combined with all_namespaces or label_selector"), nil
+	}
+
+	crdOutput, err := k.runKubectlCommandString(ctx, "get", "crds", "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError("Error getting CRDs: " + err.Error()), nil
+	}
+	crd, err := findCRD(crdOutput, resourceType)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	version, columns := crd.summaryVersion()
+	if version == "" {
+		return mcp.NewToolResultError(fmt.Sprintf("CRD %s serves no version", crd.Metadata.Name)), nil
+	}
+
+	// Resources are fetched at the version whose printer columns are used
+	args := []string{"get", crd.Spec.Names.Plural + "." + version + "." + crd.Spec.Group}
+	if resourceName != "" {
+		args = append(args, resourceName)
+	}
+	args = append(args, "-o", "json")
+	if labelSelector != "" {
+		args = append(args, "-l", labelSelector)
+	}
+	if crd.Spec.Scope == "Namespaced" {
+		switch {
+		case allNamespaces:
+			args = append(args, "--all-namespaces")
+		case namespace != "":
+			args = append(args, "-n", namespace)
+		}
+	}
+	output, err := k.runKubectlCommandString(ctx, args...)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get %s: %v", crd.Spec.Names.Plural, err)), nil
+	}
+	resources, err := parseCustomResources(output)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := buildCRSummaryReport(*crd, version, columns, resources, wide, notReadyOnly)
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling custom resource summary: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
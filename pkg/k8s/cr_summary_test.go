@@ -0,0 +1,187 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const testSummaryCRDs = `{"items":[
+ {"metadata":{"name":"kafkas.kafka.strimzi.io"},"spec":{"group":"kafka.strimzi.io","scope":"Namespaced",
+  "names":{"kind":"Kafka","plural":"kafkas","singular":"kafka","shortNames":["k"]},
+  "versions":[
+   {"name":"v1beta1","served":true,"storage":false},
+   {"name":"v1beta2","served":true,"storage":true,"additionalPrinterColumns":[
+    {"name":"Desired Kafka replicas","type":"integer","jsonPath":".spec.kafka.replicas"},
+    {"name":"Ready","type":"string","jsonPath":".status.conditions[?(@.type==\"Ready\")].status"},
+    {"name":"Listeners","type":"string","priority":1,"jsonPath":".status.listeners[*].name"}]}]}},
+ {"metadata":{"name":"clusters.postgresql.cnpg.io"},"spec":{"group":"postgresql.cnpg.io","scope":"Namespaced",
+  "names":{"kind":"Cluster","plural":"clusters","singular":"cluster"},
+  "versions":[{"name":"v1","served":true,"storage":true}]}},
+ {"metadata":{"name":"clusters.cluster.x-k8s.io"},"spec":{"group":"cluster.x-k8s.io","scope":"Namespaced",
+  "names":{"kind":"Cluster","plural":"clusters","singular":"cluster"},
+  "versions":[{"name":"v1beta1","served":true,"storage":true}]}}]}`
+
+const testKafkas = `{"kind":"List","items":[
+ {"metadata":{"name":"events","namespace":"kafka","generation":3},
+  "spec":{"kafka":{"replicas":3}},
+  "status":{"observedGeneration":3,"listeners":[{"name":"plain"},{"name":"tls"}],
+   "conditions":[{"type":"Ready","status":"True"}]}},
+ {"metadata":{"name":"audit","namespace":"kafka","generation":5},
+  "spec":{"kafka":{"replicas":1}},
+  "status":{"observedGeneration":4,
+   "conditions":[{"type":"NotReady","status":"True","reason":"Creating","message":"Kafka cluster is being deployed"},{"type":"Ready","status":"False","reason":"Creating","message":"Kafka cluster is being deployed"}]}}]}`
+
+func TestFindCRD(t *testing.T) {
+	for _, resourceType := range []string{"kafkas", "Kafka", "k", "kafkas.kafka.strimzi.io", "kafka.kafka.strimzi.io"} {
+		crd, err := findCRD(testSummaryCRDs, resourceType)
+		require.NoError(t, err, resourceType)
+		assert.Equal(t, "kafkas.kafka.strimzi.io", crd.Metadata.Name)
+	}
+
+	_, err := findCRD(testSummaryCRDs, "clusters")
+	assert.ErrorContains(t, err, "ambiguous")
+	crd, err := findCRD(testSummaryCRDs, "clusters.postgresql.cnpg.io")
+	require.NoError(t, err)
+	assert.Equal(t, "postgresql.cnpg.io", crd.Spec.Group)
+
+	_, err = findCRD(testSummaryCRDs, "pods")
+	assert.ErrorContains(t, err, "no CRD defines resource type pods")
+}
+
+func TestEvalPrinterPath(t *testing.T) {
+	var object map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"spec":{"replicas":3,"paused":false,"annotations":{"example.com/tier":"gold"}},
+	 "status":{"conditions":[{"type":"Synced","status":"True"},{"type":"Ready","status":"False"}],"nodes":[{"name":"a"},{"name":"b"}]}}`), &object))
+
+	for path, expected := range map[string][]interface{}{
+		".spec.replicas": {float64(3)},
+		"{.spec.paused}": {false},
+		`.status.conditions[?(@.type=="Ready")].status`: {"False"},
+		`.status.conditions[?(@.type!='Ready')].type`:   {"Synced"},
+		".status.nodes[*].name":                         {"a", "b"},
+		".status.nodes[-1].name":                        {"b"},
+		`.spec.annotations.example\.com/tier`:           {"gold"},
+		".status.missing":                               nil,
+	} {
+		values, err := evalPrinterPath(object, path)
+		require.NoError(t, err, path)
+		assert.Equal(t, expected, values, path)
+	}
+
+	for _, path := range []string{"spec.replicas", ".status.nodes[0", ".status.nodes[?(@.name)]"} {
+		_, err := evalPrinterPath(object, path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestSummarizeCustomResource(t *testing.T) {
+	for name, test := range map[string]struct {
+		resource string
+		expected CRSummary
+	}{
+		"failure condition wins": {
+			`{"metadata":{"name":"a"},"status":{"conditions":[{"type":"Ready","status":"True"},{"type":"Degraded","status":"True","reason":"BrokerDown","message":"1 broker is down"}]}}`,
+			CRSummary{Name: "a", Ready: CRReadyFalse, Reason: "BrokerDown", Message: "1 broker is down"},
+		},
+		"ready and synced conditions": {
+			`{"metadata":{"name":"b"},"status":{"conditions":[{"type":"Synced","status":"False","reason":"ReconcileError","message":"cannot update"},{"type":"Ready","status":"True"}]}}`,
+			CRSummary{Name: "b", Ready: CRReadyTrue, Synced: "False", Reason: "ReconcileError", Message: "cannot update"},
+		},
+		"phase without conditions": {
+			`{"metadata":{"name":"c","generation":2},"status":{"phase":"Cluster in healthy state","observedGeneration":2}}`,
+			CRSummary{Name: "c", Ready: CRReadyUnknown, Synced: "True", Phase: "Cluster in healthy state"},
+		},
+		"running state": {
+			`{"metadata":{"name":"d"},"status":{"state":"Running"}}`,
+			CRSummary{Name: "d", Ready: CRReadyTrue, Phase: "Running"},
+		},
+		"stale status": {
+			`{"metadata":{"name":"e","generation":4},"status":{"observedGeneration":3,"state":{"code":1}}}`,
+			CRSummary{Name: "e", Ready: CRReadyUnknown, Synced: "False", Reason: "StatusStale", Message: "status reflects generation 3 of 4"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resources, err := parseCustomResources(test.resource)
+			require.NoError(t, err)
+			require.Len(t, resources, 1)
+			assert.Equal(t, test.expected, summarizeCustomResource(resources[0]))
+		})
+	}
+}
+
+func TestHandleCRSummary(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "crds", "-o", "json"}, testSummaryCRDs, nil)
+	mock.AddCommandString("kubectl", []string{"get", "kafkas.v1beta2.kafka.strimzi.io", "-o", "json", "-n", "kafka"}, testKafkas, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"resource_type": "kafka", "namespace": "kafka"}
+	result, err := newTestK8sTool().handleCRSummary(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var report CRSummaryReport
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+	assert.Equal(t, "v1beta2", report.Version)
+	assert.Equal(t, "Kafka", report.Kind)
+	require.Len(t, report.Columns, 2, "wide columns are left out")
+	assert.Equal(t, CRSummaryCounts{Total: 2, Ready: 1, NotReady: 1}, report.Summary)
+
+	require.Len(t, report.Resources, 2)
+	audit := report.Resources[0]
+	assert.Equal(t, "audit", audit.Name, "not ready resources come first")
+	assert.Equal(t, CRReadyFalse, audit.Ready)
+	assert.Equal(t, "False", audit.Synced)
+	assert.Equal(t, "Creating", audit.Reason)
+	assert.Equal(t, map[string]string{"Desired Kafka replicas": "1", "Ready": "False"}, audit.Columns)
+	assert.Empty(t, audit.Conditions, "conditions are only listed for a single resource")
+
+	request.Params.Arguments = map[string]interface{}{"resource_type": "kafka", "namespace": "kafka", "wide": "true", "not_ready_only": "true"}
+	result, err = newTestK8sTool().handleCRSummary(ctx, request)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+	assert.Len(t, report.Columns, 3)
+	require.Len(t, report.Resources, 1)
+	assert.Equal(t, "audit", report.Resources[0].Name)
+	assert.Equal(t, 2, report.Summary.Total)
+}
+
+func TestHandleCRSummaryNamedResource(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "crds", "-o", "json"}, testSummaryCRDs, nil)
+	mock.AddCommandString("kubectl", []string{"get", "kafkas.v1beta2.kafka.strimzi.io", "events", "-o", "json", "-n", "kafka"},
+		`{"kind":"Kafka","metadata":{"name":"events","namespace":"kafka"},"status":{"listeners":[{"name":"plain"}],"conditions":[{"type":"Ready","status":"True"}]}}`, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"resource_type": "k", "resource_name": "events", "namespace": "kafka", "wide": "true"}
+	result, err := newTestK8sTool().handleCRSummary(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var report CRSummaryReport
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+	require.Len(t, report.Resources, 1)
+	assert.Equal(t, CRReadyTrue, report.Resources[0].Ready)
+	assert.Equal(t, "plain", report.Resources[0].Columns["Listeners"])
+	assert.Len(t, report.Resources[0].Conditions, 1)
+
+	for _, args := range []map[string]interface{}{
+		{},
+		{"resource_type": "pods"},
+		{"resource_type": "kafkas", "resource_name": "events", "all_namespaces": "true"},
+	} {
+		request.Params.Arguments = args
+		result, err := newTestK8sTool().handleCRSummary(ctx, request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError, args)
+	}
+}
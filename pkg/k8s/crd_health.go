@@ -0,0 +1,516 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// crdCondition is a status condition of a CRD or custom resource
+type crdCondition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason"`
+	Message            string `json:"message"`
+	ObservedGeneration int64  `json:"observedGeneration"`
+}
+
+// webhookServiceRef is the service a webhook client config points at
+type webhookServiceRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+func (r webhookServiceRef) String() string {
+	return r.Namespace + "/" + r.Name
+}
+
+// crdList is the subset of `kubectl get crds -o json` output used for reports
+type crdList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec struct {
+			Group string `json:"group"`
+			Names struct {
+				Kind   string `json:"kind"`
+				Plural string `json:"plural"`
+			} `json:"names"`
+			Scope    string `json:"scope"`
+			Versions []struct {
+				Name   string `json:"name"`
+				Served bool   `json:"served"`
+			} `json:"versions"`
+			Conversion struct {
+				Strategy string `json:"strategy"`
+				Webhook  struct {
+					ClientConfig struct {
+						Service *webhookServiceRef `json:"service"`
+					} `json:"clientConfig"`
+				} `json:"webhook"`
+			} `json:"conversion"`
+		} `json:"spec"`
+		Status struct {
+			Conditions []crdCondition `json:"conditions"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// webhookConfigurationList is the subset of admission webhook configurations used for reports
+type webhookConfigurationList struct {
+	Items []struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Webhooks []struct {
+			Name          string `json:"name"`
+			FailurePolicy string `json:"failurePolicy"`
+			ClientConfig  struct {
+				Service *webhookServiceRef `json:"service"`
+				URL     string             `json:"url"`
+			} `json:"clientConfig"`
+			Rules []struct {
+				APIGroups []string `json:"apiGroups"`
+			} `json:"rules"`
+		} `json:"webhooks"`
+	} `json:"items"`
+}
+
+// deploymentList is the subset of `kubectl get deployments -o json` output used for reports
+type deploymentList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			Replicas *int `json:"replicas"`
+			Template struct {
+				Metadata struct {
+					Labels map[string]string `json:"labels"`
+				} `json:"metadata"`
+			} `json:"template"`
+		} `json:"spec"`
+		Status struct {
+			ReadyReplicas int            `json:"readyReplicas"`
+			Conditions    []crdCondition `json:"conditions"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// ControllerHealth describes a deployment believed to run the controller of a CRD
+type ControllerHealth struct {
+	Name          string `json:"name"`
+	Namespace     string `json:"namespace"`
+	MatchedBy     string `json:"matched_by"`
+	Replicas      int    `json:"replicas"`
+	ReadyReplicas int    `json:"ready_replicas"`
+	Available     bool   `json:"available"`
+}
+
+// WebhookHealth describes the availability of an admission or conversion webhook
+type WebhookHealth struct {
+	Name           string   `json:"name"`
+	Configuration  string   `json:"configuration"`
+	Type           string   `json:"type"`
+	Service        string   `json:"service,omitempty"`
+	FailurePolicy  string   `json:"failure_policy,omitempty"`
+	Groups         []string `json:"groups,omitempty"`
+	ReadyEndpoints int      `json:"ready_endpoints"`
+	Available      bool     `json:"available"`
+	Issue          string   `json:"issue,omitempty"`
+}
+
+// ResourceIssue is a custom resource with a failed or stale condition
+type ResourceIssue struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Condition string `json:"condition,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Stale     bool   `json:"stale"`
+}
+
+// CRDHealth describes a CRD together with its controllers, webhooks and failing resources
+type CRDHealth struct {
+	Name             string             `json:"name"`
+	Group            string             `json:"group"`
+	Kind             string             `json:"kind"`
+	Scope            string             `json:"scope"`
+	Versions         []string           `json:"versions"`
+	Established      bool               `json:"established"`
+	Issues           []string           `json:"issues,omitempty"`
+	Controllers      []ControllerHealth `json:"controllers"`
+	Webhooks         []string           `json:"webhooks,omitempty"`
+	ResourceCount    int                `json:"resource_count"`
+	FailingResources []ResourceIssue    `json:"failing_resources,omitempty"`
+	Healthy          bool               `json:"healthy"`
+}
+
+// CRDHealthSummary counts the problems found across CRDs
+type CRDHealthSummary struct {
+	CRDs                 int `json:"crds"`
+	UnhealthyCRDs        int `json:"unhealthy_crds"`
+	UnavailableWebhooks  int `json:"unavailable_webhooks"`
+	UnhealthyControllers int `json:"unhealthy_controllers"`
+	FailingResources     int `json:"failing_resources"`
+}
+
+// CRDHealthReport is the structured response of k8s_crd_health
+type CRDHealthReport struct {
+	Summary  CRDHealthSummary `json:"summary"`
+	CRDs     []CRDHealth      `json:"crds"`
+	Webhooks []WebhookHealth  `json:"webhooks"`
+}
+
+// failureConditionTypes are condition types that signal a failure when True
+var failureConditionTypes = []string{"Failed", "Degraded", "Stalled", "Error"}
+
+// readinessConditionTypes are condition types that signal a failure when False
+var readinessConditionTypes = []string{"Ready", "Available", "Synced", "Healthy", "Reconciled"}
+
+// customResourceIssues returns the resources of a `kubectl get <crd> -o json` list
+// with failed readiness conditions or a status lagging behind their spec
+func customResourceIssues(output string) (int, []ResourceIssue, error) {
+	var resources struct {
+		Items []struct {
+			Metadata struct {
+				Name       string `json:"name"`
+				Namespace  string `json:"namespace"`
+				Generation int64  `json:"generation"`
+			} `json:"metadata"`
+			Status struct {
+				ObservedGeneration int64          `json:"observedGeneration"`
+				Conditions         []crdCondition `json:"conditions"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &resources); err != nil {
+		return 0, nil, fmt.Errorf("failed to parse resources: %w", err)
+	}
+
+	var issues []ResourceIssue
+	for _, item := range resources.Items {
+		issue := ResourceIssue{Name: item.Metadata.Name, Namespace: item.Metadata.Namespace}
+		failing := false
+
+		generation := item.Metadata.Generation
+		if item.Status.ObservedGeneration > 0 && item.Status.ObservedGeneration < generation {
+			issue.Stale = true
+		}
+		for _, condition := range item.Status.Conditions {
+			if condition.ObservedGeneration > 0 && condition.ObservedGeneration < generation {
+				issue.Stale = true
+			}
+			failed := (condition.Status == "True" && containsFold(failureConditionTypes, condition.Type)) ||
+				(condition.Status == "False" && containsFold(readinessConditionTypes, condition.Type))
+			if failed && !failing {
+				failing = true
+				issue.Condition = condition.Type
+				issue.Status = condition.Status
+				issue.Reason = condition.Reason
+				issue.Message = condition.Message
+			}
+		}
+
+		if failing || issue.Stale {
+			issues = append(issues, issue)
+		}
+	}
+	return len(resources.Items), issues, nil
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectorMatches reports whether every selector label is present in labels
+func selectorMatches(selector, labels map[string]string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWebhookService looks up the endpoints and selector of a webhook service
+func (k *K8sTool) checkWebhookService(ctx context.Context, ref webhookServiceRef) (int, map[string]string, error) {
+	endpointsOutput, err := k.runKubectlCommandString(ctx, "get", "endpoints", ref.Name, "-n", ref.Namespace, "-o", "json")
+	if err != nil {
+		return 0, nil, fmt.Errorf("service %s has no endpoints: %w", ref, err)
+	}
+	var endpoints struct {
+		Subsets []struct {
+			Addresses []struct {
+				IP string `json:"ip"`
+			} `json:"addresses"`
+		} `json:"subsets"`
+	}
+	if err := json.Unmarshal([]byte(endpointsOutput), &endpoints); err != nil {
+		return 0, nil, fmt.Errorf("failed to parse endpoints of %s: %w", ref, err)
+	}
+	ready := 0
+	for _, subset := range endpoints.Subsets {
+		ready += len(subset.Addresses)
+	}
+
+	var service struct {
+		Spec struct {
+			Selector map[string]string `json:"selector"`
+		} `json:"spec"`
+	}
+	serviceOutput, err := k.runKubectlCommandString(ctx, "get", "service", ref.Name, "-n", ref.Namespace, "-o", "json")
+	if err == nil {
+		_ = json.Unmarshal([]byte(serviceOutput), &service)
+	}
+	return ready, service.Spec.Selector, nil
+}
+
+// CRD and operator health
+func (k *K8sTool) handleCRDHealth(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	group := p.String("group", "")
+	checkResources := p.Bool("check_resources", true)
+	unhealthyOnly := p.Bool("unhealthy_only", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	crdOutput, err := k.runKubectlCommandString(ctx, "get", "crds", "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError("Error getting CRDs: " + err.Error()), nil
+	}
+	var crds crdList
+	if err := json.Unmarshal([]byte(crdOutput), &crds); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse CRDs: %v", err)), nil
+	}
+
+	var deployments deploymentList
+	if deploymentOutput, err := k.runKubectlCommandString(ctx, "get", "deployments", "--all-namespaces", "-o", "json"); err == nil {
+		_ = json.Unmarshal([]byte(deploymentOutput), &deployments)
+	}
+
+	var configurations webhookConfigurationList
+	if webhookOutput, err := k.runKubectlCommandString(ctx, "get", "validatingwebhookconfigurations,mutatingwebhookconfigurations", "-o", "json"); err == nil {
+		_ = json.Unmarshal([]byte(webhookOutput), &configurations)
+	}
+
+	// Webhook services are checked once, however many webhooks point at them
+	type serviceState struct {
+		ready    int
+		selector map[string]string
+		err      error
+	}
+	services := make(map[string]*serviceState)
+	checkService := func(ref webhookServiceRef) *serviceState {
+		if state, ok := services[ref.String()]; ok {
+			return state
+		}
+		state := &serviceState{}
+		state.ready, state.selector, state.err = k.checkWebhookService(ctx, ref)
+		services[ref.String()] = state
+		return state
+	}
+
+	report := CRDHealthReport{CRDs: []CRDHealth{}, Webhooks: []WebhookHealth{}}
+	unhealthyControllers := make(map[string]bool)
+	groupWebhooks := make(map[string][]string)
+	groupServices := make(map[string][]webhookServiceRef)
+
+	for _, configuration := range configurations.Items {
+		webhookType := strings.TrimSuffix(strings.ToLower(configuration.Kind), "webhookconfiguration")
+		for _, webhook := range configuration.Webhooks {
+			health := WebhookHealth{
+				Name:          webhook.Name,
+				Configuration: configuration.Metadata.Name,
+				Type:          webhookType,
+				FailurePolicy: webhook.FailurePolicy,
+			}
+			for _, rule := range webhook.Rules {
+				for _, apiGroup := range rule.APIGroups {
+					if apiGroup != "" && apiGroup != "*" && !containsFold(health.Groups, apiGroup) {
+						health.Groups = append(health.Groups, apiGroup)
+					}
+				}
+			}
+			if group != "" && !containsFold(health.Groups, group) {
+				continue
+			}
+
+			if ref := webhook.ClientConfig.Service; ref != nil {
+				health.Service = ref.String()
+				state := checkService(*ref)
+				health.ReadyEndpoints = state.ready
+				health.Available = state.err == nil && state.ready > 0
+				switch {
+				case state.err != nil:
+					health.Issue = state.err.Error()
+				case state.ready == 0:
+					health.Issue = fmt.Sprintf("service %s has no ready endpoints", ref)
+				}
+				for _, g := range health.Groups {
+					groupServices[g] = append(groupServices[g], *ref)
+				}
+			} else {
+				health.Available = true
+				health.Issue = "webhook uses an external URL and was not checked"
+			}
+
+			for _, g := range health.Groups {
+				groupWebhooks[g] = append(groupWebhooks[g], webhook.Name)
+			}
+			if !health.Available {
+				report.Summary.UnavailableWebhooks++
+			}
+			report.Webhooks = append(report.Webhooks, health)
+		}
+	}
+
+	for _, crd := range crds.Items {
+		spec := crd.Spec
+		if group != "" && !strings.EqualFold(spec.Group, group) {
+			continue
+		}
+
+		health := CRDHealth{
+			Name:        crd.Metadata.Name,
+			Group:       spec.Group,
+			Kind:        spec.Names.Kind,
+			Scope:       spec.Scope,
+			Webhooks:    groupWebhooks[spec.Group],
+			Controllers: []ControllerHealth{},
+		}
+		for _, version := range spec.Versions {
+			if version.Served {
+				health.Versions = append(health.Versions, version.Name)
+			}
+		}
+		for _, condition := range crd.Status.Conditions {
+			switch {
+			case condition.Type == "Established":
+				health.Established = condition.Status == "True"
+			case condition.Status != "True" && (condition.Type == "NamesAccepted" || condition.Type == "NonStructuralSchema"):
+				health.Issues = append(health.Issues, fmt.Sprintf("%s: %s", condition.Type, condition.Message))
+			}
+		}
+		if !health.Established {
+			health.Issues = append(health.Issues, "CRD is not established")
+		}
+
+		serviceRefs := groupServices[spec.Group]
+		if ref := spec.Conversion.Webhook.ClientConfig.Service; spec.Conversion.Strategy == "Webhook" && ref != nil {
+			state := checkService(*ref)
+			if state.err != nil || state.ready == 0 {
+				health.Issues = append(health.Issues, fmt.Sprintf("conversion webhook service %s is unavailable", ref))
+			}
+			serviceRefs = append(serviceRefs, *ref)
+		}
+
+		// Controllers are the deployments behind the group's webhook services, or
+		// failing that, deployments named after the group's first DNS label
+		groupPrefix := strings.SplitN(spec.Group, ".", 2)[0]
+		for _, deployment := range deployments.Items {
+			matchedBy := ""
+			for _, ref := range serviceRefs {
+				if ref.Namespace == deployment.Metadata.Namespace &&
+					selectorMatches(services[ref.String()].selector, deployment.Spec.Template.Metadata.Labels) {
+					matchedBy = "webhook_service"
+					break
+				}
+			}
+			if matchedBy == "" && len(groupPrefix) >= 3 && strings.Contains(deployment.Metadata.Name, groupPrefix) {
+				matchedBy = "name"
+			}
+			if matchedBy == "" {
+				continue
+			}
+
+			replicas := 1
+			if deployment.Spec.Replicas != nil {
+				replicas = *deployment.Spec.Replicas
+			}
+			controller := ControllerHealth{
+				Name:          deployment.Metadata.Name,
+				Namespace:     deployment.Metadata.Namespace,
+				MatchedBy:     matchedBy,
+				Replicas:      replicas,
+				ReadyReplicas: deployment.Status.ReadyReplicas,
+			}
+			for _, condition := range deployment.Status.Conditions {
+				if condition.Type == "Available" {
+					controller.Available = condition.Status == "True"
+				}
+			}
+			if !controller.Available || controller.ReadyReplicas < controller.Replicas {
+				health.Issues = append(health.Issues, fmt.Sprintf("controller %s/%s has %d/%d ready replicas",
+					controller.Namespace, controller.Name, controller.ReadyReplicas, controller.Replicas))
+				unhealthyControllers[controller.Namespace+"/"+controller.Name] = true
+			}
+			health.Controllers = append(health.Controllers, controller)
+		}
+
+		if checkResources {
+			resourceOutput, err := k.runKubectlCommandString(ctx, "get", spec.Names.Plural+"."+spec.Group, "--all-namespaces", "-o", "json")
+			if err != nil {
+				health.Issues = append(health.Issues, fmt.Sprintf("failed to list resources: %v", err))
+			} else if count, issues, err := customResourceIssues(resourceOutput); err != nil {
+				health.Issues = append(health.Issues, err.Error())
+			} else {
+				health.ResourceCount = count
+				health.FailingResources = issues
+				report.Summary.FailingResources += len(issues)
+			}
+		}
+
+		for _, webhook := range report.Webhooks {
+			if !webhook.Available && containsFold(webhook.Groups, spec.Group) {
+				health.Issues = append(health.Issues, fmt.Sprintf("%s webhook %s is unavailable", webhook.Type, webhook.Name))
+			}
+		}
+
+		health.Healthy = len(health.Issues) == 0 && len(health.FailingResources) == 0
+		report.Summary.CRDs++
+		if !health.Healthy {
+			report.Summary.UnhealthyCRDs++
+		} else if unhealthyOnly {
+			continue
+		}
+		report.CRDs = append(report.CRDs, health)
+	}
+
+	report.Summary.UnhealthyControllers = len(unhealthyControllers)
+
+	// Worst CRDs first: unhealthy before healthy, then by number of problems
+	sort.SliceStable(report.CRDs, func(i, j int) bool {
+		a, b := report.CRDs[i], report.CRDs[j]
+		if a.Healthy != b.Healthy {
+			return !a.Healthy
+		}
+		return len(a.Issues)+len(a.FailingResources) > len(b.Issues)+len(b.FailingResources)
+	})
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling CRD health report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
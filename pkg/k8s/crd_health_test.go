@@ -0,0 +1,164 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCRDs = `{"items":[
+{"metadata":{"name":"certificates.cert-manager.io"},
+ "spec":{"group":"cert-manager.io","names":{"kind":"Certificate","plural":"certificates"},"scope":"Namespaced","versions":[{"name":"v1","served":true}]},
+ "status":{"conditions":[{"type":"Established","status":"True"},{"type":"NamesAccepted","status":"True"}]}},
+{"metadata":{"name":"widgets.example.com"},
+ "spec":{"group":"example.com","names":{"kind":"Widget","plural":"widgets"},"scope":"Cluster","versions":[{"name":"v1alpha1","served":true}]},
+ "status":{"conditions":[{"type":"Established","status":"True"}]}}
+]}`
+
+const testWebhooks = `{"items":[
+{"kind":"ValidatingWebhookConfiguration","metadata":{"name":"cert-manager-webhook"},
+ "webhooks":[{"name":"webhook.cert-manager.io","failurePolicy":"Fail",
+  "clientConfig":{"service":{"namespace":"cert-manager","name":"cert-manager-webhook"}},
+  "rules":[{"apiGroups":["cert-manager.io","acme.cert-manager.io"]}]}]}
+]}`
+
+const testDeployments = `{"items":[
+{"metadata":{"name":"cert-manager","namespace":"cert-manager"},
+ "spec":{"replicas":1,"template":{"metadata":{"labels":{"app":"cert-manager"}}}},
+ "status":{"readyReplicas":1,"conditions":[{"type":"Available","status":"True"}]}},
+{"metadata":{"name":"webhook","namespace":"cert-manager"},
+ "spec":{"replicas":2,"template":{"metadata":{"labels":{"app":"webhook","component":"webhook"}}}},
+ "status":{"readyReplicas":0,"conditions":[{"type":"Available","status":"False"}]}},
+{"metadata":{"name":"web","namespace":"prod"},
+ "spec":{"replicas":1,"template":{"metadata":{"labels":{"app":"web"}}}},
+ "status":{"readyReplicas":1,"conditions":[{"type":"Available","status":"True"}]}}
+]}`
+
+const testCertificates = `{"items":[
+{"metadata":{"name":"tls","namespace":"prod","generation":2},
+ "status":{"observedGeneration":2,"conditions":[{"type":"Ready","status":"False","reason":"Pending","message":"Issuing certificate"}]}},
+{"metadata":{"name":"api","namespace":"prod","generation":3},
+ "status":{"observedGeneration":2,"conditions":[{"type":"Ready","status":"True"}]}},
+{"metadata":{"name":"ok","namespace":"prod","generation":1},
+ "status":{"conditions":[{"type":"Ready","status":"True","observedGeneration":1}]}}
+]}`
+
+func TestCustomResourceIssues(t *testing.T) {
+	count, issues, err := customResourceIssues(testCertificates)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	require.Len(t, issues, 2)
+
+	assert.Equal(t, "tls", issues[0].Name)
+	assert.Equal(t, "Ready", issues[0].Condition)
+	assert.Equal(t, "Pending", issues[0].Reason)
+	assert.False(t, issues[0].Stale)
+
+	assert.Equal(t, "api", issues[1].Name)
+	assert.True(t, issues[1].Stale)
+	assert.Empty(t, issues[1].Condition)
+
+	_, _, err = customResourceIssues("not json")
+	assert.Error(t, err)
+}
+
+func TestHandleCRDHealth(t *testing.T) {
+	newMock := func() *cmd.MockShellExecutor {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "crds", "-o", "json"}, testCRDs, nil)
+		mock.AddCommandString("kubectl", []string{"get", "deployments", "--all-namespaces", "-o", "json"}, testDeployments, nil)
+		mock.AddCommandString("kubectl", []string{"get", "validatingwebhookconfigurations,mutatingwebhookconfigurations", "-o", "json"}, testWebhooks, nil)
+		mock.AddCommandString("kubectl", []string{"get", "endpoints", "cert-manager-webhook", "-n", "cert-manager", "-o", "json"}, `{"subsets":[]}`, nil)
+		mock.AddCommandString("kubectl", []string{"get", "service", "cert-manager-webhook", "-n", "cert-manager", "-o", "json"}, `{"spec":{"selector":{"component":"webhook"}}}`, nil)
+		mock.AddCommandString("kubectl", []string{"get", "certificates.cert-manager.io", "--all-namespaces", "-o", "json"}, testCertificates, nil)
+		mock.AddCommandString("kubectl", []string{"get", "widgets.example.com", "--all-namespaces", "-o", "json"}, `{"items":[]}`, nil)
+		return mock
+	}
+
+	t.Run("reports broken operator", func(t *testing.T) {
+		mock := newMock()
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleCRDHealth(ctx, mcp.CallToolRequest{})
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report CRDHealthReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		assert.Equal(t, CRDHealthSummary{CRDs: 2, UnhealthyCRDs: 1, UnavailableWebhooks: 1, UnhealthyControllers: 1, FailingResources: 2}, report.Summary)
+
+		require.Len(t, report.Webhooks, 1)
+		assert.False(t, report.Webhooks[0].Available)
+		assert.Equal(t, "validating", report.Webhooks[0].Type)
+
+		require.Len(t, report.CRDs, 2)
+		certs := report.CRDs[0]
+		assert.Equal(t, "certificates.cert-manager.io", certs.Name)
+		assert.False(t, certs.Healthy)
+		assert.Equal(t, 3, certs.ResourceCount)
+		assert.Len(t, certs.FailingResources, 2)
+		assert.Equal(t, []string{"webhook.cert-manager.io"}, certs.Webhooks)
+
+		require.Len(t, certs.Controllers, 2)
+		assert.Equal(t, "cert-manager", certs.Controllers[0].Name)
+		assert.Equal(t, "name", certs.Controllers[0].MatchedBy)
+		assert.Equal(t, "webhook", certs.Controllers[1].Name)
+		assert.Equal(t, "webhook_service", certs.Controllers[1].MatchedBy)
+
+		widgets := report.CRDs[1]
+		assert.True(t, widgets.Healthy)
+		assert.Empty(t, widgets.Controllers)
+	})
+
+	t.Run("filters by group", func(t *testing.T) {
+		mock := newMock()
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{
+			"group":           "example.com",
+			"check_resources": "false",
+		}
+		result, err := newTestK8sTool().handleCRDHealth(ctx, req)
+		require.NoError(t, err)
+
+		var report CRDHealthReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		require.Len(t, report.CRDs, 1)
+		assert.Equal(t, "widgets.example.com", report.CRDs[0].Name)
+		assert.Empty(t, report.Webhooks)
+
+		for _, call := range mock.GetCallLog() {
+			assert.NotEqual(t, "widgets.example.com", call.Args[1])
+		}
+	})
+
+	t.Run("unhealthy only", func(t *testing.T) {
+		ctx := cmd.WithShellExecutor(context.Background(), newMock())
+
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{"unhealthy_only": "true"}
+		result, err := newTestK8sTool().handleCRDHealth(ctx, req)
+		require.NoError(t, err)
+
+		var report CRDHealthReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		require.Len(t, report.CRDs, 1)
+		assert.Equal(t, 2, report.Summary.CRDs)
+	})
+
+	t.Run("kubectl failure", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "crds", "-o", "json"}, "", assert.AnError)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleCRDHealth(ctx, mcp.CallToolRequest{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
@@ -0,0 +1,420 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/cliout"
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Statuses of a DeprecationFinding at the target version
+const (
+	DeprecationStatusRemoved    = "removed"
+	DeprecationStatusDeprecated = "deprecated"
+)
+
+// Sources of a DeprecationFinding
+const (
+	DeprecationSourceLive = "live"
+	DeprecationSourceHelm = "helm"
+)
+
+// deprecatedAPI is an API version of a kind that is deprecated and removed in
+// the given Kubernetes minor versions (1.x)
+type deprecatedAPI struct {
+	APIVersion string
+	Kind       string
+	// Resource lists the kind's objects through a version that is still served
+	Resource     string
+	Namespaced   bool
+	Replacement  string
+	DeprecatedIn int
+	RemovedIn    int
+	// Note is a migration hint beyond changing the apiVersion
+	Note string
+}
+
+// deprecatedAPIs are the removed and scheduled removals of built-in APIs, from
+// the Kubernetes deprecated API migration guide
+var deprecatedAPIs = []deprecatedAPI{
+	{"extensions/v1beta1", "Deployment", "deployments.apps", true, "apps/v1", 9, 16, "spec.selector is required and immutable"},
+	{"apps/v1beta1", "Deployment", "deployments.apps", true, "apps/v1", 9, 16, "spec.selector is required and immutable"},
+	{"apps/v1beta2", "Deployment", "deployments.apps", true, "apps/v1", 9, 16, ""},
+	{"extensions/v1beta1", "DaemonSet", "daemonsets.apps", true, "apps/v1", 9, 16, "spec.selector is required and spec.updateStrategy defaults to RollingUpdate"},
+	{"apps/v1beta2", "DaemonSet", "daemonsets.apps", true, "apps/v1", 9, 16, ""},
+	{"apps/v1beta1", "StatefulSet", "statefulsets.apps", true, "apps/v1", 9, 16, "spec.updateStrategy defaults to RollingUpdate"},
+	{"apps/v1beta2", "StatefulSet", "statefulsets.apps", true, "apps/v1", 9, 16, ""},
+	{"extensions/v1beta1", "ReplicaSet", "replicasets.apps", true, "apps/v1", 9, 16, ""},
+	{"apps/v1beta2", "ReplicaSet", "replicasets.apps", true, "apps/v1", 9, 16, ""},
+	{"extensions/v1beta1", "NetworkPolicy", "networkpolicies.networking.k8s.io", true, "networking.k8s.io/v1", 9, 16, ""},
+	{"extensions/v1beta1", "PodSecurityPolicy", "podsecuritypolicies.policy", false, "policy/v1beta1", 10, 16, ""},
+	{"extensions/v1beta1", "Ingress", "ingresses.networking.k8s.io", true, "networking.k8s.io/v1", 14, 22, "spec.backend is renamed spec.defaultBackend, backends use service.name and service.port, and pathType is required"},
+	{"networking.k8s.io/v1beta1", "Ingress", "ingresses.networking.k8s.io", true, "networking.k8s.io/v1", 19, 22, "spec.backend is renamed spec.defaultBackend, backends use service.name and service.port, and pathType is required"},
+	{"networking.k8s.io/v1beta1", "IngressClass", "ingressclasses.networking.k8s.io", false, "networking.k8s.io/v1", 19, 22, ""},
+	{"apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "customresourcedefinitions.apiextensions.k8s.io", false, "apiextensions.k8s.io/v1", 16, 22, "a structural schema is required per version and spec.preserveUnknownFields must be false"},
+	{"admissionregistration.k8s.io/v1beta1", "MutatingWebhookConfiguration", "mutatingwebhookconfigurations.admissionregistration.k8s.io", false, "admissionregistration.k8s.io/v1", 16, 22, "sideEffects and admissionReviewVersions are required"},
+	{"admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "validatingwebhookconfigurations.admissionregistration.k8s.io", false, "admissionregistration.k8s.io/v1", 16, 22, "sideEffects and admissionReviewVersions are required"},
+	{"apiregistration.k8s.io/v1beta1", "APIService", "apiservices.apiregistration.k8s.io", false, "apiregistration.k8s.io/v1", 19, 22, ""},
+	{"certificates.k8s.io/v1beta1", "CertificateSigningRequest", "certificatesigningrequests.certificates.k8s.io", false, "certificates.k8s.io/v1", 19, 22, "spec.signerName is required"},
+	{"coordination.k8s.io/v1beta1", "Lease", "leases.coordination.k8s.io", true, "coordination.k8s.io/v1", 19, 22, ""},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRole", "clusterroles.rbac.authorization.k8s.io", false, "rbac.authorization.k8s.io/v1", 17, 22, ""},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRoleBinding", "clusterrolebindings.rbac.authorization.k8s.io", false, "rbac.authorization.k8s.io/v1", 17, 22, ""},
+	{"rbac.authorization.k8s.io/v1beta1", "Role", "roles.rbac.authorization.k8s.io", true, "rbac.authorization.k8s.io/v1", 17, 22, ""},
+	{"rbac.authorization.k8s.io/v1beta1", "RoleBinding", "rolebindings.rbac.authorization.k8s.io", true, "rbac.authorization.k8s.io/v1", 17, 22, ""},
+	{"scheduling.k8s.io/v1beta1", "PriorityClass", "priorityclasses.scheduling.k8s.io", false, "scheduling.k8s.io/v1", 14, 22, ""},
+	{"storage.k8s.io/v1beta1", "CSIDriver", "csidrivers.storage.k8s.io", false, "storage.k8s.io/v1", 19, 22, ""},
+	{"storage.k8s.io/v1beta1", "CSINode", "csinodes.storage.k8s.io", false, "storage.k8s.io/v1", 17, 22, ""},
+	{"storage.k8s.io/v1beta1", "StorageClass", "storageclasses.storage.k8s.io", false, "storage.k8s.io/v1", 19, 22, ""},
+	{"storage.k8s.io/v1beta1", "VolumeAttachment", "volumeattachments.storage.k8s.io", false, "storage.k8s.io/v1", 19, 22, ""},
+	{"batch/v1beta1", "CronJob", "cronjobs.batch", true, "batch/v1", 21, 25, ""},
+	{"discovery.k8s.io/v1beta1", "EndpointSlice", "endpointslices.discovery.k8s.io", true, "discovery.k8s.io/v1", 21, 25, "topology is replaced by the per-endpoint nodeName and zone fields"},
+	{"events.k8s.io/v1beta1", "Event", "events.events.k8s.io", true, "events.k8s.io/v1", 19, 25, ""},
+	{"autoscaling/v2beta1", "HorizontalPodAutoscaler", "horizontalpodautoscalers.autoscaling", true, "autoscaling/v2", 22, 25, "metric targets move under target with type, value, averageValue and averageUtilization"},
+	{"policy/v1beta1", "PodDisruptionBudget", "poddisruptionbudgets.policy", true, "policy/v1", 21, 25, "an empty spec.selector selects every pod in the namespace"},
+	{"policy/v1beta1", "PodSecurityPolicy", "podsecuritypolicies.policy", false, "", 21, 25, "there is no replacement; enforce Pod Security Standards with Pod Security Admission namespace labels"},
+	{"node.k8s.io/v1beta1", "RuntimeClass", "runtimeclasses.node.k8s.io", false, "node.k8s.io/v1", 20, 25, ""},
+	{"autoscaling/v2beta2", "HorizontalPodAutoscaler", "horizontalpodautoscalers.autoscaling", true, "autoscaling/v2", 23, 26, ""},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "FlowSchema", "flowschemas.flowcontrol.apiserver.k8s.io", false, "flowcontrol.apiserver.k8s.io/v1", 23, 26, ""},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "PriorityLevelConfiguration", "prioritylevelconfigurations.flowcontrol.apiserver.k8s.io", false, "flowcontrol.apiserver.k8s.io/v1", 23, 26, ""},
+	{"storage.k8s.io/v1beta1", "CSIStorageCapacity", "csistoragecapacities.storage.k8s.io", true, "storage.k8s.io/v1", 24, 27, ""},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "FlowSchema", "flowschemas.flowcontrol.apiserver.k8s.io", false, "flowcontrol.apiserver.k8s.io/v1", 26, 29, ""},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "PriorityLevelConfiguration", "prioritylevelconfigurations.flowcontrol.apiserver.k8s.io", false, "flowcontrol.apiserver.k8s.io/v1", 26, 29, ""},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "FlowSchema", "flowschemas.flowcontrol.apiserver.k8s.io", false, "flowcontrol.apiserver.k8s.io/v1", 29, 32, ""},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "PriorityLevelConfiguration", "prioritylevelconfigurations.flowcontrol.apiserver.k8s.io", false, "flowcontrol.apiserver.k8s.io/v1", 29, 32, "spec.limited.assuredConcurrencyShares is renamed nominalConcurrencyShares"},
+}
+
+// lookupDeprecatedAPI returns the deprecation of a kind's API version, if any
+func lookupDeprecatedAPI(apiVersion, kind string) (deprecatedAPI, bool) {
+	for _, api := range deprecatedAPIs {
+		if api.APIVersion == apiVersion && api.Kind == kind {
+			return api, true
+		}
+	}
+	return deprecatedAPI{}, false
+}
+
+// status returns the status of the API at the target minor version, empty when it is still current
+func (api deprecatedAPI) status(targetMinor int) string {
+	switch {
+	case targetMinor >= api.RemovedIn:
+		return DeprecationStatusRemoved
+	case targetMinor >= api.DeprecatedIn:
+		return DeprecationStatusDeprecated
+	}
+	return ""
+}
+
+// DeprecationFinding is an object that uses a deprecated or removed API version
+type DeprecationFinding struct {
+	Source     string `json:"source"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	APIVersion string `json:"api_version"`
+	// Release is the Helm release rendering the object
+	Release      string `json:"release,omitempty"`
+	Replacement  string `json:"replacement,omitempty"`
+	DeprecatedIn string `json:"deprecated_in"`
+	RemovedIn    string `json:"removed_in"`
+	Status       string `json:"status"`
+}
+
+// DeprecationReport is the structured response of k8s_deprecation_scan
+type DeprecationReport struct {
+	ServerVersion string               `json:"server_version,omitempty"`
+	TargetVersion string               `json:"target_version"`
+	Removed       int                  `json:"removed"`
+	Deprecated    int                  `json:"deprecated"`
+	Findings      []DeprecationFinding `json:"findings"`
+	// Checklist lists the migrations to make, most urgent first
+	Checklist []string `json:"checklist"`
+	Notes     []string `json:"notes,omitempty"`
+}
+
+func (r *DeprecationReport) note(format string, args ...interface{}) {
+	r.Notes = append(r.Notes, fmt.Sprintf(format, args...))
+}
+
+// add records a finding for an object using a deprecated API when the API is
+// deprecated or removed at the target minor version
+func (r *DeprecationReport) add(api deprecatedAPI, targetMinor int, finding DeprecationFinding) {
+	status := api.status(targetMinor)
+	if status == "" {
+		return
+	}
+	finding.Kind, finding.APIVersion, finding.Replacement, finding.Status = api.Kind, api.APIVersion, api.Replacement, status
+	finding.DeprecatedIn = fmt.Sprintf("1.%d", api.DeprecatedIn)
+	finding.RemovedIn = fmt.Sprintf("1.%d", api.RemovedIn)
+	r.Findings = append(r.Findings, finding)
+}
+
+// deprecatedObject is the subset of a listed object used to tell the API versions it was written with
+type deprecatedObject struct {
+	Metadata struct {
+		Name          string               `json:"name"`
+		Namespace     string               `json:"namespace"`
+		Annotations   map[string]string    `json:"annotations"`
+		ManagedFields []managedFieldsEntry `json:"managedFields"`
+	} `json:"metadata"`
+}
+
+// writtenAPIVersions returns the API versions an object was last applied or written with.
+// The API server converts objects to the version they are read with, so the
+// last-applied configuration and managed fields are the only record of the
+// versions clients use.
+func (o deprecatedObject) writtenAPIVersions() map[string]bool {
+	versions := map[string]bool{}
+	if lastApplied := o.Metadata.Annotations["kubectl.kubernetes.io/last-applied-configuration"]; lastApplied != "" {
+		var applied struct {
+			APIVersion string `json:"apiVersion"`
+		}
+		if json.Unmarshal([]byte(lastApplied), &applied) == nil && applied.APIVersion != "" {
+			versions[applied.APIVersion] = true
+		}
+	}
+	for _, entry := range o.Metadata.ManagedFields {
+		if entry.APIVersion != "" {
+			versions[entry.APIVersion] = true
+		}
+	}
+	return versions
+}
+
+// scanLiveResources records the live objects written with deprecated API versions
+// that are still served, listing each resource once
+func (k *K8sTool) scanLiveResources(ctx context.Context, report *DeprecationReport, targetMinor int, namespace string, served map[string]bool) {
+	byResource := map[string][]deprecatedAPI{}
+	var resources []string
+	for _, api := range deprecatedAPIs {
+		if !served[api.APIVersion] || api.status(targetMinor) == "" || (namespace != "" && !api.Namespaced) {
+			continue
+		}
+		if _, ok := byResource[api.Resource]; !ok {
+			resources = append(resources, api.Resource)
+		}
+		byResource[api.Resource] = append(byResource[api.Resource], api)
+	}
+
+	for _, resource := range resources {
+		args := []string{"get", resource, "-o", "json", "--show-managed-fields"}
+		if namespace != "" {
+			args = append(args, "-n", namespace)
+		} else {
+			args = append(args, "--all-namespaces")
+		}
+		output, err := k.runKubectlCommandString(ctx, args...)
+		if err != nil {
+			report.note("%s could not be listed: %v", resource, err)
+			continue
+		}
+		var list struct {
+			Items []deprecatedObject `json:"items"`
+		}
+		if err := json.Unmarshal([]byte(output), &list); err != nil {
+			report.note("%s could not be parsed: %v", resource, err)
+			continue
+		}
+		for _, object := range list.Items {
+			versions := object.writtenAPIVersions()
+			for _, api := range byResource[resource] {
+				if versions[api.APIVersion] {
+					report.add(api, targetMinor, DeprecationFinding{Source: DeprecationSourceLive, Name: object.Metadata.Name, Namespace: object.Metadata.Namespace})
+				}
+			}
+		}
+	}
+}
+
+// scanHelmReleases records the objects rendered by Helm releases with deprecated API versions,
+// which fail to upgrade once their API version is removed
+func (k *K8sTool) scanHelmReleases(ctx context.Context, report *DeprecationReport, targetMinor int, namespace string) {
+	args := []string{"list", "-o", "json"}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	} else {
+		args = append(args, "--all-namespaces")
+	}
+	output, err := commands.NewCommandBuilder("helm").WithArgs(args...).WithKubeconfig(k.kubeconfig).Execute(ctx)
+	if err != nil {
+		report.note("Helm releases could not be listed: %v", err)
+		return
+	}
+	var releases []struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	}
+	if err := json.Unmarshal([]byte(output), &releases); err != nil {
+		report.note("Helm releases could not be parsed: %v", err)
+		return
+	}
+
+	for _, release := range releases {
+		manifest, err := commands.NewCommandBuilder("helm").
+			WithArgs("get", "manifest", release.Name, "-n", release.Namespace).
+			WithKubeconfig(k.kubeconfig).
+			Execute(ctx)
+		if err != nil {
+			report.note("manifest of Helm release %s/%s unavailable: %v", release.Namespace, release.Name, err)
+			continue
+		}
+		resources, err := parseManifestResources(manifest)
+		if err != nil {
+			report.note("manifest of Helm release %s/%s could not be parsed: %v", release.Namespace, release.Name, err)
+			continue
+		}
+		for _, resource := range resources {
+			apiVersion, _ := resource.object["apiVersion"].(string)
+			api, ok := lookupDeprecatedAPI(apiVersion, resource.kind)
+			if !ok {
+				continue
+			}
+			finding := DeprecationFinding{Source: DeprecationSourceHelm, Name: resource.name, Release: release.Name}
+			if api.Namespaced {
+				finding.Namespace = resource.namespace
+				if finding.Namespace == "" {
+					finding.Namespace = release.Namespace
+				}
+			}
+			report.add(api, targetMinor, finding)
+		}
+	}
+}
+
+// buildChecklist groups findings into one migration per API version and kind,
+// removals first and then by the version removing them
+func buildChecklist(findings []DeprecationFinding) []string {
+	type migration struct {
+		api     deprecatedAPI
+		status  string
+		objects []string
+	}
+	var migrations []*migration
+	byAPI := map[string]*migration{}
+	for _, finding := range findings {
+		key := finding.APIVersion + " " + finding.Kind
+		m, ok := byAPI[key]
+		if !ok {
+			api, _ := lookupDeprecatedAPI(finding.APIVersion, finding.Kind)
+			m = &migration{api: api, status: finding.Status}
+			byAPI[key] = m
+			migrations = append(migrations, m)
+		}
+		object := finding.Name
+		if finding.Namespace != "" {
+			object = finding.Namespace + "/" + object
+		}
+		if finding.Release != "" {
+			object += fmt.Sprintf(" (Helm release %s)", finding.Release)
+		}
+		if !containsString(m.objects, object) {
+			m.objects = append(m.objects, object)
+		}
+	}
+	sort.SliceStable(migrations, func(i, j int) bool {
+		if (migrations[i].status == DeprecationStatusRemoved) != (migrations[j].status == DeprecationStatusRemoved) {
+			return migrations[i].status == DeprecationStatusRemoved
+		}
+		return migrations[i].api.RemovedIn < migrations[j].api.RemovedIn
+	})
+
+	checklist := make([]string, 0, len(migrations))
+	for _, m := range migrations {
+		var item string
+		if m.api.Replacement == "" {
+			item = fmt.Sprintf("Remove %d %s using %s before upgrading to 1.%d: %s",
+				len(m.objects), m.api.Kind, m.api.APIVersion, m.api.RemovedIn, strings.Join(m.objects, ", "))
+		} else {
+			item = fmt.Sprintf("Migrate %d %s from %s to %s before upgrading to 1.%d: %s",
+				len(m.objects), m.api.Kind, m.api.APIVersion, m.api.Replacement, m.api.RemovedIn, strings.Join(m.objects, ", "))
+		}
+		if m.api.Note != "" {
+			item += " (" + m.api.Note + ")"
+		}
+		checklist = append(checklist, item)
+	}
+	return checklist
+}
+
+// upgradeTarget returns the cluster's server version, empty when it cannot be
+// determined, and the Kubernetes version to upgrade to, which defaults to the
+// minor version after the server's
+func (k *K8sTool) upgradeTarget(ctx context.Context, targetVersion string) (string, semver, error) {
+	output, _ := k.runKubectlCommandString(ctx, "version", "-o", "json")
+	kubectlVersion, _ := cliout.ParseKubectlVersion(output)
+	server, serverOK := parseVersion(kubectlVersion.Server)
+	if !serverOK {
+		kubectlVersion.Server = ""
+	}
+
+	switch {
+	case targetVersion != "":
+		target, ok := parseVersion(targetVersion)
+		if !ok || target.Major != 1 {
+			return "", semver{}, fmt.Errorf("target_version %q is not a Kubernetes 1.x version, e.g. 1.32", targetVersion)
+		}
+		return kubectlVersion.Server, semver{Major: 1, Minor: target.Minor}, nil
+	case serverOK:
+		return kubectlVersion.Server, semver{Major: 1, Minor: server.Minor + 1}, nil
+	}
+	return "", semver{}, fmt.Errorf("the Kubernetes server version could not be determined; set target_version")
+}
+
+// scanDeprecations scans live resources, and optionally Helm releases, for API
+// versions deprecated or removed at the target minor version
+func (k *K8sTool) scanDeprecations(ctx context.Context, targetMinor int, namespace string, includeHelm bool) *DeprecationReport {
+	report := &DeprecationReport{TargetVersion: fmt.Sprintf("1.%d", targetMinor), Findings: []DeprecationFinding{}}
+	if output, err := k.runKubectlCommandString(ctx, "api-versions"); err != nil {
+		report.note("served API versions could not be listed, live resources were not scanned: %v", err)
+	} else {
+		served := map[string]bool{}
+		for _, version := range strings.Fields(output) {
+			served[version] = true
+		}
+		k.scanLiveResources(ctx, report, targetMinor, namespace, served)
+	}
+	if includeHelm {
+		k.scanHelmReleases(ctx, report, targetMinor, namespace)
+	}
+
+	for _, finding := range report.Findings {
+		if finding.Status == DeprecationStatusRemoved {
+			report.Removed++
+		} else {
+			report.Deprecated++
+		}
+	}
+	report.Checklist = buildChecklist(report.Findings)
+	return report
+}
+
+// Deprecated and removed API scan of live resources and Helm manifests ahead of an upgrade
+func (k *K8sTool) handleDeprecationScan(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	targetVersion := p.String("target_version", "")
+	namespace := p.String("namespace", "", params.Check(security.ValidateNamespace))
+	includeHelm := p.Bool("include_helm", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	serverVersion, target, err := k.upgradeTarget(ctx, targetVersion)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	report := k.scanDeprecations(ctx, target.Minor, namespace, includeHelm)
+	report.ServerVersion = serverVersion
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling deprecation report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
@@ -0,0 +1,112 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const testDeprecationIngresses = `{"items":[
+	{"metadata":{"name":"legacy","namespace":"web","annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{\"apiVersion\":\"networking.k8s.io/v1beta1\",\"kind\":\"Ingress\"}"}}},
+	{"metadata":{"name":"current","namespace":"web","managedFields":[{"manager":"kubectl","apiVersion":"networking.k8s.io/v1"}]}}
+]}`
+
+const testDeprecationHPAs = `{"items":[
+	{"metadata":{"name":"api","namespace":"web","managedFields":[{"manager":"helm","apiVersion":"autoscaling/v2beta2"}]}}
+]}`
+
+const testDeprecationManifest = `apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: api
+spec:
+  minAvailable: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+`
+
+func TestDeprecatedAPIStatus(t *testing.T) {
+	api, ok := lookupDeprecatedAPI("autoscaling/v2beta2", "HorizontalPodAutoscaler")
+	require.True(t, ok)
+	assert.Empty(t, api.status(22))
+	assert.Equal(t, DeprecationStatusDeprecated, api.status(23))
+	assert.Equal(t, DeprecationStatusRemoved, api.status(26))
+
+	_, ok = lookupDeprecatedAPI("apps/v1", "Deployment")
+	assert.False(t, ok)
+}
+
+func TestBuildChecklist(t *testing.T) {
+	findings := []DeprecationFinding{
+		{Kind: "HorizontalPodAutoscaler", APIVersion: "autoscaling/v2beta2", Name: "api", Namespace: "web", Status: DeprecationStatusDeprecated},
+		{Kind: "PodSecurityPolicy", APIVersion: "policy/v1beta1", Name: "restricted", Status: DeprecationStatusRemoved},
+		{Kind: "Ingress", APIVersion: "networking.k8s.io/v1beta1", Name: "legacy", Namespace: "web", Status: DeprecationStatusRemoved},
+		{Kind: "Ingress", APIVersion: "networking.k8s.io/v1beta1", Name: "legacy", Namespace: "web", Release: "site", Status: DeprecationStatusRemoved},
+	}
+	checklist := buildChecklist(findings)
+	require.Len(t, checklist, 3)
+	assert.Contains(t, checklist[0], "Migrate 2 Ingress from networking.k8s.io/v1beta1 to networking.k8s.io/v1 before upgrading to 1.22: web/legacy, web/legacy (Helm release site)")
+	assert.Contains(t, checklist[1], "Remove 1 PodSecurityPolicy using policy/v1beta1 before upgrading to 1.25: restricted")
+	assert.Contains(t, checklist[1], "Pod Security Admission")
+	assert.Contains(t, checklist[2], "HorizontalPodAutoscaler from autoscaling/v2beta2 to autoscaling/v2 before upgrading to 1.26")
+}
+
+func TestHandleDeprecationScan(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"version", "-o", "json"}, `{"clientVersion":{"gitVersion":"v1.21.3"},"serverVersion":{"gitVersion":"v1.21.3"}}`, nil)
+	mock.AddCommandString("kubectl", []string{"api-versions"}, "apps/v1\nautoscaling/v2beta2\nnetworking.k8s.io/v1\nnetworking.k8s.io/v1beta1\npolicy/v1beta1\nv1\n", nil)
+	mock.AddCommandString("kubectl", []string{"get", "ingresses.networking.k8s.io", "-o", "json", "--show-managed-fields", "-n", "web"}, testDeprecationIngresses, nil)
+	mock.AddCommandString("kubectl", []string{"get", "poddisruptionbudgets.policy", "-o", "json", "--show-managed-fields", "-n", "web"}, `{"items":[]}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "horizontalpodautoscalers.autoscaling", "-o", "json", "--show-managed-fields", "-n", "web"}, testDeprecationHPAs, nil)
+	mock.AddCommandString("helm", []string{"list", "-o", "json", "-n", "web"}, `[{"name":"api","namespace":"web"}]`, nil)
+	mock.AddCommandString("helm", []string{"get", "manifest", "api", "-n", "web"}, testDeprecationManifest, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"namespace": "web", "include_helm": "true", "target_version": "1.25"}
+	result, err := newTestK8sTool().handleDeprecationScan(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var report DeprecationReport
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+	assert.Equal(t, "1.25", report.TargetVersion)
+	assert.Equal(t, 2, report.Removed)
+	assert.Equal(t, 1, report.Deprecated)
+	assert.Equal(t, []DeprecationFinding{
+		{Source: DeprecationSourceLive, Kind: "Ingress", Name: "legacy", Namespace: "web", APIVersion: "networking.k8s.io/v1beta1", Replacement: "networking.k8s.io/v1", DeprecatedIn: "1.19", RemovedIn: "1.22", Status: DeprecationStatusRemoved},
+		{Source: DeprecationSourceLive, Kind: "HorizontalPodAutoscaler", Name: "api", Namespace: "web", APIVersion: "autoscaling/v2beta2", Replacement: "autoscaling/v2", DeprecatedIn: "1.23", RemovedIn: "1.26", Status: DeprecationStatusDeprecated},
+		{Source: DeprecationSourceHelm, Kind: "PodDisruptionBudget", Name: "api", Namespace: "web", Release: "api", APIVersion: "policy/v1beta1", Replacement: "policy/v1", DeprecatedIn: "1.21", RemovedIn: "1.25", Status: DeprecationStatusRemoved},
+	}, report.Findings)
+	assert.Len(t, report.Checklist, 3)
+	assert.Empty(t, report.Notes)
+
+	t.Run("default target", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"namespace": "web"}
+		result, err := newTestK8sTool().handleDeprecationScan(ctx, request)
+		require.NoError(t, err)
+		var report DeprecationReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		assert.Equal(t, "1.22", report.TargetVersion)
+		require.Len(t, report.Findings, 1)
+		assert.Equal(t, "legacy", report.Findings[0].Name)
+	})
+
+	t.Run("invalid target", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"target_version": "next"}
+		result, err := newTestK8sTool().handleDeprecationScan(ctx, request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
@@ -0,0 +1,424 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/cache"
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+	"github.com/kagent-dev/tools/pkg/utils"
+)
+
+// defaultRestartTimeout bounds the wait for a restarted workload's rollout when no timeout is given
+const defaultRestartTimeout = 5 * time.Minute
+
+// Outcomes of k8s_restart_workload
+const (
+	RestartStatusRestarted = "restarted"
+	RestartStatusStarted   = "restart_started"
+	RestartStatusBlocked   = "blocked"
+	RestartStatusFailed    = "rollout_failed"
+)
+
+// Outcomes of k8s_evict_pod
+const (
+	EvictionStatusEvicted = "evicted"
+	EvictionStatusBlocked = "blocked"
+)
+
+// restartableKinds are the workload kinds supported by kubectl rollout restart
+var restartableKinds = []string{"deployment", "statefulset", "daemonset"}
+
+// pdbList is the subset of `kubectl get pdb -o json` output used for disruption checks
+type pdbList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			Selector       *labelSelector  `json:"selector"`
+			MinAvailable   json.RawMessage `json:"minAvailable"`
+			MaxUnavailable json.RawMessage `json:"maxUnavailable"`
+		} `json:"spec"`
+		Status struct {
+			CurrentHealthy     int `json:"currentHealthy"`
+			DesiredHealthy     int `json:"desiredHealthy"`
+			ExpectedPods       int `json:"expectedPods"`
+			DisruptionsAllowed int `json:"disruptionsAllowed"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// PDBCheck is a PodDisruptionBudget covering the pods of a disruption
+type PDBCheck struct {
+	Name               string `json:"name"`
+	MinAvailable       string `json:"min_available,omitempty"`
+	MaxUnavailable     string `json:"max_unavailable,omitempty"`
+	CurrentHealthy     int    `json:"current_healthy"`
+	DesiredHealthy     int    `json:"desired_healthy"`
+	DisruptionsAllowed int    `json:"disruptions_allowed"`
+	Blocking           bool   `json:"blocking"`
+}
+
+// intOrString renders an int-or-string field such as minAvailable
+func intOrString(raw json.RawMessage) string {
+	return strings.Trim(string(raw), `"`)
+}
+
+// matchingPDBs returns the PodDisruptionBudgets selecting pods with the given
+// labels. A budget blocks the disruption when it allows no more disruptions.
+func matchingPDBs(output string, labels map[string]string) ([]PDBCheck, error) {
+	var pdbs pdbList
+	if err := json.Unmarshal([]byte(output), &pdbs); err != nil {
+		return nil, fmt.Errorf("failed to parse PodDisruptionBudgets: %w", err)
+	}
+
+	checks := []PDBCheck{}
+	for _, pdb := range pdbs.Items {
+		// A budget without a selector selects no pods
+		if pdb.Spec.Selector == nil || !pdb.Spec.Selector.matches(labels) {
+			continue
+		}
+		checks = append(checks, PDBCheck{
+			Name:               pdb.Metadata.Name,
+			MinAvailable:       intOrString(pdb.Spec.MinAvailable),
+			MaxUnavailable:     intOrString(pdb.Spec.MaxUnavailable),
+			CurrentHealthy:     pdb.Status.CurrentHealthy,
+			DesiredHealthy:     pdb.Status.DesiredHealthy,
+			DisruptionsAllowed: pdb.Status.DisruptionsAllowed,
+			Blocking:           pdb.Status.DisruptionsAllowed < 1,
+		})
+	}
+	return checks, nil
+}
+
+// blockingPDBs returns the names of the budgets that allow no disruption
+func blockingPDBs(checks []PDBCheck) []string {
+	var names []string
+	for _, check := range checks {
+		if check.Blocking {
+			names = append(names, check.Name)
+		}
+	}
+	return names
+}
+
+// restartWorkload is the subset of a Deployment, StatefulSet or DaemonSet used by restarts
+type restartWorkload struct {
+	Spec struct {
+		Template struct {
+			Metadata struct {
+				Labels map[string]string `json:"labels"`
+			} `json:"metadata"`
+		} `json:"template"`
+	} `json:"spec"`
+	Status struct {
+		Replicas               int `json:"replicas"`
+		ReadyReplicas          int `json:"readyReplicas"`
+		UpdatedReplicas        int `json:"updatedReplicas"`
+		DesiredNumberScheduled int `json:"desiredNumberScheduled"`
+		NumberReady            int `json:"numberReady"`
+		UpdatedNumberScheduled int `json:"updatedNumberScheduled"`
+	} `json:"status"`
+}
+
+// WorkloadReplicas is the replica state of a workload
+type WorkloadReplicas struct {
+	Desired int `json:"desired"`
+	Ready   int `json:"ready"`
+	Updated int `json:"updated"`
+}
+
+// replicas returns the replica state of a workload, reading the DaemonSet fields when set
+func (w restartWorkload) replicas() WorkloadReplicas {
+	if w.Status.DesiredNumberScheduled > 0 {
+		return WorkloadReplicas{Desired: w.Status.DesiredNumberScheduled, Ready: w.Status.NumberReady, Updated: w.Status.UpdatedNumberScheduled}
+	}
+	return WorkloadReplicas{Desired: w.Status.Replicas, Ready: w.Status.ReadyReplicas, Updated: w.Status.UpdatedReplicas}
+}
+
+// WorkloadRestartReport is the structured response of k8s_restart_workload
+type WorkloadRestartReport struct {
+	Workload             string            `json:"workload"`
+	Namespace            string            `json:"namespace"`
+	Status               string            `json:"status"`
+	Message              string            `json:"message"`
+	PodDisruptionBudgets []PDBCheck        `json:"pod_disruption_budgets"`
+	RolloutStatus        string            `json:"rollout_status,omitempty"`
+	Replicas             *WorkloadReplicas `json:"replicas,omitempty"`
+}
+
+// getWorkload fetches a workload for a restart
+func (k *K8sTool) getWorkload(ctx context.Context, workload, namespace string) (*restartWorkload, error) {
+	output, err := k.runKubectlCommandString(ctx, "get", workload, "-n", namespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var parsed restartWorkload
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", workload, err)
+	}
+	return &parsed, nil
+}
+
+// podDisruptionBudgets returns the budgets in a namespace covering pods with the given labels
+func (k *K8sTool) podDisruptionBudgets(ctx context.Context, namespace string, labels map[string]string) ([]PDBCheck, error) {
+	output, err := k.runKubectlCommandString(ctx, "get", "poddisruptionbudgets", "-n", namespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	return matchingPDBs(output, labels)
+}
+
+// Restart workload
+func (k *K8sTool) handleRestartWorkload(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	kind := p.String("resource_type", "deployment", params.OneOf(restartableKinds...))
+	name := p.String("resource_name", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	namespace := p.String("namespace", "", params.Required(), params.Check(security.ValidateNamespace))
+	force := p.Bool("force", false)
+	wait := p.Bool("wait", true)
+	timeout := p.Duration("timeout", defaultRestartTimeout)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	steps := []string{"check_budgets", "restart"}
+	if wait {
+		steps = append(steps, "wait_rollout")
+	}
+	reportStep := utils.StepReporter(ctx, request, steps...)
+
+	ref := kind + "/" + name
+	reportStep("check_budgets")
+	workload, err := k.getWorkload(ctx, ref, namespace)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error getting %s: %v", ref, err)), nil
+	}
+
+	report := WorkloadRestartReport{Workload: ref, Namespace: namespace}
+	report.PodDisruptionBudgets, err = k.podDisruptionBudgets(ctx, namespace, workload.Spec.Template.Metadata.Labels)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error getting PodDisruptionBudgets: %v", err)), nil
+	}
+
+	if blocking := blockingPDBs(report.PodDisruptionBudgets); len(blocking) > 0 && !force {
+		report.Status = RestartStatusBlocked
+		report.Message = fmt.Sprintf("PodDisruptionBudget %s allows no disruptions, so restarting would take pods below their budget; fix the unhealthy pods or set force=true", strings.Join(blocking, ", "))
+		replicas := workload.replicas()
+		report.Replicas = &replicas
+		return marshalRestartReport(report)
+	}
+
+	reportStep("restart")
+	if _, err := k.runKubectlCommandString(ctx, "rollout", "restart", ref, "-n", namespace); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error restarting %s: %v", ref, err)), nil
+	}
+	cache.InvalidateKubernetesCache()
+
+	report.Status = RestartStatusStarted
+	report.Message = "restart triggered"
+	if wait {
+		reportStep("wait_rollout")
+		status, err := commands.NewCommandBuilder("kubectl").
+			WithArgs("rollout", "status", ref, "-n", namespace).
+			WithKubeconfig(k.kubeconfig).
+			WithTimeout(timeout).
+			Execute(ctx)
+		report.RolloutStatus = strings.TrimSpace(status)
+		if err != nil {
+			report.Status = RestartStatusFailed
+			report.Message = fmt.Sprintf("rollout did not complete within %s: %v", timeout, err)
+		} else {
+			report.Status = RestartStatusRestarted
+			report.Message = "all pods were replaced and are ready"
+		}
+	}
+
+	if workload, err := k.getWorkload(ctx, ref, namespace); err == nil {
+		replicas := workload.replicas()
+		report.Replicas = &replicas
+	}
+	return marshalRestartReport(report)
+}
+
+// marshalRestartReport renders a restart report as the tool result
+func marshalRestartReport(report WorkloadRestartReport) (*mcp.CallToolResult, error) {
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling restart report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
+
+// evictionPod is the subset of a pod used by evictions
+type evictionPod struct {
+	Metadata struct {
+		Labels            map[string]string `json:"labels"`
+		DeletionTimestamp string            `json:"deletionTimestamp"`
+		OwnerReferences   []struct {
+			Kind       string `json:"kind"`
+			Name       string `json:"name"`
+			Controller bool   `json:"controller"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName string `json:"nodeName"`
+	} `json:"spec"`
+	Status struct {
+		Phase string `json:"phase"`
+	} `json:"status"`
+}
+
+// controller returns the kind/name of the pod's controller, or an empty string
+func (p evictionPod) controller() string {
+	for _, owner := range p.Metadata.OwnerReferences {
+		if owner.Controller {
+			return owner.Kind + "/" + owner.Name
+		}
+	}
+	return ""
+}
+
+// PodEvictionReport is the structured response of k8s_evict_pod
+type PodEvictionReport struct {
+	Pod                  string     `json:"pod"`
+	Namespace            string     `json:"namespace"`
+	Node                 string     `json:"node,omitempty"`
+	Controller           string     `json:"controller,omitempty"`
+	Status               string     `json:"status"`
+	Message              string     `json:"message"`
+	PodDisruptionBudgets []PDBCheck `json:"pod_disruption_budgets"`
+	PodState             string     `json:"pod_state,omitempty"`
+}
+
+// getEvictionPod fetches a pod, returning nil when it does not exist
+func (k *K8sTool) getEvictionPod(ctx context.Context, name, namespace string) (*evictionPod, error) {
+	output, err := k.runKubectlCommandString(ctx, "get", "pod", name, "-n", namespace, "-o", "json", "--ignore-not-found")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(output) == "" {
+		return nil, nil
+	}
+	var pod evictionPod
+	if err := json.Unmarshal([]byte(output), &pod); err != nil {
+		return nil, fmt.Errorf("failed to parse pod: %w", err)
+	}
+	return &pod, nil
+}
+
+// evictPod submits an Eviction for a pod through the Eviction API, which
+// refuses evictions that would violate a PodDisruptionBudget
+func (k *K8sTool) evictPod(ctx context.Context, name, namespace string, gracePeriod int) error {
+	eviction := map[string]interface{}{
+		"apiVersion": "policy/v1",
+		"kind":       "Eviction",
+		"metadata":   map[string]string{"name": name, "namespace": namespace},
+	}
+	if gracePeriod >= 0 {
+		eviction["deleteOptions"] = map[string]int{"gracePeriodSeconds": gracePeriod}
+	}
+	body, err := json.Marshal(eviction)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp("", "k8s-eviction-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		if removeErr := os.Remove(tmpFile.Name()); removeErr != nil {
+			logger.Get().Error("Failed to remove temporary file", "error", removeErr, "file", tmpFile.Name())
+		}
+	}()
+	if _, err := tmpFile.Write(body); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	uri := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/eviction", namespace, name)
+	_, err = k.runKubectlCommandString(ctx, "create", "--raw", uri, "-f", tmpFile.Name())
+	return err
+}
+
+// Evict pod
+func (k *K8sTool) handleEvictPod(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("pod_name", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	namespace := p.String("namespace", "", params.Required(), params.Check(security.ValidateNamespace))
+	gracePeriod := p.Int("grace_period", -1, params.Min(-1))
+	force := p.Bool("force", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	pod, err := k.getEvictionPod(ctx, name, namespace)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error getting pod: %v", err)), nil
+	}
+	if pod == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("pod %s not found in namespace %s", name, namespace)), nil
+	}
+
+	report := PodEvictionReport{Pod: name, Namespace: namespace, Node: pod.Spec.NodeName, Controller: pod.controller()}
+	if report.Controller == "" && !force {
+		return mcp.NewToolResultError(fmt.Sprintf("pod %s is not managed by a controller and will not be recreated after eviction; set force=true to evict it anyway", name)), nil
+	}
+
+	report.PodDisruptionBudgets, err = k.podDisruptionBudgets(ctx, namespace, pod.Metadata.Labels)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error getting PodDisruptionBudgets: %v", err)), nil
+	}
+
+	// The Eviction API enforces the budgets itself; this also covers budgets
+	// that changed since they were read
+	if err := k.evictPod(ctx, name, namespace, gracePeriod); err != nil {
+		if !strings.Contains(err.Error(), "disruption budget") && !strings.Contains(err.Error(), "TooManyRequests") {
+			return mcp.NewToolResultError(fmt.Sprintf("Error evicting pod: %v", err)), nil
+		}
+		report.Status = EvictionStatusBlocked
+		report.Message = "eviction refused because it would violate a PodDisruptionBudget"
+		if blocking := blockingPDBs(report.PodDisruptionBudgets); len(blocking) > 0 {
+			report.Message += ": " + strings.Join(blocking, ", ")
+		}
+	} else {
+		cache.InvalidateKubernetesCache()
+		report.Status = EvictionStatusEvicted
+		report.Message = "pod evicted"
+		if report.Controller != "" {
+			report.Message += fmt.Sprintf("; %s will create a replacement", report.Controller)
+		}
+	}
+
+	switch after, err := k.getEvictionPod(ctx, name, namespace); {
+	case err != nil:
+		logger.Get().Info("Evicted pod could not be inspected", "pod", name, "namespace", namespace, "error", err)
+	case after == nil:
+		report.PodState = "deleted"
+	case after.Metadata.DeletionTimestamp != "":
+		report.PodState = "terminating"
+	default:
+		report.PodState = after.Status.Phase
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling eviction report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
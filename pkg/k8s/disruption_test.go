@@ -0,0 +1,179 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPDBs returns a PodDisruptionBudget list with a budget for app=web
+func testPDBs(disruptionsAllowed int) string {
+	return fmt.Sprintf(`{"items":[
+{"metadata":{"name":"web"},"spec":{"selector":{"matchLabels":{"app":"web"}},"minAvailable":"50%%"},
+ "status":{"currentHealthy":2,"desiredHealthy":2,"expectedPods":3,"disruptionsAllowed":%d}},
+{"metadata":{"name":"db"},"spec":{"selector":{"matchLabels":{"app":"db"}},"maxUnavailable":1},"status":{"disruptionsAllowed":0}},
+{"metadata":{"name":"unselected"},"spec":{},"status":{"disruptionsAllowed":0}}
+]}`, disruptionsAllowed)
+}
+
+const testRestartDeployment = `{"spec":{"template":{"metadata":{"labels":{"app":"web","tier":"frontend"}}}},
+ "status":{"replicas":3,"readyReplicas":3,"updatedReplicas":3}}`
+
+func TestMatchingPDBs(t *testing.T) {
+	checks, err := matchingPDBs(testPDBs(0), map[string]string{"app": "web"})
+	require.NoError(t, err)
+	require.Len(t, checks, 1)
+	assert.Equal(t, PDBCheck{Name: "web", MinAvailable: "50%", CurrentHealthy: 2, DesiredHealthy: 2, Blocking: true}, checks[0])
+	assert.Equal(t, []string{"web"}, blockingPDBs(checks))
+
+	checks, err = matchingPDBs(testPDBs(1), map[string]string{"app": "db"})
+	require.NoError(t, err)
+	require.Len(t, checks, 1)
+	assert.Equal(t, "1", checks[0].MaxUnavailable)
+
+	_, err = matchingPDBs("not json", nil)
+	assert.Error(t, err)
+}
+
+func TestHandleRestartWorkload(t *testing.T) {
+	runRestart := func(t *testing.T, mock *cmd.MockShellExecutor, arguments map[string]interface{}) WorkloadRestartReport {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = arguments
+		result, err := newTestK8sTool().handleRestartWorkload(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report WorkloadRestartReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		return report
+	}
+
+	newMock := func(disruptionsAllowed int, rolloutErr error) *cmd.MockShellExecutor {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "deployment/web", "-n", "prod", "-o", "json"}, testRestartDeployment, nil)
+		mock.AddCommandString("kubectl", []string{"get", "poddisruptionbudgets", "-n", "prod", "-o", "json"}, testPDBs(disruptionsAllowed), nil)
+		mock.AddCommandString("kubectl", []string{"rollout", "restart", "deployment/web", "-n", "prod"}, "deployment.apps/web restarted", nil)
+		mock.AddCommandString("kubectl", []string{"rollout", "status", "deployment/web", "-n", "prod", "--timeout", "2m0s"}, "deployment \"web\" successfully rolled out\n", rolloutErr)
+		return mock
+	}
+
+	t.Run("restarted", func(t *testing.T) {
+		report := runRestart(t, newMock(1, nil), map[string]interface{}{"resource_name": "web", "namespace": "prod", "timeout": "2m"})
+		assert.Equal(t, RestartStatusRestarted, report.Status)
+		assert.Equal(t, "deployment \"web\" successfully rolled out", report.RolloutStatus)
+		assert.Equal(t, &WorkloadReplicas{Desired: 3, Ready: 3, Updated: 3}, report.Replicas)
+		require.Len(t, report.PodDisruptionBudgets, 1)
+		assert.False(t, report.PodDisruptionBudgets[0].Blocking)
+	})
+
+	t.Run("blocked by budget", func(t *testing.T) {
+		mock := newMock(0, nil)
+		report := runRestart(t, mock, map[string]interface{}{"resource_name": "web", "namespace": "prod"})
+		assert.Equal(t, RestartStatusBlocked, report.Status)
+		assert.Contains(t, report.Message, "PodDisruptionBudget web allows no disruptions")
+		assert.Len(t, mock.GetCallLog(), 2)
+	})
+
+	t.Run("forced without waiting", func(t *testing.T) {
+		report := runRestart(t, newMock(0, nil), map[string]interface{}{"resource_name": "web", "namespace": "prod", "force": "true", "wait": "false"})
+		assert.Equal(t, RestartStatusStarted, report.Status)
+		assert.Empty(t, report.RolloutStatus)
+	})
+
+	t.Run("rollout times out", func(t *testing.T) {
+		report := runRestart(t, newMock(1, errors.New("timed out waiting for the condition")), map[string]interface{}{"resource_name": "web", "namespace": "prod", "timeout": "2m"})
+		assert.Equal(t, RestartStatusFailed, report.Status)
+		assert.Contains(t, report.Message, "timed out")
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, arguments := range []map[string]interface{}{
+			{"resource_name": "web"},
+			{"resource_name": "web", "namespace": "prod", "resource_type": "job"},
+			{"resource_name": "web;rm", "namespace": "prod"},
+		} {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = arguments
+			result, err := newTestK8sTool().handleRestartWorkload(context.Background(), request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError, arguments)
+		}
+	})
+}
+
+const testEvictionPod = `{"metadata":{"labels":{"app":"web"},"ownerReferences":[{"kind":"ReplicaSet","name":"web-7c9d8f6b5","controller":true}]},
+ "spec":{"nodeName":"node-1"},"status":{"phase":"Running"}}`
+
+func TestHandleEvictPod(t *testing.T) {
+	getPod := []string{"get", "pod", "web-1", "-n", "prod", "-o", "json", "--ignore-not-found"}
+
+	t.Run("evicted", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", getPod, testEvictionPod, nil)
+		mock.AddCommandString("kubectl", []string{"get", "poddisruptionbudgets", "-n", "prod", "-o", "json"}, testPDBs(1), nil)
+		mock.AddPartialMatcherString("kubectl", []string{"create", "--raw", "/api/v1/namespaces/prod/pods/web-1/eviction", "-f"}, "", nil)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"pod_name": "web-1", "namespace": "prod"}
+		result, err := newTestK8sTool().handleEvictPod(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report PodEvictionReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		assert.Equal(t, EvictionStatusEvicted, report.Status)
+		assert.Equal(t, "ReplicaSet/web-7c9d8f6b5", report.Controller)
+		assert.Equal(t, "node-1", report.Node)
+		assert.Contains(t, report.Message, "will create a replacement")
+		// The mock returns the pod again after the eviction
+		assert.Equal(t, "Running", report.PodState)
+	})
+
+	t.Run("blocked by budget", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", getPod, testEvictionPod, nil)
+		mock.AddCommandString("kubectl", []string{"get", "poddisruptionbudgets", "-n", "prod", "-o", "json"}, testPDBs(0), nil)
+		mock.AddPartialMatcherString("kubectl", []string{"create", "--raw"}, "", errors.New("Error from server (TooManyRequests): Cannot evict pod as it would violate the pod's disruption budget."))
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"pod_name": "web-1", "namespace": "prod", "grace_period": float64(10)}
+		result, err := newTestK8sTool().handleEvictPod(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report PodEvictionReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		assert.Equal(t, EvictionStatusBlocked, report.Status)
+		assert.Equal(t, "eviction refused because it would violate a PodDisruptionBudget: web", report.Message)
+	})
+
+	t.Run("unmanaged pod needs force", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", getPod, `{"metadata":{"labels":{"app":"web"}},"status":{"phase":"Running"}}`, nil)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"pod_name": "web-1", "namespace": "prod"}
+		result, err := newTestK8sTool().handleEvictPod(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "not managed by a controller")
+	})
+
+	t.Run("missing pod", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", getPod, "", nil)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"pod_name": "web-1", "namespace": "prod"}
+		result, err := newTestK8sTool().handleEvictPod(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
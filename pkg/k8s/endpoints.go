@@ -0,0 +1,449 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// serviceNameLabel links EndpointSlices to the Service they were created for
+const serviceNameLabel = "kubernetes.io/service-name"
+
+// maxSelectorMisses bounds the pods reported as almost matching a Service's selector
+const maxSelectorMisses = 10
+
+// Statuses of a Service's endpoints
+const (
+	EndpointsHealthy     = "healthy"
+	EndpointsDegraded    = "degraded"
+	EndpointsUnavailable = "unavailable"
+	// EndpointsUnmanaged is the status of Services without a selector, whose
+	// endpoints are managed by hand or by another controller
+	EndpointsUnmanaged = "unmanaged"
+)
+
+// endpointsService is the subset of a Service used to check its endpoints
+type endpointsService struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Type                     string            `json:"type"`
+		Selector                 map[string]string `json:"selector"`
+		PublishNotReadyAddresses bool              `json:"publishNotReadyAddresses"`
+		Ports                    []struct {
+			Name       string          `json:"name"`
+			Port       int             `json:"port"`
+			Protocol   string          `json:"protocol"`
+			TargetPort json.RawMessage `json:"targetPort"`
+		} `json:"ports"`
+	} `json:"spec"`
+}
+
+// endpointSliceList is the subset of `kubectl get endpointslices -o json` output used to check endpoints
+type endpointSliceList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Endpoints []struct {
+			Addresses  []string `json:"addresses"`
+			Conditions struct {
+				Ready       *bool `json:"ready"`
+				Serving     *bool `json:"serving"`
+				Terminating *bool `json:"terminating"`
+			} `json:"conditions"`
+			TargetRef *struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"targetRef"`
+		} `json:"endpoints"`
+		Ports []struct {
+			Name string `json:"name"`
+			Port int    `json:"port"`
+		} `json:"ports"`
+	} `json:"items"`
+}
+
+// endpointsPodList is the subset of `kubectl get pods -o json` output used to check endpoints
+type endpointsPodList struct {
+	Items []struct {
+		Metadata struct {
+			Name              string            `json:"name"`
+			Labels            map[string]string `json:"labels"`
+			DeletionTimestamp string            `json:"deletionTimestamp"`
+		} `json:"metadata"`
+		Spec struct {
+			ReadinessGates []struct {
+				ConditionType string `json:"conditionType"`
+			} `json:"readinessGates"`
+			Containers []struct {
+				Name           string          `json:"name"`
+				ReadinessProbe json.RawMessage `json:"readinessProbe"`
+				Ports          []struct {
+					Name string `json:"name"`
+				} `json:"ports"`
+			} `json:"containers"`
+		} `json:"spec"`
+		Status struct {
+			Phase             string               `json:"phase"`
+			PodIP             string               `json:"podIP"`
+			Conditions        []podConditionStatus `json:"conditions"`
+			ContainerStatuses []struct {
+				Name  string `json:"name"`
+				Ready bool   `json:"ready"`
+				State struct {
+					Waiting *struct {
+						Reason string `json:"reason"`
+					} `json:"waiting"`
+					Terminated *struct {
+						Reason string `json:"reason"`
+					} `json:"terminated"`
+				} `json:"state"`
+			} `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// EndpointPod is a pod selected by a Service and whether it receives traffic
+type EndpointPod struct {
+	Name string `json:"name"`
+	IP   string `json:"ip,omitempty"`
+	// InSlice reports whether an EndpointSlice of the Service lists the pod
+	InSlice bool `json:"in_slice"`
+	// Ready reports whether the pod receives traffic through the Service
+	Ready       bool `json:"ready"`
+	Terminating bool `json:"terminating,omitempty"`
+	// Reasons explain why a pod does not receive traffic
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// SelectorMiss is a pod differing from a Service's selector by a single label,
+// often a typo or a label changed in only one of them
+type SelectorMiss struct {
+	Pod      string `json:"pod"`
+	Label    string `json:"label"`
+	Expected string `json:"expected"`
+	// Actual is the pod's value of the label, empty when the pod lacks it
+	Actual string `json:"actual,omitempty"`
+}
+
+// EndpointsFinding is a reason a Service sends traffic to fewer pods than intended
+type EndpointsFinding struct {
+	Rule        string `json:"rule"`
+	Severity    string `json:"severity"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation"`
+}
+
+// EndpointsReport is the structured response of k8s_endpoints_check
+type EndpointsReport struct {
+	Service   string            `json:"service"`
+	Namespace string            `json:"namespace"`
+	Type      string            `json:"type,omitempty"`
+	Status    string            `json:"status"`
+	Selector  map[string]string `json:"selector,omitempty"`
+	// EndpointSlices are the names of the Service's EndpointSlices
+	EndpointSlices []string `json:"endpoint_slices"`
+	ReadyEndpoints int      `json:"ready_endpoints"`
+	// NotReadyEndpoints counts the endpoints listed but not receiving traffic
+	NotReadyEndpoints int                `json:"not_ready_endpoints"`
+	Pods              []EndpointPod      `json:"pods"`
+	SelectorMisses    []SelectorMiss     `json:"selector_misses,omitempty"`
+	Findings          []EndpointsFinding `json:"findings"`
+}
+
+// podConditionStatus is a condition of a pod's status
+type podConditionStatus struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// podCondition returns the status of a pod condition, with its reason and message
+func podCondition(conditions []podConditionStatus, conditionType string) (status, detail string) {
+	for _, condition := range conditions {
+		if condition.Type != conditionType {
+			continue
+		}
+		detail = condition.Reason
+		if condition.Message != "" {
+			detail = strings.TrimSpace(detail + ": " + condition.Message)
+		}
+		return condition.Status, detail
+	}
+	return "", ""
+}
+
+// selectorMiss returns the single label by which a pod misses a selector, if
+// it misses by exactly one. Pods lacking the only label of a selector are
+// unrelated rather than near misses.
+func selectorMiss(selector, labels map[string]string) (label string, ok bool) {
+	misses := 0
+	for key, value := range selector {
+		if labels[key] != value {
+			misses++
+			label = key
+		}
+	}
+	if misses != 1 {
+		return "", false
+	}
+	_, hasLabel := labels[label]
+	return label, len(selector) > 1 || hasLabel
+}
+
+// buildEndpointsReport compares the pods a Service selects with the endpoints
+// its EndpointSlices list and explains each pod that does not receive traffic
+func buildEndpointsReport(namespace string, service endpointsService, slices endpointSliceList, pods endpointsPodList) *EndpointsReport {
+	report := &EndpointsReport{
+		Service:        service.Metadata.Name,
+		Namespace:      namespace,
+		Type:           service.Spec.Type,
+		Selector:       service.Spec.Selector,
+		EndpointSlices: []string{},
+		Pods:           []EndpointPod{},
+		Findings:       []EndpointsFinding{},
+	}
+	add := func(rule, severity, message, remediation string) {
+		report.Findings = append(report.Findings, EndpointsFinding{Rule: rule, Severity: severity, Message: message, Remediation: remediation})
+	}
+
+	// Endpoints of the slices by pod name. An endpoint is ready when its ready
+	// condition is unset or true, as nil means unknown and is treated as ready.
+	type sliceEndpoint struct{ ready, terminating bool }
+	listed := map[string]sliceEndpoint{}
+	slicePorts := map[string]bool{}
+	for _, slice := range slices.Items {
+		report.EndpointSlices = append(report.EndpointSlices, slice.Metadata.Name)
+		for _, port := range slice.Ports {
+			slicePorts[port.Name] = true
+		}
+		for _, endpoint := range slice.Endpoints {
+			ready := endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready
+			terminating := endpoint.Conditions.Terminating != nil && *endpoint.Conditions.Terminating
+			if ready {
+				report.ReadyEndpoints++
+			} else {
+				report.NotReadyEndpoints++
+			}
+			if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+				listed[endpoint.TargetRef.Name] = sliceEndpoint{ready: ready, terminating: terminating}
+			}
+		}
+	}
+	sort.Strings(report.EndpointSlices)
+
+	if len(service.Spec.Selector) == 0 {
+		report.Status = EndpointsUnmanaged
+		if report.ReadyEndpoints == 0 {
+			report.Status = EndpointsUnavailable
+			add("no_endpoints", severityHigh, "Service has no selector and no ready endpoints, so connections to it fail",
+				"Create an EndpointSlice labeled "+serviceNameLabel+"="+service.Metadata.Name+" listing the backend addresses, or add a selector")
+		}
+		return report
+	}
+
+	// Named target ports resolve per pod, so pods lacking the name get no endpoint for that port
+	var namedPorts []string
+	for _, port := range service.Spec.Ports {
+		var name string
+		if json.Unmarshal(port.TargetPort, &name) == nil && name != "" {
+			namedPorts = append(namedPorts, name)
+		}
+	}
+
+	selected, excluded := 0, 0
+	for _, pod := range pods.Items {
+		if !(labelSelector{MatchLabels: service.Spec.Selector}).matches(pod.Metadata.Labels) {
+			if label, ok := selectorMiss(service.Spec.Selector, pod.Metadata.Labels); ok && len(report.SelectorMisses) < maxSelectorMisses {
+				report.SelectorMisses = append(report.SelectorMisses, SelectorMiss{
+					Pod: pod.Metadata.Name, Label: label, Expected: service.Spec.Selector[label], Actual: pod.Metadata.Labels[label],
+				})
+			}
+			continue
+		}
+		// Completed pods never serve traffic and are not endpoints
+		if pod.Status.Phase == "Succeeded" || pod.Status.Phase == "Failed" {
+			continue
+		}
+		selected++
+
+		entry, inSlice := listed[pod.Metadata.Name]
+		check := EndpointPod{
+			Name:        pod.Metadata.Name,
+			IP:          pod.Status.PodIP,
+			InSlice:     inSlice,
+			Ready:       inSlice && entry.ready,
+			Terminating: pod.Metadata.DeletionTimestamp != "" || entry.terminating,
+		}
+		if check.Terminating {
+			check.Reasons = append(check.Reasons, "pod is terminating, so it is removed from the ready endpoints")
+		}
+		if pod.Status.Phase != "Running" {
+			check.Reasons = append(check.Reasons, fmt.Sprintf("pod is %s", pod.Status.Phase))
+		}
+		if pod.Status.PodIP == "" {
+			check.Reasons = append(check.Reasons, "pod has no IP yet")
+		}
+		for _, gate := range pod.Spec.ReadinessGates {
+			if status, detail := podCondition(pod.Status.Conditions, gate.ConditionType); status != "True" {
+				reason := fmt.Sprintf("readiness gate %s is not True", gate.ConditionType)
+				if status == "" {
+					reason = fmt.Sprintf("readiness gate %s has not been reported by its controller", gate.ConditionType)
+				}
+				if detail != "" {
+					reason += " (" + detail + ")"
+				}
+				check.Reasons = append(check.Reasons, reason)
+			}
+		}
+		probes := map[string]bool{}
+		for _, container := range pod.Spec.Containers {
+			probes[container.Name] = len(container.ReadinessProbe) > 0 && string(container.ReadinessProbe) != "null"
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			switch {
+			case status.Ready:
+			case status.State.Waiting != nil:
+				check.Reasons = append(check.Reasons, fmt.Sprintf("container %s is waiting: %s", status.Name, status.State.Waiting.Reason))
+			case status.State.Terminated != nil:
+				check.Reasons = append(check.Reasons, fmt.Sprintf("container %s terminated: %s", status.Name, status.State.Terminated.Reason))
+			case probes[status.Name]:
+				check.Reasons = append(check.Reasons, fmt.Sprintf("container %s is failing its readiness probe", status.Name))
+			default:
+				check.Reasons = append(check.Reasons, fmt.Sprintf("container %s is not ready", status.Name))
+			}
+		}
+		for _, name := range namedPorts {
+			found := false
+			for _, container := range pod.Spec.Containers {
+				for _, port := range container.Ports {
+					found = found || port.Name == name
+				}
+			}
+			if !found {
+				check.Reasons = append(check.Reasons, fmt.Sprintf("no container declares the port %s the Service targets", name))
+			}
+		}
+		if ready, detail := podCondition(pod.Status.Conditions, "Ready"); ready != "True" && len(check.Reasons) == 0 && detail != "" {
+			check.Reasons = append(check.Reasons, "pod is not ready: "+detail)
+		}
+		if !inSlice && len(check.Reasons) == 0 {
+			check.Reasons = append(check.Reasons, "pod is ready but not listed in any EndpointSlice yet; the EndpointSlice controller may be lagging")
+		}
+		if service.Spec.PublishNotReadyAddresses && inSlice {
+			check.Ready = true
+		}
+		if !check.Ready {
+			excluded++
+		}
+		report.Pods = append(report.Pods, check)
+	}
+	sort.SliceStable(report.Pods, func(i, j int) bool {
+		if report.Pods[i].Ready != report.Pods[j].Ready {
+			return !report.Pods[i].Ready
+		}
+		return report.Pods[i].Name < report.Pods[j].Name
+	})
+
+	selector := formatSelector(service.Spec.Selector)
+	switch {
+	case selected == 0 && len(report.SelectorMisses) > 0:
+		miss := report.SelectorMisses[0]
+		add("selector_mismatch", severityHigh, fmt.Sprintf("Selector %s matches no pods, but %d pods differ by a single label, such as pod %s with %s=%q instead of %q", selector, len(report.SelectorMisses), miss.Pod, miss.Label, miss.Actual, miss.Expected),
+			"Fix the label in the Service selector or in the pod template so that they agree")
+	case selected == 0:
+		add("no_pods", severityHigh, fmt.Sprintf("Selector %s matches no pods in namespace %s", selector, namespace),
+			"Check that the workload is running in this namespace and that its pod template labels match the selector")
+	case excluded == selected:
+		add("no_ready_pods", severityHigh, fmt.Sprintf("None of the %d selected pods is ready, so the Service has no endpoints to send traffic to", selected),
+			"Fix the reasons listed for each pod, starting with failing readiness probes and unreported readiness gates")
+	case excluded > 0:
+		add("excluded_pods", severityMedium, fmt.Sprintf("%d of %d selected pods are excluded from the Service's endpoints", excluded, selected),
+			"Fix the reasons listed for each excluded pod; the remaining pods carry all the traffic meanwhile")
+	}
+	if len(namedPorts) > 0 && selected > 0 && len(slices.Items) > 0 && len(slicePorts) == 0 {
+		add("unresolved_target_port", severityHigh, fmt.Sprintf("EndpointSlices list no ports, so the named target ports %s resolve in none of the pods", strings.Join(namedPorts, ", ")),
+			"Name the container ports as the Service's targetPort, or target the port number")
+	}
+
+	switch {
+	case report.ReadyEndpoints == 0:
+		report.Status = EndpointsUnavailable
+	case excluded > 0 || report.NotReadyEndpoints > 0:
+		report.Status = EndpointsDegraded
+	default:
+		report.Status = EndpointsHealthy
+	}
+	rank := map[string]int{severityHigh: 0, severityMedium: 1, severityLow: 2}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return rank[report.Findings[i].Severity] < rank[report.Findings[j].Severity]
+	})
+	return report
+}
+
+// formatSelector renders a label selector as kubectl accepts it, in key order
+func formatSelector(selector map[string]string) string {
+	pairs := make([]string, 0, len(selector))
+	for key, value := range selector {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Endpoints check
+func (k *K8sTool) handleEndpointsCheck(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("service", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output, err := k.runKubectlCommandString(ctx, "get", "service", name, "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get service %s: %v", name, err)), nil
+	}
+	var service endpointsService
+	if err := json.Unmarshal([]byte(output), &service); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse service %s: %v", name, err)), nil
+	}
+
+	output, err = k.runKubectlCommandString(ctx, "get", "endpointslices", "-n", namespace, "-l", serviceNameLabel+"="+name, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list EndpointSlices: %v", err)), nil
+	}
+	var slices endpointSliceList
+	if err := json.Unmarshal([]byte(output), &slices); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse EndpointSlices: %v", err)), nil
+	}
+
+	var pods endpointsPodList
+	if len(service.Spec.Selector) > 0 {
+		// All pods are listed, not just the selected ones, to find those missing the selector by one label
+		output, err = k.runKubectlCommandString(ctx, "get", "pods", "-n", namespace, "-o", "json")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list pods: %v", err)), nil
+		}
+		if err := json.Unmarshal([]byte(output), &pods); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to parse pods: %v", err)), nil
+		}
+	}
+
+	report := buildEndpointsReport(namespace, service, slices, pods)
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling endpoints report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
@@ -0,0 +1,125 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const testEndpointsService = `{"metadata":{"name":"web"},"spec":{"type":"ClusterIP","selector":{"app":"web","tier":"frontend"},
+ "ports":[{"name":"http","port":80,"protocol":"TCP","targetPort":"http"}]}}`
+
+const testEndpointSlices = `{"items":[{"metadata":{"name":"web-abcde"},
+ "ports":[{"name":"http","port":8080}],
+ "endpoints":[
+  {"addresses":["10.0.0.1"],"conditions":{"ready":true,"serving":true,"terminating":false},"targetRef":{"kind":"Pod","name":"web-1"}},
+  {"addresses":["10.0.0.2"],"conditions":{"ready":false,"serving":false,"terminating":false},"targetRef":{"kind":"Pod","name":"web-2"}},
+  {"addresses":["10.0.0.3"],"conditions":{"ready":false,"serving":true,"terminating":true},"targetRef":{"kind":"Pod","name":"web-3"}}]}]}`
+
+const testEndpointsPods = `{"items":[
+ {"metadata":{"name":"web-1","labels":{"app":"web","tier":"frontend"}},
+  "spec":{"containers":[{"name":"app","ports":[{"name":"http"}]}]},
+  "status":{"phase":"Running","podIP":"10.0.0.1","conditions":[{"type":"Ready","status":"True"}],"containerStatuses":[{"name":"app","ready":true,"state":{"running":{}}}]}},
+ {"metadata":{"name":"web-2","labels":{"app":"web","tier":"frontend"}},
+  "spec":{"readinessGates":[{"conditionType":"target-health.elbv2.k8s.aws/web"}],
+   "containers":[{"name":"app","readinessProbe":{"httpGet":{"path":"/ready"}},"ports":[{"name":"http"}]}]},
+  "status":{"phase":"Running","podIP":"10.0.0.2","conditions":[{"type":"Ready","status":"False","reason":"ContainersNotReady"}],"containerStatuses":[{"name":"app","ready":false,"state":{"running":{}}}]}},
+ {"metadata":{"name":"web-3","labels":{"app":"web","tier":"frontend"},"deletionTimestamp":"2025-01-01T00:00:00Z"},
+  "spec":{"containers":[{"name":"app","ports":[{"name":"http"}]}]},
+  "status":{"phase":"Running","podIP":"10.0.0.3","containerStatuses":[{"name":"app","ready":true,"state":{"running":{}}}]}},
+ {"metadata":{"name":"web-canary","labels":{"app":"web","tier":"front-end"}},
+  "spec":{"containers":[{"name":"app"}]},
+  "status":{"phase":"Running","podIP":"10.0.0.4"}},
+ {"metadata":{"name":"db-0","labels":{"app":"db"}},"spec":{"containers":[{"name":"db"}]},"status":{"phase":"Running"}}]}`
+
+func parseEndpointsFixtures(t *testing.T, serviceJSON, slicesJSON, podsJSON string) (endpointsService, endpointSliceList, endpointsPodList) {
+	var service endpointsService
+	var slices endpointSliceList
+	var pods endpointsPodList
+	require.NoError(t, json.Unmarshal([]byte(serviceJSON), &service))
+	require.NoError(t, json.Unmarshal([]byte(slicesJSON), &slices))
+	require.NoError(t, json.Unmarshal([]byte(podsJSON), &pods))
+	return service, slices, pods
+}
+
+func TestBuildEndpointsReport(t *testing.T) {
+	service, slices, pods := parseEndpointsFixtures(t, testEndpointsService, testEndpointSlices, testEndpointsPods)
+	report := buildEndpointsReport("prod", service, slices, pods)
+
+	assert.Equal(t, EndpointsDegraded, report.Status)
+	assert.Equal(t, []string{"web-abcde"}, report.EndpointSlices)
+	assert.Equal(t, 1, report.ReadyEndpoints)
+	assert.Equal(t, 2, report.NotReadyEndpoints)
+
+	require.Len(t, report.Pods, 3)
+	byName := map[string]EndpointPod{}
+	for _, pod := range report.Pods {
+		byName[pod.Name] = pod
+	}
+	assert.True(t, byName["web-1"].Ready)
+	assert.Empty(t, byName["web-1"].Reasons)
+	assert.False(t, byName["web-2"].Ready)
+	assert.Contains(t, byName["web-2"].Reasons, "readiness gate target-health.elbv2.k8s.aws/web has not been reported by its controller")
+	assert.Contains(t, byName["web-2"].Reasons, "container app is failing its readiness probe")
+	assert.True(t, byName["web-3"].Terminating)
+	assert.Equal(t, "web-2", report.Pods[0].Name, "excluded pods first")
+
+	require.Len(t, report.SelectorMisses, 1)
+	assert.Equal(t, SelectorMiss{Pod: "web-canary", Label: "tier", Expected: "frontend", Actual: "front-end"}, report.SelectorMisses[0])
+
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, "excluded_pods", report.Findings[0].Rule)
+}
+
+func TestBuildEndpointsReportSelectorMismatch(t *testing.T) {
+	service, slices, pods := parseEndpointsFixtures(t,
+		`{"metadata":{"name":"web"},"spec":{"selector":{"app":"web","tier":"frontend"},"ports":[{"port":80,"targetPort":8080}]}}`,
+		`{"items":[]}`,
+		`{"items":[{"metadata":{"name":"web-1","labels":{"app":"web","tier":"web"}},"status":{"phase":"Running"}}]}`)
+	report := buildEndpointsReport("prod", service, slices, pods)
+
+	assert.Equal(t, EndpointsUnavailable, report.Status)
+	require.NotEmpty(t, report.Findings)
+	assert.Equal(t, "selector_mismatch", report.Findings[0].Rule)
+	assert.Contains(t, report.Findings[0].Message, `tier="web" instead of "frontend"`)
+}
+
+func TestBuildEndpointsReportWithoutSelector(t *testing.T) {
+	service, slices, pods := parseEndpointsFixtures(t, `{"metadata":{"name":"external"},"spec":{"ports":[{"port":5432}]}}`,
+		`{"items":[{"metadata":{"name":"external-1"},"endpoints":[{"addresses":["192.168.1.10"]}]}]}`, `{"items":[]}`)
+	report := buildEndpointsReport("prod", service, slices, pods)
+
+	assert.Equal(t, EndpointsUnmanaged, report.Status)
+	assert.Equal(t, 1, report.ReadyEndpoints)
+	assert.Empty(t, report.Findings)
+}
+
+func TestHandleEndpointsCheck(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "service", "web", "-n", "prod", "-o", "json"}, testEndpointsService, nil)
+	mock.AddCommandString("kubectl", []string{"get", "endpointslices", "-n", "prod", "-l", "kubernetes.io/service-name=web", "-o", "json"}, testEndpointSlices, nil)
+	mock.AddCommandString("kubectl", []string{"get", "pods", "-n", "prod", "-o", "json"}, testEndpointsPods, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"service": "web", "namespace": "prod"}
+	result, err := newTestK8sTool().handleEndpointsCheck(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var report EndpointsReport
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+	assert.Equal(t, "web", report.Service)
+	assert.Equal(t, EndpointsDegraded, report.Status)
+
+	request.Params.Arguments = map[string]interface{}{}
+	result, err = newTestK8sTool().handleEndpointsCheck(ctx, request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
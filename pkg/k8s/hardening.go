@@ -0,0 +1,451 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Severities of hardening findings, from most to least severe
+const (
+	severityHigh   = "high"
+	severityMedium = "medium"
+	severityLow    = "low"
+)
+
+// nonRootUID is the user suggested for containers running as root, the nonroot user of distroless images
+const nonRootUID = 65532
+
+// Placeholder resources suggested for containers without requests or limits
+const (
+	defaultCPURequest    = "100m"
+	defaultMemoryRequest = "128Mi"
+)
+
+// dangerousCapabilities are Linux capabilities that allow escaping or controlling the node
+var dangerousCapabilities = []string{"ALL", "SYS_ADMIN", "NET_ADMIN", "SYS_PTRACE", "SYS_MODULE", "DAC_READ_SEARCH", "BPF"}
+
+// seccompProfile is a seccomp profile of a pod or container security context
+type seccompProfile struct {
+	Type string `json:"type"`
+}
+
+// confined reports whether the profile restricts syscalls
+func (p *seccompProfile) confined() bool {
+	return p != nil && (p.Type == "RuntimeDefault" || p.Type == "Localhost")
+}
+
+// hardeningContainer is the subset of a container spec evaluated by the hardening check
+type hardeningContainer struct {
+	Name            string `json:"name"`
+	Image           string `json:"image"`
+	SecurityContext struct {
+		Privileged               *bool  `json:"privileged"`
+		AllowPrivilegeEscalation *bool  `json:"allowPrivilegeEscalation"`
+		RunAsNonRoot             *bool  `json:"runAsNonRoot"`
+		RunAsUser                *int64 `json:"runAsUser"`
+		ReadOnlyRootFilesystem   *bool  `json:"readOnlyRootFilesystem"`
+		Capabilities             struct {
+			Add  []string `json:"add"`
+			Drop []string `json:"drop"`
+		} `json:"capabilities"`
+		SeccompProfile *seccompProfile `json:"seccompProfile"`
+	} `json:"securityContext"`
+	Resources struct {
+		Requests map[string]string `json:"requests"`
+		Limits   map[string]string `json:"limits"`
+	} `json:"resources"`
+	LivenessProbe  json.RawMessage `json:"livenessProbe"`
+	ReadinessProbe json.RawMessage `json:"readinessProbe"`
+}
+
+// hardeningPodSpec is the subset of a pod spec evaluated by the hardening check
+type hardeningPodSpec struct {
+	HostNetwork     bool `json:"hostNetwork"`
+	HostPID         bool `json:"hostPID"`
+	HostIPC         bool `json:"hostIPC"`
+	SecurityContext struct {
+		RunAsNonRoot   *bool           `json:"runAsNonRoot"`
+		RunAsUser      *int64          `json:"runAsUser"`
+		SeccompProfile *seccompProfile `json:"seccompProfile"`
+	} `json:"securityContext"`
+	Containers     []hardeningContainer `json:"containers"`
+	InitContainers []hardeningContainer `json:"initContainers"`
+}
+
+// HardeningFinding is a violated best practice of a workload
+type HardeningFinding struct {
+	Rule        string `json:"rule"`
+	Severity    string `json:"severity"`
+	Container   string `json:"container,omitempty"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation"`
+	// Patch is a strategic merge patch fixing only this finding, for k8s_patch_resource
+	Patch string `json:"patch,omitempty"`
+
+	// listKey is containers or initContainers for container findings
+	listKey      string
+	containerFix map[string]interface{}
+	podFix       map[string]interface{}
+}
+
+// HardeningSummary counts the findings by severity
+type HardeningSummary struct {
+	High   int `json:"high"`
+	Medium int `json:"medium"`
+	Low    int `json:"low"`
+}
+
+// PatchSuggestion holds the arguments of a k8s_patch_resource call
+type PatchSuggestion struct {
+	ResourceType string `json:"resource_type"`
+	ResourceName string `json:"resource_name"`
+	Namespace    string `json:"namespace"`
+	Patch        string `json:"patch"`
+}
+
+// HardeningReport is the structured response of k8s_hardening_check
+type HardeningReport struct {
+	Workload  string             `json:"workload"`
+	Namespace string             `json:"namespace"`
+	Summary   HardeningSummary   `json:"summary"`
+	Findings  []HardeningFinding `json:"findings"`
+	// SuggestedPatch fixes all patchable findings at once
+	SuggestedPatch *PatchSuggestion `json:"suggested_patch,omitempty"`
+	Note           string           `json:"note,omitempty"`
+}
+
+// imageUsesLatest reports whether an image is pinned neither by digest nor by a tag other than latest
+func imageUsesLatest(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	_, tag, found := strings.Cut(name, ":")
+	return !found || tag == "latest"
+}
+
+// checkContainer evaluates a container against the hardening ruleset
+func checkContainer(pod hardeningPodSpec, c hardeningContainer, listKey string, longRunning bool) []HardeningFinding {
+	var findings []HardeningFinding
+	add := func(rule, severity, message, remediation string, fix map[string]interface{}) {
+		findings = append(findings, HardeningFinding{
+			Rule: rule, Severity: severity, Container: c.Name, Message: message, Remediation: remediation,
+			listKey: listKey, containerFix: fix,
+		})
+	}
+	securityContext := func(fields map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"securityContext": fields}
+	}
+	sc := c.SecurityContext
+
+	if sc.Privileged != nil && *sc.Privileged {
+		add("privileged", severityHigh, "Container runs privileged, with full access to the node's devices and kernel",
+			"Set securityContext.privileged to false and grant only the capabilities the container needs",
+			securityContext(map[string]interface{}{"privileged": false}))
+	}
+	if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+		add("allow_privilege_escalation", severityMedium, "Processes can gain more privileges than their parent, e.g. through setuid binaries",
+			"Set securityContext.allowPrivilegeEscalation to false",
+			securityContext(map[string]interface{}{"allowPrivilegeEscalation": false}))
+	}
+
+	runAsUser := pod.SecurityContext.RunAsUser
+	if sc.RunAsUser != nil {
+		runAsUser = sc.RunAsUser
+	}
+	runAsNonRoot := pod.SecurityContext.RunAsNonRoot
+	if sc.RunAsNonRoot != nil {
+		runAsNonRoot = sc.RunAsNonRoot
+	}
+	switch {
+	case runAsUser != nil && *runAsUser == 0:
+		add("run_as_non_root", severityHigh, "Container is configured to run as root (UID 0)",
+			fmt.Sprintf("Run as an unprivileged user; the patch uses UID %d, which the image must support", nonRootUID),
+			securityContext(map[string]interface{}{"runAsNonRoot": true, "runAsUser": nonRootUID}))
+	case runAsNonRoot == nil || !*runAsNonRoot:
+		add("run_as_non_root", severityMedium, "Container may run as root, as runAsNonRoot is not set",
+			"Set securityContext.runAsNonRoot to true so the kubelet refuses to start it as root; the image must declare a non-root USER or runAsUser must be set",
+			securityContext(map[string]interface{}{"runAsNonRoot": true}))
+	}
+
+	if sc.ReadOnlyRootFilesystem == nil || !*sc.ReadOnlyRootFilesystem {
+		add("read_only_root_filesystem", severityLow, "Root filesystem is writable",
+			"Set securityContext.readOnlyRootFilesystem to true and mount emptyDir volumes for paths the application writes to",
+			securityContext(map[string]interface{}{"readOnlyRootFilesystem": true}))
+	}
+
+	var dangerous, kept []string
+	for _, capability := range sc.Capabilities.Add {
+		if containsString(dangerousCapabilities, strings.TrimPrefix(strings.ToUpper(capability), "CAP_")) {
+			dangerous = append(dangerous, capability)
+		} else {
+			kept = append(kept, capability)
+		}
+	}
+	if len(dangerous) > 0 {
+		// A null add list removes it, as lists of capabilities are replaced rather than merged
+		var remaining interface{}
+		if len(kept) > 0 {
+			remaining = kept
+		}
+		add("dangerous_capabilities", severityHigh, "Container adds capabilities that allow controlling the node: "+strings.Join(dangerous, ", "),
+			"Remove these capabilities from securityContext.capabilities.add",
+			securityContext(map[string]interface{}{"capabilities": map[string]interface{}{"add": remaining}}))
+	}
+	if !containsString(sc.Capabilities.Drop, "ALL") {
+		add("drop_capabilities", severityLow, "Container keeps the runtime's default capabilities",
+			"Drop all capabilities and add back only those the container needs",
+			securityContext(map[string]interface{}{"capabilities": map[string]interface{}{"drop": []string{"ALL"}}}))
+	}
+	if sc.SeccompProfile != nil && !sc.SeccompProfile.confined() {
+		add("seccomp_profile", severityMedium, fmt.Sprintf("Container seccomp profile is %s, so all syscalls are allowed", sc.SeccompProfile.Type),
+			"Use the RuntimeDefault seccomp profile",
+			securityContext(map[string]interface{}{"seccompProfile": map[string]interface{}{"type": "RuntimeDefault"}}))
+	}
+
+	if imageUsesLatest(c.Image) {
+		add("image_tag", severityMedium, fmt.Sprintf("Image %s is not pinned to a version, so restarts may run different code", c.Image),
+			"Reference the image by an immutable version tag or digest", nil)
+	}
+
+	requests := map[string]interface{}{}
+	if c.Resources.Requests["cpu"] == "" {
+		requests["cpu"] = defaultCPURequest
+	}
+	memoryRequest := c.Resources.Requests["memory"]
+	if memoryRequest == "" {
+		memoryRequest = defaultMemoryRequest
+		requests["memory"] = memoryRequest
+	}
+	if len(requests) > 0 {
+		add("resource_requests", severityMedium, "Container has no CPU or memory requests, so it is scheduled without reserved capacity and evicted first",
+			"Set resources.requests from observed usage; the patch uses placeholder values",
+			map[string]interface{}{"resources": map[string]interface{}{"requests": requests}})
+	}
+	if c.Resources.Limits["memory"] == "" {
+		add("memory_limit", severityMedium, "Container has no memory limit and can exhaust the node's memory",
+			"Set resources.limits.memory from observed usage; the patch uses the memory request",
+			map[string]interface{}{"resources": map[string]interface{}{"limits": map[string]interface{}{"memory": memoryRequest}}})
+	}
+
+	if longRunning {
+		if len(c.ReadinessProbe) == 0 || string(c.ReadinessProbe) == "null" {
+			add("readiness_probe", severityMedium, "Container has no readiness probe, so it receives traffic before it is ready",
+				"Add a readinessProbe checking the application's health endpoint or port", nil)
+		}
+		if len(c.LivenessProbe) == 0 || string(c.LivenessProbe) == "null" {
+			add("liveness_probe", severityLow, "Container has no liveness probe, so a hung process is never restarted",
+				"Add a livenessProbe checking the application's health endpoint", nil)
+		}
+	}
+	return findings
+}
+
+// checkPodSpec evaluates a pod spec against the hardening ruleset
+func checkPodSpec(pod hardeningPodSpec, longRunning bool) []HardeningFinding {
+	var findings []HardeningFinding
+	addPod := func(rule, severity, message, remediation string, fix map[string]interface{}) {
+		findings = append(findings, HardeningFinding{Rule: rule, Severity: severity, Message: message, Remediation: remediation, podFix: fix})
+	}
+
+	for _, host := range []struct {
+		enabled bool
+		field   string
+		what    string
+	}{
+		{pod.HostNetwork, "hostNetwork", "the node's network stack"},
+		{pod.HostPID, "hostPID", "the node's processes"},
+		{pod.HostIPC, "hostIPC", "the node's IPC namespace"},
+	} {
+		if host.enabled {
+			addPod("host_namespaces", severityHigh, fmt.Sprintf("Pod shares %s (%s)", host.what, host.field),
+				fmt.Sprintf("Set %s to false", host.field), map[string]interface{}{host.field: false})
+		}
+	}
+
+	if !pod.SecurityContext.SeccompProfile.confined() {
+		unconfined := false
+		for _, c := range append(append([]hardeningContainer{}, pod.InitContainers...), pod.Containers...) {
+			if c.SecurityContext.SeccompProfile == nil {
+				unconfined = true
+			}
+		}
+		if unconfined {
+			addPod("seccomp_profile", severityLow, "Pod has no seccomp profile, so containers may run unconfined",
+				"Set the pod's securityContext.seccompProfile.type to RuntimeDefault",
+				map[string]interface{}{"securityContext": map[string]interface{}{"seccompProfile": map[string]interface{}{"type": "RuntimeDefault"}}})
+		}
+	}
+
+	for _, c := range pod.InitContainers {
+		findings = append(findings, checkContainer(pod, c, "initContainers", false)...)
+	}
+	for _, c := range pod.Containers {
+		findings = append(findings, checkContainer(pod, c, "containers", longRunning)...)
+	}
+
+	rank := map[string]int{severityHigh: 0, severityMedium: 1, severityLow: 2}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return rank[findings[i].Severity] < rank[findings[j].Severity]
+	})
+	return findings
+}
+
+// mergePatch deep merges src into dst
+func mergePatch(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if srcMap, ok := value.(map[string]interface{}); ok {
+			if dstMap, ok := dst[key].(map[string]interface{}); ok {
+				mergePatch(dstMap, srcMap)
+				continue
+			}
+			copied := map[string]interface{}{}
+			mergePatch(copied, srcMap)
+			value = copied
+		}
+		dst[key] = value
+	}
+}
+
+// podSpecPatch builds a strategic merge patch of a pod spec; containers are merged by name
+type podSpecPatch struct {
+	spec map[string]interface{}
+}
+
+// add merges the fix of a finding into the patch
+func (p *podSpecPatch) add(finding HardeningFinding) {
+	if p.spec == nil {
+		p.spec = map[string]interface{}{}
+	}
+	if finding.podFix != nil {
+		mergePatch(p.spec, finding.podFix)
+	}
+	if finding.containerFix == nil {
+		return
+	}
+	containers, _ := p.spec[finding.listKey].([]map[string]interface{})
+	for _, container := range containers {
+		if container["name"] == finding.Container {
+			mergePatch(container, finding.containerFix)
+			return
+		}
+	}
+	container := map[string]interface{}{"name": finding.Container}
+	mergePatch(container, finding.containerFix)
+	p.spec[finding.listKey] = append(containers, container)
+}
+
+// render returns the patch for a workload of the given kind
+func (p *podSpecPatch) render(kind string) (string, error) {
+	patch := map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": p.spec}}}
+	if kind == "pod" {
+		patch = map[string]interface{}{"spec": p.spec}
+	}
+	patchJSON, err := json.Marshal(patch)
+	return string(patchJSON), err
+}
+
+// buildHardeningReport evaluates a workload and attaches patches for the fixable findings
+func buildHardeningReport(kind, name, namespace string, pod hardeningPodSpec) (*HardeningReport, error) {
+	report := &HardeningReport{
+		Workload:  kind + "/" + name,
+		Namespace: namespace,
+		Findings:  checkPodSpec(pod, kind != "job"),
+	}
+	if report.Findings == nil {
+		report.Findings = []HardeningFinding{}
+	}
+
+	var combined podSpecPatch
+	for i := range report.Findings {
+		finding := &report.Findings[i]
+		switch finding.Severity {
+		case severityHigh:
+			report.Summary.High++
+		case severityMedium:
+			report.Summary.Medium++
+		default:
+			report.Summary.Low++
+		}
+		if finding.podFix == nil && finding.containerFix == nil {
+			continue
+		}
+		combined.add(*finding)
+		if kind == "pod" {
+			continue
+		}
+		var single podSpecPatch
+		single.add(*finding)
+		patch, err := single.render(kind)
+		if err != nil {
+			return nil, err
+		}
+		finding.Patch = patch
+	}
+
+	if combined.spec == nil {
+		return report, nil
+	}
+	if kind == "pod" {
+		report.Note = "The security settings of a running pod cannot be patched; apply the fixes to the controller that creates it or recreate the pod"
+		return report, nil
+	}
+	patch, err := combined.render(kind)
+	if err != nil {
+		return nil, err
+	}
+	report.SuggestedPatch = &PatchSuggestion{ResourceType: kind, ResourceName: name, Namespace: namespace, Patch: patch}
+	return report, nil
+}
+
+// Pod spec hardening check
+func (k *K8sTool) handleHardeningCheck(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	workload := p.String("workload", "", params.Required())
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	kind, name, err := parseWorkloadRef(workload)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	output, err := k.runKubectlCommandString(ctx, "get", kind, name, "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get %s/%s: %v", kind, name, err)), nil
+	}
+	var object struct {
+		Spec struct {
+			hardeningPodSpec
+			Template struct {
+				Spec hardeningPodSpec `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal([]byte(output), &object); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse %s/%s: %v", kind, name, err)), nil
+	}
+	pod := object.Spec.Template.Spec
+	if kind == "pod" {
+		pod = object.Spec.hardeningPodSpec
+	}
+
+	report, err := buildHardeningReport(kind, name, namespace, pod)
+	if err != nil {
+		return mcp.NewToolResultError("Error building hardening patch: " + err.Error()), nil
+	}
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling hardening report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
@@ -0,0 +1,134 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInsecureDeployment = `{"spec":{"template":{"spec":{
+ "hostNetwork":true,
+ "containers":[{"name":"app","image":"nginx",
+  "securityContext":{"privileged":true,"runAsUser":0,"capabilities":{"add":["SYS_ADMIN","NET_BIND_SERVICE"]}},
+  "resources":{"requests":{"memory":"64Mi"}}}]}}}}`
+
+const testHardenedDeployment = `{"spec":{"template":{"spec":{
+ "securityContext":{"runAsNonRoot":true,"seccompProfile":{"type":"RuntimeDefault"}},
+ "containers":[{"name":"app","image":"registry.example.com:5000/app:1.4.2",
+  "securityContext":{"allowPrivilegeEscalation":false,"readOnlyRootFilesystem":true,"capabilities":{"drop":["ALL"]}},
+  "resources":{"requests":{"cpu":"50m","memory":"64Mi"},"limits":{"memory":"128Mi"}},
+  "readinessProbe":{"httpGet":{"path":"/ready","port":8080}},"livenessProbe":{"httpGet":{"path":"/healthz","port":8080}}}]}}}}`
+
+func TestImageUsesLatest(t *testing.T) {
+	assert.True(t, imageUsesLatest("nginx"))
+	assert.True(t, imageUsesLatest("nginx:latest"))
+	assert.True(t, imageUsesLatest("registry.example.com:5000/app"))
+	assert.False(t, imageUsesLatest("registry.example.com:5000/app:1.4.2"))
+	assert.False(t, imageUsesLatest("nginx@sha256:0123abcd"))
+}
+
+func TestHandleHardeningCheck(t *testing.T) {
+	check := func(t *testing.T, kind, output string) HardeningReport {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", kind, "web", "-n", "shop", "-o", "json"}, output, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleHardeningCheck(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"workload": kind + "/web", "namespace": "shop",
+		}}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report HardeningReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		return report
+	}
+	rules := func(report HardeningReport) map[string]HardeningFinding {
+		byRule := map[string]HardeningFinding{}
+		for _, finding := range report.Findings {
+			byRule[finding.Rule] = finding
+		}
+		return byRule
+	}
+
+	t.Run("insecure deployment", func(t *testing.T) {
+		report := check(t, "deployment", testInsecureDeployment)
+		findings := rules(report)
+		for _, rule := range []string{"privileged", "host_namespaces", "run_as_non_root", "dangerous_capabilities", "image_tag", "resource_requests", "memory_limit", "readiness_probe", "liveness_probe"} {
+			assert.Contains(t, findings, rule)
+		}
+		assert.Equal(t, severityHigh, report.Findings[0].Severity)
+		assert.Equal(t, 4, report.Summary.High)
+		assert.Equal(t, `{"spec":{"template":{"spec":{"hostNetwork":false}}}}`, findings["host_namespaces"].Patch)
+		assert.Empty(t, findings["image_tag"].Patch)
+
+		require.NotNil(t, report.SuggestedPatch)
+		assert.Equal(t, "deployment", report.SuggestedPatch.ResourceType)
+		assert.Equal(t, "web", report.SuggestedPatch.ResourceName)
+		assert.Equal(t, "shop", report.SuggestedPatch.Namespace)
+
+		var patch struct {
+			Spec struct {
+				Template struct {
+					Spec struct {
+						HostNetwork     *bool                    `json:"hostNetwork"`
+						SecurityContext map[string]interface{}   `json:"securityContext"`
+						Containers      []map[string]interface{} `json:"containers"`
+					} `json:"spec"`
+				} `json:"template"`
+			} `json:"spec"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(report.SuggestedPatch.Patch), &patch))
+		spec := patch.Spec.Template.Spec
+		assert.False(t, *spec.HostNetwork)
+		assert.Equal(t, map[string]interface{}{"type": "RuntimeDefault"}, spec.SecurityContext["seccompProfile"])
+		require.Len(t, spec.Containers, 1)
+		container := spec.Containers[0]
+		assert.Equal(t, "app", container["name"])
+		assert.Equal(t, map[string]interface{}{
+			"privileged":               false,
+			"allowPrivilegeEscalation": false,
+			"runAsNonRoot":             true,
+			"runAsUser":                float64(nonRootUID),
+			"readOnlyRootFilesystem":   true,
+			"capabilities":             map[string]interface{}{"add": []interface{}{"NET_BIND_SERVICE"}, "drop": []interface{}{"ALL"}},
+		}, container["securityContext"])
+		assert.Equal(t, map[string]interface{}{
+			"requests": map[string]interface{}{"cpu": defaultCPURequest},
+			"limits":   map[string]interface{}{"memory": "64Mi"},
+		}, container["resources"])
+	})
+
+	t.Run("hardened deployment", func(t *testing.T) {
+		report := check(t, "deployment", testHardenedDeployment)
+		assert.Empty(t, report.Findings)
+		assert.Nil(t, report.SuggestedPatch)
+	})
+
+	t.Run("job skips probes", func(t *testing.T) {
+		findings := rules(check(t, "job", testInsecureDeployment))
+		assert.NotContains(t, findings, "readiness_probe")
+		assert.NotContains(t, findings, "liveness_probe")
+	})
+
+	t.Run("pod has no patches", func(t *testing.T) {
+		report := check(t, "pod", `{"spec":{"containers":[{"name":"app","image":"nginx:1.27"}]}}`)
+		assert.NotEmpty(t, report.Findings)
+		assert.Nil(t, report.SuggestedPatch)
+		assert.Empty(t, report.Findings[0].Patch)
+		assert.NotEmpty(t, report.Note)
+	})
+
+	t.Run("invalid workload", func(t *testing.T) {
+		result, err := newTestK8sTool().handleHardeningCheck(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"workload": "cronjob/web",
+		}}})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
@@ -0,0 +1,770 @@
+package k8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Registry defaults of k8s_image_inspect
+const (
+	defaultImagePlatform = "linux/amd64"
+	dockerHubRegistry    = "docker.io"
+	dockerHubAPIHost     = "registry-1.docker.io"
+	// maxRegistryResponse bounds the manifests and image configs read from a registry
+	maxRegistryResponse = 4 << 20
+)
+
+// Media types of the manifests k8s_image_inspect resolves
+const (
+	mediaTypeOCIIndex          = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerList        = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest       = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifest    = "application/vnd.docker.distribution.manifest.v2+json"
+	registryDigestHeader       = "Docker-Content-Digest"
+	registryAuthenticateHeader = "WWW-Authenticate"
+)
+
+// Causes of a failed image resolution, matching the reasons kubelet fails to pull
+const (
+	ImageErrorUnauthorized = "unauthorized"
+	ImageErrorNotFound     = "not_found"
+	ImageErrorUnreachable  = "unreachable"
+	ImageErrorPlatform     = "platform_mismatch"
+	ImageErrorInvalid      = "invalid"
+)
+
+// errNoPlatformImage is returned when an image index has no image for the platform
+var errNoPlatformImage = errors.New("no image for the platform")
+
+// registryClientKey is the context key for the registry http client
+type registryClientKey struct{}
+
+// registryHTTPClient returns the http client registry requests are sent with
+func registryHTTPClient(ctx context.Context) *http.Client {
+	if client, ok := ctx.Value(registryClientKey{}).(*http.Client); ok && client != nil {
+		return client
+	}
+	return http.DefaultClient
+}
+
+// imageRef is a parsed image reference
+type imageRef struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// parseImageRef parses an image reference the way the container runtime does,
+// defaulting to Docker Hub, the library namespace and the latest tag
+func parseImageRef(image string) (imageRef, error) {
+	var ref imageRef
+	name := strings.TrimSpace(image)
+	if name == "" {
+		return ref, errors.New("image reference is empty")
+	}
+	if before, digest, found := strings.Cut(name, "@"); found {
+		if !strings.HasPrefix(digest, "sha256:") {
+			return ref, fmt.Errorf("invalid digest %q in image reference %q", digest, image)
+		}
+		name, ref.Digest = before, digest
+	}
+	if slash := strings.LastIndex(name, "/"); strings.LastIndex(name, ":") > slash {
+		colon := strings.LastIndex(name, ":")
+		name, ref.Tag = name[:colon], name[colon+1:]
+	}
+
+	ref.Registry = dockerHubRegistry
+	if first, rest, found := strings.Cut(name, "/"); found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry, name = first, rest
+	}
+	if ref.Registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if name == "" || name != strings.ToLower(name) {
+		return ref, fmt.Errorf("invalid repository in image reference %q", image)
+	}
+	ref.Repository = name
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+// reference is the tag or digest manifests are requested by
+func (r imageRef) reference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// apiHost is the host serving the registry API
+func (r imageRef) apiHost() string {
+	if r.Registry == dockerHubRegistry {
+		return dockerHubAPIHost
+	}
+	return r.Registry
+}
+
+// String renders the fully qualified reference
+func (r imageRef) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// registryCredentials authenticate registry requests
+type registryCredentials struct {
+	Username string
+	Password string
+	// Secret is the pull secret the credentials were read from
+	Secret string
+}
+
+// dockerConfigAuth is a registry entry of a .dockerconfigjson pull secret
+type dockerConfigAuth struct {
+	Auth     string `json:"auth"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// registryHostKey normalizes a registry host or a docker config key such as
+// https://index.docker.io/v1/ for comparison
+func registryHostKey(host string) string {
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	switch host {
+	case "index.docker.io", dockerHubAPIHost:
+		return dockerHubRegistry
+	}
+	return strings.ToLower(host)
+}
+
+// pullSecret holds the registry credentials of an image pull secret by host
+type pullSecret struct {
+	Name  string
+	Auths map[string]dockerConfigAuth
+}
+
+// parsePullSecret reads the registry credentials of a kubernetes.io/dockerconfigjson
+// or kubernetes.io/dockercfg secret
+func parsePullSecret(output string) (*pullSecret, error) {
+	var secret struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Type string            `json:"type"`
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(output), &secret); err != nil {
+		return nil, fmt.Errorf("failed to parse secret: %w", err)
+	}
+
+	result := &pullSecret{Name: secret.Metadata.Name}
+	if encoded, ok := secret.Data[".dockerconfigjson"]; ok {
+		config, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode .dockerconfigjson of secret %s: %w", result.Name, err)
+		}
+		var dockerConfig struct {
+			Auths map[string]dockerConfigAuth `json:"auths"`
+		}
+		if err := json.Unmarshal(config, &dockerConfig); err != nil {
+			return nil, fmt.Errorf("failed to parse .dockerconfigjson of secret %s: %w", result.Name, err)
+		}
+		result.Auths = dockerConfig.Auths
+	} else if encoded, ok := secret.Data[".dockercfg"]; ok {
+		config, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode .dockercfg of secret %s: %w", result.Name, err)
+		}
+		if err := json.Unmarshal(config, &result.Auths); err != nil {
+			return nil, fmt.Errorf("failed to parse .dockercfg of secret %s: %w", result.Name, err)
+		}
+	} else {
+		return nil, fmt.Errorf("secret %s of type %s is not an image pull secret", result.Name, secret.Type)
+	}
+	return result, nil
+}
+
+// registries lists the registries the secret has credentials for
+func (s *pullSecret) registries() []string {
+	var hosts []string
+	for host := range s.Auths {
+		if key := registryHostKey(host); !containsString(hosts, key) {
+			hosts = append(hosts, key)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// credentials returns the secret's credentials for a registry, or nil when it
+// has none for it
+func (s *pullSecret) credentials(registry string) (*registryCredentials, error) {
+	want := registryHostKey(registry)
+	for host, auth := range s.Auths {
+		if registryHostKey(host) != want {
+			continue
+		}
+		creds := &registryCredentials{Username: auth.Username, Password: auth.Password, Secret: s.Name}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode auth of %s in secret %s: %w", host, s.Name, err)
+			}
+			creds.Username, creds.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		return creds, nil
+	}
+	return nil, nil
+}
+
+// registryError is a failed registry request
+type registryError struct {
+	Status  int
+	Message string
+}
+
+func (e *registryError) Error() string {
+	return fmt.Sprintf("registry returned HTTP %d: %s", e.Status, e.Message)
+}
+
+// registryErrorMessage extracts the error messages of a registry error response
+func registryErrorMessage(body []byte) string {
+	var response struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || len(response.Errors) == 0 {
+		return strings.TrimSpace(string(body))
+	}
+	var messages []string
+	for _, e := range response.Errors {
+		messages = append(messages, strings.TrimSpace(e.Code+" "+e.Message))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// authChallengePattern matches the parameters of a WWW-Authenticate challenge
+var authChallengePattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// registryClient sends registry API requests for one repository, answering
+// the registry's Bearer or Basic authentication challenge
+type registryClient struct {
+	client *http.Client
+	ref    imageRef
+	creds  *registryCredentials
+	// authorization is the Authorization header once a challenge was answered
+	authorization string
+}
+
+// get requests a registry API path, returning the response body and headers
+func (c *registryClient) get(ctx context.Context, path string, accept ...string) ([]byte, http.Header, error) {
+	body, header, status, err := c.do(ctx, path, accept)
+	if err != nil {
+		return nil, nil, err
+	}
+	if status == http.StatusUnauthorized && c.authorization == "" {
+		if err := c.authenticate(ctx, header.Get(registryAuthenticateHeader)); err != nil {
+			return nil, nil, err
+		}
+		body, header, status, err = c.do(ctx, path, accept)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if status != http.StatusOK {
+		return nil, nil, &registryError{Status: status, Message: registryErrorMessage(body)}
+	}
+	return body, header, nil
+}
+
+func (c *registryClient) do(ctx context.Context, path string, accept []string) ([]byte, http.Header, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+c.ref.apiHost()+"/v2/"+c.ref.Repository+path, nil)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRegistryResponse))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return body, resp.Header, resp.StatusCode, nil
+}
+
+// authenticate answers a WWW-Authenticate challenge, fetching a pull token
+// from the realm of a Bearer challenge
+func (c *registryClient) authenticate(ctx context.Context, challenge string) error {
+	scheme, _, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.creds == nil {
+			return &registryError{Status: http.StatusUnauthorized, Message: "the registry requires credentials"}
+		}
+		c.authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(c.creds.Username+":"+c.creds.Password))
+		return nil
+	case "bearer":
+	default:
+		return &registryError{Status: http.StatusUnauthorized, Message: fmt.Sprintf("unsupported authentication challenge %q", challenge)}
+	}
+
+	challengeParams := map[string]string{}
+	for _, match := range authChallengePattern.FindAllStringSubmatch(challenge, -1) {
+		challengeParams[match[1]] = match[2]
+	}
+	realm := challengeParams["realm"]
+	if realm == "" {
+		return &registryError{Status: http.StatusUnauthorized, Message: "authentication challenge has no realm"}
+	}
+	query := url.Values{}
+	if service := challengeParams["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", "repository:"+c.ref.Repository+":pull")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if c.creds != nil {
+		req.SetBasicAuth(c.creds.Username, c.creds.Password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRegistryResponse))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &registryError{Status: resp.StatusCode, Message: "token request failed: " + registryErrorMessage(body)}
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return fmt.Errorf("failed to parse registry token: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	c.authorization = "Bearer " + token.Token
+	return nil
+}
+
+// contentDigest is the digest the registry reported for a response, or the
+// digest of its body
+func contentDigest(body []byte, header http.Header) string {
+	if digest := header.Get(registryDigestHeader); digest != "" {
+		return digest
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// imageManifest is an image manifest or an image index
+type imageManifest struct {
+	MediaType string `json:"mediaType"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+			Variant      string `json:"variant"`
+		} `json:"platform"`
+	} `json:"manifests"`
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Layers []struct {
+		Size int64 `json:"size"`
+	} `json:"layers"`
+}
+
+// imageConfig is the subset of an image configuration blob that is reported
+type imageConfig struct {
+	Created      string `json:"created"`
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Config       struct {
+		User         string              `json:"User"`
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+		Entrypoint   []string            `json:"Entrypoint"`
+		Cmd          []string            `json:"Cmd"`
+		WorkingDir   string              `json:"WorkingDir"`
+		Labels       map[string]string   `json:"Labels"`
+	} `json:"config"`
+}
+
+// ImagePodStatus is the image a pod's container runs
+type ImagePodStatus struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	ImageID   string `json:"image_id,omitempty"`
+	// RunningDigest is the digest of the image the container runs
+	RunningDigest string `json:"running_digest,omitempty"`
+	// MatchesResolved is false when the tag has moved since the image was pulled
+	MatchesResolved *bool    `json:"matches_resolved,omitempty"`
+	WaitingReason   string   `json:"waiting_reason,omitempty"`
+	WaitingMessage  string   `json:"waiting_message,omitempty"`
+	PullSecrets     []string `json:"pull_secrets,omitempty"`
+}
+
+// ImageInspection is the structured response of k8s_image_inspect
+type ImageInspection struct {
+	Image      string `json:"image"`
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	// Digest is the digest the tag resolves to, of the image index for multi-platform images
+	Digest    string   `json:"digest,omitempty"`
+	MediaType string   `json:"media_type,omitempty"`
+	Platforms []string `json:"platforms,omitempty"`
+	// Platform and PlatformDigest are the image selected from an image index
+	Platform       string            `json:"platform,omitempty"`
+	PlatformDigest string            `json:"platform_digest,omitempty"`
+	CompressedSize int64             `json:"compressed_size,omitempty"`
+	Layers         int               `json:"layers,omitempty"`
+	Created        string            `json:"created,omitempty"`
+	User           string            `json:"user,omitempty"`
+	WorkingDir     string            `json:"working_dir,omitempty"`
+	Entrypoint     []string          `json:"entrypoint,omitempty"`
+	Cmd            []string          `json:"cmd,omitempty"`
+	ExposedPorts   []string          `json:"exposed_ports,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	// PullSecret is the pull secret whose credentials authenticated the requests
+	PullSecret string          `json:"pull_secret,omitempty"`
+	Pod        *ImagePodStatus `json:"pod,omitempty"`
+	// Error and ErrorReason explain why the image could not be resolved
+	Error       string `json:"error,omitempty"`
+	ErrorReason string `json:"error_reason,omitempty"`
+	Hint        string `json:"hint,omitempty"`
+}
+
+// fail records why the image could not be resolved, with a hint for fixing it
+func (i *ImageInspection) fail(err error) {
+	i.Error = err.Error()
+	var regErr *registryError
+	switch {
+	case errors.As(err, &regErr) && (regErr.Status == http.StatusUnauthorized || regErr.Status == http.StatusForbidden):
+		i.ErrorReason = ImageErrorUnauthorized
+		if i.PullSecret == "" {
+			i.Hint = "the registry denied anonymous access; pass the image pull secret with pull_secrets, or add it to the pod's imagePullSecrets or service account"
+		} else {
+			i.Hint = fmt.Sprintf("the registry rejected the credentials of pull secret %s; check that they are current and allowed to pull %s", i.PullSecret, i.Repository)
+		}
+	case errors.As(err, &regErr) && regErr.Status == http.StatusNotFound:
+		i.ErrorReason = ImageErrorNotFound
+		i.Hint = fmt.Sprintf("%s does not exist in %s; check the repository name and tag, and that private repositories are not hidden from unauthenticated requests", i.Image, i.Registry)
+	case errors.Is(err, errNoPlatformImage):
+		i.ErrorReason = ImageErrorPlatform
+		i.Hint = "nodes of this platform cannot run the image; build it for the platform or schedule the pods on nodes of an available platform"
+	case errors.As(err, &regErr):
+		i.ErrorReason = ImageErrorInvalid
+	default:
+		i.ErrorReason = ImageErrorUnreachable
+		i.Hint = fmt.Sprintf("the registry %s could not be reached; check its name, DNS and egress from the cluster", i.Registry)
+	}
+}
+
+// platformString renders an image index platform as os/architecture[/variant]
+func platformString(os, architecture, variant string) string {
+	platform := os + "/" + architecture
+	if variant != "" {
+		platform += "/" + variant
+	}
+	return platform
+}
+
+// inspectImage resolves an image reference and reads its configuration
+func inspectImage(ctx context.Context, client *registryClient, platform string, inspection *ImageInspection) error {
+	accept := []string{mediaTypeOCIIndex, mediaTypeDockerList, mediaTypeOCIManifest, mediaTypeDockerManifest}
+	body, header, err := client.get(ctx, "/manifests/"+client.ref.reference(), accept...)
+	if err != nil {
+		return err
+	}
+	inspection.Digest = contentDigest(body, header)
+
+	var manifest imageManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if manifest.MediaType == "" {
+		manifest.MediaType = header.Get("Content-Type")
+	}
+	inspection.MediaType = manifest.MediaType
+
+	if len(manifest.Manifests) > 0 {
+		selected := ""
+		for _, m := range manifest.Manifests {
+			p := m.Platform
+			// Attestation manifests are listed with an unknown platform
+			if p.OS == "unknown" {
+				continue
+			}
+			candidate := platformString(p.OS, p.Architecture, p.Variant)
+			inspection.Platforms = append(inspection.Platforms, candidate)
+			if selected == "" && (candidate == platform || platformString(p.OS, p.Architecture, "") == platform) {
+				selected, inspection.Platform = m.Digest, candidate
+			}
+		}
+		if selected == "" {
+			return fmt.Errorf("%w %s, available platforms: %s", errNoPlatformImage, platform, strings.Join(inspection.Platforms, ", "))
+		}
+		inspection.PlatformDigest = selected
+		body, _, err = client.get(ctx, "/manifests/"+selected, mediaTypeOCIManifest, mediaTypeDockerManifest)
+		if err != nil {
+			return err
+		}
+		manifest = imageManifest{}
+		if err := json.Unmarshal(body, &manifest); err != nil {
+			return fmt.Errorf("failed to parse manifest of platform %s: %w", platform, err)
+		}
+	}
+	if manifest.Config.Digest == "" {
+		return &registryError{Status: http.StatusNotAcceptable, Message: fmt.Sprintf("unsupported manifest media type %q", inspection.MediaType)}
+	}
+
+	inspection.Layers = len(manifest.Layers)
+	for _, layer := range manifest.Layers {
+		inspection.CompressedSize += layer.Size
+	}
+
+	body, _, err = client.get(ctx, "/blobs/"+manifest.Config.Digest)
+	if err != nil {
+		return err
+	}
+	var config imageConfig
+	if err := json.Unmarshal(body, &config); err != nil {
+		return fmt.Errorf("failed to parse image config: %w", err)
+	}
+	if inspection.Platform == "" {
+		inspection.Platform = platformString(config.OS, config.Architecture, "")
+	}
+	inspection.Created = config.Created
+	inspection.User = config.Config.User
+	inspection.WorkingDir = config.Config.WorkingDir
+	inspection.Entrypoint = config.Config.Entrypoint
+	inspection.Cmd = config.Config.Cmd
+	inspection.Labels = config.Config.Labels
+	for port := range config.Config.ExposedPorts {
+		inspection.ExposedPorts = append(inspection.ExposedPorts, port)
+	}
+	sort.Strings(inspection.ExposedPorts)
+	return nil
+}
+
+// resolveImage resolves an image through the registry API, recording why it
+// could not be resolved in the inspection
+func resolveImage(ctx context.Context, ref imageRef, creds *registryCredentials, platform string) ImageInspection {
+	inspection := ImageInspection{Image: ref.String(), Registry: ref.Registry, Repository: ref.Repository, Tag: ref.Tag}
+	if creds != nil {
+		inspection.PullSecret = creds.Secret
+	}
+	client := &registryClient{client: registryHTTPClient(ctx), ref: ref, creds: creds}
+	if err := inspectImage(ctx, client, platform, &inspection); err != nil {
+		inspection.fail(err)
+	}
+	return inspection
+}
+
+// imagePod is the subset of a pod used to find the image a container runs
+type imagePod struct {
+	Spec struct {
+		NodeName         string              `json:"nodeName"`
+		Containers       []imagePodContainer `json:"containers"`
+		InitContainers   []imagePodContainer `json:"initContainers"`
+		ImagePullSecrets []struct {
+			Name string `json:"name"`
+		} `json:"imagePullSecrets"`
+	} `json:"spec"`
+	Status struct {
+		ContainerStatuses     []imageContainerStatus `json:"containerStatuses"`
+		InitContainerStatuses []imageContainerStatus `json:"initContainerStatuses"`
+	} `json:"status"`
+}
+
+type imagePodContainer struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+type imageContainerStatus struct {
+	Name    string `json:"name"`
+	ImageID string `json:"imageID"`
+	State   struct {
+		Waiting *struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"waiting"`
+	} `json:"state"`
+}
+
+// podImage returns the image of a pod's container, defaulting to its only
+// container, and the container's status
+func podImage(output, pod, container string) (string, *ImagePodStatus, []string, error) {
+	var p imagePod
+	if err := json.Unmarshal([]byte(output), &p); err != nil {
+		return "", nil, nil, fmt.Errorf("failed to parse pod %s: %w", pod, err)
+	}
+	containers := append(p.Spec.Containers, p.Spec.InitContainers...)
+	if container == "" {
+		if len(p.Spec.Containers) != 1 {
+			var names []string
+			for _, c := range containers {
+				names = append(names, c.Name)
+			}
+			return "", nil, nil, fmt.Errorf("pod %s has containers %s; choose one with container", pod, strings.Join(names, ", "))
+		}
+		container = p.Spec.Containers[0].Name
+	}
+
+	image := ""
+	for _, c := range containers {
+		if c.Name == container {
+			image = c.Image
+		}
+	}
+	if image == "" {
+		return "", nil, nil, fmt.Errorf("pod %s has no container %s", pod, container)
+	}
+
+	status := &ImagePodStatus{Pod: pod, Container: container}
+	for _, s := range append(p.Status.ContainerStatuses, p.Status.InitContainerStatuses...) {
+		if s.Name != container {
+			continue
+		}
+		status.ImageID = s.ImageID
+		if _, digest, found := strings.Cut(s.ImageID, "@"); found {
+			status.RunningDigest = digest
+		}
+		if s.State.Waiting != nil {
+			status.WaitingReason = s.State.Waiting.Reason
+			status.WaitingMessage = s.State.Waiting.Message
+		}
+	}
+	var secrets []string
+	for _, secret := range p.Spec.ImagePullSecrets {
+		secrets = append(secrets, secret.Name)
+	}
+	status.PullSecrets = secrets
+	return image, status, secrets, nil
+}
+
+// Image inspection
+func (k *K8sTool) handleImageInspect(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	image := p.String("image", "")
+	pod := p.String("pod_name", "", params.Check(security.ValidateK8sResourceName))
+	container := p.String("container", "")
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	pullSecrets := p.String("pull_secrets", "")
+	platform := p.String("platform", defaultImagePlatform)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if (image == "") == (pod == "") {
+		return mcp.NewToolResultError("exactly one of image or pod_name is required"), nil
+	}
+
+	var secrets []string
+	for _, name := range strings.Split(pullSecrets, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			if err := security.ValidateK8sResourceName(name); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid pull secret %q: %v", name, err)), nil
+			}
+			secrets = append(secrets, name)
+		}
+	}
+
+	var podStatus *ImagePodStatus
+	if pod != "" {
+		output, err := k.runKubectlCommandString(ctx, "get", "pod", pod, "-n", namespace, "-o", "json")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get pod %s: %v", pod, err)), nil
+		}
+		var podSecrets []string
+		image, podStatus, podSecrets, err = podImage(output, pod, container)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		for _, name := range podSecrets {
+			if !containsString(secrets, name) {
+				secrets = append(secrets, name)
+			}
+		}
+	}
+
+	ref, err := parseImageRef(image)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	// The first pull secret with credentials for the registry is used, as kubelet does
+	var creds *registryCredentials
+	for _, name := range secrets {
+		output, err := k.runKubectlCommandString(ctx, "get", "secret", name, "-n", namespace, "-o", "json")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get pull secret %s: %v", name, err)), nil
+		}
+		secret, err := parsePullSecret(output)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if creds, err = secret.credentials(ref.Registry); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if creds != nil {
+			break
+		}
+	}
+
+	inspection := resolveImage(ctx, ref, creds, platform)
+	inspection.Pod = podStatus
+	if podStatus != nil && podStatus.RunningDigest != "" && inspection.Digest != "" {
+		matches := podStatus.RunningDigest == inspection.Digest || podStatus.RunningDigest == inspection.PlatformDigest
+		podStatus.MatchesResolved = &matches
+	}
+
+	inspectionJSON, err := json.MarshalIndent(inspection, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal image inspection: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(inspectionJSON)), nil
+}
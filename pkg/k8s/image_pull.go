@@ -0,0 +1,372 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Causes of image pull failures found by k8s_diagnose_image_pull
+const (
+	PullCauseInvalidReference    = "invalid_reference"
+	PullCauseNeverPull           = "never_pull"
+	PullCauseMissingPullSecret   = "missing_pull_secret"
+	PullCauseInvalidPullSecret   = "invalid_pull_secret"
+	PullCauseNoCredentials       = "no_credentials"
+	PullCauseRejectedCredentials = "rejected_credentials"
+	PullCauseNotFound            = "image_not_found"
+	PullCausePlatform            = "platform_mismatch"
+	PullCauseRateLimited         = "rate_limited"
+	PullCauseDNS                 = "dns_failure"
+	PullCauseTLS                 = "tls_error"
+	PullCauseUnreachable         = "registry_unreachable"
+	PullCauseTransient           = "transient"
+	PullCauseUnknown             = "unknown"
+
+	// pullUnauthorized is refined into a credentials cause by checking the pull secrets
+	pullUnauthorized = "unauthorized"
+)
+
+// Statuses of the pull secrets a pod references
+const (
+	PullSecretFound      = "found"
+	PullSecretMissing    = "missing"
+	PullSecretInvalid    = "invalid"
+	PullSecretUnreadable = "unreadable"
+)
+
+// imagePullReasons are the waiting reasons of containers whose image cannot be pulled
+var imagePullReasons = []string{"ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull", "RegistryUnavailable"}
+
+// pullErrorPatterns classify kubelet pull errors by substrings of their
+// lowercase message, checked in order
+var pullErrorPatterns = []struct {
+	cause      string
+	substrings []string
+}{
+	{PullCauseRateLimited, []string{"toomanyrequests", "too many requests", "rate limit"}},
+	{PullCausePlatform, []string{"no match for platform"}},
+	{PullCauseDNS, []string{"no such host", "server misbehaving"}},
+	{PullCauseTLS, []string{"x509:", "tls:", "certificate signed by unknown authority"}},
+	{PullCauseUnreachable, []string{"i/o timeout", "connection refused", "network is unreachable", "no route to host", "connection reset", "context deadline exceeded"}},
+	{pullUnauthorized, []string{"unauthorized", "403 forbidden", "authorization failed", "denied"}},
+	{PullCauseNotFound, []string{"not found", "manifest unknown", "name unknown"}},
+}
+
+// classifyPullError returns the cause of a kubelet pull error, or an empty string
+func classifyPullError(message string) string {
+	message = strings.ToLower(message)
+	for _, pattern := range pullErrorPatterns {
+		for _, substring := range pattern.substrings {
+			if strings.Contains(message, substring) {
+				return pattern.cause
+			}
+		}
+	}
+	return ""
+}
+
+// PullSecretCheck is a pull secret referenced by the pod
+type PullSecretCheck struct {
+	Name       string   `json:"name"`
+	Status     string   `json:"status"`
+	Registries []string `json:"registries,omitempty"`
+	Error      string   `json:"error,omitempty"`
+
+	secret *pullSecret
+}
+
+// RegistryCheck is the result of resolving the image from the tools server
+// with the pod's credentials
+type RegistryCheck struct {
+	Resolved    bool   `json:"resolved"`
+	Digest      string `json:"digest,omitempty"`
+	PullSecret  string `json:"pull_secret,omitempty"`
+	ErrorReason string `json:"error_reason,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ContainerPullDiagnosis explains why a container's image cannot be pulled
+type ContainerPullDiagnosis struct {
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	Reason    string `json:"reason,omitempty"`
+	Message   string `json:"message,omitempty"`
+	// NodeError is the latest pull error the kubelet reported in an event
+	NodeError   string         `json:"node_error,omitempty"`
+	Cause       string         `json:"cause"`
+	Detail      string         `json:"detail"`
+	Remediation string         `json:"remediation"`
+	Registry    *RegistryCheck `json:"registry,omitempty"`
+}
+
+// ImagePullDiagnosis is the structured response of k8s_diagnose_image_pull
+type ImagePullDiagnosis struct {
+	Pod         string                   `json:"pod"`
+	Namespace   string                   `json:"namespace"`
+	Node        string                   `json:"node,omitempty"`
+	Platform    string                   `json:"platform"`
+	PullSecrets []PullSecretCheck        `json:"pull_secrets,omitempty"`
+	Containers  []ContainerPullDiagnosis `json:"containers"`
+	Message     string                   `json:"message,omitempty"`
+}
+
+// latestPullError returns the message of the latest failed pull of an image
+func latestPullError(events eventList, image string) string {
+	message, latest := "", ""
+	for _, event := range events.Items {
+		if event.Type != "Warning" || !strings.Contains(event.Message, `"`+image+`"`) {
+			continue
+		}
+		lastTime := event.LastTimestamp
+		if lastTime == "" {
+			lastTime = event.EventTime
+		}
+		if lastTime >= latest {
+			message, latest = event.Message, lastTime
+		}
+	}
+	return message
+}
+
+// checkPullSecrets reads the pull secrets a pod references
+func (k *K8sTool) checkPullSecrets(ctx context.Context, namespace string, names []string) []PullSecretCheck {
+	checks := make([]PullSecretCheck, 0, len(names))
+	for _, name := range names {
+		check := PullSecretCheck{Name: name}
+		output, err := k.runKubectlCommandString(ctx, "get", "secret", name, "-n", namespace, "-o", "json")
+		switch {
+		case err != nil && strings.Contains(strings.ToLower(err.Error()), "not found"):
+			check.Status = PullSecretMissing
+		case err != nil:
+			check.Status, check.Error = PullSecretUnreadable, err.Error()
+		default:
+			if check.secret, err = parsePullSecret(output); err != nil {
+				check.Status, check.Error = PullSecretInvalid, err.Error()
+			} else {
+				check.Status, check.Registries = PullSecretFound, check.secret.registries()
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// diagnoseImagePull explains why a container's image cannot be pulled from the
+// kubelet's error, the pull secrets and resolving the image from the tools server
+func diagnoseImagePull(ctx context.Context, diagnosis *ContainerPullDiagnosis, secrets []PullSecretCheck, node, platform string) {
+	ref, err := parseImageRef(diagnosis.Image)
+	if err != nil || diagnosis.Reason == "InvalidImageName" {
+		diagnosis.Cause = PullCauseInvalidReference
+		diagnosis.Detail = diagnosis.Message
+		if err != nil {
+			diagnosis.Detail = err.Error()
+		}
+		diagnosis.Remediation = "fix the image in the pod template; it must be [registry/]repository[:tag][@sha256:digest] with a lowercase repository"
+		return
+	}
+	if diagnosis.Reason == "ErrImageNeverPull" {
+		diagnosis.Cause = PullCauseNeverPull
+		diagnosis.Detail = fmt.Sprintf("imagePullPolicy is Never and %s is not present on node %s", diagnosis.Image, node)
+		diagnosis.Remediation = "load the image onto the node, or set imagePullPolicy to IfNotPresent"
+		return
+	}
+
+	// The first pull secret with credentials for the registry is used, as kubelet does
+	var creds *registryCredentials
+	var missing, invalid []string
+	for _, check := range secrets {
+		switch check.Status {
+		case PullSecretMissing:
+			missing = append(missing, check.Name)
+		case PullSecretInvalid, PullSecretUnreadable:
+			invalid = append(invalid, check.Name)
+		}
+		if check.secret != nil && creds == nil {
+			var err error
+			if creds, err = check.secret.credentials(ref.Registry); err != nil {
+				invalid = append(invalid, check.Name)
+			}
+		}
+	}
+
+	inspection := resolveImage(ctx, ref, creds, platform)
+	diagnosis.Registry = &RegistryCheck{
+		Resolved:    inspection.Error == "",
+		Digest:      inspection.Digest,
+		PullSecret:  inspection.PullSecret,
+		ErrorReason: inspection.ErrorReason,
+		Error:       inspection.Error,
+	}
+
+	cause := classifyPullError(diagnosis.NodeError + " " + diagnosis.Message)
+	if cause == "" {
+		switch inspection.ErrorReason {
+		case ImageErrorUnauthorized:
+			cause = pullUnauthorized
+		case ImageErrorNotFound:
+			cause = PullCauseNotFound
+		case ImageErrorPlatform:
+			cause = PullCausePlatform
+		case ImageErrorUnreachable:
+			cause = PullCauseUnreachable
+		}
+	}
+	if cause == pullUnauthorized {
+		switch {
+		case creds != nil:
+			cause = PullCauseRejectedCredentials
+		case len(missing) > 0:
+			cause = PullCauseMissingPullSecret
+		case len(invalid) > 0:
+			cause = PullCauseInvalidPullSecret
+		default:
+			cause = PullCauseNoCredentials
+		}
+	}
+	if cause == "" && diagnosis.Registry.Resolved {
+		cause = PullCauseTransient
+	}
+	diagnosis.Cause = cause
+
+	reachable := ""
+	if diagnosis.Registry.Resolved {
+		reachable = fmt.Sprintf(", although the tools server resolved it to %s", inspection.Digest)
+	}
+	switch cause {
+	case PullCauseMissingPullSecret:
+		diagnosis.Detail = fmt.Sprintf("the registry %s requires credentials and pull secret %s does not exist in the namespace", ref.Registry, strings.Join(missing, ", "))
+		diagnosis.Remediation = "create the pull secret in the pod's namespace, or fix its name in imagePullSecrets"
+	case PullCauseInvalidPullSecret:
+		diagnosis.Detail = fmt.Sprintf("the registry %s requires credentials and pull secret %s cannot be used", ref.Registry, strings.Join(invalid, ", "))
+		diagnosis.Remediation = "recreate the secret with kubectl create secret docker-registry, so it is of type kubernetes.io/dockerconfigjson"
+	case PullCauseNoCredentials:
+		diagnosis.Detail = fmt.Sprintf("the registry %s requires credentials and no pull secret of the pod has any for it", ref.Registry)
+		diagnosis.Remediation = fmt.Sprintf("create a docker-registry secret for %s and add it to the pod's imagePullSecrets or its service account", ref.Registry)
+	case PullCauseRejectedCredentials:
+		diagnosis.Detail = fmt.Sprintf("the registry %s rejected the credentials of pull secret %s", ref.Registry, creds.Secret)
+		diagnosis.Remediation = fmt.Sprintf("refresh the credentials in %s, and check that they are allowed to pull %s", creds.Secret, ref.Repository)
+	case PullCauseNotFound:
+		diagnosis.Detail = fmt.Sprintf("%s does not exist in %s", ref.String(), ref.Registry)
+		diagnosis.Remediation = "check the repository and tag for typos, and that the image was pushed; use k8s_image_inspect to list what the registry serves"
+	case PullCausePlatform:
+		diagnosis.Detail = fmt.Sprintf("%s has no image for the %s node platform %s", ref.String(), node, platform)
+		diagnosis.Remediation = "build and push the image for the platform, or restrict the pod to nodes of a platform the image supports with a nodeSelector on kubernetes.io/arch"
+	case PullCauseRateLimited:
+		diagnosis.Detail = fmt.Sprintf("the registry %s rate limited the node's pulls", ref.Registry)
+		diagnosis.Remediation = "authenticate pulls with a pull secret, mirror the image to a registry you control, or configure a pull-through cache"
+	case PullCauseDNS:
+		diagnosis.Detail = fmt.Sprintf("node %s cannot resolve the registry %s%s", node, ref.Registry, reachable)
+		diagnosis.Remediation = "check the registry host name and the node's DNS configuration"
+	case PullCauseTLS:
+		diagnosis.Detail = fmt.Sprintf("node %s does not trust the certificate of the registry %s", node, ref.Registry)
+		diagnosis.Remediation = "add the registry's CA to the container runtime's trusted certificates on the nodes"
+	case PullCauseUnreachable:
+		diagnosis.Detail = fmt.Sprintf("the registry %s cannot be reached from node %s%s", ref.Registry, node, reachable)
+		if !diagnosis.Registry.Resolved {
+			diagnosis.Detail = fmt.Sprintf("the registry %s cannot be reached from node %s or the tools server", ref.Registry, node)
+		}
+		diagnosis.Remediation = "check egress firewall rules, network policies of the node network and proxy settings of the container runtime"
+	case PullCauseTransient:
+		diagnosis.Detail = fmt.Sprintf("the registry serves %s with the pod's credentials now, so the last pull failure was transient", ref.String())
+		diagnosis.Remediation = "the kubelet retries with back-off; delete the pod to retry immediately"
+	default:
+		diagnosis.Cause = PullCauseUnknown
+		diagnosis.Detail = strings.TrimSpace(diagnosis.NodeError + " " + inspection.Error)
+		diagnosis.Remediation = "inspect the node's container runtime logs for the pull error"
+	}
+}
+
+// Image pull diagnosis
+func (k *K8sTool) handleDiagnoseImagePull(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	podName := p.String("pod_name", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	container := p.String("container", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output, err := k.runKubectlCommandString(ctx, "get", "pod", podName, "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get pod %s: %v", podName, err)), nil
+	}
+	var pod imagePod
+	if err := json.Unmarshal([]byte(output), &pod); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to parse pod %s: %v", podName, err)), nil
+	}
+
+	statuses := make(map[string]imageContainerStatus)
+	for _, status := range append(pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses...) {
+		statuses[status.Name] = status
+	}
+	var failing []ContainerPullDiagnosis
+	for _, c := range append(pod.Spec.Containers, pod.Spec.InitContainers...) {
+		diagnosis := ContainerPullDiagnosis{Container: c.Name, Image: c.Image}
+		if waiting := statuses[c.Name].State.Waiting; waiting != nil {
+			diagnosis.Reason, diagnosis.Message = waiting.Reason, waiting.Message
+		}
+		// A named container is diagnosed even when it is not failing, e.g. between pull attempts
+		if c.Name == container || (container == "" && containsString(imagePullReasons, diagnosis.Reason)) {
+			failing = append(failing, diagnosis)
+		}
+	}
+	if container != "" && len(failing) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("pod %s has no container %s", podName, container)), nil
+	}
+
+	report := ImagePullDiagnosis{Pod: podName, Namespace: namespace, Node: pod.Spec.NodeName, Platform: defaultImagePlatform, Containers: []ContainerPullDiagnosis{}}
+	if len(failing) == 0 {
+		report.Message = "no container of the pod is failing to pull its image"
+		return marshalImagePullDiagnosis(report)
+	}
+
+	if report.Node != "" {
+		if nodeOutput, err := k.runKubectlCommandString(ctx, "get", "node", report.Node, "-o", "json"); err == nil {
+			var node struct {
+				Status struct {
+					NodeInfo struct {
+						OperatingSystem string `json:"operatingSystem"`
+						Architecture    string `json:"architecture"`
+					} `json:"nodeInfo"`
+				} `json:"status"`
+			}
+			if json.Unmarshal([]byte(nodeOutput), &node) == nil && node.Status.NodeInfo.Architecture != "" {
+				report.Platform = platformString(node.Status.NodeInfo.OperatingSystem, node.Status.NodeInfo.Architecture, "")
+			}
+		}
+	}
+
+	var secretNames []string
+	for _, secret := range pod.Spec.ImagePullSecrets {
+		secretNames = append(secretNames, secret.Name)
+	}
+	report.PullSecrets = k.checkPullSecrets(ctx, namespace, secretNames)
+
+	var events eventList
+	if eventsOutput, err := k.runKubectlCommandString(ctx, "get", "events", "-n", namespace, "--field-selector", "involvedObject.name="+podName, "-o", "json"); err == nil {
+		_ = json.Unmarshal([]byte(eventsOutput), &events)
+	}
+
+	for i := range failing {
+		failing[i].NodeError = latestPullError(events, failing[i].Image)
+		diagnoseImagePull(ctx, &failing[i], report.PullSecrets, report.Node, report.Platform)
+	}
+	report.Containers = failing
+	return marshalImagePullDiagnosis(report)
+}
+
+// marshalImagePullDiagnosis renders an image pull diagnosis as the tool result
+func marshalImagePullDiagnosis(report ImagePullDiagnosis) (*mcp.CallToolResult, error) {
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal image pull diagnosis: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
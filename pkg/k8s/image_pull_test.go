@@ -0,0 +1,153 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+func TestClassifyPullError(t *testing.T) {
+	tests := map[string]string{
+		`Failed to pull image "nginx:1.27": toomanyrequests: You have reached your pull rate limit`:           PullCauseRateLimited,
+		`failed to resolve reference "docker.io/library/nginx:nope": docker.io/library/nginx:nope: not found`: PullCauseNotFound,
+		`failed to authorize: failed to fetch anonymous token: unexpected status: 401 Unauthorized`:           pullUnauthorized,
+		`pull access denied, repository does not exist or may require authorization`:                          pullUnauthorized,
+		`dial tcp: lookup registry.internal on 10.96.0.10:53: no such host`:                                   PullCauseDNS,
+		`dial tcp 10.0.0.5:443: i/o timeout`:                                                                  PullCauseUnreachable,
+		`tls: failed to verify certificate: x509: certificate signed by unknown authority`:                    PullCauseTLS,
+		`failed to unpack image on snapshotter overlayfs: no match for platform in manifest: not found`:       PullCausePlatform,
+		`Back-off pulling image "nginx:1.27"`:                                                                 "",
+	}
+	for message, want := range tests {
+		assert.Equal(t, want, classifyPullError(message), message)
+	}
+}
+
+func TestHandleDiagnoseImagePull(t *testing.T) {
+	registry := newTestRegistry(t)
+	host := strings.TrimPrefix(registry.URL, "https://")
+
+	pullingPod := func(image string, secrets ...string) string {
+		refs := make([]string, 0, len(secrets))
+		for _, secret := range secrets {
+			refs = append(refs, fmt.Sprintf(`{"name":%q}`, secret))
+		}
+		return fmt.Sprintf(`{"spec":{"nodeName":"node-1","containers":[{"name":"app","image":%q},{"name":"sidecar","image":"busybox"}],"imagePullSecrets":[%s]},
+			"status":{"containerStatuses":[{"name":"app","state":{"waiting":{"reason":"ImagePullBackOff","message":"Back-off pulling image \"%s\""}}},
+			{"name":"sidecar","state":{"running":{}}}]}}`, image, strings.Join(refs, ","), image)
+	}
+	pullEvents := func(image, message string) string {
+		return fmt.Sprintf(`{"items":[{"type":"Warning","reason":"Failed","lastTimestamp":"2026-10-15T10:00:00Z","message":%q}]}`,
+			fmt.Sprintf(`Failed to pull image "%s": %s`, image, message))
+	}
+	newMock := func(pod, arch, events string) *cmd.MockShellExecutor {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pod", "app-1", "-n", "team", "-o", "json"}, pod, nil)
+		mock.AddCommandString("kubectl", []string{"get", "node", "node-1", "-o", "json"},
+			fmt.Sprintf(`{"status":{"nodeInfo":{"operatingSystem":"linux","architecture":%q}}}`, arch), nil)
+		mock.AddCommandString("kubectl", []string{"get", "events", "-n", "team", "--field-selector", "involvedObject.name=app-1", "-o", "json"}, events, nil)
+		return mock
+	}
+	diagnose := func(t *testing.T, mock *cmd.MockShellExecutor) ImagePullDiagnosis {
+		ctx := context.WithValue(cmd.WithShellExecutor(context.Background(), mock), registryClientKey{}, registry.Client())
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"pod_name": "app-1", "namespace": "team"}
+		result, err := newTestK8sTool().handleDiagnoseImagePull(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report ImagePullDiagnosis
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		return report
+	}
+	image := host + "/team/app:1.0"
+
+	t.Run("missing pull secret", func(t *testing.T) {
+		mock := newMock(pullingPod(image, "regcred"), "amd64", pullEvents(image, "401 Unauthorized"))
+		mock.AddCommandString("kubectl", []string{"get", "secret", "regcred", "-n", "team", "-o", "json"}, "",
+			errors.New(`Error from server (NotFound): secrets "regcred" not found`))
+
+		report := diagnose(t, mock)
+		assert.Equal(t, "linux/amd64", report.Platform)
+		require.Len(t, report.PullSecrets, 1)
+		assert.Equal(t, PullSecretMissing, report.PullSecrets[0].Status)
+		require.Len(t, report.Containers, 1)
+		diagnosis := report.Containers[0]
+		assert.Equal(t, "app", diagnosis.Container)
+		assert.Equal(t, PullCauseMissingPullSecret, diagnosis.Cause)
+		assert.Contains(t, diagnosis.NodeError, "401 Unauthorized")
+		require.NotNil(t, diagnosis.Registry)
+		assert.False(t, diagnosis.Registry.Resolved)
+		assert.Equal(t, ImageErrorUnauthorized, diagnosis.Registry.ErrorReason)
+	})
+
+	t.Run("no credentials for the registry", func(t *testing.T) {
+		mock := newMock(pullingPod(image, "hub"), "amd64", `{"items":[]}`)
+		mock.AddCommandString("kubectl", []string{"get", "secret", "hub", "-n", "team", "-o", "json"}, testPullSecret("hub", "docker.io", "x", "y"), nil)
+
+		report := diagnose(t, mock)
+		assert.Equal(t, []string{"docker.io"}, report.PullSecrets[0].Registries)
+		assert.Equal(t, PullCauseNoCredentials, report.Containers[0].Cause)
+		assert.Contains(t, report.Containers[0].Remediation, host)
+	})
+
+	t.Run("tag not found", func(t *testing.T) {
+		missing := host + "/team/app:2.0"
+		mock := newMock(pullingPod(missing, "regcred"), "amd64", `{"items":[]}`)
+		mock.AddCommandString("kubectl", []string{"get", "secret", "regcred", "-n", "team", "-o", "json"}, testPullSecret("regcred", host, "bot", "pw"), nil)
+
+		diagnosis := diagnose(t, mock).Containers[0]
+		assert.Equal(t, PullCauseNotFound, diagnosis.Cause)
+		assert.Equal(t, "regcred", diagnosis.Registry.PullSecret)
+	})
+
+	t.Run("node cannot resolve the registry", func(t *testing.T) {
+		mock := newMock(pullingPod(image, "regcred"), "amd64", pullEvents(image, "dial tcp: lookup registry: no such host"))
+		mock.AddCommandString("kubectl", []string{"get", "secret", "regcred", "-n", "team", "-o", "json"}, testPullSecret("regcred", host, "bot", "pw"), nil)
+
+		diagnosis := diagnose(t, mock).Containers[0]
+		assert.Equal(t, PullCauseDNS, diagnosis.Cause)
+		assert.True(t, diagnosis.Registry.Resolved)
+		assert.Contains(t, diagnosis.Detail, "the tools server resolved it to "+testIndexDigest)
+	})
+
+	t.Run("platform mismatch", func(t *testing.T) {
+		mock := newMock(pullingPod(image, "regcred"), "s390x", `{"items":[]}`)
+		mock.AddCommandString("kubectl", []string{"get", "secret", "regcred", "-n", "team", "-o", "json"}, testPullSecret("regcred", host, "bot", "pw"), nil)
+
+		report := diagnose(t, mock)
+		assert.Equal(t, "linux/s390x", report.Platform)
+		assert.Equal(t, PullCausePlatform, report.Containers[0].Cause)
+	})
+
+	t.Run("invalid image reference", func(t *testing.T) {
+		pod := `{"spec":{"containers":[{"name":"app","image":"Team/App"}]},
+			"status":{"containerStatuses":[{"name":"app","state":{"waiting":{"reason":"InvalidImageName","message":"couldn't parse image reference"}}}]}}`
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pod", "app-1", "-n", "team", "-o", "json"}, pod, nil)
+
+		diagnosis := diagnose(t, mock).Containers[0]
+		assert.Equal(t, PullCauseInvalidReference, diagnosis.Cause)
+		assert.Nil(t, diagnosis.Registry)
+	})
+
+	t.Run("pod pulling its images", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pod", "app-1", "-n", "team", "-o", "json"},
+			`{"spec":{"containers":[{"name":"app","image":"nginx"}]},"status":{"containerStatuses":[{"name":"app","state":{"running":{}}}]}}`, nil)
+
+		report := diagnose(t, mock)
+		assert.Empty(t, report.Containers)
+		assert.NotEmpty(t, report.Message)
+		assert.Len(t, mock.GetCallLog(), 1)
+	})
+}
@@ -0,0 +1,209 @@
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		image string
+		want  imageRef
+	}{
+		{"nginx", imageRef{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"bitnami/redis:7.2", imageRef{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7.2"}},
+		{"ghcr.io/org/app:v1.0.0", imageRef{Registry: "ghcr.io", Repository: "org/app", Tag: "v1.0.0"}},
+		{"localhost:5000/app", imageRef{Registry: "localhost:5000", Repository: "app", Tag: "latest"}},
+		{"quay.io/org/app:1.2@sha256:abc", imageRef{Registry: "quay.io", Repository: "org/app", Tag: "1.2", Digest: "sha256:abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			ref, err := parseImageRef(tt.image)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ref)
+		})
+	}
+
+	for _, image := range []string{"", "Nginx", "app@md5:abc"} {
+		_, err := parseImageRef(image)
+		assert.Error(t, err, image)
+	}
+	assert.Equal(t, "registry-1.docker.io", imageRef{Registry: "docker.io"}.apiHost())
+}
+
+// testPullSecret renders a kubernetes.io/dockerconfigjson secret
+func testPullSecret(name, host, username, password string) string {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	config := fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, host, auth)
+	return fmt.Sprintf(`{"metadata":{"name":%q},"type":"kubernetes.io/dockerconfigjson","data":{".dockerconfigjson":%q}}`,
+		name, base64.StdEncoding.EncodeToString([]byte(config)))
+}
+
+func TestParsePullSecret(t *testing.T) {
+	secret, err := parsePullSecret(testPullSecret("hub", "https://index.docker.io/v1/", "bot", "s3:cret"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docker.io"}, secret.registries())
+
+	creds, err := secret.credentials("docker.io")
+	require.NoError(t, err)
+	require.NotNil(t, creds)
+	assert.Equal(t, registryCredentials{Username: "bot", Password: "s3:cret", Secret: "hub"}, *creds)
+
+	creds, err = secret.credentials("ghcr.io")
+	require.NoError(t, err)
+	assert.Nil(t, creds)
+
+	_, err = parsePullSecret(`{"metadata":{"name":"tls"},"type":"kubernetes.io/tls","data":{"tls.crt":""}}`)
+	assert.ErrorContains(t, err, "not an image pull secret")
+}
+
+const (
+	testIndexDigest    = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	testManifestDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	testConfigDigest   = "sha256:3333333333333333333333333333333333333333333333333333333333333333"
+)
+
+// newTestRegistry serves a multi-platform image team/app:1.0 behind Bearer
+// authentication accepting only bot:pw
+func newTestRegistry(t *testing.T) *httptest.Server {
+	var registry *httptest.Server
+	registry = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if username, password, _ := r.BasicAuth(); username != "bot" || password != "pw" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Equal(t, "repository:team/app:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token":"pull-token"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set(registryAuthenticateHeader, fmt.Sprintf(`Bearer realm="%s/token",service="test-registry"`, registry.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/team/app/manifests/1.0":
+			w.Header().Set(registryDigestHeader, testIndexDigest)
+			_, _ = fmt.Fprintf(w, `{"mediaType":%q,"manifests":[
+				{"digest":%q,"platform":{"os":"linux","architecture":"amd64"}},
+				{"digest":"sha256:arm","platform":{"os":"linux","architecture":"arm64","variant":"v8"}},
+				{"digest":"sha256:att","platform":{"os":"unknown","architecture":"unknown"}}]}`, mediaTypeOCIIndex, testManifestDigest)
+		case "/v2/team/app/manifests/" + testManifestDigest:
+			_, _ = fmt.Fprintf(w, `{"mediaType":%q,"config":{"digest":%q},"layers":[{"size":1000},{"size":234}]}`, mediaTypeOCIManifest, testConfigDigest)
+		case "/v2/team/app/blobs/" + testConfigDigest:
+			_, _ = w.Write([]byte(`{"created":"2026-10-01T12:00:00Z","os":"linux","architecture":"amd64","config":{
+				"User":"65532","ExposedPorts":{"8080/tcp":{},"9090/tcp":{}},"Entrypoint":["/app"],"Cmd":["serve"],
+				"Labels":{"org.opencontainers.image.version":"1.0.3"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown"}]}`))
+		}
+	}))
+	t.Cleanup(registry.Close)
+	return registry
+}
+
+func TestHandleImageInspect(t *testing.T) {
+	registry := newTestRegistry(t)
+	host := strings.TrimPrefix(registry.URL, "https://")
+
+	inspect := func(t *testing.T, mock *cmd.MockShellExecutor, args map[string]interface{}) ImageInspection {
+		ctx := context.WithValue(cmd.WithShellExecutor(context.Background(), mock), registryClientKey{}, registry.Client())
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := newTestK8sTool().handleImageInspect(ctx, request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var inspection ImageInspection
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &inspection))
+		return inspection
+	}
+
+	t.Run("image with pull secret", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "secret", "other", "-n", "team", "-o", "json"}, testPullSecret("other", "ghcr.io", "x", "y"), nil)
+		mock.AddCommandString("kubectl", []string{"get", "secret", "regcred", "-n", "team", "-o", "json"}, testPullSecret("regcred", host, "bot", "pw"), nil)
+
+		inspection := inspect(t, mock, map[string]interface{}{"image": host + "/team/app:1.0", "namespace": "team", "pull_secrets": "other, regcred"})
+		assert.Empty(t, inspection.Error)
+		assert.Equal(t, "regcred", inspection.PullSecret)
+		assert.Equal(t, testIndexDigest, inspection.Digest)
+		assert.Equal(t, []string{"linux/amd64", "linux/arm64/v8"}, inspection.Platforms)
+		assert.Equal(t, "linux/amd64", inspection.Platform)
+		assert.Equal(t, testManifestDigest, inspection.PlatformDigest)
+		assert.Equal(t, int64(1234), inspection.CompressedSize)
+		assert.Equal(t, 2, inspection.Layers)
+		assert.Equal(t, []string{"/app"}, inspection.Entrypoint)
+		assert.Equal(t, []string{"8080/tcp", "9090/tcp"}, inspection.ExposedPorts)
+		assert.Equal(t, "1.0.3", inspection.Labels["org.opencontainers.image.version"])
+	})
+
+	t.Run("pod running an outdated image", func(t *testing.T) {
+		pod := fmt.Sprintf(`{"spec":{"containers":[{"name":"app","image":"%s/team/app:1.0"}],"imagePullSecrets":[{"name":"regcred"}]},
+			"status":{"containerStatuses":[{"name":"app","imageID":"%s/team/app@sha256:old"}]}}`, host, host)
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pod", "app-1", "-n", "team", "-o", "json"}, pod, nil)
+		mock.AddCommandString("kubectl", []string{"get", "secret", "regcred", "-n", "team", "-o", "json"}, testPullSecret("regcred", host, "bot", "pw"), nil)
+
+		inspection := inspect(t, mock, map[string]interface{}{"pod_name": "app-1", "namespace": "team"})
+		require.NotNil(t, inspection.Pod)
+		assert.Equal(t, "app", inspection.Pod.Container)
+		assert.Equal(t, "sha256:old", inspection.Pod.RunningDigest)
+		require.NotNil(t, inspection.Pod.MatchesResolved)
+		assert.False(t, *inspection.Pod.MatchesResolved)
+	})
+
+	t.Run("pod in ImagePullBackOff without credentials", func(t *testing.T) {
+		pod := fmt.Sprintf(`{"spec":{"containers":[{"name":"app","image":"%s/team/app:1.0"}]},
+			"status":{"containerStatuses":[{"name":"app","state":{"waiting":{"reason":"ImagePullBackOff","message":"Back-off pulling image"}}}]}}`, host)
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pod", "app-1", "-n", "team", "-o", "json"}, pod, nil)
+
+		inspection := inspect(t, mock, map[string]interface{}{"pod_name": "app-1", "namespace": "team"})
+		assert.Equal(t, "ImagePullBackOff", inspection.Pod.WaitingReason)
+		assert.Nil(t, inspection.Pod.MatchesResolved)
+		assert.Equal(t, ImageErrorUnauthorized, inspection.ErrorReason)
+		assert.Contains(t, inspection.Hint, "pull_secrets")
+	})
+
+	t.Run("missing tag", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "secret", "regcred", "-n", "default", "-o", "json"}, testPullSecret("regcred", host, "bot", "pw"), nil)
+
+		inspection := inspect(t, mock, map[string]interface{}{"image": host + "/team/app:2.0", "pull_secrets": "regcred"})
+		assert.Equal(t, ImageErrorNotFound, inspection.ErrorReason)
+		assert.Contains(t, inspection.Error, "MANIFEST_UNKNOWN")
+	})
+
+	t.Run("platform without image", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "secret", "regcred", "-n", "default", "-o", "json"}, testPullSecret("regcred", host, "bot", "pw"), nil)
+
+		inspection := inspect(t, mock, map[string]interface{}{"image": host + "/team/app:1.0", "pull_secrets": "regcred", "platform": "linux/s390x"})
+		assert.Equal(t, ImageErrorPlatform, inspection.ErrorReason)
+		assert.Equal(t, testIndexDigest, inspection.Digest)
+	})
+
+	t.Run("image or pod required", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{}
+		result, err := newTestK8sTool().handleImageInspect(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
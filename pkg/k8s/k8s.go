@@ -16,10 +16,14 @@ import (
 	"github.com/tmc/langchaingo/llms"
 
 	"github.com/kagent-dev/tools/internal/cache"
+	"github.com/kagent-dev/tools/internal/cliout"
 	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/llm"
 	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
 	"github.com/kagent-dev/tools/internal/security"
 	"github.com/kagent-dev/tools/internal/telemetry"
+	"github.com/kagent-dev/tools/pkg/utils"
 )
 
 // K8sTool struct to hold the LLM model
@@ -54,14 +58,34 @@ func (k *K8sTool) runKubectlCommandWithCacheInvalidation(ctx context.Context, ar
 
 // Enhanced kubectl get
 func (k *K8sTool) handleKubectlGetEnhanced(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	resourceType := mcp.ParseString(request, "resource_type", "")
-	resourceName := mcp.ParseString(request, "resource_name", "")
-	namespace := mcp.ParseString(request, "namespace", "")
-	allNamespaces := mcp.ParseString(request, "all_namespaces", "") == "true"
-	output := mcp.ParseString(request, "output", "wide")
+	p := params.New(request)
+	resourceType := p.String("resource_type", "", params.Required())
+	resourceName := p.String("resource_name", "")
+	namespace := p.String("namespace", "")
+	allNamespaces := p.Bool("all_namespaces", false)
+	output := p.String("output", "wide")
+	maxItems := p.Int("max_items", 0, params.Min(0))
+	continueToken := p.String("continue", "")
+	summarize := p.String("summarize", summarizeAuto, params.OneOf(summarizeAuto, summarizeAlways, summarizeNever))
+	jsonPath := p.String("jsonpath", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
-	if resourceType == "" {
-		return mcp.NewToolResultError("resource_type parameter is required"), nil
+	if jsonPath != "" {
+		if maxItems > 0 || continueToken != "" {
+			return mcp.NewToolResultError("jsonpath cannot be combined with max_items or continue"), nil
+		}
+		var err error
+		if output, err = jsonPathOutput(jsonPath); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		// Projections are small by design, so they are never summarized
+		summarize = summarizeNever
+	}
+
+	if resourceName == "" && (maxItems > 0 || continueToken != "") {
+		return k.getResourcesPage(ctx, resourceType, namespace, allNamespaces, maxItems, continueToken, output)
 	}
 
 	args := []string{"get", resourceType}
@@ -70,30 +94,46 @@ func (k *K8sTool) handleKubectlGetEnhanced(ctx context.Context, request mcp.Call
 		args = append(args, resourceName)
 	}
 
+	var scopeArgs []string
 	if allNamespaces {
-		args = append(args, "--all-namespaces")
+		scopeArgs = []string{"--all-namespaces"}
 	} else if namespace != "" {
-		args = append(args, "-n", namespace)
+		scopeArgs = []string{"-n", namespace}
 	}
+	args = append(args, scopeArgs...)
 
-	if output != "" {
-		args = append(args, "-o", output)
-	} else {
-		args = append(args, "-o", "json")
+	if resourceName == "" && summarize == summarizeAlways {
+		return k.summarizeResources(ctx, resourceType, scopeArgs, "", "")
 	}
 
-	return k.runKubectlCommand(ctx, args...)
+	args = append(args, "-o", output)
+
+	// Listings that will be summarized are not held in memory beyond the summary limit
+	inlineLimit := maxInlineOutputBytes
+	if resourceName == "" && summarize == summarizeAuto {
+		inlineLimit = maxResourceListBytes
+	}
+	result, err := k.streamKubectl(ctx, inlineLimit, args...)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if resourceName == "" && summarize == summarizeAuto && result.Size > maxResourceListBytes {
+		return k.summarizeResources(ctx, resourceType, scopeArgs, fmt.Sprintf(
+			"The listing is %d bytes, more than the %d byte limit, so only counts per namespace are shown. Narrow it down with namespace, page through it with max_items and continue, or read the full listing from output_uri with fetch_blob.",
+			result.Size, maxResourceListBytes), result.URI)
+	}
+	return result.result(), nil
 }
 
 // Get pod logs
 func (k *K8sTool) handleKubectlLogsEnhanced(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	podName := mcp.ParseString(request, "pod_name", "")
-	namespace := mcp.ParseString(request, "namespace", "default")
-	container := mcp.ParseString(request, "container", "")
-	tailLines := mcp.ParseInt(request, "tail_lines", 50)
-
-	if podName == "" {
-		return mcp.NewToolResultError("pod_name parameter is required"), nil
+	p := params.New(request)
+	podName := p.String("pod_name", "", params.Required())
+	namespace := p.String("namespace", "default")
+	container := p.String("container", "")
+	tailLines := p.Int("tail_lines", 50, params.Min(0))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	args := []string{"logs", podName, "-n", namespace}
@@ -111,61 +151,47 @@ func (k *K8sTool) handleKubectlLogsEnhanced(ctx context.Context, request mcp.Cal
 
 // Scale deployment
 func (k *K8sTool) handleScaleDeployment(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	deploymentName := mcp.ParseString(request, "name", "")
-	namespace := mcp.ParseString(request, "namespace", "default")
-	replicas := mcp.ParseInt(request, "replicas", 1)
-
-	if deploymentName == "" {
-		return mcp.NewToolResultError("name parameter is required"), nil
+	p := params.New(request)
+	deploymentName := p.String("name", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	namespace := p.String("namespace", "default")
+	replicas := p.Int("replicas", 1, params.Min(0))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	args := []string{"scale", "deployment", deploymentName, "--replicas", fmt.Sprintf("%d", replicas), "-n", namespace}
 
-	return k.runKubectlCommandWithCacheInvalidation(ctx, args...)
+	prior := k.priorReplicas(ctx, "deployment", deploymentName, namespace)
+	result, err := k.runKubectlCommandWithCacheInvalidation(ctx, args...)
+	recordChanges(ctx, result, "k8s_scale", fmt.Sprintf("scaled to %d replicas", replicas), prior)
+	return result, err
 }
 
 // Patch resource
 func (k *K8sTool) handlePatchResource(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	resourceType := mcp.ParseString(request, "resource_type", "")
-	resourceName := mcp.ParseString(request, "resource_name", "")
-	patch := mcp.ParseString(request, "patch", "")
-	namespace := mcp.ParseString(request, "namespace", "default")
-
-	if resourceType == "" || resourceName == "" || patch == "" {
-		return mcp.NewToolResultError("resource_type, resource_name, and patch parameters are required"), nil
-	}
-
-	// Validate resource name for security
-	if err := security.ValidateK8sResourceName(resourceName); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid resource name: %v", err)), nil
-	}
-
-	// Validate namespace for security
-	if err := security.ValidateNamespace(namespace); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid namespace: %v", err)), nil
-	}
-
-	// Validate patch content as JSON/YAML
-	if err := security.ValidateYAMLContent(patch); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid patch content: %v", err)), nil
+	p := params.New(request)
+	resourceType := p.String("resource_type", "", params.Required())
+	resourceName := p.String("resource_name", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	patch := p.String("patch", "", params.Required(), params.Check(security.ValidateYAMLContent))
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	args := []string{"patch", resourceType, resourceName, "-p", patch, "-n", namespace}
 
-	return k.runKubectlCommandWithCacheInvalidation(ctx, args...)
+	prior := k.priorState(ctx, resourceType, resourceName, namespace)
+	result, err := k.runKubectlCommandWithCacheInvalidation(ctx, args...)
+	recordChanges(ctx, result, "k8s_patch_resource", "patched", prior)
+	return result, err
 }
 
 // Apply manifest from content
 func (k *K8sTool) handleApplyManifest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	manifest := mcp.ParseString(request, "manifest", "")
-
-	if manifest == "" {
-		return mcp.NewToolResultError("manifest parameter is required"), nil
-	}
-
-	// Validate YAML content for security
-	if err := security.ValidateYAMLContent(manifest); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid manifest content: %v", err)), nil
+	p := params.New(request)
+	manifest := p.String("manifest", "", params.Required(), params.Check(security.ValidateYAMLContent))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	// Create temporary file with secure permissions
@@ -197,33 +223,42 @@ func (k *K8sTool) handleApplyManifest(ctx context.Context, request mcp.CallToolR
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to close temp file: %v", err)), nil
 	}
 
-	return k.runKubectlCommandWithCacheInvalidation(ctx, "apply", "-f", tmpFile.Name())
+	priors := k.manifestPriorStates(ctx, manifest)
+	result, err := k.runKubectlCommandWithCacheInvalidation(ctx, "apply", "-f", tmpFile.Name())
+	recordChanges(ctx, result, "k8s_apply_manifest", "applied", priors...)
+	return result, err
 }
 
 // Delete resource
 func (k *K8sTool) handleDeleteResource(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	resourceType := mcp.ParseString(request, "resource_type", "")
-	resourceName := mcp.ParseString(request, "resource_name", "")
-	namespace := mcp.ParseString(request, "namespace", "default")
-
-	if resourceType == "" || resourceName == "" {
-		return mcp.NewToolResultError("resource_type and resource_name parameters are required"), nil
+	p := params.New(request)
+	resourceType := p.String("resource_type", "", params.Required())
+	resourceName := p.String("resource_name", "", params.Required())
+	namespace := p.String("namespace", "default")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	args := []string{"delete", resourceType, resourceName, "-n", namespace}
 
-	return k.runKubectlCommandWithCacheInvalidation(ctx, args...)
+	prior := k.priorState(ctx, resourceType, resourceName, namespace)
+	result, err := k.runKubectlCommandWithCacheInvalidation(ctx, args...)
+	recordChanges(ctx, result, "k8s_delete_resource", "deleted", prior)
+	return result, err
 }
 
 // Check service connectivity
 func (k *K8sTool) handleCheckServiceConnectivity(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	serviceName := mcp.ParseString(request, "service_name", "")
-	namespace := mcp.ParseString(request, "namespace", "default")
-
-	if serviceName == "" {
-		return mcp.NewToolResultError("service_name parameter is required"), nil
+	p := params.New(request)
+	serviceName := p.String("service_name", "", params.Required())
+	namespace := p.String("namespace", "default")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
+	// Waiting for the pod takes up to a minute, so clients are told which step is running
+	reportStep := utils.StepReporter(ctx, request, "create_pod", "wait_ready", "exec_curl")
+
 	// Create a temporary curl pod for connectivity check
 	podName := fmt.Sprintf("curl-test-%d", rand.Intn(10000))
 	defer func() {
@@ -231,24 +266,31 @@ func (k *K8sTool) handleCheckServiceConnectivity(ctx context.Context, request mc
 	}()
 
 	// Create the curl pod
+	reportStep("create_pod")
 	_, err := k.runKubectlCommand(ctx, "run", podName, "--image=curlimages/curl", "-n", namespace, "--restart=Never", "--", "sleep", "3600")
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to create curl pod: %v", err)), nil
 	}
 
 	// Wait for pod to be ready
+	reportStep("wait_ready")
 	_, err = k.runKubectlCommandWithTimeout(ctx, 60*time.Second, "wait", "--for=condition=ready", "pod/"+podName, "-n", namespace)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to wait for curl pod: %v", err)), nil
 	}
 
 	// Execute kubectl command
+	reportStep("exec_curl")
 	return k.runKubectlCommand(ctx, "exec", podName, "-n", namespace, "--", "curl", "-s", serviceName)
 }
 
 // Get cluster events
 func (k *K8sTool) handleGetEvents(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	namespace := mcp.ParseString(request, "namespace", "")
+	p := params.New(request)
+	namespace := p.String("namespace", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	args := []string{"get", "events", "-o", "json"}
 	if namespace != "" {
@@ -262,27 +304,12 @@ func (k *K8sTool) handleGetEvents(ctx context.Context, request mcp.CallToolReque
 
 // Execute command in pod
 func (k *K8sTool) handleExecCommand(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	podName := mcp.ParseString(request, "pod_name", "")
-	namespace := mcp.ParseString(request, "namespace", "default")
-	command := mcp.ParseString(request, "command", "")
-
-	if podName == "" || command == "" {
-		return mcp.NewToolResultError("pod_name and command parameters are required"), nil
-	}
-
-	// Validate pod name for security
-	if err := security.ValidateK8sResourceName(podName); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid pod name: %v", err)), nil
-	}
-
-	// Validate namespace for security
-	if err := security.ValidateNamespace(namespace); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid namespace: %v", err)), nil
-	}
-
-	// Validate command input for security
-	if err := security.ValidateCommandInput(command); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid command: %v", err)), nil
+	p := params.New(request)
+	podName := p.String("pod_name", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	command := p.String("command", "", params.Required(), params.Check(security.ValidateCommandInput))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	args := []string{"exec", podName, "-n", namespace, "--", command}
@@ -292,17 +319,26 @@ func (k *K8sTool) handleExecCommand(ctx context.Context, request mcp.CallToolReq
 
 // Get available API resources
 func (k *K8sTool) handleGetAvailableAPIResources(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return k.runKubectlCommand(ctx, "api-resources")
+	output, err := k.runKubectlCommandString(ctx, "api-resources")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	// Render the same columns whichever kubectl release listed the resources
+	resources, err := cliout.ParseAPIResources(output)
+	if err != nil {
+		return mcp.NewToolResultText(output), nil
+	}
+	return mcp.NewToolResultText(cliout.FormatAPIResources(resources)), nil
 }
 
 // Kubectl describe tool
 func (k *K8sTool) handleKubectlDescribeTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	resourceType := mcp.ParseString(request, "resource_type", "")
-	resourceName := mcp.ParseString(request, "resource_name", "")
-	namespace := mcp.ParseString(request, "namespace", "")
-
-	if resourceType == "" || resourceName == "" {
-		return mcp.NewToolResultError("resource_type and resource_name parameters are required"), nil
+	p := params.New(request)
+	resourceType := p.String("resource_type", "", params.Required())
+	resourceName := p.String("resource_name", "", params.Required())
+	namespace := p.String("namespace", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	args := []string{"describe", resourceType, resourceName}
@@ -313,15 +349,18 @@ func (k *K8sTool) handleKubectlDescribeTool(ctx context.Context, request mcp.Cal
 	return k.runKubectlCommand(ctx, args...)
 }
 
+// rolloutActions are the supported kubectl rollout subcommands
+var rolloutActions = []string{"history", "pause", "restart", "resume", "status", "undo"}
+
 // Rollout operations
 func (k *K8sTool) handleRollout(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	action := mcp.ParseString(request, "action", "")
-	resourceType := mcp.ParseString(request, "resource_type", "")
-	resourceName := mcp.ParseString(request, "resource_name", "")
-	namespace := mcp.ParseString(request, "namespace", "")
-
-	if action == "" || resourceType == "" || resourceName == "" {
-		return mcp.NewToolResultError("action, resource_type, and resource_name parameters are required"), nil
+	p := params.New(request)
+	action := p.String("action", "", params.Required(), params.OneOf(rolloutActions...))
+	resourceType := p.String("resource_type", "", params.Required())
+	resourceName := p.String("resource_name", "", params.Required())
+	namespace := p.String("namespace", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	args := []string{"rollout", action, fmt.Sprintf("%s/%s", resourceType, resourceName)}
@@ -339,13 +378,23 @@ func (k *K8sTool) handleGetClusterConfiguration(ctx context.Context, request mcp
 
 // Remove annotation
 func (k *K8sTool) handleRemoveAnnotation(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	resourceType := mcp.ParseString(request, "resource_type", "")
-	resourceName := mcp.ParseString(request, "resource_name", "")
-	annotationKey := mcp.ParseString(request, "annotation_key", "")
-	namespace := mcp.ParseString(request, "namespace", "")
-
-	if resourceType == "" || resourceName == "" || annotationKey == "" {
-		return mcp.NewToolResultError("resource_type, resource_name, and annotation_key parameters are required"), nil
+	p := params.New(request)
+	resourceType := p.String("resource_type", "", params.Required())
+	resourceName := p.String("resource_name", "")
+	annotationKey := p.String("annotation_key", "", params.Required())
+	namespace := p.String("namespace", "")
+	selection := parseBulkSelection(p)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := selection.validate(resourceName); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if selection.active() {
+		return k.bulkMetadata(ctx, resourceType, namespace, selection, metadataEdit{
+			tool: "k8s_remove_annotation", verb: "annotate", field: "annotations",
+			assignments: []string{annotationKey + "-"}, summary: "removed annotation " + annotationKey,
+		})
 	}
 
 	args := []string{"annotate", resourceType, resourceName, annotationKey + "-"}
@@ -353,18 +402,31 @@ func (k *K8sTool) handleRemoveAnnotation(ctx context.Context, request mcp.CallTo
 		args = append(args, "-n", namespace)
 	}
 
-	return k.runKubectlCommand(ctx, args...)
+	prior := k.priorState(ctx, resourceType, resourceName, namespace)
+	result, err := k.runKubectlCommand(ctx, args...)
+	recordChanges(ctx, result, "k8s_remove_annotation", "removed annotation "+annotationKey, prior)
+	return result, err
 }
 
 // Remove label
 func (k *K8sTool) handleRemoveLabel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	resourceType := mcp.ParseString(request, "resource_type", "")
-	resourceName := mcp.ParseString(request, "resource_name", "")
-	labelKey := mcp.ParseString(request, "label_key", "")
-	namespace := mcp.ParseString(request, "namespace", "")
-
-	if resourceType == "" || resourceName == "" || labelKey == "" {
-		return mcp.NewToolResultError("resource_type, resource_name, and label_key parameters are required"), nil
+	p := params.New(request)
+	resourceType := p.String("resource_type", "", params.Required())
+	resourceName := p.String("resource_name", "")
+	labelKey := p.String("label_key", "", params.Required())
+	namespace := p.String("namespace", "")
+	selection := parseBulkSelection(p)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := selection.validate(resourceName); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if selection.active() {
+		return k.bulkMetadata(ctx, resourceType, namespace, selection, metadataEdit{
+			tool: "k8s_remove_label", verb: "label", field: "labels",
+			assignments: []string{labelKey + "-"}, summary: "removed label " + labelKey,
+		})
 	}
 
 	args := []string{"label", resourceType, resourceName, labelKey + "-"}
@@ -372,61 +434,122 @@ func (k *K8sTool) handleRemoveLabel(ctx context.Context, request mcp.CallToolReq
 		args = append(args, "-n", namespace)
 	}
 
-	return k.runKubectlCommand(ctx, args...)
+	prior := k.priorState(ctx, resourceType, resourceName, namespace)
+	result, err := k.runKubectlCommand(ctx, args...)
+	recordChanges(ctx, result, "k8s_remove_label", "removed label "+labelKey, prior)
+	return result, err
 }
 
 // Annotate resource
 func (k *K8sTool) handleAnnotateResource(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	resourceType := mcp.ParseString(request, "resource_type", "")
-	resourceName := mcp.ParseString(request, "resource_name", "")
-	annotations := mcp.ParseString(request, "annotations", "")
-	namespace := mcp.ParseString(request, "namespace", "")
-
-	if resourceType == "" || resourceName == "" || annotations == "" {
-		return mcp.NewToolResultError("resource_type, resource_name, and annotations parameters are required"), nil
+	p := params.New(request)
+	resourceType := p.String("resource_type", "", params.Required())
+	resourceName := p.String("resource_name", "")
+	annotations := p.String("annotations", "", params.Required(), params.Check(security.ValidateAnnotationAssignments))
+	namespace := p.String("namespace", "")
+	overwrite := p.Bool("overwrite", false)
+	selection := parseBulkSelection(p)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := selection.validate(resourceName); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if selection.active() {
+		return k.bulkMetadata(ctx, resourceType, namespace, selection, metadataEdit{
+			tool: "k8s_annotate_resource", verb: "annotate", field: "annotations",
+			assignments: strings.Fields(annotations), overwrite: overwrite, summary: "annotated " + annotations,
+		})
 	}
 
 	args := []string{"annotate", resourceType, resourceName}
 	args = append(args, strings.Fields(annotations)...)
+	if overwrite {
+		args = append(args, "--overwrite")
+	}
 
 	if namespace != "" {
 		args = append(args, "-n", namespace)
 	}
 
-	return k.runKubectlCommand(ctx, args...)
+	prior := k.priorState(ctx, resourceType, resourceName, namespace)
+	result, err := k.runKubectlCommand(ctx, args...)
+	recordChanges(ctx, result, "k8s_annotate_resource", "annotated "+annotations, prior)
+	return result, err
 }
 
 // Label resource
 func (k *K8sTool) handleLabelResource(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	resourceType := mcp.ParseString(request, "resource_type", "")
-	resourceName := mcp.ParseString(request, "resource_name", "")
-	labels := mcp.ParseString(request, "labels", "")
-	namespace := mcp.ParseString(request, "namespace", "")
-
-	if resourceType == "" || resourceName == "" || labels == "" {
-		return mcp.NewToolResultError("resource_type, resource_name, and labels parameters are required"), nil
+	p := params.New(request)
+	resourceType := p.String("resource_type", "", params.Required())
+	resourceName := p.String("resource_name", "")
+	labels := p.String("labels", "", params.Required(), params.Check(security.ValidateLabelAssignments))
+	namespace := p.String("namespace", "")
+	overwrite := p.Bool("overwrite", false)
+	selection := parseBulkSelection(p)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := selection.validate(resourceName); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if selection.active() {
+		return k.bulkMetadata(ctx, resourceType, namespace, selection, metadataEdit{
+			tool: "k8s_label_resource", verb: "label", field: "labels",
+			assignments: strings.Fields(labels), overwrite: overwrite, summary: "labeled " + labels,
+		})
 	}
 
 	args := []string{"label", resourceType, resourceName}
 	args = append(args, strings.Fields(labels)...)
+	if overwrite {
+		args = append(args, "--overwrite")
+	}
 
 	if namespace != "" {
 		args = append(args, "-n", namespace)
 	}
 
-	return k.runKubectlCommand(ctx, args...)
+	prior := k.priorState(ctx, resourceType, resourceName, namespace)
+	result, err := k.runKubectlCommand(ctx, args...)
+	recordChanges(ctx, result, "k8s_label_resource", "labeled "+labels, prior)
+	return result, err
 }
 
+// fetchManifest downloads the manifests of k8s_create_resource_from_url.
+// Tests replace it to serve manifests without network access.
+var fetchManifest = security.FetchURL
+
 // Create resource from URL
 func (k *K8sTool) handleCreateResourceFromURL(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	url := mcp.ParseString(request, "url", "")
-	namespace := mcp.ParseString(request, "namespace", "")
+	p := params.New(request)
+	url := p.String("url", "", params.Required(), params.Check(security.ValidateFetchURL))
+	namespace := p.String("namespace", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// kubectl would fetch the URL itself, following redirects and resolving
+	// the host again, so the manifest is fetched here with every address
+	// checked and handed to kubectl as a file
+	manifest, err := fetchManifest(ctx, url, security.MaxFetchSize)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to fetch manifest: %v", err)), nil
+	}
 
-	if url == "" {
-		return mcp.NewToolResultError("url parameter is required"), nil
+	tmpFile, err := os.CreateTemp("", "k8s-resource-*.yaml")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to create temp file: %v", err)), nil
 	}
+	defer os.Remove(tmpFile.Name())
 
-	args := []string{"create", "-f", url}
+	if _, err := tmpFile.Write(manifest); err != nil {
+		tmpFile.Close()
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to write to temp file: %v", err)), nil
+	}
+	tmpFile.Close()
+
+	args := []string{"create", "-f", tmpFile.Name()}
 	if namespace != "" {
 		args = append(args, "-n", namespace)
 	}
@@ -480,11 +603,11 @@ var (
 
 // Generate resource using LLM
 func (k *K8sTool) handleGenerateResource(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	resourceType := mcp.ParseString(request, "resource_type", "")
-	resourceDescription := mcp.ParseString(request, "resource_description", "")
-
-	if resourceType == "" || resourceDescription == "" {
-		return mcp.NewToolResultError("resource_type and resource_description parameters are required"), nil
+	p := params.New(request)
+	resourceType := p.String("resource_type", "", params.Required())
+	resourceDescription := p.String("resource_description", "", params.Required())
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	systemPrompt, ok := resourceMap[resourceType]
@@ -496,8 +619,6 @@ func (k *K8sTool) handleGenerateResource(ctx context.Context, request mcp.CallTo
 	if k.llmModel == nil {
 		return mcp.NewToolResultError("No LLM client present, can't generate resource"), nil
 	}
-	llm := k.llmModel
-
 	contents := []llms.MessageContent{
 		{
 			Role: llms.ChatMessageTypeSystem,
@@ -513,33 +634,31 @@ func (k *K8sTool) handleGenerateResource(ctx context.Context, request mcp.CallTo
 		},
 	}
 
-	resp, err := llm.GenerateContent(ctx, contents, llms.WithModel("gpt-4o-mini"))
+	// Stream the manifest to clients that asked for progress
+	responseText, err := llm.Generate(ctx, k.llmModel, llm.TaskManifest, contents, utils.TokenStreamer(ctx, request))
 	if err != nil {
 		return mcp.NewToolResultError("failed to generate content: " + err.Error()), nil
 	}
 
-	choices := resp.Choices
-	if len(choices) < 1 {
-		return mcp.NewToolResultError("empty response from model"), nil
-	}
-	c1 := choices[0]
-	responseText := c1.Content
-
 	return mcp.NewToolResultText(responseText), nil
 }
 
 // runKubectlCommand is a helper function to execute kubectl commands
 func (k *K8sTool) runKubectlCommand(ctx context.Context, args ...string) (*mcp.CallToolResult, error) {
-	output, err := commands.NewCommandBuilder("kubectl").
-		WithArgs(args...).
-		WithKubeconfig(k.kubeconfig).
-		Execute(ctx)
-
+	output, err := k.streamKubectl(ctx, maxInlineOutputBytes, args...)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	return mcp.NewToolResultText(output), nil
+	return output.result(), nil
+}
+
+// runKubectlCommandString runs a kubectl command and returns just the string output
+func (k *K8sTool) runKubectlCommandString(ctx context.Context, args ...string) (string, error) {
+	return commands.NewCommandBuilder("kubectl").
+		WithArgs(args...).
+		WithKubeconfig(k.kubeconfig).
+		Execute(ctx)
 }
 
 // runKubectlCommandWithTimeout is a helper function to execute kubectl commands with a timeout
@@ -568,6 +687,10 @@ func RegisterTools(s *server.MCPServer, llm llms.Model, kubeconfig string) {
 		mcp.WithString("namespace", mcp.Description("Namespace to query (optional)")),
 		mcp.WithString("all_namespaces", mcp.Description("Query all namespaces (true/false)")),
 		mcp.WithString("output", mcp.Description("Output format (json, yaml, wide)"), mcp.DefaultString("wide")),
+		mcp.WithNumber("max_items", mcp.Description("Return a page of at most this many resources, with a continue token for the next page. Pages hold full objects for json and yaml output and compact rows otherwise")),
+		mcp.WithString("continue", mcp.Description("Continue token of the previous page")),
+		mcp.WithString("summarize", mcp.Description("Return counts per namespace instead of the resources: auto (when the listing is too large), always or never (default: auto)")),
+		mcp.WithString("jsonpath", mcp.Description(`JSONPath template returning only part of the resources instead of the output format, such as {.spec.template.spec.containers[?(@.name=="app")].image} or {range .items[*]}{.metadata.name}{"\t"}{.status.phase}{"\n"}{end}. Lists are projected from their items field`)),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_get_resources", k8sTool.handleKubectlGetEnhanced)))
 
 	s.AddTool(mcp.NewTool("k8s_get_pod_logs",
@@ -616,6 +739,173 @@ func RegisterTools(s *server.MCPServer, llm llms.Model, kubeconfig string) {
 		mcp.WithString("namespace", mcp.Description("Namespace to get events from (default: default)")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_get_events", k8sTool.handleGetEvents)))
 
+	s.AddTool(mcp.NewTool("k8s_restart_report",
+		mcp.WithDescription("Report pod restart counts, last termination reasons and OOMKilled occurrences per workload, sorted by worst offenders"),
+		mcp.WithString("namespace", mcp.Description("Namespace to report on (default: all namespaces)")),
+		mcp.WithString("all_namespaces", mcp.Description("Report on all namespaces (true/false)")),
+		mcp.WithString("label_selector", mcp.Description("Label selector to filter pods (e.g. app=web)")),
+		mcp.WithNumber("min_restarts", mcp.Description("Minimum restarts for a workload to be reported (default: 1)")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of workloads to report (default: 20)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_restart_report", k8sTool.handleRestartReport)))
+
+	s.AddTool(mcp.NewTool("k8s_crd_health",
+		mcp.WithDescription("Report installed CRDs with their controller deployments, admission and conversion webhook availability, and custom resources with failed or stale conditions"),
+		mcp.WithString("group", mcp.Description("Only report CRDs of this API group (e.g. cert-manager.io)")),
+		mcp.WithString("check_resources", mcp.Description("Inspect custom resources for failed or stale conditions (true/false, default: true)")),
+		mcp.WithString("unhealthy_only", mcp.Description("Only include unhealthy CRDs in the report (true/false)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_crd_health", k8sTool.handleCRDHealth)))
+
+	s.AddTool(mcp.NewTool("k8s_cr_summary",
+		mcp.WithDescription("Summarize custom resources of any CRD, such as Kafka clusters, Postgres clusters or Istio resources, in a standard view: ready, synced, phase and reason from their status conditions, with the values of the CRD's printer columns. Not ready resources are listed first"),
+		mcp.WithString("resource_type", mcp.Description("Custom resource type as plural, singular, kind or short name, optionally qualified by its group (e.g. kafkas, kafka.kafka.strimzi.io, postgresclusters)"), mcp.Required()),
+		mcp.WithString("resource_name", mcp.Description("Only summarize this resource, listing its conditions in full")),
+		mcp.WithString("namespace", mcp.Description("Namespace of namespaced resources (default: the current namespace)")),
+		mcp.WithString("all_namespaces", mcp.Description("Summarize resources across all namespaces (true/false, default: false)")),
+		mcp.WithString("label_selector", mcp.Description("Label selector to filter resources (e.g. strimzi.io/cluster=events)")),
+		mcp.WithString("wide", mcp.Description("Include the printer columns kubectl only shows with -o wide (true/false, default: false)")),
+		mcp.WithString("not_ready_only", mcp.Description("Only list resources that are not ready (true/false, default: false)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_cr_summary", k8sTool.handleCRSummary)))
+
+	s.AddTool(mcp.NewTool("k8s_storage_diagnose",
+		mcp.WithDescription("Correlate StatefulSet pods with their PVCs and PVs, reporting Pending, Bound and Lost claims, storage class provisioner health and recent volume events"),
+		mcp.WithString("namespace", mcp.Description("Namespace to diagnose (default: all namespaces)")),
+		mcp.WithString("statefulset", mcp.Description("Only diagnose this StatefulSet (requires namespace)")),
+		mcp.WithNumber("event_limit", mcp.Description("Maximum number of volume events to report (default: 20)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_storage_diagnose", k8sTool.handleStorageDiagnose)))
+
+	s.AddTool(mcp.NewTool("k8s_autoscaling_status",
+		mcp.WithDescription("Report HPA targets against current metrics, recent scaling events and workloads pinned at min or max replicas, including KEDA ScaledObjects when installed"),
+		mcp.WithString("namespace", mcp.Description("Namespace to report (default: all namespaces)")),
+		mcp.WithString("name", mcp.Description("Only report the HPA or ScaledObject with this name")),
+		mcp.WithNumber("event_limit", mcp.Description("Maximum number of events per autoscaler, 0 to skip events (default: 5)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_autoscaling_status", k8sTool.handleAutoscalingStatus)))
+
+	s.AddTool(mcp.NewTool("k8s_networkpolicy_check",
+		mcp.WithDescription("Simulate whether traffic from a source workload to a destination workload and port is allowed by the Kubernetes NetworkPolicies selecting them, and which policy blocks it otherwise"),
+		mcp.WithString("source", mcp.Description("Source workload as kind/name (e.g. deployment/web) or a pod name"), mcp.Required()),
+		mcp.WithString("source_namespace", mcp.Description("Namespace of the source workload (default: default)")),
+		mcp.WithString("destination", mcp.Description("Destination workload as kind/name (e.g. statefulset/db) or a pod name"), mcp.Required()),
+		mcp.WithString("destination_namespace", mcp.Description("Namespace of the destination workload (default: source namespace)")),
+		mcp.WithString("port", mcp.Description("Destination port number or named container port"), mcp.Required()),
+		mcp.WithString("protocol", mcp.Description("Protocol: TCP, UDP or SCTP (default: TCP)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_networkpolicy_check", k8sTool.handleNetworkPolicyCheck)))
+
+	s.AddTool(mcp.NewTool("k8s_version_skew",
+		mcp.WithDescription("Compare kubectl, helm and istioctl client versions with the cluster's Kubernetes version and installed Istio and Cilium versions, flagging unsupported version skews"),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_version_skew", k8sTool.handleVersionSkew)))
+
+	s.AddTool(mcp.NewTool("k8s_deprecation_scan",
+		mcp.WithDescription("Scan live resources, and optionally the manifests of Helm releases, for API versions deprecated or removed by a target Kubernetes version, returning the affected objects and a migration checklist to work through before upgrading. Live objects are matched by the API versions they were last applied or written with"),
+		mcp.WithString("target_version", mcp.Description("Kubernetes version to upgrade to, e.g. 1.32 (default: the minor version after the cluster's)")),
+		mcp.WithString("namespace", mcp.Description("Namespace to scan; cluster-scoped resources are only scanned without it (default: all namespaces)")),
+		mcp.WithString("include_helm", mcp.Description("Also scan the rendered manifests of Helm releases (true/false, default: false)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_deprecation_scan", k8sTool.handleDeprecationScan)))
+
+	s.AddTool(mcp.NewTool("k8s_upgrade_readiness",
+		mcp.WithDescription("Check whether the cluster is ready to upgrade to a target Kubernetes version, combining the deprecated API scan, PodDisruptionBudget coverage, node pool version skew, admission webhook health and Istio/Cilium compatibility into a scored report of blocking and warning findings"),
+		mcp.WithString("target_version", mcp.Description("Kubernetes version to upgrade to, e.g. 1.32 (default: the minor version after the cluster's)")),
+		mcp.WithString("include_helm", mcp.Description("Also scan the rendered manifests of Helm releases for deprecated APIs (true/false, default: false)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_upgrade_readiness", k8sTool.handleUpgradeReadiness)))
+
+	s.AddTool(mcp.NewTool("k8s_can_i",
+		mcp.WithDescription("Check whether the server's Kubernetes identity may perform an action, like kubectl auth can-i, explaining denials with the RBAC rule that would allow them. With list, returns all of its permissions in a namespace."),
+		mcp.WithString("verb", mcp.Description("Verb to check, such as get, list, create, delete or * (required unless list is true)")),
+		mcp.WithString("resource", mcp.Description("Resource to check, optionally with API group and subresource, e.g. pods, deployments.apps or pods/log")),
+		mcp.WithString("non_resource_url", mcp.Description("Non-resource URL to check instead of a resource, e.g. /healthz")),
+		mcp.WithString("name", mcp.Description("Name of a specific resource")),
+		mcp.WithString("namespace", mcp.Description("Namespace to check (default: all namespaces and cluster scope; the current context's namespace in list mode)")),
+		mcp.WithString("list", mcp.Description("List all permissions instead of checking one action (true/false)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_can_i", k8sTool.handleCanI)))
+
+	s.AddTool(mcp.NewTool("k8s_hardening_check",
+		mcp.WithDescription("Evaluate a workload's pod spec against security and reliability best practices (security context, privileged mode, capabilities, host namespaces, resources, probes and image tags), with strategic merge patches for k8s_patch_resource that fix the findings"),
+		mcp.WithString("workload", mcp.Description("Workload as kind/name (e.g. deployment/web) or a pod name"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the workload (default: default)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_hardening_check", k8sTool.handleHardeningCheck)))
+
+	s.AddTool(mcp.NewTool("k8s_topology_report",
+		mcp.WithDescription("Report how a workload's pods are distributed across nodes and zones, flagging single-replica, single-node and single-zone concentration and missing topologySpreadConstraints or pod anti-affinity, with a strategic merge patch for k8s_patch_resource that spreads the pods"),
+		mcp.WithString("workload", mcp.Description("Workload as kind/name, where kind is deployment, statefulset or replicaset (e.g. deployment/web)"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the workload (default: default)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_topology_report", k8sTool.handleTopologyReport)))
+
+	s.AddTool(mcp.NewTool("k8s_check_references",
+		mcp.WithDescription("Check that the ConfigMaps, Secrets and ServiceAccount a workload's pod spec references through env, envFrom, volumes, serviceAccountName and imagePullSecrets exist and have the referenced keys, explaining the failure each broken reference causes, such as CreateContainerConfigError or FailedMount"),
+		mcp.WithString("workload", mcp.Description("Workload as kind/name, where kind is pod, deployment, statefulset, daemonset, replicaset or job (e.g. deployment/web); a bare name is a pod"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the workload (default: default)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_check_references", k8sTool.handleCheckReferences)))
+
+	s.AddTool(mcp.NewTool("k8s_endpoints_check",
+		mcp.WithDescription("Check why a Service sends traffic to fewer pods than intended: its EndpointSlice membership, which selected pods are excluded and why (not ready, terminating, failing readiness probes, unreported readiness gates, missing named target ports), and pods that miss the selector by a single label"),
+		mcp.WithString("service", mcp.Description("Name of the Service"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the Service (default: default)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_endpoints_check", k8sTool.handleEndpointsCheck)))
+
+	s.AddTool(mcp.NewTool("k8s_control_plane_health",
+		mcp.WithDescription("Check the control plane beyond workloads: the API server's verbose /readyz and /livez checks, component statuses, etcd leader, alarms and database size through etcdctl when the etcd pods are reachable, and API request latency percentiles by verb from the API server's metrics, flagging p99 latencies above the Kubernetes API SLO"),
+		mcp.WithString("check_etcd", mcp.Description("Inspect the etcd members with etcdctl in the kubeadm etcd pods of kube-system (true/false, default: true)")),
+		mcp.WithString("include_latency", mcp.Description("Compute API request latency percentiles from the API server's /metrics (true/false, default: true)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_control_plane_health", k8sTool.handleControlPlaneHealth)))
+
+	s.AddTool(mcp.NewTool("k8s_quota_check",
+		mcp.WithDescription("Check whether applying a manifest or scaling a workload would exceed the namespace's ResourceQuotas or violate its LimitRanges, applying LimitRange defaults first. Reports the blocking quota with its hard limit, usage, headroom and the requested amount"),
+		mcp.WithString("manifest", mcp.Description("YAML manifest of the resources to apply (required unless workload is set)")),
+		mcp.WithString("workload", mcp.Description("Workload to scale as <kind>/<name>, e.g. deployment/web, instead of manifest")),
+		mcp.WithNumber("replicas", mcp.Description("Target replica count of the workload (required with workload)")),
+		mcp.WithString("namespace", mcp.Description("Namespace the change is applied to (default: default)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_quota_check", k8sTool.handleQuotaCheck)))
+
+	s.AddTool(mcp.NewTool("k8s_release_timeline",
+		mcp.WithDescription("Build a chronological timeline of the changes affecting a service's workloads over a time range: Helm release revisions, Deployment, StatefulSet, DaemonSet and Argo Rollout revisions with their images, applied manifests from managed fields or the API server audit log, restarts and HPA scale events. Answers what changed before an incident"),
+		mcp.WithString("service", mcp.Description("Service whose workloads are traced"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the service (default: default)")),
+		mcp.WithString("since", mcp.Description("Length of the time range, ending at until (e.g. 6h, default: 24h)")),
+		mcp.WithString("until", mcp.Description("End of the time range as an RFC 3339 time, e.g. the start of an incident (default: now)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_release_timeline", k8sTool.handleReleaseTimeline)))
+
+	s.AddTool(mcp.NewTool("k8s_search",
+		mcp.WithDescription("Search resource names, labels, annotations and spec fields across resource types and namespaces, listing them concurrently, and return each matching object with the paths of its matching fields. Finds everything referencing a ConfigMap, Secret, image or service account. ConfigMap and Secret data is never searched"),
+		mcp.WithString("query", mcp.Description("Case-insensitive substring to search for, or a regular expression with regex"), mcp.Required()),
+		mcp.WithString("regex", mcp.Description("Treat query as a regular expression (true/false, default: false)")),
+		mcp.WithString("resource_types", mcp.Description("Comma-separated resource types to search (default: workloads, pods, services, ingresses, configmaps, secrets, service accounts, PVCs, HPAs and role bindings)")),
+		mcp.WithString("namespaces", mcp.Description("Comma-separated namespaces to search (default: all namespaces)")),
+		mcp.WithString("fields", mcp.Description("Comma-separated fields to search: name, labels, annotations, spec (default: all)")),
+		mcp.WithNumber("parallelism", mcp.Description("Number of resource lists fetched concurrently (default: 4, max: 16)")),
+		mcp.WithNumber("max_results", mcp.Description("Maximum number of matching objects returned (default: 100)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_search", k8sTool.handleSearch)))
+
+	s.AddTool(mcp.NewTool("k8s_image_inspect",
+		mcp.WithDescription("Resolve an image reference to its digest through the registry API and report its platforms, size, entrypoint, exposed ports and build labels. Given a pod, inspects the container's image with the pod's pull secrets and compares the tag with the digest the container runs, explaining ImagePullBackOff causes"),
+		mcp.WithString("image", mcp.Description("Image reference, e.g. nginx:1.27 or registry.example.com/team/app@sha256:... (required unless pod_name is set)")),
+		mcp.WithString("pod_name", mcp.Description("Pod whose container image is inspected, instead of image")),
+		mcp.WithString("container", mcp.Description("Container of the pod (default: the pod's only container)")),
+		mcp.WithString("namespace", mcp.Description("Namespace of the pod and pull secrets (default: default)")),
+		mcp.WithString("pull_secrets", mcp.Description("Comma-separated image pull secrets to authenticate with, in addition to the pod's imagePullSecrets")),
+		mcp.WithString("platform", mcp.Description("Platform selected from multi-platform images (default: linux/amd64)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_image_inspect", k8sTool.handleImageInspect)))
+
+	s.AddTool(mcp.NewTool("k8s_diagnose_image_pull",
+		mcp.WithDescription("Find the precise cause of a pod's ImagePullBackOff or ErrImagePull: an invalid image reference, missing or invalid imagePullSecrets, missing or rejected registry credentials, a nonexistent tag, a platform mismatch, rate limiting, or DNS, TLS and network failures of the node. Combines the kubelet's pull errors with resolving the image through the registry API, and returns a remediation for each failing container"),
+		mcp.WithString("pod_name", mcp.Description("Name of the pod failing to pull its image"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the pod (default: default)")),
+		mcp.WithString("container", mcp.Description("Container to diagnose (default: all containers failing to pull their image)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_diagnose_image_pull", k8sTool.handleDiagnoseImagePull)))
+
+	s.AddTool(mcp.NewTool("k8s_apply_manifest_chunked",
+		mcp.WithDescription("Apply a large multi-document manifest (e.g. an operator bundle) in chunks, reporting progress per chunk. Resources are annotated with a checksum so a failed apply can be resumed by applying the same manifest again, skipping resources that are already up to date"),
+		mcp.WithString("manifest", mcp.Description("YAML manifest with one or more resources"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace for resources that do not set one")),
+		mcp.WithNumber("chunk_size", mcp.Description("Number of resources applied per chunk (default: 25)")),
+		mcp.WithString("resume", mcp.Description("Skip resources whose live checksum annotation matches the manifest (true/false, default: true)")),
+		mcp.WithString("continue_on_error", mcp.Description("Keep applying the remaining chunks after a chunk fails (true/false, default: false)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_apply_manifest_chunked", k8sTool.handleApplyManifestChunked)))
+
+	s.AddTool(mcp.NewTool("k8s_rollout_analyze",
+		mcp.WithDescription("Analyze an in-progress or failed Deployment rollout: compare the new and old ReplicaSets, explain pending pods (image pull, scheduling, probes, crashes) and return a verdict with a completion estimate or an undo recommendation"),
+		mcp.WithString("deployment", mcp.Description("Deployment name, optionally as deployment/name"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the deployment (default: default)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_rollout_analyze", k8sTool.handleRolloutAnalyze)))
+
 	s.AddTool(mcp.NewTool("k8s_execute_command",
 		mcp.WithDescription("Execute a command in a Kubernetes pod"),
 		mcp.WithString("pod_name", mcp.Description("Name of the pod to execute in"), mcp.Required()),
@@ -624,6 +914,35 @@ func RegisterTools(s *server.MCPServer, llm llms.Model, kubeconfig string) {
 		mcp.WithString("command", mcp.Description("Command to execute"), mcp.Required()),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_execute_command", k8sTool.handleExecCommand)))
 
+	s.AddTool(mcp.NewTool("k8s_cp_from_pod",
+		mcp.WithDescription("Copy a file or directory from a pod, such as a heap dump, core dump or config file, into the pod file store and return a pod-files:// reference to it. Directories are stored as gzipped tar archives"),
+		mcp.WithString("pod_name", mcp.Description("Name of the pod"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the pod (default: default)")),
+		mcp.WithString("container", mcp.Description("Container name (for multi-container pods)")),
+		mcp.WithString("path", mcp.Description("Absolute path of the file or directory in the container"), mcp.Required()),
+		mcp.WithNumber("max_bytes", mcp.Description("Refuse copies larger than this many bytes (default: 52428800)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_cp_from_pod", k8sTool.handleCopyFromPod)))
+
+	s.AddResourceTemplate(mcp.NewResourceTemplate(podFileResourceScheme+"{id}", "Pod file",
+		mcp.WithTemplateDescription("File or directory copied from a pod by k8s_cp_from_pod, as binary content"),
+	), k8sTool.handleReadPodFileResource)
+
+	s.AddTool(mcp.NewTool("k8s_generate_support_bundle",
+		mcp.WithDescription("Collect the diagnostics vendors ask for during escalations (resource YAML, events, logs, Helm releases, Istio config and analysis, Prometheus snapshots of key metrics) for a namespace or service into a compressed archive, and return a support-bundles:// reference with a manifest of what was collected. Secrets are not included"),
+		mcp.WithString("namespace", mcp.Description("Namespace to collect diagnostics from"), mcp.Required()),
+		mcp.WithString("service", mcp.Description("Limit logs and metrics to the pods selected by this service (optional)")),
+		mcp.WithString("sections", mcp.Description("Comma-separated sections to collect: resources, events, logs, helm, istio, metrics (default: all; metrics only when prometheus_url is given)")),
+		mcp.WithString("prometheus_url", mcp.Description("Prometheus server URL to snapshot metrics from (optional)")),
+		mcp.WithString("since", mcp.Description("Collect logs newer than this duration (e.g. 30m, default: 1h)")),
+		mcp.WithNumber("tail_lines", mcp.Description("Maximum log lines per container (default: 1000)")),
+		mcp.WithNumber("max_bytes", mcp.Description("Refuse bundles larger than this many bytes once compressed (default: 104857600)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_generate_support_bundle", k8sTool.handleGenerateSupportBundle)))
+
+	s.AddResourceTemplate(mcp.NewResourceTemplate(supportBundleResourceScheme+"{id}", "Support bundle",
+		mcp.WithTemplateDescription("Gzipped tar archive of diagnostics generated by k8s_generate_support_bundle, with a manifest.json listing its files"),
+		mcp.WithTemplateMIMEType("application/gzip"),
+	), k8sTool.handleReadSupportBundleResource)
+
 	s.AddTool(mcp.NewTool("k8s_get_available_api_resources",
 		mcp.WithDescription("Get available Kubernetes API resources"),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_get_available_api_resources", k8sTool.handleGetAvailableAPIResources)))
@@ -640,46 +959,66 @@ func RegisterTools(s *server.MCPServer, llm llms.Model, kubeconfig string) {
 		mcp.WithString("namespace", mcp.Description("The namespace of the resource")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_rollout", k8sTool.handleRollout)))
 
-	s.AddTool(mcp.NewTool("k8s_label_resource",
-		mcp.WithDescription("Add or update labels on a Kubernetes resource"),
+	s.AddTool(mcp.NewTool("k8s_restart_workload",
+		mcp.WithDescription("Restart a Deployment, StatefulSet or DaemonSet with a rolling restart, after checking that its PodDisruptionBudgets allow disruption, and report the resulting rollout state"),
+		mcp.WithString("resource_name", mcp.Description("Name of the workload"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the workload"), mcp.Required()),
+		mcp.WithString("resource_type", mcp.Description("Kind of workload (deployment, statefulset, daemonset; default: deployment)")),
+		mcp.WithString("force", mcp.Description("Restart even when a PodDisruptionBudget allows no disruptions (true/false, default: false)")),
+		mcp.WithString("wait", mcp.Description("Wait for the rollout to complete (true/false, default: true)")),
+		mcp.WithString("timeout", mcp.Description("How long to wait for the rollout (e.g. 5m, default: 5m)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_restart_workload", k8sTool.handleRestartWorkload)))
+
+	s.AddTool(mcp.NewTool("k8s_evict_pod",
+		mcp.WithDescription("Evict a pod through the Eviction API, which respects PodDisruptionBudgets, instead of deleting it, and report whether it was evicted or blocked"),
+		mcp.WithString("pod_name", mcp.Description("Name of the pod"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the pod"), mcp.Required()),
+		mcp.WithNumber("grace_period", mcp.Description("Termination grace period in seconds (default: the pod's own)")),
+		mcp.WithString("force", mcp.Description("Evict a pod that is not managed by a controller and will not be recreated (true/false, default: false)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_evict_pod", k8sTool.handleEvictPod)))
+
+	s.AddTool(mcp.NewTool("k8s_label_resource", withSelectorMode(
+		mcp.WithDescription("Add or update labels on a Kubernetes resource, or on all resources matching a selector"),
 		mcp.WithString("resource_type", mcp.Description("The type of resource"), mcp.Required()),
-		mcp.WithString("resource_name", mcp.Description("The name of the resource"), mcp.Required()),
+		mcp.WithString("resource_name", mcp.Description("The name of the resource (required unless a selector is given)")),
 		mcp.WithString("labels", mcp.Description("Space-separated key=value pairs for labels"), mcp.Required()),
 		mcp.WithString("namespace", mcp.Description("The namespace of the resource")),
-	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_label_resource", k8sTool.handleLabelResource)))
+		mcp.WithString("overwrite", mcp.Description("Replace values that keys already have (true/false, default: false)")),
+	)...), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_label_resource", k8sTool.handleLabelResource)))
 
-	s.AddTool(mcp.NewTool("k8s_annotate_resource",
-		mcp.WithDescription("Add or update annotations on a Kubernetes resource"),
+	s.AddTool(mcp.NewTool("k8s_annotate_resource", withSelectorMode(
+		mcp.WithDescription("Add or update annotations on a Kubernetes resource, or on all resources matching a selector"),
 		mcp.WithString("resource_type", mcp.Description("The type of resource"), mcp.Required()),
-		mcp.WithString("resource_name", mcp.Description("The name of the resource"), mcp.Required()),
+		mcp.WithString("resource_name", mcp.Description("The name of the resource (required unless a selector is given)")),
 		mcp.WithString("annotations", mcp.Description("Space-separated key=value pairs for annotations"), mcp.Required()),
 		mcp.WithString("namespace", mcp.Description("The namespace of the resource")),
-	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_annotate_resource", k8sTool.handleAnnotateResource)))
+		mcp.WithString("overwrite", mcp.Description("Replace values that keys already have (true/false, default: false)")),
+	)...), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_annotate_resource", k8sTool.handleAnnotateResource)))
 
-	s.AddTool(mcp.NewTool("k8s_remove_annotation",
-		mcp.WithDescription("Remove an annotation from a Kubernetes resource"),
+	s.AddTool(mcp.NewTool("k8s_remove_annotation", withSelectorMode(
+		mcp.WithDescription("Remove an annotation from a Kubernetes resource, or from all resources matching a selector"),
 		mcp.WithString("resource_type", mcp.Description("The type of resource"), mcp.Required()),
-		mcp.WithString("resource_name", mcp.Description("The name of the resource"), mcp.Required()),
+		mcp.WithString("resource_name", mcp.Description("The name of the resource (required unless a selector is given)")),
 		mcp.WithString("annotation_key", mcp.Description("The key of the annotation to remove"), mcp.Required()),
 		mcp.WithString("namespace", mcp.Description("The namespace of the resource")),
-	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_remove_annotation", k8sTool.handleRemoveAnnotation)))
+	)...), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_remove_annotation", k8sTool.handleRemoveAnnotation)))
 
-	s.AddTool(mcp.NewTool("k8s_remove_label",
-		mcp.WithDescription("Remove a label from a Kubernetes resource"),
+	s.AddTool(mcp.NewTool("k8s_remove_label", withSelectorMode(
+		mcp.WithDescription("Remove a label from a Kubernetes resource, or from all resources matching a selector"),
 		mcp.WithString("resource_type", mcp.Description("The type of resource"), mcp.Required()),
-		mcp.WithString("resource_name", mcp.Description("The name of the resource"), mcp.Required()),
+		mcp.WithString("resource_name", mcp.Description("The name of the resource (required unless a selector is given)")),
 		mcp.WithString("label_key", mcp.Description("The key of the label to remove"), mcp.Required()),
 		mcp.WithString("namespace", mcp.Description("The namespace of the resource")),
-	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_remove_label", k8sTool.handleRemoveLabel)))
+	)...), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_remove_label", k8sTool.handleRemoveLabel)))
 
 	s.AddTool(mcp.NewTool("k8s_create_resource",
 		mcp.WithDescription("Create a Kubernetes resource from YAML content"),
 		mcp.WithString("yaml_content", mcp.Description("YAML content of the resource"), mcp.Required()),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_create_resource", func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		yamlContent := mcp.ParseString(request, "yaml_content", "")
-
-		if yamlContent == "" {
-			return mcp.NewToolResultError("yaml_content is required"), nil
+		p := params.New(request)
+		yamlContent := p.String("yaml_content", "", params.Required())
+		if err := p.Err(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
 		}
 
 		// Create temporary file
@@ -694,46 +1033,34 @@ func RegisterTools(s *server.MCPServer, llm llms.Model, kubeconfig string) {
 		}
 		tmpFile.Close()
 
+		// Created resources did not exist before, so undoing deletes them
+		priors := createdResources(yamlContent)
 		result, err := k8sTool.runKubectlCommand(ctx, "create", "-f", tmpFile.Name())
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Create command failed: %v", err)), nil
 		}
+		recordChanges(ctx, result, "k8s_create_resource", "created", priors...)
 
 		return result, nil
 	})))
 
 	s.AddTool(mcp.NewTool("k8s_create_resource_from_url",
 		mcp.WithDescription("Create a Kubernetes resource from a URL pointing to a YAML manifest"),
-		mcp.WithString("url", mcp.Description("The URL of the manifest"), mcp.Required()),
+		mcp.WithString("url", mcp.Description("The public http(s) URL of the manifest; private and metadata addresses are refused"), mcp.Required()),
 		mcp.WithString("namespace", mcp.Description("The namespace to create the resource in")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_create_resource_from_url", k8sTool.handleCreateResourceFromURL)))
 
 	s.AddTool(mcp.NewTool("k8s_get_resource_yaml",
-		mcp.WithDescription("Get the YAML representation of a Kubernetes resource"),
+		mcp.WithDescription("Get the YAML representation of a Kubernetes resource, with managedFields removed and Secret data redacted by default"),
 		mcp.WithString("resource_type", mcp.Description("Type of resource"), mcp.Required()),
 		mcp.WithString("resource_name", mcp.Description("Name of the resource"), mcp.Required()),
 		mcp.WithString("namespace", mcp.Description("Namespace of the resource (optional)")),
-	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_get_resource_yaml", func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		resourceType := mcp.ParseString(request, "resource_type", "")
-		resourceName := mcp.ParseString(request, "resource_name", "")
-		namespace := mcp.ParseString(request, "namespace", "")
-
-		if resourceType == "" || resourceName == "" {
-			return mcp.NewToolResultError("resource_type and resource_name are required"), nil
-		}
-
-		args := []string{"get", resourceType, resourceName, "-o", "yaml"}
-		if namespace != "" {
-			args = append(args, "-n", namespace)
-		}
-
-		result, err := k8sTool.runKubectlCommand(ctx, args...)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Get YAML command failed: %v", err)), nil
-		}
-
-		return result, nil
-	})))
+		mcp.WithString("strip_managed_fields", mcp.Description("Remove metadata.managedFields (true/false, default: true)")),
+		mcp.WithString("strip_status", mcp.Description("Remove the status (true/false, default: false)")),
+		mcp.WithString("redact_secrets", mcp.Description("Replace the values of Secret data and stringData with REDACTED (true/false, default: true)")),
+		mcp.WithString("clean", mcp.Description("Remove server-set fields (uid, resourceVersion, generation, creationTimestamp, status, last-applied annotation) for a manifest that can be committed to git or re-applied (true/false, default: false)")),
+		mcp.WithString("jsonpath", mcp.Description("JSONPath template returning only part of the resource instead of its YAML, such as {.spec.replicas} or {.spec.template.spec.containers[*].image}. Secrets can only be projected with redact_secrets=false")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_get_resource_yaml", k8sTool.handleGetResourceYAML)))
 
 	s.AddTool(mcp.NewTool("k8s_describe_resource",
 		mcp.WithDescription("Describe a Kubernetes resource in detail"),
@@ -747,4 +1074,23 @@ func RegisterTools(s *server.MCPServer, llm llms.Model, kubeconfig string) {
 		mcp.WithString("resource_description", mcp.Description("Detailed description of the resource to generate"), mcp.Required()),
 		mcp.WithString("resource_type", mcp.Description(fmt.Sprintf("Type of resource to generate (%s)", strings.Join(slices.Collect(resourceTypes), ", "))), mcp.Required()),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("k8s_generate_resource", k8sTool.handleGenerateResource)))
+
+	s.AddTool(mcp.NewTool("change_history",
+		mcp.WithDescription("List the changes the Kubernetes and Helm tools made, the most recent first, with the prior state each change can be undone to: replica count, manifest or Helm revision"),
+		mcp.WithString("resource_type", mcp.Description("Only list changes to resources of this type, e.g. deployment or helm_release")),
+		mcp.WithString("resource_name", mcp.Description("Only list changes to resources with this name")),
+		mcp.WithString("namespace", mcp.Description("Only list changes in this namespace")),
+		mcp.WithString("include_undone", mcp.Description("Also list the changes that were undone (true/false, default: true)")),
+		mcp.WithString("include_manifests", mcp.Description("Include the prior manifests (true/false, default: false)")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of changes to list (default: 20)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("change_history", k8sTool.handleChangeHistory)))
+
+	s.AddTool(mcp.NewTool("undo_last_change",
+		mcp.WithDescription("Undo the last change the tools made to a resource by restoring its prior state: scaling back, replacing or recreating the prior manifest, deleting what was created, or rolling a Helm release back. Changes made since outside these tools are overwritten"),
+		mcp.WithString("resource_type", mcp.Description("Type of the resource, e.g. deployment, or helm_release for Helm releases"), mcp.Required()),
+		mcp.WithString("resource_name", mcp.Description("Name of the resource or release"), mcp.Required()),
+		mcp.WithString("namespace", mcp.Description("Namespace of the resource (default: default, or none for cluster-scoped resources)")),
+		mcp.WithString("all_namespaces", mcp.Description("Undo the last change to the resource in any namespace (true/false, default: false)")),
+		mcp.WithString("dry_run", mcp.Description("Only show the change that would be undone and how (true/false, default: false)")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("undo_last_change", k8sTool.handleUndoLastChange)))
 }
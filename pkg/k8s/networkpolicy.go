@@ -0,0 +1,468 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// networkPolicyWorkloadKinds are the workload kinds accepted as traffic endpoints
+var networkPolicyWorkloadKinds = []string{"pod", "deployment", "statefulset", "daemonset", "replicaset", "job"}
+
+// labelSelector is a Kubernetes label selector
+type labelSelector struct {
+	MatchLabels      map[string]string `json:"matchLabels"`
+	MatchExpressions []struct {
+		Key      string   `json:"key"`
+		Operator string   `json:"operator"`
+		Values   []string `json:"values"`
+	} `json:"matchExpressions"`
+}
+
+// matches reports whether labels satisfy the selector; an empty selector matches everything
+func (s labelSelector) matches(labels map[string]string) bool {
+	for key, value := range s.MatchLabels {
+		if actual, ok := labels[key]; !ok || actual != value {
+			return false
+		}
+	}
+	for _, expression := range s.MatchExpressions {
+		value, ok := labels[expression.Key]
+		switch expression.Operator {
+		case "In":
+			if !ok || !containsString(expression.Values, value) {
+				return false
+			}
+		case "NotIn":
+			if ok && containsString(expression.Values, value) {
+				return false
+			}
+		case "Exists":
+			if !ok {
+				return false
+			}
+		case "DoesNotExist":
+			if ok {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// networkPolicyPeer is an entry of a NetworkPolicy rule's from or to list
+type networkPolicyPeer struct {
+	PodSelector       *labelSelector `json:"podSelector"`
+	NamespaceSelector *labelSelector `json:"namespaceSelector"`
+	IPBlock           *struct {
+		CIDR   string   `json:"cidr"`
+		Except []string `json:"except"`
+	} `json:"ipBlock"`
+}
+
+// networkPolicyPort is an entry of a NetworkPolicy rule's ports list; Port is a number or a named port
+type networkPolicyPort struct {
+	Protocol string           `json:"protocol"`
+	Port     *json.RawMessage `json:"port"`
+	EndPort  *int             `json:"endPort"`
+}
+
+// networkPolicyRule is an ingress or egress rule, with peers in From or To respectively
+type networkPolicyRule struct {
+	From  []networkPolicyPeer `json:"from"`
+	To    []networkPolicyPeer `json:"to"`
+	Ports []networkPolicyPort `json:"ports"`
+}
+
+// networkPolicy is the subset of a NetworkPolicy used for evaluation
+type networkPolicy struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		PodSelector labelSelector       `json:"podSelector"`
+		PolicyTypes []string            `json:"policyTypes"`
+		Ingress     []networkPolicyRule `json:"ingress"`
+		Egress      []networkPolicyRule `json:"egress"`
+	} `json:"spec"`
+}
+
+// appliesTo reports whether the policy isolates its selected pods in a direction
+func (p networkPolicy) appliesTo(direction string) bool {
+	if len(p.Spec.PolicyTypes) == 0 {
+		// Without policyTypes, ingress always applies and egress only when rules are present
+		return direction == "Ingress" || len(p.Spec.Egress) > 0
+	}
+	return containsString(p.Spec.PolicyTypes, direction)
+}
+
+// containerPort is a named container port of a workload
+type containerPort struct {
+	Name          string `json:"name"`
+	ContainerPort int    `json:"containerPort"`
+	Protocol      string `json:"protocol"`
+}
+
+// podTemplate is the subset of a pod spec used to resolve named ports
+type podTemplate struct {
+	Containers []struct {
+		Ports []containerPort `json:"ports"`
+	} `json:"containers"`
+}
+
+// NetworkEndpoint is a resolved source or destination of the checked traffic
+type NetworkEndpoint struct {
+	Workload  string            `json:"workload"`
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels"`
+	PodIP     string            `json:"pod_ip,omitempty"`
+
+	namespaceLabels map[string]string
+	ports           []containerPort
+}
+
+// DirectionVerdict is the outcome of evaluating policies for one direction of the traffic
+type DirectionVerdict struct {
+	Isolated          bool     `json:"isolated"`
+	Allowed           bool     `json:"allowed"`
+	SelectingPolicies []string `json:"selecting_policies"`
+	AllowedBy         []string `json:"allowed_by,omitempty"`
+	BlockedBy         []string `json:"blocked_by,omitempty"`
+}
+
+// NetworkPolicyCheckResult is the structured response of k8s_networkpolicy_check
+type NetworkPolicyCheckResult struct {
+	Source      NetworkEndpoint  `json:"source"`
+	Destination NetworkEndpoint  `json:"destination"`
+	Port        string           `json:"port"`
+	Protocol    string           `json:"protocol"`
+	Allowed     bool             `json:"allowed"`
+	Reason      string           `json:"reason"`
+	Egress      DirectionVerdict `json:"egress"`
+	Ingress     DirectionVerdict `json:"ingress"`
+}
+
+// resolvePort returns the number and name of the destination port, looking up named container ports
+func resolvePort(port, protocol string, ports []containerPort) (int, string) {
+	if number, err := strconv.Atoi(port); err == nil {
+		for _, p := range ports {
+			if p.ContainerPort == number && protocolOrTCP(p.Protocol) == protocol {
+				return number, p.Name
+			}
+		}
+		return number, ""
+	}
+	for _, p := range ports {
+		if p.Name == port && protocolOrTCP(p.Protocol) == protocol {
+			return p.ContainerPort, port
+		}
+	}
+	return 0, port
+}
+
+// protocolOrTCP applies the Kubernetes default protocol
+func protocolOrTCP(protocol string) string {
+	if protocol == "" {
+		return "TCP"
+	}
+	return protocol
+}
+
+// portsMatch reports whether a rule's ports admit the destination port; no ports admits all
+func portsMatch(ports []networkPolicyPort, number int, name, protocol string) bool {
+	if len(ports) == 0 {
+		return true
+	}
+	for _, p := range ports {
+		if protocolOrTCP(p.Protocol) != protocol {
+			continue
+		}
+		if p.Port == nil {
+			return true
+		}
+		var portNumber int
+		if err := json.Unmarshal(*p.Port, &portNumber); err == nil {
+			endPort := portNumber
+			if p.EndPort != nil {
+				endPort = *p.EndPort
+			}
+			if number >= portNumber && number <= endPort {
+				return true
+			}
+			continue
+		}
+		var portName string
+		if err := json.Unmarshal(*p.Port, &portName); err == nil && name != "" && portName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// peerMatches reports whether a rule peer selects the endpoint, for a policy in policyNamespace
+func peerMatches(peer networkPolicyPeer, policyNamespace string, endpoint NetworkEndpoint) bool {
+	if peer.IPBlock != nil {
+		ip := net.ParseIP(endpoint.PodIP)
+		if ip == nil {
+			return false
+		}
+		_, cidr, err := net.ParseCIDR(peer.IPBlock.CIDR)
+		if err != nil || !cidr.Contains(ip) {
+			return false
+		}
+		for _, except := range peer.IPBlock.Except {
+			if _, exceptCIDR, err := net.ParseCIDR(except); err == nil && exceptCIDR.Contains(ip) {
+				return false
+			}
+		}
+		return true
+	}
+	if peer.NamespaceSelector == nil {
+		// A pod selector alone only selects pods in the policy's namespace
+		if endpoint.Namespace != policyNamespace {
+			return false
+		}
+	} else if !peer.NamespaceSelector.matches(endpoint.namespaceLabels) {
+		return false
+	}
+	return peer.PodSelector == nil || peer.PodSelector.matches(endpoint.Labels)
+}
+
+// evaluateDirection evaluates the policies isolating target against traffic with peer.
+// direction is Ingress (peer is the source) or Egress (peer is the destination).
+func evaluateDirection(policies []networkPolicy, direction string, target, peer NetworkEndpoint, number int, name, protocol string) DirectionVerdict {
+	verdict := DirectionVerdict{SelectingPolicies: []string{}}
+	for _, policy := range policies {
+		if policy.Metadata.Namespace != target.Namespace || !policy.appliesTo(direction) || !policy.Spec.PodSelector.matches(target.Labels) {
+			continue
+		}
+		verdict.Isolated = true
+		verdict.SelectingPolicies = append(verdict.SelectingPolicies, policy.Metadata.Name)
+
+		rules := policy.Spec.Ingress
+		if direction == "Egress" {
+			rules = policy.Spec.Egress
+		}
+		allowed := false
+		for _, rule := range rules {
+			peers := rule.From
+			if direction == "Egress" {
+				peers = rule.To
+			}
+			peerAllowed := len(peers) == 0
+			for _, p := range peers {
+				if peerMatches(p, policy.Metadata.Namespace, peer) {
+					peerAllowed = true
+					break
+				}
+			}
+			if peerAllowed && portsMatch(rule.Ports, number, name, protocol) {
+				allowed = true
+				break
+			}
+		}
+		if allowed {
+			verdict.AllowedBy = append(verdict.AllowedBy, policy.Metadata.Name)
+		} else {
+			verdict.BlockedBy = append(verdict.BlockedBy, policy.Metadata.Name)
+		}
+	}
+	// Policies are additive: traffic passes when any selecting policy allows it
+	verdict.Allowed = !verdict.Isolated || len(verdict.AllowedBy) > 0
+	if verdict.Allowed {
+		verdict.BlockedBy = nil
+	}
+	return verdict
+}
+
+// parseWorkloadRef splits kind/name, defaulting to a pod when no kind is given
+func parseWorkloadRef(ref string) (string, string, error) {
+	kind, name := "pod", ref
+	if before, after, found := strings.Cut(ref, "/"); found {
+		kind, name = strings.ToLower(before), after
+	}
+	if !containsString(networkPolicyWorkloadKinds, kind) {
+		return "", "", fmt.Errorf("unsupported workload kind %q, must be one of: %s", kind, strings.Join(networkPolicyWorkloadKinds, ", "))
+	}
+	if err := security.ValidateK8sResourceName(name); err != nil {
+		return "", "", err
+	}
+	return kind, name, nil
+}
+
+// resolveEndpoint fetches a workload's pod labels and ports and its namespace labels
+func (k *K8sTool) resolveEndpoint(ctx context.Context, ref, namespace string) (NetworkEndpoint, error) {
+	kind, name, err := parseWorkloadRef(ref)
+	if err != nil {
+		return NetworkEndpoint{}, err
+	}
+	output, err := k.runKubectlCommandString(ctx, "get", kind, name, "-n", namespace, "-o", "json")
+	if err != nil {
+		return NetworkEndpoint{}, fmt.Errorf("failed to get %s/%s: %w", kind, name, err)
+	}
+	var workload struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+		Spec struct {
+			podTemplate
+			Template struct {
+				Metadata struct {
+					Labels map[string]string `json:"labels"`
+				} `json:"metadata"`
+				Spec podTemplate `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+		Status struct {
+			PodIP string `json:"podIP"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(output), &workload); err != nil {
+		return NetworkEndpoint{}, fmt.Errorf("failed to parse %s/%s: %w", kind, name, err)
+	}
+
+	endpoint := NetworkEndpoint{Workload: kind + "/" + name, Namespace: namespace}
+	spec := workload.Spec.Template.Spec
+	if kind == "pod" {
+		endpoint.Labels = workload.Metadata.Labels
+		endpoint.PodIP = workload.Status.PodIP
+		spec = workload.Spec.podTemplate
+	} else {
+		endpoint.Labels = workload.Spec.Template.Metadata.Labels
+	}
+	if endpoint.Labels == nil {
+		endpoint.Labels = map[string]string{}
+	}
+	for _, container := range spec.Containers {
+		endpoint.ports = append(endpoint.ports, container.Ports...)
+	}
+
+	output, err = k.runKubectlCommandString(ctx, "get", "namespace", namespace, "-o", "json")
+	if err != nil {
+		return NetworkEndpoint{}, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	var ns struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(output), &ns); err != nil {
+		return NetworkEndpoint{}, fmt.Errorf("failed to parse namespace %s: %w", namespace, err)
+	}
+	endpoint.namespaceLabels = ns.Metadata.Labels
+	if endpoint.namespaceLabels == nil {
+		endpoint.namespaceLabels = map[string]string{}
+	}
+	// Set by the API server since Kubernetes 1.22, but older namespaces may lack it
+	endpoint.namespaceLabels["kubernetes.io/metadata.name"] = namespace
+	return endpoint, nil
+}
+
+// listNetworkPolicies fetches the NetworkPolicies of the given namespaces
+func (k *K8sTool) listNetworkPolicies(ctx context.Context, namespaces ...string) ([]networkPolicy, error) {
+	var policies []networkPolicy
+	seen := make(map[string]bool)
+	for _, namespace := range namespaces {
+		if seen[namespace] {
+			continue
+		}
+		seen[namespace] = true
+
+		output, err := k.runKubectlCommandString(ctx, "get", "networkpolicies", "-n", namespace, "-o", "json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get network policies in %s: %w", namespace, err)
+		}
+		var list struct {
+			Items []networkPolicy `json:"items"`
+		}
+		if err := json.Unmarshal([]byte(output), &list); err != nil {
+			return nil, fmt.Errorf("failed to parse network policies in %s: %w", namespace, err)
+		}
+		policies = append(policies, list.Items...)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Metadata.Name < policies[j].Metadata.Name
+	})
+	return policies, nil
+}
+
+// NetworkPolicy traffic simulation
+func (k *K8sTool) handleNetworkPolicyCheck(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	source := p.String("source", "", params.Required())
+	sourceNamespace := p.String("source_namespace", "default", params.Check(security.ValidateNamespace))
+	destination := p.String("destination", "", params.Required())
+	destinationNamespace := p.String("destination_namespace", sourceNamespace, params.Check(security.ValidateNamespace))
+	port := p.String("port", "", params.Required())
+	protocol := p.String("protocol", "TCP", params.OneOf("TCP", "UDP", "SCTP"))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if number, err := strconv.Atoi(port); err == nil && (number < 1 || number > 65535) {
+		return mcp.NewToolResultError(fmt.Sprintf("port parameter must be between 1 and 65535, got %d", number)), nil
+	}
+
+	src, err := k.resolveEndpoint(ctx, source, sourceNamespace)
+	if err != nil {
+		return mcp.NewToolResultError("Error resolving source: " + err.Error()), nil
+	}
+	dst, err := k.resolveEndpoint(ctx, destination, destinationNamespace)
+	if err != nil {
+		return mcp.NewToolResultError("Error resolving destination: " + err.Error()), nil
+	}
+	number, name := resolvePort(port, protocol, dst.ports)
+
+	policies, err := k.listNetworkPolicies(ctx, sourceNamespace, destinationNamespace)
+	if err != nil {
+		return mcp.NewToolResultError("Error listing network policies: " + err.Error()), nil
+	}
+
+	result := NetworkPolicyCheckResult{
+		Source:      src,
+		Destination: dst,
+		Port:        port,
+		Protocol:    protocol,
+		Egress:      evaluateDirection(policies, "Egress", src, dst, number, name, protocol),
+		Ingress:     evaluateDirection(policies, "Ingress", dst, src, number, name, protocol),
+	}
+	result.Allowed = result.Egress.Allowed && result.Ingress.Allowed
+	switch {
+	case !result.Egress.Allowed:
+		result.Reason = fmt.Sprintf("egress from %s is blocked by %s", src.Workload, strings.Join(result.Egress.BlockedBy, ", "))
+	case !result.Ingress.Allowed:
+		result.Reason = fmt.Sprintf("ingress to %s is blocked by %s", dst.Workload, strings.Join(result.Ingress.BlockedBy, ", "))
+	case !result.Egress.Isolated && !result.Ingress.Isolated:
+		result.Reason = "no network policy selects either workload"
+	default:
+		result.Reason = "allowed by " + strings.Join(append(result.Egress.AllowedBy, result.Ingress.AllowedBy...), ", ")
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling network policy check: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
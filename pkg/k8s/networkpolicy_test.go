@@ -0,0 +1,129 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebDeployment = `{"metadata":{"labels":{"team":"shop"}},
+ "spec":{"template":{"metadata":{"labels":{"app":"web"}},"spec":{"containers":[{"ports":[{"name":"http","containerPort":8080}]}]}}}}`
+
+const testDBPod = `{"metadata":{"labels":{"app":"db","tier":"data"}},
+ "spec":{"containers":[{"ports":[{"name":"postgres","containerPort":5432}]}]},
+ "status":{"podIP":"10.0.1.5"}}`
+
+const testShopPolicies = `{"items":[
+{"metadata":{"name":"default-deny","namespace":"shop"},"spec":{"podSelector":{},"policyTypes":["Ingress"]}},
+{"metadata":{"name":"db-from-web","namespace":"shop"},
+ "spec":{"podSelector":{"matchLabels":{"app":"db"}},
+  "ingress":[{"from":[{"podSelector":{"matchLabels":{"app":"web"}}}],"ports":[{"port":"postgres"}]}]}}
+]}`
+
+func TestLabelSelectorMatches(t *testing.T) {
+	var selector labelSelector
+	require.NoError(t, json.Unmarshal([]byte(`{"matchLabels":{"app":"db"},
+	 "matchExpressions":[{"key":"tier","operator":"In","values":["data","cache"]},{"key":"canary","operator":"DoesNotExist"}]}`), &selector))
+
+	assert.True(t, selector.matches(map[string]string{"app": "db", "tier": "data"}))
+	assert.False(t, selector.matches(map[string]string{"app": "db", "tier": "web"}))
+	assert.False(t, selector.matches(map[string]string{"app": "db", "tier": "data", "canary": "true"}))
+	assert.True(t, labelSelector{}.matches(nil))
+}
+
+func TestPortsMatch(t *testing.T) {
+	var ports []networkPolicyPort
+	require.NoError(t, json.Unmarshal([]byte(`[{"port":8000,"endPort":8100},{"protocol":"UDP","port":53},{"port":"metrics"}]`), &ports))
+
+	assert.True(t, portsMatch(ports, 8080, "", "TCP"))
+	assert.False(t, portsMatch(ports, 53, "", "TCP"))
+	assert.True(t, portsMatch(ports, 53, "", "UDP"))
+	assert.True(t, portsMatch(ports, 9090, "metrics", "TCP"))
+	assert.True(t, portsMatch(nil, 1, "", "TCP"))
+}
+
+func TestHandleNetworkPolicyCheck(t *testing.T) {
+	newMock := func(shopPolicies string) *cmd.MockShellExecutor {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "deployment", "web", "-n", "shop", "-o", "json"}, testWebDeployment, nil)
+		mock.AddCommandString("kubectl", []string{"get", "pod", "db-0", "-n", "shop", "-o", "json"}, testDBPod, nil)
+		mock.AddCommandString("kubectl", []string{"get", "namespace", "shop", "-o", "json"}, `{"metadata":{"labels":{"env":"prod"}}}`, nil)
+		mock.AddCommandString("kubectl", []string{"get", "networkpolicies", "-n", "shop", "-o", "json"}, shopPolicies, nil)
+		return mock
+	}
+	check := func(t *testing.T, mock *cmd.MockShellExecutor, args map[string]interface{}) NetworkPolicyCheckResult {
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		result, err := newTestK8sTool().handleNetworkPolicyCheck(ctx, req)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var response NetworkPolicyCheckResult
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &response))
+		return response
+	}
+
+	t.Run("allowed by named port", func(t *testing.T) {
+		response := check(t, newMock(testShopPolicies), map[string]interface{}{
+			"source": "deployment/web", "source_namespace": "shop", "destination": "db-0", "port": "5432",
+		})
+		assert.True(t, response.Allowed)
+		assert.False(t, response.Egress.Isolated)
+		assert.True(t, response.Ingress.Isolated)
+		assert.Equal(t, []string{"db-from-web", "default-deny"}, response.Ingress.SelectingPolicies)
+		assert.Equal(t, []string{"db-from-web"}, response.Ingress.AllowedBy)
+		assert.Empty(t, response.Ingress.BlockedBy)
+		assert.Equal(t, "allowed by db-from-web", response.Reason)
+	})
+
+	t.Run("blocked on other port", func(t *testing.T) {
+		response := check(t, newMock(testShopPolicies), map[string]interface{}{
+			"source": "deployment/web", "source_namespace": "shop", "destination": "db-0", "port": "6432",
+		})
+		assert.False(t, response.Allowed)
+		assert.Equal(t, []string{"db-from-web", "default-deny"}, response.Ingress.BlockedBy)
+		assert.Contains(t, response.Reason, "ingress to pod/db-0 is blocked by db-from-web, default-deny")
+	})
+
+	t.Run("egress blocked", func(t *testing.T) {
+		policies := `{"items":[{"metadata":{"name":"web-egress","namespace":"shop"},
+		 "spec":{"podSelector":{"matchLabels":{"app":"web"}},"policyTypes":["Egress"],
+		  "egress":[{"to":[{"ipBlock":{"cidr":"10.0.0.0/16","except":["10.0.1.0/24"]}}]}]}}]}`
+		response := check(t, newMock(policies), map[string]interface{}{
+			"source": "deployment/web", "source_namespace": "shop", "destination": "db-0", "port": "postgres",
+		})
+		assert.False(t, response.Allowed)
+		assert.False(t, response.Ingress.Isolated)
+		assert.Equal(t, []string{"web-egress"}, response.Egress.BlockedBy)
+	})
+
+	t.Run("no policies", func(t *testing.T) {
+		response := check(t, newMock(`{"items":[]}`), map[string]interface{}{
+			"source": "deployment/web", "source_namespace": "shop", "destination": "db-0", "port": "5432",
+		})
+		assert.True(t, response.Allowed)
+		assert.Equal(t, "no network policy selects either workload", response.Reason)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		tests := []map[string]interface{}{
+			{"destination": "db-0", "port": "5432"},
+			{"source": "service/web", "destination": "db-0", "port": "5432"},
+			{"source": "deployment/web", "source_namespace": "shop", "destination": "db-0", "port": "70000"},
+			{"source": "web", "destination": "db-0", "port": "5432", "protocol": "ICMP"},
+		}
+		for _, args := range tests {
+			req := mcp.CallToolRequest{}
+			req.Params.Arguments = args
+			result, err := newTestK8sTool().handleNetworkPolicyCheck(cmd.WithShellExecutor(context.Background(), newMock(testShopPolicies)), req)
+			require.NoError(t, err)
+			assert.True(t, result.IsError, args)
+		}
+	})
+}
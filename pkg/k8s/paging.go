@@ -0,0 +1,252 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kagent-dev/tools/internal/cliout"
+)
+
+// maxResourceListBytes is the size of a resource listing above which
+// k8s_get_resources switches to counts per namespace
+const maxResourceListBytes = 256 * 1024
+
+// defaultPageSize is the page size when paging is continued without max_items
+const defaultPageSize = 500
+
+// Summary modes of k8s_get_resources
+const (
+	summarizeAuto   = "auto"
+	summarizeAlways = "always"
+	summarizeNever  = "never"
+)
+
+// apiResource is a resource type served by the API server, as listed by kubectl api-resources
+type apiResource struct {
+	name         string
+	shortNames   []string
+	groupVersion string
+	namespaced   bool
+	kind         string
+}
+
+// group returns the API group of the resource, empty for the core group
+func (r apiResource) group() string {
+	group, _, found := strings.Cut(r.groupVersion, "/")
+	if !found {
+		return ""
+	}
+	return group
+}
+
+// parseAPIResources parses the output of kubectl api-resources
+func parseAPIResources(output string) ([]apiResource, error) {
+	parsed, err := cliout.ParseAPIResources(output)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]apiResource, 0, len(parsed))
+	for _, r := range parsed {
+		resources = append(resources, apiResource{
+			name:         r.Name,
+			shortNames:   r.ShortNames,
+			groupVersion: r.APIVersion,
+			namespaced:   r.Namespaced,
+			kind:         r.Kind,
+		})
+	}
+	return resources, nil
+}
+
+// findAPIResource resolves a resource type as accepted by kubectl get, e.g.
+// pods, pod, po, Pod or deployments.apps
+func findAPIResource(resources []apiResource, resourceType string) (apiResource, bool) {
+	name, group, _ := strings.Cut(strings.ToLower(resourceType), ".")
+	for _, resource := range resources {
+		if group != "" && resource.group() != group {
+			continue
+		}
+		if resource.name == name || strings.ToLower(resource.kind) == name || slices.Contains(resource.shortNames, name) {
+			return resource, true
+		}
+	}
+	return apiResource{}, false
+}
+
+// listPath returns the API path listing the resources, in a namespace or across all namespaces
+func (r apiResource) listPath(namespace string) string {
+	path := "/apis/" + r.groupVersion
+	if r.group() == "" {
+		path = "/api/" + r.groupVersion
+	}
+	if r.namespaced && namespace != "" {
+		path += "/namespaces/" + url.PathEscape(namespace)
+	}
+	return path + "/" + r.name
+}
+
+// ResourceRow is the compact form of a listed resource
+type ResourceRow struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Created   string `json:"created,omitempty"`
+	Phase     string `json:"phase,omitempty"`
+}
+
+// ResourcePage is a page of a paginated resource listing
+type ResourcePage struct {
+	ResourceType string        `json:"resource_type"`
+	Items        []interface{} `json:"items"`
+	// Continue is passed as continue to fetch the next page; empty on the last page
+	Continue           string `json:"continue,omitempty"`
+	RemainingItemCount *int64 `json:"remaining_item_count,omitempty"`
+}
+
+// NamespaceCount is the number of resources in a namespace
+type NamespaceCount struct {
+	Namespace string `json:"namespace"`
+	Count     int    `json:"count"`
+}
+
+// ResourceSummary replaces a resource listing that is too large to return
+type ResourceSummary struct {
+	ResourceType string           `json:"resource_type"`
+	Total        int              `json:"total"`
+	Namespaces   []NamespaceCount `json:"namespaces"`
+	Note         string           `json:"note,omitempty"`
+	// OutputURI references the full listing, to be read with fetch_blob
+	OutputURI string `json:"output_uri,omitempty"`
+}
+
+// getResourcesPage lists a page of resources with the limit and continue
+// parameters of the API server. Items are returned in full for json and yaml
+// output and as compact rows otherwise.
+func (k *K8sTool) getResourcesPage(ctx context.Context, resourceType, namespace string, allNamespaces bool, limit int, continueToken, output string) (*mcp.CallToolResult, error) {
+	resourcesOutput, err := k.runKubectlCommandString(ctx, "api-resources")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list API resources: %v", err)), nil
+	}
+	apiResources, err := parseAPIResources(resourcesOutput)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse API resources: %v", err)), nil
+	}
+	resource, ok := findAPIResource(apiResources, resourceType)
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("unknown resource type %q", resourceType)), nil
+	}
+
+	if allNamespaces {
+		namespace = ""
+	} else if namespace == "" {
+		namespace = "default"
+	}
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	query := url.Values{"limit": {strconv.Itoa(limit)}}
+	if continueToken != "" {
+		query.Set("continue", continueToken)
+	}
+
+	raw, err := k.runKubectlCommandString(ctx, "get", "--raw", resource.listPath(namespace)+"?"+query.Encode())
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var list struct {
+		Metadata struct {
+			Continue           string `json:"continue"`
+			RemainingItemCount *int64 `json:"remainingItemCount"`
+		} `json:"metadata"`
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to parse %s list: %v", resource.name, err)), nil
+	}
+
+	page := ResourcePage{
+		ResourceType:       resource.name,
+		Items:              make([]interface{}, 0, len(list.Items)),
+		Continue:           list.Metadata.Continue,
+		RemainingItemCount: list.Metadata.RemainingItemCount,
+	}
+	for _, item := range list.Items {
+		if output == "json" || output == "yaml" {
+			page.Items = append(page.Items, item)
+			continue
+		}
+		metadata, _ := item["metadata"].(map[string]interface{})
+		status, _ := item["status"].(map[string]interface{})
+		row := ResourceRow{}
+		row.Namespace, _ = metadata["namespace"].(string)
+		row.Name, _ = metadata["name"].(string)
+		row.Created, _ = metadata["creationTimestamp"].(string)
+		row.Phase, _ = status["phase"].(string)
+		page.Items = append(page.Items, row)
+	}
+
+	data, err := json.MarshalIndent(page, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal page: %v", err)), nil
+	}
+	if output == "yaml" {
+		// JSON is valid YAML, so reencoding keeps the JSON field names
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal page: %v", err)), nil
+		}
+		if data, err = yaml.Marshal(document); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal page: %v", err)), nil
+		}
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// summarizeResources counts resources per namespace, listing only their namespaces
+func (k *K8sTool) summarizeResources(ctx context.Context, resourceType string, scopeArgs []string, note, outputURI string) (*mcp.CallToolResult, error) {
+	args := append([]string{"get", resourceType}, scopeArgs...)
+	args = append(args, "-o", "custom-columns=NAMESPACE:.metadata.namespace", "--no-headers")
+	output, err := k.runKubectlCommandString(ctx, args...)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	counts := map[string]int{}
+	summary := ResourceSummary{ResourceType: resourceType, Namespaces: []NamespaceCount{}, Note: note, OutputURI: outputURI}
+	for _, line := range strings.Split(output, "\n") {
+		namespace := strings.TrimSpace(line)
+		if namespace == "" {
+			continue
+		}
+		if namespace == "<none>" {
+			// Cluster-scoped resources
+			namespace = ""
+		}
+		counts[namespace]++
+		summary.Total++
+	}
+	for namespace, count := range counts {
+		summary.Namespaces = append(summary.Namespaces, NamespaceCount{Namespace: namespace, Count: count})
+	}
+	sort.Slice(summary.Namespaces, func(i, j int) bool {
+		a, b := summary.Namespaces[i], summary.Namespaces[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Namespace < b.Namespace
+	})
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal summary: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,176 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/blobs"
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const testAPIResources = `NAME          SHORTNAMES   APIVERSION             NAMESPACED   KIND
+namespaces    ns           v1                     false        Namespace
+pods          po           v1                     true         Pod
+bindings                   v1                     true         Binding
+deployments   deploy       apps/v1                true         Deployment
+ingresses     ing          networking.k8s.io/v1   true         Ingress
+`
+
+func TestFindAPIResource(t *testing.T) {
+	resources, err := parseAPIResources(testAPIResources)
+	require.NoError(t, err)
+	require.Len(t, resources, 5)
+	assert.Nil(t, resources[2].shortNames)
+
+	for _, resourceType := range []string{"pods", "pod", "po", "Pod"} {
+		resource, ok := findAPIResource(resources, resourceType)
+		require.True(t, ok, resourceType)
+		assert.Equal(t, "/api/v1/namespaces/prod/pods", resource.listPath("prod"))
+		assert.Equal(t, "/api/v1/pods", resource.listPath(""))
+	}
+
+	resource, ok := findAPIResource(resources, "deployments.apps")
+	require.True(t, ok)
+	assert.Equal(t, "/apis/apps/v1/deployments", resource.listPath(""))
+
+	resource, ok = findAPIResource(resources, "ingresses.networking.k8s.io")
+	require.True(t, ok)
+	assert.Equal(t, "networking.k8s.io", resource.group())
+
+	resource, ok = findAPIResource(resources, "ns")
+	require.True(t, ok)
+	assert.Equal(t, "/api/v1/namespaces", resource.listPath("prod"))
+
+	_, ok = findAPIResource(resources, "deployments.extensions")
+	assert.False(t, ok)
+}
+
+func TestHandleKubectlGetEnhancedPaging(t *testing.T) {
+	page := `{"kind":"PodList","metadata":{"continue":"eyJ2IjoibWV0YS5rOHMuaW8vdjEifQ==","remainingItemCount":3},"items":[
+		{"metadata":{"name":"web-1","namespace":"prod","creationTimestamp":"2024-01-01T10:00:00Z"},"status":{"phase":"Running"}},
+		{"metadata":{"name":"web-2","namespace":"prod","creationTimestamp":"2024-01-01T10:00:00Z"},"status":{"phase":"Pending"}}]}`
+
+	t.Run("first page", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"api-resources"}, testAPIResources, nil)
+		mock.AddCommandString("kubectl", []string{"get", "--raw", "/api/v1/pods?limit=2"}, page, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleKubectlGetEnhanced(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"resource_type": "pods", "all_namespaces": "true", "max_items": float64(2),
+		}}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var got struct {
+			ResourcePage
+			Items []ResourceRow `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &got))
+		assert.Equal(t, "pods", got.ResourceType)
+		assert.Equal(t, []ResourceRow{
+			{Namespace: "prod", Name: "web-1", Created: "2024-01-01T10:00:00Z", Phase: "Running"},
+			{Namespace: "prod", Name: "web-2", Created: "2024-01-01T10:00:00Z", Phase: "Pending"},
+		}, got.Items)
+		assert.Equal(t, "eyJ2IjoibWV0YS5rOHMuaW8vdjEifQ==", got.Continue)
+		require.NotNil(t, got.RemainingItemCount)
+		assert.Equal(t, int64(3), *got.RemainingItemCount)
+	})
+
+	t.Run("next page in yaml", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"api-resources"}, testAPIResources, nil)
+		mock.AddCommandString("kubectl", []string{"get", "--raw", "/apis/apps/v1/namespaces/default/deployments?continue=abc%3D&limit=500"},
+			`{"metadata":{},"items":[{"metadata":{"name":"web"},"spec":{"replicas":2}}]}`, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleKubectlGetEnhanced(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"resource_type": "deploy", "continue": "abc=", "output": "yaml",
+		}}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+		assert.Contains(t, getResultText(result), "resource_type: deployments")
+		assert.Contains(t, getResultText(result), "replicas: 2")
+	})
+
+	t.Run("unknown resource type", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"api-resources"}, testAPIResources, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleKubectlGetEnhanced(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"resource_type": "widgets", "max_items": float64(10),
+		}}})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestHandleKubectlGetEnhancedSummary(t *testing.T) {
+	namespaces := "prod\nprod\nstaging\nprod\n"
+
+	t.Run("large listings are summarized", func(t *testing.T) {
+		previous := blobs.Default()
+		blobs.SetDefault(blobs.NewMemoryStore())
+		defer blobs.SetDefault(previous)
+
+		large := strings.Repeat("x", maxResourceListBytes+1)
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pods", "--all-namespaces", "-o", "wide"}, large, nil)
+		mock.AddCommandString("kubectl", []string{"get", "pods", "--all-namespaces", "-o", "custom-columns=NAMESPACE:.metadata.namespace", "--no-headers"}, namespaces, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleKubectlGetEnhanced(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"resource_type": "pods", "all_namespaces": "true",
+		}}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var summary ResourceSummary
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &summary))
+		assert.Equal(t, 4, summary.Total)
+		assert.Equal(t, []NamespaceCount{{Namespace: "prod", Count: 3}, {Namespace: "staging", Count: 1}}, summary.Namespaces)
+		assert.Contains(t, summary.Note, "max_items")
+
+		// The full listing is stored for fetch_blob
+		bucket, id, err := blobs.ParseURI(summary.OutputURI)
+		require.NoError(t, err)
+		var metadata KubectlOutput
+		stored, err := blobs.Default().Read(context.Background(), bucket, id, &metadata)
+		require.NoError(t, err)
+		assert.Equal(t, large, string(stored))
+		assert.Equal(t, []string{"get", "pods", "--all-namespaces", "-o", "wide"}, metadata.Args)
+	})
+
+	t.Run("never", func(t *testing.T) {
+		large := strings.Repeat("x", maxResourceListBytes+1)
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pods", "--all-namespaces", "-o", "wide"}, large, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleKubectlGetEnhanced(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"resource_type": "pods", "all_namespaces": "true", "summarize": "never",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, large, getResultText(result))
+	})
+
+	t.Run("always", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pods", "-n", "prod", "-o", "custom-columns=NAMESPACE:.metadata.namespace", "--no-headers"}, "prod\n", nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleKubectlGetEnhanced(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"resource_type": "pods", "namespace": "prod", "summarize": "always",
+		}}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+		assert.Len(t, mock.GetCallLog(), 1)
+	})
+}
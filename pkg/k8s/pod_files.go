@@ -0,0 +1,272 @@
+package k8s
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/blobs"
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// PodFileStoreDir configures the directory files copied from pods are stored
+// in. When unset, they are stored under the XDG data directory.
+const PodFileStoreDir = "POD_FILE_STORE_DIR"
+
+// defaultPodFileStoreDir is the directory name used under the XDG data directory
+const defaultPodFileStoreDir = "kagent-tools/pod-files"
+
+// podFileResourceScheme is the URI scheme of files copied from pods
+const podFileResourceScheme = "pod-files://"
+
+// podFileBucket stores files copied from pods
+var podFileBucket = blobs.Register(blobs.Bucket{Name: "pod-files", DirEnv: PodFileStoreDir, DefaultDir: defaultPodFileStoreDir})
+
+// defaultPodFileMaxBytes caps the size of a copy when max_bytes is not given
+const defaultPodFileMaxBytes = 50 << 20
+
+// podFileCopyTimeout bounds kubectl cp, which streams the file through the API server
+const podFileCopyTimeout = 5 * time.Minute
+
+// Types of copied paths
+const (
+	PodFileTypeFile      = "file"
+	PodFileTypeDirectory = "directory"
+)
+
+// PodFile describes a file or directory copied from a pod into the pod file store.
+// Directories are stored as gzipped tar archives.
+type PodFile struct {
+	ID        string    `json:"id"`
+	URI       string    `json:"uri"`
+	PodName   string    `json:"pod_name"`
+	Namespace string    `json:"namespace"`
+	Container string    `json:"container,omitempty"`
+	Path      string    `json:"path"`
+	Type      string    `json:"type"`
+	MIMEType  string    `json:"mime_type"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// archiveDirectory writes a directory as a gzipped tar archive
+func archiveDirectory(dir string, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		// Symlinks are kept as links rather than followed
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// storePodFile moves a copied file or directory into the pod file store,
+// failing when the stored content would be larger than maxBytes
+func storePodFile(ctx context.Context, src string, file PodFile, maxBytes int64) (PodFile, error) {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return file, fmt.Errorf("nothing was copied from %s: %w", file.Path, err)
+	}
+
+	var write func(io.Writer) error
+	switch {
+	case info.IsDir():
+		file.Type = PodFileTypeDirectory
+		file.MIMEType = "application/gzip"
+		write = func(w io.Writer) error { return archiveDirectory(src, w) }
+	case info.Mode().IsRegular():
+		file.Type = PodFileTypeFile
+		file.MIMEType = "application/octet-stream"
+		write = func(w io.Writer) error {
+			f, err := os.Open(src)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(w, f)
+			return err
+		}
+	default:
+		return file, fmt.Errorf("%s is not a regular file or directory", file.Path)
+	}
+
+	file.CreatedAt = time.Now().UTC()
+	_, err = blobs.Default().Write(ctx, podFileBucket, maxBytes, write, func(info blobs.Info) interface{} {
+		file.ID, file.URI, file.Size, file.SHA256 = info.ID, info.URI, info.Size, info.SHA256
+		return file
+	})
+	return file, err
+}
+
+// loadPodFile returns the metadata and content of a stored pod file
+func loadPodFile(ctx context.Context, id string) (PodFile, []byte, error) {
+	var file PodFile
+	content, err := blobs.Default().Read(ctx, podFileBucket, id, &file)
+	return file, content, err
+}
+
+// podPathSize returns the disk usage of a path in a pod, as reported by du
+func (k *K8sTool) podPathSize(ctx context.Context, podName, namespace, container, path string) (int64, error) {
+	args := []string{"exec", podName, "-n", namespace}
+	if container != "" {
+		args = append(args, "-c", container)
+	}
+	args = append(args, "--", "du", "-sk", path)
+
+	output, err := k.runKubectlCommandString(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected du output: %q", output)
+	}
+	kb, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected du output: %q", output)
+	}
+	return kb * 1024, nil
+}
+
+// Copy a file or directory from a pod into the pod file store
+func (k *K8sTool) handleCopyFromPod(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	podName := p.String("pod_name", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	container := p.String("container", "", params.Check(security.ValidateK8sResourceName))
+	path := p.String("path", "", params.Required(), params.Check(security.ValidateFilePath))
+	maxBytes := p.Int("max_bytes", defaultPodFileMaxBytes, params.Min(1))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return mcp.NewToolResultError("path must be absolute"), nil
+	}
+
+	// Refuse oversized paths before streaming them. Images without du are
+	// still capped while the copy is stored.
+	size, err := k.podPathSize(ctx, podName, namespace, container, path)
+	if err != nil {
+		logger.Get().Info("Could not size pod path before copying", "pod", podName, "path", path, "error", err)
+	} else if size > int64(maxBytes) {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is about %d bytes, larger than max_bytes (%d)", path, size, maxBytes)), nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "kagent-pod-file-*")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to create temp directory: %v", err)), nil
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			logger.Get().Error("Failed to remove temp directory", "path", tmpDir, "error", err)
+		}
+	}()
+
+	dest := filepath.Join(tmpDir, "copy")
+	args := []string{"cp", namespace + "/" + podName + ":" + path, dest}
+	if container != "" {
+		args = append(args, "-c", container)
+	}
+	if _, err := commands.NewCommandBuilder("kubectl").
+		WithArgs(args...).
+		WithKubeconfig(k.kubeconfig).
+		WithTimeout(podFileCopyTimeout).
+		Execute(ctx); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Copy from pod failed: %v", err)), nil
+	}
+
+	file, err := storePodFile(ctx, dest, PodFile{
+		PodName:   podName,
+		Namespace: namespace,
+		Container: container,
+		Path:      path,
+	}, int64(maxBytes))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to store copied file: %v", err)), nil
+	}
+
+	fileJSON, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal file reference: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(fileJSON)), nil
+}
+
+// handleReadPodFileResource serves a stored pod file as a blob resource
+func (k *K8sTool) handleReadPodFileResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	id := strings.TrimPrefix(request.Params.URI, podFileResourceScheme)
+	if id == request.Params.URI {
+		return nil, fmt.Errorf("invalid pod file URI: %s", request.Params.URI)
+	}
+
+	file, content, err := loadPodFile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.BlobResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: file.MIMEType,
+			Blob:     base64.StdEncoding.EncodeToString(content),
+		},
+	}, nil
+}
@@ -0,0 +1,136 @@
+package k8s
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorePodFile(t *testing.T) {
+	t.Setenv(PodFileStoreDir, t.TempDir())
+	ctx := context.Background()
+	src := t.TempDir()
+	source := PodFile{PodName: "web-1", Namespace: "prod", Path: "/tmp/dump"}
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(src, "heap.hprof")
+		require.NoError(t, os.WriteFile(path, []byte("heap dump"), 0o600))
+
+		file, err := storePodFile(ctx, path, source, 1024)
+		require.NoError(t, err)
+		assert.Equal(t, PodFileTypeFile, file.Type)
+		assert.Equal(t, int64(9), file.Size)
+		assert.Equal(t, podFileResourceScheme+file.ID, file.URI)
+		assert.Len(t, file.SHA256, 64)
+
+		request := mcp.ReadResourceRequest{}
+		request.Params.URI = file.URI
+		contents, err := newTestK8sTool().handleReadPodFileResource(ctx, request)
+		require.NoError(t, err)
+		require.Len(t, contents, 1)
+		blob := contents[0].(mcp.BlobResourceContents)
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("heap dump")), blob.Blob)
+		assert.Equal(t, "application/octet-stream", blob.MIMEType)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		dir := filepath.Join(src, "conf")
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "app.yaml"), []byte("port: 8080\n"), 0o600))
+
+		file, err := storePodFile(ctx, dir, source, 1<<20)
+		require.NoError(t, err)
+		assert.Equal(t, PodFileTypeDirectory, file.Type)
+
+		_, content, err := loadPodFile(ctx, file.ID)
+		require.NoError(t, err)
+		gz, err := gzip.NewReader(bytes.NewReader(content))
+		require.NoError(t, err)
+		tr := tar.NewReader(gz)
+		var names []string
+		for {
+			header, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			names = append(names, header.Name)
+		}
+		assert.Equal(t, []string{"sub/", "sub/app.yaml"}, names)
+	})
+
+	t.Run("over the cap", func(t *testing.T) {
+		path := filepath.Join(src, "core")
+		require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("x"), 2048), 0o600))
+
+		_, err := storePodFile(ctx, path, source, 1024)
+		assert.ErrorContains(t, err, "exceeds max_bytes")
+		blobs, _ := filepath.Glob(filepath.Join(os.Getenv(PodFileStoreDir), "*.blob"))
+		assert.Len(t, blobs, 2)
+	})
+
+	t.Run("nothing copied", func(t *testing.T) {
+		_, err := storePodFile(ctx, filepath.Join(src, "missing"), source, 1024)
+		assert.ErrorContains(t, err, "nothing was copied")
+	})
+
+	_, _, err := loadPodFile(ctx, "../alerts")
+	assert.Error(t, err)
+}
+
+func TestHandleCopyFromPod(t *testing.T) {
+	t.Setenv(PodFileStoreDir, t.TempDir())
+
+	t.Run("too large", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"exec", "web-1", "-n", "prod", "-c", "app", "--", "du", "-sk", "/tmp/heap.hprof"}, "2048\t/tmp/heap.hprof\n", nil)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"pod_name": "web-1", "namespace": "prod", "container": "app", "path": "/tmp/heap.hprof", "max_bytes": float64(1 << 20)}
+		result, err := newTestK8sTool().handleCopyFromPod(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "larger than max_bytes")
+		assert.Len(t, mock.GetCallLog(), 1)
+	})
+
+	t.Run("copy failure", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddPartialMatcherString("kubectl", []string{"exec"}, "", errors.New("du: not found"))
+		mock.AddPartialMatcherString("kubectl", []string{"cp", "prod/web-1:/tmp/heap.hprof"}, "", errors.New("tar: not found"))
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"pod_name": "web-1", "namespace": "prod", "path": "/tmp/heap.hprof"}
+		result, err := newTestK8sTool().handleCopyFromPod(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "Copy from pod failed")
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, arguments := range []map[string]interface{}{
+			{"pod_name": "web-1"},
+			{"pod_name": "web-1", "path": "tmp/heap.hprof"},
+			{"pod_name": "web-1", "path": "/proc/../etc/shadow"},
+			{"pod_name": "web-1", "path": "/tmp/heap.hprof", "max_bytes": float64(0)},
+		} {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = arguments
+			result, err := newTestK8sTool().handleCopyFromPod(context.Background(), request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError, arguments)
+		}
+	})
+}
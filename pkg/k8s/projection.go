@@ -0,0 +1,36 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kagent-dev/tools/internal/changes"
+)
+
+// jsonPathOutput returns the kubectl output format projecting resources with
+// a JSONPath template. Bare expressions such as .spec.replicas are wrapped in
+// braces, as kubectl only evaluates the parts of a template inside them.
+func jsonPathOutput(expression string) (string, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return "", fmt.Errorf("jsonpath cannot be empty")
+	}
+	if strings.ContainsAny(expression, "\n\r") {
+		return "", fmt.Errorf("jsonpath must be a single line")
+	}
+	if !strings.Contains(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+	return "jsonpath=" + expression, nil
+}
+
+// projectsSecretData reports whether a projection of the resource types could
+// return the data of Secrets, which redaction cannot reach once projected
+func projectsSecretData(resourceTypes string) bool {
+	for _, resourceType := range strings.Split(resourceTypes, ",") {
+		if changes.NormalizeKind(resourceType) == "secret" {
+			return true
+		}
+	}
+	return false
+}
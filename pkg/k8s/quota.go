@@ -0,0 +1,930 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/quantity"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Statuses of a quota resource in a quota check
+const (
+	QuotaOK          = "ok"
+	QuotaExceeded    = "exceeded"
+	QuotaUnspecified = "unspecified"
+)
+
+// resourceList is a requests or limits map as found in manifests, where values may
+// be strings or plain YAML numbers
+type resourceList map[string]interface{}
+
+func (l resourceList) quantities() (map[string]quantity.Quantity, error) {
+	quantities := make(map[string]quantity.Quantity, len(l))
+	for name, value := range l {
+		q, err := quantity.Parse(fmt.Sprint(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		quantities[name] = q
+	}
+	return quantities, nil
+}
+
+type quotaContainer struct {
+	Name      string `json:"name"`
+	Resources struct {
+		Requests resourceList `json:"requests"`
+		Limits   resourceList `json:"limits"`
+	} `json:"resources"`
+}
+
+type quotaPodSpec struct {
+	Containers            []quotaContainer `json:"containers"`
+	InitContainers        []quotaContainer `json:"initContainers"`
+	ActiveDeadlineSeconds *int64           `json:"activeDeadlineSeconds"`
+	Overhead              resourceList     `json:"overhead"`
+}
+
+type quotaClaim struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec quotaClaimSpec `json:"spec"`
+}
+
+type quotaClaimSpec struct {
+	StorageClassName *string `json:"storageClassName"`
+	Resources        struct {
+		Requests resourceList `json:"requests"`
+	} `json:"resources"`
+}
+
+type podTemplateSpec struct {
+	Spec quotaPodSpec `json:"spec"`
+}
+
+// quotaObject holds the fields of a manifest object that consume quota
+type quotaObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		quotaPodSpec
+		quotaClaimSpec
+		Replicas             *int64          `json:"replicas"`
+		Parallelism          *int64          `json:"parallelism"`
+		Template             podTemplateSpec `json:"template"`
+		VolumeClaimTemplates []quotaClaim    `json:"volumeClaimTemplates"`
+		JobTemplate          struct {
+			Spec struct {
+				Parallelism *int64          `json:"parallelism"`
+				Template    podTemplateSpec `json:"template"`
+			} `json:"spec"`
+		} `json:"jobTemplate"`
+		Type  string            `json:"type"`
+		Ports []json.RawMessage `json:"ports"`
+	} `json:"spec"`
+}
+
+type limitRangeItem struct {
+	Type                 string       `json:"type"`
+	Max                  resourceList `json:"max"`
+	Min                  resourceList `json:"min"`
+	Default              resourceList `json:"default"`
+	DefaultRequest       resourceList `json:"defaultRequest"`
+	MaxLimitRequestRatio resourceList `json:"maxLimitRequestRatio"`
+}
+
+type limitRange struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Limits []limitRangeItem `json:"limits"`
+	} `json:"spec"`
+}
+
+type resourceQuota struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Hard          resourceList    `json:"hard"`
+		Scopes        []string        `json:"scopes"`
+		ScopeSelector json.RawMessage `json:"scopeSelector"`
+	} `json:"spec"`
+	Status struct {
+		Hard resourceList `json:"hard"`
+		Used resourceList `json:"used"`
+	} `json:"status"`
+}
+
+// QuotaResourceCheck compares the demand of the change with one resource of a ResourceQuota
+type QuotaResourceCheck struct {
+	Quota     string `json:"quota"`
+	Resource  string `json:"resource"`
+	Hard      string `json:"hard"`
+	Used      string `json:"used"`
+	Headroom  string `json:"headroom"`
+	Requested string `json:"requested"`
+	After     string `json:"after"`
+	Status    string `json:"status"`
+	Message   string `json:"message,omitempty"`
+}
+
+// LimitRangeViolation is a LimitRange constraint the change would be rejected for
+type LimitRangeViolation struct {
+	LimitRange string `json:"limit_range"`
+	Object     string `json:"object"`
+	Type       string `json:"type"`
+	Resource   string `json:"resource"`
+	Constraint string `json:"constraint"`
+	Message    string `json:"message"`
+}
+
+// QuotaObjectDemand is the change in quota usage caused by one object
+type QuotaObjectDemand struct {
+	Object    string            `json:"object"`
+	Change    string            `json:"change"`
+	Pods      int64             `json:"pods,omitempty"`
+	Resources map[string]string `json:"resources,omitempty"`
+}
+
+// QuotaCheckReport is the result of checking a change against the quotas and limit ranges of a namespace
+type QuotaCheckReport struct {
+	Namespace            string                `json:"namespace"`
+	Allowed              bool                  `json:"allowed"`
+	Blocking             []string              `json:"blocking"`
+	Objects              []QuotaObjectDemand   `json:"objects"`
+	Quotas               []QuotaResourceCheck  `json:"quotas"`
+	LimitRangeViolations []LimitRangeViolation `json:"limit_range_violations"`
+	Defaulted            []string              `json:"defaulted,omitempty"`
+	Notes                []string              `json:"notes,omitempty"`
+}
+
+// podDemand is what one pod of an object requests once LimitRange defaults are applied
+type podDemand struct {
+	requests    map[string]quantity.Quantity
+	limits      map[string]quantity.Quantity
+	bestEffort  bool
+	terminating bool
+	// unspecified maps the compute resources quotas may track to the containers leaving them unset
+	unspecified map[string][]string
+}
+
+// demandEntry is a set of quota resources consumed together; pod is set for
+// resources consumed by pods, which scoped quotas are matched against
+type demandEntry struct {
+	resources map[string]quantity.Quantity
+	pod       *podDemand
+	removed   bool
+}
+
+// computeResources are the resources quotas can require every container to set
+var computeResources = []string{"cpu", "memory", "ephemeral-storage"}
+
+// objectCountResources maps kinds to their resource and API group for count/<resource>[.<group>] quotas
+var objectCountResources = map[string][2]string{
+	"Pod":                   {"pods", ""},
+	"Service":               {"services", ""},
+	"ConfigMap":             {"configmaps", ""},
+	"Secret":                {"secrets", ""},
+	"PersistentVolumeClaim": {"persistentvolumeclaims", ""},
+	"ReplicationController": {"replicationcontrollers", ""},
+	"ResourceQuota":         {"resourcequotas", ""},
+	"Deployment":            {"deployments", "apps"},
+	"StatefulSet":           {"statefulsets", "apps"},
+	"ReplicaSet":            {"replicasets", "apps"},
+	"DaemonSet":             {"daemonsets", "apps"},
+	"Job":                   {"jobs", "batch"},
+	"CronJob":               {"cronjobs", "batch"},
+}
+
+// legacyCountResources are core resources that quotas may also count without the count/ prefix
+var legacyCountResources = map[string]bool{
+	"pods": true, "services": true, "configmaps": true, "secrets": true,
+	"persistentvolumeclaims": true, "replicationcontrollers": true, "resourcequotas": true,
+}
+
+var clusterScopedKinds = map[string]bool{
+	"Namespace": true, "Node": true, "PersistentVolume": true, "StorageClass": true, "PriorityClass": true,
+	"ClusterRole": true, "ClusterRoleBinding": true, "CustomResourceDefinition": true, "APIService": true,
+	"ValidatingWebhookConfiguration": true, "MutatingWebhookConfiguration": true, "IngressClass": true,
+}
+
+// scalableKinds are the workload kinds a scale-up can be checked for
+var scalableKinds = map[string]bool{"deployment": true, "statefulset": true, "replicaset": true, "replicationcontroller": true}
+
+// supportedQuotaScopes are the quota scopes that can be evaluated from a pod spec alone
+var supportedQuotaScopes = map[string]bool{"BestEffort": true, "NotBestEffort": true, "Terminating": true, "NotTerminating": true}
+
+func parseLimitRanges(output string) ([]limitRange, error) {
+	var list struct {
+		Items []limitRange `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func parseResourceQuotas(output string) ([]resourceQuota, error) {
+	var list struct {
+		Items []resourceQuota `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// limitItems returns the parsed constraints of the given type across all limit ranges
+func limitItems(ranges []limitRange, itemType string) []parsedLimitItem {
+	var items []parsedLimitItem
+	for _, lr := range ranges {
+		for _, item := range lr.Spec.Limits {
+			if item.Type != itemType {
+				continue
+			}
+			items = append(items, parsedLimitItem{
+				limitRange:     lr.Metadata.Name,
+				itemType:       item.Type,
+				max:            lenientQuantities(item.Max),
+				min:            lenientQuantities(item.Min),
+				defaults:       lenientQuantities(item.Default),
+				defaultRequest: lenientQuantities(item.DefaultRequest),
+				ratio:          lenientQuantities(item.MaxLimitRequestRatio),
+			})
+		}
+	}
+	return items
+}
+
+type parsedLimitItem struct {
+	limitRange                                string
+	itemType                                  string
+	max, min, defaults, defaultRequest, ratio map[string]quantity.Quantity
+}
+
+// lenientQuantities parses a resource list read from the cluster, which the API server has validated
+func lenientQuantities(list resourceList) map[string]quantity.Quantity {
+	quantities := make(map[string]quantity.Quantity, len(list))
+	for name, value := range list {
+		if q, err := quantity.Parse(fmt.Sprint(value)); err == nil {
+			quantities[name] = q
+		}
+	}
+	return quantities
+}
+
+// check returns the violations of the item's min, max and ratio constraints by the given requests and limits
+func (item parsedLimitItem) check(object string, requests, limits map[string]quantity.Quantity) []LimitRangeViolation {
+	var violations []LimitRangeViolation
+	add := func(resource, constraint, message string) {
+		violations = append(violations, LimitRangeViolation{
+			LimitRange: item.limitRange, Object: object, Type: item.itemType,
+			Resource: resource, Constraint: constraint, Message: message,
+		})
+	}
+	for _, resource := range sortedKeys(item.min) {
+		min := item.min[resource]
+		request, ok := requests[resource]
+		switch {
+		case !ok:
+			add(resource, "min", fmt.Sprintf("minimum %s usage per %s is %s, but no request is specified", resource, item.itemType, quantity.Format(resource, min)))
+		case request < min:
+			add(resource, "min", fmt.Sprintf("minimum %s usage per %s is %s, but request is %s", resource, item.itemType, quantity.Format(resource, min), quantity.Format(resource, request)))
+		}
+		if limit, ok := limits[resource]; ok && limit < min {
+			add(resource, "min", fmt.Sprintf("minimum %s usage per %s is %s, but limit is %s", resource, item.itemType, quantity.Format(resource, min), quantity.Format(resource, limit)))
+		}
+	}
+	for _, resource := range sortedKeys(item.max) {
+		max := item.max[resource]
+		limit, ok := limits[resource]
+		switch {
+		case !ok:
+			add(resource, "max", fmt.Sprintf("maximum %s usage per %s is %s, but no limit is specified", resource, item.itemType, quantity.Format(resource, max)))
+		case limit > max:
+			add(resource, "max", fmt.Sprintf("maximum %s usage per %s is %s, but limit is %s", resource, item.itemType, quantity.Format(resource, max), quantity.Format(resource, limit)))
+		}
+	}
+	for _, resource := range sortedKeys(item.ratio) {
+		ratio := float64(item.ratio[resource]) / 1000
+		limit, hasLimit := limits[resource]
+		request := requests[resource]
+		if !hasLimit {
+			continue
+		}
+		if request <= 0 {
+			add(resource, "maxLimitRequestRatio", fmt.Sprintf("%s max limit to request ratio per %s is %g, but no request is specified", resource, item.itemType, ratio))
+		} else if actual := float64(limit) / float64(request); actual > ratio {
+			add(resource, "maxLimitRequestRatio", fmt.Sprintf("%s max limit to request ratio per %s is %g, but provided ratio is %.2f", resource, item.itemType, ratio, actual))
+		}
+	}
+	return violations
+}
+
+// evaluatePod applies LimitRange defaults to a pod spec the way the LimitRanger admission
+// plugin does, and returns the pod's demand, the violations and the defaults applied
+func evaluatePod(object string, spec quotaPodSpec, ranges []limitRange) (*podDemand, []LimitRangeViolation, []string, error) {
+	containerItems := limitItems(ranges, "Container")
+	var violations []LimitRangeViolation
+	var defaulted []string
+	pod := &podDemand{requests: map[string]quantity.Quantity{}, limits: map[string]quantity.Quantity{}, bestEffort: true, unspecified: map[string][]string{}}
+	pod.terminating = spec.ActiveDeadlineSeconds != nil
+
+	sumRequests, sumLimits := map[string]quantity.Quantity{}, map[string]quantity.Quantity{}
+	initRequests, initLimits := map[string]quantity.Quantity{}, map[string]quantity.Quantity{}
+	containers := append(append([]quotaContainer{}, spec.InitContainers...), spec.Containers...)
+	for i, container := range containers {
+		isInit := i < len(spec.InitContainers)
+		requests, err := container.Resources.Requests.quantities()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s container %s requests: %w", object, container.Name, err)
+		}
+		limits, err := container.Resources.Limits.quantities()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s container %s limits: %w", object, container.Name, err)
+		}
+		if len(requests) > 0 || len(limits) > 0 {
+			pod.bestEffort = false
+		}
+		// The API server defaults a missing request to the container's limit
+		for resource, limit := range limits {
+			if _, ok := requests[resource]; !ok {
+				requests[resource] = limit
+			}
+		}
+		target := fmt.Sprintf("%s container %s", object, container.Name)
+		for _, item := range containerItems {
+			for _, resource := range sortedKeys(item.defaults) {
+				if _, ok := limits[resource]; !ok {
+					limits[resource] = item.defaults[resource]
+					defaulted = append(defaulted, fmt.Sprintf("%s: limits.%s=%s from LimitRange %s", target, resource, quantity.Format(resource, item.defaults[resource]), item.limitRange))
+				}
+			}
+			defaultRequests := item.defaultRequest
+			for resource, q := range item.defaults {
+				if _, ok := defaultRequests[resource]; !ok {
+					defaultRequests = mergeQuantities(defaultRequests, map[string]quantity.Quantity{resource: q})
+				}
+			}
+			for _, resource := range sortedKeys(defaultRequests) {
+				if _, ok := requests[resource]; !ok {
+					requests[resource] = defaultRequests[resource]
+					defaulted = append(defaulted, fmt.Sprintf("%s: requests.%s=%s from LimitRange %s", target, resource, quantity.Format(resource, defaultRequests[resource]), item.limitRange))
+				}
+			}
+			violations = append(violations, item.check(target, requests, limits)...)
+		}
+		for _, resource := range computeResources {
+			if _, ok := requests[resource]; !ok {
+				pod.unspecified["requests."+resource] = append(pod.unspecified["requests."+resource], container.Name)
+				pod.unspecified[resource] = append(pod.unspecified[resource], container.Name)
+			}
+			if _, ok := limits[resource]; !ok {
+				pod.unspecified["limits."+resource] = append(pod.unspecified["limits."+resource], container.Name)
+			}
+		}
+		if isInit {
+			maxQuantities(initRequests, requests)
+			maxQuantities(initLimits, limits)
+		} else {
+			addQuantities(sumRequests, requests, 1)
+			addQuantities(sumLimits, limits, 1)
+		}
+	}
+
+	for _, item := range limitItems(ranges, "Pod") {
+		violations = append(violations, item.check(object, sumRequests, sumLimits)...)
+	}
+
+	// A pod needs the larger of its app containers' total and its largest init container, plus overhead
+	overhead, err := spec.Overhead.quantities()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s overhead: %w", object, err)
+	}
+	maxQuantities(sumRequests, initRequests)
+	maxQuantities(sumLimits, initLimits)
+	addQuantities(sumRequests, overhead, 1)
+	addQuantities(sumLimits, overhead, 1)
+	pod.requests, pod.limits = sumRequests, sumLimits
+	return pod, violations, defaulted, nil
+}
+
+func mergeQuantities(a, b map[string]quantity.Quantity) map[string]quantity.Quantity {
+	merged := make(map[string]quantity.Quantity, len(a)+len(b))
+	for k, v := range a {
+		merged[k] = v
+	}
+	for k, v := range b {
+		merged[k] = v
+	}
+	return merged
+}
+
+func addQuantities(dst, src map[string]quantity.Quantity, times int64) {
+	for resource, q := range src {
+		dst[resource] += q * quantity.Quantity(times)
+	}
+}
+
+func maxQuantities(dst, src map[string]quantity.Quantity) {
+	for resource, q := range src {
+		if q > dst[resource] {
+			dst[resource] = q
+		}
+	}
+}
+
+// quotaResources returns the quota resources consumed by n pods of the demand
+func (pod *podDemand) quotaResources(n int64) map[string]quantity.Quantity {
+	resources := map[string]quantity.Quantity{"pods": quantity.Quantity(n * 1000), "count/pods": quantity.Quantity(n * 1000)}
+	for resource, q := range pod.requests {
+		resources["requests."+resource] += q * quantity.Quantity(n)
+		if containsString(computeResources, resource) {
+			resources[resource] += q * quantity.Quantity(n)
+		}
+	}
+	for resource, q := range pod.limits {
+		resources["limits."+resource] += q * quantity.Quantity(n)
+	}
+	return resources
+}
+
+// matchesScopes reports whether pods of the demand are tracked by a quota with the given scopes
+func (pod *podDemand) matchesScopes(scopes []string) bool {
+	for _, scope := range scopes {
+		var matches bool
+		switch scope {
+		case "BestEffort":
+			matches = pod.bestEffort
+		case "NotBestEffort":
+			matches = !pod.bestEffort
+		case "Terminating":
+			matches = pod.terminating
+		case "NotTerminating":
+			matches = !pod.terminating
+		}
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
+
+// countResources returns the object count quota resources of one object
+func countResources(apiVersion, kind string) map[string]quantity.Quantity {
+	resource, ok := objectCountResources[kind]
+	if !ok {
+		group := ""
+		if i := strings.Index(apiVersion, "/"); i >= 0 {
+			group = apiVersion[:i]
+		}
+		resource = [2]string{strings.ToLower(kind) + "s", group}
+	}
+	name := "count/" + resource[0]
+	if resource[1] != "" {
+		name += "." + resource[1]
+	}
+	counts := map[string]quantity.Quantity{name: 1000}
+	if resource[1] == "" && legacyCountResources[resource[0]] {
+		counts[resource[0]] = 1000
+	}
+	return counts
+}
+
+// claimResources returns the quota resources consumed by a PersistentVolumeClaim
+func claimResources(spec quotaClaimSpec, n int64) (map[string]quantity.Quantity, error) {
+	requests, err := spec.Resources.Requests.quantities()
+	if err != nil {
+		return nil, err
+	}
+	storage := requests["storage"] * quantity.Quantity(n)
+	resources := map[string]quantity.Quantity{
+		"persistentvolumeclaims":       quantity.Quantity(n * 1000),
+		"count/persistentvolumeclaims": quantity.Quantity(n * 1000),
+		"requests.storage":             storage,
+	}
+	if spec.StorageClassName != nil && *spec.StorageClassName != "" {
+		class := *spec.StorageClassName + ".storageclass.storage.k8s.io/"
+		resources[class+"requests.storage"] = storage
+		resources[class+"persistentvolumeclaims"] = quantity.Quantity(n * 1000)
+	}
+	return resources, nil
+}
+
+// objectDemand is the quota demand of one object and what was learned evaluating it
+type objectDemand struct {
+	entries    []demandEntry
+	pods       int64
+	violations []LimitRangeViolation
+	defaulted  []string
+	notes      []string
+}
+
+// evaluateObject computes the quota an object consumes once created; nodes is called
+// to count the pods of a DaemonSet
+func evaluateObject(raw map[string]interface{}, ranges []limitRange, nodes func() (int64, error)) (*objectDemand, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var object quotaObject
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("failed to decode %v: %w", raw["kind"], err)
+	}
+	id := object.Kind + "/" + object.Metadata.Name
+	demand := &objectDemand{entries: []demandEntry{{resources: countResources(object.APIVersion, object.Kind)}}}
+
+	replicas := func(value *int64) int64 {
+		if value == nil {
+			return 1
+		}
+		return *value
+	}
+	var spec *quotaPodSpec
+	switch object.Kind {
+	case "Pod":
+		spec, demand.pods = &object.Spec.quotaPodSpec, 1
+	case "Deployment", "ReplicaSet", "StatefulSet", "ReplicationController":
+		spec, demand.pods = &object.Spec.Template.Spec, replicas(object.Spec.Replicas)
+		if object.Kind == "Deployment" {
+			demand.entries[0].resources["count/replicasets.apps"] = 1000
+		}
+	case "Job":
+		spec, demand.pods = &object.Spec.Template.Spec, replicas(object.Spec.Parallelism)
+	case "CronJob":
+		spec, demand.pods = &object.Spec.JobTemplate.Spec.Template.Spec, replicas(object.Spec.JobTemplate.Spec.Parallelism)
+		demand.notes = append(demand.notes, fmt.Sprintf("%s: counted as one running job; its pods consume quota only while a job runs", id))
+	case "DaemonSet":
+		spec = &object.Spec.Template.Spec
+		if demand.pods, err = nodes(); err != nil {
+			return nil, fmt.Errorf("failed to count nodes for %s: %w", id, err)
+		}
+		demand.notes = append(demand.notes, fmt.Sprintf("%s: assumed to run one pod on each of the %d nodes", id, demand.pods))
+	case "PersistentVolumeClaim":
+		resources, err := claimResources(object.Spec.quotaClaimSpec, 1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+		demand.entries[0].resources = mergeQuantities(demand.entries[0].resources, resources)
+		if requests := lenientQuantities(object.Spec.Resources.Requests); len(requests) > 0 {
+			for _, item := range limitItems(ranges, "PersistentVolumeClaim") {
+				demand.violations = append(demand.violations, item.check(id, requests, requests)...)
+			}
+		}
+	case "Service":
+		switch object.Spec.Type {
+		case "LoadBalancer":
+			demand.entries[0].resources["services.loadbalancers"] = 1000
+			demand.entries[0].resources["services.nodeports"] = quantity.Quantity(len(object.Spec.Ports) * 1000)
+		case "NodePort":
+			demand.entries[0].resources["services.nodeports"] = quantity.Quantity(len(object.Spec.Ports) * 1000)
+		}
+	}
+
+	if spec != nil {
+		pod, violations, defaulted, err := evaluatePod(id, *spec, ranges)
+		if err != nil {
+			return nil, err
+		}
+		demand.violations = append(demand.violations, violations...)
+		demand.defaulted = append(demand.defaulted, defaulted...)
+		demand.entries = append(demand.entries, demandEntry{resources: pod.quotaResources(demand.pods), pod: pod})
+	}
+	if object.Kind == "StatefulSet" {
+		for _, claim := range object.Spec.VolumeClaimTemplates {
+			resources, err := claimResources(claim.Spec, demand.pods)
+			if err != nil {
+				return nil, fmt.Errorf("%s volume claim template %s: %w", id, claim.Metadata.Name, err)
+			}
+			demand.entries = append(demand.entries, demandEntry{resources: resources})
+		}
+	}
+	return demand, nil
+}
+
+// evaluateQuotas compares the demand with every quota of the namespace
+func evaluateQuotas(quotas []resourceQuota, entries []demandEntry) ([]QuotaResourceCheck, []string) {
+	checks := []QuotaResourceCheck{}
+	var notes []string
+	for _, quota := range quotas {
+		name := quota.Metadata.Name
+		if len(quota.Spec.ScopeSelector) > 0 && string(quota.Spec.ScopeSelector) != "null" {
+			notes = append(notes, fmt.Sprintf("quota %s uses a scopeSelector and was not evaluated", name))
+			continue
+		}
+		supported := true
+		for _, scope := range quota.Spec.Scopes {
+			supported = supported && supportedQuotaScopes[scope]
+		}
+		if !supported {
+			notes = append(notes, fmt.Sprintf("quota %s has scopes %s and was not evaluated", name, strings.Join(quota.Spec.Scopes, ", ")))
+			continue
+		}
+
+		requested := map[string]quantity.Quantity{}
+		unspecified := map[string][]string{}
+		for _, entry := range entries {
+			if len(quota.Spec.Scopes) > 0 && (entry.pod == nil || !entry.pod.matchesScopes(quota.Spec.Scopes)) {
+				continue
+			}
+			addQuantities(requested, entry.resources, 1)
+			if entry.pod != nil && !entry.removed {
+				for resource, containers := range entry.pod.unspecified {
+					unspecified[resource] = append(unspecified[resource], containers...)
+				}
+			}
+		}
+
+		hard := lenientQuantities(quota.Status.Hard)
+		if len(hard) == 0 {
+			hard = lenientQuantities(quota.Spec.Hard)
+		}
+		used := lenientQuantities(quota.Status.Used)
+		for _, resource := range sortedKeys(hard) {
+			q, missing := requested[resource], unspecified[resource]
+			if q == 0 && len(missing) == 0 {
+				continue
+			}
+			check := QuotaResourceCheck{
+				Quota:     name,
+				Resource:  resource,
+				Hard:      quantity.Format(resource, hard[resource]),
+				Used:      quantity.Format(resource, used[resource]),
+				Headroom:  quantity.Format(resource, hard[resource]-used[resource]),
+				Requested: quantity.Format(resource, q),
+				After:     quantity.Format(resource, used[resource]+q),
+				Status:    QuotaOK,
+			}
+			switch {
+			case len(missing) > 0:
+				check.Status = QuotaUnspecified
+				check.Message = fmt.Sprintf("quota %s tracks %s, so every container must set it; unset in containers %s", name, resource, strings.Join(uniqueSorted(missing), ", "))
+			case q > 0 && used[resource]+q > hard[resource]:
+				check.Status = QuotaExceeded
+				check.Message = fmt.Sprintf("exceeded quota %s: requested %s=%s, used %s=%s, limited %s=%s (headroom %s)",
+					name, resource, check.Requested, resource, check.Used, resource, check.Hard, check.Headroom)
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks, notes
+}
+
+func uniqueSorted(values []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// summarizeDemand reports the net quota change of an object's entries
+func summarizeDemand(object, change string, entries []demandEntry, pods int64) QuotaObjectDemand {
+	total := map[string]quantity.Quantity{}
+	for _, entry := range entries {
+		addQuantities(total, entry.resources, 1)
+	}
+	summary := QuotaObjectDemand{Object: object, Change: change, Pods: pods, Resources: map[string]string{}}
+	for resource, q := range total {
+		if q != 0 && !strings.HasPrefix(resource, "count/") && !strings.Contains(resource, ".storageclass.") {
+			summary.Resources[resource] = quantity.Format(resource, q)
+		}
+	}
+	return summary
+}
+
+func negate(entries []demandEntry) []demandEntry {
+	negated := make([]demandEntry, 0, len(entries))
+	for _, entry := range entries {
+		resources := make(map[string]quantity.Quantity, len(entry.resources))
+		for resource, q := range entry.resources {
+			resources[resource] = -q
+		}
+		negated = append(negated, demandEntry{resources: resources, pod: entry.pod, removed: true})
+	}
+	return negated
+}
+
+// getObject returns an object of the namespace, or nil when it does not exist
+func (k *K8sTool) getObject(ctx context.Context, namespace, kind, name string) (map[string]interface{}, error) {
+	output, err := k.runKubectlCommandString(ctx, "get", strings.ToLower(kind), name, "-n", namespace, "-o", "json")
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "not found") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &object); err != nil {
+		return nil, fmt.Errorf("failed to parse %s/%s: %w", kind, name, err)
+	}
+	return object, nil
+}
+
+func (k *K8sTool) handleQuotaCheck(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	manifest := p.String("manifest", "", params.Check(security.ValidateYAMLContent))
+	workload := p.String("workload", "")
+	replicas := p.Int("replicas", -1)
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if (manifest == "") == (workload == "") {
+		return mcp.NewToolResultError("exactly one of manifest or workload is required"), nil
+	}
+	if workload != "" && replicas < 0 {
+		return mcp.NewToolResultError("replicas is required with workload"), nil
+	}
+
+	var scaleKind, scaleName string
+	var resources []manifestResource
+	if workload != "" {
+		kind, name, ok := strings.Cut(workload, "/")
+		if !ok || !scalableKinds[strings.ToLower(kind)] {
+			return mcp.NewToolResultError("workload must be <kind>/<name> with kind deployment, statefulset, replicaset or replicationcontroller"), nil
+		}
+		if err := security.ValidateK8sResourceName(name); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		scaleKind, scaleName = strings.ToLower(kind), name
+	} else {
+		var err error
+		if resources, err = parseManifestResources(manifest); err != nil {
+			return mcp.NewToolResultError("Invalid manifest: " + err.Error()), nil
+		}
+		if len(resources) == 0 {
+			return mcp.NewToolResultError("manifest contains no resources"), nil
+		}
+		for _, resource := range resources {
+			if resource.namespace != "" && resource.namespace != namespace && !clusterScopedKinds[resource.kind] {
+				return mcp.NewToolResultError(fmt.Sprintf("%s is in namespace %s; all resources must be in namespace %s", resource.id(), resource.namespace, namespace)), nil
+			}
+		}
+	}
+
+	quotaOutput, err := k.runKubectlCommandString(ctx, "get", "resourcequotas", "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list resource quotas: %v", err)), nil
+	}
+	quotas, err := parseResourceQuotas(quotaOutput)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse resource quotas: %v", err)), nil
+	}
+	rangeOutput, err := k.runKubectlCommandString(ctx, "get", "limitranges", "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list limit ranges: %v", err)), nil
+	}
+	ranges, err := parseLimitRanges(rangeOutput)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse limit ranges: %v", err)), nil
+	}
+
+	nodeCount := int64(-1)
+	nodes := func() (int64, error) {
+		if nodeCount < 0 {
+			output, err := k.runKubectlCommandString(ctx, "get", "nodes", "-o", "json")
+			if err != nil {
+				return 0, err
+			}
+			var list struct {
+				Items []json.RawMessage `json:"items"`
+			}
+			if err := json.Unmarshal([]byte(output), &list); err != nil {
+				return 0, err
+			}
+			nodeCount = int64(len(list.Items))
+		}
+		return nodeCount, nil
+	}
+
+	report := QuotaCheckReport{Namespace: namespace, Blocking: []string{}, Objects: []QuotaObjectDemand{}, LimitRangeViolations: []LimitRangeViolation{}}
+	var entries []demandEntry
+	evaluate := func(id string, proposed, existing map[string]interface{}, change string) error {
+		next, err := evaluateObject(proposed, ranges, nodes)
+		if err != nil {
+			return err
+		}
+		report.LimitRangeViolations = append(report.LimitRangeViolations, next.violations...)
+		report.Defaulted = append(report.Defaulted, next.defaulted...)
+		report.Notes = append(report.Notes, next.notes...)
+		objectEntries, pods := next.entries, next.pods
+		if existing != nil {
+			current, err := evaluateObject(existing, ranges, nodes)
+			if err != nil {
+				return err
+			}
+			objectEntries = append(objectEntries, negate(current.entries)...)
+			pods -= current.pods
+		}
+		entries = append(entries, objectEntries...)
+		report.Objects = append(report.Objects, summarizeDemand(id, change, objectEntries, pods))
+		return nil
+	}
+
+	if workload != "" {
+		existing, err := k.getObject(ctx, namespace, scaleKind, scaleName)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to get %s: %v", workload, err)), nil
+		}
+		if existing == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("%s not found in namespace %s", workload, namespace)), nil
+		}
+		scaled, err := scaledCopy(existing, replicas)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if err := evaluate(fmt.Sprintf("%v/%s", existing["kind"], scaleName), scaled, existing, "scale"); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	} else {
+		for _, resource := range resources {
+			if clusterScopedKinds[resource.kind] {
+				report.Objects = append(report.Objects, QuotaObjectDemand{Object: resource.id(), Change: "skipped"})
+				report.Notes = append(report.Notes, fmt.Sprintf("%s is cluster-scoped and not subject to namespace quotas", resource.id()))
+				continue
+			}
+			existing, err := k.getObject(ctx, namespace, resource.kind, resource.name)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get %s: %v", resource.id(), err)), nil
+			}
+			change := "create"
+			if existing != nil {
+				change = "update"
+				if resource.kind == "Deployment" {
+					report.Notes = append(report.Notes, fmt.Sprintf("%s: a rolling update also needs quota headroom for its surge pods (maxSurge, 25%% by default)", resource.id()))
+				}
+			}
+			if err := evaluate(resource.id(), resource.object, existing, change); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+	}
+
+	var quotaNotes []string
+	report.Quotas, quotaNotes = evaluateQuotas(quotas, entries)
+	report.Notes = append(report.Notes, quotaNotes...)
+	if len(quotas) == 0 && len(ranges) == 0 {
+		report.Notes = append(report.Notes, fmt.Sprintf("namespace %s has no ResourceQuotas or LimitRanges", namespace))
+	}
+	for _, check := range report.Quotas {
+		if check.Status != QuotaOK {
+			report.Blocking = append(report.Blocking, check.Message)
+		}
+	}
+	for _, violation := range report.LimitRangeViolations {
+		report.Blocking = append(report.Blocking, fmt.Sprintf("%s: %s (LimitRange %s)", violation.Object, violation.Message, violation.LimitRange))
+	}
+	report.Allowed = len(report.Blocking) == 0
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal quota check: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
+
+// scaledCopy returns a copy of a workload with spec.replicas set
+func scaledCopy(object map[string]interface{}, replicas int) (map[string]interface{}, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	var scaled map[string]interface{}
+	if err := json.Unmarshal(data, &scaled); err != nil {
+		return nil, err
+	}
+	spec, ok := scaled["spec"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%v has no spec", object["kind"])
+	}
+	spec["replicas"] = replicas
+	return scaled, nil
+}
@@ -0,0 +1,173 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/kagent-dev/tools/internal/quantity"
+)
+
+const testLimitRanges = `{"items":[{"metadata":{"name":"defaults"},"spec":{"limits":[
+	{"type":"Container","default":{"cpu":"500m","memory":"512Mi"},"defaultRequest":{"cpu":"100m"},"max":{"cpu":"2"},"maxLimitRequestRatio":{"memory":"2"}},
+	{"type":"Pod","max":{"memory":"2Gi"}}]}}]}`
+
+func TestEvaluatePod(t *testing.T) {
+	ranges, err := parseLimitRanges(testLimitRanges)
+	require.NoError(t, err)
+
+	var spec quotaPodSpec
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"initContainers":[{"name":"init","resources":{"requests":{"cpu":"1","memory":"256Mi"},"limits":{"cpu":"1","memory":"256Mi"}}}],
+		"containers":[
+			{"name":"app","resources":{"limits":{"cpu":"3","memory":"1Gi"}}},
+			{"name":"sidecar","resources":{"requests":{"memory":"128Mi"}}}]}`), &spec))
+	pod, violations, defaulted, err := evaluatePod("Deployment/web", spec, ranges)
+	require.NoError(t, err)
+
+	// app: requests default to its limits; sidecar: limits from default, cpu request from defaultRequest
+	assert.Equal(t, quantity.Quantity(3100), pod.requests["cpu"])
+	assert.Equal(t, quantity.Quantity((1024+128)<<20*1000), pod.requests["memory"])
+	assert.Equal(t, quantity.Quantity(3500), pod.limits["cpu"])
+	assert.Equal(t, quantity.Quantity((1024+512)<<20*1000), pod.limits["memory"])
+	assert.False(t, pod.bestEffort)
+	assert.Equal(t, []string{"app", "init", "sidecar"}, uniqueSorted(pod.unspecified["limits.ephemeral-storage"]))
+	assert.Empty(t, pod.unspecified["limits.cpu"])
+	assert.Equal(t, []string{
+		"Deployment/web container sidecar: limits.cpu=500m from LimitRange defaults",
+		"Deployment/web container sidecar: limits.memory=512Mi from LimitRange defaults",
+		"Deployment/web container sidecar: requests.cpu=100m from LimitRange defaults",
+	}, defaulted)
+
+	require.Len(t, violations, 2)
+	assert.Equal(t, LimitRangeViolation{
+		LimitRange: "defaults", Object: "Deployment/web container app", Type: "Container", Resource: "cpu", Constraint: "max",
+		Message: "maximum cpu usage per Container is 2, but limit is 3",
+	}, violations[0])
+	assert.Equal(t, "sidecar", violations[1].Object[len(violations[1].Object)-7:])
+	assert.Equal(t, "maxLimitRequestRatio", violations[1].Constraint)
+	assert.Equal(t, "memory max limit to request ratio per Container is 2, but provided ratio is 4.00", violations[1].Message)
+}
+
+const testQuotas = `{"items":[
+	{"metadata":{"name":"compute"},"spec":{"hard":{"requests.cpu":"4","limits.memory":"8Gi","pods":"10"}},
+	 "status":{"hard":{"requests.cpu":"4","limits.memory":"8Gi","pods":"10"},"used":{"requests.cpu":"3","limits.memory":"2Gi","pods":"6"}}},
+	{"metadata":{"name":"best-effort"},"spec":{"hard":{"pods":"1"},"scopes":["BestEffort"]},
+	 "status":{"hard":{"pods":"1"},"used":{"pods":"1"}}},
+	{"metadata":{"name":"priority"},"spec":{"hard":{"pods":"5"},"scopeSelector":{"matchExpressions":[{"scopeName":"PriorityClass","operator":"In","values":["high"]}]}},
+	 "status":{"hard":{"pods":"5"},"used":{"pods":"0"}}}]}`
+
+func TestHandleQuotaCheck(t *testing.T) {
+	call := func(t *testing.T, mock *cmd.MockShellExecutor, args map[string]interface{}) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := newTestK8sTool().handleQuotaCheck(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		return result
+	}
+	decode := func(t *testing.T, result *mcp.CallToolResult) QuotaCheckReport {
+		require.False(t, result.IsError, getResultText(result))
+		var report QuotaCheckReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		return report
+	}
+	namespaceMock := func(limitRanges string) *cmd.MockShellExecutor {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "resourcequotas", "-n", "shop", "-o", "json"}, testQuotas, nil)
+		mock.AddCommandString("kubectl", []string{"get", "limitranges", "-n", "shop", "-o", "json"}, limitRanges, nil)
+		return mock
+	}
+	deployment := `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"shop"},"spec":{"replicas":2,
+		"template":{"spec":{"containers":[{"name":"app","resources":{"requests":{"cpu":"250m"},"limits":{"memory":"1Gi"}}}]}}}}`
+
+	t.Run("new deployment exceeds the cpu quota", func(t *testing.T) {
+		mock := namespaceMock(`{"items":[]}`)
+		mock.AddCommandString("kubectl", []string{"get", "deployment", "api", "-n", "shop", "-o", "json"}, "", errors.New(`Error from server (NotFound): deployments.apps "api" not found`))
+		manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: api
+        resources:
+          requests:
+            cpu: 500m
+          limits:
+            memory: 1Gi
+`
+		report := decode(t, call(t, mock, map[string]interface{}{"manifest": manifest, "namespace": "shop"}))
+		assert.False(t, report.Allowed)
+		assert.Equal(t, []QuotaObjectDemand{{Object: "Deployment/api", Change: "create", Pods: 3, Resources: map[string]string{
+			"cpu": "1500m", "requests.cpu": "1500m", "memory": "3Gi", "requests.memory": "3Gi", "limits.memory": "3Gi", "pods": "3",
+		}}}, report.Objects)
+		assert.Equal(t, []QuotaResourceCheck{
+			{Quota: "compute", Resource: "limits.memory", Hard: "8Gi", Used: "2Gi", Headroom: "6Gi", Requested: "3Gi", After: "5Gi", Status: QuotaOK},
+			{Quota: "compute", Resource: "pods", Hard: "10", Used: "6", Headroom: "4", Requested: "3", After: "9", Status: QuotaOK},
+			{Quota: "compute", Resource: "requests.cpu", Hard: "4", Used: "3", Headroom: "1", Requested: "1500m", After: "4500m", Status: QuotaExceeded,
+				Message: "exceeded quota compute: requested requests.cpu=1500m, used requests.cpu=3, limited requests.cpu=4 (headroom 1)"},
+		}, report.Quotas)
+		assert.Equal(t, []string{report.Quotas[2].Message}, report.Blocking)
+		assert.Equal(t, []string{"quota priority uses a scopeSelector and was not evaluated"}, report.Notes)
+	})
+
+	t.Run("scale-up within quota", func(t *testing.T) {
+		mock := namespaceMock(`{"items":[]}`)
+		mock.AddCommandString("kubectl", []string{"get", "deployment", "web", "-n", "shop", "-o", "json"}, deployment, nil)
+		report := decode(t, call(t, mock, map[string]interface{}{"workload": "deployment/web", "replicas": float64(4), "namespace": "shop"}))
+		assert.True(t, report.Allowed)
+		require.Len(t, report.Objects, 1)
+		assert.Equal(t, QuotaObjectDemand{Object: "Deployment/web", Change: "scale", Pods: 2, Resources: map[string]string{
+			"cpu": "500m", "requests.cpu": "500m", "memory": "2Gi", "requests.memory": "2Gi", "limits.memory": "2Gi", "pods": "2",
+		}}, report.Objects[0])
+		require.Len(t, report.Quotas, 3)
+		assert.Equal(t, "3500m", report.Quotas[2].After)
+	})
+
+	t.Run("limit ranges and unspecified quota resources block the change", func(t *testing.T) {
+		mock := namespaceMock(`{"items":[{"metadata":{"name":"cpu-only"},"spec":{"limits":[{"type":"Container","default":{"cpu":"500m"},"max":{"cpu":"500m"}}]}}]}`)
+		mock.AddCommandString("kubectl", []string{"get", "pod", "debug", "-n", "shop", "-o", "json"}, "", errors.New(`Error from server (NotFound): pods "debug" not found`))
+		manifest := `apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+spec:
+  containers:
+  - name: shell
+    resources:
+      limits:
+        cpu: 1
+`
+		report := decode(t, call(t, mock, map[string]interface{}{"manifest": manifest, "namespace": "shop"}))
+		assert.False(t, report.Allowed)
+		require.Len(t, report.LimitRangeViolations, 1)
+		assert.Equal(t, "maximum cpu usage per Container is 500m, but limit is 1", report.LimitRangeViolations[0].Message)
+		assert.Equal(t, []string{
+			"quota compute tracks limits.memory, so every container must set it; unset in containers shell",
+			"Pod/debug container shell: maximum cpu usage per Container is 500m, but limit is 1 (LimitRange cpu-only)",
+		}, report.Blocking)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		result := call(t, cmd.NewMockShellExecutor(), map[string]interface{}{})
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "exactly one of manifest or workload")
+
+		result = call(t, cmd.NewMockShellExecutor(), map[string]interface{}{"workload": "daemonset/agent", "replicas": float64(2)})
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "workload must be <kind>/<name>")
+
+		result = call(t, cmd.NewMockShellExecutor(), map[string]interface{}{"manifest": "kind: ConfigMap\nmetadata:\n  name: a\n  namespace: other\n", "namespace": "shop"})
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "all resources must be in namespace shop")
+	})
+}
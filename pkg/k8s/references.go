@@ -0,0 +1,321 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Statuses of the references checked by k8s_check_references
+const (
+	ReferenceOK         = "ok"
+	ReferenceMissing    = "missing"
+	ReferenceMissingKey = "missing_key"
+)
+
+// Kinds of objects a pod spec references
+const (
+	referenceConfigMap      = "ConfigMap"
+	referenceSecret         = "Secret"
+	referenceServiceAccount = "ServiceAccount"
+)
+
+// objectReference is a reference to a ConfigMap or Secret, optionally to one key
+type objectReference struct {
+	Name     string `json:"name"`
+	Key      string `json:"key"`
+	Optional *bool  `json:"optional"`
+}
+
+// keyToPath projects a ConfigMap or Secret key into a volume
+type keyToPath struct {
+	Key string `json:"key"`
+}
+
+// referencePodSpec is the subset of a pod spec referencing other objects
+type referencePodSpec struct {
+	ServiceAccountName string               `json:"serviceAccountName"`
+	ImagePullSecrets   []objectReference    `json:"imagePullSecrets"`
+	Containers         []referenceContainer `json:"containers"`
+	InitContainers     []referenceContainer `json:"initContainers"`
+	Volumes            []struct {
+		Name      string `json:"name"`
+		ConfigMap *struct {
+			Name     string      `json:"name"`
+			Items    []keyToPath `json:"items"`
+			Optional *bool       `json:"optional"`
+		} `json:"configMap"`
+		Secret *struct {
+			SecretName string      `json:"secretName"`
+			Items      []keyToPath `json:"items"`
+			Optional   *bool       `json:"optional"`
+		} `json:"secret"`
+		Projected *struct {
+			Sources []struct {
+				ConfigMap *struct {
+					Name     string      `json:"name"`
+					Items    []keyToPath `json:"items"`
+					Optional *bool       `json:"optional"`
+				} `json:"configMap"`
+				Secret *struct {
+					Name     string      `json:"name"`
+					Items    []keyToPath `json:"items"`
+					Optional *bool       `json:"optional"`
+				} `json:"secret"`
+			} `json:"sources"`
+		} `json:"projected"`
+	} `json:"volumes"`
+}
+
+type referenceContainer struct {
+	Name string `json:"name"`
+	Env  []struct {
+		Name      string `json:"name"`
+		ValueFrom *struct {
+			ConfigMapKeyRef *objectReference `json:"configMapKeyRef"`
+			SecretKeyRef    *objectReference `json:"secretKeyRef"`
+		} `json:"valueFrom"`
+	} `json:"env"`
+	EnvFrom []struct {
+		ConfigMapRef *objectReference `json:"configMapRef"`
+		SecretRef    *objectReference `json:"secretRef"`
+	} `json:"envFrom"`
+}
+
+// ReferenceCheck is an object referenced by a workload's pod spec
+type ReferenceCheck struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Key      string `json:"key,omitempty"`
+	Source   string `json:"source"`
+	Optional bool   `json:"optional,omitempty"`
+	Status   string `json:"status"`
+	// Severity and Message explain the effect of a broken reference on the pods
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message,omitempty"`
+
+	// effect is the failure a required broken reference causes
+	effect string
+}
+
+// ReferenceReport is the structured response of k8s_check_references
+type ReferenceReport struct {
+	Workload   string           `json:"workload"`
+	Namespace  string           `json:"namespace"`
+	Checked    int              `json:"checked"`
+	Broken     int              `json:"broken"`
+	References []ReferenceCheck `json:"references"`
+}
+
+// collectReferences lists the ConfigMaps, Secrets and ServiceAccount a pod spec references
+func collectReferences(spec referencePodSpec) []ReferenceCheck {
+	var refs []ReferenceCheck
+	add := func(kind, name, key, source string, optional *bool, effect string) {
+		if name == "" {
+			return
+		}
+		refs = append(refs, ReferenceCheck{Kind: kind, Name: name, Key: key, Source: source, Optional: optional != nil && *optional, effect: effect})
+	}
+
+	serviceAccount := spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+	add(referenceServiceAccount, serviceAccount, "", "serviceAccountName", nil, "the API server rejects the pods, so the controller cannot create them")
+	for _, secret := range spec.ImagePullSecrets {
+		// The kubelet pulls without a missing pull secret, so the reference is effectively optional
+		optional := true
+		add(referenceSecret, secret.Name, "", "imagePullSecrets", &optional, "")
+	}
+
+	containerEffect := "containers fail with CreateContainerConfigError"
+	for _, containers := range [][]referenceContainer{spec.InitContainers, spec.Containers} {
+		for _, c := range containers {
+			for _, env := range c.Env {
+				if env.ValueFrom == nil {
+					continue
+				}
+				source := fmt.Sprintf("container %s env %s", c.Name, env.Name)
+				if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+					add(referenceConfigMap, ref.Name, ref.Key, source, ref.Optional, containerEffect)
+				}
+				if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+					add(referenceSecret, ref.Name, ref.Key, source, ref.Optional, containerEffect)
+				}
+			}
+			for _, envFrom := range c.EnvFrom {
+				source := fmt.Sprintf("container %s envFrom", c.Name)
+				if ref := envFrom.ConfigMapRef; ref != nil {
+					add(referenceConfigMap, ref.Name, "", source, ref.Optional, containerEffect)
+				}
+				if ref := envFrom.SecretRef; ref != nil {
+					add(referenceSecret, ref.Name, "", source, ref.Optional, containerEffect)
+				}
+			}
+		}
+	}
+
+	volumeEffect := "the volume cannot be mounted (FailedMount), so the pods stay in ContainerCreating"
+	addItems := func(kind, name string, items []keyToPath, source string, optional *bool) {
+		if len(items) == 0 {
+			add(kind, name, "", source, optional, volumeEffect)
+		}
+		for _, item := range items {
+			add(kind, name, item.Key, source, optional, volumeEffect)
+		}
+	}
+	for _, volume := range spec.Volumes {
+		source := "volume " + volume.Name
+		if cm := volume.ConfigMap; cm != nil {
+			addItems(referenceConfigMap, cm.Name, cm.Items, source, cm.Optional)
+		}
+		if secret := volume.Secret; secret != nil {
+			addItems(referenceSecret, secret.SecretName, secret.Items, source, secret.Optional)
+		}
+		if projected := volume.Projected; projected != nil {
+			for _, s := range projected.Sources {
+				if cm := s.ConfigMap; cm != nil {
+					addItems(referenceConfigMap, cm.Name, cm.Items, source, cm.Optional)
+				}
+				if secret := s.Secret; secret != nil {
+					addItems(referenceSecret, secret.Name, secret.Items, source, secret.Optional)
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// objectKeys maps object names to their data keys, read from a kubectl list
+func objectKeys(output string) (map[string]map[string]bool, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Data       map[string]json.RawMessage `json:"data"`
+			BinaryData map[string]json.RawMessage `json:"binaryData"`
+			StringData map[string]json.RawMessage `json:"stringData"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, err
+	}
+	objects := make(map[string]map[string]bool, len(list.Items))
+	for _, item := range list.Items {
+		keys := make(map[string]bool)
+		for _, data := range []map[string]json.RawMessage{item.Data, item.BinaryData, item.StringData} {
+			for key := range data {
+				keys[key] = true
+			}
+		}
+		objects[item.Metadata.Name] = keys
+	}
+	return objects, nil
+}
+
+// checkReferences resolves references against the objects of their namespace
+func checkReferences(refs []ReferenceCheck, objects map[string]map[string]map[string]bool) []ReferenceCheck {
+	for i := range refs {
+		ref := &refs[i]
+		keys, exists := objects[ref.Kind][ref.Name]
+		switch {
+		case !exists:
+			ref.Status = ReferenceMissing
+			ref.Message = fmt.Sprintf("%s %s does not exist", ref.Kind, ref.Name)
+		case ref.Key != "" && !keys[ref.Key]:
+			ref.Status = ReferenceMissingKey
+			ref.Message = fmt.Sprintf("%s %s has no key %s", ref.Kind, ref.Name, ref.Key)
+		default:
+			ref.Status = ReferenceOK
+			continue
+		}
+		switch {
+		case ref.Source == "imagePullSecrets":
+			ref.Severity = severityMedium
+			ref.Message += "; images are pulled without its credentials, so private images fail with ImagePullBackOff"
+		case ref.Optional:
+			ref.Severity = severityLow
+			ref.Message += "; the reference is optional, so the pods start without it"
+		default:
+			ref.Severity = severityHigh
+			ref.Message += "; " + ref.effect
+		}
+	}
+	return refs
+}
+
+// Reference integrity check
+func (k *K8sTool) handleCheckReferences(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	workload := p.String("workload", "", params.Required())
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	kind, name, err := parseWorkloadRef(workload)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	output, err := k.runKubectlCommandString(ctx, "get", kind, name, "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get %s/%s: %v", kind, name, err)), nil
+	}
+	var object struct {
+		Spec struct {
+			referencePodSpec
+			Template struct {
+				Spec referencePodSpec `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal([]byte(output), &object); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse %s/%s: %v", kind, name, err)), nil
+	}
+	spec := object.Spec.Template.Spec
+	if kind == "pod" {
+		spec = object.Spec.referencePodSpec
+	}
+	refs := collectReferences(spec)
+
+	objects := make(map[string]map[string]map[string]bool)
+	for _, list := range []struct{ kind, resource string }{
+		{referenceConfigMap, "configmaps"},
+		{referenceSecret, "secrets"},
+		{referenceServiceAccount, "serviceaccounts"},
+	} {
+		listOutput, err := k.runKubectlCommandString(ctx, "get", list.resource, "-n", namespace, "-o", "json")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list %s: %v", list.resource, err)), nil
+		}
+		if objects[list.kind], err = objectKeys(listOutput); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to parse %s: %v", list.resource, err)), nil
+		}
+	}
+
+	report := ReferenceReport{Workload: kind + "/" + name, Namespace: namespace, References: checkReferences(refs, objects)}
+	report.Checked = len(report.References)
+	for _, ref := range report.References {
+		if ref.Status != ReferenceOK {
+			report.Broken++
+		}
+	}
+	// Broken references first, the most severe at the top
+	rank := map[string]int{severityHigh: 0, severityMedium: 1, severityLow: 2, "": 3}
+	sort.SliceStable(report.References, func(i, j int) bool {
+		return rank[report.References[i].Severity] < rank[report.References[j].Severity]
+	})
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling reference report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
@@ -0,0 +1,98 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const testReferenceDeployment = `{"spec":{"template":{"spec":{
+ "serviceAccountName":"api",
+ "imagePullSecrets":[{"name":"regcred"}],
+ "initContainers":[{"name":"migrate","envFrom":[{"secretRef":{"name":"db"}}]}],
+ "containers":[{"name":"app",
+  "env":[
+   {"name":"LOG_LEVEL","value":"info"},
+   {"name":"DB_PASSWORD","valueFrom":{"secretKeyRef":{"name":"db","key":"password"}}},
+   {"name":"DB_USER","valueFrom":{"secretKeyRef":{"name":"db","key":"username"}}},
+   {"name":"FEATURES","valueFrom":{"configMapKeyRef":{"name":"features","key":"flags","optional":true}}}],
+  "envFrom":[{"configMapRef":{"name":"app-config"}}]}],
+ "volumes":[
+  {"name":"tls","secret":{"secretName":"api-tls","items":[{"key":"tls.crt","path":"tls.crt"},{"key":"ca.crt","path":"ca.crt"}]}},
+  {"name":"bundle","projected":{"sources":[{"configMap":{"name":"trust-bundle"}}]}},
+  {"name":"data","emptyDir":{}}]}}}}`
+
+const testReferenceConfigMaps = `{"items":[
+ {"metadata":{"name":"app-config"},"data":{"PORT":"8080"}},
+ {"metadata":{"name":"trust-bundle"},"binaryData":{"bundle.pem":"AA=="}}]}`
+
+const testReferenceSecrets = `{"items":[
+ {"metadata":{"name":"db"},"data":{"password":"cGFzcw=="}},
+ {"metadata":{"name":"api-tls"},"data":{"tls.crt":"","tls.key":""}}]}`
+
+const testReferenceServiceAccounts = `{"items":[{"metadata":{"name":"default"}}]}`
+
+func TestHandleCheckReferences(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "deployment", "api", "-n", "shop", "-o", "json"}, testReferenceDeployment, nil)
+	mock.AddCommandString("kubectl", []string{"get", "configmaps", "-n", "shop", "-o", "json"}, testReferenceConfigMaps, nil)
+	mock.AddCommandString("kubectl", []string{"get", "secrets", "-n", "shop", "-o", "json"}, testReferenceSecrets, nil)
+	mock.AddCommandString("kubectl", []string{"get", "serviceaccounts", "-n", "shop", "-o", "json"}, testReferenceServiceAccounts, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"workload": "deployment/api", "namespace": "shop"}
+	result, err := newTestK8sTool().handleCheckReferences(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var report ReferenceReport
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+	assert.Equal(t, "deployment/api", report.Workload)
+	assert.Equal(t, 10, report.Checked)
+	assert.Equal(t, 5, report.Broken)
+
+	type brokenRef struct{ Kind, Name, Key, Source, Status, Severity string }
+	var broken []brokenRef
+	for _, ref := range report.References {
+		if ref.Status != ReferenceOK {
+			broken = append(broken, brokenRef{ref.Kind, ref.Name, ref.Key, ref.Source, ref.Status, ref.Severity})
+		}
+	}
+	assert.Equal(t, []brokenRef{
+		{"ServiceAccount", "api", "", "serviceAccountName", ReferenceMissing, severityHigh},
+		{"Secret", "db", "username", "container app env DB_USER", ReferenceMissingKey, severityHigh},
+		{"Secret", "api-tls", "ca.crt", "volume tls", ReferenceMissingKey, severityHigh},
+		{"Secret", "regcred", "", "imagePullSecrets", ReferenceMissing, severityMedium},
+		{"ConfigMap", "features", "flags", "container app env FEATURES", ReferenceMissing, severityLow},
+	}, broken)
+	assert.Contains(t, report.References[1].Message, "CreateContainerConfigError")
+	assert.Contains(t, report.References[2].Message, "FailedMount")
+}
+
+func TestHandleCheckReferencesPod(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "pod", "web-1", "-n", "default", "-o", "json"},
+		`{"spec":{"containers":[{"name":"web","envFrom":[{"configMapRef":{"name":"web"}}]}]}}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "configmaps", "-n", "default", "-o", "json"}, `{"items":[{"metadata":{"name":"web"}}]}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "secrets", "-n", "default", "-o", "json"}, `{"items":[]}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "serviceaccounts", "-n", "default", "-o", "json"}, testReferenceServiceAccounts, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"workload": "web-1"}
+	result, err := newTestK8sTool().handleCheckReferences(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var report ReferenceReport
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+	assert.Equal(t, 2, report.Checked)
+	assert.Equal(t, 0, report.Broken)
+}
@@ -0,0 +1,571 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// AuditLogFile configures the Kubernetes API server audit log, in JSON lines
+// format, that release timelines read applied changes from
+const AuditLogFile = "AUDIT_LOG_FILE"
+
+// defaultTimelineWindow is how far back a release timeline looks when no window is given
+const defaultTimelineWindow = 24 * time.Hour
+
+// maxHelmHistory is the number of revisions read from the history of each Helm release
+const maxHelmHistory = 50
+
+// Sources of release timeline events
+const (
+	TimelineSourceHelm          = "helm"
+	TimelineSourceRevision      = "revision"
+	TimelineSourceArgoRollout   = "argo_rollout"
+	TimelineSourceManagedFields = "managed_fields"
+	TimelineSourceAuditLog      = "audit_log"
+	TimelineSourceScaling       = "scaling"
+	TimelineSourceRestart       = "restart"
+)
+
+// Annotations linking resources to the changes that produced them
+const (
+	helmReleaseAnnotation     = "meta.helm.sh/release-name"
+	argoRevisionAnnotation    = "rollout.argoproj.io/revision"
+	revisionHistoryAnnotation = "deployment.kubernetes.io/revision-history"
+	restartedAtAnnotation     = "kubectl.kubernetes.io/restartedAt"
+)
+
+// controllerManagers are field managers of Kubernetes controllers, whose writes
+// follow from other changes rather than being changes themselves
+var controllerManagers = map[string]bool{
+	"kube-controller-manager": true,
+	"kube-scheduler":          true,
+	"rollouts-controller":     true,
+}
+
+// scalingEventReasons are the event reasons recorded when a workload is scaled
+var scalingEventReasons = map[string]bool{"ScalingReplicaSet": true, "SuccessfulRescale": true}
+
+// auditedResources are the audit log resources of the objects in a timeline
+var auditedResources = map[string]string{
+	"Deployment":              "deployments",
+	"StatefulSet":             "statefulsets",
+	"DaemonSet":               "daemonsets",
+	"Rollout":                 "rollouts",
+	"Service":                 "services",
+	"HorizontalPodAutoscaler": "horizontalpodautoscalers",
+}
+
+// TimelineEvent is a deployment-affecting change
+type TimelineEvent struct {
+	Time        time.Time `json:"time"`
+	Source      string    `json:"source"`
+	Object      string    `json:"object"`
+	Action      string    `json:"action"`
+	Description string    `json:"description"`
+	Revision    string    `json:"revision,omitempty"`
+	Images      []string  `json:"images,omitempty"`
+	Actor       string    `json:"actor,omitempty"`
+}
+
+// ReleaseTimeline is the chronological list of changes affecting a service
+type ReleaseTimeline struct {
+	Service      string          `json:"service"`
+	Namespace    string          `json:"namespace"`
+	Start        time.Time       `json:"start"`
+	End          time.Time       `json:"end"`
+	Workloads    []string        `json:"workloads"`
+	HelmReleases []string        `json:"helm_releases,omitempty"`
+	Events       []TimelineEvent `json:"events"`
+	Notes        []string        `json:"notes,omitempty"`
+}
+
+// managedFieldsEntry is the subset of a metadata.managedFields entry used for timelines
+// and deprecation scans
+type managedFieldsEntry struct {
+	Manager     string `json:"manager"`
+	APIVersion  string `json:"apiVersion"`
+	Operation   string `json:"operation"`
+	Time        string `json:"time"`
+	Subresource string `json:"subresource"`
+}
+
+// timelineObject is the subset of a workload, service or autoscaler used for timelines
+type timelineObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name              string               `json:"name"`
+		CreationTimestamp string               `json:"creationTimestamp"`
+		Annotations       map[string]string    `json:"annotations"`
+		ManagedFields     []managedFieldsEntry `json:"managedFields"`
+		OwnerReferences   []struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec struct {
+		Selector  map[string]interface{} `json:"selector"`
+		RestartAt string                 `json:"restartAt"`
+		Template  struct {
+			Metadata struct {
+				Labels      map[string]string `json:"labels"`
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+			Spec struct {
+				Containers []rolloutContainer `json:"containers"`
+			} `json:"spec"`
+		} `json:"template"`
+		ScaleTargetRef struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"scaleTargetRef"`
+	} `json:"spec"`
+	// Revision is set on ControllerRevisions
+	Revision int64 `json:"revision"`
+}
+
+func (o timelineObject) id() string {
+	return o.Kind + "/" + o.Metadata.Name
+}
+
+// ownedBy returns the owner of the object among the given workload IDs
+func (o timelineObject) ownedBy(workloads map[string]bool) string {
+	for _, owner := range o.Metadata.OwnerReferences {
+		if id := owner.Kind + "/" + owner.Name; workloads[id] {
+			return id
+		}
+	}
+	return ""
+}
+
+func parseTimelineObjects(output, kind string) ([]timelineObject, error) {
+	var list struct {
+		Items []timelineObject `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, err
+	}
+	// kubectl omits the kind of list items of a single resource type
+	for i := range list.Items {
+		if list.Items[i].Kind == "" {
+			list.Items[i].Kind = kind
+		}
+	}
+	return list.Items, nil
+}
+
+// helmRevision is an entry of `helm history -o json`
+type helmRevision struct {
+	Revision    int    `json:"revision"`
+	Updated     string `json:"updated"`
+	Status      string `json:"status"`
+	Chart       string `json:"chart"`
+	AppVersion  string `json:"app_version"`
+	Description string `json:"description"`
+}
+
+// parseTimestamp parses Kubernetes and Helm timestamps
+func parseTimestamp(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999 -0700 MST"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// timelineCollector gathers the events of a release timeline within its window
+type timelineCollector struct {
+	timeline *ReleaseTimeline
+}
+
+// add records an event when its time falls in the timeline's window
+func (c *timelineCollector) add(timestamp string, event TimelineEvent) {
+	t, ok := parseTimestamp(timestamp)
+	if !ok || t.Before(c.timeline.Start) || t.After(c.timeline.End) {
+		return
+	}
+	event.Time = t.UTC()
+	c.timeline.Events = append(c.timeline.Events, event)
+}
+
+func (c *timelineCollector) note(format string, args ...interface{}) {
+	c.timeline.Notes = append(c.timeline.Notes, fmt.Sprintf(format, args...))
+}
+
+// addManagedFields records the last write of each field manager of an object.
+// Status writes and controllers are skipped, as they follow from other changes.
+func (c *timelineCollector) addManagedFields(object timelineObject) {
+	for _, entry := range object.Metadata.ManagedFields {
+		if entry.Subresource != "" || controllerManagers[entry.Manager] {
+			continue
+		}
+		action := "updated"
+		if entry.Operation == "Apply" {
+			action = "applied"
+		}
+		c.add(entry.Time, TimelineEvent{
+			Source:      TimelineSourceManagedFields,
+			Object:      object.id(),
+			Action:      action,
+			Description: fmt.Sprintf("%s %s by %s (last write by this field manager)", object.id(), action, entry.Manager),
+			Actor:       entry.Manager,
+		})
+	}
+}
+
+// addRestart records a rollout restart requested through the pod template or an Argo Rollout's restartAt
+func (c *timelineCollector) addRestart(object timelineObject) {
+	if restartedAt := object.Spec.Template.Metadata.Annotations[restartedAtAnnotation]; restartedAt != "" {
+		c.add(restartedAt, TimelineEvent{Source: TimelineSourceRestart, Object: object.id(), Action: "restarted", Description: object.id() + " rollout restart requested"})
+	}
+	if object.Spec.RestartAt != "" {
+		c.add(object.Spec.RestartAt, TimelineEvent{Source: TimelineSourceRestart, Object: object.id(), Action: "restarted", Description: object.id() + " pods scheduled for restart"})
+	}
+}
+
+// addReplicaSets records the revisions of Deployments and Argo Rollouts
+func (c *timelineCollector) addReplicaSets(replicaSets []timelineObject, workloads map[string]bool) {
+	for _, rs := range replicaSets {
+		owner := rs.ownedBy(workloads)
+		if owner == "" {
+			continue
+		}
+		source, revision := TimelineSourceRevision, rs.Metadata.Annotations[revisionAnnotation]
+		if strings.HasPrefix(owner, "Rollout/") {
+			source, revision = TimelineSourceArgoRollout, rs.Metadata.Annotations[argoRevisionAnnotation]
+		}
+		if history := rs.Metadata.Annotations[revisionHistoryAnnotation]; history != "" {
+			c.note("%s revision %s reuses ReplicaSet %s of revisions %s, so it was a rollback; its time is not recorded", owner, revision, rs.Metadata.Name, history)
+			revision = strings.Split(history, ",")[0]
+		}
+		images := containerImages(rs.Spec.Template.Spec.Containers)
+		c.add(rs.Metadata.CreationTimestamp, TimelineEvent{
+			Source:      source,
+			Object:      owner,
+			Action:      "revision",
+			Description: fmt.Sprintf("%s revision %s rolled out as ReplicaSet %s (%s)", owner, revision, rs.Metadata.Name, strings.Join(images, ", ")),
+			Revision:    revision,
+			Images:      images,
+		})
+	}
+}
+
+// addControllerRevisions records the revisions of StatefulSets and DaemonSets
+func (c *timelineCollector) addControllerRevisions(revisions []timelineObject, workloads map[string]bool) {
+	for _, revision := range revisions {
+		owner := revision.ownedBy(workloads)
+		if owner == "" {
+			continue
+		}
+		number := strconv.FormatInt(revision.Revision, 10)
+		c.add(revision.Metadata.CreationTimestamp, TimelineEvent{
+			Source:      TimelineSourceRevision,
+			Object:      owner,
+			Action:      "revision",
+			Description: fmt.Sprintf("%s revision %s rolled out as ControllerRevision %s", owner, number, revision.Metadata.Name),
+			Revision:    number,
+		})
+	}
+}
+
+// addEvents records scaling events of the workloads and autoscalers, and the
+// lifecycle events of Argo Rollouts
+func (c *timelineCollector) addEvents(output string, objects map[string]bool) {
+	var events eventList
+	if err := json.Unmarshal([]byte(output), &events); err != nil {
+		c.note("events could not be parsed: %v", err)
+		return
+	}
+	for _, event := range events.Items {
+		object := event.InvolvedObject.Kind + "/" + event.InvolvedObject.Name
+		if !objects[object] {
+			continue
+		}
+		source := TimelineSourceScaling
+		switch {
+		case scalingEventReasons[event.Reason]:
+		case event.InvolvedObject.Kind == "Rollout" && strings.HasPrefix(event.Reason, "Rollout"):
+			source = TimelineSourceArgoRollout
+		default:
+			continue
+		}
+		timestamp := event.LastTimestamp
+		if timestamp == "" {
+			timestamp = event.EventTime
+		}
+		c.add(timestamp, TimelineEvent{
+			Source:      source,
+			Object:      object,
+			Action:      event.Reason,
+			Description: fmt.Sprintf("%s: %s", object, event.Message),
+		})
+	}
+}
+
+// addHelmHistory records the revisions of a Helm release
+func (c *timelineCollector) addHelmHistory(release, output string) {
+	var history []helmRevision
+	if err := json.Unmarshal([]byte(output), &history); err != nil {
+		c.note("history of Helm release %s could not be parsed: %v", release, err)
+		return
+	}
+	object := "HelmRelease/" + release
+	for _, revision := range history {
+		description := fmt.Sprintf("%s revision %d %s: %s (chart %s", object, revision.Revision, revision.Status, revision.Description, revision.Chart)
+		if revision.AppVersion != "" {
+			description += ", app version " + revision.AppVersion
+		}
+		c.add(revision.Updated, TimelineEvent{
+			Source:      TimelineSourceHelm,
+			Object:      object,
+			Action:      revision.Status,
+			Description: description + ")",
+			Revision:    strconv.Itoa(revision.Revision),
+		})
+	}
+}
+
+// auditEvent is the subset of a Kubernetes audit event used for timelines
+type auditEvent struct {
+	Stage string `json:"stage"`
+	Verb  string `json:"verb"`
+	User  struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	ObjectRef struct {
+		Resource    string `json:"resource"`
+		Namespace   string `json:"namespace"`
+		Name        string `json:"name"`
+		Subresource string `json:"subresource"`
+	} `json:"objectRef"`
+	ResponseStatus struct {
+		Code int `json:"code"`
+	} `json:"responseStatus"`
+	StageTimestamp string `json:"stageTimestamp"`
+}
+
+// auditVerbs are the audited requests that change objects
+var auditVerbs = map[string]string{"create": "created", "update": "updated", "patch": "patched", "delete": "deleted"}
+
+// addAuditLog records the successful writes to the timeline's objects found in
+// an audit log; objects maps audit resource/name to object IDs
+func (c *timelineCollector) addAuditLog(path string, objects map[string]string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		// Skip the decoding of lines that cannot concern the namespace
+		if !strings.Contains(string(line), `"namespace":"`+c.timeline.Namespace+`"`) {
+			continue
+		}
+		var event auditEvent
+		if err := json.Unmarshal(line, &event); err != nil || event.Stage != "ResponseComplete" {
+			continue
+		}
+		action, ok := auditVerbs[event.Verb]
+		ref := event.ObjectRef
+		if !ok || ref.Namespace != c.timeline.Namespace || ref.Subresource == "status" || event.ResponseStatus.Code >= 400 {
+			continue
+		}
+		object, ok := objects[ref.Resource+"/"+ref.Name]
+		if !ok {
+			continue
+		}
+		description := fmt.Sprintf("%s %s by %s", object, action, event.User.Username)
+		if ref.Subresource != "" {
+			description = fmt.Sprintf("%s %s %s by %s", object, ref.Subresource, action, event.User.Username)
+		}
+		c.add(event.StageTimestamp, TimelineEvent{
+			Source:      TimelineSourceAuditLog,
+			Object:      object,
+			Action:      event.Verb,
+			Description: description,
+			Actor:       event.User.Username,
+		})
+	}
+	return scanner.Err()
+}
+
+// selects reports whether a label selector given as a map matches the labels
+func selects(selector, labels map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return len(selector) > 0
+}
+
+func (k *K8sTool) handleReleaseTimeline(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	service := p.String("service", "", params.Required(), params.Check(security.ValidateK8sResourceName))
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	since := p.Duration("since", defaultTimelineWindow)
+	until := p.String("until", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	end := time.Now().UTC()
+	if until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return mcp.NewToolResultError("until must be an RFC 3339 time, e.g. 2025-01-02T15:04:05Z"), nil
+		}
+		end = t.UTC()
+	}
+
+	timeline := &ReleaseTimeline{Service: service, Namespace: namespace, Start: end.Add(-since), End: end, Workloads: []string{}, Events: []TimelineEvent{}}
+	c := &timelineCollector{timeline: timeline}
+
+	output, err := k.runKubectlCommandString(ctx, "get", "service", service, "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get service %s: %v", service, err)), nil
+	}
+	var svc struct {
+		timelineObject
+		Spec struct {
+			Selector map[string]string `json:"selector"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal([]byte(output), &svc); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse service %s: %v", service, err)), nil
+	}
+	svc.Kind = "Service"
+	if len(svc.Spec.Selector) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("service %s has no pod selector", service)), nil
+	}
+
+	// The workloads of the service are those whose pod template its selector matches
+	output, err = k.runKubectlCommandString(ctx, "get", "deployments,statefulsets,daemonsets", "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list workloads: %v", err)), nil
+	}
+	candidates, err := parseTimelineObjects(output, "")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse workloads: %v", err)), nil
+	}
+	if output, err := k.runKubectlCommandString(ctx, "get", "rollouts.argoproj.io", "-n", namespace, "-o", "json"); err == nil {
+		if rollouts, err := parseTimelineObjects(output, "Rollout"); err == nil {
+			candidates = append(candidates, rollouts...)
+		}
+	}
+
+	objects := []timelineObject{svc.timelineObject}
+	workloads := map[string]bool{}
+	releases := map[string]bool{}
+	if release := svc.Metadata.Annotations[helmReleaseAnnotation]; release != "" {
+		releases[release] = true
+	}
+	for _, workload := range candidates {
+		if !selects(svc.Spec.Selector, workload.Spec.Template.Metadata.Labels) {
+			continue
+		}
+		workloads[workload.id()] = true
+		timeline.Workloads = append(timeline.Workloads, workload.id())
+		objects = append(objects, workload)
+		if release := workload.Metadata.Annotations[helmReleaseAnnotation]; release != "" {
+			releases[release] = true
+		}
+	}
+	sort.Strings(timeline.Workloads)
+	if len(workloads) == 0 {
+		c.note("no Deployment, StatefulSet, DaemonSet or Argo Rollout runs the pods selected by service %s", service)
+	}
+
+	autoscalers := map[string]bool{}
+	if output, err := k.runKubectlCommandString(ctx, "get", "horizontalpodautoscalers", "-n", namespace, "-o", "json"); err == nil {
+		hpas, _ := parseTimelineObjects(output, "HorizontalPodAutoscaler")
+		for _, hpa := range hpas {
+			if workloads[hpa.Spec.ScaleTargetRef.Kind+"/"+hpa.Spec.ScaleTargetRef.Name] {
+				autoscalers[hpa.id()] = true
+				objects = append(objects, hpa)
+			}
+		}
+	}
+
+	for _, object := range objects {
+		c.addManagedFields(object)
+		c.addRestart(object)
+	}
+
+	if output, err := k.runKubectlCommandString(ctx, "get", "replicasets", "-n", namespace, "-o", "json"); err != nil {
+		c.note("ReplicaSet revisions unavailable: %v", err)
+	} else if replicaSets, err := parseTimelineObjects(output, "ReplicaSet"); err == nil {
+		c.addReplicaSets(replicaSets, workloads)
+	}
+	if output, err := k.runKubectlCommandString(ctx, "get", "controllerrevisions", "-n", namespace, "-o", "json"); err != nil {
+		c.note("ControllerRevisions unavailable: %v", err)
+	} else if revisions, err := parseTimelineObjects(output, "ControllerRevision"); err == nil {
+		c.addControllerRevisions(revisions, workloads)
+	}
+
+	if output, err := k.runKubectlCommandString(ctx, "get", "events", "-n", namespace, "-o", "json"); err != nil {
+		c.note("events unavailable: %v", err)
+	} else {
+		eventObjects := map[string]bool{}
+		for id := range workloads {
+			eventObjects[id] = true
+		}
+		for id := range autoscalers {
+			eventObjects[id] = true
+		}
+		c.addEvents(output, eventObjects)
+	}
+
+	for release := range releases {
+		timeline.HelmReleases = append(timeline.HelmReleases, release)
+	}
+	sort.Strings(timeline.HelmReleases)
+	for _, release := range timeline.HelmReleases {
+		output, err := commands.NewCommandBuilder("helm").
+			WithArgs("history", release, "-n", namespace, "--max", strconv.Itoa(maxHelmHistory), "-o", "json").
+			WithKubeconfig(k.kubeconfig).
+			Execute(ctx)
+		if err != nil {
+			c.note("history of Helm release %s unavailable: %v", release, err)
+			continue
+		}
+		c.addHelmHistory(release, output)
+	}
+
+	if path := os.Getenv(AuditLogFile); path != "" {
+		audited := map[string]string{}
+		for _, object := range objects {
+			if resource, ok := auditedResources[object.Kind]; ok {
+				audited[resource+"/"+object.Metadata.Name] = object.id()
+			}
+		}
+		if err := c.addAuditLog(path, audited); err != nil {
+			c.note("audit log %s could not be read: %v", path, err)
+		}
+	} else {
+		c.note("managed fields only record the last write of each field manager; set %s to the API server audit log to list every applied change", AuditLogFile)
+	}
+
+	sort.SliceStable(timeline.Events, func(i, j int) bool { return timeline.Events[i].Time.Before(timeline.Events[j].Time) })
+
+	timelineJSON, err := json.MarshalIndent(timeline, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal release timeline: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(timelineJSON)), nil
+}
@@ -0,0 +1,157 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const (
+	testTimelineService = `{"kind":"Service","metadata":{"name":"web","annotations":{"meta.helm.sh/release-name":"shop"}},"spec":{"selector":{"app":"web"}}}`
+
+	testTimelineWorkloads = `{"items":[
+		{"kind":"Deployment","metadata":{"name":"web","annotations":{"meta.helm.sh/release-name":"shop"},"managedFields":[
+			{"manager":"helm","operation":"Update","time":"2025-03-01T09:00:00Z"},
+			{"manager":"kubectl-client-side-apply","operation":"Update","time":"2025-03-01T10:30:00Z"},
+			{"manager":"kube-controller-manager","operation":"Update","time":"2025-03-01T10:31:00Z","subresource":"status"}]},
+		 "spec":{"template":{"metadata":{"labels":{"app":"web","tier":"frontend"},"annotations":{"kubectl.kubernetes.io/restartedAt":"2025-03-01T11:00:00Z"}}}}},
+		{"kind":"Deployment","metadata":{"name":"worker"},"spec":{"template":{"metadata":{"labels":{"app":"worker"}}}}}]}`
+
+	testTimelineReplicaSets = `{"items":[
+		{"metadata":{"name":"web-old","creationTimestamp":"2025-02-20T09:00:00Z","annotations":{"deployment.kubernetes.io/revision":"3"},"ownerReferences":[{"kind":"Deployment","name":"web"}]},
+		 "spec":{"template":{"spec":{"containers":[{"name":"app","image":"web:1.0"}]}}}},
+		{"metadata":{"name":"web-new","creationTimestamp":"2025-03-01T09:00:05Z","annotations":{"deployment.kubernetes.io/revision":"4"},"ownerReferences":[{"kind":"Deployment","name":"web"}]},
+		 "spec":{"template":{"spec":{"containers":[{"name":"app","image":"web:1.1"}]}}}},
+		{"metadata":{"name":"worker-a","creationTimestamp":"2025-03-01T09:30:00Z","annotations":{"deployment.kubernetes.io/revision":"1"},"ownerReferences":[{"kind":"Deployment","name":"worker"}]}}]}`
+
+	testTimelineHPAs = `{"items":[{"metadata":{"name":"web"},"spec":{"scaleTargetRef":{"kind":"Deployment","name":"web"}}}]}`
+
+	testTimelineEvents = `{"items":[
+		{"involvedObject":{"kind":"HorizontalPodAutoscaler","name":"web"},"reason":"SuccessfulRescale","message":"New size: 6; reason: cpu resource utilization above target","lastTimestamp":"2025-03-01T10:45:00Z"},
+		{"involvedObject":{"kind":"Deployment","name":"web"},"reason":"ScalingReplicaSet","message":"Scaled up replica set web-new to 2","lastTimestamp":"2025-03-01T09:00:06Z"},
+		{"involvedObject":{"kind":"Deployment","name":"worker"},"reason":"ScalingReplicaSet","message":"Scaled up replica set worker-a to 1","lastTimestamp":"2025-03-01T09:30:01Z"},
+		{"involvedObject":{"kind":"Pod","name":"web-new-abc"},"reason":"Killing","message":"Stopping container app","lastTimestamp":"2025-03-01T10:00:00Z"}]}`
+
+	testTimelineHelmHistory = `[
+		{"revision":6,"updated":"2025-02-20T08:59:00.123456+00:00","status":"superseded","chart":"shop-1.0.0","app_version":"1.0","description":"Upgrade complete"},
+		{"revision":7,"updated":"2025-03-01T08:59:58.5+00:00","status":"deployed","chart":"shop-1.1.0","app_version":"1.1","description":"Upgrade complete"}]`
+)
+
+func timelineMock() *cmd.MockShellExecutor {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "service", "web", "-n", "shop", "-o", "json"}, testTimelineService, nil)
+	mock.AddCommandString("kubectl", []string{"get", "deployments,statefulsets,daemonsets", "-n", "shop", "-o", "json"}, testTimelineWorkloads, nil)
+	mock.AddCommandString("kubectl", []string{"get", "rollouts.argoproj.io", "-n", "shop", "-o", "json"}, "", errors.New(`error: the server doesn't have a resource type "rollouts"`))
+	mock.AddCommandString("kubectl", []string{"get", "horizontalpodautoscalers", "-n", "shop", "-o", "json"}, testTimelineHPAs, nil)
+	mock.AddCommandString("kubectl", []string{"get", "replicasets", "-n", "shop", "-o", "json"}, testTimelineReplicaSets, nil)
+	mock.AddCommandString("kubectl", []string{"get", "controllerrevisions", "-n", "shop", "-o", "json"}, `{"items":[]}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "events", "-n", "shop", "-o", "json"}, testTimelineEvents, nil)
+	mock.AddCommandString("helm", []string{"history", "shop", "-n", "shop", "--max", "50", "-o", "json"}, testTimelineHelmHistory, nil)
+	return mock
+}
+
+func callReleaseTimeline(t *testing.T, mock *cmd.MockShellExecutor, args map[string]interface{}) *mcp.CallToolResult {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	result, err := newTestK8sTool().handleReleaseTimeline(cmd.WithShellExecutor(context.Background(), mock), request)
+	require.NoError(t, err)
+	return result
+}
+
+func TestHandleReleaseTimeline(t *testing.T) {
+	t.Run("collects changes in the time range chronologically", func(t *testing.T) {
+		t.Setenv(AuditLogFile, "")
+		result := callReleaseTimeline(t, timelineMock(), map[string]interface{}{
+			"service": "web", "namespace": "shop", "since": "12h", "until": "2025-03-01T12:00:00Z",
+		})
+		require.False(t, result.IsError, getResultText(result))
+
+		var timeline ReleaseTimeline
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &timeline))
+		assert.Equal(t, []string{"Deployment/web"}, timeline.Workloads)
+		assert.Equal(t, []string{"shop"}, timeline.HelmReleases)
+
+		var summary [][3]string
+		for _, event := range timeline.Events {
+			summary = append(summary, [3]string{event.Time.Format("15:04:05"), event.Source, event.Object})
+		}
+		assert.Equal(t, [][3]string{
+			{"08:59:58", TimelineSourceHelm, "HelmRelease/shop"},
+			{"09:00:00", TimelineSourceManagedFields, "Deployment/web"},
+			{"09:00:05", TimelineSourceRevision, "Deployment/web"},
+			{"09:00:06", TimelineSourceScaling, "Deployment/web"},
+			{"10:30:00", TimelineSourceManagedFields, "Deployment/web"},
+			{"10:45:00", TimelineSourceScaling, "HorizontalPodAutoscaler/web"},
+			{"11:00:00", TimelineSourceRestart, "Deployment/web"},
+		}, summary)
+
+		assert.Equal(t, "HelmRelease/shop revision 7 deployed: Upgrade complete (chart shop-1.1.0, app version 1.1)", timeline.Events[0].Description)
+		assert.Equal(t, "4", timeline.Events[2].Revision)
+		assert.Equal(t, []string{"app=web:1.1"}, timeline.Events[2].Images)
+		assert.Equal(t, "kubectl-client-side-apply", timeline.Events[4].Actor)
+		assert.Contains(t, timeline.Notes[len(timeline.Notes)-1], AuditLogFile)
+	})
+
+	t.Run("audit log lists every applied change", func(t *testing.T) {
+		auditLog := filepath.Join(t.TempDir(), "audit.log")
+		require.NoError(t, os.WriteFile(auditLog, []byte(`{"stage":"ResponseComplete","verb":"patch","user":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"shop","name":"web"},"responseStatus":{"code":200},"stageTimestamp":"2025-03-01T10:10:00.000000Z"}
+{"stage":"RequestReceived","verb":"patch","user":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"shop","name":"web"},"stageTimestamp":"2025-03-01T10:10:00.000000Z"}
+{"stage":"ResponseComplete","verb":"update","user":{"username":"system:serviceaccount:kube-system:deployment-controller"},"objectRef":{"resource":"deployments","namespace":"shop","name":"web","subresource":"status"},"responseStatus":{"code":200},"stageTimestamp":"2025-03-01T10:10:01.000000Z"}
+{"stage":"ResponseComplete","verb":"patch","user":{"username":"bob"},"objectRef":{"resource":"deployments","namespace":"shop","name":"web","subresource":"scale"},"responseStatus":{"code":200},"stageTimestamp":"2025-03-01T10:20:00.000000Z"}
+{"stage":"ResponseComplete","verb":"patch","user":{"username":"bob"},"objectRef":{"resource":"deployments","namespace":"shop","name":"web"},"responseStatus":{"code":403},"stageTimestamp":"2025-03-01T10:21:00.000000Z"}
+{"stage":"ResponseComplete","verb":"delete","user":{"username":"carol"},"objectRef":{"resource":"deployments","namespace":"shop","name":"worker"},"responseStatus":{"code":200},"stageTimestamp":"2025-03-01T10:22:00.000000Z"}
+`), 0o600))
+		t.Setenv(AuditLogFile, auditLog)
+
+		result := callReleaseTimeline(t, timelineMock(), map[string]interface{}{
+			"service": "web", "namespace": "shop", "since": "12h", "until": "2025-03-01T12:00:00Z",
+		})
+		require.False(t, result.IsError, getResultText(result))
+		var timeline ReleaseTimeline
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &timeline))
+
+		var audited []TimelineEvent
+		for _, event := range timeline.Events {
+			if event.Source == TimelineSourceAuditLog {
+				audited = append(audited, event)
+			}
+		}
+		require.Len(t, audited, 2)
+		assert.Equal(t, "Deployment/web patched by alice", audited[0].Description)
+		assert.Equal(t, "Deployment/web scale patched by bob", audited[1].Description)
+		assert.Equal(t, "bob", audited[1].Actor)
+	})
+
+	t.Run("rollbacks are noted", func(t *testing.T) {
+		c := &timelineCollector{timeline: &ReleaseTimeline{}}
+		replicaSets, err := parseTimelineObjects(`{"items":[{"metadata":{"name":"web-old","creationTimestamp":"2025-02-20T09:00:00Z",
+			"annotations":{"deployment.kubernetes.io/revision":"5","deployment.kubernetes.io/revision-history":"3"},"ownerReferences":[{"kind":"Deployment","name":"web"}]}}]}`, "ReplicaSet")
+		require.NoError(t, err)
+		c.addReplicaSets(replicaSets, map[string]bool{"Deployment/web": true})
+		assert.Equal(t, []string{"Deployment/web revision 5 reuses ReplicaSet web-old of revisions 3, so it was a rollback; its time is not recorded"}, c.timeline.Notes)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		result := callReleaseTimeline(t, cmd.NewMockShellExecutor(), map[string]interface{}{})
+		assert.True(t, result.IsError)
+
+		result = callReleaseTimeline(t, cmd.NewMockShellExecutor(), map[string]interface{}{"service": "web", "until": "yesterday"})
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "RFC 3339")
+
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "service", "web", "-n", "default", "-o", "json"}, "", errors.New(`Error from server (NotFound): services "web" not found`))
+		result = callReleaseTimeline(t, mock, map[string]interface{}{"service": "web"})
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "not found")
+	})
+}
@@ -0,0 +1,172 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// redactedValue replaces the values of Secret data
+const redactedValue = "REDACTED"
+
+// lastAppliedAnnotation holds the manifest last applied with kubectl, which
+// includes the data of Secrets
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// serverMetadataFields are set by the API server and differ between clusters and generations
+var serverMetadataFields = []string{"uid", "resourceVersion", "generation", "creationTimestamp", "selfLink", "managedFields"}
+
+// serverAnnotations are added by controllers and tools rather than written in manifests
+var serverAnnotations = []string{lastAppliedAnnotation, "deployment.kubernetes.io/revision"}
+
+// yamlCleanOptions selects what is removed from a resource's YAML
+type yamlCleanOptions struct {
+	StripManagedFields bool
+	StripStatus        bool
+	RedactSecrets      bool
+	// Clean removes everything set by the server, leaving an applyable manifest
+	Clean bool
+}
+
+// mappingValue returns the value of a key in a YAML mapping, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// deleteMappingKeys removes keys from a YAML mapping
+func deleteMappingKeys(node *yaml.Node, keys ...string) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	content := node.Content[:0]
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !slices.Contains(keys, node.Content[i].Value) {
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+	}
+	node.Content = content
+}
+
+// cleanResource applies the options to a resource, and to the items of a List
+func cleanResource(resource *yaml.Node, opts yamlCleanOptions) {
+	if items := mappingValue(resource, "items"); items != nil && items.Kind == yaml.SequenceNode {
+		for _, item := range items.Content {
+			cleanResource(item, opts)
+		}
+	}
+
+	metadata := mappingValue(resource, "metadata")
+	if opts.StripManagedFields || opts.Clean {
+		deleteMappingKeys(metadata, "managedFields")
+	}
+	if opts.StripStatus || opts.Clean {
+		deleteMappingKeys(resource, "status")
+	}
+	if opts.Clean {
+		deleteMappingKeys(metadata, serverMetadataFields...)
+		deleteMappingKeys(mappingValue(metadata, "annotations"), serverAnnotations...)
+	}
+
+	if kind := mappingValue(resource, "kind"); opts.RedactSecrets && kind != nil && kind.Value == "Secret" {
+		for _, field := range []string{"data", "stringData"} {
+			data := mappingValue(resource, field)
+			if data == nil || data.Kind != yaml.MappingNode {
+				continue
+			}
+			for i := 1; i < len(data.Content); i += 2 {
+				data.Content[i] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: redactedValue}
+			}
+		}
+		deleteMappingKeys(mappingValue(metadata, "annotations"), lastAppliedAnnotation)
+	}
+
+	if annotations := mappingValue(metadata, "annotations"); annotations != nil && len(annotations.Content) == 0 {
+		deleteMappingKeys(metadata, "annotations")
+	}
+}
+
+// cleanResourceYAML applies the options to the YAML output of kubectl get
+func cleanResourceYAML(output string, opts yamlCleanOptions) (string, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(output), &document); err != nil {
+		return "", fmt.Errorf("failed to parse resource YAML: %w", err)
+	}
+	if len(document.Content) == 0 {
+		return output, nil
+	}
+	cleanResource(document.Content[0], opts)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return "", fmt.Errorf("failed to encode resource YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode resource YAML: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Get resource YAML
+func (k *K8sTool) handleGetResourceYAML(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	resourceType := p.String("resource_type", "", params.Required())
+	resourceName := p.String("resource_name", "", params.Required())
+	namespace := p.String("namespace", "")
+	opts := yamlCleanOptions{
+		StripManagedFields: p.Bool("strip_managed_fields", true),
+		StripStatus:        p.Bool("strip_status", false),
+		RedactSecrets:      p.Bool("redact_secrets", true),
+		Clean:              p.Bool("clean", false),
+	}
+	jsonPath := p.String("jsonpath", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if jsonPath != "" {
+		if opts.RedactSecrets && projectsSecretData(resourceType) {
+			return mcp.NewToolResultError("Secrets cannot be projected with jsonpath while redact_secrets is true"), nil
+		}
+		output, err := jsonPathOutput(jsonPath)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		args := []string{"get", resourceType, resourceName, "-o", output}
+		if namespace != "" {
+			args = append(args, "-n", namespace)
+		}
+		return k.runKubectlCommand(ctx, args...)
+	}
+
+	args := []string{"get", resourceType, resourceName, "-o", "yaml"}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+
+	output, err := k.runKubectlCommandString(ctx, args...)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Get YAML command failed: %v", err)), nil
+	}
+
+	cleaned, err := cleanResourceYAML(output, opts)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(cleaned), nil
+}
@@ -0,0 +1,142 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecretYAML = `apiVersion: v1
+data:
+  password: c2VjcmV0
+kind: Secret
+metadata:
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: |
+      {"apiVersion":"v1","data":{"password":"c2VjcmV0"},"kind":"Secret"}
+  creationTimestamp: "2026-10-15T09:00:00Z"
+  managedFields:
+  - apiVersion: v1
+    manager: kubectl
+  name: db
+  namespace: prod
+  resourceVersion: "1234"
+  uid: 0b1c2d3e
+stringData:
+  token: plain
+type: Opaque
+`
+
+const testDeploymentYAML = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    deployment.kubernetes.io/revision: "3"
+    team: web
+  generation: 3
+  managedFields:
+  - manager: kube-controller-manager
+  name: web
+spec:
+  replicas: 2
+status:
+  readyReplicas: 2
+`
+
+func TestCleanResourceYAML(t *testing.T) {
+	t.Run("secret defaults", func(t *testing.T) {
+		cleaned, err := cleanResourceYAML(testSecretYAML, yamlCleanOptions{StripManagedFields: true, RedactSecrets: true})
+		require.NoError(t, err)
+		assert.NotContains(t, cleaned, "c2VjcmV0")
+		assert.NotContains(t, cleaned, "plain")
+		assert.NotContains(t, cleaned, "managedFields")
+		assert.NotContains(t, cleaned, "annotations")
+		assert.Contains(t, cleaned, "password: REDACTED")
+		assert.Contains(t, cleaned, "token: REDACTED")
+		assert.Contains(t, cleaned, `resourceVersion: "1234"`)
+	})
+
+	t.Run("clean manifest", func(t *testing.T) {
+		cleaned, err := cleanResourceYAML(testDeploymentYAML, yamlCleanOptions{Clean: true})
+		require.NoError(t, err)
+		assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    team: web
+  name: web
+spec:
+  replicas: 2
+`, cleaned)
+	})
+
+	t.Run("status only", func(t *testing.T) {
+		cleaned, err := cleanResourceYAML(testDeploymentYAML, yamlCleanOptions{StripStatus: true})
+		require.NoError(t, err)
+		assert.NotContains(t, cleaned, "readyReplicas")
+		assert.Contains(t, cleaned, "managedFields")
+		assert.Contains(t, cleaned, "generation: 3")
+	})
+
+	t.Run("list items", func(t *testing.T) {
+		list := "apiVersion: v1\nitems:\n- " + strings.ReplaceAll(strings.TrimSuffix(testSecretYAML, "\n"), "\n", "\n  ") + "\nkind: List\n"
+		cleaned, err := cleanResourceYAML(list, yamlCleanOptions{RedactSecrets: true})
+		require.NoError(t, err)
+		assert.NotContains(t, cleaned, "c2VjcmV0")
+	})
+
+	_, err := cleanResourceYAML("a: [", yamlCleanOptions{})
+	assert.Error(t, err)
+}
+
+func TestHandleGetResourceYAML(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "secret", "db", "-o", "yaml", "-n", "prod"}, testSecretYAML, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	k8sTool := newTestK8sTool()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"resource_type": "secret", "resource_name": "db", "namespace": "prod"}
+	result, err := k8sTool.handleGetResourceYAML(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Contains(t, getResultText(result), "password: REDACTED")
+	assert.NotContains(t, getResultText(result), "managedFields")
+
+	request.Params.Arguments = map[string]interface{}{"resource_type": "secret", "resource_name": "db", "namespace": "prod", "redact_secrets": "false", "strip_managed_fields": "false"}
+	result, err = k8sTool.handleGetResourceYAML(ctx, request)
+	require.NoError(t, err)
+	assert.Contains(t, getResultText(result), "password: c2VjcmV0")
+	assert.Contains(t, getResultText(result), "managedFields")
+
+	request.Params.Arguments = map[string]interface{}{"resource_type": "secret"}
+	result, err = k8sTool.handleGetResourceYAML(ctx, request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestHandleGetResourceYAMLJSONPath(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "deployment", "web", "-o", "jsonpath={.spec.replicas}", "-n", "prod"}, "3", nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	k8sTool := newTestK8sTool()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"resource_type": "deployment", "resource_name": "web", "namespace": "prod", "jsonpath": ".spec.replicas"}
+	result, err := k8sTool.handleGetResourceYAML(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+	assert.Equal(t, "3", getResultText(result))
+
+	// Projections would bypass the redaction of Secret data
+	request.Params.Arguments = map[string]interface{}{"resource_type": "secrets", "resource_name": "db", "jsonpath": "{.data.password}"}
+	result, err = k8sTool.handleGetResourceYAML(ctx, request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Len(t, mock.GetCallLog(), 1)
+}
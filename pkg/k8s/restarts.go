@@ -0,0 +1,260 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// defaultRestartReportLimit is the number of workloads reported when no limit is given
+const defaultRestartReportLimit = 20
+
+// podTermination is the subset of a container termination state used for reports
+type podTermination struct {
+	Reason     string `json:"reason"`
+	ExitCode   int    `json:"exitCode"`
+	FinishedAt string `json:"finishedAt"`
+}
+
+// podContainerStatus is the subset of a container status used for reports
+type podContainerStatus struct {
+	Name         string `json:"name"`
+	RestartCount int    `json:"restartCount"`
+	State        struct {
+		Terminated *podTermination `json:"terminated"`
+	} `json:"state"`
+	LastState struct {
+		Terminated *podTermination `json:"terminated"`
+	} `json:"lastState"`
+}
+
+// podList is the subset of `kubectl get pods -o json` output used for reports
+type podList struct {
+	Items []struct {
+		Metadata struct {
+			Name            string            `json:"name"`
+			Namespace       string            `json:"namespace"`
+			Labels          map[string]string `json:"labels"`
+			OwnerReferences []struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"ownerReferences"`
+		} `json:"metadata"`
+		Status struct {
+			Phase                 string               `json:"phase"`
+			InitContainerStatuses []podContainerStatus `json:"initContainerStatuses"`
+			ContainerStatuses     []podContainerStatus `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// PodRestarts describes the restarts of a single pod
+type PodRestarts struct {
+	Name                  string `json:"name"`
+	Phase                 string `json:"phase"`
+	Restarts              int    `json:"restarts"`
+	OOMKilled             int    `json:"oom_killed"`
+	LastTerminationReason string `json:"last_termination_reason,omitempty"`
+	LastTerminationExit   int    `json:"last_termination_exit_code,omitempty"`
+	LastTerminationTime   string `json:"last_termination_time,omitempty"`
+}
+
+// WorkloadRestarts aggregates the restarts of the pods of a workload
+type WorkloadRestarts struct {
+	Namespace          string         `json:"namespace"`
+	Kind               string         `json:"kind"`
+	Name               string         `json:"name"`
+	Pods               int            `json:"pods"`
+	Restarts           int            `json:"restarts"`
+	OOMKilled          int            `json:"oom_killed"`
+	TerminationReasons map[string]int `json:"termination_reasons"`
+	LastTermination    string         `json:"last_termination_time,omitempty"`
+	PodDetails         []PodRestarts  `json:"pod_details"`
+}
+
+// RestartReport is the structured response of k8s_restart_report
+type RestartReport struct {
+	TotalPods          int                `json:"total_pods"`
+	TotalRestarts      int                `json:"total_restarts"`
+	TotalOOMKilled     int                `json:"total_oom_killed"`
+	FlappingWorkloads  int                `json:"flapping_workloads"`
+	ReportedWorkloads  int                `json:"reported_workloads"`
+	Workloads          []WorkloadRestarts `json:"workloads"`
+	TerminationReasons map[string]int     `json:"termination_reasons"`
+}
+
+// podWorkload returns the kind and name of the workload owning a pod. Pods
+// owned by a ReplicaSet are attributed to its Deployment when the ReplicaSet
+// name carries the pod template hash.
+func podWorkload(name string, labels map[string]string, ownerKind, ownerName string) (string, string) {
+	switch ownerKind {
+	case "":
+		return "Pod", name
+	case "ReplicaSet":
+		if hash := labels["pod-template-hash"]; hash != "" {
+			if deployment, ok := strings.CutSuffix(ownerName, "-"+hash); ok {
+				return "Deployment", deployment
+			}
+		}
+	}
+	return ownerKind, ownerName
+}
+
+// latestTermination returns the most recent termination of a container, preferring
+// the current state of a terminated container over its previous state
+func latestTermination(status podContainerStatus) *podTermination {
+	if status.State.Terminated != nil {
+		return status.State.Terminated
+	}
+	return status.LastState.Terminated
+}
+
+// buildRestartReport aggregates pod restarts by workload, ordered by restarts
+// and OOM kills, keeping workloads with at least minRestarts restarts
+func buildRestartReport(output string, minRestarts, limit int) (RestartReport, error) {
+	report := RestartReport{
+		Workloads:          []WorkloadRestarts{},
+		TerminationReasons: make(map[string]int),
+	}
+
+	var pods podList
+	if err := json.Unmarshal([]byte(output), &pods); err != nil {
+		return report, fmt.Errorf("failed to parse pods: %w", err)
+	}
+
+	workloads := make(map[string]*WorkloadRestarts)
+	for _, pod := range pods.Items {
+		var ownerKind, ownerName string
+		if len(pod.Metadata.OwnerReferences) > 0 {
+			ownerKind = pod.Metadata.OwnerReferences[0].Kind
+			ownerName = pod.Metadata.OwnerReferences[0].Name
+		}
+		kind, name := podWorkload(pod.Metadata.Name, pod.Metadata.Labels, ownerKind, ownerName)
+
+		key := pod.Metadata.Namespace + "/" + kind + "/" + name
+		workload, ok := workloads[key]
+		if !ok {
+			workload = &WorkloadRestarts{
+				Namespace:          pod.Metadata.Namespace,
+				Kind:               kind,
+				Name:               name,
+				TerminationReasons: make(map[string]int),
+				PodDetails:         []PodRestarts{},
+			}
+			workloads[key] = workload
+		}
+
+		details := PodRestarts{Name: pod.Metadata.Name, Phase: pod.Status.Phase}
+		statuses := append(append([]podContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			details.Restarts += status.RestartCount
+
+			termination := latestTermination(status)
+			if termination == nil || termination.Reason == "" {
+				continue
+			}
+			workload.TerminationReasons[termination.Reason]++
+			report.TerminationReasons[termination.Reason]++
+			if termination.Reason == "OOMKilled" {
+				details.OOMKilled++
+			}
+			if termination.FinishedAt >= details.LastTerminationTime {
+				details.LastTerminationReason = termination.Reason
+				details.LastTerminationExit = termination.ExitCode
+				details.LastTerminationTime = termination.FinishedAt
+			}
+		}
+
+		workload.Pods++
+		workload.Restarts += details.Restarts
+		workload.OOMKilled += details.OOMKilled
+		if details.LastTerminationTime > workload.LastTermination {
+			workload.LastTermination = details.LastTerminationTime
+		}
+		workload.PodDetails = append(workload.PodDetails, details)
+
+		report.TotalPods++
+		report.TotalRestarts += details.Restarts
+		report.TotalOOMKilled += details.OOMKilled
+	}
+
+	for _, workload := range workloads {
+		if workload.Restarts == 0 && workload.OOMKilled == 0 {
+			continue
+		}
+		report.FlappingWorkloads++
+		if workload.Restarts < minRestarts {
+			continue
+		}
+		sort.Slice(workload.PodDetails, func(i, j int) bool {
+			if workload.PodDetails[i].Restarts == workload.PodDetails[j].Restarts {
+				return workload.PodDetails[i].Name < workload.PodDetails[j].Name
+			}
+			return workload.PodDetails[i].Restarts > workload.PodDetails[j].Restarts
+		})
+		report.Workloads = append(report.Workloads, *workload)
+	}
+
+	sort.Slice(report.Workloads, func(i, j int) bool {
+		a, b := report.Workloads[i], report.Workloads[j]
+		if a.Restarts != b.Restarts {
+			return a.Restarts > b.Restarts
+		}
+		if a.OOMKilled != b.OOMKilled {
+			return a.OOMKilled > b.OOMKilled
+		}
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+	if limit > 0 && len(report.Workloads) > limit {
+		report.Workloads = report.Workloads[:limit]
+	}
+	report.ReportedWorkloads = len(report.Workloads)
+	return report, nil
+}
+
+// Restart and OOM report
+func (k *K8sTool) handleRestartReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	namespace := p.String("namespace", "", params.Check(security.ValidateNamespace))
+	allNamespaces := p.Bool("all_namespaces", false)
+	labelSelector := p.String("label_selector", "", params.Check(security.ValidateCommandInput))
+	minRestarts := p.Int("min_restarts", 1, params.Min(0))
+	limit := p.Int("limit", defaultRestartReportLimit, params.Min(1))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	args := []string{"get", "pods", "-o", "json"}
+	if allNamespaces || namespace == "" {
+		args = append(args, "--all-namespaces")
+	} else {
+		args = append(args, "-n", namespace)
+	}
+	if labelSelector != "" {
+		args = append(args, "-l", labelSelector)
+	}
+
+	output, err := k.runKubectlCommandString(ctx, args...)
+	if err != nil {
+		return mcp.NewToolResultError("Error getting pods: " + err.Error()), nil
+	}
+
+	report, err := buildRestartReport(output, minRestarts, limit)
+	if err != nil {
+		return mcp.NewToolResultError("Error building restart report: " + err.Error()), nil
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling restart report: " + err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
@@ -0,0 +1,109 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRestartPods = `{"items":[
+{"metadata":{"name":"web-7d9f8-abcde","namespace":"prod","labels":{"pod-template-hash":"7d9f8"},"ownerReferences":[{"kind":"ReplicaSet","name":"web-7d9f8"}]},
+ "status":{"phase":"Running","containerStatuses":[{"name":"web","restartCount":4,"lastState":{"terminated":{"reason":"OOMKilled","exitCode":137,"finishedAt":"2024-01-01T10:00:00Z"}}}]}},
+{"metadata":{"name":"web-7d9f8-fghij","namespace":"prod","labels":{"pod-template-hash":"7d9f8"},"ownerReferences":[{"kind":"ReplicaSet","name":"web-7d9f8"}]},
+ "status":{"phase":"Running","containerStatuses":[{"name":"web","restartCount":2,"lastState":{"terminated":{"reason":"Error","exitCode":1,"finishedAt":"2024-01-01T11:00:00Z"}}}]}},
+{"metadata":{"name":"db-0","namespace":"prod","ownerReferences":[{"kind":"StatefulSet","name":"db"}]},
+ "status":{"phase":"Running","containerStatuses":[{"name":"db","restartCount":1,"lastState":{"terminated":{"reason":"OOMKilled","exitCode":137,"finishedAt":"2024-01-01T09:00:00Z"}}}]}},
+{"metadata":{"name":"debug","namespace":"dev"},
+ "status":{"phase":"Running","containerStatuses":[{"name":"debug","restartCount":0}]}}
+]}`
+
+func TestBuildRestartReport(t *testing.T) {
+	report, err := buildRestartReport(testRestartPods, 1, 20)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, report.TotalPods)
+	assert.Equal(t, 7, report.TotalRestarts)
+	assert.Equal(t, 2, report.TotalOOMKilled)
+	assert.Equal(t, 2, report.FlappingWorkloads)
+	require.Len(t, report.Workloads, 2)
+
+	web := report.Workloads[0]
+	assert.Equal(t, "Deployment", web.Kind)
+	assert.Equal(t, "web", web.Name)
+	assert.Equal(t, 2, web.Pods)
+	assert.Equal(t, 6, web.Restarts)
+	assert.Equal(t, 1, web.OOMKilled)
+	assert.Equal(t, map[string]int{"OOMKilled": 1, "Error": 1}, web.TerminationReasons)
+	assert.Equal(t, "2024-01-01T11:00:00Z", web.LastTermination)
+	assert.Equal(t, "web-7d9f8-abcde", web.PodDetails[0].Name)
+	assert.Equal(t, 137, web.PodDetails[0].LastTerminationExit)
+
+	assert.Equal(t, "StatefulSet", report.Workloads[1].Kind)
+	assert.Equal(t, 2, report.TerminationReasons["OOMKilled"])
+
+	report, err = buildRestartReport(testRestartPods, 5, 20)
+	require.NoError(t, err)
+	require.Len(t, report.Workloads, 1)
+	assert.Equal(t, "web", report.Workloads[0].Name)
+
+	report, err = buildRestartReport(testRestartPods, 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.ReportedWorkloads)
+
+	_, err = buildRestartReport("not json", 1, 20)
+	assert.Error(t, err)
+}
+
+func TestHandleRestartReport(t *testing.T) {
+	t.Run("namespace and selector", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pods", "-o", "json", "-n", "prod", "-l", "app=web"}, testRestartPods, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{
+			"namespace":      "prod",
+			"label_selector": "app=web",
+		}
+		result, err := newTestK8sTool().handleRestartReport(ctx, req)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report RestartReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		assert.Equal(t, 2, report.ReportedWorkloads)
+	})
+
+	t.Run("defaults to all namespaces", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pods", "-o", "json", "--all-namespaces"}, `{"items":[]}`, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleRestartReport(ctx, mcp.CallToolRequest{})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{"limit": float64(0)}
+		result, err := newTestK8sTool().handleRestartReport(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("kubectl failure", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "pods", "-o", "json", "--all-namespaces"}, "", assert.AnError)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleRestartReport(ctx, mcp.CallToolRequest{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
@@ -0,0 +1,592 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// revisionAnnotation holds the rollout revision of a Deployment and its ReplicaSets
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// defaultProgressDeadline is the progressDeadlineSeconds of a Deployment that does not set it
+const defaultProgressDeadline = 600 * time.Second
+
+// Values of RolloutAnalysis.Verdict
+const (
+	rolloutComplete    = "complete"
+	rolloutProgressing = "progressing"
+	rolloutStalled     = "stalled"
+	rolloutFailed      = "failed"
+	rolloutPaused      = "paused"
+)
+
+// Values of RolloutAnalysis.Recommendation
+const (
+	recommendNone        = "none"
+	recommendWait        = "wait"
+	recommendUndo        = "undo"
+	recommendInvestigate = "investigate"
+	recommendResume      = "resume"
+)
+
+// Values of PendingPod.Category
+const (
+	pendingImagePull  = "image_pull"
+	pendingScheduling = "scheduling"
+	pendingProbe      = "probe"
+	pendingCrash      = "crash"
+	pendingConfig     = "config"
+	pendingStarting   = "starting"
+)
+
+// blockingCategories are pending pod categories that do not resolve without a change
+var blockingCategories = []string{pendingImagePull, pendingCrash, pendingConfig}
+
+// rolloutContainer is the subset of a pod template container used for image changes
+type rolloutContainer struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+// rolloutDeployment is the subset of `kubectl get deployment -o json` output used for rollout analysis
+type rolloutDeployment struct {
+	Metadata struct {
+		Name        string            `json:"name"`
+		Namespace   string            `json:"namespace"`
+		Generation  int64             `json:"generation"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas                *int `json:"replicas"`
+		Paused                  bool `json:"paused"`
+		ProgressDeadlineSeconds *int `json:"progressDeadlineSeconds"`
+		Strategy                struct {
+			Type string `json:"type"`
+		} `json:"strategy"`
+	} `json:"spec"`
+	Status struct {
+		ObservedGeneration  int64 `json:"observedGeneration"`
+		Replicas            int   `json:"replicas"`
+		UpdatedReplicas     int   `json:"updatedReplicas"`
+		ReadyReplicas       int   `json:"readyReplicas"`
+		AvailableReplicas   int   `json:"availableReplicas"`
+		UnavailableReplicas int   `json:"unavailableReplicas"`
+		Conditions          []struct {
+			crdCondition
+			LastUpdateTime string `json:"lastUpdateTime"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// replicaSetList is the subset of `kubectl get replicasets -o json` output used for rollout analysis
+type replicaSetList struct {
+	Items []struct {
+		Metadata struct {
+			Name              string            `json:"name"`
+			CreationTimestamp string            `json:"creationTimestamp"`
+			Annotations       map[string]string `json:"annotations"`
+			OwnerReferences   []struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"ownerReferences"`
+		} `json:"metadata"`
+		Spec struct {
+			Replicas *int `json:"replicas"`
+			Template struct {
+				Spec struct {
+					Containers []rolloutContainer `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+		Status struct {
+			Replicas          int `json:"replicas"`
+			ReadyReplicas     int `json:"readyReplicas"`
+			AvailableReplicas int `json:"availableReplicas"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// rolloutContainerState is the subset of a container status used to explain pending pods
+type rolloutContainerState struct {
+	Name         string `json:"name"`
+	Ready        bool   `json:"ready"`
+	RestartCount int    `json:"restartCount"`
+	State        struct {
+		Waiting *struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"waiting"`
+		Running *struct {
+			StartedAt string `json:"startedAt"`
+		} `json:"running"`
+	} `json:"state"`
+}
+
+// rolloutPodList is the subset of `kubectl get pods -o json` output used for rollout analysis
+type rolloutPodList struct {
+	Items []struct {
+		Metadata struct {
+			Name            string `json:"name"`
+			OwnerReferences []struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"ownerReferences"`
+		} `json:"metadata"`
+		Status struct {
+			Phase                 string                  `json:"phase"`
+			Conditions            []crdCondition          `json:"conditions"`
+			InitContainerStatuses []rolloutContainerState `json:"initContainerStatuses"`
+			ContainerStatuses     []rolloutContainerState `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// ReplicaSetStatus describes a ReplicaSet of a Deployment's rollout
+type ReplicaSetStatus struct {
+	Name      string   `json:"name"`
+	Revision  int      `json:"revision"`
+	Desired   int      `json:"desired"`
+	Current   int      `json:"current"`
+	Ready     int      `json:"ready"`
+	Available int      `json:"available"`
+	Images    []string `json:"images"`
+	Created   string   `json:"created,omitempty"`
+}
+
+// ImageChange is a container image changed by the rollout
+type ImageChange struct {
+	Container string `json:"container"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to"`
+}
+
+// PendingPod is a pod of the new ReplicaSet that is not ready, and why
+type PendingPod struct {
+	Name      string `json:"name"`
+	Phase     string `json:"phase"`
+	Category  string `json:"category"`
+	Reason    string `json:"reason"`
+	Message   string `json:"message,omitempty"`
+	Container string `json:"container,omitempty"`
+	Restarts  int    `json:"restarts,omitempty"`
+}
+
+// RolloutReplicas are the replica counts of a Deployment
+type RolloutReplicas struct {
+	Desired     int `json:"desired"`
+	Updated     int `json:"updated"`
+	Ready       int `json:"ready"`
+	Available   int `json:"available"`
+	Unavailable int `json:"unavailable"`
+}
+
+// RolloutEstimate projects the completion of a progressing rollout from its rate so far
+type RolloutEstimate struct {
+	ReadyPerMinute float64 `json:"ready_per_minute"`
+	Remaining      int     `json:"remaining"`
+	ETA            string  `json:"eta,omitempty"`
+	Deadline       string  `json:"deadline,omitempty"`
+}
+
+// RolloutAnalysis is the structured response of k8s_rollout_analyze
+type RolloutAnalysis struct {
+	Deployment     string                `json:"deployment"`
+	Namespace      string                `json:"namespace"`
+	Revision       int                   `json:"revision"`
+	Strategy       string                `json:"strategy"`
+	Verdict        string                `json:"verdict"`
+	Recommendation string                `json:"recommendation"`
+	Summary        string                `json:"summary"`
+	Commands       []string              `json:"commands,omitempty"`
+	Replicas       RolloutReplicas       `json:"replicas"`
+	NewReplicaSet  *ReplicaSetStatus     `json:"new_replica_set,omitempty"`
+	OldReplicaSets []ReplicaSetStatus    `json:"old_replica_sets"`
+	ImageChanges   []ImageChange         `json:"image_changes,omitempty"`
+	PendingPods    []PendingPod          `json:"pending_pods"`
+	PendingReasons map[string]int        `json:"pending_reasons"`
+	Conditions     []AutoscalerCondition `json:"conditions,omitempty"`
+	Estimate       *RolloutEstimate      `json:"estimate,omitempty"`
+}
+
+// classifyPendingPod explains why a pod is not ready, preferring image pull, crash
+// and configuration failures of its containers over scheduling and probes
+func classifyPendingPod(phase string, conditions []crdCondition, statuses []rolloutContainerState) PendingPod {
+	pod := PendingPod{Phase: phase}
+	for _, status := range statuses {
+		pod.Restarts += status.RestartCount
+	}
+	for _, status := range statuses {
+		if status.State.Waiting == nil {
+			continue
+		}
+		waiting := status.State.Waiting
+		switch waiting.Reason {
+		case "ImagePullBackOff", "ErrImagePull", "InvalidImageName", "ErrImageNeverPull":
+			pod.Category = pendingImagePull
+		case "CrashLoopBackOff", "RunContainerError":
+			pod.Category = pendingCrash
+		case "CreateContainerConfigError", "CreateContainerError":
+			pod.Category = pendingConfig
+		default:
+			continue
+		}
+		pod.Reason, pod.Message, pod.Container = waiting.Reason, waiting.Message, status.Name
+		return pod
+	}
+	for _, condition := range conditions {
+		if condition.Type == "PodScheduled" && condition.Status == "False" {
+			pod.Category, pod.Reason, pod.Message = pendingScheduling, condition.Reason, condition.Message
+			return pod
+		}
+	}
+	for _, status := range statuses {
+		if status.State.Running != nil && !status.Ready {
+			pod.Category, pod.Reason, pod.Container = pendingProbe, "ReadinessProbeFailing", status.Name
+			return pod
+		}
+	}
+	pod.Category, pod.Reason = pendingStarting, phase
+	for _, status := range statuses {
+		if status.State.Waiting != nil {
+			pod.Reason, pod.Message, pod.Container = status.State.Waiting.Reason, status.State.Waiting.Message, status.Name
+			break
+		}
+	}
+	return pod
+}
+
+// containerImages lists the images of a pod template as container=image
+func containerImages(containers []rolloutContainer) []string {
+	images := make([]string, 0, len(containers))
+	for _, container := range containers {
+		images = append(images, container.Name+"="+container.Image)
+	}
+	return images
+}
+
+// buildRolloutAnalysis compares the new and old ReplicaSets of a Deployment,
+// explains its pods that are not ready and returns a verdict with a recommendation
+func buildRolloutAnalysis(deploymentOutput, replicaSetOutput, podOutput, eventsOutput string, now time.Time) (*RolloutAnalysis, error) {
+	var deployment rolloutDeployment
+	if err := json.Unmarshal([]byte(deploymentOutput), &deployment); err != nil {
+		return nil, fmt.Errorf("failed to parse deployment: %w", err)
+	}
+	var replicaSets replicaSetList
+	if err := json.Unmarshal([]byte(replicaSetOutput), &replicaSets); err != nil {
+		return nil, fmt.Errorf("failed to parse replica sets: %w", err)
+	}
+	var pods rolloutPodList
+	if err := json.Unmarshal([]byte(podOutput), &pods); err != nil {
+		return nil, fmt.Errorf("failed to parse pods: %w", err)
+	}
+	// Events are optional, so parse failures only drop them from the report
+	var events eventList
+	_ = json.Unmarshal([]byte(eventsOutput), &events)
+
+	name, namespace := deployment.Metadata.Name, deployment.Metadata.Namespace
+	desired := 1
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	strategy := deployment.Spec.Strategy.Type
+	if strategy == "" {
+		strategy = "RollingUpdate"
+	}
+	revision, _ := strconv.Atoi(deployment.Metadata.Annotations[revisionAnnotation])
+	analysis := &RolloutAnalysis{
+		Deployment: name,
+		Namespace:  namespace,
+		Revision:   revision,
+		Strategy:   strategy,
+		Replicas: RolloutReplicas{
+			Desired:     desired,
+			Updated:     deployment.Status.UpdatedReplicas,
+			Ready:       deployment.Status.ReadyReplicas,
+			Available:   deployment.Status.AvailableReplicas,
+			Unavailable: deployment.Status.UnavailableReplicas,
+		},
+		OldReplicaSets: []ReplicaSetStatus{},
+		PendingPods:    []PendingPod{},
+		PendingReasons: map[string]int{},
+	}
+
+	// The new ReplicaSet carries the Deployment's revision; the others are old
+	var newContainers, previousContainers []rolloutContainer
+	previousRevision := 0
+	for _, rs := range replicaSets.Items {
+		owned := false
+		for _, owner := range rs.Metadata.OwnerReferences {
+			owned = owned || (owner.Kind == "Deployment" && owner.Name == name)
+		}
+		if !owned {
+			continue
+		}
+		rsRevision, _ := strconv.Atoi(rs.Metadata.Annotations[revisionAnnotation])
+		status := ReplicaSetStatus{
+			Name:      rs.Metadata.Name,
+			Revision:  rsRevision,
+			Current:   rs.Status.Replicas,
+			Ready:     rs.Status.ReadyReplicas,
+			Available: rs.Status.AvailableReplicas,
+			Images:    containerImages(rs.Spec.Template.Spec.Containers),
+			Created:   rs.Metadata.CreationTimestamp,
+		}
+		if rs.Spec.Replicas != nil {
+			status.Desired = *rs.Spec.Replicas
+		}
+		switch {
+		case rsRevision == revision:
+			analysis.NewReplicaSet = &status
+			newContainers = rs.Spec.Template.Spec.Containers
+		case status.Desired > 0 || status.Current > 0:
+			analysis.OldReplicaSets = append(analysis.OldReplicaSets, status)
+		}
+		if rsRevision != revision && rsRevision > previousRevision {
+			previousRevision, previousContainers = rsRevision, rs.Spec.Template.Spec.Containers
+		}
+	}
+	sort.Slice(analysis.OldReplicaSets, func(i, j int) bool {
+		return analysis.OldReplicaSets[i].Revision > analysis.OldReplicaSets[j].Revision
+	})
+
+	previousImages := make(map[string]string)
+	for _, container := range previousContainers {
+		previousImages[container.Name] = container.Image
+	}
+	if previousRevision > 0 {
+		for _, container := range newContainers {
+			if previousImages[container.Name] != container.Image {
+				analysis.ImageChanges = append(analysis.ImageChanges, ImageChange{
+					Container: container.Name,
+					From:      previousImages[container.Name],
+					To:        container.Image,
+				})
+			}
+		}
+	}
+
+	// The latest warning of each pod, e.g. the readiness probe output or FailedScheduling
+	podWarnings := make(map[string]string)
+	podWarningTimes := make(map[string]string)
+	for _, event := range events.Items {
+		if event.InvolvedObject.Kind != "Pod" || event.Type != "Warning" {
+			continue
+		}
+		lastTime := event.LastTimestamp
+		if lastTime == "" {
+			lastTime = event.EventTime
+		}
+		if lastTime >= podWarningTimes[event.InvolvedObject.Name] {
+			podWarnings[event.InvolvedObject.Name] = event.Message
+			podWarningTimes[event.InvolvedObject.Name] = lastTime
+		}
+	}
+
+	if analysis.NewReplicaSet != nil {
+		for _, pod := range pods.Items {
+			owned := false
+			for _, owner := range pod.Metadata.OwnerReferences {
+				owned = owned || (owner.Kind == "ReplicaSet" && owner.Name == analysis.NewReplicaSet.Name)
+			}
+			if !owned || pod.Status.Phase == "Succeeded" {
+				continue
+			}
+			ready := false
+			for _, condition := range pod.Status.Conditions {
+				ready = ready || (condition.Type == "Ready" && condition.Status == "True")
+			}
+			if ready {
+				continue
+			}
+			statuses := append(append([]rolloutContainerState{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+			pending := classifyPendingPod(pod.Status.Phase, pod.Status.Conditions, statuses)
+			pending.Name = pod.Metadata.Name
+			if warning := podWarnings[pod.Metadata.Name]; warning != "" && (pending.Message == "" || pending.Category == pendingProbe) {
+				pending.Message = warning
+			}
+			analysis.PendingPods = append(analysis.PendingPods, pending)
+			analysis.PendingReasons[pending.Category]++
+		}
+		sort.SliceStable(analysis.PendingPods, func(i, j int) bool {
+			return analysis.PendingPods[i].Name < analysis.PendingPods[j].Name
+		})
+	}
+
+	var progressing *crdCondition
+	progressingUpdated := ""
+	for _, condition := range deployment.Status.Conditions {
+		analysis.Conditions = append(analysis.Conditions, AutoscalerCondition{
+			Type:    condition.Type,
+			Status:  condition.Status,
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		})
+		if condition.Type == "Progressing" {
+			progressing = &condition.crdCondition
+			progressingUpdated = condition.LastUpdateTime
+		}
+	}
+
+	blocked := 0
+	for _, category := range blockingCategories {
+		blocked += analysis.PendingReasons[category]
+	}
+	oldServing := 0
+	for _, rs := range analysis.OldReplicaSets {
+		oldServing += rs.Available
+	}
+	target := fmt.Sprintf("deployment/%s -n %s", name, namespace)
+	undo := "kubectl rollout undo " + target
+	if previousRevision > 0 {
+		undo = fmt.Sprintf("kubectl rollout undo %s --to-revision=%d", target, previousRevision)
+	}
+
+	switch {
+	case deployment.Status.ObservedGeneration >= deployment.Metadata.Generation &&
+		analysis.Replicas.Updated == desired && deployment.Status.Replicas == desired &&
+		analysis.Replicas.Available == desired && len(analysis.OldReplicaSets) == 0:
+		analysis.Verdict, analysis.Recommendation = rolloutComplete, recommendNone
+		analysis.Summary = fmt.Sprintf("Revision %d of %s is fully rolled out with %d/%d replicas available.", revision, name, desired, desired)
+	case deployment.Spec.Paused:
+		analysis.Verdict, analysis.Recommendation = rolloutPaused, recommendResume
+		analysis.Summary = fmt.Sprintf("The rollout of %s is paused at %d/%d updated replicas.", name, analysis.Replicas.Updated, desired)
+		analysis.Commands = []string{"kubectl rollout resume " + target}
+	case progressing != nil && progressing.Reason == "ProgressDeadlineExceeded":
+		analysis.Verdict, analysis.Recommendation = rolloutFailed, recommendUndo
+		analysis.Summary = fmt.Sprintf("The rollout of revision %d of %s exceeded its progress deadline with %d/%d updated replicas available.",
+			revision, name, newAvailable(analysis), desired)
+		analysis.Commands = []string{undo}
+	case blocked > 0:
+		analysis.Verdict, analysis.Recommendation = rolloutStalled, recommendUndo
+		analysis.Summary = fmt.Sprintf("The rollout of revision %d of %s is stalled: %d new pods cannot start (%s).",
+			revision, name, blocked, pendingReasonList(analysis.PendingReasons))
+		if oldServing > 0 {
+			analysis.Summary += fmt.Sprintf(" %d replicas of the previous revision are still serving.", oldServing)
+		}
+		analysis.Commands = []string{undo}
+	case analysis.PendingReasons[pendingScheduling] > 0 && newAvailable(analysis) == 0:
+		analysis.Verdict, analysis.Recommendation = rolloutStalled, recommendInvestigate
+		analysis.Summary = fmt.Sprintf("The rollout of revision %d of %s is waiting for %d new pods to be scheduled.",
+			revision, name, analysis.PendingReasons[pendingScheduling])
+		analysis.Commands = []string{"kubectl describe nodes"}
+	default:
+		analysis.Verdict, analysis.Recommendation = rolloutProgressing, recommendWait
+		analysis.Estimate = estimateRollout(analysis, progressing, progressingUpdated, deployment.Spec.ProgressDeadlineSeconds, now)
+		analysis.Summary = fmt.Sprintf("The rollout of revision %d of %s is progressing with %d/%d updated replicas available.",
+			revision, name, newAvailable(analysis), desired)
+		if analysis.Estimate.ETA != "" {
+			analysis.Summary += fmt.Sprintf(" It should complete in about %s.", analysis.Estimate.ETA)
+		}
+		if len(analysis.PendingPods) > 0 {
+			analysis.Summary += fmt.Sprintf(" Pods not ready yet: %s.", pendingReasonList(analysis.PendingReasons))
+		}
+		analysis.Commands = []string{"kubectl rollout status " + target}
+	}
+	return analysis, nil
+}
+
+// newAvailable returns the available replicas of the new ReplicaSet
+func newAvailable(analysis *RolloutAnalysis) int {
+	if analysis.NewReplicaSet == nil {
+		return 0
+	}
+	return analysis.NewReplicaSet.Available
+}
+
+// pendingReasonList renders pending pod counts by category, e.g. 2 image_pull, 1 probe
+func pendingReasonList(reasons map[string]int) string {
+	categories := make([]string, 0, len(reasons))
+	for category := range reasons {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	parts := make([]string, 0, len(categories))
+	for _, category := range categories {
+		parts = append(parts, fmt.Sprintf("%d %s", reasons[category], category))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// estimateRollout projects when the new ReplicaSet becomes fully available from
+// the rate its replicas became available since it was created
+func estimateRollout(analysis *RolloutAnalysis, progressing *crdCondition, progressingUpdated string, deadlineSeconds *int, now time.Time) *RolloutEstimate {
+	available := newAvailable(analysis)
+	estimate := &RolloutEstimate{Remaining: max(analysis.Replicas.Desired-available, 0)}
+
+	deadline := defaultProgressDeadline
+	if deadlineSeconds != nil {
+		deadline = time.Duration(*deadlineSeconds) * time.Second
+	}
+	if updated, err := time.Parse(time.RFC3339, progressingUpdated); err == nil && progressing != nil {
+		estimate.Deadline = updated.Add(deadline).UTC().Format(time.RFC3339)
+	}
+
+	if analysis.NewReplicaSet == nil || available == 0 {
+		return estimate
+	}
+	created, err := time.Parse(time.RFC3339, analysis.NewReplicaSet.Created)
+	if err != nil || !now.After(created) {
+		return estimate
+	}
+	perMinute := float64(available) / now.Sub(created).Minutes()
+	estimate.ReadyPerMinute = float64(int(perMinute*100)) / 100
+	if estimate.Remaining > 0 {
+		eta := time.Duration(float64(estimate.Remaining) / perMinute * float64(time.Minute))
+		estimate.ETA = eta.Round(time.Second).String()
+	}
+	return estimate
+}
+
+// Deployment rollout progress analysis
+func (k *K8sTool) handleRolloutAnalyze(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	deployment := p.String("deployment", "", params.Required())
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if kind, name, found := strings.Cut(deployment, "/"); found {
+		if !strings.EqualFold(kind, "deployment") && !strings.EqualFold(kind, "deploy") && !strings.EqualFold(kind, "deployments") {
+			return mcp.NewToolResultError(fmt.Sprintf("unsupported workload kind %q, only deployments are supported", kind)), nil
+		}
+		deployment = name
+	}
+	if err := security.ValidateK8sResourceName(deployment); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	deploymentOutput, err := k.runKubectlCommandString(ctx, "get", "deployment", deployment, "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError("Error getting deployment: " + err.Error()), nil
+	}
+	replicaSetOutput, err := k.runKubectlCommandString(ctx, "get", "replicasets", "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError("Error getting replica sets: " + err.Error()), nil
+	}
+	podOutput, err := k.runKubectlCommandString(ctx, "get", "pods", "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError("Error getting pods: " + err.Error()), nil
+	}
+	eventsOutput, _ := k.runKubectlCommandString(ctx, "get", "events", "-n", namespace, "-o", "json")
+
+	analysis, err := buildRolloutAnalysis(deploymentOutput, replicaSetOutput, podOutput, eventsOutput, time.Now())
+	if err != nil {
+		return mcp.NewToolResultError("Error building rollout analysis: " + err.Error()), nil
+	}
+
+	analysisJSON, err := json.MarshalIndent(analysis, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling rollout analysis: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(analysisJSON)), nil
+}
@@ -0,0 +1,174 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRolloutDeployment = `{
+ "metadata":{"name":"web","namespace":"prod","generation":4,"annotations":{"deployment.kubernetes.io/revision":"3"}},
+ "spec":{"replicas":4,"progressDeadlineSeconds":600,"strategy":{"type":"RollingUpdate"}},
+ "status":{"observedGeneration":4,"replicas":5,"updatedReplicas":2,"readyReplicas":4,"availableReplicas":4,"unavailableReplicas":1,
+  "conditions":[{"type":"Available","status":"True","reason":"MinimumReplicasAvailable"},
+   {"type":"Progressing","status":"True","reason":"ReplicaSetUpdated","message":"ReplicaSet \"web-3\" is progressing.","lastUpdateTime":"2026-10-15T10:04:00Z"}]}}`
+
+const testRolloutReplicaSets = `{"items":[
+{"metadata":{"name":"web-3","creationTimestamp":"2026-10-15T10:00:00Z","annotations":{"deployment.kubernetes.io/revision":"3"},
+  "ownerReferences":[{"kind":"Deployment","name":"web"}]},
+ "spec":{"replicas":2,"template":{"spec":{"containers":[{"name":"app","image":"web:1.3"},{"name":"proxy","image":"envoy:1.30"}]}}},
+ "status":{"replicas":2,"readyReplicas":1,"availableReplicas":1}},
+{"metadata":{"name":"web-2","annotations":{"deployment.kubernetes.io/revision":"2"},"ownerReferences":[{"kind":"Deployment","name":"web"}]},
+ "spec":{"replicas":3,"template":{"spec":{"containers":[{"name":"app","image":"web:1.2"},{"name":"proxy","image":"envoy:1.30"}]}}},
+ "status":{"replicas":3,"readyReplicas":3,"availableReplicas":3}},
+{"metadata":{"name":"web-1","annotations":{"deployment.kubernetes.io/revision":"1"},"ownerReferences":[{"kind":"Deployment","name":"web"}]},
+ "spec":{"replicas":0,"template":{"spec":{"containers":[{"name":"app","image":"web:1.1"}]}}},
+ "status":{"replicas":0}},
+{"metadata":{"name":"api-1","annotations":{"deployment.kubernetes.io/revision":"3"},"ownerReferences":[{"kind":"Deployment","name":"api"}]},
+ "spec":{"replicas":1},"status":{"replicas":1}}
+]}`
+
+const testRolloutPods = `{"items":[
+{"metadata":{"name":"web-3-a","ownerReferences":[{"kind":"ReplicaSet","name":"web-3"}]},
+ "status":{"phase":"Running","conditions":[{"type":"Ready","status":"True"}],"containerStatuses":[{"name":"app","ready":true,"state":{"running":{}}}]}},
+{"metadata":{"name":"web-3-b","ownerReferences":[{"kind":"ReplicaSet","name":"web-3"}]},
+ "status":{"phase":"Running","conditions":[{"type":"Ready","status":"False"}],"containerStatuses":[{"name":"app","ready":false,"state":{"running":{}}}]}},
+{"metadata":{"name":"web-2-a","ownerReferences":[{"kind":"ReplicaSet","name":"web-2"}]},
+ "status":{"phase":"Pending","conditions":[{"type":"PodScheduled","status":"False","reason":"Unschedulable"}]}}
+]}`
+
+const testRolloutEvents = `{"items":[
+{"involvedObject":{"kind":"Pod","name":"web-3-b"},"type":"Warning","reason":"Unhealthy",
+ "message":"Readiness probe failed: HTTP probe failed with statuscode: 503","lastTimestamp":"2026-10-15T10:05:00Z"},
+{"involvedObject":{"kind":"Pod","name":"web-3-b"},"type":"Normal","reason":"Started","message":"Started container app","lastTimestamp":"2026-10-15T10:06:00Z"}
+]}`
+
+var testRolloutNow = time.Date(2026, 10, 15, 10, 10, 0, 0, time.UTC)
+
+func TestBuildRolloutAnalysisProgressing(t *testing.T) {
+	analysis, err := buildRolloutAnalysis(testRolloutDeployment, testRolloutReplicaSets, testRolloutPods, testRolloutEvents, testRolloutNow)
+	require.NoError(t, err)
+
+	assert.Equal(t, rolloutProgressing, analysis.Verdict)
+	assert.Equal(t, recommendWait, analysis.Recommendation)
+	assert.Equal(t, 3, analysis.Revision)
+	require.NotNil(t, analysis.NewReplicaSet)
+	assert.Equal(t, "web-3", analysis.NewReplicaSet.Name)
+	// Scaled down ReplicaSets and those of other deployments are left out
+	require.Len(t, analysis.OldReplicaSets, 1)
+	assert.Equal(t, "web-2", analysis.OldReplicaSets[0].Name)
+	assert.Equal(t, []ImageChange{{Container: "app", From: "web:1.2", To: "web:1.3"}}, analysis.ImageChanges)
+
+	require.Len(t, analysis.PendingPods, 1)
+	pending := analysis.PendingPods[0]
+	assert.Equal(t, "web-3-b", pending.Name)
+	assert.Equal(t, pendingProbe, pending.Category)
+	assert.Contains(t, pending.Message, "Readiness probe failed")
+	assert.Equal(t, map[string]int{pendingProbe: 1}, analysis.PendingReasons)
+
+	// One replica became available in 10 minutes, three remain
+	require.NotNil(t, analysis.Estimate)
+	assert.Equal(t, 0.1, analysis.Estimate.ReadyPerMinute)
+	assert.Equal(t, 3, analysis.Estimate.Remaining)
+	assert.Equal(t, "30m0s", analysis.Estimate.ETA)
+	assert.Equal(t, "2026-10-15T10:14:00Z", analysis.Estimate.Deadline)
+	assert.Contains(t, analysis.Summary, "1/4 updated replicas available")
+	assert.Equal(t, []string{"kubectl rollout status deployment/web -n prod"}, analysis.Commands)
+}
+
+func TestBuildRolloutAnalysisStalled(t *testing.T) {
+	pods := `{"items":[
+{"metadata":{"name":"web-3-b","ownerReferences":[{"kind":"ReplicaSet","name":"web-3"}]},
+ "status":{"phase":"Pending","conditions":[{"type":"Ready","status":"False"}],
+  "containerStatuses":[{"name":"app","state":{"waiting":{"reason":"ImagePullBackOff","message":"Back-off pulling image \"web:1.3\""}}}]}}
+]}`
+	analysis, err := buildRolloutAnalysis(testRolloutDeployment, testRolloutReplicaSets, pods, "", testRolloutNow)
+	require.NoError(t, err)
+
+	assert.Equal(t, rolloutStalled, analysis.Verdict)
+	assert.Equal(t, recommendUndo, analysis.Recommendation)
+	require.Len(t, analysis.PendingPods, 1)
+	assert.Equal(t, pendingImagePull, analysis.PendingPods[0].Category)
+	assert.Equal(t, "app", analysis.PendingPods[0].Container)
+	assert.Contains(t, analysis.Summary, "3 replicas of the previous revision are still serving")
+	assert.Equal(t, []string{"kubectl rollout undo deployment/web -n prod --to-revision=2"}, analysis.Commands)
+	assert.Nil(t, analysis.Estimate)
+}
+
+func TestBuildRolloutAnalysisFailedAndComplete(t *testing.T) {
+	failed := `{"metadata":{"name":"web","namespace":"prod","generation":4,"annotations":{"deployment.kubernetes.io/revision":"3"}},
+ "spec":{"replicas":4},
+ "status":{"observedGeneration":4,"replicas":5,"updatedReplicas":2,"availableReplicas":4,
+  "conditions":[{"type":"Progressing","status":"False","reason":"ProgressDeadlineExceeded"}]}}`
+	analysis, err := buildRolloutAnalysis(failed, testRolloutReplicaSets, testRolloutPods, "", testRolloutNow)
+	require.NoError(t, err)
+	assert.Equal(t, rolloutFailed, analysis.Verdict)
+	assert.Equal(t, recommendUndo, analysis.Recommendation)
+
+	complete := `{"metadata":{"name":"web","namespace":"prod","generation":4,"annotations":{"deployment.kubernetes.io/revision":"3"}},
+ "spec":{"replicas":2},
+ "status":{"observedGeneration":4,"replicas":2,"updatedReplicas":2,"readyReplicas":2,"availableReplicas":2}}`
+	replicaSets := `{"items":[
+{"metadata":{"name":"web-3","annotations":{"deployment.kubernetes.io/revision":"3"},"ownerReferences":[{"kind":"Deployment","name":"web"}]},
+ "spec":{"replicas":2},"status":{"replicas":2,"readyReplicas":2,"availableReplicas":2}}]}`
+	analysis, err = buildRolloutAnalysis(complete, replicaSets, `{"items":[]}`, "", testRolloutNow)
+	require.NoError(t, err)
+	assert.Equal(t, rolloutComplete, analysis.Verdict)
+	assert.Equal(t, recommendNone, analysis.Recommendation)
+	assert.Empty(t, analysis.Commands)
+}
+
+func TestClassifyPendingPod(t *testing.T) {
+	unschedulable := classifyPendingPod("Pending", []crdCondition{{Type: "PodScheduled", Status: "False", Reason: "Unschedulable", Message: "0/3 nodes are available: 3 Insufficient cpu."}}, nil)
+	assert.Equal(t, pendingScheduling, unschedulable.Category)
+	assert.Contains(t, unschedulable.Message, "Insufficient cpu")
+
+	var crashing rolloutContainerState
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"app","restartCount":7,"state":{"waiting":{"reason":"CrashLoopBackOff"}}}`), &crashing))
+	crash := classifyPendingPod("Running", nil, []rolloutContainerState{crashing})
+	assert.Equal(t, pendingCrash, crash.Category)
+	assert.Equal(t, 7, crash.Restarts)
+
+	var creating rolloutContainerState
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"app","state":{"waiting":{"reason":"ContainerCreating"}}}`), &creating))
+	starting := classifyPendingPod("Pending", nil, []rolloutContainerState{creating})
+	assert.Equal(t, pendingStarting, starting.Category)
+	assert.Equal(t, "ContainerCreating", starting.Reason)
+}
+
+func TestHandleRolloutAnalyze(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "deployment", "web", "-n", "prod", "-o", "json"}, testRolloutDeployment, nil)
+	mock.AddCommandString("kubectl", []string{"get", "replicasets", "-n", "prod", "-o", "json"}, testRolloutReplicaSets, nil)
+	mock.AddCommandString("kubectl", []string{"get", "pods", "-n", "prod", "-o", "json"}, testRolloutPods, nil)
+	mock.AddCommandString("kubectl", []string{"get", "events", "-n", "prod", "-o", "json"}, testRolloutEvents, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	k8sTool := newTestK8sTool()
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"deployment": "deployment/web", "namespace": "prod"}
+	result, err := k8sTool.handleRolloutAnalyze(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var analysis RolloutAnalysis
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &analysis))
+	assert.Equal(t, "web", analysis.Deployment)
+	assert.Equal(t, rolloutProgressing, analysis.Verdict)
+
+	request.Params.Arguments = map[string]interface{}{"deployment": "statefulset/db"}
+	result, err = k8sTool.handleRolloutAnalyze(ctx, request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	request.Params.Arguments = map[string]interface{}{}
+	result, err = k8sTool.handleRolloutAnalyze(ctx, request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
@@ -0,0 +1,326 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Fields searched by k8s_search
+const (
+	SearchFieldName        = "name"
+	SearchFieldLabels      = "labels"
+	SearchFieldAnnotations = "annotations"
+	SearchFieldSpec        = "spec"
+)
+
+var searchFields = []string{SearchFieldName, SearchFieldLabels, SearchFieldAnnotations, SearchFieldSpec}
+
+// defaultSearchResourceTypes are the resource types searched when none are given:
+// workloads and the objects that reference, or are referenced by, them
+var defaultSearchResourceTypes = []string{
+	"deployments", "statefulsets", "daemonsets", "cronjobs", "jobs", "pods",
+	"services", "ingresses", "configmaps", "secrets", "serviceaccounts",
+	"persistentvolumeclaims", "horizontalpodautoscalers", "rolebindings",
+}
+
+// Search limits
+const (
+	defaultSearchParallelism = 4
+	maxSearchParallelism     = 16
+	defaultSearchMaxResults  = 100
+	maxSearchValueLength     = 200
+)
+
+// unsearchedTopLevelFields hold an object's identity, observed state or payload
+// rather than configuration; ConfigMap and Secret data are never searched
+var unsearchedTopLevelFields = map[string]bool{
+	"apiVersion": true, "kind": true, "metadata": true, "status": true,
+	"data": true, "stringData": true, "binaryData": true,
+}
+
+// searchResourceTypePattern matches kubectl resource types such as deployments or certificates.cert-manager.io
+var searchResourceTypePattern = regexp.MustCompile(`^[a-z][a-z0-9.-]*$`)
+
+// SearchLocation is a field of an object matching the query
+type SearchLocation struct {
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+// SearchMatch is an object with the locations matching the query
+type SearchMatch struct {
+	Kind      string           `json:"kind"`
+	Namespace string           `json:"namespace,omitempty"`
+	Name      string           `json:"name"`
+	Locations []SearchLocation `json:"locations"`
+}
+
+// SearchResults is the structured response of k8s_search
+type SearchResults struct {
+	Query         string        `json:"query"`
+	ResourceTypes []string      `json:"resource_types"`
+	Namespaces    []string      `json:"namespaces,omitempty"`
+	Matches       []SearchMatch `json:"matches"`
+	Truncated     bool          `json:"truncated,omitempty"`
+	Errors        []string      `json:"errors,omitempty"`
+}
+
+// searchMatcher reports whether a value matches the query
+type searchMatcher func(value string) bool
+
+func newSearchMatcher(query string, useRegex bool) (searchMatcher, error) {
+	if useRegex {
+		re, err := regexp.Compile(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %v", err)
+		}
+		return re.MatchString, nil
+	}
+	query = strings.ToLower(query)
+	return func(value string) bool { return strings.Contains(strings.ToLower(value), query) }, nil
+}
+
+// truncateSearchValue shortens long values such as annotations holding JSON
+func truncateSearchValue(value string) string {
+	if len(value) > maxSearchValueLength {
+		return value[:maxSearchValueLength] + "..."
+	}
+	return value
+}
+
+// searchValue walks a decoded JSON value, recording the string leaves that match
+func searchValue(path string, value interface{}, match searchMatcher, locations *[]SearchLocation) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			searchValue(path+"."+key, v[key], match, locations)
+		}
+	case []interface{}:
+		for i, item := range v {
+			searchValue(fmt.Sprintf("%s[%d]", path, i), item, match, locations)
+		}
+	case string:
+		if match(v) {
+			*locations = append(*locations, SearchLocation{Path: path, Value: truncateSearchValue(v)})
+		}
+	}
+}
+
+// searchObject returns the locations of an object's selected fields matching the query
+func searchObject(object map[string]interface{}, fields []string, match searchMatcher) []SearchLocation {
+	var locations []SearchLocation
+	metadata, _ := object["metadata"].(map[string]interface{})
+
+	if slices.Contains(fields, SearchFieldName) {
+		if name, _ := metadata["name"].(string); match(name) {
+			locations = append(locations, SearchLocation{Path: "metadata.name", Value: name})
+		}
+	}
+	// Labels and annotations match on their key or value
+	for _, field := range []string{SearchFieldLabels, SearchFieldAnnotations} {
+		if !slices.Contains(fields, field) {
+			continue
+		}
+		entries, _ := metadata[field].(map[string]interface{})
+		for _, key := range sortedKeys(entries) {
+			// The last applied configuration duplicates the spec
+			if key == lastAppliedAnnotation {
+				continue
+			}
+			value, _ := entries[key].(string)
+			if match(key) || match(value) {
+				locations = append(locations, SearchLocation{Path: "metadata." + field + "." + key, Value: truncateSearchValue(value)})
+			}
+		}
+	}
+	if slices.Contains(fields, SearchFieldSpec) {
+		for _, key := range sortedKeys(object) {
+			if !unsearchedTopLevelFields[key] {
+				searchValue(key, object[key], match, &locations)
+			}
+		}
+	}
+	return locations
+}
+
+// searchJob lists one resource type in one namespace, or in all namespaces when namespace is empty
+type searchJob struct {
+	resourceType string
+	namespace    string
+}
+
+func (j searchJob) String() string {
+	if j.namespace == "" {
+		return j.resourceType
+	}
+	return j.resourceType + " in " + j.namespace
+}
+
+// searchResources lists and searches the objects of a job
+func (k *K8sTool) searchResources(ctx context.Context, job searchJob, fields []string, match searchMatcher) ([]SearchMatch, error) {
+	args := []string{"get", job.resourceType}
+	if job.namespace == "" {
+		args = append(args, "--all-namespaces")
+	} else {
+		args = append(args, "-n", job.namespace)
+	}
+	output, err := k.runKubectlCommandString(ctx, append(args, "-o", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", job, err)
+	}
+
+	var matches []SearchMatch
+	for _, object := range list.Items {
+		locations := searchObject(object, fields, match)
+		if len(locations) == 0 {
+			continue
+		}
+		metadata, _ := object["metadata"].(map[string]interface{})
+		result := SearchMatch{Locations: locations}
+		result.Kind, _ = object["kind"].(string)
+		if result.Kind == "" {
+			result.Kind = job.resourceType
+		}
+		result.Namespace, _ = metadata["namespace"].(string)
+		result.Name, _ = metadata["name"].(string)
+		matches = append(matches, result)
+	}
+	return matches, nil
+}
+
+// splitList splits a comma-separated parameter, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Search resource names, labels, annotations and spec fields across resource types and namespaces
+func (k *K8sTool) handleSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	query := p.String("query", "", params.Required())
+	useRegex := p.Bool("regex", false)
+	resourceTypesParam := p.String("resource_types", "")
+	namespacesParam := p.String("namespaces", "")
+	fieldsParam := p.String("fields", "")
+	parallelism := p.Int("parallelism", defaultSearchParallelism, params.Range(1, maxSearchParallelism))
+	maxResults := p.Int("max_results", defaultSearchMaxResults, params.Range(1, 1000))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	match, err := newSearchMatcher(query, useRegex)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	resourceTypes := splitList(resourceTypesParam)
+	for _, resourceType := range resourceTypes {
+		if !searchResourceTypePattern.MatchString(resourceType) {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid resource type %q", resourceType)), nil
+		}
+	}
+	if len(resourceTypes) == 0 {
+		resourceTypes = defaultSearchResourceTypes
+	}
+	namespaces := splitList(namespacesParam)
+	for _, namespace := range namespaces {
+		if err := security.ValidateNamespace(namespace); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid namespace %q: %v", namespace, err)), nil
+		}
+	}
+	fields := splitList(fieldsParam)
+	for _, field := range fields {
+		if !slices.Contains(searchFields, field) {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown field %q, expected one of: %s", field, strings.Join(searchFields, ", "))), nil
+		}
+	}
+	if len(fields) == 0 {
+		fields = searchFields
+	}
+
+	var searchJobs []searchJob
+	for _, resourceType := range resourceTypes {
+		if len(namespaces) == 0 {
+			searchJobs = append(searchJobs, searchJob{resourceType: resourceType})
+		}
+		for _, namespace := range namespaces {
+			searchJobs = append(searchJobs, searchJob{resourceType: resourceType, namespace: namespace})
+		}
+	}
+
+	results := SearchResults{Query: query, ResourceTypes: resourceTypes, Namespaces: namespaces, Matches: []SearchMatch{}}
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	jobs := make(chan searchJob)
+	var wg sync.WaitGroup
+	for range min(parallelism, len(searchJobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				matches, err := k.searchResources(ctx, job, fields, match)
+				mu.Lock()
+				if err != nil {
+					results.Errors = append(results.Errors, err.Error())
+				}
+				// Cluster-scoped types are listed once per namespace
+				for _, m := range matches {
+					key := m.Kind + "/" + m.Namespace + "/" + m.Name
+					if !seen[key] {
+						seen[key] = true
+						results.Matches = append(results.Matches, m)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, job := range searchJobs {
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Strings(results.Errors)
+	sort.Slice(results.Matches, func(i, j int) bool {
+		a, b := results.Matches[i], results.Matches[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	if len(results.Matches) > maxResults {
+		results.Matches = results.Matches[:maxResults]
+		results.Truncated = true
+	}
+
+	resultsJSON, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal search results: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(resultsJSON)), nil
+}
@@ -0,0 +1,109 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const (
+	testSearchDeployments = `{"items":[
+		{"kind":"Deployment","metadata":{"name":"web","namespace":"shop","labels":{"app":"web"},
+		  "annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{\"spec\":{\"configMapRef\":\"web-config\"}}"}},
+		 "spec":{"template":{"spec":{"containers":[{"name":"app","image":"web:1.1","envFrom":[{"configMapRef":{"name":"web-config"}}]}],
+		  "volumes":[{"name":"config","configMap":{"name":"web-config"}}]}}},
+		 "status":{"conditions":[{"message":"web-config"}]}},
+		{"kind":"Deployment","metadata":{"name":"worker","namespace":"shop"},"spec":{"template":{"spec":{"containers":[{"name":"app","image":"worker:2.0"}]}}}}]}`
+
+	testSearchConfigMaps = `{"items":[
+		{"kind":"ConfigMap","metadata":{"name":"web-config","namespace":"shop","labels":{"app.kubernetes.io/part-of":"web-config"}},"data":{"ref":"web-config"}},
+		{"kind":"ConfigMap","metadata":{"name":"other","namespace":"shop"},"data":{"ref":"web-config"}}]}`
+)
+
+func searchResults(t *testing.T, mock *cmd.MockShellExecutor, args map[string]interface{}) SearchResults {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	result, err := newTestK8sTool().handleSearch(cmd.WithShellExecutor(context.Background(), mock), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var results SearchResults
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &results))
+	return results
+}
+
+func TestHandleSearch(t *testing.T) {
+	t.Run("finds references across resource types", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "deployments", "--all-namespaces", "-o", "json"}, testSearchDeployments, nil)
+		mock.AddCommandString("kubectl", []string{"get", "configmaps", "--all-namespaces", "-o", "json"}, testSearchConfigMaps, nil)
+		mock.AddCommandString("kubectl", []string{"get", "rollouts.argoproj.io", "--all-namespaces", "-o", "json"}, "", errors.New(`error: the server doesn't have a resource type "rollouts"`))
+
+		results := searchResults(t, mock, map[string]interface{}{"query": "WEB-CONFIG", "resource_types": "deployments, configmaps,rollouts.argoproj.io"})
+		assert.Equal(t, []string{"deployments", "configmaps", "rollouts.argoproj.io"}, results.ResourceTypes)
+		require.Len(t, results.Errors, 1)
+		assert.Contains(t, results.Errors[0], `the server doesn't have a resource type "rollouts"`)
+		assert.False(t, results.Truncated)
+
+		// ConfigMap data, status and the last applied configuration are not searched
+		require.Len(t, results.Matches, 2)
+		assert.Equal(t, SearchMatch{Kind: "ConfigMap", Namespace: "shop", Name: "web-config", Locations: []SearchLocation{
+			{Path: "metadata.name", Value: "web-config"},
+			{Path: "metadata.labels.app.kubernetes.io/part-of", Value: "web-config"},
+		}}, results.Matches[0])
+		assert.Equal(t, SearchMatch{Kind: "Deployment", Namespace: "shop", Name: "web", Locations: []SearchLocation{
+			{Path: "spec.template.spec.containers[0].envFrom[0].configMapRef.name", Value: "web-config"},
+			{Path: "spec.template.spec.volumes[0].configMap.name", Value: "web-config"},
+		}}, results.Matches[1])
+	})
+
+	t.Run("regex over selected fields and namespaces", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "deployments", "-n", "shop", "-o", "json"}, testSearchDeployments, nil)
+		mock.AddCommandString("kubectl", []string{"get", "deployments", "-n", "staging", "-o", "json"}, `{"items":[]}`, nil)
+
+		results := searchResults(t, mock, map[string]interface{}{
+			"query": `^(web|worker):\d`, "regex": "true", "resource_types": "deployments", "namespaces": "shop,staging", "fields": "spec", "max_results": float64(1),
+		})
+		assert.Equal(t, []string{"shop", "staging"}, results.Namespaces)
+		assert.True(t, results.Truncated)
+		require.Len(t, results.Matches, 1)
+		assert.Equal(t, []SearchLocation{{Path: "spec.template.spec.containers[0].image", Value: "web:1.1"}}, results.Matches[0].Locations)
+		assert.Len(t, mock.GetCallLog(), 2)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, args := range []map[string]interface{}{
+			{},
+			{"query": "(", "regex": "true"},
+			{"query": "web", "resource_types": "pods,--raw=/"},
+			{"query": "web", "namespaces": "Invalid_Namespace"},
+			{"query": "web", "fields": "status"},
+			{"query": "web", "parallelism": float64(32)},
+		} {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = args
+			result, err := newTestK8sTool().handleSearch(context.Background(), request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError, args)
+		}
+	})
+}
+
+func TestSearchObjectTruncatesLongValues(t *testing.T) {
+	long := "web-config " + string(make([]byte, 300))
+	match, err := newSearchMatcher("web-config", false)
+	require.NoError(t, err)
+	locations := searchObject(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]interface{}{"example.com/note": long}},
+	}, searchFields, match)
+	require.Len(t, locations, 1)
+	assert.Len(t, locations[0].Value, maxSearchValueLength+3)
+}
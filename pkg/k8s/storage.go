@@ -0,0 +1,584 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// defaultStorageEventLimit is the number of volume events reported when no limit is given
+const defaultStorageEventLimit = 20
+
+// Claim phases reported in addition to the PVC phases Pending, Bound and Lost
+const claimPhaseMissing = "Missing"
+
+// volumeEventReasons are the event reasons related to volume binding, provisioning and mounting
+var volumeEventReasons = []string{
+	"FailedBinding", "ProvisioningFailed", "ExternalProvisioning", "WaitForFirstConsumer", "WaitForPodScheduled",
+	"FailedMount", "FailedAttachVolume", "FailedMapVolume", "VolumeResizeFailed", "FailedDetachVolume",
+}
+
+// statefulSetList is the subset of `kubectl get statefulsets -o json` output used for diagnosis
+type statefulSetList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			Replicas             *int `json:"replicas"`
+			VolumeClaimTemplates []struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+			} `json:"volumeClaimTemplates"`
+		} `json:"spec"`
+		Status struct {
+			ReadyReplicas int `json:"readyReplicas"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// storagePodList is the subset of `kubectl get pods -o json` output used for diagnosis
+type storagePodList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Status struct {
+			Phase      string         `json:"phase"`
+			Conditions []crdCondition `json:"conditions"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// pvcList is the subset of `kubectl get pvc -o json` output used for diagnosis
+type pvcList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			StorageClassName *string  `json:"storageClassName"`
+			VolumeName       string   `json:"volumeName"`
+			AccessModes      []string `json:"accessModes"`
+			Resources        struct {
+				Requests map[string]string `json:"requests"`
+			} `json:"resources"`
+		} `json:"spec"`
+		Status struct {
+			Phase    string            `json:"phase"`
+			Capacity map[string]string `json:"capacity"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// pvList is the subset of `kubectl get pv -o json` output used for diagnosis
+type pvList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Status struct {
+			Phase   string `json:"phase"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// storageClassList is the subset of `kubectl get storageclasses -o json` output used for diagnosis
+type storageClassList struct {
+	Items []struct {
+		Metadata struct {
+			Name        string            `json:"name"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Provisioner       string `json:"provisioner"`
+		VolumeBindingMode string `json:"volumeBindingMode"`
+	} `json:"items"`
+}
+
+// eventList is the subset of `kubectl get events -o json` output used for diagnosis
+type eventList struct {
+	Items []struct {
+		InvolvedObject struct {
+			Kind      string `json:"kind"`
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"involvedObject"`
+		Type          string `json:"type"`
+		Reason        string `json:"reason"`
+		Message       string `json:"message"`
+		Count         int    `json:"count"`
+		LastTimestamp string `json:"lastTimestamp"`
+		EventTime     string `json:"eventTime"`
+	} `json:"items"`
+}
+
+// ClaimStatus describes a PVC and the PV bound to it
+type ClaimStatus struct {
+	Name         string   `json:"name"`
+	Namespace    string   `json:"namespace"`
+	Phase        string   `json:"phase"`
+	StorageClass string   `json:"storage_class,omitempty"`
+	Requested    string   `json:"requested,omitempty"`
+	Capacity     string   `json:"capacity,omitempty"`
+	AccessModes  []string `json:"access_modes,omitempty"`
+	Volume       string   `json:"volume,omitempty"`
+	VolumePhase  string   `json:"volume_phase,omitempty"`
+	Reason       string   `json:"reason,omitempty"`
+}
+
+// PodStorage describes a StatefulSet pod and the claims of its volume templates
+type PodStorage struct {
+	Name              string        `json:"name"`
+	Phase             string        `json:"phase"`
+	SchedulingMessage string        `json:"scheduling_message,omitempty"`
+	Claims            []ClaimStatus `json:"claims"`
+}
+
+// StatefulSetStorage correlates a StatefulSet with its pods and claims
+type StatefulSetStorage struct {
+	Name          string       `json:"name"`
+	Namespace     string       `json:"namespace"`
+	Replicas      int          `json:"replicas"`
+	ReadyReplicas int          `json:"ready_replicas"`
+	Pods          []PodStorage `json:"pods"`
+	Issues        []string     `json:"issues,omitempty"`
+}
+
+// StorageClassHealth describes a storage class and the health of its provisioner
+type StorageClassHealth struct {
+	Name                 string   `json:"name"`
+	Provisioner          string   `json:"provisioner"`
+	Default              bool     `json:"default"`
+	VolumeBindingMode    string   `json:"volume_binding_mode,omitempty"`
+	CSIDriverRegistered  *bool    `json:"csi_driver_registered,omitempty"`
+	NodesWithDriver      int      `json:"nodes_with_driver"`
+	PendingClaims        int      `json:"pending_claims"`
+	ProvisioningFailures int      `json:"provisioning_failures"`
+	Healthy              bool     `json:"healthy"`
+	Issues               []string `json:"issues,omitempty"`
+}
+
+// VolumeEvent is a recent event related to volumes
+type VolumeEvent struct {
+	Namespace string `json:"namespace,omitempty"`
+	Object    string `json:"object"`
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+	Message   string `json:"message"`
+	Count     int    `json:"count"`
+	LastTime  string `json:"last_time,omitempty"`
+}
+
+// StorageSummary counts the storage problems found
+type StorageSummary struct {
+	StatefulSets  int `json:"statefulsets"`
+	Claims        int `json:"claims"`
+	PendingClaims int `json:"pending_claims"`
+	LostClaims    int `json:"lost_claims"`
+	MissingClaims int `json:"missing_claims"`
+	PendingPods   int `json:"pending_pods"`
+}
+
+// StorageDiagnosis is the structured response of k8s_storage_diagnose
+type StorageDiagnosis struct {
+	Summary        StorageSummary       `json:"summary"`
+	StatefulSets   []StatefulSetStorage `json:"statefulsets"`
+	UnboundClaims  []ClaimStatus        `json:"unbound_claims"`
+	StorageClasses []StorageClassHealth `json:"storage_classes"`
+	Events         []VolumeEvent        `json:"events"`
+}
+
+// storageInputs holds the raw kubectl outputs a diagnosis is built from
+type storageInputs struct {
+	StatefulSets   string
+	Pods           string
+	Claims         string
+	Volumes        string
+	StorageClasses string
+	Events         string
+	CSIDrivers     string
+	CSINodes       string
+}
+
+// buildStorageDiagnosis correlates StatefulSets, pods, claims, volumes, storage classes and events
+func buildStorageDiagnosis(in storageInputs, statefulSetName string, eventLimit int) (*StorageDiagnosis, error) {
+	var statefulSets statefulSetList
+	if err := json.Unmarshal([]byte(in.StatefulSets), &statefulSets); err != nil {
+		return nil, fmt.Errorf("failed to parse statefulsets: %w", err)
+	}
+	var pods storagePodList
+	if err := json.Unmarshal([]byte(in.Pods), &pods); err != nil {
+		return nil, fmt.Errorf("failed to parse pods: %w", err)
+	}
+	var claims pvcList
+	if err := json.Unmarshal([]byte(in.Claims), &claims); err != nil {
+		return nil, fmt.Errorf("failed to parse persistent volume claims: %w", err)
+	}
+	// Cluster-scoped lists are optional, as they need broader permissions
+	var volumes pvList
+	_ = json.Unmarshal([]byte(in.Volumes), &volumes)
+	var classes storageClassList
+	_ = json.Unmarshal([]byte(in.StorageClasses), &classes)
+	var events eventList
+	_ = json.Unmarshal([]byte(in.Events), &events)
+
+	volumePhases := make(map[string]string)
+	volumeReasons := make(map[string]string)
+	for _, pv := range volumes.Items {
+		volumePhases[pv.Metadata.Name] = pv.Status.Phase
+		if pv.Status.Message != "" {
+			volumeReasons[pv.Metadata.Name] = pv.Status.Message
+		} else {
+			volumeReasons[pv.Metadata.Name] = pv.Status.Reason
+		}
+	}
+
+	defaultClass := ""
+	classBindingModes := make(map[string]string)
+	for _, class := range classes.Items {
+		classBindingModes[class.Metadata.Name] = class.VolumeBindingMode
+		if class.Metadata.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" {
+			defaultClass = class.Metadata.Name
+		}
+	}
+
+	// Latest warning message per PVC, used to explain why it is not bound
+	claimEvents := make(map[string]string)
+	provisioningFailures := make(map[string]int)
+	var volumeEvents []VolumeEvent
+	for _, event := range events.Items {
+		if !containsFold(volumeEventReasons, event.Reason) &&
+			!(event.Reason == "FailedScheduling" && strings.Contains(strings.ToLower(event.Message), "persistentvolumeclaim")) {
+			continue
+		}
+		lastTime := event.LastTimestamp
+		if lastTime == "" {
+			lastTime = event.EventTime
+		}
+		volumeEvents = append(volumeEvents, VolumeEvent{
+			Namespace: event.InvolvedObject.Namespace,
+			Object:    strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name,
+			Type:      event.Type,
+			Reason:    event.Reason,
+			Message:   event.Message,
+			Count:     event.Count,
+			LastTime:  lastTime,
+		})
+		if event.InvolvedObject.Kind == "PersistentVolumeClaim" {
+			claimEvents[event.InvolvedObject.Namespace+"/"+event.InvolvedObject.Name] = event.Message
+		}
+		if event.Reason == "ProvisioningFailed" {
+			for _, class := range classes.Items {
+				if strings.Contains(event.Message, class.Provisioner) || strings.Contains(event.Message, `"`+class.Metadata.Name+`"`) {
+					provisioningFailures[class.Metadata.Name]++
+				}
+			}
+		}
+	}
+	sort.SliceStable(volumeEvents, func(i, j int) bool {
+		return volumeEvents[i].LastTime > volumeEvents[j].LastTime
+	})
+	if eventLimit >= 0 && len(volumeEvents) > eventLimit {
+		volumeEvents = volumeEvents[:eventLimit]
+	}
+
+	diagnosis := &StorageDiagnosis{
+		StatefulSets:   []StatefulSetStorage{},
+		UnboundClaims:  []ClaimStatus{},
+		StorageClasses: []StorageClassHealth{},
+		Events:         volumeEvents,
+	}
+	if diagnosis.Events == nil {
+		diagnosis.Events = []VolumeEvent{}
+	}
+
+	claimStatuses := make(map[string]ClaimStatus)
+	pendingByClass := make(map[string]int)
+	for _, pvc := range claims.Items {
+		status := ClaimStatus{
+			Name:        pvc.Metadata.Name,
+			Namespace:   pvc.Metadata.Namespace,
+			Phase:       pvc.Status.Phase,
+			Requested:   pvc.Spec.Resources.Requests["storage"],
+			Capacity:    pvc.Status.Capacity["storage"],
+			AccessModes: pvc.Spec.AccessModes,
+			Volume:      pvc.Spec.VolumeName,
+		}
+		if pvc.Spec.StorageClassName != nil {
+			status.StorageClass = *pvc.Spec.StorageClassName
+		} else {
+			status.StorageClass = defaultClass
+		}
+		if status.Volume != "" {
+			status.VolumePhase = volumePhases[status.Volume]
+		}
+
+		key := status.Namespace + "/" + status.Name
+		switch status.Phase {
+		case "Pending":
+			pendingByClass[status.StorageClass]++
+			status.Reason = claimEvents[key]
+			if status.Reason == "" {
+				switch {
+				case status.StorageClass == "":
+					status.Reason = "no storage class set and no default storage class exists"
+				case len(classes.Items) > 0 && !classExists(classes, status.StorageClass):
+					status.Reason = fmt.Sprintf("storage class %q does not exist", status.StorageClass)
+				case classBindingModes[status.StorageClass] == "WaitForFirstConsumer":
+					status.Reason = "waiting for a pod using the claim to be scheduled (WaitForFirstConsumer)"
+				}
+			}
+		case "Lost":
+			status.Reason = fmt.Sprintf("bound volume %s no longer exists", status.Volume)
+		}
+		if status.Volume != "" && status.Reason == "" && status.VolumePhase != "" && status.VolumePhase != "Bound" {
+			status.Reason = volumeReasons[status.Volume]
+		}
+		claimStatuses[key] = status
+	}
+
+	podsByKey := make(map[string]int)
+	for i, pod := range pods.Items {
+		podsByKey[pod.Metadata.Namespace+"/"+pod.Metadata.Name] = i
+	}
+
+	templateClaims := make(map[string]bool)
+	for _, sts := range statefulSets.Items {
+		replicas := 1
+		if sts.Spec.Replicas != nil {
+			replicas = *sts.Spec.Replicas
+		}
+		// Claims created from templates belong to the StatefulSet even when it is filtered out
+		for i := 0; i < replicas; i++ {
+			for _, template := range sts.Spec.VolumeClaimTemplates {
+				templateClaims[fmt.Sprintf("%s/%s-%s-%d", sts.Metadata.Namespace, template.Metadata.Name, sts.Metadata.Name, i)] = true
+			}
+		}
+		if statefulSetName != "" && sts.Metadata.Name != statefulSetName {
+			continue
+		}
+
+		storage := StatefulSetStorage{
+			Name:          sts.Metadata.Name,
+			Namespace:     sts.Metadata.Namespace,
+			Replicas:      replicas,
+			ReadyReplicas: sts.Status.ReadyReplicas,
+			Pods:          []PodStorage{},
+		}
+		for i := 0; i < replicas; i++ {
+			podName := fmt.Sprintf("%s-%d", sts.Metadata.Name, i)
+			podStorage := PodStorage{Name: podName, Phase: "Missing", Claims: []ClaimStatus{}}
+			if index, ok := podsByKey[sts.Metadata.Namespace+"/"+podName]; ok {
+				pod := pods.Items[index]
+				podStorage.Phase = pod.Status.Phase
+				for _, condition := range pod.Status.Conditions {
+					if condition.Type == "PodScheduled" && condition.Status == "False" {
+						podStorage.SchedulingMessage = condition.Message
+					}
+				}
+			}
+			if podStorage.Phase == "Pending" {
+				diagnosis.Summary.PendingPods++
+			}
+
+			for _, template := range sts.Spec.VolumeClaimTemplates {
+				claimName := fmt.Sprintf("%s-%s", template.Metadata.Name, podName)
+				claim, ok := claimStatuses[sts.Metadata.Namespace+"/"+claimName]
+				if !ok {
+					claim = ClaimStatus{Name: claimName, Namespace: sts.Metadata.Namespace, Phase: claimPhaseMissing,
+						Reason: "claim has not been created for this ordinal"}
+				}
+				switch claim.Phase {
+				case "Pending":
+					diagnosis.Summary.PendingClaims++
+					storage.Issues = append(storage.Issues, fmt.Sprintf("claim %s is Pending: %s", claimName, claim.Reason))
+				case "Lost":
+					diagnosis.Summary.LostClaims++
+					storage.Issues = append(storage.Issues, fmt.Sprintf("claim %s is Lost: %s", claimName, claim.Reason))
+				case claimPhaseMissing:
+					diagnosis.Summary.MissingClaims++
+					storage.Issues = append(storage.Issues, fmt.Sprintf("claim %s is missing", claimName))
+				}
+				diagnosis.Summary.Claims++
+				podStorage.Claims = append(podStorage.Claims, claim)
+			}
+			storage.Pods = append(storage.Pods, podStorage)
+		}
+		diagnosis.StatefulSets = append(diagnosis.StatefulSets, storage)
+	}
+	diagnosis.Summary.StatefulSets = len(diagnosis.StatefulSets)
+
+	// Claims outside StatefulSets are only reported when they are not bound
+	if statefulSetName == "" {
+		for _, pvc := range claims.Items {
+			key := pvc.Metadata.Namespace + "/" + pvc.Metadata.Name
+			claim := claimStatuses[key]
+			if templateClaims[key] || claim.Phase == "Bound" {
+				continue
+			}
+			switch claim.Phase {
+			case "Pending":
+				diagnosis.Summary.PendingClaims++
+			case "Lost":
+				diagnosis.Summary.LostClaims++
+			}
+			diagnosis.Summary.Claims++
+			diagnosis.UnboundClaims = append(diagnosis.UnboundClaims, claim)
+		}
+		sort.Slice(diagnosis.UnboundClaims, func(i, j int) bool {
+			a, b := diagnosis.UnboundClaims[i], diagnosis.UnboundClaims[j]
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Name < b.Name
+		})
+	}
+
+	diagnosis.StorageClasses = storageClassHealth(classes, in.CSIDrivers, in.CSINodes, pendingByClass, provisioningFailures)
+	return diagnosis, nil
+}
+
+// classExists reports whether a storage class is installed
+func classExists(classes storageClassList, name string) bool {
+	for _, class := range classes.Items {
+		if class.Metadata.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// storageClassHealth checks that the CSI driver of each storage class is registered on the cluster and its nodes
+func storageClassHealth(classes storageClassList, csiDriversOutput, csiNodesOutput string, pendingByClass, provisioningFailures map[string]int) []StorageClassHealth {
+	var drivers struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	driversKnown := json.Unmarshal([]byte(csiDriversOutput), &drivers) == nil
+	registered := make(map[string]bool)
+	for _, driver := range drivers.Items {
+		registered[driver.Metadata.Name] = true
+	}
+
+	var nodes struct {
+		Items []struct {
+			Spec struct {
+				Drivers []struct {
+					Name string `json:"name"`
+				} `json:"drivers"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	_ = json.Unmarshal([]byte(csiNodesOutput), &nodes)
+	nodesWithDriver := make(map[string]int)
+	for _, node := range nodes.Items {
+		for _, driver := range node.Spec.Drivers {
+			nodesWithDriver[driver.Name]++
+		}
+	}
+
+	result := []StorageClassHealth{}
+	for _, class := range classes.Items {
+		health := StorageClassHealth{
+			Name:                 class.Metadata.Name,
+			Provisioner:          class.Provisioner,
+			Default:              class.Metadata.Annotations["storageclass.kubernetes.io/is-default-class"] == "true",
+			VolumeBindingMode:    class.VolumeBindingMode,
+			NodesWithDriver:      nodesWithDriver[class.Provisioner],
+			PendingClaims:        pendingByClass[class.Metadata.Name],
+			ProvisioningFailures: provisioningFailures[class.Metadata.Name],
+		}
+
+		// In-tree and static provisioners have no CSI driver object
+		csi := !strings.HasPrefix(class.Provisioner, "kubernetes.io/")
+		if csi && driversKnown {
+			isRegistered := registered[class.Provisioner]
+			health.CSIDriverRegistered = &isRegistered
+			if !isRegistered {
+				health.Issues = append(health.Issues, fmt.Sprintf("CSI driver %s is not registered", class.Provisioner))
+			} else if health.NodesWithDriver == 0 && len(nodes.Items) > 0 {
+				health.Issues = append(health.Issues, fmt.Sprintf("no node reports CSI driver %s", class.Provisioner))
+			}
+		}
+		if health.ProvisioningFailures > 0 {
+			health.Issues = append(health.Issues, fmt.Sprintf("%d recent provisioning failures", health.ProvisioningFailures))
+		}
+		health.Healthy = len(health.Issues) == 0
+		result = append(result, health)
+	}
+	return result
+}
+
+// StatefulSet and PVC diagnostics
+func (k *K8sTool) handleStorageDiagnose(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	namespace := p.String("namespace", "", params.Check(security.ValidateNamespace))
+	statefulSet := p.String("statefulset", "", params.Check(security.ValidateK8sResourceName))
+	eventLimit := p.Int("event_limit", defaultStorageEventLimit, params.Min(0))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if statefulSet != "" && namespace == "" {
+		return mcp.NewToolResultError("namespace parameter is required when statefulset is set"), nil
+	}
+
+	scope := []string{"--all-namespaces"}
+	if namespace != "" {
+		scope = []string{"-n", namespace}
+	}
+	get := func(resource string, namespaced bool) (string, error) {
+		args := []string{"get", resource, "-o", "json"}
+		if namespaced {
+			args = append(args, scope...)
+		}
+		return k.runKubectlCommandString(ctx, args...)
+	}
+
+	var in storageInputs
+	var err error
+	if in.StatefulSets, err = get("statefulsets", true); err != nil {
+		return mcp.NewToolResultError("Error getting statefulsets: " + err.Error()), nil
+	}
+	if in.Pods, err = get("pods", true); err != nil {
+		return mcp.NewToolResultError("Error getting pods: " + err.Error()), nil
+	}
+	if in.Claims, err = get("persistentvolumeclaims", true); err != nil {
+		return mcp.NewToolResultError("Error getting persistent volume claims: " + err.Error()), nil
+	}
+	// Cluster-scoped resources and events only enrich the diagnosis, so failures are tolerated
+	in.Volumes, _ = get("persistentvolumes", false)
+	in.StorageClasses, _ = get("storageclasses", false)
+	in.CSIDrivers, _ = get("csidrivers", false)
+	in.CSINodes, _ = get("csinodes", false)
+	in.Events, _ = get("events", true)
+
+	diagnosis, err := buildStorageDiagnosis(in, statefulSet, eventLimit)
+	if err != nil {
+		return mcp.NewToolResultError("Error building storage diagnosis: " + err.Error()), nil
+	}
+	if statefulSet != "" && len(diagnosis.StatefulSets) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("statefulset %s/%s not found", namespace, statefulSet)), nil
+	}
+
+	diagnosisJSON, err := json.MarshalIndent(diagnosis, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling storage diagnosis: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(diagnosisJSON)), nil
+}
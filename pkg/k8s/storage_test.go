@@ -0,0 +1,161 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStatefulSets = `{"items":[
+{"metadata":{"name":"db","namespace":"prod"},
+ "spec":{"replicas":3,"volumeClaimTemplates":[{"metadata":{"name":"data"}}]},
+ "status":{"readyReplicas":1}}
+]}`
+
+const testStoragePods = `{"items":[
+{"metadata":{"name":"db-0","namespace":"prod"},"status":{"phase":"Running"}},
+{"metadata":{"name":"db-1","namespace":"prod"},
+ "status":{"phase":"Pending","conditions":[{"type":"PodScheduled","status":"False","message":"pod has unbound immediate PersistentVolumeClaims"}]}}
+]}`
+
+const testClaims = `{"items":[
+{"metadata":{"name":"data-db-0","namespace":"prod"},
+ "spec":{"storageClassName":"fast","volumeName":"pv-0","accessModes":["ReadWriteOnce"],"resources":{"requests":{"storage":"10Gi"}}},
+ "status":{"phase":"Bound","capacity":{"storage":"10Gi"}}},
+{"metadata":{"name":"data-db-1","namespace":"prod"},
+ "spec":{"storageClassName":"fast","resources":{"requests":{"storage":"10Gi"}}},
+ "status":{"phase":"Pending"}},
+{"metadata":{"name":"cache","namespace":"prod"},
+ "spec":{"volumeName":"pv-old"},
+ "status":{"phase":"Lost"}},
+{"metadata":{"name":"logs","namespace":"prod"},
+ "spec":{"storageClassName":"standard","volumeName":"pv-logs"},
+ "status":{"phase":"Bound"}}
+]}`
+
+const testVolumes = `{"items":[
+{"metadata":{"name":"pv-0"},"status":{"phase":"Bound"}},
+{"metadata":{"name":"pv-logs"},"status":{"phase":"Bound"}}
+]}`
+
+const testStorageClasses = `{"items":[
+{"metadata":{"name":"fast"},"provisioner":"ebs.csi.aws.com","volumeBindingMode":"Immediate"},
+{"metadata":{"name":"standard","annotations":{"storageclass.kubernetes.io/is-default-class":"true"}},
+ "provisioner":"rancher.io/local-path","volumeBindingMode":"WaitForFirstConsumer"}
+]}`
+
+const testStorageEvents = `{"items":[
+{"involvedObject":{"kind":"PersistentVolumeClaim","name":"data-db-1","namespace":"prod"},"type":"Warning",
+ "reason":"ProvisioningFailed","message":"failed to provision volume with StorageClass \"fast\": rpc error","count":4,"lastTimestamp":"2026-10-15T10:00:00Z"},
+{"involvedObject":{"kind":"Pod","name":"db-1","namespace":"prod"},"type":"Warning",
+ "reason":"FailedScheduling","message":"0/3 nodes are available: pod has unbound immediate PersistentVolumeClaims","count":9,"lastTimestamp":"2026-10-15T10:05:00Z"},
+{"involvedObject":{"kind":"Pod","name":"web","namespace":"prod"},"type":"Warning",
+ "reason":"BackOff","message":"Back-off restarting failed container","count":2,"lastTimestamp":"2026-10-15T10:06:00Z"}
+]}`
+
+func newStorageMock() *cmd.MockShellExecutor {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "statefulsets", "-o", "json", "-n", "prod"}, testStatefulSets, nil)
+	mock.AddCommandString("kubectl", []string{"get", "pods", "-o", "json", "-n", "prod"}, testStoragePods, nil)
+	mock.AddCommandString("kubectl", []string{"get", "persistentvolumeclaims", "-o", "json", "-n", "prod"}, testClaims, nil)
+	mock.AddCommandString("kubectl", []string{"get", "persistentvolumes", "-o", "json"}, testVolumes, nil)
+	mock.AddCommandString("kubectl", []string{"get", "storageclasses", "-o", "json"}, testStorageClasses, nil)
+	mock.AddCommandString("kubectl", []string{"get", "csidrivers", "-o", "json"}, `{"items":[]}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "csinodes", "-o", "json"}, `{"items":[{"spec":{"drivers":[]}}]}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "events", "-o", "json", "-n", "prod"}, testStorageEvents, nil)
+	return mock
+}
+
+func TestHandleStorageDiagnose(t *testing.T) {
+	t.Run("correlates statefulset claims", func(t *testing.T) {
+		ctx := cmd.WithShellExecutor(context.Background(), newStorageMock())
+
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{"namespace": "prod"}
+		result, err := newTestK8sTool().handleStorageDiagnose(ctx, req)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var diagnosis StorageDiagnosis
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &diagnosis))
+		assert.Equal(t, StorageSummary{StatefulSets: 1, Claims: 4, PendingClaims: 1, LostClaims: 1, MissingClaims: 1, PendingPods: 1}, diagnosis.Summary)
+
+		require.Len(t, diagnosis.StatefulSets, 1)
+		db := diagnosis.StatefulSets[0]
+		require.Len(t, db.Pods, 3)
+		assert.Equal(t, "Bound", db.Pods[0].Claims[0].Phase)
+		assert.Equal(t, "Bound", db.Pods[0].Claims[0].VolumePhase)
+
+		assert.Equal(t, "Pending", db.Pods[1].Phase)
+		assert.Contains(t, db.Pods[1].SchedulingMessage, "unbound immediate")
+		assert.Equal(t, "Pending", db.Pods[1].Claims[0].Phase)
+		assert.Contains(t, db.Pods[1].Claims[0].Reason, "rpc error")
+
+		assert.Equal(t, "Missing", db.Pods[2].Phase)
+		assert.Equal(t, claimPhaseMissing, db.Pods[2].Claims[0].Phase)
+		assert.Len(t, db.Issues, 2)
+
+		// Bound claims outside StatefulSets are not reported
+		require.Len(t, diagnosis.UnboundClaims, 1)
+		assert.Equal(t, "cache", diagnosis.UnboundClaims[0].Name)
+		assert.Equal(t, "standard", diagnosis.UnboundClaims[0].StorageClass)
+
+		require.Len(t, diagnosis.StorageClasses, 2)
+		fast := diagnosis.StorageClasses[0]
+		assert.False(t, fast.Healthy)
+		require.NotNil(t, fast.CSIDriverRegistered)
+		assert.False(t, *fast.CSIDriverRegistered)
+		assert.Equal(t, 1, fast.PendingClaims)
+		assert.Equal(t, 1, fast.ProvisioningFailures)
+		assert.True(t, diagnosis.StorageClasses[1].Default)
+
+		require.Len(t, diagnosis.Events, 2)
+		assert.Equal(t, "FailedScheduling", diagnosis.Events[0].Reason)
+		assert.Equal(t, "persistentvolumeclaim/data-db-1", diagnosis.Events[1].Object)
+	})
+
+	t.Run("single statefulset", func(t *testing.T) {
+		ctx := cmd.WithShellExecutor(context.Background(), newStorageMock())
+
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{"namespace": "prod", "statefulset": "db", "event_limit": float64(1)}
+		result, err := newTestK8sTool().handleStorageDiagnose(ctx, req)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var diagnosis StorageDiagnosis
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &diagnosis))
+		assert.Empty(t, diagnosis.UnboundClaims)
+		assert.Equal(t, 3, diagnosis.Summary.Claims)
+		assert.Len(t, diagnosis.Events, 1)
+
+		req.Params.Arguments = map[string]interface{}{"namespace": "prod", "statefulset": "cache"}
+		result, err = newTestK8sTool().handleStorageDiagnose(ctx, req)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "not found")
+	})
+
+	t.Run("statefulset requires namespace", func(t *testing.T) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{"statefulset": "db"}
+		result, err := newTestK8sTool().handleStorageDiagnose(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("kubectl failure", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "statefulsets", "-o", "json", "--all-namespaces"}, "", assert.AnError)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleStorageDiagnose(ctx, mcp.CallToolRequest{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/blobs"
+	"github.com/kagent-dev/tools/internal/commands"
+)
+
+// KubectlOutputDir configures the directory kubectl output too large to
+// return inline is stored in. When unset, it is stored under the XDG data directory.
+const KubectlOutputDir = "KUBECTL_OUTPUT_DIR"
+
+// kubectlOutputBucket stores kubectl output too large to return inline
+var kubectlOutputBucket = blobs.Register(blobs.Bucket{Name: "kubectl-output", DirEnv: KubectlOutputDir, DefaultDir: "kagent-tools/kubectl-output"})
+
+const (
+	// maxInlineOutputBytes is the size of kubectl output above which tools
+	// return its beginning and a kubectl-output:// URI of the whole output
+	maxInlineOutputBytes = 1 << 20
+	// maxStoredOutputBytes caps the stored kubectl output; commands printing
+	// more are stopped and fail
+	maxStoredOutputBytes = 512 << 20
+)
+
+// KubectlOutput is the metadata of stored kubectl output
+type KubectlOutput struct {
+	ID        string    `json:"id"`
+	URI       string    `json:"uri"`
+	Args      []string  `json:"args"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// streamedOutput is the output of a kubectl command run by streamKubectl
+type streamedOutput struct {
+	// Head is the whole output, or its first inline limit bytes when it was stored
+	Head []byte
+	// Size is the size of the whole output
+	Size int64
+	// URI references the stored output; empty when the output fit inline
+	URI string
+}
+
+// streamKubectl runs a kubectl command, keeping at most inlineLimit bytes of
+// its output in memory. Larger output is streamed into the kubectl-output
+// store as it is produced, so kubectl is slowed down to the pace of the store
+// instead of the whole output being buffered.
+func (k *K8sTool) streamKubectl(ctx context.Context, inlineLimit int, args ...string) (*streamedOutput, error) {
+	metadata := KubectlOutput{Args: args, CreatedAt: time.Now().UTC()}
+	spill := blobs.NewSpill(ctx, blobs.Default(), kubectlOutputBucket, inlineLimit, maxStoredOutputBytes, func(info blobs.Info) interface{} {
+		metadata.ID, metadata.URI, metadata.Size, metadata.SHA256 = info.ID, info.URI, info.Size, info.SHA256
+		return metadata
+	})
+
+	err := commands.NewCommandBuilder("kubectl").
+		WithArgs(args...).
+		WithKubeconfig(k.kubeconfig).
+		Stream(ctx, spill)
+	if err != nil {
+		spill.Abort(err)
+		// kubectl fails with a broken pipe once the store stops accepting output
+		if spillErr := spill.Err(); spillErr != nil {
+			return nil, fmt.Errorf("failed to store kubectl output: %w", spillErr)
+		}
+		return nil, err
+	}
+
+	info, err := spill.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to store kubectl output: %w", err)
+	}
+	return &streamedOutput{Head: spill.Head(), Size: spill.Size(), URI: info.URI}, nil
+}
+
+// result returns the output as a tool result. Stored output is cut at the
+// last line break of its head and followed by a note with its URI.
+func (o *streamedOutput) result() *mcp.CallToolResult {
+	if o.URI == "" {
+		return mcp.NewToolResultText(string(o.Head))
+	}
+	head := o.Head
+	if i := bytes.LastIndexByte(head, '\n'); i > 0 {
+		head = head[:i+1]
+	}
+	return mcp.NewToolResultText(fmt.Sprintf(
+		"%s\n[Output truncated: showing the first %d of %d bytes. The full output is stored at %s; read it with fetch_blob, paging with offset and length.]",
+		head, len(head), o.Size, o.URI))
+}
@@ -0,0 +1,81 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/blobs"
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+var storedOutputURI = regexp.MustCompile(`kubectl-output://[0-9a-f]{32}`)
+
+func TestRunKubectlCommandLargeOutput(t *testing.T) {
+	previous := blobs.Default()
+	store := blobs.NewMemoryStore()
+	blobs.SetDefault(store)
+	defer blobs.SetDefault(previous)
+
+	line := `{"kind":"Event","message":"Back-off restarting failed container"}` + "\n"
+	large := strings.Repeat(line, maxInlineOutputBytes/len(line)+10)
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "events", "-o", "json", "--all-namespaces"}, large, nil)
+	mock.AddCommandString("kubectl", []string{"get", "events", "-o", "json", "-n", "prod"}, line, nil)
+	mock.AddCommandString("kubectl", []string{"get", "events", "-o", "json", "-n", "denied"}, "forbidden", errors.New("exit status 1"))
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	getEvents := func(namespace string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"namespace": namespace}
+		result, err := newTestK8sTool().handleGetEvents(ctx, request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("small output is returned inline", func(t *testing.T) {
+		result := getEvents("prod")
+		assert.False(t, result.IsError)
+		assert.Equal(t, line, getResultText(result))
+	})
+
+	t.Run("large output is truncated and stored", func(t *testing.T) {
+		result := getEvents("")
+		require.False(t, result.IsError, getResultText(result))
+		text := getResultText(result)
+		assert.Less(t, len(text), maxInlineOutputBytes+1024)
+		assert.True(t, strings.HasPrefix(text, line))
+		assert.Contains(t, text, "[Output truncated: showing the first")
+		assert.Contains(t, text, "fetch_blob")
+
+		uri := storedOutputURI.FindString(text)
+		require.NotEmpty(t, uri)
+		bucket, id, err := blobs.ParseURI(uri)
+		require.NoError(t, err)
+		var metadata KubectlOutput
+		stored, err := store.Read(context.Background(), bucket, id, &metadata)
+		require.NoError(t, err)
+		assert.Equal(t, large, string(stored))
+		assert.Equal(t, int64(len(large)), metadata.Size)
+	})
+
+	t.Run("failed commands return their error", func(t *testing.T) {
+		result := getEvents("denied")
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "forbidden")
+	})
+}
+
+func TestStreamedOutputResult(t *testing.T) {
+	output := &streamedOutput{Head: []byte("a\nb\npartial"), Size: 100, URI: "kubectl-output://0123"}
+	assert.Equal(t, "a\nb\n\n[Output truncated: showing the first 4 of 100 bytes. The full output is stored at kubectl-output://0123; read it with fetch_blob, paging with offset and length.]",
+		getResultText(output.result()))
+
+	output = &streamedOutput{Head: []byte("all of it"), Size: 9}
+	assert.Equal(t, "all of it", getResultText(output.result()))
+}
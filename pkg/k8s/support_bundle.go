@@ -0,0 +1,498 @@
+package k8s
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/blobs"
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// SupportBundleDir configures the directory support bundles are stored in.
+// When unset, they are stored under the XDG data directory.
+const SupportBundleDir = "SUPPORT_BUNDLE_DIR"
+
+// defaultSupportBundleDir is the directory name used under the XDG data directory
+const defaultSupportBundleDir = "kagent-tools/support-bundles"
+
+// supportBundleResourceScheme is the URI scheme of support bundles
+const supportBundleResourceScheme = "support-bundles://"
+
+// supportBundleBucket stores support bundles
+var supportBundleBucket = blobs.Register(blobs.Bucket{Name: "support-bundles", DirEnv: SupportBundleDir, DefaultDir: defaultSupportBundleDir})
+
+const (
+	defaultBundleLogSince  = time.Hour
+	defaultBundleTailLines = 1000
+	defaultBundleMaxBytes  = 100 << 20
+	// maxBundlePods bounds the pods whose logs are collected
+	maxBundlePods = 20
+)
+
+// Sections of a support bundle
+const (
+	BundleSectionResources = "resources"
+	BundleSectionEvents    = "events"
+	BundleSectionLogs      = "logs"
+	BundleSectionHelm      = "helm"
+	BundleSectionIstio     = "istio"
+	BundleSectionMetrics   = "metrics"
+)
+
+var bundleSections = []string{
+	BundleSectionResources, BundleSectionEvents, BundleSectionLogs,
+	BundleSectionHelm, BundleSectionIstio, BundleSectionMetrics,
+}
+
+// bundleResourceTypes are the namespaced resources included in a bundle.
+// Secrets are left out; Secret references in the other resources are kept.
+const bundleResourceTypes = "all,configmaps,ingresses,networkpolicies,poddisruptionbudgets,persistentvolumeclaims,serviceaccounts,roles,rolebindings"
+
+// bundleIstioResourceTypes are the Istio resources included in a bundle
+const bundleIstioResourceTypes = "virtualservices,destinationrules,gateways,serviceentries,sidecars,peerauthentications,authorizationpolicies"
+
+// bundleMetric is a Prometheus query snapshotted into a bundle
+type bundleMetric struct {
+	Name  string
+	Query func(namespace, service string, pods []string) string
+}
+
+// podMatchers returns the label matchers of the namespace's pods, or of the
+// service's pods when a service is given
+func podMatchers(namespace, service string, pods []string) string {
+	matchers := fmt.Sprintf(`namespace=%q`, namespace)
+	if service != "" {
+		quoted := make([]string, len(pods))
+		for i, pod := range pods {
+			quoted[i] = regexp.QuoteMeta(pod)
+		}
+		matchers += fmt.Sprintf(`,pod=~%q`, strings.Join(quoted, "|"))
+	}
+	return matchers
+}
+
+// bundleMetrics are the key metrics snapshotted into a bundle
+var bundleMetrics = []bundleMetric{
+	{"container_restarts", func(namespace, service string, pods []string) string {
+		return fmt.Sprintf(`sum by (pod, container) (kube_pod_container_status_restarts_total{%s})`, podMatchers(namespace, service, pods))
+	}},
+	{"cpu_usage", func(namespace, service string, pods []string) string {
+		return fmt.Sprintf(`sum by (pod) (rate(container_cpu_usage_seconds_total{%s,container!=""}[5m]))`, podMatchers(namespace, service, pods))
+	}},
+	{"memory_working_set", func(namespace, service string, pods []string) string {
+		return fmt.Sprintf(`sum by (pod) (container_memory_working_set_bytes{%s,container!=""})`, podMatchers(namespace, service, pods))
+	}},
+	{"request_rate", func(namespace, service string, pods []string) string {
+		matchers := fmt.Sprintf(`destination_service_namespace=%q`, namespace)
+		if service != "" {
+			matchers += fmt.Sprintf(`,destination_service_name=%q`, service)
+		}
+		return fmt.Sprintf(`sum by (destination_service_name, response_code) (rate(istio_requests_total{%s}[5m]))`, matchers)
+	}},
+}
+
+// BundleFile is a file of a support bundle, with the command or query that
+// produced it. Diagnostics that failed to collect are listed with their error.
+type BundleFile struct {
+	Path   string `json:"path"`
+	Source string `json:"source"`
+	Bytes  int    `json:"bytes"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SupportBundle describes a stored support bundle, a gzipped tar archive
+// whose manifest.json lists its files
+type SupportBundle struct {
+	ID        string       `json:"id"`
+	URI       string       `json:"uri"`
+	Namespace string       `json:"namespace"`
+	Service   string       `json:"service,omitempty"`
+	Sections  []string     `json:"sections"`
+	Size      int64        `json:"size"`
+	SHA256    string       `json:"sha256"`
+	Errors    int          `json:"errors"`
+	Notes     []string     `json:"notes,omitempty"`
+	Files     []BundleFile `json:"files"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// bundleCollector gathers the files of a support bundle
+type bundleCollector struct {
+	k         *K8sTool
+	namespace string
+	files     []BundleFile
+	contents  [][]byte
+	notes     []string
+}
+
+// add records a collected file, or the error collecting it
+func (c *bundleCollector) add(path, source, content string, err error) {
+	file := BundleFile{Path: path, Source: source}
+	if err != nil {
+		file.Error = err.Error()
+		// Commands that fail often still explain why on their output
+		content = strings.TrimSpace(content)
+	}
+	file.Bytes = len(content)
+	c.files = append(c.files, file)
+	c.contents = append(c.contents, []byte(content))
+}
+
+// run runs a command, records its output as a file and returns it
+func (c *bundleCollector) run(ctx context.Context, path, command string, args ...string) (string, error) {
+	output, err := commands.NewCommandBuilder(command).
+		WithArgs(args...).
+		WithKubeconfig(c.k.kubeconfig).
+		Execute(ctx)
+	c.add(path, command+" "+strings.Join(args, " "), output, err)
+	return output, err
+}
+
+// runYAML runs kubectl get -o yaml, recording the output without managedFields
+// and with Secret data redacted
+func (c *bundleCollector) runYAML(ctx context.Context, path string, args ...string) {
+	source := "kubectl " + strings.Join(args, " ")
+	output, err := c.k.runKubectlCommandString(ctx, args...)
+	if err == nil {
+		output, err = cleanResourceYAML(output, yamlCleanOptions{StripManagedFields: true, RedactSecrets: true})
+	}
+	c.add(path, source, output, err)
+}
+
+// bundlePod is a pod whose logs are collected
+type bundlePod struct {
+	Name       string
+	Containers []string
+	Restarted  map[string]bool
+}
+
+// parseBundlePods extracts the containers of pods listed as JSON
+func parseBundlePods(output string) ([]bundlePod, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				InitContainers []struct {
+					Name string `json:"name"`
+				} `json:"initContainers"`
+				Containers []struct {
+					Name string `json:"name"`
+				} `json:"containers"`
+			} `json:"spec"`
+			Status struct {
+				InitContainerStatuses []struct {
+					Name         string `json:"name"`
+					RestartCount int    `json:"restartCount"`
+				} `json:"initContainerStatuses"`
+				ContainerStatuses []struct {
+					Name         string `json:"name"`
+					RestartCount int    `json:"restartCount"`
+				} `json:"containerStatuses"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse pods: %w", err)
+	}
+
+	pods := make([]bundlePod, 0, len(list.Items))
+	for _, item := range list.Items {
+		pod := bundlePod{Name: item.Metadata.Name, Restarted: map[string]bool{}}
+		for _, container := range item.Spec.InitContainers {
+			pod.Containers = append(pod.Containers, container.Name)
+		}
+		for _, container := range item.Spec.Containers {
+			pod.Containers = append(pod.Containers, container.Name)
+		}
+		for _, status := range append(item.Status.InitContainerStatuses, item.Status.ContainerStatuses...) {
+			pod.Restarted[status.Name] = status.RestartCount > 0
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// serviceSelector returns the label selector of a service's pods
+func (k *K8sTool) serviceSelector(ctx context.Context, namespace, service string) (string, error) {
+	output, err := k.runKubectlCommandString(ctx, "get", "service", service, "-n", namespace, "-o", "json")
+	if err != nil {
+		return "", fmt.Errorf("failed to get service %s: %w", service, err)
+	}
+	var svc struct {
+		Spec struct {
+			Selector map[string]string `json:"selector"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal([]byte(output), &svc); err != nil {
+		return "", fmt.Errorf("failed to parse service %s: %w", service, err)
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return "", fmt.Errorf("service %s has no pod selector", service)
+	}
+
+	selector := make([]string, 0, len(svc.Spec.Selector))
+	for key, value := range svc.Spec.Selector {
+		selector = append(selector, key+"="+value)
+	}
+	sort.Strings(selector)
+	return strings.Join(selector, ","), nil
+}
+
+// collectLogs records the current and, for restarted containers, previous
+// logs of each pod
+func (c *bundleCollector) collectLogs(ctx context.Context, pods []bundlePod, since time.Duration, tailLines int) {
+	if len(pods) > maxBundlePods {
+		c.notes = append(c.notes, fmt.Sprintf("logs collected from %d of %d pods", maxBundlePods, len(pods)))
+		pods = pods[:maxBundlePods]
+	}
+	for _, pod := range pods {
+		for _, container := range pod.Containers {
+			args := []string{"logs", pod.Name, "-n", c.namespace, "-c", container, "--since", since.String(), "--tail", fmt.Sprintf("%d", tailLines)}
+			_, _ = c.run(ctx, fmt.Sprintf("logs/%s/%s.log", pod.Name, container), "kubectl", args...)
+			if pod.Restarted[container] {
+				_, _ = c.run(ctx, fmt.Sprintf("logs/%s/%s.previous.log", pod.Name, container), "kubectl", append(args, "--previous")...)
+			}
+		}
+	}
+}
+
+// collectHelm records the status and history of the namespace's releases
+func (c *bundleCollector) collectHelm(ctx context.Context) {
+	output, err := c.run(ctx, "helm/releases.json", "helm", "list", "-n", c.namespace, "-o", "json")
+	if err != nil {
+		return
+	}
+	var releases []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(output), &releases); err != nil {
+		c.add("helm/releases.error", "", "", fmt.Errorf("failed to parse releases: %w", err))
+		return
+	}
+	for _, release := range releases {
+		_, _ = c.run(ctx, fmt.Sprintf("helm/%s/status.txt", release.Name), "helm", "status", release.Name, "-n", c.namespace)
+		_, _ = c.run(ctx, fmt.Sprintf("helm/%s/history.txt", release.Name), "helm", "history", release.Name, "-n", c.namespace)
+	}
+}
+
+// collectMetrics records a snapshot of the key metrics from Prometheus
+func (c *bundleCollector) collectMetrics(ctx context.Context, prometheusURL, service string, pods []bundlePod) {
+	podNames := make([]string, len(pods))
+	for i, pod := range pods {
+		podNames[i] = pod.Name
+	}
+
+	for _, metric := range bundleMetrics {
+		query := metric.Query(c.namespace, service, podNames)
+		body, err := queryPrometheus(ctx, prometheusURL, query)
+		c.add(fmt.Sprintf("metrics/%s.json", metric.Name), query, string(body), err)
+	}
+}
+
+// queryPrometheus runs an instant query, returning the response body
+func queryPrometheus(ctx context.Context, prometheusURL, query string) ([]byte, error) {
+	values := url.Values{}
+	values.Add("query", query)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(prometheusURL, "/")+"/api/v1/query?"+values.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return body, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+// writeBundleArchive writes the manifest and files of a bundle as a gzipped tar archive
+func writeBundleArchive(w io.Writer, manifest []byte, files []BundleFile, contents [][]byte) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	write := func(name string, content []byte) error {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), ModTime: now}); err != nil {
+			return err
+		}
+		_, err := tw.Write(content)
+		return err
+	}
+
+	if err := write("manifest.json", manifest); err != nil {
+		return err
+	}
+	for i, file := range files {
+		if file.Error != "" && len(contents[i]) == 0 {
+			continue
+		}
+		if err := write(file.Path, contents[i]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Generate a support bundle of a namespace or service
+func (k *K8sTool) handleGenerateSupportBundle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	namespace := p.String("namespace", "", params.Required(), params.Check(security.ValidateNamespace))
+	service := p.String("service", "", params.Check(security.ValidateK8sResourceName))
+	sectionsParam := p.String("sections", "")
+	prometheusURL := p.String("prometheus_url", "", params.Check(security.ValidateURL))
+	since := p.Duration("since", defaultBundleLogSince)
+	tailLines := p.Int("tail_lines", defaultBundleTailLines, params.Min(1))
+	maxBytes := p.Int("max_bytes", defaultBundleMaxBytes, params.Min(1))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var sections []string
+	for _, section := range strings.Split(sectionsParam, ",") {
+		if section = strings.TrimSpace(section); section == "" {
+			continue
+		}
+		if !slices.Contains(bundleSections, section) {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown section %q, expected one of: %s", section, strings.Join(bundleSections, ", "))), nil
+		}
+		sections = append(sections, section)
+	}
+	if len(sections) == 0 {
+		sections = bundleSections
+	}
+	c := &bundleCollector{k: k, namespace: namespace}
+	if slices.Contains(sections, BundleSectionMetrics) && prometheusURL == "" {
+		if sectionsParam != "" {
+			return mcp.NewToolResultError("prometheus_url is required for the metrics section"), nil
+		}
+		sections = slices.DeleteFunc(slices.Clone(sections), func(s string) bool { return s == BundleSectionMetrics })
+		c.notes = append(c.notes, "metrics skipped because no prometheus_url was given")
+	}
+
+	// A service scopes the bundle to the pods it selects
+	podArgs := []string{"get", "pods", "-n", namespace, "-o", "json"}
+	if service != "" {
+		selector, err := k.serviceSelector(ctx, namespace, service)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		podArgs = append(podArgs, "-l", selector)
+	}
+
+	var pods []bundlePod
+	if slices.Contains(sections, BundleSectionLogs) || slices.Contains(sections, BundleSectionMetrics) {
+		output, err := k.runKubectlCommandString(ctx, podArgs...)
+		if err == nil {
+			pods, err = parseBundlePods(output)
+		}
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error listing pods: %v", err)), nil
+		}
+	}
+
+	for _, section := range sections {
+		switch section {
+		case BundleSectionResources:
+			c.runYAML(ctx, "resources/namespace.yaml", "get", "namespace", namespace, "-o", "yaml")
+			c.runYAML(ctx, "resources/resources.yaml", "get", bundleResourceTypes, "-n", namespace, "-o", "yaml")
+			if service != "" {
+				c.runYAML(ctx, "resources/endpoints.yaml", "get", "endpoints", service, "-n", namespace, "-o", "yaml")
+			}
+		case BundleSectionEvents:
+			_, _ = c.run(ctx, "events.txt", "kubectl", "get", "events", "-n", namespace, "--sort-by=.lastTimestamp", "-o", "wide")
+		case BundleSectionLogs:
+			c.collectLogs(ctx, pods, since, tailLines)
+		case BundleSectionHelm:
+			c.collectHelm(ctx)
+		case BundleSectionIstio:
+			c.runYAML(ctx, "istio/config.yaml", "get", bundleIstioResourceTypes, "-n", namespace, "-o", "yaml")
+			_, _ = c.run(ctx, "istio/analyze.txt", "istioctl", "analyze", "-n", namespace)
+		case BundleSectionMetrics:
+			c.collectMetrics(ctx, prometheusURL, service, pods)
+		}
+	}
+
+	bundle := SupportBundle{
+		Namespace: namespace,
+		Service:   service,
+		Sections:  sections,
+		Files:     c.files,
+		Notes:     c.notes,
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, file := range c.files {
+		if file.Error != "" {
+			bundle.Errors++
+		}
+	}
+	manifest, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal bundle manifest: %v", err)), nil
+	}
+
+	_, err = blobs.Default().Write(ctx, supportBundleBucket, int64(maxBytes), func(w io.Writer) error {
+		return writeBundleArchive(w, manifest, c.files, c.contents)
+	}, func(info blobs.Info) interface{} {
+		bundle.ID, bundle.URI, bundle.Size, bundle.SHA256 = info.ID, info.URI, info.Size, info.SHA256
+		return bundle
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to store support bundle: %v", err)), nil
+	}
+
+	bundleJSON, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal support bundle: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(bundleJSON)), nil
+}
+
+// handleReadSupportBundleResource serves a stored support bundle as a blob resource
+func (k *K8sTool) handleReadSupportBundleResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	id := strings.TrimPrefix(request.Params.URI, supportBundleResourceScheme)
+	if id == request.Params.URI {
+		return nil, fmt.Errorf("invalid support bundle URI: %s", request.Params.URI)
+	}
+
+	var bundle SupportBundle
+	content, err := blobs.Default().Read(ctx, supportBundleBucket, id, &bundle)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.BlobResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/gzip",
+			Blob:     base64.StdEncoding.EncodeToString(content),
+		},
+	}, nil
+}
@@ -0,0 +1,173 @@
+package k8s
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBundlePods = `{"items":[
+{"metadata":{"name":"web-1"},"spec":{"containers":[{"name":"app"},{"name":"istio-proxy"}]},
+ "status":{"containerStatuses":[{"name":"app","restartCount":2},{"name":"istio-proxy","restartCount":0}]}}
+]}`
+
+// readBundleArchive returns the files of a bundle archive by path
+func readBundleArchive(t *testing.T, content []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(content))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[header.Name] = string(data)
+	}
+}
+
+func TestParseBundlePods(t *testing.T) {
+	pods, err := parseBundlePods(testBundlePods)
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.Equal(t, []string{"app", "istio-proxy"}, pods[0].Containers)
+	assert.True(t, pods[0].Restarted["app"])
+	assert.False(t, pods[0].Restarted["istio-proxy"])
+
+	_, err = parseBundlePods("not json")
+	assert.Error(t, err)
+}
+
+func TestPodMatchers(t *testing.T) {
+	assert.Equal(t, `namespace="prod"`, podMatchers("prod", "", []string{"web-1"}))
+	assert.Equal(t, `namespace="prod",pod=~"web-1|web\\.2"`, podMatchers("prod", "web", []string{"web-1", "web.2"}))
+}
+
+func TestHandleGenerateSupportBundle(t *testing.T) {
+	t.Setenv(SupportBundleDir, t.TempDir())
+
+	var queries []string
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer prometheus.Close()
+
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "service", "web", "-n", "prod", "-o", "json"}, `{"spec":{"selector":{"tier":"frontend","app":"web"}}}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "pods", "-n", "prod", "-o", "json", "-l", "app=web,tier=frontend"}, testBundlePods, nil)
+	mock.AddCommandString("kubectl", []string{"get", "namespace", "prod", "-o", "yaml"}, "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: prod\n", nil)
+	mock.AddCommandString("kubectl", []string{"get", bundleResourceTypes, "-n", "prod", "-o", "yaml"}, "apiVersion: v1\nitems: []\nkind: List\n", nil)
+	mock.AddCommandString("kubectl", []string{"get", "endpoints", "web", "-n", "prod", "-o", "yaml"}, "apiVersion: v1\nkind: Endpoints\n", nil)
+	mock.AddCommandString("kubectl", []string{"get", "events", "-n", "prod", "--sort-by=.lastTimestamp", "-o", "wide"}, "LAST SEEN   TYPE      REASON\n", nil)
+	mock.AddCommandString("kubectl", []string{"logs", "web-1", "-n", "prod", "-c", "app", "--since", "30m0s", "--tail", "100"}, "started\n", nil)
+	mock.AddCommandString("kubectl", []string{"logs", "web-1", "-n", "prod", "-c", "app", "--since", "30m0s", "--tail", "100", "--previous"}, "panic: boom\n", nil)
+	mock.AddCommandString("kubectl", []string{"logs", "web-1", "-n", "prod", "-c", "istio-proxy", "--since", "30m0s", "--tail", "100"}, "envoy ready\n", nil)
+	mock.AddCommandString("helm", []string{"list", "-n", "prod", "-o", "json"}, `[{"name":"web"}]`, nil)
+	mock.AddCommandString("helm", []string{"status", "web", "-n", "prod"}, "STATUS: deployed\n", nil)
+	mock.AddCommandString("helm", []string{"history", "web", "-n", "prod"}, "REVISION  STATUS\n", nil)
+	mock.AddCommandString("istioctl", []string{"analyze", "-n", "prod"}, "", errors.New("istioctl: not found"))
+	mock.AddPartialMatcherString("kubectl", []string{"get", bundleIstioResourceTypes}, "", errors.New(`the server doesn't have a resource type "virtualservices"`))
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	k8sTool := newTestK8sTool()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"namespace":      "prod",
+		"service":        "web",
+		"prometheus_url": prometheus.URL,
+		"since":          "30m",
+		"tail_lines":     float64(100),
+	}
+	result, err := k8sTool.handleGenerateSupportBundle(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var bundle SupportBundle
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &bundle))
+	assert.Equal(t, bundleSections, bundle.Sections)
+	assert.Equal(t, 2, bundle.Errors)
+	assert.Equal(t, supportBundleResourceScheme+bundle.ID, bundle.URI)
+	require.Len(t, queries, len(bundleMetrics))
+	assert.Contains(t, queries[0], `pod=~"web-1"`)
+
+	resource := mcp.ReadResourceRequest{}
+	resource.Params.URI = bundle.URI
+	contents, err := k8sTool.handleReadSupportBundleResource(ctx, resource)
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	archive, err := base64.StdEncoding.DecodeString(contents[0].(mcp.BlobResourceContents).Blob)
+	require.NoError(t, err)
+
+	files := readBundleArchive(t, archive)
+	assert.Equal(t, "panic: boom\n", files["logs/web-1/app.previous.log"])
+	assert.Equal(t, "STATUS: deployed\n", files["helm/web/status.txt"])
+	assert.Contains(t, files, "metrics/container_restarts.json")
+	assert.NotContains(t, files, "istio/analyze.txt")
+	var manifest SupportBundle
+	require.NoError(t, json.Unmarshal([]byte(files["manifest.json"]), &manifest))
+	assert.Len(t, manifest.Files, len(bundle.Files))
+}
+
+func TestHandleGenerateSupportBundleOptions(t *testing.T) {
+	t.Setenv(SupportBundleDir, t.TempDir())
+
+	t.Run("metrics skipped without prometheus", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "events", "-n", "prod", "--sort-by=.lastTimestamp", "-o", "wide"}, "No resources found\n", nil)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"namespace": "prod", "sections": "events"}
+		result, err := newTestK8sTool().handleGenerateSupportBundle(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var bundle SupportBundle
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &bundle))
+		assert.Equal(t, []string{BundleSectionEvents}, bundle.Sections)
+		assert.Len(t, mock.GetCallLog(), 1)
+	})
+
+	t.Run("over the cap", func(t *testing.T) {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "events", "-n", "prod", "--sort-by=.lastTimestamp", "-o", "wide"}, "No resources found\n", nil)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"namespace": "prod", "sections": "events", "max_bytes": float64(10)}
+		result, err := newTestK8sTool().handleGenerateSupportBundle(cmd.WithShellExecutor(context.Background(), mock), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "exceeds max_bytes")
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, arguments := range []map[string]interface{}{
+			{},
+			{"namespace": "prod", "sections": "events,secrets"},
+			{"namespace": "prod", "sections": "metrics"},
+			{"namespace": "prod", "service": "web;rm"},
+		} {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = arguments
+			result, err := newTestK8sTool().handleGenerateSupportBundle(context.Background(), request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError, arguments)
+		}
+	})
+}
@@ -0,0 +1,336 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Well-known topology labels of nodes
+const (
+	zoneLabel       = "topology.kubernetes.io/zone"
+	legacyZoneLabel = "failure-domain.beta.kubernetes.io/zone"
+	hostnameLabel   = "kubernetes.io/hostname"
+)
+
+// topologyWorkloadKinds are the replicated workload kinds accepted by the topology report
+var topologyWorkloadKinds = []string{"deployment", "statefulset", "replicaset"}
+
+// topologyWorkload is the subset of a Deployment, StatefulSet or ReplicaSet used by the topology report
+type topologyWorkload struct {
+	Spec struct {
+		Replicas *int          `json:"replicas"`
+		Selector labelSelector `json:"selector"`
+		Template struct {
+			Metadata struct {
+				Labels map[string]string `json:"labels"`
+			} `json:"metadata"`
+			Spec struct {
+				TopologySpreadConstraints []struct {
+					TopologyKey string `json:"topologyKey"`
+				} `json:"topologySpreadConstraints"`
+				Affinity struct {
+					PodAntiAffinity *struct {
+						Required []struct {
+							TopologyKey string `json:"topologyKey"`
+						} `json:"requiredDuringSchedulingIgnoredDuringExecution"`
+						Preferred []struct {
+							PodAffinityTerm struct {
+								TopologyKey string `json:"topologyKey"`
+							} `json:"podAffinityTerm"`
+						} `json:"preferredDuringSchedulingIgnoredDuringExecution"`
+					} `json:"podAntiAffinity"`
+				} `json:"affinity"`
+			} `json:"spec"`
+		} `json:"template"`
+	} `json:"spec"`
+}
+
+// topologyPodList is the subset of `kubectl get pods -o json` output used by the topology report
+type topologyPodList struct {
+	Items []struct {
+		Metadata struct {
+			Name              string            `json:"name"`
+			Labels            map[string]string `json:"labels"`
+			DeletionTimestamp string            `json:"deletionTimestamp"`
+		} `json:"metadata"`
+		Spec struct {
+			NodeName string `json:"nodeName"`
+		} `json:"spec"`
+		Status struct {
+			Phase string `json:"phase"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// topologyNodeList is the subset of `kubectl get nodes -o json` output used by the topology report
+type topologyNodeList struct {
+	Items []struct {
+		Metadata struct {
+			Name   string            `json:"name"`
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+		Spec struct {
+			Unschedulable bool `json:"unschedulable"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// TopologyDomain is a node or zone and the workload's pods scheduled in it
+type TopologyDomain struct {
+	Name string   `json:"name"`
+	Pods []string `json:"pods"`
+}
+
+// TopologyFinding is an availability gap in how a workload's pods are spread
+type TopologyFinding struct {
+	Rule        string `json:"rule"`
+	Severity    string `json:"severity"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation"`
+}
+
+// TopologyReport is the structured response of k8s_topology_report
+type TopologyReport struct {
+	Workload        string           `json:"workload"`
+	Namespace       string           `json:"namespace"`
+	DesiredReplicas int              `json:"desired_replicas"`
+	ScheduledPods   int              `json:"scheduled_pods"`
+	PendingPods     []string         `json:"pending_pods,omitempty"`
+	Nodes           []TopologyDomain `json:"nodes"`
+	Zones           []TopologyDomain `json:"zones,omitempty"`
+	// ClusterNodes and ClusterZones count the schedulable nodes and their zones
+	ClusterNodes int `json:"cluster_nodes"`
+	ClusterZones int `json:"cluster_zones"`
+	// SpreadKeys are the topology keys of the workload's spread constraints and pod anti-affinity
+	SpreadKeys []string          `json:"spread_keys,omitempty"`
+	Findings   []TopologyFinding `json:"findings"`
+	// SuggestedPatch adds topology spread constraints when the workload has none
+	SuggestedPatch *PatchSuggestion `json:"suggested_patch,omitempty"`
+}
+
+// nodeZone returns the zone label of a node, or an empty string
+func nodeZone(labels map[string]string) string {
+	if zone := labels[zoneLabel]; zone != "" {
+		return zone
+	}
+	return labels[legacyZoneLabel]
+}
+
+// spreadKeys returns the topology keys pods of the workload are spread over
+func (w topologyWorkload) spreadKeys() []string {
+	var keys []string
+	add := func(key string) {
+		if key != "" && !containsString(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	spec := w.Spec.Template.Spec
+	for _, constraint := range spec.TopologySpreadConstraints {
+		add(constraint.TopologyKey)
+	}
+	if antiAffinity := spec.Affinity.PodAntiAffinity; antiAffinity != nil {
+		for _, term := range antiAffinity.Required {
+			add(term.TopologyKey)
+		}
+		for _, term := range antiAffinity.Preferred {
+			add(term.PodAffinityTerm.TopologyKey)
+		}
+	}
+	return keys
+}
+
+// domains groups pods by domain, sorted by name
+func domains(podsByDomain map[string][]string) []TopologyDomain {
+	result := make([]TopologyDomain, 0, len(podsByDomain))
+	for name, pods := range podsByDomain {
+		sort.Strings(pods)
+		result = append(result, TopologyDomain{Name: name, Pods: pods})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// spreadPatch returns a strategic merge patch adding topology spread
+// constraints over zones, when the cluster has several, and nodes. Pods that
+// cannot be spread are still scheduled, so the patch never blocks a rollout.
+func spreadPatch(kind string, podLabels map[string]string, multiZone bool) (string, error) {
+	keys := []string{hostnameLabel}
+	if multiZone {
+		keys = []string{zoneLabel, hostnameLabel}
+	}
+	constraints := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		constraints = append(constraints, map[string]interface{}{
+			"maxSkew":           1,
+			"topologyKey":       key,
+			"whenUnsatisfiable": "ScheduleAnyway",
+			"labelSelector":     map[string]interface{}{"matchLabels": podLabels},
+		})
+	}
+	patch := podSpecPatch{spec: map[string]interface{}{"topologySpreadConstraints": constraints}}
+	return patch.render(kind)
+}
+
+// buildTopologyReport maps a workload's pods to nodes and zones and flags concentration in one failure domain
+func buildTopologyReport(kind, name, namespace string, workload topologyWorkload, pods topologyPodList, nodes topologyNodeList) (*TopologyReport, error) {
+	report := &TopologyReport{Workload: kind + "/" + name, Namespace: namespace, Findings: []TopologyFinding{}}
+	report.DesiredReplicas = 1
+	if workload.Spec.Replicas != nil {
+		report.DesiredReplicas = *workload.Spec.Replicas
+	}
+	report.SpreadKeys = workload.spreadKeys()
+
+	zoneOfNode := map[string]string{}
+	clusterZones := map[string]bool{}
+	for _, node := range nodes.Items {
+		zone := nodeZone(node.Metadata.Labels)
+		zoneOfNode[node.Metadata.Name] = zone
+		if node.Spec.Unschedulable {
+			continue
+		}
+		report.ClusterNodes++
+		if zone != "" {
+			clusterZones[zone] = true
+		}
+	}
+	report.ClusterZones = len(clusterZones)
+
+	podsByNode := map[string][]string{}
+	podsByZone := map[string][]string{}
+	for _, pod := range pods.Items {
+		if pod.Metadata.DeletionTimestamp != "" || pod.Status.Phase == "Succeeded" || pod.Status.Phase == "Failed" {
+			continue
+		}
+		if !workload.Spec.Selector.matches(pod.Metadata.Labels) {
+			continue
+		}
+		if pod.Spec.NodeName == "" {
+			report.PendingPods = append(report.PendingPods, pod.Metadata.Name)
+			continue
+		}
+		report.ScheduledPods++
+		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod.Metadata.Name)
+		if zone := zoneOfNode[pod.Spec.NodeName]; zone != "" {
+			podsByZone[zone] = append(podsByZone[zone], pod.Metadata.Name)
+		}
+	}
+	sort.Strings(report.PendingPods)
+	report.Nodes = domains(podsByNode)
+	if len(podsByZone) > 0 {
+		report.Zones = domains(podsByZone)
+	}
+
+	add := func(rule, severity, message, remediation string) {
+		report.Findings = append(report.Findings, TopologyFinding{Rule: rule, Severity: severity, Message: message, Remediation: remediation})
+	}
+	if report.DesiredReplicas == 1 {
+		add("single_replica", severityMedium, "Workload runs a single replica, so any node or zone failure takes it down",
+			"Scale to at least 2 replicas, or 3 to tolerate a zone failure during a rollout")
+	}
+	if report.ScheduledPods > 1 && len(report.Nodes) == 1 && report.ClusterNodes > 1 {
+		add("single_node", severityHigh, fmt.Sprintf("All %d pods run on node %s, so a node failure or drain takes the workload down", report.ScheduledPods, report.Nodes[0].Name),
+			"Spread the pods over nodes with a topology spread constraint or pod anti-affinity on "+hostnameLabel)
+	}
+	if report.ScheduledPods > 1 && len(report.Zones) == 1 && report.ClusterZones > 1 {
+		add("single_zone", severityHigh, fmt.Sprintf("All %d pods run in zone %s although the cluster spans %d zones, so a zone outage takes the workload down", report.ScheduledPods, report.Zones[0].Name, report.ClusterZones),
+			"Spread the pods over zones with a topology spread constraint on "+zoneLabel)
+	} else if len(report.Zones) > 1 {
+		fewest, most := report.Zones[0], report.Zones[0]
+		for _, zone := range report.Zones[1:] {
+			if len(zone.Pods) < len(fewest.Pods) {
+				fewest = zone
+			}
+			if len(zone.Pods) > len(most.Pods) {
+				most = zone
+			}
+		}
+		if len(most.Pods)*2 > report.ScheduledPods && len(most.Pods)-len(fewest.Pods) > 1 {
+			add("zone_skew", severityMedium, fmt.Sprintf("%d of %d pods run in zone %s, so losing it takes down most of the capacity", len(most.Pods), report.ScheduledPods, most.Name),
+				"Add a topology spread constraint on "+zoneLabel+" with maxSkew 1 and restart the workload to rebalance it")
+		}
+	}
+	if report.DesiredReplicas > 1 && len(report.SpreadKeys) == 0 {
+		add("missing_spread", severityMedium, "Workload has neither topologySpreadConstraints nor pod anti-affinity, so the scheduler may place all replicas in one failure domain",
+			"Add topology spread constraints over zones and nodes; the suggested patch uses whenUnsatisfiable ScheduleAnyway so pods are still scheduled when they cannot be spread")
+
+		podLabels := workload.Spec.Selector.MatchLabels
+		if len(podLabels) == 0 {
+			podLabels = workload.Spec.Template.Metadata.Labels
+		}
+		patch, err := spreadPatch(kind, podLabels, report.ClusterZones > 1)
+		if err != nil {
+			return nil, err
+		}
+		report.SuggestedPatch = &PatchSuggestion{ResourceType: kind, ResourceName: name, Namespace: namespace, Patch: patch}
+	}
+
+	rank := map[string]int{severityHigh: 0, severityMedium: 1, severityLow: 2}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return rank[report.Findings[i].Severity] < rank[report.Findings[j].Severity]
+	})
+	return report, nil
+}
+
+// Topology report
+func (k *K8sTool) handleTopologyReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	ref := p.String("workload", "", params.Required())
+	namespace := p.String("namespace", "default", params.Check(security.ValidateNamespace))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	kind, name, found := strings.Cut(ref, "/")
+	kind = strings.ToLower(kind)
+	if !found || !containsString(topologyWorkloadKinds, kind) {
+		return mcp.NewToolResultError(fmt.Sprintf("workload must be kind/name with kind one of: %s", strings.Join(topologyWorkloadKinds, ", "))), nil
+	}
+	if err := security.ValidateK8sResourceName(name); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output, err := k.runKubectlCommandString(ctx, "get", kind, name, "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get %s/%s: %v", kind, name, err)), nil
+	}
+	var workload topologyWorkload
+	if err := json.Unmarshal([]byte(output), &workload); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse %s/%s: %v", kind, name, err)), nil
+	}
+
+	output, err = k.runKubectlCommandString(ctx, "get", "pods", "-n", namespace, "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get pods: %v", err)), nil
+	}
+	var pods topologyPodList
+	if err := json.Unmarshal([]byte(output), &pods); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse pods: %v", err)), nil
+	}
+
+	output, err = k.runKubectlCommandString(ctx, "get", "nodes", "-o", "json")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get nodes: %v", err)), nil
+	}
+	var nodes topologyNodeList
+	if err := json.Unmarshal([]byte(output), &nodes); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse nodes: %v", err)), nil
+	}
+
+	report, err := buildTopologyReport(kind, name, namespace, workload, pods, nodes)
+	if err != nil {
+		return mcp.NewToolResultError("Error building topology spread patch: " + err.Error()), nil
+	}
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling topology report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
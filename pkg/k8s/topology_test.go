@@ -0,0 +1,143 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTopologyNodes = `{"items":[
+ {"metadata":{"name":"node-a1","labels":{"topology.kubernetes.io/zone":"zone-a"}}},
+ {"metadata":{"name":"node-a2","labels":{"topology.kubernetes.io/zone":"zone-a"}}},
+ {"metadata":{"name":"node-b1","labels":{"failure-domain.beta.kubernetes.io/zone":"zone-b"}}},
+ {"metadata":{"name":"node-c1","labels":{"topology.kubernetes.io/zone":"zone-c"}},"spec":{"unschedulable":true}}]}`
+
+const testTopologyPods = `{"items":[
+ {"metadata":{"name":"web-1","labels":{"app":"web"}},"spec":{"nodeName":"node-a1"},"status":{"phase":"Running"}},
+ {"metadata":{"name":"web-2","labels":{"app":"web"}},"spec":{"nodeName":"node-a1"},"status":{"phase":"Running"}},
+ {"metadata":{"name":"web-3","labels":{"app":"web"}},"spec":{"nodeName":"node-a1"},"status":{"phase":"Running"}},
+ {"metadata":{"name":"web-4","labels":{"app":"web"}},"status":{"phase":"Pending"}},
+ {"metadata":{"name":"web-old","labels":{"app":"web"},"deletionTimestamp":"2024-05-01T10:00:00Z"},"spec":{"nodeName":"node-b1"},"status":{"phase":"Running"}},
+ {"metadata":{"name":"db-1","labels":{"app":"db"}},"spec":{"nodeName":"node-b1"},"status":{"phase":"Running"}}]}`
+
+const testUnspreadDeployment = `{"spec":{"replicas":4,"selector":{"matchLabels":{"app":"web"}},
+ "template":{"metadata":{"labels":{"app":"web","version":"v2"}},"spec":{"containers":[{"name":"web","image":"web:1.0"}]}}}}`
+
+const testSpreadDeployment = `{"spec":{"replicas":4,"selector":{"matchLabels":{"app":"web"}},
+ "template":{"metadata":{"labels":{"app":"web"}},"spec":{
+  "topologySpreadConstraints":[{"maxSkew":1,"topologyKey":"topology.kubernetes.io/zone","whenUnsatisfiable":"DoNotSchedule","labelSelector":{"matchLabels":{"app":"web"}}}],
+  "affinity":{"podAntiAffinity":{"preferredDuringSchedulingIgnoredDuringExecution":[{"weight":100,"podAffinityTerm":{"topologyKey":"kubernetes.io/hostname"}}]}}}}}}`
+
+func TestHandleTopologyReport(t *testing.T) {
+	report := func(t *testing.T, workload, pods string) TopologyReport {
+		mock := cmd.NewMockShellExecutor()
+		mock.AddCommandString("kubectl", []string{"get", "deployment", "web", "-n", "shop", "-o", "json"}, workload, nil)
+		mock.AddCommandString("kubectl", []string{"get", "pods", "-n", "shop", "-o", "json"}, pods, nil)
+		mock.AddCommandString("kubectl", []string{"get", "nodes", "-o", "json"}, testTopologyNodes, nil)
+		ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+		result, err := newTestK8sTool().handleTopologyReport(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"workload": "deployment/web", "namespace": "shop",
+		}}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report TopologyReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		return report
+	}
+	rules := func(report TopologyReport) []string {
+		var names []string
+		for _, finding := range report.Findings {
+			names = append(names, finding.Rule)
+		}
+		return names
+	}
+
+	t.Run("pods concentrated on one node", func(t *testing.T) {
+		report := report(t, testUnspreadDeployment, testTopologyPods)
+		assert.Equal(t, 4, report.DesiredReplicas)
+		assert.Equal(t, 3, report.ScheduledPods)
+		assert.Equal(t, []string{"web-4"}, report.PendingPods)
+		assert.Equal(t, []TopologyDomain{{Name: "node-a1", Pods: []string{"web-1", "web-2", "web-3"}}}, report.Nodes)
+		assert.Equal(t, []TopologyDomain{{Name: "zone-a", Pods: []string{"web-1", "web-2", "web-3"}}}, report.Zones)
+		assert.Equal(t, 3, report.ClusterNodes)
+		assert.Equal(t, 2, report.ClusterZones)
+		assert.Equal(t, []string{"single_node", "single_zone", "missing_spread"}, rules(report))
+
+		require.NotNil(t, report.SuggestedPatch)
+		assert.Equal(t, "deployment", report.SuggestedPatch.ResourceType)
+		assert.Equal(t, "web", report.SuggestedPatch.ResourceName)
+		assert.Equal(t, "shop", report.SuggestedPatch.Namespace)
+		var patch struct {
+			Spec struct {
+				Template struct {
+					Spec struct {
+						TopologySpreadConstraints []struct {
+							TopologyKey       string        `json:"topologyKey"`
+							WhenUnsatisfiable string        `json:"whenUnsatisfiable"`
+							LabelSelector     labelSelector `json:"labelSelector"`
+						} `json:"topologySpreadConstraints"`
+					} `json:"spec"`
+				} `json:"template"`
+			} `json:"spec"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(report.SuggestedPatch.Patch), &patch))
+		constraints := patch.Spec.Template.Spec.TopologySpreadConstraints
+		require.Len(t, constraints, 2)
+		assert.Equal(t, zoneLabel, constraints[0].TopologyKey)
+		assert.Equal(t, hostnameLabel, constraints[1].TopologyKey)
+		assert.Equal(t, "ScheduleAnyway", constraints[0].WhenUnsatisfiable)
+		assert.Equal(t, map[string]string{"app": "web"}, constraints[0].LabelSelector.MatchLabels)
+	})
+
+	t.Run("spread workload", func(t *testing.T) {
+		pods := `{"items":[
+ {"metadata":{"name":"web-1","labels":{"app":"web"}},"spec":{"nodeName":"node-a1"},"status":{"phase":"Running"}},
+ {"metadata":{"name":"web-2","labels":{"app":"web"}},"spec":{"nodeName":"node-a2"},"status":{"phase":"Running"}},
+ {"metadata":{"name":"web-3","labels":{"app":"web"}},"spec":{"nodeName":"node-b1"},"status":{"phase":"Running"}}]}`
+		report := report(t, testSpreadDeployment, pods)
+		assert.Equal(t, []string{zoneLabel, hostnameLabel}, report.SpreadKeys)
+		assert.Len(t, report.Nodes, 3)
+		assert.Len(t, report.Zones, 2)
+		assert.Empty(t, report.Findings)
+		assert.Nil(t, report.SuggestedPatch)
+	})
+
+	t.Run("zone skew", func(t *testing.T) {
+		pods := `{"items":[
+ {"metadata":{"name":"web-1","labels":{"app":"web"}},"spec":{"nodeName":"node-a1"},"status":{"phase":"Running"}},
+ {"metadata":{"name":"web-2","labels":{"app":"web"}},"spec":{"nodeName":"node-a2"},"status":{"phase":"Running"}},
+ {"metadata":{"name":"web-3","labels":{"app":"web"}},"spec":{"nodeName":"node-a2"},"status":{"phase":"Running"}},
+ {"metadata":{"name":"web-4","labels":{"app":"web"}},"spec":{"nodeName":"node-b1"},"status":{"phase":"Running"}}]}`
+		report := report(t, testSpreadDeployment, pods)
+		assert.Equal(t, []string{"zone_skew"}, rules(report))
+	})
+
+	t.Run("single replica", func(t *testing.T) {
+		workload := `{"spec":{"replicas":1,"selector":{"matchLabels":{"app":"web"}},"template":{"metadata":{"labels":{"app":"web"}}}}}`
+		pods := `{"items":[{"metadata":{"name":"web-1","labels":{"app":"web"}},"spec":{"nodeName":"node-a1"},"status":{"phase":"Running"}}]}`
+		report := report(t, workload, pods)
+		assert.Equal(t, []string{"single_replica"}, rules(report))
+		assert.Nil(t, report.SuggestedPatch)
+	})
+}
+
+func TestHandleTopologyReportInvalidWorkload(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	for _, workload := range []string{"web", "daemonset/agent", "deployment/Web_1"} {
+		result, err := newTestK8sTool().handleTopologyReport(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]interface{}{
+			"workload": workload,
+		}}})
+		require.NoError(t, err)
+		assert.True(t, result.IsError, workload)
+	}
+	assert.Empty(t, mock.GetCallLog())
+}
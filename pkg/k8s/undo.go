@@ -0,0 +1,316 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/cache"
+	"github.com/kagent-dev/tools/internal/changes"
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// defaultChangeHistoryLimit is the number of changes change_history returns by default
+const defaultChangeHistoryLimit = 20
+
+// priorState reads a resource a tool is about to change and returns the
+// change to record, holding the manifest to restore to undo it
+func (k *K8sTool) priorState(ctx context.Context, resourceType, name, namespace string) changes.Change {
+	change := changes.Change{Kind: resourceType, Name: name, Namespace: namespace}
+	args := []string{"get", resourceType, name, "--ignore-not-found", "-o", "json"}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	output, err := k.runKubectlCommandString(ctx, args...)
+	if err != nil {
+		change.Prior, change.Note = changes.PriorNone, fmt.Sprintf("the resource could not be read before the change: %v", err)
+		return change
+	}
+	if strings.TrimSpace(output) == "" {
+		change.Prior = changes.PriorAbsent
+		return change
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &object); err != nil {
+		change.Prior, change.Note = changes.PriorNone, fmt.Sprintf("the resource could not be parsed before the change: %v", err)
+		return change
+	}
+	return priorFromObject(change, object)
+}
+
+// priorFromObject completes the change of a resource with the manifest of
+// the object read before the change
+func priorFromObject(change changes.Change, object map[string]interface{}) changes.Change {
+	if kind, _ := object["kind"].(string); kind != "" {
+		change.Kind = kind
+	}
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		change.Namespace, _ = metadata["namespace"].(string)
+	}
+	// Secret values are never written to the change history
+	if change.Kind == "Secret" {
+		change.Prior, change.Note = changes.PriorNone, "the contents of Secrets are not recorded"
+		return change
+	}
+
+	changes.CleanManifest(object)
+	manifest, err := json.Marshal(object)
+	if err != nil {
+		change.Prior, change.Note = changes.PriorNone, fmt.Sprintf("the resource could not be encoded: %v", err)
+		return change
+	}
+	change.Prior, change.Manifest = changes.PriorManifest, string(manifest)
+	return change
+}
+
+// priorReplicas returns the change of a scale, holding the replica count to restore
+func (k *K8sTool) priorReplicas(ctx context.Context, resourceType, name, namespace string) changes.Change {
+	change := k.priorState(ctx, resourceType, name, namespace)
+	if change.Prior != changes.PriorManifest {
+		return change
+	}
+	var object struct {
+		Spec struct {
+			Replicas *int `json:"replicas"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal([]byte(change.Manifest), &object); err != nil {
+		return change
+	}
+	// The API server defaults unset replicas to 1
+	replicas := 1
+	if object.Spec.Replicas != nil {
+		replicas = *object.Spec.Replicas
+	}
+	change.Prior, change.Replicas, change.Manifest = changes.PriorReplicas, &replicas, ""
+	return change
+}
+
+// manifestPriorStates reads the prior state of every resource of a manifest,
+// or returns nil when the manifest cannot be parsed, leaving kubectl to report it
+func (k *K8sTool) manifestPriorStates(ctx context.Context, manifest string) []changes.Change {
+	resources, err := parseManifestResources(manifest)
+	if err != nil {
+		return nil
+	}
+	priors := make([]changes.Change, 0, len(resources))
+	for _, resource := range resources {
+		prior := k.priorState(ctx, resource.kind, resource.name, resource.namespace)
+		if prior.Prior == changes.PriorAbsent {
+			prior.Kind = resource.kind
+		}
+		priors = append(priors, prior)
+	}
+	return priors
+}
+
+// createdResources returns the changes of creating the resources of a
+// manifest, which did not exist before
+func createdResources(manifest string) []changes.Change {
+	resources, err := parseManifestResources(manifest)
+	if err != nil {
+		return nil
+	}
+	created := make([]changes.Change, 0, len(resources))
+	for _, resource := range resources {
+		created = append(created, changes.Change{Kind: resource.kind, Name: resource.name, Namespace: resource.namespace, Prior: changes.PriorAbsent})
+	}
+	return created
+}
+
+// recordChanges records the changes of a tool call once it has succeeded
+func recordChanges(ctx context.Context, result *mcp.CallToolResult, tool, summary string, priors ...changes.Change) {
+	if result == nil || result.IsError {
+		return
+	}
+	for _, prior := range priors {
+		prior.Tool, prior.Summary = tool, summary
+		changes.Record(ctx, prior)
+	}
+}
+
+// UndoResult is the response of undo_last_change
+type UndoResult struct {
+	Change changes.Change `json:"change"`
+	// Action is what restored the prior state, e.g. "replace" or "helm rollback"
+	Action string `json:"action"`
+	DryRun bool   `json:"dry_run,omitempty"`
+	Output string `json:"output,omitempty"`
+}
+
+// undoArgs returns the command restoring the prior state of a change, the
+// manifest it needs in a file, and the name of the action
+func (k *K8sTool) undoArgs(ctx context.Context, change changes.Change) (string, []string, map[string]interface{}, string, error) {
+	var namespaceArgs []string
+	if change.Namespace != "" {
+		namespaceArgs = []string{"-n", change.Namespace}
+	}
+
+	switch change.Prior {
+	case changes.PriorReplicas:
+		if change.Replicas == nil {
+			return "", nil, nil, "", fmt.Errorf("change %d has no replica count", change.ID)
+		}
+		args := append([]string{"scale", change.Kind, change.Name, "--replicas", strconv.Itoa(*change.Replicas)}, namespaceArgs...)
+		return "kubectl", args, nil, "scale", nil
+
+	case changes.PriorManifest:
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(change.Manifest), &object); err != nil {
+			return "", nil, nil, "", fmt.Errorf("change %d has an invalid manifest: %w", change.ID, err)
+		}
+		// Deleted resources are created again, others are replaced as a whole,
+		// which also removes what a patch added
+		existing, err := k.runKubectlCommandString(ctx, append([]string{"get", change.Kind, change.Name, "--ignore-not-found", "-o", "name"}, namespaceArgs...)...)
+		if err != nil {
+			return "", nil, nil, "", fmt.Errorf("failed to look up %s/%s: %w", change.Kind, change.Name, err)
+		}
+		if strings.TrimSpace(existing) == "" {
+			return "kubectl", []string{"create"}, object, "create", nil
+		}
+		return "kubectl", []string{"replace"}, object, "replace", nil
+
+	case changes.PriorAbsent:
+		if change.Kind == changes.KindHelmRelease {
+			return "helm", append([]string{"uninstall", change.Name}, namespaceArgs...), nil, "helm uninstall", nil
+		}
+		args := append([]string{"delete", change.Kind, change.Name, "--ignore-not-found"}, namespaceArgs...)
+		return "kubectl", args, nil, "delete", nil
+
+	case changes.PriorHelmRevision:
+		args := append([]string{"rollback", change.Name, strconv.Itoa(change.Revision)}, namespaceArgs...)
+		return "helm", args, nil, "helm rollback", nil
+	}
+	return "", nil, nil, "", fmt.Errorf("change %d cannot be undone: %s", change.ID, change.Note)
+}
+
+// Undo the last change made to a resource
+func (k *K8sTool) handleUndoLastChange(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	resourceType := p.String("resource_type", "", params.Required())
+	resourceName := p.String("resource_name", "", params.Required())
+	namespace := p.String("namespace", "", params.Check(security.ValidateNamespace))
+	allNamespaces := p.Bool("all_namespaces", false)
+	dryRun := p.Bool("dry_run", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if allNamespaces && namespace != "" {
+		return mcp.NewToolResultError("namespace cannot be combined with all_namespaces"), nil
+	}
+
+	history, err := changes.Default().List(ctx, changes.Filter{Kind: resourceType, Name: resourceName})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read change history: %v", err)), nil
+	}
+	// Like kubectl, no namespace means the default namespace, or none for
+	// cluster-scoped resources, so a change in another namespace is only
+	// undone when all_namespaces asks for it
+	history = slices.DeleteFunc(history, func(change changes.Change) bool {
+		switch {
+		case allNamespaces:
+			return false
+		case namespace == "":
+			return change.Namespace != "" && change.Namespace != "default"
+		default:
+			return change.Namespace != namespace
+		}
+	})
+	if len(history) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("no change to %s %s was recorded; see change_history", resourceType, resourceName)), nil
+	}
+	change := history[0]
+	if !change.Undoable() {
+		return mcp.NewToolResultError(fmt.Sprintf("the last change to %s %s (%s by %s) cannot be undone: %s", change.Kind, change.Name, change.Summary, change.Tool, change.Note)), nil
+	}
+
+	command, args, manifest, action, err := k.undoArgs(ctx, change)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	result := UndoResult{Change: change, Action: action, DryRun: dryRun}
+	if dryRun {
+		return marshalUndoResult(result)
+	}
+
+	if manifest != nil {
+		filename, err := writeResourceList([]manifestResource{{object: manifest}})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to write manifest: %v", err)), nil
+		}
+		defer removeTempFile(filename)
+		args = append(args, "-f", filename)
+	}
+	output, err := commands.NewCommandBuilder(command).
+		WithArgs(args...).
+		WithKubeconfig(k.kubeconfig).
+		Execute(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to undo change %d with %s: %v", change.ID, action, err)), nil
+	}
+	cache.InvalidateKubernetesCache()
+
+	if result.Change, err = changes.Default().MarkUndone(ctx, change.ID); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Change %d was undone but could not be marked as undone: %v", change.ID, err)), nil
+	}
+	result.Output = output
+	return marshalUndoResult(result)
+}
+
+func marshalUndoResult(result UndoResult) (*mcp.CallToolResult, error) {
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling undo result: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// ChangeHistory is the response of change_history
+type ChangeHistory struct {
+	Total   int              `json:"total"`
+	Changes []changes.Change `json:"changes"`
+}
+
+// List the changes the tools made, the most recent first
+func (k *K8sTool) handleChangeHistory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	resourceType := p.String("resource_type", "")
+	resourceName := p.String("resource_name", "")
+	namespace := p.String("namespace", "")
+	includeUndone := p.Bool("include_undone", true)
+	includeManifests := p.Bool("include_manifests", false)
+	limit := p.Int("limit", defaultChangeHistoryLimit, params.Range(1, 500))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	history, err := changes.Default().List(ctx, changes.Filter{
+		Kind:          resourceType,
+		Name:          resourceName,
+		Namespace:     namespace,
+		IncludeUndone: includeUndone,
+		Limit:         limit,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read change history: %v", err)), nil
+	}
+	if !includeManifests {
+		for i := range history {
+			history[i].Manifest = ""
+		}
+	}
+
+	historyJSON, err := json.MarshalIndent(ChangeHistory{Total: len(history), Changes: history}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling change history: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(historyJSON)), nil
+}
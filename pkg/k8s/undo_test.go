@@ -0,0 +1,190 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/changes"
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPriorDeployment = `{"kind": "Deployment", "metadata": {"name": "web", "namespace": "prod", "resourceVersion": "7"}, "spec": {"replicas": 3}, "status": {"replicas": 3}}`
+
+// useChangeHistory replaces the default change history for the duration of a test
+func useChangeHistory(t *testing.T) *changes.MemoryStore {
+	history := changes.NewMemoryStore()
+	changes.SetDefault(history)
+	t.Cleanup(func() { changes.SetDefault(changes.NewMemoryStore()) })
+	return history
+}
+
+func TestUndoScale(t *testing.T) {
+	useChangeHistory(t)
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "deployment", "web", "--ignore-not-found", "-o", "json", "-n", "prod"}, testPriorDeployment, nil)
+	mock.AddCommandString("kubectl", []string{"scale", "deployment", "web", "--replicas", "10", "-n", "prod"}, "deployment.apps/web scaled", nil)
+	mock.AddCommandString("kubectl", []string{"scale", "Deployment", "web", "--replicas", "3", "-n", "prod"}, "deployment.apps/web scaled", nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	k8sTool := newTestK8sTool()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"name": "web", "namespace": "prod", "replicas": float64(10)}
+	result, err := k8sTool.handleScaleDeployment(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	undo := mcp.CallToolRequest{}
+	undo.Params.Arguments = map[string]interface{}{"resource_type": "deploy", "resource_name": "web", "namespace": "prod"}
+	result, err = k8sTool.handleUndoLastChange(ctx, undo)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var undone UndoResult
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &undone))
+	assert.Equal(t, "scale", undone.Action)
+	require.NotNil(t, undone.Change.Replicas)
+	assert.Equal(t, 3, *undone.Change.Replicas)
+	assert.NotNil(t, undone.Change.UndoneAt)
+
+	callLog := mock.GetCallLog()
+	require.Len(t, callLog, 3)
+	assert.Equal(t, []string{"scale", "Deployment", "web", "--replicas", "3", "-n", "prod"}, callLog[2].Args)
+
+	// The change was undone, so there is nothing left to undo
+	result, err = k8sTool.handleUndoLastChange(ctx, undo)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestUndoDeleteRecreatesResource(t *testing.T) {
+	useChangeHistory(t)
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"get", "deployment", "web", "--ignore-not-found", "-o", "json", "-n", "prod"}, testPriorDeployment, nil)
+	mock.AddCommandString("kubectl", []string{"delete", "deployment", "web", "-n", "prod"}, `deployment.apps "web" deleted`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "Deployment", "web", "--ignore-not-found", "-o", "name", "-n", "prod"}, "", nil)
+	mock.AddPartialMatcherString("kubectl", []string{"create", "-f"}, "deployment.apps/web created", nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	k8sTool := newTestK8sTool()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"resource_type": "deployment", "resource_name": "web", "namespace": "prod"}
+	result, err := k8sTool.handleDeleteResource(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	history, err := changes.Default().List(ctx, changes.Filter{})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, changes.PriorManifest, history[0].Prior)
+	assert.NotContains(t, history[0].Manifest, "resourceVersion")
+	assert.NotContains(t, history[0].Manifest, "status")
+
+	// Without a namespace only changes in the default namespace are undone
+	undo := mcp.CallToolRequest{}
+	undo.Params.Arguments = map[string]interface{}{"resource_type": "deployments", "resource_name": "web"}
+	result, err = k8sTool.handleUndoLastChange(ctx, undo)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, getResultText(result), "no change")
+
+	undo.Params.Arguments = map[string]interface{}{"resource_type": "deployments", "resource_name": "web", "namespace": "prod", "all_namespaces": "true"}
+	result, err = k8sTool.handleUndoLastChange(ctx, undo)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	undo.Params.Arguments = map[string]interface{}{"resource_type": "deployments", "resource_name": "web", "all_namespaces": "true"}
+	result, err = k8sTool.handleUndoLastChange(ctx, undo)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+	assert.Contains(t, getResultText(result), `"action": "create"`)
+}
+
+func TestUndoLastChangeDryRunAndUnrecorded(t *testing.T) {
+	history := useChangeHistory(t)
+	mock := cmd.NewMockShellExecutor()
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	k8sTool := newTestK8sTool()
+
+	undo := mcp.CallToolRequest{}
+	undo.Params.Arguments = map[string]interface{}{"resource_type": changes.KindHelmRelease, "resource_name": "web"}
+	result, err := k8sTool.handleUndoLastChange(ctx, undo)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, getResultText(result), "no change")
+
+	_, err = history.Record(ctx, changes.Change{Tool: "helm_upgrade", Kind: changes.KindHelmRelease, Name: "web", Namespace: "prod", Prior: changes.PriorHelmRevision, Revision: 4})
+	require.NoError(t, err)
+	undo.Params.Arguments = map[string]interface{}{"resource_type": changes.KindHelmRelease, "resource_name": "web", "namespace": "prod", "dry_run": "true"}
+	result, err = k8sTool.handleUndoLastChange(ctx, undo)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var undone UndoResult
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &undone))
+	assert.Equal(t, "helm rollback", undone.Action)
+	assert.True(t, undone.DryRun)
+	assert.Nil(t, undone.Change.UndoneAt)
+	assert.Empty(t, mock.GetCallLog())
+}
+
+func TestUndoLastChangeMatchesNamespace(t *testing.T) {
+	history := useChangeHistory(t)
+	mock := cmd.NewMockShellExecutor()
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+	k8sTool := newTestK8sTool()
+	replicas := 2
+	for _, namespace := range []string{"default", "staging", "prod"} {
+		_, err := history.Record(ctx, changes.Change{Tool: "k8s_scale", Kind: "Deployment", Name: "web", Namespace: namespace, Prior: changes.PriorReplicas, Replicas: &replicas})
+		require.NoError(t, err)
+	}
+
+	// The latest change is in prod, but only a change in the requested
+	// namespace is undone unless all_namespaces is set
+	for _, tc := range []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{}, "default"},
+		{map[string]interface{}{"namespace": "staging"}, "staging"},
+		{map[string]interface{}{"all_namespaces": "true"}, "prod"},
+	} {
+		undo := mcp.CallToolRequest{}
+		undo.Params.Arguments = map[string]interface{}{"resource_type": "deployment", "resource_name": "web", "dry_run": "true"}
+		for key, value := range tc.args {
+			undo.Params.Arguments.(map[string]interface{})[key] = value
+		}
+		result, err := k8sTool.handleUndoLastChange(ctx, undo)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var undone UndoResult
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &undone))
+		assert.Equal(t, tc.want, undone.Change.Namespace, tc.args)
+	}
+	assert.Empty(t, mock.GetCallLog())
+}
+
+func TestHandleChangeHistory(t *testing.T) {
+	history := useChangeHistory(t)
+	ctx := context.Background()
+	_, err := history.Record(ctx, changes.Change{Tool: "k8s_patch_resource", Kind: "Deployment", Name: "web", Prior: changes.PriorManifest, Manifest: `{"kind": "Deployment"}`})
+	require.NoError(t, err)
+	_, err = history.Record(ctx, changes.Change{Tool: "k8s_delete_resource", Kind: "Secret", Name: "creds", Prior: changes.PriorNone, Note: "the contents of Secrets are not recorded"})
+	require.NoError(t, err)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"resource_type": "deployment"}
+	result, err := newTestK8sTool().handleChangeHistory(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var listed ChangeHistory
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &listed))
+	require.Equal(t, 1, listed.Total)
+	assert.Equal(t, "web", listed.Changes[0].Name)
+	assert.Empty(t, listed.Changes[0].Manifest, "manifests are only included on request")
+}
@@ -0,0 +1,425 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/cliout"
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// Checks of k8s_upgrade_readiness
+const (
+	ReadinessCheckDeprecations = "deprecations"
+	ReadinessCheckPDBCoverage  = "pdb_coverage"
+	ReadinessCheckVersionSkew  = "version_skew"
+	ReadinessCheckWebhooks     = "webhooks"
+	ReadinessCheckOperators    = "operators"
+)
+
+// Severities of a ReadinessFinding, and the statuses of a ReadinessCheck
+const (
+	ReadinessBlocking    = "blocking"
+	ReadinessWarning     = "warning"
+	ReadinessOK          = "ok"
+	ReadinessUnavailable = "unavailable"
+)
+
+// Score deducted per finding from a perfect readiness score of 100
+const (
+	blockingScorePenalty = 25
+	warningScorePenalty  = 5
+)
+
+// readinessChecks are the checks of a readiness report in the order they are reported
+var readinessChecks = []string{ReadinessCheckDeprecations, ReadinessCheckPDBCoverage, ReadinessCheckVersionSkew, ReadinessCheckWebhooks, ReadinessCheckOperators}
+
+// nodePoolLabels name a node's pool on GKE, EKS, AKS and Karpenter, by preference
+var nodePoolLabels = []string{
+	"cloud.google.com/gke-nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+	"karpenter.sh/nodepool",
+}
+
+// ReadinessFinding is a problem to fix before, or keep an eye on during, an upgrade
+type ReadinessFinding struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Object   string `json:"object,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ReadinessCheck summarizes one check of an upgrade readiness report
+type ReadinessCheck struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Blocking int    `json:"blocking"`
+	Warnings int    `json:"warnings"`
+	// Message tells why an unavailable check could not run
+	Message string `json:"message,omitempty"`
+}
+
+// UpgradeReadinessReport is the structured response of k8s_upgrade_readiness
+type UpgradeReadinessReport struct {
+	ServerVersion string `json:"server_version,omitempty"`
+	TargetVersion string `json:"target_version"`
+	// Ready is true when no finding blocks the upgrade
+	Ready    bool               `json:"ready"`
+	Score    int                `json:"score"`
+	Checks   []ReadinessCheck   `json:"checks"`
+	Blocking []ReadinessFinding `json:"blocking"`
+	Warnings []ReadinessFinding `json:"warnings"`
+	Notes    []string           `json:"notes,omitempty"`
+}
+
+// readinessCollector gathers the findings of the readiness checks
+type readinessCollector struct {
+	findings    []ReadinessFinding
+	unavailable map[string]string
+	notes       []string
+}
+
+func (c *readinessCollector) add(check, severity, object, format string, args ...interface{}) {
+	c.findings = append(c.findings, ReadinessFinding{Check: check, Severity: severity, Object: object, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *readinessCollector) fail(check string, err error) {
+	c.unavailable[check] = err.Error()
+}
+
+// report builds the scored report from the collected findings
+func (c *readinessCollector) report(serverVersion string, target semver) UpgradeReadinessReport {
+	report := UpgradeReadinessReport{
+		ServerVersion: serverVersion,
+		TargetVersion: fmt.Sprintf("1.%d", target.Minor),
+		Score:         100,
+		Checks:        []ReadinessCheck{},
+		Blocking:      []ReadinessFinding{},
+		Warnings:      []ReadinessFinding{},
+		Notes:         c.notes,
+	}
+	checks := map[string]*ReadinessCheck{}
+	for _, name := range readinessChecks {
+		report.Checks = append(report.Checks, ReadinessCheck{Name: name, Status: ReadinessOK})
+	}
+	for i := range report.Checks {
+		checks[report.Checks[i].Name] = &report.Checks[i]
+	}
+	for _, finding := range c.findings {
+		check := checks[finding.Check]
+		if finding.Severity == ReadinessBlocking {
+			report.Blocking = append(report.Blocking, finding)
+			check.Blocking++
+			check.Status = ReadinessBlocking
+			report.Score -= blockingScorePenalty
+		} else {
+			report.Warnings = append(report.Warnings, finding)
+			check.Warnings++
+			if check.Status == ReadinessOK {
+				check.Status = ReadinessWarning
+			}
+			report.Score -= warningScorePenalty
+		}
+	}
+	for name, message := range c.unavailable {
+		if checks[name].Status == ReadinessOK {
+			checks[name].Status = ReadinessUnavailable
+		}
+		checks[name].Message = message
+	}
+	report.Score = max(report.Score, 0)
+	report.Ready = len(report.Blocking) == 0
+	return report
+}
+
+// checkDeprecations reports objects using API versions removed (blocking) or
+// deprecated (warning) at the target version
+func (k *K8sTool) checkDeprecations(ctx context.Context, c *readinessCollector, target semver, includeHelm bool) {
+	deprecations := k.scanDeprecations(ctx, target.Minor, "", includeHelm)
+	for _, finding := range deprecations.Findings {
+		object := finding.Kind + " " + finding.Name
+		if finding.Namespace != "" {
+			object = finding.Kind + " " + finding.Namespace + "/" + finding.Name
+		}
+		if finding.Release != "" {
+			object += fmt.Sprintf(" (Helm release %s)", finding.Release)
+		}
+		replacement := "remove it"
+		if finding.Replacement != "" {
+			replacement = "migrate to " + finding.Replacement
+		}
+		if finding.Status == DeprecationStatusRemoved {
+			c.add(ReadinessCheckDeprecations, ReadinessBlocking, object, "uses %s, removed in %s; %s", finding.APIVersion, finding.RemovedIn, replacement)
+		} else {
+			c.add(ReadinessCheckDeprecations, ReadinessWarning, object, "uses %s, deprecated in %s and removed in %s; %s", finding.APIVersion, finding.DeprecatedIn, finding.RemovedIn, replacement)
+		}
+	}
+	c.notes = append(c.notes, deprecations.Notes...)
+}
+
+// readinessWorkloadList is the subset of `kubectl get deployments,statefulsets -o json` output used for PDB coverage
+type readinessWorkloadList struct {
+	Items []struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			Replicas *int `json:"replicas"`
+			Template struct {
+				Metadata struct {
+					Labels map[string]string `json:"labels"`
+				} `json:"metadata"`
+			} `json:"template"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// checkPDBCoverage reports budgets that allow no disruption, which block node
+// drains (blocking), and replicated workloads no budget protects during drains (warning)
+func (k *K8sTool) checkPDBCoverage(ctx context.Context, c *readinessCollector) {
+	output, err := k.runKubectlCommandString(ctx, "get", "poddisruptionbudgets", "--all-namespaces", "-o", "json")
+	if err != nil {
+		c.fail(ReadinessCheckPDBCoverage, fmt.Errorf("PodDisruptionBudgets could not be listed: %w", err))
+		return
+	}
+	var pdbs pdbList
+	if err := json.Unmarshal([]byte(output), &pdbs); err != nil {
+		c.fail(ReadinessCheckPDBCoverage, fmt.Errorf("failed to parse PodDisruptionBudgets: %w", err))
+		return
+	}
+	for _, pdb := range pdbs.Items {
+		if pdb.Status.ExpectedPods > 0 && pdb.Status.DisruptionsAllowed < 1 {
+			c.add(ReadinessCheckPDBCoverage, ReadinessBlocking, "PodDisruptionBudget "+pdb.Metadata.Namespace+"/"+pdb.Metadata.Name,
+				"allows no disruptions (%d of %d pods healthy, %d required), so draining its nodes hangs", pdb.Status.CurrentHealthy, pdb.Status.ExpectedPods, pdb.Status.DesiredHealthy)
+		}
+	}
+
+	output, err = k.runKubectlCommandString(ctx, "get", "deployments,statefulsets", "--all-namespaces", "-o", "json")
+	if err != nil {
+		c.fail(ReadinessCheckPDBCoverage, fmt.Errorf("workloads could not be listed: %w", err))
+		return
+	}
+	var workloads readinessWorkloadList
+	if err := json.Unmarshal([]byte(output), &workloads); err != nil {
+		c.fail(ReadinessCheckPDBCoverage, fmt.Errorf("failed to parse workloads: %w", err))
+		return
+	}
+	for _, workload := range workloads.Items {
+		// Single replicas are disrupted by a drain whatever their budget
+		if workload.Spec.Replicas == nil || *workload.Spec.Replicas < 2 {
+			continue
+		}
+		covered := false
+		for _, pdb := range pdbs.Items {
+			if pdb.Metadata.Namespace == workload.Metadata.Namespace && pdb.Spec.Selector != nil && pdb.Spec.Selector.matches(workload.Spec.Template.Metadata.Labels) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			c.add(ReadinessCheckPDBCoverage, ReadinessWarning, workload.Kind+" "+workload.Metadata.Namespace+"/"+workload.Metadata.Name,
+				"has %d replicas but no PodDisruptionBudget, so a drain may evict them all at once", *workload.Spec.Replicas)
+		}
+	}
+}
+
+// readinessNodeList is the subset of `kubectl get nodes -o json` output used for version skew
+type readinessNodeList struct {
+	Items []struct {
+		Metadata struct {
+			Name   string            `json:"name"`
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+		Status struct {
+			NodeInfo struct {
+				KubeletVersion string `json:"kubeletVersion"`
+			} `json:"nodeInfo"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// nodePool returns the pool of a node from its labels, or the node's own name
+func nodePool(name string, labels map[string]string) string {
+	for _, label := range nodePoolLabels {
+		if pool := labels[label]; pool != "" {
+			return pool
+		}
+	}
+	return name
+}
+
+// maxKubeletSkew is the number of minor versions kubelets may lag the API
+// server: three since Kubernetes 1.28, two before
+func maxKubeletSkew(serverMinor int) int {
+	if serverMinor >= 28 {
+		return 3
+	}
+	return 2
+}
+
+// checkVersionSkew reports control plane upgrades skipping minor versions and
+// node pools whose kubelets the target API server does not support (blocking),
+// and node pools lagging the current control plane (warning)
+func (k *K8sTool) checkVersionSkew(ctx context.Context, c *readinessCollector, server semver, serverOK bool, target semver) {
+	if serverOK && target.Minor-server.Minor > 1 {
+		c.add(ReadinessCheckVersionSkew, ReadinessBlocking, "control plane",
+			"the control plane can only be upgraded one minor version at a time; upgrade from 1.%d through each minor version to 1.%d", server.Minor, target.Minor)
+	}
+
+	output, err := k.runKubectlCommandString(ctx, "get", "nodes", "-o", "json")
+	if err != nil {
+		c.fail(ReadinessCheckVersionSkew, fmt.Errorf("nodes could not be listed: %w", err))
+		return
+	}
+	var nodes readinessNodeList
+	if err := json.Unmarshal([]byte(output), &nodes); err != nil {
+		c.fail(ReadinessCheckVersionSkew, fmt.Errorf("failed to parse nodes: %w", err))
+		return
+	}
+
+	// Each pool is judged by its oldest kubelet
+	type poolVersion struct {
+		oldest semver
+	}
+	pools := map[string]*poolVersion{}
+	var names []string
+	for _, node := range nodes.Items {
+		version, ok := parseVersion(node.Status.NodeInfo.KubeletVersion)
+		if !ok {
+			continue
+		}
+		name := nodePool(node.Metadata.Name, node.Metadata.Labels)
+		pool, ok := pools[name]
+		if !ok {
+			pool = &poolVersion{oldest: version}
+			pools[name] = pool
+			names = append(names, name)
+		}
+		if version.Minor < pool.oldest.Minor {
+			pool.oldest = version
+		}
+	}
+	sort.Strings(names)
+
+	maxSkew := maxKubeletSkew(target.Minor)
+	for _, name := range names {
+		pool := pools[name]
+		object := "node pool " + name
+		switch skew := target.Minor - pool.oldest.Minor; {
+		case skew > maxSkew:
+			c.add(ReadinessCheckVersionSkew, ReadinessBlocking, object,
+				"runs kubelet 1.%d, but 1.%d supports kubelets down to 1.%d; upgrade the pool first", pool.oldest.Minor, target.Minor, target.Minor-maxSkew)
+		case skew < 0:
+			c.add(ReadinessCheckVersionSkew, ReadinessBlocking, object,
+				"runs kubelet 1.%d, newer than the target API server 1.%d", pool.oldest.Minor, target.Minor)
+		case serverOK && pool.oldest.Minor < server.Minor:
+			c.add(ReadinessCheckVersionSkew, ReadinessWarning, object,
+				"runs kubelet 1.%d, behind the control plane's 1.%d; upgrade it after the control plane to keep within the skew policy", pool.oldest.Minor, server.Minor)
+		}
+	}
+}
+
+// checkWebhooks reports admission webhooks without ready endpoints: those
+// failing closed reject API requests during the upgrade (blocking), the others
+// are silently skipped (warning)
+func (k *K8sTool) checkWebhooks(ctx context.Context, c *readinessCollector) {
+	output, err := k.runKubectlCommandString(ctx, "get", "validatingwebhookconfigurations,mutatingwebhookconfigurations", "-o", "json")
+	if err != nil {
+		c.fail(ReadinessCheckWebhooks, fmt.Errorf("webhook configurations could not be listed: %w", err))
+		return
+	}
+	var configurations webhookConfigurationList
+	if err := json.Unmarshal([]byte(output), &configurations); err != nil {
+		c.fail(ReadinessCheckWebhooks, fmt.Errorf("failed to parse webhook configurations: %w", err))
+		return
+	}
+
+	// Webhook services are checked once, however many webhooks point at them
+	ready := map[string]int{}
+	for _, configuration := range configurations.Items {
+		for _, webhook := range configuration.Webhooks {
+			ref := webhook.ClientConfig.Service
+			if ref == nil {
+				continue
+			}
+			endpoints, checked := ready[ref.String()]
+			if !checked {
+				endpoints, _, _ = k.checkWebhookService(ctx, *ref)
+				ready[ref.String()] = endpoints
+			}
+			if endpoints > 0 {
+				continue
+			}
+			object := fmt.Sprintf("%s %s/%s", configuration.Kind, configuration.Metadata.Name, webhook.Name)
+			if webhook.FailurePolicy == "Ignore" {
+				c.add(ReadinessCheckWebhooks, ReadinessWarning, object, "service %s has no ready endpoints; requests skip the webhook", ref)
+			} else {
+				c.add(ReadinessCheckWebhooks, ReadinessBlocking, object, "service %s has no ready endpoints and the webhook fails closed, rejecting the requests it intercepts", ref)
+			}
+		}
+	}
+}
+
+// checkOperators reports Istio and Cilium versions not supported on the target
+// version (blocking) or without compatibility data (warning)
+func (k *K8sTool) checkOperators(ctx context.Context, c *readinessCollector, target semver) {
+	var checks []SkewCheck
+	istioctl, _ := commands.NewCommandBuilder("istioctl").WithArgs("version", "-o", "json").WithKubeconfig(k.kubeconfig).Execute(ctx)
+	if istioVersion, err := cliout.ParseIstioVersion(istioctl); err == nil {
+		if controlPlane, ok := parseVersion(istioVersion.ControlPlane); ok {
+			checks = append(checks, rangeSkewCheck("istio", controlPlane, target, istioKubernetesSupport))
+		}
+	}
+	cilium, _ := commands.NewCommandBuilder("cilium").WithArgs("version").Execute(ctx)
+	if ciliumVersion, ok := parseVersion(cliout.ParseCiliumVersion(cilium).Running); ok {
+		checks = append(checks, rangeSkewCheck("cilium", ciliumVersion, target, ciliumKubernetesSupport))
+	}
+
+	for _, check := range checks {
+		object := check.Component + " " + check.Version
+		switch check.Status {
+		case skewStatusUnsupported:
+			c.add(ReadinessCheckOperators, ReadinessBlocking, object, "%s; upgrade it to a version supporting the target first", check.Message)
+		case skewStatusUnknown:
+			c.add(ReadinessCheckOperators, ReadinessWarning, object, "%s; check its release notes for the target version", check.Message)
+		}
+	}
+}
+
+// Cluster upgrade readiness
+func (k *K8sTool) handleUpgradeReadiness(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	targetVersion := p.String("target_version", "")
+	includeHelm := p.Bool("include_helm", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	serverVersion, target, err := k.upgradeTarget(ctx, targetVersion)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	server, serverOK := parseVersion(serverVersion)
+
+	c := &readinessCollector{unavailable: map[string]string{}}
+	k.checkDeprecations(ctx, c, target, includeHelm)
+	k.checkPDBCoverage(ctx, c)
+	k.checkVersionSkew(ctx, c, server, serverOK, target)
+	k.checkWebhooks(ctx, c)
+	k.checkOperators(ctx, c, target)
+
+	reportJSON, err := json.MarshalIndent(c.report(serverVersion, target), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling upgrade readiness report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
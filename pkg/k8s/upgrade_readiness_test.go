@@ -0,0 +1,126 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+)
+
+const testReadinessPDBs = `{"items":[
+	{"metadata":{"name":"db","namespace":"web"},"spec":{"selector":{"matchLabels":{"app":"db"}},"minAvailable":2},
+	 "status":{"currentHealthy":2,"desiredHealthy":2,"expectedPods":2,"disruptionsAllowed":0}}
+]}`
+
+const testReadinessWorkloads = `{"items":[
+	{"kind":"Deployment","metadata":{"name":"api","namespace":"web"},"spec":{"replicas":3,"template":{"metadata":{"labels":{"app":"api"}}}}},
+	{"kind":"StatefulSet","metadata":{"name":"db","namespace":"web"},"spec":{"replicas":2,"template":{"metadata":{"labels":{"app":"db"}}}}},
+	{"kind":"Deployment","metadata":{"name":"cron","namespace":"web"},"spec":{"replicas":1,"template":{"metadata":{"labels":{"app":"cron"}}}}}
+]}`
+
+const testReadinessNodes = `{"items":[
+	{"metadata":{"name":"a-1","labels":{"cloud.google.com/gke-nodepool":"pool-a"}},"status":{"nodeInfo":{"kubeletVersion":"v1.29.4"}}},
+	{"metadata":{"name":"a-2","labels":{"cloud.google.com/gke-nodepool":"pool-a"}},"status":{"nodeInfo":{"kubeletVersion":"v1.29.4"}}},
+	{"metadata":{"name":"old-1","labels":{"cloud.google.com/gke-nodepool":"pool-old"}},"status":{"nodeInfo":{"kubeletVersion":"v1.26.1"}}},
+	{"metadata":{"name":"node-x","labels":{}},"status":{"nodeInfo":{"kubeletVersion":"v1.28.0"}}}
+]}`
+
+const testReadinessWebhooks = `{"items":[
+	{"kind":"ValidatingWebhookConfiguration","metadata":{"name":"policy"},"webhooks":[
+		{"name":"validate.policy.io","failurePolicy":"Fail","clientConfig":{"service":{"name":"policy","namespace":"policy-system"}}},
+		{"name":"audit.policy.io","failurePolicy":"Ignore","clientConfig":{"service":{"name":"policy","namespace":"policy-system"}}}
+	]}
+]}`
+
+func TestReadinessReportScore(t *testing.T) {
+	c := &readinessCollector{unavailable: map[string]string{ReadinessCheckOperators: "istioctl failed"}}
+	c.add(ReadinessCheckWebhooks, ReadinessWarning, "webhook", "skipped")
+	c.add(ReadinessCheckWebhooks, ReadinessBlocking, "webhook", "fails closed")
+	report := c.report("v1.29.0", semver{Major: 1, Minor: 30})
+
+	assert.False(t, report.Ready)
+	assert.Equal(t, 100-blockingScorePenalty-warningScorePenalty, report.Score)
+	assert.Equal(t, "1.30", report.TargetVersion)
+	require.Len(t, report.Checks, len(readinessChecks))
+	assert.Equal(t, ReadinessCheck{Name: ReadinessCheckWebhooks, Status: ReadinessBlocking, Blocking: 1, Warnings: 1}, report.Checks[3])
+	assert.Equal(t, ReadinessCheck{Name: ReadinessCheckOperators, Status: ReadinessUnavailable, Message: "istioctl failed"}, report.Checks[4])
+
+	report = (&readinessCollector{unavailable: map[string]string{}}).report("", semver{Major: 1, Minor: 30})
+	assert.True(t, report.Ready)
+	assert.Equal(t, 100, report.Score)
+}
+
+func TestNodePool(t *testing.T) {
+	assert.Equal(t, "workers", nodePool("ip-10-0-0-1", map[string]string{"eks.amazonaws.com/nodegroup": "workers"}))
+	assert.Equal(t, "ip-10-0-0-1", nodePool("ip-10-0-0-1", nil))
+	assert.Equal(t, 2, maxKubeletSkew(27))
+	assert.Equal(t, 3, maxKubeletSkew(28))
+}
+
+func TestHandleUpgradeReadiness(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"version", "-o", "json"}, `{"clientVersion":{"gitVersion":"v1.29.0"},"serverVersion":{"gitVersion":"v1.29.2"}}`, nil)
+	mock.AddCommandString("kubectl", []string{"api-versions"}, "apps/v1\nflowcontrol.apiserver.k8s.io/v1\nflowcontrol.apiserver.k8s.io/v1beta3\nv1\n", nil)
+	mock.AddCommandString("kubectl", []string{"get", "flowschemas.flowcontrol.apiserver.k8s.io", "-o", "json", "--show-managed-fields", "--all-namespaces"},
+		`{"items":[{"metadata":{"name":"custom","managedFields":[{"manager":"kubectl","apiVersion":"flowcontrol.apiserver.k8s.io/v1beta3"}]}}]}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "prioritylevelconfigurations.flowcontrol.apiserver.k8s.io", "-o", "json", "--show-managed-fields", "--all-namespaces"}, `{"items":[]}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "poddisruptionbudgets", "--all-namespaces", "-o", "json"}, testReadinessPDBs, nil)
+	mock.AddCommandString("kubectl", []string{"get", "deployments,statefulsets", "--all-namespaces", "-o", "json"}, testReadinessWorkloads, nil)
+	mock.AddCommandString("kubectl", []string{"get", "nodes", "-o", "json"}, testReadinessNodes, nil)
+	mock.AddCommandString("kubectl", []string{"get", "validatingwebhookconfigurations,mutatingwebhookconfigurations", "-o", "json"}, testReadinessWebhooks, nil)
+	mock.AddCommandString("kubectl", []string{"get", "endpoints", "policy", "-n", "policy-system", "-o", "json"}, `{"subsets":[]}`, nil)
+	mock.AddCommandString("kubectl", []string{"get", "service", "policy", "-n", "policy-system", "-o", "json"}, `{"spec":{"selector":{"app":"policy"}}}`, nil)
+	mock.AddCommandString("istioctl", []string{"version", "-o", "json"}, "", errors.New("istioctl: not found"))
+	mock.AddCommandString("cilium", []string{"version"}, testCiliumVersion, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	result, err := newTestK8sTool().handleUpgradeReadiness(ctx, mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var report UpgradeReadinessReport
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+	assert.Equal(t, "1.30", report.TargetVersion)
+	assert.False(t, report.Ready)
+	assert.Equal(t, 0, report.Score)
+
+	objects := func(findings []ReadinessFinding) []string {
+		var names []string
+		for _, finding := range findings {
+			names = append(names, finding.Check+": "+finding.Object)
+		}
+		return names
+	}
+	assert.Equal(t, []string{
+		"pdb_coverage: PodDisruptionBudget web/db",
+		"version_skew: node pool pool-old",
+		"webhooks: ValidatingWebhookConfiguration policy/validate.policy.io",
+		"operators: cilium 1.15.1",
+	}, objects(report.Blocking))
+	assert.Equal(t, []string{
+		"deprecations: FlowSchema custom",
+		"pdb_coverage: Deployment web/api",
+		"version_skew: node pool node-x",
+		"webhooks: ValidatingWebhookConfiguration policy/audit.policy.io",
+	}, objects(report.Warnings))
+	assert.Contains(t, report.Blocking[1].Message, "supports kubelets down to 1.27")
+	// The webhook service is only checked once
+	assert.Len(t, mock.GetCallLog(), 12)
+
+	t.Run("control plane skipping minor versions", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"target_version": "1.31"}
+		result, err := newTestK8sTool().handleUpgradeReadiness(ctx, request)
+		require.NoError(t, err)
+		var report UpgradeReadinessReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		require.NotEmpty(t, report.Blocking)
+		assert.Equal(t, "control plane", report.Blocking[1].Object)
+	})
+}
@@ -0,0 +1,264 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/cliout"
+	"github.com/kagent-dev/tools/internal/commands"
+)
+
+// Statuses of a SkewCheck
+const (
+	skewStatusOK          = "ok"
+	skewStatusUnsupported = "unsupported"
+	skewStatusUnknown     = "unknown"
+	skewStatusUnavailable = "unavailable"
+)
+
+var (
+	// versionPattern extracts major.minor.patch from version strings such as v1.29.2-gke.1 or 1.22.0
+	versionPattern = regexp.MustCompile(`v?(\d+)\.(\d+)(?:\.(\d+))?`)
+)
+
+// supportRange is an inclusive range of supported Kubernetes minor versions (1.x)
+type supportRange struct {
+	MinMinor int
+	MaxMinor int
+}
+
+// istioKubernetesSupport maps Istio minor versions to the Kubernetes minors they are tested against
+var istioKubernetesSupport = map[int]supportRange{
+	20: {25, 29},
+	21: {26, 29},
+	22: {27, 30},
+	23: {27, 30},
+	24: {28, 31},
+	25: {29, 32},
+	26: {29, 33},
+	27: {30, 33},
+}
+
+// ciliumKubernetesSupport maps Cilium minor versions to the Kubernetes minors they are tested against
+var ciliumKubernetesSupport = map[int]supportRange{
+	14: {16, 27},
+	15: {16, 29},
+	16: {16, 30},
+	17: {16, 32},
+	18: {16, 33},
+}
+
+// semver is a parsed major.minor.patch version
+type semver struct {
+	Major, Minor, Patch int
+}
+
+// parseVersion extracts the first version in s
+func parseVersion(s string) (semver, bool) {
+	match := versionPattern.FindStringSubmatch(s)
+	if match == nil {
+		return semver{}, false
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	patch, _ := strconv.Atoi(match[3])
+	return semver{major, minor, patch}, true
+}
+
+// String renders the version as major.minor.patch
+func (v semver) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// SkewCheck compares a component's version with the version it must be compatible with
+type SkewCheck struct {
+	Component       string `json:"component"`
+	Version         string `json:"version,omitempty"`
+	ComparedWith    string `json:"compared_with"`
+	ComparedVersion string `json:"compared_version,omitempty"`
+	Status          string `json:"status"`
+	Message         string `json:"message"`
+}
+
+// VersionSkewReport is the structured response of k8s_version_skew
+type VersionSkewReport struct {
+	KubernetesServer string      `json:"kubernetes_server,omitempty"`
+	Unsupported      int         `json:"unsupported"`
+	Checks           []SkewCheck `json:"checks"`
+}
+
+// versionOutputs holds the raw CLI outputs a skew report is built from; empty outputs mean the CLI failed
+type versionOutputs struct {
+	Kubectl  string
+	Helm     string
+	Istioctl string
+	Cilium   string
+}
+
+// kubectlSkewCheck applies the kubectl policy of one minor version of skew with the API server
+func kubectlSkewCheck(client, server semver) SkewCheck {
+	check := SkewCheck{
+		Component:       "kubectl",
+		Version:         client.String(),
+		ComparedWith:    "kubernetes",
+		ComparedVersion: server.String(),
+		Status:          skewStatusOK,
+		Message:         "within one minor version of the API server",
+	}
+	if skew := client.Minor - server.Minor; client.Major != server.Major || abs(skew) > 1 {
+		check.Status = skewStatusUnsupported
+		check.Message = fmt.Sprintf("kubectl is %d minor versions from the API server; only one minor version of skew is supported", abs(skew))
+	}
+	return check
+}
+
+// helmSkewCheck applies the Helm policy of supporting the Kubernetes minor it was built against and the three before it
+func helmSkewCheck(client, server semver) SkewCheck {
+	check := SkewCheck{
+		Component:       "helm",
+		Version:         client.String(),
+		ComparedWith:    "kubernetes",
+		ComparedVersion: server.String(),
+		Status:          skewStatusOK,
+	}
+	if client.Major != 3 {
+		check.Status = skewStatusUnsupported
+		check.Message = fmt.Sprintf("Helm %d is not supported", client.Major)
+		return check
+	}
+	// Helm 3.x is built against Kubernetes 1.(x+15)
+	supported := supportRange{client.Minor + 12, client.Minor + 15}
+	return applySupportRange(check, fmt.Sprintf("Helm %d.%d", client.Major, client.Minor), supported, server)
+}
+
+// applySupportRange sets the check status from a range of supported Kubernetes minors
+func applySupportRange(check SkewCheck, name string, supported supportRange, server semver) SkewCheck {
+	if server.Major == 1 && server.Minor >= supported.MinMinor && server.Minor <= supported.MaxMinor {
+		check.Status = skewStatusOK
+		check.Message = fmt.Sprintf("%s supports Kubernetes 1.%d to 1.%d", name, supported.MinMinor, supported.MaxMinor)
+		return check
+	}
+	check.Status = skewStatusUnsupported
+	check.Message = fmt.Sprintf("%s supports Kubernetes 1.%d to 1.%d, cluster runs %d.%d", name, supported.MinMinor, supported.MaxMinor, server.Major, server.Minor)
+	return check
+}
+
+// rangeSkewCheck checks a cluster component against a table of supported Kubernetes minors
+func rangeSkewCheck(component string, version, server semver, table map[int]supportRange) SkewCheck {
+	check := SkewCheck{
+		Component:       component,
+		Version:         version.String(),
+		ComparedWith:    "kubernetes",
+		ComparedVersion: server.String(),
+	}
+	supported, ok := table[version.Minor]
+	if !ok || version.Major != 1 {
+		check.Status = skewStatusUnknown
+		check.Message = fmt.Sprintf("no compatibility data for %s %d.%d", component, version.Major, version.Minor)
+		return check
+	}
+	return applySupportRange(check, fmt.Sprintf("%s %d.%d", component, version.Major, version.Minor), supported, server)
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// buildVersionSkewReport parses the CLI version outputs and checks each supported skew policy
+func buildVersionSkewReport(out versionOutputs) VersionSkewReport {
+	report := VersionSkewReport{Checks: []SkewCheck{}}
+
+	// Parse errors leave the versions empty, reported as unavailable
+	kubectlVersion, _ := cliout.ParseKubectlVersion(out.Kubectl)
+	client, clientOK := parseVersion(kubectlVersion.Client)
+	server, serverOK := parseVersion(kubectlVersion.Server)
+	report.KubernetesServer = kubectlVersion.Server
+
+	unavailable := func(component, message string) {
+		report.Checks = append(report.Checks, SkewCheck{Component: component, ComparedWith: "kubernetes", Status: skewStatusUnavailable, Message: message})
+	}
+	switch {
+	case !clientOK:
+		unavailable("kubectl", "kubectl version could not be determined")
+	case !serverOK:
+		unavailable("kubectl", "Kubernetes API server version could not be determined")
+	default:
+		report.Checks = append(report.Checks, kubectlSkewCheck(client, server))
+	}
+
+	if helmVersion, ok := parseVersion(cliout.ParseHelmVersion(out.Helm)); !ok {
+		unavailable("helm", "helm is not installed or its version could not be determined")
+	} else if serverOK {
+		report.Checks = append(report.Checks, helmSkewCheck(helmVersion, server))
+	}
+
+	if istioVersion, err := cliout.ParseIstioVersion(out.Istioctl); err != nil {
+		unavailable("istio", "istioctl is not installed or its version could not be determined")
+	} else {
+		istioClient, istioClientOK := parseVersion(istioVersion.Client)
+		controlPlane, controlPlaneOK := parseVersion(istioVersion.ControlPlane)
+		if istioClientOK && controlPlaneOK {
+			check := SkewCheck{
+				Component:       "istioctl",
+				Version:         istioClient.String(),
+				ComparedWith:    "istiod",
+				ComparedVersion: controlPlane.String(),
+				Status:          skewStatusOK,
+				Message:         "within one minor version of the control plane",
+			}
+			if skew := istioClient.Minor - controlPlane.Minor; istioClient.Major != controlPlane.Major || abs(skew) > 1 {
+				check.Status = skewStatusUnsupported
+				check.Message = fmt.Sprintf("istioctl is %d minor versions from istiod; only one minor version of skew is supported", abs(skew))
+			}
+			report.Checks = append(report.Checks, check)
+		}
+		if controlPlaneOK && serverOK {
+			report.Checks = append(report.Checks, rangeSkewCheck("istio", controlPlane, server, istioKubernetesSupport))
+		} else if !controlPlaneOK {
+			unavailable("istio", "no Istio control plane found")
+		}
+	}
+
+	if ciliumVersion, ok := parseVersion(cliout.ParseCiliumVersion(out.Cilium).Running); !ok {
+		unavailable("cilium", "cilium is not installed or its running version could not be determined")
+	} else if serverOK {
+		report.Checks = append(report.Checks, rangeSkewCheck("cilium", ciliumVersion, server, ciliumKubernetesSupport))
+	}
+
+	for _, check := range report.Checks {
+		if check.Status == skewStatusUnsupported {
+			report.Unsupported++
+		}
+	}
+	return report
+}
+
+// Version skew detection between client CLIs and the cluster
+func (k *K8sTool) handleVersionSkew(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var out versionOutputs
+	// Missing CLIs and unreachable components are reported as unavailable checks
+	out.Kubectl, _ = k.runKubectlCommandString(ctx, "version", "-o", "json")
+	if out.Kubectl == "" {
+		// kubectl version fails when the API server is unreachable, so fall back to the client version
+		out.Kubectl, _ = k.runKubectlCommandString(ctx, "version", "--client", "-o", "json")
+	}
+	out.Helm, _ = commands.NewCommandBuilder("helm").WithArgs("version", "--short").WithKubeconfig(k.kubeconfig).Execute(ctx)
+	out.Istioctl, _ = commands.NewCommandBuilder("istioctl").WithArgs("version", "-o", "json").WithKubeconfig(k.kubeconfig).Execute(ctx)
+	out.Cilium, _ = commands.NewCommandBuilder("cilium").WithArgs("version").Execute(ctx)
+
+	report := buildVersionSkewReport(out)
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Error marshaling version skew report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
@@ -0,0 +1,97 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKubectlVersion = `{"clientVersion":{"gitVersion":"v1.26.3"},"serverVersion":{"gitVersion":"v1.29.2-gke.1060000"}}`
+
+const testIstioctlVersion = `{"clientVersion":{"version":"1.22.1"},
+ "meshVersion":[{"Component":"pilot","Info":{"version":"1.20.3"}}]}`
+
+const testCiliumVersion = `cilium-cli: v0.16.4 compiled with go1.22.1 on linux/amd64
+cilium image (default): v1.15.3
+cilium image (stable): v1.15.4
+cilium image (running): 1.15.1
+`
+
+func TestParseVersion(t *testing.T) {
+	v, ok := parseVersion("v1.29.2-gke.1060000")
+	require.True(t, ok)
+	assert.Equal(t, semver{1, 29, 2}, v)
+
+	v, ok = parseVersion("v3.14.2+gc309b6f")
+	require.True(t, ok)
+	assert.Equal(t, "3.14.2", v.String())
+
+	_, ok = parseVersion("unknown")
+	assert.False(t, ok)
+}
+
+func TestBuildVersionSkewReport(t *testing.T) {
+	report := buildVersionSkewReport(versionOutputs{
+		Kubectl:  testKubectlVersion,
+		Helm:     "v3.15.2+g1a500d5",
+		Istioctl: testIstioctlVersion,
+		Cilium:   testCiliumVersion,
+	})
+
+	assert.Equal(t, "v1.29.2-gke.1060000", report.KubernetesServer)
+	require.Len(t, report.Checks, 5)
+	checks := make(map[string]SkewCheck)
+	for _, check := range report.Checks {
+		checks[check.Component] = check
+	}
+
+	assert.Equal(t, skewStatusUnsupported, checks["kubectl"].Status)
+	assert.Contains(t, checks["kubectl"].Message, "3 minor versions")
+	assert.Equal(t, skewStatusOK, checks["helm"].Status)
+	assert.Equal(t, skewStatusUnsupported, checks["istioctl"].Status)
+	assert.Equal(t, "1.20.3", checks["istioctl"].ComparedVersion)
+	assert.Equal(t, skewStatusOK, checks["istio"].Status)
+	assert.Equal(t, skewStatusOK, checks["cilium"].Status)
+	assert.Equal(t, 2, report.Unsupported)
+}
+
+func TestBuildVersionSkewReportUnavailable(t *testing.T) {
+	report := buildVersionSkewReport(versionOutputs{
+		Kubectl: `{"clientVersion":{"gitVersion":"v1.30.0"}}`,
+		Helm:    "v2.17.0+ga690bad",
+	})
+
+	require.Len(t, report.Checks, 3)
+	for _, check := range report.Checks {
+		assert.Equal(t, skewStatusUnavailable, check.Status, check.Component)
+	}
+	assert.Contains(t, report.Checks[0].Message, "API server")
+	assert.Zero(t, report.Unsupported)
+}
+
+func TestHandleVersionSkew(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("kubectl", []string{"version", "-o", "json"}, "", assert.AnError)
+	mock.AddCommandString("kubectl", []string{"version", "--client", "-o", "json"}, `{"clientVersion":{"gitVersion":"v1.30.0"}}`, nil)
+	mock.AddCommandString("helm", []string{"version", "--short"}, "v3.16.1+g5a5449d", nil)
+	mock.AddCommandString("istioctl", []string{"version", "-o", "json"}, "", assert.AnError)
+	mock.AddCommandString("cilium", []string{"version"}, testCiliumVersion, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	result, err := newTestK8sTool().handleVersionSkew(ctx, mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.False(t, result.IsError, getResultText(result))
+
+	var report VersionSkewReport
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+	assert.Empty(t, report.KubernetesServer)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "kubectl", report.Checks[0].Component)
+	assert.Equal(t, "istio", report.Checks[1].Component)
+	assert.Len(t, mock.GetCallLog(), 5)
+}
@@ -0,0 +1,540 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/prometheus/client_golang/api"
+
+	"github.com/kagent-dev/tools/internal/datasources"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+	"github.com/kagent-dev/tools/internal/tenancy"
+)
+
+// Alertmanager tools using the Alertmanager v2 HTTP API
+
+const (
+	// maxSilenceDuration is the longest silence the tools create, so a
+	// forgotten silence cannot hide an alert indefinitely
+	maxSilenceDuration = 7 * 24 * time.Hour
+	// defaultSilenceCreator attributes silences created without a tenant or created_by
+	defaultSilenceCreator = "kagent-tools"
+)
+
+// Matcher matches a label of alerts, as in the Alertmanager API
+type Matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// String renders the matcher in the selector syntax
+func (m Matcher) String() string {
+	op := "="
+	switch {
+	case m.IsRegex && m.IsEqual:
+		op = "=~"
+	case m.IsRegex:
+		op = "!~"
+	case !m.IsEqual:
+		op = "!="
+	}
+	return m.Name + op + strconv.Quote(m.Value)
+}
+
+// matcherNamePattern matches a label name
+var matcherNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*`)
+
+// parseMatchers parses comma-separated matchers in the PromQL selector syntax,
+// such as {alertname="HighLatency", namespace=~"prod|staging"}. Braces and the
+// quotes of values without commas are optional.
+func parseMatchers(selector string) ([]Matcher, error) {
+	s := strings.TrimSpace(selector)
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+
+	var matchers []Matcher
+	for s != "" {
+		name := matcherNamePattern.FindString(s)
+		if name == "" {
+			return nil, fmt.Errorf("expected a label name at %q", s)
+		}
+		s = strings.TrimSpace(s[len(name):])
+
+		matcher := Matcher{Name: name, IsEqual: true}
+		switch {
+		case strings.HasPrefix(s, "=~"):
+			matcher.IsRegex, s = true, s[2:]
+		case strings.HasPrefix(s, "!~"):
+			matcher.IsRegex, matcher.IsEqual, s = true, false, s[2:]
+		case strings.HasPrefix(s, "!="):
+			matcher.IsEqual, s = false, s[2:]
+		case strings.HasPrefix(s, "="):
+			s = s[1:]
+		default:
+			return nil, fmt.Errorf("expected =, !=, =~ or !~ after label %s", name)
+		}
+		s = strings.TrimSpace(s)
+
+		if strings.HasPrefix(s, `"`) {
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value of label %s: %w", name, err)
+			}
+			matcher.Value, _ = strconv.Unquote(quoted)
+			s = s[len(quoted):]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			matcher.Value, s = strings.TrimSpace(s[:end]), s[end:]
+		}
+		if matcher.IsRegex {
+			if _, err := regexp.Compile("^(?:" + matcher.Value + ")$"); err != nil {
+				return nil, fmt.Errorf("invalid regular expression of label %s: %w", name, err)
+			}
+		}
+		matchers = append(matchers, matcher)
+
+		s = strings.TrimSpace(s)
+		if s != "" {
+			if !strings.HasPrefix(s, ",") {
+				return nil, fmt.Errorf("expected , after the matcher of label %s", name)
+			}
+			s = strings.TrimSpace(s[1:])
+		}
+	}
+
+	if len(matchers) == 0 {
+		return nil, fmt.Errorf("at least one matcher is required")
+	}
+	// A silence matching every alert would hide all of them
+	for _, matcher := range matchers {
+		if !matcher.matchesEmpty() {
+			return matchers, nil
+		}
+	}
+	return nil, fmt.Errorf("at least one matcher must not match the empty string, so the silence cannot match every alert")
+}
+
+// matchesEmpty reports whether the matcher matches alerts without its label
+func (m Matcher) matchesEmpty() bool {
+	matches := m.Value == ""
+	if m.IsRegex {
+		matches = regexp.MustCompile("^(?:" + m.Value + ")$").MatchString("")
+	}
+	return matches == m.IsEqual
+}
+
+// ActiveAlert is an alert firing in Alertmanager
+type ActiveAlert struct {
+	Fingerprint  string            `json:"fingerprint"`
+	AlertName    string            `json:"alertname,omitempty"`
+	State        string            `json:"state"`
+	Severity     string            `json:"severity,omitempty"`
+	Summary      string            `json:"summary,omitempty"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"starts_at"`
+	SilencedBy   []string          `json:"silenced_by,omitempty"`
+	InhibitedBy  []string          `json:"inhibited_by,omitempty"`
+	Receivers    []string          `json:"receivers,omitempty"`
+	GeneratorURL string            `json:"generator_url,omitempty"`
+}
+
+// gettableAlert is an alert of the Alertmanager API
+type gettableAlert struct {
+	Fingerprint  string            `json:"fingerprint"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Receivers    []struct {
+		Name string `json:"name"`
+	} `json:"receivers"`
+	Status struct {
+		State       string   `json:"state"`
+		SilencedBy  []string `json:"silencedBy"`
+		InhibitedBy []string `json:"inhibitedBy"`
+	} `json:"status"`
+}
+
+// AlertmanagerSilence is a silence of Alertmanager
+type AlertmanagerSilence struct {
+	ID        string    `json:"id"`
+	State     string    `json:"state"`
+	Matchers  []Matcher `json:"matchers"`
+	Selector  string    `json:"selector"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	CreatedBy string    `json:"created_by"`
+	Comment   string    `json:"comment"`
+}
+
+// gettableSilence is a silence of the Alertmanager API
+type gettableSilence struct {
+	ID        string    `json:"id"`
+	Matchers  []Matcher `json:"matchers"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedBy string    `json:"createdBy"`
+	Comment   string    `json:"comment"`
+	Status    struct {
+		State string `json:"state"`
+	} `json:"status"`
+}
+
+// postableSilence creates a silence with the Alertmanager API
+type postableSilence struct {
+	Matchers  []Matcher `json:"matchers"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedBy string    `json:"createdBy"`
+	Comment   string    `json:"comment"`
+}
+
+// selector renders matchers in the selector syntax
+func selector(matchers []Matcher) string {
+	parts := make([]string, len(matchers))
+	for i, matcher := range matchers {
+		parts[i] = matcher.String()
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// newAlertmanagerClient returns an API client of the named Alertmanager
+// datasource, or of alertmanagerURL when given
+func newAlertmanagerClient(ctx context.Context, datasource, alertmanagerURL string) (api.Client, error) {
+	if alertmanagerURL != "" {
+		if err := security.ValidateURL(alertmanagerURL); err != nil {
+			return nil, fmt.Errorf("invalid Alertmanager URL: %v", err)
+		}
+	}
+	client, _, err := newDatasourceClient(ctx, datasources.TypeAlertmanager, "alertmanager_url", datasource, alertmanagerURL)
+	return client, err
+}
+
+// alertmanagerRequest performs an Alertmanager API request, decoding the
+// response into result unless it is nil
+func alertmanagerRequest(ctx context.Context, client api.Client, method, path string, query url.Values, body, result interface{}) error {
+	var (
+		resp *http.Response
+		data []byte
+		err  error
+	)
+	if method == http.MethodGet {
+		resp, data, err = getAPIResponse(ctx, client, path, query)
+	} else {
+		resp, data, err = sendAPIRequest(ctx, client, method, path, body)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query Alertmanager: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API error from Alertmanager (%d): %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to parse Alertmanager response: %w", err)
+	}
+	return nil
+}
+
+// listAlerts returns the alerts of Alertmanager matching the filter
+func listAlerts(ctx context.Context, client api.Client, filter []Matcher, silenced, inhibited bool) ([]ActiveAlert, error) {
+	query := url.Values{}
+	query.Set("active", "true")
+	query.Set("silenced", strconv.FormatBool(silenced))
+	query.Set("inhibited", strconv.FormatBool(inhibited))
+	for _, matcher := range filter {
+		query.Add("filter", matcher.String())
+	}
+
+	var gettable []gettableAlert
+	if err := alertmanagerRequest(ctx, client, http.MethodGet, "/api/v2/alerts", query, nil, &gettable); err != nil {
+		return nil, err
+	}
+	alerts := make([]ActiveAlert, 0, len(gettable))
+	for _, alert := range gettable {
+		active := ActiveAlert{
+			Fingerprint:  alert.Fingerprint,
+			AlertName:    alert.Labels["alertname"],
+			State:        alert.Status.State,
+			Severity:     alert.Labels["severity"],
+			Summary:      alert.Annotations["summary"],
+			Labels:       alert.Labels,
+			Annotations:  alert.Annotations,
+			StartsAt:     alert.StartsAt,
+			SilencedBy:   alert.Status.SilencedBy,
+			InhibitedBy:  alert.Status.InhibitedBy,
+			GeneratorURL: alert.GeneratorURL,
+		}
+		for _, receiver := range alert.Receivers {
+			active.Receivers = append(active.Receivers, receiver.Name)
+		}
+		alerts = append(alerts, active)
+	}
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].StartsAt.After(alerts[j].StartsAt) })
+	return alerts, nil
+}
+
+// silenceAttribution returns who a silence is created by: the caller's
+// created_by, or the tenant, and the comment annotated with the session
+func silenceAttribution(ctx context.Context, createdBy, comment string) (string, string) {
+	if createdBy == "" {
+		if profile := tenancy.FromContext(ctx); profile != nil {
+			createdBy = profile.Name
+		} else {
+			createdBy = defaultSilenceCreator
+		}
+	}
+	if session := server.ClientSessionFromContext(ctx); session != nil && session.SessionID() != "" {
+		comment = fmt.Sprintf("%s (created via kagent-tools, session %s)", comment, session.SessionID())
+	} else {
+		comment += " (created via kagent-tools)"
+	}
+	return createdBy, comment
+}
+
+// handleAlertmanagerListAlerts lists the active alerts of Alertmanager
+func handleAlertmanagerListAlerts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	filter := p.String("filter", "")
+	silenced := p.Bool("include_silenced", false)
+	inhibited := p.Bool("include_inhibited", false)
+	datasource := p.String("datasource", "")
+	alertmanagerURL := p.String("alertmanager_url", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var matchers []Matcher
+	if filter != "" {
+		var err error
+		if matchers, err = parseMatchers(filter); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid filter: %v", err)), nil
+		}
+	}
+	client, err := newAlertmanagerClient(ctx, datasource, alertmanagerURL)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	alerts, err := listAlerts(ctx, client, matchers, silenced, inhibited)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	resultJSON, err := json.MarshalIndent(map[string]interface{}{"total": len(alerts), "alerts": alerts}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal alerts: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleAlertmanagerListSilences lists the silences of Alertmanager
+func handleAlertmanagerListSilences(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	state := p.String("state", "active", params.OneOf("active", "pending", "expired", "all"))
+	datasource := p.String("datasource", "")
+	alertmanagerURL := p.String("alertmanager_url", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, err := newAlertmanagerClient(ctx, datasource, alertmanagerURL)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var gettable []gettableSilence
+	if err := alertmanagerRequest(ctx, client, http.MethodGet, "/api/v2/silences", nil, nil, &gettable); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	silences := []AlertmanagerSilence{}
+	for _, silence := range gettable {
+		if state != "all" && silence.Status.State != state {
+			continue
+		}
+		silences = append(silences, AlertmanagerSilence{
+			ID:        silence.ID,
+			State:     silence.Status.State,
+			Matchers:  silence.Matchers,
+			Selector:  selector(silence.Matchers),
+			StartsAt:  silence.StartsAt,
+			EndsAt:    silence.EndsAt,
+			CreatedBy: silence.CreatedBy,
+			Comment:   silence.Comment,
+		})
+	}
+	sort.SliceStable(silences, func(i, j int) bool { return silences[i].EndsAt.Before(silences[j].EndsAt) })
+
+	resultJSON, err := json.MarshalIndent(map[string]interface{}{"total": len(silences), "silences": silences}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal silences: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleAlertmanagerCreateSilence silences the alerts matching the matchers,
+// or the labels of an active alert, for a duration
+func handleAlertmanagerCreateSilence(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	matchersParam := p.String("matchers", "")
+	fingerprint := p.String("alert_fingerprint", "")
+	startsAtParam := p.String("starts_at", "")
+	endsAtParam := p.String("ends_at", "")
+	duration := p.Duration("duration", 0)
+	comment := p.String("comment", "", params.Required())
+	createdBy := p.String("created_by", "")
+	datasource := p.String("datasource", "")
+	alertmanagerURL := p.String("alertmanager_url", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if (matchersParam == "") == (fingerprint == "") {
+		return mcp.NewToolResultError("give either matchers or alert_fingerprint"), nil
+	}
+
+	startsAt := time.Now()
+	if startsAtParam != "" {
+		var err error
+		if startsAt, err = time.Parse(time.RFC3339, startsAtParam); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("starts_at must be an RFC 3339 time: %v", err)), nil
+		}
+	}
+	var endsAt time.Time
+	switch {
+	case endsAtParam != "" && duration > 0:
+		return mcp.NewToolResultError("give either ends_at or duration, not both"), nil
+	case endsAtParam != "":
+		var err error
+		if endsAt, err = time.Parse(time.RFC3339, endsAtParam); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("ends_at must be an RFC 3339 time: %v", err)), nil
+		}
+	case duration > 0:
+		endsAt = startsAt.Add(duration)
+	default:
+		return mcp.NewToolResultError("ends_at or duration is required"), nil
+	}
+	if !endsAt.After(startsAt) {
+		return mcp.NewToolResultError("the silence must end after it starts"), nil
+	}
+	if !endsAt.After(time.Now()) {
+		return mcp.NewToolResultError("the silence has already ended"), nil
+	}
+	if endsAt.Sub(startsAt) > maxSilenceDuration {
+		return mcp.NewToolResultError(fmt.Sprintf("the silence must not last longer than %s", maxSilenceDuration)), nil
+	}
+
+	var matchers []Matcher
+	if matchersParam != "" {
+		var err error
+		if matchers, err = parseMatchers(matchersParam); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid matchers: %v", err)), nil
+		}
+	}
+	client, err := newAlertmanagerClient(ctx, datasource, alertmanagerURL)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Silencing an alert matches all of its labels, so only that alert is silenced
+	if fingerprint != "" {
+		alerts, err := listAlerts(ctx, client, nil, true, true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		for _, alert := range alerts {
+			if alert.Fingerprint != fingerprint {
+				continue
+			}
+			names := make([]string, 0, len(alert.Labels))
+			for name := range alert.Labels {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				matchers = append(matchers, Matcher{Name: name, Value: alert.Labels[name], IsEqual: true})
+			}
+		}
+		if len(matchers) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("no active alert with fingerprint %s; list them with alertmanager_list_alerts", fingerprint)), nil
+		}
+	}
+
+	createdBy, comment = silenceAttribution(ctx, createdBy, comment)
+	silence := postableSilence{
+		Matchers:  matchers,
+		StartsAt:  startsAt.UTC(),
+		EndsAt:    endsAt.UTC(),
+		CreatedBy: createdBy,
+		Comment:   comment,
+	}
+	var created struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := alertmanagerRequest(ctx, client, http.MethodPost, "/api/v2/silences", nil, silence, &created); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(AlertmanagerSilence{
+		ID:        created.SilenceID,
+		Matchers:  matchers,
+		Selector:  selector(matchers),
+		StartsAt:  silence.StartsAt,
+		EndsAt:    silence.EndsAt,
+		CreatedBy: silence.CreatedBy,
+		Comment:   silence.Comment,
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal silence: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleAlertmanagerExpireSilence expires a silence, ending it early
+func handleAlertmanagerExpireSilence(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	silenceID := p.String("silence_id", "", params.Required(), params.Check(validateSilenceID))
+	datasource := p.String("datasource", "")
+	alertmanagerURL := p.String("alertmanager_url", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, err := newAlertmanagerClient(ctx, datasource, alertmanagerURL)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := alertmanagerRequest(ctx, client, http.MethodDelete, "/api/v2/silence/"+url.PathEscape(silenceID), nil, nil, nil); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Silence %s expired", silenceID)), nil
+}
+
+// silenceIDPattern matches the UUIDs Alertmanager identifies silences with
+var silenceIDPattern = regexp.MustCompile(`^[0-9a-fA-F-]{1,64}$`)
+
+// validateSilenceID checks that a silence ID is a UUID
+func validateSilenceID(id string) error {
+	if !silenceIDPattern.MatchString(id) {
+		return fmt.Errorf("silence_id must be the UUID of a silence")
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/tenancy"
+)
+
+func TestParseMatchers(t *testing.T) {
+	matchers, err := parseMatchers(`{alertname="HighLatency", namespace=~"prod|staging", pod!="web-0", cluster!~"dev-.*"}`)
+	require.NoError(t, err)
+	assert.Equal(t, []Matcher{
+		{Name: "alertname", Value: "HighLatency", IsEqual: true},
+		{Name: "namespace", Value: "prod|staging", IsRegex: true, IsEqual: true},
+		{Name: "pod", Value: "web-0"},
+		{Name: "cluster", Value: "dev-.*", IsRegex: true},
+	}, matchers)
+	assert.Equal(t, `{alertname="HighLatency", namespace=~"prod|staging", pod!="web-0", cluster!~"dev-.*"}`, selector(matchers))
+
+	matchers, err = parseMatchers(`alertname=KubePodCrashLooping, summary="a, b"`)
+	require.NoError(t, err)
+	assert.Equal(t, "KubePodCrashLooping", matchers[0].Value)
+	assert.Equal(t, "a, b", matchers[1].Value)
+
+	for selector, message := range map[string]string{
+		"":                         "at least one matcher is required",
+		`{alertname}`:              "expected =, !=, =~ or !~ after label alertname",
+		`alertname="a" severity=b`: "expected , after the matcher of label alertname",
+		`alertname="unterminated`:  "invalid quoted value",
+		`namespace=~"("`:           "invalid regular expression",
+		`1abc="x"`:                 "expected a label name",
+		`alertname=~".*"`:          "must not match the empty string",
+		`severity!="critical"`:     "must not match the empty string",
+	} {
+		_, err := parseMatchers(selector)
+		if assert.Error(t, err, selector) {
+			assert.Contains(t, err.Error(), message, selector)
+		}
+	}
+}
+
+// fakeAlertmanager serves the alerts and silences endpoints of the Alertmanager API
+type fakeAlertmanager struct {
+	alertsQuery string
+	posted      postableSilence
+	expired     string
+}
+
+func (f *fakeAlertmanager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/alerts":
+		f.alertsQuery = r.URL.RawQuery
+		_, _ = w.Write([]byte(`[
+			{"fingerprint": "a1", "labels": {"alertname": "HighLatency", "namespace": "prod", "severity": "warning"},
+			 "annotations": {"summary": "p99 above 1s"}, "startsAt": "2026-10-16T08:00:00Z",
+			 "receivers": [{"name": "oncall"}], "status": {"state": "active", "silencedBy": [], "inhibitedBy": []}},
+			{"fingerprint": "b2", "labels": {"alertname": "KubePodCrashLooping", "namespace": "prod", "pod": "web-0"},
+			 "annotations": {}, "startsAt": "2026-10-16T09:00:00Z",
+			 "receivers": [{"name": "oncall"}], "status": {"state": "active", "silencedBy": [], "inhibitedBy": []}}
+		]`))
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/silences":
+		_, _ = w.Write([]byte(`[
+			{"id": "6f1c", "matchers": [{"name": "alertname", "value": "HighLatency", "isRegex": false, "isEqual": true}],
+			 "startsAt": "2026-10-16T08:00:00Z", "endsAt": "2026-10-16T10:00:00Z", "createdBy": "alice", "comment": "deploy", "status": {"state": "active"}},
+			{"id": "0b2d", "matchers": [{"name": "namespace", "value": "dev", "isRegex": false, "isEqual": true}],
+			 "startsAt": "2026-10-15T08:00:00Z", "endsAt": "2026-10-15T10:00:00Z", "createdBy": "bob", "comment": "test", "status": {"state": "expired"}}
+		]`))
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/silences":
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &f.posted)
+		_, _ = w.Write([]byte(`{"silenceID": "9a8b7c6d-0000-4000-8000-000000000001"}`))
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v2/silence/"):
+		f.expired = strings.TrimPrefix(r.URL.Path, "/api/v2/silence/")
+		if f.expired == "00000000-0000-0000-0000-000000000000" {
+			http.Error(w, "silence not found", http.StatusNotFound)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestAlertmanagerTools(t *testing.T) {
+	fake := &fakeAlertmanager{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	call := func(ctx context.Context, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) *mcp.CallToolResult {
+		args["alertmanager_url"] = server.URL
+		result, err := handler(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("list alerts", func(t *testing.T) {
+		result := call(context.Background(), handleAlertmanagerListAlerts, map[string]interface{}{"filter": `namespace="prod"`, "include_silenced": "true"})
+		require.False(t, result.IsError, getResultText(result))
+		assert.Contains(t, fake.alertsQuery, "filter=namespace%3D%22prod%22")
+		assert.Contains(t, fake.alertsQuery, "silenced=true")
+		assert.Contains(t, fake.alertsQuery, "inhibited=false")
+
+		var list struct {
+			Total  int           `json:"total"`
+			Alerts []ActiveAlert `json:"alerts"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &list))
+		require.Equal(t, 2, list.Total)
+		assert.Equal(t, "KubePodCrashLooping", list.Alerts[0].AlertName)
+		assert.Equal(t, "HighLatency", list.Alerts[1].AlertName)
+		assert.Equal(t, "p99 above 1s", list.Alerts[1].Summary)
+		assert.Equal(t, []string{"oncall"}, list.Alerts[1].Receivers)
+	})
+
+	t.Run("list silences", func(t *testing.T) {
+		result := call(context.Background(), handleAlertmanagerListSilences, map[string]interface{}{})
+		require.False(t, result.IsError, getResultText(result))
+		var list struct {
+			Total    int                   `json:"total"`
+			Silences []AlertmanagerSilence `json:"silences"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &list))
+		require.Equal(t, 1, list.Total)
+		assert.Equal(t, "6f1c", list.Silences[0].ID)
+		assert.Equal(t, `{alertname="HighLatency"}`, list.Silences[0].Selector)
+
+		result = call(context.Background(), handleAlertmanagerListSilences, map[string]interface{}{"state": "all"})
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &list))
+		assert.Equal(t, 2, list.Total)
+	})
+
+	t.Run("create silence from matchers", func(t *testing.T) {
+		ctx := tenancy.WithProfile(context.Background(), &tenancy.Profile{Name: "team-a"})
+		result := call(ctx, handleAlertmanagerCreateSilence, map[string]interface{}{
+			"matchers": `{alertname="HighLatency", namespace="prod"}`,
+			"duration": "2h",
+			"comment":  "fixing the slow query",
+		})
+		require.False(t, result.IsError, getResultText(result))
+
+		assert.Len(t, fake.posted.Matchers, 2)
+		assert.Equal(t, "team-a", fake.posted.CreatedBy)
+		assert.Equal(t, "fixing the slow query (created via kagent-tools)", fake.posted.Comment)
+		assert.InDelta(t, 2*time.Hour, fake.posted.EndsAt.Sub(fake.posted.StartsAt), float64(time.Second))
+
+		var silence AlertmanagerSilence
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &silence))
+		assert.Equal(t, "9a8b7c6d-0000-4000-8000-000000000001", silence.ID)
+	})
+
+	t.Run("create silence from an alert", func(t *testing.T) {
+		result := call(context.Background(), handleAlertmanagerCreateSilence, map[string]interface{}{
+			"alert_fingerprint": "b2",
+			"duration":          "30m",
+			"comment":           "rolling back",
+			"created_by":        "alice",
+		})
+		require.False(t, result.IsError, getResultText(result))
+		assert.Equal(t, "alice", fake.posted.CreatedBy)
+		assert.Equal(t, `{alertname="KubePodCrashLooping", namespace="prod", pod="web-0"}`, selector(fake.posted.Matchers))
+	})
+
+	t.Run("invalid silences", func(t *testing.T) {
+		for message, args := range map[string]map[string]interface{}{
+			"give either matchers or alert_fingerprint": {"duration": "1h", "comment": "x"},
+			"ends_at or duration is required":           {"matchers": `alertname="a"`, "comment": "x"},
+			"must not last longer than":                 {"matchers": `alertname="a"`, "duration": "200h", "comment": "x"},
+			"must not match the empty string":           {"matchers": `alertname=~".*"`, "duration": "1h", "comment": "x"},
+			"no active alert with fingerprint ffff":     {"alert_fingerprint": "ffff", "duration": "1h", "comment": "x"},
+			"comment parameter is required":             {"matchers": `alertname="a"`, "duration": "1h"},
+			"give either ends_at or duration, not both": {"matchers": `alertname="a"`, "duration": "1h", "ends_at": "2030-01-01T00:00:00Z", "comment": "x"},
+			"starts_at must be an RFC 3339 time":        {"matchers": `alertname="a"`, "duration": "1h", "starts_at": "now", "comment": "x"},
+			"set either datasource or alertmanager_url": {"matchers": `alertname="a"`, "duration": "1h", "comment": "x", "datasource": "prod"},
+			"the silence has already ended":             {"matchers": `alertname="a"`, "ends_at": "2020-01-01T00:00:00Z", "starts_at": "2019-12-31T00:00:00Z", "comment": "x"},
+		} {
+			result := call(context.Background(), handleAlertmanagerCreateSilence, args)
+			assert.True(t, result.IsError, message)
+			assert.Contains(t, getResultText(result), message)
+		}
+	})
+
+	t.Run("expire silence", func(t *testing.T) {
+		result := call(context.Background(), handleAlertmanagerExpireSilence, map[string]interface{}{"silence_id": "6f1c"})
+		require.False(t, result.IsError, getResultText(result))
+		assert.Equal(t, "6f1c", fake.expired)
+
+		result = call(context.Background(), handleAlertmanagerExpireSilence, map[string]interface{}{"silence_id": "00000000-0000-0000-0000-000000000000"})
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "404")
+
+		result = call(context.Background(), handleAlertmanagerExpireSilence, map[string]interface{}{"silence_id": "../alerts"})
+		assert.True(t, result.IsError)
+	})
+}
@@ -0,0 +1,386 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// Defaults of prometheus_cardinality
+const (
+	defaultCardinalityLimit     = 10
+	defaultLabelValueThreshold  = 1000
+	defaultCardinalityBreakdown = 5
+)
+
+// unboundedLabelName matches label names that usually hold unbounded values,
+// such as identifiers, addresses and raw request paths
+var unboundedLabelName = regexp.MustCompile(`(?i)(^|_)(id|uuid|guid|user|email|session|trace|span|request|path|url|uri|ip|address|query|hash|timestamp)($|_)`)
+
+// ignoredCardinalityLabels are labels every series carries, which are not label explosions
+var ignoredCardinalityLabels = map[string]bool{"__name__": true}
+
+// LabelCardinality is the number of distinct values of a label
+type LabelCardinality struct {
+	Name        string `json:"name"`
+	Values      int64  `json:"values"`
+	MemoryBytes int64  `json:"memory_bytes,omitempty"`
+	Flagged     bool   `json:"flagged,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// JobSeries is the number of series of a metric scraped by a job
+type JobSeries struct {
+	Job    string `json:"job"`
+	Series int64  `json:"series"`
+}
+
+// LabelPairSeries is the number of series carrying a label pair
+type LabelPairSeries struct {
+	Pair   string `json:"pair"`
+	Series int64  `json:"series"`
+}
+
+// MetricCardinality is the series count of a metric, with the jobs and labels producing them
+type MetricCardinality struct {
+	Name   string             `json:"name"`
+	Series int64              `json:"series"`
+	Share  float64            `json:"share"`
+	Jobs   []JobSeries        `json:"jobs,omitempty"`
+	Labels []LabelCardinality `json:"labels,omitempty"`
+}
+
+// ScrapeJobCardinality is the number of samples a scrape job ingests per scrape
+type ScrapeJobCardinality struct {
+	Job          string   `json:"job"`
+	ScrapePools  []string `json:"scrape_pools,omitempty"`
+	Samples      int64    `json:"samples"`
+	SeriesAdded  int64    `json:"series_added"`
+	SampleLimit  int64    `json:"sample_limit,omitempty"`
+	LabelLimit   int64    `json:"label_limit,omitempty"`
+	Responsible  bool     `json:"responsible,omitempty"`
+	Observations []string `json:"observations,omitempty"`
+}
+
+// CardinalityReport is the structured response of prometheus_cardinality
+type CardinalityReport struct {
+	HeadSeries     int64                  `json:"head_series"`
+	HeadLabelPairs int64                  `json:"head_label_pairs"`
+	HeadChunks     int64                  `json:"head_chunks"`
+	TopMetrics     []MetricCardinality    `json:"top_metrics"`
+	TopLabels      []LabelCardinality     `json:"top_labels"`
+	TopLabelPairs  []LabelPairSeries      `json:"top_label_pairs,omitempty"`
+	ScrapeJobs     []ScrapeJobCardinality `json:"scrape_jobs,omitempty"`
+	Findings       []string               `json:"findings"`
+	Notes          []string               `json:"notes,omitempty"`
+}
+
+// scrapeConfigFile is the subset of the Prometheus configuration used to find scrape limits
+type scrapeConfigFile struct {
+	ScrapeConfigs []scrapeConfig `yaml:"scrape_configs"`
+}
+
+type scrapeConfig struct {
+	JobName     string `yaml:"job_name"`
+	SampleLimit int64  `yaml:"sample_limit"`
+	LabelLimit  int64  `yaml:"label_limit"`
+}
+
+// seriesValues returns the integer value of each series of an instant query, keyed by label
+func seriesValues(ctx context.Context, client api.Client, query, label string) (map[string]int64, error) {
+	result, err := runInstantQuery(ctx, client, query, UnitNone)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]int64{}
+	for _, series := range result.Series {
+		if series.Value != nil && series.Value.Value != nil {
+			values[series.Metric[label]] += int64(*series.Value.Value)
+		}
+	}
+	return values, nil
+}
+
+// flagLabel marks a label whose value count reaches the threshold as an explosion
+func flagLabel(label *LabelCardinality, threshold int64) {
+	if label.Values < threshold {
+		return
+	}
+	label.Flagged = true
+	label.Reason = fmt.Sprintf("%d distinct values (threshold %d)", label.Values, threshold)
+	if unboundedLabelName.MatchString(label.Name) {
+		label.Reason += "; the name suggests unbounded values such as IDs, paths or addresses, which belong in logs or traces rather than labels"
+	}
+}
+
+// cardinalityAnalysis collects a cardinality report from the Prometheus API
+type cardinalityAnalysis struct {
+	ctx       context.Context
+	client    api.Client
+	api       v1.API
+	threshold int64
+	report    *CardinalityReport
+}
+
+func (a *cardinalityAnalysis) note(format string, args ...interface{}) {
+	a.report.Notes = append(a.report.Notes, fmt.Sprintf(format, args...))
+}
+
+// breakDownMetrics attributes the series of the top metrics to jobs and finds their exploding labels
+func (a *cardinalityAnalysis) breakDownMetrics(metrics []MetricCardinality) {
+	names := make([]string, 0, len(metrics))
+	for _, metric := range metrics {
+		names = append(names, metric.Name)
+	}
+	query := fmt.Sprintf(`count by (__name__, job) ({__name__=~"%s"})`, strings.Join(names, "|"))
+	result, err := runInstantQuery(a.ctx, a.client, query, UnitNone)
+	if err != nil {
+		a.note("series of the top metrics could not be attributed to jobs: %v", err)
+	} else {
+		for _, series := range result.Series {
+			for i := range metrics {
+				if metrics[i].Name == series.Metric["__name__"] && series.Value != nil && series.Value.Value != nil {
+					metrics[i].Jobs = append(metrics[i].Jobs, JobSeries{Job: series.Metric["job"], Series: int64(*series.Value.Value)})
+				}
+			}
+		}
+	}
+
+	for i := range metrics {
+		metric := &metrics[i]
+		sort.Slice(metric.Jobs, func(x, y int) bool { return metric.Jobs[x].Series > metric.Jobs[y].Series })
+
+		labels, _, err := a.api.LabelNames(a.ctx, []string{metric.Name}, time.Time{}, time.Time{})
+		if err != nil {
+			a.note("labels of %s could not be listed: %v", metric.Name, err)
+			continue
+		}
+		for _, label := range labels {
+			if ignoredCardinalityLabels[label] {
+				continue
+			}
+			values, err := seriesValues(a.ctx, a.client, fmt.Sprintf(`count(count by (%s) (%s))`, label, metric.Name), "")
+			if err != nil {
+				a.note("values of label %s on %s could not be counted: %v", label, metric.Name, err)
+				continue
+			}
+			cardinality := LabelCardinality{Name: label, Values: values[""]}
+			flagLabel(&cardinality, a.threshold)
+			metric.Labels = append(metric.Labels, cardinality)
+		}
+		sort.Slice(metric.Labels, func(x, y int) bool { return metric.Labels[x].Values > metric.Labels[y].Values })
+	}
+}
+
+// scrapeJobs reports the samples each job ingests per scrape, the scrape pools
+// producing the job and their limits, marking the jobs responsible for the top metrics
+func (a *cardinalityAnalysis) scrapeJobs(limit int, metrics []MetricCardinality) []ScrapeJobCardinality {
+	samples, err := seriesValues(a.ctx, a.client, fmt.Sprintf(`topk(%d, sum by (job) (scrape_samples_post_metric_relabeling))`, limit), "job")
+	if err != nil {
+		a.note("samples per scrape job could not be queried: %v", err)
+		return nil
+	}
+	added, err := seriesValues(a.ctx, a.client, `sum by (job) (scrape_series_added)`, "job")
+	if err != nil {
+		a.note("series added per scrape job could not be queried: %v", err)
+	}
+
+	responsible := map[string]bool{}
+	for _, metric := range metrics {
+		if len(metric.Jobs) > 0 && float64(metric.Jobs[0].Series) >= 0.5*float64(metric.Series) {
+			responsible[metric.Jobs[0].Job] = true
+		}
+	}
+	for job := range responsible {
+		if _, ok := samples[job]; !ok {
+			samples[job] = 0
+		}
+	}
+
+	// The targets API maps job labels to the scrape configs producing them, which
+	// differ when jobs are relabeled, e.g. by the Prometheus Operator
+	pools := map[string][]string{}
+	targets, err := a.api.Targets(a.ctx)
+	if err != nil {
+		a.note("scrape pools could not be listed: %v", err)
+	}
+	for _, target := range targets.Active {
+		job := string(target.Labels["job"])
+		if !containsPool(pools[job], target.ScrapePool) {
+			pools[job] = append(pools[job], target.ScrapePool)
+		}
+	}
+
+	limits := map[string]scrapeConfig{}
+	var file scrapeConfigFile
+	config, err := a.api.Config(a.ctx)
+	if err != nil {
+		a.note("scrape limits could not be read from the configuration: %v", err)
+	} else if err := yaml.Unmarshal([]byte(config.YAML), &file); err != nil {
+		a.note("scrape limits could not be parsed from the configuration: %v", err)
+	}
+	for _, config := range file.ScrapeConfigs {
+		limits[config.JobName] = config
+	}
+
+	jobs := make([]ScrapeJobCardinality, 0, len(samples))
+	for job, count := range samples {
+		entry := ScrapeJobCardinality{Job: job, ScrapePools: pools[job], Samples: count, SeriesAdded: added[job], Responsible: responsible[job]}
+		sort.Strings(entry.ScrapePools)
+		for _, pool := range entry.ScrapePools {
+			config, ok := limits[pool]
+			if !ok {
+				continue
+			}
+			if config.SampleLimit > 0 {
+				entry.SampleLimit = config.SampleLimit
+				if float64(count) >= 0.8*float64(config.SampleLimit) {
+					entry.Observations = append(entry.Observations, fmt.Sprintf("scrape pool %s is at %d%% of its sample_limit %d; scrapes exceeding it fail entirely", pool, count*100/config.SampleLimit, config.SampleLimit))
+				}
+			} else if entry.Responsible {
+				entry.Observations = append(entry.Observations, fmt.Sprintf("scrape pool %s sets no sample_limit, so a cardinality explosion in its targets is ingested unbounded", pool))
+			}
+			if config.LabelLimit > 0 {
+				entry.LabelLimit = config.LabelLimit
+			}
+		}
+		if entry.SeriesAdded > 0 && count > 0 && float64(entry.SeriesAdded) >= 0.1*float64(count) {
+			entry.Observations = append(entry.Observations, fmt.Sprintf("%d new series per scrape (%d%% of its samples) indicates series churn, e.g. from labels holding pod names or timestamps", entry.SeriesAdded, entry.SeriesAdded*100/count))
+		}
+		jobs = append(jobs, entry)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].Samples != jobs[j].Samples {
+			return jobs[i].Samples > jobs[j].Samples
+		}
+		return jobs[i].Job < jobs[j].Job
+	})
+	return jobs
+}
+
+func containsPool(pools []string, pool string) bool {
+	for _, p := range pools {
+		if p == pool {
+			return true
+		}
+	}
+	return false
+}
+
+// cardinalityFindings summarizes the metrics, labels and scrape jobs driving the series count
+func cardinalityFindings(report *CardinalityReport) []string {
+	findings := []string{}
+	for _, metric := range report.TopMetrics {
+		var exploding []string
+		for _, label := range metric.Labels {
+			if label.Flagged {
+				exploding = append(exploding, fmt.Sprintf("%s (%d values)", label.Name, label.Values))
+			}
+		}
+		// Metrics below 5% of the head series only matter when their labels explode
+		if metric.Share < 0.05 && len(exploding) == 0 {
+			continue
+		}
+		finding := fmt.Sprintf("%s has %d series (%.1f%% of head series)", metric.Name, metric.Series, metric.Share*100)
+		if len(metric.Jobs) > 0 {
+			finding += fmt.Sprintf(", %d from job %s", metric.Jobs[0].Series, metric.Jobs[0].Job)
+		}
+		if len(exploding) > 0 {
+			finding += "; exploding labels: " + strings.Join(exploding, ", ")
+		}
+		findings = append(findings, finding)
+	}
+	for _, label := range report.TopLabels {
+		if label.Flagged {
+			findings = append(findings, fmt.Sprintf("label %s: %s", label.Name, label.Reason))
+		}
+	}
+	for _, job := range report.ScrapeJobs {
+		for _, observation := range job.Observations {
+			findings = append(findings, fmt.Sprintf("job %s: %s", job.Job, observation))
+		}
+	}
+	return findings
+}
+
+// Prometheus metric cardinality report
+func handlePrometheusCardinality(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	prometheusURL := p.String("prometheus_url", "", params.Check(security.ValidateURL))
+	datasource := p.String("datasource", "")
+	limit := p.Int("limit", defaultCardinalityLimit, params.Range(1, 100))
+	threshold := p.Int("label_value_threshold", defaultLabelValueThreshold, params.Min(1))
+	breakdown := p.Int("breakdown", defaultCardinalityBreakdown, params.Range(0, 20))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, _, err := newClient(ctx, datasource, prometheusURL)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	prometheusAPI := v1.NewAPI(client)
+	status, err := prometheusAPI.TSDB(ctx, v1.WithLimit(uint64(limit)))
+	if err != nil {
+		return mcp.NewToolResultError("failed to get TSDB status: " + err.Error()), nil
+	}
+
+	report := &CardinalityReport{
+		HeadSeries:     int64(status.HeadStats.NumSeries),
+		HeadLabelPairs: int64(status.HeadStats.NumLabelPairs),
+		HeadChunks:     int64(status.HeadStats.ChunkCount),
+		TopMetrics:     []MetricCardinality{},
+		TopLabels:      []LabelCardinality{},
+	}
+	a := &cardinalityAnalysis{ctx: ctx, client: client, api: prometheusAPI, threshold: int64(threshold), report: report}
+
+	for _, stat := range status.SeriesCountByMetricName {
+		metric := MetricCardinality{Name: stat.Name, Series: int64(stat.Value)}
+		if report.HeadSeries > 0 {
+			metric.Share = float64(stat.Value) / float64(report.HeadSeries)
+		}
+		report.TopMetrics = append(report.TopMetrics, metric)
+	}
+	memory := map[string]int64{}
+	for _, stat := range status.MemoryInBytesByLabelName {
+		memory[stat.Name] = int64(stat.Value)
+	}
+	for _, stat := range status.LabelValueCountByLabelName {
+		if ignoredCardinalityLabels[stat.Name] {
+			continue
+		}
+		label := LabelCardinality{Name: stat.Name, Values: int64(stat.Value), MemoryBytes: memory[stat.Name]}
+		flagLabel(&label, int64(threshold))
+		report.TopLabels = append(report.TopLabels, label)
+	}
+	for _, stat := range status.SeriesCountByLabelValuePair {
+		report.TopLabelPairs = append(report.TopLabelPairs, LabelPairSeries{Pair: stat.Name, Series: int64(stat.Value)})
+	}
+
+	if breakdown > len(report.TopMetrics) {
+		breakdown = len(report.TopMetrics)
+	}
+	if breakdown > 0 {
+		a.breakDownMetrics(report.TopMetrics[:breakdown])
+	}
+	report.ScrapeJobs = a.scrapeJobs(limit, report.TopMetrics[:breakdown])
+	report.Findings = cardinalityFindings(report)
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal cardinality report: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
@@ -0,0 +1,133 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTSDBStatus = `{"status":"success","data":{
+	"headStats":{"numSeries":200000,"numLabelPairs":60000,"chunkCount":400000},
+	"seriesCountByMetricName":[{"name":"http_request_duration_seconds_bucket","value":120000},{"name":"up","value":300}],
+	"labelValueCountByLabelName":[{"name":"__name__","value":900},{"name":"user_id","value":40000},{"name":"pod","value":350}],
+	"memoryInBytesByLabelName":[{"name":"user_id","value":1200000}],
+	"seriesCountByLabelValuePair":[{"name":"job=api","value":130000}]}}`
+
+func cardinalityClient(t *testing.T) *http.Client {
+	return &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		vector := func(result string) *http.Response {
+			return createMockResponse(http.StatusOK, `{"status":"success","data":{"resultType":"vector","result":[`+result+`]}}`)
+		}
+		query := req.URL.Query().Get("query")
+		switch {
+		case req.URL.Path == "/api/v1/status/tsdb":
+			assert.Equal(t, "10", req.URL.Query().Get("limit"))
+			return createMockResponse(http.StatusOK, testTSDBStatus), nil
+		case req.URL.Path == "/api/v1/labels":
+			assert.NoError(t, req.ParseForm())
+			if req.Form.Get("match[]") == "up" {
+				return createMockResponse(http.StatusOK, `{"status":"success","data":["__name__","instance","job"]}`), nil
+			}
+			return createMockResponse(http.StatusOK, `{"status":"success","data":["__name__","job","le","user_id"]}`), nil
+		case req.URL.Path == "/api/v1/targets":
+			return createMockResponse(http.StatusOK, `{"status":"success","data":{"activeTargets":[
+				{"scrapePool":"serviceMonitor/shop/api/0","labels":{"job":"api"}},
+				{"scrapePool":"serviceMonitor/shop/api/0","labels":{"job":"api"}},
+				{"scrapePool":"node","labels":{"job":"node"}}]}}`), nil
+		case req.URL.Path == "/api/v1/status/config":
+			return createMockResponse(http.StatusOK, `{"status":"success","data":{"yaml":"scrape_configs:\n- job_name: serviceMonitor/shop/api/0\n- job_name: node\n  sample_limit: 5000\n"}}`), nil
+		case strings.HasPrefix(query, "count by (__name__, job)"):
+			assert.Equal(t, `count by (__name__, job) ({__name__=~"http_request_duration_seconds_bucket|up"})`, query)
+			return vector(`{"metric":{"__name__":"http_request_duration_seconds_bucket","job":"api"},"value":[1700000000,"119000"]},
+				{"metric":{"__name__":"http_request_duration_seconds_bucket","job":"gateway"},"value":[1700000000,"1000"]},
+				{"metric":{"__name__":"up","job":"node"},"value":[1700000000,"300"]}`), nil
+		case query == "count(count by (user_id) (http_request_duration_seconds_bucket))":
+			return vector(`{"metric":{},"value":[1700000000,"39000"]}`), nil
+		case strings.HasPrefix(query, "count(count by ("):
+			return vector(`{"metric":{},"value":[1700000000,"12"]}`), nil
+		case strings.Contains(query, "scrape_samples_post_metric_relabeling"):
+			return vector(`{"metric":{"job":"api"},"value":[1700000000,"130000"]},{"metric":{"job":"node"},"value":[1700000000,"4500"]}`), nil
+		case strings.Contains(query, "scrape_series_added"):
+			return vector(`{"metric":{"job":"api"},"value":[1700000000,"26000"]},{"metric":{"job":"node"},"value":[1700000000,"0"]}`), nil
+		}
+		t.Fatalf("unexpected request %s", req.URL)
+		return nil, nil
+	})}
+}
+
+func TestHandlePrometheusCardinality(t *testing.T) {
+	t.Run("reports top metrics, exploding labels and responsible scrape jobs", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"prometheus_url": "http://prometheus:9090"}
+		result, err := handlePrometheusCardinality(contextWithMockClient(cardinalityClient(t)), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var report CardinalityReport
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &report))
+		assert.Equal(t, int64(200000), report.HeadSeries)
+		assert.Empty(t, report.Notes)
+
+		require.Len(t, report.TopMetrics, 2)
+		histogram := report.TopMetrics[0]
+		assert.Equal(t, 0.6, histogram.Share)
+		assert.Equal(t, []JobSeries{{Job: "api", Series: 119000}, {Job: "gateway", Series: 1000}}, histogram.Jobs)
+		require.Len(t, histogram.Labels, 3)
+		assert.Equal(t, "user_id", histogram.Labels[0].Name)
+		assert.True(t, histogram.Labels[0].Flagged)
+		assert.Contains(t, histogram.Labels[0].Reason, "unbounded values")
+		assert.False(t, histogram.Labels[1].Flagged)
+
+		require.Len(t, report.TopLabels, 2)
+		assert.Equal(t, "user_id", report.TopLabels[0].Name)
+		assert.Equal(t, int64(1200000), report.TopLabels[0].MemoryBytes)
+		assert.True(t, strings.HasPrefix(report.TopLabels[0].Reason, "40000 distinct values (threshold 1000)"))
+		assert.False(t, report.TopLabels[1].Flagged)
+		assert.Equal(t, []LabelPairSeries{{Pair: "job=api", Series: 130000}}, report.TopLabelPairs)
+
+		require.Len(t, report.ScrapeJobs, 2)
+		assert.Equal(t, ScrapeJobCardinality{
+			Job: "api", ScrapePools: []string{"serviceMonitor/shop/api/0"}, Samples: 130000, SeriesAdded: 26000, Responsible: true,
+			Observations: []string{
+				"scrape pool serviceMonitor/shop/api/0 sets no sample_limit, so a cardinality explosion in its targets is ingested unbounded",
+				"26000 new series per scrape (20% of its samples) indicates series churn, e.g. from labels holding pod names or timestamps",
+			},
+		}, report.ScrapeJobs[0])
+		assert.Equal(t, int64(5000), report.ScrapeJobs[1].SampleLimit)
+		assert.Equal(t, []string{"scrape pool node is at 90% of its sample_limit 5000; scrapes exceeding it fail entirely"}, report.ScrapeJobs[1].Observations)
+
+		assert.Equal(t, "http_request_duration_seconds_bucket has 120000 series (60.0% of head series), 119000 from job api; exploding labels: user_id (39000 values)", report.Findings[0])
+		assert.Len(t, report.Findings, 5)
+	})
+
+	t.Run("TSDB status failure", func(t *testing.T) {
+		client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return createMockResponse(http.StatusServiceUnavailable, "unavailable"), nil
+		})}
+		request := mcp.CallToolRequest{}
+		result, err := handlePrometheusCardinality(contextWithMockClient(client), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, getResultText(result), "failed to get TSDB status")
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, args := range []map[string]interface{}{
+			{"limit": float64(0)},
+			{"breakdown": float64(50)},
+			{"prometheus_url": "ftp://prometheus"},
+		} {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = args
+			result, err := handlePrometheusCardinality(context.Background(), request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError, args)
+		}
+	})
+}
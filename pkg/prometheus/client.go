@@ -0,0 +1,84 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/prometheus/client_golang/api"
+
+	"github.com/kagent-dev/tools/internal/datasources"
+)
+
+// clientKey is the context key for the http client.
+type clientKey struct{}
+
+// newClient returns a Prometheus API client of the named Prometheus datasource,
+// or of prometheusURL when given. Credentials of the configured datasources are
+// never sent to URLs given by callers.
+func newClient(ctx context.Context, datasource, prometheusURL string) (api.Client, string, error) {
+	return newDatasourceClient(ctx, datasources.TypePrometheus, "prometheus_url", datasource, prometheusURL)
+}
+
+// newDatasourceClient returns an API client of the named datasource of a type,
+// or of address, given by the caller with the urlParam parameter, without credentials
+func newDatasourceClient(ctx context.Context, datasourceType, urlParam, datasource, address string) (api.Client, string, error) {
+	if datasource != "" && address != "" {
+		return nil, "", fmt.Errorf("set either datasource or %s, not both", urlParam)
+	}
+	roundTripper := api.DefaultRoundTripper
+	if address == "" {
+		ds, rt, err := datasources.Global().RoundTripper(datasourceType, datasource)
+		if err != nil {
+			return nil, "", err
+		}
+		address, roundTripper = ds.URL, rt
+	}
+
+	cfg := api.Config{Address: address, RoundTripper: roundTripper}
+	if client, ok := ctx.Value(clientKey{}).(*http.Client); ok && client != nil {
+		cfg.Client, cfg.RoundTripper = client, nil
+	}
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create %s client: %w", datasourceType, err)
+	}
+	return client, address, nil
+}
+
+// getAPIResponse performs a GET request of a Prometheus HTTP API endpoint,
+// returning the response and its body whatever its status
+func getAPIResponse(ctx context.Context, client api.Client, path string, query url.Values) (*http.Response, []byte, error) {
+	endpoint := client.URL(path, nil)
+	endpoint.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return client.Do(ctx, req)
+}
+
+// sendAPIRequest performs a request with a JSON body, or none when body is nil,
+// returning the response and its body whatever its status
+func sendAPIRequest(ctx context.Context, client api.Client, method, path string, body interface{}) (*http.Response, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, client.URL(path, nil).String(), reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return client.Do(ctx, req)
+}
@@ -0,0 +1,73 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/datasources"
+)
+
+func TestNewClient(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"status":"success","data":["job"]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv(datasources.DatasourcesConfig, "")
+	t.Setenv("PROMETHEUS_URL", server.URL)
+	t.Setenv("PROMETHEUS_BEARER_TOKEN", "token")
+	t.Setenv("PROMETHEUS_INSTANCES", "prod,broken")
+	t.Setenv("PROMETHEUS_PROD_URL", server.URL)
+	t.Setenv("PROMETHEUS_PROD_USERNAME", "agent")
+	t.Setenv("PROMETHEUS_PROD_PASSWORD", "secret")
+	t.Setenv("PROMETHEUS_BROKEN_URL", server.URL)
+	t.Setenv("PROMETHEUS_BROKEN_BEARER_TOKEN", "token")
+	t.Setenv("PROMETHEUS_BROKEN_USERNAME", "agent")
+	datasources.Reset()
+	t.Cleanup(datasources.Reset)
+
+	labels := func(args map[string]interface{}) *mcp.CallToolResult {
+		authorization = ""
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handlePrometheusLabelsQueryTool(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("datasources authenticate with their credentials", func(t *testing.T) {
+		result := labels(map[string]interface{}{})
+		require.False(t, result.IsError, getResultText(result))
+		assert.Equal(t, "Bearer token", authorization)
+
+		result = labels(map[string]interface{}{"datasource": "prod"})
+		require.False(t, result.IsError, getResultText(result))
+		assert.Equal(t, "Basic YWdlbnQ6c2VjcmV0", authorization)
+	})
+
+	t.Run("credentials are not sent to caller URLs", func(t *testing.T) {
+		result := labels(map[string]interface{}{"prometheus_url": server.URL})
+		require.False(t, result.IsError, getResultText(result))
+		assert.Empty(t, authorization)
+	})
+
+	t.Run("invalid datasources", func(t *testing.T) {
+		for message, args := range map[string]map[string]interface{}{
+			`unknown prometheus datasource "staging", configured datasources: default, prod`: {"datasource": "staging"},
+			`prometheus datasource "broken" is misconfigured`:                                {"datasource": "broken"},
+			"set either datasource or prometheus_url, not both":                              {"datasource": "prod", "prometheus_url": server.URL},
+		} {
+			result := labels(args)
+			assert.True(t, result.IsError)
+			assert.Contains(t, getResultText(result), message)
+		}
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"time"
@@ -16,29 +15,27 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 )
 
-// clientKey is the context key for the http client.
-type clientKey struct{}
-
-func getHTTPClient(ctx context.Context) *http.Client {
-	if client, ok := ctx.Value(clientKey{}).(*http.Client); ok && client != nil {
-		return client
-	}
-	return http.DefaultClient
-}
-
-// Prometheus tools using direct HTTP API calls
+// Prometheus tools using the Prometheus HTTP API client
 
 func handlePrometheusQueryTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	prometheusURL := mcp.ParseString(request, "prometheus_url", "http://localhost:9090")
+	prometheusURL := mcp.ParseString(request, "prometheus_url", "")
+	datasource := mcp.ParseString(request, "datasource", "")
 	query := mcp.ParseString(request, "query", "")
+	unit := mcp.ParseString(request, "unit", UnitAuto)
+	output := mcp.ParseString(request, "output", OutputStructured)
 
 	if query == "" {
 		return mcp.NewToolResultError("query parameter is required"), nil
 	}
+	if err := validateOutputOptions(unit, output); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	// Validate prometheus URL
-	if err := security.ValidateURL(prometheusURL); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid Prometheus URL: %v", err)), nil
+	if prometheusURL != "" {
+		if err := security.ValidateURL(prometheusURL); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid Prometheus URL: %v", err)), nil
+		}
 	}
 
 	// Validate PromQL query
@@ -46,24 +43,18 @@ func handlePrometheusQueryTool(ctx context.Context, request mcp.CallToolRequest)
 		return mcp.NewToolResultError(fmt.Sprintf("Invalid PromQL query: %v", err)), nil
 	}
 
+	client, prometheusURL, err := newClient(ctx, datasource, prometheusURL)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	// Make request to Prometheus API
 	apiURL := fmt.Sprintf("%s/api/v1/query", prometheusURL)
 	params := url.Values{}
 	params.Add("query", query)
 	params.Add("time", fmt.Sprintf("%d", time.Now().Unix()))
 
-	fullURL := fmt.Sprintf("%s?%s", apiURL, params.Encode())
-
-	client := getHTTPClient(ctx)
-	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
-	if err != nil {
-		toolErr := errors.NewPrometheusError("create_request", err).
-			WithContext("prometheus_url", prometheusURL).
-			WithContext("query", query)
-		return toolErr.ToMCPResult(), nil
-	}
-
-	resp, err := client.Do(req)
+	resp, body, err := getAPIResponse(ctx, client, "/api/v1/query", params)
 	if err != nil {
 		toolErr := errors.NewPrometheusError("query_execution", err).
 			WithContext("prometheus_url", prometheusURL).
@@ -71,16 +62,6 @@ func handlePrometheusQueryTool(ctx context.Context, request mcp.CallToolRequest)
 			WithContext("api_url", apiURL)
 		return toolErr.ToMCPResult(), nil
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		toolErr := errors.NewPrometheusError("read_response", err).
-			WithContext("prometheus_url", prometheusURL).
-			WithContext("query", query).
-			WithContext("status_code", resp.StatusCode)
-		return toolErr.ToMCPResult(), nil
-	}
 
 	if resp.StatusCode != http.StatusOK {
 		toolErr := errors.NewPrometheusError("api_error", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))).
@@ -91,34 +72,31 @@ func handlePrometheusQueryTool(ctx context.Context, request mcp.CallToolRequest)
 		return toolErr.ToMCPResult(), nil
 	}
 
-	// Parse the JSON response to pretty-print it
-	var result interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return mcp.NewToolResultText(string(body)), nil
-	}
-
-	prettyJSON, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultText(string(body)), nil
-	}
-
-	return mcp.NewToolResultText(string(prettyJSON)), nil
+	return queryResponseResult(body, query, unit, output, nil), nil
 }
 
 func handlePrometheusRangeQueryTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	prometheusURL := mcp.ParseString(request, "prometheus_url", "http://localhost:9090")
+	prometheusURL := mcp.ParseString(request, "prometheus_url", "")
+	datasource := mcp.ParseString(request, "datasource", "")
 	query := mcp.ParseString(request, "query", "")
 	start := mcp.ParseString(request, "start", "")
 	end := mcp.ParseString(request, "end", "")
 	step := mcp.ParseString(request, "step", "15s")
+	unit := mcp.ParseString(request, "unit", UnitAuto)
+	output := mcp.ParseString(request, "output", OutputStructured)
 
 	if query == "" {
 		return mcp.NewToolResultError("query parameter is required"), nil
 	}
+	if err := validateOutputOptions(unit, output); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	// Validate prometheus URL
-	if err := security.ValidateURL(prometheusURL); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid Prometheus URL: %v", err)), nil
+	if prometheusURL != "" {
+		if err := security.ValidateURL(prometheusURL); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid Prometheus URL: %v", err)), nil
+		}
 	}
 
 	// Validate PromQL query
@@ -151,88 +129,58 @@ func handlePrometheusRangeQueryTool(ctx context.Context, request mcp.CallToolReq
 		end = fmt.Sprintf("%d", time.Now().Unix())
 	}
 
+	client, _, err := newClient(ctx, datasource, prometheusURL)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	// Make request to Prometheus API
-	apiURL := fmt.Sprintf("%s/api/v1/query_range", prometheusURL)
 	params := url.Values{}
 	params.Add("query", query)
 	params.Add("start", start)
 	params.Add("end", end)
 	params.Add("step", step)
 
-	fullURL := fmt.Sprintf("%s?%s", apiURL, params.Encode())
-
-	client := getHTTPClient(ctx)
-	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
-	if err != nil {
-		return mcp.NewToolResultError("failed to create request: " + err.Error()), nil
-	}
-
-	resp, err := client.Do(req)
+	resp, body, err := getAPIResponse(ctx, client, "/api/v1/query_range", params)
 	if err != nil {
 		return mcp.NewToolResultError("failed to query Prometheus: " + err.Error()), nil
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return mcp.NewToolResultError("failed to read response: " + err.Error()), nil
-	}
 
 	if resp.StatusCode != http.StatusOK {
 		return mcp.NewToolResultError(fmt.Sprintf("Prometheus API error (%d): %s", resp.StatusCode, string(body))), nil
 	}
 
-	// Parse the JSON response to pretty-print it
-	var result interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return mcp.NewToolResultText(string(body)), nil
-	}
-
-	prettyJSON, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultText(string(body)), nil
-	}
-
-	return mcp.NewToolResultText(string(prettyJSON)), nil
+	return queryResponseResult(body, query, unit, output, func(result *QueryResult) {
+		result.Start, result.End, result.Step = start, end, step
+	}), nil
 }
 
 func handlePrometheusLabelsQueryTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	prometheusURL := mcp.ParseString(request, "prometheus_url", "http://localhost:9090")
+	prometheusURL := mcp.ParseString(request, "prometheus_url", "")
+	datasource := mcp.ParseString(request, "datasource", "")
 
 	// Validate prometheus URL
-	if err := security.ValidateURL(prometheusURL); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid Prometheus URL: %v", err)), nil
+	if prometheusURL != "" {
+		if err := security.ValidateURL(prometheusURL); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid Prometheus URL: %v", err)), nil
+		}
 	}
 
-	// Make request to Prometheus API for labels
-	apiURL := fmt.Sprintf("%s/api/v1/labels", prometheusURL)
-
-	client := getHTTPClient(ctx)
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	client, prometheusURL, err := newClient(ctx, datasource, prometheusURL)
 	if err != nil {
-		toolErr := errors.NewPrometheusError("create_request", err).
-			WithContext("prometheus_url", prometheusURL).
-			WithContext("api_url", apiURL)
-		return toolErr.ToMCPResult(), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	resp, err := client.Do(req)
+	// Make request to Prometheus API for labels
+	apiURL := fmt.Sprintf("%s/api/v1/labels", prometheusURL)
+
+	resp, body, err := getAPIResponse(ctx, client, "/api/v1/labels", nil)
 	if err != nil {
 		toolErr := errors.NewPrometheusError("query_execution", err).
 			WithContext("prometheus_url", prometheusURL).
 			WithContext("api_url", apiURL)
 		return toolErr.ToMCPResult(), nil
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		toolErr := errors.NewPrometheusError("read_response", err).
-			WithContext("prometheus_url", prometheusURL).
-			WithContext("api_url", apiURL).
-			WithContext("status_code", resp.StatusCode)
-		return toolErr.ToMCPResult(), nil
-	}
 
 	if resp.StatusCode != http.StatusOK {
 		toolErr := errors.NewPrometheusError("api_error", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))).
@@ -258,32 +206,26 @@ func handlePrometheusLabelsQueryTool(ctx context.Context, request mcp.CallToolRe
 }
 
 func handlePrometheusTargetsQueryTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	prometheusURL := mcp.ParseString(request, "prometheus_url", "http://localhost:9090")
+	prometheusURL := mcp.ParseString(request, "prometheus_url", "")
+	datasource := mcp.ParseString(request, "datasource", "")
 
 	// Validate prometheus URL
-	if err := security.ValidateURL(prometheusURL); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid Prometheus URL: %v", err)), nil
+	if prometheusURL != "" {
+		if err := security.ValidateURL(prometheusURL); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid Prometheus URL: %v", err)), nil
+		}
 	}
 
-	// Make request to Prometheus API for targets
-	apiURL := fmt.Sprintf("%s/api/v1/targets", prometheusURL)
-
-	client := getHTTPClient(ctx)
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	client, _, err := newClient(ctx, datasource, prometheusURL)
 	if err != nil {
-		return mcp.NewToolResultError("failed to create request: " + err.Error()), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	resp, err := client.Do(req)
+	// Make request to Prometheus API for targets
+	resp, body, err := getAPIResponse(ctx, client, "/api/v1/targets", nil)
 	if err != nil {
 		return mcp.NewToolResultError("failed to query Prometheus: " + err.Error()), nil
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return mcp.NewToolResultError("failed to read response: " + err.Error()), nil
-	}
 
 	if resp.StatusCode != http.StatusOK {
 		return mcp.NewToolResultError(fmt.Sprintf("Prometheus API error (%d): %s", resp.StatusCode, string(body))), nil
@@ -303,11 +245,55 @@ func handlePrometheusTargetsQueryTool(ctx context.Context, request mcp.CallToolR
 	return mcp.NewToolResultText(string(prettyJSON)), nil
 }
 
+// validateOutputOptions checks the unit and output parameters of the query tools
+func validateOutputOptions(unit, output string) error {
+	switch unit {
+	case UnitAuto, UnitBytes, UnitSeconds, UnitRatio, UnitNone:
+	default:
+		return fmt.Errorf("unit must be one of: %s", unitDescription())
+	}
+	if output != OutputStructured && output != OutputRaw {
+		return fmt.Errorf("output must be one of: %s, %s", OutputStructured, OutputRaw)
+	}
+	return nil
+}
+
+// queryResponseResult renders a query response as a typed result, or as the
+// pretty-printed API response when raw output is requested or it cannot be parsed
+func queryResponseResult(body []byte, query, unit, output string, withMetadata func(*QueryResult)) *mcp.CallToolResult {
+	if output == OutputStructured {
+		if result, err := parseQueryResponse(body, query, unit); err == nil {
+			if withMetadata != nil {
+				withMetadata(result)
+			}
+			if resultJSON, err := json.MarshalIndent(result, "", "  "); err == nil {
+				return mcp.NewToolResultText(string(resultJSON))
+			}
+		}
+	}
+
+	// Parse the JSON response to pretty-print it
+	var result interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return mcp.NewToolResultText(string(body))
+	}
+
+	prettyJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultText(string(body))
+	}
+
+	return mcp.NewToolResultText(string(prettyJSON))
+}
+
 func RegisterTools(s *server.MCPServer) {
 	s.AddTool(mcp.NewTool("prometheus_query_tool",
 		mcp.WithDescription("Execute a PromQL query against Prometheus"),
 		mcp.WithString("query", mcp.Description("PromQL query to execute"), mcp.Required()),
-		mcp.WithString("prometheus_url", mcp.Description("Prometheus server URL (default: http://localhost:9090)")),
+		mcp.WithString("unit", mcp.Description("Unit used to format values ("+unitDescription()+"; default: auto, inferred from metric name suffixes)")),
+		mcp.WithString("output", mcp.Description("Output format: structured (typed series with formatted values) or raw (Prometheus API response; default: structured)")),
+		mcp.WithString("datasource", mcp.Description("Named Prometheus datasource to query (default: the default Prometheus datasource)")),
+		mcp.WithString("prometheus_url", mcp.Description("Prometheus server URL to query without configured credentials, instead of datasource")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("prometheus_query_tool", handlePrometheusQueryTool)))
 
 	s.AddTool(mcp.NewTool("prometheus_query_range_tool",
@@ -316,19 +302,84 @@ func RegisterTools(s *server.MCPServer) {
 		mcp.WithString("start", mcp.Description("Start time (Unix timestamp or relative time)")),
 		mcp.WithString("end", mcp.Description("End time (Unix timestamp or relative time)")),
 		mcp.WithString("step", mcp.Description("Query resolution step (default: 15s)")),
-		mcp.WithString("prometheus_url", mcp.Description("Prometheus server URL (default: http://localhost:9090)")),
+		mcp.WithString("unit", mcp.Description("Unit used to format values ("+unitDescription()+"; default: auto, inferred from metric name suffixes)")),
+		mcp.WithString("output", mcp.Description("Output format: structured (typed series with formatted values and min/max/avg summaries) or raw (Prometheus API response; default: structured)")),
+		mcp.WithString("datasource", mcp.Description("Named Prometheus datasource to query (default: the default Prometheus datasource)")),
+		mcp.WithString("prometheus_url", mcp.Description("Prometheus server URL to query without configured credentials, instead of datasource")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("prometheus_query_range_tool", handlePrometheusRangeQueryTool)))
 
 	s.AddTool(mcp.NewTool("prometheus_label_names_tool",
 		mcp.WithDescription("Get all available labels from Prometheus"),
-		mcp.WithString("prometheus_url", mcp.Description("Prometheus server URL (default: http://localhost:9090)")),
+		mcp.WithString("datasource", mcp.Description("Named Prometheus datasource to query (default: the default Prometheus datasource)")),
+		mcp.WithString("prometheus_url", mcp.Description("Prometheus server URL to query without configured credentials, instead of datasource")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("prometheus_label_names_tool", handlePrometheusLabelsQueryTool)))
 
 	s.AddTool(mcp.NewTool("prometheus_targets_tool",
 		mcp.WithDescription("Get all Prometheus targets and their status"),
-		mcp.WithString("prometheus_url", mcp.Description("Prometheus server URL (default: http://localhost:9090)")),
+		mcp.WithString("datasource", mcp.Description("Named Prometheus datasource to query (default: the default Prometheus datasource)")),
+		mcp.WithString("prometheus_url", mcp.Description("Prometheus server URL to query without configured credentials, instead of datasource")),
 	), telemetry.AdaptToolHandler(telemetry.WithTracing("prometheus_targets_tool", handlePrometheusTargetsQueryTool)))
 
+	s.AddTool(mcp.NewTool("prometheus_slo_query",
+		mcp.WithDescription("Generate and execute PromQL for common SLO metrics of a service (error rate, p95/p99 latency, CPU and memory saturation), returning the generated queries with their results"),
+		mcp.WithString("service", mcp.Description("Service to query, matched by the service label and as the pod name prefix"), mcp.Required()),
+		mcp.WithString("pattern", mcp.Description("SLO pattern: error_rate, latency_p95, latency_p99, saturation or all (default: all)")),
+		mcp.WithString("namespace", mcp.Description("Namespace label to filter by (optional)")),
+		mcp.WithString("window", mcp.Description("PromQL rate window (default: 5m)")),
+		mcp.WithString("service_label", mcp.Description("Label identifying the service on request metrics (default: service)")),
+		mcp.WithString("status_label", mcp.Description("Label holding the HTTP status code (default: code)")),
+		mcp.WithString("request_metric", mcp.Description("Request counter metric (default: http_requests_total)")),
+		mcp.WithString("duration_metric", mcp.Description("Request duration histogram metric, without the _bucket suffix (default: http_request_duration_seconds)")),
+		mcp.WithString("execute", mcp.Description("Execute the generated queries (true/false, default: true)")),
+		mcp.WithString("datasource", mcp.Description("Named Prometheus datasource to query (default: the default Prometheus datasource)")),
+		mcp.WithString("prometheus_url", mcp.Description("Prometheus server URL to query without configured credentials, instead of datasource")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("prometheus_slo_query", handlePrometheusSLOQuery)))
+
+	s.AddTool(mcp.NewTool("prometheus_cardinality",
+		mcp.WithDescription("Report the metrics with the most series, labels with exploding value counts and the scrape jobs and scrape configs producing them, flagging missing sample limits and series churn. Cardinality blowups are a common cause of Prometheus outages"),
+		mcp.WithNumber("limit", mcp.Description("Number of top metrics, labels and scrape jobs to report (default: 10)")),
+		mcp.WithNumber("label_value_threshold", mcp.Description("Distinct values from which a label is flagged as exploding (default: 1000)")),
+		mcp.WithNumber("breakdown", mcp.Description("Number of top metrics whose series are broken down by job and label (default: 5, 0 to skip)")),
+		mcp.WithString("datasource", mcp.Description("Named Prometheus datasource to query (default: the default Prometheus datasource)")),
+		mcp.WithString("prometheus_url", mcp.Description("Prometheus server URL to query without configured credentials, instead of datasource")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("prometheus_cardinality", handlePrometheusCardinality)))
+
+	s.AddTool(mcp.NewTool("alertmanager_list_alerts",
+		mcp.WithDescription("List the active alerts of Alertmanager with their labels, summary, state and fingerprint, newest first. Silenced and inhibited alerts are left out unless requested"),
+		mcp.WithString("filter", mcp.Description(`Only list alerts matching these label matchers, e.g. {alertname="HighLatency", namespace=~"prod|staging"}`)),
+		mcp.WithString("include_silenced", mcp.Description("Include silenced alerts (true/false, default: false)")),
+		mcp.WithString("include_inhibited", mcp.Description("Include inhibited alerts (true/false, default: false)")),
+		mcp.WithString("datasource", mcp.Description("Named Alertmanager datasource to use (default: the default Alertmanager datasource)")),
+		mcp.WithString("alertmanager_url", mcp.Description("Alertmanager URL to use without configured credentials, instead of datasource")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alertmanager_list_alerts", handleAlertmanagerListAlerts)))
+
+	s.AddTool(mcp.NewTool("alertmanager_list_silences",
+		mcp.WithDescription("List the silences of Alertmanager with their matchers, time window, creator and comment"),
+		mcp.WithString("state", mcp.Description("Only list silences in this state: active, pending, expired or all (default: active)")),
+		mcp.WithString("datasource", mcp.Description("Named Alertmanager datasource to use (default: the default Alertmanager datasource)")),
+		mcp.WithString("alertmanager_url", mcp.Description("Alertmanager URL to use without configured credentials, instead of datasource")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alertmanager_list_silences", handleAlertmanagerListSilences)))
+
+	s.AddTool(mcp.NewTool("alertmanager_create_silence",
+		mcp.WithDescription("Silence Alertmanager alerts for a time window, such as while an incident is being fixed. Silences either the alerts matching label matchers or exactly one active alert by its fingerprint, for at most 7 days. The silence is attributed to created_by, or the tenant, and its comment records the session it was created from"),
+		mcp.WithString("matchers", mcp.Description(`Label matchers of the alerts to silence, e.g. {alertname="HighLatency", namespace="prod"}; at least one must not match the empty string`)),
+		mcp.WithString("alert_fingerprint", mcp.Description("Fingerprint of an active alert, from alertmanager_list_alerts, to silence by all of its labels, instead of matchers")),
+		mcp.WithString("duration", mcp.Description("Length of the silence (e.g. 2h), instead of ends_at")),
+		mcp.WithString("starts_at", mcp.Description("Start of the silence as an RFC 3339 time (default: now)")),
+		mcp.WithString("ends_at", mcp.Description("End of the silence as an RFC 3339 time")),
+		mcp.WithString("comment", mcp.Description("Reason for the silence, such as the incident being fixed"), mcp.Required()),
+		mcp.WithString("created_by", mcp.Description("User the silence is created for (default: the tenant, or kagent-tools)")),
+		mcp.WithString("datasource", mcp.Description("Named Alertmanager datasource to use (default: the default Alertmanager datasource)")),
+		mcp.WithString("alertmanager_url", mcp.Description("Alertmanager URL to use without configured credentials, instead of datasource")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alertmanager_create_silence", handleAlertmanagerCreateSilence)))
+
+	s.AddTool(mcp.NewTool("alertmanager_expire_silence",
+		mcp.WithDescription("Expire an Alertmanager silence, ending it early so its alerts notify again"),
+		mcp.WithString("silence_id", mcp.Description("ID of the silence"), mcp.Required()),
+		mcp.WithString("datasource", mcp.Description("Named Alertmanager datasource to use (default: the default Alertmanager datasource)")),
+		mcp.WithString("alertmanager_url", mcp.Description("Alertmanager URL to use without configured credentials, instead of datasource")),
+	), telemetry.AdaptToolHandler(telemetry.WithTracing("alertmanager_expire_silence", handleAlertmanagerExpireSilence)))
+
 	s.AddTool(mcp.NewTool("prometheus_promql_tool",
 		mcp.WithDescription("Generate a PromQL query"),
 		mcp.WithString("query_description", mcp.Description("A string describing the query to generate"), mcp.Required()),
@@ -6,7 +6,9 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/tmc/langchaingo/llms"
-	"github.com/tmc/langchaingo/llms/openai"
+
+	"github.com/kagent-dev/tools/internal/llm"
+	"github.com/kagent-dev/tools/pkg/utils"
 )
 
 //go:embed promql_prompt.md
@@ -18,7 +20,7 @@ func handlePromql(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallTo
 		return mcp.NewToolResultError("query_description is required"), nil
 	}
 
-	llm, err := openai.New()
+	model, err := llm.New()
 	if err != nil {
 		return mcp.NewToolResultError("failed to create LLM client: " + err.Error()), nil
 	}
@@ -39,15 +41,10 @@ func handlePromql(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallTo
 		},
 	}
 
-	resp, err := llm.GenerateContent(ctx, contents, llms.WithModel("gpt-4o-mini"))
+	// Stream the query to clients that asked for progress
+	query, err := llm.Generate(ctx, model, llm.TaskQuery, contents, utils.TokenStreamer(ctx, request))
 	if err != nil {
 		return mcp.NewToolResultError("failed to generate content: " + err.Error()), nil
 	}
-
-	choices := resp.Choices
-	if len(choices) < 1 {
-		return mcp.NewToolResultError("empty response from model"), nil
-	}
-	c1 := choices[0]
-	return mcp.NewToolResultText(c1.Content), nil
+	return mcp.NewToolResultText(query), nil
 }
@@ -0,0 +1,304 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Units used to format query results
+const (
+	UnitAuto    = "auto"
+	UnitBytes   = "bytes"
+	UnitSeconds = "seconds"
+	UnitRatio   = "ratio"
+	UnitNone    = "none"
+)
+
+// Output formats of the query tools
+const (
+	OutputStructured = "structured"
+	OutputRaw        = "raw"
+)
+
+var (
+	// metricNameInQuery matches metric names carrying a unit suffix, per the Prometheus naming conventions
+	metricNameInQuery = regexp.MustCompile(`[a-zA-Z_:][a-zA-Z0-9_:]*_(bytes|seconds|ratio)(_total|_sum|_count|_bucket)?\b`)
+	// rateOfSeconds matches rates of second counters, which are dimensionless (e.g. CPU cores)
+	rateOfSeconds = regexp.MustCompile(`(rate|irate|deriv)\s*\(\s*[a-zA-Z_:][a-zA-Z0-9_:]*_seconds_total`)
+)
+
+// Sample is a single value of a query result. Value is omitted when it is not
+// a finite number, in which case Formatted holds NaN, +Inf or -Inf.
+type Sample struct {
+	Time      string   `json:"time"`
+	Value     *float64 `json:"value,omitempty"`
+	Formatted string   `json:"formatted"`
+}
+
+// SeriesSummary summarizes the finite values of a range query series
+type SeriesSummary struct {
+	Min  string `json:"min"`
+	Max  string `json:"max"`
+	Avg  string `json:"avg"`
+	Last string `json:"last"`
+}
+
+// Series is one labelled series of a vector or matrix result
+type Series struct {
+	Metric  map[string]string `json:"metric"`
+	Value   *Sample           `json:"value,omitempty"`
+	Values  []Sample          `json:"values,omitempty"`
+	Summary *SeriesSummary    `json:"summary,omitempty"`
+}
+
+// QueryResult is the typed, unit-formatted result of a PromQL query
+type QueryResult struct {
+	Status     string   `json:"status"`
+	ResultType string   `json:"result_type,omitempty"`
+	Unit       string   `json:"unit"`
+	Start      string   `json:"start,omitempty"`
+	End        string   `json:"end,omitempty"`
+	Step       string   `json:"step,omitempty"`
+	Series     []Series `json:"series,omitempty"`
+	Scalar     *Sample  `json:"scalar,omitempty"`
+	String     string   `json:"string,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+	Infos      []string `json:"infos,omitempty"`
+}
+
+// apiResponse is a Prometheus HTTP API query response
+type apiResponse struct {
+	Status    string   `json:"status"`
+	ErrorType string   `json:"errorType"`
+	Error     string   `json:"error"`
+	Warnings  []string `json:"warnings"`
+	Infos     []string `json:"infos"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// inferUnit guesses the unit of a query's results from the unit suffix of the metrics it uses
+func inferUnit(query string) string {
+	if rateOfSeconds.MatchString(query) {
+		return UnitNone
+	}
+	match := metricNameInQuery.FindStringSubmatch(query)
+	if match == nil {
+		return UnitNone
+	}
+	return match[1]
+}
+
+// parseQueryResponse parses a Prometheus query response into a typed result
+// formatted in unit; UnitAuto infers the unit from the query
+func parseQueryResponse(body []byte, query, unit string) (*QueryResult, error) {
+	var response apiResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("query failed (%s): %s", response.ErrorType, response.Error)
+	}
+	if unit == "" || unit == UnitAuto {
+		unit = inferUnit(query)
+	}
+
+	result := &QueryResult{
+		Status:     response.Status,
+		ResultType: response.Data.ResultType,
+		Unit:       unit,
+		Warnings:   response.Warnings,
+		Infos:      response.Infos,
+	}
+	if len(response.Data.Result) == 0 {
+		return result, nil
+	}
+
+	switch response.Data.ResultType {
+	case "vector":
+		var vector []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		}
+		if err := json.Unmarshal(response.Data.Result, &vector); err != nil {
+			return nil, fmt.Errorf("failed to parse vector result: %w", err)
+		}
+		result.Series = make([]Series, 0, len(vector))
+		for _, series := range vector {
+			sample, err := parseSample(series.Value, unit)
+			if err != nil {
+				return nil, err
+			}
+			result.Series = append(result.Series, Series{Metric: series.Metric, Value: &sample})
+		}
+	case "matrix":
+		var matrix []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][]interface{}   `json:"values"`
+		}
+		if err := json.Unmarshal(response.Data.Result, &matrix); err != nil {
+			return nil, fmt.Errorf("failed to parse matrix result: %w", err)
+		}
+		result.Series = make([]Series, 0, len(matrix))
+		for _, series := range matrix {
+			values := make([]Sample, 0, len(series.Values))
+			for _, pair := range series.Values {
+				sample, err := parseSample(pair, unit)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, sample)
+			}
+			result.Series = append(result.Series, Series{Metric: series.Metric, Values: values, Summary: summarize(values, unit)})
+		}
+	case "scalar":
+		var pair []interface{}
+		if err := json.Unmarshal(response.Data.Result, &pair); err != nil {
+			return nil, fmt.Errorf("failed to parse scalar result: %w", err)
+		}
+		sample, err := parseSample(pair, unit)
+		if err != nil {
+			return nil, err
+		}
+		result.Scalar = &sample
+	case "string":
+		var pair []interface{}
+		if err := json.Unmarshal(response.Data.Result, &pair); err != nil || len(pair) != 2 {
+			return nil, fmt.Errorf("failed to parse string result")
+		}
+		result.String, _ = pair[1].(string)
+	default:
+		return nil, fmt.Errorf("unsupported result type %q", response.Data.ResultType)
+	}
+	return result, nil
+}
+
+// parseSample parses a [timestamp, "value"] pair
+func parseSample(pair []interface{}, unit string) (Sample, error) {
+	if len(pair) != 2 {
+		return Sample{}, fmt.Errorf("invalid sample %v", pair)
+	}
+	timestamp, ok := pair[0].(float64)
+	if !ok {
+		return Sample{}, fmt.Errorf("invalid sample timestamp %v", pair[0])
+	}
+	raw, _ := pair[1].(string)
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return Sample{}, fmt.Errorf("invalid sample value %q", raw)
+	}
+
+	sample := Sample{Time: formatTimestamp(timestamp), Formatted: formatValue(value, unit)}
+	if !math.IsNaN(value) && !math.IsInf(value, 0) {
+		sample.Value = &value
+	}
+	return sample, nil
+}
+
+// summarize returns the min, max, average and last finite values of a series
+func summarize(values []Sample, unit string) *SeriesSummary {
+	var minValue, maxValue, sum, last float64
+	count := 0
+	for _, sample := range values {
+		if sample.Value == nil {
+			continue
+		}
+		value := *sample.Value
+		if count == 0 || value < minValue {
+			minValue = value
+		}
+		if count == 0 || value > maxValue {
+			maxValue = value
+		}
+		sum += value
+		last = value
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+	return &SeriesSummary{
+		Min:  formatValue(minValue, unit),
+		Max:  formatValue(maxValue, unit),
+		Avg:  formatValue(sum/float64(count), unit),
+		Last: formatValue(last, unit),
+	}
+}
+
+// formatTimestamp renders a Prometheus Unix timestamp in RFC 3339
+func formatTimestamp(timestamp float64) string {
+	seconds, fraction := math.Modf(timestamp)
+	return time.Unix(int64(seconds), int64(fraction*1e9)).UTC().Format(time.RFC3339Nano)
+}
+
+// formatValue renders a value for humans in the given unit
+func formatValue(value float64, unit string) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+
+	switch unit {
+	case UnitBytes:
+		return formatBytes(value)
+	case UnitSeconds:
+		return formatSeconds(value)
+	case UnitRatio:
+		return formatNumber(value*100) + "%"
+	default:
+		return formatNumber(value)
+	}
+}
+
+// formatNumber renders a number with at most four decimals
+func formatNumber(value float64) string {
+	if math.Abs(value) >= 1e15 || (value != 0 && math.Abs(value) < 1e-4) {
+		return strconv.FormatFloat(value, 'g', 4, 64)
+	}
+	return strconv.FormatFloat(math.Round(value*1e4)/1e4, 'f', -1, 64)
+}
+
+// formatBytes renders a byte count with binary prefixes, e.g. 1.5 GiB
+func formatBytes(value float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	scaled := value
+	i := 0
+	for math.Abs(scaled) >= 1024 && i < len(units)-1 {
+		scaled /= 1024
+		i++
+	}
+	return strconv.FormatFloat(math.Round(scaled*100)/100, 'f', -1, 64) + " " + units[i]
+}
+
+// formatSeconds renders a duration in seconds, e.g. 250ms or 1h2m3s
+func formatSeconds(value float64) string {
+	abs := math.Abs(value)
+	switch {
+	case abs == 0:
+		return "0s"
+	case abs < 1e-3:
+		return formatNumber(value*1e6) + "µs"
+	case abs < 1:
+		return formatNumber(value*1e3) + "ms"
+	case abs < 60:
+		return formatNumber(value) + "s"
+	default:
+		return time.Duration(value * float64(time.Second)).Round(time.Second).String()
+	}
+}
+
+// unitDescription lists the accepted unit values for tool descriptions
+func unitDescription() string {
+	return strings.Join([]string{UnitAuto, UnitBytes, UnitSeconds, UnitRatio, UnitNone}, ", ")
+}
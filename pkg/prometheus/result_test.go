@@ -0,0 +1,123 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatValue(t *testing.T) {
+	for _, tc := range []struct {
+		value    float64
+		unit     string
+		expected string
+	}{
+		{1610612736, UnitBytes, "1.5 GiB"},
+		{512, UnitBytes, "512 B"},
+		{0.25, UnitSeconds, "250ms"},
+		{0.000042, UnitSeconds, "42µs"},
+		{12.3456, UnitSeconds, "12.3456s"},
+		{3723, UnitSeconds, "1h2m3s"},
+		{0.0234, UnitRatio, "2.34%"},
+		{1234567, UnitNone, "1234567"},
+		{0.123456789, UnitNone, "0.1235"},
+		{0.00001234, UnitNone, "1.234e-05"},
+	} {
+		assert.Equal(t, tc.expected, formatValue(tc.value, tc.unit), "%v %s", tc.value, tc.unit)
+	}
+}
+
+func TestInferUnit(t *testing.T) {
+	assert.Equal(t, UnitBytes, inferUnit(`sum(container_memory_working_set_bytes{pod="web"})`))
+	assert.Equal(t, UnitSeconds, inferUnit(`histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket[5m])))`))
+	assert.Equal(t, UnitNone, inferUnit(`sum(rate(container_cpu_usage_seconds_total[5m]))`))
+	assert.Equal(t, UnitNone, inferUnit(`up`))
+}
+
+func TestParseQueryResponse(t *testing.T) {
+	t.Run("vector", func(t *testing.T) {
+		body := `{"status":"success","warnings":["partial response"],"data":{"resultType":"vector","result":[
+			{"metric":{"pod":"web-1"},"value":[1700000000.5,"268435456"]},
+			{"metric":{"pod":"web-2"},"value":[1700000000.5,"NaN"]}]}}`
+
+		result, err := parseQueryResponse([]byte(body), "container_memory_working_set_bytes", UnitAuto)
+		require.NoError(t, err)
+		assert.Equal(t, UnitBytes, result.Unit)
+		assert.Equal(t, []string{"partial response"}, result.Warnings)
+		require.Len(t, result.Series, 2)
+		assert.Equal(t, "web-1", result.Series[0].Metric["pod"])
+		assert.Equal(t, "2023-11-14T22:13:20.5Z", result.Series[0].Value.Time)
+		assert.Equal(t, 268435456.0, *result.Series[0].Value.Value)
+		assert.Equal(t, "256 MiB", result.Series[0].Value.Formatted)
+		assert.Nil(t, result.Series[1].Value.Value)
+		assert.Equal(t, "NaN", result.Series[1].Value.Formatted)
+
+		// Non-finite values must not break encoding
+		_, err = json.Marshal(result)
+		assert.NoError(t, err)
+	})
+
+	t.Run("matrix", func(t *testing.T) {
+		body := `{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{},"values":[[1700000000,"0.1"],[1700000060,"0.3"],[1700000120,"0.2"]]}]}}`
+
+		result, err := parseQueryResponse([]byte(body), "errors", UnitRatio)
+		require.NoError(t, err)
+		require.Len(t, result.Series, 1)
+		assert.Len(t, result.Series[0].Values, 3)
+		assert.Equal(t, &SeriesSummary{Min: "10%", Max: "30%", Avg: "20%", Last: "20%"}, result.Series[0].Summary)
+	})
+
+	t.Run("scalar", func(t *testing.T) {
+		result, err := parseQueryResponse([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1700000000,"42"]}}`), "42", UnitAuto)
+		require.NoError(t, err)
+		assert.Equal(t, "42", result.Scalar.Formatted)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := parseQueryResponse([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`), "up{", UnitAuto)
+		assert.ErrorContains(t, err, "parse error")
+	})
+}
+
+func TestQueryToolOutputFormats(t *testing.T) {
+	body := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"up"},"value":[1700000000,"1"]}]}}`
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"query": "up"}
+	result, err := handlePrometheusQueryTool(contextWithMockClient(newTestClient(createMockResponse(200, body), nil)), request)
+	require.NoError(t, err)
+	var structured QueryResult
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &structured))
+	assert.Equal(t, "1", structured.Series[0].Value.Formatted)
+
+	request.Params.Arguments = map[string]interface{}{"query": "up", "output": "raw"}
+	result, err = handlePrometheusQueryTool(contextWithMockClient(newTestClient(createMockResponse(200, body), nil)), request)
+	require.NoError(t, err)
+	assert.Contains(t, getResultText(result), `"resultType": "vector"`)
+
+	request.Params.Arguments = map[string]interface{}{"query": "up", "unit": "furlongs"}
+	result, err = handlePrometheusQueryTool(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestRangeQueryToolMetadata(t *testing.T) {
+	body := `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1700000000,"0.5"]]}]}}`
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"query": "rate(request_duration_seconds_sum[5m])", "start": "1700000000", "end": "1700000060", "step": "60s"}
+	result, err := handlePrometheusRangeQueryTool(contextWithMockClient(newTestClient(createMockResponse(200, body), nil)), request)
+	require.NoError(t, err)
+
+	var structured QueryResult
+	require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &structured))
+	assert.Equal(t, "60s", structured.Step)
+	assert.Equal(t, "1700000000", structured.Start)
+	assert.Equal(t, UnitSeconds, structured.Unit)
+	assert.Equal(t, "500ms", structured.Series[0].Values[0].Formatted)
+}
@@ -0,0 +1,264 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/prometheus/client_golang/api"
+
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// SLO query patterns supported by prometheus_slo_query
+const (
+	SLOPatternErrorRate  = "error_rate"
+	SLOPatternLatencyP95 = "latency_p95"
+	SLOPatternLatencyP99 = "latency_p99"
+	SLOPatternSaturation = "saturation"
+	SLOPatternAll        = "all"
+)
+
+// Default label and metric conventions, matching common HTTP instrumentation
+const (
+	defaultServiceLabel   = "service"
+	defaultStatusLabel    = "code"
+	defaultRequestMetric  = "http_requests_total"
+	defaultDurationMetric = "http_request_duration_seconds"
+	defaultSLOWindow      = "5m"
+)
+
+var (
+	// labelValuePattern restricts label values so they can be embedded in PromQL unescaped
+	labelValuePattern = regexp.MustCompile(`^[a-zA-Z0-9_.:/-]+$`)
+	labelNamePattern  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	// promDurationPattern matches PromQL range durations such as 5m or 1h30m
+	promDurationPattern = regexp.MustCompile(`^([0-9]+(ms|[smhdwy]))+$`)
+)
+
+// sloConventions describes how a service's metrics are labelled
+type sloConventions struct {
+	Service        string
+	Namespace      string
+	ServiceLabel   string
+	StatusLabel    string
+	RequestMetric  string
+	DurationMetric string
+	Window         string
+}
+
+// SLOQuery is a generated PromQL query and, once executed, its result
+type SLOQuery struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Query       string       `json:"query"`
+	Unit        string       `json:"unit"`
+	Result      *QueryResult `json:"result,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// SLOQueryResult is the structured response of prometheus_slo_query
+type SLOQueryResult struct {
+	Service string     `json:"service"`
+	Window  string     `json:"window"`
+	Queries []SLOQuery `json:"queries"`
+}
+
+// selector renders a label matcher list, e.g. {service="web",namespace="prod"}
+func (c sloConventions) selector(extra ...string) string {
+	matchers := []string{fmt.Sprintf(`%s="%s"`, c.ServiceLabel, c.Service)}
+	if c.Namespace != "" {
+		matchers = append(matchers, fmt.Sprintf(`namespace="%s"`, c.Namespace))
+	}
+	matchers = append(matchers, extra...)
+	return "{" + strings.Join(matchers, ",") + "}"
+}
+
+// podSelector matches the containers of pods named after the service
+func (c sloConventions) podSelector(extra ...string) string {
+	matchers := []string{fmt.Sprintf(`pod=~"%s-.*"`, c.Service)}
+	if c.Namespace != "" {
+		matchers = append(matchers, fmt.Sprintf(`namespace="%s"`, c.Namespace))
+	}
+	matchers = append(matchers, extra...)
+	return "{" + strings.Join(matchers, ",") + "}"
+}
+
+// latencyQuery builds a histogram quantile query over the duration metric
+func (c sloConventions) latencyQuery(quantile string) string {
+	return fmt.Sprintf(`histogram_quantile(%s, sum by (le) (rate(%s_bucket%s[%s])))`,
+		quantile, c.DurationMetric, c.selector(), c.Window)
+}
+
+// buildSLOQueries generates the PromQL queries for a pattern
+func buildSLOQueries(c sloConventions, pattern string) []SLOQuery {
+	var queries []SLOQuery
+	include := func(p string) bool { return pattern == SLOPatternAll || pattern == p }
+
+	if include(SLOPatternErrorRate) {
+		queries = append(queries, SLOQuery{
+			Name:        SLOPatternErrorRate,
+			Description: "Ratio of 5xx responses to all requests",
+			Unit:        UnitRatio,
+			Query: fmt.Sprintf(`sum(rate(%s%s[%s])) / sum(rate(%s%s[%s]))`,
+				c.RequestMetric, c.selector(fmt.Sprintf(`%s=~"5.."`, c.StatusLabel)), c.Window,
+				c.RequestMetric, c.selector(), c.Window),
+		})
+	}
+	if include(SLOPatternLatencyP95) {
+		queries = append(queries, SLOQuery{
+			Name:        SLOPatternLatencyP95,
+			Description: "95th percentile request latency in seconds",
+			Query:       c.latencyQuery("0.95"),
+			Unit:        UnitSeconds,
+		})
+	}
+	if include(SLOPatternLatencyP99) {
+		queries = append(queries, SLOQuery{
+			Name:        SLOPatternLatencyP99,
+			Description: "99th percentile request latency in seconds",
+			Query:       c.latencyQuery("0.99"),
+			Unit:        UnitSeconds,
+		})
+	}
+	if include(SLOPatternSaturation) {
+		queries = append(queries,
+			SLOQuery{
+				Name:        SLOPatternSaturation + "_cpu",
+				Description: "CPU usage as a fraction of CPU limits",
+				Unit:        UnitRatio,
+				Query: fmt.Sprintf(`sum(rate(container_cpu_usage_seconds_total%s[%s])) / sum(kube_pod_container_resource_limits%s)`,
+					c.podSelector(`container!=""`), c.Window, c.podSelector(`resource="cpu"`)),
+			},
+			SLOQuery{
+				Name:        SLOPatternSaturation + "_memory",
+				Description: "Working set memory as a fraction of memory limits",
+				Unit:        UnitRatio,
+				Query: fmt.Sprintf(`sum(container_memory_working_set_bytes%s) / sum(kube_pod_container_resource_limits%s)`,
+					c.podSelector(`container!=""`), c.podSelector(`resource="memory"`)),
+			},
+		)
+	}
+	return queries
+}
+
+// matchPattern returns a validator rejecting values that do not match re
+func matchPattern(re *regexp.Regexp, description string) func(string) error {
+	return func(value string) error {
+		if !re.MatchString(value) {
+			return fmt.Errorf("must be a valid %s", description)
+		}
+		return nil
+	}
+}
+
+// runInstantQuery executes a PromQL instant query and returns its result formatted in unit
+func runInstantQuery(ctx context.Context, client api.Client, query, unit string) (*QueryResult, error) {
+	queryParams := url.Values{}
+	queryParams.Add("query", query)
+	queryParams.Add("time", fmt.Sprintf("%d", time.Now().Unix()))
+
+	resp, body, err := getAPIResponse(ctx, client, "/api/v1/query", queryParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Prometheus: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Prometheus API error (%d): %s", resp.StatusCode, string(body))
+	}
+	return parseQueryResponse(body, query, unit)
+}
+
+// QueryValue runs a PromQL instant query against prometheusURL, or the default
+// instance when empty, and returns the value of its scalar or first series, or
+// nil when the query returned no finite value
+func QueryValue(ctx context.Context, prometheusURL, query string) (*float64, error) {
+	client, _, err := newClient(ctx, "", prometheusURL)
+	if err != nil {
+		return nil, err
+	}
+	return queryValue(ctx, client, query)
+}
+
+// QueryDatasourceValue is QueryValue against the named Prometheus datasource,
+// or the default datasource when empty
+func QueryDatasourceValue(ctx context.Context, datasource, query string) (*float64, error) {
+	client, _, err := newClient(ctx, datasource, "")
+	if err != nil {
+		return nil, err
+	}
+	return queryValue(ctx, client, query)
+}
+
+// queryValue returns the value of the scalar or first series of an instant query
+func queryValue(ctx context.Context, client api.Client, query string) (*float64, error) {
+	result, err := runInstantQuery(ctx, client, query, UnitNone)
+	if err != nil {
+		return nil, err
+	}
+	if result.Scalar != nil {
+		return result.Scalar.Value, nil
+	}
+	if len(result.Series) > 0 && result.Series[0].Value != nil {
+		return result.Series[0].Value.Value, nil
+	}
+	return nil, nil
+}
+
+// Prometheus SLO query builder
+func handlePrometheusSLOQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	prometheusURL := p.String("prometheus_url", "", params.Check(security.ValidateURL))
+	datasource := p.String("datasource", "")
+	pattern := p.String("pattern", SLOPatternAll, params.OneOf(
+		SLOPatternErrorRate, SLOPatternLatencyP95, SLOPatternLatencyP99, SLOPatternSaturation, SLOPatternAll))
+	conventions := sloConventions{
+		Service:        p.String("service", "", params.Required(), params.Check(matchPattern(labelValuePattern, "label value"))),
+		Namespace:      p.String("namespace", "", params.Check(matchPattern(labelValuePattern, "label value"))),
+		ServiceLabel:   p.String("service_label", defaultServiceLabel, params.Check(matchPattern(labelNamePattern, "label name"))),
+		StatusLabel:    p.String("status_label", defaultStatusLabel, params.Check(matchPattern(labelNamePattern, "label name"))),
+		RequestMetric:  p.String("request_metric", defaultRequestMetric, params.Check(matchPattern(metricNamePattern, "metric name"))),
+		DurationMetric: p.String("duration_metric", defaultDurationMetric, params.Check(matchPattern(metricNamePattern, "metric name"))),
+		Window:         p.String("window", defaultSLOWindow, params.Check(matchPattern(promDurationPattern, "PromQL duration such as 5m"))),
+	}
+	execute := p.Bool("execute", true)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, _, err := newClient(ctx, datasource, prometheusURL)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result := SLOQueryResult{
+		Service: conventions.Service,
+		Window:  conventions.Window,
+		Queries: buildSLOQueries(conventions, pattern),
+	}
+
+	// Failed queries are reported individually so the others still return results
+	if execute {
+		for i := range result.Queries {
+			data, err := runInstantQuery(ctx, client, result.Queries[i].Query, result.Queries[i].Unit)
+			if err != nil {
+				result.Queries[i].Error = err.Error()
+				continue
+			}
+			result.Queries[i].Result = data
+		}
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal SLO query result: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,151 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestBuildSLOQueries(t *testing.T) {
+	conventions := sloConventions{
+		Service:        "checkout",
+		Namespace:      "shop",
+		ServiceLabel:   "service",
+		StatusLabel:    "code",
+		RequestMetric:  "http_requests_total",
+		DurationMetric: "http_request_duration_seconds",
+		Window:         "5m",
+	}
+
+	queries := buildSLOQueries(conventions, SLOPatternAll)
+	require.Len(t, queries, 5)
+	assert.Equal(t, `sum(rate(http_requests_total{service="checkout",namespace="shop",code=~"5.."}[5m])) / sum(rate(http_requests_total{service="checkout",namespace="shop"}[5m]))`, queries[0].Query)
+	assert.Equal(t, `histogram_quantile(0.95, sum by (le) (rate(http_request_duration_seconds_bucket{service="checkout",namespace="shop"}[5m])))`, queries[1].Query)
+	assert.Equal(t, "latency_p99", queries[2].Name)
+	assert.Equal(t, `sum(rate(container_cpu_usage_seconds_total{pod=~"checkout-.*",namespace="shop",container!=""}[5m])) / sum(kube_pod_container_resource_limits{pod=~"checkout-.*",namespace="shop",resource="cpu"})`, queries[3].Query)
+	assert.Equal(t, "saturation_memory", queries[4].Name)
+
+	conventions.Namespace = ""
+	conventions.ServiceLabel = "app"
+	queries = buildSLOQueries(conventions, SLOPatternLatencyP99)
+	require.Len(t, queries, 1)
+	assert.Equal(t, `histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket{app="checkout"}[5m])))`, queries[0].Query)
+}
+
+func TestHandlePrometheusSLOQuery(t *testing.T) {
+	t.Run("executes generated queries", func(t *testing.T) {
+		var executed []string
+		client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			query := req.URL.Query().Get("query")
+			executed = append(executed, query)
+			if strings.Contains(query, "_bucket") {
+				return createMockResponse(http.StatusBadRequest, `{"status":"error","error":"bad query"}`), nil
+			}
+			return createMockResponse(http.StatusOK, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"0.02"]}]}}`), nil
+		})}
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"service": "checkout",
+			"pattern": "all",
+			"window":  "10m",
+		}
+		result, err := handlePrometheusSLOQuery(contextWithMockClient(client), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, getResultText(result))
+
+		var response SLOQueryResult
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &response))
+		assert.Equal(t, "10m", response.Window)
+		require.Len(t, response.Queries, 5)
+		assert.Len(t, executed, 5)
+
+		// Latency queries fail but the others still report results
+		assert.NotNil(t, response.Queries[0].Result)
+		assert.Empty(t, response.Queries[0].Error)
+		assert.Contains(t, response.Queries[1].Error, "400")
+		assert.NotNil(t, response.Queries[3].Result)
+	})
+
+	t.Run("generates without executing", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"service": "checkout",
+			"pattern": "error_rate",
+			"execute": "false",
+		}
+		result, err := handlePrometheusSLOQuery(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		var response SLOQueryResult
+		require.NoError(t, json.Unmarshal([]byte(getResultText(result)), &response))
+		require.Len(t, response.Queries, 1)
+		assert.Nil(t, response.Queries[0].Result)
+		assert.Contains(t, response.Queries[0].Query, "http_requests_total")
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		tests := []map[string]interface{}{
+			{},
+			{"service": "checkout", "pattern": "throughput"},
+			{"service": `checkout"}`},
+			{"service": "checkout", "window": "5 minutes"},
+			{"service": "checkout", "service_label": "app-name"},
+			{"service": "checkout", "request_metric": "requests{}"},
+		}
+		for _, args := range tests {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = args
+			result, err := handlePrometheusSLOQuery(context.Background(), request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError, args)
+		}
+	})
+}
+
+func TestQueryValue(t *testing.T) {
+	responses := []string{
+		`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"0.25"]}]}}`,
+		`{"status":"success","data":{"resultType":"scalar","result":[1700000000,"3"]}}`,
+		`{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"NaN"]}]}}`,
+	}
+	calls := 0
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return createMockResponse(http.StatusOK, responses[calls-1]), nil
+	})}
+	ctx := contextWithMockClient(client)
+
+	value, err := QueryValue(ctx, "http://prometheus:9090", "ratio")
+	require.NoError(t, err)
+	require.NotNil(t, value)
+	assert.Equal(t, 0.25, *value)
+
+	value, err = QueryValue(ctx, "http://prometheus:9090", "scalar(up)")
+	require.NoError(t, err)
+	require.NotNil(t, value)
+	assert.Equal(t, 3.0, *value)
+
+	// No data and NaN have no value
+	value, err = QueryValue(ctx, "http://prometheus:9090", "missing")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+	value, err = QueryValue(ctx, "http://prometheus:9090", "0/0")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
@@ -0,0 +1,141 @@
+package runbooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/logger"
+	"github.com/kagent-dev/tools/internal/security"
+	"github.com/kagent-dev/tools/pkg/utils"
+)
+
+// configMapPrefix marks a source that is read from a Kubernetes ConfigMap
+const configMapPrefix = "configmap:"
+
+// Load reads the Markdown runbooks of a single source
+func Load(ctx context.Context, source string) ([]Runbook, error) {
+	switch {
+	case strings.HasPrefix(source, configMapPrefix):
+		return loadConfigMap(ctx, source)
+	case isGitURL(source):
+		return loadGit(ctx, source)
+	default:
+		return loadDirectory(source, source)
+	}
+}
+
+// isGitURL reports whether a source should be cloned with git
+func isGitURL(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") ||
+		strings.HasPrefix(source, "git@") || strings.HasSuffix(source, ".git")
+}
+
+// loadDirectory reads every .md file below dir
+func loadDirectory(source, dir string) ([]Runbook, error) {
+	var runbooks []Runbook
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".md") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		runbooks = append(runbooks, Runbook{Source: source, Path: filepath.ToSlash(rel), Content: string(content)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runbook directory: %w", err)
+	}
+
+	return runbooks, nil
+}
+
+// loadConfigMap reads the .md keys of a ConfigMap given as configmap:<namespace>/<name>
+func loadConfigMap(ctx context.Context, source string) ([]Runbook, error) {
+	ref := strings.TrimPrefix(source, configMapPrefix)
+	namespace, name, found := strings.Cut(ref, "/")
+	if !found {
+		return nil, fmt.Errorf("configmap source must be configmap:<namespace>/<name>")
+	}
+	if err := security.ValidateK8sResourceName(name); err != nil {
+		return nil, err
+	}
+
+	output, err := commands.NewCommandBuilder("kubectl").
+		WithArgs("get", "configmap", name, "-n", namespace, "-o", "json").
+		WithKubeconfig(utils.GetKubeconfig()).
+		Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var configMap struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(output), &configMap); err != nil {
+		return nil, fmt.Errorf("failed to parse configmap: %w", err)
+	}
+
+	keys := make([]string, 0, len(configMap.Data))
+	for key := range configMap.Data {
+		if strings.EqualFold(filepath.Ext(key), ".md") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	runbooks := make([]Runbook, 0, len(keys))
+	for _, key := range keys {
+		runbooks = append(runbooks, Runbook{Source: source, Path: key, Content: configMap.Data[key]})
+	}
+	return runbooks, nil
+}
+
+// loadGit shallow-clones a repository into a temporary directory and reads its runbooks
+func loadGit(ctx context.Context, source string) ([]Runbook, error) {
+	if err := security.ValidateCommandInput(strings.NewReplacer("://", "", "@", "").Replace(source)); err != nil {
+		return nil, fmt.Errorf("invalid git URL: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "runbooks-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			logger.Get().Error("Failed to remove runbook checkout", "dir", dir, "error", err)
+		}
+	}()
+
+	if _, err := commands.NewCommandBuilder("git").
+		WithArgs("clone", "--depth", "1", "--", source, dir).
+		Execute(ctx); err != nil {
+		return nil, err
+	}
+
+	return loadDirectory(source, dir)
+}
@@ -0,0 +1,337 @@
+package runbooks
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/kagent-dev/tools/internal/llm"
+	"github.com/kagent-dev/tools/internal/logger"
+)
+
+// RunbookSources is the environment variable listing runbook sources, comma-separated.
+// Each source is a directory path, a git URL, or configmap:<namespace>/<name>.
+const RunbookSources = "RUNBOOK_SOURCES"
+
+// maxChunkSize is the maximum number of characters in a runbook chunk
+const maxChunkSize = 1500
+
+// Embedder creates vector embeddings for text. It is satisfied by the
+// langchaingo embeddings.Embedder implementations.
+type Embedder interface {
+	EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error)
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+}
+
+// Runbook is a Markdown document loaded from a source
+type Runbook struct {
+	Source  string
+	Path    string
+	Content string
+}
+
+// Chunk is a retrievable section of a runbook
+type Chunk struct {
+	Source  string  `json:"source"`
+	Path    string  `json:"path"`
+	Heading string  `json:"heading"`
+	Content string  `json:"content"`
+	Score   float64 `json:"score"`
+
+	terms     map[string]int
+	embedding []float32
+}
+
+// Index holds chunked runbooks and retrieves the sections relevant to a query
+type Index struct {
+	mu       sync.RWMutex
+	sources  []string
+	embedder Embedder
+	chunks   []Chunk
+	docFreq  map[string]int
+	// embedded is true when the chunks carry embeddings
+	embedded bool
+}
+
+// NewIndex creates an index over the given sources. Embeddings are used for
+// retrieval when an embedder is provided, otherwise term matching is used.
+func NewIndex(sources []string, embedder Embedder) *Index {
+	return &Index{sources: sources, embedder: embedder, docFreq: make(map[string]int)}
+}
+
+// NewIndexFromEnv creates an index over the sources configured in the
+// environment. Retrieval uses embeddings of the configured LLM endpoint when
+// one is set, otherwise term matching.
+func NewIndexFromEnv() *Index {
+	var embedder Embedder
+	if e, err := llm.NewEmbedder(); err != nil {
+		logger.Get().Error("Runbook embeddings disabled, using term matching", "error", err)
+	} else if e != nil {
+		embedder = e
+	}
+	return NewIndex(SourcesFromEnv(), embedder)
+}
+
+// SourcesFromEnv returns the runbook sources configured in the environment
+func SourcesFromEnv() []string {
+	var sources []string
+	for _, source := range strings.Split(os.Getenv(RunbookSources), ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// Sources returns the configured runbook sources
+func (idx *Index) Sources() []string {
+	return idx.sources
+}
+
+// Size returns the number of indexed chunks
+func (idx *Index) Size() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.chunks)
+}
+
+// Reload loads every source and rebuilds the index. Sources that fail to load
+// are skipped and reported in the returned error.
+func (idx *Index) Reload(ctx context.Context) error {
+	var runbooks []Runbook
+	var failures []string
+
+	for _, source := range idx.sources {
+		loaded, err := Load(ctx, source)
+		if err != nil {
+			logger.Get().Error("Failed to load runbooks", "source", source, "error", err)
+			failures = append(failures, fmt.Sprintf("%s: %v", source, err))
+			continue
+		}
+		runbooks = append(runbooks, loaded...)
+	}
+
+	if err := idx.Build(ctx, runbooks); err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to load runbook sources: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Build replaces the index contents with the given runbooks
+func (idx *Index) Build(ctx context.Context, runbooks []Runbook) error {
+	var chunks []Chunk
+	for _, runbook := range runbooks {
+		chunks = append(chunks, chunkRunbook(runbook)...)
+	}
+
+	docFreq := make(map[string]int)
+	for i := range chunks {
+		chunks[i].terms = termCounts(chunks[i].Heading + " " + chunks[i].Content)
+		for term := range chunks[i].terms {
+			docFreq[term]++
+		}
+	}
+
+	// An endpoint that cannot serve embeddings leaves retrieval to term
+	// matching rather than leaving the index empty
+	embedded := false
+	if idx.embedder != nil && len(chunks) > 0 {
+		texts := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			texts[i] = chunk.Heading + "\n" + chunk.Content
+		}
+		embeddings, err := idx.embedder.EmbedDocuments(ctx, texts)
+		if err != nil {
+			logger.Get().Error("Failed to embed runbooks, using term matching", "error", err)
+		} else {
+			for i := range chunks {
+				if i < len(embeddings) {
+					chunks[i].embedding = embeddings[i]
+				}
+			}
+			embedded = true
+		}
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.chunks = chunks
+	idx.docFreq = docFreq
+	idx.embedded = embedded
+
+	logger.Get().Info("Runbook index built", "runbooks", len(runbooks), "chunks", len(chunks))
+	return nil
+}
+
+// Search returns up to topK chunks relevant to the query, best match first
+func (idx *Index) Search(ctx context.Context, query string, topK int) ([]Chunk, error) {
+	if topK <= 0 {
+		topK = 3
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(idx.chunks) == 0 {
+		return nil, nil
+	}
+
+	var queryEmbedding []float32
+	if idx.embedded {
+		embedding, err := idx.embedder.EmbedQuery(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+		queryEmbedding = embedding
+	}
+	queryTerms := termCounts(query)
+
+	var results []Chunk
+	for _, chunk := range idx.chunks {
+		var score float64
+		if queryEmbedding != nil && chunk.embedding != nil {
+			score = cosineSimilarity(queryEmbedding, chunk.embedding)
+		} else {
+			score = idx.termScore(queryTerms, chunk)
+		}
+		if score <= 0 {
+			continue
+		}
+		chunk.Score = score
+		results = append(results, chunk)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+// FormatForPrompt renders chunks as context for an LLM prompt
+func FormatForPrompt(chunks []Chunk) string {
+	if len(chunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, chunk := range chunks {
+		b.WriteString(fmt.Sprintf("### %s (%s)\n%s\n\n", chunk.Heading, chunk.Path, chunk.Content))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// termScore scores a chunk with TF-IDF over the query terms
+func (idx *Index) termScore(queryTerms map[string]int, chunk Chunk) float64 {
+	var score float64
+	total := float64(len(idx.chunks))
+	for term := range queryTerms {
+		count, ok := chunk.terms[term]
+		if !ok {
+			continue
+		}
+		idf := math.Log(1 + total/float64(idx.docFreq[term]))
+		score += (1 + math.Log(float64(count))) * idf
+	}
+	return score
+}
+
+// chunkRunbook splits a runbook into sections at Markdown headings, further
+// splitting long sections on paragraph boundaries
+func chunkRunbook(runbook Runbook) []Chunk {
+	var chunks []Chunk
+	heading := runbook.Path
+	var section strings.Builder
+
+	flush := func() {
+		for _, part := range splitParagraphs(strings.TrimSpace(section.String()), maxChunkSize) {
+			chunks = append(chunks, Chunk{
+				Source:  runbook.Source,
+				Path:    runbook.Path,
+				Heading: heading,
+				Content: part,
+			})
+		}
+		section.Reset()
+	}
+
+	for _, line := range strings.Split(runbook.Content, "\n") {
+		if strings.HasPrefix(line, "#") {
+			flush()
+			heading = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+		section.WriteString(line)
+		section.WriteString("\n")
+	}
+	flush()
+
+	return chunks
+}
+
+// splitParagraphs splits text into parts no longer than size, breaking between paragraphs
+func splitParagraphs(text string, size int) []string {
+	if text == "" {
+		return nil
+	}
+	if len(text) <= size {
+		return []string{text}
+	}
+
+	var parts []string
+	var current strings.Builder
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if current.Len() > 0 && current.Len()+len(paragraph)+2 > size {
+			parts = append(parts, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+		current.WriteString(paragraph)
+		current.WriteString("\n\n")
+	}
+	if current.Len() > 0 {
+		parts = append(parts, strings.TrimSpace(current.String()))
+	}
+	return parts
+}
+
+// termCounts tokenizes text into lower-cased alphanumeric terms
+func termCounts(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, term := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len(term) > 2 {
+			counts[term]++
+		}
+	}
+	return counts
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
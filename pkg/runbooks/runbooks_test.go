@@ -0,0 +1,194 @@
+package runbooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/kagent-dev/tools/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const crashLoopRunbook = `# CrashLoopBackOff
+
+Check the previous container logs with kubectl logs --previous.
+
+## OOMKilled
+
+Increase the memory limit of the container or fix the memory leak.
+
+## ImagePullBackOff
+
+Verify the image name and the registry pull secret.
+`
+
+type fakeEmbedder struct{}
+
+// embed maps text onto a two dimensional space: memory and image terms
+func (fakeEmbedder) embed(text string) []float32 {
+	text = strings.ToLower(text)
+	return []float32{
+		float32(strings.Count(text, "memory")) + 0.01,
+		float32(strings.Count(text, "image")) + 0.01,
+	}
+}
+
+func (f fakeEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = f.embed(text)
+	}
+	return embeddings, nil
+}
+
+func (f fakeEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return f.embed(text), nil
+}
+
+func TestChunkRunbook(t *testing.T) {
+	chunks := chunkRunbook(Runbook{Source: "dir", Path: "pods.md", Content: crashLoopRunbook})
+	require.Len(t, chunks, 3)
+	assert.Equal(t, "CrashLoopBackOff", chunks[0].Heading)
+	assert.Equal(t, "OOMKilled", chunks[1].Heading)
+	assert.Contains(t, chunks[1].Content, "memory limit")
+	assert.Equal(t, "pods.md", chunks[2].Path)
+}
+
+func TestSplitParagraphs(t *testing.T) {
+	text := strings.Repeat("a", 40) + "\n\n" + strings.Repeat("b", 40) + "\n\n" + strings.Repeat("c", 40)
+	parts := splitParagraphs(text, 90)
+	require.Len(t, parts, 2)
+	assert.True(t, strings.HasPrefix(parts[1], "ccc"))
+	assert.Nil(t, splitParagraphs("", 10))
+}
+
+func TestSearchTermMatching(t *testing.T) {
+	idx := NewIndex(nil, nil)
+	require.NoError(t, idx.Build(context.Background(), []Runbook{{Path: "pods.md", Content: crashLoopRunbook}}))
+	assert.Equal(t, 3, idx.Size())
+
+	results, err := idx.Search(context.Background(), "container OOMKilled memory", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "OOMKilled", results[0].Heading)
+	assert.Greater(t, results[0].Score, 0.0)
+
+	results, err = idx.Search(context.Background(), "unrelated", 3)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSearchEmbeddings(t *testing.T) {
+	idx := NewIndex(nil, fakeEmbedder{})
+	require.NoError(t, idx.Build(context.Background(), []Runbook{{Path: "pods.md", Content: crashLoopRunbook}}))
+
+	results, err := idx.Search(context.Background(), "wrong image tag", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "ImagePullBackOff", results[0].Heading)
+}
+
+// failingEmbedder is an endpoint that cannot serve embeddings
+type failingEmbedder struct{}
+
+func (failingEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, errors.New("embeddings not supported")
+}
+
+func (failingEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return nil, errors.New("embeddings not supported")
+}
+
+func TestSearchFallsBackToTermMatching(t *testing.T) {
+	idx := NewIndex(nil, failingEmbedder{})
+	require.NoError(t, idx.Build(context.Background(), []Runbook{{Path: "pods.md", Content: crashLoopRunbook}}))
+
+	results, err := idx.Search(context.Background(), "container OOMKilled memory", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "OOMKilled", results[0].Heading)
+}
+
+func TestNewIndexFromEnv(t *testing.T) {
+	t.Setenv(RunbookSources, "/runbooks")
+	t.Setenv(llm.LLMBaseURL, "")
+	t.Setenv(llm.LLMAPIKey, "")
+	t.Setenv("OPENAI_API_KEY", "")
+	idx := NewIndexFromEnv()
+	assert.Equal(t, []string{"/runbooks"}, idx.Sources())
+	assert.Nil(t, idx.embedder)
+
+	t.Setenv(llm.LLMBaseURL, "http://localhost:8000/v1")
+	assert.NotNil(t, NewIndexFromEnv().embedder)
+}
+
+func TestFormatForPrompt(t *testing.T) {
+	assert.Empty(t, FormatForPrompt(nil))
+	prompt := FormatForPrompt([]Chunk{{Heading: "OOMKilled", Path: "pods.md", Content: "Raise limits"}})
+	assert.Equal(t, "### OOMKilled (pods.md)\nRaise limits", prompt)
+}
+
+func TestLoadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "network"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pods.md"), []byte(crashLoopRunbook), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "network", "dns.md"), []byte("# DNS\nCheck coredns"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644))
+
+	runbooks, err := Load(context.Background(), dir)
+	require.NoError(t, err)
+	require.Len(t, runbooks, 2)
+	assert.Equal(t, "network/dns.md", runbooks[0].Path)
+	assert.Equal(t, "pods.md", runbooks[1].Path)
+}
+
+func TestLoadConfigMap(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddPartialMatcherString("kubectl", []string{"get", "configmap", "runbooks", "-n", "ops"},
+		`{"data":{"pods.md":"# Pods\nRestart it","config.yaml":"ignored"}}`, nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	runbooks, err := Load(ctx, "configmap:ops/runbooks")
+	require.NoError(t, err)
+	require.Len(t, runbooks, 1)
+	assert.Equal(t, "pods.md", runbooks[0].Path)
+	assert.Equal(t, "configmap:ops/runbooks", runbooks[0].Source)
+
+	_, err = Load(ctx, "configmap:runbooks")
+	assert.Error(t, err)
+}
+
+func TestLoadGit(t *testing.T) {
+	mock := cmd.NewMockShellExecutor()
+	mock.AddPartialMatcherString("git", []string{"clone"}, "", nil)
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	runbooks, err := Load(ctx, "https://github.com/example/runbooks.git")
+	require.NoError(t, err)
+	assert.Empty(t, runbooks)
+
+	// The source is separated from the options so that it is never parsed as one
+	callLog := mock.GetCallLog()
+	require.Len(t, callLog, 1)
+	assert.Equal(t, []string{"clone", "--depth", "1", "--", "https://github.com/example/runbooks.git"}, callLog[0].Args[:5])
+}
+
+func TestReloadReportsFailedSources(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pods.md"), []byte(crashLoopRunbook), 0o644))
+
+	idx := NewIndex([]string{dir, filepath.Join(dir, "missing")}, nil)
+	err := idx.Reload(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 3, idx.Size())
+}
+
+func TestSourcesFromEnv(t *testing.T) {
+	t.Setenv(RunbookSources, " /runbooks , configmap:ops/runbooks,,")
+	assert.Equal(t, []string{"/runbooks", "configmap:ops/runbooks"}, SourcesFromEnv())
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/blobs"
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// Range limits of fetch_blob
+const (
+	defaultBlobFetchLength = 1 << 20
+	maxBlobFetchLength     = 16 << 20
+)
+
+// Encodings of fetched blob content
+const (
+	BlobEncodingText   = "text"
+	BlobEncodingBase64 = "base64"
+)
+
+// BlobRange is a range of a stored blob's content
+type BlobRange struct {
+	URI      string `json:"uri"`
+	Offset   int64  `json:"offset"`
+	Length   int    `json:"length"`
+	Size     int64  `json:"size"`
+	EOF      bool   `json:"eof"`
+	Encoding string `json:"encoding"`
+	Content  string `json:"content"`
+}
+
+// handleFetchBlob returns a range of a stored blob, such as a support bundle or the full logs of an alert
+func handleFetchBlob(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	uri := p.String("uri", "", params.Required())
+	offset := p.Int("offset", 0, params.Min(0))
+	length := p.Int("length", defaultBlobFetchLength, params.Range(1, maxBlobFetchLength))
+	encoding := p.String("encoding", "", params.OneOf(BlobEncodingText, BlobEncodingBase64))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	bucket, id, err := blobs.ParseURI(uri)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	content, size, err := blobs.Default().ReadRange(ctx, bucket, id, int64(offset), int64(length))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result := BlobRange{
+		URI:      uri,
+		Offset:   int64(offset),
+		Length:   len(content),
+		Size:     size,
+		EOF:      int64(offset+len(content)) >= size,
+		Encoding: encoding,
+	}
+	// Binary content, such as gzipped bundles, is base64 encoded unless text is requested
+	if result.Encoding == "" {
+		result.Encoding = BlobEncodingBase64
+		if utf8.Valid(content) {
+			result.Encoding = BlobEncodingText
+		}
+	}
+	if result.Encoding == BlobEncodingText {
+		result.Content = string(content)
+	} else {
+		result.Content = base64.StdEncoding.EncodeToString(content)
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal blob range: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
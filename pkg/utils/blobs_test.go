@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/blobs"
+)
+
+var testBlobBucket = blobs.Register(blobs.Bucket{Name: "utils-test"})
+
+func TestHandleFetchBlob(t *testing.T) {
+	previous := blobs.Default()
+	blobs.SetDefault(blobs.NewMemoryStore())
+	defer blobs.SetDefault(previous)
+	ctx := context.Background()
+
+	store := func(content string) string {
+		info, err := blobs.Default().Write(ctx, testBlobBucket, 1024, func(w io.Writer) error {
+			_, err := io.WriteString(w, content)
+			return err
+		}, func(info blobs.Info) interface{} { return info })
+		require.NoError(t, err)
+		return info.URI
+	}
+	fetch := func(args map[string]interface{}) (*mcp.CallToolResult, BlobRange) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handleFetchBlob(ctx, request)
+		require.NoError(t, err)
+		var blobRange BlobRange
+		if !result.IsError {
+			require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &blobRange))
+		}
+		return result, blobRange
+	}
+
+	textURI := store("line 1\nline 2\n")
+	_, blobRange := fetch(map[string]interface{}{"uri": textURI, "offset": float64(7), "length": float64(4)})
+	assert.Equal(t, BlobRange{URI: textURI, Offset: 7, Length: 4, Size: 14, Encoding: BlobEncodingText, Content: "line"}, blobRange)
+
+	_, blobRange = fetch(map[string]interface{}{"uri": textURI, "offset": float64(7)})
+	assert.True(t, blobRange.EOF)
+	assert.Equal(t, "line 2\n", blobRange.Content)
+
+	binaryURI := store("\x1f\x8b\x08\x00")
+	_, blobRange = fetch(map[string]interface{}{"uri": binaryURI})
+	assert.Equal(t, BlobEncodingBase64, blobRange.Encoding)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("\x1f\x8b\x08\x00")), blobRange.Content)
+
+	_, blobRange = fetch(map[string]interface{}{"uri": textURI, "encoding": "base64"})
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("line 1\nline 2\n")), blobRange.Content)
+
+	t.Run("invalid requests", func(t *testing.T) {
+		tests := []map[string]interface{}{
+			{},
+			{"uri": "unknown://0123"},
+			{"uri": "utils-test://../../etc/passwd"},
+			{"uri": testBlobBucket.URI("0123456789abcdef0123456789abcdef")},
+			{"uri": textURI, "offset": float64(-1)},
+			{"uri": textURI, "offset": float64(100)},
+			{"uri": textURI, "length": float64(maxBlobFetchLength + 1)},
+			{"uri": textURI, "encoding": "hex"},
+		}
+		for _, args := range tests {
+			result, _ := fetch(args)
+			assert.True(t, result.IsError, "%v", args)
+		}
+	})
+}
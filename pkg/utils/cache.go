@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/cache"
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// defaultCacheInspectLimit is the number of entries listed per cache when no limit is given
+const defaultCacheInspectLimit = 50
+
+// cacheNames lists the names accepted by the cache parameter
+var cacheNames = []string{
+	cache.CacheTypeCommand.String(),
+	cache.CacheTypeHelm.String(),
+	cache.CacheTypeIstio.String(),
+	cache.CacheTypeKubernetes.String(),
+}
+
+// CacheInspection describes a cache and its entries; values are never included
+type CacheInspection struct {
+	Stats     cache.CacheStats  `json:"stats"`
+	Entries   []cache.EntryInfo `json:"entries"`
+	Truncated bool              `json:"truncated,omitempty"`
+}
+
+// selectCaches returns the named cache, or all caches when name is empty
+func selectCaches(name string) []*cache.Cache[string] {
+	if name == "" {
+		return cache.AllCaches()
+	}
+	c, _ := cache.GetCacheByName(name)
+	return []*cache.Cache[string]{c}
+}
+
+// handleCacheInspect lists cache statistics and entry keys with their remaining TTL
+func handleCacheInspect(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("cache", "", params.OneOf(cacheNames...))
+	prefix := p.String("key_prefix", "")
+	limit := p.Int("limit", defaultCacheInspectLimit, params.Range(1, 1000))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	inspections := []CacheInspection{}
+	for _, c := range selectCaches(name) {
+		entries := c.Entries(prefix)
+		inspection := CacheInspection{Stats: c.Stats(), Entries: entries}
+		if len(entries) > limit {
+			inspection.Entries = entries[:limit]
+			inspection.Truncated = true
+		}
+		inspections = append(inspections, inspection)
+	}
+
+	inspectionsJSON, err := json.MarshalIndent(inspections, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal cache inspection: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(inspectionsJSON)), nil
+}
+
+// handleCacheFlush removes cache entries, optionally limited to one cache and a key prefix
+func handleCacheFlush(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	name := p.String("cache", "", params.OneOf(cacheNames...))
+	prefix := p.String("key_prefix", "")
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	flushed := make(map[string]int)
+	total := 0
+	for _, c := range selectCaches(name) {
+		removed := c.DeletePrefix(prefix)
+		flushed[c.Name()] = removed
+		total += removed
+	}
+
+	flushedJSON, err := json.MarshalIndent(map[string]interface{}{
+		"flushed": flushed,
+		"total":   total,
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal flush result: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(flushedJSON)), nil
+}
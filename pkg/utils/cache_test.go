@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/cache"
+)
+
+func resultText(t *testing.T, result *mcp.CallToolResult) string {
+	t.Helper()
+	if result == nil || len(result.Content) == 0 {
+		t.Fatal("Expected content in result")
+	}
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatal("Expected text content in result")
+	}
+	return textContent.Text
+}
+
+func TestHandleCacheInspectAndFlush(t *testing.T) {
+	helmCache := cache.GetCacheByType(cache.CacheTypeHelm)
+	helmCache.Clear()
+	helmCache.Set("helm:list:-A", "secret-release-data")
+	helmCache.Set("helm:status:web", "secret-release-data")
+	helmCache.Get("helm:list:-A")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"cache": "helm", "limit": float64(1)}
+	result, err := handleCacheInspect(context.Background(), request)
+	if err != nil || result.IsError {
+		t.Fatalf("handleCacheInspect failed: %v %s", err, resultText(t, result))
+	}
+
+	text := resultText(t, result)
+	if strings.Contains(text, "secret-release-data") {
+		t.Error("Expected cached values to be redacted")
+	}
+	var inspections []CacheInspection
+	if err := json.Unmarshal([]byte(text), &inspections); err != nil {
+		t.Fatalf("Failed to parse inspection: %v", err)
+	}
+	if len(inspections) != 1 || inspections[0].Stats.Name != "helm" {
+		t.Fatalf("Expected only the helm cache, got %+v", inspections)
+	}
+	if len(inspections[0].Entries) != 1 || !inspections[0].Truncated {
+		t.Errorf("Expected 1 entry and truncation, got %d entries", len(inspections[0].Entries))
+	}
+	if inspections[0].Stats.Size != 2 || inspections[0].Stats.Hits < 1 {
+		t.Errorf("Unexpected stats %+v", inspections[0].Stats)
+	}
+
+	request.Params.Arguments = map[string]interface{}{"cache": "helm", "key_prefix": "helm:status"}
+	result, err = handleCacheFlush(context.Background(), request)
+	if err != nil || result.IsError {
+		t.Fatalf("handleCacheFlush failed: %v", err)
+	}
+	if !strings.Contains(resultText(t, result), `"total": 1`) {
+		t.Errorf("Expected 1 entry flushed, got %s", resultText(t, result))
+	}
+	if helmCache.Size() != 1 {
+		t.Errorf("Expected 1 entry left, got %d", helmCache.Size())
+	}
+}
+
+func TestHandleCacheInvalidCache(t *testing.T) {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"cache": "redis"}
+
+	for _, handler := range []func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){handleCacheInspect, handleCacheFlush} {
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !result.IsError {
+			t.Error("Expected error result for unknown cache")
+		}
+	}
+}
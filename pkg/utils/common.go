@@ -101,5 +101,46 @@ func RegisterTools(s *server.MCPServer) {
 		mcp.WithDescription("Returns the current date and time in ISO 8601 format."),
 	), handleGetCurrentDateTimeTool)
 
+	// Register cache admin tools
+	s.AddTool(mcp.NewTool("cache_inspect",
+		mcp.WithDescription("Show cache hit, miss and eviction statistics and list cached keys with their remaining TTL (values are redacted)"),
+		mcp.WithString("cache", mcp.Description("Cache to inspect: command, helm, istio or kubernetes (default: all)")),
+		mcp.WithString("key_prefix", mcp.Description("Only list keys starting with this prefix (e.g. kubectl:get)")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of keys listed per cache (default: 50)")),
+	), handleCacheInspect)
+
+	s.AddTool(mcp.NewTool("cache_flush",
+		mcp.WithDescription("Remove cached command results so the next call fetches fresh data"),
+		mcp.WithString("cache", mcp.Description("Cache to flush: command, helm, istio or kubernetes (default: all)")),
+		mcp.WithString("key_prefix", mcp.Description("Only remove keys starting with this prefix")),
+	), handleCacheFlush)
+
+	s.AddTool(mcp.NewTool("providers_status",
+		mcp.WithDescription("Report the health of each tool provider: required binary versions, last successful call, error rate and configuration gaps such as a missing kubeconfig"),
+		mcp.WithString("provider", mcp.Description("Only report this provider, e.g. k8s or prometheus (default: all registered providers)")),
+		mcp.WithNumber("window_minutes", mcp.Description("Minutes of tool calls the error rate covers (default: 60)")),
+	), handleProvidersStatus)
+
+	s.AddTool(mcp.NewTool("datasources_list",
+		mcp.WithDescription("List the named Prometheus, Loki, Grafana and Alertmanager datasources tools can select with their datasource parameter, with their URL, authentication method and which is the default of its type. Credentials are never included"),
+		mcp.WithString("type", mcp.Description("Only list datasources of this type: prometheus, loki, grafana or alertmanager (default: all)")),
+	), handleDatasourcesList)
+
+	s.AddTool(mcp.NewTool("fetch_blob",
+		mcp.WithDescription("Fetch a byte range of a stored blob, such as a support bundle (support-bundles://), a file copied from a pod (pod-files://) the full logs of an alert whose logs were truncated (alert-logs://), the logs of a remediation Job (remediation-logs://) or kubectl output too large to return inline (kubectl-output://). Large blobs are read in ranges; the response tells the blob's size and whether the range reached its end"),
+		mcp.WithString("uri", mcp.Description("URI of the blob"), mcp.Required()),
+		mcp.WithNumber("offset", mcp.Description("Byte offset to start reading from (default: 0)")),
+		mcp.WithNumber("length", mcp.Description("Maximum number of bytes to read (default: 1048576, max: 16777216)")),
+		mcp.WithString("encoding", mcp.Description("Encoding of the returned content: text or base64 (default: text when the range is valid UTF-8, otherwise base64)")),
+	), handleFetchBlob)
+
+	s.AddTool(mcp.NewTool(setContextTool,
+		mcp.WithDescription("Set the namespace used by this session's tool calls that omit one, overriding the server's DEFAULT_NAMESPACE, the language analyses and summaries are generated in, overriding LLM_LANGUAGE, and the cluster the session's queries of stored alerts are restricted to. Returns the session's current defaults and, when SESSION_MAX_* limits are set, what it used of its budget of LLM calls, LLM tokens and kubectl invocations"),
+		mcp.WithString("namespace", mcp.Description("Default namespace for the rest of the session")),
+		mcp.WithString("language", mcp.Description("Language of generated analyses and summaries for the rest of the session, as a code or name (e.g. fr, pt-BR, Japanese). Fixed text such as report headings stays in English")),
+		mcp.WithString("cluster", mcp.Description("Cluster the alert history, explanations and ops summaries of the rest of the session are restricted to, unless a call names another")),
+		mcp.WithString("clear", mcp.Description("Clear the session defaults, falling back to the server defaults (true/false, default: false)")),
+	), handleSetContext)
+
 	// Note: LLM Tool implementation would go here if needed
 }
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/datasources"
+	"github.com/kagent-dev/tools/internal/params"
+)
+
+// handleDatasourcesList lists the configured datasources without their credentials
+func handleDatasourcesList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	datasourceType := p.String("type", "", params.OneOf(datasources.Types...))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	summariesJSON, err := json.MarshalIndent(datasources.Global().List(datasourceType), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal datasources: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(summariesJSON)), nil
+}
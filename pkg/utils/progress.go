@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/kagent-dev/tools/internal/logger"
+)
+
+// ProgressReporter returns a function sending notifications/progress for the
+// request, or a no-op when the client did not ask for progress
+func ProgressReporter(ctx context.Context, request mcp.CallToolRequest) func(progress, total int, message string) {
+	mcpServer := server.ServerFromContext(ctx)
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil || mcpServer == nil {
+		return func(int, int, string) {}
+	}
+	token := request.Params.Meta.ProgressToken
+	return func(progress, total int, message string) {
+		if err := mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+			"progressToken": token,
+			"progress":      progress,
+			"total":         total,
+			"message":       message,
+		}); err != nil {
+			logger.Get().Error("Failed to send progress notification", "error", err)
+		}
+	}
+}
+
+// TokenStreamer returns a function streaming generated text to the client as
+// notifications/progress messages, one per chunk, or nil when the client did
+// not ask for progress. Clients render the chunks as they arrive instead of
+// waiting for the complete tool result.
+func TokenStreamer(ctx context.Context, request mcp.CallToolRequest) func(chunk string) {
+	mcpServer := server.ServerFromContext(ctx)
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil || mcpServer == nil {
+		return nil
+	}
+	token := request.Params.Meta.ProgressToken
+	chunks := 0
+	return func(chunk string) {
+		chunks++
+		if err := mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+			"progressToken": token,
+			"progress":      chunks,
+			"message":       chunk,
+		}); err != nil {
+			logger.Get().Error("Failed to stream generated text", "error", err)
+		}
+	}
+}
+
+// StepReporter returns a function reporting the step a multi-step tool call
+// is running, or a no-op when the client did not ask for progress. Progress
+// counts the steps finished before the reported one, and the message carries
+// the step name and the percentage complete.
+func StepReporter(ctx context.Context, request mcp.CallToolRequest, steps ...string) func(step string) {
+	reportProgress := ProgressReporter(ctx, request)
+	return func(step string) {
+		index := slices.Index(steps, step)
+		if index < 0 {
+			logger.Get().Error("Unknown progress step", "step", step)
+			return
+		}
+		reportProgress(index, len(steps), fmt.Sprintf("%s (%d%%)", step, index*100/len(steps)))
+	}
+}
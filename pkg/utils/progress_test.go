@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// progressSession is a client session buffering the notifications sent to it
+type progressSession chan mcp.JSONRPCNotification
+
+func (s progressSession) Initialize()                                         {}
+func (s progressSession) Initialized() bool                                   { return true }
+func (s progressSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return s }
+func (s progressSession) SessionID() string                                   { return "progress" }
+
+// callWithProgress calls a tool whose handler reports progress through the
+// MCP server, and returns the notifications the client session received
+func callWithProgress(token string, handler server.ToolHandlerFunc) progressSession {
+	s := server.NewMCPServer("test", "1.0", server.WithToolCapabilities(false))
+	s.AddTool(mcp.NewTool("slow_tool"), handler)
+	session := make(progressSession, 10)
+	ctx := s.WithContext(context.Background(), session)
+
+	meta := ""
+	if token != "" {
+		meta = `,"_meta":{"progressToken":"` + token + `"}`
+	}
+	s.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow_tool"`+meta+`}}`))
+	close(session)
+	return session
+}
+
+func TestProgressReporter(t *testing.T) {
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ProgressReporter(ctx, request)(2, 5, "halfway")
+		return mcp.NewToolResultText("ok"), nil
+	}
+
+	session := callWithProgress("call-1", handler)
+	require.Len(t, session, 1)
+	notification := <-session
+	assert.Equal(t, "notifications/progress", notification.Method)
+	assert.Equal(t, map[string]any{"progressToken": mcp.ProgressToken("call-1"), "progress": 2, "total": 5, "message": "halfway"}, notification.Params.AdditionalFields)
+
+	// Without a progress token nothing is sent
+	assert.Empty(t, callWithProgress("", handler))
+}
+
+func TestStepReporter(t *testing.T) {
+	session := callWithProgress("call-1", func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		reportStep := StepReporter(ctx, request, "create_pod", "wait_ready", "exec_curl", "cleanup")
+		reportStep("create_pod")
+		reportStep("exec_curl")
+		reportStep("unknown")
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	require.Len(t, session, 2)
+	first := (<-session).Params.AdditionalFields
+	assert.Equal(t, 0, first["progress"])
+	assert.Equal(t, 4, first["total"])
+	assert.Equal(t, "create_pod (0%)", first["message"])
+	second := (<-session).Params.AdditionalFields
+	assert.Equal(t, 2, second["progress"])
+	assert.Equal(t, "exec_curl (50%)", second["message"])
+}
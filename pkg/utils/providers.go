@@ -0,0 +1,280 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/kagent-dev/tools/internal/commands"
+	"github.com/kagent-dev/tools/internal/datasources"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/telemetry"
+	"github.com/kagent-dev/tools/internal/tenancy"
+)
+
+const (
+	// defaultStatusWindow is the period error rates are computed over when no window is given
+	defaultStatusWindow = time.Hour
+
+	// binaryVersionTimeout bounds each binary version check
+	binaryVersionTimeout = 10 * time.Second
+
+	// runbookSources mirrors runbooks.RunbookSources, which cannot be imported as runbooks imports this package
+	runbookSources = "RUNBOOK_SOURCES"
+)
+
+// binaryRequirement is a CLI a provider runs, with the arguments printing its version
+type binaryRequirement struct {
+	Name    string
+	Command string
+	Args    []string
+}
+
+// providerRequirement lists what a provider needs to work
+type providerRequirement struct {
+	Binaries []binaryRequirement
+	// Cluster is true when the provider talks to a Kubernetes cluster
+	Cluster bool
+	// Checks return a configuration gap, or an empty string
+	Checks []func(ctx context.Context) string
+}
+
+var (
+	kubectlBinary  = binaryRequirement{Name: "kubectl", Command: "kubectl", Args: []string{"version", "--client"}}
+	helmBinary     = binaryRequirement{Name: "helm", Command: "helm", Args: []string{"version", "--short"}}
+	istioctlBinary = binaryRequirement{Name: "istioctl", Command: "istioctl", Args: []string{"version", "--remote=false"}}
+	ciliumBinary   = binaryRequirement{Name: "cilium", Command: "cilium", Args: []string{"version", "--client"}}
+	hubbleBinary   = binaryRequirement{Name: "hubble", Command: "hubble", Args: []string{"version"}}
+	rolloutsBinary = binaryRequirement{Name: "kubectl-argo-rollouts", Command: "kubectl", Args: []string{"argo", "rollouts", "version"}}
+)
+
+// providerRequirements lists the requirements of each tool provider
+var providerRequirements = map[string]providerRequirement{
+	"alerts":     {Binaries: []binaryRequirement{kubectlBinary}, Cluster: true, Checks: []func(context.Context) string{runbookSourcesGap}},
+	"argo":       {Binaries: []binaryRequirement{kubectlBinary, rolloutsBinary}, Cluster: true},
+	"cilium":     {Binaries: []binaryRequirement{ciliumBinary, hubbleBinary}, Cluster: true},
+	"helm":       {Binaries: []binaryRequirement{helmBinary}, Cluster: true},
+	"istio":      {Binaries: []binaryRequirement{istioctlBinary}, Cluster: true},
+	"k8s":        {Binaries: []binaryRequirement{kubectlBinary}, Cluster: true},
+	"prometheus": {Checks: []func(context.Context) string{prometheusGap}},
+	"utils":      {},
+}
+
+// providersConfig holds the registered tools and the server kubeconfig
+var providersConfig struct {
+	sync.RWMutex
+	tools      map[string]tenancy.ToolInfo
+	kubeconfig string
+}
+
+// SetProviderTools sets the registered tools, keyed by tool name, and the
+// kubeconfig given to the server, for providers_status and namespace defaults
+func SetProviderTools(tools map[string]tenancy.ToolInfo, kubeconfig string) {
+	providersConfig.Lock()
+	defer providersConfig.Unlock()
+	providersConfig.tools = tools
+	providersConfig.kubeconfig = kubeconfig
+}
+
+// BinaryStatus is the version of a CLI a provider runs
+type BinaryStatus struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ToolStatus is the call statistics of one tool
+type ToolStatus struct {
+	Name string `json:"name"`
+	telemetry.ToolCallStats
+}
+
+// ProviderStatus describes the health of a tool provider
+type ProviderStatus struct {
+	Name        string         `json:"name"`
+	ToolCount   int            `json:"tool_count"`
+	Binaries    []BinaryStatus `json:"binaries,omitempty"`
+	ConfigGaps  []string       `json:"config_gaps,omitempty"`
+	Calls       int            `json:"calls"`
+	Errors      int            `json:"errors"`
+	ErrorRate   float64        `json:"error_rate"`
+	LastSuccess *time.Time     `json:"last_success,omitempty"`
+	// Tools lists the tools called at least once since the server started
+	Tools []ToolStatus `json:"tools,omitempty"`
+}
+
+// ProvidersStatus is the structured response of providers_status
+type ProvidersStatus struct {
+	WindowMinutes int              `json:"window_minutes"`
+	Providers     []ProviderStatus `json:"providers"`
+}
+
+// binaryStatus runs a binary's version command
+func binaryStatus(ctx context.Context, binary binaryRequirement) BinaryStatus {
+	ctx, cancel := context.WithTimeout(ctx, binaryVersionTimeout)
+	defer cancel()
+
+	status := BinaryStatus{Name: binary.Name}
+	output, err := commands.NewCommandBuilder(binary.Command).WithArgs(binary.Args...).Execute(ctx)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Available = true
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			status.Version = line
+			break
+		}
+	}
+	return status
+}
+
+// clusterAccessGap reports when no kubeconfig or in-cluster configuration is available
+func clusterAccessGap(kubeconfig string) string {
+	if kubeconfig != "" {
+		if _, err := os.Stat(commands.ExpandPath(kubeconfig)); err != nil {
+			return fmt.Sprintf("kubeconfig %s is not readable: %v", kubeconfig, err)
+		}
+		return ""
+	}
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return ""
+	}
+	for _, path := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+		if _, err := os.Stat(path); err == nil {
+			return ""
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if _, err := os.Stat(filepath.Join(home, ".kube", "config")); err == nil {
+			return ""
+		}
+	}
+	return "no kubeconfig found and not running in a cluster; pass --kubeconfig or set KUBECONFIG"
+}
+
+// runbookSourcesGap reports when runbook search has no sources
+func runbookSourcesGap(context.Context) string {
+	if strings.TrimSpace(os.Getenv(runbookSources)) == "" {
+		return fmt.Sprintf("%s is unset, so alerts_search_runbooks and runbook context in analysis are unavailable", runbookSources)
+	}
+	return ""
+}
+
+// prometheusGap reports when the default Prometheus datasource is missing or unreachable
+func prometheusGap(ctx context.Context) string {
+	ds, roundTripper, err := datasources.Global().RoundTripper(datasources.TypePrometheus, "")
+	if err != nil {
+		return err.Error()
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ds.URL+"/-/ready", nil)
+	if err != nil {
+		return ""
+	}
+	resp, err := (&http.Client{Transport: roundTripper}).Do(req)
+	if err != nil {
+		return fmt.Sprintf("Prometheus is unreachable at the default datasource %s; configure PROMETHEUS_URL or pass datasource or prometheus_url to prometheus tools", ds.URL)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Sprintf("Prometheus at the default datasource %s is not ready (HTTP %d); configure PROMETHEUS_URL or pass datasource or prometheus_url to prometheus tools", ds.URL, resp.StatusCode)
+	}
+	return ""
+}
+
+// providerStatus builds the status of one provider from its tools and their call statistics
+func providerStatus(ctx context.Context, name string, tools []string, stats map[string]telemetry.ToolCallStats, kubeconfig string) ProviderStatus {
+	status := ProviderStatus{Name: name, ToolCount: len(tools)}
+
+	requirement := providerRequirements[name]
+	for _, binary := range requirement.Binaries {
+		status.Binaries = append(status.Binaries, binaryStatus(ctx, binary))
+	}
+	if requirement.Cluster {
+		if gap := clusterAccessGap(kubeconfig); gap != "" {
+			status.ConfigGaps = append(status.ConfigGaps, gap)
+		}
+	}
+	for _, check := range requirement.Checks {
+		if gap := check(ctx); gap != "" {
+			status.ConfigGaps = append(status.ConfigGaps, gap)
+		}
+	}
+
+	for _, tool := range tools {
+		s, ok := stats[tool]
+		if !ok {
+			continue
+		}
+		status.Tools = append(status.Tools, ToolStatus{Name: tool, ToolCallStats: s})
+		status.Calls += s.Calls
+		status.Errors += s.Errors
+		if s.LastSuccess != nil && (status.LastSuccess == nil || s.LastSuccess.After(*status.LastSuccess)) {
+			status.LastSuccess = s.LastSuccess
+		}
+	}
+	if status.Calls > 0 {
+		status.ErrorRate = float64(status.Errors) / float64(status.Calls)
+	}
+	return status
+}
+
+// handleProvidersStatus reports binary versions, call outcomes and configuration
+// gaps for each registered tool provider
+func handleProvidersStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	provider := p.String("provider", "")
+	window := p.Int("window_minutes", int(defaultStatusWindow/time.Minute), params.Range(1, 24*60))
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	providersConfig.RLock()
+	toolsByProvider := map[string][]string{}
+	for tool, info := range providersConfig.tools {
+		toolsByProvider[info.Provider] = append(toolsByProvider[info.Provider], tool)
+	}
+	kubeconfig := providersConfig.kubeconfig
+	providersConfig.RUnlock()
+
+	if provider != "" {
+		if _, ok := toolsByProvider[provider]; !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("provider %s is not registered", provider)), nil
+		}
+	}
+
+	names := make([]string, 0, len(toolsByProvider))
+	for name := range toolsByProvider {
+		if provider == "" || name == provider {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	stats := telemetry.ToolCallStatsSince(time.Now().Add(-time.Duration(window) * time.Minute))
+	report := ProvidersStatus{WindowMinutes: window, Providers: []ProviderStatus{}}
+	for _, name := range names {
+		tools := toolsByProvider[name]
+		sort.Strings(tools)
+		report.Providers = append(report.Providers, providerStatus(ctx, name, tools, stats, kubeconfig))
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal providers status: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(reportJSON)), nil
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/cmd"
+	"github.com/kagent-dev/tools/internal/telemetry"
+	"github.com/kagent-dev/tools/internal/tenancy"
+)
+
+func TestClusterAccessGap(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBECONFIG", "")
+	t.Setenv("HOME", t.TempDir())
+
+	assert.NotEmpty(t, clusterAccessGap(""))
+	assert.Contains(t, clusterAccessGap(filepath.Join(t.TempDir(), "missing")), "not readable")
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	assert.Empty(t, clusterAccessGap(""))
+}
+
+func TestHandleProvidersStatus(t *testing.T) {
+	telemetry.ResetToolCallStats()
+	defer telemetry.ResetToolCallStats()
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv(runbookSources, "")
+
+	SetProviderTools(map[string]tenancy.ToolInfo{
+		"alerts_get_pod_alerts": {Provider: "alerts"},
+		"helm_list_releases":    {Provider: "helm"},
+		"helm_upgrade":          {Provider: "helm"},
+	}, "")
+	defer SetProviderTools(nil, "")
+
+	now := time.Now()
+	telemetry.RecordToolCall("helm_list_releases", now, "")
+	telemetry.RecordToolCall("helm_upgrade", now, "Error: release not found")
+	telemetry.RecordToolCall("helm_upgrade", now, "Error: release not found")
+
+	mock := cmd.NewMockShellExecutor()
+	mock.AddCommandString("helm", []string{"version", "--short"}, "v3.15.2+g1a500d5\n", nil)
+	mock.AddCommandString("kubectl", []string{"version", "--client"}, "", errors.New("executable file not found in $PATH"))
+	ctx := cmd.WithShellExecutor(context.Background(), mock)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"window_minutes": float64(30)}
+	result, err := handleProvidersStatus(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, resultText(t, result))
+
+	var report ProvidersStatus
+	require.NoError(t, json.Unmarshal([]byte(resultText(t, result)), &report))
+	assert.Equal(t, 30, report.WindowMinutes)
+	require.Len(t, report.Providers, 2)
+
+	alerts := report.Providers[0]
+	assert.Equal(t, "alerts", alerts.Name)
+	assert.False(t, alerts.Binaries[0].Available)
+	require.Len(t, alerts.ConfigGaps, 1)
+	assert.Contains(t, alerts.ConfigGaps[0], runbookSources)
+
+	helm := report.Providers[1]
+	assert.Equal(t, 2, helm.ToolCount)
+	assert.Equal(t, "v3.15.2+g1a500d5", helm.Binaries[0].Version)
+	assert.Empty(t, helm.ConfigGaps)
+	assert.Equal(t, 3, helm.Calls)
+	assert.Equal(t, 2, helm.Errors)
+	assert.InDelta(t, 2.0/3, helm.ErrorRate, 0.001)
+	require.Len(t, helm.Tools, 2)
+	assert.Equal(t, "Error: release not found", helm.Tools[1].LastErrorMessage)
+
+	request.Params.Arguments = map[string]interface{}{"provider": "istio"}
+	result, err = handleProvidersStatus(ctx, request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
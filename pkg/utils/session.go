@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/kagent-dev/tools/internal/budget"
+	"github.com/kagent-dev/tools/internal/llm"
+	"github.com/kagent-dev/tools/internal/params"
+	"github.com/kagent-dev/tools/internal/security"
+)
+
+// DefaultNamespace is the namespace used by tools called without one, unless
+// the session sets its own with set_context
+const DefaultNamespace = "DEFAULT_NAMESPACE"
+
+// setContextTool is excluded from namespace defaulting, as its namespace is the new default
+const setContextTool = "set_context"
+
+// sessionDefaults holds the default namespace, language and cluster of each
+// session; stdio servers have a single session with an empty ID
+var sessionDefaults = struct {
+	sync.RWMutex
+	namespaces map[string]string
+	languages  map[string]string
+	clusters   map[string]string
+}{namespaces: map[string]string{}, languages: map[string]string{}, clusters: map[string]string{}}
+
+// sessionID returns the ID of the client session of a request
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// SessionContext is the default namespace and language of a session and
+// where they come from
+type SessionContext struct {
+	Namespace        string `json:"namespace,omitempty"`
+	SessionNamespace string `json:"session_namespace,omitempty"`
+	ServerNamespace  string `json:"server_namespace,omitempty"`
+	// Language is the language generated text is written in
+	Language        string `json:"language,omitempty"`
+	SessionLanguage string `json:"session_language,omitempty"`
+	// Cluster restricts the session's queries of stored alerts to one cluster
+	Cluster string `json:"cluster,omitempty"`
+	// Budget is what the session used of its budget, when SESSION_MAX_* limits are set
+	Budget *budget.Usage `json:"budget,omitempty"`
+}
+
+// sessionContext returns the defaults that apply to the session of a request
+func sessionContext(ctx context.Context) SessionContext {
+	sessionDefaults.RLock()
+	sessionNamespace := sessionDefaults.namespaces[sessionID(ctx)]
+	sessionLanguage := sessionDefaults.languages[sessionID(ctx)]
+	sessionCluster := sessionDefaults.clusters[sessionID(ctx)]
+	sessionDefaults.RUnlock()
+
+	c := SessionContext{SessionNamespace: sessionNamespace, ServerNamespace: os.Getenv(DefaultNamespace), SessionLanguage: sessionLanguage, Cluster: sessionCluster}
+	c.Namespace = c.SessionNamespace
+	if c.Namespace == "" {
+		c.Namespace = c.ServerNamespace
+	}
+	c.Language = llm.Language(llm.WithLanguage(ctx, sessionLanguage))
+	if usage := budget.SessionUsage(ctx); !usage.Limits.Unlimited() {
+		c.Budget = &usage
+	}
+	return c
+}
+
+// ForgetSession drops the defaults of a session that ended
+func ForgetSession(ctx context.Context, session server.ClientSession) {
+	sessionDefaults.Lock()
+	defer sessionDefaults.Unlock()
+	delete(sessionDefaults.namespaces, session.SessionID())
+	delete(sessionDefaults.languages, session.SessionID())
+	delete(sessionDefaults.clusters, session.SessionID())
+}
+
+// DefaultNamespaceMiddleware fills in the namespace of calls to namespaced tools
+// that omit it with the session or server default. Calls across all namespaces
+// are left as they are.
+func DefaultNamespaceMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.Params.Name == setContextTool {
+			return next(ctx, request)
+		}
+
+		providersConfig.RLock()
+		info, ok := providersConfig.tools[request.Params.Name]
+		providersConfig.RUnlock()
+		if !ok || !info.Namespaced {
+			return next(ctx, request)
+		}
+
+		args := request.GetArguments()
+		if namespace, _ := args["namespace"].(string); namespace != "" {
+			return next(ctx, request)
+		}
+		if params.FromArgs(args).Bool("all_namespaces", false) {
+			return next(ctx, request)
+		}
+		namespace := sessionContext(ctx).Namespace
+		if namespace == "" {
+			return next(ctx, request)
+		}
+
+		withDefault := make(map[string]any, len(args)+1)
+		for key, value := range args {
+			withDefault[key] = value
+		}
+		withDefault["namespace"] = namespace
+		request.Params.Arguments = withDefault
+		return next(ctx, request)
+	}
+}
+
+// DefaultClusterMiddleware fills in the cluster of calls to tools querying
+// stored alerts that omit it with the session's cluster, so that a fleet
+// operator can focus a conversation on one cluster
+func DefaultClusterMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		providersConfig.RLock()
+		info, ok := providersConfig.tools[request.Params.Name]
+		providersConfig.RUnlock()
+		if !ok || !info.ClusterFiltered {
+			return next(ctx, request)
+		}
+
+		args := request.GetArguments()
+		if cluster, _ := args["cluster"].(string); cluster != "" {
+			return next(ctx, request)
+		}
+		cluster := sessionContext(ctx).Cluster
+		if cluster == "" {
+			return next(ctx, request)
+		}
+
+		withDefault := make(map[string]any, len(args)+1)
+		for key, value := range args {
+			withDefault[key] = value
+		}
+		withDefault["cluster"] = cluster
+		request.Params.Arguments = withDefault
+		return next(ctx, request)
+	}
+}
+
+// LanguageMiddleware sets the language text is generated in for a call: the
+// language argument of tools taking one, else the session's language. Tools
+// fall back to LLM_LANGUAGE, then English, when neither is set.
+func LanguageMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		language, _ := request.GetArguments()["language"].(string)
+		if language != "" {
+			normalized, err := llm.NormalizeLanguage(language)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			return next(llm.WithLanguage(ctx, normalized), request)
+		}
+		if language = sessionContext(ctx).SessionLanguage; language != "" {
+			ctx = llm.WithLanguage(ctx, language)
+		}
+		return next(ctx, request)
+	}
+}
+
+// handleSetContext sets or clears the default namespace, language and cluster of the session
+func handleSetContext(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	p := params.New(request)
+	namespace := p.String("namespace", "", params.Check(security.ValidateNamespace))
+	language := p.String("language", "", params.Check(llm.ValidateLanguage))
+	cluster := strings.TrimSpace(p.String("cluster", ""))
+	reset := p.Bool("clear", false)
+	if err := p.Err(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if (namespace != "" || language != "" || cluster != "") && reset {
+		return mcp.NewToolResultError("give either namespace, language and cluster or clear, not both"), nil
+	}
+	language, _ = llm.NormalizeLanguage(language)
+
+	id := sessionID(ctx)
+	sessionDefaults.Lock()
+	if reset {
+		delete(sessionDefaults.namespaces, id)
+		delete(sessionDefaults.languages, id)
+		delete(sessionDefaults.clusters, id)
+	}
+	if namespace != "" {
+		sessionDefaults.namespaces[id] = namespace
+	}
+	if language != "" {
+		sessionDefaults.languages[id] = language
+	}
+	if cluster != "" {
+		sessionDefaults.clusters[id] = cluster
+	}
+	sessionDefaults.Unlock()
+
+	contextJSON, err := json.MarshalIndent(sessionContext(ctx), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to marshal session context: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(contextJSON)), nil
+}
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kagent-dev/tools/internal/llm"
+	"github.com/kagent-dev/tools/internal/tenancy"
+)
+
+// testSession is a client session identified by its ID
+type testSession string
+
+func (s testSession) Initialize()       {}
+func (s testSession) Initialized() bool { return true }
+func (s testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return make(chan mcp.JSONRPCNotification, 1)
+}
+func (s testSession) SessionID() string { return string(s) }
+
+func TestDefaultNamespaceMiddleware(t *testing.T) {
+	t.Setenv(DefaultNamespace, "platform")
+	SetProviderTools(map[string]tenancy.ToolInfo{
+		"k8s_get_resources": {Provider: "k8s", Namespaced: true},
+		"k8s_get_nodes":     {Provider: "k8s"},
+	}, "")
+	defer SetProviderTools(nil, "")
+
+	s := server.NewMCPServer("test", "1.0")
+	alice := s.WithContext(context.Background(), testSession("alice"))
+	bob := s.WithContext(context.Background(), testSession("bob"))
+	defer ForgetSession(alice, testSession("alice"))
+
+	var received map[string]any
+	handler := DefaultNamespaceMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		received = request.GetArguments()
+		return mcp.NewToolResultText("ok"), nil
+	})
+	call := func(ctx context.Context, tool string, arguments map[string]any) map[string]any {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = tool
+		request.Params.Arguments = arguments
+		_, err := handler(ctx, request)
+		require.NoError(t, err)
+		return received
+	}
+
+	assert.Equal(t, "platform", call(bob, "k8s_get_resources", map[string]any{"resource_type": "pods"})["namespace"])
+	assert.Equal(t, "web", call(bob, "k8s_get_resources", map[string]any{"namespace": "web"})["namespace"])
+	assert.NotContains(t, call(bob, "k8s_get_resources", map[string]any{"all_namespaces": "true"}), "namespace")
+	assert.NotContains(t, call(bob, "k8s_get_resources", map[string]any{"all_namespaces": true}), "namespace")
+	assert.NotContains(t, call(bob, "k8s_get_nodes", nil), "namespace")
+
+	// A session default overrides the server default for that session only
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"namespace": "payments"}
+	result, err := handleSetContext(alice, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, resultText(t, result))
+	var current SessionContext
+	require.NoError(t, json.Unmarshal([]byte(resultText(t, result)), &current))
+	assert.Equal(t, SessionContext{Namespace: "payments", SessionNamespace: "payments", ServerNamespace: "platform", Language: llm.English}, current)
+
+	assert.Equal(t, "payments", call(alice, "k8s_get_resources", nil)["namespace"])
+	assert.Equal(t, "platform", call(bob, "k8s_get_resources", nil)["namespace"])
+
+	request.Params.Arguments = map[string]any{"clear": "true"}
+	_, err = handleSetContext(alice, request)
+	require.NoError(t, err)
+	assert.Equal(t, "platform", call(alice, "k8s_get_resources", nil)["namespace"])
+
+	t.Setenv(DefaultNamespace, "")
+	assert.NotContains(t, call(alice, "k8s_get_resources", nil), "namespace")
+}
+
+func TestDefaultClusterMiddleware(t *testing.T) {
+	SetProviderTools(map[string]tenancy.ToolInfo{
+		"alerts_remediation_history": {Provider: "alerts", Namespaced: true, ClusterFiltered: true},
+		"k8s_get_resources":          {Provider: "k8s", Namespaced: true},
+	}, "")
+	defer SetProviderTools(nil, "")
+
+	s := server.NewMCPServer("test", "1.0")
+	alice := s.WithContext(context.Background(), testSession("alice"))
+	bob := s.WithContext(context.Background(), testSession("bob"))
+	defer ForgetSession(alice, testSession("alice"))
+
+	var received map[string]any
+	handler := DefaultClusterMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		received = request.GetArguments()
+		return mcp.NewToolResultText("ok"), nil
+	})
+	call := func(ctx context.Context, tool string, arguments map[string]any) map[string]any {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = tool
+		request.Params.Arguments = arguments
+		_, err := handler(ctx, request)
+		require.NoError(t, err)
+		return received
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"cluster": " prod-eu "}
+	result, err := handleSetContext(alice, request)
+	require.NoError(t, err)
+	var current SessionContext
+	require.NoError(t, json.Unmarshal([]byte(resultText(t, result)), &current))
+	assert.Equal(t, "prod-eu", current.Cluster)
+
+	assert.Equal(t, "prod-eu", call(alice, "alerts_remediation_history", nil)["cluster"])
+	assert.Equal(t, "prod-us", call(alice, "alerts_remediation_history", map[string]any{"cluster": "prod-us"})["cluster"])
+	assert.NotContains(t, call(alice, "k8s_get_resources", nil), "cluster")
+	assert.NotContains(t, call(bob, "alerts_remediation_history", nil), "cluster")
+
+	request.Params.Arguments = map[string]any{"clear": "true"}
+	_, err = handleSetContext(alice, request)
+	require.NoError(t, err)
+	assert.NotContains(t, call(alice, "alerts_remediation_history", nil), "cluster")
+}
+
+func TestLanguageMiddleware(t *testing.T) {
+	t.Setenv(llm.LLMLanguage, "")
+	s := server.NewMCPServer("test", "1.0")
+	alice := s.WithContext(context.Background(), testSession("alice"))
+	defer ForgetSession(alice, testSession("alice"))
+
+	var language string
+	handler := LanguageMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		language = llm.Language(ctx)
+		return mcp.NewToolResultText("ok"), nil
+	})
+	call := func(arguments map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = arguments
+		result, err := handler(alice, request)
+		require.NoError(t, err)
+		return result
+	}
+
+	call(nil)
+	assert.Equal(t, llm.English, language)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"language": "fr"}
+	result, err := handleSetContext(alice, request)
+	require.NoError(t, err)
+	var current SessionContext
+	require.NoError(t, json.Unmarshal([]byte(resultText(t, result)), &current))
+	assert.Equal(t, "French", current.Language)
+
+	call(nil)
+	assert.Equal(t, "French", language)
+	call(map[string]any{"language": "ja"})
+	assert.Equal(t, "Japanese", language, "the argument overrides the session")
+	assert.True(t, call(map[string]any{"language": "klingon"}).IsError)
+}
+
+func TestHandleSetContextInvalid(t *testing.T) {
+	for _, arguments := range []map[string]any{
+		{"namespace": "Not_Valid"},
+		{"namespace": "web", "clear": "true"},
+		{"cluster": "prod-eu", "clear": "true"},
+		{"language": "klingon"},
+	} {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = arguments
+		result, err := handleSetContext(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError, arguments)
+	}
+}